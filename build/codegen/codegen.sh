@@ -15,6 +15,9 @@
 # limitations under the License.
 
 GROUP_VERSIONS="ceph.rook.io:v1"
+# the newer versions are only served through the conversion webhook, the operator and the clients
+# keep using the v1 storage version so only their deepcopy functions are generated
+DEEPCOPY_GROUP_VERSIONS="ceph.rook.io:v1,v2alpha1"
 
 scriptdir="$( cd "$( dirname "${BASH_SOURCE[0]}" )" && pwd )"
 
@@ -27,7 +30,7 @@ bash ${CODE_GENERATOR}/generate-groups.sh \
     deepcopy \
     github.com/rook/rook/pkg/client \
     github.com/rook/rook/pkg/apis \
-    "${GROUP_VERSIONS}" \
+    "${DEEPCOPY_GROUP_VERSIONS}" \
     --output-base "$(dirname "${BASH_SOURCE[0]}")/../../../../.." \
     --go-header-file "${scriptdir}/boilerplate.go.txt"
 
@@ -53,6 +53,7 @@ var RootCmd = &cobra.Command{
 
 var (
 	logLevelRaw        string
+	logFormatRaw       string
 	operatorImage      string
 	serviceAccountName string
 	logger             = capnslog.NewPackageLogger("github.com/rook/rook", "rookcmd")
@@ -64,6 +65,7 @@ var (
 //  3) command line parameter
 func init() {
 	RootCmd.PersistentFlags().StringVar(&logLevelRaw, "log-level", "INFO", "logging level for logging/tracing output (valid values: ERROR,WARNING,INFO,DEBUG)")
+	RootCmd.PersistentFlags().StringVar(&logFormatRaw, "log-format", util.DefaultLogFormat, "format of the logging output (valid values: console,json)")
 	RootCmd.PersistentFlags().StringVar(&operatorImage, "operator-image", "", "Override the image url that the operator uses. The default is read from the operator pod.")
 	RootCmd.PersistentFlags().StringVar(&serviceAccountName, "service-account", "", "Override the service account that the operator uses. The default is read from the operator pod.")
 
@@ -72,8 +74,9 @@ func init() {
 	flags.SetFlagsFromEnv(RootCmd.PersistentFlags(), RookEnvVarPrefix)
 }
 
-// SetLogLevel set log level based on provided log option.
+// SetLogLevel set log level and format based on provided log options.
 func SetLogLevel() {
+	util.SetLogFormat(logFormatRaw, logger)
 	util.SetGlobalLogLevel(logLevelRaw, logger)
 }
 
@@ -2,6 +2,7 @@
 ClusterRoleBindings needed for running a Rook CephCluster
 */}}
 {{- define "library.cluster.clusterrolebindings" }}
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Allow the ceph mgr to access cluster-wide resources necessary for the mgr modules
 kind: ClusterRoleBinding
 apiVersion: rbac.authorization.k8s.io/v1
@@ -15,7 +16,9 @@ subjects:
   - kind: ServiceAccount
     name: rook-ceph-mgr
     namespace: {{ .Release.Namespace }} # namespace:cluster
+{{- end }}
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Allow the ceph osd to access cluster-wide resources necessary for determining their topology location
 kind: ClusterRoleBinding
 apiVersion: rbac.authorization.k8s.io/v1
@@ -30,3 +33,4 @@ subjects:
     name: rook-ceph-osd
     namespace: {{ .Release.Namespace }} # namespace:cluster
 {{- end }}
+{{- end }}
@@ -0,0 +1,8 @@
+{{/*
+Whether the CephCluster of the release is connected to an external Ceph cluster. The OSDs and mgrs
+do not run in the namespace of an external cluster, so their RBAC is not created.
+The operator chart won't set .Values.cephClusterSpec, so default to a cluster that is not external.
+*/}}
+{{- define "library.cluster.external" -}}
+{{- dig "external" "enable" false (.Values.cephClusterSpec | default dict) -}}
+{{- end }}
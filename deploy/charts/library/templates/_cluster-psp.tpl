@@ -20,6 +20,7 @@ subjects:
     name: default
     namespace: {{ .Release.Namespace }} # namespace:cluster
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
 metadata:
@@ -33,7 +34,9 @@ subjects:
   - kind: ServiceAccount
     name: rook-ceph-osd
     namespace: {{ .Release.Namespace }} # namespace:cluster
+{{- end }}
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
 metadata:
@@ -47,6 +50,7 @@ subjects:
   - kind: ServiceAccount
     name: rook-ceph-mgr
     namespace: {{ .Release.Namespace }} # namespace:cluster
+{{- end }}
 ---
 apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
@@ -2,6 +2,7 @@
 Roles needed for running a Rook CephCluster
 */}}
 {{- define "library.cluster.roles" }}
+{{- if ne (include "library.cluster.external" .) "true" }}
 kind: Role
 apiVersion: rbac.authorization.k8s.io/v1
 metadata:
@@ -19,7 +20,9 @@ rules:
   - apiGroups: ["ceph.rook.io"]
     resources: ["cephclusters", "cephclusters/finalizers"]
     verbs: ["get", "list", "create", "update", "delete"]
+{{- end }}
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Aspects of ceph-mgr that operate within the cluster's namespace
 kind: Role
 apiVersion: rbac.authorization.k8s.io/v1
@@ -71,6 +74,7 @@ rules:
       - persistentvolumeclaims
     verbs:
       - delete
+{{- end }}
 ---
 kind: Role
 apiVersion: rbac.authorization.k8s.io/v1
@@ -91,6 +95,7 @@ rules:
       - update
       - delete
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Aspects of ceph osd purge job that require access to the cluster namespace
 kind: Role
 apiVersion: rbac.authorization.k8s.io/v1
@@ -111,3 +116,4 @@ rules:
     resources: ["persistentvolumeclaims"]
     verbs: ["get", "update", "delete", "list"]
 {{- end }}
+{{- end }}
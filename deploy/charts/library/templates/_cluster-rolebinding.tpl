@@ -17,6 +17,7 @@ subjects:
     name: rook-ceph-system
     namespace: {{ .Values.operatorNamespace | default .Release.Namespace }} # namespace:operator
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Allow the osd pods in this namespace to work with configmaps
 kind: RoleBinding
 apiVersion: rbac.authorization.k8s.io/v1
@@ -31,7 +32,9 @@ subjects:
   - kind: ServiceAccount
     name: rook-ceph-osd
     namespace: {{ .Release.Namespace }} # namespace:cluster
+{{- end }}
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Allow the ceph mgr to access resources scoped to the CephCluster namespace necessary for mgr modules
 kind: RoleBinding
 apiVersion: rbac.authorization.k8s.io/v1
@@ -46,7 +49,9 @@ subjects:
   - kind: ServiceAccount
     name: rook-ceph-mgr
     namespace: {{ .Release.Namespace }} # namespace:cluster
+{{- end }}
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Allow the ceph mgr to access resources in the Rook operator namespace necessary for mgr modules
 kind: RoleBinding
 apiVersion: rbac.authorization.k8s.io/v1
@@ -61,6 +66,7 @@ subjects:
   - kind: ServiceAccount
     name: rook-ceph-mgr
     namespace: {{ .Release.Namespace }} # namespace:cluster
+{{- end }}
 ---
 kind: RoleBinding
 apiVersion: rbac.authorization.k8s.io/v1
@@ -76,6 +82,7 @@ subjects:
     name: rook-ceph-cmd-reporter
     namespace: {{ .Release.Namespace }} # namespace:cluster
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Allow the osd purge job to run in this namespace
 kind: RoleBinding
 apiVersion: rbac.authorization.k8s.io/v1
@@ -91,3 +98,4 @@ subjects:
     name: rook-ceph-purge-osd
     namespace: {{ .Release.Namespace }} # namespace:cluster
 {{- end }}
+{{- end }}
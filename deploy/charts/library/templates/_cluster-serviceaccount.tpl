@@ -2,6 +2,7 @@
 ServiceAccounts needed for running a Rook CephCluster
 */}}
 {{- define "library.cluster.serviceaccounts" }}
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Service account for Ceph OSDs
 apiVersion: v1
 kind: ServiceAccount
@@ -13,7 +14,9 @@ metadata:
     storage-backend: ceph
     {{- include "library.rook-ceph.labels" . | nindent 4 }}
 {{ include "library.imagePullSecrets" . }}
+{{- end }}
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Service account for Ceph mgrs
 apiVersion: v1
 kind: ServiceAccount
@@ -25,6 +28,7 @@ metadata:
     storage-backend: ceph
     {{- include "library.rook-ceph.labels" . | nindent 4 }}
 {{ include "library.imagePullSecrets" . }}
+{{- end }}
 ---
 # Service account for the job that reports the Ceph version in an image
 apiVersion: v1
@@ -38,6 +42,7 @@ metadata:
     {{- include "library.rook-ceph.labels" . | nindent 4 }}
 {{ include "library.imagePullSecrets" . }}
 ---
+{{- if ne (include "library.cluster.external" .) "true" }}
 # Service account for job that purges OSDs from a Rook-Ceph cluster
 apiVersion: v1
 kind: ServiceAccount
@@ -45,4 +50,5 @@ metadata:
   name: rook-ceph-purge-osd
   namespace: {{ .Release.Namespace }} # namespace:cluster
 {{ include "library.imagePullSecrets" . }}
+{{- end }}
 {{ end }}
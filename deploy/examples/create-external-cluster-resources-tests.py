@@ -50,6 +50,9 @@ class TestRadosJSON(unittest.TestCase):
         print("\n\nShell Output")
         self.rjObj._arg_parser.format = "bash"
         self.rjObj.main()
+        print("\n\nSecret Output")
+        self.rjObj._arg_parser.format = "secret"
+        self.rjObj.main()
         print("\n\nNon compatible output (--abcd)")
         try:
             self.rjObj._arg_parser.format = 'abcd'
@@ -186,9 +186,12 @@ class RadosJSON:
                                   "sample run: `python3 /etc/ceph/create-external-cluster-resources.py --cephfs-filesystem-name myfs --rbd-data-pool-name replicapool --rados-namespace radosNamespace --cluster-name rookStorage --restricted-auth-permission true`" +
                                   "Note: Restricting the users per pool, per cluster and per pool namespace will require to create new users and new secrets for that users.")
 
+        common_group.add_argument("--manage-subvolume-groups", default=False, action='store_true',
+                                  help="Allows the health checker user to create the subvolume groups of the CephFilesystemSubVolumeGroup CRs")
+
         output_group = argP.add_argument_group('output')
-        output_group.add_argument("--format", "-t", choices=["json", "bash"],
-                                  default='json', help="Provides the output format (json | bash)")
+        output_group.add_argument("--format", "-t", choices=["json", "bash", "secret"],
+                                  default='json', help="Provides the output format (json | bash | secret)")
         output_group.add_argument("--output", "-o", default="",
                                   help="Output will be stored into the provided file")
         output_group.add_argument("--cephfs-filesystem-name", default="",
@@ -359,6 +362,8 @@ class RadosJSON:
             "allow rx pool={0}.rgw.log, " +
             "allow x pool={0}.rgw.buckets.index"
         }
+        if self._arg_parser.manage_subvolume_groups:
+            self.MIN_USER_CAP_PERMISSIONS['mgr'] += ', allow command "fs subvolumegroup create"'
         # if user not provided, give a default user
         if not self.run_as_user and not self._arg_parser.upgrade:
             self.run_as_user = self.EXTERNAL_USER_NAME
@@ -885,6 +890,40 @@ class RadosJSON:
         shOutIO.close()
         return shOut
 
+    def gen_secret_out(self):
+        if self._arg_parser.restricted_auth_permission:
+            raise ExecutionFailureException(
+                "The 'secret' format does not support the restricted auth permissions")
+        self._gen_output_map()
+        # the keys expected by the operator in the bootstrap secret of the external cluster
+        secret_keys = {
+            'ROOK_EXTERNAL_FSID': 'ROOK_EXTERNAL_FSID',
+            'ROOK_EXTERNAL_CEPH_MON_DATA': 'ROOK_EXTERNAL_CEPH_MON_DATA',
+            'ROOK_EXTERNAL_USERNAME': 'ROOK_EXTERNAL_USERNAME',
+            'ROOK_EXTERNAL_USER_SECRET': 'ROOK_EXTERNAL_USER_SECRET',
+            'CSI_RBD_NODE_SECRET': 'CSI_RBD_NODE_SECRET_SECRET',
+            'CSI_RBD_PROVISIONER_SECRET': 'CSI_RBD_PROVISIONER_SECRET',
+            'CSI_CEPHFS_NODE_SECRET': 'CSI_CEPHFS_NODE_SECRET',
+            'CSI_CEPHFS_PROVISIONER_SECRET': 'CSI_CEPHFS_PROVISIONER_SECRET',
+            'RGW_ADMIN_OPS_USER_ACCESS_KEY': 'ACCESS_KEY',
+            'RGW_ADMIN_OPS_USER_SECRET_KEY': 'SECRET_KEY',
+        }
+        string_data = {}
+        for secret_key, out_key in secret_keys.items():
+            if self.out_map.get(out_key):
+                string_data[secret_key] = self.out_map[out_key]
+        metadata = {"name": "rook-ceph-external-cluster-bootstrap"}
+        if self._arg_parser.namespace:
+            metadata["namespace"] = self._arg_parser.namespace
+        secret = {
+            "apiVersion": "v1",
+            "kind": "Secret",
+            "metadata": metadata,
+            "type": "kubernetes.io/rook",
+            "stringData": string_data
+        }
+        return json.dumps(secret, indent=2)
+
     def gen_json_out(self):
         self._gen_output_map()
         json_out = [
@@ -1152,6 +1191,8 @@ class RadosJSON:
             generated_output = self.gen_json_out()
         elif self._arg_parser.format == 'bash':
             generated_output = self.gen_shell_out()
+        elif self._arg_parser.format == 'secret':
+            generated_output = self.gen_secret_out()
         else:
             raise ExecutionFailureException("Unsupported format: {}".format(
                 self._arg_parser.format))
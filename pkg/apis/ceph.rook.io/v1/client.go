@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// compile-time assertions ensures CephClient implements webhook.Validator so a webhook builder
+// will be registered for the validating webhook.
+var _ webhook.Validator = &CephClient{}
+
+func (c *CephClient) ValidateCreate() error {
+	return nil
+}
+
+func (c *CephClient) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephclient %q", c.Name)
+	occ := old.(*CephClient)
+	if c.Spec.Name != occ.Spec.Name {
+		return errors.Errorf("invalid update: name change from %q to %q is not allowed", occ.Spec.Name, c.Spec.Name)
+	}
+	return nil
+}
+
+func (c *CephClient) ValidateDelete() error {
+	return nil
+}
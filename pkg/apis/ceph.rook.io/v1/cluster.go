@@ -18,7 +18,9 @@ package v1
 
 import (
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -29,6 +31,13 @@ import (
 // will be registered for the validating webhook.
 var _ webhook.Validator = &CephCluster{}
 
+var (
+	// the names of the prometheus labels
+	prometheusLabelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// the labels of the targets relied on by the rules and the dashboards, which cannot be overwritten
+	reservedExternalLabels = []string{"namespace", "job", "instance"}
+)
+
 func (c *ClusterSpec) IsStretchCluster() bool {
 	return c.Mon.StretchCluster != nil && len(c.Mon.StretchCluster.Zones) > 0
 }
@@ -41,12 +50,15 @@ func (c *CephCluster) ValidateCreate() error {
 			return errors.New("invalid create : external mode enabled cannot have mon,dashboard,monitoring,network,disruptionManagement,storage fields in CR")
 		}
 	}
-	return nil
+	return c.Spec.Monitoring.ValidateExternalLabels()
 }
 
 func (c *CephCluster) ValidateUpdate(old runtime.Object) error {
 	logger.Infof("validate update cephcluster %q", c.ObjectMeta.Name)
 	occ := old.(*CephCluster)
+	if err := c.Spec.Monitoring.ValidateExternalLabels(); err != nil {
+		return err
+	}
 	return validateUpdatedCephCluster(c, occ)
 }
 
@@ -76,6 +88,26 @@ func validateUpdatedCephCluster(updatedCephCluster *CephCluster, found *CephClus
 	return nil
 }
 
+// ValidateExternalLabels returns an error if an external label is not a valid prometheus label name or
+// would overwrite a label of the targets
+func (m *MonitoringSpec) ValidateExternalLabels() error {
+	for name := range m.ExternalLabels {
+		if !prometheusLabelNameRegex.MatchString(name) || strings.HasPrefix(name, "__") {
+			return errors.Errorf("invalid external label %q: not a valid prometheus label name", name)
+		}
+		for _, reserved := range reservedExternalLabels {
+			if name == reserved {
+				return errors.Errorf("invalid external label %q: the label is set by prometheus", name)
+			}
+		}
+	}
+	return nil
+}
+
 func (c *CephCluster) GetStatusConditions() *[]Condition {
 	return &c.Status.Conditions
 }
+
+func (c *CephCluster) GetStatusObservedGeneration() *int64 {
+	return &c.Status.ObservedGeneration
+}
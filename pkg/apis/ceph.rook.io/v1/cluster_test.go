@@ -88,3 +88,17 @@ func TestCephClusterValidateUpdate(t *testing.T) {
 	err = uc.ValidateUpdate(c)
 	assert.Error(t, err)
 }
+
+func TestValidateExternalLabels(t *testing.T) {
+	m := &MonitoringSpec{ExternalLabels: map[string]string{"cluster": "prod-a", "region": "eu-west-1"}}
+	assert.NoError(t, m.ValidateExternalLabels())
+
+	for _, name := range []string{"namespace", "instance", "__name__", "1cluster", "cluster-name"} {
+		m.ExternalLabels = map[string]string{name: "value"}
+		assert.Error(t, m.ValidateExternalLabels(), name)
+	}
+
+	c := &CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"}}
+	c.Spec.Monitoring.ExternalLabels = map[string]string{"job": "ceph"}
+	assert.Error(t, c.ValidateCreate())
+}
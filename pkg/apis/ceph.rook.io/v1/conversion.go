@@ -0,0 +1,24 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// Hub marks CephCluster as the conversion hub, every other version of the type is converted to and
+// from the v1 storage version
+func (*CephCluster) Hub() {}
+
+// Hub marks CephObjectStore as the conversion hub
+func (*CephObjectStore) Hub() {}
@@ -16,6 +16,106 @@ limitations under the License.
 
 package v1
 
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// compile-time assertions ensures CephFilesystem implements webhook.Validator so a webhook builder
+// will be registered for the validating webhook.
+var _ webhook.Validator = &CephFilesystem{}
+
+func (f *CephFilesystem) ValidateCreate() error {
+	logger.Infof("validate create cephfilesystem %q", f.Name)
+	return validateFilesystemSpec(&f.Spec)
+}
+
+func (f *CephFilesystem) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephfilesystem %q", f.Name)
+	if err := validateFilesystemSpec(&f.Spec); err != nil {
+		return err
+	}
+
+	ocf := old.(*CephFilesystem)
+	if err := validatePoolSpecUpdate(f.Spec.MetadataPool, ocf.Spec.MetadataPool); err != nil {
+		return errors.Wrap(err, "invalid metadata pool")
+	}
+	for i, pool := range f.Spec.DataPools {
+		for j, oldPool := range ocf.Spec.DataPools {
+			if dataPoolKey(pool, i) != dataPoolKey(oldPool, j) {
+				continue
+			}
+			if err := validatePoolSpecUpdate(pool.PoolSpec, oldPool.PoolSpec); err != nil {
+				return errors.Wrapf(err, "invalid data pool %q", dataPoolKey(pool, i))
+			}
+		}
+	}
+	return nil
+}
+
+func (f *CephFilesystem) ValidateDelete() error {
+	return nil
+}
+
+func validateFilesystemSpec(s *FilesystemSpec) error {
+	if s.MetadataServer.ActiveCount < 1 {
+		return errors.New("metadataServer.activeCount must be at least 1")
+	}
+	// No data pool means that the filesystem is expected to exist already
+	if len(s.DataPools) == 0 {
+		return nil
+	}
+
+	if err := validateMetadataPool(s.MetadataPool); err != nil {
+		return err
+	}
+	keys := map[string]bool{}
+	for i, pool := range s.DataPools {
+		key := dataPoolKey(pool, i)
+		if keys[key] {
+			return errors.Errorf("data pool %q is specified more than once", key)
+		}
+		keys[key] = true
+		if err := validatePoolSpec(pool); err != nil {
+			return errors.Wrapf(err, "invalid data pool %q", key)
+		}
+	}
+	return nil
+}
+
+// validateMetadataPool validates the metadata pool of a filesystem or an object store, which
+// cannot be erasure coded
+func validateMetadataPool(p PoolSpec) error {
+	if p.IsErasureCoded() {
+		return errors.New("invalid metadata pool: erasure coded pools can only be used as data pools")
+	}
+	if err := validatePoolSpec(NamedPoolSpec{PoolSpec: p}); err != nil {
+		return errors.Wrap(err, "invalid metadata pool")
+	}
+	return nil
+}
+
+// dataPoolKey returns the suffix of the name of the ceph pool of a data pool of a filesystem
+func dataPoolKey(pool NamedPoolSpec, index int) string {
+	if pool.Name != "" {
+		return pool.Name
+	}
+	return fmt.Sprintf("data%d", index)
+}
+
 func (c *CephFilesystem) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephFilesystemStatus{}
+	}
 	return &c.Status.Conditions
 }
+
+func (c *CephFilesystem) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &CephFilesystemStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCephFilesystemValidate(t *testing.T) {
+	f := &CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph"},
+		Spec: FilesystemSpec{
+			MetadataPool: PoolSpec{Replicated: ReplicatedSpec{Size: 3}},
+			DataPools: []NamedPoolSpec{
+				{PoolSpec: PoolSpec{Replicated: ReplicatedSpec{Size: 3}}},
+				{Name: "ec", PoolSpec: PoolSpec{ErasureCoded: ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}}},
+			},
+			MetadataServer: MetadataServerSpec{ActiveCount: 1},
+		},
+	}
+	assert.NoError(t, f.ValidateCreate())
+
+	t.Run("create", func(t *testing.T) {
+		invalid := f.DeepCopy()
+		invalid.Spec.MetadataServer.ActiveCount = 0
+		assert.Error(t, invalid.ValidateCreate())
+
+		// the metadata pool cannot be erasure coded
+		invalid = f.DeepCopy()
+		invalid.Spec.MetadataPool = invalid.Spec.DataPools[1].PoolSpec
+		assert.Error(t, invalid.ValidateCreate())
+
+		// the names of the data pools must be unique
+		invalid = f.DeepCopy()
+		invalid.Spec.DataPools[0].Name = "ec"
+		assert.Error(t, invalid.ValidateCreate())
+
+		invalid = f.DeepCopy()
+		invalid.Spec.DataPools[1].ErasureCoded.CodingChunks = 0
+		assert.Error(t, invalid.ValidateCreate())
+
+		// the pools of an existing filesystem are not set
+		existing := f.DeepCopy()
+		existing.Spec.MetadataPool = PoolSpec{}
+		existing.Spec.DataPools = nil
+		assert.NoError(t, existing.ValidateCreate())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		// a data pool can be added
+		updated := f.DeepCopy()
+		updated.Spec.DataPools = append(updated.Spec.DataPools, NamedPoolSpec{Name: "other", PoolSpec: PoolSpec{Replicated: ReplicatedSpec{Size: 2}}})
+		assert.NoError(t, updated.ValidateUpdate(f))
+
+		// the erasure code profile of a data pool cannot change
+		updated = f.DeepCopy()
+		updated.Spec.DataPools[1].ErasureCoded.DataChunks = 4
+		assert.Error(t, updated.ValidateUpdate(f))
+
+		// a data pool cannot change from replicated to erasure coded
+		updated = f.DeepCopy()
+		updated.Spec.DataPools[0].PoolSpec = updated.Spec.DataPools[1].PoolSpec
+		assert.Error(t, updated.ValidateUpdate(f))
+	})
+}
@@ -29,4 +29,6 @@ const (
 	KeyMonitoring      KeyType = "monitoring"
 	KeyCrashCollector  KeyType = "crashcollector"
 	KeyClusterMetadata KeyType = "clusterMetadata"
+	KeyRBDMirror       KeyType = "rbdmirror"
+	KeyFSMirror        KeyType = "fsmirror"
 )
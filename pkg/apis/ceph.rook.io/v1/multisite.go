@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// compile-time assertions ensures the multisite resources implement webhook.Validator so a webhook
+// builder will be registered for the validating webhook.
+var (
+	_ webhook.Validator = &CephObjectRealm{}
+	_ webhook.Validator = &CephObjectZoneGroup{}
+	_ webhook.Validator = &CephObjectZone{}
+)
+
+func (r *CephObjectRealm) ValidateCreate() error {
+	logger.Infof("validate create cephobjectrealm %q", r.Name)
+	return validatePullEndpoint(r.Spec.Pull.Endpoint)
+}
+
+func (r *CephObjectRealm) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephobjectrealm %q", r.Name)
+	return validatePullEndpoint(r.Spec.Pull.Endpoint)
+}
+
+func (r *CephObjectRealm) ValidateDelete() error {
+	return nil
+}
+
+// validatePullEndpoint validates the endpoint of the rgw the realm is pulled from, if any
+func validatePullEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return errors.Wrapf(err, "invalid pull endpoint %q", endpoint)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid pull endpoint %q: expected an http or https url", endpoint)
+	}
+	return nil
+}
+
+func (z *CephObjectZoneGroup) ValidateCreate() error {
+	logger.Infof("validate create cephobjectzonegroup %q", z.Name)
+	if z.Spec.Realm == "" {
+		return errors.New("missing realm")
+	}
+	return nil
+}
+
+func (z *CephObjectZoneGroup) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephobjectzonegroup %q", z.Name)
+	ozg := old.(*CephObjectZoneGroup)
+	if z.Spec.Realm != ozg.Spec.Realm {
+		return errors.Errorf("invalid update: realm change from %q to %q is not allowed", ozg.Spec.Realm, z.Spec.Realm)
+	}
+	return nil
+}
+
+func (z *CephObjectZoneGroup) ValidateDelete() error {
+	return nil
+}
+
+func (z *CephObjectZone) ValidateCreate() error {
+	logger.Infof("validate create cephobjectzone %q", z.Name)
+	if z.Spec.ZoneGroup == "" {
+		return errors.New("missing zone group")
+	}
+	return validateObjectPools(z.Spec.MetadataPool, z.Spec.DataPool)
+}
+
+func (z *CephObjectZone) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephobjectzone %q", z.Name)
+	if err := validateObjectPools(z.Spec.MetadataPool, z.Spec.DataPool); err != nil {
+		return err
+	}
+
+	oz := old.(*CephObjectZone)
+	if z.Spec.ZoneGroup != oz.Spec.ZoneGroup {
+		return errors.Errorf("invalid update: zone group change from %q to %q is not allowed", oz.Spec.ZoneGroup, z.Spec.ZoneGroup)
+	}
+	return validateObjectPoolsUpdate(z.Spec.MetadataPool, z.Spec.DataPool, oz.Spec.MetadataPool, oz.Spec.DataPool)
+}
+
+func (z *CephObjectZone) ValidateDelete() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCephObjectRealmValidate(t *testing.T) {
+	r := &CephObjectRealm{}
+	assert.NoError(t, r.ValidateCreate())
+
+	r.Spec.Pull.Endpoint = "http://10.2.105.133:80"
+	assert.NoError(t, r.ValidateCreate())
+
+	r.Spec.Pull.Endpoint = "10.2.105.133:80"
+	assert.Error(t, r.ValidateCreate())
+
+	r.Spec.Pull.Endpoint = "ftp://10.2.105.133"
+	assert.Error(t, r.ValidateCreate())
+}
+
+func TestCephObjectZoneGroupValidate(t *testing.T) {
+	z := &CephObjectZoneGroup{}
+	assert.Error(t, z.ValidateCreate())
+
+	z.Spec.Realm = "realm-a"
+	assert.NoError(t, z.ValidateCreate())
+
+	updated := z.DeepCopy()
+	updated.Spec.Realm = "realm-b"
+	assert.Error(t, updated.ValidateUpdate(z))
+}
+
+func TestCephObjectZoneValidate(t *testing.T) {
+	z := &CephObjectZone{
+		Spec: ObjectZoneSpec{
+			ZoneGroup:    "zonegroup-a",
+			MetadataPool: PoolSpec{Replicated: ReplicatedSpec{Size: 3}},
+			DataPool:     PoolSpec{ErasureCoded: ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}},
+		},
+	}
+	assert.NoError(t, z.ValidateCreate())
+
+	invalid := z.DeepCopy()
+	invalid.Spec.MetadataPool = invalid.Spec.DataPool
+	assert.Error(t, invalid.ValidateCreate())
+
+	updated := z.DeepCopy()
+	updated.Spec.ZoneGroup = "zonegroup-b"
+	assert.Error(t, updated.ValidateUpdate(z))
+
+	updated = z.DeepCopy()
+	updated.Spec.MetadataPool.Replicated.Size = 2
+	assert.NoError(t, updated.ValidateUpdate(z))
+}
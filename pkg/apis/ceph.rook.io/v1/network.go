@@ -26,3 +26,9 @@ func (n *NetworkSpec) IsMultus() bool {
 func (n *NetworkSpec) IsHost() bool {
 	return (n.HostNetwork && n.Provider == "") || n.Provider == "host"
 }
+
+// NetworkPoliciesEnabled get whether the operator creates the NetworkPolicies of the daemons. The
+// NetworkPolicies do not apply to the pods on the host network.
+func (n *NetworkSpec) NetworkPoliciesEnabled() bool {
+	return n.NetworkPolicies != nil && n.NetworkPolicies.Enabled && !n.IsHost()
+}
@@ -71,3 +71,18 @@ selectors:
 
 	assert.Equal(t, expected, net)
 }
+
+func TestNetworkPoliciesEnabled(t *testing.T) {
+	net := NetworkSpec{}
+	assert.False(t, net.NetworkPoliciesEnabled())
+
+	net.NetworkPolicies = &NetworkPoliciesSpec{}
+	assert.False(t, net.NetworkPoliciesEnabled())
+
+	net.NetworkPolicies.Enabled = true
+	assert.True(t, net.NetworkPoliciesEnabled())
+
+	// the policies do not apply to the host network
+	net.Provider = "host"
+	assert.False(t, net.NetworkPoliciesEnabled())
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// compile-time assertions ensures CephNFS implements webhook.Validator so a webhook builder
+// will be registered for the validating webhook.
+var _ webhook.Validator = &CephNFS{}
+
+func (n *CephNFS) ValidateCreate() error {
+	logger.Infof("validate create cephnfs %q", n.Name)
+	return validateNFSSpec(&n.Spec)
+}
+
+func (n *CephNFS) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephnfs %q", n.Name)
+	return validateNFSSpec(&n.Spec)
+}
+
+func (n *CephNFS) ValidateDelete() error {
+	return nil
+}
+
+func validateNFSSpec(s *NFSGaneshaSpec) error {
+	if s.Server.Active < 1 {
+		return errors.New("at least one active server required")
+	}
+	return nil
+}
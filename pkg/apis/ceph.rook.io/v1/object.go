@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"reflect"
+
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -26,6 +28,10 @@ import (
 // will be registered for the validating webhook.
 var _ webhook.Validator = &CephObjectStore{}
 
+// compile-time assertions ensures CephObjectStoreUser implements webhook.Validator so a webhook
+// builder will be registered for the validating webhook.
+var _ webhook.Validator = &CephObjectStoreUser{}
+
 const ServiceServingCertKey = "service.beta.openshift.io/serving-cert-secret-name"
 
 func (s *ObjectStoreSpec) IsMultisite() bool {
@@ -58,7 +64,7 @@ func (o *CephObjectStore) ValidateCreate() error {
 	if err := ValidateObjectSpec(o); err != nil {
 		return err
 	}
-	return nil
+	return validateObjectPools(o.Spec.MetadataPool, o.Spec.DataPool)
 }
 
 // ValidateObjectSpec validate the object store arguments
@@ -85,13 +91,64 @@ func (o *CephObjectStore) ValidateUpdate(old runtime.Object) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	if err := validateObjectPools(o.Spec.MetadataPool, o.Spec.DataPool); err != nil {
+		return err
+	}
+
+	oos := old.(*CephObjectStore)
+	if o.Spec.Zone.Name != oos.Spec.Zone.Name {
+		return errors.Errorf("invalid update: zone change from %q to %q is not allowed", oos.Spec.Zone.Name, o.Spec.Zone.Name)
+	}
+	return validateObjectPoolsUpdate(o.Spec.MetadataPool, o.Spec.DataPool, oos.Spec.MetadataPool, oos.Spec.DataPool)
 }
 
 func (o *CephObjectStore) ValidateDelete() error {
 	return nil
 }
 
+// validateObjectPools validates the pools of an object store or of a zone, which are not created
+// when they are empty
+func validateObjectPools(metadataPool, dataPool PoolSpec) error {
+	if !reflect.DeepEqual(metadataPool, PoolSpec{}) {
+		if err := validateMetadataPool(metadataPool); err != nil {
+			return err
+		}
+	}
+	if !reflect.DeepEqual(dataPool, PoolSpec{}) {
+		if err := validatePoolSpec(NamedPoolSpec{PoolSpec: dataPool}); err != nil {
+			return errors.Wrap(err, "invalid data pool")
+		}
+	}
+	return nil
+}
+
+func validateObjectPoolsUpdate(metadataPool, dataPool, oldMetadataPool, oldDataPool PoolSpec) error {
+	if err := validatePoolSpecUpdate(metadataPool, oldMetadataPool); err != nil {
+		return errors.Wrap(err, "invalid metadata pool")
+	}
+	if err := validatePoolSpecUpdate(dataPool, oldDataPool); err != nil {
+		return errors.Wrap(err, "invalid data pool")
+	}
+	return nil
+}
+
+func (u *CephObjectStoreUser) ValidateCreate() error {
+	return nil
+}
+
+func (u *CephObjectStoreUser) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephobjectstoreuser %q", u.Name)
+	ou := old.(*CephObjectStoreUser)
+	if u.Spec.Store != ou.Spec.Store {
+		return errors.Errorf("invalid update: store change from %q to %q is not allowed", ou.Spec.Store, u.Spec.Store)
+	}
+	return nil
+}
+
+func (u *CephObjectStoreUser) ValidateDelete() error {
+	return nil
+}
+
 func (s *ObjectStoreSpec) GetServiceServingCert() string {
 	if s.Gateway.Service != nil {
 		return s.Gateway.Service.Annotations[ServiceServingCertKey]
@@ -100,5 +157,15 @@ func (s *ObjectStoreSpec) GetServiceServingCert() string {
 }
 
 func (c *CephObjectStore) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &ObjectStoreStatus{}
+	}
 	return &c.Status.Conditions
 }
+
+func (c *CephObjectStore) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &ObjectStoreStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
@@ -59,6 +59,52 @@ func TestValidateObjectStoreSpec(t *testing.T) {
 	err = ValidateObjectSpec(o)
 	assert.Error(t, err)
 }
+
+func TestCephObjectStoreValidate(t *testing.T) {
+	o := &CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"},
+		Spec: ObjectStoreSpec{
+			Gateway:      GatewaySpec{Port: 80},
+			MetadataPool: PoolSpec{Replicated: ReplicatedSpec{Size: 3}},
+			DataPool:     PoolSpec{ErasureCoded: ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}},
+		},
+	}
+	assert.NoError(t, o.ValidateCreate())
+
+	// the metadata pool cannot be erasure coded
+	invalid := o.DeepCopy()
+	invalid.Spec.MetadataPool = invalid.Spec.DataPool
+	assert.Error(t, invalid.ValidateCreate())
+
+	// the pools are optional when the store is in a zone
+	inZone := o.DeepCopy()
+	inZone.Spec.MetadataPool = PoolSpec{}
+	inZone.Spec.DataPool = PoolSpec{}
+	inZone.Spec.Zone.Name = "zone-a"
+	assert.NoError(t, inZone.ValidateCreate())
+
+	// the zone cannot change
+	updated := inZone.DeepCopy()
+	updated.Spec.Zone.Name = "zone-b"
+	assert.Error(t, updated.ValidateUpdate(inZone))
+
+	// the erasure code profile of the data pool cannot change
+	updated = o.DeepCopy()
+	updated.Spec.DataPool.ErasureCoded.CodingChunks = 2
+	assert.Error(t, updated.ValidateUpdate(o))
+	updated = o.DeepCopy()
+	updated.Spec.Gateway.Instances = 2
+	assert.NoError(t, updated.ValidateUpdate(o))
+}
+
+func TestCephObjectStoreUserValidateUpdate(t *testing.T) {
+	u := &CephObjectStoreUser{Spec: ObjectStoreUserSpec{Store: "my-store"}}
+	updated := u.DeepCopy()
+	updated.Spec.DisplayName = "my user"
+	assert.NoError(t, updated.ValidateUpdate(u))
+	updated.Spec.Store = "other-store"
+	assert.Error(t, updated.ValidateUpdate(u))
+}
 func TestIsTLSEnabled(t *testing.T) {
 	objStore := &CephObjectStore{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OperatorConfigName is the name of the CephOperatorConfig read by the operator, the same as the
+// name of the operator ConfigMap
+const OperatorConfigName = "rook-ceph-operator-config"
+
+// OperatorSettings returns the operator settings set in the spec by their name in the operator ConfigMap
+func (s *OperatorConfigSpec) OperatorSettings() map[string]string {
+	settings := map[string]string{}
+	for name, value := range s.Settings {
+		settings[name] = value
+	}
+
+	setString := func(name, value string) {
+		if value != "" {
+			settings[name] = value
+		}
+	}
+	setBool := func(name string, value *bool) {
+		if value != nil {
+			settings[name] = strconv.FormatBool(*value)
+		}
+	}
+	setInt := func(name string, value *int) {
+		if value != nil {
+			settings[name] = strconv.Itoa(*value)
+		}
+	}
+
+	setString("ROOK_LOG_LEVEL", s.LogLevel)
+	setString("ROOK_LOG_FORMAT", s.LogFormat)
+	if len(s.LogLevels) > 0 {
+		levels := []string{}
+		for name, level := range s.LogLevels {
+			levels = append(levels, name+"="+level)
+		}
+		sort.Strings(levels)
+		settings["ROOK_LOG_LEVELS"] = strings.Join(levels, ",")
+	}
+	setBool("ROOK_CURRENT_NAMESPACE_ONLY", s.CurrentNamespaceOnly)
+	if len(s.WatchNamespaces) > 0 {
+		settings["ROOK_WATCH_NAMESPACES"] = strings.Join(s.WatchNamespaces, ",")
+	}
+	setBool("ROOK_ENABLE_DISCOVERY_DAEMON", s.EnableDiscoveryDaemon)
+	setBool("ROOK_ENABLE_OBC_PROVISIONER", s.EnableOBCProvisioner)
+	setBool("ROOK_OBC_WATCH_OPERATOR_NAMESPACE", s.OBCWatchOperatorNamespace)
+	setBool("ROOK_REQUIRE_CONTROLLER_PERMISSIONS", s.RequireControllerPermissions)
+	setBool("ROOK_ENABLE_ADMISSION_POLICIES", s.EnableAdmissionPolicies)
+	if s.CephCommands != nil {
+		setInt("ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS", s.CephCommands.TimeoutSeconds)
+		setInt("ROOK_CEPH_COMMANDS_MAX_DURATION_SECONDS", s.CephCommands.MaxDurationSeconds)
+		setInt("ROOK_CEPH_COMMANDS_RETRIES", s.CephCommands.Retries)
+		setInt("ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_THRESHOLD", s.CephCommands.CircuitBreakerThreshold)
+		setInt("ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_COOLDOWN_SECONDS", s.CephCommands.CircuitBreakerCooldownSeconds)
+		setInt("ROOK_CEPH_COMMANDS_CACHE_TTL_SECONDS", s.CephCommands.CacheTTLSeconds)
+	}
+	if s.AuditLog != nil {
+		setString("ROOK_AUDIT_LOG", s.AuditLog.Path)
+		setInt("ROOK_AUDIT_LOG_MAX_SIZE_MB", s.AuditLog.MaxSizeMB)
+		setInt("ROOK_AUDIT_LOG_MAX_BACKUPS", s.AuditLog.MaxBackups)
+	}
+	if s.Tracing != nil {
+		setString("ROOK_TRACING_OTLP_ENDPOINT", s.Tracing.OTLPEndpoint)
+		setString("ROOK_TRACING_SERVICE_NAME", s.Tracing.ServiceName)
+	}
+	return settings
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func TestOperatorConfigSettings(t *testing.T) {
+	specYaml := []byte(`
+logLevel: DEBUG
+logFormat: json
+logLevels:
+  op-mon: WARNING
+  ceph-block-pool-controller: DEBUG
+currentNamespaceOnly: false
+watchNamespaces:
+- team-a
+- team-b
+cephCommands:
+  timeoutSeconds: 30
+  retries: 0
+  cacheTTLSeconds: 0
+auditLog:
+  path: stdout
+settings:
+  CSI_LOG_LEVEL: "5"
+`)
+
+	rawJSON, err := yaml.ToJSON(specYaml)
+	assert.Nil(t, err)
+	var spec OperatorConfigSpec
+	err = json.Unmarshal(rawJSON, &spec)
+	assert.Nil(t, err)
+
+	expected := map[string]string{
+		"ROOK_LOG_LEVEL":                       "DEBUG",
+		"ROOK_LOG_FORMAT":                      "json",
+		"ROOK_LOG_LEVELS":                      "ceph-block-pool-controller=DEBUG,op-mon=WARNING",
+		"ROOK_CURRENT_NAMESPACE_ONLY":          "false",
+		"ROOK_WATCH_NAMESPACES":                "team-a,team-b",
+		"ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS":   "30",
+		"ROOK_CEPH_COMMANDS_RETRIES":           "0",
+		"ROOK_CEPH_COMMANDS_CACHE_TTL_SECONDS": "0",
+		"ROOK_AUDIT_LOG":                       "stdout",
+		"CSI_LOG_LEVEL":                        "5",
+	}
+	assert.Equal(t, expected, spec.OperatorSettings())
+
+	// an empty spec has no settings
+	assert.Empty(t, (&OperatorConfigSpec{}).OperatorSettings())
+}
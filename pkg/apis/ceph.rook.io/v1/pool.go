@@ -82,6 +82,33 @@ func validatePoolSpec(ps NamedPoolSpec) error {
 		if ps.ErasureCoded.CodingChunks < 1 && ps.ErasureCoded.CodingChunks != 0 {
 			return errors.New("invalid create: erasurecoded.codingchunks needs minimum value of 1")
 		}
+
+		// An erasure code profile needs both the data and the coding chunks
+		if ps.IsErasureCoded() && (ps.ErasureCoded.DataChunks == 0 || ps.ErasureCoded.CodingChunks == 0) {
+			return errors.New("invalid create: both erasurecoded.datachunks and erasurecoded.codingchunks must be set")
+		}
+	}
+	return nil
+}
+
+// validatePoolSpecUpdate returns an error if the settings of an existing pool that ceph cannot
+// change are updated
+func validatePoolSpecUpdate(ps, old PoolSpec) error {
+	if ps.ErasureCoded.CodingChunks > 0 || ps.ErasureCoded.DataChunks > 0 || ps.ErasureCoded.Algorithm != "" {
+		if old.Replicated.Size > 0 || old.Replicated.TargetSizeRatio > 0 {
+			return errors.New("invalid update: replicated field is set already in previous object. cannot be changed to use erasurecoded")
+		}
+	}
+
+	if ps.Replicated.Size > 0 || ps.Replicated.TargetSizeRatio > 0 {
+		if old.ErasureCoded.CodingChunks > 0 || old.ErasureCoded.DataChunks > 0 || old.ErasureCoded.Algorithm != "" {
+			return errors.New("invalid update: erasurecoded field is set already in previous object. cannot be changed to use replicated")
+		}
+	}
+
+	// the erasure code profile of a pool is set when the pool is created
+	if ps.IsErasureCoded() && old.IsErasureCoded() && (ps.ErasureCoded.DataChunks != old.ErasureCoded.DataChunks || ps.ErasureCoded.CodingChunks != old.ErasureCoded.CodingChunks) {
+		return errors.Errorf("invalid update: erasurecoded chunks change from %d+%d to %d+%d is not allowed", old.ErasureCoded.DataChunks, old.ErasureCoded.CodingChunks, ps.ErasureCoded.DataChunks, ps.ErasureCoded.CodingChunks)
 	}
 	return nil
 }
@@ -100,18 +127,18 @@ func (p *CephBlockPool) ValidateUpdate(old runtime.Object) error {
 	if err != nil {
 		return err
 	}
-	if p.Spec.ErasureCoded.CodingChunks > 0 || p.Spec.ErasureCoded.DataChunks > 0 || p.Spec.ErasureCoded.Algorithm != "" {
-		if ocbp.Spec.Replicated.Size > 0 || ocbp.Spec.Replicated.TargetSizeRatio > 0 {
-			return errors.New("invalid update: replicated field is set already in previous object. cannot be changed to use erasurecoded")
-		}
+	if p.poolName() != ocbp.poolName() {
+		return errors.Errorf("invalid update: pool name change from %q to %q is not allowed", ocbp.poolName(), p.poolName())
 	}
+	return validatePoolSpecUpdate(p.Spec.PoolSpec, ocbp.Spec.PoolSpec)
+}
 
-	if p.Spec.Replicated.Size > 0 || p.Spec.Replicated.TargetSizeRatio > 0 {
-		if ocbp.Spec.ErasureCoded.CodingChunks > 0 || ocbp.Spec.ErasureCoded.DataChunks > 0 || ocbp.Spec.ErasureCoded.Algorithm != "" {
-			return errors.New("invalid update: erasurecoded field is set already in previous object. cannot be changed to use replicated")
-		}
+// poolName returns the name of the ceph pool of the CephBlockPool
+func (p *CephBlockPool) poolName() string {
+	if p.Spec.Name != "" {
+		return p.Spec.Name
 	}
-	return nil
+	return p.Name
 }
 
 func (p *CephBlockPool) ValidateDelete() error {
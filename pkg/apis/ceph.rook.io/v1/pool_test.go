@@ -43,6 +43,12 @@ func TestValidatePoolSpec(t *testing.T) {
 	p.Spec.ErasureCoded.DataChunks = 1
 	err = validatePoolSpec(p.Spec.ToNamedPoolSpec())
 	assert.Error(t, err)
+
+	// the coding chunks are missing
+	p.Spec.ErasureCoded.DataChunks = 2
+	p.Spec.ErasureCoded.CodingChunks = 0
+	err = validatePoolSpec(p.Spec.ToNamedPoolSpec())
+	assert.Error(t, err)
 }
 
 func TestCephBlockPoolValidateUpdate(t *testing.T) {
@@ -61,6 +67,27 @@ func TestCephBlockPoolValidateUpdate(t *testing.T) {
 	up.Spec.ErasureCoded.CodingChunks = 1
 	err := up.ValidateUpdate(p)
 	assert.Error(t, err)
+
+	// the size of a replicated pool can change
+	up = p.DeepCopy()
+	up.Spec.Replicated.Size = 2
+	assert.NoError(t, up.ValidateUpdate(p))
+
+	// the name of the ceph pool cannot change
+	up.Spec.Name = "other"
+	assert.Error(t, up.ValidateUpdate(p))
+	up.Spec.Name = "ec-pool"
+	assert.NoError(t, up.ValidateUpdate(p))
+
+	// the erasure code profile cannot change
+	ec := &CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "ec-pool"},
+		Spec:       NamedBlockPoolSpec{PoolSpec: PoolSpec{ErasureCoded: ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}}},
+	}
+	up = ec.DeepCopy()
+	up.Spec.ErasureCoded.DataChunks = 4
+	err = up.ValidateUpdate(ec)
+	assert.EqualError(t, err, "invalid update: erasurecoded chunks change from 2+1 to 4+1 is not allowed")
 }
 
 func TestMirroringSpec_SnapshotSchedulesEnabled(t *testing.T) {
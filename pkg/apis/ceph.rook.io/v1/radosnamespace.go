@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// compile-time assertions ensures CephBlockPoolRadosNamespace implements webhook.Validator so a
+// webhook builder will be registered for the validating webhook.
+var _ webhook.Validator = &CephBlockPoolRadosNamespace{}
+
+func (n *CephBlockPoolRadosNamespace) ValidateCreate() error {
+	logger.Infof("validate create cephblockpoolradosnamespace %q", n.Name)
+	if n.Spec.BlockPoolName == "" {
+		return errors.New("missing block pool name")
+	}
+	return nil
+}
+
+func (n *CephBlockPoolRadosNamespace) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephblockpoolradosnamespace %q", n.Name)
+	ocn := old.(*CephBlockPoolRadosNamespace)
+	if n.Spec.BlockPoolName != ocn.Spec.BlockPoolName {
+		return errors.Errorf("invalid update: block pool change from %q to %q is not allowed", ocn.Spec.BlockPoolName, n.Spec.BlockPoolName)
+	}
+	return nil
+}
+
+func (n *CephBlockPoolRadosNamespace) ValidateDelete() error {
+	return nil
+}
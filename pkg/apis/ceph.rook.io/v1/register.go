@@ -85,6 +85,24 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CephFilesystemMirrorList{},
 		&CephFilesystemSubVolumeGroup{},
 		&CephFilesystemSubVolumeGroupList{},
+		&CephFilesystemSubVolume{},
+		&CephFilesystemSubVolumeList{},
+		&CephBlockPoolRadosNamespace{},
+		&CephBlockPoolRadosNamespaceList{},
+		&CephBlockPoolTopology{},
+		&CephBlockPoolTopologyList{},
+		&CephCSIDriver{},
+		&CephCSIDriverList{},
+		&CephStaticVolume{},
+		&CephStaticVolumeList{},
+		&CephRBDMirrorPeer{},
+		&CephRBDMirrorPeerList{},
+		&CephRBDMirrorPeerToken{},
+		&CephRBDMirrorPeerTokenList{},
+		&CephDRAction{},
+		&CephDRActionList{},
+		&CephOperatorConfig{},
+		&CephOperatorConfigList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	scheme.AddKnownTypes(bktv1alpha1.SchemeGroupVersion,
@@ -24,10 +24,27 @@ import (
 	"github.com/libopenstorage/secrets/vault"
 )
 
+const (
+	// VaultAuthMethodAppRole is the Vault AppRole auth method
+	VaultAuthMethodAppRole = "approle"
+)
+
 var (
 	VaultTLSConnectionDetails = []string{api.EnvVaultCACert, api.EnvVaultClientCert, api.EnvVaultClientKey}
 )
 
+// Get returns the security profile of a daemon type, or the profile of all the daemons if the daemon
+// type has none
+func (p SecurityProfilesSpec) Get(key KeyType) *SecurityProfileSpec {
+	if profile, ok := p[key]; ok {
+		return &profile
+	}
+	if profile, ok := p[KeyAll]; ok {
+		return &profile
+	}
+	return nil
+}
+
 // IsEnabled return whether a KMS is configured
 func (kms *KeyManagementServiceSpec) IsEnabled() bool {
 	return len(kms.ConnectionDetails) != 0
@@ -43,6 +60,12 @@ func (kms *KeyManagementServiceSpec) IsK8sAuthEnabled() bool {
 	return getParam(kms.ConnectionDetails, vault.AuthMethod) == vault.AuthMethodKubernetes && kms.TokenSecretName == ""
 }
 
+// IsAppRoleAuthEnabled return whether Vault is accessed with the AppRole auth, the role ID and the
+// secret ID are read from the token secret
+func (kms *KeyManagementServiceSpec) IsAppRoleAuthEnabled() bool {
+	return getParam(kms.ConnectionDetails, vault.AuthMethod) == VaultAuthMethodAppRole
+}
+
 // IsVaultKMS return whether Vault KMS is configured
 func (kms *KeyManagementServiceSpec) IsVaultKMS() bool {
 	return getParam(kms.ConnectionDetails, "KMS_PROVIDER") == secrets.TypeVault
@@ -53,6 +76,22 @@ func (kms *KeyManagementServiceSpec) IsIBMKeyProtectKMS() bool {
 	return getParam(kms.ConnectionDetails, "KMS_PROVIDER") == "ibmkeyprotect"
 }
 
+// IsAzureKeyVaultKMS return whether Azure Key Vault KMS is configured
+func (kms *KeyManagementServiceSpec) IsAzureKeyVaultKMS() bool {
+	return getParam(kms.ConnectionDetails, "KMS_PROVIDER") == "azure-kv"
+}
+
+// IsAzureWorkloadIdentityEnabled return whether Azure Key Vault is accessed with workload identity,
+// exchanging the service account token of the pods for an Azure token
+func (kms *KeyManagementServiceSpec) IsAzureWorkloadIdentityEnabled() bool {
+	return kms.IsAzureKeyVaultKMS() && getParam(kms.ConnectionDetails, "AZURE_AUTH_METHOD") == "workload-identity" && kms.TokenSecretName == ""
+}
+
+// IsKMIPKMS return whether a KMIP server is configured as KMS
+func (kms *KeyManagementServiceSpec) IsKMIPKMS() bool {
+	return getParam(kms.ConnectionDetails, "KMS_PROVIDER") == "kmip"
+}
+
 // IsTLSEnabled return KMS TLS details are configured
 func (kms *KeyManagementServiceSpec) IsTLSEnabled() bool {
 	for _, tlsOption := range VaultTLSConnectionDetails {
@@ -64,6 +103,63 @@ func (kms *KeyManagementServiceSpec) IsTLSEnabled() bool {
 	return false
 }
 
+// IsRestricted returns whether the pods of the daemons comply with the "restricted" Pod Security
+// Standard
+func (s *SecuritySpec) IsRestricted() bool {
+	return s.PodSecurityStandard == PodSecurityStandardRestricted
+}
+
+// IsFIPSEnabled returns whether the cryptography of the cluster is restricted to the algorithms
+// approved by FIPS 140
+func (s *SecuritySpec) IsFIPSEnabled() bool {
+	return s.FIPS != nil && s.FIPS.Enabled
+}
+
+// PushesCredentials returns whether a kind of credentials is pushed to an external secret store
+func (s *SecuritySpec) PushesCredentials(credentials ExternalSecretCredentials) bool {
+	if s.ExternalSecrets == nil {
+		return false
+	}
+	if len(s.ExternalSecrets.Credentials) == 0 {
+		return true
+	}
+	for _, c := range s.ExternalSecrets.Credentials {
+		if c == credentials {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEnabled returns whether the images are pinned to their digests
+func (p *ImagePolicySpec) IsEnabled() bool {
+	return p != nil && (p.PinDigests || p.VerificationKeysSecretName != "")
+}
+
+// VerifiesSignatures returns whether the cosign signatures of the images are verified
+func (p *ImagePolicySpec) VerifiesSignatures() bool {
+	return p != nil && p.VerificationKeysSecretName != ""
+}
+
+// PinnedImage returns the image referenced by its digest, the tag is kept for readability
+func (s ImageStatus) PinnedImage() string {
+	image := s.Image
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	return image + "@" + s.Digest
+}
+
+// FindImage returns the status of an image, or nil if the image was not pinned
+func FindImage(images []ImageStatus, image string) *ImageStatus {
+	for i := range images {
+		if images[i].Image == image && images[i].Digest != "" {
+			return &images[i]
+		}
+	}
+	return nil
+}
+
 // getParam returns the value of the KMS config option
 func getParam(kmsConfig map[string]string, param string) string {
 	if val, ok := kmsConfig[param]; ok && val != "" {
@@ -46,3 +46,60 @@ func TestKeyManagementServiceSpec_IsK8sAuthEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestPushesCredentials(t *testing.T) {
+	s := &SecuritySpec{}
+	if s.PushesCredentials(ExternalSecretCSICredentials) {
+		t.Error("no credentials are pushed without external secrets")
+	}
+	s.ExternalSecrets = &ExternalSecretsSpec{SecretStoreRef: ExternalSecretStoreRef{Name: "vault"}}
+	if !s.PushesCredentials(ExternalSecretObjectBucketClaimCredentials) {
+		t.Error("all the credentials are pushed by default")
+	}
+	s.ExternalSecrets.Credentials = []ExternalSecretCredentials{ExternalSecretCSICredentials}
+	if !s.PushesCredentials(ExternalSecretCSICredentials) || s.PushesCredentials(ExternalSecretObjectStoreUserCredentials) {
+		t.Error("only the listed credentials are pushed")
+	}
+}
+
+func TestSecurityProfilesGet(t *testing.T) {
+	var profiles SecurityProfilesSpec
+	if profiles.Get(KeyMon) != nil {
+		t.Error("no profile is set by default")
+	}
+	profiles = SecurityProfilesSpec{
+		KeyAll: {AppArmor: "runtime/default"},
+		KeyOSD: {AppArmor: "localhost/rook-ceph-osd"},
+	}
+	if p := profiles.Get(KeyOSD); p == nil || p.AppArmor != "localhost/rook-ceph-osd" {
+		t.Error("the profile of the daemon type is used")
+	}
+	if p := profiles.Get(KeyMon); p == nil || p.AppArmor != "runtime/default" {
+		t.Error("the profile of all the daemons is used when the daemon type has none")
+	}
+}
+
+func TestImagePolicy(t *testing.T) {
+	var policy *ImagePolicySpec
+	if policy.IsEnabled() || (&ImagePolicySpec{PullSecretName: "creds"}).IsEnabled() {
+		t.Error("the images are not pinned by default")
+	}
+	if !(&ImagePolicySpec{PinDigests: true}).IsEnabled() {
+		t.Error("the images are pinned")
+	}
+	policy = &ImagePolicySpec{VerificationKeysSecretName: "keys"}
+	if !policy.IsEnabled() || !policy.VerifiesSignatures() {
+		t.Error("verifying the signatures pins the images")
+	}
+
+	images := []ImageStatus{{Image: "quay.io/ceph/ceph:v16.2.7", Digest: "sha256:1234"}}
+	if FindImage(images, "quay.io/ceph/ceph:v17") != nil {
+		t.Error("the image is not pinned")
+	}
+	if p := FindImage(images, "quay.io/ceph/ceph:v16.2.7"); p == nil || p.PinnedImage() != "quay.io/ceph/ceph:v16.2.7@sha256:1234" {
+		t.Error("the pinned image keeps its tag")
+	}
+	if p := (ImageStatus{Image: "quay.io/ceph/ceph@sha256:5678", Digest: "sha256:5678"}).PinnedImage(); p != "quay.io/ceph/ceph@sha256:5678" {
+		t.Errorf("unexpected pinned image %q", p)
+	}
+}
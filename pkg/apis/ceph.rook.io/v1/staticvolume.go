@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// compile-time assertions ensures CephStaticVolume implements webhook.Validator so a webhook
+// builder will be registered for the validating webhook.
+var _ webhook.Validator = &CephStaticVolume{}
+
+func (v *CephStaticVolume) ValidateCreate() error {
+	return nil
+}
+
+// ValidateUpdate rejects the changes of the volume, the source of a PersistentVolume cannot be
+// updated
+func (v *CephStaticVolume) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephstaticvolume %q", v.Name)
+	ocv := old.(*CephStaticVolume)
+	if !reflect.DeepEqual(v.Spec.RBD, ocv.Spec.RBD) || !reflect.DeepEqual(v.Spec.CephFS, ocv.Spec.CephFS) {
+		return errors.New("invalid update: the rbd image or the cephfs subvolume of the volume cannot be changed")
+	}
+	if v.Spec.PersistentVolumeName != ocv.Spec.PersistentVolumeName {
+		return errors.Errorf("invalid update: persistent volume name change from %q to %q is not allowed", ocv.Spec.PersistentVolumeName, v.Spec.PersistentVolumeName)
+	}
+	return nil
+}
+
+func (v *CephStaticVolume) ValidateDelete() error {
+	return nil
+}
@@ -34,6 +34,13 @@ type StatusConditionGetter interface {
 	GetStatusConditions() *[]Condition
 }
 
+// A StatusObservedGenerationGetter allows getting a pointer to the generation of the spec last
+// reconciled successfully. It is not implemented by the resources whose controller sets the
+// observed generation itself as part of its state.
+type StatusObservedGenerationGetter interface {
+	GetStatusObservedGeneration() *int64
+}
+
 // SetStatusCondition sets the corresponding condition in conditions to newCondition.
 // conditions must be non-nil.
 // 1. if the condition of the specified type already exists (all fields of the existing condition are updated to
@@ -67,6 +74,9 @@ func SetStatusCondition(conditions *[]Condition, newCondition Condition) {
 
 	existingCondition.Reason = newCondition.Reason
 	existingCondition.Message = newCondition.Message
+	if newCondition.ObservedGeneration != 0 {
+		existingCondition.ObservedGeneration = newCondition.ObservedGeneration
+	}
 	if !newCondition.LastHeartbeatTime.IsZero() {
 		existingCondition.LastHeartbeatTime = newCondition.LastHeartbeatTime
 	} else {
@@ -84,3 +94,265 @@ func FindStatusCondition(conditions []Condition, conditionType ConditionType) *C
 
 	return nil
 }
+
+// The conditions and the observed generation of the resources whose status is a pointer are
+// allocated with the status when it is nil.
+
+func (c *CephBlockPool) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephBlockPoolStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephBlockPool) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &CephBlockPoolStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephObjectStoreUser) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &ObjectStoreUserStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephObjectStoreUser) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &ObjectStoreUserStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephObjectRealm) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephObjectRealm) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephObjectZoneGroup) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephObjectZoneGroup) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephObjectZone) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephObjectZone) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephBucketTopic) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &BucketTopicStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephBucketTopic) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &BucketTopicStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephBucketNotification) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephBucketNotification) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephNFS) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &NFSStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephNFS) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &NFSStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephClient) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephClientStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephClient) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &CephClientStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephRBDMirror) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &RBDMirrorStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephRBDMirror) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &RBDMirrorStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephFilesystemMirror) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephFilesystemMirror) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &Status{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephFilesystemSubVolumeGroup) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephFilesystemSubVolumeGroupStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephFilesystemSubVolumeGroup) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &CephFilesystemSubVolumeGroupStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephFilesystemSubVolume) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephFilesystemSubVolumeStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephFilesystemSubVolume) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &CephFilesystemSubVolumeStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephBlockPoolRadosNamespace) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephBlockPoolRadosNamespaceStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephBlockPoolRadosNamespace) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &CephBlockPoolRadosNamespaceStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephBlockPoolTopology) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephBlockPoolTopologyStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephBlockPoolTopology) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &CephBlockPoolTopologyStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
+
+func (c *CephCSIDriver) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephCSIDriverStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephStaticVolume) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephStaticVolumeStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephRBDMirrorPeer) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephRBDMirrorPeerStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephRBDMirrorPeerToken) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephRBDMirrorPeerTokenStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephDRAction) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephDRActionStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephOperatorConfig) GetStatusConditions() *[]Condition {
+	if c.Status == nil {
+		c.Status = &CephOperatorConfigStatus{}
+	}
+	return &c.Status.Conditions
+}
+
+func (c *CephOperatorConfig) GetStatusObservedGeneration() *int64 {
+	if c.Status == nil {
+		c.Status = &CephOperatorConfigStatus{}
+	}
+	return &c.Status.ObservedGeneration
+}
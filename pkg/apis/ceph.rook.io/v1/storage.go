@@ -168,3 +168,41 @@ func (s *StorageScopeSpec) IsOnPVCEncrypted() bool {
 
 	return false
 }
+
+// IsOnHostEncrypted returns whether the config of the storage, of a node or of a device asks for the
+// encryption of the OSDs on hosts
+func (s *StorageScopeSpec) IsOnHostEncrypted() bool {
+	// the "encryptedDevice" setting of the OSD config
+	encrypted := func(config map[string]string) bool {
+		return config["encryptedDevice"] == "true"
+	}
+	selectionEncrypted := func(config map[string]string, selection Selection) bool {
+		if encrypted(config) {
+			return true
+		}
+		for _, device := range selection.Devices {
+			if encrypted(device.Config) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if selectionEncrypted(s.Config, s.Selection) {
+		return true
+	}
+	for _, node := range s.Nodes {
+		if selectionEncrypted(node.Config, node.Selection) {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrationConfirmation is the confirmation of the migration of the OSDs
+const MigrationConfirmation = "yes-really-migrate-osds"
+
+// IsMigrationConfirmed returns whether the user confirmed the migration of the OSDs
+func (s *StorageScopeSpec) IsMigrationConfirmed() bool {
+	return s.Migration.Confirmation == MigrationConfirmation
+}
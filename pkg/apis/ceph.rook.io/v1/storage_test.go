@@ -287,3 +287,18 @@ func TestIsOnPVCEncrypted(t *testing.T) {
 	}
 	assert.True(t, s.IsOnPVCEncrypted())
 }
+
+func TestIsOnHostEncrypted(t *testing.T) {
+	s := &StorageScopeSpec{}
+	assert.False(t, s.IsOnHostEncrypted())
+
+	s.Config = map[string]string{"encryptedDevice": "true"}
+	assert.True(t, s.IsOnHostEncrypted())
+
+	s.Config = nil
+	s.Nodes = []Node{{Name: "node1", Selection: Selection{Devices: []Device{{Name: "sdb", Config: map[string]string{"encryptedDevice": "true"}}}}}}
+	assert.True(t, s.IsOnHostEncrypted())
+
+	s.Nodes[0].Devices[0].Config["encryptedDevice"] = "false"
+	assert.False(t, s.IsOnHostEncrypted())
+}
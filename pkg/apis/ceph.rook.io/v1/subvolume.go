@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// compile-time assertions ensures CephFilesystemSubVolume implements webhook.Validator so a
+// webhook builder will be registered for the validating webhook.
+var _ webhook.Validator = &CephFilesystemSubVolume{}
+
+func (v *CephFilesystemSubVolume) ValidateCreate() error {
+	logger.Infof("validate create cephfilesystemsubvolume %q", v.Name)
+	if v.Spec.FilesystemName == "" {
+		return errors.New("missing filesystem name")
+	}
+	return v.validateSpec()
+}
+
+func (v *CephFilesystemSubVolume) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephfilesystemsubvolume %q", v.Name)
+	ocv := old.(*CephFilesystemSubVolume)
+	if v.Spec.FilesystemName != ocv.Spec.FilesystemName {
+		return errors.Errorf("invalid update: filesystem change from %q to %q is not allowed", ocv.Spec.FilesystemName, v.Spec.FilesystemName)
+	}
+	if v.Spec.SubVolumeGroupName != ocv.Spec.SubVolumeGroupName {
+		return errors.Errorf("invalid update: subvolume group change from %q to %q is not allowed", ocv.Spec.SubVolumeGroupName, v.Spec.SubVolumeGroupName)
+	}
+	if v.GetSubVolumeName() != ocv.GetSubVolumeName() {
+		return errors.Errorf("invalid update: subvolume name change from %q to %q is not allowed", ocv.GetSubVolumeName(), v.GetSubVolumeName())
+	}
+	if v.Spec.NamespaceIsolated != ocv.Spec.NamespaceIsolated {
+		return errors.New("invalid update: the namespace isolation of the subvolume cannot be changed")
+	}
+	return v.validateSpec()
+}
+
+func (v *CephFilesystemSubVolume) ValidateDelete() error {
+	return nil
+}
+
+// GetSubVolumeName returns the name of the subvolume in its subvolume group
+func (v *CephFilesystemSubVolume) GetSubVolumeName() string {
+	if v.Spec.Name != "" {
+		return v.Spec.Name
+	}
+	return v.Name
+}
+
+func (v *CephFilesystemSubVolume) validateSpec() error {
+	if strings.Contains(v.Spec.Name, "/") || strings.Contains(v.Spec.SubVolumeGroupName, "/") {
+		return errors.New("invalid subvolume, the names of the subvolume and its group cannot contain a slash")
+	}
+	if v.Spec.Size != nil && v.Spec.Size.Sign() <= 0 {
+		return errors.Errorf("invalid size %q, it must be positive", v.Spec.Size.String())
+	}
+	return nil
+}
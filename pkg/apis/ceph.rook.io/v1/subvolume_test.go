@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateSubVolume(t *testing.T) {
+	size := resource.MustParse("10Gi")
+	subvolume := &CephFilesystemSubVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "static-a", Namespace: "rook-ceph"},
+		Spec:       CephFilesystemSubVolumeSpec{FilesystemName: "myfs", SubVolumeGroupName: "tenant-a", Size: &size},
+	}
+	assert.NoError(t, subvolume.ValidateCreate())
+	assert.Equal(t, "static-a", subvolume.GetSubVolumeName())
+
+	t.Run("the filesystem is required", func(t *testing.T) {
+		v := subvolume.DeepCopy()
+		v.Spec.FilesystemName = ""
+		assert.Error(t, v.ValidateCreate())
+	})
+
+	t.Run("the size must be positive", func(t *testing.T) {
+		v := subvolume.DeepCopy()
+		zero := resource.MustParse("0")
+		v.Spec.Size = &zero
+		assert.Error(t, v.ValidateCreate())
+	})
+
+	t.Run("the names cannot contain a slash", func(t *testing.T) {
+		v := subvolume.DeepCopy()
+		v.Spec.Name = "a/b"
+		assert.Error(t, v.ValidateCreate())
+	})
+
+	t.Run("the subvolume cannot be moved", func(t *testing.T) {
+		v := subvolume.DeepCopy()
+		v.Spec.SubVolumeGroupName = "tenant-b"
+		assert.Error(t, v.ValidateUpdate(subvolume))
+
+		v = subvolume.DeepCopy()
+		v.Spec.Name = "static-b"
+		assert.Error(t, v.ValidateUpdate(subvolume))
+
+		v = subvolume.DeepCopy()
+		v.Spec.NamespaceIsolated = true
+		assert.Error(t, v.ValidateUpdate(subvolume))
+
+		// the name of the spec can be set to the name of the CR
+		v = subvolume.DeepCopy()
+		v.Spec.Name = "static-a"
+		larger := resource.MustParse("20Gi")
+		v.Spec.Size = &larger
+		assert.NoError(t, v.ValidateUpdate(subvolume))
+	})
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// compile-time assertions ensures CephFilesystemSubVolumeGroup implements webhook.Validator so a
+// webhook builder will be registered for the validating webhook.
+var _ webhook.Validator = &CephFilesystemSubVolumeGroup{}
+
+func (g *CephFilesystemSubVolumeGroup) ValidateCreate() error {
+	logger.Infof("validate create cephfilesystemsubvolumegroup %q", g.Name)
+	if g.Spec.FilesystemName == "" {
+		return errors.New("missing filesystem name")
+	}
+	return g.validateSpec()
+}
+
+func (g *CephFilesystemSubVolumeGroup) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephfilesystemsubvolumegroup %q", g.Name)
+	ocg := old.(*CephFilesystemSubVolumeGroup)
+	if g.Spec.FilesystemName != ocg.Spec.FilesystemName {
+		return errors.Errorf("invalid update: filesystem change from %q to %q is not allowed", ocg.Spec.FilesystemName, g.Spec.FilesystemName)
+	}
+	if g.GetSubVolumeGroupName() != ocg.GetSubVolumeGroupName() {
+		return errors.Errorf("invalid update: subvolume group name change from %q to %q is not allowed", ocg.GetSubVolumeGroupName(), g.GetSubVolumeGroupName())
+	}
+	if g.Spec.DataPoolName != ocg.Spec.DataPoolName {
+		return errors.Errorf("invalid update: data pool change from %q to %q is not allowed", ocg.Spec.DataPoolName, g.Spec.DataPoolName)
+	}
+	return g.validateSpec()
+}
+
+func (g *CephFilesystemSubVolumeGroup) ValidateDelete() error {
+	return nil
+}
+
+// GetSubVolumeGroupName returns the name of the subvolume group in the filesystem
+func (g *CephFilesystemSubVolumeGroup) GetSubVolumeGroupName() string {
+	if g.Spec.Name != "" {
+		return g.Spec.Name
+	}
+	return g.Name
+}
+
+func (g *CephFilesystemSubVolumeGroup) validateSpec() error {
+	if strings.Contains(g.Spec.Name, "/") {
+		return errors.Errorf("invalid subvolume group name %q, it cannot contain a slash", g.Spec.Name)
+	}
+	if g.Spec.Quota != nil && g.Spec.Quota.Sign() <= 0 {
+		return errors.Errorf("invalid quota %q, it must be positive", g.Spec.Quota.String())
+	}
+	if g.Spec.DeletionPolicy == SubVolumeGroupDeletionPolicyForce && g.GetSubVolumeGroupName() == CSISubVolumeGroupName {
+		return errors.Errorf("invalid deletion policy %q, the subvolumes of the %q subvolume group of the CSI driver cannot be deleted with the CR", SubVolumeGroupDeletionPolicyForce, CSISubVolumeGroupName)
+	}
+	if p := g.Spec.Pinning; p != nil {
+		policies := 0
+		for _, set := range []bool{p.Export != nil, p.Distributed != nil, p.Random != nil} {
+			if set {
+				policies++
+			}
+		}
+		if policies > 1 {
+			return errors.New("invalid pinning, only one of export, distributed and random can be set")
+		}
+	}
+	if r := g.Spec.SnapshotRetention; r != nil {
+		if r.Count == nil && r.Duration == nil {
+			return errors.New("invalid snapshot retention, count or duration must be set")
+		}
+		if r.Count != nil && *r.Count < 1 {
+			return errors.Errorf("invalid snapshot retention count %d, at least one snapshot must be kept", *r.Count)
+		}
+		if r.Duration != nil && r.Duration.Duration <= 0 {
+			return errors.Errorf("invalid snapshot retention duration %q, it must be positive", r.Duration.Duration.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateSubVolumeGroup(t *testing.T) {
+	group := &CephFilesystemSubVolumeGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group-a", Namespace: "rook-ceph"},
+		Spec:       CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs", DeletionPolicy: SubVolumeGroupDeletionPolicyForce},
+	}
+	assert.NoError(t, group.ValidateCreate())
+	assert.Equal(t, "group-a", group.GetSubVolumeGroupName())
+
+	t.Run("the filesystem is required", func(t *testing.T) {
+		g := group.DeepCopy()
+		g.Spec.FilesystemName = ""
+		assert.Error(t, g.ValidateCreate())
+	})
+
+	t.Run("the subvolume group of the CSI driver cannot be forcibly deleted", func(t *testing.T) {
+		g := group.DeepCopy()
+		g.Spec.Name = CSISubVolumeGroupName
+		err := g.ValidateCreate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Force")
+
+		g = group.DeepCopy()
+		g.Name = CSISubVolumeGroupName
+		assert.Error(t, g.ValidateCreate())
+
+		// the other policies keep the subvolumes
+		g.Spec.DeletionPolicy = SubVolumeGroupDeletionPolicyDelete
+		assert.NoError(t, g.ValidateCreate())
+		forced := g.DeepCopy()
+		forced.Spec.DeletionPolicy = SubVolumeGroupDeletionPolicyForce
+		assert.Error(t, forced.ValidateUpdate(g))
+	})
+
+	t.Run("the subvolume group cannot be renamed", func(t *testing.T) {
+		g := group.DeepCopy()
+		g.Spec.Name = "group-b"
+		assert.Error(t, g.ValidateUpdate(group))
+	})
+}
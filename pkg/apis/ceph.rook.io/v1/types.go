@@ -42,7 +42,11 @@ import (
 // +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,description="Message"
 // +kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.ceph.health`,description="Ceph Health"
 // +kubebuilder:printcolumn:name="External",type=boolean,JSONPath=`.spec.external.enable`
+// +kubebuilder:printcolumn:name="Capacity",type=integer,JSONPath=`.status.ceph.capacity.bytesTotal`,priority=1,description="Raw capacity in bytes"
+// +kubebuilder:printcolumn:name="Used",type=integer,JSONPath=`.status.ceph.capacity.bytesUsed`,priority=1,description="Raw capacity used in bytes"
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 type CephCluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
@@ -65,6 +69,28 @@ type CephClusterHealthCheckSpec struct {
 	// StartupProbe allows changing the startupProbe configuration for a given daemon
 	// +optional
 	StartupProbe map[KeyType]*ProbeSpec `json:"startupProbe,omitempty"`
+	// Backpressure pauses the reconciles of the resources creating churn on the cluster while the
+	// cluster is unhealthy
+	// +optional
+	// +nullable
+	Backpressure *BackpressureSpec `json:"backpressure,omitempty"`
+}
+
+// BackpressureSpec is the policy pausing the reconciles of the users, buckets, subvolume groups,
+// rados namespaces, clients, topics and notifications while the health of the cluster is HEALTH_ERR,
+// so the operator does not send more commands to the mons and the mgr of a struggling cluster. The
+// reconciles resume when the cluster recovers.
+type BackpressureSpec struct {
+	// Enabled pauses the reconciles while the health of the cluster is HEALTH_ERR
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// PauseOnRecovery also pauses the reconciles while the data of the cluster is recovering, i.e.
+	// while the PG_DEGRADED or PG_AVAILABILITY health checks are raised
+	// +optional
+	PauseOnRecovery bool `json:"pauseOnRecovery,omitempty"`
+	// RetryInterval is the interval the paused reconciles are retried at, 1m by default
+	// +optional
+	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
 }
 
 // DaemonHealthSpec is a daemon health check
@@ -220,6 +246,293 @@ type ClusterSpec struct {
 	// +optional
 	// +nullable
 	LogCollector LogCollectorSpec `json:"logCollector,omitempty"`
+
+	// CSI represents the ceph-csi settings of the cluster
+	// +optional
+	// +nullable
+	CSI CSIDriverSpec `json:"csi,omitempty"`
+
+	// Tasks are the long-running operations run in jobs on the cluster, like purging OSDs
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Tasks []TaskSpec `json:"tasks,omitempty"`
+
+	// Adoption discovers the pools, object store users, subvolume groups and rados namespaces of the
+	// cluster that are not managed by a CR
+	// +optional
+	// +nullable
+	Adoption *AdoptionSpec `json:"adoption,omitempty"`
+}
+
+// CSIDriverSpec defines the ceph-csi settings applying to the volumes of the cluster
+type CSIDriverSpec struct {
+	// DriverNamePrefix deploys a set of CSI drivers dedicated to the cluster, named
+	// "<prefix>.rbd.csi.ceph.com" and "<prefix>.cephfs.csi.ceph.com", instead of using the drivers
+	// shared by the clusters of the operator. The prefix must be set before any volume is created,
+	// since the volumes are bound to the driver that provisioned them.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	// +optional
+	DriverNamePrefix string `json:"driverNamePrefix,omitempty"`
+
+	// PortOffset is added to the metrics and csi-addons ports of the dedicated drivers of the cluster,
+	// so that they do not conflict with the ports of the other drivers on the host network
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	PortOffset int `json:"portOffset,omitempty"`
+
+	// Provisioner defines the scheduling of the provisioner pods of the dedicated drivers of the cluster
+	// +optional
+	Provisioner CSIPodSchedulingSpec `json:"provisioner,omitempty"`
+
+	// Plugin defines the scheduling of the node plugin pods of the dedicated drivers of the cluster. The
+	// volumes of the cluster can only be mounted on the nodes running the plugin.
+	// +optional
+	Plugin CSIPodSchedulingSpec `json:"plugin,omitempty"`
+
+	// ReadAffinity defines the read affinity settings for the csi driver
+	// +optional
+	ReadAffinity ReadAffinitySpec `json:"readAffinity,omitempty"`
+
+	// CephFS defines how the CephFS volumes of the cluster are mounted
+	// +optional
+	CephFS CSICephFSSpec `json:"cephfs,omitempty"`
+
+	// SnapshotClasses defines the VolumeSnapshotClasses generated for the cluster
+	// +optional
+	SnapshotClasses SnapshotClassesSpec `json:"snapshotClasses,omitempty"`
+
+	// ReplicationClasses defines the VolumeGroupReplicationClasses generated for the mirrored pools of
+	// the cluster
+	// +optional
+	ReplicationClasses ReplicationClassesSpec `json:"replicationClasses,omitempty"`
+
+	// KMS defines the key management services used to encrypt the RBD volumes of the cluster. They are
+	// rendered into the ceph-csi KMS ConfigMap, where the IDs must be unique across all the clusters.
+	// +optional
+	KMS []CSIKMSSpec `json:"kms,omitempty"`
+}
+
+// CSIPodSchedulingSpec defines the scheduling of csi pods, the settings that are set replace the ones
+// of the operator
+type CSIPodSchedulingSpec struct {
+	// NodeSelector of the pods
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the pods
+	// +optional
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the pods
+	// +optional
+	// +nullable
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+}
+
+// CSIKMSSpec defines a key management service encrypting RBD volumes. Exactly one provider must be set.
+type CSIKMSSpec struct {
+	// ID of the KMS, referenced by the encryptionKMSID parameter of the StorageClasses
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`
+	ID string `json:"id"`
+
+	// Vault stores the encryption keys in HashiCorp Vault
+	// +optional
+	Vault *CSIVaultKMSSpec `json:"vault,omitempty"`
+
+	// KMIP stores the encryption keys in a KMIP compliant server
+	// +optional
+	KMIP *CSIKMIPKMSSpec `json:"kmip,omitempty"`
+
+	// AzureKeyVault stores the encryption keys in Azure Key Vault
+	// +optional
+	AzureKeyVault *CSIAzureKeyVaultKMSSpec `json:"azureKeyVault,omitempty"`
+
+	// SecretsMetadata encrypts the encryption keys with a passphrase from a Kubernetes Secret and
+	// stores them in the image metadata
+	// +optional
+	SecretsMetadata *CSISecretsMetadataKMSSpec `json:"secretsMetadata,omitempty"`
+}
+
+// CSIVaultKMSSpec defines the connection to HashiCorp Vault
+type CSIVaultKMSSpec struct {
+	// Address of the Vault server, e.g. https://vault.default.svc:8200
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// AuthMethod is the way ceph-csi authenticates to Vault: "kubernetes" with the ServiceAccount of
+	// the csi pods, or "tokens" with a token stored in the namespace of each tenant
+	// +kubebuilder:validation:Enum=kubernetes;tokens
+	// +optional
+	AuthMethod string `json:"authMethod,omitempty"`
+
+	// BackendPath is the path of the kv secrets engine storing the keys
+	// +optional
+	BackendPath string `json:"backendPath,omitempty"`
+
+	// Backend is the version of the kv secrets engine (kv or kv-v2) with the tokens auth method
+	// +kubebuilder:validation:Enum=kv;kv-v2
+	// +optional
+	Backend string `json:"backend,omitempty"`
+
+	// Namespace is the Vault enterprise namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// TLSServerName is the name to verify the certificate of the Vault server against
+	// +optional
+	TLSServerName string `json:"tlsServerName,omitempty"`
+
+	// CAFromSecret is the name of the Secret holding the CA certificate of the Vault server
+	// +optional
+	CAFromSecret string `json:"caFromSecret,omitempty"`
+
+	// CAVerify determines whether the certificate of the Vault server is verified. Defaults to true.
+	// +optional
+	CAVerify *bool `json:"caVerify,omitempty"`
+
+	// AuthPath is the path of the kubernetes auth method
+	// +optional
+	AuthPath string `json:"authPath,omitempty"`
+
+	// Role is the Vault role of the kubernetes auth method
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// TokenName is the name of the Secret holding the Vault token in the tenant namespace with the
+	// tokens auth method
+	// +optional
+	TokenName string `json:"tokenName,omitempty"`
+
+	// DestroyKeys determines whether the keys are destroyed from Vault when the volume is deleted.
+	// Defaults to true.
+	// +optional
+	DestroyKeys *bool `json:"destroyKeys,omitempty"`
+}
+
+// CSIKMIPKMSSpec defines the connection to a KMIP server
+type CSIKMIPKMSSpec struct {
+	// Endpoint of the KMIP server in the host:port form
+	// +kubebuilder:validation:MinLength=1
+	Endpoint string `json:"endpoint"`
+
+	// SecretName is the name of the Secret holding the CA certificate, the client certificate and the
+	// client key, in the namespace of the operator
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// TLSServerName is the name to verify the certificate of the KMIP server against
+	// +optional
+	TLSServerName string `json:"tlsServerName,omitempty"`
+
+	// ReadTimeout in seconds
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ReadTimeout int `json:"readTimeout,omitempty"`
+
+	// WriteTimeout in seconds
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	WriteTimeout int `json:"writeTimeout,omitempty"`
+}
+
+// CSIAzureKeyVaultKMSSpec defines the connection to Azure Key Vault
+type CSIAzureKeyVaultKMSSpec struct {
+	// VaultURL is the URL of the key vault, e.g. https://myvault.vault.azure.net
+	// +kubebuilder:validation:MinLength=1
+	VaultURL string `json:"vaultURL"`
+
+	// ClientID of the application authenticating to Azure
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// TenantID of the Azure Active Directory
+	// +kubebuilder:validation:MinLength=1
+	TenantID string `json:"tenantID"`
+
+	// CertSecretName is the name of the Secret holding the client certificate of the application,
+	// in the namespace of the operator
+	// +kubebuilder:validation:MinLength=1
+	CertSecretName string `json:"certSecretName"`
+}
+
+// CSISecretsMetadataKMSSpec defines the Secret holding the passphrase encrypting the keys
+type CSISecretsMetadataKMSSpec struct {
+	// SecretName is the name of the Secret holding the passphrase
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// SecretNamespace is the namespace of the Secret. If empty, the namespace of the PVC is used.
+	// +optional
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+}
+
+// SnapshotClassesSpec defines the RBD and CephFS VolumeSnapshotClasses generated for the cluster
+type SnapshotClassesSpec struct {
+	// Enabled generates the RBD and CephFS VolumeSnapshotClasses of the cluster when the
+	// VolumeSnapshot CRDs are installed
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// DeletionPolicy determines whether the snapshot content is deleted with the VolumeSnapshot.
+	// Defaults to Delete.
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+}
+
+// ReplicationClassesSpec defines the csi-addons VolumeGroupReplicationClasses generated for the
+// CephBlockPools of the cluster mirrored in image mode, so the RBD volumes of a group are mirrored
+// and fail over consistently
+type ReplicationClassesSpec struct {
+	// Enabled generates a VolumeGroupReplicationClass for each CephBlockPool of the cluster mirrored in
+	// image mode when the VolumeGroupReplication CRDs are installed
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// SchedulingInterval is the interval of the mirror snapshots of the volume groups, in minutes (m),
+	// hours (h) or days (d). Defaults to 5m.
+	// +kubebuilder:validation:Pattern=`^\d+[mhd]$`
+	// +optional
+	SchedulingInterval string `json:"schedulingInterval,omitempty"`
+
+	// SchedulingStartTime is the start time of the mirror snapshot schedule of the volume groups, in
+	// the ISO 8601 time format
+	// +optional
+	SchedulingStartTime string `json:"schedulingStartTime,omitempty"`
+}
+
+// ReadAffinitySpec defines the read affinity settings for the csi driver
+type ReadAffinitySpec struct {
+	// Enables read affinity, so that reads of RBD and CephFS volumes are served by the OSDs closest to
+	// the client, according to the crush location of the node the volume is mounted on
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// CrushLocationLabels defines the node labels used to determine the crush location of the client.
+	// If empty, the kubernetes topology labels and the rook topology labels (topology.rook.io/...) are used,
+	// only the ones matching the CRUSH map of an external cluster.
+	// +optional
+	CrushLocationLabels []string `json:"crushLocationLabels,omitempty"`
+}
+
+// CSICephFSSpec defines how the CephFS volumes of a cluster are mounted by the csi driver
+type CSICephFSSpec struct {
+	// Mounter is the client mounting the volumes, "kernel" or "fuse". If empty, the default mounter
+	// of ceph-csi is used.
+	// +kubebuilder:validation:Enum=kernel;fuse;""
+	// +optional
+	Mounter string `json:"mounter,omitempty"`
+
+	// KernelMountOptions are the comma separated options passed to the kernel client when mounting the volumes
+	// +optional
+	KernelMountOptions string `json:"kernelMountOptions,omitempty"`
+
+	// FuseMountOptions are the comma separated options passed to ceph-fuse when mounting the volumes
+	// +optional
+	FuseMountOptions string `json:"fuseMountOptions,omitempty"`
 }
 
 // LogCollectorSpec is the logging spec
@@ -238,6 +551,190 @@ type SecuritySpec struct {
 	// +optional
 	// +nullable
 	KeyManagementService KeyManagementServiceSpec `json:"kms,omitempty"`
+	// Cephx is the rotation of the cephx keys of the csi users and of the daemons, only used by the
+	// CephCluster
+	// +optional
+	// +nullable
+	Cephx *ClusterCephxSpec `json:"cephx,omitempty"`
+	// PodSecurityStandard is the Pod Security Standard the pods of the daemons comply with, only used
+	// by the CephCluster. With "restricted", the daemons run as the ceph user without privileges, the
+	// OSDs excepted since they access the devices. If empty, the daemons comply with "privileged".
+	// +kubebuilder:validation:Enum="";restricted
+	// +optional
+	PodSecurityStandard PodSecurityStandard `json:"podSecurityStandard,omitempty"`
+	// FIPS restricts the cryptography of the cluster to the algorithms approved by FIPS 140, only used
+	// by the CephCluster
+	// +optional
+	// +nullable
+	FIPS *FIPSSpec `json:"fips,omitempty"`
+	// ExternalSecrets pushes the credentials generated by Rook to an external secret store with the
+	// PushSecrets of the External Secrets Operator, only used by the CephCluster
+	// +optional
+	// +nullable
+	ExternalSecrets *ExternalSecretsSpec `json:"externalSecrets,omitempty"`
+	// Profiles are the seccomp and AppArmor profiles of the daemons keyed by daemon type, the "all"
+	// profile applying to the daemons without a profile, only used by the CephCluster
+	// +optional
+	// +nullable
+	Profiles SecurityProfilesSpec `json:"profiles,omitempty"`
+	// ImagePolicy pins the ceph image to its digest and verifies its signature before the daemons are
+	// updated, only used by the CephCluster
+	// +optional
+	// +nullable
+	ImagePolicy *ImagePolicySpec `json:"imagePolicy,omitempty"`
+}
+
+// ImagePolicySpec represents the pinning of the images to their digests and the verification of their
+// cosign signatures
+type ImagePolicySpec struct {
+	// PinDigests resolves the tags of the images to their digests when the images are set or changed.
+	// The daemons run the recorded digests until the images change, even if the tags are moved.
+	// +optional
+	PinDigests bool `json:"pinDigests,omitempty"`
+	// VerificationKeysSecretName is the name of the secret holding the PEM encoded public keys the
+	// cosign signatures of the images are verified with. The daemons are not updated to an image
+	// without a signature verified by one of the keys. Verifying the signatures pins the digests.
+	// +optional
+	VerificationKeysSecretName string `json:"verificationKeysSecretName,omitempty"`
+	// PullSecretName is the name of the secret of type kubernetes.io/dockerconfigjson holding the
+	// credentials of the registries
+	// +optional
+	PullSecretName string `json:"pullSecretName,omitempty"`
+}
+
+// ImageStatus represents the digest an image is pinned to
+type ImageStatus struct {
+	// Image is the image of the spec
+	Image string `json:"image"`
+	// Digest is the digest the image was resolved to
+	Digest string `json:"digest"`
+	// Verified is whether the cosign signature of the digest was verified
+	// +optional
+	Verified bool `json:"verified,omitempty"`
+}
+
+// SecurityProfilesSpec are the seccomp and AppArmor profiles of the daemons keyed by daemon type
+type SecurityProfilesSpec map[KeyType]SecurityProfileSpec
+
+// SecurityProfileSpec represents the seccomp and AppArmor profiles confining the containers of a daemon
+type SecurityProfileSpec struct {
+	// Seccomp is the seccomp profile of the pods of the daemon. The privileged containers are not
+	// confined by seccomp.
+	// +optional
+	// +nullable
+	Seccomp *v1.SeccompProfile `json:"seccomp,omitempty"`
+	// AppArmor is the AppArmor profile of the containers of the daemon, "runtime/default",
+	// "unconfined" or "localhost/<profile>" for a profile loaded on the nodes
+	// +kubebuilder:validation:Pattern=`^(runtime/default|unconfined|localhost/.+)$`
+	// +optional
+	AppArmor string `json:"appArmor,omitempty"`
+}
+
+// ExternalSecretsSpec represents the push of the credentials generated by Rook to an external secret
+// store. A PushSecret of the External Secrets Operator is created for each Secret of credentials.
+type ExternalSecretsSpec struct {
+	// SecretStoreRef is the SecretStore or the ClusterSecretStore the credentials are pushed to. The
+	// credentials of the object bucket claims live in the namespaces of the claims, they are only
+	// pushed to a ClusterSecretStore.
+	SecretStoreRef ExternalSecretStoreRef `json:"secretStoreRef"`
+	// RefreshInterval is the interval the credentials are pushed at, 1h by default
+	// +optional
+	// +nullable
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+	// RemoteKeyPrefix is the prefix of the keys of the credentials in the secret store, followed by the
+	// namespace and the name of their Secret. It is "rook/<cluster namespace>" by default.
+	// +optional
+	RemoteKeyPrefix string `json:"remoteKeyPrefix,omitempty"`
+	// Credentials are the kinds of credentials pushed, all of them by default
+	// +optional
+	Credentials []ExternalSecretCredentials `json:"credentials,omitempty"`
+}
+
+// ExternalSecretStoreRef references a secret store of the External Secrets Operator
+type ExternalSecretStoreRef struct {
+	// Name is the name of the secret store
+	Name string `json:"name"`
+	// Kind is the kind of the secret store, SecretStore by default
+	// +kubebuilder:validation:Enum=SecretStore;ClusterSecretStore
+	// +optional
+	Kind string `json:"kind,omitempty"`
+}
+
+// ExternalSecretCredentials is a kind of credentials generated by Rook
+// +kubebuilder:validation:Enum=csi;objectStoreUser;objectBucketClaim
+type ExternalSecretCredentials string
+
+const (
+	// ExternalSecretCSICredentials are the keys of the users of the CSI drivers
+	ExternalSecretCSICredentials ExternalSecretCredentials = "csi"
+	// ExternalSecretObjectStoreUserCredentials are the S3 keys of the CephObjectStoreUsers
+	ExternalSecretObjectStoreUserCredentials ExternalSecretCredentials = "objectStoreUser"
+	// ExternalSecretObjectBucketClaimCredentials are the S3 keys of the object bucket claims
+	ExternalSecretObjectBucketClaimCredentials ExternalSecretCredentials = "objectBucketClaim"
+)
+
+// FIPSSpec represents the FIPS 140 mode of a cluster
+type FIPSSpec struct {
+	// Enabled encrypts the connections to the daemons with AES-GCM in the msgr2 secure mode and
+	// rejects the settings of the cluster relying on algorithms not approved by FIPS 140. The nodes
+	// must run their kernel in FIPS mode and the Ceph image must be built with a FIPS validated
+	// crypto library.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// PodSecurityStandard is a Pod Security Standard of Kubernetes
+type PodSecurityStandard string
+
+const (
+	// PodSecurityStandardRestricted is the "restricted" Pod Security Standard
+	PodSecurityStandardRestricted PodSecurityStandard = "restricted"
+)
+
+// ClusterCephxSpec represents the rotation of the cephx keys of a cluster
+type ClusterCephxSpec struct {
+	// CSI rotates the keys of the csi users. The new keys are given to new users so that the volumes
+	// mounted with the keys of the previous users keep working.
+	// +optional
+	CSI CSICephxSpec `json:"csi,omitempty"`
+	// Daemon rotates the keys of the mgr, mds, rgw, rbd mirror, cephfs mirror, nfs and crash collector
+	// daemons, which are restarted with their new keys. The keys of the mons and osds are not rotated.
+	// +optional
+	Daemon KeyRotationPolicySpec `json:"daemon,omitempty"`
+}
+
+// CSICephxSpec represents the rotation of the keys of the csi users
+type CSICephxSpec struct {
+	KeyRotationPolicySpec `json:",inline"`
+	// KeepPriorKeys is the number of previous generations of csi users kept for the volumes mounted
+	// with their keys, defaults to 3
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepPriorKeys int `json:"keepPriorKeys,omitempty"`
+}
+
+// KeyRotationPolicySpec represents when the cephx keys are rotated
+type KeyRotationPolicySpec struct {
+	// Period rotates the keys when they are older than the period, e.g. 720h
+	// +optional
+	// +nullable
+	Period *metav1.Duration `json:"period,omitempty"`
+	// Generation rotates the keys on demand when it is increased above the generation of the keys
+	// reported in the status
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Generation int `json:"generation,omitempty"`
+}
+
+// KeyRotationStatus represents the cephx keys in use
+type KeyRotationStatus struct {
+	// Generation is the generation of the keys, incremented at each rotation
+	// +optional
+	Generation int `json:"generation,omitempty"`
+	// LastRotationTime is the time the keys were created or last rotated
+	// +optional
+	// +nullable
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
 }
 
 // KeyManagementServiceSpec represent various details of the KMS server
@@ -280,6 +777,11 @@ type DashboardSpec struct {
 	// SSL determines whether SSL should be used
 	// +optional
 	SSL bool `json:"ssl,omitempty"`
+	// CertificateSecretName is the name of a kubernetes.io/tls secret with the certificate served by
+	// the dashboard when SSL is enabled, such as a secret issued by cert-manager. The dashboard is
+	// reloaded when the certificate is renewed. If empty, a self-signed certificate is created.
+	// +optional
+	CertificateSecretName string `json:"certificateSecretName,omitempty"`
 }
 
 // MonitoringSpec represents the settings for Prometheus based Ceph monitoring
@@ -304,58 +806,323 @@ type MonitoringSpec struct {
 	// +kubebuilder:validation:Maximum=65535
 	// +optional
 	ExternalMgrPrometheusPort uint16 `json:"externalMgrPrometheusPort,omitempty"`
-}
-
-// ClusterStatus represents the status of a Ceph cluster
-type ClusterStatus struct {
-	State       ClusterState    `json:"state,omitempty"`
-	Phase       ConditionType   `json:"phase,omitempty"`
-	Message     string          `json:"message,omitempty"`
-	Conditions  []Condition     `json:"conditions,omitempty"`
-	CephStatus  *CephStatus     `json:"ceph,omitempty"`
-	CephStorage *CephStorage    `json:"storage,omitempty"`
-	CephVersion *ClusterVersion `json:"version,omitempty"`
-}
 
-// CephDaemonsVersions show the current ceph version for different ceph daemons
-type CephDaemonsVersions struct {
-	// Mon shows Mon Ceph version
-	// +optional
-	Mon map[string]int `json:"mon,omitempty"`
-	// Mgr shows Mgr Ceph version
-	// +optional
-	Mgr map[string]int `json:"mgr,omitempty"`
-	// Osd shows Osd Ceph version
+	// CSI represents the settings for the monitoring of the ceph-csi drivers
 	// +optional
-	Osd map[string]int `json:"osd,omitempty"`
-	// Rgw shows Rgw Ceph version
+	CSI CSIMonitoringSpec `json:"csi,omitempty"`
+
+	// Alerts customize the alerts of the prometheus rules created by the operator, keyed by alert name
 	// +optional
-	Rgw map[string]int `json:"rgw,omitempty"`
-	// Mds shows Mds Ceph version
+	// +nullable
+	Alerts map[string]PrometheusAlertSpec `json:"alerts,omitempty"`
+
+	// Dashboards deploys the grafana dashboards of the ceph clusters
 	// +optional
-	Mds map[string]int `json:"mds,omitempty"`
-	// RbdMirror shows RbdMirror Ceph version
+	// +nullable
+	Dashboards *GrafanaDashboardsSpec `json:"dashboards,omitempty"`
+
+	// AlertSilences silence the alerts expected while the cluster is upgrading or in maintenance
 	// +optional
-	RbdMirror map[string]int `json:"rbd-mirror,omitempty"`
-	// CephFSMirror shows CephFSMirror Ceph version
+	// +nullable
+	AlertSilences *AlertSilencesSpec `json:"alertSilences,omitempty"`
+
+	// SNMPGateway deploys a gateway sending the alerts received from alertmanager as SNMP traps
 	// +optional
-	CephFSMirror map[string]int `json:"cephfs-mirror,omitempty"`
-	// Overall shows overall Ceph version
+	// +nullable
+	SNMPGateway *SNMPGatewaySpec `json:"snmpGateway,omitempty"`
+
+	// ExternalLabels are added to the metrics of the cluster scraped by prometheus, such as the name
+	// and the region of the cluster, so that the metrics of several clusters can be federated
 	// +optional
-	Overall map[string]int `json:"overall,omitempty"`
+	// +nullable
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
 }
 
-// CephStatus is the details health of a Ceph Cluster
-type CephStatus struct {
-	Health         string                       `json:"health,omitempty"`
-	Details        map[string]CephHealthMessage `json:"details,omitempty"`
-	LastChecked    string                       `json:"lastChecked,omitempty"`
-	LastChanged    string                       `json:"lastChanged,omitempty"`
-	PreviousHealth string                       `json:"previousHealth,omitempty"`
-	Capacity       Capacity                     `json:"capacity,omitempty"`
+// AlertSilencesSpec represents the alertmanager silences created while the cluster is upgrading, when
+// its daemons run different ceph versions, or in maintenance, when the noout or other OSD flags are set
+// such as while nodes are drained. The silences are expired when the cluster is done.
+type AlertSilencesSpec struct {
+	// Enabled creates the silences
 	// +optional
-	Versions *CephDaemonsVersions `json:"versions,omitempty"`
-}
+	Enabled bool `json:"enabled,omitempty"`
+
+	// AlertmanagerURL is the URL of the alertmanager API, such as http://alertmanager-operated.monitoring:9093
+	// +kubebuilder:validation:Pattern=`^https?://`
+	AlertmanagerURL string `json:"alertmanagerURL"`
+
+	// Alerts are the names of the alerts silenced, the alerts of the daemons being down and of the
+	// degraded data if not set
+	// +optional
+	Alerts []string `json:"alerts,omitempty"`
+}
+
+// SNMPVersion is the version of the SNMP traps sent by the SNMP gateway
+type SNMPVersion string
+
+const (
+	// SNMPVersionV2c sends SNMP v2c traps authenticated by a community
+	SNMPVersionV2c SNMPVersion = "V2c"
+	// SNMPVersionV3 sends SNMP v3 traps authenticated by a user
+	SNMPVersionV3 SNMPVersion = "V3"
+)
+
+// SNMPGatewaySpec represents the deployment of the SNMP gateway of the alerts
+type SNMPGatewaySpec struct {
+	// Enabled deploys the SNMP gateway
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Image of the SNMP gateway, the image of the snmp_notifier deployed by cephadm if not set
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Destination is the host and port of the SNMP manager receiving the traps, such as snmp.example.com:162
+	// +kubebuilder:validation:Pattern=`^.+:[0-9]+$`
+	Destination string `json:"destination"`
+
+	// Version of the SNMP traps, V2c if not set
+	// +kubebuilder:validation:Enum="";V2c;V3
+	// +optional
+	Version SNMPVersion `json:"version,omitempty"`
+
+	// CredentialsSecretName is the name of the Secret of the credentials of the traps in the namespace of
+	// the cluster, with the community key for V2c, and the username and password keys, and the
+	// privacyPassword key if the privacy protocol is set, for V3
+	CredentialsSecretName string `json:"credentialsSecretName"`
+
+	// AuthProtocol is the authentication protocol of the V3 traps, MD5 if not set
+	// +kubebuilder:validation:Enum="";MD5;SHA
+	// +optional
+	AuthProtocol string `json:"authProtocol,omitempty"`
+
+	// PrivacyProtocol encrypts the V3 traps with this protocol, the traps are not encrypted if not set
+	// +kubebuilder:validation:Enum="";DES;AES
+	// +optional
+	PrivacyProtocol string `json:"privacyProtocol,omitempty"`
+
+	// EngineID is the security engine ID of the V3 traps
+	// +optional
+	EngineID string `json:"engineID,omitempty"`
+
+	// AlertmanagerConfig creates an AlertmanagerConfig of the prometheus operator in the namespace of the
+	// cluster, routing the alerts of the namespace to the gateway
+	// +optional
+	AlertmanagerConfig bool `json:"alertmanagerConfig,omitempty"`
+
+	// Resources of the SNMP gateway
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// GrafanaDashboardsMode is how the grafana dashboards are deployed
+type GrafanaDashboardsMode string
+
+const (
+	// GrafanaDashboardsConfigMap deploys the dashboards as ConfigMaps loaded by the dashboard sidecar of grafana
+	GrafanaDashboardsConfigMap GrafanaDashboardsMode = "ConfigMap"
+	// GrafanaDashboardsCR deploys the dashboards as GrafanaDashboards of the grafana operator
+	GrafanaDashboardsCR GrafanaDashboardsMode = "GrafanaDashboard"
+)
+
+// GrafanaDashboardsSpec represents the deployment of the grafana dashboards
+type GrafanaDashboardsSpec struct {
+	// Enabled deploys the dashboards
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Mode deploys the dashboards as ConfigMaps for the dashboard sidecar of grafana, or as
+	// GrafanaDashboards of the grafana operator. ConfigMap if not set.
+	// +kubebuilder:validation:Enum="";ConfigMap;GrafanaDashboard
+	// +optional
+	Mode GrafanaDashboardsMode `json:"mode,omitempty"`
+
+	// Namespace of the dashboards, the namespace of the cluster if not set
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Labels of the ConfigMaps selected by the dashboard sidecar, grafana_dashboard: "1" if not set
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Folder is the grafana folder of the dashboards
+	// +optional
+	Folder string `json:"folder,omitempty"`
+
+	// InstanceSelector selects the grafana instances of the grafana operator importing the
+	// GrafanaDashboards, all the instances of the namespace if not set
+	// +optional
+	// +nullable
+	InstanceSelector *metav1.LabelSelector `json:"instanceSelector,omitempty"`
+}
+
+// PrometheusAlertSpec represents the customization of an alert of the prometheus rules
+type PrometheusAlertSpec struct {
+	// Disabled removes the alert from the prometheus rules
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Threshold replaces the value the expression of the alert is compared to
+	// +kubebuilder:validation:Pattern=`^-?[0-9]+(\.[0-9]+)?$`
+	// +optional
+	Threshold string `json:"threshold,omitempty"`
+
+	// For replaces how long the expression of the alert must be true before the alert fires
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h|d|w|y))+$`
+	// +optional
+	For string `json:"for,omitempty"`
+
+	// Severity replaces the severity label of the alert
+	// +kubebuilder:validation:Enum=critical;warning;info
+	// +optional
+	Severity string `json:"severity,omitempty"`
+}
+
+// CSIMonitoringSpec represents the settings for the monitoring of the ceph-csi drivers
+type CSIMonitoringSpec struct {
+	// Enabled determines whether to expose the liveness and grpc metrics of the ceph-csi provisioner
+	// and plugin pods with a ServiceMonitor. If true, the prometheus types must exist or the
+	// creation will fail.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Interval determines prometheus scrape interval of the ceph-csi metrics, 5s if not set
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// ClusterStatus represents the status of a Ceph cluster
+type ClusterStatus struct {
+	State       ClusterState    `json:"state,omitempty"`
+	Phase       ConditionType   `json:"phase,omitempty"`
+	Message     string          `json:"message,omitempty"`
+	Conditions  []Condition     `json:"conditions,omitempty"`
+	CephStatus  *CephStatus     `json:"ceph,omitempty"`
+	CephStorage *CephStorage    `json:"storage,omitempty"`
+	CephVersion *ClusterVersion `json:"version,omitempty"`
+	// External is the status of the connection to an external cluster
+	// +optional
+	External *ExternalClusterStatus `json:"external,omitempty"`
+	// KMS is the status of the key management service storing the OSD encryption keys
+	// +optional
+	KMS *KMSStatus `json:"kms,omitempty"`
+	// Cephx is the status of the cephx keys of the csi users and of the daemons
+	// +optional
+	Cephx *ClusterCephxStatus `json:"cephx,omitempty"`
+	// FIPS is the compliance of the cluster with FIPS 140, set when the FIPS mode is enabled
+	// +optional
+	FIPS *FIPSStatus `json:"fips,omitempty"`
+	// Images are the digests the images of the cluster are pinned to, set when the image policy is enabled
+	// +optional
+	Images []ImageStatus `json:"images,omitempty"`
+	// Tasks is the progress of the tasks of the spec
+	// +optional
+	Tasks []TaskStatus `json:"tasks,omitempty"`
+	// Adoption reports the resources of the cluster that are not managed by a CR
+	// +optional
+	Adoption *AdoptionStatus `json:"adoption,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// FIPSStatus represents the compliance of a cluster with FIPS 140
+type FIPSStatus struct {
+	// Compliant is whether the settings of the cluster only rely on algorithms approved by FIPS 140
+	Compliant bool `json:"compliant"`
+	// Violations are the settings of the cluster relying on algorithms not approved by FIPS 140
+	// +optional
+	Violations []string `json:"violations,omitempty"`
+}
+
+// ClusterCephxStatus represents the cephx keys of the csi users and of the daemons
+type ClusterCephxStatus struct {
+	// CSI is the status of the keys of the csi users
+	// +optional
+	CSI *CSICephxStatus `json:"csi,omitempty"`
+	// Daemon is the status of the keys of the daemons
+	// +optional
+	Daemon *KeyRotationStatus `json:"daemon,omitempty"`
+}
+
+// CSICephxStatus represents the keys of the csi users
+type CSICephxStatus struct {
+	KeyRotationStatus `json:",inline"`
+	// PriorKeyGenerations are the generations of the previous csi users that are kept
+	// +optional
+	PriorKeyGenerations []int `json:"priorKeyGenerations,omitempty"`
+}
+
+// KMSStatus represents the key management service storing the encryption keys of a resource
+type KMSStatus struct {
+	// Provider is the KMS provider storing the keys
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// KeyVersions are the versions of the keys in use by name, for the providers versioning the keys
+	// +optional
+	// +nullable
+	KeyVersions map[string]string `json:"keyVersions,omitempty"`
+}
+
+// ExternalClusterStatus is the status of the connection to an external cluster
+type ExternalClusterStatus struct {
+	// KeyEpoch is incremented each time the keys of the bootstrap secret are rotated
+	// +optional
+	KeyEpoch int `json:"keyEpoch,omitempty"`
+	// KeysHash is the hash of the keys of the bootstrap secret in use
+	// +optional
+	KeysHash string `json:"keysHash,omitempty"`
+	// LastKeyRotationTime is the time the keys of the bootstrap secret in use were applied
+	// +optional
+	LastKeyRotationTime *metav1.Time `json:"lastKeyRotationTime,omitempty"`
+	// CrushLocationLabels are the node labels matching the types of the CRUSH buckets of the external
+	// cluster, used for the read affinity and topology of the csi drivers when they are not configured
+	// +optional
+	CrushLocationLabels []string `json:"crushLocationLabels,omitempty"`
+}
+
+// CephDaemonsVersions show the current ceph version for different ceph daemons
+type CephDaemonsVersions struct {
+	// Mon shows Mon Ceph version
+	// +optional
+	Mon map[string]int `json:"mon,omitempty"`
+	// Mgr shows Mgr Ceph version
+	// +optional
+	Mgr map[string]int `json:"mgr,omitempty"`
+	// Osd shows Osd Ceph version
+	// +optional
+	Osd map[string]int `json:"osd,omitempty"`
+	// Rgw shows Rgw Ceph version
+	// +optional
+	Rgw map[string]int `json:"rgw,omitempty"`
+	// Mds shows Mds Ceph version
+	// +optional
+	Mds map[string]int `json:"mds,omitempty"`
+	// RbdMirror shows RbdMirror Ceph version
+	// +optional
+	RbdMirror map[string]int `json:"rbd-mirror,omitempty"`
+	// CephFSMirror shows CephFSMirror Ceph version
+	// +optional
+	CephFSMirror map[string]int `json:"cephfs-mirror,omitempty"`
+	// Overall shows overall Ceph version
+	// +optional
+	Overall map[string]int `json:"overall,omitempty"`
+}
+
+// CephStatus is the details health of a Ceph Cluster
+type CephStatus struct {
+	Health         string                       `json:"health,omitempty"`
+	Details        map[string]CephHealthMessage `json:"details,omitempty"`
+	LastChecked    string                       `json:"lastChecked,omitempty"`
+	LastChanged    string                       `json:"lastChanged,omitempty"`
+	PreviousHealth string                       `json:"previousHealth,omitempty"`
+	Capacity       Capacity                     `json:"capacity,omitempty"`
+	// +optional
+	Versions *CephDaemonsVersions `json:"versions,omitempty"`
+	// Checks are the active health checks of the cluster, the errors first
+	// +optional
+	Checks []CephHealthCheck `json:"checks,omitempty"`
+}
 
 // Capacity is the capacity information of a Ceph Cluster
 type Capacity struct {
@@ -363,11 +1130,62 @@ type Capacity struct {
 	UsedBytes      uint64 `json:"bytesUsed,omitempty"`
 	AvailableBytes uint64 `json:"bytesAvailable,omitempty"`
 	LastUpdated    string `json:"lastUpdated,omitempty"`
+	// Forecast projects when the pools and the device classes are full from their recent growth
+	// +optional
+	Forecast *CapacityForecast `json:"forecast,omitempty"`
+}
+
+// CapacityForecast is the projection of the usage of the pools and the device classes of a cluster
+type CapacityForecast struct {
+	// Pools are the forecasts of the data stored in the pools, until the pools cannot store more data
+	// +optional
+	Pools []UsageForecast `json:"pools,omitempty"`
+	// DeviceClasses are the forecasts of the raw capacity used on the OSDs of each device class, until
+	// it reaches the full ratio of the OSDs
+	// +optional
+	DeviceClasses []UsageForecast `json:"deviceClasses,omitempty"`
+	// LastUpdated is the time the forecasts were computed
+	// +optional
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+// UsageForecast projects when a pool or a device class is full from the growth of its usage
+type UsageForecast struct {
+	// Name is the name of the pool or of the device class
+	Name string `json:"name"`
+	// GrowthBytesPerDay is the growth of the usage per day, negative when the usage decreases
+	GrowthBytesPerDay int64 `json:"growthBytesPerDay"`
+	// DaysUntilFull is the number of days until full at the current growth, not set when the usage
+	// does not grow
+	// +optional
+	DaysUntilFull *int64 `json:"daysUntilFull,omitempty"`
 }
 
 // CephStorage represents flavors of Ceph Cluster Storage
 type CephStorage struct {
 	DeviceClasses []DeviceClasses `json:"deviceClasses,omitempty"`
+	// OSDMigration is the progress of the migration of the OSDs to the encryption of their device set
+	// +optional
+	OSDMigration *OSDMigrationStatus `json:"osdMigration,omitempty"`
+}
+
+// OSDMigrationStatus represents the progress of the migration of the OSDs
+type OSDMigrationStatus struct {
+	// Pending is the number of OSDs left to migrate, including the OSD being migrated
+	Pending int `json:"pending"`
+	// Migrated is the number of OSDs migrated
+	// +optional
+	Migrated int `json:"migrated,omitempty"`
+	// OSD is the ID of the OSD being migrated
+	// +optional
+	// +nullable
+	OSD *int `json:"osd,omitempty"`
+	// Phase is the step of the migration of the OSD: Draining, Removing or Recovering
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Message explains why the migration is waiting
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // DeviceClasses represents device classes of a Ceph Cluster
@@ -387,6 +1205,26 @@ type CephHealthMessage struct {
 	Message  string `json:"message"`
 }
 
+// CephHealthCheck is an active health check of a Ceph cluster
+type CephHealthCheck struct {
+	// Code is the code of the health check, such as OSD_DOWN
+	Code string `json:"code"`
+	// Severity is the severity of the health check, HEALTH_WARN or HEALTH_ERR
+	Severity string `json:"severity"`
+	// Summary is the summary message of the health check
+	// +optional
+	Summary string `json:"summary,omitempty"`
+	// Count is the number of entities the health check is raised for, such as the number of OSDs down
+	// +optional
+	Count int `json:"count,omitempty"`
+	// Muted is whether the health check is muted
+	// +optional
+	Muted bool `json:"muted,omitempty"`
+	// Since is the time the health check was first found active by the operator
+	// +optional
+	Since string `json:"since,omitempty"`
+}
+
 // Condition represents a status condition on any Rook-Ceph Custom Resource.
 type Condition struct {
 	Type               ConditionType      `json:"type,omitempty"`
@@ -395,6 +1233,9 @@ type Condition struct {
 	Message            string             `json:"message,omitempty"`
 	LastHeartbeatTime  metav1.Time        `json:"lastHeartbeatTime,omitempty"`
 	LastTransitionTime metav1.Time        `json:"lastTransitionTime,omitempty"`
+	// ObservedGeneration is the generation of the resource the condition was set for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // ConditionReason is a reason for a condition
@@ -418,6 +1259,12 @@ const (
 	ReconcileFailed ConditionReason = "ReconcileFailed"
 	// ReconcileStarted represents when a resource reconciliation started.
 	ReconcileStarted ConditionReason = "ReconcileStarted"
+	// ReconcileRequeuing represents when a resource reconciliation is waiting to be retried, such as
+	// when waiting for the CephCluster to be ready.
+	ReconcileRequeuing ConditionReason = "ReconcileRequeuing"
+	// ReconcileDryRun represents when a resource reconciliation only reported the changes it would
+	// make since the resource is in dry run.
+	ReconcileDryRun ConditionReason = "ReconcileDryRun"
 
 	// DeletingReason represents when Rook has detected a resource object should be deleted.
 	DeletingReason ConditionReason = "Deleting"
@@ -427,6 +1274,48 @@ const (
 	// ObjectHasNoDependentsReason represents when a resource object has no dependents that are
 	// blocking deletion.
 	ObjectHasNoDependentsReason ConditionReason = "ObjectHasNoDependents"
+
+	// PeersConnectedReason represents when the remote clusters of all the mirror peers can be
+	// reached with the credentials of their bootstrap peer token.
+	PeersConnectedReason ConditionReason = "PeersConnected"
+	// PeerConnectionFailedReason represents when the remote cluster of a mirror peer cannot be
+	// reached or the credentials of its bootstrap peer token are refused.
+	PeerConnectionFailedReason ConditionReason = "PeerConnectionFailed"
+
+	// ReplicationHealthyReason represents when the replication of a resource to its peer sites is
+	// healthy.
+	ReplicationHealthyReason ConditionReason = "ReplicationHealthy"
+	// ReplicationDaemonUnhealthyReason represents when the rbd-mirror or cephfs-mirror daemons
+	// replicating a resource are not running or not healthy.
+	ReplicationDaemonUnhealthyReason ConditionReason = "DaemonUnhealthy"
+	// ReplicationSyncBehindReason represents when some images, directories or shards of a resource
+	// are not synced with the peer sites.
+	ReplicationSyncBehindReason ConditionReason = "SyncBehind"
+	// ReplicationStatusUnknownReason represents when the replication status of a resource could not
+	// be checked.
+	ReplicationStatusUnknownReason ConditionReason = "StatusUnknown"
+
+	// KMSConnectedReason represents when the KMS storing the encryption keys can be reached and the
+	// operator is authenticated.
+	KMSConnectedReason ConditionReason = "KMSConnected"
+	// KMSConnectionFailedReason represents when the KMS storing the encryption keys cannot be reached
+	// or refuses the credentials of the operator.
+	KMSConnectionFailedReason ConditionReason = "KMSConnectionFailed"
+
+	// OSDMigrationHostOSDsReason represents when the confirmation of the OSD migration is rejected
+	// since OSDs on hosts are not encrypted, they cannot be migrated.
+	OSDMigrationHostOSDsReason ConditionReason = "HostOSDsNotMigrated"
+	// OSDMigrationAcceptedReason represents when the confirmation of the OSD migration is accepted.
+	OSDMigrationAcceptedReason ConditionReason = "MigrationAccepted"
+
+	// ClusterHealthErrorReason represents when the reconciles are paused since the health of the
+	// cluster is HEALTH_ERR.
+	ClusterHealthErrorReason ConditionReason = "ClusterHealthError"
+	// ClusterRecoveringReason represents when the reconciles are paused since the data of the cluster
+	// is recovering.
+	ClusterRecoveringReason ConditionReason = "ClusterRecovering"
+	// ClusterHealthyReason represents when the reconciles are not paused by the health of the cluster.
+	ClusterHealthyReason ConditionReason = "ClusterHealthy"
 )
 
 // ConditionType represent a resource's status
@@ -441,6 +1330,8 @@ const (
 	ConditionProgressing ConditionType = "Progressing"
 	// ConditionReady represents Ready state of an object
 	ConditionReady ConditionType = "Ready"
+	// ConditionDegraded represents whether the latest reconcile of an object failed
+	ConditionDegraded ConditionType = "Degraded"
 	// ConditionFailure represents Failure state of an object
 	ConditionFailure ConditionType = "Failure"
 	// ConditionDeleting represents Deleting state of an object
@@ -448,6 +1339,29 @@ const (
 
 	// ConditionDeletionIsBlocked represents when deletion of the object is blocked.
 	ConditionDeletionIsBlocked ConditionType = "DeletionIsBlocked"
+
+	// ConditionPeerConnected represents whether the remote clusters of the mirror peers of the object
+	// can be reached.
+	ConditionPeerConnected ConditionType = "PeerConnected"
+
+	// ConditionDRReady represents whether the resource is replicated to its peer sites and can be
+	// failed over. It is set on the mirrored pools and filesystems and on the multisite object stores.
+	ConditionDRReady ConditionType = "DRReady"
+	// ConditionReplicationDegraded represents whether the replication of the resource to its peer
+	// sites is degraded, the reason tells which part of the replication is degraded.
+	ConditionReplicationDegraded ConditionType = "ReplicationDegraded"
+
+	// ConditionKMSConnected represents whether the KMS storing the encryption keys of the OSDs can be
+	// reached. The OSDs are not provisioned while it is false.
+	ConditionKMSConnected ConditionType = "KMSConnected"
+
+	// ConditionReconcilePaused represents whether the reconciles of the object are paused by the
+	// backpressure policy of the CephCluster until the cluster recovers.
+	ConditionReconcilePaused ConditionType = "ReconcilePaused"
+
+	// ConditionOSDMigrationRejected represents whether the confirmation of the OSD encryption migration
+	// is rejected. The OSDs on hosts cannot be migrated, no OSD is migrated while it is true.
+	ConditionOSDMigrationRejected ConditionType = "OSDMigrationRejected"
 )
 
 // ClusterState represents the state of a Ceph Cluster
@@ -529,6 +1443,11 @@ type MgrSpec struct {
 	// +optional
 	// +nullable
 	Modules []Module `json:"modules,omitempty"`
+	// Telemetry configures the reports sent by the telemetry module to the Ceph project. The telemetry
+	// settings are left unchanged if not set.
+	// +optional
+	// +nullable
+	Telemetry *TelemetrySpec `json:"telemetry,omitempty"`
 }
 
 // Module represents mgr modules that the user wants to enable or disable
@@ -541,6 +1460,48 @@ type Module struct {
 	Enabled bool `json:"enabled,omitempty"`
 }
 
+// TelemetrySpec configures the telemetry module of the manager, see
+// https://docs.ceph.com/en/latest/mgr/telemetry/
+type TelemetrySpec struct {
+	// Enabled turns on the telemetry reports, accepting the Community Data License Agreement - Sharing -
+	// Version 1.0. The telemetry is kept off if false.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Channels are the channels of the reports to send. Only the basic, crash and device channels are
+	// sent if not set.
+	// +optional
+	Channels []TelemetryChannel `json:"channels,omitempty"`
+	// Contact is the email address of the contact of the cluster, sent with the ident channel
+	// +optional
+	Contact string `json:"contact,omitempty"`
+	// Organization is the name of the organization of the cluster, sent with the ident channel
+	// +optional
+	Organization string `json:"organization,omitempty"`
+	// Description is the description of the cluster, sent with the ident channel
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Proxy is the proxy through which the reports are sent, such as http://proxy.example.com:3128
+	// +optional
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// TelemetryChannel is a channel of the telemetry reports
+// +kubebuilder:validation:Enum=basic;crash;device;ident;perf
+type TelemetryChannel string
+
+const (
+	// TelemetryChannelBasic reports the basic information about the cluster
+	TelemetryChannelBasic TelemetryChannel = "basic"
+	// TelemetryChannelCrash reports the information about the daemon crashes
+	TelemetryChannelCrash TelemetryChannel = "crash"
+	// TelemetryChannelDevice reports the anonymized health metrics of the devices
+	TelemetryChannelDevice TelemetryChannel = "device"
+	// TelemetryChannelIdent reports the contact, the organization and the description of the cluster
+	TelemetryChannelIdent TelemetryChannel = "ident"
+	// TelemetryChannelPerf reports the performance metrics of the cluster, from Ceph Quincy
+	TelemetryChannelPerf TelemetryChannel = "perf"
+)
+
 // ExternalSpec represents the options supported by an external cluster
 // +kubebuilder:pruning:PreserveUnknownFields
 // +nullable
@@ -548,6 +1509,35 @@ type ExternalSpec struct {
 	// Enable determines whether external mode is enabled or not
 	// +optional
 	Enable bool `json:"enable,omitempty"`
+	// BootstrapSecret is the name of the secret in the namespace of the cluster with the connection
+	// info of the external cluster. The operator creates the mon secret, the mon endpoints configmap
+	// and the csi secrets from it.
+	// +optional
+	BootstrapSecret string `json:"bootstrapSecret,omitempty"`
+	// ObjectOnly connects to the gateways of the external cluster only, with the credentials of its
+	// rgw admin ops user and without any access to its mons. Only the object stores, their users and
+	// their bucket claims are supported.
+	// +optional
+	ObjectOnly bool `json:"objectOnly,omitempty"`
+	// Users are the features whose users the operator creates in the external cluster, each with the
+	// minimal caps of its feature. The users of the features that are not enabled are removed. The
+	// operator must connect with the admin key. If not set, the users of all the csi drivers are created.
+	// +optional
+	// +nullable
+	Users *ExternalUsersSpec `json:"users,omitempty"`
+}
+
+// ExternalUsersSpec defines the features of an external cluster the operator creates users for
+type ExternalUsersSpec struct {
+	// RBD creates the provisioner and node users of the RBD csi driver
+	// +optional
+	RBD bool `json:"rbd,omitempty"`
+	// CephFS creates the provisioner and node users of the CephFS csi driver
+	// +optional
+	CephFS bool `json:"cephFS,omitempty"`
+	// ObjectStore creates the rgw admin ops user of the external object stores
+	// +optional
+	ObjectStore bool `json:"objectStore,omitempty"`
 }
 
 // CrashCollectorSpec represents options to configure the crash controller
@@ -566,6 +1556,11 @@ type CrashCollectorSpec struct {
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // CephBlockPool represents a Ceph Storage Pool
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.spec.replicated.size`,description="Number of copies of the data"
+// +kubebuilder:printcolumn:name="FailureDomain",type=string,JSONPath=`.spec.failureDomain`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephBlockPool struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -682,8 +1677,66 @@ type CephBlockPoolStatus struct {
 	// +optional
 	SnapshotScheduleStatus *SnapshotScheduleStatusSpec `json:"snapshotScheduleStatus,omitempty"`
 	// +optional
+	GroupReplicationStatus *GroupReplicationStatusSpec `json:"groupReplicationStatus,omitempty"`
+	// +optional
+	ImageModeMigrationStatus *ImageModeMigrationStatusSpec `json:"imageModeMigrationStatus,omitempty"`
+	// +optional
 	// +nullable
 	Info map[string]string `json:"info,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// ImageModeMigrationStatusSpec is the progress of the migration of the mirrored images of the pool to
+// the image mirroring mode of its spec
+type ImageModeMigrationStatusSpec struct {
+	// ImageMode is the mirroring mode the images are migrated to
+	// +optional
+	ImageMode string `json:"imageMode,omitempty"`
+	// Pending is the number of primary images still mirrored in the other mode
+	// +optional
+	Pending int `json:"pending,omitempty"`
+	// Migrated is the number of images migrated by the last check
+	// +optional
+	Migrated int `json:"migrated,omitempty"`
+	// LastChecked is the last time the images were checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Details contains the reason the migration is waiting or failed
+	// +optional
+	Details string `json:"details,omitempty"`
+}
+
+// GroupReplicationStatusSpec is the status of the csi-addons VolumeGroupReplications of the
+// generated VolumeGroupReplicationClass of the pool
+type GroupReplicationStatusSpec struct {
+	// ClassName is the name of the VolumeGroupReplicationClass of the pool
+	// +optional
+	ClassName string `json:"className,omitempty"`
+	// Groups is the number of VolumeGroupReplications of the pool
+	// +optional
+	Groups int `json:"groups,omitempty"`
+	// Primary is the number of groups replicated from this cluster
+	// +optional
+	Primary int `json:"primary,omitempty"`
+	// Secondary is the number of groups replicated to this cluster
+	// +optional
+	Secondary int `json:"secondary,omitempty"`
+	// Degraded is the number of groups reporting a degraded replication
+	// +optional
+	Degraded int `json:"degraded,omitempty"`
+	// PersistentVolumeClaims is the number of PVCs replicated by the groups
+	// +optional
+	PersistentVolumeClaims int `json:"persistentVolumeClaims,omitempty"`
+	// LastChecked is the last time the status was checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Details contains potential status errors
+	// +optional
+	Details string `json:"details,omitempty"`
 }
 
 // MirroringStatusSpec is the status of the pool mirroring
@@ -810,6 +1863,10 @@ type SnapshotScheduleStatusSpec struct {
 	// Details contains potential status errors
 	// +optional
 	Details string `json:"details,omitempty"`
+	// ImagesWithoutSchedule is the number of primary images mirrored with snapshots that have
+	// no snapshot schedule, neither on the pool nor on the image
+	// +optional
+	ImagesWithoutSchedule int `json:"imagesWithoutSchedule,omitempty"`
 }
 
 // SnapshotSchedulesSpec is the list of snapshot scheduled for images in a pool
@@ -842,6 +1899,12 @@ type SnapshotSchedule struct {
 type Status struct {
 	// +optional
 	Phase string `json:"phase,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // ReplicatedSpec represents the spec for replication in a pool
@@ -897,6 +1960,13 @@ type MirroringSpec struct {
 	// +optional
 	Mode string `json:"mode,omitempty"`
 
+	// ImageMode is the mirroring mode of the images of a pool in image mode: either journal or snapshot.
+	// The primary images mirrored in the other mode are migrated to this mode a few at a time, each
+	// migrated image is fully synced again by the peers.
+	// +kubebuilder:validation:Enum=journal;snapshot
+	// +optional
+	ImageMode string `json:"imageMode,omitempty"`
+
 	// SnapshotSchedules is the scheduling of snapshot for mirrored images/pools
 	// +optional
 	SnapshotSchedules []SnapshotScheduleSpec `json:"snapshotSchedules,omitempty"`
@@ -913,6 +1983,11 @@ type SnapshotScheduleSpec struct {
 	// +optional
 	Path string `json:"path,omitempty"`
 
+	// Image is the name of the image to snapshot, only valid for RBD pools in image mirroring mode.
+	// The schedule applies to all the images of the pool when empty.
+	// +optional
+	Image string `json:"image,omitempty"`
+
 	// Interval represent the periodicity of the snapshot.
 	// +optional
 	Interval string `json:"interval,omitempty"`
@@ -965,6 +2040,7 @@ type ErasureCodedSpec struct {
 // +kubebuilder:printcolumn:name="ActiveMDS",type=string,JSONPath=`.spec.metadataServer.activeCount`,description="Number of desired active MDS daemons"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephFilesystem struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -1012,6 +2088,28 @@ type FilesystemSpec struct {
 	// The mirroring statusCheck
 	// +kubebuilder:pruning:PreserveUnknownFields
 	StatusCheck MirrorHealthCheckSpec `json:"statusCheck,omitempty"`
+
+	// The monitoring of the clients of the filesystem
+	// +nullable
+	// +optional
+	Monitoring *FilesystemMonitoringSpec `json:"monitoring,omitempty"`
+
+	// Tasks are the long-running operations run in jobs on the filesystem, like scrubbing it
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Tasks []TaskSpec `json:"tasks,omitempty"`
+}
+
+// FilesystemMonitoringSpec represents the monitoring of the clients of a filesystem
+type FilesystemMonitoringSpec struct {
+	// ClientMetrics exports the caps, the session age and the versions of the clients connected to the
+	// active MDS daemons of the filesystem on the metrics endpoint of the operator
+	// +optional
+	ClientMetrics bool `json:"clientMetrics,omitempty"`
+	// Interval is the interval the sessions of the clients are collected at, 1m by default
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
 }
 
 // MetadataServerSpec represents the specification of a Ceph Metadata Server
@@ -1108,7 +2206,13 @@ type CephFilesystemStatus struct {
 	// +optional
 	MirroringStatus *FilesystemMirroringInfoSpec `json:"mirroringStatus,omitempty"`
 	Conditions      []Condition                  `json:"conditions,omitempty"`
-}
+	// Tasks is the progress of the tasks of the spec
+	// +optional
+	Tasks []TaskStatus `json:"tasks,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
 
 // FilesystemMirroringInfo is the status of the pool mirroring
 type FilesystemMirroringInfoSpec struct {
@@ -1116,6 +2220,9 @@ type FilesystemMirroringInfoSpec struct {
 	// +nullable
 	// +optional
 	FilesystemMirroringAllInfo []FilesystemMirroringInfo `json:"daemonsStatus,omitempty"`
+	// Peers is the mirroring status of the filesystem with each of its peers
+	// +optional
+	Peers []FilesystemMirrorPeerStatus `json:"peers,omitempty"`
 	// LastChecked is the last time time the status was checked
 	// +optional
 	LastChecked string `json:"lastChecked,omitempty"`
@@ -1127,6 +2234,43 @@ type FilesystemMirroringInfoSpec struct {
 	Details string `json:"details,omitempty"`
 }
 
+// FilesystemMirrorPeerStatus is the mirroring status of a filesystem with a peer
+type FilesystemMirrorPeerStatus struct {
+	// UUID is the peer unique identifier
+	UUID string `json:"uuid"`
+	// SecretName is the name of the secret the peer token was imported from, empty for a peer that
+	// was not added by the operator
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// SiteName is the site name of the remote cluster
+	// +optional
+	SiteName string `json:"siteName,omitempty"`
+	// FSName is the name of the remote filesystem
+	// +optional
+	FSName string `json:"fsName,omitempty"`
+	// Directories is the number of directories mirrored to the peer
+	// +optional
+	Directories int `json:"directories,omitempty"`
+	// FailedDirectories is the number of directories whose sync with the peer failed
+	// +optional
+	FailedDirectories int `json:"failedDirectories,omitempty"`
+	// SnapsSynced is the number of snapshots synced to the peer
+	// +optional
+	SnapsSynced int `json:"snapsSynced,omitempty"`
+	// SnapsDeleted is the number of snapshots deleted from the peer
+	// +optional
+	SnapsDeleted int `json:"snapsDeleted,omitempty"`
+	// SnapsRenamed is the number of snapshots renamed in the peer
+	// +optional
+	SnapsRenamed int `json:"snapsRenamed,omitempty"`
+	// FailureCount is the number of mirroring failures with the peer
+	// +optional
+	FailureCount int `json:"failureCount,omitempty"`
+	// RecoveryCount is the number of recoveries after failures
+	// +optional
+	RecoveryCount int `json:"recoveryCount,omitempty"`
+}
+
 // FilesystemSnapshotScheduleStatusSpec is the status of the snapshot schedule
 type FilesystemSnapshotScheduleStatusSpec struct {
 	// SnapshotSchedules is the list of snapshots scheduled
@@ -1257,7 +2401,15 @@ type PeerStatSpec struct {
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // CephObjectStore represents a Ceph Object Store Gateway
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Instances",type=integer,JSONPath=`.spec.gateway.instances`,description="Number of desired rgw daemons"
+// +kubebuilder:printcolumn:name="Running",type=integer,JSONPath=`.status.replicas`,description="Number of rgw daemons running"
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.info.endpoint`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.gateway.instances,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:storageversion
 type CephObjectStore struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
@@ -1310,6 +2462,28 @@ type ObjectStoreSpec struct {
 	// +optional
 	// +nullable
 	Security *SecuritySpec `json:"security,omitempty"`
+
+	// Monitoring of the usage of the users and the buckets of the object store
+	// +optional
+	// +nullable
+	Monitoring *ObjectStoreMonitoringSpec `json:"monitoring,omitempty"`
+
+	// Tasks are the long-running operations run in jobs on the object store, like resharding a bucket
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Tasks []TaskSpec `json:"tasks,omitempty"`
+}
+
+// ObjectStoreMonitoringSpec represents the monitoring of the usage of an object store
+type ObjectStoreMonitoringSpec struct {
+	// UsageMetrics exports the usage and the quotas of the users and the buckets of the object store on
+	// the metrics endpoint of the operator
+	// +optional
+	UsageMetrics bool `json:"usageMetrics,omitempty"`
+	// Interval is the interval the usage is collected at, 5m by default
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
 }
 
 // BucketHealthCheckSpec represents the health check of an object store
@@ -1420,6 +2594,21 @@ type ObjectStoreStatus struct {
 	// +nullable
 	Info       map[string]string `json:"info,omitempty"`
 	Conditions []Condition       `json:"conditions,omitempty"`
+	// KMS is the status of the key management service used by the server side encryption
+	// +optional
+	KMS *KMSStatus `json:"kms,omitempty"`
+	// Replicas is the number of rgw daemons running, reported by the scale subresource
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the rgw pods, reported by the scale subresource
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// Tasks is the progress of the tasks of the spec
+	// +optional
+	Tasks []TaskStatus `json:"tasks,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // BucketStatus represents the status of a bucket
@@ -1439,6 +2628,10 @@ type BucketStatus struct {
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:resource:shortName=rcou;objectuser
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Store",type=string,JSONPath=`.spec.store`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephObjectStoreUser struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -1456,6 +2649,15 @@ type ObjectStoreUserStatus struct {
 	// +optional
 	// +nullable
 	Info map[string]string `json:"info,omitempty"`
+	// KeyRotation is the status of the S3 key of the user
+	// +optional
+	KeyRotation *KeyRotationStatus `json:"keyRotation,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -1481,6 +2683,10 @@ type ObjectStoreUserSpec struct {
 	// +optional
 	// +nullable
 	Quotas *ObjectUserQuotaSpec `json:"quotas,omitempty"`
+	// KeyRotation rotates the S3 key of the user, the secret of the user is updated with the new key
+	// +optional
+	// +nullable
+	KeyRotation *KeyRotationPolicySpec `json:"keyRotation,omitempty"`
 }
 
 // Additional admin-level capabilities for the Ceph object store user
@@ -1528,6 +2734,9 @@ type ObjectUserQuotaSpec struct {
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephObjectRealm struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -1563,6 +2772,10 @@ type PullSpec struct {
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Realm",type=string,JSONPath=`.spec.realm`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephObjectZoneGroup struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -1591,6 +2804,10 @@ type ObjectZoneGroupSpec struct {
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="ZoneGroup",type=string,JSONPath=`.spec.zoneGroup`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephObjectZone struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -1627,6 +2844,10 @@ type ObjectZoneSpec struct {
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="ARN",type=string,JSONPath=`.status.ARN`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephBucketTopic struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -1645,6 +2866,12 @@ type BucketTopicStatus struct {
 	// +optional
 	// +nullable
 	ARN *string `json:"ARN,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // CephBucketTopicList represents a list Ceph Object Store Bucket Notification Topics
@@ -1740,6 +2967,10 @@ type KafkaEndpointSpec struct {
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Topic",type=string,JSONPath=`.spec.topic`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephBucketNotification struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -1819,14 +3050,38 @@ type RGWServiceSpec struct {
 // +genclient:noStatus
 // +kubebuilder:resource:shortName=nfs,path=cephnfses
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Servers",type=integer,JSONPath=`.spec.server.active`,description="Number of desired nfs servers"
+// +kubebuilder:printcolumn:name="Running",type=integer,JSONPath=`.status.replicas`,description="Number of nfs servers running"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.server.active,statuspath=.status.replicas,selectorpath=.status.selector
 type CephNFS struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 	Spec              NFSGaneshaSpec `json:"spec"`
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +optional
-	Status *Status `json:"status,omitempty"`
+	Status *NFSStatus `json:"status,omitempty"`
+}
+
+// NFSStatus represents the status of the nfs servers
+type NFSStatus struct {
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Replicas is the number of nfs servers running, reported by the scale subresource
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the nfs pods, reported by the scale subresource
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // CephNFSList represents a list Ceph NFSes
@@ -1928,6 +3183,28 @@ type NetworkSpec struct {
 	// DualStack determines whether Ceph daemons should listen on both IPv4 and IPv6
 	// +optional
 	DualStack bool `json:"dualStack,omitempty"`
+
+	// NetworkPolicies restrict the traffic to the ceph daemons to the expected clients
+	// +optional
+	NetworkPolicies *NetworkPoliciesSpec `json:"networkPolicies,omitempty"`
+}
+
+// NetworkPoliciesSpec configures the NetworkPolicies created for the ceph daemons, so that the
+// namespace of the cluster can deny the ingress traffic by default
+type NetworkPoliciesSpec struct {
+	// Enabled creates a NetworkPolicy per daemon type allowing only the traffic expected by the daemons
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// ObjectStoreNamespaces are the namespaces allowed to connect to the object stores, in addition
+	// to the namespaces of the cluster and of the operator
+	// +optional
+	ObjectStoreNamespaces []string `json:"objectStoreNamespaces,omitempty"`
+
+	// NodeCIDRs are the CIDRs of the nodes allowed to connect to the daemons, needed for the clients
+	// running on the host network such as the csi plugins and the kernel clients
+	// +optional
+	NodeCIDRs []string `json:"nodeCIDRs,omitempty"`
 }
 
 // DisruptionManagementSpec configures management of daemon disruptions
@@ -1963,6 +3240,9 @@ type DisruptionManagementSpec struct {
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // CephClient represents a Ceph Client
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephClient struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -1988,10 +3268,75 @@ type CephClientList struct {
 type ClientSpec struct {
 	// +optional
 	Name string `json:"name,omitempty"`
+	// Caps are the raw caps of the client, they override the caps of the template for the same daemon
+	// types. Required unless a caps template is set.
 	// +kubebuilder:pruning:PreserveUnknownFields
-	Caps map[string]string `json:"caps"`
+	// +optional
+	Caps map[string]string `json:"caps,omitempty"`
+	// CapsTemplate generates the caps of the client for a common use case
+	// +optional
+	// +nullable
+	CapsTemplate *ClientCapsTemplateSpec `json:"capsTemplate,omitempty"`
+	// SecretFormats are the formats of the key published in the secret of the client, the key under the
+	// name of the client by default
+	// +optional
+	SecretFormats []ClientSecretFormat `json:"secretFormats,omitempty"`
+	// KeyRotation rotates the key of the client, the secret of the client is updated with the new key
+	// +optional
+	// +nullable
+	KeyRotation *KeyRotationPolicySpec `json:"keyRotation,omitempty"`
+}
+
+// ClientSecretFormat is a format of the key of a client in its secret
+// +kubebuilder:validation:Enum=key;keyring;csi;mount
+type ClientSecretFormat string
+
+const (
+	// ClientSecretFormatKey publishes the key under the name of the client
+	ClientSecretFormatKey ClientSecretFormat = "key"
+	// ClientSecretFormatKeyring publishes a keyring file of the client under "keyring"
+	ClientSecretFormatKeyring ClientSecretFormat = "keyring"
+	// ClientSecretFormatCSI publishes the client ID and key under "userID" and "userKey", as expected
+	// by the Ceph CSI driver
+	ClientSecretFormatCSI ClientSecretFormat = "csi"
+	// ClientSecretFormatMount publishes the mount.ceph options of the client under "mountOptions"
+	ClientSecretFormatMount ClientSecretFormat = "mount"
+)
+
+// ClientCapsTemplateSpec generates the caps of a client from a template
+type ClientCapsTemplateSpec struct {
+	// Name is the name of the template: rbd-user-on-pool grants the rbd access to the images of a pool
+	// or of a rados namespace of the pool, cephfs-subvolume-scoped grants the access to a subvolume
+	// group or a subvolume of a filesystem
+	// +kubebuilder:validation:Enum=rbd-user-on-pool;cephfs-subvolume-scoped
+	Name ClientCapsTemplateName `json:"name"`
+	// Pool is the pool of the rbd-user-on-pool template
+	// +optional
+	Pool string `json:"pool,omitempty"`
+	// RadosNamespace restricts the rbd-user-on-pool template to a rados namespace of the pool
+	// +optional
+	RadosNamespace string `json:"radosNamespace,omitempty"`
+	// Filesystem is the filesystem of the cephfs-subvolume-scoped template
+	// +optional
+	Filesystem string `json:"filesystem,omitempty"`
+	// SubVolumeGroup is the subvolume group of the cephfs-subvolume-scoped template, csi by default
+	// +optional
+	SubVolumeGroup string `json:"subVolumeGroup,omitempty"`
+	// SubVolume restricts the cephfs-subvolume-scoped template to a subvolume of the group
+	// +optional
+	SubVolume string `json:"subVolume,omitempty"`
 }
 
+// ClientCapsTemplateName is the name of a caps template of a client
+type ClientCapsTemplateName string
+
+const (
+	// ClientCapsTemplateRBDUserOnPool grants the rbd access to the images of a pool
+	ClientCapsTemplateRBDUserOnPool ClientCapsTemplateName = "rbd-user-on-pool"
+	// ClientCapsTemplateCephFSSubVolumeScoped grants the access to a subvolume group or a subvolume
+	ClientCapsTemplateCephFSSubVolumeScoped ClientCapsTemplateName = "cephfs-subvolume-scoped"
+)
+
 // CephClientStatus represents the Status of Ceph Client
 type CephClientStatus struct {
 	// +optional
@@ -1999,6 +3344,15 @@ type CephClientStatus struct {
 	// +optional
 	// +nullable
 	Info map[string]string `json:"info,omitempty"`
+	// KeyRotation is the status of the key of the client
+	// +optional
+	KeyRotation *KeyRotationStatus `json:"keyRotation,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // CleanupPolicySpec represents a Ceph Cluster cleanup policy
@@ -2046,14 +3400,50 @@ type SanitizeDisksSpec struct {
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // CephRBDMirror represents a Ceph RBD Mirror
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Count",type=integer,JSONPath=`.spec.count`,description="Number of desired rbd-mirror daemons"
+// +kubebuilder:printcolumn:name="Running",type=integer,JSONPath=`.status.replicas`,description="Number of rbd-mirror daemons running"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.count,statuspath=.status.replicas,selectorpath=.status.selector
 type CephRBDMirror struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 	Spec              RBDMirroringSpec `json:"spec"`
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +optional
-	Status *Status `json:"status,omitempty"`
+	Status *RBDMirrorStatus `json:"status,omitempty"`
+}
+
+// RBDMirrorStatus represents the status of the rbd-mirror daemons
+type RBDMirrorStatus struct {
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Count is the number of rbd-mirror daemons chosen by the autoscaling
+	// +optional
+	Count int `json:"count,omitempty"`
+	// MirroredPools is the number of mirrored pools the count was computed from
+	// +optional
+	MirroredPools int `json:"mirroredPools,omitempty"`
+	// MirroredImages is the number of mirrored images the count was computed from
+	// +optional
+	MirroredImages int `json:"mirroredImages,omitempty"`
+	// LastChecked is the last time the number of mirrored images was checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Replicas is the number of rbd-mirror daemons running, reported by the scale subresource
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the rbd-mirror pods, reported by the scale subresource
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // CephRBDMirrorList represents a list Ceph RBD Mirrors
@@ -2066,10 +3456,23 @@ type CephRBDMirrorList struct {
 
 // RBDMirroringSpec represents the specification of an RBD mirror daemon
 type RBDMirroringSpec struct {
-	// Count represents the number of rbd mirror instance to run
+	// Count represents the number of rbd mirror instance to run, the minimum number of instances
+	// when the autoscaling is enabled
 	// +kubebuilder:validation:Minimum=1
 	Count int `json:"count"`
 
+	// Autoscale scales the number of rbd mirror instances with the number of mirrored images and
+	// pools
+	// +optional
+	Autoscale *RBDMirrorAutoscaleSpec `json:"autoscale,omitempty"`
+
+	// Pools are the names of the CephBlockPools replicated by the rbd mirror instances, each one
+	// optionally restricted to a RADOS namespace with "<pool>/<namespace>". The pools are assigned
+	// to the instances with the caps of their cephx user, so the instances of other CephRBDMirrors
+	// can replicate the other pools. All the mirrored pools are replicated when empty.
+	// +optional
+	Pools []string `json:"pools,omitempty"`
+
 	// Peers represents the peers spec
 	// +nullable
 	// +optional
@@ -2104,6 +3507,30 @@ type RBDMirroringSpec struct {
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
+// RBDMirrorAutoscaleSpec represents the autoscaling of the rbd mirror instances. The number of
+// instances is the number needed for the mirrored images or for the mirrored pools, whichever is
+// higher, between the count and the max count.
+type RBDMirrorAutoscaleSpec struct {
+	// MaxCount is the maximum number of rbd mirror instances
+	// +kubebuilder:validation:Minimum=1
+	MaxCount int `json:"maxCount"`
+
+	// ImagesPerDaemon is the number of mirrored images an rbd mirror instance replays, 100 by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ImagesPerDaemon int `json:"imagesPerDaemon,omitempty"`
+
+	// PoolsPerDaemon is the number of mirrored pools an rbd mirror instance handles, not used to
+	// scale by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PoolsPerDaemon int `json:"poolsPerDaemon,omitempty"`
+
+	// Interval is the interval of the check of the number of mirrored images, 5m by default
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
 // MirroringPeerSpec represents the specification of a mirror peer
 type MirroringPeerSpec struct {
 	// SecretNames represents the Kubernetes Secret names to add rbd-mirror or cephfs-mirror peers
@@ -2116,6 +3543,9 @@ type MirroringPeerSpec struct {
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // CephFilesystemMirror is the Ceph Filesystem Mirror object definition
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephFilesystemMirror struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -2187,6 +3617,21 @@ type StorageScopeSpec struct {
 	// +nullable
 	// +optional
 	StorageClassDeviceSets []StorageClassDeviceSet `json:"storageClassDeviceSets,omitempty"`
+	// Migration migrates the OSDs provisioned before their device set was encrypted, one at a time
+	// +optional
+	Migration MigrationSpec `json:"migration,omitempty"`
+}
+
+// MigrationSpec represents the migration of the OSDs to the encryption of their device set
+type MigrationSpec struct {
+	// Confirmation confirms the migration of the OSDs. Each OSD is drained, removed with its PVCs and
+	// provisioned again on new PVCs, the next OSD being migrated once the placement groups are clean.
+	// +kubebuilder:validation:Pattern=`^$|^yes-really-migrate-osds$`
+	// +optional
+	Confirmation string `json:"confirmation,omitempty"`
+	// Paused stops the migration before the next OSD, the OSD being migrated is still completed
+	// +optional
+	Paused bool `json:"paused,omitempty"`
 }
 
 // Node is a storage nodes
@@ -2326,6 +3771,10 @@ type StorageClassDeviceSet struct {
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // CephFilesystemSubVolumeGroup represents a Ceph Filesystem SubVolumeGroup
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Filesystem",type=string,JSONPath=`.spec.filesystemName`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
 // +kubebuilder:subresource:status
 type CephFilesystemSubVolumeGroup struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -2349,11 +3798,104 @@ type CephFilesystemSubVolumeGroupList struct {
 
 // CephFilesystemSubVolumeGroupSpec represents the specification of a Ceph Filesystem SubVolumeGroup
 type CephFilesystemSubVolumeGroupSpec struct {
+	// Name is the name of the subvolume group in the filesystem, the name of the CR when not set. It
+	// cannot be changed.
+	// +optional
+	Name string `json:"name,omitempty"`
 	// FilesystemName is the name of Ceph Filesystem SubVolumeGroup volume name. Typically it's the name of
 	// the CephFilesystem CR. If not coming from the CephFilesystem CR, it can be retrieved from the
 	// list of Ceph Filesystem volumes with `ceph fs volume ls`. To learn more about Ceph Filesystem
 	// abstractions see https://docs.ceph.com/en/latest/cephfs/fs-volumes/#fs-volumes-and-subvolumes
 	FilesystemName string `json:"filesystemName"`
+	// Quota is the maximum size of the subvolume group, the total size of its subvolumes cannot exceed
+	// it. The subvolume group has no quota when not set.
+	// See https://pkg.go.dev/k8s.io/apimachinery/pkg/api/resource#Quantity for more info.
+	// +optional
+	// +nullable
+	Quota *resource.Quantity `json:"quota,omitempty"`
+	// DataPoolName is the data pool of the filesystem storing the data of the subvolumes of the
+	// group, the name of the pool in Ceph or in the data pools of the CephFilesystem. The default
+	// data pool of the filesystem is used when not set. It cannot be changed.
+	// +optional
+	DataPoolName string `json:"dataPoolName,omitempty"`
+	// Pinning is the policy pinning the subvolumes of the group to the ranks of the active MDS
+	// daemons. The subvolume group is not pinned when not set.
+	// +optional
+	// +nullable
+	Pinning *CephFilesystemSubVolumeGroupPinning `json:"pinning,omitempty"`
+	// DeletionPolicy is the policy applied to the subvolume group when the CR is deleted, Delete by
+	// default
+	// +optional
+	DeletionPolicy SubVolumeGroupDeletionPolicy `json:"deletionPolicy,omitempty"`
+	// SnapshotRetention is the policy pruning the old snapshots of the subvolumes of the group. The
+	// snapshots are not pruned when not set.
+	// +optional
+	// +nullable
+	SnapshotRetention *CephFilesystemSubVolumeGroupSnapshotRetention `json:"snapshotRetention,omitempty"`
+}
+
+// SubVolumeGroupDeletionPolicy is the policy applied to a subvolume group when its CR is deleted
+// +kubebuilder:validation:Enum=Retain;Delete;Force
+type SubVolumeGroupDeletionPolicy string
+
+const (
+	// SubVolumeGroupDeletionPolicyRetain keeps the subvolume group and its subvolumes
+	SubVolumeGroupDeletionPolicyRetain SubVolumeGroupDeletionPolicy = "Retain"
+	// SubVolumeGroupDeletionPolicyDelete deletes the subvolume group, the deletion waits until the
+	// subvolume group has no subvolumes
+	SubVolumeGroupDeletionPolicyDelete SubVolumeGroupDeletionPolicy = "Delete"
+	// SubVolumeGroupDeletionPolicyForce deletes the subvolumes of the group and their snapshots
+	// before the subvolume group
+	SubVolumeGroupDeletionPolicyForce SubVolumeGroupDeletionPolicy = "Force"
+)
+
+// CSISubVolumeGroupName is the name of the subvolume group of the volumes provisioned by the CephFS
+// CSI driver when the CSI configuration does not set one
+const CSISubVolumeGroupName = "csi"
+
+// CephFilesystemSubVolumeGroupPinning is the policy pinning the subvolume group to the MDS ranks,
+// only one of the policies can be set.
+// See https://docs.ceph.com/en/latest/cephfs/multimds/#manually-pinning-directory-trees-to-a-particular-rank
+type CephFilesystemSubVolumeGroupPinning struct {
+	// Export pins the subvolume group to the given MDS rank, -1 removes the pin
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=256
+	// +optional
+	// +nullable
+	Export *int `json:"export,omitempty"`
+	// Distributed pins the subvolumes of the group across the MDS ranks when set to 1, 0 removes the pin
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	// +optional
+	// +nullable
+	Distributed *int `json:"distributed,omitempty"`
+	// Random pins each directory of the subvolume group to a random MDS rank with the given
+	// probability, 0 removes the pin
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	// +optional
+	// +nullable
+	Random *float64 `json:"random,omitempty"`
+}
+
+// CephFilesystemSubVolumeGroupSnapshotRetention is the policy pruning the snapshots of the
+// subvolumes of a subvolume group. A snapshot is pruned when it is beyond the count or older than
+// the duration.
+type CephFilesystemSubVolumeGroupSnapshotRetention struct {
+	// Count is the number of the newest snapshots kept per subvolume
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	// +nullable
+	Count *int `json:"count,omitempty"`
+	// Duration is how long the snapshots are kept, e.g. "168h"
+	// +optional
+	// +nullable
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// PruneOnExternalCluster allows pruning the snapshots when the CephCluster is external. The
+	// snapshots of an external cluster are not pruned by default since they may be managed outside
+	// of Rook.
+	// +optional
+	PruneOnExternalCluster bool `json:"pruneOnExternalCluster,omitempty"`
 }
 
 // CephFilesystemSubVolumeGroupStatus represents the Status of Ceph Filesystem SubVolumeGroup
@@ -2363,4 +3905,1409 @@ type CephFilesystemSubVolumeGroupStatus struct {
 	// +optional
 	// +nullable
 	Info map[string]string `json:"info,omitempty"`
+	// Name is the name of the subvolume group in the filesystem created or adopted by the CR. The
+	// reconcile fails when the name in the spec no longer matches it.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Pinning is the pinning policy applied to the subvolume group, its pin is removed when the policy
+	// is removed or changed in the spec
+	// +optional
+	// +nullable
+	Pinning *CephFilesystemSubVolumeGroupPinning `json:"pinning,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephFilesystemSubVolume represents a Ceph Filesystem SubVolume
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Filesystem",type=string,JSONPath=`.spec.filesystemName`
+// +kubebuilder:printcolumn:name="Group",type=string,JSONPath=`.spec.subVolumeGroupName`
+// +kubebuilder:printcolumn:name="Size",type=string,JSONPath=`.spec.size`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Path",type=string,JSONPath=`.status.path`,priority=1
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephFilesystemSubVolume struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the specification of a Ceph Filesystem SubVolume
+	Spec CephFilesystemSubVolumeSpec `json:"spec"`
+	// Status represents the status of a Ceph Filesystem SubVolume
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephFilesystemSubVolumeStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephFilesystemSubVolumeList represents a list of Ceph Filesystem SubVolumes
+type CephFilesystemSubVolumeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephFilesystemSubVolume `json:"items"`
+}
+
+// CephFilesystemSubVolumeSpec represents the specification of a Ceph Filesystem SubVolume
+type CephFilesystemSubVolumeSpec struct {
+	// Name is the name of the subvolume in its subvolume group, the name of the CR when not set. It
+	// cannot be changed.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// FilesystemName is the name of the filesystem of the subvolume, typically the name of the
+	// CephFilesystem CR. It cannot be changed.
+	FilesystemName string `json:"filesystemName"`
+	// SubVolumeGroupName is the name of the subvolume group of the subvolume in the filesystem, the
+	// default subvolume group of the filesystem when not set. It cannot be changed.
+	// +optional
+	SubVolumeGroupName string `json:"subVolumeGroupName,omitempty"`
+	// Size is the quota of the subvolume. The subvolume has no quota when not set.
+	// See https://pkg.go.dev/k8s.io/apimachinery/pkg/api/resource#Quantity for more info.
+	// +optional
+	// +nullable
+	Size *resource.Quantity `json:"size,omitempty"`
+	// UID is the user ID owning the root directory of the subvolume
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	// +nullable
+	UID *int64 `json:"uid,omitempty"`
+	// GID is the group ID owning the root directory of the subvolume
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	// +nullable
+	GID *int64 `json:"gid,omitempty"`
+	// Mode is the octal permissions of the root directory of the subvolume, e.g. "755"
+	// +kubebuilder:validation:Pattern=`^[0-7]{3,4}$`
+	// +optional
+	Mode string `json:"mode,omitempty"`
+	// NamespaceIsolated stores the data of the subvolume in its own RADOS namespace of the data pool,
+	// so a client of the subvolume can be restricted to it. It cannot be changed.
+	// +optional
+	NamespaceIsolated bool `json:"namespaceIsolated,omitempty"`
+}
+
+// CephFilesystemSubVolumeStatus represents the Status of a Ceph Filesystem SubVolume
+type CephFilesystemSubVolumeStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// Path is the path of the subvolume in the filesystem, to mount it or to create a static PV
+	// +optional
+	Path string `json:"path,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBlockPoolRadosNamespace represents a RADOS namespace of a CephBlockPool, to isolate the RBD
+// images of a tenant and to mirror them independently of the other namespaces of the pool
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="BlockPool",type=string,JSONPath=`.spec.blockPoolName`
+// +kubebuilder:printcolumn:name="Mirroring",type=string,JSONPath=`.status.mirroringInfo.mode`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephBlockPoolRadosNamespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the specification of a Ceph BlockPool Rados Namespace
+	Spec CephBlockPoolRadosNamespaceSpec `json:"spec"`
+	// Status represents the status of a CephBlockPool Rados Namespace
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephBlockPoolRadosNamespaceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBlockPoolRadosNamespaceList represents a list of Ceph BlockPool Rados Namespace
+type CephBlockPoolRadosNamespaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBlockPoolRadosNamespace `json:"items"`
+}
+
+// CephBlockPoolRadosNamespaceSpec represents the specification of a CephBlockPool Rados Namespace
+type CephBlockPoolRadosNamespaceSpec struct {
+	// BlockPoolName is the name of the CephBlockPool CR of the namespace, it cannot be changed once
+	// the namespace is created
+	BlockPoolName string `json:"blockPoolName"`
+
+	// Mirroring configures the mirroring of the images of the namespace, independently of the
+	// mirroring of the other namespaces of the pool
+	// +optional
+	Mirroring *RadosNamespaceMirroring `json:"mirroring,omitempty"`
+}
+
+// RadosNamespaceMirroringMode is the mirroring mode of a RADOS namespace
+// +kubebuilder:validation:Enum=pool;image
+type RadosNamespaceMirroringMode string
+
+const (
+	// RadosNamespaceMirroringModePool mirrors all the images of the namespace with journaling
+	RadosNamespaceMirroringModePool RadosNamespaceMirroringMode = "pool"
+	// RadosNamespaceMirroringModeImage mirrors the images of the namespace that are enabled explicitly
+	RadosNamespaceMirroringModeImage RadosNamespaceMirroringMode = "image"
+)
+
+// RadosNamespaceMirroring represents the mirroring settings of a RADOS namespace. The mirroring
+// of the namespace uses the peers of the mirroring of the pool.
+type RadosNamespaceMirroring struct {
+	// RemoteNamespace is the name of the RADOS namespace the images are mirrored to on the peer
+	// cluster. The images are mirrored to a namespace of the same name when empty.
+	// +optional
+	RemoteNamespace *string `json:"remoteNamespace,omitempty"`
+
+	// Mode is the mirroring mode of the namespace: pool or image
+	Mode RadosNamespaceMirroringMode `json:"mode"`
+
+	// SnapshotSchedules is the scheduling of snapshot for the mirrored images of the namespace
+	// +optional
+	SnapshotSchedules []SnapshotScheduleSpec `json:"snapshotSchedules,omitempty"`
+}
+
+// CephBlockPoolRadosNamespaceStatus represents the Status of Ceph BlockPool Rados Namespace
+type CephBlockPoolRadosNamespaceStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// +optional
+	// +nullable
+	Info map[string]string `json:"info,omitempty"`
+	// MirroringInfo is the mirroring mode and remote namespace of the namespace
+	// +optional
+	MirroringInfo *RadosNamespaceMirroringInfoSpec `json:"mirroringInfo,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// RadosNamespaceMirroringInfoSpec is the mirroring info of a RADOS namespace
+type RadosNamespaceMirroringInfoSpec struct {
+	// Mode is the mirroring mode of the namespace, disabled when the namespace is not mirrored
+	// +optional
+	Mode string `json:"mode,omitempty"`
+	// RemoteNamespace is the namespace the images are mirrored to on the peer cluster
+	// +optional
+	RemoteNamespace string `json:"remoteNamespace,omitempty"`
+	// LastChecked is the last time the mirroring of the namespace was configured
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Details contains potential status errors
+	// +optional
+	Details string `json:"details,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBlockPoolTopology represents a set of replicated pools, one per failure domain, and the
+// StorageClass needed for topology aware provisioning of RBD volumes from these pools
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="StorageClass",type=string,JSONPath=`.status.storageClassName`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephBlockPoolTopology struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the specification of the per failure domain pools and their StorageClass
+	Spec CephBlockPoolTopologySpec `json:"spec"`
+	// Status represents the status of the per failure domain pools and their StorageClass
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephBlockPoolTopologyStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBlockPoolTopologyList represents a list of CephBlockPoolTopology
+type CephBlockPoolTopologyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBlockPoolTopology `json:"items"`
+}
+
+// CephBlockPoolTopologySpec represents the specification of a CephBlockPoolTopology
+type CephBlockPoolTopologySpec struct {
+	// DomainLabel is the node label whose value identifies the failure domain of a node. The ceph-csi
+	// RBD plugin is configured to report this label as part of the node topology.
+	// +kubebuilder:default="topology.kubernetes.io/zone"
+	// +optional
+	DomainLabel string `json:"domainLabel,omitempty"`
+
+	// FailureDomains is the list of failure domains, one pool is created for each of them. Each entry
+	// must be the value of the DomainLabel on the nodes of that failure domain and the name of the
+	// CRUSH bucket the OSDs of that failure domain belong to.
+	// +kubebuilder:validation:MinItems=1
+	FailureDomains []string `json:"failureDomains"`
+
+	// FailureDomain is the failure domain used to spread the replicas within each pool
+	// +optional
+	FailureDomain string `json:"failureDomain,omitempty"`
+
+	// DeviceClass is the device class the OSDs of each pool should belong to
+	// +optional
+	DeviceClass string `json:"deviceClass,omitempty"`
+
+	// Replicated is the replication settings of each pool
+	Replicated ReplicatedSpec `json:"replicated"`
+
+	// StorageClass is the specification of the StorageClass consuming the pools
+	// +optional
+	StorageClass TopologyStorageClassSpec `json:"storageClass,omitempty"`
+}
+
+// TopologyStorageClassSpec represents the StorageClass created for a CephBlockPoolTopology
+type TopologyStorageClassSpec struct {
+	// Name of the StorageClass, defaults to the name of the CephBlockPoolTopology
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// ReclaimPolicy of the volumes provisioned from the StorageClass
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +optional
+	ReclaimPolicy v1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+
+	// AllowVolumeExpansion allows the volumes provisioned from the StorageClass to be expanded
+	// +optional
+	AllowVolumeExpansion bool `json:"allowVolumeExpansion,omitempty"`
+
+	// Parameters are additional ceph-csi parameters of the StorageClass, e.g. imageFeatures
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	// +nullable
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// CephBlockPoolTopologyStatus represents the status of a CephBlockPoolTopology
+type CephBlockPoolTopologyStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// Pools are the names of the CephBlockPools created for each failure domain
+	// +optional
+	// +nullable
+	Pools []string `json:"pools,omitempty"`
+	// StorageClassName is the name of the StorageClass consuming the pools
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// ObservedGeneration is the latest generation of the spec reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephCSIDriver represents the settings of the ceph-csi drivers deployed by the operator. It must be
+// created in the operator namespace with the name "ceph-csi-driver", and its settings take precedence
+// over the CSI settings of the operator ConfigMap and environment variables.
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephCSIDriver struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the specification of the ceph-csi drivers
+	Spec CephCSIDriverSpec `json:"spec"`
+	// Status represents the status of the ceph-csi drivers
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephCSIDriverStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephCSIDriverList represents a list of CephCSIDriver
+type CephCSIDriverList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephCSIDriver `json:"items"`
+}
+
+// CephCSIDriverSpec represents the specification of the ceph-csi drivers. The settings that are not
+// set fall back to the operator ConfigMap and environment variables.
+type CephCSIDriverSpec struct {
+	// EnableRBD deploys the RBD driver
+	// +optional
+	EnableRBD *bool `json:"enableRBD,omitempty"`
+
+	// EnableCephFS deploys the CephFS driver
+	// +optional
+	EnableCephFS *bool `json:"enableCephFS,omitempty"`
+
+	// Images are the images of the ceph-csi driver and its sidecars
+	// +optional
+	Images CSIDriverImagesSpec `json:"images,omitempty"`
+
+	// KubeletDirPath is the kubelet directory of the nodes
+	// +kubebuilder:validation:Pattern=`^/.*`
+	// +optional
+	KubeletDirPath string `json:"kubeletDirPath,omitempty"`
+
+	// WindowsKubeletDirPath is the kubelet directory of the windows nodes
+	// +optional
+	WindowsKubeletDirPath string `json:"windowsKubeletDirPath,omitempty"`
+
+	// LogLevel is the log level of the csi containers, from 0 to 5
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	// +optional
+	LogLevel *int `json:"logLevel,omitempty"`
+
+	// LogRotation writes the logs of the csi containers to files on the nodes, which are rotated by a
+	// log collector sidecar
+	// +optional
+	LogRotation CSILogRotationSpec `json:"logRotation,omitempty"`
+
+	// ProvisionerReplicas is the number of replicas of the provisioner deployments
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ProvisionerReplicas *int32 `json:"provisionerReplicas,omitempty"`
+
+	// FeatureGates enable the optional features of the drivers
+	// +optional
+	FeatureGates CSIFeatureGatesSpec `json:"featureGates,omitempty"`
+
+	// Provisioner are the settings of the provisioner pods
+	// +optional
+	Provisioner CSIComponentSpec `json:"provisioner,omitempty"`
+
+	// Plugin are the settings of the node plugin pods
+	// +optional
+	Plugin CSIComponentSpec `json:"plugin,omitempty"`
+
+	// ImagePolicy pins the images of the drivers to their digests and verifies their signatures before
+	// the drivers are updated. The secrets are read from the operator namespace.
+	// +optional
+	// +nullable
+	ImagePolicy *ImagePolicySpec `json:"imagePolicy,omitempty"`
+}
+
+// CSIDriverImagesSpec represents the images of the ceph-csi driver and its sidecars
+type CSIDriverImagesSpec struct {
+	// +optional
+	CephCSI string `json:"cephcsi,omitempty"`
+	// +optional
+	Registrar string `json:"registrar,omitempty"`
+	// +optional
+	Provisioner string `json:"provisioner,omitempty"`
+	// +optional
+	Attacher string `json:"attacher,omitempty"`
+	// +optional
+	Snapshotter string `json:"snapshotter,omitempty"`
+	// +optional
+	Resizer string `json:"resizer,omitempty"`
+	// +optional
+	VolumeReplication string `json:"volumeReplication,omitempty"`
+	// +optional
+	CSIAddons string `json:"csiAddons,omitempty"`
+	// WindowsCephCSI is the image of the rbd plugin of the windows nodes
+	// +optional
+	WindowsCephCSI string `json:"windowsCephcsi,omitempty"`
+	// Architectures are the images of the plugins of the nodes of an architecture, when the images
+	// above are not available for this architecture
+	// +optional
+	Architectures []CSIArchImagesSpec `json:"architectures,omitempty"`
+}
+
+// CSIArchImagesSpec represents the images of the ceph-csi plugins of the nodes of an architecture
+type CSIArchImagesSpec struct {
+	// Arch is the architecture of the nodes, as in their kubernetes.io/arch label
+	// +kubebuilder:validation:Enum=amd64;arm64;ppc64le;s390x
+	Arch string `json:"arch"`
+	// +optional
+	CephCSI string `json:"cephcsi,omitempty"`
+	// +optional
+	Registrar string `json:"registrar,omitempty"`
+}
+
+// CSIFeatureGatesSpec represents the optional features of the ceph-csi drivers
+type CSIFeatureGatesSpec struct {
+	// GRPCMetrics exposes the grpc metrics of the drivers
+	// +optional
+	GRPCMetrics *bool `json:"grpcMetrics,omitempty"`
+	// HostNetwork runs the drivers on the host network
+	// +optional
+	HostNetwork *bool `json:"hostNetwork,omitempty"`
+	// OMAPGenerator deploys the omap generator sidecar in the RBD provisioner
+	// +optional
+	OMAPGenerator *bool `json:"omapGenerator,omitempty"`
+	// RBDSnapshotter deploys the snapshotter sidecar in the RBD provisioner
+	// +optional
+	RBDSnapshotter *bool `json:"rbdSnapshotter,omitempty"`
+	// CephFSSnapshotter deploys the snapshotter sidecar in the CephFS provisioner
+	// +optional
+	CephFSSnapshotter *bool `json:"cephfsSnapshotter,omitempty"`
+	// VolumeGroupSnapshot enables the volume group snapshots in the snapshotter sidecars
+	// +optional
+	VolumeGroupSnapshot *bool `json:"volumeGroupSnapshot,omitempty"`
+	// VolumeReplication deploys the volume replication sidecar in the RBD provisioner
+	// +optional
+	VolumeReplication *bool `json:"volumeReplication,omitempty"`
+	// CSIAddons deploys the csi-addons sidecar in the RBD provisioner and plugin
+	// +optional
+	CSIAddons *bool `json:"csiAddons,omitempty"`
+	// PluginSELinuxHostMount mounts the /etc/selinux directory of the host in the plugins
+	// +optional
+	PluginSELinuxHostMount *bool `json:"pluginSELinuxHostMount,omitempty"`
+	// ForceCephFSKernelClient forces the kernel client for CephFS volumes
+	// +optional
+	ForceCephFSKernelClient *bool `json:"forceCephFSKernelClient,omitempty"`
+	// RBDWindowsPlugin deploys the rbd plugin on the windows nodes, it requires csi-proxy on the nodes
+	// +optional
+	RBDWindowsPlugin *bool `json:"rbdWindowsPlugin,omitempty"`
+}
+
+// CSIComponentSpec represents the settings of the provisioner or plugin pods of the drivers
+type CSIComponentSpec struct {
+	// Placement of the pods, it replaces the tolerations and node affinity of the operator settings
+	// +optional
+	// +nullable
+	Placement *Placement `json:"placement,omitempty"`
+
+	// Resources of the containers of the pods, by container name
+	// +optional
+	Resources []CSIContainerResources `json:"resources,omitempty"`
+
+	// PriorityClassName of the pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// UpdateStrategy of the plugin daemonsets, ignored for the provisioners
+	// +kubebuilder:validation:Enum=RollingUpdate;OnDelete
+	// +optional
+	UpdateStrategy string `json:"updateStrategy,omitempty"`
+
+	// MaxUnavailablePerZone lets the operator restart the plugin pods zone after zone, at most this
+	// number of pods of a zone at a time, waiting for each restarted plugin to re-register its driver.
+	// Ignored for the provisioners.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnavailablePerZone *int `json:"maxUnavailablePerZone,omitempty"`
+
+	// LogLevels of the containers of the pods, by container name. They override the log level of the drivers.
+	// +optional
+	LogLevels []CSIContainerLogLevel `json:"logLevels,omitempty"`
+}
+
+// CSIContainerResources represents the resources of a container of the csi pods
+type CSIContainerResources struct {
+	// Name of the container
+	Name string `json:"name"`
+	// Resource requirements of the container
+	Resource v1.ResourceRequirements `json:"resource"`
+}
+
+// CSIContainerLogLevel represents the log level of a container of the csi pods
+type CSIContainerLogLevel struct {
+	// Name of the container
+	Name string `json:"name"`
+	// Level is the log level of the container, from 0 to 5
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	Level int `json:"level"`
+}
+
+// CSILogRotationSpec represents the rotation of the log files of the csi containers
+type CSILogRotationSpec struct {
+	// Enabled writes the logs of the csi containers to files rotated by a log collector sidecar
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// MaxSize is the size of a log file rotated by the log collector, in bytes with an optional k, M or G suffix
+	// +kubebuilder:validation:Pattern=`^[0-9]+[kMG]?$`
+	// +optional
+	MaxSize string `json:"maxSize,omitempty"`
+	// MaxAge is the number of days the rotated log files are kept
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxAge *int `json:"maxAge,omitempty"`
+}
+
+// CephCSIDriverStatus represents the status of the ceph-csi drivers
+type CephCSIDriverStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// Message explains the phase of the drivers
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the latest generation of the settings applied to the drivers
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Images are the digests the images of the drivers are pinned to, set when the image policy is enabled
+	// +optional
+	Images []ImageStatus `json:"images,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephStaticVolume represents an existing RBD image or CephFS subvolume exposed as a static
+// PersistentVolume. The operator generates the PersistentVolume and PersistentVolumeClaim manifests
+// of the volume and optionally creates them.
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="PersistentVolume",type=string,JSONPath=`.status.persistentVolumeName`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephStaticVolume struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the specification of the existing volume and of its PersistentVolume
+	Spec CephStaticVolumeSpec `json:"spec"`
+	// Status represents the status of the PersistentVolume
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephStaticVolumeStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephStaticVolumeList represents a list of CephStaticVolume
+type CephStaticVolumeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephStaticVolume `json:"items"`
+}
+
+// CephStaticVolumeSpec represents the specification of a CephStaticVolume. Exactly one of RBD and
+// CephFS must be set.
+type CephStaticVolumeSpec struct {
+	// RBD is the existing RBD image of the volume
+	// +optional
+	RBD *StaticRBDVolumeSource `json:"rbd,omitempty"`
+
+	// CephFS is the existing CephFS subvolume of the volume
+	// +optional
+	CephFS *StaticCephFSVolumeSource `json:"cephfs,omitempty"`
+
+	// PersistentVolumeName is the name of the PersistentVolume, defaults to "<namespace>-<name>" of
+	// the CephStaticVolume
+	// +optional
+	PersistentVolumeName string `json:"persistentVolumeName,omitempty"`
+
+	// Capacity of the PersistentVolume, defaults to the size of the RBD image or to the quota of the
+	// CephFS subvolume. It must be set for a subvolume without quota.
+	// +optional
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
+
+	// AccessModes of the PersistentVolume, defaults to ReadWriteOnce for an RBD image and to
+	// ReadWriteMany for a CephFS subvolume
+	// +optional
+	AccessModes []v1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+
+	// VolumeMode of the PersistentVolume. The Block mode is only valid for an RBD image.
+	// +kubebuilder:validation:Enum=Filesystem;Block
+	// +optional
+	VolumeMode *v1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+
+	// FSType is the filesystem of an RBD image in the Filesystem mode, defaults to ext4
+	// +optional
+	FSType string `json:"fsType,omitempty"`
+
+	// Claim is the PersistentVolumeClaim bound to the PersistentVolume. No claim is generated when
+	// it is not set.
+	// +optional
+	Claim *StaticVolumeClaim `json:"claim,omitempty"`
+
+	// Create the PersistentVolume and PersistentVolumeClaim when they do not exist, otherwise only
+	// their manifests are generated in the status
+	// +optional
+	Create bool `json:"create,omitempty"`
+}
+
+// StaticRBDVolumeSource represents an existing RBD image
+type StaticRBDVolumeSource struct {
+	// Pool is the name of the CephBlockPool of the image
+	// +kubebuilder:validation:MinLength=1
+	Pool string `json:"pool"`
+	// Image is the name of the RBD image
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+}
+
+// StaticCephFSVolumeSource represents an existing CephFS subvolume
+type StaticCephFSVolumeSource struct {
+	// FilesystemName is the name of the CephFilesystem of the subvolume
+	// +kubebuilder:validation:MinLength=1
+	FilesystemName string `json:"filesystemName"`
+	// SubvolumeGroup is the name of the subvolume group of the subvolume
+	// +kubebuilder:default="csi"
+	// +optional
+	SubvolumeGroup string `json:"subvolumeGroup,omitempty"`
+	// Subvolume is the name of the subvolume
+	// +kubebuilder:validation:MinLength=1
+	Subvolume string `json:"subvolume"`
+}
+
+// StaticVolumeClaim represents the PersistentVolumeClaim bound to a static PersistentVolume
+type StaticVolumeClaim struct {
+	// Name of the PersistentVolumeClaim
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Namespace of the PersistentVolumeClaim
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+}
+
+// CephStaticVolumeStatus represents the status of a CephStaticVolume
+type CephStaticVolumeStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// Message is the reason of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// PersistentVolumeName is the name of the PersistentVolume
+	// +optional
+	PersistentVolumeName string `json:"persistentVolumeName,omitempty"`
+	// Manifests are the PersistentVolume and PersistentVolumeClaim manifests of the volume
+	// +optional
+	Manifests string `json:"manifests,omitempty"`
+	// ObservedGeneration is the latest generation of the spec the manifests were generated from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephRBDMirrorPeer represents a peer cluster of the RBD mirroring of a list of pools. The operator
+// imports the bootstrap peer token of the remote cluster in the pools and reports the health of the
+// mirroring with the peer.
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephRBDMirrorPeer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the specification of the peer
+	Spec RBDMirrorPeerSpec `json:"spec"`
+	// Status represents the status of the peer in each pool
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephRBDMirrorPeerStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephRBDMirrorPeerList represents a list of CephRBDMirrorPeer
+type CephRBDMirrorPeerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephRBDMirrorPeer `json:"items"`
+}
+
+// RBDMirrorPeerSpec represents the specification of an RBD mirror peer
+type RBDMirrorPeerSpec struct {
+	// SecretName is the name of the Secret with the bootstrap peer token of the remote cluster in
+	// its "token" key
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// Pools are the names of the CephBlockPools the peer is added to. Mirroring must be enabled in
+	// the pools.
+	// +kubebuilder:validation:MinItems=1
+	Pools []string `json:"pools"`
+
+	// Direction of the mirroring with the peer, defaults to the direction of the token secret or to
+	// rx-tx
+	// +kubebuilder:validation:Enum=rx-only;rx-tx
+	// +optional
+	Direction string `json:"direction,omitempty"`
+
+	// StatusCheck represents the interval of the check of the mirroring health of the pools
+	// +optional
+	StatusCheck MirrorHealthCheckSpec `json:"statusCheck,omitempty"`
+}
+
+// CephRBDMirrorPeerStatus represents the status of an RBD mirror peer
+type CephRBDMirrorPeerStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// Message is the reason of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Pools is the status of the peer in each pool
+	// +optional
+	Pools []RBDMirrorPeerPoolStatus `json:"pools,omitempty"`
+	// LastChecked is the last time the mirroring health was checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+	// ObservedGeneration is the latest generation of the spec applied to the pools
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the peer, PeerConnected reports whether the remote cluster can be reached
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// RBDMirrorPeerPoolStatus represents the status of an RBD mirror peer in a pool
+type RBDMirrorPeerPoolStatus struct {
+	// Pool is the name of the pool
+	Pool string `json:"pool"`
+	// UUID is the uuid of the peer in the pool
+	// +optional
+	UUID string `json:"uuid,omitempty"`
+	// SiteName is the site name of the peer
+	// +optional
+	SiteName string `json:"siteName,omitempty"`
+	// Direction is the mirroring direction with the peer
+	// +optional
+	Direction string `json:"direction,omitempty"`
+	// Health is the mirroring health of the pool
+	// +optional
+	Health string `json:"health,omitempty"`
+	// DaemonHealth is the health of the rbd-mirror daemons
+	// +optional
+	DaemonHealth string `json:"daemonHealth,omitempty"`
+	// ImageHealth is the health of the mirrored images
+	// +optional
+	ImageHealth string `json:"imageHealth,omitempty"`
+	// Message is the reason of the failure to add the peer or to check the pool
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephRBDMirrorPeerToken represents a bootstrap peer token issued to a remote cluster for the RBD
+// mirroring of a list of pools. The token has its own cephx user whose caps only give access to the
+// pools, and expires when the remote cluster does not accept it in time.
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Expiration",type=string,JSONPath=`.status.expirationTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephRBDMirrorPeerToken struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the specification of the token
+	Spec RBDMirrorPeerTokenSpec `json:"spec"`
+	// Status represents the status of the token
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephRBDMirrorPeerTokenStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephRBDMirrorPeerTokenList represents a list of CephRBDMirrorPeerToken
+type CephRBDMirrorPeerTokenList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephRBDMirrorPeerToken `json:"items"`
+}
+
+// RBDMirrorPeerTokenSpec represents the specification of an RBD mirror peer token
+type RBDMirrorPeerTokenSpec struct {
+	// Pools are the names of the mirrored CephBlockPools the remote cluster can replicate with the
+	// token
+	// +kubebuilder:validation:MinItems=1
+	Pools []string `json:"pools"`
+
+	// Expiration is how long the remote cluster can accept the token after it is issued, 24h by
+	// default. The cephx user of a token not accepted in time is deleted.
+	// +optional
+	Expiration *metav1.Duration `json:"expiration,omitempty"`
+}
+
+// RBDMirrorPeerTokenPhase is the phase of an RBD mirror peer token
+type RBDMirrorPeerTokenPhase string
+
+const (
+	// RBDMirrorPeerTokenPhasePending is the phase of a token waiting to be accepted by the remote cluster
+	RBDMirrorPeerTokenPhasePending RBDMirrorPeerTokenPhase = "Pending"
+	// RBDMirrorPeerTokenPhaseAccepted is the phase of a token accepted by the remote cluster
+	RBDMirrorPeerTokenPhaseAccepted RBDMirrorPeerTokenPhase = "Accepted"
+	// RBDMirrorPeerTokenPhaseExpired is the phase of a token not accepted in time
+	RBDMirrorPeerTokenPhaseExpired RBDMirrorPeerTokenPhase = "Expired"
+	// RBDMirrorPeerTokenPhaseFailed is the phase of a token that could not be issued
+	RBDMirrorPeerTokenPhaseFailed RBDMirrorPeerTokenPhase = "Failed"
+)
+
+// CephRBDMirrorPeerTokenStatus represents the status of an RBD mirror peer token
+type CephRBDMirrorPeerTokenStatus struct {
+	// +optional
+	Phase RBDMirrorPeerTokenPhase `json:"phase,omitempty"`
+	// Message is the reason of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// SecretName is the name of the Secret with the token in its "token" key. The Secret is deleted
+	// once the token is accepted or expired.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// User is the cephx user of the token
+	// +optional
+	User string `json:"user,omitempty"`
+	// ExpirationTime is the time the token expires if it is not accepted
+	// +optional
+	ExpirationTime string `json:"expirationTime,omitempty"`
+	// AcceptedTime is the time the token was found accepted by the remote cluster
+	// +optional
+	AcceptedTime string `json:"acceptedTime,omitempty"`
+	// Pools is the status of the token in each pool
+	// +optional
+	Pools []RBDMirrorPeerTokenPoolStatus `json:"pools,omitempty"`
+	// ObservedGeneration is the latest generation of the spec the token was issued for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// RBDMirrorPeerTokenPoolStatus represents the status of an RBD mirror peer token in a pool
+type RBDMirrorPeerTokenPoolStatus struct {
+	// Pool is the name of the CephBlockPool
+	Pool string `json:"pool"`
+	// IssuedPeers are the uuids of the peers of the pool when the token was issued, a new peer is
+	// the remote cluster that accepted the token
+	// +optional
+	IssuedPeers []string `json:"issuedPeers,omitempty"`
+	// AcceptedPeer is the site name of the peer added to the pool with the token
+	// +optional
+	AcceptedPeer string `json:"acceptedPeer,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephDRAction represents a promotion, a demotion or a quiesce of mirrored block pools, images and
+// filesystems for a failover or a failback. The operator runs the action once, after checking that
+// the mirroring is healthy, and reports each step in the status.
+// +kubebuilder:printcolumn:name="Action",type=string,JSONPath=`.spec.action`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephDRAction struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the specification of the action
+	Spec DRActionSpec `json:"spec"`
+	// Status represents the progress of the action
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephDRActionStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephDRActionList represents a list of CephDRAction
+type CephDRActionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephDRAction `json:"items"`
+}
+
+// DRActionType is the type of a DR action
+type DRActionType string
+
+const (
+	// DRActionPromote promotes the mirrored images to primary
+	DRActionPromote DRActionType = "promote"
+	// DRActionDemote demotes the mirrored images to non-primary
+	DRActionDemote DRActionType = "demote"
+	// DRActionQuiesce pauses the snapshot schedules, takes a final mirror snapshot of the images and
+	// subvolumes and waits for the peers to sync it, before a planned failover
+	DRActionQuiesce DRActionType = "quiesce"
+)
+
+// DRActionSpec represents the specification of a DR action
+type DRActionSpec struct {
+	// Action is the action to run on the mirrored resources
+	// +kubebuilder:validation:Enum=promote;demote;quiesce
+	Action DRActionType `json:"action"`
+
+	// Force promotes the images even when the peer cluster is unreachable or the images are not
+	// synced, for a failover after the loss of the primary cluster. The pre-checks are skipped.
+	// +optional
+	Force bool `json:"force,omitempty"`
+
+	// Pools are the names of the CephBlockPools whose mirrored images are all promoted or demoted
+	// +optional
+	Pools []string `json:"pools,omitempty"`
+
+	// Images are the mirrored images promoted or demoted
+	// +optional
+	Images []DRActionImageSpec `json:"images,omitempty"`
+
+	// Filesystems are the names of the mirrored CephFilesystems of the action. The snapshot
+	// mirroring of a filesystem has no primary, only the sync with the peers is checked.
+	// +optional
+	Filesystems []string `json:"filesystems,omitempty"`
+
+	// Subvolumes are the CephFS subvolumes of which a quiesce action takes a final snapshot. The
+	// subvolume, or its parent directory, must be a mirrored directory of its filesystem.
+	// +optional
+	Subvolumes []DRActionSubvolumeSpec `json:"subvolumes,omitempty"`
+}
+
+// DRActionImageSpec represents a mirrored image of a DR action
+type DRActionImageSpec struct {
+	// Pool is the name of the CephBlockPool of the image
+	Pool string `json:"pool"`
+	// Image is the name of the image
+	Image string `json:"image"`
+}
+
+// DRActionSubvolumeSpec represents a CephFS subvolume of a DR action
+type DRActionSubvolumeSpec struct {
+	// Filesystem is the name of the CephFilesystem of the subvolume
+	Filesystem string `json:"filesystem"`
+	// Group is the subvolume group of the subvolume, the default group when empty
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Name is the name of the subvolume
+	Name string `json:"name"`
+}
+
+// DRActionPhase is the phase of a DR action
+type DRActionPhase string
+
+const (
+	// DRActionPhaseRunning means the action is running
+	DRActionPhaseRunning DRActionPhase = "Running"
+	// DRActionPhaseSucceeded means all the steps of the action succeeded
+	DRActionPhaseSucceeded DRActionPhase = "Succeeded"
+	// DRActionPhaseFailed means a step of the action failed, the next steps were not run
+	DRActionPhaseFailed DRActionPhase = "Failed"
+)
+
+// CephDRActionStatus represents the progress of a DR action
+type CephDRActionStatus struct {
+	// +optional
+	Phase DRActionPhase `json:"phase,omitempty"`
+	// Message is the reason of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Steps are the steps of the action that were run
+	// +optional
+	Steps []DRActionStepStatus `json:"steps,omitempty"`
+	// StartTime is the time the action started
+	// +optional
+	StartTime string `json:"startTime,omitempty"`
+	// CompletionTime is the time the action succeeded or failed
+	// +optional
+	CompletionTime string `json:"completionTime,omitempty"`
+	// ObservedGeneration is the generation of the spec of the action that was run
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Quiesce is the progress of the peers syncing the final snapshots of a quiesce action
+	// +optional
+	Quiesce *DRActionQuiesceStatus `json:"quiesce,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// DRActionQuiesceStatus represents the progress of the peers syncing the final snapshots of a
+// quiesce action. The action stays running until the peers caught up.
+type DRActionQuiesceStatus struct {
+	// SnapshotName is the name of the final snapshots of the subvolumes
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// QuiescedTime is the time the final snapshots were taken
+	// +optional
+	QuiescedTime string `json:"quiescedTime,omitempty"`
+	// CaughtUpTime is the time the peers were found to have synced all the final snapshots
+	// +optional
+	CaughtUpTime string `json:"caughtUpTime,omitempty"`
+	// LastChecked is the last time the sync of the peers was checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Pending are the images, subvolumes and filesystems not synced yet at the last check
+	// +optional
+	Pending []string `json:"pending,omitempty"`
+}
+
+// DRActionStepStatus represents the result of a step of a DR action
+type DRActionStepStatus struct {
+	// Name describes the step, like "check pool replicapool"
+	Name string `json:"name"`
+	// Succeeded is whether the step succeeded
+	Succeeded bool `json:"succeeded"`
+	// Message is the result of the step
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Time is the time the step completed
+	// +optional
+	Time string `json:"time,omitempty"`
+}
+
+// TaskType is the type of a long-running operation run in a job
+type TaskType string
+
+const (
+	// TaskTypeScrub scrubs the metadata of a CephFilesystem
+	TaskTypeScrub TaskType = "scrub"
+	// TaskTypeReshard reshards the index of a bucket of a CephObjectStore
+	TaskTypeReshard TaskType = "reshard"
+	// TaskTypePurgeOSD purges OSDs from a CephCluster
+	TaskTypePurgeOSD TaskType = "purge-osd"
+	// TaskTypeMigratePool migrates the RBD images of a pool of a CephCluster to another pool
+	TaskTypeMigratePool TaskType = "migrate-pool"
+)
+
+// TaskSpec represents a long-running operation run by the operator in a job instead of the
+// reconcile of the resource. A task runs once, a task with a new name must be added to run it
+// again. Removing a task from the spec deletes its job and its status.
+type TaskSpec struct {
+	// Name is the unique name of the task in the resource
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+
+	// Type is the operation run by the task, the types supported depend on the resource
+	// +kubebuilder:validation:Enum=scrub;reshard;purge-osd;migrate-pool
+	Type TaskType `json:"type"`
+
+	// Args are the arguments of the operation, like the bucket of a reshard
+	// +optional
+	Args map[string]string `json:"args,omitempty"`
+
+	// Retries is the number of times a failed job of the task is run again before the task fails
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	// +optional
+	Retries *int32 `json:"retries,omitempty"`
+
+	// TimeoutSeconds is the duration after which a job of the task is stopped and fails, no
+	// timeout when not set
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+
+	// Cancel stops the job of the task if it is running, the task is not retried
+	// +optional
+	Cancel bool `json:"cancel,omitempty"`
+}
+
+// TaskPhase is the phase of a task
+type TaskPhase string
+
+const (
+	// TaskPhasePending means the job of the task is not created yet
+	TaskPhasePending TaskPhase = "Pending"
+	// TaskPhaseRunning means a job of the task is running
+	TaskPhaseRunning TaskPhase = "Running"
+	// TaskPhaseSucceeded means a job of the task completed
+	TaskPhaseSucceeded TaskPhase = "Succeeded"
+	// TaskPhaseFailed means the last job of the task failed and the retries are exhausted
+	TaskPhaseFailed TaskPhase = "Failed"
+	// TaskPhaseCanceled means the task was canceled before it completed
+	TaskPhaseCanceled TaskPhase = "Canceled"
+)
+
+// TaskStatus represents the progress of a task
+type TaskStatus struct {
+	// Name is the name of the task
+	Name string `json:"name"`
+	// Type is the operation run by the task
+	// +optional
+	Type TaskType `json:"type,omitempty"`
+	// +optional
+	Phase TaskPhase `json:"phase,omitempty"`
+	// JobName is the name of the job of the latest attempt
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// Attempts is the number of jobs run for the task
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+	// StartTime is the time the first job of the task was created
+	// +optional
+	StartTime string `json:"startTime,omitempty"`
+	// CompletionTime is the time the task succeeded, failed or was canceled
+	// +optional
+	CompletionTime string `json:"completionTime,omitempty"`
+	// Message is the reason of the failure of the latest attempt
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// AdoptionMode is what the adoption does with the resources of the cluster not managed by a CR
+type AdoptionMode string
+
+const (
+	// AdoptionModeReport reports the unmanaged resources in the status of the CephCluster
+	AdoptionModeReport AdoptionMode = "Report"
+	// AdoptionModeGenerate generates the CRs managing the unmanaged resources
+	AdoptionModeGenerate AdoptionMode = "Generate"
+)
+
+// AdoptionSpec brings the pools, object store users, subvolume groups and rados namespaces created
+// outside of Rook under the management of CRs
+type AdoptionSpec struct {
+	// Mode is Report to report the unmanaged resources in the status of the CephCluster, or Generate
+	// to generate the CRs managing them. The generated CephBlockPools are in dry run until the
+	// ceph.rook.io/dry-run annotation is removed.
+	// +kubebuilder:validation:Enum=Report;Generate
+	Mode AdoptionMode `json:"mode"`
+	// Interval is the interval the resources of the cluster are discovered at, 10m by default
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// AdoptionStatus reports the resources of the cluster that are not managed by a CR
+type AdoptionStatus struct {
+	// Unmanaged are the resources of the cluster that are not managed by a CR
+	// +optional
+	Unmanaged []UnmanagedResource `json:"unmanaged,omitempty"`
+	// Generated are the CRs generated by the last discovery to manage the resources of the cluster,
+	// in the Generate mode
+	// +optional
+	Generated []string `json:"generated,omitempty"`
+	// LastChecked is the time the resources of the cluster were last discovered
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Message is the error of the last discovery, if any
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// UnmanagedResource is a resource of the cluster that is not managed by a CR
+type UnmanagedResource struct {
+	// Kind is the kind of the CR managing this type of resource, such as CephBlockPool
+	Kind string `json:"kind"`
+	// Name is the name of the resource in the cluster
+	Name string `json:"name"`
+	// Parent is the pool, filesystem or object store of the resource
+	// +optional
+	Parent string `json:"parent,omitempty"`
+	// Message explains why no CR is generated for the resource, if any
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephOperatorConfig represents the settings of the operator. It must be named
+// rook-ceph-operator-config and created in the namespace of the operator. Its settings take
+// precedence over the rook-ceph-operator-config ConfigMap and the environment variables of the
+// operator, and the operator reports the settings it applies in the status.
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Reconciled",type=date,JSONPath=`.status.conditions[?(@.type=='Ready')].lastHeartbeatTime`,priority=1,description="Time of the latest reconcile"
+// +kubebuilder:subresource:status
+type CephOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	// Spec represents the settings of the operator
+	Spec OperatorConfigSpec `json:"spec"`
+	// Status represents the settings applied by the operator
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *CephOperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephOperatorConfigList represents a list of CephOperatorConfig
+type CephOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephOperatorConfig `json:"items"`
+}
+
+// OperatorConfigSpec represents the settings of the operator. A setting not set falls back to the
+// rook-ceph-operator-config ConfigMap, then to the environment variable of the operator, then to
+// its default value.
+type OperatorConfigSpec struct {
+	// LogLevel is the log level of the operator (ROOK_LOG_LEVEL)
+	// +kubebuilder:validation:Enum=ERROR;WARNING;INFO;DEBUG
+	// +optional
+	LogLevel string `json:"logLevel,omitempty"`
+	// LogFormat is the format of the logs of the operator (ROOK_LOG_FORMAT)
+	// +kubebuilder:validation:Enum=console;json
+	// +optional
+	LogFormat string `json:"logFormat,omitempty"`
+	// LogLevels are the log levels of single loggers of the operator over LogLevel by the name of
+	// the loggers (ROOK_LOG_LEVELS). The loggers of the controllers are named after them, like
+	// ceph-block-pool-controller. The levels are ERROR, WARNING, INFO or DEBUG.
+	// +optional
+	LogLevels map[string]string `json:"logLevels,omitempty"`
+	// CurrentNamespaceOnly is whether the operator only watches the CRs of its own namespace
+	// (ROOK_CURRENT_NAMESPACE_ONLY). Changing it restarts the controllers.
+	// +optional
+	CurrentNamespaceOnly *bool `json:"currentNamespaceOnly,omitempty"`
+	// WatchNamespaces is the list of the namespaces watched by the operator, overriding
+	// CurrentNamespaceOnly (ROOK_WATCH_NAMESPACES). The namespace of the operator is always watched.
+	// Changing it restarts the controllers.
+	// +optional
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+	// EnableDiscoveryDaemon is whether the device discovery daemonset runs
+	// (ROOK_ENABLE_DISCOVERY_DAEMON)
+	// +optional
+	EnableDiscoveryDaemon *bool `json:"enableDiscoveryDaemon,omitempty"`
+	// EnableOBCProvisioner is whether the operator provisions the ObjectBucketClaims
+	// (ROOK_ENABLE_OBC_PROVISIONER). Changing it restarts the controllers.
+	// +optional
+	EnableOBCProvisioner *bool `json:"enableOBCProvisioner,omitempty"`
+	// OBCWatchOperatorNamespace is whether the ObjectBucketClaims are only watched in the
+	// namespace of the operator (ROOK_OBC_WATCH_OPERATOR_NAMESPACE)
+	// +optional
+	OBCWatchOperatorNamespace *bool `json:"obcWatchOperatorNamespace,omitempty"`
+	// RequireControllerPermissions is whether the controllers lacking permissions are not started
+	// (ROOK_REQUIRE_CONTROLLER_PERMISSIONS). Changing it restarts the controllers.
+	// +optional
+	RequireControllerPermissions *bool `json:"requireControllerPermissions,omitempty"`
+	// EnableAdmissionPolicies is whether the operator applies the ValidatingAdmissionPolicies of the
+	// core invariants of the CRs, which do not need the admission webhook
+	// (ROOK_ENABLE_ADMISSION_POLICIES). Changing it restarts the controllers.
+	// +optional
+	EnableAdmissionPolicies *bool `json:"enableAdmissionPolicies,omitempty"`
+	// CephCommands are the settings of the ceph commands run by the operator
+	// +optional
+	CephCommands *OperatorCephCommandsSpec `json:"cephCommands,omitempty"`
+	// AuditLog are the settings of the audit log of the ceph commands run by the operator
+	// +optional
+	AuditLog *OperatorAuditLogSpec `json:"auditLog,omitempty"`
+	// Tracing are the settings of the export of the traces of the reconciles
+	// +optional
+	Tracing *OperatorTracingSpec `json:"tracing,omitempty"`
+	// Settings are the other settings by their name in the rook-ceph-operator-config ConfigMap,
+	// like the CSI_* settings. A setting of a field of the spec cannot be set here.
+	// +optional
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// OperatorCephCommandsSpec represents the settings of the ceph commands run by the operator
+type OperatorCephCommandsSpec struct {
+	// TimeoutSeconds is the timeout of a ceph command (ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds *int `json:"timeoutSeconds,omitempty"`
+	// MaxDurationSeconds is the max duration of a ceph command with its retries
+	// (ROOK_CEPH_COMMANDS_MAX_DURATION_SECONDS)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxDurationSeconds *int `json:"maxDurationSeconds,omitempty"`
+	// Retries is the number of retries of a ceph command that timed out
+	// (ROOK_CEPH_COMMANDS_RETRIES)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Retries *int `json:"retries,omitempty"`
+	// CircuitBreakerThreshold is the number of consecutive timeouts of the commands of a cluster
+	// before its commands fail fast (ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_THRESHOLD)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CircuitBreakerThreshold *int `json:"circuitBreakerThreshold,omitempty"`
+	// CircuitBreakerCooldownSeconds is the time the commands of a cluster fail fast before they
+	// are run again (ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_COOLDOWN_SECONDS)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CircuitBreakerCooldownSeconds *int `json:"circuitBreakerCooldownSeconds,omitempty"`
+	// CacheTTLSeconds is how long the output of the frequently repeated read-only commands is shared
+	// by the reconciles, 0 to disable the cache (ROOK_CEPH_COMMANDS_CACHE_TTL_SECONDS)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CacheTTLSeconds *int `json:"cacheTTLSeconds,omitempty"`
+}
+
+// OperatorAuditLogSpec represents the settings of the audit log of the ceph commands
+type OperatorAuditLogSpec struct {
+	// Path is "stdout" or the path of the file the ceph commands are recorded to, no audit log if
+	// empty (ROOK_AUDIT_LOG)
+	// +optional
+	Path string `json:"path,omitempty"`
+	// MaxSizeMB is the size the audit log file is rotated at (ROOK_AUDIT_LOG_MAX_SIZE_MB)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxSizeMB *int `json:"maxSizeMB,omitempty"`
+	// MaxBackups is the number of rotated audit log files kept (ROOK_AUDIT_LOG_MAX_BACKUPS)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBackups *int `json:"maxBackups,omitempty"`
+}
+
+// OperatorTracingSpec represents the settings of the export of the traces of the reconciles
+type OperatorTracingSpec struct {
+	// OTLPEndpoint is the OTLP/HTTP endpoint of the OpenTelemetry collector, no traces are
+	// exported if empty (ROOK_TRACING_OTLP_ENDPOINT)
+	// +optional
+	OTLPEndpoint string `json:"otlpEndpoint,omitempty"`
+	// ServiceName is the service name of the exported spans (ROOK_TRACING_SERVICE_NAME)
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// OperatorSettingSource is where the value of an operator setting comes from
+type OperatorSettingSource string
+
+const (
+	// OperatorSettingSourceCR is a setting of the CephOperatorConfig
+	OperatorSettingSourceCR OperatorSettingSource = "CephOperatorConfig"
+	// OperatorSettingSourceConfigMap is a setting of the rook-ceph-operator-config ConfigMap
+	OperatorSettingSourceConfigMap OperatorSettingSource = "ConfigMap"
+	// OperatorSettingSourceEnv is an environment variable of the operator
+	OperatorSettingSourceEnv OperatorSettingSource = "Env"
+	// OperatorSettingSourceDefault is the default value of a setting
+	OperatorSettingSourceDefault OperatorSettingSource = "Default"
+)
+
+// OperatorSettingStatus represents the value of an operator setting applied by the operator
+type OperatorSettingStatus struct {
+	// Name is the name of the setting, like ROOK_LOG_LEVEL
+	Name string `json:"name"`
+	// Value is the value applied
+	// +optional
+	Value string `json:"value,omitempty"`
+	// Source is where the value comes from
+	Source OperatorSettingSource `json:"source"`
+}
+
+// CephOperatorConfigStatus represents the settings applied by the operator
+type CephOperatorConfigStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// Message is the reason the spec was not applied
+	// +optional
+	Message string `json:"message,omitempty"`
+	// EffectiveSettings are the settings applied by the operator with their source
+	// +optional
+	EffectiveSettings []OperatorSettingStatus `json:"effectiveSettings,omitempty"`
+	// LastApplied is the last time the settings were applied
+	// +optional
+	LastApplied string `json:"lastApplied,omitempty"`
+	// ObservedGeneration is the generation of the spec that was applied
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the standard Ready, Progressing and Degraded conditions of the resource
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
@@ -42,6 +42,74 @@ func (in *AMQPEndpointSpec) DeepCopy() *AMQPEndpointSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdoptionSpec) DeepCopyInto(out *AdoptionSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdoptionSpec.
+func (in *AdoptionSpec) DeepCopy() *AdoptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AdoptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdoptionStatus) DeepCopyInto(out *AdoptionStatus) {
+	*out = *in
+	if in.Unmanaged != nil {
+		in, out := &in.Unmanaged, &out.Unmanaged
+		*out = make([]UnmanagedResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.Generated != nil {
+		in, out := &in.Generated, &out.Generated
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdoptionStatus.
+func (in *AdoptionStatus) DeepCopy() *AdoptionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AdoptionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertSilencesSpec) DeepCopyInto(out *AlertSilencesSpec) {
+	*out = *in
+	if in.Alerts != nil {
+		in, out := &in.Alerts, &out.Alerts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertSilencesSpec.
+func (in *AlertSilencesSpec) DeepCopy() *AlertSilencesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertSilencesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in Annotations) DeepCopyInto(out *Annotations) {
 	{
@@ -96,6 +164,27 @@ func (in AnnotationsSpec) DeepCopy() AnnotationsSpec {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackpressureSpec) DeepCopyInto(out *BackpressureSpec) {
+	*out = *in
+	if in.RetryInterval != nil {
+		in, out := &in.RetryInterval, &out.RetryInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackpressureSpec.
+func (in *BackpressureSpec) DeepCopy() *BackpressureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackpressureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BucketHealthCheckSpec) DeepCopyInto(out *BucketHealthCheckSpec) {
 	*out = *in
@@ -195,6 +284,13 @@ func (in *BucketTopicStatus) DeepCopyInto(out *BucketTopicStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -209,294 +305,1625 @@ func (in *BucketTopicStatus) DeepCopy() *BucketTopicStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Capacity) DeepCopyInto(out *Capacity) {
+func (in *CSIArchImagesSpec) DeepCopyInto(out *CSIArchImagesSpec) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Capacity.
-func (in *Capacity) DeepCopy() *Capacity {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIArchImagesSpec.
+func (in *CSIArchImagesSpec) DeepCopy() *CSIArchImagesSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(Capacity)
+	out := new(CSIArchImagesSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephBlockPool) DeepCopyInto(out *CephBlockPool) {
+func (in *CSIAzureKeyVaultKMSSpec) DeepCopyInto(out *CSIAzureKeyVaultKMSSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	if in.Status != nil {
-		in, out := &in.Status, &out.Status
-		*out = new(CephBlockPoolStatus)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPool.
-func (in *CephBlockPool) DeepCopy() *CephBlockPool {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIAzureKeyVaultKMSSpec.
+func (in *CSIAzureKeyVaultKMSSpec) DeepCopy() *CSIAzureKeyVaultKMSSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CephBlockPool)
+	out := new(CSIAzureKeyVaultKMSSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephBlockPool) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephBlockPoolList) DeepCopyInto(out *CephBlockPoolList) {
+func (in *CSICephFSSpec) DeepCopyInto(out *CSICephFSSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]CephBlockPool, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolList.
-func (in *CephBlockPoolList) DeepCopy() *CephBlockPoolList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSICephFSSpec.
+func (in *CSICephFSSpec) DeepCopy() *CSICephFSSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CephBlockPoolList)
+	out := new(CSICephFSSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephBlockPoolList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephBlockPoolStatus) DeepCopyInto(out *CephBlockPoolStatus) {
+func (in *CSICephxSpec) DeepCopyInto(out *CSICephxSpec) {
 	*out = *in
-	if in.MirroringStatus != nil {
-		in, out := &in.MirroringStatus, &out.MirroringStatus
-		*out = new(MirroringStatusSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.MirroringInfo != nil {
-		in, out := &in.MirroringInfo, &out.MirroringInfo
-		*out = new(MirroringInfoSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.SnapshotScheduleStatus != nil {
-		in, out := &in.SnapshotScheduleStatus, &out.SnapshotScheduleStatus
-		*out = new(SnapshotScheduleStatusSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Info != nil {
-		in, out := &in.Info, &out.Info
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
+	in.KeyRotationPolicySpec.DeepCopyInto(&out.KeyRotationPolicySpec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolStatus.
-func (in *CephBlockPoolStatus) DeepCopy() *CephBlockPoolStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSICephxSpec.
+func (in *CSICephxSpec) DeepCopy() *CSICephxSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CephBlockPoolStatus)
+	out := new(CSICephxSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephBucketNotification) DeepCopyInto(out *CephBucketNotification) {
+func (in *CSICephxStatus) DeepCopyInto(out *CSICephxStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	if in.Status != nil {
-		in, out := &in.Status, &out.Status
-		*out = new(Status)
-		**out = **in
+	in.KeyRotationStatus.DeepCopyInto(&out.KeyRotationStatus)
+	if in.PriorKeyGenerations != nil {
+		in, out := &in.PriorKeyGenerations, &out.PriorKeyGenerations
+		*out = make([]int, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketNotification.
-func (in *CephBucketNotification) DeepCopy() *CephBucketNotification {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSICephxStatus.
+func (in *CSICephxStatus) DeepCopy() *CSICephxStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CephBucketNotification)
+	out := new(CSICephxStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephBucketNotification) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephBucketNotificationList) DeepCopyInto(out *CephBucketNotificationList) {
+func (in *CSIComponentSpec) DeepCopyInto(out *CSIComponentSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]CephBucketNotification, len(*in))
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(Placement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]CSIContainerResources, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MaxUnavailablePerZone != nil {
+		in, out := &in.MaxUnavailablePerZone, &out.MaxUnavailablePerZone
+		*out = new(int)
+		**out = **in
+	}
+	if in.LogLevels != nil {
+		in, out := &in.LogLevels, &out.LogLevels
+		*out = make([]CSIContainerLogLevel, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketNotificationList.
-func (in *CephBucketNotificationList) DeepCopy() *CephBucketNotificationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIComponentSpec.
+func (in *CSIComponentSpec) DeepCopy() *CSIComponentSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CephBucketNotificationList)
+	out := new(CSIComponentSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephBucketNotificationList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephBucketTopic) DeepCopyInto(out *CephBucketTopic) {
+func (in *CSIContainerLogLevel) DeepCopyInto(out *CSIContainerLogLevel) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	if in.Status != nil {
-		in, out := &in.Status, &out.Status
-		*out = new(BucketTopicStatus)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketTopic.
-func (in *CephBucketTopic) DeepCopy() *CephBucketTopic {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIContainerLogLevel.
+func (in *CSIContainerLogLevel) DeepCopy() *CSIContainerLogLevel {
 	if in == nil {
 		return nil
 	}
-	out := new(CephBucketTopic)
+	out := new(CSIContainerLogLevel)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephBucketTopic) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSIContainerResources) DeepCopyInto(out *CSIContainerResources) {
+	*out = *in
+	in.Resource.DeepCopyInto(&out.Resource)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIContainerResources.
+func (in *CSIContainerResources) DeepCopy() *CSIContainerResources {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(CSIContainerResources)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephBucketTopicList) DeepCopyInto(out *CephBucketTopicList) {
+func (in *CSIDriverImagesSpec) DeepCopyInto(out *CSIDriverImagesSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]CephBucketTopic, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]CSIArchImagesSpec, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketTopicList.
-func (in *CephBucketTopicList) DeepCopy() *CephBucketTopicList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIDriverImagesSpec.
+func (in *CSIDriverImagesSpec) DeepCopy() *CSIDriverImagesSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CephBucketTopicList)
+	out := new(CSIDriverImagesSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephBucketTopicList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephClient) DeepCopyInto(out *CephClient) {
+func (in *CSIDriverSpec) DeepCopyInto(out *CSIDriverSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	if in.Status != nil {
-		in, out := &in.Status, &out.Status
-		*out = new(CephClientStatus)
-		(*in).DeepCopyInto(*out)
+	in.Provisioner.DeepCopyInto(&out.Provisioner)
+	in.Plugin.DeepCopyInto(&out.Plugin)
+	in.ReadAffinity.DeepCopyInto(&out.ReadAffinity)
+	out.CephFS = in.CephFS
+	out.SnapshotClasses = in.SnapshotClasses
+	out.ReplicationClasses = in.ReplicationClasses
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = make([]CSIKMSSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClient.
-func (in *CephClient) DeepCopy() *CephClient {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIDriverSpec.
+func (in *CSIDriverSpec) DeepCopy() *CSIDriverSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CephClient)
+	out := new(CSIDriverSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephClient) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSIFeatureGatesSpec) DeepCopyInto(out *CSIFeatureGatesSpec) {
+	*out = *in
+	if in.GRPCMetrics != nil {
+		in, out := &in.GRPCMetrics, &out.GRPCMetrics
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HostNetwork != nil {
+		in, out := &in.HostNetwork, &out.HostNetwork
+		*out = new(bool)
+		**out = **in
+	}
+	if in.OMAPGenerator != nil {
+		in, out := &in.OMAPGenerator, &out.OMAPGenerator
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RBDSnapshotter != nil {
+		in, out := &in.RBDSnapshotter, &out.RBDSnapshotter
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CephFSSnapshotter != nil {
+		in, out := &in.CephFSSnapshotter, &out.CephFSSnapshotter
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VolumeGroupSnapshot != nil {
+		in, out := &in.VolumeGroupSnapshot, &out.VolumeGroupSnapshot
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VolumeReplication != nil {
+		in, out := &in.VolumeReplication, &out.VolumeReplication
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CSIAddons != nil {
+		in, out := &in.CSIAddons, &out.CSIAddons
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PluginSELinuxHostMount != nil {
+		in, out := &in.PluginSELinuxHostMount, &out.PluginSELinuxHostMount
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ForceCephFSKernelClient != nil {
+		in, out := &in.ForceCephFSKernelClient, &out.ForceCephFSKernelClient
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RBDWindowsPlugin != nil {
+		in, out := &in.RBDWindowsPlugin, &out.RBDWindowsPlugin
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIFeatureGatesSpec.
+func (in *CSIFeatureGatesSpec) DeepCopy() *CSIFeatureGatesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSIFeatureGatesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSIKMIPKMSSpec) DeepCopyInto(out *CSIKMIPKMSSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIKMIPKMSSpec.
+func (in *CSIKMIPKMSSpec) DeepCopy() *CSIKMIPKMSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSIKMIPKMSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSIKMSSpec) DeepCopyInto(out *CSIKMSSpec) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(CSIVaultKMSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KMIP != nil {
+		in, out := &in.KMIP, &out.KMIP
+		*out = new(CSIKMIPKMSSpec)
+		**out = **in
+	}
+	if in.AzureKeyVault != nil {
+		in, out := &in.AzureKeyVault, &out.AzureKeyVault
+		*out = new(CSIAzureKeyVaultKMSSpec)
+		**out = **in
+	}
+	if in.SecretsMetadata != nil {
+		in, out := &in.SecretsMetadata, &out.SecretsMetadata
+		*out = new(CSISecretsMetadataKMSSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIKMSSpec.
+func (in *CSIKMSSpec) DeepCopy() *CSIKMSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSIKMSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSILogRotationSpec) DeepCopyInto(out *CSILogRotationSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSILogRotationSpec.
+func (in *CSILogRotationSpec) DeepCopy() *CSILogRotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSILogRotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSIMonitoringSpec) DeepCopyInto(out *CSIMonitoringSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIMonitoringSpec.
+func (in *CSIMonitoringSpec) DeepCopy() *CSIMonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSIMonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSIPodSchedulingSpec) DeepCopyInto(out *CSIPodSchedulingSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIPodSchedulingSpec.
+func (in *CSIPodSchedulingSpec) DeepCopy() *CSIPodSchedulingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSIPodSchedulingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSISecretsMetadataKMSSpec) DeepCopyInto(out *CSISecretsMetadataKMSSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSISecretsMetadataKMSSpec.
+func (in *CSISecretsMetadataKMSSpec) DeepCopy() *CSISecretsMetadataKMSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSISecretsMetadataKMSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSIVaultKMSSpec) DeepCopyInto(out *CSIVaultKMSSpec) {
+	*out = *in
+	if in.CAVerify != nil {
+		in, out := &in.CAVerify, &out.CAVerify
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DestroyKeys != nil {
+		in, out := &in.DestroyKeys, &out.DestroyKeys
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSIVaultKMSSpec.
+func (in *CSIVaultKMSSpec) DeepCopy() *CSIVaultKMSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSIVaultKMSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Capacity) DeepCopyInto(out *Capacity) {
+	*out = *in
+	if in.Forecast != nil {
+		in, out := &in.Forecast, &out.Forecast
+		*out = new(CapacityForecast)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Capacity.
+func (in *Capacity) DeepCopy() *Capacity {
+	if in == nil {
+		return nil
+	}
+	out := new(Capacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacityForecast) DeepCopyInto(out *CapacityForecast) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]UsageForecast, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeviceClasses != nil {
+		in, out := &in.DeviceClasses, &out.DeviceClasses
+		*out = make([]UsageForecast, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacityForecast.
+func (in *CapacityForecast) DeepCopy() *CapacityForecast {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityForecast)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPool) DeepCopyInto(out *CephBlockPool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephBlockPoolStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPool.
+func (in *CephBlockPool) DeepCopy() *CephBlockPool {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBlockPool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolList) DeepCopyInto(out *CephBlockPoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephBlockPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolList.
+func (in *CephBlockPoolList) DeepCopy() *CephBlockPoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBlockPoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolRadosNamespace) DeepCopyInto(out *CephBlockPoolRadosNamespace) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephBlockPoolRadosNamespaceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolRadosNamespace.
+func (in *CephBlockPoolRadosNamespace) DeepCopy() *CephBlockPoolRadosNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolRadosNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBlockPoolRadosNamespace) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolRadosNamespaceList) DeepCopyInto(out *CephBlockPoolRadosNamespaceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephBlockPoolRadosNamespace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolRadosNamespaceList.
+func (in *CephBlockPoolRadosNamespaceList) DeepCopy() *CephBlockPoolRadosNamespaceList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolRadosNamespaceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBlockPoolRadosNamespaceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolRadosNamespaceSpec) DeepCopyInto(out *CephBlockPoolRadosNamespaceSpec) {
+	*out = *in
+	if in.Mirroring != nil {
+		in, out := &in.Mirroring, &out.Mirroring
+		*out = new(RadosNamespaceMirroring)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolRadosNamespaceSpec.
+func (in *CephBlockPoolRadosNamespaceSpec) DeepCopy() *CephBlockPoolRadosNamespaceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolRadosNamespaceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolRadosNamespaceStatus) DeepCopyInto(out *CephBlockPoolRadosNamespaceStatus) {
+	*out = *in
+	if in.Info != nil {
+		in, out := &in.Info, &out.Info
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MirroringInfo != nil {
+		in, out := &in.MirroringInfo, &out.MirroringInfo
+		*out = new(RadosNamespaceMirroringInfoSpec)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolRadosNamespaceStatus.
+func (in *CephBlockPoolRadosNamespaceStatus) DeepCopy() *CephBlockPoolRadosNamespaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolRadosNamespaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolStatus) DeepCopyInto(out *CephBlockPoolStatus) {
+	*out = *in
+	if in.MirroringStatus != nil {
+		in, out := &in.MirroringStatus, &out.MirroringStatus
+		*out = new(MirroringStatusSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MirroringInfo != nil {
+		in, out := &in.MirroringInfo, &out.MirroringInfo
+		*out = new(MirroringInfoSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SnapshotScheduleStatus != nil {
+		in, out := &in.SnapshotScheduleStatus, &out.SnapshotScheduleStatus
+		*out = new(SnapshotScheduleStatusSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GroupReplicationStatus != nil {
+		in, out := &in.GroupReplicationStatus, &out.GroupReplicationStatus
+		*out = new(GroupReplicationStatusSpec)
+		**out = **in
+	}
+	if in.ImageModeMigrationStatus != nil {
+		in, out := &in.ImageModeMigrationStatus, &out.ImageModeMigrationStatus
+		*out = new(ImageModeMigrationStatusSpec)
+		**out = **in
+	}
+	if in.Info != nil {
+		in, out := &in.Info, &out.Info
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolStatus.
+func (in *CephBlockPoolStatus) DeepCopy() *CephBlockPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolTopology) DeepCopyInto(out *CephBlockPoolTopology) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephBlockPoolTopologyStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolTopology.
+func (in *CephBlockPoolTopology) DeepCopy() *CephBlockPoolTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBlockPoolTopology) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolTopologyList) DeepCopyInto(out *CephBlockPoolTopologyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephBlockPoolTopology, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolTopologyList.
+func (in *CephBlockPoolTopologyList) DeepCopy() *CephBlockPoolTopologyList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolTopologyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBlockPoolTopologyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolTopologySpec) DeepCopyInto(out *CephBlockPoolTopologySpec) {
+	*out = *in
+	if in.FailureDomains != nil {
+		in, out := &in.FailureDomains, &out.FailureDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Replicated.DeepCopyInto(&out.Replicated)
+	in.StorageClass.DeepCopyInto(&out.StorageClass)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolTopologySpec.
+func (in *CephBlockPoolTopologySpec) DeepCopy() *CephBlockPoolTopologySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolTopologySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolTopologyStatus) DeepCopyInto(out *CephBlockPoolTopologyStatus) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolTopologyStatus.
+func (in *CephBlockPoolTopologyStatus) DeepCopy() *CephBlockPoolTopologyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolTopologyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBucketNotification) DeepCopyInto(out *CephBucketNotification) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(Status)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketNotification.
+func (in *CephBucketNotification) DeepCopy() *CephBucketNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBucketNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBucketNotification) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBucketNotificationList) DeepCopyInto(out *CephBucketNotificationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephBucketNotification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketNotificationList.
+func (in *CephBucketNotificationList) DeepCopy() *CephBucketNotificationList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBucketNotificationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBucketNotificationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBucketTopic) DeepCopyInto(out *CephBucketTopic) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(BucketTopicStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketTopic.
+func (in *CephBucketTopic) DeepCopy() *CephBucketTopic {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBucketTopic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBucketTopic) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBucketTopicList) DeepCopyInto(out *CephBucketTopicList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephBucketTopic, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketTopicList.
+func (in *CephBucketTopicList) DeepCopy() *CephBucketTopicList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBucketTopicList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBucketTopicList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephCSIDriver) DeepCopyInto(out *CephCSIDriver) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephCSIDriverStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephCSIDriver.
+func (in *CephCSIDriver) DeepCopy() *CephCSIDriver {
+	if in == nil {
+		return nil
+	}
+	out := new(CephCSIDriver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephCSIDriver) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephCSIDriverList) DeepCopyInto(out *CephCSIDriverList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephCSIDriver, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephCSIDriverList.
+func (in *CephCSIDriverList) DeepCopy() *CephCSIDriverList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephCSIDriverList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephCSIDriverList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephCSIDriverSpec) DeepCopyInto(out *CephCSIDriverSpec) {
+	*out = *in
+	if in.EnableRBD != nil {
+		in, out := &in.EnableRBD, &out.EnableRBD
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableCephFS != nil {
+		in, out := &in.EnableCephFS, &out.EnableCephFS
+		*out = new(bool)
+		**out = **in
+	}
+	in.Images.DeepCopyInto(&out.Images)
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(int)
+		**out = **in
+	}
+	in.LogRotation.DeepCopyInto(&out.LogRotation)
+	if in.ProvisionerReplicas != nil {
+		in, out := &in.ProvisionerReplicas, &out.ProvisionerReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.FeatureGates.DeepCopyInto(&out.FeatureGates)
+	in.Provisioner.DeepCopyInto(&out.Provisioner)
+	in.Plugin.DeepCopyInto(&out.Plugin)
+	if in.ImagePolicy != nil {
+		in, out := &in.ImagePolicy, &out.ImagePolicy
+		*out = new(ImagePolicySpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephCSIDriverSpec.
+func (in *CephCSIDriverSpec) DeepCopy() *CephCSIDriverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CephCSIDriverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephCSIDriverStatus) DeepCopyInto(out *CephCSIDriverStatus) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ImageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephCSIDriverStatus.
+func (in *CephCSIDriverStatus) DeepCopy() *CephCSIDriverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephCSIDriverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephClient) DeepCopyInto(out *CephClient) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephClientStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClient.
+func (in *CephClient) DeepCopy() *CephClient {
+	if in == nil {
+		return nil
+	}
+	out := new(CephClient)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephClient) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephClientList) DeepCopyInto(out *CephClientList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephClient, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClientList.
+func (in *CephClientList) DeepCopy() *CephClientList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephClientList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephClientList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephClientStatus) DeepCopyInto(out *CephClientStatus) {
+	*out = *in
+	if in.Info != nil {
+		in, out := &in.Info, &out.Info
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClientStatus.
+func (in *CephClientStatus) DeepCopy() *CephClientStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephClientStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephCluster) DeepCopyInto(out *CephCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephCluster.
+func (in *CephCluster) DeepCopy() *CephCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(CephCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephClusterHealthCheckSpec) DeepCopyInto(out *CephClusterHealthCheckSpec) {
+	*out = *in
+	in.DaemonHealth.DeepCopyInto(&out.DaemonHealth)
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = make(map[KeyType]*ProbeSpec, len(*in))
+		for key, val := range *in {
+			var outVal *ProbeSpec
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(ProbeSpec)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = make(map[KeyType]*ProbeSpec, len(*in))
+		for key, val := range *in {
+			var outVal *ProbeSpec
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(ProbeSpec)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Backpressure != nil {
+		in, out := &in.Backpressure, &out.Backpressure
+		*out = new(BackpressureSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClusterHealthCheckSpec.
+func (in *CephClusterHealthCheckSpec) DeepCopy() *CephClusterHealthCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CephClusterHealthCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephClusterList) DeepCopyInto(out *CephClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClusterList.
+func (in *CephClusterList) DeepCopy() *CephClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephDRAction) DeepCopyInto(out *CephDRAction) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephDRActionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephDRAction.
+func (in *CephDRAction) DeepCopy() *CephDRAction {
+	if in == nil {
+		return nil
+	}
+	out := new(CephDRAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephDRAction) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephDRActionList) DeepCopyInto(out *CephDRActionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephDRAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephDRActionList.
+func (in *CephDRActionList) DeepCopy() *CephDRActionList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephDRActionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephDRActionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephDRActionStatus) DeepCopyInto(out *CephDRActionStatus) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]DRActionStepStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Quiesce != nil {
+		in, out := &in.Quiesce, &out.Quiesce
+		*out = new(DRActionQuiesceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephDRActionStatus.
+func (in *CephDRActionStatus) DeepCopy() *CephDRActionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephDRActionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephDaemonsVersions) DeepCopyInto(out *CephDaemonsVersions) {
+	*out = *in
+	if in.Mon != nil {
+		in, out := &in.Mon, &out.Mon
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Mgr != nil {
+		in, out := &in.Mgr, &out.Mgr
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Osd != nil {
+		in, out := &in.Osd, &out.Osd
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Rgw != nil {
+		in, out := &in.Rgw, &out.Rgw
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Mds != nil {
+		in, out := &in.Mds, &out.Mds
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RbdMirror != nil {
+		in, out := &in.RbdMirror, &out.RbdMirror
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CephFSMirror != nil {
+		in, out := &in.CephFSMirror, &out.CephFSMirror
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Overall != nil {
+		in, out := &in.Overall, &out.Overall
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephDaemonsVersions.
+func (in *CephDaemonsVersions) DeepCopy() *CephDaemonsVersions {
+	if in == nil {
+		return nil
+	}
+	out := new(CephDaemonsVersions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystem) DeepCopyInto(out *CephFilesystem) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephFilesystemStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystem.
+func (in *CephFilesystem) DeepCopy() *CephFilesystem {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephFilesystem) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemList) DeepCopyInto(out *CephFilesystemList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephFilesystem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemList.
+func (in *CephFilesystemList) DeepCopy() *CephFilesystemList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephFilesystemList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
 	return nil
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephClientList) DeepCopyInto(out *CephClientList) {
+func (in *CephFilesystemMirror) DeepCopyInto(out *CephFilesystemMirror) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(Status)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemMirror.
+func (in *CephFilesystemMirror) DeepCopy() *CephFilesystemMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephFilesystemMirror) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemMirrorList) DeepCopyInto(out *CephFilesystemMirrorList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephClient, len(*in))
+		*out = make([]CephFilesystemMirror, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -504,26 +1931,256 @@ func (in *CephClientList) DeepCopyInto(out *CephClientList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClientList.
-func (in *CephClientList) DeepCopy() *CephClientList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemMirrorList.
+func (in *CephFilesystemMirrorList) DeepCopy() *CephFilesystemMirrorList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemMirrorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephFilesystemMirrorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemStatus) DeepCopyInto(out *CephFilesystemStatus) {
+	*out = *in
+	if in.SnapshotScheduleStatus != nil {
+		in, out := &in.SnapshotScheduleStatus, &out.SnapshotScheduleStatus
+		*out = new(FilesystemSnapshotScheduleStatusSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Info != nil {
+		in, out := &in.Info, &out.Info
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MirroringStatus != nil {
+		in, out := &in.MirroringStatus, &out.MirroringStatus
+		*out = new(FilesystemMirroringInfoSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]TaskStatus, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemStatus.
+func (in *CephFilesystemStatus) DeepCopy() *CephFilesystemStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemSubVolume) DeepCopyInto(out *CephFilesystemSubVolume) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephFilesystemSubVolumeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolume.
+func (in *CephFilesystemSubVolume) DeepCopy() *CephFilesystemSubVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemSubVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephFilesystemSubVolume) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemSubVolumeGroup) DeepCopyInto(out *CephFilesystemSubVolumeGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(CephFilesystemSubVolumeGroupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroup.
+func (in *CephFilesystemSubVolumeGroup) DeepCopy() *CephFilesystemSubVolumeGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemSubVolumeGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephFilesystemSubVolumeGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemSubVolumeGroupList) DeepCopyInto(out *CephFilesystemSubVolumeGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephFilesystemSubVolumeGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroupList.
+func (in *CephFilesystemSubVolumeGroupList) DeepCopy() *CephFilesystemSubVolumeGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemSubVolumeGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephFilesystemSubVolumeGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemSubVolumeGroupPinning) DeepCopyInto(out *CephFilesystemSubVolumeGroupPinning) {
+	*out = *in
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(int)
+		**out = **in
+	}
+	if in.Distributed != nil {
+		in, out := &in.Distributed, &out.Distributed
+		*out = new(int)
+		**out = **in
+	}
+	if in.Random != nil {
+		in, out := &in.Random, &out.Random
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroupPinning.
+func (in *CephFilesystemSubVolumeGroupPinning) DeepCopy() *CephFilesystemSubVolumeGroupPinning {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemSubVolumeGroupPinning)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemSubVolumeGroupSnapshotRetention) DeepCopyInto(out *CephFilesystemSubVolumeGroupSnapshotRetention) {
+	*out = *in
+	if in.Count != nil {
+		in, out := &in.Count, &out.Count
+		*out = new(int)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroupSnapshotRetention.
+func (in *CephFilesystemSubVolumeGroupSnapshotRetention) DeepCopy() *CephFilesystemSubVolumeGroupSnapshotRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemSubVolumeGroupSnapshotRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemSubVolumeGroupSpec) DeepCopyInto(out *CephFilesystemSubVolumeGroupSpec) {
+	*out = *in
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Pinning != nil {
+		in, out := &in.Pinning, &out.Pinning
+		*out = new(CephFilesystemSubVolumeGroupPinning)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SnapshotRetention != nil {
+		in, out := &in.SnapshotRetention, &out.SnapshotRetention
+		*out = new(CephFilesystemSubVolumeGroupSnapshotRetention)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroupSpec.
+func (in *CephFilesystemSubVolumeGroupSpec) DeepCopy() *CephFilesystemSubVolumeGroupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CephClientList)
+	out := new(CephFilesystemSubVolumeGroupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephClientList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephClientStatus) DeepCopyInto(out *CephClientStatus) {
+func (in *CephFilesystemSubVolumeGroupStatus) DeepCopyInto(out *CephFilesystemSubVolumeGroupStatus) {
 	*out = *in
 	if in.Info != nil {
 		in, out := &in.Info, &out.Info
@@ -532,41 +2189,58 @@ func (in *CephClientStatus) DeepCopyInto(out *CephClientStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Pinning != nil {
+		in, out := &in.Pinning, &out.Pinning
+		*out = new(CephFilesystemSubVolumeGroupPinning)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClientStatus.
-func (in *CephClientStatus) DeepCopy() *CephClientStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroupStatus.
+func (in *CephFilesystemSubVolumeGroupStatus) DeepCopy() *CephFilesystemSubVolumeGroupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CephClientStatus)
+	out := new(CephFilesystemSubVolumeGroupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephCluster) DeepCopyInto(out *CephCluster) {
+func (in *CephFilesystemSubVolumeList) DeepCopyInto(out *CephFilesystemSubVolumeList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephFilesystemSubVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephCluster.
-func (in *CephCluster) DeepCopy() *CephCluster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeList.
+func (in *CephFilesystemSubVolumeList) DeepCopy() *CephFilesystemSubVolumeList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephCluster)
+	out := new(CephFilesystemSubVolumeList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephCluster) DeepCopyObject() runtime.Object {
+func (in *CephFilesystemSubVolumeList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -574,60 +2248,42 @@ func (in *CephCluster) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephClusterHealthCheckSpec) DeepCopyInto(out *CephClusterHealthCheckSpec) {
+func (in *CephFilesystemSubVolumeSpec) DeepCopyInto(out *CephFilesystemSubVolumeSpec) {
 	*out = *in
-	in.DaemonHealth.DeepCopyInto(&out.DaemonHealth)
-	if in.LivenessProbe != nil {
-		in, out := &in.LivenessProbe, &out.LivenessProbe
-		*out = make(map[KeyType]*ProbeSpec, len(*in))
-		for key, val := range *in {
-			var outVal *ProbeSpec
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				in, out := &val, &outVal
-				*out = new(ProbeSpec)
-				(*in).DeepCopyInto(*out)
-			}
-			(*out)[key] = outVal
-		}
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	if in.StartupProbe != nil {
-		in, out := &in.StartupProbe, &out.StartupProbe
-		*out = make(map[KeyType]*ProbeSpec, len(*in))
-		for key, val := range *in {
-			var outVal *ProbeSpec
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				in, out := &val, &outVal
-				*out = new(ProbeSpec)
-				(*in).DeepCopyInto(*out)
-			}
-			(*out)[key] = outVal
-		}
+	if in.UID != nil {
+		in, out := &in.UID, &out.UID
+		*out = new(int64)
+		**out = **in
+	}
+	if in.GID != nil {
+		in, out := &in.GID, &out.GID
+		*out = new(int64)
+		**out = **in
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClusterHealthCheckSpec.
-func (in *CephClusterHealthCheckSpec) DeepCopy() *CephClusterHealthCheckSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeSpec.
+func (in *CephFilesystemSubVolumeSpec) DeepCopy() *CephFilesystemSubVolumeSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CephClusterHealthCheckSpec)
+	out := new(CephFilesystemSubVolumeSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephClusterList) DeepCopyInto(out *CephClusterList) {
+func (in *CephFilesystemSubVolumeStatus) DeepCopyInto(out *CephFilesystemSubVolumeStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]CephCluster, len(*in))
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -635,122 +2291,74 @@ func (in *CephClusterList) DeepCopyInto(out *CephClusterList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClusterList.
-func (in *CephClusterList) DeepCopy() *CephClusterList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeStatus.
+func (in *CephFilesystemSubVolumeStatus) DeepCopy() *CephFilesystemSubVolumeStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CephClusterList)
+	out := new(CephFilesystemSubVolumeStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephClusterList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephHealthCheck) DeepCopyInto(out *CephHealthCheck) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephHealthCheck.
+func (in *CephHealthCheck) DeepCopy() *CephHealthCheck {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(CephHealthCheck)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephDaemonsVersions) DeepCopyInto(out *CephDaemonsVersions) {
+func (in *CephHealthMessage) DeepCopyInto(out *CephHealthMessage) {
 	*out = *in
-	if in.Mon != nil {
-		in, out := &in.Mon, &out.Mon
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Mgr != nil {
-		in, out := &in.Mgr, &out.Mgr
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Osd != nil {
-		in, out := &in.Osd, &out.Osd
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Rgw != nil {
-		in, out := &in.Rgw, &out.Rgw
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Mds != nil {
-		in, out := &in.Mds, &out.Mds
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.RbdMirror != nil {
-		in, out := &in.RbdMirror, &out.RbdMirror
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.CephFSMirror != nil {
-		in, out := &in.CephFSMirror, &out.CephFSMirror
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Overall != nil {
-		in, out := &in.Overall, &out.Overall
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephDaemonsVersions.
-func (in *CephDaemonsVersions) DeepCopy() *CephDaemonsVersions {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephHealthMessage.
+func (in *CephHealthMessage) DeepCopy() *CephHealthMessage {
 	if in == nil {
 		return nil
 	}
-	out := new(CephDaemonsVersions)
+	out := new(CephHealthMessage)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystem) DeepCopyInto(out *CephFilesystem) {
+func (in *CephNFS) DeepCopyInto(out *CephNFS) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
-		*out = new(CephFilesystemStatus)
+		*out = new(NFSStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystem.
-func (in *CephFilesystem) DeepCopy() *CephFilesystem {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephNFS.
+func (in *CephNFS) DeepCopy() *CephNFS {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystem)
+	out := new(CephNFS)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephFilesystem) DeepCopyObject() runtime.Object {
+func (in *CephNFS) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -758,13 +2366,13 @@ func (in *CephFilesystem) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystemList) DeepCopyInto(out *CephFilesystemList) {
+func (in *CephNFSList) DeepCopyInto(out *CephNFSList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephFilesystem, len(*in))
+		*out = make([]CephNFS, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -772,18 +2380,18 @@ func (in *CephFilesystemList) DeepCopyInto(out *CephFilesystemList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemList.
-func (in *CephFilesystemList) DeepCopy() *CephFilesystemList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephNFSList.
+func (in *CephNFSList) DeepCopy() *CephNFSList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystemList)
+	out := new(CephNFSList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephFilesystemList) DeepCopyObject() runtime.Object {
+func (in *CephNFSList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -791,31 +2399,31 @@ func (in *CephFilesystemList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystemMirror) DeepCopyInto(out *CephFilesystemMirror) {
+func (in *CephObjectRealm) DeepCopyInto(out *CephObjectRealm) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	out.Spec = in.Spec
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
 		*out = new(Status)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemMirror.
-func (in *CephFilesystemMirror) DeepCopy() *CephFilesystemMirror {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectRealm.
+func (in *CephObjectRealm) DeepCopy() *CephObjectRealm {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystemMirror)
+	out := new(CephObjectRealm)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephFilesystemMirror) DeepCopyObject() runtime.Object {
+func (in *CephObjectRealm) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -823,13 +2431,13 @@ func (in *CephFilesystemMirror) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystemMirrorList) DeepCopyInto(out *CephFilesystemMirrorList) {
+func (in *CephObjectRealmList) DeepCopyInto(out *CephObjectRealmList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephFilesystemMirror, len(*in))
+		*out = make([]CephObjectRealm, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -837,18 +2445,18 @@ func (in *CephFilesystemMirrorList) DeepCopyInto(out *CephFilesystemMirrorList)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemMirrorList.
-func (in *CephFilesystemMirrorList) DeepCopy() *CephFilesystemMirrorList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectRealmList.
+func (in *CephObjectRealmList) DeepCopy() *CephObjectRealmList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystemMirrorList)
+	out := new(CephObjectRealmList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephFilesystemMirrorList) DeepCopyObject() runtime.Object {
+func (in *CephObjectRealmList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -856,28 +2464,45 @@ func (in *CephFilesystemMirrorList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystemStatus) DeepCopyInto(out *CephFilesystemStatus) {
+func (in *CephObjectStore) DeepCopyInto(out *CephObjectStore) {
 	*out = *in
-	if in.SnapshotScheduleStatus != nil {
-		in, out := &in.SnapshotScheduleStatus, &out.SnapshotScheduleStatus
-		*out = new(FilesystemSnapshotScheduleStatusSpec)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(ObjectStoreStatus)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.Info != nil {
-		in, out := &in.Info, &out.Info
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStore.
+func (in *CephObjectStore) DeepCopy() *CephObjectStore {
+	if in == nil {
+		return nil
 	}
-	if in.MirroringStatus != nil {
-		in, out := &in.MirroringStatus, &out.MirroringStatus
-		*out = new(FilesystemMirroringInfoSpec)
-		(*in).DeepCopyInto(*out)
+	out := new(CephObjectStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectStore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]Condition, len(*in))
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephObjectStoreList) DeepCopyInto(out *CephObjectStoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephObjectStore, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -885,42 +2510,50 @@ func (in *CephFilesystemStatus) DeepCopyInto(out *CephFilesystemStatus) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemStatus.
-func (in *CephFilesystemStatus) DeepCopy() *CephFilesystemStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStoreList.
+func (in *CephObjectStoreList) DeepCopy() *CephObjectStoreList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystemStatus)
+	out := new(CephObjectStoreList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectStoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystemSubVolumeGroup) DeepCopyInto(out *CephFilesystemSubVolumeGroup) {
+func (in *CephObjectStoreUser) DeepCopyInto(out *CephObjectStoreUser) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
-		*out = new(CephFilesystemSubVolumeGroupStatus)
+		*out = new(ObjectStoreUserStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroup.
-func (in *CephFilesystemSubVolumeGroup) DeepCopy() *CephFilesystemSubVolumeGroup {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStoreUser.
+func (in *CephObjectStoreUser) DeepCopy() *CephObjectStoreUser {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystemSubVolumeGroup)
+	out := new(CephObjectStoreUser)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephFilesystemSubVolumeGroup) DeepCopyObject() runtime.Object {
+func (in *CephObjectStoreUser) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -928,13 +2561,13 @@ func (in *CephFilesystemSubVolumeGroup) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystemSubVolumeGroupList) DeepCopyInto(out *CephFilesystemSubVolumeGroupList) {
+func (in *CephObjectStoreUserList) DeepCopyInto(out *CephObjectStoreUserList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephFilesystemSubVolumeGroup, len(*in))
+		*out = make([]CephObjectStoreUser, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -942,18 +2575,18 @@ func (in *CephFilesystemSubVolumeGroupList) DeepCopyInto(out *CephFilesystemSubV
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroupList.
-func (in *CephFilesystemSubVolumeGroupList) DeepCopy() *CephFilesystemSubVolumeGroupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStoreUserList.
+func (in *CephObjectStoreUserList) DeepCopy() *CephObjectStoreUserList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystemSubVolumeGroupList)
+	out := new(CephObjectStoreUserList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephFilesystemSubVolumeGroupList) DeepCopyObject() runtime.Object {
+func (in *CephObjectStoreUserList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -961,86 +2594,96 @@ func (in *CephFilesystemSubVolumeGroupList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystemSubVolumeGroupSpec) DeepCopyInto(out *CephFilesystemSubVolumeGroupSpec) {
+func (in *CephObjectZone) DeepCopyInto(out *CephObjectZone) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(Status)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroupSpec.
-func (in *CephFilesystemSubVolumeGroupSpec) DeepCopy() *CephFilesystemSubVolumeGroupSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZone.
+func (in *CephObjectZone) DeepCopy() *CephObjectZone {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystemSubVolumeGroupSpec)
+	out := new(CephObjectZone)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectZone) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephFilesystemSubVolumeGroupStatus) DeepCopyInto(out *CephFilesystemSubVolumeGroupStatus) {
+func (in *CephObjectZoneGroup) DeepCopyInto(out *CephObjectZoneGroup) {
 	*out = *in
-	if in.Info != nil {
-		in, out := &in.Info, &out.Info
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(Status)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemSubVolumeGroupStatus.
-func (in *CephFilesystemSubVolumeGroupStatus) DeepCopy() *CephFilesystemSubVolumeGroupStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneGroup.
+func (in *CephObjectZoneGroup) DeepCopy() *CephObjectZoneGroup {
 	if in == nil {
 		return nil
 	}
-	out := new(CephFilesystemSubVolumeGroupStatus)
+	out := new(CephObjectZoneGroup)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephHealthMessage) DeepCopyInto(out *CephHealthMessage) {
-	*out = *in
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephHealthMessage.
-func (in *CephHealthMessage) DeepCopy() *CephHealthMessage {
-	if in == nil {
-		return nil
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectZoneGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
-	out := new(CephHealthMessage)
-	in.DeepCopyInto(out)
-	return out
+	return nil
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephNFS) DeepCopyInto(out *CephNFS) {
+func (in *CephObjectZoneGroupList) DeepCopyInto(out *CephObjectZoneGroupList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	if in.Status != nil {
-		in, out := &in.Status, &out.Status
-		*out = new(Status)
-		**out = **in
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephObjectZoneGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephNFS.
-func (in *CephNFS) DeepCopy() *CephNFS {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneGroupList.
+func (in *CephObjectZoneGroupList) DeepCopy() *CephObjectZoneGroupList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephNFS)
+	out := new(CephObjectZoneGroupList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephNFS) DeepCopyObject() runtime.Object {
+func (in *CephObjectZoneGroupList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1048,13 +2691,13 @@ func (in *CephNFS) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephNFSList) DeepCopyInto(out *CephNFSList) {
+func (in *CephObjectZoneList) DeepCopyInto(out *CephObjectZoneList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephNFS, len(*in))
+		*out = make([]CephObjectZone, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1062,18 +2705,18 @@ func (in *CephNFSList) DeepCopyInto(out *CephNFSList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephNFSList.
-func (in *CephNFSList) DeepCopy() *CephNFSList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneList.
+func (in *CephObjectZoneList) DeepCopy() *CephObjectZoneList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephNFSList)
+	out := new(CephObjectZoneList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephNFSList) DeepCopyObject() runtime.Object {
+func (in *CephObjectZoneList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1081,31 +2724,31 @@ func (in *CephNFSList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectRealm) DeepCopyInto(out *CephObjectRealm) {
+func (in *CephOperatorConfig) DeepCopyInto(out *CephOperatorConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
-		*out = new(Status)
-		**out = **in
+		*out = new(CephOperatorConfigStatus)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectRealm.
-func (in *CephObjectRealm) DeepCopy() *CephObjectRealm {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephOperatorConfig.
+func (in *CephOperatorConfig) DeepCopy() *CephOperatorConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectRealm)
+	out := new(CephOperatorConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectRealm) DeepCopyObject() runtime.Object {
+func (in *CephOperatorConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1113,13 +2756,13 @@ func (in *CephObjectRealm) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectRealmList) DeepCopyInto(out *CephObjectRealmList) {
+func (in *CephOperatorConfigList) DeepCopyInto(out *CephOperatorConfigList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephObjectRealm, len(*in))
+		*out = make([]CephOperatorConfig, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1127,18 +2770,18 @@ func (in *CephObjectRealmList) DeepCopyInto(out *CephObjectRealmList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectRealmList.
-func (in *CephObjectRealmList) DeepCopy() *CephObjectRealmList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephOperatorConfigList.
+func (in *CephOperatorConfigList) DeepCopy() *CephOperatorConfigList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectRealmList)
+	out := new(CephOperatorConfigList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectRealmList) DeepCopyObject() runtime.Object {
+func (in *CephOperatorConfigList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1146,31 +2789,59 @@ func (in *CephObjectRealmList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectStore) DeepCopyInto(out *CephObjectStore) {
+func (in *CephOperatorConfigStatus) DeepCopyInto(out *CephOperatorConfigStatus) {
+	*out = *in
+	if in.EffectiveSettings != nil {
+		in, out := &in.EffectiveSettings, &out.EffectiveSettings
+		*out = make([]OperatorSettingStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephOperatorConfigStatus.
+func (in *CephOperatorConfigStatus) DeepCopy() *CephOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephRBDMirror) DeepCopyInto(out *CephRBDMirror) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
-		*out = new(ObjectStoreStatus)
+		*out = new(RBDMirrorStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStore.
-func (in *CephObjectStore) DeepCopy() *CephObjectStore {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirror.
+func (in *CephRBDMirror) DeepCopy() *CephRBDMirror {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectStore)
+	out := new(CephRBDMirror)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectStore) DeepCopyObject() runtime.Object {
+func (in *CephRBDMirror) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1178,13 +2849,13 @@ func (in *CephObjectStore) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectStoreList) DeepCopyInto(out *CephObjectStoreList) {
+func (in *CephRBDMirrorList) DeepCopyInto(out *CephRBDMirrorList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephObjectStore, len(*in))
+		*out = make([]CephRBDMirror, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1192,18 +2863,18 @@ func (in *CephObjectStoreList) DeepCopyInto(out *CephObjectStoreList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStoreList.
-func (in *CephObjectStoreList) DeepCopy() *CephObjectStoreList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirrorList.
+func (in *CephRBDMirrorList) DeepCopy() *CephRBDMirrorList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectStoreList)
+	out := new(CephRBDMirrorList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectStoreList) DeepCopyObject() runtime.Object {
+func (in *CephRBDMirrorList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1211,31 +2882,31 @@ func (in *CephObjectStoreList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectStoreUser) DeepCopyInto(out *CephObjectStoreUser) {
+func (in *CephRBDMirrorPeer) DeepCopyInto(out *CephRBDMirrorPeer) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
-		*out = new(ObjectStoreUserStatus)
+		*out = new(CephRBDMirrorPeerStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStoreUser.
-func (in *CephObjectStoreUser) DeepCopy() *CephObjectStoreUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirrorPeer.
+func (in *CephRBDMirrorPeer) DeepCopy() *CephRBDMirrorPeer {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectStoreUser)
+	out := new(CephRBDMirrorPeer)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectStoreUser) DeepCopyObject() runtime.Object {
+func (in *CephRBDMirrorPeer) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1243,13 +2914,13 @@ func (in *CephObjectStoreUser) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectStoreUserList) DeepCopyInto(out *CephObjectStoreUserList) {
+func (in *CephRBDMirrorPeerList) DeepCopyInto(out *CephRBDMirrorPeerList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephObjectStoreUser, len(*in))
+		*out = make([]CephRBDMirrorPeer, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1257,18 +2928,18 @@ func (in *CephObjectStoreUserList) DeepCopyInto(out *CephObjectStoreUserList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStoreUserList.
-func (in *CephObjectStoreUserList) DeepCopy() *CephObjectStoreUserList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirrorPeerList.
+func (in *CephRBDMirrorPeerList) DeepCopy() *CephRBDMirrorPeerList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectStoreUserList)
+	out := new(CephRBDMirrorPeerList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectStoreUserList) DeepCopyObject() runtime.Object {
+func (in *CephRBDMirrorPeerList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1276,63 +2947,59 @@ func (in *CephObjectStoreUserList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectZone) DeepCopyInto(out *CephObjectZone) {
+func (in *CephRBDMirrorPeerStatus) DeepCopyInto(out *CephRBDMirrorPeerStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	if in.Status != nil {
-		in, out := &in.Status, &out.Status
-		*out = new(Status)
-		**out = **in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]RBDMirrorPeerPoolStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZone.
-func (in *CephObjectZone) DeepCopy() *CephObjectZone {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirrorPeerStatus.
+func (in *CephRBDMirrorPeerStatus) DeepCopy() *CephRBDMirrorPeerStatus {
 	if in == nil {
-		return nil
-	}
-	out := new(CephObjectZone)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectZone) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+		return nil
 	}
-	return nil
+	out := new(CephRBDMirrorPeerStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectZoneGroup) DeepCopyInto(out *CephObjectZoneGroup) {
+func (in *CephRBDMirrorPeerToken) DeepCopyInto(out *CephRBDMirrorPeerToken) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
-		*out = new(Status)
-		**out = **in
+		*out = new(CephRBDMirrorPeerTokenStatus)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneGroup.
-func (in *CephObjectZoneGroup) DeepCopy() *CephObjectZoneGroup {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirrorPeerToken.
+func (in *CephRBDMirrorPeerToken) DeepCopy() *CephRBDMirrorPeerToken {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectZoneGroup)
+	out := new(CephRBDMirrorPeerToken)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectZoneGroup) DeepCopyObject() runtime.Object {
+func (in *CephRBDMirrorPeerToken) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1340,13 +3007,13 @@ func (in *CephObjectZoneGroup) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectZoneGroupList) DeepCopyInto(out *CephObjectZoneGroupList) {
+func (in *CephRBDMirrorPeerTokenList) DeepCopyInto(out *CephRBDMirrorPeerTokenList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephObjectZoneGroup, len(*in))
+		*out = make([]CephRBDMirrorPeerToken, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1354,18 +3021,18 @@ func (in *CephObjectZoneGroupList) DeepCopyInto(out *CephObjectZoneGroupList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneGroupList.
-func (in *CephObjectZoneGroupList) DeepCopy() *CephObjectZoneGroupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirrorPeerTokenList.
+func (in *CephRBDMirrorPeerTokenList) DeepCopy() *CephRBDMirrorPeerTokenList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectZoneGroupList)
+	out := new(CephRBDMirrorPeerTokenList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectZoneGroupList) DeepCopyObject() runtime.Object {
+func (in *CephRBDMirrorPeerTokenList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1373,13 +3040,18 @@ func (in *CephObjectZoneGroupList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephObjectZoneList) DeepCopyInto(out *CephObjectZoneList) {
+func (in *CephRBDMirrorPeerTokenStatus) DeepCopyInto(out *CephRBDMirrorPeerTokenStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]CephObjectZone, len(*in))
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]RBDMirrorPeerTokenPoolStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1387,50 +3059,42 @@ func (in *CephObjectZoneList) DeepCopyInto(out *CephObjectZoneList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneList.
-func (in *CephObjectZoneList) DeepCopy() *CephObjectZoneList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirrorPeerTokenStatus.
+func (in *CephRBDMirrorPeerTokenStatus) DeepCopy() *CephRBDMirrorPeerTokenStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CephObjectZoneList)
+	out := new(CephRBDMirrorPeerTokenStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephObjectZoneList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephRBDMirror) DeepCopyInto(out *CephRBDMirror) {
+func (in *CephStaticVolume) DeepCopyInto(out *CephStaticVolume) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
-		*out = new(Status)
-		**out = **in
+		*out = new(CephStaticVolumeStatus)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirror.
-func (in *CephRBDMirror) DeepCopy() *CephRBDMirror {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephStaticVolume.
+func (in *CephStaticVolume) DeepCopy() *CephStaticVolume {
 	if in == nil {
 		return nil
 	}
-	out := new(CephRBDMirror)
+	out := new(CephStaticVolume)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephRBDMirror) DeepCopyObject() runtime.Object {
+func (in *CephStaticVolume) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1438,13 +3102,13 @@ func (in *CephRBDMirror) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephRBDMirrorList) DeepCopyInto(out *CephRBDMirrorList) {
+func (in *CephStaticVolumeList) DeepCopyInto(out *CephStaticVolumeList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CephRBDMirror, len(*in))
+		*out = make([]CephStaticVolume, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1452,24 +3116,93 @@ func (in *CephRBDMirrorList) DeepCopyInto(out *CephRBDMirrorList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephRBDMirrorList.
-func (in *CephRBDMirrorList) DeepCopy() *CephRBDMirrorList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephStaticVolumeList.
+func (in *CephStaticVolumeList) DeepCopy() *CephStaticVolumeList {
 	if in == nil {
 		return nil
 	}
-	out := new(CephRBDMirrorList)
+	out := new(CephStaticVolumeList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephRBDMirrorList) DeepCopyObject() runtime.Object {
+func (in *CephStaticVolumeList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephStaticVolumeSpec) DeepCopyInto(out *CephStaticVolumeSpec) {
+	*out = *in
+	if in.RBD != nil {
+		in, out := &in.RBD, &out.RBD
+		*out = new(StaticRBDVolumeSource)
+		**out = **in
+	}
+	if in.CephFS != nil {
+		in, out := &in.CephFS, &out.CephFS
+		*out = new(StaticCephFSVolumeSource)
+		**out = **in
+	}
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeMode != nil {
+		in, out := &in.VolumeMode, &out.VolumeMode
+		*out = new(corev1.PersistentVolumeMode)
+		**out = **in
+	}
+	if in.Claim != nil {
+		in, out := &in.Claim, &out.Claim
+		*out = new(StaticVolumeClaim)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephStaticVolumeSpec.
+func (in *CephStaticVolumeSpec) DeepCopy() *CephStaticVolumeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CephStaticVolumeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephStaticVolumeStatus) DeepCopyInto(out *CephStaticVolumeStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephStaticVolumeStatus.
+func (in *CephStaticVolumeStatus) DeepCopy() *CephStaticVolumeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephStaticVolumeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephStatus) DeepCopyInto(out *CephStatus) {
 	*out = *in
@@ -1480,12 +3213,17 @@ func (in *CephStatus) DeepCopyInto(out *CephStatus) {
 			(*out)[key] = val
 		}
 	}
-	out.Capacity = in.Capacity
+	in.Capacity.DeepCopyInto(&out.Capacity)
 	if in.Versions != nil {
 		in, out := &in.Versions, &out.Versions
 		*out = new(CephDaemonsVersions)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]CephHealthCheck, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1507,6 +3245,11 @@ func (in *CephStorage) DeepCopyInto(out *CephStorage) {
 		*out = make([]DeviceClasses, len(*in))
 		copy(*out, *in)
 	}
+	if in.OSDMigration != nil {
+		in, out := &in.OSDMigration, &out.OSDMigration
+		*out = new(OSDMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1553,6 +3296,22 @@ func (in *CleanupPolicySpec) DeepCopy() *CleanupPolicySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCapsTemplateSpec) DeepCopyInto(out *ClientCapsTemplateSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCapsTemplateSpec.
+func (in *ClientCapsTemplateSpec) DeepCopy() *ClientCapsTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCapsTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientSpec) DeepCopyInto(out *ClientSpec) {
 	*out = *in
@@ -1563,6 +3322,21 @@ func (in *ClientSpec) DeepCopyInto(out *ClientSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.CapsTemplate != nil {
+		in, out := &in.CapsTemplate, &out.CapsTemplate
+		*out = new(ClientCapsTemplateSpec)
+		**out = **in
+	}
+	if in.SecretFormats != nil {
+		in, out := &in.SecretFormats, &out.SecretFormats
+		*out = make([]ClientSecretFormat, len(*in))
+		copy(*out, *in)
+	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotationPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1576,6 +3350,50 @@ func (in *ClientSpec) DeepCopy() *ClientSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCephxSpec) DeepCopyInto(out *ClusterCephxSpec) {
+	*out = *in
+	in.CSI.DeepCopyInto(&out.CSI)
+	in.Daemon.DeepCopyInto(&out.Daemon)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCephxSpec.
+func (in *ClusterCephxSpec) DeepCopy() *ClusterCephxSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCephxSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCephxStatus) DeepCopyInto(out *ClusterCephxStatus) {
+	*out = *in
+	if in.CSI != nil {
+		in, out := &in.CSI, &out.CSI
+		*out = new(CSICephxStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Daemon != nil {
+		in, out := &in.Daemon, &out.Daemon
+		*out = new(KeyRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCephxStatus.
+func (in *ClusterCephxStatus) DeepCopy() *ClusterCephxStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCephxStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	*out = *in
@@ -1642,12 +3460,25 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	out.CrashCollector = in.CrashCollector
 	out.Dashboard = in.Dashboard
 	in.Monitoring.DeepCopyInto(&out.Monitoring)
-	out.External = in.External
+	in.External.DeepCopyInto(&out.External)
 	in.Mgr.DeepCopyInto(&out.Mgr)
 	out.CleanupPolicy = in.CleanupPolicy
 	in.HealthCheck.DeepCopyInto(&out.HealthCheck)
 	in.Security.DeepCopyInto(&out.Security)
 	out.LogCollector = in.LogCollector
+	in.CSI.DeepCopyInto(&out.CSI)
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]TaskSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Adoption != nil {
+		in, out := &in.Adoption, &out.Adoption
+		*out = new(AdoptionSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1686,6 +3517,41 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 		*out = new(ClusterVersion)
 		**out = **in
 	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalClusterStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cephx != nil {
+		in, out := &in.Cephx, &out.Cephx
+		*out = new(ClusterCephxStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FIPS != nil {
+		in, out := &in.FIPS, &out.FIPS
+		*out = new(FIPSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ImageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]TaskStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Adoption != nil {
+		in, out := &in.Adoption, &out.Adoption
+		*out = new(AdoptionStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1718,33 +3584,138 @@ func (in *ClusterVersion) DeepCopy() *ClusterVersion {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
-	in.LastHeartbeatTime.DeepCopyInto(&out.LastHeartbeatTime)
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.LastHeartbeatTime.DeepCopyInto(&out.LastHeartbeatTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CrashCollectorSpec) DeepCopyInto(out *CrashCollectorSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrashCollectorSpec.
+func (in *CrashCollectorSpec) DeepCopy() *CrashCollectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CrashCollectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DRActionImageSpec) DeepCopyInto(out *DRActionImageSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRActionImageSpec.
+func (in *DRActionImageSpec) DeepCopy() *DRActionImageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DRActionImageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DRActionQuiesceStatus) DeepCopyInto(out *DRActionQuiesceStatus) {
+	*out = *in
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRActionQuiesceStatus.
+func (in *DRActionQuiesceStatus) DeepCopy() *DRActionQuiesceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DRActionQuiesceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DRActionSpec) DeepCopyInto(out *DRActionSpec) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]DRActionImageSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.Filesystems != nil {
+		in, out := &in.Filesystems, &out.Filesystems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Subvolumes != nil {
+		in, out := &in.Subvolumes, &out.Subvolumes
+		*out = make([]DRActionSubvolumeSpec, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRActionSpec.
+func (in *DRActionSpec) DeepCopy() *DRActionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DRActionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DRActionStepStatus) DeepCopyInto(out *DRActionStepStatus) {
+	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
-func (in *Condition) DeepCopy() *Condition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRActionStepStatus.
+func (in *DRActionStepStatus) DeepCopy() *DRActionStepStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Condition)
+	out := new(DRActionStepStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CrashCollectorSpec) DeepCopyInto(out *CrashCollectorSpec) {
+func (in *DRActionSubvolumeSpec) DeepCopyInto(out *DRActionSubvolumeSpec) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrashCollectorSpec.
-func (in *CrashCollectorSpec) DeepCopy() *CrashCollectorSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRActionSubvolumeSpec.
+func (in *DRActionSubvolumeSpec) DeepCopy() *DRActionSubvolumeSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CrashCollectorSpec)
+	out := new(DRActionSubvolumeSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1855,9 +3826,82 @@ func (in *ErasureCodedSpec) DeepCopy() *ErasureCodedSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalClusterStatus) DeepCopyInto(out *ExternalClusterStatus) {
+	*out = *in
+	if in.LastKeyRotationTime != nil {
+		in, out := &in.LastKeyRotationTime, &out.LastKeyRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CrushLocationLabels != nil {
+		in, out := &in.CrushLocationLabels, &out.CrushLocationLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalClusterStatus.
+func (in *ExternalClusterStatus) DeepCopy() *ExternalClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretStoreRef) DeepCopyInto(out *ExternalSecretStoreRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretStoreRef.
+func (in *ExternalSecretStoreRef) DeepCopy() *ExternalSecretStoreRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretStoreRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretsSpec) DeepCopyInto(out *ExternalSecretsSpec) {
+	*out = *in
+	out.SecretStoreRef = in.SecretStoreRef
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = make([]ExternalSecretCredentials, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretsSpec.
+func (in *ExternalSecretsSpec) DeepCopy() *ExternalSecretsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExternalSpec) DeepCopyInto(out *ExternalSpec) {
 	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = new(ExternalUsersSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -1871,6 +3915,59 @@ func (in *ExternalSpec) DeepCopy() *ExternalSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalUsersSpec) DeepCopyInto(out *ExternalUsersSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalUsersSpec.
+func (in *ExternalUsersSpec) DeepCopy() *ExternalUsersSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalUsersSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FIPSSpec) DeepCopyInto(out *FIPSSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FIPSSpec.
+func (in *FIPSSpec) DeepCopy() *FIPSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FIPSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FIPSStatus) DeepCopyInto(out *FIPSStatus) {
+	*out = *in
+	if in.Violations != nil {
+		in, out := &in.Violations, &out.Violations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FIPSStatus.
+func (in *FIPSStatus) DeepCopy() *FIPSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FIPSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FSMirroringSpec) DeepCopyInto(out *FSMirroringSpec) {
 	*out = *in
@@ -1928,6 +4025,22 @@ func (in *FilesystemMirrorInfoPeerSpec) DeepCopy() *FilesystemMirrorInfoPeerSpec
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilesystemMirrorPeerStatus) DeepCopyInto(out *FilesystemMirrorPeerStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilesystemMirrorPeerStatus.
+func (in *FilesystemMirrorPeerStatus) DeepCopy() *FilesystemMirrorPeerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FilesystemMirrorPeerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FilesystemMirroringInfo) DeepCopyInto(out *FilesystemMirroringInfo) {
 	*out = *in
@@ -1961,6 +4074,11 @@ func (in *FilesystemMirroringInfoSpec) DeepCopyInto(out *FilesystemMirroringInfo
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]FilesystemMirrorPeerStatus, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -2006,6 +4124,27 @@ func (in *FilesystemMirroringSpec) DeepCopy() *FilesystemMirroringSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilesystemMonitoringSpec) DeepCopyInto(out *FilesystemMonitoringSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilesystemMonitoringSpec.
+func (in *FilesystemMonitoringSpec) DeepCopy() *FilesystemMonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FilesystemMonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FilesystemSnapshotScheduleStatusRetention) DeepCopyInto(out *FilesystemSnapshotScheduleStatusRetention) {
 	*out = *in
@@ -2078,6 +4217,18 @@ func (in *FilesystemSpec) DeepCopyInto(out *FilesystemSpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.StatusCheck.DeepCopyInto(&out.StatusCheck)
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(FilesystemMonitoringSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]TaskSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -2206,6 +4357,50 @@ func (in *GatewaySpec) DeepCopy() *GatewaySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GrafanaDashboardsSpec) DeepCopyInto(out *GrafanaDashboardsSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.InstanceSelector != nil {
+		in, out := &in.InstanceSelector, &out.InstanceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GrafanaDashboardsSpec.
+func (in *GrafanaDashboardsSpec) DeepCopy() *GrafanaDashboardsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GrafanaDashboardsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupReplicationStatusSpec) DeepCopyInto(out *GroupReplicationStatusSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupReplicationStatusSpec.
+func (in *GroupReplicationStatusSpec) DeepCopy() *GroupReplicationStatusSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupReplicationStatusSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPEndpointSpec) DeepCopyInto(out *HTTPEndpointSpec) {
 	*out = *in
@@ -2249,51 +4444,163 @@ func (in *HybridStorageSpec) DeepCopyInto(out *HybridStorageSpec) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HybridStorageSpec.
-func (in *HybridStorageSpec) DeepCopy() *HybridStorageSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HybridStorageSpec.
+func (in *HybridStorageSpec) DeepCopy() *HybridStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HybridStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageModeMigrationStatusSpec) DeepCopyInto(out *ImageModeMigrationStatusSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageModeMigrationStatusSpec.
+func (in *ImageModeMigrationStatusSpec) DeepCopy() *ImageModeMigrationStatusSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageModeMigrationStatusSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePolicySpec) DeepCopyInto(out *ImagePolicySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicySpec.
+func (in *ImagePolicySpec) DeepCopy() *ImagePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageStatus) DeepCopyInto(out *ImageStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageStatus.
+func (in *ImageStatus) DeepCopy() *ImageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KMSStatus) DeepCopyInto(out *KMSStatus) {
+	*out = *in
+	if in.KeyVersions != nil {
+		in, out := &in.KeyVersions, &out.KeyVersions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KMSStatus.
+func (in *KMSStatus) DeepCopy() *KMSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KMSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaEndpointSpec) DeepCopyInto(out *KafkaEndpointSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaEndpointSpec.
+func (in *KafkaEndpointSpec) DeepCopy() *KafkaEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyManagementServiceSpec) DeepCopyInto(out *KeyManagementServiceSpec) {
+	*out = *in
+	if in.ConnectionDetails != nil {
+		in, out := &in.ConnectionDetails, &out.ConnectionDetails
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyManagementServiceSpec.
+func (in *KeyManagementServiceSpec) DeepCopy() *KeyManagementServiceSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HybridStorageSpec)
+	out := new(KeyManagementServiceSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KafkaEndpointSpec) DeepCopyInto(out *KafkaEndpointSpec) {
+func (in *KeyRotationPolicySpec) DeepCopyInto(out *KeyRotationPolicySpec) {
 	*out = *in
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaEndpointSpec.
-func (in *KafkaEndpointSpec) DeepCopy() *KafkaEndpointSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRotationPolicySpec.
+func (in *KeyRotationPolicySpec) DeepCopy() *KeyRotationPolicySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(KafkaEndpointSpec)
+	out := new(KeyRotationPolicySpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeyManagementServiceSpec) DeepCopyInto(out *KeyManagementServiceSpec) {
+func (in *KeyRotationStatus) DeepCopyInto(out *KeyRotationStatus) {
 	*out = *in
-	if in.ConnectionDetails != nil {
-		in, out := &in.ConnectionDetails, &out.ConnectionDetails
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyManagementServiceSpec.
-func (in *KeyManagementServiceSpec) DeepCopy() *KeyManagementServiceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRotationStatus.
+func (in *KeyRotationStatus) DeepCopy() *KeyRotationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KeyManagementServiceSpec)
+	out := new(KeyRotationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -2418,6 +4725,11 @@ func (in *MgrSpec) DeepCopyInto(out *MgrSpec) {
 		*out = make([]Module, len(*in))
 		copy(*out, *in)
 	}
+	if in.Telemetry != nil {
+		in, out := &in.Telemetry, &out.Telemetry
+		*out = new(TelemetrySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -2431,6 +4743,22 @@ func (in *MgrSpec) DeepCopy() *MgrSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationSpec.
+func (in *MigrationSpec) DeepCopy() *MigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MirrorHealthCheckSpec) DeepCopyInto(out *MirrorHealthCheckSpec) {
 	*out = *in
@@ -2585,6 +4913,36 @@ func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	in.CSI.DeepCopyInto(&out.CSI)
+	if in.Alerts != nil {
+		in, out := &in.Alerts, &out.Alerts
+		*out = make(map[string]PrometheusAlertSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Dashboards != nil {
+		in, out := &in.Dashboards, &out.Dashboards
+		*out = new(GrafanaDashboardsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AlertSilences != nil {
+		in, out := &in.AlertSilences, &out.AlertSilences
+		*out = new(AlertSilencesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SNMPGateway != nil {
+		in, out := &in.SNMPGateway, &out.SNMPGateway
+		*out = new(SNMPGatewaySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalLabels != nil {
+		in, out := &in.ExternalLabels, &out.ExternalLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -2616,6 +4974,29 @@ func (in *NFSGaneshaSpec) DeepCopy() *NFSGaneshaSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NFSStatus) DeepCopyInto(out *NFSStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NFSStatus.
+func (in *NFSStatus) DeepCopy() *NFSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NFSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamedBlockPoolSpec) DeepCopyInto(out *NamedBlockPoolSpec) {
 	*out = *in
@@ -2650,6 +5031,32 @@ func (in *NamedPoolSpec) DeepCopy() *NamedPoolSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPoliciesSpec) DeepCopyInto(out *NetworkPoliciesSpec) {
+	*out = *in
+	if in.ObjectStoreNamespaces != nil {
+		in, out := &in.ObjectStoreNamespaces, &out.ObjectStoreNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeCIDRs != nil {
+		in, out := &in.NodeCIDRs, &out.NodeCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPoliciesSpec.
+func (in *NetworkPoliciesSpec) DeepCopy() *NetworkPoliciesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPoliciesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 	*out = *in
@@ -2660,6 +5067,11 @@ func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.NetworkPolicies != nil {
+		in, out := &in.NetworkPolicies, &out.NetworkPolicies
+		*out = new(NetworkPoliciesSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -2783,6 +5195,27 @@ func (in *NotificationKeyFilterRule) DeepCopy() *NotificationKeyFilterRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDMigrationStatus) DeepCopyInto(out *OSDMigrationStatus) {
+	*out = *in
+	if in.OSD != nil {
+		in, out := &in.OSD, &out.OSD
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDMigrationStatus.
+func (in *OSDMigrationStatus) DeepCopy() *OSDMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectRealmSpec) DeepCopyInto(out *ObjectRealmSpec) {
 	*out = *in
@@ -2800,6 +5233,27 @@ func (in *ObjectRealmSpec) DeepCopy() *ObjectRealmSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStoreMonitoringSpec) DeepCopyInto(out *ObjectStoreMonitoringSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStoreMonitoringSpec.
+func (in *ObjectStoreMonitoringSpec) DeepCopy() *ObjectStoreMonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStoreMonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectStoreSpec) DeepCopyInto(out *ObjectStoreSpec) {
 	*out = *in
@@ -2813,6 +5267,18 @@ func (in *ObjectStoreSpec) DeepCopyInto(out *ObjectStoreSpec) {
 		*out = new(SecuritySpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(ObjectStoreMonitoringSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]TaskSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -2848,6 +5314,16 @@ func (in *ObjectStoreStatus) DeepCopyInto(out *ObjectStoreStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]TaskStatus, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -2874,6 +5350,11 @@ func (in *ObjectStoreUserSpec) DeepCopyInto(out *ObjectStoreUserSpec) {
 		*out = new(ObjectUserQuotaSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotationPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -2897,6 +5378,18 @@ func (in *ObjectStoreUserStatus) DeepCopyInto(out *ObjectStoreUserStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -2916,77 +5409,261 @@ func (in *ObjectUserCapSpec) DeepCopyInto(out *ObjectUserCapSpec) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectUserCapSpec.
-func (in *ObjectUserCapSpec) DeepCopy() *ObjectUserCapSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectUserCapSpec.
+func (in *ObjectUserCapSpec) DeepCopy() *ObjectUserCapSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectUserCapSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectUserQuotaSpec) DeepCopyInto(out *ObjectUserQuotaSpec) {
+	*out = *in
+	if in.MaxBuckets != nil {
+		in, out := &in.MaxBuckets, &out.MaxBuckets
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxObjects != nil {
+		in, out := &in.MaxObjects, &out.MaxObjects
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectUserQuotaSpec.
+func (in *ObjectUserQuotaSpec) DeepCopy() *ObjectUserQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectUserQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectZoneGroupSpec) DeepCopyInto(out *ObjectZoneGroupSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectZoneGroupSpec.
+func (in *ObjectZoneGroupSpec) DeepCopy() *ObjectZoneGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectZoneGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectZoneSpec) DeepCopyInto(out *ObjectZoneSpec) {
+	*out = *in
+	in.MetadataPool.DeepCopyInto(&out.MetadataPool)
+	in.DataPool.DeepCopyInto(&out.DataPool)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectZoneSpec.
+func (in *ObjectZoneSpec) DeepCopy() *ObjectZoneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectZoneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorAuditLogSpec) DeepCopyInto(out *OperatorAuditLogSpec) {
+	*out = *in
+	if in.MaxSizeMB != nil {
+		in, out := &in.MaxSizeMB, &out.MaxSizeMB
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxBackups != nil {
+		in, out := &in.MaxBackups, &out.MaxBackups
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorAuditLogSpec.
+func (in *OperatorAuditLogSpec) DeepCopy() *OperatorAuditLogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorAuditLogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorCephCommandsSpec) DeepCopyInto(out *OperatorCephCommandsSpec) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxDurationSeconds != nil {
+		in, out := &in.MaxDurationSeconds, &out.MaxDurationSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int)
+		**out = **in
+	}
+	if in.CircuitBreakerThreshold != nil {
+		in, out := &in.CircuitBreakerThreshold, &out.CircuitBreakerThreshold
+		*out = new(int)
+		**out = **in
+	}
+	if in.CircuitBreakerCooldownSeconds != nil {
+		in, out := &in.CircuitBreakerCooldownSeconds, &out.CircuitBreakerCooldownSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.CacheTTLSeconds != nil {
+		in, out := &in.CacheTTLSeconds, &out.CacheTTLSeconds
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorCephCommandsSpec.
+func (in *OperatorCephCommandsSpec) DeepCopy() *OperatorCephCommandsSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ObjectUserCapSpec)
+	out := new(OperatorCephCommandsSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ObjectUserQuotaSpec) DeepCopyInto(out *ObjectUserQuotaSpec) {
+func (in *OperatorConfigSpec) DeepCopyInto(out *OperatorConfigSpec) {
 	*out = *in
-	if in.MaxBuckets != nil {
-		in, out := &in.MaxBuckets, &out.MaxBuckets
-		*out = new(int)
+	if in.LogLevels != nil {
+		in, out := &in.LogLevels, &out.LogLevels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CurrentNamespaceOnly != nil {
+		in, out := &in.CurrentNamespaceOnly, &out.CurrentNamespaceOnly
+		*out = new(bool)
 		**out = **in
 	}
-	if in.MaxSize != nil {
-		in, out := &in.MaxSize, &out.MaxSize
-		x := (*in).DeepCopy()
-		*out = &x
+	if in.WatchNamespaces != nil {
+		in, out := &in.WatchNamespaces, &out.WatchNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.MaxObjects != nil {
-		in, out := &in.MaxObjects, &out.MaxObjects
-		*out = new(int64)
+	if in.EnableDiscoveryDaemon != nil {
+		in, out := &in.EnableDiscoveryDaemon, &out.EnableDiscoveryDaemon
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableOBCProvisioner != nil {
+		in, out := &in.EnableOBCProvisioner, &out.EnableOBCProvisioner
+		*out = new(bool)
+		**out = **in
+	}
+	if in.OBCWatchOperatorNamespace != nil {
+		in, out := &in.OBCWatchOperatorNamespace, &out.OBCWatchOperatorNamespace
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RequireControllerPermissions != nil {
+		in, out := &in.RequireControllerPermissions, &out.RequireControllerPermissions
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableAdmissionPolicies != nil {
+		in, out := &in.EnableAdmissionPolicies, &out.EnableAdmissionPolicies
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CephCommands != nil {
+		in, out := &in.CephCommands, &out.CephCommands
+		*out = new(OperatorCephCommandsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(OperatorAuditLogSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tracing != nil {
+		in, out := &in.Tracing, &out.Tracing
+		*out = new(OperatorTracingSpec)
 		**out = **in
 	}
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectUserQuotaSpec.
-func (in *ObjectUserQuotaSpec) DeepCopy() *ObjectUserQuotaSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigSpec.
+func (in *OperatorConfigSpec) DeepCopy() *OperatorConfigSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ObjectUserQuotaSpec)
+	out := new(OperatorConfigSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ObjectZoneGroupSpec) DeepCopyInto(out *ObjectZoneGroupSpec) {
+func (in *OperatorSettingStatus) DeepCopyInto(out *OperatorSettingStatus) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectZoneGroupSpec.
-func (in *ObjectZoneGroupSpec) DeepCopy() *ObjectZoneGroupSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorSettingStatus.
+func (in *OperatorSettingStatus) DeepCopy() *OperatorSettingStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ObjectZoneGroupSpec)
+	out := new(OperatorSettingStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ObjectZoneSpec) DeepCopyInto(out *ObjectZoneSpec) {
+func (in *OperatorTracingSpec) DeepCopyInto(out *OperatorTracingSpec) {
 	*out = *in
-	in.MetadataPool.DeepCopyInto(&out.MetadataPool)
-	in.DataPool.DeepCopyInto(&out.DataPool)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectZoneSpec.
-func (in *ObjectZoneSpec) DeepCopy() *ObjectZoneSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorTracingSpec.
+func (in *OperatorTracingSpec) DeepCopy() *OperatorTracingSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ObjectZoneSpec)
+	out := new(OperatorTracingSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -3236,6 +5913,22 @@ func (in *ProbeSpec) DeepCopy() *ProbeSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusAlertSpec) DeepCopyInto(out *PrometheusAlertSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusAlertSpec.
+func (in *PrometheusAlertSpec) DeepCopy() *PrometheusAlertSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusAlertSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PullSpec) DeepCopyInto(out *PullSpec) {
 	*out = *in
@@ -3273,68 +5966,270 @@ func (in *QuotaSpec) DeepCopyInto(out *QuotaSpec) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSpec.
-func (in *QuotaSpec) DeepCopy() *QuotaSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSpec.
+func (in *QuotaSpec) DeepCopy() *QuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBDMirrorAutoscaleSpec) DeepCopyInto(out *RBDMirrorAutoscaleSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirrorAutoscaleSpec.
+func (in *RBDMirrorAutoscaleSpec) DeepCopy() *RBDMirrorAutoscaleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RBDMirrorAutoscaleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBDMirrorPeerPoolStatus) DeepCopyInto(out *RBDMirrorPeerPoolStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirrorPeerPoolStatus.
+func (in *RBDMirrorPeerPoolStatus) DeepCopy() *RBDMirrorPeerPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RBDMirrorPeerPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBDMirrorPeerSpec) DeepCopyInto(out *RBDMirrorPeerSpec) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StatusCheck.DeepCopyInto(&out.StatusCheck)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirrorPeerSpec.
+func (in *RBDMirrorPeerSpec) DeepCopy() *RBDMirrorPeerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RBDMirrorPeerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBDMirrorPeerTokenPoolStatus) DeepCopyInto(out *RBDMirrorPeerTokenPoolStatus) {
+	*out = *in
+	if in.IssuedPeers != nil {
+		in, out := &in.IssuedPeers, &out.IssuedPeers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirrorPeerTokenPoolStatus.
+func (in *RBDMirrorPeerTokenPoolStatus) DeepCopy() *RBDMirrorPeerTokenPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RBDMirrorPeerTokenPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBDMirrorPeerTokenSpec) DeepCopyInto(out *RBDMirrorPeerTokenSpec) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Expiration != nil {
+		in, out := &in.Expiration, &out.Expiration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirrorPeerTokenSpec.
+func (in *RBDMirrorPeerTokenSpec) DeepCopy() *RBDMirrorPeerTokenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RBDMirrorPeerTokenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBDMirrorStatus) DeepCopyInto(out *RBDMirrorStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirrorStatus.
+func (in *RBDMirrorStatus) DeepCopy() *RBDMirrorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RBDMirrorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBDMirroringSpec) DeepCopyInto(out *RBDMirroringSpec) {
+	*out = *in
+	if in.Autoscale != nil {
+		in, out := &in.Autoscale, &out.Autoscale
+		*out = new(RBDMirrorAutoscaleSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Peers.DeepCopyInto(&out.Peers)
+	in.Placement.DeepCopyInto(&out.Placement)
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(Annotations, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(Labels, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirroringSpec.
+func (in *RBDMirroringSpec) DeepCopy() *RBDMirroringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RBDMirroringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RGWServiceSpec) DeepCopyInto(out *RGWServiceSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(Annotations, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RGWServiceSpec.
+func (in *RGWServiceSpec) DeepCopy() *RGWServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RGWServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RadosNamespaceMirroring) DeepCopyInto(out *RadosNamespaceMirroring) {
+	*out = *in
+	if in.RemoteNamespace != nil {
+		in, out := &in.RemoteNamespace, &out.RemoteNamespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnapshotSchedules != nil {
+		in, out := &in.SnapshotSchedules, &out.SnapshotSchedules
+		*out = make([]SnapshotScheduleSpec, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RadosNamespaceMirroring.
+func (in *RadosNamespaceMirroring) DeepCopy() *RadosNamespaceMirroring {
 	if in == nil {
 		return nil
 	}
-	out := new(QuotaSpec)
+	out := new(RadosNamespaceMirroring)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RBDMirroringSpec) DeepCopyInto(out *RBDMirroringSpec) {
+func (in *RadosNamespaceMirroringInfoSpec) DeepCopyInto(out *RadosNamespaceMirroringInfoSpec) {
 	*out = *in
-	in.Peers.DeepCopyInto(&out.Peers)
-	in.Placement.DeepCopyInto(&out.Placement)
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(Annotations, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(Labels, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	in.Resources.DeepCopyInto(&out.Resources)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirroringSpec.
-func (in *RBDMirroringSpec) DeepCopy() *RBDMirroringSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RadosNamespaceMirroringInfoSpec.
+func (in *RadosNamespaceMirroringInfoSpec) DeepCopy() *RadosNamespaceMirroringInfoSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(RBDMirroringSpec)
+	out := new(RadosNamespaceMirroringInfoSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RGWServiceSpec) DeepCopyInto(out *RGWServiceSpec) {
+func (in *ReadAffinitySpec) DeepCopyInto(out *ReadAffinitySpec) {
 	*out = *in
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(Annotations, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.CrushLocationLabels != nil {
+		in, out := &in.CrushLocationLabels, &out.CrushLocationLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RGWServiceSpec.
-func (in *RGWServiceSpec) DeepCopy() *RGWServiceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadAffinitySpec.
+func (in *ReadAffinitySpec) DeepCopy() *ReadAffinitySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(RGWServiceSpec)
+	out := new(ReadAffinitySpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -3360,6 +6255,22 @@ func (in *ReplicatedSpec) DeepCopy() *ReplicatedSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationClassesSpec) DeepCopyInto(out *ReplicationClassesSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationClassesSpec.
+func (in *ReplicationClassesSpec) DeepCopy() *ReplicationClassesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationClassesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in ResourceSpec) DeepCopyInto(out *ResourceSpec) {
 	{
@@ -3382,6 +6293,23 @@ func (in ResourceSpec) DeepCopy() ResourceSpec {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SNMPGatewaySpec) DeepCopyInto(out *SNMPGatewaySpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SNMPGatewaySpec.
+func (in *SNMPGatewaySpec) DeepCopy() *SNMPGatewaySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SNMPGatewaySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SanitizeDisksSpec) DeepCopyInto(out *SanitizeDisksSpec) {
 	*out = *in
@@ -3398,10 +6326,80 @@ func (in *SanitizeDisksSpec) DeepCopy() *SanitizeDisksSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityProfileSpec) DeepCopyInto(out *SecurityProfileSpec) {
+	*out = *in
+	if in.Seccomp != nil {
+		in, out := &in.Seccomp, &out.Seccomp
+		*out = new(corev1.SeccompProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityProfileSpec.
+func (in *SecurityProfileSpec) DeepCopy() *SecurityProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in SecurityProfilesSpec) DeepCopyInto(out *SecurityProfilesSpec) {
+	{
+		in := &in
+		*out = make(SecurityProfilesSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityProfilesSpec.
+func (in SecurityProfilesSpec) DeepCopy() SecurityProfilesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityProfilesSpec)
+	in.DeepCopyInto(out)
+	return *out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecuritySpec) DeepCopyInto(out *SecuritySpec) {
 	*out = *in
 	in.KeyManagementService.DeepCopyInto(&out.KeyManagementService)
+	if in.Cephx != nil {
+		in, out := &in.Cephx, &out.Cephx
+		*out = new(ClusterCephxSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FIPS != nil {
+		in, out := &in.FIPS, &out.FIPS
+		*out = new(FIPSSpec)
+		**out = **in
+	}
+	if in.ExternalSecrets != nil {
+		in, out := &in.ExternalSecrets, &out.ExternalSecrets
+		*out = new(ExternalSecretsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make(SecurityProfilesSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.ImagePolicy != nil {
+		in, out := &in.ImagePolicy, &out.ImagePolicy
+		*out = new(ImagePolicySpec)
+		**out = **in
+	}
 	return
 }
 
@@ -3450,6 +6448,22 @@ func (in *Selection) DeepCopy() *Selection {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotClassesSpec) DeepCopyInto(out *SnapshotClassesSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotClassesSpec.
+func (in *SnapshotClassesSpec) DeepCopy() *SnapshotClassesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotClassesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SnapshotSchedule) DeepCopyInto(out *SnapshotSchedule) {
 	*out = *in
@@ -3558,9 +6572,64 @@ func (in *StatesSpec) DeepCopy() *StatesSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticCephFSVolumeSource) DeepCopyInto(out *StaticCephFSVolumeSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticCephFSVolumeSource.
+func (in *StaticCephFSVolumeSource) DeepCopy() *StaticCephFSVolumeSource {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticCephFSVolumeSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticRBDVolumeSource) DeepCopyInto(out *StaticRBDVolumeSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticRBDVolumeSource.
+func (in *StaticRBDVolumeSource) DeepCopy() *StaticRBDVolumeSource {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticRBDVolumeSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticVolumeClaim) DeepCopyInto(out *StaticVolumeClaim) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticVolumeClaim.
+func (in *StaticVolumeClaim) DeepCopy() *StaticVolumeClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticVolumeClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Status) DeepCopyInto(out *Status) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -3636,6 +6705,7 @@ func (in *StorageScopeSpec) DeepCopyInto(out *StorageScopeSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	out.Migration = in.Migration
 	return
 }
 
@@ -3693,6 +6763,76 @@ func (in *StretchClusterZoneSpec) DeepCopy() *StretchClusterZoneSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskSpec) DeepCopyInto(out *TaskSpec) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskSpec.
+func (in *TaskSpec) DeepCopy() *TaskSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskStatus) DeepCopyInto(out *TaskStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskStatus.
+func (in *TaskStatus) DeepCopy() *TaskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TelemetrySpec) DeepCopyInto(out *TelemetrySpec) {
+	*out = *in
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]TelemetryChannel, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelemetrySpec.
+func (in *TelemetrySpec) DeepCopy() *TelemetrySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TelemetrySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TopicEndpointSpec) DeepCopyInto(out *TopicEndpointSpec) {
 	*out = *in
@@ -3724,6 +6864,66 @@ func (in *TopicEndpointSpec) DeepCopy() *TopicEndpointSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyStorageClassSpec) DeepCopyInto(out *TopologyStorageClassSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyStorageClassSpec.
+func (in *TopologyStorageClassSpec) DeepCopy() *TopologyStorageClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyStorageClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnmanagedResource) DeepCopyInto(out *UnmanagedResource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnmanagedResource.
+func (in *UnmanagedResource) DeepCopy() *UnmanagedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(UnmanagedResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageForecast) DeepCopyInto(out *UsageForecast) {
+	*out = *in
+	if in.DaysUntilFull != nil {
+		in, out := &in.DaysUntilFull, &out.DaysUntilFull
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageForecast.
+func (in *UsageForecast) DeepCopy() *UsageForecast {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageForecast)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ZoneSpec) DeepCopyInto(out *ZoneSpec) {
 	*out = *in
@@ -0,0 +1,201 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2alpha1
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+var _ conversion.Convertible = &CephCluster{}
+var _ conversion.Convertible = &CephObjectStore{}
+
+// ConvertTo converts the CephCluster to the v1 hub version
+func (c *CephCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*cephv1.CephCluster)
+	if !ok {
+		return errors.Errorf("cannot convert CephCluster to %T", dstRaw)
+	}
+	dst.ObjectMeta = c.ObjectMeta
+	dst.Status = c.Status
+
+	src := c.Spec
+	dst.Spec = cephv1.ClusterSpec{
+		CephVersion:        src.CephVersion,
+		Storage:            src.Storage,
+		Annotations:        src.Annotations,
+		Labels:             src.Labels,
+		Placement:          src.Placement,
+		Network:            src.Network,
+		Resources:          src.Resources,
+		PriorityClassNames: src.PriorityClassNames,
+		DataDirHostPath:    src.DataDirHostPath,
+		SkipUpgradeChecks:  src.Upgrade.SkipChecks,
+		ContinueUpgradeAfterChecksEvenIfNotHealthy: src.Upgrade.ContinueIfNotHealthy,
+		DisruptionManagement:                       src.DisruptionManagement,
+		Mon:                                        src.Mon,
+		CrashCollector:                             src.CrashCollector,
+		Dashboard:                                  src.Dashboard,
+		Monitoring:                                 src.Monitoring,
+		External:                                   src.External,
+		Mgr:                                        src.Mgr,
+		RemoveOSDsIfOutAndSafeToRemove:             src.RemoveOSDsIfOutAndSafeToRemove,
+		CleanupPolicy:                              src.CleanupPolicy,
+		HealthCheck:                                src.HealthCheck,
+		Security:                                   src.Security,
+		LogCollector:                               src.LogCollector,
+		CSI:                                        src.CSI,
+		Tasks:                                      src.Tasks,
+		Adoption:                                   src.Adoption,
+	}
+	// v1 stores the timeout as a raw number of minutes
+	if src.Upgrade.WaitTimeoutForHealthyOSD != nil {
+		dst.Spec.WaitTimeoutForHealthyOSDInMinutes = src.Upgrade.WaitTimeoutForHealthyOSD.Duration / time.Minute
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1 hub version to the CephCluster
+func (c *CephCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*cephv1.CephCluster)
+	if !ok {
+		return errors.Errorf("cannot convert %T to CephCluster", srcRaw)
+	}
+	c.ObjectMeta = src.ObjectMeta
+	c.Status = src.Status
+
+	spec := src.Spec
+	c.Spec = ClusterSpec{
+		CephVersion:        spec.CephVersion,
+		Storage:            spec.Storage,
+		Annotations:        spec.Annotations,
+		Labels:             spec.Labels,
+		Placement:          spec.Placement,
+		Network:            spec.Network,
+		Resources:          spec.Resources,
+		PriorityClassNames: spec.PriorityClassNames,
+		DataDirHostPath:    spec.DataDirHostPath,
+		Upgrade: UpgradeSpec{
+			SkipChecks:           spec.SkipUpgradeChecks,
+			ContinueIfNotHealthy: spec.ContinueUpgradeAfterChecksEvenIfNotHealthy,
+		},
+		DisruptionManagement:           spec.DisruptionManagement,
+		Mon:                            spec.Mon,
+		CrashCollector:                 spec.CrashCollector,
+		Dashboard:                      spec.Dashboard,
+		Monitoring:                     spec.Monitoring,
+		External:                       spec.External,
+		Mgr:                            spec.Mgr,
+		RemoveOSDsIfOutAndSafeToRemove: spec.RemoveOSDsIfOutAndSafeToRemove,
+		CleanupPolicy:                  spec.CleanupPolicy,
+		HealthCheck:                    spec.HealthCheck,
+		Security:                       spec.Security,
+		LogCollector:                   spec.LogCollector,
+		CSI:                            spec.CSI,
+		Tasks:                          spec.Tasks,
+		Adoption:                       spec.Adoption,
+	}
+	if spec.WaitTimeoutForHealthyOSDInMinutes != 0 {
+		c.Spec.Upgrade.WaitTimeoutForHealthyOSD = &metav1.Duration{Duration: spec.WaitTimeoutForHealthyOSDInMinutes * time.Minute}
+	}
+	return nil
+}
+
+// ConvertTo converts the CephObjectStore to the v1 hub version
+func (s *CephObjectStore) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*cephv1.CephObjectStore)
+	if !ok {
+		return errors.Errorf("cannot convert CephObjectStore to %T", dstRaw)
+	}
+	dst.ObjectMeta = s.ObjectMeta
+	dst.Status = s.Status
+
+	src := s.Spec
+	dst.Spec = cephv1.ObjectStoreSpec{
+		MetadataPool:          src.MetadataPool,
+		DataPool:              src.DataPool,
+		PreservePoolsOnDelete: src.PreservePoolsOnDelete,
+		Gateway: cephv1.GatewaySpec{
+			Port:                 src.Gateway.Port,
+			Instances:            src.Gateway.Instances,
+			CaBundleRef:          src.Gateway.CaBundleRef,
+			Placement:            src.Gateway.Placement,
+			Annotations:          src.Gateway.Annotations,
+			Labels:               src.Gateway.Labels,
+			Resources:            src.Gateway.Resources,
+			PriorityClassName:    src.Gateway.PriorityClassName,
+			ExternalRgwEndpoints: src.Gateway.ExternalRgwEndpoints,
+			Service:              src.Gateway.Service,
+		},
+		Zone:        src.Zone,
+		HealthCheck: src.HealthCheck,
+		Security:    src.Security,
+		Monitoring:  src.Monitoring,
+		Tasks:       src.Tasks,
+	}
+	if src.Gateway.TLS != nil {
+		dst.Spec.Gateway.SecurePort = src.Gateway.TLS.Port
+		dst.Spec.Gateway.SSLCertificateRef = src.Gateway.TLS.CertificateRef
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1 hub version to the CephObjectStore
+func (s *CephObjectStore) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*cephv1.CephObjectStore)
+	if !ok {
+		return errors.Errorf("cannot convert %T to CephObjectStore", srcRaw)
+	}
+	s.ObjectMeta = src.ObjectMeta
+	s.Status = src.Status
+
+	spec := src.Spec
+	s.Spec = ObjectStoreSpec{
+		MetadataPool:          spec.MetadataPool,
+		DataPool:              spec.DataPool,
+		PreservePoolsOnDelete: spec.PreservePoolsOnDelete,
+		Gateway: GatewaySpec{
+			Port:                 spec.Gateway.Port,
+			Instances:            spec.Gateway.Instances,
+			CaBundleRef:          spec.Gateway.CaBundleRef,
+			Placement:            spec.Gateway.Placement,
+			Annotations:          spec.Gateway.Annotations,
+			Labels:               spec.Gateway.Labels,
+			Resources:            spec.Gateway.Resources,
+			PriorityClassName:    spec.Gateway.PriorityClassName,
+			ExternalRgwEndpoints: spec.Gateway.ExternalRgwEndpoints,
+			Service:              spec.Gateway.Service,
+		},
+		Zone:        spec.Zone,
+		HealthCheck: spec.HealthCheck,
+		Security:    spec.Security,
+		Monitoring:  spec.Monitoring,
+		Tasks:       spec.Tasks,
+	}
+	if spec.Gateway.SecurePort != 0 || spec.Gateway.SSLCertificateRef != "" {
+		s.Spec.Gateway.TLS = &GatewayTLSSpec{
+			Port:           spec.Gateway.SecurePort,
+			CertificateRef: spec.Gateway.SSLCertificateRef,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+func TestCephClusterConversion(t *testing.T) {
+	hub := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "rook-ceph"},
+		Spec: cephv1.ClusterSpec{
+			CephVersion:       cephv1.CephVersionSpec{Image: "quay.io/ceph/ceph:v16.2.7"},
+			DataDirHostPath:   "/var/lib/rook",
+			SkipUpgradeChecks: true,
+			ContinueUpgradeAfterChecksEvenIfNotHealthy: true,
+			WaitTimeoutForHealthyOSDInMinutes:          90,
+			Mon:                                        cephv1.MonSpec{Count: 3},
+		},
+		Status: cephv1.ClusterStatus{Phase: cephv1.ConditionReady},
+	}
+
+	t.Run("from v1", func(t *testing.T) {
+		c := &CephCluster{}
+		assert.NoError(t, c.ConvertFrom(hub))
+		assert.Equal(t, "my-cluster", c.Name)
+		assert.Equal(t, "/var/lib/rook", c.Spec.DataDirHostPath)
+		assert.Equal(t, 3, c.Spec.Mon.Count)
+		assert.True(t, c.Spec.Upgrade.SkipChecks)
+		assert.True(t, c.Spec.Upgrade.ContinueIfNotHealthy)
+		assert.Equal(t, 90*time.Minute, c.Spec.Upgrade.WaitTimeoutForHealthyOSD.Duration)
+		assert.Equal(t, "1h30m0s", c.Spec.Upgrade.WaitTimeoutForHealthyOSD.Duration.String())
+		assert.Equal(t, cephv1.ConditionReady, c.Status.Phase)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		c := &CephCluster{}
+		assert.NoError(t, c.ConvertFrom(hub))
+		back := &cephv1.CephCluster{}
+		assert.NoError(t, c.ConvertTo(back))
+		assert.Equal(t, hub, back)
+	})
+
+	t.Run("unset timeout", func(t *testing.T) {
+		c := &CephCluster{}
+		assert.NoError(t, c.ConvertFrom(&cephv1.CephCluster{}))
+		assert.Nil(t, c.Spec.Upgrade.WaitTimeoutForHealthyOSD)
+		back := &cephv1.CephCluster{}
+		assert.NoError(t, c.ConvertTo(back))
+		assert.Equal(t, time.Duration(0), back.Spec.WaitTimeoutForHealthyOSDInMinutes)
+	})
+
+	t.Run("wrong hub", func(t *testing.T) {
+		c := &CephCluster{}
+		assert.Error(t, c.ConvertFrom(&cephv1.CephObjectStore{}))
+		assert.Error(t, c.ConvertTo(&cephv1.CephObjectStore{}))
+	})
+}
+
+func TestCephObjectStoreConversion(t *testing.T) {
+	hub := &cephv1.CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"},
+		Spec: cephv1.ObjectStoreSpec{
+			PreservePoolsOnDelete: true,
+			Gateway: cephv1.GatewaySpec{
+				Port:              80,
+				SecurePort:        443,
+				SSLCertificateRef: "my-cert",
+				Instances:         2,
+			},
+		},
+		Status: &cephv1.ObjectStoreStatus{Phase: cephv1.ConditionReady},
+	}
+
+	t.Run("from v1", func(t *testing.T) {
+		s := &CephObjectStore{}
+		assert.NoError(t, s.ConvertFrom(hub))
+		assert.Equal(t, int32(80), s.Spec.Gateway.Port)
+		assert.Equal(t, int32(2), s.Spec.Gateway.Instances)
+		assert.Equal(t, &GatewayTLSSpec{Port: 443, CertificateRef: "my-cert"}, s.Spec.Gateway.TLS)
+		assert.True(t, s.Spec.PreservePoolsOnDelete)
+		assert.Equal(t, cephv1.ConditionReady, s.Status.Phase)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		s := &CephObjectStore{}
+		assert.NoError(t, s.ConvertFrom(hub))
+		back := &cephv1.CephObjectStore{}
+		assert.NoError(t, s.ConvertTo(back))
+		assert.Equal(t, hub, back)
+	})
+
+	t.Run("no tls", func(t *testing.T) {
+		s := &CephObjectStore{}
+		assert.NoError(t, s.ConvertFrom(&cephv1.CephObjectStore{Spec: cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{Port: 80}}}))
+		assert.Nil(t, s.Spec.Gateway.TLS)
+	})
+
+	t.Run("wrong hub", func(t *testing.T) {
+		s := &CephObjectStore{}
+		assert.Error(t, s.ConvertFrom(&cephv1.CephCluster{}))
+		assert.Error(t, s.ConvertTo(&cephv1.CephCluster{}))
+	})
+}
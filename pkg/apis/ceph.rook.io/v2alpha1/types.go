@@ -0,0 +1,357 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephCluster is a Ceph storage cluster
+// +kubebuilder:printcolumn:name="DataDirHostPath",type=string,JSONPath=`.spec.dataDirHostPath`,description="Directory used on the K8s nodes"
+// +kubebuilder:printcolumn:name="MonCount",type=string,JSONPath=`.spec.mon.count`,description="Number of MONs"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="Phase"
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,description="Message"
+// +kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.ceph.health`,description="Ceph Health"
+// +kubebuilder:printcolumn:name="External",type=boolean,JSONPath=`.spec.external.enable`
+// +kubebuilder:subresource:status
+// +kubebuilder:unservedversion
+type CephCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ClusterSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	// +nullable
+	Status cephv1.ClusterStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephClusterList is a list of CephCluster
+type CephClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephCluster `json:"items"`
+}
+
+// ClusterSpec represents the specification of Ceph Cluster
+type ClusterSpec struct {
+	// The version information that instructs Rook to orchestrate a particular version of Ceph.
+	// +optional
+	// +nullable
+	CephVersion cephv1.CephVersionSpec `json:"cephVersion,omitempty"`
+
+	// A spec for available storage in the cluster and how it should be used
+	// +optional
+	// +nullable
+	Storage cephv1.StorageScopeSpec `json:"storage,omitempty"`
+
+	// The annotations-related configuration to add/set on each Pod related object.
+	// +nullable
+	// +optional
+	Annotations cephv1.AnnotationsSpec `json:"annotations,omitempty"`
+
+	// The labels-related configuration to add/set on each Pod related object.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	Labels cephv1.LabelsSpec `json:"labels,omitempty"`
+
+	// The placement-related configuration to pass to kubernetes (affinity, node selector, tolerations).
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	Placement cephv1.PlacementSpec `json:"placement,omitempty"`
+
+	// Network related configuration
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	Network cephv1.NetworkSpec `json:"network,omitempty"`
+
+	// Resources set resource requests and limits
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	Resources cephv1.ResourceSpec `json:"resources,omitempty"`
+
+	// PriorityClassNames sets priority classes on components
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	PriorityClassNames cephv1.PriorityClassNamesSpec `json:"priorityClassNames,omitempty"`
+
+	// The path on the host where config and data can be persisted
+	// +kubebuilder:validation:Pattern=`^/(\S+)`
+	// +optional
+	DataDirHostPath string `json:"dataDirHostPath,omitempty"`
+
+	// Upgrade holds the settings of the upgrades of the Ceph daemons
+	// +optional
+	// +nullable
+	Upgrade UpgradeSpec `json:"upgrade,omitempty"`
+
+	// A spec for configuring disruption management.
+	// +nullable
+	// +optional
+	DisruptionManagement cephv1.DisruptionManagementSpec `json:"disruptionManagement,omitempty"`
+
+	// A spec for mon related options
+	// +optional
+	// +nullable
+	Mon cephv1.MonSpec `json:"mon,omitempty"`
+
+	// A spec for the crash controller
+	// +optional
+	// +nullable
+	CrashCollector cephv1.CrashCollectorSpec `json:"crashCollector,omitempty"`
+
+	// Dashboard settings
+	// +optional
+	// +nullable
+	Dashboard cephv1.DashboardSpec `json:"dashboard,omitempty"`
+
+	// Prometheus based Monitoring settings
+	// +optional
+	// +nullable
+	Monitoring cephv1.MonitoringSpec `json:"monitoring,omitempty"`
+
+	// Whether the Ceph Cluster is running external to this Kubernetes cluster
+	// mon, mgr, osd, mds, and discover daemons will not be created for external clusters.
+	// +optional
+	// +nullable
+	External cephv1.ExternalSpec `json:"external,omitempty"`
+
+	// A spec for mgr related options
+	// +optional
+	// +nullable
+	Mgr cephv1.MgrSpec `json:"mgr,omitempty"`
+
+	// Remove the OSD that is out and safe to remove only if this option is true
+	// +optional
+	RemoveOSDsIfOutAndSafeToRemove bool `json:"removeOSDsIfOutAndSafeToRemove,omitempty"`
+
+	// Indicates user intent when deleting a cluster; blocks orchestration and should not be set if cluster
+	// deletion is not imminent.
+	// +optional
+	// +nullable
+	CleanupPolicy cephv1.CleanupPolicySpec `json:"cleanupPolicy,omitempty"`
+
+	// Internal daemon healthchecks and liveness probe
+	// +optional
+	// +nullable
+	HealthCheck cephv1.CephClusterHealthCheckSpec `json:"healthCheck,omitempty"`
+
+	// Security represents security settings
+	// +optional
+	// +nullable
+	Security cephv1.SecuritySpec `json:"security,omitempty"`
+
+	// Logging represents loggings settings
+	// +optional
+	// +nullable
+	LogCollector cephv1.LogCollectorSpec `json:"logCollector,omitempty"`
+
+	// CSI represents the ceph-csi settings of the cluster
+	// +optional
+	// +nullable
+	CSI cephv1.CSIDriverSpec `json:"csi,omitempty"`
+
+	// Tasks are the long-running operations run in jobs on the cluster, like purging OSDs
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Tasks []cephv1.TaskSpec `json:"tasks,omitempty"`
+
+	// Adoption discovers the pools, object store users, subvolume groups and rados namespaces of the
+	// cluster that are not managed by a CR
+	// +optional
+	// +nullable
+	Adoption *cephv1.AdoptionSpec `json:"adoption,omitempty"`
+}
+
+// UpgradeSpec represents the settings of the upgrades of the Ceph daemons
+type UpgradeSpec struct {
+	// SkipChecks defines if an upgrade should be forced even if one of the check fails
+	// +optional
+	SkipChecks bool `json:"skipChecks,omitempty"`
+
+	// ContinueIfNotHealthy defines if an upgrade should continue even if PGs are not clean
+	// +optional
+	ContinueIfNotHealthy bool `json:"continueIfNotHealthy,omitempty"`
+
+	// WaitTimeoutForHealthyOSD defines the time the operator would wait before an OSD can be stopped for upgrade or restart.
+	// If the timeout exceeds and OSD is not ok to stop, then the operator would skip upgrade for the current OSD and proceed with the next one
+	// if `continueIfNotHealthy` is `false`. If `continueIfNotHealthy` is `true`, then operator would
+	// continue with the upgrade of an OSD even if its not ok to stop after the timeout. This timeout won't be applied if `skipChecks` is `true`.
+	// The timeout is a whole number of minutes, e.g. "10m" or "1h30m". The default wait timeout is 10 minutes.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^([0-9]+h)?([0-9]+m)?(0s)?$`
+	// +optional
+	// +nullable
+	WaitTimeoutForHealthyOSD *metav1.Duration `json:"waitTimeoutForHealthyOSD,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephObjectStore represents a Ceph Object Store Gateway
+// +kubebuilder:subresource:status
+// +kubebuilder:unservedversion
+type CephObjectStore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectStoreSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Status *cephv1.ObjectStoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephObjectStoreList represents a Ceph Object Store Gateways
+type CephObjectStoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephObjectStore `json:"items"`
+}
+
+// ObjectStoreSpec represent the spec of a pool
+type ObjectStoreSpec struct {
+	// The metadata pool settings
+	// +optional
+	// +nullable
+	MetadataPool cephv1.PoolSpec `json:"metadataPool,omitempty"`
+
+	// The data pool settings
+	// +optional
+	// +nullable
+	DataPool cephv1.PoolSpec `json:"dataPool,omitempty"`
+
+	// Preserve pools on object store deletion
+	// +optional
+	PreservePoolsOnDelete bool `json:"preservePoolsOnDelete,omitempty"`
+
+	// The rgw pod info
+	// +optional
+	// +nullable
+	Gateway GatewaySpec `json:"gateway"`
+
+	// The multisite info
+	// +optional
+	// +nullable
+	Zone cephv1.ZoneSpec `json:"zone,omitempty"`
+
+	// The rgw Bucket healthchecks and liveness probe
+	// +optional
+	// +nullable
+	HealthCheck cephv1.BucketHealthCheckSpec `json:"healthCheck,omitempty"`
+
+	// Security represents security settings
+	// +optional
+	// +nullable
+	Security *cephv1.SecuritySpec `json:"security,omitempty"`
+
+	// Monitoring of the usage of the users and the buckets of the object store
+	// +optional
+	// +nullable
+	Monitoring *cephv1.ObjectStoreMonitoringSpec `json:"monitoring,omitempty"`
+
+	// Tasks are the long-running operations run in jobs on the object store, like resharding a bucket
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Tasks []cephv1.TaskSpec `json:"tasks,omitempty"`
+}
+
+// GatewaySpec represents the specification of Ceph Object Store Gateway
+type GatewaySpec struct {
+	// The port the rgw service will be listening on (http)
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// The https settings of the rgw service
+	// +optional
+	// +nullable
+	TLS *GatewayTLSSpec `json:"tls,omitempty"`
+
+	// The number of pods in the rgw replicaset.
+	// +nullable
+	// +optional
+	Instances int32 `json:"instances,omitempty"`
+
+	// The name of the secret that stores custom ca-bundle with root and intermediate certificates.
+	// +nullable
+	// +optional
+	CaBundleRef string `json:"caBundleRef,omitempty"`
+
+	// The affinity to place the rgw pods (default is to place on any available node)
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	Placement cephv1.Placement `json:"placement,omitempty"`
+
+	// The annotations-related configuration to add/set on each Pod related object.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	Annotations cephv1.Annotations `json:"annotations,omitempty"`
+
+	// The labels-related configuration to add/set on each Pod related object.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	Labels cephv1.Labels `json:"labels,omitempty"`
+
+	// The resource requirements for the rgw pods
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// PriorityClassName sets priority classes on the rgw pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// ExternalRgwEndpoints points to external rgw endpoint(s)
+	// +nullable
+	// +optional
+	ExternalRgwEndpoints []corev1.EndpointAddress `json:"externalRgwEndpoints,omitempty"`
+
+	// The configuration related to add/set on each rgw service.
+	// +optional
+	// +nullable
+	Service *cephv1.RGWServiceSpec `json:"service,omitempty"`
+}
+
+// GatewayTLSSpec represents the https settings of the rgw service
+// +kubebuilder:validation:MinProperties=1
+type GatewayTLSSpec struct {
+	// The port the rgw service will be listening on (https)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// The name of the secret that stores the ssl certificate for secure rgw connections
+	// +optional
+	CertificateRef string `json:"certificateRef,omitempty"`
+}
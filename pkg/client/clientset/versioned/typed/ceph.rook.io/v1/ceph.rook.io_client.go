@@ -27,12 +27,17 @@ import (
 type CephV1Interface interface {
 	RESTClient() rest.Interface
 	CephBlockPoolsGetter
+	CephBlockPoolRadosNamespacesGetter
+	CephBlockPoolTopologiesGetter
 	CephBucketNotificationsGetter
 	CephBucketTopicsGetter
+	CephCSIDriversGetter
 	CephClientsGetter
 	CephClustersGetter
+	CephDRActionsGetter
 	CephFilesystemsGetter
 	CephFilesystemMirrorsGetter
+	CephFilesystemSubVolumesGetter
 	CephFilesystemSubVolumeGroupsGetter
 	CephNFSesGetter
 	CephObjectRealmsGetter
@@ -40,7 +45,11 @@ type CephV1Interface interface {
 	CephObjectStoreUsersGetter
 	CephObjectZonesGetter
 	CephObjectZoneGroupsGetter
+	CephOperatorConfigsGetter
 	CephRBDMirrorsGetter
+	CephRBDMirrorPeersGetter
+	CephRBDMirrorPeerTokensGetter
+	CephStaticVolumesGetter
 }
 
 // CephV1Client is used to interact with features provided by the ceph.rook.io group.
@@ -52,6 +61,14 @@ func (c *CephV1Client) CephBlockPools(namespace string) CephBlockPoolInterface {
 	return newCephBlockPools(c, namespace)
 }
 
+func (c *CephV1Client) CephBlockPoolRadosNamespaces(namespace string) CephBlockPoolRadosNamespaceInterface {
+	return newCephBlockPoolRadosNamespaces(c, namespace)
+}
+
+func (c *CephV1Client) CephBlockPoolTopologies(namespace string) CephBlockPoolTopologyInterface {
+	return newCephBlockPoolTopologies(c, namespace)
+}
+
 func (c *CephV1Client) CephBucketNotifications(namespace string) CephBucketNotificationInterface {
 	return newCephBucketNotifications(c, namespace)
 }
@@ -60,6 +77,10 @@ func (c *CephV1Client) CephBucketTopics(namespace string) CephBucketTopicInterfa
 	return newCephBucketTopics(c, namespace)
 }
 
+func (c *CephV1Client) CephCSIDrivers(namespace string) CephCSIDriverInterface {
+	return newCephCSIDrivers(c, namespace)
+}
+
 func (c *CephV1Client) CephClients(namespace string) CephClientInterface {
 	return newCephClients(c, namespace)
 }
@@ -68,6 +89,10 @@ func (c *CephV1Client) CephClusters(namespace string) CephClusterInterface {
 	return newCephClusters(c, namespace)
 }
 
+func (c *CephV1Client) CephDRActions(namespace string) CephDRActionInterface {
+	return newCephDRActions(c, namespace)
+}
+
 func (c *CephV1Client) CephFilesystems(namespace string) CephFilesystemInterface {
 	return newCephFilesystems(c, namespace)
 }
@@ -76,6 +101,10 @@ func (c *CephV1Client) CephFilesystemMirrors(namespace string) CephFilesystemMir
 	return newCephFilesystemMirrors(c, namespace)
 }
 
+func (c *CephV1Client) CephFilesystemSubVolumes(namespace string) CephFilesystemSubVolumeInterface {
+	return newCephFilesystemSubVolumes(c, namespace)
+}
+
 func (c *CephV1Client) CephFilesystemSubVolumeGroups(namespace string) CephFilesystemSubVolumeGroupInterface {
 	return newCephFilesystemSubVolumeGroups(c, namespace)
 }
@@ -104,10 +133,26 @@ func (c *CephV1Client) CephObjectZoneGroups(namespace string) CephObjectZoneGrou
 	return newCephObjectZoneGroups(c, namespace)
 }
 
+func (c *CephV1Client) CephOperatorConfigs(namespace string) CephOperatorConfigInterface {
+	return newCephOperatorConfigs(c, namespace)
+}
+
 func (c *CephV1Client) CephRBDMirrors(namespace string) CephRBDMirrorInterface {
 	return newCephRBDMirrors(c, namespace)
 }
 
+func (c *CephV1Client) CephRBDMirrorPeers(namespace string) CephRBDMirrorPeerInterface {
+	return newCephRBDMirrorPeers(c, namespace)
+}
+
+func (c *CephV1Client) CephRBDMirrorPeerTokens(namespace string) CephRBDMirrorPeerTokenInterface {
+	return newCephRBDMirrorPeerTokens(c, namespace)
+}
+
+func (c *CephV1Client) CephStaticVolumes(namespace string) CephStaticVolumeInterface {
+	return newCephStaticVolumes(c, namespace)
+}
+
 // NewForConfig creates a new CephV1Client for the given config.
 func NewForConfig(c *rest.Config) (*CephV1Client, error) {
 	config := *c
@@ -32,6 +32,14 @@ func (c *FakeCephV1) CephBlockPools(namespace string) v1.CephBlockPoolInterface
 	return &FakeCephBlockPools{c, namespace}
 }
 
+func (c *FakeCephV1) CephBlockPoolRadosNamespaces(namespace string) v1.CephBlockPoolRadosNamespaceInterface {
+	return &FakeCephBlockPoolRadosNamespaces{c, namespace}
+}
+
+func (c *FakeCephV1) CephBlockPoolTopologies(namespace string) v1.CephBlockPoolTopologyInterface {
+	return &FakeCephBlockPoolTopologies{c, namespace}
+}
+
 func (c *FakeCephV1) CephBucketNotifications(namespace string) v1.CephBucketNotificationInterface {
 	return &FakeCephBucketNotifications{c, namespace}
 }
@@ -40,6 +48,10 @@ func (c *FakeCephV1) CephBucketTopics(namespace string) v1.CephBucketTopicInterf
 	return &FakeCephBucketTopics{c, namespace}
 }
 
+func (c *FakeCephV1) CephCSIDrivers(namespace string) v1.CephCSIDriverInterface {
+	return &FakeCephCSIDrivers{c, namespace}
+}
+
 func (c *FakeCephV1) CephClients(namespace string) v1.CephClientInterface {
 	return &FakeCephClients{c, namespace}
 }
@@ -48,6 +60,10 @@ func (c *FakeCephV1) CephClusters(namespace string) v1.CephClusterInterface {
 	return &FakeCephClusters{c, namespace}
 }
 
+func (c *FakeCephV1) CephDRActions(namespace string) v1.CephDRActionInterface {
+	return &FakeCephDRActions{c, namespace}
+}
+
 func (c *FakeCephV1) CephFilesystems(namespace string) v1.CephFilesystemInterface {
 	return &FakeCephFilesystems{c, namespace}
 }
@@ -56,6 +72,10 @@ func (c *FakeCephV1) CephFilesystemMirrors(namespace string) v1.CephFilesystemMi
 	return &FakeCephFilesystemMirrors{c, namespace}
 }
 
+func (c *FakeCephV1) CephFilesystemSubVolumes(namespace string) v1.CephFilesystemSubVolumeInterface {
+	return &FakeCephFilesystemSubVolumes{c, namespace}
+}
+
 func (c *FakeCephV1) CephFilesystemSubVolumeGroups(namespace string) v1.CephFilesystemSubVolumeGroupInterface {
 	return &FakeCephFilesystemSubVolumeGroups{c, namespace}
 }
@@ -84,10 +104,26 @@ func (c *FakeCephV1) CephObjectZoneGroups(namespace string) v1.CephObjectZoneGro
 	return &FakeCephObjectZoneGroups{c, namespace}
 }
 
+func (c *FakeCephV1) CephOperatorConfigs(namespace string) v1.CephOperatorConfigInterface {
+	return &FakeCephOperatorConfigs{c, namespace}
+}
+
 func (c *FakeCephV1) CephRBDMirrors(namespace string) v1.CephRBDMirrorInterface {
 	return &FakeCephRBDMirrors{c, namespace}
 }
 
+func (c *FakeCephV1) CephRBDMirrorPeers(namespace string) v1.CephRBDMirrorPeerInterface {
+	return &FakeCephRBDMirrorPeers{c, namespace}
+}
+
+func (c *FakeCephV1) CephRBDMirrorPeerTokens(namespace string) v1.CephRBDMirrorPeerTokenInterface {
+	return &FakeCephRBDMirrorPeerTokens{c, namespace}
+}
+
+func (c *FakeCephV1) CephStaticVolumes(namespace string) v1.CephStaticVolumeInterface {
+	return &FakeCephStaticVolumes{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeCephV1) RESTClient() rest.Interface {
@@ -20,18 +20,28 @@ package v1
 
 type CephBlockPoolExpansion interface{}
 
+type CephBlockPoolRadosNamespaceExpansion interface{}
+
+type CephBlockPoolTopologyExpansion interface{}
+
 type CephBucketNotificationExpansion interface{}
 
 type CephBucketTopicExpansion interface{}
 
+type CephCSIDriverExpansion interface{}
+
 type CephClientExpansion interface{}
 
 type CephClusterExpansion interface{}
 
+type CephDRActionExpansion interface{}
+
 type CephFilesystemExpansion interface{}
 
 type CephFilesystemMirrorExpansion interface{}
 
+type CephFilesystemSubVolumeExpansion interface{}
+
 type CephFilesystemSubVolumeGroupExpansion interface{}
 
 type CephNFSExpansion interface{}
@@ -46,4 +56,12 @@ type CephObjectZoneExpansion interface{}
 
 type CephObjectZoneGroupExpansion interface{}
 
+type CephOperatorConfigExpansion interface{}
+
 type CephRBDMirrorExpansion interface{}
+
+type CephRBDMirrorPeerExpansion interface{}
+
+type CephRBDMirrorPeerTokenExpansion interface{}
+
+type CephStaticVolumeExpansion interface{}
@@ -26,18 +26,28 @@ import (
 type Interface interface {
 	// CephBlockPools returns a CephBlockPoolInformer.
 	CephBlockPools() CephBlockPoolInformer
+	// CephBlockPoolRadosNamespaces returns a CephBlockPoolRadosNamespaceInformer.
+	CephBlockPoolRadosNamespaces() CephBlockPoolRadosNamespaceInformer
+	// CephBlockPoolTopologies returns a CephBlockPoolTopologyInformer.
+	CephBlockPoolTopologies() CephBlockPoolTopologyInformer
 	// CephBucketNotifications returns a CephBucketNotificationInformer.
 	CephBucketNotifications() CephBucketNotificationInformer
 	// CephBucketTopics returns a CephBucketTopicInformer.
 	CephBucketTopics() CephBucketTopicInformer
+	// CephCSIDrivers returns a CephCSIDriverInformer.
+	CephCSIDrivers() CephCSIDriverInformer
 	// CephClients returns a CephClientInformer.
 	CephClients() CephClientInformer
 	// CephClusters returns a CephClusterInformer.
 	CephClusters() CephClusterInformer
+	// CephDRActions returns a CephDRActionInformer.
+	CephDRActions() CephDRActionInformer
 	// CephFilesystems returns a CephFilesystemInformer.
 	CephFilesystems() CephFilesystemInformer
 	// CephFilesystemMirrors returns a CephFilesystemMirrorInformer.
 	CephFilesystemMirrors() CephFilesystemMirrorInformer
+	// CephFilesystemSubVolumes returns a CephFilesystemSubVolumeInformer.
+	CephFilesystemSubVolumes() CephFilesystemSubVolumeInformer
 	// CephFilesystemSubVolumeGroups returns a CephFilesystemSubVolumeGroupInformer.
 	CephFilesystemSubVolumeGroups() CephFilesystemSubVolumeGroupInformer
 	// CephNFSes returns a CephNFSInformer.
@@ -52,8 +62,16 @@ type Interface interface {
 	CephObjectZones() CephObjectZoneInformer
 	// CephObjectZoneGroups returns a CephObjectZoneGroupInformer.
 	CephObjectZoneGroups() CephObjectZoneGroupInformer
+	// CephOperatorConfigs returns a CephOperatorConfigInformer.
+	CephOperatorConfigs() CephOperatorConfigInformer
 	// CephRBDMirrors returns a CephRBDMirrorInformer.
 	CephRBDMirrors() CephRBDMirrorInformer
+	// CephRBDMirrorPeers returns a CephRBDMirrorPeerInformer.
+	CephRBDMirrorPeers() CephRBDMirrorPeerInformer
+	// CephRBDMirrorPeerTokens returns a CephRBDMirrorPeerTokenInformer.
+	CephRBDMirrorPeerTokens() CephRBDMirrorPeerTokenInformer
+	// CephStaticVolumes returns a CephStaticVolumeInformer.
+	CephStaticVolumes() CephStaticVolumeInformer
 }
 
 type version struct {
@@ -72,6 +90,16 @@ func (v *version) CephBlockPools() CephBlockPoolInformer {
 	return &cephBlockPoolInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephBlockPoolRadosNamespaces returns a CephBlockPoolRadosNamespaceInformer.
+func (v *version) CephBlockPoolRadosNamespaces() CephBlockPoolRadosNamespaceInformer {
+	return &cephBlockPoolRadosNamespaceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// CephBlockPoolTopologies returns a CephBlockPoolTopologyInformer.
+func (v *version) CephBlockPoolTopologies() CephBlockPoolTopologyInformer {
+	return &cephBlockPoolTopologyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephBucketNotifications returns a CephBucketNotificationInformer.
 func (v *version) CephBucketNotifications() CephBucketNotificationInformer {
 	return &cephBucketNotificationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -82,6 +110,11 @@ func (v *version) CephBucketTopics() CephBucketTopicInformer {
 	return &cephBucketTopicInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephCSIDrivers returns a CephCSIDriverInformer.
+func (v *version) CephCSIDrivers() CephCSIDriverInformer {
+	return &cephCSIDriverInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephClients returns a CephClientInformer.
 func (v *version) CephClients() CephClientInformer {
 	return &cephClientInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -92,6 +125,11 @@ func (v *version) CephClusters() CephClusterInformer {
 	return &cephClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephDRActions returns a CephDRActionInformer.
+func (v *version) CephDRActions() CephDRActionInformer {
+	return &cephDRActionInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephFilesystems returns a CephFilesystemInformer.
 func (v *version) CephFilesystems() CephFilesystemInformer {
 	return &cephFilesystemInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -102,6 +140,11 @@ func (v *version) CephFilesystemMirrors() CephFilesystemMirrorInformer {
 	return &cephFilesystemMirrorInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephFilesystemSubVolumes returns a CephFilesystemSubVolumeInformer.
+func (v *version) CephFilesystemSubVolumes() CephFilesystemSubVolumeInformer {
+	return &cephFilesystemSubVolumeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephFilesystemSubVolumeGroups returns a CephFilesystemSubVolumeGroupInformer.
 func (v *version) CephFilesystemSubVolumeGroups() CephFilesystemSubVolumeGroupInformer {
 	return &cephFilesystemSubVolumeGroupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -137,7 +180,27 @@ func (v *version) CephObjectZoneGroups() CephObjectZoneGroupInformer {
 	return &cephObjectZoneGroupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephOperatorConfigs returns a CephOperatorConfigInformer.
+func (v *version) CephOperatorConfigs() CephOperatorConfigInformer {
+	return &cephOperatorConfigInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephRBDMirrors returns a CephRBDMirrorInformer.
 func (v *version) CephRBDMirrors() CephRBDMirrorInformer {
 	return &cephRBDMirrorInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// CephRBDMirrorPeers returns a CephRBDMirrorPeerInformer.
+func (v *version) CephRBDMirrorPeers() CephRBDMirrorPeerInformer {
+	return &cephRBDMirrorPeerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// CephRBDMirrorPeerTokens returns a CephRBDMirrorPeerTokenInformer.
+func (v *version) CephRBDMirrorPeerTokens() CephRBDMirrorPeerTokenInformer {
+	return &cephRBDMirrorPeerTokenInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// CephStaticVolumes returns a CephStaticVolumeInformer.
+func (v *version) CephStaticVolumes() CephStaticVolumeInformer {
+	return &cephStaticVolumeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -55,18 +55,28 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=ceph.rook.io, Version=v1
 	case v1.SchemeGroupVersion.WithResource("cephblockpools"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBlockPools().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephblockpoolradosnamespaces"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBlockPoolRadosNamespaces().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephblockpooltopologies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBlockPoolTopologies().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephbucketnotifications"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBucketNotifications().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephbuckettopics"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBucketTopics().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephcsidrivers"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephCSIDrivers().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephclients"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephClients().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephClusters().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephdractions"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephDRActions().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephfilesystems"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephFilesystems().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephfilesystemmirrors"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephFilesystemMirrors().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephfilesystemsubvolumes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephFilesystemSubVolumes().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephfilesystemsubvolumegroups"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephFilesystemSubVolumeGroups().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephnfses"):
@@ -81,8 +91,16 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephObjectZones().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephobjectzonegroups"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephObjectZoneGroups().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephoperatorconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephOperatorConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephrbdmirrors"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephRBDMirrors().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephrbdmirrorpeers"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephRBDMirrorPeers().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephrbdmirrorpeertokens"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephRBDMirrorPeerTokens().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephstaticvolumes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephStaticVolumes().Informer()}, nil
 
 	}
 
@@ -26,6 +26,22 @@ type CephBlockPoolListerExpansion interface{}
 // CephBlockPoolNamespaceLister.
 type CephBlockPoolNamespaceListerExpansion interface{}
 
+// CephBlockPoolRadosNamespaceListerExpansion allows custom methods to be added to
+// CephBlockPoolRadosNamespaceLister.
+type CephBlockPoolRadosNamespaceListerExpansion interface{}
+
+// CephBlockPoolRadosNamespaceNamespaceListerExpansion allows custom methods to be added to
+// CephBlockPoolRadosNamespaceNamespaceLister.
+type CephBlockPoolRadosNamespaceNamespaceListerExpansion interface{}
+
+// CephBlockPoolTopologyListerExpansion allows custom methods to be added to
+// CephBlockPoolTopologyLister.
+type CephBlockPoolTopologyListerExpansion interface{}
+
+// CephBlockPoolTopologyNamespaceListerExpansion allows custom methods to be added to
+// CephBlockPoolTopologyNamespaceLister.
+type CephBlockPoolTopologyNamespaceListerExpansion interface{}
+
 // CephBucketNotificationListerExpansion allows custom methods to be added to
 // CephBucketNotificationLister.
 type CephBucketNotificationListerExpansion interface{}
@@ -42,6 +58,14 @@ type CephBucketTopicListerExpansion interface{}
 // CephBucketTopicNamespaceLister.
 type CephBucketTopicNamespaceListerExpansion interface{}
 
+// CephCSIDriverListerExpansion allows custom methods to be added to
+// CephCSIDriverLister.
+type CephCSIDriverListerExpansion interface{}
+
+// CephCSIDriverNamespaceListerExpansion allows custom methods to be added to
+// CephCSIDriverNamespaceLister.
+type CephCSIDriverNamespaceListerExpansion interface{}
+
 // CephClientListerExpansion allows custom methods to be added to
 // CephClientLister.
 type CephClientListerExpansion interface{}
@@ -58,6 +82,14 @@ type CephClusterListerExpansion interface{}
 // CephClusterNamespaceLister.
 type CephClusterNamespaceListerExpansion interface{}
 
+// CephDRActionListerExpansion allows custom methods to be added to
+// CephDRActionLister.
+type CephDRActionListerExpansion interface{}
+
+// CephDRActionNamespaceListerExpansion allows custom methods to be added to
+// CephDRActionNamespaceLister.
+type CephDRActionNamespaceListerExpansion interface{}
+
 // CephFilesystemListerExpansion allows custom methods to be added to
 // CephFilesystemLister.
 type CephFilesystemListerExpansion interface{}
@@ -74,6 +106,14 @@ type CephFilesystemMirrorListerExpansion interface{}
 // CephFilesystemMirrorNamespaceLister.
 type CephFilesystemMirrorNamespaceListerExpansion interface{}
 
+// CephFilesystemSubVolumeListerExpansion allows custom methods to be added to
+// CephFilesystemSubVolumeLister.
+type CephFilesystemSubVolumeListerExpansion interface{}
+
+// CephFilesystemSubVolumeNamespaceListerExpansion allows custom methods to be added to
+// CephFilesystemSubVolumeNamespaceLister.
+type CephFilesystemSubVolumeNamespaceListerExpansion interface{}
+
 // CephFilesystemSubVolumeGroupListerExpansion allows custom methods to be added to
 // CephFilesystemSubVolumeGroupLister.
 type CephFilesystemSubVolumeGroupListerExpansion interface{}
@@ -130,6 +170,14 @@ type CephObjectZoneGroupListerExpansion interface{}
 // CephObjectZoneGroupNamespaceLister.
 type CephObjectZoneGroupNamespaceListerExpansion interface{}
 
+// CephOperatorConfigListerExpansion allows custom methods to be added to
+// CephOperatorConfigLister.
+type CephOperatorConfigListerExpansion interface{}
+
+// CephOperatorConfigNamespaceListerExpansion allows custom methods to be added to
+// CephOperatorConfigNamespaceLister.
+type CephOperatorConfigNamespaceListerExpansion interface{}
+
 // CephRBDMirrorListerExpansion allows custom methods to be added to
 // CephRBDMirrorLister.
 type CephRBDMirrorListerExpansion interface{}
@@ -137,3 +185,27 @@ type CephRBDMirrorListerExpansion interface{}
 // CephRBDMirrorNamespaceListerExpansion allows custom methods to be added to
 // CephRBDMirrorNamespaceLister.
 type CephRBDMirrorNamespaceListerExpansion interface{}
+
+// CephRBDMirrorPeerListerExpansion allows custom methods to be added to
+// CephRBDMirrorPeerLister.
+type CephRBDMirrorPeerListerExpansion interface{}
+
+// CephRBDMirrorPeerNamespaceListerExpansion allows custom methods to be added to
+// CephRBDMirrorPeerNamespaceLister.
+type CephRBDMirrorPeerNamespaceListerExpansion interface{}
+
+// CephRBDMirrorPeerTokenListerExpansion allows custom methods to be added to
+// CephRBDMirrorPeerTokenLister.
+type CephRBDMirrorPeerTokenListerExpansion interface{}
+
+// CephRBDMirrorPeerTokenNamespaceListerExpansion allows custom methods to be added to
+// CephRBDMirrorPeerTokenNamespaceLister.
+type CephRBDMirrorPeerTokenNamespaceListerExpansion interface{}
+
+// CephStaticVolumeListerExpansion allows custom methods to be added to
+// CephStaticVolumeLister.
+type CephStaticVolumeListerExpansion interface{}
+
+// CephStaticVolumeNamespaceListerExpansion allows custom methods to be added to
+// CephStaticVolumeNamespaceLister.
+type CephStaticVolumeNamespaceListerExpansion interface{}
@@ -17,9 +17,14 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util"
 )
 
 // AuthGetOrCreate will either get or create a user with the given capabilities.  The keyring for the
@@ -104,6 +109,48 @@ func AuthGetCaps(context *clusterd.Context, clusterInfo *ClusterInfo, name strin
 	return caps, err
 }
 
+// AuthRotateKey replaces the key of the given user with a new key, the caps of the user are kept. It
+// returns the new key.
+func AuthRotateKey(context *clusterd.Context, clusterInfo *ClusterInfo, name string) (string, error) {
+	logger.Infof("rotating ceph auth key %q", name)
+	caps, err := AuthGetCaps(context, clusterInfo, name)
+	if err != nil {
+		return "", err
+	}
+	key, err := context.Executor.ExecuteCommandWithOutput("ceph-authtool", "--gen-print-key")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to generate a new key for %s", name)
+	}
+	key = strings.TrimSpace(key)
+
+	// importing a keyring replaces the key and the caps of an existing user
+	keyring := fmt.Sprintf("[%s]\n\tkey = %s\n", name, key)
+	daemons := make([]string, 0, len(caps))
+	for daemon := range caps {
+		daemons = append(daemons, daemon)
+	}
+	sort.Strings(daemons)
+	for _, daemon := range daemons {
+		keyring += fmt.Sprintf("\tcaps %s = \"%s\"\n", daemon, caps[daemon])
+	}
+	file, err := util.CreateTempFile(keyring)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create a temporary keyring file for %s", name)
+	}
+	defer func() {
+		if err := os.Remove(file.Name()); err != nil {
+			logger.Errorf("failed to clean up keyring file %q. %v", file.Name(), err)
+		}
+	}()
+
+	args := []string{"auth", "import", "-i", file.Name()}
+	_, err = NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to import the new key of %s", name)
+	}
+	return key, nil
+}
+
 // AuthDelete will delete the given user.
 func AuthDelete(context *clusterd.Context, clusterInfo *ClusterInfo, name string) error {
 	logger.Infof("deleting ceph auth %q", name)
@@ -17,14 +17,20 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"fmt"
 	"path"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/util/exec"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 // RunAllCephCommandsInToolboxPod - when running the e2e tests, all ceph commands need to be run in the toolbox.
@@ -133,6 +139,80 @@ func (c *CephToolCommand) run() ([]byte, error) {
 		return nil, c.clusterInfo.Context.Err()
 	}
 
+	// A dry run only executes the commands reading the state of the cluster
+	readOnly := isReadOnlyCommand(c.tool, c.args)
+	if dryRun := exec.DryRunFromContext(c.clusterInfo.Context); dryRun != nil && !readOnly {
+		dryRun.Record(c.tool, c.args)
+		return []byte{}, nil
+	}
+
+	// Only the ceph and rbd commands connect to the mons
+	if c.tool != CephTool && c.tool != RBDTool {
+		return c.execute()
+	}
+
+	if exec.CephCommandsCacheTTL > 0 {
+		if isCachedCommand(c.tool, c.args) {
+			return c.runCached(c.runWithRetries)
+		}
+		if !readOnly {
+			// the cached outputs might be outdated once the command changed the cluster
+			commandCache.invalidate(c.clusterInfo.Namespace)
+			defer commandCache.invalidate(c.clusterInfo.Namespace)
+		}
+	}
+	return c.runWithRetries()
+}
+
+// runWithRetries runs a command connecting to the mons, retrying it when it fails to reach them
+func (c *CephToolCommand) runWithRetries() ([]byte, error) {
+
+	breaker := circuitBreaker(c.clusterInfo.Namespace)
+	if err := breaker.Allow(); err != nil {
+		return nil, errors.Wrapf(err, "not running %s %s, the mons of the cluster in namespace %q are unreachable", c.tool, strings.Join(c.args, " "), c.clusterInfo.Namespace)
+	}
+
+	backoff := wait.Backoff{Duration: exec.CephCommandsRetryDelay, Factor: 2, Jitter: 0.1, Steps: exec.CephCommandsRetries}
+	for {
+		output, err := c.execute()
+		if err == nil {
+			breaker.Success()
+			return output, nil
+		}
+		retry, unreachable := classifyCommandError(string(output), err)
+		if !unreachable {
+			// the mons replied, even if with an error
+			breaker.Success()
+		} else {
+			breaker.Failure()
+		}
+		if !retry || backoff.Steps == 0 {
+			return output, err
+		}
+
+		delay := backoff.Step()
+		logger.Infof("%s %s failed to reach the mons, retrying in %s. %v", c.tool, strings.Join(c.args, " "), delay.Round(time.Millisecond), err)
+		select {
+		case <-c.clusterInfo.Context.Done():
+			return output, err
+		case <-time.After(delay):
+		}
+		if err := breaker.Allow(); err != nil {
+			return output, errors.Wrapf(err, "not retrying %s %s, the mons of the cluster in namespace %q are unreachable", c.tool, strings.Join(c.args, " "), c.clusterInfo.Namespace)
+		}
+	}
+}
+
+// execute runs the command once
+func (c *CephToolCommand) execute() ([]byte, error) {
+	// Skip the fork and exec of the CLI for the hot path commands when built with the librados bindings
+	start := time.Now()
+	if output, ok, err := c.runOverRados(); ok {
+		exec.AuditCommand(c.clusterInfo.Context, c.tool, c.args, start, err)
+		exec.TraceCommand(c.clusterInfo.Context, c.tool, c.args, start, err)
+		return []byte(output), err
+	}
+
 	// Initialize the command and args
 	command := c.tool
 	args := c.args
@@ -170,23 +250,40 @@ func (c *CephToolCommand) run() ([]byte, error) {
 				err = errors.Errorf("%s. %s", err.Error(), stderr)
 			}
 		} else if c.timeout == 0 {
-			output, err = c.context.Executor.ExecuteCommandWithOutput(command, args...)
+			output, err = c.executeWithMaxDuration(command, args)
 		} else {
 			output, err = c.context.Executor.ExecuteCommandWithTimeout(c.timeout, command, args...)
 		}
 	} else if c.timeout == 0 {
-		if c.combinedOutput {
-			output, err = c.context.Executor.ExecuteCommandWithCombinedOutput(command, args...)
-		} else {
-			output, err = c.context.Executor.ExecuteCommandWithOutput(command, args...)
-		}
+		output, err = c.executeWithMaxDuration(command, args)
 	} else {
 		output, err = c.context.Executor.ExecuteCommandWithTimeout(c.timeout, command, args...)
 	}
+	exec.AuditCommand(c.clusterInfo.Context, c.tool, c.args, start, err)
+	exec.TraceCommand(c.clusterInfo.Context, c.tool, c.args, start, err)
 
 	return []byte(output), err
 }
 
+// executeWithMaxDuration runs the command without its own timeout, stopping it after the max
+// duration of the commands if the executor supports it
+func (c *CephToolCommand) executeWithMaxDuration(command string, args []string) (string, error) {
+	executor, ok := c.context.Executor.(exec.ContextExecutor)
+	if !ok {
+		if c.combinedOutput {
+			return c.context.Executor.ExecuteCommandWithCombinedOutput(command, args...)
+		}
+		return c.context.Executor.ExecuteCommandWithOutput(command, args...)
+	}
+
+	ctx, cancel := context.WithTimeout(c.clusterInfo.Context, exec.CephCommandsMaxDuration)
+	defer cancel()
+	if c.combinedOutput {
+		return executor.ExecuteCommandWithCombinedOutputContext(ctx, command, args...)
+	}
+	return executor.ExecuteCommandWithOutputContext(ctx, command, args...)
+}
+
 func (c *CephToolCommand) Run() ([]byte, error) {
 	c.timeout = 0
 	return c.run()
@@ -233,3 +330,71 @@ func ExecuteCephCommandWithRetry(
 	}
 	return nil, errors.New("max command retries exceeded")
 }
+
+var (
+	circuitBreakersMutex sync.Mutex
+	// the circuit breakers of the commands keyed by the namespace of the cluster
+	circuitBreakers = map[string]*exec.CircuitBreaker{}
+	// the error of the ceph CLI when it fails to connect to the mons, e.g.
+	// "[errno 110] RADOS timed out (error connecting to the cluster)"
+	connectErrorRegexp = regexp.MustCompile(`\[errno (\d+)\] .*\(error connecting to the cluster\)`)
+)
+
+// circuitBreaker returns the circuit breaker of the commands to the cluster in the namespace
+func circuitBreaker(namespace string) *exec.CircuitBreaker {
+	circuitBreakersMutex.Lock()
+	defer circuitBreakersMutex.Unlock()
+	breaker, ok := circuitBreakers[namespace]
+	if !ok {
+		breaker = exec.NewCircuitBreaker(fmt.Sprintf("the cluster in namespace %q", namespace))
+		circuitBreakers[namespace] = breaker
+	}
+	return breaker
+}
+
+// RemoveCircuitBreaker removes the circuit breaker of the commands to the cluster in the namespace
+func RemoveCircuitBreaker(namespace string) {
+	circuitBreakersMutex.Lock()
+	defer circuitBreakersMutex.Unlock()
+	delete(circuitBreakers, namespace)
+}
+
+// classifyCommandError returns whether a failed command is worth retrying and whether it failed to
+// reach the mons. Only a command failing to connect to the mons is retried since it never ran. A
+// command stopped by a timeout, or exiting with ETIMEDOUT, is not retried since it might still have
+// been applied.
+func classifyCommandError(output string, err error) (bool, bool) {
+	if exec.IsTimeout(err) {
+		return false, true
+	}
+	if match := connectErrorRegexp.FindStringSubmatch(output + " " + err.Error()); match != nil {
+		errno, _ := strconv.Atoi(match[1])
+		if isConnectErrno(syscall.Errno(errno)) {
+			return true, true
+		}
+		// e.g. the keyring is denied, retrying would not help
+		return false, false
+	}
+	if strings.Contains(output, "couldn't connect to the cluster") {
+		// the rbd CLI exits with the errno of the connection
+		if code, ok := exec.ExitStatus(err); ok && isConnectErrno(syscall.Errno(code)) {
+			return true, true
+		}
+		return false, false
+	}
+	if code, ok := exec.ExitStatus(err); ok && syscall.Errno(code) == syscall.ETIMEDOUT {
+		// the mons did not reply in time, but they might have received the command
+		return false, true
+	}
+	return false, false
+}
+
+// isConnectErrno returns whether the errno of a failed connection to the mons means the connection
+// was not established, so the command was never sent
+func isConnectErrno(errno syscall.Errno) bool {
+	switch errno {
+	case syscall.ETIMEDOUT, syscall.EAGAIN, syscall.EINTR, syscall.ECONNREFUSED, syscall.EHOSTUNREACH, syscall.ENETUNREACH, syscall.ENOTCONN:
+		return true
+	}
+	return false
+}
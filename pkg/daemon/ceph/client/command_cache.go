@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rook/rook/pkg/util/exec"
+	"golang.org/x/sync/singleflight"
+)
+
+// the read-only commands repeated by most of the reconciles, whose output is shared by the commands to
+// the same cluster for exec.CephCommandsCacheTTL
+var cachedCommands = [][]string{
+	{"status"},
+	{"osd", "dump"},
+	{"fs", "ls"},
+	{"fs", "dump"},
+	{"mon", "dump"},
+	{"mgr", "dump"},
+	{"versions"},
+}
+
+var commandCache = &cephCommandCache{namespaces: map[string]*namespaceCommandCache{}}
+
+// cephCommandCache caches the output of the cached commands per cluster namespace. The concurrent
+// executions of the same command share a single execution, and the cache of a cluster is invalidated
+// by the commands that may change the cluster.
+type cephCommandCache struct {
+	mutex      sync.Mutex
+	namespaces map[string]*namespaceCommandCache
+	group      singleflight.Group
+}
+
+type namespaceCommandCache struct {
+	// incremented by each invalidation, so an output read before an invalidation is not cached
+	generation uint64
+	entries    map[string]cachedCommandOutput
+}
+
+type cachedCommandOutput struct {
+	output  []byte
+	expires time.Time
+}
+
+// isCachedCommand returns whether the output of the command can be cached
+func isCachedCommand(tool string, args []string) bool {
+	if tool != CephTool {
+		return false
+	}
+	for _, command := range cachedCommands {
+		if sameArgs(args, command) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameArgs(args, command []string) bool {
+	if len(args) != len(command) {
+		return false
+	}
+	for i := range command {
+		if args[i] != command[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *CephToolCommand) cacheKey() string {
+	return fmt.Sprintf("%s %s json=%t remote=%t", c.tool, strings.Join(c.args, " "), c.JsonOutput, c.RemoteExecution)
+}
+
+// get returns the cached output of a command and the generation of the cache of the cluster
+func (cc *cephCommandCache) get(namespace, key string) ([]byte, bool, uint64) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	ns, ok := cc.namespaces[namespace]
+	if !ok {
+		return nil, false, 0
+	}
+	entry, ok := ns.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(ns.entries, key)
+		return nil, false, ns.generation
+	}
+	return entry.output, true, ns.generation
+}
+
+// set caches the output of a command unless the cache of the cluster was invalidated since the
+// command started
+func (cc *cephCommandCache) set(namespace, key string, generation uint64, output []byte, ttl time.Duration) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	ns, ok := cc.namespaces[namespace]
+	if !ok {
+		ns = &namespaceCommandCache{entries: map[string]cachedCommandOutput{}}
+		cc.namespaces[namespace] = ns
+	}
+	if ns.generation != generation {
+		return
+	}
+	ns.entries[key] = cachedCommandOutput{output: output, expires: time.Now().Add(ttl)}
+}
+
+// invalidate removes the cached outputs of the commands to the cluster
+func (cc *cephCommandCache) invalidate(namespace string) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	ns, ok := cc.namespaces[namespace]
+	if !ok {
+		// the commands started before the invalidation must not be cached either
+		ns = &namespaceCommandCache{}
+		cc.namespaces[namespace] = ns
+	}
+	ns.generation++
+	ns.entries = map[string]cachedCommandOutput{}
+}
+
+// InvalidateCommandCache removes the cached outputs of the commands to the cluster in the namespace,
+// e.g. when the cluster is deleted
+func InvalidateCommandCache(namespace string) {
+	commandCache.mutex.Lock()
+	defer commandCache.mutex.Unlock()
+	delete(commandCache.namespaces, namespace)
+}
+
+// runCached runs a cached command, returning its cached output when it did not expire, or sharing the
+// output of the same command being executed by another reconcile. The failures are not cached.
+func (c *CephToolCommand) runCached(run func() ([]byte, error)) ([]byte, error) {
+	ttl := exec.CephCommandsCacheTTL
+	namespace := c.clusterInfo.Namespace
+	key := c.cacheKey()
+	output, ok, generation := commandCache.get(namespace, key)
+	if ok {
+		logger.Debugf("using the cached output of %s %s", c.tool, strings.Join(c.args, " "))
+		return copyOutput(output), nil
+	}
+	// an execution started before an invalidation is not shared with the commands run after it
+	result, err, _ := commandCache.group.Do(fmt.Sprintf("%s/%d/%s", namespace, generation, key), func() (interface{}, error) {
+		output, err := run()
+		if err == nil {
+			commandCache.set(namespace, key, generation, output, ttl)
+		}
+		return output, err
+	})
+	output, _ = result.([]byte)
+	return copyOutput(output), err
+}
+
+// copyOutput copies a shared output, so the caller modifying it does not modify the output returned
+// to the other callers
+func copyOutput(output []byte) []byte {
+	if output == nil {
+		return nil
+	}
+	return append([]byte{}, output...)
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandCache(t *testing.T) {
+	exec.CephCommandsCacheTTL = time.Minute
+	defer func() { exec.CephCommandsCacheTTL = 0 }()
+	defer InvalidateCommandCache("rook-ceph")
+	defer InvalidateCommandCache("other")
+	context := &clusterd.Context{}
+	clusterInfo := AdminTestClusterInfo("rook-ceph")
+
+	var mutex sync.Mutex
+	executed := map[string]int{}
+	var failure error
+	context.Executor = &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			executed[args[0]]++
+			if failure != nil {
+				return "", failure
+			}
+			return `{"executed":` + strings.Repeat("1", executed[args[0]]) + `}`, nil
+		},
+	}
+	run := func(args ...string) string {
+		output, err := NewCephCommand(context, clusterInfo, args).Run()
+		assert.NoError(t, err)
+		return string(output)
+	}
+
+	t.Run("cached query", func(t *testing.T) {
+		assert.Equal(t, `{"executed":1}`, run("status"))
+		assert.Equal(t, `{"executed":1}`, run("status"))
+		assert.Equal(t, 1, executed["status"])
+
+		// another cluster has its own cache
+		output, err := NewCephCommand(context, AdminTestClusterInfo("other"), []string{"status"}).Run()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"executed":11}`, string(output))
+	})
+
+	t.Run("not cached query", func(t *testing.T) {
+		run("osd", "pool", "get", "replicapool", "all")
+		run("osd", "pool", "get", "replicapool", "all")
+		assert.Equal(t, 2, executed["osd"])
+	})
+
+	t.Run("invalidated by a change", func(t *testing.T) {
+		run("osd", "pool", "create", "replicapool")
+		assert.Equal(t, `{"executed":111}`, run("status"))
+		assert.Equal(t, 3, executed["status"])
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		exec.CephCommandsCacheTTL = time.Nanosecond
+		run("fs", "ls")
+		time.Sleep(time.Millisecond)
+		run("fs", "ls")
+		assert.Equal(t, 2, executed["fs"])
+		exec.CephCommandsCacheTTL = time.Minute
+	})
+
+	t.Run("failure not cached", func(t *testing.T) {
+		failure = errors.New("failed")
+		_, err := NewCephCommand(context, clusterInfo, []string{"mon", "dump"}).Run()
+		assert.Error(t, err)
+		failure = nil
+		run("mon", "dump")
+		assert.Equal(t, 2, executed["mon"])
+	})
+
+	t.Run("output copied", func(t *testing.T) {
+		output, err := NewCephCommand(context, clusterInfo, []string{"osd", "dump"}).Run()
+		assert.NoError(t, err)
+		expected := string(output)
+		output[0] = 'x'
+		assert.Equal(t, expected, run("osd", "dump"))
+	})
+
+	t.Run("execution not shared after an invalidation", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		executor := context.Executor
+		defer func() { context.Executor = executor }()
+		context.Executor = &exectest.MockExecutor{
+			MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+				mutex.Lock()
+				executed[args[0]]++
+				count := executed[args[0]]
+				mutex.Unlock()
+				if count == 1 {
+					close(started)
+					<-release
+				}
+				return `{"executed":` + strings.Repeat("1", count) + `}`, nil
+			},
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, `{"executed":1}`, run("mgr", "dump"))
+		}()
+		<-started
+		commandCache.invalidate("rook-ceph")
+		// the query started after the invalidation does not wait for the stale output
+		assert.Equal(t, `{"executed":11}`, run("mgr", "dump"))
+		close(release)
+		wg.Wait()
+		// the stale output is not cached
+		assert.Equal(t, `{"executed":11}`, run("mgr", "dump"))
+		assert.Equal(t, 2, executed["mgr"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		exec.CephCommandsCacheTTL = 0
+		run("versions")
+		run("versions")
+		assert.Equal(t, 2, executed["versions"])
+		exec.CephCommandsCacheTTL = time.Minute
+	})
+}
+
+func TestIsCachedCommand(t *testing.T) {
+	assert.True(t, isCachedCommand(CephTool, []string{"status"}))
+	assert.True(t, isCachedCommand(CephTool, []string{"osd", "dump"}))
+	assert.True(t, isCachedCommand(CephTool, []string{"fs", "ls"}))
+	assert.False(t, isCachedCommand(CephTool, []string{"osd", "dump", "5"}))
+	assert.False(t, isCachedCommand(CephTool, []string{"osd", "pool", "create", "replicapool"}))
+	assert.False(t, isCachedCommand(RBDTool, []string{"status"}))
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/rook/rook/pkg/util/exec"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	kexec "k8s.io/utils/exec"
 )
 
 func TestFinalizeCephCommandArgs(t *testing.T) {
@@ -158,3 +159,162 @@ func TestNewRBDCommand(t *testing.T) {
 	})
 
 }
+
+func TestRunRetries(t *testing.T) {
+	exec.CephCommandsRetryDelay = time.Millisecond
+	defer func() { exec.CephCommandsRetryDelay = time.Second }()
+	defer RemoveCircuitBreaker("rook-ceph")
+	context := &clusterd.Context{}
+	clusterInfo := AdminTestClusterInfo("rook-ceph")
+
+	attempts := 0
+	var failures []string
+	context.Executor = &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			attempts++
+			if attempts <= len(failures) {
+				return failures[attempts-1], errors.New("exit status 1")
+			}
+			return "{}", nil
+		},
+	}
+
+	t.Run("connection retried", func(t *testing.T) {
+		attempts = 0
+		failures = []string{"[errno 110] RADOS timed out (error connecting to the cluster)", "[errno 111] RADOS connection refused (error connecting to the cluster)"}
+		output, err := NewCephCommand(context, clusterInfo, []string{"status"}).Run()
+		assert.NoError(t, err)
+		assert.Equal(t, "{}", string(output))
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("bounded retries", func(t *testing.T) {
+		attempts = 0
+		failures = []string{"", "", "", "", ""}
+		for i := range failures {
+			failures[i] = "[errno 110] RADOS timed out (error connecting to the cluster)"
+		}
+		_, err := NewCephCommand(context, clusterInfo, []string{"status"}).Run()
+		assert.Error(t, err)
+		assert.Equal(t, exec.CephCommandsRetries+1, attempts)
+		RemoveCircuitBreaker("rook-ceph")
+	})
+
+	t.Run("other errors not retried", func(t *testing.T) {
+		attempts = 0
+		failures = []string{"[errno 13] RADOS permission denied (error connecting to the cluster)"}
+		_, err := NewCephCommand(context, clusterInfo, []string{"status"}).Run()
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+
+		attempts = 0
+		failures = []string{"Error ENOENT: unrecognized pool 'foo'"}
+		_, err = NewCephCommand(context, clusterInfo, []string{"osd", "pool", "get", "foo", "all"}).Run()
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("circuit breaker", func(t *testing.T) {
+		exec.CephCommandsRetries = 0
+		defer func() { exec.CephCommandsRetries = 3 }()
+		attempts = 0
+		failures = make([]string, exec.CephCommandsCircuitBreakerThreshold)
+		for i := range failures {
+			failures[i] = "[errno 110] RADOS timed out (error connecting to the cluster)"
+		}
+		for i := 0; i < exec.CephCommandsCircuitBreakerThreshold; i++ {
+			_, err := NewCephCommand(context, clusterInfo, []string{"status"}).Run()
+			assert.Error(t, err)
+		}
+		_, err := NewCephCommand(context, clusterInfo, []string{"status"}).Run()
+		assert.Equal(t, exec.ErrCircuitOpen, errors.Cause(err))
+		assert.Equal(t, exec.CephCommandsCircuitBreakerThreshold, attempts)
+
+		// the commands to other clusters are still run
+		_, err = NewCephCommand(context, AdminTestClusterInfo("other"), []string{"status"}).Run()
+		assert.NoError(t, err)
+		RemoveCircuitBreaker("other")
+	})
+}
+
+func TestRunDryRun(t *testing.T) {
+	context := &clusterd.Context{}
+	clusterInfo := AdminTestClusterInfo("rook-ceph")
+	var executed [][]string
+	context.Executor = &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			executed = append(executed, append([]string{command}, args[:3]...))
+			return "{}", nil
+		},
+	}
+	var dryRun *exec.DryRun
+	clusterInfo.Context, dryRun = exec.WithDryRun(clusterInfo.Context)
+
+	// the commands reading the cluster are executed
+	output, err := NewCephCommand(context, clusterInfo, []string{"osd", "pool", "get", "replicapool", "all"}).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(output))
+	assert.Equal(t, [][]string{{"ceph", "osd", "pool", "get"}}, executed)
+
+	// the commands changing the cluster are recorded
+	output, err = NewCephCommand(context, clusterInfo, []string{"osd", "pool", "set", "replicapool", "size", "3"}).Run()
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+	_, err = NewRBDCommand(context, clusterInfo, []string{"pool", "init", "replicapool"}).Run()
+	assert.NoError(t, err)
+	_, err = NewCephCommand(context, clusterInfo, []string{"config-key", "set", "key", "secret"}).Run()
+	assert.NoError(t, err)
+	assert.Len(t, executed, 1)
+	assert.Equal(t, []string{
+		"ceph osd pool set replicapool size 3",
+		"rbd pool init replicapool",
+		"ceph config-key set key <redacted>",
+	}, dryRun.Commands())
+}
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	assert.True(t, isReadOnlyCommand(CephTool, []string{"status"}))
+	assert.True(t, isReadOnlyCommand(CephTool, []string{"osd", "crush", "rule", "dump", "replicapool"}))
+	assert.True(t, isReadOnlyCommand(RBDTool, []string{"mirror", "pool", "info", "replicapool"}))
+	assert.True(t, isReadOnlyCommand(CrushTool, []string{"--compile", "map", "--outfn", "out"}))
+	assert.False(t, isReadOnlyCommand(CephTool, []string{"osd", "pool", "set", "status", "size", "3"}))
+	assert.False(t, isReadOnlyCommand(CephTool, []string{"osd", "crush", "rule", "create-replicated", "rule", "default", "host"}))
+	assert.False(t, isReadOnlyCommand(RBDTool, []string{"status"}))
+	assert.False(t, isReadOnlyCommand(CephTool, []string{"osd"}))
+	assert.False(t, isReadOnlyCommand("radosgw-admin", []string{"user", "info"}))
+}
+
+func TestClassifyCommandError(t *testing.T) {
+	retry, unreachable := classifyCommandError("", &exec.TimeoutError{})
+	assert.False(t, retry)
+	assert.True(t, unreachable)
+
+	retry, unreachable = classifyCommandError("rbd: couldn't connect to the cluster!", exitError(110))
+	assert.True(t, retry)
+	assert.True(t, unreachable)
+
+	retry, unreachable = classifyCommandError("rbd: couldn't connect to the cluster!", exitError(13))
+	assert.False(t, retry)
+	assert.False(t, unreachable)
+
+	retry, unreachable = classifyCommandError("[errno 110] RADOS timed out (error connecting to the cluster)", exitError(1))
+	assert.True(t, retry)
+	assert.True(t, unreachable)
+
+	// the command might have been applied by the mons
+	retry, unreachable = classifyCommandError("", exitError(110))
+	assert.False(t, retry)
+	assert.True(t, unreachable)
+
+	retry, unreachable = classifyCommandError("Error EAGAIN: pool busy", exitError(11))
+	assert.False(t, retry)
+	assert.False(t, unreachable)
+
+	retry, unreachable = classifyCommandError("Error EINVAL: invalid command", exitError(22))
+	assert.False(t, retry)
+	assert.False(t, unreachable)
+}
+
+func exitError(code int) error {
+	return &kexec.CodeExitError{Err: errors.Errorf("exit status %d", code), Code: code}
+}
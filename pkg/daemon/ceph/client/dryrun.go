@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+// the commands of the ceph and rbd tools that only read the state of the cluster, executed in a dry
+// run since the reconciles need their output to compute the changes. Any other command is assumed to
+// change the cluster.
+var readOnlyCommands = map[string][][]string{
+	CephTool: {
+		{"status"},
+		{"health"},
+		{"df"},
+		{"version"},
+		{"versions"},
+		{"quorum_status"},
+		{"auth", "get"},
+		{"auth", "get-key"},
+		{"config", "dump"},
+		{"config", "get"},
+		{"config-key", "get"},
+		{"config-key", "ls"},
+		{"fs", "dump"},
+		{"fs", "get"},
+		{"fs", "ls"},
+		{"fs", "subvolume", "info"},
+		{"mgr", "dump"},
+		{"mgr", "stat"},
+		{"mon", "dump"},
+		{"osd", "crush", "class", "ls"},
+		{"osd", "crush", "class", "ls-osd"},
+		{"osd", "crush", "dump"},
+		{"osd", "crush", "get-device-class"},
+		{"osd", "crush", "ls"},
+		{"osd", "crush", "rule", "dump"},
+		{"osd", "crush", "rule", "ls"},
+		{"osd", "df"},
+		{"osd", "dump"},
+		{"osd", "erasure-code-profile", "get"},
+		{"osd", "erasure-code-profile", "ls"},
+		{"osd", "getcrushmap"},
+		{"osd", "ls"},
+		{"osd", "lspools"},
+		{"osd", "pool", "application", "get"},
+		{"osd", "pool", "get"},
+		{"osd", "pool", "ls"},
+		{"osd", "tree"},
+	},
+	RBDTool: {
+		{"info"},
+		{"ls"},
+		{"mirror", "pool", "info"},
+		{"mirror", "pool", "status"},
+		{"mirror", "snapshot", "schedule", "ls"},
+		{"mirror", "snapshot", "schedule", "status"},
+		{"namespace", "ls"},
+		{"pool", "stats"},
+	},
+}
+
+// isReadOnlyCommand returns whether the command only reads the state of the cluster. The crushtool
+// only works on local files, the crush map is changed by the ceph tool.
+func isReadOnlyCommand(tool string, args []string) bool {
+	if tool == CrushTool {
+		return true
+	}
+	for _, command := range readOnlyCommands[tool] {
+		if len(args) >= len(command) && isPrefix(command, args) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPrefix(prefix, args []string) bool {
+	for i, word := range prefix {
+		if args[i] != word {
+			return false
+		}
+	}
+	return true
+}
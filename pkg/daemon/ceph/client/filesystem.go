@@ -24,6 +24,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -381,3 +382,39 @@ func GetMDSDump(context *clusterd.Context, clusterInfo *ClusterInfo) (*MDSDump,
 	}
 	return &dump, nil
 }
+
+// MDSSession is a representation of a client session of an mds returned by 'ceph tell mds.<name> session ls'
+type MDSSession struct {
+	ID               int64                    `json:"id"`
+	State            string                   `json:"state"`
+	NumCaps          int                      `json:"num_caps"`
+	NumLeases        int                      `json:"num_leases"`
+	RequestsInFlight int                      `json:"requests_in_flight"`
+	Uptime           float64                  `json:"uptime"`
+	Inst             string                   `json:"inst"`
+	ClientMetadata   MDSSessionClientMetadata `json:"client_metadata"`
+}
+
+// MDSSessionClientMetadata is the metadata a client reports when opening its session
+type MDSSessionClientMetadata struct {
+	EntityID      string `json:"entity_id"`
+	Hostname      string `json:"hostname"`
+	KernelVersion string `json:"kernel_version"`
+	CephVersion   string `json:"ceph_version"`
+	Root          string `json:"root"`
+}
+
+// ListMDSSessions lists the client sessions of an mds daemon. The command times out since an mds that
+// is not responding does not answer it.
+func ListMDSSessions(context *clusterd.Context, clusterInfo *ClusterInfo, mdsName string) ([]MDSSession, error) {
+	args := []string{"tell", fmt.Sprintf("mds.%s", mdsName), "session", "ls"}
+	buf, err := NewCephCommand(context, clusterInfo, args).RunWithTimeout(exec.CephCommandsTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the sessions of mds %q", mdsName)
+	}
+	var sessions []MDSSession
+	if err := json.Unmarshal(buf, &sessions); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the sessions of mds %q. %s", mdsName, buf)
+	}
+	return sessions, nil
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package client
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -33,24 +34,103 @@ type BootstrapPeerToken struct {
 	Token string `json:"token"`
 }
 
-// RemoveFilesystemMirrorPeer add a mirror peer in the cephfs-mirror configuration
-func RemoveFilesystemMirrorPeer(context *clusterd.Context, clusterInfo *ClusterInfo, peerUUID string) error {
-	logger.Infof("removing cephfs-mirror peer %q", peerUUID)
+// FSMirrorBootstrapPeerToken is the decoded content of a cephfs-mirror bootstrap peer token
+type FSMirrorBootstrapPeerToken struct {
+	FSID       string `json:"fsid"`
+	Filesystem string `json:"filesystem"`
+	User       string `json:"user"`
+	SiteName   string `json:"site_name"`
+}
+
+// FSMirrorPeer is a peer of a mirrored filesystem
+type FSMirrorPeer struct {
+	ClientName string `json:"client_name"`
+	SiteName   string `json:"site_name"`
+	FSName     string `json:"fs_name"`
+}
+
+// FSMirrorPeerDirectoryStatus is the sync status of a mirrored directory with a peer, reported by
+// the admin socket of the cephfs-mirror daemon
+type FSMirrorPeerDirectoryStatus struct {
+	State        string `json:"state"`
+	SnapsSynced  int    `json:"snaps_synced"`
+	SnapsDeleted int    `json:"snaps_deleted"`
+	SnapsRenamed int    `json:"snaps_renamed"`
+	LastSynced   struct {
+		Name string `json:"name"`
+	} `json:"last_synced_snap"`
+}
+
+// RemoveFilesystemMirrorPeer removes a mirror peer of a filesystem from the cephfs-mirror configuration
+func RemoveFilesystemMirrorPeer(context *clusterd.Context, clusterInfo *ClusterInfo, fsName, peerUUID string) error {
+	logger.Infof("removing cephfs-mirror peer %q of filesystem %q", peerUUID, fsName)
 
 	// Build command
-	args := []string{"fs", "snapshot", "mirror", "peer_remove", peerUUID}
+	args := []string{"fs", "snapshot", "mirror", "peer_remove", fsName, peerUUID}
 	cmd := NewCephCommand(context, clusterInfo, args)
+	cmd.JsonOutput = false
 
 	// Run command
 	output, err := cmd.Run()
 	if err != nil {
-		return errors.Wrapf(err, "failed to remove cephfs-mirror peer for filesystem %q. %s", peerUUID, output)
+		return errors.Wrapf(err, "failed to remove cephfs-mirror peer %q of filesystem %q. %s", peerUUID, fsName, output)
 	}
 
-	logger.Infof("successfully removed cephfs-mirror peer %q", peerUUID)
+	logger.Infof("successfully removed cephfs-mirror peer %q of filesystem %q", peerUUID, fsName)
 	return nil
 }
 
+// ListFSMirrorPeers returns the mirror peers of a filesystem by uuid
+func ListFSMirrorPeers(context *clusterd.Context, clusterInfo *ClusterInfo, fsName string) (map[string]FSMirrorPeer, error) {
+	logger.Debugf("listing cephfs-mirror peers of filesystem %q", fsName)
+
+	// Build command
+	args := []string{"fs", "snapshot", "mirror", "peer_list", fsName}
+	cmd := NewCephCommand(context, clusterInfo, args)
+
+	// Run command
+	output, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list cephfs-mirror peers of filesystem %q. %s", fsName, output)
+	}
+
+	peers := map[string]FSMirrorPeer{}
+	if err := json.Unmarshal(output, &peers); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal cephfs-mirror peer list response. %s", output)
+	}
+
+	return peers, nil
+}
+
+// DecodeFSMirrorBootstrapPeerToken decodes a cephfs-mirror bootstrap peer token
+func DecodeFSMirrorBootstrapPeerToken(token string) (*FSMirrorBootstrapPeerToken, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode cephfs-mirror bootstrap peer token")
+	}
+
+	var peerToken FSMirrorBootstrapPeerToken
+	if err := json.Unmarshal(decoded, &peerToken); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal cephfs-mirror bootstrap peer token")
+	}
+	if peerToken.SiteName == "" || peerToken.Filesystem == "" {
+		return nil, errors.New("cephfs-mirror bootstrap peer token has no site name or filesystem")
+	}
+
+	return &peerToken, nil
+}
+
+// FindFSMirrorPeer returns the uuid of the peer of the remote filesystem of a site, or an empty
+// string when the remote filesystem is not a peer
+func FindFSMirrorPeer(peers map[string]FSMirrorPeer, siteName, fsName string) string {
+	for uuid, peer := range peers {
+		if peer.SiteName == siteName && peer.FSName == fsName {
+			return uuid
+		}
+	}
+	return ""
+}
+
 // EnableFilesystemSnapshotMirror enables filesystem snapshot mirroring
 func EnableFilesystemSnapshotMirror(context *clusterd.Context, clusterInfo *ClusterInfo, filesystem string) error {
 	logger.Infof("enabling ceph filesystem snapshot mirror for filesystem %q", filesystem)
@@ -138,6 +218,38 @@ func AddSnapshotScheduleRetention(context *clusterd.Context, clusterInfo *Cluste
 	return nil
 }
 
+// DeactivateSnapshotSchedule deactivates the snapshot schedules of a path of a filesystem, no
+// snapshot is taken until they are activated again
+func DeactivateSnapshotSchedule(context *clusterd.Context, clusterInfo *ClusterInfo, path, filesystem string) error {
+	return setSnapshotScheduleActive(context, clusterInfo, path, filesystem, false)
+}
+
+// ActivateSnapshotSchedule activates the snapshot schedules of a path of a filesystem
+func ActivateSnapshotSchedule(context *clusterd.Context, clusterInfo *ClusterInfo, path, filesystem string) error {
+	return setSnapshotScheduleActive(context, clusterInfo, path, filesystem, true)
+}
+
+func setSnapshotScheduleActive(context *clusterd.Context, clusterInfo *ClusterInfo, path, filesystem string, active bool) error {
+	action := "deactivate"
+	if active {
+		action = "activate"
+	}
+
+	// Example command: "ceph fs snap-schedule deactivate / fs=myfs2"
+	args := []string{"fs", "snap-schedule", action, path, fmt.Sprintf("fs=%s", filesystem)}
+	cmd := NewCephCommand(context, clusterInfo, args)
+	cmd.JsonOutput = false
+
+	// Run command
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to %s the snapshot schedules of ceph filesystem %q on path %q. %s", action, filesystem, path, output)
+	}
+
+	logger.Infof("successfully %sd the snapshot schedules of ceph filesystem %q on path %q", action, filesystem, path)
+	return nil
+}
+
 func GetSnapshotScheduleStatus(context *clusterd.Context, clusterInfo *ClusterInfo, filesystem string) ([]cephv1.FilesystemSnapshotSchedulesSpec, error) {
 	logger.Infof("retrieving snapshot schedule status for ceph filesystem %q", filesystem)
 
@@ -18,6 +18,7 @@ package client
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -132,17 +133,58 @@ func TestRemoveFilesystemMirrorPeer(t *testing.T) {
 			assert.Equal(t, "snapshot", args[1])
 			assert.Equal(t, "mirror", args[2])
 			assert.Equal(t, "peer_remove", args[3])
-			assert.Equal(t, peerUUID, args[4])
+			assert.Equal(t, "myfs", args[4])
+			assert.Equal(t, peerUUID, args[5])
 			return "", nil
 		}
 		return "", errors.New("unknown command")
 	}
 	context := &clusterd.Context{Executor: executor}
 
-	err := RemoveFilesystemMirrorPeer(context, AdminTestClusterInfo("mycluster"), peerUUID)
+	err := RemoveFilesystemMirrorPeer(context, AdminTestClusterInfo("mycluster"), "myfs", peerUUID)
 	assert.NoError(t, err)
 }
 
+func TestListFSMirrorPeers(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" {
+			assert.Equal(t, "snapshot", args[1])
+			assert.Equal(t, "mirror", args[2])
+			assert.Equal(t, "peer_list", args[3])
+			assert.Equal(t, "myfs", args[4])
+			return `{"4a6983c0-3c9d-40f5-b2a9-2334a4659827": {"client_name": "client.mirror", "site_name": "test", "fs_name": "myfs2"}}`, nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	peers, err := ListFSMirrorPeers(context, AdminTestClusterInfo("mycluster"), "myfs")
+	assert.NoError(t, err)
+	assert.Len(t, peers, 1)
+	assert.Equal(t, "4a6983c0-3c9d-40f5-b2a9-2334a4659827", FindFSMirrorPeer(peers, "test", "myfs2"))
+	assert.Equal(t, "", FindFSMirrorPeer(peers, "test", "myfs"))
+	assert.Equal(t, "", FindFSMirrorPeer(peers, "other", "myfs2"))
+}
+
+func TestDecodeFSMirrorBootstrapPeerToken(t *testing.T) {
+	var token BootstrapPeerToken
+	err := json.Unmarshal([]byte(fsMirrorToken), &token)
+	assert.NoError(t, err)
+
+	peerToken, err := DecodeFSMirrorBootstrapPeerToken(token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "82b7ed92-73b0-4b22-a8b7-ed9483e28756", peerToken.FSID)
+	assert.Equal(t, "myfs2", peerToken.Filesystem)
+	assert.Equal(t, "test", peerToken.SiteName)
+
+	_, err = DecodeFSMirrorBootstrapPeerToken("invalid")
+	assert.Error(t, err)
+
+	_, err = DecodeFSMirrorBootstrapPeerToken(base64.StdEncoding.EncodeToString([]byte(`{"fsid": "82b7ed92-73b0-4b22-a8b7-ed9483e28756"}`)))
+	assert.Error(t, err)
+}
+
 func TestFSMirrorDaemonStatus(t *testing.T) {
 	fs := "myfs"
 	executor := &exectest.MockExecutor{}
@@ -187,3 +229,26 @@ func TestFSMirrorDaemonStatus(t *testing.T) {
 		assert.Equal(t, "myfsNew", s[0].Filesystems[0].Name)
 	})
 }
+
+func TestSetSnapshotScheduleActive(t *testing.T) {
+	var action string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" {
+			assert.Equal(t, "snap-schedule", args[1])
+			action = args[2]
+			assert.Equal(t, []string{"/volumes", "fs=myfs"}, args[3:5])
+			return "", nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := DeactivateSnapshotSchedule(context, AdminTestClusterInfo("mycluster"), "/volumes", "myfs")
+	assert.NoError(t, err)
+	assert.Equal(t, "deactivate", action)
+
+	err = ActivateSnapshotSchedule(context, AdminTestClusterInfo("mycluster"), "/volumes", "myfs")
+	assert.NoError(t, err)
+	assert.Equal(t, "activate", action)
+}
@@ -587,3 +587,29 @@ func TestWaitForNoStandbys(t *testing.T) {
 	assert.NoError(t, err)
 
 }
+
+func TestListMDSSessions(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithTimeout = func(timeout time.Duration, command string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "tell" && args[1] == "mds.myfs-a" && args[2] == "session" && args[3] == "ls" {
+			return `[{"id":4305,"entity":{"name":{"type":"client","num":4305}},"state":"open","num_leases":0,"num_caps":12,"request_load_avg":0,
+			"uptime":3600.5,"requests_in_flight":1,"inst":"client.4305 v1:10.244.0.12:0/2831795632",
+			"client_metadata":{"client_features":{"feature_bits":"0x0000000000007bff"},"entity_id":"csi-cephfs-node","hostname":"node-1",
+			"kernel_version":"5.10.0-11-amd64","root":"/volumes/csi"}}]`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	sessions, err := ListMDSSessions(context, AdminTestClusterInfo("mycluster"), "myfs-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sessions))
+	assert.Equal(t, int64(4305), sessions[0].ID)
+	assert.Equal(t, 12, sessions[0].NumCaps)
+	assert.Equal(t, "node-1", sessions[0].ClientMetadata.Hostname)
+	assert.Equal(t, "5.10.0-11-amd64", sessions[0].ClientMetadata.KernelVersion)
+
+	_, err = ListMDSSessions(context, AdminTestClusterInfo("mycluster"), "myfs-b")
+	assert.Error(t, err)
+}
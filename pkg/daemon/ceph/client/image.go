@@ -35,10 +35,21 @@ const (
 )
 
 type CephBlockImage struct {
-	Name     string `json:"image"`
-	Size     uint64 `json:"size"`
-	Format   int    `json:"format"`
-	InfoName string `json:"name"`
+	Name     string   `json:"image"`
+	Size     uint64   `json:"size"`
+	Format   int      `json:"format"`
+	InfoName string   `json:"name"`
+	Features []string `json:"features,omitempty"`
+	// Mirroring is only set by GetImageInfo, for a mirrored image
+	Mirroring *ImageMirroringInfo `json:"mirroring,omitempty"`
+}
+
+// ImageMirroringInfo is the mirroring mode and state of an image
+type ImageMirroringInfo struct {
+	Mode     string `json:"mode"`
+	State    string `json:"state"`
+	GlobalID string `json:"global_id"`
+	Primary  bool   `json:"primary"`
 }
 
 func ListImages(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string) ([]CephBlockImage, error) {
@@ -68,6 +79,26 @@ func ListImages(context *clusterd.Context, clusterInfo *ClusterInfo, poolName st
 	return images, nil
 }
 
+// GetImageInfo returns the details of a block storage image, the size and features of the image
+// are set but its name is only set in InfoName
+func GetImageInfo(context *clusterd.Context, clusterInfo *ClusterInfo, name, poolName string) (*CephBlockImage, error) {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"info", imageSpec}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+	buf, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get image %s info, output: %s", imageSpec, string(buf))
+	}
+
+	var image CephBlockImage
+	if err = json.Unmarshal(buf, &image); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed, raw buffer response: %s", string(buf))
+	}
+
+	return &image, nil
+}
+
 // CreateImage creates a block storage image.
 // If dataPoolName is not empty, the image will use poolName as the metadata pool and the dataPoolname for data.
 // If size is zero an empty image will be created. Otherwise, an image will be
@@ -32,6 +32,34 @@ const (
 	sizeMB = 1048576 // 1 MB
 )
 
+func TestGetImageInfo(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if command == "rbd" && args[0] == "info" {
+			assert.Equal(t, "pool1/image1", args[1])
+			assert.Contains(t, args, "--format")
+			return `{"name":"image1","size":1073741824,"objects":256,"order":22,"object_size":4194304,` +
+				`"block_name_prefix":"rbd_data.1f2a6b8b4567","format":2,"features":["layering","exclusive-lock"],"op_features":[],"flags":[]}`, nil
+		}
+		return "", errors.Errorf("unexpected rbd command %q", args)
+	}
+	image, err := GetImageInfo(context, clusterInfo, "image1", "pool1")
+	assert.NoError(t, err)
+	assert.Equal(t, "image1", image.InfoName)
+	assert.Equal(t, uint64(1073741824), image.Size)
+	assert.Equal(t, []string{"layering", "exclusive-lock"}, image.Features)
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		return "rbd: error opening image image1: (2) No such file or directory", errors.New("exit status 2")
+	}
+	_, err = GetImageInfo(context, clusterInfo, "image1", "pool1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "No such file or directory")
+}
+
 func TestCreateImage(t *testing.T) {
 	executor := &exectest.MockExecutor{}
 	context := &clusterd.Context{Executor: executor}
@@ -28,6 +28,9 @@ var (
 	moduleEnableWaitTime = 5 * time.Second
 )
 
+// the license of the data shared by the telemetry module
+const telemetryLicense = "sharing-1-0"
+
 func CephMgrMap(context *clusterd.Context, clusterInfo *ClusterInfo) (*MgrMap, error) {
 	args := []string{"mgr", "dump"}
 	buf, err := NewCephCommand(context, clusterInfo, args).Run()
@@ -102,6 +105,19 @@ func MgrDisableModule(context *clusterd.Context, clusterInfo *ClusterInfo, name
 	return enableModule(context, clusterInfo, name, false, "disable")
 }
 
+// MgrSetTelemetry turns the telemetry reports on, accepting the sharing license, or off
+func MgrSetTelemetry(context *clusterd.Context, clusterInfo *ClusterInfo, enabled bool) error {
+	args := []string{"telemetry", "off"}
+	if enabled {
+		args = []string{"telemetry", "on", "--license", telemetryLicense}
+	}
+	_, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to turn %q the telemetry", args[1])
+	}
+	return nil
+}
+
 func enableModule(context *clusterd.Context, clusterInfo *ClusterInfo, name string, force bool, action string) error {
 	args := []string{"mgr", "module", action, name}
 	if force {
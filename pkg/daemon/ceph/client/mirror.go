@@ -23,6 +23,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -38,6 +39,10 @@ type PeerToken struct {
 	MonHost     string `json:"mon_host"`
 	// These fields are added by Rook and NOT part of the output of client.CreateRBDMirrorBootstrapPeer()
 	Namespace string `json:"namespace"`
+	// These fields are only set in the tokens issued by a CephRBDMirrorPeerToken, whose user only has
+	// access to the pools of the token
+	Pools   []string `json:"pools,omitempty"`
+	Expires string   `json:"expires,omitempty"`
 }
 
 var (
@@ -137,7 +142,8 @@ func disablePoolMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, p
 	return nil
 }
 
-func removeClusterPeer(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, peerUUID string) error {
+// RemoveClusterPeer removes a peer from the mirroring of a pool
+func RemoveClusterPeer(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, peerUUID string) error {
 	logger.Infof("removing cluster peer with UUID %q for the pool %q", peerUUID, poolName)
 
 	// Build command
@@ -153,6 +159,88 @@ func removeClusterPeer(context *clusterd.Context, clusterInfo *ClusterInfo, pool
 	return nil
 }
 
+// PromotePoolMirroring promotes all the mirrored images of a pool to primary. With force, the
+// images are promoted even if the peer cluster cannot be reached to demote them.
+func PromotePoolMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string, force bool) error {
+	logger.Infof("promoting the mirrored images of pool %q", poolName)
+
+	// Build command
+	args := []string{"mirror", "pool", "promote", poolName}
+	if force {
+		args = append(args, "--force")
+	}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+
+	// Run command
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to promote the mirrored images of pool %q. %s", poolName, output)
+	}
+
+	logger.Infof("successfully promoted the mirrored images of pool %q. %s", poolName, output)
+	return nil
+}
+
+// DemotePoolMirroring demotes all the primary mirrored images of a pool to non-primary
+func DemotePoolMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string) error {
+	logger.Infof("demoting the mirrored images of pool %q", poolName)
+
+	// Build command
+	args := []string{"mirror", "pool", "demote", poolName}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+
+	// Run command
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to demote the mirrored images of pool %q. %s", poolName, output)
+	}
+
+	logger.Infof("successfully demoted the mirrored images of pool %q. %s", poolName, output)
+	return nil
+}
+
+// PromoteImageMirroring promotes a mirrored image to primary. With force, the image is promoted
+// even if the peer cluster cannot be reached to demote it.
+func PromoteImageMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName string, force bool) error {
+	image := fmt.Sprintf("%s/%s", poolName, imageName)
+	logger.Infof("promoting mirrored image %q", image)
+
+	// Build command
+	args := []string{"mirror", "image", "promote", image}
+	if force {
+		args = append(args, "--force")
+	}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+
+	// Run command
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to promote mirrored image %q. %s", image, output)
+	}
+
+	logger.Infof("successfully promoted mirrored image %q", image)
+	return nil
+}
+
+// DemoteImageMirroring demotes a primary mirrored image to non-primary
+func DemoteImageMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName string) error {
+	image := fmt.Sprintf("%s/%s", poolName, imageName)
+	logger.Infof("demoting mirrored image %q", image)
+
+	// Build command
+	args := []string{"mirror", "image", "demote", image}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+
+	// Run command
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to demote mirrored image %q. %s", image, output)
+	}
+
+	logger.Infof("successfully demoted mirrored image %q", image)
+	return nil
+}
+
 // GetPoolMirroringStatus prints the pool mirroring status
 func GetPoolMirroringStatus(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string) (*cephv1.PoolMirroringStatus, error) {
 	logger.Debugf("retrieving mirroring pool %q status", poolName)
@@ -200,12 +288,88 @@ func GetPoolMirroringInfo(context *clusterd.Context, clusterInfo *ClusterInfo, p
 	return &poolMirroringInfo, nil
 }
 
+// PoolMirroringImagesStatus is the mirroring status of the images of a pool
+type PoolMirroringImagesStatus struct {
+	Images []MirroredImageStatus `json:"images"`
+}
+
+// MirroredImageStatus is the mirroring status of an image and of its peer sites
+type MirroredImageStatus struct {
+	Name        string                        `json:"name"`
+	State       string                        `json:"state"`
+	Description string                        `json:"description"`
+	PeerSites   []MirroredImagePeerSiteStatus `json:"peer_sites"`
+}
+
+// MirroredImagePeerSiteStatus is the mirroring status of an image in a peer site
+type MirroredImagePeerSiteStatus struct {
+	SiteName    string `json:"site_name"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+}
+
+// ImageReplayStatus is the progress of the replay of a non-primary image, reported in the
+// description of its mirroring status
+type ImageReplayStatus struct {
+	// snapshot-based mirroring
+	BytesPerSnapshot        float64 `json:"bytes_per_snapshot"`
+	SyncingPercent          float64 `json:"syncing_percent"`
+	LocalSnapshotTimestamp  int64   `json:"local_snapshot_timestamp"`
+	RemoteSnapshotTimestamp int64   `json:"remote_snapshot_timestamp"`
+	// journal-based mirroring
+	EntriesBehindPrimary int64 `json:"entries_behind_primary"`
+}
+
+// GetPoolMirroringImagesStatus returns the mirroring status of each image of a pool
+func GetPoolMirroringImagesStatus(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string) (*PoolMirroringImagesStatus, error) {
+	logger.Debugf("retrieving mirroring status of the images of pool %q", poolName)
+
+	// Build command
+	args := []string{"mirror", "pool", "status", poolName, "--verbose"}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+
+	// Run command
+	buf, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve mirroring status of the images of pool %q", poolName)
+	}
+
+	var imagesStatus PoolMirroringImagesStatus
+	if err := json.Unmarshal(buf, &imagesStatus); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal mirror pool verbose status response")
+	}
+
+	return &imagesStatus, nil
+}
+
+// ParseImageReplayStatus parses the replay progress of the description of the mirroring status of
+// an image, like 'replaying, {"bytes_per_second":0.0,...}'. It returns false when the description
+// has no replay progress, like the description of a primary image.
+func ParseImageReplayStatus(description string) (*ImageReplayStatus, bool) {
+	i := strings.Index(description, "{")
+	if i < 0 {
+		return nil, false
+	}
+	var replayStatus ImageReplayStatus
+	if err := json.Unmarshal([]byte(description[i:]), &replayStatus); err != nil {
+		return nil, false
+	}
+	return &replayStatus, true
+}
+
 // enableSnapshotSchedule configures the snapshots schedule on a mirrored pool
 func enableSnapshotSchedule(context *clusterd.Context, clusterInfo *ClusterInfo, snapSpec cephv1.SnapshotScheduleSpec, poolName string) error {
 	logger.Infof("enabling snapshot schedule for pool %q", poolName)
 
 	// Build command
-	args := []string{"mirror", "snapshot", "schedule", "add", "--pool", poolName, snapSpec.Interval}
+	args := []string{"mirror", "snapshot", "schedule", "add", "--pool", poolName}
+
+	// The schedule of a single image
+	if snapSpec.Image != "" {
+		args = append(args, "--image", snapSpec.Image)
+	}
+	args = append(args, snapSpec.Interval)
 
 	// If a start time is defined let's add it
 	if snapSpec.StartTime != "" {
@@ -330,6 +494,241 @@ func ListSnapshotSchedulesRecursively(context *clusterd.Context, clusterInfo *Cl
 	return snapshotSchedulesRecursive, nil
 }
 
+// SnapshotScheduleStatus is the next snapshot of an image scheduled by the snapshot schedules
+type SnapshotScheduleStatus struct {
+	ScheduleTime string `json:"schedule_time"`
+	Image        string `json:"image"`
+}
+
+// ListSnapshotScheduleStatus returns the images of a pool that have a snapshot scheduled, either by
+// a schedule of the pool or of the image
+func ListSnapshotScheduleStatus(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string) ([]SnapshotScheduleStatus, error) {
+	// Build command
+	args := []string{"mirror", "snapshot", "schedule", "status", "--pool", poolName}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+
+	// Run command
+	buf, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve snapshot schedule status on pool %q. %s", poolName, string(buf))
+	}
+
+	var scheduleStatus []SnapshotScheduleStatus
+	if err := json.Unmarshal(buf, &scheduleStatus); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal mirror snapshot schedule status response")
+	}
+
+	return scheduleStatus, nil
+}
+
+// RepairSnapshotSchedules adds the snapshot schedules of the spec of a mirrored pool that are
+// missing, for example after an image was recreated from a backup or a schedule was removed with
+// the rbd CLI. The schedules are compared by their interval since ceph normalizes the start time.
+// It returns the number of schedules added again.
+func RepairSnapshotSchedules(context *clusterd.Context, clusterInfo *ClusterInfo, pool cephv1.NamedPoolSpec) (int, error) {
+	existing, err := ListSnapshotSchedulesRecursively(context, clusterInfo, pool.Name)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list snapshot schedule(s)")
+	}
+
+	repaired := 0
+	for _, snapSchedule := range pool.Mirroring.SnapshotSchedules {
+		if snapshotScheduleExists(existing, snapSchedule) {
+			continue
+		}
+
+		logger.Infof("snapshot schedule %q of pool %q (image %q) is missing, adding it again", snapSchedule.Interval, pool.Name, snapSchedule.Image)
+		err := enableSnapshotSchedule(context, clusterInfo, snapSchedule, pool.Name)
+		if err != nil {
+			return repaired, errors.Wrap(err, "failed to enable snapshot schedule")
+		}
+		repaired++
+	}
+
+	return repaired, nil
+}
+
+// PauseSnapshotSchedules removes the snapshot schedules of the spec of a mirrored pool, so that no
+// mirror snapshot is taken while the pool is quiesced for a planned failover. The schedules are
+// added again by RepairSnapshotSchedules. It returns the number of schedules removed.
+func PauseSnapshotSchedules(context *clusterd.Context, clusterInfo *ClusterInfo, pool cephv1.NamedPoolSpec) (int, error) {
+	existing, err := ListSnapshotSchedulesRecursively(context, clusterInfo, pool.Name)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list snapshot schedule(s)")
+	}
+
+	removed := 0
+	for _, snapSchedule := range pool.Mirroring.SnapshotSchedules {
+		if !snapshotScheduleExists(existing, snapSchedule) {
+			continue
+		}
+
+		args := []string{"mirror", "snapshot", "schedule", "remove", "--pool", pool.Name}
+		if snapSchedule.Image != "" {
+			args = append(args, "--image", snapSchedule.Image)
+		}
+		args = append(args, snapSchedule.Interval)
+		output, err := NewRBDCommand(context, clusterInfo, args).Run()
+		if err != nil {
+			return removed, errors.Wrapf(err, "failed to pause snapshot schedule %q of pool %q (image %q). %s", snapSchedule.Interval, pool.Name, snapSchedule.Image, string(output))
+		}
+		logger.Infof("paused snapshot schedule %q of pool %q (image %q)", snapSchedule.Interval, pool.Name, snapSchedule.Image)
+		removed++
+	}
+
+	return removed, nil
+}
+
+// CreateMirrorImageSnapshot takes a mirror snapshot of a primary image mirrored with snapshots, the
+// peers sync the image up to this snapshot
+func CreateMirrorImageSnapshot(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName string) error {
+	image := fmt.Sprintf("%s/%s", poolName, imageName)
+	logger.Infof("taking a mirror snapshot of image %q", image)
+
+	// Build command
+	args := []string{"mirror", "image", "snapshot", image}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+
+	// Run command
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to take a mirror snapshot of image %q. %s", image, output)
+	}
+
+	logger.Infof("successfully took a mirror snapshot of image %q. %s", image, output)
+	return nil
+}
+
+// MigrateImageMirroringMode changes the mirroring mode of a primary image to journal or snapshot.
+// The mirroring of the image is disabled, its journaling feature is enabled for the journal mode or
+// disabled for the snapshot mode, then the mirroring is enabled again in the new mode. The peers
+// delete their copy of the image and sync it again from scratch.
+func MigrateImageMirroringMode(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName, mode string) error {
+	image := fmt.Sprintf("%s/%s", poolName, imageName)
+	info, err := GetImageInfo(context, clusterInfo, imageName, poolName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the mirroring mode of image %q", image)
+	}
+	if info.Mirroring == nil {
+		return errors.Errorf("image %q is not mirrored", image)
+	}
+	previousMode := info.Mirroring.Mode
+	if previousMode == mode {
+		return nil
+	}
+	logger.Infof("migrating the mirroring of image %q from %q to %q mode", image, previousMode, mode)
+
+	if err := setImageMirroring(context, clusterInfo, image, "disable"); err != nil {
+		return err
+	}
+
+	journaling := false
+	for _, feature := range info.Features {
+		if feature == "journaling" {
+			journaling = true
+		}
+	}
+	featureAction := ""
+	if mode == "journal" && !journaling {
+		featureAction = "enable"
+	} else if mode == "snapshot" && journaling {
+		featureAction = "disable"
+	}
+	if featureAction != "" {
+		args := []string{"feature", featureAction, image, "journaling"}
+		output, err := NewRBDCommand(context, clusterInfo, args).Run()
+		if err != nil {
+			err = errors.Wrapf(err, "failed to %s the journaling of image %q. %s", featureAction, image, output)
+			return restoreImageMirroring(context, clusterInfo, image, previousMode, err)
+		}
+	}
+
+	if err := setImageMirroring(context, clusterInfo, image, "enable", mode); err != nil {
+		return restoreImageMirroring(context, clusterInfo, image, previousMode, err)
+	}
+
+	logger.Infof("successfully migrated the mirroring of image %q to %q mode", image, mode)
+	return nil
+}
+
+// restoreImageMirroring enables again the mirroring of an image whose migration to another mode
+// failed, so the image is not left unmirrored
+func restoreImageMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, image, mode string, migrationErr error) error {
+	if err := setImageMirroring(context, clusterInfo, image, "enable", mode); err != nil {
+		return errors.Wrapf(migrationErr, "failed to restore the %q mirroring of image %q (%v)", mode, image, err)
+	}
+	return migrationErr
+}
+
+func setImageMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, image, action string, mode ...string) error {
+	args := append([]string{"mirror", "image", action, image}, mode...)
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to %s the mirroring of image %q. %s", action, image, output)
+	}
+	return nil
+}
+
+// snapshotScheduleExists returns whether a schedule of the spec is one of the existing schedules,
+// the pool level is listed with "-" as image
+func snapshotScheduleExists(existing []cephv1.SnapshotSchedulesSpec, snapSchedule cephv1.SnapshotScheduleSpec) bool {
+	for _, level := range existing {
+		if level.Namespace != "" && level.Namespace != "-" {
+			continue
+		}
+		image := level.Image
+		if image == "-" {
+			image = ""
+		}
+		if image != snapSchedule.Image {
+			continue
+		}
+		for _, item := range level.Items {
+			if item.Interval == snapSchedule.Interval {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CountImagesWithoutSnapshotSchedule returns the number of primary images of a pool mirrored with
+// snapshots that have no snapshot scheduled. Their snapshots are only taken on demand, so they are
+// not synced to the peers within the expected recovery point objective.
+func CountImagesWithoutSnapshotSchedule(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string) (int, error) {
+	imagesStatus, err := GetPoolMirroringImagesStatus(context, clusterInfo, poolName)
+	if err != nil {
+		return 0, err
+	}
+
+	scheduleStatus, err := ListSnapshotScheduleStatus(context, clusterInfo, poolName)
+	if err != nil {
+		return 0, err
+	}
+	scheduled := map[string]bool{}
+	for _, status := range scheduleStatus {
+		// the image is listed as pool/image, or as pool/namespace/image
+		scheduled[status.Image[strings.LastIndex(status.Image, "/")+1:]] = true
+	}
+
+	count := 0
+	for _, image := range imagesStatus.Images {
+		if scheduled[image.Name] {
+			continue
+		}
+		info, err := GetImageInfo(context, clusterInfo, image.Name, poolName)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to get the mirroring mode of image %q", image.Name)
+		}
+		if info.Mirroring != nil && info.Mirroring.Mode == "snapshot" && info.Mirroring.Primary {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
 /* CreateRBDMirrorBootstrapPeerWithoutPool creates a bootstrap peer for the current cluster
 It creates the cephx user for the remote cluster to use with all the necessary details
 This function is handy on scenarios where no pools have been created yet but replication communication is required (connecting peers)
@@ -362,19 +761,76 @@ func CreateRBDMirrorBootstrapPeerWithoutPool(context *clusterd.Context, clusterI
 	}
 	logger.Infof("successfully created rbd-mirror bootstrap peer token for cluster %q", clusterInfo.NamespacedName().Name)
 
-	mons := sets.NewString()
-	for _, mon := range clusterInfo.Monitors {
-		mons.Insert(mon.Endpoint)
+	peerToken := PeerToken{
+		ClusterFSID: clusterInfo.FSID,
+		ClientID:    rbdMirrorPeerKeyringID,
+		Key:         key,
+		MonHost:     monHost(clusterInfo),
+		Namespace:   clusterInfo.Namespace,
+	}
+
+	return encodePeerToken(peerToken)
+}
+
+// CreateRBDMirrorPeerToken creates a bootstrap peer token for the RBD mirroring of a list of pools
+// only. The token has its own cephx user whose osd caps are restricted to the pools, and an
+// expiration time checked by the remote cluster before importing it.
+func CreateRBDMirrorPeerToken(context *clusterd.Context, clusterInfo *ClusterInfo, userID string, pools []string, expires time.Time) ([]byte, error) {
+	fullClientName := getQualifiedUser(userID)
+	caps := RBDMirrorPeerTokenCaps(pools)
+	logger.Infof("create rbd-mirror peer token %q for pools %v", fullClientName, pools)
+	// the user may exist already with the pools of a previous token, get-or-create fails when the
+	// caps changed
+	key, err := AuthGetKey(context, clusterInfo, fullClientName)
+	if err == nil {
+		err = AuthUpdateCaps(context, clusterInfo, fullClientName, caps)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to update the caps of rbd-mirror peer %q", fullClientName)
+		}
+	} else {
+		key, err = AuthGetOrCreateKey(context, clusterInfo, fullClientName, caps)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create rbd-mirror peer key %q", fullClientName)
+		}
 	}
 
 	peerToken := PeerToken{
 		ClusterFSID: clusterInfo.FSID,
-		ClientID:    rbdMirrorPeerKeyringID,
+		ClientID:    userID,
 		Key:         key,
-		MonHost:     strings.Join(mons.List(), ","),
+		MonHost:     monHost(clusterInfo),
 		Namespace:   clusterInfo.Namespace,
+		Pools:       pools,
+		Expires:     expires.UTC().Format(time.RFC3339),
+	}
+
+	return encodePeerToken(peerToken)
+}
+
+// RBDMirrorPeerTokenCaps returns the caps of the user of a peer token for a list of pools
+func RBDMirrorPeerTokenCaps(pools []string) []string {
+	osdCaps := []string{}
+	for _, pool := range pools {
+		osdCaps = append(osdCaps, fmt.Sprintf("profile rbd pool=%s", pool))
 	}
+	return []string{"mon", "profile rbd-mirror-peer", "osd", strings.Join(osdCaps, ", ")}
+}
+
+// ParsePeerToken decodes a base64 encoded bootstrap peer token
+func ParsePeerToken(token []byte) (*PeerToken, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(token))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode bootstrap peer token")
+	}
+
+	var peerToken PeerToken
+	if err := json.Unmarshal(decoded, &peerToken); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal decoded token")
+	}
+	return &peerToken, nil
+}
 
+func encodePeerToken(peerToken PeerToken) ([]byte, error) {
 	// Marshal the Go type back to JSON
 	decodedTokenBackToJSON, err := json.Marshal(peerToken)
 	if err != nil {
@@ -384,3 +840,11 @@ func CreateRBDMirrorBootstrapPeerWithoutPool(context *clusterd.Context, clusterI
 	// Return the base64 encoded token
 	return []byte(base64.StdEncoding.EncodeToString(decodedTokenBackToJSON)), nil
 }
+
+func monHost(clusterInfo *ClusterInfo) string {
+	mons := sets.NewString()
+	for _, mon := range clusterInfo.Monitors {
+		mons.Insert(mon.Endpoint)
+	}
+	return strings.Join(mons.List(), ",")
+}
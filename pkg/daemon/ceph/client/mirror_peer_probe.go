@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util"
+)
+
+// MirrorPeerConnection is the information of a bootstrap peer token needed to connect to the remote
+// cluster of a mirror peer
+type MirrorPeerConnection struct {
+	FSID    string
+	User    string
+	Key     string
+	MonHost string
+}
+
+// mirrorPeerToken holds the fields of both the rbd and the cephfs bootstrap peer tokens. The rbd
+// token has the id of the peer user, the cephfs token has the full user name.
+type mirrorPeerToken struct {
+	FSID     string `json:"fsid"`
+	ClientID string `json:"client_id"`
+	User     string `json:"user"`
+	Key      string `json:"key"`
+	MonHost  string `json:"mon_host"`
+}
+
+// ParseMirrorPeerConnection decodes an rbd or cephfs bootstrap peer token
+func ParseMirrorPeerConnection(token []byte) (*MirrorPeerConnection, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(token)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode bootstrap peer token")
+	}
+
+	var peerToken mirrorPeerToken
+	err = json.Unmarshal(decoded, &peerToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal bootstrap peer token")
+	}
+
+	user := peerToken.User
+	if user == "" && peerToken.ClientID != "" {
+		user = fmt.Sprintf("client.%s", peerToken.ClientID)
+	}
+	if peerToken.FSID == "" || user == "" || peerToken.Key == "" || peerToken.MonHost == "" {
+		return nil, errors.New("bootstrap peer token is missing the fsid, user, key or mon_host of the remote cluster")
+	}
+
+	return &MirrorPeerConnection{
+		FSID:    peerToken.FSID,
+		User:    user,
+		Key:     peerToken.Key,
+		MonHost: peerToken.MonHost,
+	}, nil
+}
+
+// ProbeMirrorPeer connects to the remote cluster of a bootstrap peer token with the credentials of
+// the token, and checks that the remote cluster is the one the token was created for. It fails when
+// the monitors of the peer cannot be reached or when the key of the peer user was rotated.
+func ProbeMirrorPeer(context *clusterd.Context, token []byte, timeout time.Duration) error {
+	peer, err := ParseMirrorPeerConnection(token)
+	if err != nil {
+		return err
+	}
+
+	// Generate the keyring of the peer user in a temporary file
+	keyring := CephKeyring(CephCred{Username: peer.User, Secret: peer.Key})
+	keyringFile, err := util.CreateTempFile(keyring)
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temp keyring file")
+	}
+	defer os.Remove(keyringFile.Name())
+
+	// Generate an empty config file to be passed as `--conf` argument in ceph CLI, only the
+	// monitors and the key of the token are used to connect to the remote cluster
+	configFile, err := util.CreateTempFile("")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temp config file")
+	}
+	defer os.Remove(configFile.Name())
+
+	args := []string{"fsid",
+		fmt.Sprintf("--conf=%s", configFile.Name()),
+		fmt.Sprintf("--mon-host=%s", peer.MonHost),
+		fmt.Sprintf("--keyring=%s", keyringFile.Name()),
+		fmt.Sprintf("--name=%s", peer.User),
+		fmt.Sprintf("--connect-timeout=%s", strconv.Itoa(int(timeout.Seconds()))),
+		"--format", "json",
+	}
+	output, err := context.Executor.ExecuteCommandWithTimeout(timeout, CephTool, args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to connect to the remote cluster %q as %q. %s", peer.FSID, peer.User, output)
+	}
+
+	var remote struct {
+		FSID string `json:"fsid"`
+	}
+	err = json.Unmarshal([]byte(output), &remote)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the fsid of the remote cluster %q. %s", peer.FSID, output)
+	}
+	if remote.FSID != peer.FSID {
+		return errors.Errorf("the remote cluster has fsid %q instead of the fsid %q of the bootstrap peer token", remote.FSID, peer.FSID)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMirrorPeerConnection(t *testing.T) {
+	// rbd token
+	peer, err := ParseMirrorPeerConnection([]byte(bootstrapPeerToken))
+	assert.NoError(t, err)
+	assert.Equal(t, "c6b087f2-7829-4dbb-bcfc-53dc34e0b35d", peer.FSID)
+	assert.Equal(t, "client.rbd-mirror-peer", peer.User)
+	assert.Equal(t, "AQAWYlZfUCT6DhAAPmVp0lknl09aVVKyrEUu4A==", peer.Key)
+	assert.True(t, strings.HasPrefix(peer.MonHost, "[v2:192.168.111.10:3300"))
+
+	// cephfs token
+	var token BootstrapPeerToken
+	err = json.Unmarshal([]byte(fsMirrorToken), &token)
+	assert.NoError(t, err)
+	peer, err = ParseMirrorPeerConnection([]byte(token.Token))
+	assert.NoError(t, err)
+	assert.Equal(t, "82b7ed92-73b0-4b22-a8b7-ed9483e28756", peer.FSID)
+	assert.Equal(t, "client.mirror", peer.User)
+
+	_, err = ParseMirrorPeerConnection([]byte("invalid"))
+	assert.Error(t, err)
+}
+
+func TestProbeMirrorPeer(t *testing.T) {
+	remoteFSID := `{"fsid":"c6b087f2-7829-4dbb-bcfc-53dc34e0b35d"}`
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithTimeout = func(timeout time.Duration, command string, args ...string) (string, error) {
+		assert.Equal(t, "ceph", command)
+		assert.Equal(t, "fsid", args[0])
+		assert.Contains(t, args, "--name=client.rbd-mirror-peer")
+		assert.Contains(t, args, "--mon-host=[v2:192.168.111.10:3300,v1:192.168.111.10:6789],[v2:192.168.111.12:3300,v1:192.168.111.12:6789],[v2:192.168.111.11:3300,v1:192.168.111.11:6789]")
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "--keyring=") {
+				keyring, err := ioutil.ReadFile(strings.TrimPrefix(arg, "--keyring="))
+				assert.NoError(t, err)
+				assert.Contains(t, string(keyring), "[client.rbd-mirror-peer]")
+				assert.Contains(t, string(keyring), "key = AQAWYlZfUCT6DhAAPmVp0lknl09aVVKyrEUu4A==")
+			}
+		}
+		return remoteFSID, nil
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	t.Run("peer connected", func(t *testing.T) {
+		err := ProbeMirrorPeer(context, []byte(bootstrapPeerToken), time.Second)
+		assert.NoError(t, err)
+	})
+
+	t.Run("another cluster", func(t *testing.T) {
+		remoteFSID = `{"fsid":"39074576-5884-4ef3-8a4d-8a0c5ed33031"}`
+		err := ProbeMirrorPeer(context, []byte(bootstrapPeerToken), time.Second)
+		assert.Error(t, err)
+	})
+
+	t.Run("key rotated", func(t *testing.T) {
+		executor.MockExecuteCommandWithTimeout = func(timeout time.Duration, command string, args ...string) (string, error) {
+			return "[errno 13] RADOS permission denied (error connecting to the cluster)", errors.New("exit status 13")
+		}
+		err := ProbeMirrorPeer(context, []byte(bootstrapPeerToken), time.Second)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "permission denied")
+	})
+}
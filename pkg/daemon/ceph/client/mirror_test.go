@@ -17,7 +17,9 @@ limitations under the License.
 package client
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -349,6 +351,289 @@ func TestRemoveClusterPeer(t *testing.T) {
 	}
 	context := &clusterd.Context{Executor: executor}
 
-	err := removeClusterPeer(context, AdminTestClusterInfo("mycluster"), pool, peerUUID)
+	err := RemoveClusterPeer(context, AdminTestClusterInfo("mycluster"), pool, peerUUID)
 	assert.NoError(t, err)
 }
+
+func TestPromoteDemoteMirroring(t *testing.T) {
+	var commandArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "mirror" {
+			commandArgs = args
+			return "", nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	err := PromotePoolMirroring(context, clusterInfo, "pool-test", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mirror", "pool", "promote", "pool-test"}, commandArgs[:4])
+	assert.NotContains(t, commandArgs, "--force")
+
+	err = PromotePoolMirroring(context, clusterInfo, "pool-test", true)
+	assert.NoError(t, err)
+	assert.Contains(t, commandArgs, "--force")
+
+	err = DemotePoolMirroring(context, clusterInfo, "pool-test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mirror", "pool", "demote", "pool-test"}, commandArgs[:4])
+
+	err = PromoteImageMirroring(context, clusterInfo, "pool-test", "image", true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mirror", "image", "promote", "pool-test/image"}, commandArgs[:4])
+	assert.Contains(t, commandArgs, "--force")
+
+	err = DemoteImageMirroring(context, clusterInfo, "pool-test", "image")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mirror", "image", "demote", "pool-test/image"}, commandArgs[:4])
+}
+
+func TestGetPoolMirroringImagesStatus(t *testing.T) {
+	pool := "pool-test"
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "mirror" {
+			assert.Equal(t, []string{"pool", "status", pool, "--verbose"}, args[1:5])
+			return `{"summary":{"health":"OK"},"images":[{"name":"img","global_id":"1b9c","state":"up+replaying","description":"replaying, {\"bytes_per_snapshot\":1024.0,\"local_snapshot_timestamp\":1650000000,\"remote_snapshot_timestamp\":1650000060,\"syncing_percent\":50}","peer_sites":[{"site_name":"site-a","state":"up+stopped","description":"local image is primary"}]}]}`, nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	status, err := GetPoolMirroringImagesStatus(context, AdminTestClusterInfo("mycluster"), pool)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(status.Images))
+	assert.Equal(t, "site-a", status.Images[0].PeerSites[0].SiteName)
+
+	replay, ok := ParseImageReplayStatus(status.Images[0].Description)
+	assert.True(t, ok)
+	assert.Equal(t, float64(1024), replay.BytesPerSnapshot)
+	assert.Equal(t, int64(1650000000), replay.LocalSnapshotTimestamp)
+	assert.Equal(t, float64(50), replay.SyncingPercent)
+
+	_, ok = ParseImageReplayStatus(status.Images[0].PeerSites[0].Description)
+	assert.False(t, ok)
+}
+
+func TestRepairSnapshotSchedules(t *testing.T) {
+	pool := cephv1.NamedPoolSpec{Name: "replicapool", PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{SnapshotSchedules: []cephv1.SnapshotScheduleSpec{
+		// existing pool schedule
+		{Interval: "1d", StartTime: "14:00:00-05:00"},
+		// missing pool schedule
+		{Interval: "1h"},
+		// existing image schedule
+		{Interval: "4h", Image: "snapeuh"},
+		// missing image schedule, the image was recreated
+		{Interval: "1d", Image: "restored"},
+	}}}}
+	added := [][]string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		switch args[3] {
+		case "ls":
+			return snapshotScheduleListRecursive, nil
+		case "add":
+			added = append(added, args[4:])
+			return "", nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	repaired, err := RepairSnapshotSchedules(context, AdminTestClusterInfo("mycluster"), pool)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, repaired)
+	assert.Equal(t, []string{"--pool", "replicapool", "1h"}, added[0][:3])
+	assert.Equal(t, []string{"--pool", "replicapool", "--image", "restored", "1d"}, added[1][:5])
+}
+
+func TestCountImagesWithoutSnapshotSchedule(t *testing.T) {
+	pool := "pool-test"
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		switch {
+		case args[0] == "mirror" && args[1] == "pool":
+			return `{"summary":{"health":"OK"},"images":[{"name":"scheduled"},{"name":"unscheduled"},{"name":"journal"},{"name":"secondary"}]}`, nil
+		case args[0] == "mirror" && args[3] == "status":
+			return `[{"schedule_time":"2022-03-01 14:00:00","image":"pool-test/scheduled"}]`, nil
+		case args[0] == "info":
+			switch args[1] {
+			case "pool-test/unscheduled":
+				return `{"name":"unscheduled","mirroring":{"mode":"snapshot","state":"enabled","primary":true}}`, nil
+			case "pool-test/journal":
+				return `{"name":"journal","mirroring":{"mode":"journal","state":"enabled","primary":true}}`, nil
+			case "pool-test/secondary":
+				return `{"name":"secondary","mirroring":{"mode":"snapshot","state":"enabled","primary":false}}`, nil
+			}
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	count, err := CountImagesWithoutSnapshotSchedule(context, AdminTestClusterInfo("mycluster"), pool)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestPauseSnapshotSchedules(t *testing.T) {
+	pool := cephv1.NamedPoolSpec{Name: "replicapool", PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{SnapshotSchedules: []cephv1.SnapshotScheduleSpec{
+		// existing pool schedule
+		{Interval: "1d", StartTime: "14:00:00-05:00"},
+		// missing pool schedule
+		{Interval: "1h"},
+		// existing image schedule
+		{Interval: "4h", Image: "snapeuh"},
+	}}}}
+	removed := [][]string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		switch args[3] {
+		case "ls":
+			return snapshotScheduleListRecursive, nil
+		case "remove":
+			removed = append(removed, args[4:])
+			return "", nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	paused, err := PauseSnapshotSchedules(context, AdminTestClusterInfo("mycluster"), pool)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, paused)
+	assert.Equal(t, []string{"--pool", "replicapool", "1d"}, removed[0][:3])
+	assert.Equal(t, []string{"--pool", "replicapool", "--image", "snapeuh", "4h"}, removed[1][:5])
+}
+
+func TestCreateMirrorImageSnapshot(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "mirror" {
+			assert.Equal(t, []string{"image", "snapshot", "replicapool/image1"}, args[1:4])
+			return "Snapshot ID: 4", nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := CreateMirrorImageSnapshot(context, AdminTestClusterInfo("mycluster"), "replicapool", "image1")
+	assert.NoError(t, err)
+}
+
+func TestMigrateImageMirroringMode(t *testing.T) {
+	info := `{"name":"image1","features":["layering","exclusive-lock","journaling"],"mirroring":{"mode":"journal","state":"enabled","primary":true}}`
+	failEnable := false
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "info" {
+			return info, nil
+		}
+		if args[0] == "feature" || args[0] == "mirror" {
+			commands = append(commands, strings.Join(args[:4], " "))
+			if failEnable && args[2] == "enable" && args[4] == "snapshot" {
+				return "", errors.New("failed to enable")
+			}
+			return "", nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	t.Run("journal to snapshot", func(t *testing.T) {
+		err := MigrateImageMirroringMode(context, AdminTestClusterInfo("mycluster"), "replicapool", "image1", "snapshot")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"mirror image disable replicapool/image1",
+			"feature disable replicapool/image1 journaling",
+			"mirror image enable replicapool/image1",
+		}, commands)
+	})
+
+	t.Run("already migrated", func(t *testing.T) {
+		commands = nil
+		err := MigrateImageMirroringMode(context, AdminTestClusterInfo("mycluster"), "replicapool", "image1", "journal")
+		assert.NoError(t, err)
+		assert.Empty(t, commands)
+	})
+
+	t.Run("the mirroring is restored after a failure", func(t *testing.T) {
+		commands = nil
+		failEnable = true
+		err := MigrateImageMirroringMode(context, AdminTestClusterInfo("mycluster"), "replicapool", "image1", "snapshot")
+		assert.Error(t, err)
+		assert.Equal(t, []string{
+			"mirror image disable replicapool/image1",
+			"feature disable replicapool/image1 journaling",
+			"mirror image enable replicapool/image1",
+			"mirror image enable replicapool/image1",
+		}, commands)
+	})
+
+	t.Run("snapshot to journal", func(t *testing.T) {
+		commands = nil
+		info = `{"name":"image1","features":["layering","exclusive-lock"],"mirroring":{"mode":"snapshot","state":"enabled","primary":true}}`
+		err := MigrateImageMirroringMode(context, AdminTestClusterInfo("mycluster"), "replicapool", "image1", "journal")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"mirror image disable replicapool/image1",
+			"feature enable replicapool/image1 journaling",
+			"mirror image enable replicapool/image1",
+		}, commands)
+	})
+}
+
+func TestCreateRBDMirrorPeerToken(t *testing.T) {
+	userExists := false
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] != "auth" {
+			return "", errors.New("unknown command")
+		}
+		commands = append(commands, strings.Join(args[:3], " "))
+		switch args[1] {
+		case "get-key":
+			if !userExists {
+				return "", errors.New("ENOENT")
+			}
+			return `{"key":"AQBsH5ZiAAAAABAAdqgcK7lwCLzUnFbKWd3Y5A=="}`, nil
+		case "get-or-create-key":
+			assert.Equal(t, []string{"mon", "profile rbd-mirror-peer", "osd", "profile rbd pool=pool1, profile rbd pool=pool2"}, args[3:7])
+			return `{"key":"AQBsH5ZiAAAAABAAdqgcK7lwCLzUnFbKWd3Y5A=="}`, nil
+		case "caps":
+			return "", nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+	clusterInfo.Monitors = map[string]*MonInfo{"a": {Name: "a", Endpoint: "10.0.0.1:6789"}, "b": {Name: "b", Endpoint: "10.0.0.2:6789"}}
+	expires := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	token, err := CreateRBDMirrorPeerToken(context, clusterInfo, "rbd-mirror-peer-dr", []string{"pool1", "pool2"}, expires)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"auth get-key client.rbd-mirror-peer-dr", "auth get-or-create-key client.rbd-mirror-peer-dr"}, commands)
+
+	peerToken, err := ParsePeerToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, clusterInfo.FSID, peerToken.ClusterFSID)
+	assert.Equal(t, "rbd-mirror-peer-dr", peerToken.ClientID)
+	assert.Equal(t, "AQBsH5ZiAAAAABAAdqgcK7lwCLzUnFbKWd3Y5A==", peerToken.Key)
+	assert.Equal(t, "10.0.0.1:6789,10.0.0.2:6789", peerToken.MonHost)
+	assert.Equal(t, []string{"pool1", "pool2"}, peerToken.Pools)
+	assert.Equal(t, "2022-03-01T10:00:00Z", peerToken.Expires)
+
+	// the caps of an existing user are updated
+	commands = nil
+	userExists = true
+	_, err = CreateRBDMirrorPeerToken(context, clusterInfo, "rbd-mirror-peer-dr", []string{"pool1", "pool2"}, expires)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"auth get-key client.rbd-mirror-peer-dr", "auth caps client.rbd-mirror-peer-dr"}, commands)
+
+	_, err = ParsePeerToken([]byte("invalid"))
+	assert.Error(t, err)
+}
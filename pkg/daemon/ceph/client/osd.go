@@ -23,6 +23,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
 )
 
 type OSDUsage struct {
@@ -61,12 +62,14 @@ type OSDPerfStats struct {
 
 type OSDDump struct {
 	OSDs []struct {
-		OSD json.Number `json:"osd"`
-		Up  json.Number `json:"up"`
-		In  json.Number `json:"in"`
+		OSD  json.Number `json:"osd"`
+		UUID string      `json:"uuid"`
+		Up   json.Number `json:"up"`
+		In   json.Number `json:"in"`
 	} `json:"osds"`
 	Flags          string              `json:"flags"`
 	CrushNodeFlags map[string][]string `json:"crush_node_flags"`
+	FullRatio      float64             `json:"full_ratio"`
 }
 
 // IsFlagSet checks if an OSD flag is set
@@ -392,3 +395,63 @@ func SetPrimaryAffinity(context *clusterd.Context, clusterInfo *ClusterInfo, osd
 	logger.Infof("successfully applied osd.%d primary-affinity %q", osdID, affinity)
 	return nil
 }
+
+// StuckPG is a placement group stuck in a state for longer than mon_pg_stuck_threshold
+type StuckPG struct {
+	PGID          string `json:"pgid"`
+	State         string `json:"state"`
+	Up            []int  `json:"up"`
+	Acting        []int  `json:"acting"`
+	UpPrimary     int    `json:"up_primary"`
+	ActingPrimary int    `json:"acting_primary"`
+}
+
+// StuckPGStates are the states in which the placement groups are reported stuck
+var StuckPGStates = []string{"inactive", "unclean", "stale", "undersized", "degraded"}
+
+// GetStuckPGs returns the placement groups stuck in any of the StuckPGStates
+func GetStuckPGs(context *clusterd.Context, clusterInfo *ClusterInfo) ([]StuckPG, error) {
+	args := append([]string{"pg", "dump_stuck"}, StuckPGStates...)
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the stuck pgs")
+	}
+	// nothing is returned when no pg is stuck
+	if len(strings.TrimSpace(string(buf))) == 0 {
+		return []StuckPG{}, nil
+	}
+	// the pgs are in a stuck_pg_stats object since pacific, and in a list before
+	var stuck struct {
+		PGs []StuckPG `json:"stuck_pg_stats"`
+	}
+	if err := json.Unmarshal(buf, &stuck); err != nil {
+		if err := json.Unmarshal(buf, &stuck.PGs); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal the stuck pgs. %s", buf)
+		}
+	}
+	return stuck.PGs, nil
+}
+
+// HistoricOp is a slow op recently completed by an OSD
+type HistoricOp struct {
+	Description string  `json:"description"`
+	InitiatedAt string  `json:"initiated_at"`
+	Duration    float64 `json:"duration"`
+}
+
+// GetOSDHistoricSlowOps returns the recent ops of an OSD that were slower than its
+// osd_op_complaint_time
+func GetOSDHistoricSlowOps(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) ([]HistoricOp, error) {
+	args := []string{"tell", fmt.Sprintf("osd.%d", osdID), "dump_historic_slow_ops"}
+	buf, err := NewCephCommand(context, clusterInfo, args).RunWithTimeout(exec.CephCommandsTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the historic slow ops of osd.%d", osdID)
+	}
+	var historic struct {
+		Ops []HistoricOp `json:"Ops"`
+	}
+	if err := json.Unmarshal(buf, &historic); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the historic slow ops of osd.%d. %s", osdID, buf)
+	}
+	return historic.Ops, nil
+}
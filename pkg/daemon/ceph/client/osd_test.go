@@ -226,3 +226,39 @@ func TestOSDOkToStop(t *testing.T) {
 		assert.NotContains(t, seenArgs[3], "--max") // do not issue the "--max" flag below pacific
 	})
 }
+
+func TestGetStuckPGs(t *testing.T) {
+	output := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "pg" && args[1] == "dump_stuck" {
+				assert.Equal(t, StuckPGStates, args[2:7])
+				return output, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	// nothing is returned when no pg is stuck
+	pgs, err := GetStuckPGs(context, clusterInfo)
+	assert.NoError(t, err)
+	assert.Empty(t, pgs)
+
+	output = `{"stuck_pg_stats":[{"pgid":"1.0","state":"active+undersized+degraded","up":[0,1],"acting":[0,1],"up_primary":0,"acting_primary":0}]}`
+	pgs, err = GetStuckPGs(context, clusterInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, []StuckPG{{PGID: "1.0", State: "active+undersized+degraded", Up: []int{0, 1}, Acting: []int{0, 1}}}, pgs)
+
+	// the pgs are in a list before pacific
+	output = `[{"pgid":"1.1","state":"stale+peering","up":[2],"acting":[2],"up_primary":2,"acting_primary":2}]`
+	pgs, err = GetStuckPGs(context, clusterInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1", pgs[0].PGID)
+	assert.Equal(t, []int{2}, pgs[0].Acting)
+
+	output = `not json`
+	_, err = GetStuckPGs(context, clusterInfo)
+	assert.Error(t, err)
+}
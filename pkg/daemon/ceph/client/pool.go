@@ -44,6 +44,23 @@ type CephStoragePoolSummary struct {
 	Number int    `json:"poolnum"`
 }
 
+// CephStoragePool is a pool listed with its details
+type CephStoragePool struct {
+	Name string `json:"pool_name"`
+	// Type is 1 for the replicated pools and 3 for the erasure coded pools
+	Type               int                    `json:"type"`
+	Size               uint                   `json:"size"`
+	ErasureCodeProfile string                 `json:"erasure_code_profile"`
+	Applications       map[string]interface{} `json:"application_metadata"`
+}
+
+const (
+	// PoolTypeReplicated is the type of the replicated pools
+	PoolTypeReplicated = 1
+	// PoolTypeErasureCoded is the type of the erasure coded pools
+	PoolTypeErasureCoded = 3
+)
+
 type CephStoragePoolDetails struct {
 	Name                   string  `json:"pool"`
 	Number                 int     `json:"pool_id"`
@@ -62,6 +79,7 @@ type CephStoragePoolStats struct {
 		Name  string `json:"name"`
 		ID    int    `json:"id"`
 		Stats struct {
+			Stored       float64 `json:"stored"`
 			BytesUsed    float64 `json:"bytes_used"`
 			RawBytesUsed float64 `json:"raw_bytes_used"`
 			MaxAvail     float64 `json:"max_avail"`
@@ -73,6 +91,11 @@ type CephStoragePoolStats struct {
 			WriteBytes   float64 `json:"wr_bytes"`
 		} `json:"stats"`
 	} `json:"pools"`
+	StatsByClass map[string]struct {
+		TotalBytes        float64 `json:"total_bytes"`
+		TotalAvailBytes   float64 `json:"total_avail_bytes"`
+		TotalUsedRawBytes float64 `json:"total_used_raw_bytes"`
+	} `json:"stats_by_class"`
 }
 
 type PoolStatistics struct {
@@ -104,6 +127,23 @@ func ListPoolSummaries(context *clusterd.Context, clusterInfo *ClusterInfo) ([]C
 	return pools, nil
 }
 
+// ListPools lists the pools with their type, size and applications
+func ListPools(context *clusterd.Context, clusterInfo *ClusterInfo) ([]CephStoragePool, error) {
+	args := []string{"osd", "pool", "ls", "detail"}
+	output, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list pools")
+	}
+
+	var pools []CephStoragePool
+	err = json.Unmarshal(output, &pools)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(output))
+	}
+
+	return pools, nil
+}
+
 func GetPoolNamesByID(context *clusterd.Context, clusterInfo *ClusterInfo) (map[int]string, error) {
 	pools, err := ListPoolSummaries(context, clusterInfo)
 	if err != nil {
@@ -333,7 +373,7 @@ func setCommonPoolProperties(context *clusterd.Context, clusterInfo *ClusterInfo
 			}
 			for _, peer := range mirrorInfo.Peers {
 				if peer.UUID != "" {
-					err := removeClusterPeer(context, clusterInfo, pool.Name, peer.UUID)
+					err := RemoveClusterPeer(context, clusterInfo, pool.Name, peer.UUID)
 					if err != nil {
 						return errors.Wrapf(err, "failed to remove cluster peer with UUID %q for the pool %q", peer.UUID, pool.Name)
 					}
@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	kexec "k8s.io/utils/exec"
+)
+
+// CommandConn sends commands to the mons and mgrs of a cluster over librados, the same as the
+// MonCommand and MgrCommand methods of the go-ceph rados connection
+type CommandConn interface {
+	MonCommand(args []byte) ([]byte, string, error)
+	MgrCommand(args [][]byte) ([]byte, string, error)
+	Shutdown()
+}
+
+// NewCommandConn connects to the cluster over librados. The go-ceph bindings need cgo and the ceph
+// libraries, so it is only set when the operator is built with the 'rados' tag. All the commands
+// are run with the ceph CLI otherwise.
+var NewCommandConn func(context *clusterd.Context, clusterInfo *ClusterInfo) (CommandConn, error)
+
+var (
+	commandConnsMutex sync.Mutex
+	// the librados connections keyed by the namespace of the cluster
+	commandConns = map[string]CommandConn{}
+)
+
+// radosCommand describes how the args of a ceph CLI command translate to the JSON command sent to
+// the mons or the mgrs
+type radosCommand struct {
+	// the words of the command, which make its prefix
+	prefix []string
+	// the names of the positional args following the prefix, the trailing ones are optional
+	args []string
+	// the names of the args given as flags, keyed by the flag
+	flags map[string]string
+	// whether the command is served by the mgrs rather than the mons
+	mgr bool
+}
+
+// radosCommands are the commands run on every reconcile, or often enough to be worth skipping the
+// fork and exec of the ceph CLI. Any other command is run with the CLI.
+var radosCommands = []radosCommand{
+	{prefix: []string{"status"}},
+	{prefix: []string{"quorum_status"}},
+	{prefix: []string{"versions"}},
+	{prefix: []string{"mgr", "dump"}},
+	{prefix: []string{"mgr", "stat"}},
+	{prefix: []string{"osd", "dump"}},
+	{prefix: []string{"osd", "ls"}},
+	{prefix: []string{"osd", "tree"}},
+	{prefix: []string{"osd", "lspools"}},
+	{prefix: []string{"osd", "crush", "class", "ls"}},
+	{prefix: []string{"osd", "crush", "rule", "dump"}, args: []string{"name"}},
+	{prefix: []string{"osd", "pool", "get"}, args: []string{"pool", "var"}},
+	{prefix: []string{"osd", "pool", "set"}, args: []string{"pool", "var", "val"}},
+	{prefix: []string{"osd", "pool", "set-quota"}, args: []string{"pool", "field", "val"}},
+	{prefix: []string{"osd", "pool", "application", "get"}, args: []string{"pool", "app", "key"}},
+	{prefix: []string{"fs", "ls"}},
+	{prefix: []string{"fs", "dump"}},
+	{prefix: []string{"fs", "get"}, args: []string{"fs_name"}},
+	{prefix: []string{"df"}, args: []string{"detail"}, mgr: true},
+	{prefix: []string{"osd", "df"}, mgr: true},
+	{prefix: []string{"osd", "perf"}, mgr: true},
+	{prefix: []string{"fs", "subvolume", "info"}, args: []string{"vol_name", "sub_name"}, flags: map[string]string{"--group_name": "group_name"}, mgr: true},
+	{prefix: []string{"fs", "subvolume", "snapshot", "create"}, args: []string{"vol_name", "sub_name", "snap_name"}, flags: map[string]string{"--group_name": "group_name"}, mgr: true},
+	{prefix: []string{"fs", "subvolumegroup", "create"}, args: []string{"vol_name", "group_name"}, mgr: true},
+	{prefix: []string{"fs", "subvolumegroup", "rm"}, args: []string{"vol_name", "group_name"}, mgr: true},
+	{prefix: []string{"fs", "snapshot", "mirror", "daemon", "status"}, args: []string{"fs_name"}, mgr: true},
+	{prefix: []string{"fs", "snapshot", "mirror", "peer_list"}, args: []string{"fs_name"}, mgr: true},
+}
+
+// translateRadosCommand translates the args of a ceph CLI command to the JSON command sent over
+// librados. It returns false if the command is not one of the radosCommands or has args the
+// translation does not know, in which case it must be run with the CLI.
+func translateRadosCommand(args []string, format string) ([]byte, bool, bool) {
+	for _, command := range radosCommands {
+		if len(args) < len(command.prefix) || strings.Join(args[:len(command.prefix)], " ") != strings.Join(command.prefix, " ") {
+			continue
+		}
+
+		cmd := map[string]string{"prefix": strings.Join(command.prefix, " "), "format": format}
+		positional := 0
+		rest := args[len(command.prefix):]
+		for i := 0; i < len(rest); i++ {
+			if strings.HasPrefix(rest[i], "--") {
+				name, ok := command.flags[rest[i]]
+				if !ok || i+1 == len(rest) {
+					return nil, false, false
+				}
+				cmd[name] = rest[i+1]
+				i++
+				continue
+			}
+			if positional == len(command.args) {
+				return nil, false, false
+			}
+			cmd[command.args[positional]] = rest[i]
+			positional++
+		}
+
+		buf, err := json.Marshal(cmd)
+		if err != nil {
+			return nil, false, false
+		}
+		return buf, command.mgr, true
+	}
+	return nil, false, false
+}
+
+// runOverRados runs the command over librados when the operator is built with the bindings and the
+// command is one of the radosCommands. It returns false if the command must be run with the CLI.
+func (c *CephToolCommand) runOverRados() (string, bool, error) {
+	if NewCommandConn == nil || c.tool != CephTool || c.RemoteExecution || c.combinedOutput || c.timeout != 0 || RunAllCephCommandsInToolboxPod != "" {
+		return "", false, nil
+	}
+	format := "plain"
+	if c.JsonOutput {
+		format = "json"
+	}
+	cmd, mgr, ok := translateRadosCommand(c.args, format)
+	if !ok {
+		return "", false, nil
+	}
+
+	conn, err := commandConn(c.context, c.clusterInfo)
+	if err != nil {
+		logger.Warningf("failed to connect to the cluster in namespace %q over librados, running the command with the ceph CLI. %v", c.clusterInfo.Namespace, err)
+		return "", false, nil
+	}
+
+	logger.Debugf("Running command over librados: %s", string(cmd))
+	var output []byte
+	var status string
+	if mgr {
+		output, status, err = conn.MgrCommand([][]byte{cmd})
+	} else {
+		output, status, err = conn.MonCommand(cmd)
+	}
+	if err != nil {
+		code := radosErrorCode(err)
+		if code == int(syscall.ETIMEDOUT) || code == int(syscall.ENOTCONN) {
+			// connect again on the next command in case the connection went stale
+			CloseCommandConn(c.clusterInfo.Namespace)
+		}
+		// the same as the output and the exit code of the CLI, which callers check
+		return fmt.Sprintf("%s. %s", strings.TrimSpace(string(output)), status), true, &kexec.CodeExitError{Err: errors.Errorf("%s. %v", status, err), Code: code}
+	}
+	return strings.TrimSpace(string(output)), true, nil
+}
+
+// radosErrorCode returns the errno of a librados error, which the ceph CLI returns as its exit code
+func radosErrorCode(err error) int {
+	if e, ok := errors.Cause(err).(interface{ ErrorCode() int }); ok && e.ErrorCode() < 0 {
+		return -e.ErrorCode()
+	}
+	return 1
+}
+
+// commandConn returns the librados connection to the cluster, connecting to it the first time
+func commandConn(context *clusterd.Context, clusterInfo *ClusterInfo) (CommandConn, error) {
+	commandConnsMutex.Lock()
+	defer commandConnsMutex.Unlock()
+	if conn, ok := commandConns[clusterInfo.Namespace]; ok {
+		return conn, nil
+	}
+	conn, err := NewCommandConn(context, clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	commandConns[clusterInfo.Namespace] = conn
+	return conn, nil
+}
+
+// CloseCommandConn closes the librados connection to the cluster in the namespace, if any
+func CloseCommandConn(namespace string) {
+	commandConnsMutex.Lock()
+	defer commandConnsMutex.Unlock()
+	if conn, ok := commandConns[namespace]; ok {
+		conn.Shutdown()
+		delete(commandConns, namespace)
+	}
+}
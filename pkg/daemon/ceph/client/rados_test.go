@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRadosError int
+
+func (e fakeRadosError) Error() string  { return "rados error" }
+func (e fakeRadosError) ErrorCode() int { return int(e) }
+
+type fakeCommandConn struct {
+	monCommands []map[string]string
+	mgrCommands []map[string]string
+	err         error
+	shutdown    bool
+}
+
+func (f *fakeCommandConn) MonCommand(args []byte) ([]byte, string, error) {
+	var cmd map[string]string
+	if err := json.Unmarshal(args, &cmd); err != nil {
+		return nil, "", err
+	}
+	f.monCommands = append(f.monCommands, cmd)
+	if f.err != nil {
+		return nil, "error from the mon", f.err
+	}
+	return []byte(`{"fsid":"abc"}` + "\n"), "", nil
+}
+
+func (f *fakeCommandConn) MgrCommand(args [][]byte) ([]byte, string, error) {
+	var cmd map[string]string
+	if err := json.Unmarshal(args[0], &cmd); err != nil {
+		return nil, "", err
+	}
+	f.mgrCommands = append(f.mgrCommands, cmd)
+	return []byte(`{}`), "", nil
+}
+
+func (f *fakeCommandConn) Shutdown() {
+	f.shutdown = true
+}
+
+func TestTranslateRadosCommand(t *testing.T) {
+	translate := func(format string, args ...string) (map[string]string, bool, bool) {
+		buf, mgr, ok := translateRadosCommand(args, format)
+		if !ok {
+			return nil, mgr, ok
+		}
+		var cmd map[string]string
+		assert.NoError(t, json.Unmarshal(buf, &cmd))
+		return cmd, mgr, ok
+	}
+
+	cmd, mgr, ok := translate("json", "status")
+	assert.True(t, ok)
+	assert.False(t, mgr)
+	assert.Equal(t, map[string]string{"prefix": "status", "format": "json"}, cmd)
+
+	cmd, mgr, ok = translate("json", "df", "detail")
+	assert.True(t, ok)
+	assert.True(t, mgr)
+	assert.Equal(t, map[string]string{"prefix": "df", "detail": "detail", "format": "json"}, cmd)
+
+	cmd, mgr, ok = translate("json", "osd", "df")
+	assert.True(t, ok)
+	assert.True(t, mgr)
+	assert.Equal(t, map[string]string{"prefix": "osd df", "format": "json"}, cmd)
+
+	cmd, _, ok = translate("json", "osd", "pool", "get", "replicapool", "all")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"prefix": "osd pool get", "pool": "replicapool", "var": "all", "format": "json"}, cmd)
+
+	cmd, _, ok = translate("json", "osd", "pool", "set-quota", "replicapool", "max_bytes", "1024")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"prefix": "osd pool set-quota", "pool": "replicapool", "field": "max_bytes", "val": "1024", "format": "json"}, cmd)
+
+	cmd, mgr, ok = translate("json", "fs", "subvolume", "info", "myfs", "sub0", "--group_name", "csi")
+	assert.True(t, ok)
+	assert.True(t, mgr)
+	assert.Equal(t, map[string]string{"prefix": "fs subvolume info", "vol_name": "myfs", "sub_name": "sub0", "group_name": "csi", "format": "json"}, cmd)
+
+	cmd, _, ok = translate("plain", "fs", "subvolumegroup", "create", "myfs", "csi")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"prefix": "fs subvolumegroup create", "vol_name": "myfs", "group_name": "csi", "format": "plain"}, cmd)
+
+	// optional trailing args
+	cmd, _, ok = translate("json", "fs", "snapshot", "mirror", "daemon", "status")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"prefix": "fs snapshot mirror daemon status", "format": "json"}, cmd)
+
+	// commands and args the translation does not know are run with the CLI
+	_, _, ok = translate("json", "osd", "pool", "create", "replicapool", "0", "replicated")
+	assert.False(t, ok)
+	_, _, ok = translate("json", "osd", "pool", "set", "replicapool", "size", "3", "--yes-i-really-mean-it")
+	assert.False(t, ok)
+	_, _, ok = translate("json", "status", "extra")
+	assert.False(t, ok)
+	_, _, ok = translate("json", "fs", "subvolume", "info", "myfs", "sub0", "--group_name")
+	assert.False(t, ok)
+}
+
+func TestRunOverRados(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "from the cli", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("rook-ceph")
+	conn := &fakeCommandConn{}
+	connects := 0
+	NewCommandConn = func(context *clusterd.Context, clusterInfo *ClusterInfo) (CommandConn, error) {
+		connects++
+		return conn, nil
+	}
+	defer func() {
+		CloseCommandConn("rook-ceph")
+		NewCommandConn = nil
+	}()
+
+	t.Run("hot path command", func(t *testing.T) {
+		output, err := NewCephCommand(context, clusterInfo, []string{"status"}).Run()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"fsid":"abc"}`, string(output))
+		_, err = NewCephCommand(context, clusterInfo, []string{"df", "detail"}).Run()
+		assert.NoError(t, err)
+		assert.Len(t, conn.monCommands, 1)
+		assert.Len(t, conn.mgrCommands, 1)
+		assert.Equal(t, 1, connects)
+	})
+
+	t.Run("other command", func(t *testing.T) {
+		output, err := NewCephCommand(context, clusterInfo, []string{"osd", "pool", "create", "replicapool", "0"}).Run()
+		assert.NoError(t, err)
+		assert.Equal(t, "from the cli", string(output))
+		// the commands with their own timeout are run with the CLI
+		_, err = NewCephCommand(context, clusterInfo, []string{"status"}).RunWithTimeout(exec.CephCommandsTimeout)
+		assert.NoError(t, err)
+		assert.Len(t, conn.monCommands, 1)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		exec.CephCommandsRetries = 0
+		defer func() { exec.CephCommandsRetries = 3 }()
+		defer RemoveCircuitBreaker("rook-ceph")
+		conn.err = errors.Wrap(fakeRadosError(-int(syscall.ENOENT)), "failed")
+		output, err := NewCephCommand(context, clusterInfo, []string{"fs", "get", "myfs"}).Run()
+		assert.Error(t, err)
+		assert.Equal(t, ". error from the mon", string(output))
+		code, ok := exec.ExitStatus(err)
+		assert.True(t, ok)
+		assert.Equal(t, int(syscall.ENOENT), code)
+		assert.False(t, conn.shutdown)
+
+		// a stale connection is closed to connect again on the next command
+		conn.err = fakeRadosError(-int(syscall.ETIMEDOUT))
+		_, err = NewCephCommand(context, clusterInfo, []string{"status"}).Run()
+		assert.Error(t, err)
+		assert.True(t, conn.shutdown)
+		conn.err = nil
+		_, err = NewCephCommand(context, clusterInfo, []string{"status"}).Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, connects)
+	})
+}
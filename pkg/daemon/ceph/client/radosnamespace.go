@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+// RadosNamespaceMirroringInfo is the mirroring info of a RADOS namespace
+type RadosNamespaceMirroringInfo struct {
+	Mode            string `json:"mode"`
+	RemoteNamespace string `json:"remote_namespace"`
+}
+
+// radosNamespaceSpec returns the <pool>/<namespace> spec of the rbd commands
+func radosNamespaceSpec(poolName, namespace string) string {
+	return fmt.Sprintf("%s/%s", poolName, namespace)
+}
+
+// CreateRadosNamespace creates a RADOS namespace in a pool, it succeeds if the namespace already exists
+func CreateRadosNamespace(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string) error {
+	logger.Infof("creating rados namespace %q in pool %q", namespace, poolName)
+
+	args := []string{"namespace", "create", radosNamespaceSpec(poolName, namespace)}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.EEXIST) {
+			logger.Debugf("rados namespace %q already exists in pool %q", namespace, poolName)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to create rados namespace %q in pool %q. %s", namespace, poolName, output)
+	}
+
+	logger.Infof("successfully created rados namespace %q in pool %q", namespace, poolName)
+	return nil
+}
+
+// ListRadosNamespaces lists the RADOS namespaces of a pool
+func ListRadosNamespaces(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string) ([]string, error) {
+	args := []string{"namespace", "ls", poolName}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+
+	output, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the rados namespaces of pool %q. %s", poolName, output)
+	}
+
+	var namespaces []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(output, &namespaces); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the rados namespaces of pool %q. %s", poolName, output)
+	}
+	names := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		names = append(names, namespace.Name)
+	}
+	return names, nil
+}
+
+// DeleteRadosNamespace removes a RADOS namespace from a pool
+func DeleteRadosNamespace(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string) error {
+	logger.Infof("deleting rados namespace %q from pool %q", namespace, poolName)
+
+	args := []string{"namespace", "remove", radosNamespaceSpec(poolName, namespace)}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		logger.Debugf("failed to delete rados namespace %q from pool %q. %s. %v", namespace, poolName, output, err)
+		// Intentionally don't wrap the error so the caller can inspect the return code
+		return err
+	}
+
+	logger.Infof("successfully deleted rados namespace %q from pool %q", namespace, poolName)
+	return nil
+}
+
+// GetRadosNamespaceMirroringInfo returns the mirroring mode and the remote namespace of a RADOS namespace
+func GetRadosNamespaceMirroringInfo(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string) (*RadosNamespaceMirroringInfo, error) {
+	args := []string{"mirror", "pool", "info", radosNamespaceSpec(poolName, namespace)}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+
+	buf, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve mirroring info of rados namespace %q of pool %q. %s", namespace, poolName, string(buf))
+	}
+
+	var info RadosNamespaceMirroringInfo
+	if err := json.Unmarshal(buf, &info); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal mirror pool info response")
+	}
+
+	return &info, nil
+}
+
+// EnableRadosNamespaceMirroring enables the mirroring of the images of a RADOS namespace, to the
+// remote namespace of the peers of the pool
+func EnableRadosNamespaceMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string, mirroring *cephv1.RadosNamespaceMirroring) error {
+	logger.Infof("enabling mirroring type %q for rados namespace %q of pool %q", mirroring.Mode, namespace, poolName)
+
+	args := []string{"mirror", "pool", "enable", radosNamespaceSpec(poolName, namespace), string(mirroring.Mode)}
+	if mirroring.RemoteNamespace != nil {
+		args = append(args, "--remote-namespace", *mirroring.RemoteNamespace)
+	}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to enable mirroring type %q for rados namespace %q of pool %q. %s", mirroring.Mode, namespace, poolName, output)
+	}
+
+	return nil
+}
+
+// DisableRadosNamespaceMirroring disables the mirroring of the images of a RADOS namespace
+func DisableRadosNamespaceMirroring(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string) error {
+	logger.Infof("disabling mirroring for rados namespace %q of pool %q", namespace, poolName)
+
+	args := []string{"mirror", "pool", "disable", radosNamespaceSpec(poolName, namespace)}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to disable mirroring for rados namespace %q of pool %q. %s", namespace, poolName, output)
+	}
+
+	return nil
+}
+
+// ConfigureRadosNamespaceSnapshotSchedules sets the mirror snapshot schedules of a RADOS namespace.
+// The schedules missing from the namespace are added and the ones not listed anymore are removed,
+// so the snapshots of the namespace are not interrupted while the schedules are updated.
+func ConfigureRadosNamespaceSnapshotSchedules(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string, schedules []cephv1.SnapshotScheduleSpec) error {
+	args := []string{"mirror", "snapshot", "schedule", "ls", "--pool", poolName, "--namespace", namespace}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+	buf, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve snapshot schedules of rados namespace %q of pool %q. %s", namespace, poolName, string(buf))
+	}
+	var existing []cephv1.SnapshotSchedule
+	if err := json.Unmarshal(buf, &existing); err != nil {
+		return errors.Wrap(err, "failed to unmarshal mirror snapshot schedule list response")
+	}
+
+	expected := map[cephv1.SnapshotSchedule]bool{}
+	for _, schedule := range schedules {
+		expected[cephv1.SnapshotSchedule{Interval: schedule.Interval, StartTime: schedule.StartTime}] = true
+	}
+	found := map[cephv1.SnapshotSchedule]bool{}
+	for _, schedule := range existing {
+		if expected[schedule] {
+			found[schedule] = true
+			continue
+		}
+		args := []string{"mirror", "snapshot", "schedule", "remove", "--pool", poolName, "--namespace", namespace, schedule.Interval}
+		if schedule.StartTime != "" {
+			args = append(args, schedule.StartTime)
+		}
+		output, err := NewRBDCommand(context, clusterInfo, args).Run()
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove snapshot schedule %q of rados namespace %q of pool %q. %s", schedule.Interval, namespace, poolName, output)
+		}
+		logger.Infof("removed snapshot schedule %q of rados namespace %q of pool %q", schedule.Interval, namespace, poolName)
+	}
+
+	for _, schedule := range schedules {
+		key := cephv1.SnapshotSchedule{Interval: schedule.Interval, StartTime: schedule.StartTime}
+		if found[key] {
+			continue
+		}
+		args := []string{"mirror", "snapshot", "schedule", "add", "--pool", poolName, "--namespace", namespace, schedule.Interval}
+		if schedule.StartTime != "" {
+			args = append(args, schedule.StartTime)
+		}
+		output, err := NewRBDCommand(context, clusterInfo, args).Run()
+		if err != nil {
+			return errors.Wrapf(err, "failed to add snapshot schedule %q to rados namespace %q of pool %q. %s", schedule.Interval, namespace, poolName, output)
+		}
+		found[key] = true
+		logger.Infof("added snapshot schedule %q to rados namespace %q of pool %q", schedule.Interval, namespace, poolName)
+	}
+
+	return nil
+}
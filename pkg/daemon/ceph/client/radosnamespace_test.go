@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableRadosNamespaceMirroring(t *testing.T) {
+	var lastArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "mirror" && args[1] == "pool" && args[2] == "enable" {
+			lastArgs = args
+			return "", nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := EnableRadosNamespaceMirroring(context, AdminTestClusterInfo("mycluster"), "replicapool", "tenant-a", &cephv1.RadosNamespaceMirroring{Mode: cephv1.RadosNamespaceMirroringModeImage})
+	assert.NoError(t, err)
+	assert.Equal(t, "replicapool/tenant-a", lastArgs[3])
+	assert.Equal(t, "image", lastArgs[4])
+	assert.NotContains(t, lastArgs, "--remote-namespace")
+
+	remote := "tenant-b"
+	err = EnableRadosNamespaceMirroring(context, AdminTestClusterInfo("mycluster"), "replicapool", "tenant-a", &cephv1.RadosNamespaceMirroring{Mode: cephv1.RadosNamespaceMirroringModePool, RemoteNamespace: &remote})
+	assert.NoError(t, err)
+	assert.Equal(t, "pool", lastArgs[4])
+	assert.Equal(t, "--remote-namespace", lastArgs[5])
+	assert.Equal(t, "tenant-b", lastArgs[6])
+}
+
+func TestGetRadosNamespaceMirroringInfo(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "mirror" && args[1] == "pool" && args[2] == "info" {
+			assert.Equal(t, "replicapool/tenant-a", args[3])
+			return `{"mode":"image","site_name":"site-a","remote_namespace":"tenant-b","peers":[]}`, nil
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	info, err := GetRadosNamespaceMirroringInfo(context, AdminTestClusterInfo("mycluster"), "replicapool", "tenant-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "image", info.Mode)
+	assert.Equal(t, "tenant-b", info.RemoteNamespace)
+}
+
+func TestConfigureRadosNamespaceSnapshotSchedules(t *testing.T) {
+	var added, removed []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "mirror" && args[1] == "snapshot" && args[2] == "schedule" {
+			assert.Equal(t, []string{"--pool", "replicapool", "--namespace", "tenant-a"}, args[4:8])
+			switch args[3] {
+			case "ls":
+				return `[{"interval":"3d","start_time":""},{"interval":"1d","start_time":"14:00:00-05:00"}]`, nil
+			case "add":
+				added = append(added, args[8])
+				return "", nil
+			case "remove":
+				removed = append(removed, args[8])
+				return "", nil
+			}
+		}
+		return "", errors.New("unknown command")
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	schedules := []cephv1.SnapshotScheduleSpec{
+		{Interval: "1d", StartTime: "14:00:00-05:00"},
+		{Interval: "4h"},
+	}
+	err := ConfigureRadosNamespaceSnapshotSchedules(context, AdminTestClusterInfo("mycluster"), "replicapool", "tenant-a", schedules)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"4h"}, added)
+	assert.Equal(t, []string{"3d"}, removed)
+}
@@ -64,10 +64,12 @@ type HealthStatus struct {
 type CheckMessage struct {
 	Severity string  `json:"severity"`
 	Summary  Summary `json:"summary"`
+	Muted    bool    `json:"muted"`
 }
 
 type Summary struct {
 	Message string `json:"message"`
+	Count   int    `json:"count"`
 }
 
 type MonMap struct {
@@ -0,0 +1,262 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+// cephFSSnapshotTimeFormat is the format of the creation time of the CephFS snapshots
+const cephFSSnapshotTimeFormat = "2006-01-02 15:04:05.999999"
+
+// CephFSSubvolumeInfo represents the details of a CephFS subvolume
+type CephFSSubvolumeInfo struct {
+	Path string `json:"path"`
+	// BytesQuota is a number of bytes, or "infinite" when the subvolume has no quota
+	BytesQuota json.RawMessage `json:"bytes_quota"`
+}
+
+// Quota returns the quota of the subvolume in bytes, false if the subvolume has no quota
+func (i *CephFSSubvolumeInfo) Quota() (uint64, bool) {
+	quota, err := strconv.ParseUint(string(i.BytesQuota), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return quota, true
+}
+
+// GetCephFSSubvolumeInfo returns the details of a CephFS subvolume.
+// volName is the name of the Ceph FS volume, the same as the CephFilesystem CR name.
+func GetCephFSSubvolumeInfo(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, subvolName string) (*CephFSSubvolumeInfo, error) {
+	args := []string{"fs", "subvolume", "info", volName, subvolName}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get info of subvolume %q in group %q of filesystem %q. %s", subvolName, groupName, volName, string(buf))
+	}
+
+	var info CephFSSubvolumeInfo
+	if err = json.Unmarshal(buf, &info); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed, raw buffer response: %s", string(buf))
+	}
+	return &info, nil
+}
+
+// ListCephFSSubvolumes lists the subvolumes of a CephFS subvolume group
+func ListCephFSSubvolumes(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName string) ([]string, error) {
+	args := []string{"fs", "subvolume", "ls", volName}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the subvolumes in group %q of filesystem %q. %s", groupName, volName, string(buf))
+	}
+
+	var subvolumes []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(buf, &subvolumes); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed, raw buffer response: %s", string(buf))
+	}
+	names := make([]string, 0, len(subvolumes))
+	for _, subvolume := range subvolumes {
+		names = append(names, subvolume.Name)
+	}
+	return names, nil
+}
+
+// CreateCephFSSubvolume creates a CephFS subvolume with the quota of size bytes, or without quota
+// when size is 0. The owner uid and gid and the octal mode are only set when not empty. With
+// namespaceIsolated, the subvolume is created in a separate RADOS namespace. An existing subvolume is
+// kept, only its attributes are updated.
+func CreateCephFSSubvolume(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, subvolName string, size uint64, uid, gid *int64, mode string, namespaceIsolated bool) error {
+	logger.Infof("creating cephfs subvolume %q in group %q of filesystem %q", subvolName, groupName, volName)
+	args := []string{"fs", "subvolume", "create", volName, subvolName}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	if size > 0 {
+		args = append(args, "--size", strconv.FormatUint(size, 10))
+	}
+	if uid != nil {
+		args = append(args, "--uid", strconv.FormatInt(*uid, 10))
+	}
+	if gid != nil {
+		args = append(args, "--gid", strconv.FormatInt(*gid, 10))
+	}
+	if mode != "" {
+		args = append(args, "--mode", mode)
+	}
+	if namespaceIsolated {
+		args = append(args, "--namespace-isolated")
+	}
+	cmd := NewCephCommand(context, clusterInfo, args)
+	cmd.JsonOutput = false
+	buf, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create subvolume %q in group %q of filesystem %q. %s", subvolName, groupName, volName, string(buf))
+	}
+
+	logger.Infof("successfully created cephfs subvolume %q in group %q of filesystem %q", subvolName, groupName, volName)
+	return nil
+}
+
+// ResizeCephFSSubvolume sets the quota of a CephFS subvolume to size bytes, or removes the quota when
+// size is 0
+func ResizeCephFSSubvolume(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, subvolName string, size uint64) error {
+	newSize := "inf"
+	if size > 0 {
+		newSize = strconv.FormatUint(size, 10)
+	}
+	logger.Infof("resizing cephfs subvolume %q in group %q of filesystem %q to %s", subvolName, groupName, volName, newSize)
+	args := []string{"fs", "subvolume", "resize", volName, subvolName, newSize}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to resize subvolume %q in group %q of filesystem %q. %s", subvolName, groupName, volName, string(buf))
+	}
+
+	logger.Infof("successfully resized cephfs subvolume %q in group %q of filesystem %q to %s", subvolName, groupName, volName, newSize)
+	return nil
+}
+
+// CreateCephFSSubvolumeSnapshot takes a snapshot of a CephFS subvolume. An existing snapshot with
+// the same name is kept.
+func CreateCephFSSubvolumeSnapshot(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, subvolName, snapName string) error {
+	args := []string{"fs", "subvolume", "snapshot", "create", volName, subvolName, snapName}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	cmd := NewCephCommand(context, clusterInfo, args)
+	cmd.JsonOutput = false
+	buf, err := cmd.Run()
+	if err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.EEXIST) {
+			logger.Debugf("snapshot %q of subvolume %q in group %q of filesystem %q already exists", snapName, subvolName, groupName, volName)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to create snapshot %q of subvolume %q in group %q of filesystem %q. %s", snapName, subvolName, groupName, volName, string(buf))
+	}
+
+	logger.Infof("successfully created snapshot %q of subvolume %q in group %q of filesystem %q", snapName, subvolName, groupName, volName)
+	return nil
+}
+
+// ListCephFSSubvolumeSnapshots lists the snapshots of a CephFS subvolume
+func ListCephFSSubvolumeSnapshots(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, subvolName string) ([]string, error) {
+	args := []string{"fs", "subvolume", "snapshot", "ls", volName, subvolName}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the snapshots of subvolume %q in group %q of filesystem %q. %s", subvolName, groupName, volName, string(buf))
+	}
+
+	var snapshots []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(buf, &snapshots); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed, raw buffer response: %s", string(buf))
+	}
+	names := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.Name)
+	}
+	return names, nil
+}
+
+// CephFSSubvolumeSnapshotInfo represents the details of a snapshot of a CephFS subvolume
+type CephFSSubvolumeSnapshotInfo struct {
+	CreatedAt        string `json:"created_at"`
+	HasPendingClones string `json:"has_pending_clones"`
+}
+
+// Created returns the creation time of the snapshot
+func (i *CephFSSubvolumeSnapshotInfo) Created() (time.Time, error) {
+	created, err := time.Parse(cephFSSnapshotTimeFormat, i.CreatedAt)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse the creation time %q of the snapshot", i.CreatedAt)
+	}
+	return created, nil
+}
+
+// GetCephFSSubvolumeSnapshotInfo returns the details of a snapshot of a CephFS subvolume
+func GetCephFSSubvolumeSnapshotInfo(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, subvolName, snapName string) (*CephFSSubvolumeSnapshotInfo, error) {
+	args := []string{"fs", "subvolume", "snapshot", "info", volName, subvolName, snapName}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get info of snapshot %q of subvolume %q in group %q of filesystem %q. %s", snapName, subvolName, groupName, volName, string(buf))
+	}
+
+	var info CephFSSubvolumeSnapshotInfo
+	if err = json.Unmarshal(buf, &info); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed, raw buffer response: %s", string(buf))
+	}
+	return &info, nil
+}
+
+// DeleteCephFSSubvolumeSnapshot deletes a snapshot of a CephFS subvolume. A snapshot that does not
+// exist is ignored.
+func DeleteCephFSSubvolumeSnapshot(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, subvolName, snapName string) error {
+	args := []string{"fs", "subvolume", "snapshot", "rm", volName, subvolName, snapName, "--force"}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	cmd := NewCephCommand(context, clusterInfo, args)
+	cmd.JsonOutput = false
+	buf, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete snapshot %q of subvolume %q in group %q of filesystem %q. %s", snapName, subvolName, groupName, volName, string(buf))
+	}
+
+	logger.Infof("successfully deleted snapshot %q of subvolume %q in group %q of filesystem %q", snapName, subvolName, groupName, volName)
+	return nil
+}
+
+// DeleteCephFSSubvolume deletes a CephFS subvolume, its data is purged asynchronously. A subvolume
+// that does not exist is ignored.
+func DeleteCephFSSubvolume(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, subvolName string) error {
+	args := []string{"fs", "subvolume", "rm", volName, subvolName, "--force"}
+	if groupName != "" {
+		args = append(args, "--group_name", groupName)
+	}
+	cmd := NewCephCommand(context, clusterInfo, args)
+	cmd.JsonOutput = false
+	buf, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete subvolume %q in group %q of filesystem %q. %s", subvolName, groupName, volName, string(buf))
+	}
+
+	logger.Infof("successfully deleted subvolume %q in group %q of filesystem %q", subvolName, groupName, volName)
+	return nil
+}
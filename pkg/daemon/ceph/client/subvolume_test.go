@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCephFSSubvolumeInfo(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	quota := `1073741824`
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" && args[1] == "subvolume" && args[2] == "info" {
+			assert.Equal(t, []string{"myfs", "subvol1", "--group_name", "csi"}, args[3:7])
+			return `{"atime": "2022-02-01 10:00:00", "bytes_pcent": "0.00", "bytes_quota": ` + quota + `, "bytes_used": 0, ` +
+				`"path": "/volumes/csi/subvol1/5b5d1d3c-8f4a-4a0b-b0a4-2ff6b5a2b9a1", "state": "complete", "type": "subvolume"}`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	info, err := GetCephFSSubvolumeInfo(context, clusterInfo, "myfs", "csi", "subvol1")
+	assert.NoError(t, err)
+	assert.Equal(t, "/volumes/csi/subvol1/5b5d1d3c-8f4a-4a0b-b0a4-2ff6b5a2b9a1", info.Path)
+	size, ok := info.Quota()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1073741824), size)
+
+	quota = `"infinite"`
+	info, err = GetCephFSSubvolumeInfo(context, clusterInfo, "myfs", "csi", "subvol1")
+	assert.NoError(t, err)
+	_, ok = info.Quota()
+	assert.False(t, ok)
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		return "Error ENOENT: subvolume 'subvol1' does not exist", errors.New("exit status 2")
+	}
+	_, err = GetCephFSSubvolumeInfo(context, clusterInfo, "myfs", "csi", "subvol1")
+	assert.Error(t, err)
+}
+
+func TestListCephFSSubvolumes(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" && args[1] == "subvolume" && args[2] == "ls" {
+			assert.Equal(t, []string{"myfs", "--group_name", "csi"}, args[3:6])
+			return `[{"name": "subvol1"}, {"name": "subvol2"}]`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	subvolumes, err := ListCephFSSubvolumes(context, clusterInfo, "myfs", "csi")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"subvol1", "subvol2"}, subvolumes)
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		return "Error ENOENT: subvolume group 'csi' does not exist", errors.New("exit status 2")
+	}
+	_, err = ListCephFSSubvolumes(context, clusterInfo, "myfs", "csi")
+	assert.Error(t, err)
+}
+
+func TestCreateCephFSSubvolumeSnapshot(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" && args[1] == "subvolume" && args[2] == "snapshot" {
+			assert.Equal(t, []string{"create", "myfs", "subvol1", "final", "--group_name", "csi"}, args[3:9])
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	err := CreateCephFSSubvolumeSnapshot(context, clusterInfo, "myfs", "csi", "subvol1", "final")
+	assert.NoError(t, err)
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		return "Error ENOENT: subvolume 'subvol1' does not exist", errors.New("exit status 2")
+	}
+	err = CreateCephFSSubvolumeSnapshot(context, clusterInfo, "myfs", "csi", "subvol1", "final")
+	assert.Error(t, err)
+}
+
+func TestCreateCephFSSubvolume(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	var created []string
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" && args[1] == "subvolume" && args[2] == "create" {
+			created = args[3:]
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	t.Run("default attributes", func(t *testing.T) {
+		err := CreateCephFSSubvolume(context, clusterInfo, "myfs", "", "subvol1", 0, nil, nil, "", false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"myfs", "subvol1"}, created[:2])
+		assert.NotContains(t, created, "--group_name")
+		assert.NotContains(t, created, "--size")
+		assert.NotContains(t, created, "--namespace-isolated")
+	})
+
+	t.Run("all attributes", func(t *testing.T) {
+		uid, gid := int64(1000), int64(2000)
+		err := CreateCephFSSubvolume(context, clusterInfo, "myfs", "csi", "subvol1", 1073741824, &uid, &gid, "0750", true)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"myfs", "subvol1", "--group_name", "csi", "--size", "1073741824", "--uid", "1000", "--gid", "2000", "--mode", "0750", "--namespace-isolated"}, created[:13])
+	})
+
+	t.Run("create fails", func(t *testing.T) {
+		executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+			return "Error ENOENT: subvolume group 'csi' does not exist", errors.New("exit status 2")
+		}
+		err := CreateCephFSSubvolume(context, clusterInfo, "myfs", "csi", "subvol1", 0, nil, nil, "", false)
+		assert.Error(t, err)
+	})
+}
+
+func TestResizeCephFSSubvolume(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	var resized []string
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" && args[1] == "subvolume" && args[2] == "resize" {
+			resized = args[3:]
+			return `[{"bytes_used": 0}, {"bytes_quota": 1073741824}, {"bytes_pcent": "0.00"}]`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	err := ResizeCephFSSubvolume(context, clusterInfo, "myfs", "", "subvol1", 1073741824)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs", "subvol1", "1073741824"}, resized[:3])
+	assert.NotContains(t, resized, "--group_name")
+
+	err = ResizeCephFSSubvolume(context, clusterInfo, "myfs", "csi", "subvol1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs", "subvol1", "inf", "--group_name", "csi"}, resized[:5])
+}
+
+func TestGetCephFSSubvolumeSnapshotInfo(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" && args[1] == "subvolume" && args[2] == "snapshot" {
+			assert.Equal(t, []string{"info", "myfs", "subvol1", "snap1", "--group_name", "csi"}, args[3:9])
+			return `{"created_at": "2022-02-01 10:00:00.123456", "data_pool": "myfs-replicated", "has_pending_clones": "no"}`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	info, err := GetCephFSSubvolumeSnapshotInfo(context, clusterInfo, "myfs", "csi", "subvol1", "snap1")
+	assert.NoError(t, err)
+	assert.Equal(t, "no", info.HasPendingClones)
+	created, err := info.Created()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 2, 1, 10, 0, 0, 123456000, time.UTC), created)
+
+	info.CreatedAt = "yesterday"
+	_, err = info.Created()
+	assert.Error(t, err)
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		return "Error ENOENT: snapshot 'snap1' does not exist", errors.New("exit status 2")
+	}
+	_, err = GetCephFSSubvolumeSnapshotInfo(context, clusterInfo, "myfs", "csi", "subvol1", "snap1")
+	assert.Error(t, err)
+}
@@ -17,16 +17,44 @@ limitations under the License.
 package client
 
 import (
+	"encoding/json"
+	"strconv"
+
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
 )
 
+// CephFSSubVolumeGroupInfo represents the details of a CephFS subvolume group
+type CephFSSubVolumeGroupInfo struct {
+	// BytesQuota is a number of bytes, or "infinite" when the subvolume group has no quota
+	BytesQuota json.RawMessage `json:"bytes_quota"`
+	// BytesUsed is the size of the data of the subvolumes of the group
+	BytesUsed uint64 `json:"bytes_used"`
+}
+
+// Quota returns the quota of the subvolume group in bytes, false if the subvolume group has no quota
+func (i *CephFSSubVolumeGroupInfo) Quota() (uint64, bool) {
+	quota, err := strconv.ParseUint(string(i.BytesQuota), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return quota, true
+}
+
 // CreateCephFSSubVolumeGroup create a CephFS subvolume group.
 // volName is the name of the Ceph FS volume, the same as the CephFilesystem CR name.
-func CreateCephFSSubVolumeGroup(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName string) error {
+// The subvolume group is created with the quota of size bytes, or without quota when size is 0, and
+// the layout of the data pool dataPool, or the default data pool of the volume when empty.
+func CreateCephFSSubVolumeGroup(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, dataPool string, size uint64) error {
 	logger.Infof("creating cephfs subvolume group %q", volName)
-	//  [--pool_layout <data_pool_name>] [--uid <uid>] [--gid <gid>] [--mode <octal_mode>]
+	//  [--uid <uid>] [--gid <gid>] [--mode <octal_mode>]
 	args := []string{"fs", "subvolumegroup", "create", volName, groupName}
+	if dataPool != "" {
+		args = append(args, "--pool_layout", dataPool)
+	}
+	if size > 0 {
+		args = append(args, "--size", strconv.FormatUint(size, 10))
+	}
 	cmd := NewCephCommand(context, clusterInfo, args)
 	cmd.JsonOutput = false
 	output, err := cmd.Run()
@@ -38,6 +66,55 @@ func CreateCephFSSubVolumeGroup(context *clusterd.Context, clusterInfo *ClusterI
 	return nil
 }
 
+// GetCephFSSubVolumeGroupInfo returns the details of a CephFS subvolume group
+func GetCephFSSubVolumeGroupInfo(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName string) (*CephFSSubVolumeGroupInfo, error) {
+	args := []string{"fs", "subvolumegroup", "info", volName, groupName}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get info of subvolume group %q of filesystem %q. %s", groupName, volName, string(buf))
+	}
+
+	var info CephFSSubVolumeGroupInfo
+	if err = json.Unmarshal(buf, &info); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed, raw buffer response: %s", string(buf))
+	}
+	return &info, nil
+}
+
+// ResizeCephFSSubVolumeGroup sets the quota of a CephFS subvolume group to size bytes, or removes the
+// quota when size is 0
+func ResizeCephFSSubVolumeGroup(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName string, size uint64) error {
+	newSize := "inf"
+	if size > 0 {
+		newSize = strconv.FormatUint(size, 10)
+	}
+	logger.Infof("resizing cephfs subvolume group %q of filesystem %q to %s", groupName, volName, newSize)
+	args := []string{"fs", "subvolumegroup", "resize", volName, groupName, newSize}
+	output, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to resize subvolume group %q of filesystem %q. %s", groupName, volName, output)
+	}
+
+	logger.Infof("successfully resized cephfs subvolume group %q of filesystem %q to %s", groupName, volName, newSize)
+	return nil
+}
+
+// PinCephFSSubVolumeGroup pins a CephFS subvolume group to the MDS ranks with the pinning policy
+// pinType, one of export, distributed or random, and its setting
+func PinCephFSSubVolumeGroup(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName, pinType, setting string) error {
+	logger.Infof("pinning cephfs subvolume group %q of filesystem %q with %s pinning %s", groupName, volName, pinType, setting)
+	args := []string{"fs", "subvolumegroup", "pin", volName, groupName, pinType, setting}
+	cmd := NewCephCommand(context, clusterInfo, args)
+	cmd.JsonOutput = false
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to pin subvolume group %q of filesystem %q. %s", groupName, volName, output)
+	}
+
+	logger.Infof("successfully pinned cephfs subvolume group %q of filesystem %q", groupName, volName)
+	return nil
+}
+
 // DeleteCephFSSubVolumeGroup delete a CephFS subvolume group.
 func DeleteCephFSSubVolumeGroup(context *clusterd.Context, clusterInfo *ClusterInfo, volName, groupName string) error {
 	logger.Infof("deleting cephfs subvolume group %q", volName)
@@ -55,3 +132,24 @@ func DeleteCephFSSubVolumeGroup(context *clusterd.Context, clusterInfo *ClusterI
 	logger.Infof("successfully deleted cephfs subvolume group %q", volName)
 	return nil
 }
+
+// ListCephFSSubVolumeGroups lists the subvolume groups of a CephFS volume
+func ListCephFSSubVolumeGroups(context *clusterd.Context, clusterInfo *ClusterInfo, volName string) ([]string, error) {
+	args := []string{"fs", "subvolumegroup", "ls", volName}
+	output, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the subvolume groups of filesystem %q. %s", volName, output)
+	}
+
+	var groups []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(output, &groups); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the subvolume groups of filesystem %q. %s", volName, output)
+	}
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names, nil
+}
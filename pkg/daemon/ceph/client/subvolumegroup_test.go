@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateCephFSSubVolumeGroup(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	var created []string
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "create" {
+			created = args
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	err := CreateCephFSSubVolumeGroup(context, clusterInfo, "myfs", "group-a", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs", "group-a"}, created[3:5])
+	assert.NotContains(t, created, "--size")
+	assert.NotContains(t, created, "--pool_layout")
+
+	err = CreateCephFSSubVolumeGroup(context, clusterInfo, "myfs", "group-a", "", 1073741824)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs", "group-a", "--size", "1073741824"}, created[3:7])
+
+	err = CreateCephFSSubVolumeGroup(context, clusterInfo, "myfs", "group-a", "myfs-ec", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs", "group-a", "--pool_layout", "myfs-ec"}, created[3:7])
+}
+
+func TestCephFSSubVolumeGroupQuota(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminTestClusterInfo("mycluster")
+
+	quota := `1073741824`
+	var resized []string
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "info" {
+			assert.Equal(t, []string{"myfs", "group-a"}, args[3:5])
+			return `{"atime": "2022-02-01 10:00:00", "bytes_pcent": "0.00", "bytes_quota": ` + quota + `, "bytes_used": 4096, "data_pool": "myfs-replicated"}`, nil
+		}
+		if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "resize" {
+			resized = args[3:6]
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	info, err := GetCephFSSubVolumeGroupInfo(context, clusterInfo, "myfs", "group-a")
+	assert.NoError(t, err)
+	size, ok := info.Quota()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1073741824), size)
+	assert.Equal(t, uint64(4096), info.BytesUsed)
+
+	quota = `"infinite"`
+	info, err = GetCephFSSubVolumeGroupInfo(context, clusterInfo, "myfs", "group-a")
+	assert.NoError(t, err)
+	_, ok = info.Quota()
+	assert.False(t, ok)
+
+	err = ResizeCephFSSubVolumeGroup(context, clusterInfo, "myfs", "group-a", 2147483648)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs", "group-a", "2147483648"}, resized)
+
+	// the quota is removed with an infinite size
+	err = ResizeCephFSSubVolumeGroup(context, clusterInfo, "myfs", "group-a", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs", "group-a", "inf"}, resized)
+
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		return "Error ENOENT: subvolume group 'group-a' does not exist", errors.New("exit status 2")
+	}
+	_, err = GetCephFSSubVolumeGroupInfo(context, clusterInfo, "myfs", "group-a")
+	assert.Error(t, err)
+}
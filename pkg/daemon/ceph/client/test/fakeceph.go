@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+)
+
+// FakeCeph is a fake of the ceph command line tools for the tests of the controllers without a
+// ceph cluster. It returns canned responses per command, fails the commands on request and records
+// the commands executed. The commands are matched on their leading words, before the flags added by
+// the operator, e.g. the response of "ceph osd pool" is returned for "ceph osd pool ls --format json"
+// unless a response of "ceph osd pool ls" is set. It is safe to use from concurrent reconciles.
+type FakeCeph struct {
+	mutex     sync.Mutex
+	responses map[string]string
+	failures  map[string]*fakeFailure
+	calls     []string
+}
+
+type fakeFailure struct {
+	err error
+	// the number of times the command fails, negative for always
+	times int
+}
+
+// NewFakeCeph returns a fake ceph answering the quorum and status of a healthy cluster with one mon
+func NewFakeCeph() *FakeCeph {
+	f := &FakeCeph{
+		responses: map[string]string{},
+		failures:  map[string]*fakeFailure{},
+	}
+	f.Respond(MonInQuorumResponse(), "ceph", "quorum_status")
+	f.RespondJSON(client.CephStatus{
+		Health: client.HealthStatus{Status: "HEALTH_OK"},
+		FSID:   "12345",
+		Quorum: []int{0},
+	}, "ceph", "status")
+	return f
+}
+
+// Respond sets the output of a command and of the commands it is a prefix of
+func (f *FakeCeph) Respond(output, command string, args ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.responses[commandKey(command, args)] = output
+}
+
+// RespondJSON sets the output of a command to the JSON of a value, e.g. a client.CephStatus
+func (f *FakeCeph) RespondJSON(v interface{}, command string, args ...string) {
+	output, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal the response of %q. %v", commandKey(command, args), err))
+	}
+	f.Respond(string(output), command, args...)
+}
+
+// Fail makes a command fail with the given error the given number of times, or always if times is
+// negative. The command succeeds again when the failures are exhausted.
+func (f *FakeCeph) Fail(err error, times int, command string, args ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.failures[commandKey(command, args)] = &fakeFailure{err: err, times: times}
+}
+
+// Reset clears the responses, failures and calls, including the default responses
+func (f *FakeCeph) Reset() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.responses = map[string]string{}
+	f.failures = map[string]*fakeFailure{}
+	f.calls = nil
+}
+
+// Calls returns the commands executed, without their flags, in the order of execution
+func (f *FakeCeph) Calls() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.calls...)
+}
+
+// Called returns the number of times the command, or a command it is a prefix of, was executed
+func (f *FakeCeph) Called(command string, args ...string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	key := commandKey(command, args)
+	count := 0
+	for _, call := range f.calls {
+		if call == key || strings.HasPrefix(call, key+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+// Executor returns a mocked executor executing all the commands with the fake
+func (f *FakeCeph) Executor() *exectest.MockExecutor {
+	return &exectest.MockExecutor{
+		MockExecuteCommand: func(command string, args ...string) error {
+			_, err := f.execute(command, args)
+			return err
+		},
+		MockExecuteCommandWithEnv: func(env []string, command string, args ...string) error {
+			_, err := f.execute(command, args)
+			return err
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return f.execute(command, args)
+		},
+		MockExecuteCommandWithCombinedOutput: func(command string, args ...string) (string, error) {
+			return f.execute(command, args)
+		},
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			return f.execute(command, args)
+		},
+	}
+}
+
+// execute records a command and returns the failure injected for the command or a command it is a
+// prefix of, else the response of the longest matching command. The commands without response
+// succeed with an empty output.
+func (f *FakeCeph) execute(command string, args []string) (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	key := commandKey(command, args)
+	f.calls = append(f.calls, key)
+
+	for prefix := key; prefix != ""; prefix = parentCommand(prefix) {
+		if failure, ok := f.failures[prefix]; ok && failure.times != 0 {
+			if failure.times > 0 {
+				failure.times--
+			}
+			return "", failure.err
+		}
+	}
+	for prefix := key; prefix != ""; prefix = parentCommand(prefix) {
+		if output, ok := f.responses[prefix]; ok {
+			return output, nil
+		}
+	}
+	return "", nil
+}
+
+// commandKey returns the command with the words of its arguments before the first flag
+func commandKey(command string, args []string) string {
+	words := []string{command}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// parentCommand returns the command without its last word, empty for the command alone
+func parentCommand(key string) string {
+	i := strings.LastIndex(key, " ")
+	if i < 0 {
+		return ""
+	}
+	return key[:i]
+}
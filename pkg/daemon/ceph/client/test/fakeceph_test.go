@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeCeph(t *testing.T) {
+	fake := NewFakeCeph()
+	context := &clusterd.Context{Executor: fake.Executor()}
+	clusterInfo := CreateTestClusterInfo(1)
+
+	// the default responses
+	status, err := client.Status(context, clusterInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "HEALTH_OK", status.Health.Status)
+	quorum, err := client.GetMonQuorumStatus(context, clusterInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", quorum.MonMap.Mons[0].Name)
+
+	// the canned responses
+	fake.RespondJSON([]client.CephStoragePoolSummary{{Name: "replicapool", Number: 1}}, "ceph", "osd", "lspools")
+	pools, err := client.ListPoolSummaries(context, clusterInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "replicapool", pools[0].Name)
+
+	// the failures are injected the given number of times
+	fake.Fail(errors.New("timed out"), 1, "ceph", "osd", "lspools")
+	_, err = client.ListPoolSummaries(context, clusterInfo)
+	assert.Error(t, err)
+	_, err = client.ListPoolSummaries(context, clusterInfo)
+	assert.NoError(t, err)
+
+	// the failure of a command applies to the commands it is a prefix of
+	fake.Fail(errors.New("down"), -1, "ceph", "osd")
+	for i := 0; i < 3; i++ {
+		_, err = client.ListPoolSummaries(context, clusterInfo)
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, 6, fake.Called("ceph", "osd", "lspools"))
+	assert.Equal(t, 1, fake.Called("ceph", "status"))
+	assert.Equal(t, "ceph status", fake.Calls()[0])
+
+	// the commands without response succeed
+	fake.Reset()
+	output, err := fake.Executor().ExecuteCommandWithTimeout(0, "rbd", "ls", "replicapool")
+	assert.NoError(t, err)
+	assert.Equal(t, "", output)
+	assert.Equal(t, []string{"rbd ls replicapool"}, fake.Calls())
+}
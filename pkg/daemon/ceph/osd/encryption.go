@@ -74,15 +74,12 @@ func setKEKinEnv(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo)
 		return nil
 	}
 
-	// The ibm key protect library does not read any environment variables, so we must set the
-	// service api key (coming from the secret mounted as environment variable) in the KMS
-	// connection details. These details are used to build the client connection
-	if clusterSpec.Security.KeyManagementService.IsIBMKeyProtectKMS() {
-		ibmServiceApiKey := os.Getenv(kms.IbmKeyProtectServiceApiKey)
-		if ibmServiceApiKey == "" {
-			return errors.Errorf("ibm key protect %q environment variable is not set", kms.IbmKeyProtectServiceApiKey)
-		}
-		clusterSpec.Security.KeyManagementService.ConnectionDetails[kms.IbmKeyProtectServiceApiKey] = ibmServiceApiKey
+	// The values of the kms token secret, e.g. the vault token or the ibm key protect service api
+	// key, are mounted from the secret as environment variables. They are already part of the
+	// connection details since they are named like the known KMS prefixes.
+	err := kms.CheckTokenEnvVars(&clusterSpec.Security.KeyManagementService)
+	if err != nil {
+		return errors.Wrap(err, "failed to check kms token")
 	}
 
 	kmsConfig := kms.NewConfig(context, clusterSpec, clusterInfo)
@@ -0,0 +1,431 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	TypeAzure = "azure-kv"
+	// AzureVaultURLKey is the URL of the Azure Key Vault, e.g. https://myvault.vault.azure.net
+	AzureVaultURLKey = "AZURE_VAULT_URL"
+	// AzureTenantIDKey is the ID of the Azure Active Directory tenant
+	AzureTenantIDKey = "AZURE_TENANT_ID"
+	// AzureClientIDKey is the client ID of the application or managed identity
+	AzureClientIDKey = "AZURE_CLIENT_ID"
+	// AzureClientSecretKey is the client secret of the service principal
+	// #nosec G101 since it's just the name of the key
+	AzureClientSecretKey = "AZURE_CLIENT_SECRET"
+	// AzureAuthMethodKey is the authentication method, client-secret (default) or workload-identity
+	AzureAuthMethodKey = "AZURE_AUTH_METHOD"
+	// AzureAuthorityHostKey is the Azure Active Directory endpoint, only needed for the sovereign clouds
+	AzureAuthorityHostKey = "AZURE_AUTHORITY_HOST"
+	// AzureFederatedTokenFileKey is the path of the service account token exchanged with workload identity
+	// #nosec G101 since it's just the name of the env var
+	AzureFederatedTokenFileKey = "AZURE_FEDERATED_TOKEN_FILE"
+
+	AzureAuthMethodClientSecret     = "client-secret"
+	AzureAuthMethodWorkloadIdentity = "workload-identity"
+
+	// AzureTokenDir is where the service account token exchanged with workload identity is mounted
+	AzureTokenDir = "/var/run/secrets/azure/tokens"
+	// #nosec G101 since it's just a file name
+	azureTokenFileName = "azure-identity-token"
+	// the audience of the service account token expected by Azure Active Directory
+	azureTokenAudience      = "api://AzureADTokenExchange"
+	azureDefaultAuthority   = "https://login.microsoftonline.com/"
+	azureKeyVaultAPIVersion = "7.3"
+	azureRequestTimeout     = 30 * time.Second
+)
+
+var (
+	kmsAzureMandatoryConnectionDetails = []string{AzureVaultURLKey, AzureTenantIDKey, AzureClientIDKey}
+	errAzureSecretNotFound             = errors.New("secret not found in azure key vault")
+)
+
+func init() {
+	RegisterProvider(TypeAzure, &ProviderPlugin{
+		NewBackend: func(c *Config) (Backend, error) {
+			return initAzureKeyVault(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+		},
+		Validate: func(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error {
+			err := validateAzureConnectionDetails(kmsSpec)
+			if err != nil {
+				return errors.Wrap(err, "failed to validate azure key vault connection details")
+			}
+			return nil
+		},
+		TokenDetails: map[string]string{AzureClientSecretKey: AzureClientSecretKey},
+		TokenOptional: func(kmsSpec *cephv1.KeyManagementServiceSpec) bool {
+			return kmsSpec.IsAzureWorkloadIdentityEnabled()
+		},
+		EnvVars:          azureEnvVars,
+		VolumesAndMounts: azureVolumesAndMounts,
+	})
+}
+
+// azureKeyVault is a client of the secrets API of Azure Key Vault. It authenticates to Azure Active
+// Directory with either a client secret or a federated service account token (workload identity).
+type azureKeyVault struct {
+	vaultURL           string
+	tenantID           string
+	clientID           string
+	clientSecret       string
+	federatedTokenFile string
+	authorityHost      string
+	httpClient         *http.Client
+	accessToken        string
+}
+
+func initAzureKeyVault(config map[string]string) (*azureKeyVault, error) {
+	for _, key := range kmsAzureMandatoryConnectionDetails {
+		if GetParam(config, key) == "" {
+			return nil, errors.Errorf("%s not set", key)
+		}
+	}
+
+	kv := &azureKeyVault{
+		vaultURL:      strings.TrimSuffix(GetParam(config, AzureVaultURLKey), "/"),
+		tenantID:      GetParam(config, AzureTenantIDKey),
+		clientID:      GetParam(config, AzureClientIDKey),
+		authorityHost: GetParam(config, AzureAuthorityHostKey),
+		httpClient:    &http.Client{Timeout: azureRequestTimeout},
+	}
+	if kv.authorityHost == "" {
+		kv.authorityHost = azureDefaultAuthority
+	}
+
+	switch azureAuthMethod(config) {
+	case AzureAuthMethodClientSecret:
+		kv.clientSecret = GetParam(config, AzureClientSecretKey)
+		if kv.clientSecret == "" {
+			return nil, errors.Errorf("%s not set", AzureClientSecretKey)
+		}
+	case AzureAuthMethodWorkloadIdentity:
+		// The file is set in the env by the azure workload identity webhook for the operator pod, and
+		// by rook for the osd pods
+		kv.federatedTokenFile = GetParam(config, AzureFederatedTokenFileKey)
+		if kv.federatedTokenFile == "" {
+			kv.federatedTokenFile = os.Getenv(AzureFederatedTokenFileKey)
+		}
+		if kv.federatedTokenFile == "" {
+			kv.federatedTokenFile = azureFederatedTokenPath()
+		}
+	default:
+		return nil, errors.Errorf("unsupported azure auth method %q", azureAuthMethod(config))
+	}
+
+	return kv, nil
+}
+
+func azureAuthMethod(config map[string]string) string {
+	method := GetParam(config, AzureAuthMethodKey)
+	if method == "" {
+		return AzureAuthMethodClientSecret
+	}
+	return method
+}
+
+func azureFederatedTokenPath() string {
+	return path.Join(AzureTokenDir, azureTokenFileName)
+}
+
+// azureKeyVaultScope returns the OAuth scope of the key vault, which depends on the cloud of the
+// vault, e.g. https://vault.azure.net/.default for https://myvault.vault.azure.net
+func azureKeyVaultScope(vaultURL string) (string, error) {
+	u, err := url.Parse(vaultURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse azure vault url %q", vaultURL)
+	}
+	labels := strings.SplitN(u.Hostname(), ".", 2)
+	if len(labels) != 2 || labels[1] == "" {
+		return "", errors.Errorf("invalid azure vault url %q", vaultURL)
+	}
+	return fmt.Sprintf("https://%s/.default", labels[1]), nil
+}
+
+// azureSecretName returns the name of the key vault secret of an osd encryption key, the secret
+// names can only contain alphanumeric characters and dashes
+func azureSecretName(secretName string) string {
+	return strings.ReplaceAll(GenerateOSDEncryptionSecretName(secretName), ".", "-")
+}
+
+// token returns an access token of the key vault from Azure Active Directory
+func (kv *azureKeyVault) token(ctx context.Context) (string, error) {
+	if kv.accessToken != "" {
+		return kv.accessToken, nil
+	}
+
+	scope, err := azureKeyVaultScope(kv.vaultURL)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {kv.clientID},
+		"scope":      {scope},
+	}
+	if kv.federatedTokenFile != "" {
+		assertion, err := ioutil.ReadFile(kv.federatedTokenFile)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read federated service account token %q", kv.federatedTokenFile)
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	} else {
+		form.Set("client_secret", kv.clientSecret)
+	}
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(kv.authorityHost, "/"), url.PathEscape(kv.tenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to build azure token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := kv.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to request azure access token")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read azure token response")
+	}
+
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", errors.Wrapf(err, "failed to parse azure token response (status %d)", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		return "", errors.Errorf("failed to authenticate to azure active directory (status %d). %s: %s", resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+	}
+
+	kv.accessToken = tokenResp.AccessToken
+	return kv.accessToken, nil
+}
+
+// do sends a request to the secrets API of the key vault and returns the response body
+func (kv *azureKeyVault) do(ctx context.Context, method, secretName string, payload interface{}) ([]byte, error) {
+	token, err := kv.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reqBody io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal azure key vault request")
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	secretURL := fmt.Sprintf("%s/secrets/%s?api-version=%s", kv.vaultURL, url.PathEscape(secretName), azureKeyVaultAPIVersion)
+	req, err := http.NewRequestWithContext(ctx, method, secretURL, reqBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build azure key vault request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := kv.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to send %s request to azure key vault", method)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read azure key vault response")
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errAzureSecretNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errResp struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &errResp)
+		return nil, errors.Errorf("azure key vault returned status %d. %s: %s", resp.StatusCode, errResp.Error.Code, errResp.Error.Message)
+	}
+
+	return body, nil
+}
+
+// azureSecretBundle is a version of a key vault secret, the id is the URL of the version
+type azureSecretBundle struct {
+	Value string `json:"value"`
+	ID    string `json:"id"`
+}
+
+func (kv *azureKeyVault) getSecretBundle(ctx context.Context, method, secretName string, payload interface{}) (*azureSecretBundle, error) {
+	body, err := kv.do(ctx, method, secretName, payload)
+	if err != nil {
+		return nil, err
+	}
+	secret := &azureSecretBundle{}
+	if err := json.Unmarshal(body, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse azure key vault secret %q", secretName)
+	}
+	return secret, nil
+}
+
+func (kv *azureKeyVault) getSecret(ctx context.Context, secretName string) (string, error) {
+	secret, err := kv.getSecretBundle(ctx, http.MethodGet, secretName, nil)
+	if err != nil {
+		return "", err
+	}
+	return secret.Value, nil
+}
+
+// putSecret stores a secret in the key vault, unless it already exists so that an existing key is
+// never overwritten
+func (kv *azureKeyVault) putSecret(ctx context.Context, secretName, value string) error {
+	_, err := kv.getSecret(ctx, secretName)
+	if err == nil {
+		logger.Debugf("secret %q already exists in azure key vault", secretName)
+		return nil
+	}
+	if !errors.Is(err, errAzureSecretNotFound) {
+		return err
+	}
+
+	_, err = kv.do(ctx, http.MethodPut, secretName, map[string]string{"value": value})
+	return err
+}
+
+// deleteSecret deletes a secret from the key vault. With soft-delete enabled on the vault the secret
+// can still be recovered during the retention period of the vault.
+func (kv *azureKeyVault) deleteSecret(ctx context.Context, secretName string) error {
+	_, err := kv.do(ctx, http.MethodDelete, secretName, nil)
+	if errors.Is(err, errAzureSecretNotFound) {
+		logger.Debugf("secret %q not found in azure key vault", secretName)
+		return nil
+	}
+	return err
+}
+
+func (kv *azureKeyVault) GetKey(ctx context.Context, name string) (string, error) {
+	return kv.getSecret(ctx, azureSecretName(name))
+}
+
+func (kv *azureKeyVault) PutKey(ctx context.Context, name, value string) error {
+	return kv.putSecret(ctx, azureSecretName(name), value)
+}
+
+func (kv *azureKeyVault) DeleteKey(ctx context.Context, name string) error {
+	return kv.deleteSecret(ctx, azureSecretName(name))
+}
+
+// RotateKey sets a new version of the secret, the previous versions are kept by the key vault
+func (kv *azureKeyVault) RotateKey(ctx context.Context, name, value string) (string, error) {
+	secretName := azureSecretName(name)
+	if _, err := kv.getSecret(ctx, secretName); err != nil {
+		return "", err
+	}
+	secret, err := kv.getSecretBundle(ctx, http.MethodPut, secretName, map[string]string{"value": value})
+	if err != nil {
+		return "", err
+	}
+	return path.Base(secret.ID), nil
+}
+
+// KeyVersion returns the current version of the secret, the last element of its id
+func (kv *azureKeyVault) KeyVersion(ctx context.Context, name string) (string, error) {
+	secret, err := kv.getSecretBundle(ctx, http.MethodGet, azureSecretName(name), nil)
+	if err != nil {
+		return "", err
+	}
+	return path.Base(secret.ID), nil
+}
+
+// validateAzureConnectionDetails validates the Azure Key Vault connection details, the client secret
+// is already read from the token secret when the client-secret auth method is used
+func validateAzureConnectionDetails(kmsSpec *cephv1.KeyManagementServiceSpec) error {
+	for _, config := range kmsAzureMandatoryConnectionDetails {
+		if GetParam(kmsSpec.ConnectionDetails, config) == "" {
+			return errors.Errorf("failed to validate kms config %q. cannot be empty", config)
+		}
+	}
+
+	if _, err := azureKeyVaultScope(GetParam(kmsSpec.ConnectionDetails, AzureVaultURLKey)); err != nil {
+		return err
+	}
+
+	switch azureAuthMethod(kmsSpec.ConnectionDetails) {
+	case AzureAuthMethodClientSecret:
+		if !kmsSpec.IsTokenAuthEnabled() {
+			return errors.New("the client-secret auth method requires the tokenSecretName containing the client secret")
+		}
+	case AzureAuthMethodWorkloadIdentity:
+		if kmsSpec.IsTokenAuthEnabled() {
+			return errors.New("the workload-identity auth method cannot be used with the tokenSecretName")
+		}
+	default:
+		return errors.Errorf("unsupported azure auth method %q", azureAuthMethod(kmsSpec.ConnectionDetails))
+	}
+
+	return nil
+}
+
+// IsAzureKeyVault determines whether the configured KMS is Azure Key Vault
+func (c *Config) IsAzureKeyVault() bool { return c.Provider == TypeAzure }
+
+// azureEnvVars passes the path of the service account token to the pods with workload identity,
+// the client secret is mounted from the token secret
+func azureEnvVars(kmsSpec *cephv1.KeyManagementServiceSpec) ([]v1.EnvVar, []string) {
+	envs := []v1.EnvVar{}
+	if kmsSpec.IsAzureWorkloadIdentityEnabled() {
+		envs = append(envs, v1.EnvVar{Name: AzureFederatedTokenFileKey, Value: azureFederatedTokenPath()})
+	}
+	return envs, []string{AzureClientSecretKey, AzureFederatedTokenFileKey}
+}
+
+func azureVolumesAndMounts(kmsSpec *cephv1.KeyManagementServiceSpec) ([]v1.Volume, []v1.VolumeMount) {
+	if !kmsSpec.IsAzureWorkloadIdentityEnabled() {
+		return nil, nil
+	}
+	volume, volumeMount := AzureWorkloadIdentityVolumeAndMount()
+	return []v1.Volume{volume}, []v1.VolumeMount{volumeMount}
+}
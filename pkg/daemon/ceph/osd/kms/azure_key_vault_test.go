@@ -0,0 +1,201 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAzure serves the token endpoint of Azure Active Directory and the secrets API of a key vault
+type fakeAzure struct {
+	t        *testing.T
+	secrets  map[string]string
+	versions map[string]int
+	forms    []map[string]string
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/my-tenant/oauth2/v2.0/token" {
+		assert.NoError(f.t, r.ParseForm())
+		form := map[string]string{}
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		f.forms = append(f.forms, form)
+		if form["client_secret"] == "wrong" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"invalid secret"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"my-token","expires_in":3599}`))
+		return
+	}
+
+	assert.Equal(f.t, "Bearer my-token", r.Header.Get("Authorization"))
+	assert.Equal(f.t, azureKeyVaultAPIVersion, r.URL.Query().Get("api-version"))
+	name := strings.TrimPrefix(r.URL.Path, "/secrets/")
+	value, ok := f.secrets[name]
+	switch r.Method {
+	case http.MethodGet, http.MethodDelete:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"not found"}}`))
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.secrets, name)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"value": value, "id": f.secretID(r, name)})
+	case http.MethodPut:
+		body := map[string]string{}
+		assert.NoError(f.t, json.NewDecoder(r.Body).Decode(&body))
+		f.secrets[name] = body["value"]
+		f.versions[name]++
+		body["id"] = f.secretID(r, name)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+// secretID returns the id of the current version of a secret
+func (f *fakeAzure) secretID(r *http.Request, name string) string {
+	return fmt.Sprintf("http://%s/secrets/%s/v%d", r.Host, name, f.versions[name])
+}
+
+func TestAzureKeyVaultScope(t *testing.T) {
+	scope, err := azureKeyVaultScope("https://myvault.vault.azure.net")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://vault.azure.net/.default", scope)
+	scope, err = azureKeyVaultScope("https://myvault.vault.azure.cn/")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://vault.azure.cn/.default", scope)
+	_, err = azureKeyVaultScope("https://localhost")
+	assert.Error(t, err)
+
+	assert.Equal(t, "rook-ceph-osd-encryption-key-set1-data-0-abc", azureSecretName("set1.data-0-abc"))
+}
+
+func TestAzureKeyVault(t *testing.T) {
+	ctx := context.TODO()
+	fake := &fakeAzure{t: t, secrets: map[string]string{}, versions: map[string]int{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	config := map[string]string{
+		AzureVaultURLKey:      server.URL,
+		AzureTenantIDKey:      "my-tenant",
+		AzureClientIDKey:      "my-client",
+		AzureAuthorityHostKey: server.URL,
+	}
+
+	t.Run("missing client secret", func(t *testing.T) {
+		_, err := initAzureKeyVault(config)
+		assert.EqualError(t, err, "AZURE_CLIENT_SECRET not set")
+	})
+
+	t.Run("client secret", func(t *testing.T) {
+		config[AzureClientSecretKey] = "my-secret"
+		kv, err := initAzureKeyVault(config)
+		assert.NoError(t, err)
+
+		_, err = kv.getSecret(ctx, "osd-key")
+		assert.ErrorIs(t, err, errAzureSecretNotFound)
+		assert.NoError(t, kv.putSecret(ctx, "osd-key", "kek"))
+		assert.Equal(t, "kek", fake.secrets["osd-key"])
+
+		// an existing key is never overwritten
+		assert.NoError(t, kv.putSecret(ctx, "osd-key", "other"))
+		value, err := kv.getSecret(ctx, "osd-key")
+		assert.NoError(t, err)
+		assert.Equal(t, "kek", value)
+
+		assert.NoError(t, kv.deleteSecret(ctx, "osd-key"))
+		assert.NotContains(t, fake.secrets, "osd-key")
+		assert.NoError(t, kv.deleteSecret(ctx, "osd-key"))
+
+		// the token is requested once per client
+		assert.Len(t, fake.forms, 1)
+		assert.Equal(t, "client_credentials", fake.forms[0]["grant_type"])
+		assert.Equal(t, "my-client", fake.forms[0]["client_id"])
+		assert.Equal(t, "my-secret", fake.forms[0]["client_secret"])
+		assert.NotContains(t, fake.forms[0], "client_assertion")
+	})
+
+	t.Run("backend", func(t *testing.T) {
+		kv, err := initAzureKeyVault(config)
+		assert.NoError(t, err)
+
+		assert.NoError(t, kv.PutKey(ctx, "set1.data-0", "kek"))
+		assert.Equal(t, "kek", fake.secrets["rook-ceph-osd-encryption-key-set1-data-0"])
+		version, err := kv.KeyVersion(ctx, "set1.data-0")
+		assert.NoError(t, err)
+		assert.Equal(t, "v1", version)
+
+		version, err = kv.RotateKey(ctx, "set1.data-0", "new-kek")
+		assert.NoError(t, err)
+		assert.Equal(t, "v2", version)
+		value, err := kv.GetKey(ctx, "set1.data-0")
+		assert.NoError(t, err)
+		assert.Equal(t, "new-kek", value)
+
+		// only an existing key is rotated
+		_, err = kv.RotateKey(ctx, "set1.data-1", "new-kek")
+		assert.ErrorIs(t, err, errAzureSecretNotFound)
+		assert.NoError(t, kv.DeleteKey(ctx, "set1.data-0"))
+	})
+
+	t.Run("authentication failure", func(t *testing.T) {
+		config[AzureClientSecretKey] = "wrong"
+		kv, err := initAzureKeyVault(config)
+		assert.NoError(t, err)
+		_, err = kv.getSecret(ctx, "osd-key")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid_client: invalid secret")
+		delete(config, AzureClientSecretKey)
+	})
+
+	t.Run("workload identity", func(t *testing.T) {
+		fake.forms = nil
+		tokenFile := path.Join(t.TempDir(), "token")
+		assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("service-account-token\n"), 0600))
+		config[AzureAuthMethodKey] = AzureAuthMethodWorkloadIdentity
+		config[AzureFederatedTokenFileKey] = tokenFile
+		kv, err := initAzureKeyVault(config)
+		assert.NoError(t, err)
+
+		assert.NoError(t, kv.putSecret(ctx, "osd-key", "kek"))
+		assert.Len(t, fake.forms, 1)
+		assert.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", fake.forms[0]["client_assertion_type"])
+		assert.Equal(t, "service-account-token", fake.forms[0]["client_assertion"])
+		assert.NotContains(t, fake.forms[0], "client_secret")
+	})
+
+	t.Run("default federated token file", func(t *testing.T) {
+		delete(config, AzureFederatedTokenFileKey)
+		kv, err := initAzureKeyVault(config)
+		assert.NoError(t, err)
+		assert.Equal(t, "/var/run/secrets/azure/tokens/azure-identity-token", kv.federatedTokenFile)
+	})
+}
@@ -22,42 +22,26 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/hashicorp/vault/api"
-	"github.com/libopenstorage/secrets/vault"
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 var (
-	knownKMSPrefix = []string{"VAULT_", "IBM_"}
+	knownKMSPrefix = []string{"VAULT_", "IBM_", "AZURE_", "KMIP_"}
 )
 
-// VaultTokenEnvVarFromSecret returns the kms token secret value as an env var
-func vaultTokenEnvVarFromSecret(tokenSecretName string) v1.EnvVar {
+// envVarFromTokenSecret returns a value of the kms token secret as an env var
+func envVarFromTokenSecret(name, tokenSecretName, key string) v1.EnvVar {
 	return v1.EnvVar{
-		Name: api.EnvVaultToken,
+		Name: name,
 		ValueFrom: &v1.EnvVarSource{
 			SecretKeyRef: &v1.SecretKeySelector{
 				LocalObjectReference: v1.LocalObjectReference{
 					Name: tokenSecretName,
 				},
-				Key: KMSTokenSecretNameKey,
-			},
-		},
-	}
-}
-
-// ibmKeyProtectServiceAPIKeyEnvVarFromSecret returns the kms token secret value as an env var
-func ibmKeyProtectServiceAPIKeyEnvVarFromSecret(tokenSecretName string) v1.EnvVar {
-	return v1.EnvVar{
-		Name: IbmKeyProtectServiceApiKey,
-		ValueFrom: &v1.EnvVarSource{
-			SecretKeyRef: &v1.SecretKeySelector{
-				LocalObjectReference: v1.LocalObjectReference{
-					Name: tokenSecretName,
-				},
-				Key: IbmKeyProtectServiceApiKey,
+				Key: key,
 			},
 		},
 	}
@@ -81,44 +65,33 @@ func vaultTLSEnvVarFromSecret(kmsConfig map[string]string) []v1.EnvVar {
 // ConfigToEnvVar populates the kms config as env variables
 func ConfigToEnvVar(spec cephv1.ClusterSpec) []v1.EnvVar {
 	envs := []v1.EnvVar{}
-
-	if spec.Security.KeyManagementService.IsVaultKMS() {
-		backendPath := GetParam(spec.Security.KeyManagementService.ConnectionDetails, vault.VaultBackendPathKey)
-		// Set BACKEND_PATH to the API's default if not passed
-		if backendPath == "" {
-			spec.Security.KeyManagementService.ConnectionDetails[vault.VaultBackendPathKey] = vault.DefaultBackendPath
-		}
-	}
-
-	if spec.Security.KeyManagementService.IsIBMKeyProtectKMS() {
-		// We don't want to leak the IBM service API key to the container environment variables even
-		// the container is ephemeral.
-		// The IBM_KP_SERVICE_API_KEY content is mounted in the provisioner container as an
-		// environment variable from a secret
-		delete(spec.Security.KeyManagementService.ConnectionDetails, IbmKeyProtectServiceApiKey)
-		envs = append(envs, ibmKeyProtectServiceAPIKeyEnvVarFromSecret(spec.Security.KeyManagementService.TokenSecretName))
-	}
-
-	for k, v := range spec.Security.KeyManagementService.ConnectionDetails {
-		if spec.Security.KeyManagementService.IsVaultKMS() {
-			// Skip TLS and token env var to avoid env being set multiple times
-			toSkip := append(cephv1.VaultTLSConnectionDetails, api.EnvVaultToken)
-			if sets.NewString(toSkip...).Has(k) {
-				continue
+	kmsSpec := &spec.Security.KeyManagementService
+	toSkip := sets.NewString()
+
+	if plugin, err := providerOf(kmsSpec); err == nil {
+		// We don't want to leak the values of the token secret to the container environment
+		// variables even the container is ephemeral, they are mounted from the secret instead
+		if kmsSpec.IsTokenAuthEnabled() {
+			tokenDetails := plugin.tokenDetails(kmsSpec)
+			for _, secretKey := range plugin.tokenSecretKeys(kmsSpec) {
+				config := tokenDetails[secretKey]
+				envs = append(envs, envVarFromTokenSecret(config, kmsSpec.TokenSecretName, secretKey))
+				toSkip.Insert(config)
 			}
 		}
-
-		envs = append(envs, v1.EnvVar{Name: k, Value: v})
+		if plugin.EnvVars != nil {
+			providerEnvs, replaced := plugin.EnvVars(kmsSpec)
+			envs = append(envs, providerEnvs...)
+			toSkip.Insert(replaced...)
+		}
 	}
 
-	if spec.Security.KeyManagementService.IsVaultKMS() {
-		// Add the VAULT_TOKEN
-		if spec.Security.KeyManagementService.IsTokenAuthEnabled() {
-			envs = append(envs, vaultTokenEnvVarFromSecret(spec.Security.KeyManagementService.TokenSecretName))
+	for k, v := range kmsSpec.ConnectionDetails {
+		// Skip the env var set above to avoid env being set multiple times
+		if toSkip.Has(k) {
+			continue
 		}
-
-		// Add TLS env if any
-		envs = append(envs, vaultTLSEnvVarFromSecret(spec.Security.KeyManagementService.ConnectionDetails)...)
+		envs = append(envs, v1.EnvVar{Name: k, Value: v})
 	}
 
 	logger.Debugf("kms envs are %v", envs)
@@ -127,6 +100,28 @@ func ConfigToEnvVar(spec cephv1.ClusterSpec) []v1.EnvVar {
 	return sortV1EnvVar(envs)
 }
 
+// CheckTokenEnvVars checks that the values of the kms token secret are set as env variables of the
+// pod, unless the KMS is reached without a token
+func CheckTokenEnvVars(kmsSpec *cephv1.KeyManagementServiceSpec) error {
+	plugin, err := providerOf(kmsSpec)
+	if err != nil {
+		return err
+	}
+	if !plugin.isTokenRequired(kmsSpec) {
+		return nil
+	}
+
+	tokenDetails := plugin.tokenDetails(kmsSpec)
+	for _, secretKey := range plugin.tokenSecretKeys(kmsSpec) {
+		env := tokenDetails[secretKey]
+		if os.Getenv(env) == "" {
+			return errors.Errorf("kms %q environment variable is not set", env)
+		}
+	}
+
+	return nil
+}
+
 // ConfigEnvsToMapString returns all the env variables in map from a known KMS
 func ConfigEnvsToMapString() map[string]string {
 	envs := make(map[string]string)
@@ -147,6 +147,35 @@ func TestVaultConfigToEnvVar(t *testing.T) {
 				{Name: "KMS_PROVIDER", Value: TypeIBM},
 			},
 		},
+		{
+			"azure kv - AZURE_CLIENT_SECRET is mounted from the secret",
+			args{spec: cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{"KMS_PROVIDER": TypeAzure, "AZURE_CLIENT_SECRET": "foo", "AZURE_VAULT_URL": "https://myvault.vault.azure.net"}, TokenSecretName: "azure-secret"}}}},
+			[]v1.EnvVar{
+				{Name: "AZURE_CLIENT_SECRET", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "azure-secret"}, Key: "AZURE_CLIENT_SECRET"}}},
+				{Name: "AZURE_VAULT_URL", Value: "https://myvault.vault.azure.net"},
+				{Name: "KMS_PROVIDER", Value: TypeAzure},
+			},
+		},
+		{
+			"kmip - the certificates are mounted from the secret",
+			args{spec: cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{"KMS_PROVIDER": TypeKMIP, "KMIP_ENDPOINT": "kmip:5696", "KMIP_CLIENT_KEY": "key"}, TokenSecretName: "kmip-certs"}}}},
+			[]v1.EnvVar{
+				{Name: "KMIP_CA_CERT", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "kmip-certs"}, Key: "CA_CERT"}}},
+				{Name: "KMIP_CLIENT_CERT", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "kmip-certs"}, Key: "CLIENT_CERT"}}},
+				{Name: "KMIP_CLIENT_KEY", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "kmip-certs"}, Key: "CLIENT_KEY"}}},
+				{Name: "KMIP_ENDPOINT", Value: "kmip:5696"},
+				{Name: "KMS_PROVIDER", Value: TypeKMIP},
+			},
+		},
+		{
+			"azure kv - workload identity",
+			args{spec: cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{"KMS_PROVIDER": TypeAzure, "AZURE_AUTH_METHOD": "workload-identity"}}}}},
+			[]v1.EnvVar{
+				{Name: "AZURE_AUTH_METHOD", Value: "workload-identity"},
+				{Name: "AZURE_FEDERATED_TOKEN_FILE", Value: "/var/run/secrets/azure/tokens/azure-identity-token"},
+				{Name: "KMS_PROVIDER", Value: TypeAzure},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
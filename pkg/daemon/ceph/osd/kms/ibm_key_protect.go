@@ -17,8 +17,13 @@ limitations under the License.
 package kms
 
 import (
+	"context"
+	"strings"
+
 	kp "github.com/IBM/keyprotect-go-client"
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
 )
 
 const (
@@ -35,7 +40,6 @@ const (
 )
 
 var (
-	kmsIBMKeyProtectMandatoryTokenDetails      = []string{IbmKeyProtectServiceApiKey}
 	kmsIBMKeyProtectMandatoryConnectionDetails = []string{IbmKeyProtectInstanceIdKey, IbmKeyProtectServiceApiKey}
 	// ErrIbmServiceApiKeyNotSet is returned when IBM_KP_SERVICE_API_KEY is not set
 	ErrIbmServiceApiKeyNotSet = errors.Errorf("%s not set.", IbmKeyProtectServiceApiKey)
@@ -43,6 +47,14 @@ var (
 	ErrIbmInstanceIdKeyNotSet = errors.Errorf("%s not set.", IbmKeyProtectInstanceIdKey)
 )
 
+func init() {
+	RegisterProvider(TypeIBM, &ProviderPlugin{
+		NewBackend:   newKeyProtectBackend,
+		Validate:     validateKeyProtect,
+		TokenDetails: map[string]string{IbmKeyProtectServiceApiKey: IbmKeyProtectServiceApiKey},
+	})
+}
+
 // InitKeyProtect initializes the KeyProtect KMS.
 // With native go client directly "github.com/IBM/keyprotect-go-client"
 func InitKeyProtect(config map[string]string) (*kp.Client, error) {
@@ -84,3 +96,77 @@ func InitKeyProtect(config map[string]string) (*kp.Client, error) {
 
 // IsIBMKeyProtect determines whether the configured KMS is IBM Key Protect
 func (c *Config) IsIBMKeyProtect() bool { return c.Provider == TypeIBM }
+
+// keyProtectBackend stores the keys as standard keys of IBM Key Protect, named and aliased after
+// the keys
+type keyProtectBackend struct {
+	client *kp.Client
+}
+
+func newKeyProtectBackend(c *Config) (Backend, error) {
+	client, err := InitKeyProtect(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	if err != nil {
+		return nil, err
+	}
+	return &keyProtectBackend{client: client}, nil
+}
+
+func (b *keyProtectBackend) GetKey(ctx context.Context, name string) (string, error) {
+	keyObject, err := b.client.GetKey(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return string(keyObject.Payload), nil
+}
+
+// PutKey creates the key if not present
+func (b *keyProtectBackend) PutKey(ctx context.Context, name, value string) error {
+	keyAlias := []string{name}
+	_, err := b.client.CreateImportedKeyWithAliases(ctx, name, nil, value, "", "", true, keyAlias)
+	if err != nil {
+		if strings.Contains(err.Error(), "KEY_ALIAS_NOT_UNIQUE_ERR") {
+			logger.Debugf("key %q already exists. %v", name, err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (b *keyProtectBackend) DeleteKey(ctx context.Context, name string) error {
+	// Fetch the key to get the ID
+	key, err := b.client.GetKey(ctx, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get key %q", name)
+	}
+
+	// DeleteKey does not support deleting secret with the alias name so we must use the ID
+	// After you delete a key, the key transitions to the Destroyed state. Any data encrypted by
+	// keys in this state is no longer accessible. Metadata that is associated with the key,
+	// such as the key's deletion date, is kept in the Key Protect database. Destroyed keys can
+	// be recovered after up to 30 days or their expiration date, whichever is sooner. After 30
+	// days, keys can no longer be recovered, and become eligible to be purged after 90 days, a
+	// process that shreds the key material and makes its metadata inaccessible.
+	_, err = b.client.DeleteKey(ctx, key.ID, kp.ReturnRepresentation, []kp.CallOpt{kp.ForceOpt{Force: true}}...)
+	return err
+}
+
+// RotateKey is not supported since only the root keys of Key Protect can be rotated, the keys are
+// standard keys so that their payload can be read
+func (b *keyProtectBackend) RotateKey(ctx context.Context, name, value string) (string, error) {
+	return "", errors.New("ibm key protect cannot rotate standard keys")
+}
+
+// KeyVersion returns an empty version since the standard keys are not versioned
+func (b *keyProtectBackend) KeyVersion(ctx context.Context, name string) (string, error) {
+	return "", nil
+}
+
+func validateKeyProtect(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error {
+	for _, config := range kmsIBMKeyProtectMandatoryConnectionDetails {
+		if GetParam(kmsSpec.ConnectionDetails, config) == "" {
+			return errors.Errorf("failed to validate kms config %q. cannot be empty", config)
+		}
+	}
+	return nil
+}
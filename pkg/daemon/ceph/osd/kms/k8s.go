@@ -17,9 +17,11 @@ limitations under the License.
 package kms
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
@@ -39,15 +41,22 @@ const (
 	KMSTokenSecretNameKey = "token"
 )
 
-// storeSecretInKubernetes stores the dmcrypt key in a Kubernetes Secret
-func (c *Config) storeSecretInKubernetes(pvcName, key string) error {
-	s, err := generateOSDEncryptedKeySecret(pvcName, key, c.ClusterInfo)
+// k8sBackend stores the dmcrypt keys in Kubernetes Secrets owned by the CephCluster, this is the
+// default when no KMS is configured
+type k8sBackend struct {
+	context     *clusterd.Context
+	clusterInfo *cephclient.ClusterInfo
+}
+
+// PutKey stores the dmcrypt key in a Kubernetes Secret
+func (b *k8sBackend) PutKey(ctx context.Context, pvcName, key string) error {
+	s, err := generateOSDEncryptedKeySecret(pvcName, key, b.clusterInfo)
 	if err != nil {
 		return err
 	}
 
 	// Create the Kubernetes Secret
-	_, err = c.context.Clientset.CoreV1().Secrets(c.ClusterInfo.Namespace).Create(c.ClusterInfo.Context, s, metav1.CreateOptions{})
+	_, err = b.context.Clientset.CoreV1().Secrets(b.clusterInfo.Namespace).Create(ctx, s, metav1.CreateOptions{})
 	if err != nil && !kerrors.IsAlreadyExists(err) {
 		return errors.Wrapf(err, "failed to save ceph osd encryption key as a secret for pvc %q", pvcName)
 	}
@@ -55,6 +64,44 @@ func (c *Config) storeSecretInKubernetes(pvcName, key string) error {
 	return nil
 }
 
+func (b *k8sBackend) GetKey(ctx context.Context, pvcName string) (string, error) {
+	s, err := b.context.Clientset.CoreV1().Secrets(b.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(pvcName), metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get ceph osd encryption key secret for pvc %q", pvcName)
+	}
+
+	return string(s.Data[OsdEncryptionSecretNameKeyName]), nil
+}
+
+// DeleteKey deletes the Kubernetes Secret, which is also garbage collected with the CephCluster
+func (b *k8sBackend) DeleteKey(ctx context.Context, pvcName string) error {
+	err := b.context.Clientset.CoreV1().Secrets(b.clusterInfo.Namespace).Delete(ctx, GenerateOSDEncryptionSecretName(pvcName), metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete ceph osd encryption key secret for pvc %q", pvcName)
+	}
+
+	return nil
+}
+
+// RotateKey replaces the dmcrypt key in the Kubernetes Secret, the secrets are not versioned
+func (b *k8sBackend) RotateKey(ctx context.Context, pvcName, key string) (string, error) {
+	s, err := b.context.Clientset.CoreV1().Secrets(b.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(pvcName), metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get ceph osd encryption key secret for pvc %q", pvcName)
+	}
+	s.Data = map[string][]byte{OsdEncryptionSecretNameKeyName: []byte(key)}
+	_, err = b.context.Clientset.CoreV1().Secrets(b.clusterInfo.Namespace).Update(ctx, s, metav1.UpdateOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to update ceph osd encryption key secret for pvc %q", pvcName)
+	}
+
+	return "", nil
+}
+
+func (b *k8sBackend) KeyVersion(ctx context.Context, pvcName string) (string, error) {
+	return "", nil
+}
+
 func generateOSDEncryptedKeySecret(pvcName, key string, clusterInfo *cephclient.ClusterInfo) (*v1.Secret, error) {
 	s := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -17,11 +17,46 @@ limitations under the License.
 package kms
 
 import (
+	"context"
 	"testing"
 
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGenerateOSDEncryptionSecretName(t *testing.T) {
 	assert.Equal(t, "rook-ceph-osd-encryption-key-set1-data-0-7dwll", GenerateOSDEncryptionSecretName("set1-data-0-7dwll"))
 }
+
+func TestK8sBackend(t *testing.T) {
+	ctx := context.TODO()
+	clusterdContext := &clusterd.Context{Clientset: test.New(t, 1)}
+	clusterInfo := cephclient.AdminTestClusterInfo("rook-ceph")
+	b := &k8sBackend{context: clusterdContext, clusterInfo: clusterInfo}
+
+	_, err := b.RotateKey(ctx, "set1-data-0", "kek")
+	assert.Error(t, err)
+
+	assert.NoError(t, b.PutKey(ctx, "set1-data-0", "kek"))
+	s, err := clusterdContext.Clientset.CoreV1().Secrets("rook-ceph").Get(ctx, "rook-ceph-osd-encryption-key-set1-data-0", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "kek", s.StringData["dmcrypt-key"])
+	assert.Equal(t, "set1-data-0", s.Labels["pvc_name"])
+	// an existing key is never overwritten
+	assert.NoError(t, b.PutKey(ctx, "set1-data-0", "other"))
+
+	version, err := b.RotateKey(ctx, "set1-data-0", "new-kek")
+	assert.NoError(t, err)
+	assert.Equal(t, "", version)
+	value, err := b.GetKey(ctx, "set1-data-0")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-kek", value)
+
+	assert.NoError(t, b.DeleteKey(ctx, "set1-data-0"))
+	_, err = b.GetKey(ctx, "set1-data-0")
+	assert.Error(t, err)
+	assert.NoError(t, b.DeleteKey(ctx, "set1-data-0"))
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	TypeKMIP = "kmip"
+	// KmipEndpointKey is the endpoint of the KMIP server in the host:port form
+	KmipEndpointKey = "KMIP_ENDPOINT"
+	// KmipTLSServerNameKey is the name to verify the certificate of the KMIP server against
+	KmipTLSServerNameKey = "KMIP_TLS_SERVER_NAME"
+	// KmipReadTimeoutKey is the timeout in seconds to read a response from the KMIP server
+	KmipReadTimeoutKey = "KMIP_READ_TIMEOUT"
+	// KmipWriteTimeoutKey is the timeout in seconds to send a request to the KMIP server
+	KmipWriteTimeoutKey = "KMIP_WRITE_TIMEOUT"
+	// KmipKeyTemplateKey is the template of the names of the keys used by the RGW SSE-KMS encryption
+	KmipKeyTemplateKey = "KMIP_KEY_TEMPLATE"
+
+	// The certificates read from the token secret are appended to the connection details as
+	KmipCACertKey     = "KMIP_CA_CERT"
+	KmipClientCertKey = "KMIP_CLIENT_CERT"
+	KmipClientKeyKey  = "KMIP_CLIENT_KEY"
+
+	// Keys of the token secret holding the certificates, the same as the ceph-csi KMIP secret
+	KmipCACertSecretKey     = "CA_CERT"
+	KmipClientCertSecretKey = "CLIENT_CERT"
+	// #nosec G101 since it's just the name of the key
+	KmipClientKeySecretKey = "CLIENT_KEY"
+
+	kmipDefaultTimeout = 10 * time.Second
+)
+
+var (
+	kmsKMIPMandatoryConnectionDetails = []string{KmipEndpointKey}
+	// kmsKMIPTokenDetails maps the keys of the token secret to the connection details
+	kmsKMIPTokenDetails = map[string]string{
+		KmipCACertSecretKey:     KmipCACertKey,
+		KmipClientCertSecretKey: KmipClientCertKey,
+		KmipClientKeySecretKey:  KmipClientKeyKey,
+	}
+	errKMIPSecretNotFound = errors.New("secret not found in kmip server")
+)
+
+func init() {
+	RegisterProvider(TypeKMIP, &ProviderPlugin{
+		NewBackend: func(c *Config) (Backend, error) {
+			return initKMIP(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+		},
+		Validate: func(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error {
+			for _, config := range kmsKMIPMandatoryConnectionDetails {
+				if GetParam(kmsSpec.ConnectionDetails, config) == "" {
+					return errors.Errorf("failed to validate kms config %q. cannot be empty", config)
+				}
+			}
+			err := validateKMIPConnectionDetails(kmsSpec.ConnectionDetails)
+			if err != nil {
+				return errors.Wrap(err, "failed to validate kmip connection details")
+			}
+			return nil
+		},
+		TokenDetails: kmsKMIPTokenDetails,
+	})
+}
+
+// kmipDial connects to the KMIP server, it can be overridden by the unit tests
+var kmipDial = func(endpoint string, tlsConfig *tls.Config) (net.Conn, error) {
+	return tls.DialWithDialer(&net.Dialer{Timeout: kmipDefaultTimeout}, "tcp", endpoint, tlsConfig)
+}
+
+// kmipClient stores secrets in a KMIP server as Secret Data objects named after the secrets. A
+// connection is opened for each operation, the secrets are only read and written when an OSD is
+// provisioned or started.
+type kmipClient struct {
+	endpoint     string
+	tlsConfig    *tls.Config
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+func initKMIP(config map[string]string) (*kmipClient, error) {
+	endpoint := GetParam(config, KmipEndpointKey)
+	if endpoint == "" {
+		return nil, errors.Errorf("%s not set", KmipEndpointKey)
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid kmip endpoint %q", endpoint)
+	}
+
+	for _, key := range kmsKMIPTokenDetails {
+		if GetParam(config, key) == "" {
+			return nil, errors.Errorf("%s not set", key)
+		}
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM([]byte(config[KmipCACertKey])) {
+		return nil, errors.New("failed to parse the kmip ca certificate")
+	}
+	cert, err := tls.X509KeyPair([]byte(config[KmipClientCertKey]), []byte(config[KmipClientKeyKey]))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the kmip client certificate")
+	}
+	serverName := GetParam(config, KmipTLSServerNameKey)
+	if serverName == "" {
+		serverName = host
+	}
+
+	c := &kmipClient{
+		endpoint: endpoint,
+		tlsConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			ServerName:   serverName,
+			RootCAs:      caPool,
+			Certificates: []tls.Certificate{cert},
+		},
+	}
+	c.readTimeout, err = kmipTimeout(config, KmipReadTimeoutKey)
+	if err != nil {
+		return nil, err
+	}
+	c.writeTimeout, err = kmipTimeout(config, KmipWriteTimeoutKey)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func kmipTimeout(config map[string]string, key string) (time.Duration, error) {
+	value := GetParam(config, key)
+	if value == "" {
+		return kmipDefaultTimeout, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, errors.Errorf("invalid %s %q, must be a number of seconds", key, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// send sends one operation to the KMIP server and returns the payload of the response
+func (c *kmipClient) send(operation uint32, payload ...ttlv) (ttlv, error) {
+	request := kmipStructure(kmipTagRequestMessage,
+		kmipStructure(kmipTagRequestHeader,
+			kmipStructure(kmipTagProtocolVersion,
+				kmipInteger(kmipTagProtocolVersionMajor, 1),
+				kmipInteger(kmipTagProtocolVersionMinor, 4),
+			),
+			kmipInteger(kmipTagBatchCount, 1),
+		),
+		kmipStructure(kmipTagBatchItem,
+			kmipEnum(kmipTagOperation, operation),
+			kmipStructure(kmipTagRequestPayload, payload...),
+		),
+	)
+
+	conn, err := kmipDial(c.endpoint, c.tlsConfig)
+	if err != nil {
+		return ttlv{}, errors.Wrapf(err, "failed to connect to kmip server %q", c.endpoint)
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+		return ttlv{}, errors.Wrap(err, "failed to set kmip write deadline")
+	}
+	if _, err := conn.Write(request.marshal()); err != nil {
+		return ttlv{}, errors.Wrap(err, "failed to send kmip request")
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+		return ttlv{}, errors.Wrap(err, "failed to set kmip read deadline")
+	}
+	response, err := readTTLV(conn)
+	if err != nil {
+		return ttlv{}, err
+	}
+
+	if response.tag != kmipTagResponseMessage {
+		return ttlv{}, errors.Errorf("unexpected kmip response 0x%06x", response.tag)
+	}
+	item, ok := response.child(kmipTagBatchItem)
+	if !ok {
+		return ttlv{}, errors.New("kmip response has no batch item")
+	}
+	status, ok := item.child(kmipTagResultStatus)
+	if !ok {
+		return ttlv{}, errors.New("kmip response has no result status")
+	}
+	if status.uint32() != kmipResultStatusSuccess {
+		reason, _ := item.child(kmipTagResultReason)
+		message, _ := item.child(kmipTagResultMessage)
+		return ttlv{}, errors.Errorf("kmip operation 0x%02x failed with status %d and reason %d. %s", operation, status.uint32(), reason.uint32(), string(message.value))
+	}
+	responsePayload, _ := item.child(kmipTagResponsePayload)
+	return responsePayload, nil
+}
+
+func kmipNameAttribute(name string) ttlv {
+	return kmipStructure(kmipTagAttribute,
+		kmipText(kmipTagAttributeName, "Name"),
+		kmipStructure(kmipTagAttributeValue,
+			kmipText(kmipTagNameValue, name),
+			kmipEnum(kmipTagNameType, kmipNameTypeUninterpretedText),
+		),
+	)
+}
+
+// locate returns the unique identifier of the secret with the given name
+func (c *kmipClient) locate(name string) (string, error) {
+	payload, err := c.send(kmipOperationLocate,
+		kmipStructure(kmipTagAttribute,
+			kmipText(kmipTagAttributeName, "Object Type"),
+			kmipEnum(kmipTagAttributeValue, kmipObjectTypeSecretData),
+		),
+		kmipNameAttribute(name),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to locate secret %q", name)
+	}
+	ids := []string{}
+	for _, child := range payload.children {
+		if child.tag == kmipTagUniqueIdentifier {
+			ids = append(ids, string(child.value))
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", errKMIPSecretNotFound
+	case 1:
+		return ids[0], nil
+	default:
+		return "", errors.Errorf("found %d secrets named %q (%s)", len(ids), name, strings.Join(ids, ", "))
+	}
+}
+
+func (c *kmipClient) getSecret(name string) (string, error) {
+	id, err := c.locate(name)
+	if err != nil {
+		return "", err
+	}
+	payload, err := c.send(kmipOperationGet, kmipText(kmipTagUniqueIdentifier, id))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get secret %q", name)
+	}
+	material, ok := payload.path(kmipTagSecretData, kmipTagKeyBlock, kmipTagKeyValue, kmipTagKeyMaterial)
+	if !ok {
+		return "", errors.Errorf("kmip object %q of secret %q is not a secret data", id, name)
+	}
+	return string(material.value), nil
+}
+
+// putSecret registers and activates a secret, unless it already exists so that an existing key is
+// never overwritten
+func (c *kmipClient) putSecret(name, value string) error {
+	_, err := c.locate(name)
+	if err == nil {
+		logger.Debugf("secret %q already exists in kmip server", name)
+		return nil
+	}
+	if !errors.Is(err, errKMIPSecretNotFound) {
+		return err
+	}
+
+	_, err = c.register(name, value)
+	return err
+}
+
+// register registers and activates a secret, it returns the unique identifier of the object
+func (c *kmipClient) register(name, value string) (string, error) {
+	payload, err := c.send(kmipOperationRegister,
+		kmipEnum(kmipTagObjectType, kmipObjectTypeSecretData),
+		kmipStructure(kmipTagTemplateAttribute, kmipNameAttribute(name)),
+		kmipStructure(kmipTagSecretData,
+			kmipEnum(kmipTagSecretDataType, kmipSecretDataTypePassword),
+			kmipStructure(kmipTagKeyBlock,
+				kmipEnum(kmipTagKeyFormatType, kmipKeyFormatTypeOpaque),
+				kmipStructure(kmipTagKeyValue,
+					kmipBytes(kmipTagKeyMaterial, []byte(value)),
+				),
+			),
+		),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to register secret %q", name)
+	}
+	id, ok := payload.child(kmipTagUniqueIdentifier)
+	if !ok {
+		return "", errors.Errorf("kmip server did not return the identifier of secret %q", name)
+	}
+	_, err = c.send(kmipOperationActivate, kmipText(kmipTagUniqueIdentifier, string(id.value)))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to activate secret %q", name)
+	}
+	return string(id.value), nil
+}
+
+// deleteSecret revokes and destroys a secret, an active object cannot be destroyed
+func (c *kmipClient) deleteSecret(name string) error {
+	id, err := c.locate(name)
+	if errors.Is(err, errKMIPSecretNotFound) {
+		logger.Debugf("secret %q not found in kmip server", name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.destroy(name, id)
+}
+
+// destroy revokes and destroys an object of a secret
+func (c *kmipClient) destroy(name, id string) error {
+	_, err := c.send(kmipOperationRevoke,
+		kmipText(kmipTagUniqueIdentifier, id),
+		kmipStructure(kmipTagRevocationReason,
+			kmipEnum(kmipTagRevocationReasonCode, kmipRevocationCessationOfOperation),
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke secret %q", name)
+	}
+	_, err = c.send(kmipOperationDestroy, kmipText(kmipTagUniqueIdentifier, id))
+	if err != nil {
+		return errors.Wrapf(err, "failed to destroy secret %q", name)
+	}
+	return nil
+}
+
+// rotateSecret registers the new value of a secret and destroys the previous one, the unique
+// identifier of the new object is the version of the secret
+func (c *kmipClient) rotateSecret(name, value string) (string, error) {
+	previousID, err := c.locate(name)
+	if err != nil {
+		return "", err
+	}
+	id, err := c.register(name, value)
+	if err != nil {
+		return "", err
+	}
+	if err := c.destroy(name, previousID); err != nil {
+		return "", errors.Wrapf(err, "failed to destroy the previous version %q", previousID)
+	}
+	return id, nil
+}
+
+func (c *kmipClient) GetKey(ctx context.Context, name string) (string, error) {
+	return c.getSecret(GenerateOSDEncryptionSecretName(name))
+}
+
+func (c *kmipClient) PutKey(ctx context.Context, name, value string) error {
+	return c.putSecret(GenerateOSDEncryptionSecretName(name), value)
+}
+
+func (c *kmipClient) DeleteKey(ctx context.Context, name string) error {
+	return c.deleteSecret(GenerateOSDEncryptionSecretName(name))
+}
+
+func (c *kmipClient) RotateKey(ctx context.Context, name, value string) (string, error) {
+	return c.rotateSecret(GenerateOSDEncryptionSecretName(name), value)
+}
+
+// KeyVersion returns the unique identifier of the object of the secret
+func (c *kmipClient) KeyVersion(ctx context.Context, name string) (string, error) {
+	return c.locate(GenerateOSDEncryptionSecretName(name))
+}
+
+// validateKMIPConnectionDetails validates the KMIP connection details, the certificates are already
+// read from the token secret
+func validateKMIPConnectionDetails(config map[string]string) error {
+	_, err := initKMIP(config)
+	return err
+}
+
+// IsKMIP determines whether the configured KMS is a KMIP server
+func (c *Config) IsKMIP() bool { return c.Provider == TypeKMIP }
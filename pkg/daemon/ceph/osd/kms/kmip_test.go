@@ -0,0 +1,261 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// kmipTestCertificate returns a self-signed certificate and its key in the PEM format
+func kmipTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kmip"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+// fakeKMIP is a KMIP server storing secret data objects in memory
+type fakeKMIP struct {
+	t          *testing.T
+	objects    map[string]string
+	names      map[string]string
+	states     map[string]string
+	operations []uint32
+	nextID     int
+}
+
+func (f *fakeKMIP) dial(endpoint string, tlsConfig *tls.Config) (net.Conn, error) {
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		request, err := readTTLV(server)
+		if !assert.NoError(f.t, err) {
+			return
+		}
+		_, err = server.Write(f.handle(request).marshal())
+		assert.NoError(f.t, err)
+	}()
+	return client, nil
+}
+
+func (f *fakeKMIP) handle(request ttlv) ttlv {
+	operation, _ := request.path(kmipTagBatchItem, kmipTagOperation)
+	payload, _ := request.path(kmipTagBatchItem, kmipTagRequestPayload)
+	f.operations = append(f.operations, operation.uint32())
+	id, _ := payload.child(kmipTagUniqueIdentifier)
+
+	response := []ttlv{}
+	switch operation.uint32() {
+	case kmipOperationRegister:
+		name, _ := payload.path(kmipTagTemplateAttribute, kmipTagAttribute, kmipTagAttributeValue, kmipTagNameValue)
+		material, _ := payload.path(kmipTagSecretData, kmipTagKeyBlock, kmipTagKeyValue, kmipTagKeyMaterial)
+		f.nextID++
+		newID := fmt.Sprintf("%d", f.nextID)
+		f.objects[newID] = string(material.value)
+		f.names[newID] = string(name.value)
+		f.states[newID] = "pre-active"
+		response = append(response, kmipText(kmipTagUniqueIdentifier, newID))
+	case kmipOperationLocate:
+		name := ""
+		for _, attribute := range payload.children {
+			if value, ok := attribute.path(kmipTagAttributeValue, kmipTagNameValue); ok {
+				name = string(value.value)
+			}
+		}
+		for objectID, objectName := range f.names {
+			if objectName == name {
+				response = append(response, kmipText(kmipTagUniqueIdentifier, objectID))
+			}
+		}
+	case kmipOperationGet:
+		response = append(response, kmipStructure(kmipTagSecretData,
+			kmipStructure(kmipTagKeyBlock, kmipStructure(kmipTagKeyValue, kmipBytes(kmipTagKeyMaterial, []byte(f.objects[string(id.value)]))))))
+	case kmipOperationActivate:
+		f.states[string(id.value)] = "active"
+	case kmipOperationRevoke:
+		f.states[string(id.value)] = "deactivated"
+	case kmipOperationDestroy:
+		if f.states[string(id.value)] == "active" {
+			return kmipFailure("object is active")
+		}
+		delete(f.objects, string(id.value))
+		delete(f.names, string(id.value))
+		delete(f.states, string(id.value))
+	}
+
+	return kmipStructure(kmipTagResponseMessage,
+		kmipStructure(kmipTagResponseHeader),
+		kmipStructure(kmipTagBatchItem,
+			operation,
+			kmipEnum(kmipTagResultStatus, kmipResultStatusSuccess),
+			kmipStructure(kmipTagResponsePayload, response...),
+		),
+	)
+}
+
+func kmipFailure(message string) ttlv {
+	return kmipStructure(kmipTagResponseMessage,
+		kmipStructure(kmipTagResponseHeader),
+		kmipStructure(kmipTagBatchItem,
+			kmipEnum(kmipTagResultStatus, 1),
+			kmipEnum(kmipTagResultReason, 0x0B),
+			kmipText(kmipTagResultMessage, message),
+		),
+	)
+}
+
+func TestTTLV(t *testing.T) {
+	item := kmipStructure(kmipTagRequestPayload,
+		kmipText(kmipTagUniqueIdentifier, "12345"),
+		kmipEnum(kmipTagObjectType, kmipObjectTypeSecretData),
+		kmipBytes(kmipTagKeyMaterial, []byte("0123456789")),
+	)
+	b := item.marshal()
+	// 8 bytes header, 16 bytes text, 16 bytes enum, 24 bytes byte string
+	assert.Len(t, b, 8+16+16+24)
+	assert.Equal(t, []byte{0x42, 0x00, 0x79, kmipTypeStructure, 0, 0, 0, 56}, b[:8])
+
+	decoded, rest, err := unmarshalTTLV(b)
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+	id, ok := decoded.child(kmipTagUniqueIdentifier)
+	assert.True(t, ok)
+	assert.Equal(t, "12345", string(id.value))
+	objectType, _ := decoded.child(kmipTagObjectType)
+	assert.Equal(t, kmipObjectTypeSecretData, objectType.uint32())
+	material, _ := decoded.child(kmipTagKeyMaterial)
+	assert.Equal(t, "0123456789", string(material.value))
+
+	_, _, err = unmarshalTTLV(b[:20])
+	assert.Error(t, err)
+}
+
+func TestInitKMIP(t *testing.T) {
+	cert, key := kmipTestCertificate(t)
+	config := map[string]string{}
+
+	_, err := initKMIP(config)
+	assert.EqualError(t, err, "KMIP_ENDPOINT not set")
+	config[KmipEndpointKey] = "kmip.example.com"
+	_, err = initKMIP(config)
+	assert.Error(t, err)
+	config[KmipEndpointKey] = "kmip.example.com:5696"
+	_, err = initKMIP(config)
+	assert.Error(t, err)
+
+	config[KmipCACertKey] = "not a certificate"
+	config[KmipClientCertKey] = cert
+	config[KmipClientKeyKey] = key
+	_, err = initKMIP(config)
+	assert.EqualError(t, err, "failed to parse the kmip ca certificate")
+	config[KmipCACertKey] = cert
+
+	c, err := initKMIP(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "kmip.example.com", c.tlsConfig.ServerName)
+	assert.Equal(t, kmipDefaultTimeout, c.readTimeout)
+
+	config[KmipTLSServerNameKey] = "kmip"
+	config[KmipReadTimeoutKey] = "30"
+	c, err = initKMIP(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "kmip", c.tlsConfig.ServerName)
+	assert.Equal(t, 30*time.Second, c.readTimeout)
+
+	config[KmipWriteTimeoutKey] = "-1"
+	_, err = initKMIP(config)
+	assert.Error(t, err)
+}
+
+func TestKMIPSecrets(t *testing.T) {
+	fake := &fakeKMIP{t: t, objects: map[string]string{}, names: map[string]string{}, states: map[string]string{}}
+	previousDial := kmipDial
+	kmipDial = fake.dial
+	defer func() { kmipDial = previousDial }()
+	c := &kmipClient{endpoint: "kmip:5696", readTimeout: time.Second, writeTimeout: time.Second}
+
+	_, err := c.getSecret("osd-key")
+	assert.ErrorIs(t, err, errKMIPSecretNotFound)
+
+	assert.NoError(t, c.putSecret("osd-key", "kek"))
+	assert.Equal(t, map[string]string{"1": "active"}, fake.states)
+	value, err := c.getSecret("osd-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "kek", value)
+
+	// an existing key is never overwritten
+	fake.operations = nil
+	assert.NoError(t, c.putSecret("osd-key", "other"))
+	assert.Equal(t, []uint32{kmipOperationLocate}, fake.operations)
+	value, err = c.getSecret("osd-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "kek", value)
+
+	fake.operations = nil
+	assert.NoError(t, c.deleteSecret("osd-key"))
+	assert.Equal(t, []uint32{kmipOperationLocate, kmipOperationRevoke, kmipOperationDestroy}, fake.operations)
+	assert.Empty(t, fake.objects)
+	assert.NoError(t, c.deleteSecret("osd-key"))
+
+	// rotating a key replaces the object of the secret
+	assert.NoError(t, c.PutKey(context.TODO(), "set1-data-0", "kek"))
+	version, err := c.KeyVersion(context.TODO(), "set1-data-0")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", version)
+	version, err = c.RotateKey(context.TODO(), "set1-data-0", "new-kek")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", version)
+	assert.Equal(t, map[string]string{"3": "active"}, fake.states)
+	value, err = c.GetKey(context.TODO(), "set1-data-0")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-kek", value)
+	assert.NoError(t, c.DeleteKey(context.TODO(), "set1-data-0"))
+	_, err = c.RotateKey(context.TODO(), "set1-data-0", "kek")
+	assert.ErrorIs(t, err, errKMIPSecretNotFound)
+
+	// the failures are reported with the message of the server
+	fake.names["2"] = "active-key"
+	fake.states["2"] = "active"
+	_, err = c.send(kmipOperationDestroy, kmipText(kmipTagUniqueIdentifier, "2"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "object is active")
+}
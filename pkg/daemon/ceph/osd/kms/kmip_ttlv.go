@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// The subset of the KMIP 1.4 Tag-Type-Length-Value encoding needed to store secrets. Each item is
+// made of a 3 bytes tag, a 1 byte type, a 4 bytes length and the value padded to 8 bytes.
+
+const (
+	kmipTypeStructure   byte = 0x01
+	kmipTypeInteger     byte = 0x02
+	kmipTypeEnumeration byte = 0x05
+	kmipTypeTextString  byte = 0x07
+	kmipTypeByteString  byte = 0x08
+
+	kmipTagAttribute            uint32 = 0x420008
+	kmipTagAttributeName        uint32 = 0x42000A
+	kmipTagAttributeValue       uint32 = 0x42000B
+	kmipTagBatchCount           uint32 = 0x42000D
+	kmipTagBatchItem            uint32 = 0x42000F
+	kmipTagKeyBlock             uint32 = 0x420040
+	kmipTagKeyFormatType        uint32 = 0x420042
+	kmipTagKeyMaterial          uint32 = 0x420043
+	kmipTagKeyValue             uint32 = 0x420045
+	kmipTagNameType             uint32 = 0x420054
+	kmipTagNameValue            uint32 = 0x420055
+	kmipTagObjectType           uint32 = 0x420057
+	kmipTagOperation            uint32 = 0x42005C
+	kmipTagProtocolVersion      uint32 = 0x420069
+	kmipTagProtocolVersionMajor uint32 = 0x42006A
+	kmipTagProtocolVersionMinor uint32 = 0x42006B
+	kmipTagRequestHeader        uint32 = 0x420077
+	kmipTagRequestMessage       uint32 = 0x420078
+	kmipTagRequestPayload       uint32 = 0x420079
+	kmipTagResponseHeader       uint32 = 0x42007A
+	kmipTagResponseMessage      uint32 = 0x42007B
+	kmipTagResponsePayload      uint32 = 0x42007C
+	kmipTagResultMessage        uint32 = 0x42007D
+	kmipTagResultReason         uint32 = 0x42007E
+	kmipTagResultStatus         uint32 = 0x42007F
+	kmipTagRevocationReason     uint32 = 0x420081
+	kmipTagRevocationReasonCode uint32 = 0x420082
+	kmipTagSecretData           uint32 = 0x420085
+	kmipTagSecretDataType       uint32 = 0x420086
+	kmipTagTemplateAttribute    uint32 = 0x420091
+	kmipTagUniqueIdentifier     uint32 = 0x420094
+
+	kmipOperationRegister uint32 = 0x03
+	kmipOperationLocate   uint32 = 0x08
+	kmipOperationGet      uint32 = 0x0A
+	kmipOperationActivate uint32 = 0x12
+	kmipOperationRevoke   uint32 = 0x13
+	kmipOperationDestroy  uint32 = 0x14
+
+	kmipObjectTypeSecretData           uint32 = 0x07
+	kmipSecretDataTypePassword         uint32 = 0x01
+	kmipKeyFormatTypeOpaque            uint32 = 0x02
+	kmipNameTypeUninterpretedText      uint32 = 0x01
+	kmipResultStatusSuccess            uint32 = 0x00
+	kmipRevocationCessationOfOperation uint32 = 0x05
+
+	kmipHeaderLength = 8
+	// the max size of a response, a secret response is a few hundred bytes
+	kmipMaxMessageLength = 1 << 20
+)
+
+// ttlv is a decoded or to be encoded KMIP item
+type ttlv struct {
+	tag      uint32
+	typ      byte
+	value    []byte
+	children []ttlv
+}
+
+func kmipStructure(tag uint32, children ...ttlv) ttlv {
+	return ttlv{tag: tag, typ: kmipTypeStructure, children: children}
+}
+
+func kmipInteger(tag uint32, value uint32) ttlv {
+	v := make([]byte, 4)
+	binary.BigEndian.PutUint32(v, value)
+	return ttlv{tag: tag, typ: kmipTypeInteger, value: v}
+}
+
+func kmipEnum(tag uint32, value uint32) ttlv {
+	v := make([]byte, 4)
+	binary.BigEndian.PutUint32(v, value)
+	return ttlv{tag: tag, typ: kmipTypeEnumeration, value: v}
+}
+
+func kmipText(tag uint32, value string) ttlv {
+	return ttlv{tag: tag, typ: kmipTypeTextString, value: []byte(value)}
+}
+
+func kmipBytes(tag uint32, value []byte) ttlv {
+	return ttlv{tag: tag, typ: kmipTypeByteString, value: value}
+}
+
+// marshal returns the TTLV encoding of the item
+func (t ttlv) marshal() []byte {
+	value := t.value
+	if t.typ == kmipTypeStructure {
+		value = []byte{}
+		for _, child := range t.children {
+			value = append(value, child.marshal()...)
+		}
+	}
+
+	b := make([]byte, kmipHeaderLength, kmipHeaderLength+len(value)+7)
+	b[0] = byte(t.tag >> 16)
+	b[1] = byte(t.tag >> 8)
+	b[2] = byte(t.tag)
+	b[3] = t.typ
+	binary.BigEndian.PutUint32(b[4:], uint32(len(value)))
+	b = append(b, value...)
+	if pad := len(value) % 8; pad != 0 {
+		b = append(b, make([]byte, 8-pad)...)
+	}
+	return b
+}
+
+// unmarshalTTLV decodes one item, it returns the item and the remaining bytes
+func unmarshalTTLV(b []byte) (ttlv, []byte, error) {
+	if len(b) < kmipHeaderLength {
+		return ttlv{}, nil, errors.New("kmip item is truncated")
+	}
+	t := ttlv{
+		tag: uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]),
+		typ: b[3],
+	}
+	length := int(binary.BigEndian.Uint32(b[4:8]))
+	padded := length
+	if pad := length % 8; pad != 0 {
+		padded += 8 - pad
+	}
+	if len(b) < kmipHeaderLength+length {
+		return ttlv{}, nil, errors.Errorf("kmip item 0x%06x is truncated", t.tag)
+	}
+	t.value = b[kmipHeaderLength : kmipHeaderLength+length]
+	rest := b[kmipHeaderLength+length:]
+	if len(b) >= kmipHeaderLength+padded {
+		rest = b[kmipHeaderLength+padded:]
+	}
+
+	if t.typ == kmipTypeStructure {
+		children := t.value
+		for len(children) > 0 {
+			var child ttlv
+			var err error
+			child, children, err = unmarshalTTLV(children)
+			if err != nil {
+				return ttlv{}, nil, err
+			}
+			t.children = append(t.children, child)
+		}
+		t.value = nil
+	}
+	return t, rest, nil
+}
+
+// readTTLV reads one message from a connection
+func readTTLV(r io.Reader) (ttlv, error) {
+	header := make([]byte, kmipHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return ttlv{}, errors.Wrap(err, "failed to read kmip message header")
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	if length > kmipMaxMessageLength {
+		return ttlv{}, errors.Errorf("kmip message of %d bytes is too large", length)
+	}
+	message := make([]byte, kmipHeaderLength+int(length))
+	copy(message, header)
+	if _, err := io.ReadFull(r, message[kmipHeaderLength:]); err != nil {
+		return ttlv{}, errors.Wrap(err, "failed to read kmip message")
+	}
+	t, _, err := unmarshalTTLV(message)
+	return t, err
+}
+
+// child returns the first child of a structure with the given tag
+func (t ttlv) child(tag uint32) (ttlv, bool) {
+	for _, c := range t.children {
+		if c.tag == tag {
+			return c, true
+		}
+	}
+	return ttlv{}, false
+}
+
+// path returns the item found by following the tags from the structure
+func (t ttlv) path(tags ...uint32) (ttlv, bool) {
+	current := t
+	for _, tag := range tags {
+		var ok bool
+		current, ok = current.child(tag)
+		if !ok {
+			return ttlv{}, false
+		}
+	}
+	return current, true
+}
+
+func (t ttlv) uint32() uint32 {
+	if len(t.value) < 4 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(t.value)
+}
@@ -18,14 +18,10 @@ package kms
 
 import (
 	"context"
-	"os"
 	"strings"
 
-	kp "github.com/IBM/keyprotect-go-client"
 	"github.com/coreos/pkg/capnslog"
-	"github.com/hashicorp/vault/api"
 	"github.com/libopenstorage/secrets"
-	"github.com/libopenstorage/secrets/vault"
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
@@ -60,59 +56,44 @@ func NewConfig(context *clusterd.Context, clusterSpec *cephv1.ClusterSpec, clust
 	}
 
 	Provider := clusterSpec.Security.KeyManagementService.ConnectionDetails[Provider]
-	switch Provider {
-	case "":
+	if Provider == "" {
 		config.Provider = secrets.TypeK8s
-	case secrets.TypeVault:
-		config.Provider = secrets.TypeVault
-	case TypeIBM:
-		config.Provider = TypeIBM
-	default:
+	} else if _, ok := getProvider(Provider); ok {
+		config.Provider = Provider
+	} else {
 		logger.Errorf("unsupported kms type %q", Provider)
 	}
 
 	return config
 }
 
-// PutSecret writes an encrypted key in a KMS
-func (c *Config) PutSecret(secretName, secretValue string) error {
-	// If Kubernetes Secret KMS is selected (default)
+// backend returns a client of the configured KMS, the keys are stored in Kubernetes Secrets when no
+// KMS is configured
+func (c *Config) backend() (Backend, error) {
 	if c.IsK8s() {
-		// Store the secret in Kubernetes Secrets
-		err := c.storeSecretInKubernetes(secretName, secretValue)
-		if err != nil {
-			return errors.Wrap(err, "failed to store secret in kubernetes secret")
-		}
+		return &k8sBackend{context: c.context, clusterInfo: c.ClusterInfo}, nil
 	}
-	if c.IsVault() {
-		// Store the secret in Vault
-		v, err := InitVault(c.context, c.ClusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
-		if err != nil {
-			return errors.Wrap(err, "failed to init vault kms")
-		}
-		k := buildVaultKeyContext(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
-		err = put(v, GenerateOSDEncryptionSecretName(secretName), secretValue, k)
-		if err != nil {
-			return errors.Wrap(err, "failed to put secret in vault")
-		}
+	plugin, ok := getProvider(c.Provider)
+	if !ok {
+		return nil, errors.Errorf("unsupported kms type %q", c.Provider)
 	}
-	if c.IsIBMKeyProtect() {
-		kpClient, err := InitKeyProtect(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
-		if err != nil {
-			return errors.Wrap(err, "failed to init ibm key protect")
-		}
-
-		// Create the key is not present
-		keyAlias := []string{secretName}
-		_, err = kpClient.CreateImportedKeyWithAliases(c.ClusterInfo.Context, secretName, nil, secretValue, "", "", true, keyAlias)
-		if err != nil {
-			if strings.Contains(err.Error(), "KEY_ALIAS_NOT_UNIQUE_ERR") {
-				logger.Debugf("key %q already exists. %v", secretName, err)
-				return nil
-			}
+	b, err := plugin.NewBackend(c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to init %q kms", c.Provider)
+	}
+	return b, nil
+}
 
-			return errors.Wrap(err, "failed to put secret in ibm key protect")
-		}
+// PutSecret writes an encrypted key in a KMS
+func (c *Config) PutSecret(secretName, secretValue string) error {
+	b, err := c.backend()
+	if err != nil {
+		return err
+	}
+	// The backends never overwrite an existing key
+	err = b.PutKey(c.ClusterInfo.Context, secretName, secretValue)
+	if err != nil {
+		return errors.Wrapf(err, "failed to put secret in %q kms", c.Provider)
 	}
 
 	return nil
@@ -120,30 +101,13 @@ func (c *Config) PutSecret(secretName, secretValue string) error {
 
 // GetSecret returns an encrypted key from a KMS
 func (c *Config) GetSecret(secretName string) (string, error) {
-	var value string
-	if c.IsVault() {
-		// Store the secret in Vault
-		v, err := InitVault(c.context, c.ClusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
-		if err != nil {
-			return "", errors.Wrap(err, "failed to init vault")
-		}
-
-		k := buildVaultKeyContext(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
-		value, err = get(v, GenerateOSDEncryptionSecretName(secretName), k)
-		if err != nil {
-			return "", errors.Wrap(err, "failed to get secret from vault")
-		}
+	b, err := c.backend()
+	if err != nil {
+		return "", err
 	}
-	if c.IsIBMKeyProtect() {
-		kpClient, err := InitKeyProtect(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
-		if err != nil {
-			return "", errors.Wrap(err, "failed to init ibm key protect")
-		}
-		keyObject, err := kpClient.GetKey(c.ClusterInfo.Context, secretName)
-		if err != nil {
-			return "", errors.Wrap(err, "failed to get secret from ibm key protect")
-		}
-		value = string(keyObject.Payload)
+	value, err := b.GetKey(c.ClusterInfo.Context, secretName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get secret from %q kms", c.Provider)
 	}
 
 	return value, nil
@@ -151,53 +115,47 @@ func (c *Config) GetSecret(secretName string) (string, error) {
 
 // DeleteSecret deletes an encrypted key from a KMS
 func (c *Config) DeleteSecret(secretName string) error {
-	if c.IsVault() {
-		// Store the secret in Vault
-		v, err := InitVault(c.context, c.ClusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
-		if err != nil {
-			return errors.Wrap(err, "failed to delete secret in vault")
-		}
-
-		k := buildVaultKeyContext(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	b, err := c.backend()
+	if err != nil {
+		return err
+	}
+	// We use context.TODO() since the clusterInfo context has been cancelled by the CephCluster's
+	// deletion event
+	err = b.DeleteKey(context.TODO(), secretName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete secret in %q kms", c.Provider)
+	}
 
-		// Force removal of all the versions of the secret on K/V version 2
-		k[secrets.DestroySecret] = "true"
+	return nil
+}
 
-		err = deleteSecret(v, GenerateOSDEncryptionSecretName(secretName), k)
-		if err != nil {
-			return errors.Wrap(err, "failed to delete secret in vault")
-		}
+// RotateSecret replaces an encrypted key in a KMS and returns the version of the new key
+func (c *Config) RotateSecret(secretName, secretValue string) (string, error) {
+	b, err := c.backend()
+	if err != nil {
+		return "", err
+	}
+	version, err := b.RotateKey(c.ClusterInfo.Context, secretName, secretValue)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to rotate secret in %q kms", c.Provider)
 	}
-	if c.IsIBMKeyProtect() {
-		kpClient, err := InitKeyProtect(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
-		if err != nil {
-			return errors.Wrap(err, "failed to init ibm key protect")
-		}
-
-		// We use context.TODO() since the clusterInfo context has been cancelled by the CephCluster's
-		// deletion event
-		ctx := context.TODO()
 
-		// Fetch the key to get the ID
-		key, err := kpClient.GetKey(ctx, secretName)
-		if err != nil {
-			return errors.Wrap(err, "failed to get secret in ibm key protect")
-		}
+	return version, nil
+}
 
-		// DeleteKey does not support deleting secret with the alias name so we must use the ID
-		// After you delete a key, the key transitions to the Destroyed state. Any data encrypted by
-		// keys in this state is no longer accessible. Metadata that is associated with the key,
-		// such as the key's deletion date, is kept in the Key Protect database. Destroyed keys can
-		// be recovered after up to 30 days or their expiration date, whichever is sooner. After 30
-		// days, keys can no longer be recovered, and become eligible to be purged after 90 days, a
-		// process that shreds the key material and makes its metadata inaccessible.
-		_, err = kpClient.DeleteKey(ctx, key.ID, kp.ReturnRepresentation, []kp.CallOpt{kp.ForceOpt{Force: true}}...)
-		if err != nil {
-			return errors.Wrap(err, "failed to delete secret in ibm key protect")
-		}
+// SecretVersion returns the version of an encrypted key, it is empty when the KMS does not version
+// the keys
+func (c *Config) SecretVersion(secretName string) (string, error) {
+	b, err := c.backend()
+	if err != nil {
+		return "", err
+	}
+	version, err := b.KeyVersion(c.ClusterInfo.Context, secretName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get secret version from %q kms", c.Provider)
 	}
 
-	return nil
+	return version, nil
 }
 
 // GetParam returns the value of the KMS config option
@@ -217,108 +175,58 @@ func ValidateConnectionDetails(ctx context.Context, clusterdContext *clusterd.Co
 		}
 	}
 
-	// A token must be specified if token-auth is used
-	if !securitySpec.KeyManagementService.IsK8sAuthEnabled() && securitySpec.KeyManagementService.TokenSecretName == "" {
-		if !securitySpec.KeyManagementService.IsTokenAuthEnabled() {
-			return errors.New("failed to validate kms configuration (missing token in spec)")
-		}
-	}
-
 	// KMS provider must be specified
 	provider := GetParam(securitySpec.KeyManagementService.ConnectionDetails, Provider)
-
-	// Validate potential token Secret presence
-	if securitySpec.KeyManagementService.IsTokenAuthEnabled() {
-		kmsToken, err := clusterdContext.Clientset.CoreV1().Secrets(ns).Get(ctx, securitySpec.KeyManagementService.TokenSecretName, metav1.GetOptions{})
-		if err != nil {
-			return errors.Wrapf(err, "failed to fetch kms token secret %q", securitySpec.KeyManagementService.TokenSecretName)
-		}
-
-		switch provider {
-		case secrets.TypeVault:
-			// Check for empty token
-			token, ok := kmsToken.Data[KMSTokenSecretNameKey]
-			if !ok || len(token) == 0 {
-				return errors.Errorf("failed to read k8s kms secret %q key %q (not found or empty)", KMSTokenSecretNameKey, securitySpec.KeyManagementService.TokenSecretName)
-			}
-
-			// Set the env variable
-			err = os.Setenv(api.EnvVaultToken, string(token))
-			if err != nil {
-				return errors.Wrap(err, "failed to set vault kms token to an env var")
-			}
-
-		case TypeIBM:
-			for _, config := range kmsIBMKeyProtectMandatoryTokenDetails {
-				v, ok := kmsToken.Data[config]
-				if !ok || len(v) == 0 {
-					return errors.Errorf("failed to read k8s kms secret %q key %q (not found or empty)", config, securitySpec.KeyManagementService.TokenSecretName)
-				}
-				// Append the token secret details to the connection details
-				securitySpec.KeyManagementService.ConnectionDetails[config] = strings.TrimSuffix(strings.TrimSpace(string(v)), "\n")
-			}
-		}
+	plugin, ok := getProvider(provider)
+	if !ok {
+		return errors.Errorf("failed to validate kms provider connection details (provider %q not supported)", provider)
 	}
 
-	// Validate KMS provider connection details for each provider
-	switch provider {
-	case secrets.TypeVault:
-		err := validateVaultConnectionDetails(clusterdContext, ns, securitySpec.KeyManagementService.ConnectionDetails)
-		if err != nil {
-			return errors.Wrap(err, "failed to validate vault connection details")
-		}
-
-		secretEngine := securitySpec.KeyManagementService.ConnectionDetails[VaultSecretEngineKey]
-		switch secretEngine {
-		case VaultKVSecretEngineKey:
-			// Append Backend Version if not already present
-			if GetParam(securitySpec.KeyManagementService.ConnectionDetails, vault.VaultBackendKey) == "" {
-				backendVersion, err := BackendVersion(clusterdContext, ns, securitySpec.KeyManagementService.ConnectionDetails)
-				if err != nil {
-					return errors.Wrap(err, "failed to get backend version")
-				}
-				securitySpec.KeyManagementService.ConnectionDetails[vault.VaultBackendKey] = backendVersion
-			}
-		}
-
-	case TypeIBM:
-		for _, config := range kmsIBMKeyProtectMandatoryConnectionDetails {
-			if GetParam(securitySpec.KeyManagementService.ConnectionDetails, config) == "" {
-				return errors.Errorf("failed to validate kms config %q. cannot be empty", config)
-			}
-		}
+	// A token must be specified if token-auth is used
+	if !securitySpec.KeyManagementService.IsTokenAuthEnabled() && plugin.isTokenRequired(&securitySpec.KeyManagementService) {
+		return errors.New("failed to validate kms configuration (missing token in spec)")
+	}
 
-	default:
-		return errors.Errorf("failed to validate kms provider connection details (provider %q not supported)", provider)
+	// Validate potential token Secret presence
+	err := LoadToken(ctx, clusterdContext, &securitySpec.KeyManagementService, ns)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	// Validate KMS provider connection details
+	return plugin.Validate(ctx, clusterdContext, &securitySpec.KeyManagementService, ns)
 }
 
-// SetTokenToEnvVar sets a KMS token as an env variable
-func SetTokenToEnvVar(ctx context.Context, clusterdContext *clusterd.Context, tokenSecretName, provider, namespace string) error {
-	// Get the secret containing the kms token
-	kmsToken, err := clusterdContext.Clientset.CoreV1().Secrets(namespace).Get(ctx, tokenSecretName, metav1.GetOptions{})
+// LoadToken reads the token secret of the KMS, if any, and loads its values for the KMS clients of
+// the operator
+func LoadToken(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, namespace string) error {
+	if !kmsSpec.IsTokenAuthEnabled() {
+		return nil
+	}
+	plugin, err := providerOf(kmsSpec)
 	if err != nil {
-		return errors.Wrapf(err, "failed to fetch kms token secret %q", tokenSecretName)
+		return err
 	}
 
-	// We set the token as an env variable, the secrets lib will pick it up
-	var key, value string
-	switch provider {
-	case secrets.TypeVault:
-		key = api.EnvVaultToken
-		value = string(kmsToken.Data[KMSTokenSecretNameKey])
-	default:
-		logger.Debugf("unknown provider %q return nil", provider)
-		return nil
+	// Get the secret containing the kms token
+	kmsToken, err := clusterdContext.Clientset.CoreV1().Secrets(namespace).Get(ctx, kmsSpec.TokenSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch kms token secret %q", kmsSpec.TokenSecretName)
 	}
 
-	// Set the env variable
-	err = os.Setenv(key, value)
+	return plugin.loadToken(kmsSpec, kmsToken.Data)
+}
+
+// CheckConnection checks that the KMS can be reached with the connection details, the token secret
+// must be loaded already. The providers that cannot be checked are assumed to be reachable.
+func CheckConnection(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error {
+	plugin, err := providerOf(kmsSpec)
 	if err != nil {
-		return errors.Wrap(err, "failed to set kms token to an env var")
+		return err
+	}
+	if plugin.CheckConnection == nil {
+		return nil
 	}
 
-	return nil
+	return plugin.CheckConnection(ctx, clusterdContext, kmsSpec, ns)
 }
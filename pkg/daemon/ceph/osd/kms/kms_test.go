@@ -19,6 +19,7 @@ package kms
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/api"
@@ -194,9 +195,98 @@ func TestValidateConnectionDetails(t *testing.T) {
 		// all the details
 		assert.Equal(t, ibmSecuritySpec.KeyManagementService.ConnectionDetails["IBM_KP_SERVICE_API_KEY"], "foo")
 	})
+
+	azureSecuritySpec := &cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{
+		ConnectionDetails: map[string]string{
+			"KMS_PROVIDER":    TypeAzure,
+			"AZURE_TENANT_ID": "tenant",
+			"AZURE_CLIENT_ID": "client",
+		},
+	}}
+
+	t.Run("azure kv - fail no token specified with the client secret auth", func(t *testing.T) {
+		err := ValidateConnectionDetails(ctx, context, azureSecuritySpec, ns)
+		assert.EqualError(t, err, "failed to validate kms configuration (missing token in spec)")
+		azureSecuritySpec.KeyManagementService.TokenSecretName = "azure-secret"
+	})
+
+	t.Run("azure kv - token present but no client secret", func(t *testing.T) {
+		azureSecret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "azure-secret", Namespace: ns}, Data: map[string][]byte{"foo": []byte("bar")}}
+		_, err := context.Clientset.CoreV1().Secrets(ns).Create(ctx, azureSecret, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		err = ValidateConnectionDetails(ctx, context, azureSecuritySpec, ns)
+		assert.EqualError(t, err, "failed to read k8s kms secret \"AZURE_CLIENT_SECRET\" key \"azure-secret\" (not found or empty)")
+		azureSecret.Data["AZURE_CLIENT_SECRET"] = []byte("secret\n")
+		_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, azureSecret, metav1.UpdateOptions{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("azure kv - no vault url", func(t *testing.T) {
+		err := ValidateConnectionDetails(ctx, context, azureSecuritySpec, ns)
+		assert.EqualError(t, err, "failed to validate azure key vault connection details: failed to validate kms config \"AZURE_VAULT_URL\". cannot be empty")
+		azureSecuritySpec.KeyManagementService.ConnectionDetails["AZURE_VAULT_URL"] = "https://myvault.vault.azure.net"
+	})
+
+	t.Run("azure kv - success with client secret", func(t *testing.T) {
+		err := ValidateConnectionDetails(ctx, context, azureSecuritySpec, ns)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", azureSecuritySpec.KeyManagementService.ConnectionDetails["AZURE_CLIENT_SECRET"])
+	})
+
+	t.Run("azure kv - workload identity cannot use a token", func(t *testing.T) {
+		azureSecuritySpec.KeyManagementService.ConnectionDetails["AZURE_AUTH_METHOD"] = "workload-identity"
+		err := ValidateConnectionDetails(ctx, context, azureSecuritySpec, ns)
+		assert.EqualError(t, err, "failed to validate azure key vault connection details: the workload-identity auth method cannot be used with the tokenSecretName")
+	})
+
+	t.Run("azure kv - success with workload identity", func(t *testing.T) {
+		azureSecuritySpec.KeyManagementService.TokenSecretName = ""
+		delete(azureSecuritySpec.KeyManagementService.ConnectionDetails, "AZURE_CLIENT_SECRET")
+		err := ValidateConnectionDetails(ctx, context, azureSecuritySpec, ns)
+		assert.NoError(t, err)
+	})
+
+	t.Run("azure kv - unknown auth method", func(t *testing.T) {
+		azureSecuritySpec.KeyManagementService.ConnectionDetails["AZURE_AUTH_METHOD"] = "certificate"
+		azureSecuritySpec.KeyManagementService.TokenSecretName = "azure-secret"
+		err := ValidateConnectionDetails(ctx, context, azureSecuritySpec, ns)
+		assert.EqualError(t, err, "failed to validate azure key vault connection details: unsupported azure auth method \"certificate\"")
+	})
+
+	kmipSecuritySpec := &cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{
+		ConnectionDetails: map[string]string{"KMS_PROVIDER": TypeKMIP},
+		TokenSecretName:   "kmip-certs",
+	}}
+	cert, key := kmipTestCertificate(t)
+	kmipSecret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kmip-certs", Namespace: ns}, Data: map[string][]byte{
+		"CA_CERT":     []byte(cert),
+		"CLIENT_CERT": []byte(cert),
+	}}
+
+	t.Run("kmip - certificate missing in the token", func(t *testing.T) {
+		_, err := context.Clientset.CoreV1().Secrets(ns).Create(ctx, kmipSecret, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		err = ValidateConnectionDetails(ctx, context, kmipSecuritySpec, ns)
+		assert.EqualError(t, err, "failed to read k8s kms secret \"CLIENT_KEY\" key \"kmip-certs\" (not found or empty)")
+		kmipSecret.Data["CLIENT_KEY"] = []byte(key)
+		_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, kmipSecret, metav1.UpdateOptions{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("kmip - no endpoint", func(t *testing.T) {
+		err := ValidateConnectionDetails(ctx, context, kmipSecuritySpec, ns)
+		assert.EqualError(t, err, "failed to validate kms config \"KMIP_ENDPOINT\". cannot be empty")
+		kmipSecuritySpec.KeyManagementService.ConnectionDetails["KMIP_ENDPOINT"] = "kmip.example.com:5696"
+	})
+
+	t.Run("kmip - success", func(t *testing.T) {
+		err := ValidateConnectionDetails(ctx, context, kmipSecuritySpec, ns)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(key), kmipSecuritySpec.KeyManagementService.ConnectionDetails["KMIP_CLIENT_KEY"])
+	})
 }
 
-func TestSetTokenToEnvVar(t *testing.T) {
+func TestLoadToken(t *testing.T) {
 	ctx := context.TODO()
 	context := &clusterd.Context{Clientset: test.New(t, 3)}
 	secretName := "vault-secret"
@@ -206,13 +296,49 @@ func TestSetTokenToEnvVar(t *testing.T) {
 			Name:      secretName,
 			Namespace: ns,
 		},
-		Data: map[string][]byte{"token": []byte("toto")},
+		Data: map[string][]byte{"token": []byte("toto"), "IBM_KP_SERVICE_API_KEY": []byte("foo\n")},
 	}
 	_, err := context.Clientset.CoreV1().Secrets(ns).Create(ctx, s, metav1.CreateOptions{})
 	assert.NoError(t, err)
 
-	err = SetTokenToEnvVar(ctx, context, secretName, "vault", ns)
+	// no token
+	os.Unsetenv("VAULT_TOKEN")
+	kmsSpec := &cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{"KMS_PROVIDER": "vault"}}
+	err = LoadToken(ctx, context, kmsSpec, ns)
+	assert.NoError(t, err)
+	assert.Equal(t, "", os.Getenv("VAULT_TOKEN"))
+
+	// vault sets the token as an env variable
+	kmsSpec.TokenSecretName = secretName
+	err = LoadToken(ctx, context, kmsSpec, ns)
 	assert.NoError(t, err)
 	assert.Equal(t, os.Getenv("VAULT_TOKEN"), "toto")
+	assert.NotContains(t, kmsSpec.ConnectionDetails, "VAULT_TOKEN")
 	os.Unsetenv("VAULT_TOKEN")
+
+	// the approle auth sets the role and secret ids as env variables
+	s.Data = map[string][]byte{"role-id": []byte("role\n"), "secret-id": []byte("secret")}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, s, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	kmsSpec.ConnectionDetails["VAULT_AUTH_METHOD"] = "approle"
+	err = LoadToken(ctx, context, kmsSpec, ns)
+	assert.NoError(t, err)
+	assert.Equal(t, "role", os.Getenv("VAULT_ROLE_ID"))
+	assert.Equal(t, "secret", os.Getenv("VAULT_SECRET_ID"))
+	os.Unsetenv("VAULT_ROLE_ID")
+	os.Unsetenv("VAULT_SECRET_ID")
+	delete(kmsSpec.ConnectionDetails, "VAULT_AUTH_METHOD")
+	s.Data = map[string][]byte{"token": []byte("toto"), "IBM_KP_SERVICE_API_KEY": []byte("foo\n")}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, s, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	// the other providers append the token to the connection details
+	kmsSpec.ConnectionDetails["KMS_PROVIDER"] = TypeIBM
+	err = LoadToken(ctx, context, kmsSpec, ns)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", kmsSpec.ConnectionDetails["IBM_KP_SERVICE_API_KEY"])
+
+	kmsSpec.ConnectionDetails["KMS_PROVIDER"] = "foo"
+	err = LoadToken(ctx, context, kmsSpec, ns)
+	assert.Error(t, err)
 }
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Backend stores the encryption keys in a KMS. The keys are named after their consumer, e.g. the
+// PVC of an OSD, each backend maps the name to the naming rules of the KMS.
+type Backend interface {
+	// GetKey returns the value of a key
+	GetKey(ctx context.Context, name string) (string, error)
+	// PutKey stores a key, an existing key is never overwritten
+	PutKey(ctx context.Context, name, value string) error
+	// DeleteKey deletes a key
+	DeleteKey(ctx context.Context, name string) error
+	// RotateKey replaces the value of an existing key and returns the version of the new value
+	RotateKey(ctx context.Context, name, value string) (string, error)
+	// KeyVersion returns the version of a key, or an empty string when the KMS does not version keys
+	KeyVersion(ctx context.Context, name string) (string, error)
+}
+
+// ProviderPlugin is a KMS provider. Only NewBackend and Validate are mandatory, the other fields
+// describe how the operator and the pods reach the KMS when they are set.
+type ProviderPlugin struct {
+	// NewBackend returns a client of the KMS configured by the connection details
+	NewBackend func(c *Config) (Backend, error)
+	// Validate validates the connection details, the values of the token secret are already loaded
+	Validate func(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error
+	// TokenDetails maps the keys of the token secret to the connection details they are loaded as,
+	// the same names are used for the env variables of the pods
+	TokenDetails map[string]string
+	// TokenDetailsOf returns the TokenDetails of the auth method configured in the connection details
+	// when the token secret depends on it
+	TokenDetailsOf func(kmsSpec *cephv1.KeyManagementServiceSpec) map[string]string
+	// TokenOptional returns whether the KMS is reached without a token secret, e.g. with the
+	// service account of the pods
+	TokenOptional func(kmsSpec *cephv1.KeyManagementServiceSpec) bool
+	// LoadToken loads the values of the token secret, by default they are appended to the connection
+	// details
+	LoadToken func(kmsSpec *cephv1.KeyManagementServiceSpec, token map[string][]byte) error
+	// EnvVars returns the env variables of the pods besides the connection details and the token, as
+	// well as the connection details they replace
+	EnvVars func(kmsSpec *cephv1.KeyManagementServiceSpec) ([]v1.EnvVar, []string)
+	// VolumesAndMounts returns the volumes and volume mounts of the pods reaching the KMS
+	VolumesAndMounts func(kmsSpec *cephv1.KeyManagementServiceSpec) ([]v1.Volume, []v1.VolumeMount)
+	// CheckConnection checks that the KMS can be reached and accepts the credentials of the operator,
+	// the values of the token secret are already loaded
+	CheckConnection func(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error
+}
+
+var (
+	providersMutex sync.RWMutex
+	providers      = map[string]*ProviderPlugin{}
+)
+
+// RegisterProvider registers a KMS provider, the providers register themselves when the package is
+// initialized
+func RegisterProvider(name string, plugin *ProviderPlugin) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	if plugin == nil || plugin.NewBackend == nil || plugin.Validate == nil {
+		panic(errors.Errorf("invalid kms provider %q", name))
+	}
+	if _, ok := providers[name]; ok {
+		panic(errors.Errorf("kms provider %q is already registered", name))
+	}
+	providers[name] = plugin
+}
+
+// RegisteredProviders returns the names of the registered KMS providers
+func RegisteredProviders() []string {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getProvider(name string) (*ProviderPlugin, bool) {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+	plugin, ok := providers[name]
+	return plugin, ok
+}
+
+// providerOf returns the provider configured in the KMS spec
+func providerOf(kmsSpec *cephv1.KeyManagementServiceSpec) (*ProviderPlugin, error) {
+	name := GetParam(kmsSpec.ConnectionDetails, Provider)
+	plugin, ok := getProvider(name)
+	if !ok {
+		return nil, errors.Errorf("kms provider %q not supported, the supported providers are %s", name, strings.Join(RegisteredProviders(), ", "))
+	}
+	return plugin, nil
+}
+
+// isTokenRequired returns whether the provider requires a token secret
+func (p *ProviderPlugin) isTokenRequired(kmsSpec *cephv1.KeyManagementServiceSpec) bool {
+	return p.TokenOptional == nil || !p.TokenOptional(kmsSpec)
+}
+
+// tokenDetails returns the keys of the token secret mapped to the connection details they are
+// loaded as
+func (p *ProviderPlugin) tokenDetails(kmsSpec *cephv1.KeyManagementServiceSpec) map[string]string {
+	if p.TokenDetailsOf != nil {
+		return p.TokenDetailsOf(kmsSpec)
+	}
+	return p.TokenDetails
+}
+
+// loadToken loads the values of the token secret
+func (p *ProviderPlugin) loadToken(kmsSpec *cephv1.KeyManagementServiceSpec, token map[string][]byte) error {
+	for _, secretKey := range p.tokenSecretKeys(kmsSpec) {
+		v, ok := token[secretKey]
+		if !ok || len(v) == 0 {
+			return errors.Errorf("failed to read k8s kms secret %q key %q (not found or empty)", secretKey, kmsSpec.TokenSecretName)
+		}
+	}
+	if p.LoadToken != nil {
+		return p.LoadToken(kmsSpec, token)
+	}
+
+	for secretKey, config := range p.tokenDetails(kmsSpec) {
+		// Append the token secret details to the connection details
+		kmsSpec.ConnectionDetails[config] = strings.TrimSpace(string(token[secretKey]))
+	}
+	return nil
+}
+
+// tokenSecretKeys returns the sorted keys of the token secret
+func (p *ProviderPlugin) tokenSecretKeys(kmsSpec *cephv1.KeyManagementServiceSpec) []string {
+	details := p.tokenDetails(kmsSpec)
+	keys := make([]string, 0, len(details))
+	for secretKey := range details {
+		keys = append(keys, secretKey)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBackend stores versioned keys in memory
+type fakeBackend struct {
+	keys     map[string][]string
+	versions map[string]int
+}
+
+func (b *fakeBackend) GetKey(ctx context.Context, name string) (string, error) {
+	values, ok := b.keys[name]
+	if !ok {
+		return "", errors.Errorf("key %q not found", name)
+	}
+	return values[len(values)-1], nil
+}
+
+func (b *fakeBackend) PutKey(ctx context.Context, name, value string) error {
+	if _, ok := b.keys[name]; !ok {
+		b.keys[name] = []string{value}
+	}
+	return nil
+}
+
+func (b *fakeBackend) DeleteKey(ctx context.Context, name string) error {
+	delete(b.keys, name)
+	return nil
+}
+
+func (b *fakeBackend) RotateKey(ctx context.Context, name, value string) (string, error) {
+	if _, ok := b.keys[name]; !ok {
+		return "", errors.Errorf("key %q not found", name)
+	}
+	b.keys[name] = append(b.keys[name], value)
+	return b.KeyVersion(ctx, name)
+}
+
+func (b *fakeBackend) KeyVersion(ctx context.Context, name string) (string, error) {
+	return fmt.Sprintf("%d", len(b.keys[name])), nil
+}
+
+func TestRegisterProvider(t *testing.T) {
+	assert.Equal(t, []string{"azure-kv", "ibmkeyprotect", "kmip", "vault"}, RegisteredProviders())
+
+	assert.Panics(t, func() { RegisterProvider(TypeKMIP, &ProviderPlugin{}) })
+	assert.Panics(t, func() {
+		RegisterProvider(TypeKMIP, &ProviderPlugin{NewBackend: providers[TypeKMIP].NewBackend, Validate: providers[TypeKMIP].Validate})
+	})
+
+	_, err := providerOf(&cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{Provider: "foo"}})
+	assert.EqualError(t, err, `kms provider "foo" not supported, the supported providers are azure-kv, ibmkeyprotect, kmip, vault`)
+}
+
+func TestProviderBackend(t *testing.T) {
+	backend := &fakeBackend{keys: map[string][]string{}}
+	RegisterProvider("fake", &ProviderPlugin{
+		NewBackend: func(c *Config) (Backend, error) {
+			if GetParam(c.clusterSpec.Security.KeyManagementService.ConnectionDetails, "FAKE_ADDR") == "" {
+				return nil, errors.New("FAKE_ADDR not set")
+			}
+			return backend, nil
+		},
+		Validate: func(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error {
+			return nil
+		},
+	})
+	defer delete(providers, "fake")
+
+	ctx := context.TODO()
+	securitySpec := &cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{Provider: "fake"}}}
+	// the provider does not require a token, unless it says otherwise
+	err := ValidateConnectionDetails(ctx, &clusterd.Context{}, securitySpec, "ns")
+	assert.EqualError(t, err, "failed to validate kms configuration (missing token in spec)")
+	providers["fake"].TokenOptional = func(kmsSpec *cephv1.KeyManagementServiceSpec) bool { return true }
+	err = ValidateConnectionDetails(ctx, &clusterd.Context{}, securitySpec, "ns")
+	assert.NoError(t, err)
+
+	clusterSpec := &cephv1.ClusterSpec{Security: *securitySpec}
+	c := NewConfig(&clusterd.Context{}, clusterSpec, cephclient.AdminTestClusterInfo("ns"))
+	assert.Equal(t, "fake", c.Provider)
+	err = c.PutSecret("set1-data-0", "kek")
+	assert.EqualError(t, err, `failed to init "fake" kms: FAKE_ADDR not set`)
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["FAKE_ADDR"] = "fake:1234"
+
+	assert.NoError(t, c.PutSecret("set1-data-0", "kek"))
+	assert.NoError(t, c.PutSecret("set1-data-0", "other"))
+	value, err := c.GetSecret("set1-data-0")
+	assert.NoError(t, err)
+	assert.Equal(t, "kek", value)
+	version, err := c.SecretVersion("set1-data-0")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", version)
+
+	version, err = c.RotateSecret("set1-data-0", "new-kek")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", version)
+	value, err = c.GetSecret("set1-data-0")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-kek", value)
+	_, err = c.RotateSecret("set1-data-1", "new-kek")
+	assert.EqualError(t, err, `failed to rotate secret in "fake" kms: key "set1-data-1" not found`)
+
+	assert.NoError(t, c.DeleteSecret("set1-data-0"))
+	assert.Empty(t, backend.keys)
+
+	clusterSpec.Security.KeyManagementService.ConnectionDetails[Provider] = "foo"
+	c = NewConfig(&clusterd.Context{}, clusterSpec, cephclient.AdminTestClusterInfo("ns"))
+	assert.EqualError(t, c.PutSecret("set1-data-0", "kek"), `unsupported kms type ""`)
+}
+
+func TestCheckTokenEnvVars(t *testing.T) {
+	kmsSpec := &cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{Provider: TypeIBM}}
+	assert.EqualError(t, CheckTokenEnvVars(kmsSpec), `kms "IBM_KP_SERVICE_API_KEY" environment variable is not set`)
+	os.Setenv(IbmKeyProtectServiceApiKey, "foo")
+	defer os.Unsetenv(IbmKeyProtectServiceApiKey)
+	assert.NoError(t, CheckTokenEnvVars(kmsSpec))
+
+	// the token is optional with the vault kubernetes auth and azure workload identity
+	kmsSpec.ConnectionDetails = map[string]string{Provider: "vault"}
+	assert.EqualError(t, CheckTokenEnvVars(kmsSpec), `kms "VAULT_TOKEN" environment variable is not set`)
+	kmsSpec.ConnectionDetails["VAULT_AUTH_METHOD"] = "kubernetes"
+	assert.NoError(t, CheckTokenEnvVars(kmsSpec))
+	kmsSpec.ConnectionDetails = map[string]string{Provider: TypeAzure, AzureAuthMethodKey: AzureAuthMethodWorkloadIdentity}
+	assert.NoError(t, CheckTokenEnvVars(kmsSpec))
+}
+
+func TestVolumesAndMounts(t *testing.T) {
+	kmsSpec := &cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{Provider: "vault"}}
+	volumes, mounts := VolumesAndMounts(kmsSpec)
+	assert.Empty(t, volumes)
+	assert.Empty(t, mounts)
+
+	kmsSpec.ConnectionDetails["VAULT_CACERT"] = "vault-ca"
+	volumes, mounts = VolumesAndMounts(kmsSpec)
+	assert.Len(t, volumes, 1)
+	assert.Equal(t, "vault", volumes[0].Name)
+	assert.Equal(t, "/etc/vault", mounts[0].MountPath)
+
+	kmsSpec.ConnectionDetails = map[string]string{Provider: TypeAzure, AzureAuthMethodKey: AzureAuthMethodWorkloadIdentity}
+	volumes, mounts = VolumesAndMounts(kmsSpec)
+	assert.Len(t, volumes, 1)
+	assert.Equal(t, AzureTokenDir, mounts[0].MountPath)
+
+	kmsSpec.ConnectionDetails = map[string]string{Provider: TypeKMIP}
+	volumes, _ = VolumesAndMounts(kmsSpec)
+	assert.Empty(t, volumes)
+}
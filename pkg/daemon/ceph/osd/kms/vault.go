@@ -18,8 +18,10 @@ package kms
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/hashicorp/vault/api"
@@ -28,7 +30,8 @@ import (
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -54,6 +57,22 @@ var (
 
 type removeCertFilesFunction func()
 
+func init() {
+	RegisterProvider(secrets.TypeVault, &ProviderPlugin{
+		NewBackend:     newVaultBackend,
+		Validate:       validateVault,
+		TokenDetails:   map[string]string{KMSTokenSecretNameKey: api.EnvVaultToken},
+		TokenDetailsOf: vaultTokenDetails,
+		TokenOptional: func(kmsSpec *cephv1.KeyManagementServiceSpec) bool {
+			return kmsSpec.IsK8sAuthEnabled()
+		},
+		LoadToken:        setVaultTokenToEnvVar,
+		EnvVars:          vaultEnvVars,
+		VolumesAndMounts: vaultVolumesAndMounts,
+		CheckConnection:  checkVaultConnection,
+	})
+}
+
 /* VAULT API INTERNAL VALUES
 // Refer to https://pkg.golangclub.com/github.com/hashicorp/vault/api?tab=doc#pkg-constants
    const EnvVaultAddress = "VAULT_ADDR"
@@ -97,6 +116,15 @@ func InitVault(context *clusterd.Context, namespace string, config map[string]st
 		c[key] = string(value)
 	}
 
+	// The secrets lib does not support the AppRole auth, so it is given the token of the login
+	if GetParam(config, vault.AuthMethod) == cephv1.VaultAuthMethodAppRole {
+		client, err := vaultClient(context, namespace, config)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to log in vault")
+		}
+		c[api.EnvVaultToken] = client.Token()
+	}
+
 	// Initialize Vault
 	v, err := vault.New(c)
 	if err != nil {
@@ -138,7 +166,7 @@ func configTLS(clusterdContext *clusterd.Context, namespace string, config map[s
 		}
 		// If the string already has the correct path /etc/vault, we are in provisioner code and all the envs have been populated by the op already
 		if !strings.Contains(tlsSecretName, EtcVaultDir) {
-			secret, err := clusterdContext.Clientset.CoreV1().Secrets(namespace).Get(ctx, tlsSecretName, v1.GetOptions{})
+			secret, err := clusterdContext.Clientset.CoreV1().Secrets(namespace).Get(ctx, tlsSecretName, metav1.GetOptions{})
 			if err != nil {
 				return nil, removeCertFiles, errors.Wrapf(err, "failed to fetch tls k8s secret %q", tlsSecretName)
 			}
@@ -248,6 +276,176 @@ func (c *Config) IsVault() bool {
 	return c.Provider == secrets.TypeVault
 }
 
+// vaultBackend stores the keys in the kv secret engine of Vault
+type vaultBackend struct {
+	client     secrets.Secrets
+	keyContext map[string]string
+	config     map[string]string
+	context    *clusterd.Context
+	namespace  string
+}
+
+func newVaultBackend(c *Config) (Backend, error) {
+	config := c.clusterSpec.Security.KeyManagementService.ConnectionDetails
+	v, err := InitVault(c.context, c.ClusterInfo.Namespace, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vaultBackend{
+		client:     v,
+		keyContext: buildVaultKeyContext(config),
+		config:     config,
+		context:    c.context,
+		namespace:  c.ClusterInfo.Namespace,
+	}, nil
+}
+
+func (b *vaultBackend) GetKey(ctx context.Context, name string) (string, error) {
+	return get(b.client, GenerateOSDEncryptionSecretName(name), b.keyContext)
+}
+
+func (b *vaultBackend) PutKey(ctx context.Context, name, value string) error {
+	return put(b.client, GenerateOSDEncryptionSecretName(name), value, b.keyContext)
+}
+
+func (b *vaultBackend) DeleteKey(ctx context.Context, name string) error {
+	keyContext := buildVaultKeyContext(b.config)
+	// Force removal of all the versions of the secret on K/V version 2
+	keyContext[secrets.DestroySecret] = "true"
+
+	return deleteSecret(b.client, GenerateOSDEncryptionSecretName(name), keyContext)
+}
+
+// RotateKey writes the new value of the secret, which is a new version of the secret on K/V version 2
+func (b *vaultBackend) RotateKey(ctx context.Context, name, value string) (string, error) {
+	secretName := GenerateOSDEncryptionSecretName(name)
+	if _, err := get(b.client, secretName, b.keyContext); err != nil {
+		return "", errors.Wrapf(err, "failed to get secret %q in vault", secretName)
+	}
+
+	// #nosec G104 Write the encryption key in Vault
+	err := b.client.PutSecret(secretName, map[string]interface{}{secretName: value}, b.keyContext)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to put secret %q in vault", secretName)
+	}
+
+	return b.KeyVersion(ctx, name)
+}
+
+// KeyVersion returns the current version of the secret, only K/V version 2 versions the secrets
+func (b *vaultBackend) KeyVersion(ctx context.Context, name string) (string, error) {
+	switch GetParam(b.config, vault.VaultBackendKey) {
+	case "v2", kvVersion2:
+	default:
+		return "", nil
+	}
+
+	client, err := vaultClient(b.context, b.namespace, b.config)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to initialize vault client")
+	}
+	backendPath := GetParam(b.config, vault.VaultBackendPathKey)
+	if backendPath == "" {
+		backendPath = vault.DefaultBackendPath
+	}
+	secretName := GenerateOSDEncryptionSecretName(name)
+	metadata, err := client.Logical().Read(path.Join(backendPath, "metadata", secretName))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read metadata of secret %q in vault", secretName)
+	}
+	if metadata == nil {
+		return "", errors.Errorf("secret %q not found in vault", secretName)
+	}
+	version, ok := metadata.Data["current_version"]
+	if !ok {
+		return "", errors.Errorf("metadata of secret %q has no version", secretName)
+	}
+
+	return fmt.Sprint(version), nil
+}
+
+// vaultTokenDetails returns the keys of the token secret of the auth method, the token secret holds
+// the role ID and the secret ID with the AppRole auth
+func vaultTokenDetails(kmsSpec *cephv1.KeyManagementServiceSpec) map[string]string {
+	if kmsSpec.IsAppRoleAuthEnabled() {
+		return map[string]string{vaultRoleIDSecretKeyName: EnvVaultRoleID, vaultSecretIDSecretKeyName: EnvVaultSecretID}
+	}
+	return map[string]string{KMSTokenSecretNameKey: api.EnvVaultToken}
+}
+
+// setVaultTokenToEnvVar sets the token as an env variable, the secrets lib picks it up
+func setVaultTokenToEnvVar(kmsSpec *cephv1.KeyManagementServiceSpec, token map[string][]byte) error {
+	for secretKey, env := range vaultTokenDetails(kmsSpec) {
+		err := os.Setenv(env, strings.TrimSpace(string(token[secretKey])))
+		if err != nil {
+			return errors.Wrapf(err, "failed to set vault kms %q to an env var", secretKey)
+		}
+	}
+
+	return nil
+}
+
+// checkVaultConnection logs in Vault and looks up the token, which every token is allowed to by the
+// default policy
+func checkVaultConnection(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error {
+	client, err := vaultClient(clusterdContext, ns, kmsSpec.ConnectionDetails)
+	if err != nil {
+		return errors.Wrapf(err, "failed to log in vault %q", GetParam(kmsSpec.ConnectionDetails, api.EnvVaultAddress))
+	}
+	if _, err := client.Auth().Token().LookupSelf(); err != nil {
+		return errors.Wrapf(err, "failed to look up the token of vault %q", GetParam(kmsSpec.ConnectionDetails, api.EnvVaultAddress))
+	}
+
+	return nil
+}
+
+func validateVault(ctx context.Context, clusterdContext *clusterd.Context, kmsSpec *cephv1.KeyManagementServiceSpec, ns string) error {
+	err := validateVaultConnectionDetails(clusterdContext, ns, kmsSpec.ConnectionDetails)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate vault connection details")
+	}
+
+	secretEngine := kmsSpec.ConnectionDetails[VaultSecretEngineKey]
+	switch secretEngine {
+	case VaultKVSecretEngineKey:
+		// Append Backend Version if not already present
+		if GetParam(kmsSpec.ConnectionDetails, vault.VaultBackendKey) == "" {
+			backendVersion, err := BackendVersion(clusterdContext, ns, kmsSpec.ConnectionDetails)
+			if err != nil {
+				return errors.Wrap(err, "failed to get backend version")
+			}
+			kmsSpec.ConnectionDetails[vault.VaultBackendKey] = backendVersion
+		}
+	}
+
+	return nil
+}
+
+// vaultEnvVars translates the TLS connection details to the paths of the files mounted in the pods
+func vaultEnvVars(kmsSpec *cephv1.KeyManagementServiceSpec) ([]v1.EnvVar, []string) {
+	backendPath := GetParam(kmsSpec.ConnectionDetails, vault.VaultBackendPathKey)
+	// Set BACKEND_PATH to the API's default if not passed
+	if backendPath == "" {
+		kmsSpec.ConnectionDetails[vault.VaultBackendPathKey] = vault.DefaultBackendPath
+	}
+
+	// Skip TLS and token env var to avoid env being set multiple times
+	return vaultTLSEnvVarFromSecret(kmsSpec.ConnectionDetails), append(cephv1.VaultTLSConnectionDetails, api.EnvVaultToken, EnvVaultRoleID, EnvVaultSecretID)
+}
+
+// vaultVolumesAndMounts returns the TLS secrets volume. We don't need to pass the Volume with
+// projection for TLS when TLS is not enabled. Somehow when this happens and we try to update a
+// deployment spec it fails with:
+//  ValidationError(Pod.spec.volumes[7].projected): missing required field "sources"
+func vaultVolumesAndMounts(kmsSpec *cephv1.KeyManagementServiceSpec) ([]v1.Volume, []v1.VolumeMount) {
+	if !kmsSpec.IsTLSEnabled() {
+		return nil, nil
+	}
+	volume, volumeMount := VaultVolumeAndMount(kmsSpec.ConnectionDetails, "")
+	return []v1.Volume{volume}, []v1.VolumeMount{volumeMount}
+}
+
 func validateVaultConnectionDetails(clusterdContext *clusterd.Context, ns string, kmsConfig map[string]string) error {
 	ctx := context.TODO()
 	for _, option := range vaultMandatoryConnectionDetails {
@@ -267,7 +465,7 @@ func validateVaultConnectionDetails(clusterdContext *clusterd.Context, ns string
 		tlsSecretName := GetParam(kmsConfig, tlsOption)
 		if tlsSecretName != "" {
 			// Fetch the secret
-			s, err := clusterdContext.Clientset.CoreV1().Secrets(ns).Get(ctx, tlsSecretName, v1.GetOptions{})
+			s, err := clusterdContext.Clientset.CoreV1().Secrets(ns).Get(ctx, tlsSecretName, metav1.GetOptions{})
 			if err != nil {
 				return errors.Errorf("failed to find TLS connection details k8s secret %q", tlsSecretName)
 			}
@@ -17,11 +17,13 @@ limitations under the License.
 package kms
 
 import (
+	"path"
 	"strings"
 
 	"github.com/libopenstorage/secrets/vault"
 	"github.com/libopenstorage/secrets/vault/utils"
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 
 	"github.com/hashicorp/vault/api"
@@ -31,6 +33,13 @@ const (
 	kvVersionKey = "version"
 	kvVersion1   = "kv"
 	kvVersion2   = "kv-v2"
+
+	// EnvVaultRoleID is the role ID of the Vault AppRole auth
+	EnvVaultRoleID = "VAULT_ROLE_ID"
+	// EnvVaultSecretID is the secret ID of the Vault AppRole auth
+	EnvVaultSecretID = "VAULT_SECRET_ID"
+	// vaultAppRoleMountPath is the default mount path of the Vault AppRole auth
+	vaultAppRoleMountPath = "approle"
 )
 
 // vaultClient returns a vault client, also used in unit tests to mock the client
@@ -89,9 +98,9 @@ func newVaultClient(clusterdContext *clusterd.Context, namespace string, secretC
 		client.SetNamespace(secretConfig[api.EnvVaultNamespace])
 	}
 
-	// Configure the authentication method, either Token or Kubernetes.
-	// Both return a token
-	token, _, err := utils.Authenticate(client, c)
+	// Configure the authentication method, either Token, Kubernetes or AppRole.
+	// All return a token
+	token, err := vaultAuthenticate(client, c)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get vault authentication token")
 	}
@@ -103,6 +112,44 @@ func newVaultClient(clusterdContext *clusterd.Context, namespace string, secretC
 	return client, nil
 }
 
+// vaultAuthenticate returns the token of the auth method configured in the connection details
+func vaultAuthenticate(client *api.Client, config map[string]interface{}) (string, error) {
+	// The AppRole auth is not supported by the secrets lib, the client logs in by itself. The auth
+	// method is checked first since a token of another cluster may be set in the env.
+	if utils.GetVaultParam(config, utils.AuthMethod) == cephv1.VaultAuthMethodAppRole {
+		return appRoleLogin(client, config)
+	}
+
+	token, _, err := utils.Authenticate(client, config)
+	return token, err
+}
+
+// appRoleLogin logs in Vault with the role ID and the secret ID of the AppRole auth
+func appRoleLogin(client *api.Client, config map[string]interface{}) (string, error) {
+	roleID := utils.GetVaultParam(config, EnvVaultRoleID)
+	secretID := utils.GetVaultParam(config, EnvVaultSecretID)
+	if roleID == "" || secretID == "" {
+		return "", errors.Errorf("%q and %q must be set with the vault approle auth", EnvVaultRoleID, EnvVaultSecretID)
+	}
+	mountPath := utils.GetVaultParam(config, utils.AuthMountPath)
+	if mountPath == "" {
+		mountPath = vaultAppRoleMountPath
+	}
+
+	secret, err := client.Logical().Write(path.Join("auth", mountPath, "login"), map[string]interface{}{
+		"role_id":   roleID,
+		"secret_id": secretID,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to log in vault with the approle auth")
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return "", errors.New("vault approle login returned no token")
+	}
+
+	return secret.Auth.ClientToken, nil
+}
+
 func BackendVersion(clusterdContext *clusterd.Context, namespace string, secretConfig map[string]string) (string, error) {
 	v1 := "v1"
 	v2 := "v2"
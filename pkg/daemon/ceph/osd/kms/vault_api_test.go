@@ -18,6 +18,9 @@ package kms
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	kv "github.com/hashicorp/vault-plugin-secrets-kv"
@@ -26,6 +29,7 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
 	"github.com/libopenstorage/secrets/vault/utils"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
@@ -199,3 +203,74 @@ cjLxGL8tcZjHKg==
 	err = utils.ConfigureTLS(config, c)
 	assert.NoError(t, err)
 }
+
+func TestVaultAuthenticate(t *testing.T) {
+	logins := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/approle/login", "/v1/auth/rook/login":
+			body := map[string]interface{}{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			body["namespace"] = r.Header.Get("X-Vault-Namespace")
+			logins = append(logins, body)
+			_, err := w.Write([]byte(`{"auth":{"client_token":"approle-token"}}`))
+			assert.NoError(t, err)
+		case "/v1/auth/token/lookup-self":
+			if r.Header.Get("X-Vault-Token") != "approle-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, err := w.Write([]byte(`{"data":{"id":"approle-token"}}`))
+			assert.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	secretConfig := map[string]string{
+		"KMS_PROVIDER":      "vault",
+		"VAULT_ADDR":        server.URL,
+		"VAULT_AUTH_METHOD": "approle",
+		"VAULT_NAMESPACE":   "team-a",
+	}
+	t.Run("approle without the ids", func(t *testing.T) {
+		_, err := newVaultClient(&clusterd.Context{}, "ns", secretConfig)
+		assert.EqualError(t, err, `failed to get vault authentication token: "VAULT_ROLE_ID" and "VAULT_SECRET_ID" must be set with the vault approle auth`)
+	})
+
+	secretConfig[EnvVaultRoleID] = "role"
+	secretConfig[EnvVaultSecretID] = "secret"
+	t.Run("approle login", func(t *testing.T) {
+		// the approle auth takes precedence over a token of another cluster
+		secretConfig["VAULT_TOKEN"] = "foo"
+		defer delete(secretConfig, "VAULT_TOKEN")
+		client, err := newVaultClient(&clusterd.Context{}, "ns", secretConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, "approle-token", client.Token())
+		assert.Equal(t, map[string]interface{}{"role_id": "role", "secret_id": "secret", "namespace": "team-a"}, logins[0])
+	})
+
+	t.Run("approle custom mount path", func(t *testing.T) {
+		secretConfig["VAULT_AUTH_MOUNT_PATH"] = "rook"
+		defer delete(secretConfig, "VAULT_AUTH_MOUNT_PATH")
+		_, err := newVaultClient(&clusterd.Context{}, "ns", secretConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(logins))
+	})
+
+	t.Run("check connection", func(t *testing.T) {
+		vaultClient = newVaultClient
+		kmsSpec := &cephv1.KeyManagementServiceSpec{ConnectionDetails: secretConfig}
+		assert.NoError(t, CheckConnection(context.TODO(), &clusterd.Context{}, kmsSpec, "ns"))
+
+		kmsSpec.ConnectionDetails = map[string]string{"KMS_PROVIDER": "vault", "VAULT_ADDR": server.URL, "VAULT_TOKEN": "foo"}
+		err := CheckConnection(context.TODO(), &clusterd.Context{}, kmsSpec, "ns")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to look up the token of vault")
+
+		// the providers without a check are assumed to be reachable
+		kmsSpec.ConnectionDetails = map[string]string{"KMS_PROVIDER": TypeKMIP}
+		assert.NoError(t, CheckConnection(context.TODO(), &clusterd.Context{}, kmsSpec, "ns"))
+	})
+}
@@ -19,6 +19,7 @@ package kms
 import (
 	"github.com/hashicorp/vault/api"
 	"github.com/libopenstorage/secrets"
+	"github.com/libopenstorage/secrets/vault"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	v1 "k8s.io/api/core/v1"
 )
@@ -28,6 +29,10 @@ const (
 	vaultCACertSecretKeyName = "cert"
 	vaultKeySecretKeyName    = "key"
 
+	// Key names of the token Secret with the AppRole auth
+	vaultRoleIDSecretKeyName   = "role-id"
+	vaultSecretIDSecretKeyName = "secret-id"
+
 	// File names of the Secret value when mapping on the filesystem
 	VaultCAFileName   = "vault.ca"
 	VaultCertFileName = "vault.crt"
@@ -35,6 +40,10 @@ const (
 
 	// File name for token file
 	VaultFileName = "vault.token"
+
+	// File names of the role ID and the secret ID with the AppRole auth
+	VaultRoleIDFileName   = "vault.role-id"
+	VaultSecretIDFileName = "vault.secret-id"
 )
 
 // VaultSecretVolumeAndMount return the volume and matching volume mount for mounting the vault secrets into /etc/vault
@@ -59,6 +68,13 @@ func VaultSecretVolumeAndMount(kmsVaultConfigFiles map[string]string, tokenSecre
 	}
 	if tokenSecretName != "" {
 		projectionSecret := &v1.SecretProjection{Items: []v1.KeyToPath{{Key: KMSTokenSecretNameKey, Path: VaultFileName, Mode: &mode}}}
+		// The AppRole auth logs in with the role ID and the secret ID instead of a token
+		if GetParam(kmsVaultConfigFiles, vault.AuthMethod) == cephv1.VaultAuthMethodAppRole {
+			projectionSecret.Items = []v1.KeyToPath{
+				{Key: vaultRoleIDSecretKeyName, Path: VaultRoleIDFileName, Mode: &mode},
+				{Key: vaultSecretIDSecretKeyName, Path: VaultSecretIDFileName, Mode: &mode},
+			}
+		}
 		projectionSecret.Name = tokenSecretName
 		secretProjection := v1.VolumeProjection{Secret: projectionSecret}
 		secretVolumeProjections = append(secretVolumeProjections, secretProjection)
@@ -86,6 +102,45 @@ func VaultVolumeAndMount(kmsVaultConfigFiles map[string]string, tokenSecretName
 	return v, m
 }
 
+// AzureWorkloadIdentityVolumeAndMount returns the volume and volume mount of the service account
+// token exchanged for an Azure token when Azure Key Vault is accessed with workload identity
+func AzureWorkloadIdentityVolumeAndMount() (v1.Volume, v1.VolumeMount) {
+	expirationSeconds := int64(3600)
+	v := v1.Volume{
+		Name: TypeAzure,
+		VolumeSource: v1.VolumeSource{
+			Projected: &v1.ProjectedVolumeSource{
+				Sources: []v1.VolumeProjection{
+					{
+						ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+							Audience:          azureTokenAudience,
+							ExpirationSeconds: &expirationSeconds,
+							Path:              azureTokenFileName,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	m := v1.VolumeMount{
+		Name:      TypeAzure,
+		ReadOnly:  true,
+		MountPath: AzureTokenDir,
+	}
+
+	return v, m
+}
+
+// VolumesAndMounts returns the volumes and volume mounts needed by the pods to reach the KMS
+func VolumesAndMounts(kmsSpec *cephv1.KeyManagementServiceSpec) ([]v1.Volume, []v1.VolumeMount) {
+	plugin, err := providerOf(kmsSpec)
+	if err != nil || plugin.VolumesAndMounts == nil {
+		return nil, nil
+	}
+	return plugin.VolumesAndMounts(kmsSpec)
+}
+
 func tlsSecretPath(tlsOption string) string {
 	switch tlsOption {
 	case api.EnvVaultCACert:
@@ -0,0 +1,270 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	clientcontroller "github.com/rook/rook/pkg/operator/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/csi/staticvolume"
+	"github.com/rook/rook/pkg/operator/ceph/draction"
+	"github.com/rook/rook/pkg/operator/ceph/pool/mirrorpeer"
+	"github.com/rook/rook/pkg/operator/ceph/pool/peertoken"
+	pooltopology "github.com/rook/rook/pkg/operator/ceph/pool/topology"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// admissionValidator validates the Rook resources in the admission webhook, so the mistakes are
+// rejected when a resource is applied instead of failing its reconcile. A resource is validated by
+// its own webhook.Validator methods, then by the validation of its controller when it does not
+// depend on the ceph cluster. The resources it references, e.g. the CephFilesystem of a
+// CephFilesystemSubVolumeGroup, must exist when it is created or when the reference is changed.
+type admissionValidator struct {
+	context *clusterd.Context
+	// reader reads the referenced resources from the API server, since the manager does not cache
+	// all the kinds of resources that can be referenced
+	reader client.Reader
+}
+
+var _ admission.CustomValidator = &admissionValidator{}
+
+// reference is a resource referenced by another resource
+type reference struct {
+	kind   string
+	object client.Object
+	name   types.NamespacedName
+}
+
+func (v *admissionValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	if validator, ok := obj.(webhook.Validator); ok {
+		if err := validator.ValidateCreate(); err != nil {
+			return err
+		}
+	}
+	if err := v.validateSpec(obj); err != nil {
+		return errors.Wrap(err, "invalid create")
+	}
+	return v.validateReferences(ctx, obj, nil)
+}
+
+func (v *admissionValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	// The updates of the metadata only, e.g. of the finalizers when a resource is deleted, are not
+	// validated so the resources created before a validation was added can still be updated
+	if !specChanged(oldObj, newObj) {
+		return nil
+	}
+
+	if validator, ok := newObj.(webhook.Validator); ok {
+		if err := validator.ValidateUpdate(oldObj); err != nil {
+			return err
+		}
+	}
+	if err := v.validateSpec(newObj); err != nil {
+		return errors.Wrap(err, "invalid update")
+	}
+	return v.validateReferences(ctx, newObj, oldObj)
+}
+
+func (v *admissionValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	if validator, ok := obj.(webhook.Validator); ok {
+		return validator.ValidateDelete()
+	}
+	return nil
+}
+
+// validateSpec runs the validation of the controller of a resource that does not depend on the
+// ceph cluster
+func (v *admissionValidator) validateSpec(obj runtime.Object) error {
+	switch o := obj.(type) {
+	case *cephv1.CephClient:
+		return clientcontroller.ValidateClient(v.context, o)
+	case *cephv1.CephRBDMirror:
+		return rbd.ValidateRBDMirrorSpec(&o.Spec)
+	case *cephv1.CephBlockPoolTopology:
+		return pooltopology.ValidateBlockPoolTopology(o)
+	case *cephv1.CephCSIDriver:
+		return csi.ValidateCephCSIDriver(&o.Spec)
+	case *cephv1.CephStaticVolume:
+		return staticvolume.ValidateStaticVolume(o)
+	case *cephv1.CephRBDMirrorPeer:
+		return mirrorpeer.ValidateMirrorPeerSpec(&o.Spec)
+	case *cephv1.CephRBDMirrorPeerToken:
+		return peertoken.ValidatePeerTokenSpec(&o.Spec)
+	case *cephv1.CephDRAction:
+		return draction.ValidateDRActionSpec(&o.Spec)
+	case *cephv1.CephOperatorConfig:
+		return ValidateOperatorConfig(o)
+	}
+	return nil
+}
+
+// validateReferences returns an error if a resource referenced by a resource does not exist. Only
+// the references that are not references of the previous version of an updated resource are
+// checked, a resource can still be updated after a resource it references was deleted.
+func (v *admissionValidator) validateReferences(ctx context.Context, obj, old runtime.Object) error {
+	refs := references(obj)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	// The pools and filesystems of an external cluster are usually not managed with CRs
+	namespace := obj.(client.Object).GetNamespace()
+	clusters := &cephv1.CephClusterList{}
+	if err := v.reader.List(ctx, clusters, client.InNamespace(namespace)); err != nil {
+		logger.Warningf("failed to list the ceph clusters in namespace %q, not checking the referenced resources. %v", namespace, err)
+		return nil
+	}
+	for _, cluster := range clusters.Items {
+		if cluster.Spec.External.Enable {
+			return nil
+		}
+	}
+
+	checked := map[string]bool{}
+	if old != nil {
+		for _, ref := range references(old) {
+			checked[ref.kind+"/"+ref.name.String()] = true
+		}
+	}
+	for _, ref := range refs {
+		key := ref.kind + "/" + ref.name.String()
+		if checked[key] {
+			continue
+		}
+		checked[key] = true
+
+		err := v.reader.Get(ctx, ref.name, ref.object)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return errors.Errorf("%s %q not found in namespace %q", ref.kind, ref.name.Name, ref.name.Namespace)
+			}
+			// Do not reject the resource when the API server cannot be reached, the reconcile
+			// reports the missing resources anyway
+			logger.Warningf("failed to check that %s %q exists. %v", ref.kind, ref.name, err)
+		}
+	}
+	return nil
+}
+
+// references returns the resources referenced by a resource
+func references(obj runtime.Object) []reference {
+	namespace := obj.(client.Object).GetNamespace()
+	refs := []reference{}
+	add := func(kind string, object client.Object, name string) {
+		refs = append(refs, reference{kind: kind, object: object, name: types.NamespacedName{Namespace: namespace, Name: name}})
+	}
+	addPool := func(name string) { add("CephBlockPool", &cephv1.CephBlockPool{}, name) }
+	addFilesystem := func(name string) { add("CephFilesystem", &cephv1.CephFilesystem{}, name) }
+	addSecret := func(name string) { add("Secret", &corev1.Secret{}, name) }
+	addPeers := func(peers *cephv1.MirroringPeerSpec) {
+		if peers != nil {
+			for _, secretName := range peers.SecretNames {
+				addSecret(secretName)
+			}
+		}
+	}
+
+	switch o := obj.(type) {
+	case *cephv1.CephBlockPool:
+		addPeers(o.Spec.Mirroring.Peers)
+	case *cephv1.CephFilesystem:
+		if o.Spec.Mirroring != nil {
+			addPeers(o.Spec.Mirroring.Peers)
+		}
+	case *cephv1.CephRBDMirror:
+		addPeers(&o.Spec.Peers)
+		for _, entry := range o.Spec.Pools {
+			// the entries are "<pool>[/<namespace>]"
+			addPool(strings.SplitN(entry, "/", 2)[0])
+		}
+	case *cephv1.CephFilesystemSubVolumeGroup:
+		addFilesystem(o.Spec.FilesystemName)
+	case *cephv1.CephFilesystemSubVolume:
+		addFilesystem(o.Spec.FilesystemName)
+	case *cephv1.CephBlockPoolRadosNamespace:
+		addPool(o.Spec.BlockPoolName)
+	case *cephv1.CephObjectStore:
+		if o.Spec.Zone.Name != "" {
+			add("CephObjectZone", &cephv1.CephObjectZone{}, o.Spec.Zone.Name)
+		}
+	case *cephv1.CephObjectStoreUser:
+		if o.Spec.Store != "" {
+			add("CephObjectStore", &cephv1.CephObjectStore{}, o.Spec.Store)
+		}
+	case *cephv1.CephObjectZoneGroup:
+		add("CephObjectRealm", &cephv1.CephObjectRealm{}, o.Spec.Realm)
+	case *cephv1.CephObjectZone:
+		add("CephObjectZoneGroup", &cephv1.CephObjectZoneGroup{}, o.Spec.ZoneGroup)
+	case *cephv1.CephBucketTopic:
+		refs = append(refs, reference{kind: "CephObjectStore", object: &cephv1.CephObjectStore{}, name: types.NamespacedName{Namespace: o.Spec.ObjectStoreNamespace, Name: o.Spec.ObjectStoreName}})
+	case *cephv1.CephBucketNotification:
+		add("CephBucketTopic", &cephv1.CephBucketTopic{}, o.Spec.Topic)
+	case *cephv1.CephRBDMirrorPeer:
+		addSecret(o.Spec.SecretName)
+		for _, pool := range o.Spec.Pools {
+			addPool(pool)
+		}
+	case *cephv1.CephRBDMirrorPeerToken:
+		for _, pool := range o.Spec.Pools {
+			addPool(pool)
+		}
+	case *cephv1.CephDRAction:
+		for _, pool := range o.Spec.Pools {
+			addPool(pool)
+		}
+		for _, image := range o.Spec.Images {
+			addPool(image.Pool)
+		}
+		for _, filesystem := range o.Spec.Filesystems {
+			addFilesystem(filesystem)
+		}
+		for _, subvolume := range o.Spec.Subvolumes {
+			addFilesystem(subvolume.Filesystem)
+		}
+	case *cephv1.CephStaticVolume:
+		if o.Spec.RBD != nil {
+			addPool(o.Spec.RBD.Pool)
+		}
+		if o.Spec.CephFS != nil {
+			addFilesystem(o.Spec.CephFS.FilesystemName)
+		}
+	}
+	return refs
+}
+
+// specChanged returns whether the spec of a resource changed
+func specChanged(oldObj, newObj runtime.Object) bool {
+	oldSpec := reflect.ValueOf(oldObj).Elem().FieldByName("Spec")
+	newSpec := reflect.ValueOf(newObj).Elem().FieldByName("Spec")
+	if !oldSpec.IsValid() || !newSpec.IsValid() {
+		return true
+	}
+	return !reflect.DeepEqual(oldSpec.Interface(), newSpec.Interface())
+}
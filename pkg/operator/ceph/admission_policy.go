@@ -0,0 +1,362 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// admissionPoliciesSetting is the operator setting applying the ValidatingAdmissionPolicies of
+	// the core invariants of the CRs, for the clusters where the admission webhook cannot run
+	admissionPoliciesSetting = "ROOK_ENABLE_ADMISSION_POLICIES"
+
+	admissionPolicyGroup = "admissionregistration.k8s.io"
+	// the label of the policies with the namespace of the operator applying them
+	admissionPolicyOperatorLabel = "rook.io/operator-namespace"
+)
+
+// the versions of the ValidatingAdmissionPolicies in the order of preference, v1beta1 being served
+// since K8s 1.28 and v1 since K8s 1.30
+var admissionPolicyVersions = []string{"v1", "v1beta1"}
+
+// admissionValidation is a CEL expression that must be true for a CR to be admitted
+type admissionValidation struct {
+	expression string
+	message    string
+}
+
+// admissionPolicy is the policy validating the CRs of a resource
+type admissionPolicy struct {
+	resource    string
+	validations []admissionValidation
+}
+
+// celField returns the CEL expression of the field at the path of the root variable, or of the
+// default value when the field or one of its parents is not set
+func celField(root, path, defaultValue string) string {
+	conditions := []string{}
+	field := root
+	for _, name := range strings.Split(path, ".") {
+		field = field + "." + name
+		conditions = append(conditions, fmt.Sprintf("has(%s)", field))
+	}
+	return fmt.Sprintf("(%s ? %s : %s)", strings.Join(conditions, " && "), field, defaultValue)
+}
+
+// celUpdate returns the CEL expression of a condition only checked by the updates
+func celUpdate(condition string) string {
+	return fmt.Sprintf("oldObject == null || %s", condition)
+}
+
+// celImmutable returns the CEL expression checking that the field at the path is not changed by an
+// update
+func celImmutable(path, defaultValue string) string {
+	return celUpdate(fmt.Sprintf("%s == %s", celField("object", path, defaultValue), celField("oldObject", path, defaultValue)))
+}
+
+// celErasureCoded returns the CEL expression of whether the pool at the path is erasure coded
+func celErasureCoded(root, path string) string {
+	return fmt.Sprintf("(%s > 0 || %s > 0 || %s != '')",
+		celField(root, path+".erasureCoded.dataChunks", "0"),
+		celField(root, path+".erasureCoded.codingChunks", "0"),
+		celField(root, path+".erasureCoded.algorithm", "''"))
+}
+
+// celReplicated returns the CEL expression of whether the pool at the path is replicated
+func celReplicated(root, path string) string {
+	return fmt.Sprintf("(%s > 0 || %s > 0.0)",
+		celField(root, path+".replicated.size", "0"),
+		celField(root, path+".replicated.targetSizeRatio", "0.0"))
+}
+
+// poolValidations returns the validations of the pool at the path, the same as the admission
+// webhook: the pool is either replicated or erasure coded, the erasure coded chunks are valid and
+// the type and the erasure code profile of the pool cannot be changed
+func poolValidations(path string) []admissionValidation {
+	dataChunks := celField("object", path+".erasureCoded.dataChunks", "0")
+	codingChunks := celField("object", path+".erasureCoded.codingChunks", "0")
+	return []admissionValidation{
+		{
+			expression: fmt.Sprintf("%s || %s", celReplicated("object", path), celErasureCoded("object", path)),
+			message:    fmt.Sprintf("either of %s.erasureCoded or %s.replicated should be set", path, path),
+		},
+		{
+			expression: fmt.Sprintf("!(%s && %s)", celReplicated("object", path), celErasureCoded("object", path)),
+			message:    fmt.Sprintf("both %s.erasureCoded and %s.replicated cannot be set at the same time", path, path),
+		},
+		{
+			expression: fmt.Sprintf("%s == 0 || %s >= 2", dataChunks, dataChunks),
+			message:    fmt.Sprintf("%s.erasureCoded.dataChunks needs minimum value of 2", path),
+		},
+		{
+			expression: fmt.Sprintf("%s == 0 || %s >= 1", codingChunks, codingChunks),
+			message:    fmt.Sprintf("%s.erasureCoded.codingChunks needs minimum value of 1", path),
+		},
+		{
+			expression: celUpdate(fmt.Sprintf("!(%s && %s)", celErasureCoded("object", path), celReplicated("oldObject", path))),
+			message:    fmt.Sprintf("%s cannot be changed from replicated to erasure coded", path),
+		},
+		{
+			expression: celUpdate(fmt.Sprintf("!(%s && %s)", celReplicated("object", path), celErasureCoded("oldObject", path))),
+			message:    fmt.Sprintf("%s cannot be changed from erasure coded to replicated", path),
+		},
+		{
+			expression: celUpdate(fmt.Sprintf("!(%s && %s) || (%s == %s && %s == %s)",
+				celErasureCoded("object", path), celErasureCoded("oldObject", path),
+				dataChunks, celField("oldObject", path+".erasureCoded.dataChunks", "0"),
+				codingChunks, celField("oldObject", path+".erasureCoded.codingChunks", "0"))),
+			message: fmt.Sprintf("%s.erasureCoded chunks cannot be changed", path),
+		},
+	}
+}
+
+// coreAdmissionPolicies returns the policies of the core invariants of the CRs checked by the
+// admission webhook that can be expressed in CEL: the immutable fields and the value ranges
+func coreAdmissionPolicies() []admissionPolicy {
+	poolName := fmt.Sprintf("(%s != '' ? object.spec.name : object.metadata.name)", celField("object", "spec.name", "''"))
+	oldPoolName := fmt.Sprintf("(%s != '' ? oldObject.spec.name : oldObject.metadata.name)", celField("oldObject", "spec.name", "''"))
+	return []admissionPolicy{
+		{
+			resource: "cephclusters",
+			validations: []admissionValidation{
+				{expression: celImmutable("spec.dataDirHostPath", "''"), message: "spec.dataDirHostPath cannot be changed"},
+				{expression: celImmutable("spec.network.hostNetwork", "false"), message: "spec.network.hostNetwork cannot be changed"},
+				{expression: celImmutable("spec.network.provider", "''"), message: "spec.network.provider cannot be changed"},
+			},
+		},
+		{
+			resource: "cephblockpools",
+			validations: append(poolValidations("spec"),
+				admissionValidation{expression: celUpdate(fmt.Sprintf("%s == %s", poolName, oldPoolName)), message: "the name of the pool cannot be changed"},
+			),
+		},
+		{
+			resource: "cephfilesystems",
+			validations: []admissionValidation{
+				{expression: fmt.Sprintf("%s >= 1", celField("object", "spec.metadataServer.activeCount", "0")), message: "spec.metadataServer.activeCount must be at least 1"},
+				// no data pool means that the filesystem is expected to exist already
+				{
+					expression: fmt.Sprintf("size(%s) == 0 || !%s", celField("object", "spec.dataPools", "[]"), celErasureCoded("object", "spec.metadataPool")),
+					message:    "spec.metadataPool cannot be erasure coded, erasure coded pools can only be used as data pools",
+				},
+			},
+		},
+		{
+			resource: "cephobjectstores",
+			validations: []admissionValidation{
+				{
+					expression: fmt.Sprintf("%s >= 0 && %s <= 65535", celField("object", "spec.gateway.securePort", "0"), celField("object", "spec.gateway.securePort", "0")),
+					message:    "spec.gateway.securePort must be between 0 and 65535",
+				},
+				{
+					expression: fmt.Sprintf("%s > 0 || %s > 0", celField("object", "spec.gateway.port", "0"), celField("object", "spec.gateway.securePort", "0")),
+					message:    "either of spec.gateway.port or spec.gateway.securePort should not be zero",
+				},
+				{expression: celImmutable("spec.zone.name", "''"), message: "spec.zone.name cannot be changed"},
+			},
+		},
+	}
+}
+
+// admissionPolicyName returns the name of the policy and of the binding of a resource, the
+// namespace of the operator keeps apart the cluster-wide policies of several operators
+func admissionPolicyName(operatorNamespace, resource string) string {
+	return fmt.Sprintf("%s.%s.ceph.rook.io", operatorNamespace, resource)
+}
+
+// admissionPolicyObjects returns the ValidatingAdmissionPolicies and their bindings of the CRs of
+// the namespaces, all the namespaces when the list is empty
+func admissionPolicyObjects(version, operatorNamespace string, namespaces []string) []*unstructured.Unstructured {
+	labels := map[string]interface{}{
+		k8sutil.AppAttr:              "rook-ceph-operator",
+		admissionPolicyOperatorLabel: operatorNamespace,
+	}
+	objects := []*unstructured.Unstructured{}
+	for _, policy := range coreAdmissionPolicies() {
+		name := admissionPolicyName(operatorNamespace, policy.resource)
+		validations := []interface{}{}
+		for _, v := range policy.validations {
+			// a CR being deleted is always admitted so that its finalizer can be removed
+			validations = append(validations, map[string]interface{}{
+				"expression": fmt.Sprintf("has(object.metadata.deletionTimestamp) || (%s)", v.expression),
+				"message":    "invalid " + policy.resource + ": " + v.message,
+			})
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": admissionPolicyGroup + "/" + version,
+			"kind":       "ValidatingAdmissionPolicy",
+			"metadata":   map[string]interface{}{"name": name, "labels": labels},
+			"spec": map[string]interface{}{
+				"failurePolicy": "Fail",
+				"matchConstraints": map[string]interface{}{
+					"resourceRules": []interface{}{
+						map[string]interface{}{
+							"apiGroups":   []interface{}{"ceph.rook.io"},
+							"apiVersions": []interface{}{"*"},
+							"operations":  []interface{}{"CREATE", "UPDATE"},
+							"resources":   []interface{}{policy.resource},
+						},
+					},
+				},
+				"validations": validations,
+			},
+		}})
+
+		binding := map[string]interface{}{
+			"policyName":        name,
+			"validationActions": []interface{}{"Deny"},
+		}
+		if len(namespaces) > 0 {
+			values := []interface{}{}
+			for _, namespace := range namespaces {
+				values = append(values, namespace)
+			}
+			binding["matchResources"] = map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{
+					"matchExpressions": []interface{}{
+						map[string]interface{}{"key": "kubernetes.io/metadata.name", "operator": "In", "values": values},
+					},
+				},
+			}
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": admissionPolicyGroup + "/" + version,
+			"kind":       "ValidatingAdmissionPolicyBinding",
+			"metadata":   map[string]interface{}{"name": name, "labels": labels},
+			"spec":       binding,
+		}})
+	}
+	return objects
+}
+
+// admissionPolicyVersion returns the version of the ValidatingAdmissionPolicies served by the API
+// server, or an empty string when they are not served
+func admissionPolicyVersion(d discovery.DiscoveryInterface) (string, error) {
+	groups, err := d.ServerGroups()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to discover the api groups")
+	}
+	served := map[string]bool{}
+	for _, group := range groups.Groups {
+		if group.Name != admissionPolicyGroup {
+			continue
+		}
+		for _, version := range group.Versions {
+			served[version.Version] = true
+		}
+	}
+
+	for _, version := range admissionPolicyVersions {
+		if !served[version] {
+			continue
+		}
+		resources, err := d.ServerResourcesForGroupVersion(admissionPolicyGroup + "/" + version)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to discover the resources of %s/%s", admissionPolicyGroup, version)
+		}
+		for _, resource := range resources.APIResources {
+			if resource.Name == "validatingadmissionpolicies" {
+				return version, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// applyAdmissionPolicies creates or updates the policies and their bindings
+func applyAdmissionPolicies(ctx context.Context, c client.Client, objects []*unstructured.Unstructured) error {
+	for _, object := range objects {
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(object.GroupVersionKind())
+		err := c.Get(ctx, client.ObjectKey{Name: object.GetName()}, existing)
+		if err != nil {
+			if !kerrors.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to get %s %q", object.GetKind(), object.GetName())
+			}
+			if err := c.Create(ctx, object); err != nil {
+				return errors.Wrapf(err, "failed to create %s %q", object.GetKind(), object.GetName())
+			}
+			logger.Infof("created %s %q", object.GetKind(), object.GetName())
+			continue
+		}
+		object.SetResourceVersion(existing.GetResourceVersion())
+		if err := c.Update(ctx, object); err != nil {
+			return errors.Wrapf(err, "failed to update %s %q", object.GetKind(), object.GetName())
+		}
+		logger.Debugf("updated %s %q", object.GetKind(), object.GetName())
+	}
+	return nil
+}
+
+// deleteAdmissionPolicies deletes the policies and their bindings, the missing ones are ignored
+func deleteAdmissionPolicies(ctx context.Context, c client.Client, objects []*unstructured.Unstructured) error {
+	for _, object := range objects {
+		err := c.Delete(ctx, object)
+		if err != nil {
+			if kerrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to delete %s %q", object.GetKind(), object.GetName())
+		}
+		logger.Infof("deleted %s %q", object.GetKind(), object.GetName())
+	}
+	return nil
+}
+
+// reconcileAdmissionPolicies applies the ValidatingAdmissionPolicies of the CRs watched by the
+// operator when they are enabled, and deletes them otherwise
+func (o *Operator) reconcileAdmissionPolicies(ctx context.Context, c client.Client) error {
+	enabled, _ := k8sutil.GetOperatorSetting(ctx, o.context.Clientset, opcontroller.OperatorSettingConfigMapName, admissionPoliciesSetting, "false")
+	version, err := admissionPolicyVersion(o.context.Clientset.Discovery())
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		if enabled == "true" {
+			logger.Warningf("%s is enabled but the ValidatingAdmissionPolicies are not served by the K8s API server, they require K8s 1.28 or newer", admissionPoliciesSetting)
+		}
+		return nil
+	}
+
+	namespaces := o.config.NamespacesToWatch
+	if len(namespaces) == 0 && o.config.NamespaceToWatch != "" {
+		namespaces = []string{o.config.NamespaceToWatch}
+	}
+	objects := admissionPolicyObjects(version, namespace, namespaces)
+	if enabled != "true" {
+		// the policies of an operator that disabled them are removed
+		if err := deleteAdmissionPolicies(ctx, c, objects); err != nil {
+			logger.Warningf("failed to delete the admission policies. %v", err)
+		}
+		return nil
+	}
+
+	logger.Infof("applying the %s admission policies of the CRs", version)
+	return applyAdmissionPolicies(ctx, c, objects)
+}
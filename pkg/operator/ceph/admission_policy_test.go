@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCelField(t *testing.T) {
+	assert.Equal(t, "(has(object.spec) && has(object.spec.dataDirHostPath) ? object.spec.dataDirHostPath : '')", celField("object", "spec.dataDirHostPath", "''"))
+	assert.Equal(t, "oldObject == null || (has(object.spec) ? object.spec : 0) == (has(oldObject.spec) ? oldObject.spec : 0)", celImmutable("spec", "0"))
+}
+
+func TestAdmissionPolicyObjects(t *testing.T) {
+	policies := coreAdmissionPolicies()
+	objects := admissionPolicyObjects("v1", "rook-ceph", nil)
+	assert.Equal(t, 2*len(policies), len(objects))
+
+	for i, policy := range policies {
+		name := "rook-ceph." + policy.resource + ".ceph.rook.io"
+		p, binding := objects[2*i], objects[2*i+1]
+		assert.Equal(t, "admissionregistration.k8s.io/v1", p.GetAPIVersion())
+		assert.Equal(t, "ValidatingAdmissionPolicy", p.GetKind())
+		assert.Equal(t, name, p.GetName())
+		assert.Equal(t, "rook-ceph", p.GetLabels()[admissionPolicyOperatorLabel])
+		resources, _, _ := unstructured.NestedSlice(p.Object, "spec", "matchConstraints", "resourceRules")
+		assert.Equal(t, []interface{}{policy.resource}, resources[0].(map[string]interface{})["resources"])
+		validations, _, _ := unstructured.NestedSlice(p.Object, "spec", "validations")
+		assert.Equal(t, len(policy.validations), len(validations))
+		for _, v := range validations {
+			expression := v.(map[string]interface{})["expression"].(string)
+			// the CRs being deleted are admitted
+			assert.True(t, strings.HasPrefix(expression, "has(object.metadata.deletionTimestamp) || ("), expression)
+			assert.Equal(t, strings.Count(expression, "("), strings.Count(expression, ")"), expression)
+		}
+
+		assert.Equal(t, "ValidatingAdmissionPolicyBinding", binding.GetKind())
+		assert.Equal(t, name, binding.GetName())
+		policyName, _, _ := unstructured.NestedString(binding.Object, "spec", "policyName")
+		assert.Equal(t, name, policyName)
+		_, found, _ := unstructured.NestedMap(binding.Object, "spec", "matchResources")
+		assert.False(t, found)
+	}
+
+	// the bindings are restricted to the watched namespaces
+	objects = admissionPolicyObjects("v1beta1", "rook-ceph", []string{"rook-ceph", "other"})
+	assert.Equal(t, "admissionregistration.k8s.io/v1beta1", objects[1].GetAPIVersion())
+	expressions, _, _ := unstructured.NestedSlice(objects[1].Object, "spec", "matchResources", "namespaceSelector", "matchExpressions")
+	assert.Equal(t, []interface{}{"rook-ceph", "other"}, expressions[0].(map[string]interface{})["values"])
+}
+
+func TestAdmissionPolicyVersion(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	version, err := admissionPolicyVersion(clientset.Discovery())
+	assert.NoError(t, err)
+	assert.Equal(t, "", version)
+
+	clientset.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "admissionregistration.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "validatingwebhookconfigurations"}}},
+		{GroupVersion: "admissionregistration.k8s.io/v1beta1", APIResources: []metav1.APIResource{{Name: "validatingadmissionpolicies"}}},
+	}
+	version, err = admissionPolicyVersion(clientset.Discovery())
+	assert.NoError(t, err)
+	assert.Equal(t, "v1beta1", version)
+
+	clientset.Resources[0].APIResources = append(clientset.Resources[0].APIResources, metav1.APIResource{Name: "validatingadmissionpolicies"})
+	version, err = admissionPolicyVersion(clientset.Discovery())
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", version)
+}
+
+func TestApplyAdmissionPolicies(t *testing.T) {
+	ctx := context.TODO()
+	c := clientfake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+
+	assert.NoError(t, applyAdmissionPolicies(ctx, c, admissionPolicyObjects("v1", "rook-ceph", nil)))
+	policy := &unstructured.Unstructured{}
+	policy.SetAPIVersion("admissionregistration.k8s.io/v1")
+	policy.SetKind("ValidatingAdmissionPolicyBinding")
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Name: "rook-ceph.cephclusters.ceph.rook.io"}, policy))
+	_, found, _ := unstructured.NestedMap(policy.Object, "spec", "matchResources")
+	assert.False(t, found)
+
+	// the existing policies are updated
+	assert.NoError(t, applyAdmissionPolicies(ctx, c, admissionPolicyObjects("v1", "rook-ceph", []string{"rook-ceph"})))
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Name: "rook-ceph.cephclusters.ceph.rook.io"}, policy))
+	_, found, _ = unstructured.NestedMap(policy.Object, "spec", "matchResources")
+	assert.True(t, found)
+
+	// the policies are deleted, twice without error
+	assert.NoError(t, deleteAdmissionPolicies(ctx, c, admissionPolicyObjects("v1", "rook-ceph", nil)))
+	assert.NoError(t, deleteAdmissionPolicies(ctx, c, admissionPolicyObjects("v1", "rook-ceph", nil)))
+	assert.Error(t, c.Get(ctx, client.ObjectKey{Name: "rook-ceph.cephclusters.ceph.rook.io"}, policy))
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAdmissionValidator(t *testing.T) {
+	ctx := context.TODO()
+	namespace := "rook-ceph"
+	s := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(s))
+	assert.NoError(t, scheme.AddToScheme(s))
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: namespace}}
+	filesystem := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: namespace}}
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace}}
+	newValidator := func(objects ...runtime.Object) *admissionValidator {
+		return &admissionValidator{
+			context: &clusterd.Context{},
+			reader:  fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build(),
+		}
+	}
+	group := &cephv1.CephFilesystemSubVolumeGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group-a", Namespace: namespace},
+		Spec:       cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs"},
+	}
+
+	t.Run("referenced resource", func(t *testing.T) {
+		err := newValidator(cluster).ValidateCreate(ctx, group)
+		assert.EqualError(t, err, `CephFilesystem "myfs" not found in namespace "rook-ceph"`)
+		assert.NoError(t, newValidator(cluster, filesystem).ValidateCreate(ctx, group))
+
+		// the resources of an external cluster may not have CRs
+		external := cluster.DeepCopy()
+		external.Spec.External.Enable = true
+		assert.NoError(t, newValidator(external).ValidateCreate(ctx, group))
+	})
+
+	t.Run("updated references", func(t *testing.T) {
+		action := &cephv1.CephDRAction{
+			ObjectMeta: metav1.ObjectMeta{Name: "failover", Namespace: namespace},
+			Spec:       cephv1.DRActionSpec{Action: cephv1.DRActionPromote, Pools: []string{"replicapool"}},
+		}
+		v := newValidator(cluster)
+		assert.Error(t, v.ValidateCreate(ctx, action))
+
+		// the pool was deleted since the action was created
+		updated := action.DeepCopy()
+		updated.Spec.Force = true
+		assert.NoError(t, v.ValidateUpdate(ctx, action, updated))
+
+		// a new pool must exist
+		updated.Spec.Pools = append(updated.Spec.Pools, "otherpool")
+		assert.EqualError(t, v.ValidateUpdate(ctx, action, updated), `CephBlockPool "otherpool" not found in namespace "rook-ceph"`)
+	})
+
+	t.Run("controller validation", func(t *testing.T) {
+		v := newValidator(cluster, pool)
+		mirror := &cephv1.CephRBDMirror{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-rbd-mirror", Namespace: namespace},
+			Spec:       cephv1.RBDMirroringSpec{Count: 1, Pools: []string{"replicapool"}},
+		}
+		assert.NoError(t, v.ValidateCreate(ctx, mirror))
+
+		invalid := mirror.DeepCopy()
+		invalid.Spec.Count = 0
+		assert.EqualError(t, v.ValidateCreate(ctx, invalid), "invalid create: rbd-mirror count must be at least one")
+
+		invalid = mirror.DeepCopy()
+		invalid.Spec.Pools = []string{"replicapool/tenant-a", "otherpool"}
+		assert.Error(t, v.ValidateCreate(ctx, invalid))
+	})
+
+	t.Run("immutable fields", func(t *testing.T) {
+		v := newValidator(cluster, filesystem)
+		updated := group.DeepCopy()
+		updated.Spec.FilesystemName = "otherfs"
+		assert.Error(t, v.ValidateUpdate(ctx, group, updated))
+	})
+
+	t.Run("metadata update", func(t *testing.T) {
+		// a resource created before a validation was added can still be deleted
+		invalid := &cephv1.CephNFS{ObjectMeta: metav1.ObjectMeta{Name: "my-nfs", Namespace: namespace}}
+		v := newValidator(cluster)
+		assert.Error(t, v.ValidateCreate(ctx, invalid))
+		updated := invalid.DeepCopy()
+		updated.Finalizers = nil
+		now := metav1.Now()
+		updated.DeletionTimestamp = &now
+		assert.NoError(t, v.ValidateUpdate(ctx, invalid, updated))
+		assert.NoError(t, v.ValidateDelete(ctx, invalid))
+	})
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package adoption discovers the pools, object store users, subvolume groups and rados namespaces
+// of a CephCluster that are not managed by a CR, to report them or to generate their CRs
+package adoption
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	packageName    = "ceph-adoption"
+	controllerName = packageName + "-controller"
+
+	// AdoptedAnnotation is the annotation of the CRs generated to manage the existing resources of
+	// the cluster, its value is the time the CR was generated
+	AdoptedAnnotation = "ceph.rook.io/adopted"
+
+	// defaultInterval is the interval the resources of the cluster are discovered at when the
+	// adoption does not set it
+	defaultInterval = 10 * time.Minute
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", packageName)
+
+// ReconcileAdoption discovers the resources of a CephCluster that are not managed by a CR
+type ReconcileAdoption struct {
+	client           client.Client
+	context          *clusterd.Context
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+	// lists the users of an object store except the skipped users, replaced by the tests
+	listUsers func(clusterInfo *cephclient.ClusterInfo, store *cephv1.CephObjectStore, skip func(string) bool) ([]objectUser, error)
+}
+
+// Add creates a new adoption controller and adds it to the Manager. The Manager will set fields on
+// the Controller and start it when the Manager is started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	r := &ReconcileAdoption{
+		client:           mgr.GetClient(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
+	}
+	r.listUsers = r.listObjectStoreUsers
+	return add(mgr, r)
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// the CephCluster reconciles its own conditions, the standard conditions of the reconcile
+	// metrics must not be set on it
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileLogFields(controllerName, r)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for the changes of the spec of the CephClusters, the discovery is then requeued at the
+	// interval of the adoption
+	return c.Watch(&source.Kind{Type: &cephv1.CephCluster{}}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})
+}
+
+// Reconcile discovers the resources of a CephCluster that are not managed by a CR
+func (r *ReconcileAdoption) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		logger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileAdoption) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	cephCluster := &cephv1.CephCluster{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephCluster)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debugf("CephCluster %q resource not found. Ignoring since object must be deleted.", request.NamespacedName)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get CephCluster %q", request.NamespacedName)
+	}
+	adoption := cephCluster.Spec.Adoption
+	if adoption == nil || !cephCluster.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+	interval := defaultInterval
+	if adoption.Interval != nil && adoption.Interval.Duration > 0 {
+		interval = adoption.Interval.Duration
+	}
+	if cephCluster.Spec.External.Enable {
+		return reconcile.Result{}, r.updateStatus(cephCluster, nil, nil, "the adoption is not supported on an external cluster")
+	}
+
+	_, isReadyToReconcile, _, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, nil
+	}
+
+	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, r.opManagerContext, request.Namespace)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	candidates, err := r.discover(clusterInfo, request.Namespace)
+	if err != nil {
+		if statusErr := r.updateStatus(cephCluster, nil, nil, err.Error()); statusErr != nil {
+			logger.Errorf("failed to update the adoption status of CephCluster %q. %v", request.NamespacedName, statusErr)
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "failed to discover the resources of CephCluster %q", request.NamespacedName)
+	}
+
+	var generated []string
+	if adoption.Mode == cephv1.AdoptionModeGenerate {
+		candidates, generated = r.generate(cephCluster, candidates)
+	}
+
+	unmanaged := make([]cephv1.UnmanagedResource, 0, len(candidates))
+	for _, candidate := range candidates {
+		unmanaged = append(unmanaged, candidate.resource)
+	}
+	if err := r.updateStatus(cephCluster, unmanaged, generated, ""); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logger.Debugf("discovered %d unmanaged resources of CephCluster %q, generated %d CRs", len(unmanaged), request.NamespacedName, len(generated))
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// updateStatus updates the adoption status of the CephCluster
+func (r *ReconcileAdoption) updateStatus(cephCluster *cephv1.CephCluster, unmanaged []cephv1.UnmanagedResource, generated []string, message string) error {
+	status := &cephv1.AdoptionStatus{
+		Unmanaged:   unmanaged,
+		Generated:   generated,
+		LastChecked: time.Now().UTC().Format(time.RFC3339),
+		Message:     message,
+	}
+	err := reporting.PatchStatus(r.client, cephCluster, func() bool {
+		// the failed discoveries keep the resources found by the last successful discovery
+		if existing := cephCluster.Status.Adoption; message != "" && existing != nil {
+			status.Unmanaged = existing.Unmanaged
+			status.Generated = existing.Generated
+		}
+		cephCluster.Status.Adoption = status
+		return true
+	})
+	return errors.Wrapf(err, "failed to update the adoption status of CephCluster %q", cephCluster.Name)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/ceph/go-ceph/rgw/admin"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephtest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAdoption(t *testing.T) {
+	namespace := "rook-ceph"
+	fakeCeph := cephtest.NewFakeCeph()
+	fakeCeph.RespondJSON([]cephclient.CephStoragePool{
+		{Name: "replicapool", Type: cephclient.PoolTypeReplicated, Size: 3, Applications: map[string]interface{}{"rbd": nil}},
+		{Name: "legacy", Type: cephclient.PoolTypeReplicated, Size: 2, Applications: map[string]interface{}{"rbd": nil}},
+		{Name: "ecpool", Type: cephclient.PoolTypeErasureCoded, Applications: map[string]interface{}{"rbd": nil}},
+		{Name: "myfs-metadata", Type: cephclient.PoolTypeReplicated, Size: 3, Applications: map[string]interface{}{"cephfs": nil}},
+		{Name: "device_health_metrics", Type: cephclient.PoolTypeReplicated, Size: 3, Applications: map[string]interface{}{"mgr_devicehealth": nil}},
+	}, "ceph", "osd", "pool", "ls", "detail")
+	fakeCeph.Respond(`[]`, "rbd", "namespace", "ls")
+	fakeCeph.Respond(`[{"name":"tenant-a"},{"name":"tenant-b"}]`, "rbd", "namespace", "ls", "replicapool")
+	fakeCeph.Respond(`[{"name":"old"}]`, "rbd", "namespace", "ls", "legacy")
+	fakeCeph.Respond(`[{"name":"csi"},{"name":"_nogroup"},{"name":"shared"},{"name":"Shared_Data"},{"name":"legacy_group"}]`, "ceph", "fs", "subvolumegroup", "ls", "myfs")
+
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: namespace}}
+	cephCluster.Spec.Adoption = &cephv1.AdoptionSpec{Mode: cephv1.AdoptionModeReport}
+	objects := []runtime.Object{
+		cephCluster,
+		&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace}},
+		&cephv1.CephBlockPoolRadosNamespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Namespace: namespace}, Spec: cephv1.CephBlockPoolRadosNamespaceSpec{BlockPoolName: "replicapool"}},
+		&cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: namespace}},
+		&cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: namespace}, Spec: cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs", Name: "legacy_group"}},
+		&cephv1.CephObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: namespace}},
+		&cephv1.CephObjectStoreUser{ObjectMeta: metav1.ObjectMeta{Name: "managed", Namespace: namespace}, Spec: cephv1.ObjectStoreUserSpec{Store: "my-store"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
+
+	displayName := "Alice"
+	maxObjects := int64(100)
+	enabled := true
+	r := &ReconcileAdoption{
+		client:           c,
+		context:          &clusterd.Context{Executor: fakeCeph.Executor()},
+		opManagerContext: context.TODO(),
+		recorder:         record.NewFakeRecorder(10),
+		listUsers: func(_ *cephclient.ClusterInfo, _ *cephv1.CephObjectStore, skip func(string) bool) ([]objectUser, error) {
+			users := []objectUser{}
+			for _, id := range []string{"managed", "alice", "tenant$bob", "ceph-user-abcdefgh", "dashboard-admin"} {
+				if skip(id) {
+					continue
+				}
+				user := objectUser{ObjectUser: object.ObjectUser{UserID: id}}
+				if id == "alice" {
+					user.DisplayName = &displayName
+					user.MaxBuckets = 5
+					user.UserQuota = admin.QuotaSpec{Enabled: &enabled, MaxObjects: &maxObjects}
+					user.Caps = []admin.UserCapSpec{{Type: "buckets", Perm: "read"}}
+				}
+				users = append(users, user)
+			}
+			return users, nil
+		},
+	}
+	clusterInfo := cephclient.AdminTestClusterInfo(namespace)
+
+	t.Run("report the unmanaged resources", func(t *testing.T) {
+		candidates, err := r.discover(clusterInfo, namespace)
+		assert.NoError(t, err)
+		resources := map[string]cephv1.UnmanagedResource{}
+		for _, candidate := range candidates {
+			resources[candidate.resource.Kind+"/"+candidate.resource.Name] = candidate.resource
+		}
+		assert.Equal(t, []string{"CephBlockPool/ecpool", "CephBlockPool/legacy", "CephBlockPoolRadosNamespace/old", "CephBlockPoolRadosNamespace/tenant-b",
+			"CephFilesystemSubVolumeGroup/Shared_Data", "CephFilesystemSubVolumeGroup/shared", "CephObjectStoreUser/alice", "CephObjectStoreUser/tenant$bob"}, sortedKeys(resources))
+		assert.Contains(t, resources["CephBlockPool/ecpool"].Message, "erasure coded")
+		assert.Equal(t, "legacy", resources["CephBlockPoolRadosNamespace/old"].Parent)
+		assert.Equal(t, "myfs", resources["CephFilesystemSubVolumeGroup/shared"].Parent)
+		assert.Contains(t, resources["CephObjectStoreUser/tenant$bob"].Message, "not a valid name")
+
+		unmanaged := []cephv1.UnmanagedResource{}
+		for _, candidate := range candidates {
+			unmanaged = append(unmanaged, candidate.resource)
+		}
+		assert.NoError(t, r.updateStatus(cephCluster, unmanaged, nil, ""))
+		assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "my-cluster"}, cephCluster))
+		assert.Len(t, cephCluster.Status.Adoption.Unmanaged, len(candidates))
+		assert.NotEmpty(t, cephCluster.Status.Adoption.LastChecked)
+
+		// a failed discovery keeps the resources of the last discovery
+		assert.NoError(t, r.updateStatus(cephCluster, nil, nil, "failed to list pools"))
+		assert.Len(t, cephCluster.Status.Adoption.Unmanaged, len(candidates))
+		assert.Equal(t, "failed to list pools", cephCluster.Status.Adoption.Message)
+	})
+
+	t.Run("generate the CRs of the unmanaged resources", func(t *testing.T) {
+		candidates, err := r.discover(clusterInfo, namespace)
+		assert.NoError(t, err)
+		unmanaged, generated := r.generate(cephCluster, candidates)
+		assert.Equal(t, []string{"CephBlockPool/legacy", "CephBlockPoolRadosNamespace/tenant-b", "CephBlockPoolRadosNamespace/old",
+			"CephFilesystemSubVolumeGroup/shared", "CephFilesystemSubVolumeGroup/shared-data", "CephObjectStoreUser/alice"}, generated)
+		assert.Len(t, unmanaged, 2)
+
+		pool := &cephv1.CephBlockPool{}
+		assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "legacy"}, pool))
+		assert.Equal(t, uint(2), pool.Spec.Replicated.Size)
+		assert.Equal(t, "true", pool.Annotations[opcontroller.DryRunAnnotation])
+		assert.NotEmpty(t, pool.Annotations[AdoptedAnnotation])
+
+		radosNamespace := &cephv1.CephBlockPoolRadosNamespace{}
+		assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "old"}, radosNamespace))
+		assert.Equal(t, "legacy", radosNamespace.Spec.BlockPoolName)
+
+		group := &cephv1.CephFilesystemSubVolumeGroup{}
+		assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "shared-data"}, group))
+		assert.Equal(t, "Shared_Data", group.Spec.Name)
+		assert.Equal(t, "Shared_Data", group.GetSubVolumeGroupName())
+
+		user := &cephv1.CephObjectStoreUser{}
+		assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "alice"}, user))
+		assert.Equal(t, "my-store", user.Spec.Store)
+		assert.Equal(t, "Alice", user.Spec.DisplayName)
+		assert.Equal(t, 5, *user.Spec.Quotas.MaxBuckets)
+		assert.Equal(t, int64(100), *user.Spec.Quotas.MaxObjects)
+		assert.Equal(t, "read", user.Spec.Capabilities.Bucket)
+
+		// the generated CRs manage the resources
+		candidates, err = r.discover(clusterInfo, namespace)
+		assert.NoError(t, err)
+		assert.Len(t, candidates, 2)
+	})
+
+	t.Run("the failures of the discovery are returned", func(t *testing.T) {
+		fakeCeph.Fail(errors.New("timed out"), 1, "ceph", "osd", "pool", "ls")
+		_, err := r.discover(clusterInfo, namespace)
+		assert.Error(t, err)
+	})
+}
+
+func TestIsInternalUser(t *testing.T) {
+	assert.True(t, isInternalUser(object.RGWAdminOpsUserSecretName))
+	assert.True(t, isInternalUser(object.DashboardUser))
+	assert.True(t, isInternalUser("ceph-user-abcdefgh"))
+	assert.True(t, isInternalUser("my-realm-system-user"))
+	assert.False(t, isInternalUser("alice"))
+}
+
+func sortedKeys(m map[string]cephv1.UnmanagedResource) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
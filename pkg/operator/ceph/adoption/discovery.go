@@ -0,0 +1,395 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// the subvolume group of the volumes provisioned by ceph-csi, it is managed by the driver
+	csiSubVolumeGroup = "csi"
+	// the prefix of the users of the object bucket claims
+	obcUserPrefix = "ceph-user-"
+	// the suffix of the system users of the realms of the object stores
+	systemUserSuffix = "-system-user"
+)
+
+// candidate is a resource of the cluster that is not managed by a CR
+type candidate struct {
+	resource cephv1.UnmanagedResource
+	// the CR generated to manage the resource, nil when the CR cannot be generated
+	object client.Object
+}
+
+// objectUser is a user of an object store
+type objectUser struct {
+	object.ObjectUser
+	// the error getting the info of the user, the CR of the user is not generated without it
+	err error
+}
+
+// discover returns the resources of the cluster that are not managed by a CR, with the CRs that
+// would manage them
+func (r *ReconcileAdoption) discover(clusterInfo *cephclient.ClusterInfo, namespace string) ([]candidate, error) {
+	pools, err := cephclient.ListPools(r.context, clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	candidates, poolCRs, err := r.discoverPools(pools, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := r.discoverRadosNamespaces(clusterInfo, namespace, pools, poolCRs)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, namespaces...)
+
+	groups, err := r.discoverSubVolumeGroups(clusterInfo, namespace)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, groups...)
+
+	users, err := r.discoverObjectStoreUsers(clusterInfo, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return append(candidates, users...), nil
+}
+
+// discoverPools returns the rbd pools that are not managed by a CephBlockPool, and the name of the
+// CephBlockPool managing or that would manage each rbd pool. The pools of the other applications
+// are managed by the CephFilesystems and CephObjectStores.
+func (r *ReconcileAdoption) discoverPools(pools []cephclient.CephStoragePool, namespace string) ([]candidate, map[string]string, error) {
+	blockPools := &cephv1.CephBlockPoolList{}
+	if err := r.client.List(r.opManagerContext, blockPools, client.InNamespace(namespace)); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list the CephBlockPools")
+	}
+	poolCRs := map[string]string{}
+	crNames := map[string]bool{}
+	for _, blockPool := range blockPools.Items {
+		poolName := blockPool.Name
+		if blockPool.Spec.Name != "" {
+			poolName = blockPool.Spec.Name
+		}
+		poolCRs[poolName] = blockPool.Name
+		crNames[blockPool.Name] = true
+	}
+
+	candidates := []candidate{}
+	for _, pool := range pools {
+		if !isBlockPool(pool) {
+			continue
+		}
+		if _, ok := poolCRs[pool.Name]; ok {
+			continue
+		}
+
+		c := candidate{resource: cephv1.UnmanagedResource{Kind: "CephBlockPool", Name: pool.Name}}
+		switch {
+		case crNames[pool.Name]:
+			c.resource.Message = fmt.Sprintf("CephBlockPool %q manages another pool", pool.Name)
+		case !isValidName(pool.Name):
+			c.resource.Message = "the name of the pool is not a valid name of a CR"
+		case pool.Type != cephclient.PoolTypeReplicated:
+			c.resource.Message = "the CRs of the erasure coded pools are not generated"
+		default:
+			blockPool := &cephv1.CephBlockPool{ObjectMeta: objectMeta(pool.Name, namespace)}
+			// the existing pool is not modified until the generated spec is reviewed
+			blockPool.Annotations[opcontroller.DryRunAnnotation] = "true"
+			blockPool.Spec.Replicated.Size = pool.Size
+			c.object = blockPool
+			poolCRs[pool.Name] = pool.Name
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, poolCRs, nil
+}
+
+// isBlockPool returns whether a pool is an rbd pool that can be managed by a CephBlockPool
+func isBlockPool(pool cephclient.CephStoragePool) bool {
+	// the pools of the mgr modules and of the nfs exports are managed by Ceph
+	if strings.HasPrefix(pool.Name, ".") || pool.Name == "device_health_metrics" {
+		return false
+	}
+	_, rbd := pool.Applications["rbd"]
+	return rbd && len(pool.Applications) == 1
+}
+
+// discoverRadosNamespaces returns the rados namespaces of the rbd pools that are not managed by a
+// CephBlockPoolRadosNamespace
+func (r *ReconcileAdoption) discoverRadosNamespaces(clusterInfo *cephclient.ClusterInfo, namespace string, pools []cephclient.CephStoragePool, poolCRs map[string]string) ([]candidate, error) {
+	radosNamespaces := &cephv1.CephBlockPoolRadosNamespaceList{}
+	if err := r.client.List(r.opManagerContext, radosNamespaces, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list the CephBlockPoolRadosNamespaces")
+	}
+	managed := map[string]bool{}
+	for _, radosNamespace := range radosNamespaces.Items {
+		managed[radosNamespace.Spec.BlockPoolName+"/"+radosNamespace.Name] = true
+	}
+
+	candidates := []candidate{}
+	for _, pool := range pools {
+		if !isBlockPool(pool) {
+			continue
+		}
+		names, err := cephclient.ListRadosNamespaces(r.context, clusterInfo, pool.Name)
+		if err != nil {
+			return nil, err
+		}
+		poolCR := poolCRs[pool.Name]
+		for _, name := range names {
+			if poolCR != "" && managed[poolCR+"/"+name] {
+				continue
+			}
+
+			c := candidate{resource: cephv1.UnmanagedResource{Kind: "CephBlockPoolRadosNamespace", Name: name, Parent: pool.Name}}
+			switch {
+			case poolCR == "":
+				c.resource.Message = "the pool is not managed by a CephBlockPool"
+			case !isValidName(name):
+				c.resource.Message = "the name of the rados namespace is not a valid name of a CR"
+			default:
+				radosNamespace := &cephv1.CephBlockPoolRadosNamespace{ObjectMeta: objectMeta(name, namespace)}
+				radosNamespace.Spec.BlockPoolName = poolCR
+				c.object = radosNamespace
+			}
+			candidates = append(candidates, c)
+		}
+	}
+
+	return candidates, nil
+}
+
+// discoverSubVolumeGroups returns the subvolume groups of the CephFilesystems that are not managed
+// by a CephFilesystemSubVolumeGroup
+func (r *ReconcileAdoption) discoverSubVolumeGroups(clusterInfo *cephclient.ClusterInfo, namespace string) ([]candidate, error) {
+	groups := &cephv1.CephFilesystemSubVolumeGroupList{}
+	if err := r.client.List(r.opManagerContext, groups, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list the CephFilesystemSubVolumeGroups")
+	}
+	managed := map[string]bool{}
+	for _, group := range groups.Items {
+		managed[group.Spec.FilesystemName+"/"+group.GetSubVolumeGroupName()] = true
+	}
+
+	filesystems := &cephv1.CephFilesystemList{}
+	if err := r.client.List(r.opManagerContext, filesystems, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list the CephFilesystems")
+	}
+	candidates := []candidate{}
+	for _, filesystem := range filesystems.Items {
+		if !filesystem.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		names, err := cephclient.ListCephFSSubVolumeGroups(r.context, clusterInfo, filesystem.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			// the internal directories of the volumes start with an underscore
+			if strings.HasPrefix(name, "_") || name == csiSubVolumeGroup || managed[filesystem.Name+"/"+name] {
+				continue
+			}
+
+			c := candidate{resource: cephv1.UnmanagedResource{Kind: "CephFilesystemSubVolumeGroup", Name: name, Parent: filesystem.Name}}
+			// the subvolume groups with a name that is not a valid name of a CR are managed by a CR
+			// with a valid name and the name of the group in the spec
+			crName := name
+			if !isValidName(crName) {
+				crName = validName(name)
+			}
+			if isValidName(crName) {
+				group := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: objectMeta(crName, namespace)}
+				group.Spec.FilesystemName = filesystem.Name
+				if crName != name {
+					group.Spec.Name = name
+				}
+				c.object = group
+			} else {
+				c.resource.Message = "the name of the subvolume group cannot be converted to a valid name of a CR"
+			}
+			candidates = append(candidates, c)
+		}
+	}
+
+	return candidates, nil
+}
+
+// discoverObjectStoreUsers returns the users of the CephObjectStores that are not managed by a
+// CephObjectStoreUser. The users created by Rook for its own use and for the object bucket claims
+// are not reported.
+func (r *ReconcileAdoption) discoverObjectStoreUsers(clusterInfo *cephclient.ClusterInfo, namespace string) ([]candidate, error) {
+	users := &cephv1.CephObjectStoreUserList{}
+	if err := r.client.List(r.opManagerContext, users, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list the CephObjectStoreUsers")
+	}
+	managed := map[string]bool{}
+	for _, user := range users.Items {
+		managed[user.Spec.Store+"/"+user.Name] = true
+	}
+
+	stores := &cephv1.CephObjectStoreList{}
+	if err := r.client.List(r.opManagerContext, stores, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list the CephObjectStores")
+	}
+	candidates := []candidate{}
+	for i := range stores.Items {
+		store := &stores.Items[i]
+		if !store.GetDeletionTimestamp().IsZero() || store.Spec.IsExternal() {
+			continue
+		}
+		storeUsers, err := r.listUsers(clusterInfo, store, func(id string) bool {
+			return isInternalUser(id) || managed[store.Name+"/"+id]
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range storeUsers {
+			c := candidate{resource: cephv1.UnmanagedResource{Kind: "CephObjectStoreUser", Name: user.UserID, Parent: store.Name}}
+			switch {
+			case !isValidName(user.UserID):
+				c.resource.Message = "the ID of the user is not a valid name of a CR"
+			case user.err != nil:
+				c.resource.Message = fmt.Sprintf("failed to get the user. %v", user.err)
+			default:
+				c.object = objectStoreUser(user.ObjectUser, store.Name, namespace)
+			}
+			candidates = append(candidates, c)
+		}
+	}
+
+	return candidates, nil
+}
+
+// listObjectStoreUsers lists the users of an object store, except the skipped users
+func (r *ReconcileAdoption) listObjectStoreUsers(clusterInfo *cephclient.ClusterInfo, store *cephv1.CephObjectStore, skip func(string) bool) ([]objectUser, error) {
+	objContext, err := object.NewMultisiteContext(r.context, clusterInfo, store)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the context of object store %q", store.Name)
+	}
+	ids, err := object.ListUsers(objContext)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the users of object store %q", store.Name)
+	}
+
+	users := []objectUser{}
+	for _, id := range ids {
+		if skip(id) {
+			continue
+		}
+		user := objectUser{ObjectUser: object.ObjectUser{UserID: id}}
+		info, _, err := object.GetUser(objContext, id)
+		if err != nil {
+			user.err = err
+		} else {
+			user.ObjectUser = *info
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// isInternalUser returns whether a user is created by Rook for its own use or for an object bucket
+// claim
+func isInternalUser(id string) bool {
+	return id == object.RGWAdminOpsUserSecretName || id == object.DashboardUser ||
+		strings.HasPrefix(id, obcUserPrefix) || strings.HasSuffix(id, systemUserSuffix)
+}
+
+// objectStoreUser returns the CephObjectStoreUser keeping the display name, the capabilities and the
+// quotas of an existing user, which would be reset to the defaults otherwise
+func objectStoreUser(user object.ObjectUser, store, namespace string) *cephv1.CephObjectStoreUser {
+	u := &cephv1.CephObjectStoreUser{ObjectMeta: objectMeta(user.UserID, namespace)}
+	u.Spec.Store = store
+	if user.DisplayName != nil {
+		u.Spec.DisplayName = *user.DisplayName
+	}
+
+	maxBuckets := user.MaxBuckets
+	u.Spec.Quotas = &cephv1.ObjectUserQuotaSpec{MaxBuckets: &maxBuckets}
+	quota := user.UserQuota
+	if quota.Enabled != nil && *quota.Enabled {
+		// a negative quota is unlimited
+		if quota.MaxObjects != nil && *quota.MaxObjects >= 0 {
+			maxObjects := *quota.MaxObjects
+			u.Spec.Quotas.MaxObjects = &maxObjects
+		}
+		if quota.MaxSize != nil && *quota.MaxSize >= 0 {
+			u.Spec.Quotas.MaxSize = resource.NewQuantity(*quota.MaxSize, resource.BinarySI)
+		}
+	}
+
+	if len(user.Caps) > 0 {
+		u.Spec.Capabilities = &cephv1.ObjectUserCapSpec{}
+		for _, capability := range user.Caps {
+			switch capability.Type {
+			case "users":
+				u.Spec.Capabilities.User = capability.Perm
+			case "buckets":
+				u.Spec.Capabilities.Bucket = capability.Perm
+			case "metadata":
+				u.Spec.Capabilities.MetaData = capability.Perm
+			case "usage":
+				u.Spec.Capabilities.Usage = capability.Perm
+			case "zone":
+				u.Spec.Capabilities.Zone = capability.Perm
+			}
+		}
+	}
+	return u
+}
+
+// isValidName returns whether the name of a resource of the cluster can be the name of its CR
+func isValidName(name string) bool {
+	return len(validation.IsDNS1123Subdomain(name)) == 0
+}
+
+// validName converts the name of a resource of the cluster to a valid name of a CR, the characters
+// that are not valid are replaced with dashes
+func validName(name string) string {
+	converted := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name))
+	return strings.Trim(converted, "-.")
+}
+
+func objectMeta(name, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Annotations: map[string]string{},
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+import (
+	"fmt"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// generate creates the CRs managing the unmanaged resources of the cluster. It returns the resources
+// that are still unmanaged, with the reason their CR was not created, and the generated CRs as
+// kind/name.
+func (r *ReconcileAdoption) generate(cephCluster *cephv1.CephCluster, candidates []candidate) ([]candidate, []string) {
+	unmanaged := []candidate{}
+	generated := []string{}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, c := range candidates {
+		if c.object == nil {
+			unmanaged = append(unmanaged, c)
+			continue
+		}
+
+		c.object.GetAnnotations()[AdoptedAnnotation] = now
+		err := r.client.Create(r.opManagerContext, c.object)
+		if err != nil {
+			if kerrors.IsAlreadyExists(err) {
+				c.resource.Message = fmt.Sprintf("%s %q already exists", c.resource.Kind, c.object.GetName())
+			} else {
+				c.resource.Message = fmt.Sprintf("failed to generate the CR. %v", err)
+				logger.Errorf("failed to generate %s %q to manage the existing resource. %v", c.resource.Kind, c.object.GetName(), err)
+			}
+			unmanaged = append(unmanaged, c)
+			continue
+		}
+
+		name := c.resource.Kind + "/" + c.object.GetName()
+		generated = append(generated, name)
+		logger.Infof("generated %s to manage the existing resource %q of CephCluster %q", name, c.resource.Name, cephCluster.Name)
+		r.recorder.Eventf(cephCluster, v1.EventTypeNormal, "Adopted", "Generated %s to manage the existing resource %q", name, c.resource.Name)
+	}
+	return unmanaged, generated
+}
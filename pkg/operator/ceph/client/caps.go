@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+// the subvolume group of the cephfs-subvolume-scoped template when not set, the group of the CSI driver
+const defaultSubVolumeGroup = "csi"
+
+// validateCapsTemplate returns an error if the parameters of a caps template are missing or do not
+// belong to the template
+func validateCapsTemplate(template *cephv1.ClientCapsTemplateSpec) error {
+	switch template.Name {
+	case cephv1.ClientCapsTemplateRBDUserOnPool:
+		if template.Pool == "" {
+			return errors.Errorf("missing pool of caps template %q", template.Name)
+		}
+		if template.Filesystem != "" || template.SubVolumeGroup != "" || template.SubVolume != "" {
+			return errors.Errorf("caps template %q does not accept a filesystem, subvolume group or subvolume", template.Name)
+		}
+	case cephv1.ClientCapsTemplateCephFSSubVolumeScoped:
+		if template.Filesystem == "" {
+			return errors.Errorf("missing filesystem of caps template %q", template.Name)
+		}
+		if template.Pool != "" || template.RadosNamespace != "" {
+			return errors.Errorf("caps template %q does not accept a pool or rados namespace", template.Name)
+		}
+	default:
+		return errors.Errorf("unknown caps template %q", template.Name)
+	}
+	return nil
+}
+
+// templateCaps returns the caps generated by a caps template, by daemon type
+func templateCaps(template *cephv1.ClientCapsTemplateSpec) map[string]string {
+	if template == nil {
+		return map[string]string{}
+	}
+	switch template.Name {
+	case cephv1.ClientCapsTemplateRBDUserOnPool:
+		profile := fmt.Sprintf("profile rbd pool=%s", template.Pool)
+		if template.RadosNamespace != "" {
+			profile += fmt.Sprintf(" namespace=%s", template.RadosNamespace)
+		}
+		return map[string]string{
+			"mon": "profile rbd",
+			"osd": profile,
+			"mgr": profile,
+		}
+	case cephv1.ClientCapsTemplateCephFSSubVolumeScoped:
+		group := template.SubVolumeGroup
+		if group == "" {
+			group = defaultSubVolumeGroup
+		}
+		return map[string]string{
+			"mon": fmt.Sprintf("allow r fsname=%s", template.Filesystem),
+			"mds": fmt.Sprintf("allow rw fsname=%s path=%s", template.Filesystem, path.Join("/volumes", group, template.SubVolume)),
+			"osd": fmt.Sprintf("allow rw tag cephfs data=%s", template.Filesystem),
+		}
+	}
+	return map[string]string{}
+}
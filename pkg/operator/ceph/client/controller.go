@@ -22,7 +22,9 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -41,15 +43,26 @@ import (
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
 	controllerName = "ceph-client-controller"
+
+	// the keys of the secret of the client in the formats other than the default one
+	keyringSecretKey      = "keyring"
+	csiUserIDSecretKey    = "userID"
+	csiUserKeySecretKey   = "userKey"
+	mountOptionsSecretKey = "mountOptions"
+
+	// the reason of the event of a key rotated on request
+	keyRotatedReason = "KeyRotated"
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
@@ -69,6 +82,7 @@ type ReconcileCephClient struct {
 	context          *clusterd.Context
 	clusterInfo      *cephclient.ClusterInfo
 	opManagerContext context.Context
+	recorder         record.EventRecorder
 }
 
 // Add creates a new CephClient Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -84,12 +98,14 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 		scheme:           mgr.GetScheme(),
 		context:          context,
 		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephClient{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephClient{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -119,36 +135,34 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephClient) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
-	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
-	}
+	reconcileResponse, cephClient, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephClient, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileCephClient) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileCephClient) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephClient, error) {
 	// Fetch the CephClient instance
 	cephClient := &cephv1.CephClient{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephClient)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("cephClient resource not found. Ignoring since object must be deleted.")
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephClient, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get cephClient")
+		return reconcile.Result{}, cephClient, errors.Wrap(err, "failed to get cephClient")
 	}
 
 	// Set a finalizer so we can do cleanup before the object goes away
 	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephClient)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+		return reconcile.Result{}, cephClient, errors.Wrap(err, "failed to add finalizer")
 	}
 
 	// The CR was just created, initializing status fields
 	if cephClient.Status == nil {
-		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionProgressing)
+		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionProgressing, nil)
 	}
 
 	// Make sure a CephCluster is present otherwise do nothing
@@ -164,83 +178,99 @@ func (r *ReconcileCephClient) reconcile(request reconcile.Request) (reconcile.Re
 			// Remove finalizer
 			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephClient)
 			if err != nil {
-				return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to remove finalizer")
+				return opcontroller.ImmediateRetryResult, cephClient, errors.Wrap(err, "failed to remove finalizer")
 			}
 
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephClient, nil
 		}
-		return reconcileResponse, nil
+		return reconcileResponse, cephClient, nil
 	}
 
 	// Populate clusterInfo during each reconcile
 	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+		return reconcile.Result{}, cephClient, errors.Wrap(err, "failed to populate cluster info")
 	}
-	r.clusterInfo.Context = r.opManagerContext
+	r.clusterInfo.Context = exec.WithAuditInitiator(r.opManagerContext, "CephClient", request.Namespace, request.Name)
 
 	// DELETE: the CR was deleted
 	if !cephClient.GetDeletionTimestamp().IsZero() {
 		logger.Debugf("deleting pool %q", cephClient.Name)
 		err := r.deleteClient(cephClient)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "failed to delete ceph client %q", cephClient.Name)
+			return reconcile.Result{}, cephClient, errors.Wrapf(err, "failed to delete ceph client %q", cephClient.Name)
 		}
 
 		// Remove finalizer
 		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephClient)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			return reconcile.Result{}, cephClient, errors.Wrap(err, "failed to remove finalizer")
 		}
 
 		// Return and do not requeue. Successful deletion.
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, cephClient, nil
 	}
 
 	// validate the client settings
 	err = ValidateClient(r.context, cephClient)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrapf(err, "failed to validate client %q arguments", cephClient.Name)
+		return reconcile.Result{}, cephClient, errors.Wrapf(err, "failed to validate client %q arguments", cephClient.Name)
 	}
 
 	// Create or Update client
-	err = r.createOrUpdateClient(cephClient)
+	keyRotation, err := r.createOrUpdateClient(cephClient)
 	if err != nil {
 		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
 			logger.Info(opcontroller.OperatorNotInitializedMessage)
-			return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephClient, nil
 		}
-		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure)
-		return reconcile.Result{}, errors.Wrapf(err, "failed to create or update client %q", cephClient.Name)
+		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure, nil)
+		return reconcile.Result{}, cephClient, errors.Wrapf(err, "failed to create or update client %q", cephClient.Name)
 	}
 
 	// Success! Let's update the status
-	r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionReady)
+	r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionReady, keyRotation)
 
-	// Return and do not requeue
+	// Return and only requeue for the next periodic rotation of the key
 	logger.Debug("done reconciling")
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: opcontroller.KeyRotationRequeueAfter(cephClient.Spec.KeyRotation, keyRotation, time.Now())}, cephClient, nil
 }
 
-// Create the client
-func (r *ReconcileCephClient) createOrUpdateClient(cephClient *cephv1.CephClient) error {
+// Create the client, and rotate its key when due. It returns the status of the rotation of the key.
+func (r *ReconcileCephClient) createOrUpdateClient(cephClient *cephv1.CephClient) (*cephv1.KeyRotationStatus, error) {
 	logger.Infof("creating client %s in namespace %s", cephClient.Name, cephClient.Namespace)
 
 	// Generate the CephX details
 	clientEntity, caps := genClientEntity(cephClient)
 
+	var currentKeyRotation *cephv1.KeyRotationStatus
+	if cephClient.Status != nil {
+		currentKeyRotation = cephClient.Status.KeyRotation
+	}
+	keyRotation, rotate := opcontroller.RequestedKeyRotation(cephClient, cephClient.Spec.KeyRotation, currentKeyRotation, time.Now())
+
 	// Check if client was created manually, create if necessary or update caps and create secret
 	key, err := cephclient.AuthGetKey(r.context, r.clusterInfo, clientEntity)
 	if err != nil {
 		key, err = cephclient.AuthGetOrCreateKey(r.context, r.clusterInfo, clientEntity, caps)
 		if err != nil {
-			return errors.Wrapf(err, "failed to create client %q", cephClient.Name)
+			return nil, errors.Wrapf(err, "failed to create client %q", cephClient.Name)
 		}
 	} else {
 		err = cephclient.AuthUpdateCaps(r.context, r.clusterInfo, clientEntity, caps)
 		if err != nil {
-			return errors.Wrapf(err, "client %q exists, failed to update client caps", cephClient.Name)
+			return nil, errors.Wrapf(err, "client %q exists, failed to update client caps", cephClient.Name)
+		}
+		if rotate {
+			key, err = cephclient.AuthRotateKey(r.context, r.clusterInfo, clientEntity)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to rotate the key of client %q", cephClient.Name)
+			}
+			logger.Infof("rotated the key of client %q to generation %d", cephClient.Name, keyRotation.Generation)
+			if opcontroller.IsKeyRotationRequested(cephClient) {
+				r.recorder.Eventf(cephClient, v1.EventTypeNormal, keyRotatedReason, "rotated the key to generation %d as requested by the %q annotation", keyRotation.Generation, opcontroller.RotateKeyAnnotation)
+			}
 		}
 	}
 
@@ -250,16 +280,14 @@ func (r *ReconcileCephClient) createOrUpdateClient(cephClient *cephv1.CephClient
 			Name:      generateCephUserSecretName(cephClient),
 			Namespace: cephClient.Namespace,
 		},
-		StringData: map[string]string{
-			cephClient.Name: key,
-		},
-		Type: k8sutil.RookType,
+		StringData: generateSecretData(cephClient, key),
+		Type:       k8sutil.RookType,
 	}
 
 	// Set CephClient owner ref to the Secret
 	err = controllerutil.SetControllerReference(cephClient, secret, r.scheme)
 	if err != nil {
-		return errors.Wrapf(err, "failed to set owner reference to ceph client secret %q", secret.Name)
+		return nil, errors.Wrapf(err, "failed to set owner reference to ceph client secret %q", secret.Name)
 	}
 
 	// Create or Update Kubernetes Secret
@@ -268,23 +296,55 @@ func (r *ReconcileCephClient) createOrUpdateClient(cephClient *cephv1.CephClient
 		if kerrors.IsNotFound(err) {
 			logger.Debugf("creating secret for %q", secret.Name)
 			if _, err := r.context.Clientset.CoreV1().Secrets(cephClient.Namespace).Create(r.clusterInfo.Context, secret, metav1.CreateOptions{}); err != nil {
-				return errors.Wrapf(err, "failed to create secret for %q", secret.Name)
+				return nil, errors.Wrapf(err, "failed to create secret for %q", secret.Name)
 			}
 			logger.Infof("created client %q", cephClient.Name)
-			return nil
+			return keyRotation, r.removeKeyRotationAnnotation(cephClient)
 		}
-		return errors.Wrapf(err, "failed to get secret for %q", secret.Name)
+		return nil, errors.Wrapf(err, "failed to get secret for %q", secret.Name)
 	}
 	logger.Debugf("updating secret for %s", secret.Name)
 	_, err = r.context.Clientset.CoreV1().Secrets(cephClient.Namespace).Update(r.clusterInfo.Context, secret, metav1.UpdateOptions{})
 	if err != nil {
-		return errors.Wrapf(err, "failed to update secret for %q", secret.Name)
+		return nil, errors.Wrapf(err, "failed to update secret for %q", secret.Name)
 	}
 
 	logger.Infof("updated client %q", cephClient.Name)
+	return keyRotation, r.removeKeyRotationAnnotation(cephClient)
+}
+
+// removeKeyRotationAnnotation removes the rotate-key annotation of the client once the secret has the new key
+func (r *ReconcileCephClient) removeKeyRotationAnnotation(cephClient *cephv1.CephClient) error {
+	if err := opcontroller.RemoveKeyRotationAnnotation(r.opManagerContext, r.client, cephClient); err != nil {
+		return errors.Wrapf(err, "key of client %q rotated", cephClient.Name)
+	}
 	return nil
 }
 
+// generateSecretData returns the content of the secret of the client with the key in each of the
+// formats of the spec, the key under the name of the client by default
+func generateSecretData(cephClient *cephv1.CephClient, key string) map[string]string {
+	formats := cephClient.Spec.SecretFormats
+	if len(formats) == 0 {
+		formats = []cephv1.ClientSecretFormat{cephv1.ClientSecretFormatKey}
+	}
+	data := map[string]string{}
+	for _, format := range formats {
+		switch format {
+		case cephv1.ClientSecretFormatKey:
+			data[cephClient.Name] = key
+		case cephv1.ClientSecretFormatKeyring:
+			data[keyringSecretKey] = fmt.Sprintf("[%s]\n\tkey = %s\n", generateClientName(cephClient.Name), key)
+		case cephv1.ClientSecretFormatCSI:
+			data[csiUserIDSecretKey] = cephClient.Name
+			data[csiUserKeySecretKey] = key
+		case cephv1.ClientSecretFormatMount:
+			data[mountOptionsSecretKey] = fmt.Sprintf("name=%s,secret=%s", cephClient.Name, key)
+		}
+	}
+	return data
+}
+
 // Delete the client
 func (r *ReconcileCephClient) deleteClient(cephClient *cephv1.CephClient) error {
 	logger.Infof("deleting client object %q", cephClient.Name)
@@ -308,7 +368,7 @@ func ValidateClient(context *clusterd.Context, cephClient *cephv1.CephClient) er
 	}
 
 	// Validate Spec
-	if cephClient.Spec.Caps == nil {
+	if cephClient.Spec.Caps == nil && cephClient.Spec.CapsTemplate == nil {
 		return errors.New("no caps specified")
 	}
 	for _, cap := range cephClient.Spec.Caps {
@@ -316,14 +376,37 @@ func ValidateClient(context *clusterd.Context, cephClient *cephv1.CephClient) er
 			return errors.New("no caps specified")
 		}
 	}
+	if cephClient.Spec.CapsTemplate != nil {
+		if err := validateCapsTemplate(cephClient.Spec.CapsTemplate); err != nil {
+			return err
+		}
+	}
+	for _, format := range cephClient.Spec.SecretFormats {
+		switch format {
+		case cephv1.ClientSecretFormatKey, cephv1.ClientSecretFormatKeyring, cephv1.ClientSecretFormatCSI, cephv1.ClientSecretFormatMount:
+		default:
+			return errors.Errorf("unknown secret format %q", format)
+		}
+	}
 
 	return nil
 }
 
+// genClientEntity returns the entity of the client and its caps, the caps of the template overridden
+// by the raw caps of the spec, sorted by daemon type
 func genClientEntity(cephClient *cephv1.CephClient) (string, []string) {
-	caps := []string{}
+	allCaps := templateCaps(cephClient.Spec.CapsTemplate)
 	for name, cap := range cephClient.Spec.Caps {
-		caps = append(caps, name, cap)
+		allCaps[name] = cap
+	}
+	names := make([]string, 0, len(allCaps))
+	for name := range allCaps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	caps := []string{}
+	for _, name := range names {
+		caps = append(caps, name, allCaps[name])
 	}
 
 	return generateClientName(cephClient.Name), caps
@@ -333,8 +416,9 @@ func generateClientName(name string) string {
 	return fmt.Sprintf("client.%s", name)
 }
 
-// updateStatus updates an object with a given status
-func (r *ReconcileCephClient) updateStatus(client client.Client, name types.NamespacedName, status cephv1.ConditionType) {
+// updateStatus updates an object with a given status, and with the status of the rotation of its key
+// when not nil
+func (r *ReconcileCephClient) updateStatus(client client.Client, name types.NamespacedName, status cephv1.ConditionType, keyRotation *cephv1.KeyRotationStatus) {
 	cephClient := &cephv1.CephClient{}
 	if err := client.Get(r.opManagerContext, name, cephClient); err != nil {
 		if kerrors.IsNotFound(err) {
@@ -344,15 +428,20 @@ func (r *ReconcileCephClient) updateStatus(client client.Client, name types.Name
 		logger.Warningf("failed to retrieve ceph client %q to update status to %q. %v", name, status, err)
 		return
 	}
-	if cephClient.Status == nil {
-		cephClient.Status = &cephv1.CephClientStatus{}
-	}
-
-	cephClient.Status.Phase = status
-	if cephClient.Status.Phase == cephv1.ConditionReady {
-		cephClient.Status.Info = generateStatusInfo(cephClient)
-	}
-	if err := reporting.UpdateStatus(client, cephClient); err != nil {
+	err := reporting.PatchStatus(client, cephClient, func() bool {
+		if cephClient.Status == nil {
+			cephClient.Status = &cephv1.CephClientStatus{}
+		}
+		cephClient.Status.Phase = status
+		if cephClient.Status.Phase == cephv1.ConditionReady {
+			cephClient.Status.Info = generateStatusInfo(cephClient)
+		}
+		if keyRotation != nil {
+			cephClient.Status.KeyRotation = keyRotation
+		}
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set ceph client %q status to %q. %v", name, status, err)
 		return
 	}
@@ -22,12 +22,14 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -36,6 +38,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -168,6 +171,7 @@ func TestCephClientController(t *testing.T) {
 
 	// Create a ReconcileCephClient object with the scheme and fake client.
 	r := &ReconcileCephClient{
+		recorder:         &record.FakeRecorder{},
 		client:           cl,
 		scheme:           s,
 		context:          c,
@@ -216,6 +220,7 @@ func TestCephClientController(t *testing.T) {
 	cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 	// Create a ReconcileCephClient object with the scheme and fake client.
 	r = &ReconcileCephClient{
+		recorder:         &record.FakeRecorder{},
 		client:           cl,
 		scheme:           s,
 		context:          c,
@@ -274,6 +279,7 @@ func TestCephClientController(t *testing.T) {
 	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPoolList{})
 	// Create a ReconcileCephClient object with the scheme and fake client.
 	r = &ReconcileCephClient{
+		recorder:         &record.FakeRecorder{},
 		client:           cl,
 		scheme:           s,
 		context:          c,
@@ -291,6 +297,129 @@ func TestCephClientController(t *testing.T) {
 	cephClientSecret, err := c.Clientset.CoreV1().Secrets(namespace).Get(ctx, cephClient.Status.Info["secretName"], metav1.GetOptions{})
 	assert.NoError(t, err)
 	assert.NotEmpty(t, cephClientSecret.StringData)
+	assert.Equal(t, 0, cephClient.Status.KeyRotation.Generation)
+
+	//
+	// TEST 4:
+	//
+	// SUCCESS! The key of the existing client is rotated on demand
+	//
+	logger.Info("RUN 4")
+	imported := false
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if command == "ceph-authtool" {
+			return "AQBrotatedkey==\n", nil
+		}
+		if args[0] == "status" {
+			return `{"fsid":"c47cac40-9bee-4d52-823b-ccd803ba5bfe","health":{"checks":{},"status":"HEALTH_OK"},"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+		}
+		if args[0] == "auth" && args[1] == "get-key" {
+			return `{"key":"AQCvzWBeIV9lFRAAninzm+8XFxbSfTiPwoX50g=="}`, nil
+		}
+		if args[0] == "auth" && args[1] == "get" {
+			return `[{"entity":"client.my-client","key":"AQCvzWBeIV9lFRAAninzm+8XFxbSfTiPwoX50g==","caps":{"mon":"allow *","osd":"allow *"}}]`, nil
+		}
+		if args[0] == "auth" && args[1] == "import" {
+			imported = true
+		}
+		return "", nil
+	}
+	cephClient.Spec.KeyRotation = &cephv1.KeyRotationPolicySpec{Generation: 1, Period: &metav1.Duration{Duration: time.Hour}}
+	assert.NoError(t, r.client.Update(ctx, cephClient))
+
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, imported)
+	assert.Greater(t, res.RequeueAfter, 59*time.Minute)
+	err = r.client.Get(context.TODO(), req.NamespacedName, cephClient)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cephClient.Status.KeyRotation.Generation)
+	cephClientSecret, err = c.Clientset.CoreV1().Secrets(namespace).Get(ctx, cephClient.Status.Info["secretName"], metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "AQBrotatedkey==", cephClientSecret.StringData[name])
+
+	//
+	// TEST 5:
+	//
+	// SUCCESS! The key is rotated on request with the annotation and published in the secret formats
+	//
+	logger.Info("RUN 5")
+	imported = false
+	cephClient.Annotations = map[string]string{opcontroller.RotateKeyAnnotation: "true"}
+	cephClient.Spec.SecretFormats = []cephv1.ClientSecretFormat{cephv1.ClientSecretFormatCSI, cephv1.ClientSecretFormatMount}
+	assert.NoError(t, r.client.Update(ctx, cephClient))
+
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, imported)
+	err = r.client.Get(context.TODO(), req.NamespacedName, cephClient)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cephClient.Status.KeyRotation.Generation)
+	assert.NotContains(t, cephClient.Annotations, opcontroller.RotateKeyAnnotation)
+	cephClientSecret, err = c.Clientset.CoreV1().Secrets(namespace).Get(ctx, cephClient.Status.Info["secretName"], metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"userID":       name,
+		"userKey":      "AQBrotatedkey==",
+		"mountOptions": "name=" + name + ",secret=AQBrotatedkey==",
+	}, cephClientSecret.StringData)
+}
+
+func TestGenerateSecretData(t *testing.T) {
+	p := &cephv1.CephClient{ObjectMeta: metav1.ObjectMeta{Name: "client1", Namespace: "myns"}}
+	assert.Equal(t, map[string]string{"client1": "AQBkey=="}, generateSecretData(p, "AQBkey=="))
+
+	p.Spec.SecretFormats = []cephv1.ClientSecretFormat{cephv1.ClientSecretFormatKey, cephv1.ClientSecretFormatKeyring}
+	assert.Equal(t, map[string]string{
+		"client1": "AQBkey==",
+		"keyring": "[client.client1]\n\tkey = AQBkey==\n",
+	}, generateSecretData(p, "AQBkey=="))
+}
+
+func TestCapsTemplate(t *testing.T) {
+	context := &clusterd.Context{Executor: &exectest.MockExecutor{}}
+	p := &cephv1.CephClient{ObjectMeta: metav1.ObjectMeta{Name: "client1", Namespace: "myns"}}
+
+	t.Run("rbd user on pool", func(t *testing.T) {
+		p.Spec.CapsTemplate = &cephv1.ClientCapsTemplateSpec{Name: cephv1.ClientCapsTemplateRBDUserOnPool}
+		assert.Error(t, ValidateClient(context, p))
+		p.Spec.CapsTemplate.Pool = "replicapool"
+		assert.NoError(t, ValidateClient(context, p))
+		_, caps := genClientEntity(p)
+		assert.Equal(t, []string{"mgr", "profile rbd pool=replicapool", "mon", "profile rbd", "osd", "profile rbd pool=replicapool"}, caps)
+
+		p.Spec.CapsTemplate.RadosNamespace = "ns"
+		_, caps = genClientEntity(p)
+		assert.Equal(t, []string{"mgr", "profile rbd pool=replicapool namespace=ns", "mon", "profile rbd", "osd", "profile rbd pool=replicapool namespace=ns"}, caps)
+
+		p.Spec.CapsTemplate.Filesystem = "myfs"
+		assert.Error(t, ValidateClient(context, p))
+	})
+
+	t.Run("cephfs subvolume scoped", func(t *testing.T) {
+		p.Spec.CapsTemplate = &cephv1.ClientCapsTemplateSpec{Name: cephv1.ClientCapsTemplateCephFSSubVolumeScoped}
+		assert.Error(t, ValidateClient(context, p))
+		p.Spec.CapsTemplate.Filesystem = "myfs"
+		assert.NoError(t, ValidateClient(context, p))
+		_, caps := genClientEntity(p)
+		assert.Equal(t, []string{"mds", "allow rw fsname=myfs path=/volumes/csi", "mon", "allow r fsname=myfs", "osd", "allow rw tag cephfs data=myfs"}, caps)
+
+		p.Spec.CapsTemplate.SubVolumeGroup = "group"
+		p.Spec.CapsTemplate.SubVolume = "subvol"
+		_, caps = genClientEntity(p)
+		assert.Equal(t, "allow rw fsname=myfs path=/volumes/group/subvol", caps[1])
+	})
+
+	t.Run("raw caps override the template", func(t *testing.T) {
+		p.Spec.Caps = map[string]string{"mgr": "allow rw"}
+		_, caps := genClientEntity(p)
+		assert.Equal(t, []string{"mds", "allow rw fsname=myfs path=/volumes/group/subvol", "mgr", "allow rw", "mon", "allow r fsname=myfs", "osd", "allow rw tag cephfs data=myfs"}, caps)
+	})
+
+	t.Run("unknown secret format", func(t *testing.T) {
+		p.Spec.SecretFormats = []cephv1.ClientSecretFormat{"unknown"}
+		assert.Error(t, ValidateClient(context, p))
+	})
 }
 
 func TestBuildUpdateStatusInfo(t *testing.T) {
@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+const (
+	// the silences are created for this duration and extended while the cluster is upgrading or in
+	// maintenance, so that they expire if the operator stops
+	alertSilenceDuration = 30 * time.Minute
+	// the silences are extended when they expire in less than this duration
+	alertSilenceRenewal = 20 * time.Minute
+	alertSilenceActive  = "active"
+)
+
+var (
+	// the client the alertmanager API is called with, overridden by the tests
+	alertmanagerHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+	// the alerts silenced by default, of the daemons being restarted or down and of the data
+	// degraded meanwhile
+	defaultSilencedAlerts = []string{
+		"CephClusterWarningState",
+		"CephDataRecoveryTakingTooLong",
+		"CephMdsMissingReplicas",
+		"CephMgrIsAbsent",
+		"CephMgrIsMissingReplicas",
+		"CephMonVersionMismatch",
+		"CephNodeDown",
+		"CephOSDDiskNotResponding",
+		"CephOSDDiskUnavailable",
+		"CephOSDVersionMismatch",
+	}
+
+	// the health checks raised when the noout or other flags are set on the cluster, its OSDs or
+	// their failure domains, such as while the nodes of a failure domain are drained
+	maintenanceHealthChecks = []string{"OSDMAP_FLAGS", "OSD_FLAGS"}
+)
+
+type silenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// alertSilence is a silence of the alertmanager API v2
+type alertSilence struct {
+	ID        string           `json:"id,omitempty"`
+	Matchers  []silenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+	Status    *struct {
+		State string `json:"state"`
+	} `json:"status,omitempty"`
+}
+
+// silenceReason returns why the alerts of the cluster must be silenced, or an empty string
+func silenceReason(status *cephclient.CephStatus, versions *cephv1.CephDaemonsVersions) string {
+	if versions != nil && len(versions.Overall) > 1 {
+		return "upgrading"
+	}
+	for _, check := range maintenanceHealthChecks {
+		if _, ok := status.Health.Checks[check]; ok {
+			return "in maintenance"
+		}
+	}
+	return ""
+}
+
+// reconcileAlertSilences creates the silence of the alerts of the cluster when it is upgrading or in
+// maintenance, extends it while it is, and expires it when it is done. The silence of a cluster is
+// found by its creator, which is unique per cluster namespace.
+func reconcileAlertSilences(ctx context.Context, cephCluster *cephv1.CephCluster, status *cephclient.CephStatus) error {
+	spec := cephCluster.Spec.Monitoring.AlertSilences
+	if spec == nil || spec.AlertmanagerURL == "" {
+		return nil
+	}
+	url := strings.TrimSuffix(spec.AlertmanagerURL, "/")
+	creator := fmt.Sprintf("rook-ceph-operator/%s", cephCluster.Namespace)
+
+	silences := []alertSilence{}
+	if err := callAlertmanager(ctx, http.MethodGet, url+"/api/v2/silences", nil, &silences); err != nil {
+		return errors.Wrap(err, "failed to list the alertmanager silences")
+	}
+	var current *alertSilence
+	for i, silence := range silences {
+		if silence.CreatedBy == creator && silence.Status != nil && silence.Status.State == alertSilenceActive {
+			current = &silences[i]
+			break
+		}
+	}
+
+	var reason string
+	if cephCluster.Spec.Monitoring.Enabled && spec.Enabled {
+		var versions *cephv1.CephDaemonsVersions
+		if cephCluster.Status.CephStatus != nil {
+			versions = cephCluster.Status.CephStatus.Versions
+		}
+		reason = silenceReason(status, versions)
+	}
+	if reason == "" {
+		if current == nil {
+			return nil
+		}
+		if err := callAlertmanager(ctx, http.MethodDelete, url+"/api/v2/silence/"+current.ID, nil, nil); err != nil {
+			return errors.Wrapf(err, "failed to expire alertmanager silence %q", current.ID)
+		}
+		logger.Infof("expired the alertmanager silence of the alerts of cluster in namespace %q", cephCluster.Namespace)
+		return nil
+	}
+
+	silence := newAlertSilence(cephCluster.Namespace, creator, reason, spec.Alerts)
+	if current != nil {
+		if time.Until(current.EndsAt) > alertSilenceRenewal && sameSilence(current, &silence) {
+			return nil
+		}
+		silence.ID = current.ID
+	}
+	if err := callAlertmanager(ctx, http.MethodPost, url+"/api/v2/silences", &silence, nil); err != nil {
+		return errors.Wrap(err, "failed to create the alertmanager silence")
+	}
+	if current == nil {
+		logger.Infof("silenced the alerts of cluster in namespace %q in alertmanager while it is %s", cephCluster.Namespace, reason)
+	}
+	return nil
+}
+
+// newAlertSilence returns the silence of the alerts of a cluster. The alerts without a namespace are
+// also silenced since the alerts aggregating the metrics of the cluster lose their namespace.
+func newAlertSilence(namespace, creator, reason string, alerts []string) alertSilence {
+	if len(alerts) == 0 {
+		alerts = defaultSilencedAlerts
+	}
+	names := []string{}
+	for _, alert := range alerts {
+		names = append(names, regexp.QuoteMeta(alert))
+	}
+	now := time.Now()
+	return alertSilence{
+		Matchers: []silenceMatcher{
+			{Name: "alertname", Value: strings.Join(names, "|"), IsRegex: true, IsEqual: true},
+			{Name: "namespace", Value: regexp.QuoteMeta(namespace) + "|", IsRegex: true, IsEqual: true},
+		},
+		StartsAt:  now,
+		EndsAt:    now.Add(alertSilenceDuration),
+		CreatedBy: creator,
+		Comment:   fmt.Sprintf("The ceph cluster in namespace %q is %s", namespace, reason),
+	}
+}
+
+// sameSilence returns whether two silences silence the same alerts for the same reason
+func sameSilence(a, b *alertSilence) bool {
+	if a.Comment != b.Comment || len(a.Matchers) != len(b.Matchers) {
+		return false
+	}
+	for i := range a.Matchers {
+		if a.Matchers[i] != b.Matchers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// callAlertmanager calls the alertmanager API with the json of the request, and decodes the response
+// in the result if not nil
+func callAlertmanager(ctx context.Context, method, url string, request, result interface{}) error {
+	var body io.Reader
+	if request != nil {
+		content, err := json.Marshal(request)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode the request")
+		}
+		body = bytes.NewReader(content)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the request")
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := alertmanagerHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("unexpected status %q. %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeAlertmanager serves the silences of the alertmanager API v2
+type fakeAlertmanager struct {
+	silences []alertSilence
+	posts    int
+}
+
+func (a *fakeAlertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/silences":
+		_ = json.NewEncoder(w).Encode(a.silences)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+		silence := alertSilence{}
+		if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		a.posts++
+		silence.Status = &struct {
+			State string `json:"state"`
+		}{State: alertSilenceActive}
+		if silence.ID == "" {
+			silence.ID = fmt.Sprintf("silence-%d", len(a.silences))
+			a.silences = append(a.silences, silence)
+		} else {
+			for i := range a.silences {
+				if a.silences[i].ID == silence.ID {
+					a.silences[i] = silence
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"silenceID": silence.ID})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v2/silence/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/v2/silence/")
+		for i := range a.silences {
+			if a.silences[i].ID == id {
+				a.silences[i].Status.State = "expired"
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (a *fakeAlertmanager) active() []alertSilence {
+	active := []alertSilence{}
+	for _, silence := range a.silences {
+		if silence.Status.State == alertSilenceActive {
+			active = append(active, silence)
+		}
+	}
+	return active
+}
+
+func TestReconcileAlertSilences(t *testing.T) {
+	alertmanager := &fakeAlertmanager{}
+	server := httptest.NewServer(alertmanager)
+	defer server.Close()
+	alertmanagerHTTPClient = server.Client()
+
+	ctx := context.TODO()
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "rook-ceph"},
+		Spec: cephv1.ClusterSpec{
+			Monitoring: cephv1.MonitoringSpec{
+				Enabled:       true,
+				AlertSilences: &cephv1.AlertSilencesSpec{Enabled: true, AlertmanagerURL: server.URL + "/"},
+			},
+		},
+		Status: cephv1.ClusterStatus{
+			CephStatus: &cephv1.CephStatus{
+				Versions: &cephv1.CephDaemonsVersions{Overall: map[string]int{"ceph version 16.2.7": 6}},
+			},
+		},
+	}
+	healthy := &cephclient.CephStatus{}
+	healthy.Health.Status = "HEALTH_OK"
+
+	t.Run("healthy cluster", func(t *testing.T) {
+		assert.NoError(t, reconcileAlertSilences(ctx, cephCluster, healthy))
+		assert.Equal(t, 0, len(alertmanager.active()))
+	})
+
+	t.Run("upgrading cluster", func(t *testing.T) {
+		cephCluster.Status.CephStatus.Versions.Overall["ceph version 17.2.0"] = 2
+		assert.NoError(t, reconcileAlertSilences(ctx, cephCluster, healthy))
+		active := alertmanager.active()
+		assert.Equal(t, 1, len(active))
+		assert.Equal(t, "rook-ceph-operator/rook-ceph", active[0].CreatedBy)
+		assert.Equal(t, `The ceph cluster in namespace "rook-ceph" is upgrading`, active[0].Comment)
+		assert.Contains(t, active[0].Matchers[0].Value, "CephOSDDiskNotResponding")
+		assert.Equal(t, "rook-ceph|", active[0].Matchers[1].Value)
+
+		// the silence is not extended before it is about to expire
+		assert.NoError(t, reconcileAlertSilences(ctx, cephCluster, healthy))
+		assert.Equal(t, 1, alertmanager.posts)
+		alertmanager.silences[0].EndsAt = time.Now().Add(5 * time.Minute)
+		assert.NoError(t, reconcileAlertSilences(ctx, cephCluster, healthy))
+		assert.Equal(t, 2, alertmanager.posts)
+		assert.Equal(t, 1, len(alertmanager.active()))
+		assert.True(t, time.Until(alertmanager.silences[0].EndsAt) > alertSilenceRenewal)
+	})
+
+	t.Run("cluster in maintenance", func(t *testing.T) {
+		delete(cephCluster.Status.CephStatus.Versions.Overall, "ceph version 17.2.0")
+		cephCluster.Spec.Monitoring.AlertSilences.Alerts = []string{"CephNodeDown"}
+		status := &cephclient.CephStatus{}
+		status.Health.Checks = map[string]cephclient.CheckMessage{"OSD_FLAGS": {Severity: "HEALTH_WARN"}}
+		assert.NoError(t, reconcileAlertSilences(ctx, cephCluster, status))
+		active := alertmanager.active()
+		assert.Equal(t, 1, len(active))
+		assert.Equal(t, `The ceph cluster in namespace "rook-ceph" is in maintenance`, active[0].Comment)
+		assert.Equal(t, "CephNodeDown", active[0].Matchers[0].Value)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		assert.NoError(t, reconcileAlertSilences(ctx, cephCluster, healthy))
+		assert.Equal(t, 0, len(alertmanager.active()))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cephCluster.Status.CephStatus.Versions.Overall["ceph version 17.2.0"] = 2
+		cephCluster.Spec.Monitoring.AlertSilences.Enabled = false
+		assert.NoError(t, reconcileAlertSilences(ctx, cephCluster, healthy))
+		assert.Equal(t, 0, len(alertmanager.active()))
+	})
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// the usage is sampled at this interval and the growth computed over the samples of the window
+	capacitySampleInterval = 5 * time.Minute
+	capacityForecastWindow = 24 * time.Hour
+	// the growth is not forecasted until the samples span this duration
+	capacityForecastMinSpan = time.Hour
+	// the full ratio of the OSDs if not found in the osd map
+	defaultFullRatio = 0.95
+)
+
+var (
+	poolGrowth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_pool_growth_bytes_per_day",
+		Help: "The growth per day of the data stored in the pool over the last day",
+	}, []string{"namespace", "pool"})
+	poolDaysUntilFull = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_pool_days_until_full",
+		Help: "The number of days until the pool cannot store more data at its current growth, not set when the pool does not grow",
+	}, []string{"namespace", "pool"})
+	deviceClassGrowth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_device_class_growth_bytes_per_day",
+		Help: "The growth per day of the raw capacity used on the OSDs of the device class over the last day",
+	}, []string{"namespace", "device_class"})
+	deviceClassDaysUntilFull = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_device_class_days_until_full",
+		Help: "The number of days until the OSDs of the device class reach their full ratio at the current growth, not set when the usage does not grow",
+	}, []string{"namespace", "device_class"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(poolGrowth, poolDaysUntilFull, deviceClassGrowth, deviceClassDaysUntilFull)
+}
+
+// capacitySample is the used and available bytes of a pool or a device class at a time
+type capacitySample struct {
+	time      time.Time
+	used      float64
+	available float64
+}
+
+// capacityForecaster keeps the recent usage of the pools and the device classes of a cluster, to
+// project when they are full from their growth. The samples are kept in memory and are lost when the
+// operator restarts, the forecasts are then computed again once the samples span an hour.
+type capacityForecaster struct {
+	namespace     string
+	lastSample    time.Time
+	pools         map[string][]capacitySample
+	deviceClasses map[string][]capacitySample
+	// the forecasts of the last sample, nil until the usage was sampled
+	forecast *cephv1.CapacityForecast
+	// the pools and device classes whose forecast was exported, to remove their metrics when gone
+	exportedPools         map[string]bool
+	exportedDeviceClasses map[string]bool
+}
+
+func newCapacityForecaster(namespace string) *capacityForecaster {
+	return &capacityForecaster{
+		namespace:             namespace,
+		pools:                 map[string][]capacitySample{},
+		deviceClasses:         map[string][]capacitySample{},
+		exportedPools:         map[string]bool{},
+		exportedDeviceClasses: map[string]bool{},
+	}
+}
+
+// sample adds the current usage of the pools and the device classes to the samples and updates the
+// forecasts, unless the usage was sampled less than the sample interval ago
+func (f *capacityForecaster) sample(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo) error {
+	now := time.Now()
+	if now.Sub(f.lastSample) < capacitySampleInterval {
+		return nil
+	}
+	stats, err := cephclient.GetPoolStats(context, clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the usage of the pools")
+	}
+	osdDump, err := cephclient.GetOSDDump(context, clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the full ratio of the osds")
+	}
+	f.addSamples(now, stats, osdDump.FullRatio)
+	return nil
+}
+
+// addSamples adds the usage of the pools and of the device classes at the given time. The pools can
+// store their max available bytes, which accounts for their replication and the full ratio, and the
+// device classes can use their raw capacity until the full ratio.
+func (f *capacityForecaster) addSamples(now time.Time, stats *cephclient.CephStoragePoolStats, fullRatio float64) {
+	if fullRatio == 0 {
+		fullRatio = defaultFullRatio
+	}
+	pools := map[string]capacitySample{}
+	for _, pool := range stats.Pools {
+		pools[pool.Name] = capacitySample{time: now, used: pool.Stats.Stored, available: pool.Stats.MaxAvail}
+	}
+	deviceClasses := map[string]capacitySample{}
+	for name, class := range stats.StatsByClass {
+		available := math.Max(class.TotalBytes*fullRatio-class.TotalUsedRawBytes, 0)
+		deviceClasses[name] = capacitySample{time: now, used: class.TotalUsedRawBytes, available: available}
+	}
+
+	f.lastSample = now
+	f.pools = addCapacitySamples(f.pools, pools, now)
+	f.deviceClasses = addCapacitySamples(f.deviceClasses, deviceClasses, now)
+	f.forecast = &cephv1.CapacityForecast{LastUpdated: formatTime(now.UTC())}
+	f.forecast.Pools, f.exportedPools = f.setForecasts(f.pools, f.exportedPools, poolGrowth, poolDaysUntilFull)
+	f.forecast.DeviceClasses, f.exportedDeviceClasses = f.setForecasts(f.deviceClasses, f.exportedDeviceClasses, deviceClassGrowth, deviceClassDaysUntilFull)
+}
+
+// addCapacitySamples returns the samples with the new samples, without the samples older than the
+// forecast window or of the pools or device classes that are gone
+func addCapacitySamples(series map[string][]capacitySample, samples map[string]capacitySample, now time.Time) map[string][]capacitySample {
+	updated := map[string][]capacitySample{}
+	for name, sample := range samples {
+		recent := []capacitySample{}
+		for _, previous := range series[name] {
+			if now.Sub(previous.time) <= capacityForecastWindow {
+				recent = append(recent, previous)
+			}
+		}
+		updated[name] = append(recent, sample)
+	}
+	return updated
+}
+
+// setForecasts exports the forecasts of the series and returns them sorted by name, with the names
+// exported. The metrics of the names previously exported without forecast anymore are removed.
+func (f *capacityForecaster) setForecasts(series map[string][]capacitySample, exported map[string]bool, growthMetric, daysMetric *prometheus.GaugeVec) ([]cephv1.UsageForecast, map[string]bool) {
+	forecasts := []cephv1.UsageForecast{}
+	forecasted := map[string]bool{}
+	for name, samples := range series {
+		forecast, ok := forecastUsage(samples)
+		if !ok {
+			continue
+		}
+		forecast.Name = name
+		forecasts = append(forecasts, forecast)
+		forecasted[name] = true
+
+		growthMetric.WithLabelValues(f.namespace, name).Set(float64(forecast.GrowthBytesPerDay))
+		if forecast.DaysUntilFull != nil {
+			daysMetric.WithLabelValues(f.namespace, name).Set(float64(*forecast.DaysUntilFull))
+		} else {
+			daysMetric.DeleteLabelValues(f.namespace, name)
+		}
+	}
+	for name := range exported {
+		if !forecasted[name] {
+			growthMetric.DeleteLabelValues(f.namespace, name)
+			daysMetric.DeleteLabelValues(f.namespace, name)
+		}
+	}
+	sort.Slice(forecasts, func(i, j int) bool { return forecasts[i].Name < forecasts[j].Name })
+	return forecasts, forecasted
+}
+
+// forecastUsage computes the growth of the usage with a linear regression of the samples, and the days
+// until the available bytes of the last sample are used at this growth
+func forecastUsage(samples []capacitySample) (cephv1.UsageForecast, bool) {
+	if len(samples) < 2 || samples[len(samples)-1].time.Sub(samples[0].time) < capacityForecastMinSpan {
+		return cephv1.UsageForecast{}, false
+	}
+	// the times are relative to the first sample to keep the precision of the regression
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.time.Sub(samples[0].time).Hours() / 24
+		sumX += x
+		sumY += sample.used
+		sumXY += x * sample.used
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	growth := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+
+	forecast := cephv1.UsageForecast{GrowthBytesPerDay: int64(math.Round(growth))}
+	if forecast.GrowthBytesPerDay > 0 {
+		days := int64(math.Floor(samples[len(samples)-1].available / growth))
+		forecast.DaysUntilFull = &days
+	}
+	return forecast, true
+}
+
+// clear removes the metrics of the cluster, when it is not monitored anymore
+func (f *capacityForecaster) clear() {
+	for name := range f.exportedPools {
+		poolGrowth.DeleteLabelValues(f.namespace, name)
+		poolDaysUntilFull.DeleteLabelValues(f.namespace, name)
+	}
+	for name := range f.exportedDeviceClasses {
+		deviceClassGrowth.DeleteLabelValues(f.namespace, name)
+		deviceClassDaysUntilFull.DeleteLabelValues(f.namespace, name)
+	}
+	f.exportedPools = map[string]bool{}
+	f.exportedDeviceClasses = map[string]bool{}
+	f.pools = map[string][]capacitySample{}
+	f.deviceClasses = map[string][]capacitySample{}
+	f.lastSample = time.Time{}
+	f.forecast = nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+const gib = 1024 * 1024 * 1024
+
+// poolStats returns the usage of the replicapool and the ssd device class, and of the shrinking pool if
+// its usage is not zero
+func poolStats(t *testing.T, stored, shrinking float64) *cephclient.CephStoragePoolStats {
+	pools := []map[string]interface{}{
+		{"name": "replicapool", "id": 1, "stats": map[string]float64{"stored": stored, "max_avail": 10*gib - stored}},
+	}
+	if shrinking != 0 {
+		pools = append(pools, map[string]interface{}{"name": "shrinking", "id": 2, "stats": map[string]float64{"stored": shrinking, "max_avail": 10 * gib}})
+	}
+	output, err := json.Marshal(map[string]interface{}{
+		"pools": pools,
+		"stats_by_class": map[string]map[string]float64{
+			"ssd": {"total_bytes": 100 * gib, "total_avail_bytes": 100*gib - 3*stored, "total_used_raw_bytes": 3 * stored},
+		},
+	})
+	assert.NoError(t, err)
+	stats := &cephclient.CephStoragePoolStats{}
+	assert.NoError(t, json.Unmarshal(output, stats))
+	return stats
+}
+
+func TestCapacityForecaster(t *testing.T) {
+	f := newCapacityForecaster("rook-ceph")
+	defer f.clear()
+
+	// the pool grows by 1GiB per day, sampled every 5 minutes
+	start := time.Now().Add(-2 * time.Hour)
+	growth := float64(gib) / (24 * 12)
+	sampleFor := func(from, to int) {
+		for i := from; i < to; i++ {
+			f.addSamples(start.Add(time.Duration(i)*capacitySampleInterval), poolStats(t, 2*gib+float64(i)*growth, 5*gib-float64(i)*growth), 0)
+		}
+	}
+
+	t.Run("not enough samples", func(t *testing.T) {
+		sampleFor(0, 6)
+		assert.NotNil(t, f.forecast)
+		assert.Empty(t, f.forecast.Pools)
+		assert.Empty(t, f.forecast.DeviceClasses)
+		assert.Equal(t, 0, testutil.CollectAndCount(poolGrowth))
+	})
+
+	t.Run("growing pools", func(t *testing.T) {
+		sampleFor(6, 24)
+		assert.Equal(t, 2, len(f.forecast.Pools))
+		pool := f.forecast.Pools[0]
+		assert.Equal(t, "replicapool", pool.Name)
+		assert.InDelta(t, gib, pool.GrowthBytesPerDay, 1024)
+		// 10GiB minus the 2GiB stored and the growth of the 23 samples, at 1GiB per day
+		assert.Equal(t, int64(7), *pool.DaysUntilFull)
+		assert.Equal(t, float64(7), testutil.ToFloat64(poolDaysUntilFull.WithLabelValues("rook-ceph", "replicapool")))
+
+		shrinking := f.forecast.Pools[1]
+		assert.Equal(t, "shrinking", shrinking.Name)
+		assert.InDelta(t, -gib, shrinking.GrowthBytesPerDay, 1024)
+		assert.Nil(t, shrinking.DaysUntilFull)
+		assert.Equal(t, 2, testutil.CollectAndCount(poolGrowth))
+		assert.Equal(t, 1, testutil.CollectAndCount(poolDaysUntilFull))
+
+		// the ssd class can use 95GiB of raw capacity and grows by 3GiB per day
+		class := f.forecast.DeviceClasses[0]
+		assert.Equal(t, "ssd", class.Name)
+		assert.InDelta(t, 3*gib, class.GrowthBytesPerDay, 1024)
+		assert.Equal(t, int64(29), *class.DaysUntilFull)
+	})
+
+	t.Run("deleted pool", func(t *testing.T) {
+		f.addSamples(start.Add(24*capacitySampleInterval), poolStats(t, 3*gib, 0), 0)
+		assert.Equal(t, 1, len(f.forecast.Pools))
+		assert.Equal(t, 1, testutil.CollectAndCount(poolGrowth))
+	})
+
+	t.Run("old samples are removed", func(t *testing.T) {
+		f.addSamples(start.Add(capacityForecastWindow+3*time.Hour), poolStats(t, 3*gib, 0), 0)
+		assert.Equal(t, 1, len(f.pools["replicapool"]))
+		assert.Empty(t, f.forecast.Pools)
+		assert.Equal(t, 0, testutil.CollectAndCount(poolGrowth))
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		start = start.Add(2 * capacityForecastWindow)
+		sampleFor(0, 24)
+		assert.Equal(t, 1, testutil.CollectAndCount(deviceClassGrowth))
+		f.clear()
+		assert.Nil(t, f.forecast)
+		assert.Equal(t, 0, testutil.CollectAndCount(deviceClassGrowth))
+		assert.Equal(t, 0, testutil.CollectAndCount(poolGrowth))
+	})
+}
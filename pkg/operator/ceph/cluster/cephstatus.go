@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -51,6 +52,12 @@ type cephStatusChecker struct {
 	interval    *time.Duration
 	client      client.Client
 	isExternal  bool
+	// the metrics of the status of an external cluster, nil for a local cluster
+	metrics *externalClusterMetrics
+	// the forecasts of the capacity of the pools and device classes
+	forecaster *capacityForecaster
+	// the slow ops and stuck pgs of a local cluster, nil for an external cluster
+	slowOps *slowOpsCollector
 }
 
 // newCephStatusChecker creates a new HealthChecker object
@@ -61,6 +68,12 @@ func newCephStatusChecker(context *clusterd.Context, clusterInfo *cephclient.Clu
 		interval:    &defaultStatusCheckInterval,
 		client:      context.Client,
 		isExternal:  clusterSpec.External.Enable,
+		forecaster:  newCapacityForecaster(clusterInfo.Namespace),
+	}
+	if c.isExternal {
+		c.metrics = newExternalClusterMetrics(clusterInfo.Namespace)
+	} else {
+		c.slowOps = newSlowOpsCollector(clusterInfo.Namespace)
 	}
 
 	// allow overriding the check interval with an env var on the operator
@@ -92,6 +105,13 @@ func (c *cephStatusChecker) checkCephStatus(context context.Context) {
 		select {
 		case <-context.Done():
 			logger.Infof("stopping monitoring of ceph status")
+			if c.metrics != nil {
+				c.metrics.clear()
+			}
+			c.forecaster.clear()
+			if c.slowOps != nil {
+				c.slowOps.clear()
+			}
 			return
 
 		case <-time.After(*c.interval):
@@ -122,6 +142,9 @@ func (c *cephStatusChecker) checkStatus(ctx context.Context) {
 			return
 		}
 		logger.Errorf("failed to get ceph status. %v", err)
+		if c.metrics != nil {
+			c.metrics.setDisconnected()
+		}
 
 		message := "Failed to configure ceph cluster"
 		if c.isExternal {
@@ -133,11 +156,27 @@ func (c *cephStatusChecker) checkStatus(ctx context.Context) {
 	}
 
 	logger.Debugf("cluster status: %+v", status)
+	if c.metrics != nil {
+		c.metrics.setStatus(&status)
+	}
+	if err := c.forecaster.sample(c.context, c.clusterInfo); err != nil {
+		logger.Errorf("failed to forecast the capacity of the cluster. %v", err)
+	}
 	message := "Cluster created successfully"
 	if c.isExternal {
 		message = "Cluster connected successfully"
 	}
-	c.updateCephStatus(&status, condition, reason, message, v1.ConditionTrue)
+	cephCluster := c.updateCephStatus(&status, condition, reason, message, v1.ConditionTrue)
+	if cephCluster != nil {
+		if err := reconcileAlertSilences(ctx, cephCluster, &status); err != nil {
+			logger.Errorf("failed to silence the alerts of the cluster. %v", err)
+		}
+	}
+	if c.slowOps != nil {
+		if err := c.slowOps.collect(c.context, c.clusterInfo, &status, cephCluster); err != nil {
+			logger.Errorf("failed to collect the slow ops and the stuck pgs of the cluster. %v", err)
+		}
+	}
 
 	if status.Health.Status != "HEALTH_OK" {
 		logger.Debug("checking for stuck pods on not ready nodes")
@@ -171,34 +210,45 @@ func (c *cephStatusChecker) configureHealthSettings(status cephclient.CephStatus
 	}
 }
 
-// updateStatus updates an object with a given status
-func (c *cephStatusChecker) updateCephStatus(status *cephclient.CephStatus, condition cephv1.ConditionType, reason cephv1.ConditionReason, message string, conditionStatus v1.ConditionStatus) {
+// updateStatus updates an object with a given status, and returns the updated object or nil if it
+// could not be retrieved
+func (c *cephStatusChecker) updateCephStatus(status *cephclient.CephStatus, condition cephv1.ConditionType, reason cephv1.ConditionReason, message string, conditionStatus v1.ConditionStatus) *cephv1.CephCluster {
 	clusterName := c.clusterInfo.NamespacedName()
 	cephCluster, err := c.context.RookClientset.CephV1().CephClusters(clusterName.Namespace).Get(c.clusterInfo.Context, clusterName.Name, metav1.GetOptions{})
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
-			return
+			return nil
 		}
 		logger.Errorf("failed to retrieve ceph cluster %q in namespace %q to update status to %+v", clusterName.Name, clusterName.Namespace, status)
-		return
+		return nil
 	}
 
-	// Update with Ceph Status
-	cephCluster.Status.CephStatus = toCustomResourceStatus(cephCluster.Status, status)
-
 	// versions store the ceph version of all the ceph daemons and overall cluster version
 	versions, err := cephclient.GetAllCephDaemonVersions(c.context, c.clusterInfo)
 	if err != nil {
 		logger.Errorf("failed to get ceph daemons versions. %v", err)
-	} else {
-		// Update status with Ceph versions
-		cephCluster.Status.CephStatus.Versions = versions
+	} else if c.metrics != nil {
+		c.metrics.setVersions(versions.Overall)
 	}
 
-	// Update condition
+	// Update with Ceph Status and condition
 	logger.Debugf("updating ceph cluster %q status and condition to %+v, %v, %s, %s", clusterName.Namespace, status, conditionStatus, reason, message)
-	opcontroller.UpdateClusterCondition(c.context, cephCluster, c.clusterInfo.NamespacedName(), condition, conditionStatus, reason, message, true)
+	err = reporting.PatchStatus(c.context.Client, cephCluster, func() bool {
+		cephCluster.Status.CephStatus = toCustomResourceStatus(cephCluster.Status, status)
+		if c.forecaster != nil && c.forecaster.forecast != nil {
+			cephCluster.Status.CephStatus.Capacity.Forecast = c.forecaster.forecast
+		}
+		if versions != nil {
+			cephCluster.Status.CephStatus.Versions = versions
+		}
+		opcontroller.SetClusterCondition(cephCluster, clusterName, condition, conditionStatus, reason, message, true)
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to update ceph cluster %q status. %v", clusterName.Namespace, err)
+	}
+	return cephCluster
 }
 
 // toCustomResourceStatus converts the ceph status to the struct expected for the CephCluster CR status
@@ -233,9 +283,59 @@ func toCustomResourceStatus(currentStatus cephv1.ClusterStatus, newStatus *cephc
 			s.Capacity = currentStatus.CephStatus.Capacity
 		}
 	}
+	s.Checks = toHealthChecks(currentStatus.CephStatus, newStatus.Health.Checks, s.LastChecked)
 	return s
 }
 
+// healthSeverityOrder orders the health checks by severity, the unknown severities last
+var healthSeverityOrder = map[string]int{
+	"HEALTH_ERR":  0,
+	"HEALTH_WARN": 1,
+}
+
+// toHealthChecks converts the health checks to the list of the CR status sorted by severity and code.
+// A health check that was already active keeps the time it was first found.
+func toHealthChecks(currentStatus *cephv1.CephStatus, checks map[string]cephclient.CheckMessage, now string) []cephv1.CephHealthCheck {
+	since := map[string]string{}
+	if currentStatus != nil {
+		for _, check := range currentStatus.Checks {
+			since[check.Code] = check.Since
+		}
+	}
+
+	healthChecks := []cephv1.CephHealthCheck{}
+	for code, check := range checks {
+		healthCheck := cephv1.CephHealthCheck{
+			Code:     code,
+			Severity: check.Severity,
+			Summary:  check.Summary.Message,
+			Count:    check.Summary.Count,
+			Muted:    check.Muted,
+			Since:    now,
+		}
+		if previous, ok := since[code]; ok && previous != "" {
+			healthCheck.Since = previous
+		}
+		healthChecks = append(healthChecks, healthCheck)
+	}
+	sort.Slice(healthChecks, func(i, j int) bool {
+		a, b := healthChecks[i], healthChecks[j]
+		orderA, ok := healthSeverityOrder[a.Severity]
+		if !ok {
+			orderA = len(healthSeverityOrder)
+		}
+		orderB, ok := healthSeverityOrder[b.Severity]
+		if !ok {
+			orderB = len(healthSeverityOrder)
+		}
+		if orderA != orderB {
+			return orderA < orderB
+		}
+		return a.Code < b.Code
+	})
+	return healthChecks
+}
+
 func formatTime(t time.Time) string {
 	return t.Format(time.RFC3339)
 }
@@ -259,8 +359,11 @@ func (c *ClusterController) updateClusterCephVersion(image string, cephVersion c
 	}
 	// update the Ceph version on the retrieved cluster object
 	// do not overwrite the ceph status that is updated in a separate goroutine
-	cephCluster.Status.CephVersion = cephClusterVersion
-	if err := reporting.UpdateStatus(c.client, cephCluster); err != nil {
+	err = reporting.PatchStatus(c.client, cephCluster, func() bool {
+		cephCluster.Status.CephVersion = cephClusterVersion
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to update cluster %q version. %v", c.namespacedName.Name, err)
 		return
 	}
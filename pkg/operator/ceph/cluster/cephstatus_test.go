@@ -80,6 +80,7 @@ func TestCephStatus(t *testing.T) {
 	// Add some details to the warning
 	osdDownMsg := cephclient.CheckMessage{Severity: "HEALTH_WARN"}
 	osdDownMsg.Summary.Message = "1 osd down"
+	osdDownMsg.Summary.Count = 1
 	pgAvailMsg := cephclient.CheckMessage{Severity: "HEALTH_ERR"}
 	pgAvailMsg.Summary.Message = "'Reduced data availability: 100 pgs stale'"
 	newStatus.Health.Checks = map[string]cephclient.CheckMessage{
@@ -99,6 +100,23 @@ func TestCephStatus(t *testing.T) {
 	assert.Equal(t, pgAvailMsg.Summary.Message, aggregateStatus.Details["PG_AVAILABILITY"].Message)
 	assert.Equal(t, pgAvailMsg.Severity, aggregateStatus.Details["PG_AVAILABILITY"].Severity)
 
+	// The health checks are listed with the errors first
+	assert.Equal(t, 2, len(aggregateStatus.Checks))
+	assert.Equal(t, "PG_AVAILABILITY", aggregateStatus.Checks[0].Code)
+	assert.Equal(t, "HEALTH_ERR", aggregateStatus.Checks[0].Severity)
+	assert.Equal(t, pgAvailMsg.Summary.Message, aggregateStatus.Checks[0].Summary)
+	assert.Equal(t, "OSD_DOWN", aggregateStatus.Checks[1].Code)
+	assert.Equal(t, 1, aggregateStatus.Checks[1].Count)
+	assert.Equal(t, aggregateStatus.LastChecked, aggregateStatus.Checks[1].Since)
+
+	// The health checks still active keep the time they were first found
+	currentStatus.CephStatus.Checks = []cephv1.CephHealthCheck{{Code: "OSD_DOWN", Severity: "HEALTH_WARN", Since: previousTime}}
+	delete(newStatus.Health.Checks, "PG_AVAILABILITY")
+	aggregateStatus = toCustomResourceStatus(currentStatus, newStatus)
+	assert.Equal(t, 1, len(aggregateStatus.Checks))
+	assert.Equal(t, previousTime, aggregateStatus.Checks[0].Since)
+	currentStatus.CephStatus.Checks = nil
+
 	// Test for storage capacity of the ceph cluster when there is no disk
 	newStatus = &cephclient.CephStatus{
 		PgMap: cephclient.PgMap{TotalBytes: 0},
@@ -143,9 +161,9 @@ func TestNewCephStatusChecker(t *testing.T) {
 		args args
 		want *cephStatusChecker
 	}{
-		{"default-interval", args{c, clusterInfo, &cephv1.ClusterSpec{}}, &cephStatusChecker{c, clusterInfo, &defaultStatusCheckInterval, c.Client, false}},
-		{"10s-interval", args{c, clusterInfo, &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Status: cephv1.HealthCheckSpec{Interval: &metav1.Duration{Duration: time10s}}}}}}, &cephStatusChecker{c, clusterInfo, &time10s, c.Client, false}},
-		{"10s-interval-external", args{c, clusterInfo, &cephv1.ClusterSpec{External: cephv1.ExternalSpec{Enable: true}, HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Status: cephv1.HealthCheckSpec{Interval: &metav1.Duration{Duration: time10s}}}}}}, &cephStatusChecker{c, clusterInfo, &time10s, c.Client, true}},
+		{"default-interval", args{c, clusterInfo, &cephv1.ClusterSpec{}}, &cephStatusChecker{c, clusterInfo, &defaultStatusCheckInterval, c.Client, false, nil, newCapacityForecaster(clusterInfo.Namespace), newSlowOpsCollector(clusterInfo.Namespace)}},
+		{"10s-interval", args{c, clusterInfo, &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Status: cephv1.HealthCheckSpec{Interval: &metav1.Duration{Duration: time10s}}}}}}, &cephStatusChecker{c, clusterInfo, &time10s, c.Client, false, nil, newCapacityForecaster(clusterInfo.Namespace), newSlowOpsCollector(clusterInfo.Namespace)}},
+		{"10s-interval-external", args{c, clusterInfo, &cephv1.ClusterSpec{External: cephv1.ExternalSpec{Enable: true}, HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Status: cephv1.HealthCheckSpec{Interval: &metav1.Duration{Duration: time10s}}}}}}, &cephStatusChecker{c, clusterInfo, &time10s, c.Client, true, newExternalClusterMetrics(clusterInfo.Namespace), newCapacityForecaster(clusterInfo.Namespace), nil}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,220 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the number of previous generations of the csi keys kept when not set in the cluster spec
+const defaultKeepPriorCSIKeys = 3
+
+// reconcileCSIKeys creates the csi users of the current generation of keys and their secrets. When
+// the keys are due for rotation, the users of a new generation are created while the users of the
+// prior generations are kept for the volumes that were mounted with their keys, the oldest being
+// removed.
+func (c *cluster) reconcileCSIKeys() error {
+	cephxStatus, err := c.getCephxStatus()
+	if err != nil {
+		return err
+	}
+	var policy *cephv1.KeyRotationPolicySpec
+	keepPriorKeys := defaultKeepPriorCSIKeys
+	if c.Spec.Security.Cephx != nil {
+		policy = &c.Spec.Security.Cephx.CSI.KeyRotationPolicySpec
+		if c.Spec.Security.Cephx.CSI.KeepPriorKeys > 0 {
+			keepPriorKeys = c.Spec.Security.Cephx.CSI.KeepPriorKeys
+		}
+	}
+	var current *cephv1.KeyRotationStatus
+	priorGenerations := []int{}
+	if cephxStatus.CSI != nil {
+		current = &cephxStatus.CSI.KeyRotationStatus
+		priorGenerations = append(priorGenerations, cephxStatus.CSI.PriorKeyGenerations...)
+	}
+
+	next, rotate := controller.KeyRotation(policy, current, time.Now())
+	if err := csi.CreateCSISecrets(c.context, c.ClusterInfo, next.Generation); err != nil {
+		return err
+	}
+	if err := c.pushCSISecrets(); err != nil {
+		return err
+	}
+	if rotate {
+		logger.Infof("rotated the keys of the csi users of cluster %q to generation %d", c.Namespace, next.Generation)
+		previous := 0
+		if current != nil {
+			previous = current.Generation
+		}
+		priorGenerations = append(priorGenerations, previous)
+		if len(priorGenerations) > keepPriorKeys {
+			removed := priorGenerations[:len(priorGenerations)-keepPriorKeys]
+			if err := csi.DeleteCSIUsers(c.context, c.ClusterInfo, removed); err != nil {
+				return err
+			}
+			priorGenerations = priorGenerations[len(priorGenerations)-keepPriorKeys:]
+		}
+	}
+
+	c.csiKeyRotationRequeueAfter = controller.KeyRotationRequeueAfter(policy, next, time.Now())
+	cephxStatus.CSI = &cephv1.CSICephxStatus{KeyRotationStatus: *next, PriorKeyGenerations: priorGenerations}
+	return c.updateCephxStatus(cephxStatus)
+}
+
+// pushCSISecrets pushes the secrets of the csi users to the external secret store of the cluster
+func (c *cluster) pushCSISecrets() error {
+	for _, name := range []string{csi.CsiRBDProvisionerSecret, csi.CsiRBDNodeSecret, csi.CsiCephFSProvisionerSecret, csi.CsiCephFSNodeSecret} {
+		secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(c.ClusterInfo.Context, name, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get csi secret %q", name)
+		}
+		if err := controller.ReconcilePushSecret(c.ClusterInfo.Context, c.context.Client, c.Namespace, &c.Spec.Security, cephv1.ExternalSecretCSICredentials, secret); err != nil {
+			return errors.Wrapf(err, "failed to push csi secret %q", name)
+		}
+	}
+	return nil
+}
+
+// rotateDaemonKeys rotates the keys of the daemons when they are due for rotation and restarts the
+// daemons that mount the keyrings of the rotated keys. The keys of the mons, of the osds and of the
+// admin are not rotated.
+func (c *cluster) rotateDaemonKeys() error {
+	cephxStatus, err := c.getCephxStatus()
+	if err != nil {
+		return err
+	}
+	var policy *cephv1.KeyRotationPolicySpec
+	if c.Spec.Security.Cephx != nil {
+		policy = &c.Spec.Security.Cephx.Daemon
+	}
+
+	next, rotate := controller.KeyRotation(policy, cephxStatus.Daemon, time.Now())
+	if rotate {
+		rotated, err := keyring.GetSecretStore(c.context, c.ClusterInfo, c.ownerInfo).RotateDaemonKeys()
+		// the daemons of the keys rotated before a failure must be restarted all the same
+		if restartErr := c.restartKeyringConsumers(rotated); restartErr != nil {
+			return restartErr
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to rotate the daemon keys")
+		}
+		logger.Infof("rotated the keys of the daemons of cluster %q to generation %d", c.Namespace, next.Generation)
+	}
+
+	c.daemonKeyRotationRequeueAfter = controller.KeyRotationRequeueAfter(policy, next, time.Now())
+	cephxStatus.Daemon = next
+	return c.updateCephxStatus(cephxStatus)
+}
+
+// restartKeyringConsumers restarts the deployments whose pods mount one of the given keyring secrets
+func (c *cluster) restartKeyringConsumers(secretNames []string) error {
+	if len(secretNames) == 0 {
+		return nil
+	}
+	secrets := map[string]bool{}
+	for _, name := range secretNames {
+		secrets[name] = true
+	}
+
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(c.ClusterInfo.Context, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the deployments to restart with the rotated keys")
+	}
+	for _, d := range deployments.Items {
+		if !mountsSecret(d.Spec.Template.Spec.Volumes, secrets) {
+			continue
+		}
+		if err := k8sutil.RestartDeployment(c.ClusterInfo.Context, c.context.Clientset, c.Namespace, d.Name); err != nil {
+			return errors.Wrapf(err, "failed to restart deployment %q with the rotated keys", d.Name)
+		}
+	}
+	return nil
+}
+
+// mountsSecret returns whether one of the volumes is one of the given secrets, or projects it
+func mountsSecret(volumes []v1.Volume, secrets map[string]bool) bool {
+	for _, volume := range volumes {
+		if volume.Secret != nil && secrets[volume.Secret.SecretName] {
+			return true
+		}
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.Secret != nil && secrets[source.Secret.Name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keyRotationRequeueAfter returns the time until the next periodic rotation of the keys of the
+// cluster, or zero when no key is rotated periodically
+func (c *cluster) keyRotationRequeueAfter() time.Duration {
+	requeueAfter := c.csiKeyRotationRequeueAfter
+	if requeueAfter == 0 || (c.daemonKeyRotationRequeueAfter > 0 && c.daemonKeyRotationRequeueAfter < requeueAfter) {
+		requeueAfter = c.daemonKeyRotationRequeueAfter
+	}
+	return requeueAfter
+}
+
+// getCephxStatus returns a copy of the status of the cephx keys of the cluster
+func (c *cluster) getCephxStatus() (*cephv1.ClusterCephxStatus, error) {
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.context.Client.Get(c.ClusterInfo.Context, c.ClusterInfo.NamespacedName(), cephCluster); err != nil {
+		return nil, errors.Wrapf(err, "failed to get ceph cluster %q to read the cephx status", c.ClusterInfo.NamespacedName().Name)
+	}
+	if cephCluster.Status.Cephx == nil {
+		return &cephv1.ClusterCephxStatus{}, nil
+	}
+	return cephCluster.Status.Cephx.DeepCopy(), nil
+}
+
+// updateCephxStatus updates the status of the cephx keys of the cluster
+func (c *cluster) updateCephxStatus(cephxStatus *cephv1.ClusterCephxStatus) error {
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.context.Client.Get(c.ClusterInfo.Context, c.ClusterInfo.NamespacedName(), cephCluster); err != nil {
+		return errors.Wrapf(err, "failed to get ceph cluster %q to update the cephx status", c.ClusterInfo.NamespacedName().Name)
+	}
+	err := reporting.PatchStatus(c.context.Client, cephCluster, func() bool {
+		if reflect.DeepEqual(cephCluster.Status.Cephx, cephxStatus) {
+			return false
+		}
+		cephCluster.Status.Cephx = cephxStatus
+		return true
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update ceph cluster %q cephx status", cephCluster.Name)
+	}
+	return nil
+}
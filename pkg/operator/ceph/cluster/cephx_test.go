@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileCSIKeys(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	created := map[string]bool{}
+	deleted := []string{}
+	mockExecutor := func(command string, args ...string) (string, error) {
+		switch args[1] {
+		case "get-or-create-key":
+			created[args[2]] = true
+			return `{"key":"mysecurekey"}`, nil
+		case "del":
+			deleted = append(deleted, args[2])
+		}
+		return "", nil
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: mockExecutor,
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			return mockExecutor(command, args...)
+		},
+	}
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: ns}}
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	clientset := testop.New(t, 1)
+	c := &cluster{
+		ClusterInfo: cephclient.AdminTestClusterInfo(ns),
+		Namespace:   ns,
+		context: &clusterd.Context{
+			Clientset: clientset,
+			Client:    fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cephCluster).Build(),
+			Executor:  executor,
+		},
+		Spec: &cephv1.ClusterSpec{},
+	}
+	status := func() *cephv1.CSICephxStatus {
+		updated := &cephv1.CephCluster{}
+		assert.NoError(t, c.context.Client.Get(ctx, c.ClusterInfo.NamespacedName(), updated))
+		return updated.Status.Cephx.CSI
+	}
+	userID := func() string {
+		secret, err := clientset.CoreV1().Secrets(ns).Get(ctx, "rook-csi-rbd-node", metav1.GetOptions{})
+		assert.NoError(t, err)
+		return string(secret.Data["userID"])
+	}
+
+	// no rotation without policy
+	assert.NoError(t, c.reconcileCSIKeys())
+	assert.True(t, created["client.csi-rbd-node"])
+	assert.Equal(t, "csi-rbd-node", userID())
+	assert.Equal(t, 0, status().Generation)
+	assert.NotNil(t, status().LastRotationTime)
+	assert.Empty(t, status().PriorKeyGenerations)
+	assert.Equal(t, time.Duration(0), c.keyRotationRequeueAfter())
+
+	// rotate on demand, keeping the users of one prior generation
+	c.Spec.Security.Cephx = &cephv1.ClusterCephxSpec{CSI: cephv1.CSICephxSpec{KeyRotationPolicySpec: cephv1.KeyRotationPolicySpec{Generation: 1}, KeepPriorKeys: 1}}
+	assert.NoError(t, c.reconcileCSIKeys())
+	assert.True(t, created["client.csi-rbd-node.1"])
+	assert.True(t, created["client.csi-cephfs-provisioner.1"])
+	assert.Equal(t, "csi-rbd-node.1", userID())
+	assert.Equal(t, 1, status().Generation)
+	assert.Equal(t, []int{0}, status().PriorKeyGenerations)
+	assert.Empty(t, deleted)
+
+	c.Spec.Security.Cephx.CSI.Generation = 2
+	c.Spec.Security.Cephx.CSI.Period = &metav1.Duration{Duration: 24 * time.Hour}
+	assert.NoError(t, c.reconcileCSIKeys())
+	assert.Equal(t, "csi-rbd-node.2", userID())
+	assert.Equal(t, []int{1}, status().PriorKeyGenerations)
+	assert.Equal(t, []string{"client.csi-rbd-provisioner", "client.csi-rbd-node", "client.csi-cephfs-provisioner", "client.csi-cephfs-node"}, deleted)
+	assert.Greater(t, c.keyRotationRequeueAfter(), 23*time.Hour)
+
+	// nothing changes until the next rotation
+	assert.NoError(t, c.reconcileCSIKeys())
+	assert.Equal(t, 2, status().Generation)
+	assert.Len(t, deleted, 4)
+}
+
+func TestRestartKeyringConsumers(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	clientset := testop.New(t, 1)
+	c := &cluster{
+		ClusterInfo: cephclient.AdminTestClusterInfo(ns),
+		Namespace:   ns,
+		context:     &clusterd.Context{Clientset: clientset},
+	}
+	volumes := map[string][]v1.Volume{
+		"rook-ceph-mgr-a": {{Name: "keyring", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "rook-ceph-mgr-a-keyring"}}}},
+		"rook-ceph-rgw-a": {{Name: "keyring", VolumeSource: v1.VolumeSource{Projected: &v1.ProjectedVolumeSource{Sources: []v1.VolumeProjection{
+			{Secret: &v1.SecretProjection{LocalObjectReference: v1.LocalObjectReference{Name: "rook-ceph-rgw-a-keyring"}}},
+		}}}}},
+		"rook-ceph-mds-a": {{Name: "keyring", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "rook-ceph-mds-a-keyring"}}}},
+	}
+	for name, v := range volumes {
+		d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}
+		d.Spec.Template.Spec.Volumes = v
+		_, err := clientset.AppsV1().Deployments(ns).Create(ctx, d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, c.restartKeyringConsumers([]string{"rook-ceph-mgr-a-keyring", "rook-ceph-rgw-a-keyring"}))
+	for name := range volumes {
+		d, err := clientset.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		_, restarted := d.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"]
+		assert.Equal(t, name != "rook-ceph-mds-a", restarted, name)
+	}
+
+	assert.True(t, mountsSecret(volumes["rook-ceph-mgr-a"], map[string]bool{"rook-ceph-mgr-a-keyring": true}))
+	assert.False(t, mountsSecret(volumes["rook-ceph-mgr-a"], map[string]bool{"rook-ceph-mds-a-keyring": true}))
+}
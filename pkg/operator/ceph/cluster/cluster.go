@@ -23,6 +23,7 @@ import (
 	"os/exec"
 	"path"
 	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -36,7 +37,6 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/controller"
-	"github.com/rook/rook/pkg/operator/ceph/csi"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
@@ -59,6 +59,11 @@ type cluster struct {
 	ownerInfo          *k8sutil.OwnerInfo
 	isUpgrade          bool
 	monitoringRoutines map[string]*clusterHealth
+	// the time until the next periodic rotation of the csi and daemon keys
+	csiKeyRotationRequeueAfter    time.Duration
+	daemonKeyRotationRequeueAfter time.Duration
+	// the time until the next step of the migration of the osds
+	osdMigrationRequeueAfter time.Duration
 }
 
 type clusterHealth struct {
@@ -82,6 +87,16 @@ func newCluster(c *cephv1.CephCluster, context *clusterd.Context, ownerInfo *k8s
 	}
 }
 
+// requeueAfter returns the time until the cluster must be reconciled again, for the next rotation of
+// the keys or the next step of the osd migration, 0 if not needed
+func (c *cluster) requeueAfter() time.Duration {
+	requeueAfter := c.keyRotationRequeueAfter()
+	if requeueAfter == 0 || (c.osdMigrationRequeueAfter > 0 && c.osdMigrationRequeueAfter < requeueAfter) {
+		requeueAfter = c.osdMigrationRequeueAfter
+	}
+	return requeueAfter
+}
+
 func (c *cluster) reconcileCephDaemons(rookImage string, cephVersion cephver.CephVersion) error {
 	// Create a configmap for overriding ceph config settings
 	// These settings should only be modified by a user after they are initialized
@@ -91,6 +106,11 @@ func (c *cluster) reconcileCephDaemons(rookImage string, cephVersion cephver.Cep
 	}
 	c.ClusterInfo.SetName(c.namespacedName.Name)
 
+	// Restrict the traffic to the daemons before they are started
+	if err := c.reconcileNetworkPolicies(); err != nil {
+		return errors.Wrap(err, "failed to reconcile the network policies")
+	}
+
 	// Execute actions before the monitors are up and running, if needed during upgrades.
 	// These actions would be skipped in a new cluster.
 	logger.Debug("monitors are about to reconcile, executing pre actions")
@@ -135,6 +155,11 @@ func (c *cluster) reconcileCephDaemons(rookImage string, cephVersion cephver.Cep
 		return errors.Wrap(err, "failed to start ceph mgr")
 	}
 
+	// Check the KMS storing the encryption keys before provisioning the OSDs
+	if err := c.checkKMSConnection(); err != nil {
+		return err
+	}
+
 	// Start the OSDs
 	controller.UpdateCondition(c.ClusterInfo.Context, c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionTrue, cephv1.ClusterProgressingReason, "Configuring Ceph OSDs")
 	osds := osd.New(c.context, c.ClusterInfo, *c.Spec, rookImage)
@@ -142,6 +167,10 @@ func (c *cluster) reconcileCephDaemons(rookImage string, cephVersion cephver.Cep
 	if err != nil {
 		return errors.Wrap(err, "failed to start ceph osds")
 	}
+	c.osdMigrationRequeueAfter = 0
+	if osds.MigrationInProgress {
+		c.osdMigrationRequeueAfter = osd.OSDMigrationRequeueAfter
+	}
 
 	// If a stretch cluster, enable the arbiter after the OSDs are created with the CRUSH map
 	if c.Spec.IsStretchCluster() {
@@ -150,6 +179,11 @@ func (c *cluster) reconcileCephDaemons(rookImage string, cephVersion cephver.Cep
 		}
 	}
 
+	// Rotate the daemon keys once all the daemons are running
+	if err := c.rotateDaemonKeys(); err != nil {
+		return errors.Wrap(err, "failed to rotate the daemon keys")
+	}
+
 	logger.Infof("done reconciling ceph cluster in namespace %q", c.Namespace)
 
 	// We should be done updating by now
@@ -173,6 +207,12 @@ func (c *ClusterController) initializeCluster(cluster *cluster) error {
 		}
 	}
 
+	// Pin the ceph image to its digest and verify its signature before any daemon is updated
+	if err := c.applyImagePolicy(cluster); err != nil {
+		controller.UpdateCondition(c.OpManagerCtx, c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionFalse, cephv1.ClusterProgressingReason, err.Error())
+		return err
+	}
+
 	// Depending on the cluster type choose the correct orchestation
 	if cluster.Spec.External.Enable {
 		err := c.configureExternalCephCluster(cluster)
@@ -181,7 +221,7 @@ func (c *ClusterController) initializeCluster(cluster *cluster) error {
 			return errors.Wrap(err, "failed to configure external ceph cluster")
 		}
 	} else {
-		clusterInfo, _, _, err := mon.LoadClusterInfo(c.context, c.OpManagerCtx, cluster.Namespace)
+		clusterInfo, _, _, err := mon.LoadClusterInfo(c.context, c.auditContext(), cluster.Namespace)
 		if err != nil {
 			if errors.Is(err, mon.ClusterInfoNoClusterNoSecret) {
 				logger.Info("clusterInfo not yet found, must be a new cluster.")
@@ -214,7 +254,10 @@ func (c *ClusterController) initializeCluster(cluster *cluster) error {
 	cluster.mons.ClusterInfo.SetName(c.namespacedName.Name)
 
 	// Start the monitoring if not already started
-	c.configureCephMonitoring(cluster, cluster.ClusterInfo)
+	// There is no ceph status to monitor for an object-only external cluster
+	if !cluster.Spec.External.ObjectOnly {
+		c.configureCephMonitoring(cluster, cluster.ClusterInfo)
+	}
 	return nil
 }
 
@@ -225,6 +268,11 @@ func (c *ClusterController) configureLocalCephCluster(cluster *cluster) error {
 		return errors.Wrap(err, "failed to perform validation before cluster creation")
 	}
 
+	// Check and report the compliance with fips
+	if err := validateFIPS(cluster); err != nil {
+		return err
+	}
+
 	// Run image validation job
 	controller.UpdateCondition(c.OpManagerCtx, c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionTrue, cephv1.ClusterProgressingReason, "Detecting Ceph version")
 	cephVersion, isUpgrade, err := c.detectAndValidateCephVersion(cluster)
@@ -243,7 +291,7 @@ func (c *ClusterController) configureLocalCephCluster(cluster *cluster) error {
 
 	controller.UpdateCondition(c.OpManagerCtx, c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionTrue, cephv1.ClusterProgressingReason, "Configuring the Ceph cluster")
 
-	cluster.ClusterInfo.Context = c.OpManagerCtx
+	cluster.ClusterInfo.Context = c.auditContext()
 	// Run the orchestration
 	err = cluster.reconcileCephDaemons(c.rookImage, *cephVersion)
 	if err != nil {
@@ -271,6 +319,9 @@ func preClusterStartValidation(cluster *cluster) error {
 	if err := validateStretchCluster(cluster); err != nil {
 		return err
 	}
+	if err := validateRestrictedPodSecurity(cluster); err != nil {
+		return err
+	}
 	if cluster.Spec.Network.IsMultus() {
 		_, isPublic := cluster.Spec.Network.Selectors[config.PublicNetworkSelectorKeyName]
 		_, isCluster := cluster.Spec.Network.Selectors[config.ClusterNetworkSelectorKeyName]
@@ -314,6 +365,24 @@ func preClusterStartValidation(cluster *cluster) error {
 	return nil
 }
 
+// validateRestrictedPodSecurity checks that the daemons can comply with the "restricted" Pod Security
+// Standard, which forbids the host network and the host paths
+func validateRestrictedPodSecurity(cluster *cluster) error {
+	if !cluster.Spec.Security.IsRestricted() {
+		return nil
+	}
+	if cluster.Spec.Network.IsHost() {
+		return errors.New("the host network cannot be used with the restricted pod security standard")
+	}
+	if cluster.Spec.Mon.VolumeClaimTemplate == nil {
+		return errors.New("the mons must store their data on a volume claim template with the restricted pod security standard")
+	}
+	if !cluster.Spec.CrashCollector.Disable {
+		return errors.New("the crash collector must be disabled with the restricted pod security standard")
+	}
+	return nil
+}
+
 func validateStretchCluster(cluster *cluster) error {
 	if !cluster.Spec.IsStretchCluster() {
 		return nil
@@ -485,7 +554,7 @@ func (c *cluster) skipMDSSanityChecks(skip bool) error {
 // Basically, it is executed between the monitors and the manager sequence
 func (c *cluster) postMonStartupActions() error {
 	// Create CSI Kubernetes Secrets
-	err := csi.CreateCSISecrets(c.context, c.ClusterInfo)
+	err := c.reconcileCSIKeys()
 	if err != nil {
 		return errors.Wrap(err, "failed to create csi kubernetes secrets")
 	}
@@ -19,6 +19,7 @@ package cluster
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -30,6 +31,8 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -46,6 +49,20 @@ func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error
 
 	opcontroller.UpdateCondition(c.OpManagerCtx, c.context, c.namespacedName, cephv1.ConditionConnecting, v1.ConditionTrue, cephv1.ClusterConnectingReason, "Attempting to connect to an external Ceph cluster")
 
+	if cluster.Spec.External.ObjectOnly {
+		return c.configureObjectOnlyExternalCluster(cluster)
+	}
+
+	// Create the secret and configmap necessary to connect to the external cluster from the
+	// bootstrap secret, instead of waiting for them to be imported
+	var bootstrap *externalBootstrap
+	if cluster.Spec.External.BootstrapSecret != "" {
+		bootstrap, err = c.importExternalBootstrap(cluster)
+		if err != nil {
+			return errors.Wrap(err, "failed to import external cluster bootstrap secret")
+		}
+	}
+
 	// loop until we find the secret necessary to connect to the external cluster
 	// then populate clusterInfo
 
@@ -54,7 +71,7 @@ func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error
 		return errors.Wrap(err, "failed to populate external cluster info")
 	}
 	cluster.ClusterInfo.SetName(c.namespacedName.Name)
-	cluster.ClusterInfo.Context = c.OpManagerCtx
+	cluster.ClusterInfo.Context = c.auditContext()
 
 	if !client.IsKeyringBase64Encoded(cluster.ClusterInfo.CephCred.Secret) {
 		return errors.Errorf("invalid user health checker key for user %q", cluster.ClusterInfo.CephCred.Username)
@@ -101,9 +118,31 @@ func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error
 
 	// Create CSI Secrets only if the user has provided the admin key
 	if cluster.ClusterInfo.CephCred.Username == client.AdminUsername {
-		err = csi.CreateCSISecrets(c.context, cluster.ClusterInfo)
+		if cluster.Spec.External.Users != nil {
+			err = c.reconcileExternalUsers(cluster)
+			if err != nil {
+				return err
+			}
+		} else {
+			err = csi.CreateCSISecrets(c.context, cluster.ClusterInfo, 0)
+			if err != nil {
+				return errors.Wrap(err, "failed to create csi kubernetes secrets")
+			}
+		}
+	} else if cluster.Spec.External.Users != nil {
+		return errors.Errorf("the users of the external cluster can only be created with the admin key, the operator connects as %q", cluster.ClusterInfo.CephCred.Username)
+	} else if bootstrap != nil {
+		err = c.importExternalCSISecrets(cluster, bootstrap)
 		if err != nil {
-			return errors.Wrap(err, "failed to create csi kubernetes secrets")
+			return err
+		}
+	}
+
+	// Report the keys in use, reconnecting with the new keys if they were rotated
+	if bootstrap != nil {
+		err = c.updateExternalKeyEpoch(cluster, bootstrap)
+		if err != nil {
+			return errors.Wrap(err, "failed to update external cluster keys")
 		}
 	}
 
@@ -113,8 +152,16 @@ func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error
 		return errors.Wrap(err, "failed to create csi config map")
 	}
 
+	// Import the CRUSH topology of the external cluster for the read affinity and topology of the csi drivers
+	crushLocationLabels, err := csi.ImportCrushLocationLabels(c.context, cluster.ClusterInfo)
+	if err != nil {
+		logger.Warningf("failed to import the crush location labels of the external cluster, using the default labels. %v", err)
+	} else if err := c.updateExternalCrushLocationLabels(crushLocationLabels); err != nil {
+		return errors.Wrap(err, "failed to report the crush location labels of the external cluster")
+	}
+
 	// Save CSI configmap
-	err = csi.SaveClusterConfig(c.context.Clientset, c.namespacedName.Namespace, cluster.ClusterInfo, &csi.CsiClusterConfigEntry{Monitors: csi.MonEndpoints(cluster.ClusterInfo.Monitors)})
+	err = csi.SaveClusterConfig(c.context.Clientset, c.namespacedName.Namespace, cluster.ClusterInfo, &csi.CsiClusterConfigEntry{Monitors: csi.MonEndpoints(cluster.ClusterInfo.Monitors), ReadAffinity: csi.ExternalReadAffinity(cluster.Spec, crushLocationLabels), CephFS: csi.CephFSMountOptions(cluster.Spec)})
 	if err != nil {
 		return errors.Wrap(err, "failed to update csi cluster config")
 	}
@@ -152,6 +199,83 @@ func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error
 	return nil
 }
 
+// configureObjectOnlyExternalCluster connects to an external cluster through the admin ops API of its
+// gateways only. There is no mon to connect to, so neither the ceph config, the csi secrets nor the
+// health checkers of the cluster are set up, the object stores check the health of the gateways.
+func (c *ClusterController) configureObjectOnlyExternalCluster(cluster *cluster) error {
+	if cluster.Spec.External.BootstrapSecret != "" {
+		_, err := c.importExternalBootstrap(cluster)
+		if err != nil {
+			return errors.Wrap(err, "failed to import external cluster bootstrap secret")
+		}
+	}
+
+	// The object stores call the admin ops API of the gateways with the keys of this secret
+	_, err := c.context.Clientset.CoreV1().Secrets(c.namespacedName.Namespace).Get(c.OpManagerCtx, object.RGWAdminOpsUserSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get rgw admin ops user secret %q of the object-only external cluster", object.RGWAdminOpsUserSecretName)
+	}
+
+	cluster.ClusterInfo = client.NewClusterInfo(c.namespacedName.Namespace, c.namespacedName.Name)
+	cluster.ClusterInfo.Context = c.auditContext()
+	cluster.ClusterInfo.OwnerInfo = cluster.ownerInfo
+
+	opcontroller.UpdateCondition(c.OpManagerCtx, c.context, c.namespacedName, cephv1.ConditionConnected, v1.ConditionTrue, cephv1.ClusterConnectedReason, "Object-only external cluster connected successfully")
+	logger.Infof("connected to object-only external cluster %q", c.namespacedName.String())
+	return nil
+}
+
+// reconcileExternalUsers creates the users of the enabled features in the external cluster, each with
+// the minimal caps of its feature, and removes the users of the disabled features
+func (c *ClusterController) reconcileExternalUsers(cluster *cluster) error {
+	users := cluster.Spec.External.Users
+	err := csi.ReconcileExternalCSIUsers(c.context, cluster.ClusterInfo, users)
+	if err != nil {
+		return errors.Wrap(err, "failed to reconcile the csi users of the external cluster")
+	}
+	err = object.ReconcileExternalAdminOpsUser(c.context, cluster.ClusterInfo, cluster.Spec, users.ObjectStore)
+	if err != nil {
+		return errors.Wrap(err, "failed to reconcile the rgw admin ops user of the external cluster")
+	}
+	return nil
+}
+
+// updateExternalCrushLocationLabels reports the crush location labels imported from the CRUSH map of
+// the external cluster in the status of the CephCluster, where the csi controller reads them
+func (c *ClusterController) updateExternalCrushLocationLabels(labels []string) error {
+	cephCluster := &cephv1.CephCluster{}
+	err := c.client.Get(c.OpManagerCtx, c.namespacedName, cephCluster)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get cluster %q", c.namespacedName.String())
+	}
+
+	changed := false
+	err = reporting.PatchStatus(c.client, cephCluster, func() bool {
+		changed = false
+		var current []string
+		if cephCluster.Status.External != nil {
+			current = cephCluster.Status.External.CrushLocationLabels
+		}
+		if (len(current) == 0 && len(labels) == 0) || reflect.DeepEqual(current, labels) {
+			return false
+		}
+		if cephCluster.Status.External == nil {
+			cephCluster.Status.External = &cephv1.ExternalClusterStatus{}
+		}
+		cephCluster.Status.External.CrushLocationLabels = labels
+		changed = true
+		return true
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update external cluster crush location labels")
+	}
+	if !changed {
+		return nil
+	}
+	logger.Infof("imported crush location labels %v of external cluster %q", labels, c.namespacedName.String())
+	return nil
+}
+
 func purgeExternalCluster(clientset kubernetes.Interface, namespace string) {
 	ctx := context.TODO()
 	// Purge the config maps
@@ -191,6 +315,18 @@ func validateExternalClusterSpec(cluster *cluster) error {
 		}
 	}
 
+	if cluster.Spec.External.ObjectOnly {
+		if cluster.Spec.CephVersion.Image != "" {
+			return errors.New("the daemons of an object-only external cluster cannot be managed, cephVersion.image must not be set")
+		}
+		if cluster.Spec.Monitoring.Enabled {
+			return errors.New("the mgr of an object-only external cluster cannot be monitored, monitoring must not be enabled")
+		}
+		if cluster.Spec.External.Users != nil {
+			return errors.New("no ceph user can be created in an object-only external cluster, users must not be set")
+		}
+	}
+
 	// Validate external services port
 	if cluster.Spec.Monitoring.Enabled {
 		if cluster.Spec.Monitoring.ExternalMgrPrometheusPort == 0 {
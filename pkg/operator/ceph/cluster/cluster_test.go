@@ -30,6 +30,7 @@ import (
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 )
 
 func TestPreClusterStartValidation(t *testing.T) {
@@ -66,6 +67,10 @@ func TestPreClusterStartValidation(t *testing.T) {
 			{Name: "b"},
 			{Name: "c"},
 		}}}}}}, true},
+		{"restricted with host network", args{&cluster{ClusterInfo: client.AdminTestClusterInfo("rook-ceph"), context: &clusterd.Context{Clientset: testop.New(t, 3)}, Spec: &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{PodSecurityStandard: cephv1.PodSecurityStandardRestricted}, Network: cephv1.NetworkSpec{Provider: "host"}, Mon: cephv1.MonSpec{VolumeClaimTemplate: &v1.PersistentVolumeClaim{}}, CrashCollector: cephv1.CrashCollectorSpec{Disable: true}}}}, true},
+		{"restricted with mons on the host", args{&cluster{ClusterInfo: client.AdminTestClusterInfo("rook-ceph"), context: &clusterd.Context{Clientset: testop.New(t, 3)}, Spec: &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{PodSecurityStandard: cephv1.PodSecurityStandardRestricted}, CrashCollector: cephv1.CrashCollectorSpec{Disable: true}}}}, true},
+		{"restricted with crash collector", args{&cluster{ClusterInfo: client.AdminTestClusterInfo("rook-ceph"), context: &clusterd.Context{Clientset: testop.New(t, 3)}, Spec: &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{PodSecurityStandard: cephv1.PodSecurityStandardRestricted}, Mon: cephv1.MonSpec{VolumeClaimTemplate: &v1.PersistentVolumeClaim{}}}}}, true},
+		{"restricted", args{&cluster{ClusterInfo: client.AdminTestClusterInfo("rook-ceph"), context: &clusterd.Context{Clientset: testop.New(t, 3)}, Spec: &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{PodSecurityStandard: cephv1.PodSecurityStandardRestricted}, Mon: cephv1.MonSpec{VolumeClaimTemplate: &v1.PersistentVolumeClaim{}}, CrashCollector: cephv1.CrashCollectorSpec{Disable: true}}}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
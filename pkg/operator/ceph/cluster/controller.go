@@ -34,6 +34,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -103,7 +104,15 @@ type ReconcileCephCluster struct {
 // Add creates a new CephCluster Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, ctx *clusterd.Context, clusterController *ClusterController, opManagerContext context.Context) error {
-	return add(opManagerContext, mgr, newReconciler(mgr, ctx, clusterController, opManagerContext), ctx)
+	r := newReconciler(mgr, ctx, clusterController, opManagerContext)
+	// the mon finalizers of a CephCluster stuck in deletion are removed with its own finalizer
+	opcontroller.RegisterFinalizerCleanup("CephCluster", func(_ context.Context, c client.Client, obj client.Object) error {
+		return r.(*ReconcileCephCluster).removeMonFinalizers(c, obj.GetNamespace())
+	})
+	if err := add(opManagerContext, mgr, r, ctx); err != nil {
+		return err
+	}
+	return addTaskController(mgr, clusterController.rookImage)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -124,7 +133,8 @@ func newReconciler(mgr manager.Manager, ctx *clusterd.Context, clusterController
 
 func add(opManagerContext context.Context, mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephCluster{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephCluster{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -182,6 +192,38 @@ func add(opManagerContext context.Context, mgr manager.Manager, r reconcile.Reco
 		return err
 	}
 
+	// Watch for changes on the bootstrap secrets of the external clusters
+	err = c.Watch(
+		&source.Kind{
+			Type: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: corev1.SchemeGroupVersion.String(),
+				},
+			},
+		},
+		handler.EnqueueRequestsFromMapFunc(handlerFunc),
+		predicateForBootstrapSecretWatcher(opManagerContext, mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes on the certificate secrets of the dashboards
+	err = c.Watch(
+		&source.Kind{
+			Type: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: corev1.SchemeGroupVersion.String(),
+				},
+			},
+		},
+		handler.EnqueueRequestsFromMapFunc(handlerFunc),
+		predicateForDashboardCertificateWatcher(opManagerContext, mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
 	// Watch for changes on the hotplug config map
 	// TODO: to improve, can we run this against the operator namespace only?
 	disableVal := os.Getenv(disableHotplugEnv)
@@ -216,7 +258,7 @@ func (r *ReconcileCephCluster) Reconcile(context context.Context, request reconc
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
 	reconcileResponse, cephCluster, err := r.reconcile(request)
 
-	return reporting.ReportReconcileResult(logger, r.clusterController.recorder,
+	return reporting.ReportReconcileResult(logger, r.clusterController.recorder, request,
 		cephCluster, reconcileResponse, err)
 }
 
@@ -259,6 +301,13 @@ func (r *ReconcileCephCluster) reconcile(request reconcile.Request) (reconcile.R
 		return reconcile.Result{}, cephCluster, errors.Wrapf(err, "failed to reconcile cluster %q", cephCluster.Name)
 	}
 
+	// Requeue for the next periodic rotation of the keys or the next step of the osd migration
+	if cluster, ok := r.clusterController.clusterMap[cephCluster.Namespace]; ok {
+		if requeueAfter := cluster.requeueAfter(); requeueAfter > 0 {
+			return reconcile.Result{RequeueAfter: requeueAfter}, cephCluster, nil
+		}
+	}
+
 	// Return and do not requeue
 	return reconcile.Result{}, cephCluster, nil
 }
@@ -338,6 +387,11 @@ func (c *ClusterController) reconcileCephCluster(clusterObj *cephv1.CephCluster,
 	}
 
 	cluster, ok := c.clusterMap[clusterObj.Namespace]
+	if ok && cluster.namespacedName.Name != clusterObj.Name {
+		// a local cluster and an external cluster must be in different namespaces, their
+		// secrets, configmaps and csi config entry are all named after the namespace
+		return errors.Errorf("CephCluster CR %q already exists in namespace %q. only one cluster cr per namespace is supported", cluster.namespacedName.Name, clusterObj.Namespace)
+	}
 	if !ok {
 		// It's a new cluster so let's populate the struct
 		cluster = newCluster(clusterObj, c.context, ownerInfo)
@@ -350,6 +404,11 @@ func (c *ClusterController) reconcileCephCluster(clusterObj *cephv1.CephCluster,
 
 	// Set the spec
 	cluster.Spec = &clusterObj.Spec
+	if err := clusterObj.Spec.Monitoring.ValidateExternalLabels(); err != nil {
+		logger.Errorf("failed to export the external labels of the cluster. %v", err)
+	} else {
+		externalLabels.set(cluster.Namespace, clusterObj.Spec.Monitoring.ExternalLabels)
+	}
 
 	c.clusterMap[cluster.Namespace] = cluster
 	logger.Infof("reconciling ceph cluster in namespace %q", cluster.Namespace)
@@ -358,6 +417,12 @@ func (c *ClusterController) reconcileCephCluster(clusterObj *cephv1.CephCluster,
 	return c.initializeCluster(cluster)
 }
 
+// auditContext returns the context of the operator whose ceph commands are attributed to the
+// CephCluster in the audit log
+func (c *ClusterController) auditContext() context.Context {
+	return exec.WithAuditInitiator(c.OpManagerCtx, "CephCluster", c.namespacedName.Namespace, c.namespacedName.Name)
+}
+
 func (c *ClusterController) requestClusterDelete(cluster *cephv1.CephCluster) (reconcile.Result, error) {
 	nsName := fmt.Sprintf("%s/%s", cluster.Namespace, cluster.Name)
 
@@ -368,6 +433,10 @@ func (c *ClusterController) requestClusterDelete(cluster *cephv1.CephCluster) (r
 	}
 
 	logger.Infof("cleaning up CephCluster %q", nsName)
+	externalLabels.set(cluster.Namespace, nil)
+	cephclient.CloseCommandConn(cluster.Namespace)
+	cephclient.RemoveCircuitBreaker(cluster.Namespace)
+	cephclient.InvalidateCommandCache(cluster.Namespace)
 
 	if cluster, ok := c.clusterMap[cluster.Namespace]; ok {
 		// We used to stop the bucket controller here but when we get a DELETE event for the CephCluster
@@ -419,9 +488,9 @@ func (c *ClusterController) checkIfVolumesExist(cluster *cephv1.CephCluster) err
 }
 
 func (c *ClusterController) csiVolumesAllowForDeletion(cluster *cephv1.CephCluster) error {
-	drivers := []string{csi.CephFSDriverName, csi.RBDDriverName}
+	drivers := []string{csi.CephFSDriverNameForCluster(&cluster.Spec), csi.RBDDriverNameForCluster(&cluster.Spec)}
 
-	logger.Infof("checking any PVC created by drivers %q and %q with clusterID %q", csi.CephFSDriverName, csi.RBDDriverName, cluster.Namespace)
+	logger.Infof("checking any PVC created by drivers %q and %q with clusterID %q", drivers[0], drivers[1], cluster.Namespace)
 	// check any PV is created in this cluster
 	attachmentsExist, err := c.checkPVPresentInCluster(drivers, cluster.Namespace)
 	if err != nil {
@@ -467,15 +536,20 @@ func (r *ReconcileCephCluster) removeFinalizers(client client.Client, name types
 		return errors.Wrap(err, "failed to remove cephcluster finalizer")
 	}
 
+	return r.removeMonFinalizers(client, name.Namespace)
+}
+
+// removeMonFinalizers removes the disaster protection finalizers of the mon secret and endpoints
+func (r *ReconcileCephCluster) removeMonFinalizers(client client.Client, namespace string) error {
 	// Remove finalizer for rook-ceph-mon secret
-	name = types.NamespacedName{Name: mon.AppName, Namespace: name.Namespace}
-	err = r.removeFinalizer(client, name, &corev1.Secret{}, mon.DisasterProtectionFinalizerName)
+	name := types.NamespacedName{Name: mon.AppName, Namespace: namespace}
+	err := r.removeFinalizer(client, name, &corev1.Secret{}, mon.DisasterProtectionFinalizerName)
 	if err != nil {
 		return errors.Wrapf(err, "failed to remove finalizer for the secret %q", name.Name)
 	}
 
 	// Remove finalizer for rook-ceph-mon-endpoints configmap
-	name = types.NamespacedName{Name: mon.EndpointConfigMapName, Namespace: name.Namespace}
+	name = types.NamespacedName{Name: mon.EndpointConfigMapName, Namespace: namespace}
 	err = r.removeFinalizer(client, name, &corev1.ConfigMap{}, mon.DisasterProtectionFinalizerName)
 	if err != nil {
 		return errors.Wrapf(err, "failed to remove finalizer for the configmap %q", name.Name)
@@ -526,21 +600,11 @@ func (c *ClusterController) deleteOSDEncryptionKeyFromKMS(currentCluster *cephv1
 	// Initialize the KMS code
 	kmsConfig := kms.NewConfig(c.context, &currentCluster.Spec, c.clusterMap[currentCluster.Namespace].ClusterInfo)
 
-	// If token auth is used by the KMS we set it as an env variable
-	if currentCluster.Spec.Security.KeyManagementService.IsTokenAuthEnabled() && currentCluster.Spec.Security.KeyManagementService.IsVaultKMS() {
-		err := kms.SetTokenToEnvVar(ctx, c.context, currentCluster.Spec.Security.KeyManagementService.TokenSecretName, kmsConfig.Provider, currentCluster.Namespace)
-		if err != nil {
-			return errors.Wrapf(err, "failed to fetch kms token secret %q", currentCluster.Spec.Security.KeyManagementService.TokenSecretName)
-		}
-	}
-
-	// We need to fetch the IBM_KP_SERVICE_API_KEY value
-	if currentCluster.Spec.Security.KeyManagementService.IsIBMKeyProtectKMS() {
-		// This will validate the connection details again and will add the IBM_KP_SERVICE_API_KEY to the spec
-		err = kms.ValidateConnectionDetails(ctx, c.context, &currentCluster.Spec.Security, currentCluster.Namespace)
-		if err != nil {
-			return errors.Wrap(err, "failed to validate kms connection details to delete the secret")
-		}
+	// If token auth is used by the KMS we load the token, e.g. the VAULT_TOKEN env variable or the
+	// IBM_KP_SERVICE_API_KEY appended to the spec
+	err = kms.LoadToken(ctx, c.context, &currentCluster.Spec.Security.KeyManagementService, currentCluster.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch kms token secret %q", currentCluster.Spec.Security.KeyManagementService.TokenSecretName)
 	}
 
 	// Delete each PV KEK
@@ -193,3 +193,20 @@ func TestRemoveFinalizers(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileSecondCephClusterInNamespace(t *testing.T) {
+	c := &ClusterController{
+		clusterMap: map[string]*cluster{
+			"rook-ceph": {namespacedName: types.NamespacedName{Name: "local", Namespace: "rook-ceph"}},
+		},
+	}
+	external := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "rook-ceph"},
+		Spec:       cephv1.ClusterSpec{External: cephv1.ExternalSpec{Enable: true}},
+	}
+
+	// the external cluster must be consumed from another namespace
+	err := c.reconcileCephCluster(external, nil)
+	assert.Error(t, err)
+	assert.Equal(t, "local", c.clusterMap["rook-ceph"].namespacedName.Name)
+}
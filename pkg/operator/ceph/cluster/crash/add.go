@@ -66,7 +66,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileLogFields(controllerName, r)})
 	if err != nil {
 		return errors.Wrapf(err, "failed to create a new %q", controllerName)
 	}
@@ -47,6 +47,9 @@ var (
 		"CephBucketTopic",
 		"CephBucketNotification",
 		"CephFilesystemSubVolumeGroup",
+		"CephFilesystemSubVolume",
+		"CephBlockPoolTopologyList",
+		"CephStaticVolumeList",
 	}
 )
 
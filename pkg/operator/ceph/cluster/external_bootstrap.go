@@ -0,0 +1,272 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The keys of the bootstrap secret of an external cluster, named after the variables of the
+// import-external-cluster.sh script
+const (
+	bootstrapFSIDKey                 = "ROOK_EXTERNAL_FSID"
+	bootstrapMonDataKey              = "ROOK_EXTERNAL_CEPH_MON_DATA"
+	bootstrapAdminSecretKey          = "ROOK_EXTERNAL_ADMIN_SECRET"
+	bootstrapUsernameKey             = "ROOK_EXTERNAL_USERNAME"
+	bootstrapUserSecretKey           = "ROOK_EXTERNAL_USER_SECRET"
+	bootstrapCSIRBDNodeKey           = "CSI_RBD_NODE_SECRET"
+	bootstrapCSIRBDProvisionerKey    = "CSI_RBD_PROVISIONER_SECRET"
+	bootstrapCSICephFSNodeKey        = "CSI_CEPHFS_NODE_SECRET"
+	bootstrapCSICephFSProvisionerKey = "CSI_CEPHFS_PROVISIONER_SECRET"
+	bootstrapRGWAccessKey            = "RGW_ADMIN_OPS_USER_ACCESS_KEY"
+	bootstrapRGWSecretKey            = "RGW_ADMIN_OPS_USER_SECRET_KEY"
+)
+
+// externalBootstrap is the connection info of an external cluster read from its bootstrap secret
+type externalBootstrap struct {
+	connection mon.ExternalConnection
+	// the keys of the csi users, only set when the operator does not connect as admin
+	csiRBDNodeKey           string
+	csiRBDProvisionerKey    string
+	csiCephFSNodeKey        string
+	csiCephFSProvisionerKey string
+	// the keys of the rgw admin ops user, optional
+	rgwAccessKey string
+	rgwSecretKey string
+}
+
+// parseExternalBootstrap reads and validates the content of the bootstrap secret of an external cluster
+func parseExternalBootstrap(data map[string][]byte) (*externalBootstrap, error) {
+	bootstrap := &externalBootstrap{
+		connection: mon.ExternalConnection{
+			FSID:      string(data[bootstrapFSIDKey]),
+			Endpoints: string(data[bootstrapMonDataKey]),
+		},
+		rgwAccessKey: string(data[bootstrapRGWAccessKey]),
+		rgwSecretKey: string(data[bootstrapRGWSecretKey]),
+	}
+
+	if _, err := uuid.Parse(bootstrap.connection.FSID); err != nil {
+		return nil, errors.Wrapf(err, "invalid fsid %q in %q", bootstrap.connection.FSID, bootstrapFSIDKey)
+	}
+	if err := mon.ValidateExternalEndpoints(bootstrap.connection.Endpoints); err != nil {
+		return nil, errors.Wrapf(err, "invalid %q", bootstrapMonDataKey)
+	}
+
+	adminSecret := string(data[bootstrapAdminSecretKey])
+	userSecret := string(data[bootstrapUserSecretKey])
+	switch {
+	case adminSecret != "" && userSecret != "":
+		return nil, errors.Errorf("only one of %q and %q can be set", bootstrapAdminSecretKey, bootstrapUserSecretKey)
+	case adminSecret != "":
+		// the operator creates the csi users itself with the admin key
+		bootstrap.connection.Username = client.AdminUsername
+		bootstrap.connection.Secret = adminSecret
+	case userSecret != "":
+		bootstrap.connection.Username = string(data[bootstrapUsernameKey])
+		bootstrap.connection.Secret = userSecret
+		if bootstrap.connection.Username == "" {
+			return nil, errors.Errorf("%q must be set with %q", bootstrapUsernameKey, bootstrapUserSecretKey)
+		}
+		bootstrap.csiRBDNodeKey = string(data[bootstrapCSIRBDNodeKey])
+		bootstrap.csiRBDProvisionerKey = string(data[bootstrapCSIRBDProvisionerKey])
+		bootstrap.csiCephFSNodeKey = string(data[bootstrapCSICephFSNodeKey])
+		bootstrap.csiCephFSProvisionerKey = string(data[bootstrapCSICephFSProvisionerKey])
+		csiKeys := map[string]string{
+			bootstrapCSIRBDNodeKey:        bootstrap.csiRBDNodeKey,
+			bootstrapCSIRBDProvisionerKey: bootstrap.csiRBDProvisionerKey,
+		}
+		// the cephfs users only exist when the external cluster has a filesystem
+		if bootstrap.csiCephFSNodeKey != "" || bootstrap.csiCephFSProvisionerKey != "" {
+			csiKeys[bootstrapCSICephFSNodeKey] = bootstrap.csiCephFSNodeKey
+			csiKeys[bootstrapCSICephFSProvisionerKey] = bootstrap.csiCephFSProvisionerKey
+		}
+		for key, value := range csiKeys {
+			if value == "" || !client.IsKeyringBase64Encoded(value) {
+				return nil, errors.Errorf("missing or invalid csi user key %q", key)
+			}
+		}
+	default:
+		return nil, errors.Errorf("either %q or %q must be set", bootstrapAdminSecretKey, bootstrapUserSecretKey)
+	}
+	if !client.IsKeyringBase64Encoded(bootstrap.connection.Secret) {
+		return nil, errors.Errorf("invalid key of user %q", bootstrap.connection.Username)
+	}
+
+	if (bootstrap.rgwAccessKey == "") != (bootstrap.rgwSecretKey == "") {
+		return nil, errors.Errorf("both %q and %q must be set", bootstrapRGWAccessKey, bootstrapRGWSecretKey)
+	}
+
+	return bootstrap, nil
+}
+
+// parseObjectOnlyBootstrap reads the keys of the rgw admin ops user, the only content of the bootstrap
+// secret of an object-only external cluster
+func parseObjectOnlyBootstrap(data map[string][]byte) (*externalBootstrap, error) {
+	bootstrap := &externalBootstrap{
+		rgwAccessKey: string(data[bootstrapRGWAccessKey]),
+		rgwSecretKey: string(data[bootstrapRGWSecretKey]),
+	}
+	if bootstrap.rgwAccessKey == "" || bootstrap.rgwSecretKey == "" {
+		return nil, errors.Errorf("both %q and %q must be set for an object-only external cluster", bootstrapRGWAccessKey, bootstrapRGWSecretKey)
+	}
+	return bootstrap, nil
+}
+
+// keysHash returns the hash of the keys of the bootstrap secret, which changes when the keys are rotated
+func (b *externalBootstrap) keysHash() string {
+	return k8sutil.Hash(strings.Join([]string{
+		b.connection.Username,
+		b.connection.Secret,
+		b.csiRBDNodeKey,
+		b.csiRBDProvisionerKey,
+		b.csiCephFSNodeKey,
+		b.csiCephFSProvisionerKey,
+		b.rgwAccessKey,
+		b.rgwSecretKey,
+	}, ","))
+}
+
+// importExternalBootstrap creates the mon secret, the mon endpoints configmap and the rgw admin ops
+// user secret of the external cluster from its bootstrap secret. Only the rgw admin ops user secret is
+// created for an object-only external cluster.
+func (c *ClusterController) importExternalBootstrap(cluster *cluster) (*externalBootstrap, error) {
+	namespace := c.namespacedName.Namespace
+	name := cluster.Spec.External.BootstrapSecret
+	secret, err := c.context.Clientset.CoreV1().Secrets(namespace).Get(c.OpManagerCtx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get external cluster bootstrap secret %q", name)
+	}
+	var bootstrap *externalBootstrap
+	if cluster.Spec.External.ObjectOnly {
+		bootstrap, err = parseObjectOnlyBootstrap(secret.Data)
+	} else {
+		bootstrap, err = parseExternalBootstrap(secret.Data)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid external cluster bootstrap secret %q", name)
+	}
+
+	if !cluster.Spec.External.ObjectOnly {
+		err = mon.ImportExternalConnection(c.OpManagerCtx, c.context.Clientset, namespace, &bootstrap.connection, cluster.ownerInfo)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to import external cluster connection info")
+		}
+	}
+
+	// The rgw admin ops user is created by the operator when the users of the object stores are managed
+	users := cluster.Spec.External.Users
+	if bootstrap.rgwAccessKey != "" && (users == nil || !users.ObjectStore) {
+		rgwSecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      object.RGWAdminOpsUserSecretName,
+				Namespace: namespace,
+			},
+			Data: map[string][]byte{
+				object.RGWAdminOpsUserAccessKey: []byte(bootstrap.rgwAccessKey),
+				object.RGWAdminOpsUserSecretKey: []byte(bootstrap.rgwSecretKey),
+			},
+			Type: k8sutil.RookType,
+		}
+		err = cluster.ownerInfo.SetControllerReference(rgwSecret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to set owner reference to secret %q", rgwSecret.Name)
+		}
+		if _, err = k8sutil.CreateOrUpdateSecret(c.OpManagerCtx, c.context.Clientset, rgwSecret); err != nil {
+			return nil, errors.Wrap(err, "failed to import rgw admin ops user secret")
+		}
+	}
+
+	logger.Infof("imported external cluster bootstrap secret %q", name)
+	return bootstrap, nil
+}
+
+// importExternalCSISecrets creates the csi secrets with the keys of the csi users of the bootstrap
+// secret, when the operator does not connect as admin to create the csi users itself
+func (c *ClusterController) importExternalCSISecrets(cluster *cluster, bootstrap *externalBootstrap) error {
+	err := csi.ImportCSISecrets(c.context, cluster.ClusterInfo, bootstrap.csiRBDProvisionerKey, bootstrap.csiRBDNodeKey, bootstrap.csiCephFSProvisionerKey, bootstrap.csiCephFSNodeKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to import csi secrets")
+	}
+	return nil
+}
+
+// updateExternalKeyEpoch reports the keys of the bootstrap secret in use in the status of the
+// CephCluster. When the keys were rotated, the operator reconnects to the external cluster with the
+// new keys before reporting them, and restarts its monitoring goroutines that still use the previous
+// keys. The csi drivers read their secrets for each operation and do not need to be restarted.
+func (c *ClusterController) updateExternalKeyEpoch(cluster *cluster, bootstrap *externalBootstrap) error {
+	cephCluster := &cephv1.CephCluster{}
+	err := c.client.Get(c.OpManagerCtx, c.namespacedName, cephCluster)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get cluster %q", c.namespacedName.String())
+	}
+
+	keysHash := bootstrap.keysHash()
+	status := cephCluster.Status.External
+	if status != nil && status.KeysHash == keysHash {
+		return nil
+	}
+	newStatus := &cephv1.ExternalClusterStatus{
+		KeyEpoch:            1,
+		KeysHash:            keysHash,
+		LastKeyRotationTime: &metav1.Time{Time: time.Now()},
+	}
+
+	if status != nil {
+		newStatus.KeyEpoch = status.KeyEpoch + 1
+		logger.Infof("keys of external cluster %q were rotated, connecting with the keys of epoch %d", c.namespacedName.String(), newStatus.KeyEpoch)
+		if err := mon.WriteConnectionConfig(c.context, cluster.ClusterInfo); err != nil {
+			return errors.Wrap(err, "failed to write connection config with the rotated keys")
+		}
+		if _, err := client.GetMonQuorumStatus(c.context, cluster.ClusterInfo); err != nil {
+			return errors.Wrapf(err, "failed to connect to the external cluster with the keys of epoch %d", newStatus.KeyEpoch)
+		}
+		// the monitoring goroutines are started again with the new keys at the end of the reconcile
+		for daemon, routine := range cluster.monitoringRoutines {
+			routine.internalCancel()
+			delete(cluster.monitoringRoutines, daemon)
+		}
+	}
+
+	err = reporting.PatchStatus(c.client, cephCluster, func() bool {
+		// keep the crush location labels imported from the external cluster
+		if cephCluster.Status.External != nil {
+			newStatus.CrushLocationLabels = cephCluster.Status.External.CrushLocationLabels
+		}
+		cephCluster.Status.External = newStatus
+		return true
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update external cluster key epoch")
+	}
+	logger.Infof("external cluster %q uses the keys of epoch %d", c.namespacedName.String(), newStatus.KeyEpoch)
+	return nil
+}
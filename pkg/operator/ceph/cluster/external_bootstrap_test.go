@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExternalBootstrap(t *testing.T) {
+	key := "AQDFkbNeft5bFRAATndLNUSEKruozxiZi3lrdA=="
+	newData := func() map[string][]byte {
+		return map[string][]byte{
+			bootstrapFSIDKey:    []byte("b5a4c9c5-4d2e-4a1e-9c5e-1f3c7f7d1a2b"),
+			bootstrapMonDataKey: []byte("a=10.0.0.1:6789,b=10.0.0.2:6789"),
+		}
+	}
+
+	t.Run("admin key", func(t *testing.T) {
+		data := newData()
+		data[bootstrapAdminSecretKey] = []byte(key)
+		bootstrap, err := parseExternalBootstrap(data)
+		assert.NoError(t, err)
+		assert.Equal(t, client.AdminUsername, bootstrap.connection.Username)
+		assert.Equal(t, key, bootstrap.connection.Secret)
+		assert.Equal(t, "a=10.0.0.1:6789,b=10.0.0.2:6789", bootstrap.connection.Endpoints)
+		assert.Equal(t, "", bootstrap.csiRBDNodeKey)
+	})
+
+	t.Run("health checker user with the csi users", func(t *testing.T) {
+		data := newData()
+		data[bootstrapUsernameKey] = []byte("client.healthchecker")
+		data[bootstrapUserSecretKey] = []byte(key)
+		_, err := parseExternalBootstrap(data)
+		assert.Error(t, err)
+
+		data[bootstrapCSIRBDNodeKey] = []byte(key)
+		data[bootstrapCSIRBDProvisionerKey] = []byte(key)
+		bootstrap, err := parseExternalBootstrap(data)
+		assert.NoError(t, err)
+		assert.Equal(t, "client.healthchecker", bootstrap.connection.Username)
+		assert.Equal(t, key, bootstrap.csiRBDNodeKey)
+		assert.Equal(t, "", bootstrap.csiCephFSNodeKey)
+
+		// both cephfs users are needed
+		data[bootstrapCSICephFSNodeKey] = []byte(key)
+		_, err = parseExternalBootstrap(data)
+		assert.Error(t, err)
+
+		data[bootstrapCSICephFSProvisionerKey] = []byte(key)
+		bootstrap, err = parseExternalBootstrap(data)
+		assert.NoError(t, err)
+		assert.Equal(t, key, bootstrap.csiCephFSProvisionerKey)
+
+		delete(data, bootstrapUsernameKey)
+		_, err = parseExternalBootstrap(data)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid connection info", func(t *testing.T) {
+		data := newData()
+		_, err := parseExternalBootstrap(data)
+		assert.Error(t, err)
+
+		data[bootstrapAdminSecretKey] = []byte("not-a-key")
+		_, err = parseExternalBootstrap(data)
+		assert.Error(t, err)
+
+		data[bootstrapAdminSecretKey] = []byte(key)
+		data[bootstrapUserSecretKey] = []byte(key)
+		_, err = parseExternalBootstrap(data)
+		assert.Error(t, err)
+
+		data = newData()
+		data[bootstrapAdminSecretKey] = []byte(key)
+		data[bootstrapFSIDKey] = []byte("fsid")
+		_, err = parseExternalBootstrap(data)
+		assert.Error(t, err)
+
+		data = newData()
+		data[bootstrapAdminSecretKey] = []byte(key)
+		data[bootstrapMonDataKey] = []byte("10.0.0.1:6789")
+		_, err = parseExternalBootstrap(data)
+		assert.Error(t, err)
+	})
+
+	t.Run("rgw admin ops user", func(t *testing.T) {
+		data := newData()
+		data[bootstrapAdminSecretKey] = []byte(key)
+		data[bootstrapRGWAccessKey] = []byte("access")
+		_, err := parseExternalBootstrap(data)
+		assert.Error(t, err)
+
+		data[bootstrapRGWSecretKey] = []byte("secret")
+		bootstrap, err := parseExternalBootstrap(data)
+		assert.NoError(t, err)
+		assert.Equal(t, "access", bootstrap.rgwAccessKey)
+		assert.Equal(t, "secret", bootstrap.rgwSecretKey)
+	})
+}
+
+func TestParseObjectOnlyBootstrap(t *testing.T) {
+	// the mon connection info is not needed
+	data := map[string][]byte{bootstrapRGWAccessKey: []byte("access")}
+	_, err := parseObjectOnlyBootstrap(data)
+	assert.Error(t, err)
+
+	data[bootstrapRGWSecretKey] = []byte("secret")
+	bootstrap, err := parseObjectOnlyBootstrap(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", bootstrap.rgwAccessKey)
+	assert.Equal(t, "secret", bootstrap.rgwSecretKey)
+	assert.Equal(t, "", bootstrap.connection.FSID)
+}
+
+func TestExternalBootstrapKeysHash(t *testing.T) {
+	bootstrap := &externalBootstrap{}
+	bootstrap.connection.Username = client.AdminUsername
+	bootstrap.connection.Secret = "AQDFkbNeft5bFRAATndLNUSEKruozxiZi3lrdA=="
+	bootstrap.connection.Endpoints = "a=10.0.0.1:6789"
+	hash := bootstrap.keysHash()
+
+	// the mon endpoints are not keys
+	bootstrap.connection.Endpoints = "a=10.0.0.2:6789"
+	assert.Equal(t, hash, bootstrap.keysHash())
+
+	bootstrap.connection.Secret = "AQBOgrNeHbK1AxAAubYBeV8S1U/GPzq5SVeq6g=="
+	assert.NotEqual(t, hash, bootstrap.keysHash())
+	hash = bootstrap.keysHash()
+
+	bootstrap.rgwSecretKey = "secret"
+	assert.NotEqual(t, hash, bootstrap.keysHash())
+}
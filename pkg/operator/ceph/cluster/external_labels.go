@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const externalLabelsMetricName = "rook_ceph_cluster_external_labels"
+
+// externalLabelsCollector exports the external labels of each cluster as the labels of an info metric.
+// The operator serves several clusters, so the labels cannot be added to all its metrics, they are
+// added to the metrics of a cluster by joining them on its namespace.
+type externalLabelsCollector struct {
+	mutex sync.Mutex
+	// the external labels keyed by the namespace of the cluster
+	labels map[string]map[string]string
+}
+
+var externalLabels = &externalLabelsCollector{labels: map[string]map[string]string{}}
+
+func init() {
+	metrics.Registry.MustRegister(externalLabels)
+}
+
+// Describe does not describe the metric since its labels vary with the clusters, which makes the
+// collector unchecked
+func (e *externalLabelsCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect exports the external labels of each cluster
+func (e *externalLabelsCollector) Collect(ch chan<- prometheus.Metric) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for namespace, labels := range e.labels {
+		names := []string{"namespace"}
+		values := []string{namespace}
+		for name := range labels {
+			names = append(names, name)
+		}
+		sort.Strings(names[1:])
+		for _, name := range names[1:] {
+			values = append(values, labels[name])
+		}
+		desc := prometheus.NewDesc(externalLabelsMetricName, "The external labels of the cluster, to be joined on the namespace with the metrics of the cluster", names, nil)
+		metric, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, 1, values...)
+		if err != nil {
+			logger.Warningf("failed to export the external labels of the cluster in namespace %q. %v", namespace, err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// set exports the external labels of the cluster, or removes them if none
+func (e *externalLabelsCollector) set(namespace string, labels map[string]string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if len(labels) == 0 {
+		delete(e.labels, namespace)
+		return
+	}
+	copied := make(map[string]string, len(labels))
+	for name, value := range labels {
+		copied[name] = value
+	}
+	e.labels[namespace] = copied
+}
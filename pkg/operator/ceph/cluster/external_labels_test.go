@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalLabelsCollector(t *testing.T) {
+	e := &externalLabelsCollector{labels: map[string]map[string]string{}}
+	assert.Equal(t, 0, testutil.CollectAndCount(e))
+
+	e.set("rook-ceph", map[string]string{"region": "eu-west-1", "cluster": "prod-a"})
+	e.set("rook-ceph-secondary", map[string]string{"cluster": "prod-b"})
+	expected := `
+# HELP rook_ceph_cluster_external_labels The external labels of the cluster, to be joined on the namespace with the metrics of the cluster
+# TYPE rook_ceph_cluster_external_labels gauge
+rook_ceph_cluster_external_labels{cluster="prod-a",namespace="rook-ceph",region="eu-west-1"} 1
+rook_ceph_cluster_external_labels{cluster="prod-b",namespace="rook-ceph-secondary"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(e, strings.NewReader(expected)))
+
+	// the labels are removed with the cluster
+	e.set("rook-ceph-secondary", nil)
+	assert.Equal(t, 1, testutil.CollectAndCount(e))
+}
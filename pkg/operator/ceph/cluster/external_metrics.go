@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// The metrics of the external clusters are served by the metrics endpoint of the operator, since
+// there is no mgr running in the namespace of an external cluster to export them
+var (
+	externalClusterConnected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_external_cluster_connected",
+		Help: "Whether the operator could get the status of the external cluster during the last check",
+	}, []string{"namespace"})
+	externalClusterHealth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_external_cluster_health_status",
+		Help: "Health of the external cluster: 0 for HEALTH_OK, 1 for HEALTH_WARN and 2 for HEALTH_ERR",
+	}, []string{"namespace"})
+	externalClusterHealthCheck = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_external_cluster_health_check",
+		Help: "The health checks raised by the external cluster",
+	}, []string{"namespace", "check", "severity"})
+	externalClusterCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_external_cluster_capacity_bytes",
+		Help: "The total, used and available capacity of the external cluster",
+	}, []string{"namespace", "type"})
+	externalClusterVersions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_external_cluster_versions",
+		Help: "The number of daemons of the external cluster running each ceph version",
+	}, []string{"namespace", "version"})
+)
+
+var healthStatusValues = map[string]float64{
+	"HEALTH_OK":   0,
+	"HEALTH_WARN": 1,
+	"HEALTH_ERR":  2,
+}
+
+func init() {
+	metrics.Registry.MustRegister(
+		externalClusterConnected,
+		externalClusterHealth,
+		externalClusterHealthCheck,
+		externalClusterCapacity,
+		externalClusterVersions,
+	)
+}
+
+// externalClusterMetrics exports the status of an external cluster. It keeps the health checks and
+// versions it reported to remove them when they are gone.
+type externalClusterMetrics struct {
+	namespace string
+	checks    map[string]string
+	versions  map[string]bool
+}
+
+func newExternalClusterMetrics(namespace string) *externalClusterMetrics {
+	return &externalClusterMetrics{
+		namespace: namespace,
+		checks:    map[string]string{},
+		versions:  map[string]bool{},
+	}
+}
+
+// setDisconnected reports that the status of the external cluster could not be retrieved
+func (m *externalClusterMetrics) setDisconnected() {
+	externalClusterConnected.WithLabelValues(m.namespace).Set(0)
+}
+
+// setStatus reports the health and capacity of the external cluster
+func (m *externalClusterMetrics) setStatus(status *cephclient.CephStatus) {
+	externalClusterConnected.WithLabelValues(m.namespace).Set(1)
+
+	if value, ok := healthStatusValues[status.Health.Status]; ok {
+		externalClusterHealth.WithLabelValues(m.namespace).Set(value)
+	} else {
+		externalClusterHealth.DeleteLabelValues(m.namespace)
+	}
+
+	checks := map[string]string{}
+	for name, check := range status.Health.Checks {
+		checks[name] = check.Severity
+		externalClusterHealthCheck.WithLabelValues(m.namespace, name, check.Severity).Set(1)
+	}
+	for name, severity := range m.checks {
+		if checks[name] != severity {
+			externalClusterHealthCheck.DeleteLabelValues(m.namespace, name, severity)
+		}
+	}
+	m.checks = checks
+
+	// the capacity is not reported until the pgs were reported to the mgr
+	if status.PgMap.TotalBytes != 0 {
+		externalClusterCapacity.WithLabelValues(m.namespace, "total").Set(float64(status.PgMap.TotalBytes))
+		externalClusterCapacity.WithLabelValues(m.namespace, "used").Set(float64(status.PgMap.UsedBytes))
+		externalClusterCapacity.WithLabelValues(m.namespace, "available").Set(float64(status.PgMap.AvailableBytes))
+	}
+}
+
+// setVersions reports the number of daemons of the external cluster running each ceph version
+func (m *externalClusterMetrics) setVersions(overall map[string]int) {
+	versions := map[string]bool{}
+	for version, count := range overall {
+		versions[version] = true
+		externalClusterVersions.WithLabelValues(m.namespace, version).Set(float64(count))
+	}
+	for version := range m.versions {
+		if !versions[version] {
+			externalClusterVersions.DeleteLabelValues(m.namespace, version)
+		}
+	}
+	m.versions = versions
+}
+
+// clear removes all the metrics of the external cluster, when it is not monitored anymore
+func (m *externalClusterMetrics) clear() {
+	externalClusterConnected.DeleteLabelValues(m.namespace)
+	externalClusterHealth.DeleteLabelValues(m.namespace)
+	for name, severity := range m.checks {
+		externalClusterHealthCheck.DeleteLabelValues(m.namespace, name, severity)
+	}
+	for _, capacityType := range []string{"total", "used", "available"} {
+		externalClusterCapacity.DeleteLabelValues(m.namespace, capacityType)
+	}
+	for version := range m.versions {
+		externalClusterVersions.DeleteLabelValues(m.namespace, version)
+	}
+	m.checks = map[string]string{}
+	m.versions = map[string]bool{}
+}
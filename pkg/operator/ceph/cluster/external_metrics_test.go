@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalClusterMetrics(t *testing.T) {
+	m := newExternalClusterMetrics("external")
+	defer m.clear()
+
+	status := &cephclient.CephStatus{
+		Health: cephclient.HealthStatus{
+			Status: "HEALTH_WARN",
+			Checks: map[string]cephclient.CheckMessage{
+				"MON_DISK_LOW": {Severity: "HEALTH_WARN"},
+				"OSD_DOWN":     {Severity: "HEALTH_WARN"},
+			},
+		},
+		PgMap: cephclient.PgMap{TotalBytes: 300, UsedBytes: 100, AvailableBytes: 200},
+	}
+	m.setStatus(status)
+	m.setVersions(map[string]int{"ceph version 16.2.7": 5})
+	assert.Equal(t, float64(1), testutil.ToFloat64(externalClusterConnected.WithLabelValues("external")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(externalClusterHealth.WithLabelValues("external")))
+	assert.Equal(t, 2, testutil.CollectAndCount(externalClusterHealthCheck))
+	assert.Equal(t, float64(100), testutil.ToFloat64(externalClusterCapacity.WithLabelValues("external", "used")))
+	assert.Equal(t, float64(5), testutil.ToFloat64(externalClusterVersions.WithLabelValues("external", "ceph version 16.2.7")))
+
+	t.Run("resolved checks and upgraded versions are removed", func(t *testing.T) {
+		status.Health.Status = "HEALTH_ERR"
+		status.Health.Checks = map[string]cephclient.CheckMessage{"OSD_DOWN": {Severity: "HEALTH_ERR"}}
+		m.setStatus(status)
+		m.setVersions(map[string]int{"ceph version 16.2.7": 2, "ceph version 17.2.0": 3})
+		assert.Equal(t, float64(2), testutil.ToFloat64(externalClusterHealth.WithLabelValues("external")))
+		assert.Equal(t, 1, testutil.CollectAndCount(externalClusterHealthCheck))
+		assert.Equal(t, float64(1), testutil.ToFloat64(externalClusterHealthCheck.WithLabelValues("external", "OSD_DOWN", "HEALTH_ERR")))
+		assert.Equal(t, 2, testutil.CollectAndCount(externalClusterVersions))
+
+		m.setVersions(map[string]int{"ceph version 17.2.0": 5})
+		assert.Equal(t, 1, testutil.CollectAndCount(externalClusterVersions))
+	})
+
+	t.Run("disconnected", func(t *testing.T) {
+		m.setDisconnected()
+		assert.Equal(t, float64(0), testutil.ToFloat64(externalClusterConnected.WithLabelValues("external")))
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		m.clear()
+		assert.Equal(t, 0, testutil.CollectAndCount(externalClusterConnected))
+		assert.Equal(t, 0, testutil.CollectAndCount(externalClusterHealth))
+		assert.Equal(t, 0, testutil.CollectAndCount(externalClusterHealthCheck))
+		assert.Equal(t, 0, testutil.CollectAndCount(externalClusterCapacity))
+		assert.Equal(t, 0, testutil.CollectAndCount(externalClusterVersions))
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// validateFIPS checks that the settings of the cluster only rely on the algorithms approved by
+// FIPS 140 when the FIPS mode is enabled, and reports the compliance in the status of the cluster
+func validateFIPS(cluster *cluster) error {
+	var fipsStatus *cephv1.FIPSStatus
+	if cluster.Spec.Security.IsFIPSEnabled() {
+		violations, err := fipsViolations(cluster)
+		if err != nil {
+			return errors.Wrap(err, "failed to check the fips compliance")
+		}
+		fipsStatus = &cephv1.FIPSStatus{Compliant: len(violations) == 0, Violations: violations}
+	}
+
+	if err := updateFIPSStatus(cluster, fipsStatus); err != nil {
+		logger.Errorf("failed to update the fips status. %v", err)
+	}
+	if fipsStatus != nil && !fipsStatus.Compliant {
+		return errors.Errorf("the cluster is not compliant with fips. %s", strings.Join(fipsStatus.Violations, "; "))
+	}
+	return nil
+}
+
+// fipsViolations returns the settings of the cluster relying on algorithms not approved by FIPS 140
+func fipsViolations(cluster *cluster) ([]string, error) {
+	violations := []string{}
+
+	// the unsupported images are development builds, not validated
+	if cluster.Spec.CephVersion.AllowUnsupported {
+		violations = append(violations, "unsupported ceph images are not allowed")
+	}
+
+	// the kernel client must connect in secure mode, an explicit other mode is not overridden
+	kernelMode := csi.KernelMountOption(cluster.Spec.CSI.CephFS.KernelMountOptions, "ms_mode")
+	if kernelMode != "" && kernelMode != "secure" {
+		violations = append(violations, fmt.Sprintf("the cephfs kernel mount option \"ms_mode=%s\" does not encrypt the connections", kernelMode))
+	}
+
+	cm, err := cluster.context.Clientset.CoreV1().ConfigMaps(cluster.Namespace).Get(cluster.ClusterInfo.Context, k8sutil.ConfigOverrideName, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get configmap %q", k8sutil.ConfigOverrideName)
+		}
+		return violations, nil
+	}
+	overrideViolations, err := config.FIPSOverrideViolations(cm.Data[k8sutil.ConfigOverrideVal])
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check configmap %q", k8sutil.ConfigOverrideName)
+	}
+	return append(violations, overrideViolations...), nil
+}
+
+// updateFIPSStatus updates the fips compliance in the status of the cluster, removed if nil
+func updateFIPSStatus(cluster *cluster, fipsStatus *cephv1.FIPSStatus) error {
+	cephCluster := &cephv1.CephCluster{}
+	if err := cluster.context.Client.Get(cluster.ClusterInfo.Context, cluster.namespacedName, cephCluster); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get ceph cluster %q to update the fips status", cluster.namespacedName.Name)
+	}
+	err := reporting.PatchStatus(cluster.context.Client, cephCluster, func() bool {
+		if reflect.DeepEqual(cephCluster.Status.FIPS, fipsStatus) {
+			return false
+		}
+		cephCluster.Status.FIPS = fipsStatus
+		return true
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update ceph cluster %q fips status", cephCluster.Name)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateFIPS(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: ns}}
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	clientset := testop.New(t, 1)
+	c := &cluster{
+		ClusterInfo:    cephclient.AdminTestClusterInfo(ns),
+		Namespace:      ns,
+		namespacedName: types.NamespacedName{Namespace: ns, Name: "testing"},
+		context: &clusterd.Context{
+			Clientset: clientset,
+			Client:    fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cephCluster).Build(),
+		},
+		Spec: &cephv1.ClusterSpec{},
+	}
+	status := func() *cephv1.FIPSStatus {
+		updated := &cephv1.CephCluster{}
+		assert.NoError(t, c.context.Client.Get(ctx, c.namespacedName, updated))
+		return updated.Status.FIPS
+	}
+
+	// no status when disabled
+	assert.NoError(t, validateFIPS(c))
+	assert.Nil(t, status())
+
+	// compliant
+	c.Spec.Security.FIPS = &cephv1.FIPSSpec{Enabled: true}
+	assert.NoError(t, validateFIPS(c))
+	assert.Equal(t, &cephv1.FIPSStatus{Compliant: true}, status())
+
+	// unsupported image and unencrypted kernel mounts
+	c.Spec.CephVersion.AllowUnsupported = true
+	c.Spec.CSI.CephFS.KernelMountOptions = "ms_mode=crc"
+	assert.Error(t, validateFIPS(c))
+	assert.False(t, status().Compliant)
+	assert.Len(t, status().Violations, 2)
+
+	// unencrypted connections in the config override
+	c.Spec.CephVersion.AllowUnsupported = false
+	c.Spec.CSI.CephFS.KernelMountOptions = ""
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8sutil.ConfigOverrideName, Namespace: ns},
+		Data:       map[string]string{k8sutil.ConfigOverrideVal: "[global]\nms_cluster_mode = crc\n"},
+	}
+	_, err := clientset.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Error(t, validateFIPS(c))
+	assert.Len(t, status().Violations, 1)
+	assert.Contains(t, status().Violations[0], "ms_cluster_mode")
+
+	// the status is removed when disabled
+	c.Spec.Security.FIPS.Enabled = false
+	assert.NoError(t, validateFIPS(c))
+	assert.Nil(t, status())
+}
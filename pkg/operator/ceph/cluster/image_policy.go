@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// applyImagePolicy pins the ceph image to its digest and verifies its signature when the image policy
+// is enabled, before any daemon is updated. The daemons of the reconcile run the pinned image, the
+// other controllers read it from the status of the cluster.
+func (c *ClusterController) applyImagePolicy(cluster *cluster) error {
+	policy := cluster.Spec.Security.ImagePolicy
+	image := cluster.Spec.CephVersion.Image
+	if !policy.IsEnabled() || image == "" {
+		return c.updateImagesStatus(nil)
+	}
+
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.client.Get(c.OpManagerCtx, c.namespacedName, cephCluster); err != nil {
+		return errors.Wrapf(err, "failed to get ceph cluster %q", c.namespacedName.Name)
+	}
+	images, err := controller.ApplyImagePolicy(c.OpManagerCtx, c.context.Clientset, cluster.Namespace, policy, []string{image}, cephCluster.Status.Images)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply the image policy to the ceph image")
+	}
+	if err := c.updateImagesStatus(images); err != nil {
+		return err
+	}
+
+	// the spec is not written back, only the daemons of this reconcile use the pinned image
+	cluster.Spec.CephVersion.Image = images[0].PinnedImage()
+	return nil
+}
+
+// updateImagesStatus records the pinned images in the status of the cluster
+func (c *ClusterController) updateImagesStatus(images []cephv1.ImageStatus) error {
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.client.Get(c.OpManagerCtx, c.namespacedName, cephCluster); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get ceph cluster %q to update the pinned images", c.namespacedName.Name)
+	}
+	err := reporting.PatchStatus(c.client, cephCluster, func() bool {
+		if len(cephCluster.Status.Images) == 0 && len(images) == 0 || reflect.DeepEqual(cephCluster.Status.Images, images) {
+			return false
+		}
+		cephCluster.Status.Images = images
+		return true
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update ceph cluster %q pinned images", cephCluster.Name)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	v1 "k8s.io/api/core/v1"
+)
+
+// checkKMSConnection checks that the KMS storing the encryption keys of the OSDs can be reached and
+// reports it with the KMSConnected condition. The OSDs are not provisioned while the KMS cannot be
+// reached, they would fail to store or to fetch their keys.
+func (c *cluster) checkKMSConnection() error {
+	kmsSpec := &c.Spec.Security.KeyManagementService
+	if !c.Spec.Storage.IsOnPVCEncrypted() || !kmsSpec.IsEnabled() {
+		return nil
+	}
+
+	condition := cephv1.Condition{
+		Type:    cephv1.ConditionKMSConnected,
+		Status:  v1.ConditionTrue,
+		Reason:  cephv1.KMSConnectedReason,
+		Message: "the kms can be reached",
+	}
+	connErr := kms.CheckConnection(c.ClusterInfo.Context, c.context, kmsSpec, c.Namespace)
+	if connErr != nil {
+		condition.Status = v1.ConditionFalse
+		condition.Reason = cephv1.KMSConnectionFailedReason
+		condition.Message = connErr.Error()
+	}
+	if err := c.updateKMSCondition(condition); err != nil {
+		logger.Errorf("failed to report the kms connection. %v", err)
+	}
+
+	if connErr != nil {
+		return errors.Wrap(connErr, "failed to connect to the kms, the osds are not provisioned")
+	}
+	return nil
+}
+
+// updateKMSCondition sets the KMSConnected condition of the cluster
+func (c *cluster) updateKMSCondition(condition cephv1.Condition) error {
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.context.Client.Get(c.ClusterInfo.Context, c.ClusterInfo.NamespacedName(), cephCluster); err != nil {
+		return errors.Wrapf(err, "failed to get ceph cluster %q to update the kms condition", c.ClusterInfo.NamespacedName().Name)
+	}
+	err := reporting.PatchStatus(c.context.Client, cephCluster, func() bool {
+		current := cephv1.FindStatusCondition(cephCluster.Status.Conditions, condition.Type)
+		if current != nil && current.Status == condition.Status && current.Reason == condition.Reason && current.Message == condition.Message {
+			return false
+		}
+		cephv1.SetStatusCondition(&cephCluster.Status.Conditions, condition)
+		return true
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update ceph cluster %q kms condition", cephCluster.Name)
+	}
+	return nil
+}
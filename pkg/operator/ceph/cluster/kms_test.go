@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckKMSConnection(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	reachable := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !reachable || r.URL.Path != "/v1/auth/token/lookup-self" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, err := w.Write([]byte(`{"data":{"id":"token"}}`))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: ns}}
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	c := &cluster{
+		ClusterInfo: cephclient.AdminTestClusterInfo(ns),
+		Namespace:   ns,
+		context: &clusterd.Context{
+			Clientset: testop.New(t, 1),
+			Client:    fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cephCluster).Build(),
+		},
+		Spec: &cephv1.ClusterSpec{
+			Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{
+				ConnectionDetails: map[string]string{"KMS_PROVIDER": "vault", "VAULT_ADDR": server.URL, "VAULT_TOKEN": "token"},
+			}},
+		},
+	}
+	condition := func() *cephv1.Condition {
+		updated := &cephv1.CephCluster{}
+		assert.NoError(t, c.context.Client.Get(ctx, c.ClusterInfo.NamespacedName(), updated))
+		return cephv1.FindStatusCondition(updated.Status.Conditions, cephv1.ConditionKMSConnected)
+	}
+
+	t.Run("the osds are not encrypted", func(t *testing.T) {
+		assert.NoError(t, c.checkKMSConnection())
+		assert.Nil(t, condition())
+	})
+
+	c.Spec.Storage.StorageClassDeviceSets = []cephv1.StorageClassDeviceSet{{Name: "set1", Encrypted: true}}
+	t.Run("connected", func(t *testing.T) {
+		assert.NoError(t, c.checkKMSConnection())
+		assert.Equal(t, v1.ConditionTrue, condition().Status)
+		assert.Equal(t, cephv1.KMSConnectedReason, condition().Reason)
+	})
+
+	t.Run("connection failed", func(t *testing.T) {
+		reachable = false
+		err := c.checkKMSConnection()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "the osds are not provisioned")
+		assert.Equal(t, v1.ConditionFalse, condition().Status)
+		assert.Equal(t, cephv1.KMSConnectionFailedReason, condition().Reason)
+		assert.Contains(t, condition().Message, "failed to look up the token of vault")
+	})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const alertSeverityLabel = "severity"
+
+// the threshold of an alert is the number its expression ends being compared to
+var alertThresholdRegex = regexp.MustCompile(`^(?s)(.*(?:>=|<=|==|!=|>|<)\s*)(-?[0-9]+(?:\.[0-9]+)?)(\s*)$`)
+
+// customizeAlerts applies the customizations of the alerts of the cluster spec to the prometheus rules,
+// removing the disabled alerts. The alerts not found in the rules are ignored, since the rules differ
+// between the ceph versions.
+func customizeAlerts(rule *monitoringv1.PrometheusRule, alerts map[string]cephv1.PrometheusAlertSpec) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	found := map[string]bool{}
+	for g := range rule.Spec.Groups {
+		group := &rule.Spec.Groups[g]
+		rules := []monitoringv1.Rule{}
+		for _, r := range group.Rules {
+			alert, ok := alerts[r.Alert]
+			if r.Alert == "" || !ok {
+				rules = append(rules, r)
+				continue
+			}
+			found[r.Alert] = true
+			if alert.Disabled {
+				continue
+			}
+			if alert.Threshold != "" {
+				match := alertThresholdRegex.FindStringSubmatch(r.Expr.String())
+				if match == nil {
+					return errors.Errorf("alert %q has no threshold to replace", r.Alert)
+				}
+				r.Expr = intstr.FromString(match[1] + alert.Threshold + match[3])
+			}
+			if alert.For != "" {
+				r.For = alert.For
+			}
+			if alert.Severity != "" {
+				labels := map[string]string{}
+				for k, v := range r.Labels {
+					labels[k] = v
+				}
+				labels[alertSeverityLabel] = alert.Severity
+				r.Labels = labels
+			}
+			rules = append(rules, r)
+		}
+		group.Rules = rules
+	}
+
+	unknown := []string{}
+	for name := range alerts {
+		if !found[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		logger.Warningf("alerts %v not found in prometheus rule %q", unknown, rule.Name)
+	}
+	return nil
+}
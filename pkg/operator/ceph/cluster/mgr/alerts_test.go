@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"testing"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findAlert(rule *monitoringv1.PrometheusRule, name string) *monitoringv1.Rule {
+	for _, group := range rule.Spec.Groups {
+		for i := range group.Rules {
+			if group.Rules[i].Alert == name {
+				return &group.Rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestCustomizeAlerts(t *testing.T) {
+	rule, err := k8sutil.GetPrometheusRule("../../../../../deploy/examples/monitoring/prometheus-ceph-v16-rules.yaml")
+	require.NoError(t, err)
+
+	err = customizeAlerts(rule, map[string]cephv1.PrometheusAlertSpec{
+		"CephOSDNearFull":  {Threshold: "0.70", For: "5m", Severity: "critical"},
+		"CephOSDFlapping":  {Threshold: "5"},
+		"CephMgrIsAbsent":  {Disabled: true},
+		"CephUnknownAlert": {Disabled: true},
+	})
+	assert.NoError(t, err)
+
+	nearFull := findAlert(rule, "CephOSDNearFull")
+	require.NotNil(t, nearFull)
+	assert.Equal(t, "(ceph_osd_metadata * on (ceph_daemon) group_right(device_class,hostname) (ceph_osd_stat_bytes_used / ceph_osd_stat_bytes)) >= 0.70\n", nearFull.Expr.String())
+	assert.Equal(t, "5m", nearFull.For)
+	assert.Equal(t, "critical", nearFull.Labels["severity"])
+	assert.Equal(t, "changes(ceph_osd_up[5m]) >= 5\n", findAlert(rule, "CephOSDFlapping").Expr.String())
+	assert.Nil(t, findAlert(rule, "CephMgrIsAbsent"))
+	assert.NotNil(t, findAlert(rule, "CephMgrIsMissingReplicas"))
+
+	// the alert of the disks not responding is not compared to a number
+	err = customizeAlerts(rule, map[string]cephv1.PrometheusAlertSpec{"CephOSDDiskNotResponding": {Threshold: "2"}})
+	assert.Error(t, err)
+}
@@ -19,6 +19,7 @@ package mgr
 import (
 	"fmt"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -43,16 +44,21 @@ type mgrConfig struct {
 }
 
 func (c *Cluster) dashboardPort() int {
-	if c.spec.Dashboard.Port == 0 {
+	return DashboardPort(c.spec.Dashboard)
+}
+
+// DashboardPort returns the port the dashboard listens on
+func DashboardPort(spec cephv1.DashboardSpec) int {
+	if spec.Port == 0 {
 		// default port for HTTP/HTTPS
-		if c.spec.Dashboard.SSL {
+		if spec.SSL {
 			return dashboardPortHTTPS
 		} else {
 			return dashboardPortHTTP
 		}
 	}
 	// crd validates port >= 0
-	return c.spec.Dashboard.Port
+	return spec.Port
 }
 
 func (c *Cluster) generateKeyring(m *mgrConfig) (string, error) {
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -48,6 +49,7 @@ const (
 	passwordLength                 = 20
 	passwordKeyName                = "password"
 	certAlreadyConfiguredErrorCode = 5
+	dashboardCertConfigKey         = "mgr/dashboard/crt"
 	invalidArgErrorCode            = int(syscall.EINVAL)
 )
 
@@ -158,7 +160,13 @@ func (c *Cluster) initializeSecureDashboard() (bool, error) {
 		return false, errors.Wrap(err, "failed to generate a password for the ceph dashboard")
 	}
 
-	if c.spec.Dashboard.SSL {
+	certChanged := false
+	if c.spec.Dashboard.SSL && c.spec.Dashboard.CertificateSecretName != "" {
+		certChanged, err = c.configureDashboardCertificate()
+		if err != nil {
+			return false, errors.Wrap(err, "failed to configure the certificate of the ceph dashboard")
+		}
+	} else if c.spec.Dashboard.SSL {
 		alreadyCreated, err := c.createSelfSignedCert()
 		if err != nil {
 			return false, errors.Wrap(err, "failed to create a self signed cert for the ceph dashboard")
@@ -172,7 +180,61 @@ func (c *Cluster) initializeSecureDashboard() (bool, error) {
 		return false, errors.Wrap(err, "failed to set login credentials for the ceph dashboard")
 	}
 
-	return false, nil
+	return certChanged, nil
+}
+
+// configureDashboardCertificate sets the certificate of the dashboard from its secret, such as a
+// certificate issued by cert-manager. It returns whether the certificate has changed, so that the
+// dashboard is reloaded with the renewed certificate.
+func (c *Cluster) configureDashboardCertificate() (bool, error) {
+	secretName := c.spec.Dashboard.CertificateSecretName
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(c.clusterInfo.Context, secretName, metav1.GetOptions{})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get dashboard certificate secret %q", secretName)
+	}
+	cert := secret.Data[v1.TLSCertKey]
+	key := secret.Data[v1.TLSPrivateKeyKey]
+	if len(cert) == 0 || len(key) == 0 {
+		return false, errors.Errorf("dashboard certificate secret %q must have the %q and %q keys", secretName, v1.TLSCertKey, v1.TLSPrivateKeyKey)
+	}
+
+	// the dashboard stores its certificate in the config-key store
+	currentCert, err := client.NewCephCommand(c.context, c.clusterInfo, []string{"config-key", "get", dashboardCertConfigKey}).RunWithTimeout(exec.CephCommandsTimeout)
+	if err == nil && strings.TrimSpace(string(currentCert)) == strings.TrimSpace(string(cert)) {
+		return false, nil
+	}
+
+	if err := c.setDashboardCertificate("set-ssl-certificate", cert); err != nil {
+		return false, err
+	}
+	if err := c.setDashboardCertificate("set-ssl-certificate-key", key); err != nil {
+		return false, err
+	}
+	logger.Infof("configured the dashboard certificate from secret %q", secretName)
+	return true, nil
+}
+
+// setDashboardCertificate runs a dashboard command reading a certificate or its key from a file
+func (c *Cluster) setDashboardCertificate(command string, data []byte) error {
+	file, err := util.CreateTempFile(string(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a temporary file for dashboard %s", command)
+	}
+	defer func() {
+		if err := os.Remove(file.Name()); err != nil {
+			logger.Errorf("failed to clean up dashboard certificate file %q. %v", file.Name(), err)
+		}
+	}()
+
+	args := []string{"dashboard", command, "-i", file.Name()}
+	_, err = client.ExecuteCephCommandWithRetry(func() (string, []byte, error) {
+		output, err := client.NewCephCommand(c.context, c.clusterInfo, args).RunWithTimeout(exec.CephCommandsTimeout)
+		return "dashboard " + command, output, err
+	}, c.exitCode, 5, invalidArgErrorCode, dashboardInitWaitTime)
+	if err != nil {
+		return errors.Wrapf(err, "failed to run dashboard %s on mgr", command)
+	}
+	return nil
 }
 
 func (c *Cluster) createSelfSignedCert() (bool, error) {
@@ -17,6 +17,7 @@ package mgr
 
 import (
 	"context"
+	"io/ioutil"
 	"testing"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -159,6 +161,70 @@ func TestStartSecureDashboard(t *testing.T) {
 	assert.Nil(t, svc)
 }
 
+func TestDashboardCertificate(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 3)
+	storedCert := ""
+	setCommands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			if args[0] == "config-key" && args[1] == "get" {
+				assert.Equal(t, dashboardCertConfigKey, args[2])
+				if storedCert == "" {
+					return "", errors.New("ENOENT")
+				}
+				return storedCert, nil
+			}
+			if args[0] == "dashboard" {
+				setCommands = append(setCommands, args[1])
+				data, err := ioutil.ReadFile(args[3])
+				assert.NoError(t, err)
+				if args[1] == "set-ssl-certificate" {
+					storedCert = string(data)
+				}
+			}
+			return "", nil
+		},
+	}
+	clusterInfo := &cephclient.ClusterInfo{Namespace: "myns", Context: ctx}
+	c := &Cluster{clusterInfo: clusterInfo, context: &clusterd.Context{Clientset: clientset, Executor: executor},
+		spec: cephv1.ClusterSpec{
+			Dashboard: cephv1.DashboardSpec{Enabled: true, SSL: true, CertificateSecretName: "dashboard-tls"},
+		},
+		exitCode: func(err error) (int, bool) { return 0, false },
+	}
+
+	// the secret does not exist
+	_, err := c.configureDashboardCertificate()
+	assert.Error(t, err)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard-tls", Namespace: "myns"},
+		Data:       map[string][]byte{v1.TLSCertKey: []byte("cert"), v1.TLSPrivateKeyKey: []byte("key")},
+		Type:       v1.SecretTypeTLS,
+	}
+	_, err = clientset.CoreV1().Secrets("myns").Create(ctx, secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	changed, err := c.configureDashboardCertificate()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"set-ssl-certificate", "set-ssl-certificate-key"}, setCommands)
+
+	// the certificate is not set again until it is renewed
+	changed, err = c.configureDashboardCertificate()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Len(t, setCommands, 2)
+
+	secret.Data[v1.TLSCertKey] = []byte("renewed-cert")
+	_, err = clientset.CoreV1().Secrets("myns").Update(ctx, secret, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	changed, err = c.configureDashboardCertificate()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "renewed-cert", storedCert)
+}
+
 func TestFileBasedPasswordSupported(t *testing.T) {
 	clusterInfo := &cephclient.ClusterInfo{CephVersion: cephver.CephVersion{Major: 15, Minor: 2, Extra: 9}}
 	value := FileBasedPasswordSupported(clusterInfo)
@@ -0,0 +1,229 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	dashboardSuffix = "-dashboard"
+	// the label of the ConfigMaps loaded by the dashboard sidecar of the grafana helm chart
+	grafanaDashboardLabel = "grafana_dashboard"
+	// the annotation of the ConfigMaps setting the folder of their dashboards
+	grafanaFolderAnnotation = "grafana_folder"
+)
+
+var (
+	// the dashboards shipped with the operator, one json file per dashboard
+	dashboardsPath = path.Join(monitoringPath, "grafana")
+
+	// GrafanaDashboardGVK is the kind of the dashboards of the grafana operator
+	GrafanaDashboardGVK = schema.GroupVersionKind{Group: "grafana.integreatly.org", Version: "v1beta1", Kind: "GrafanaDashboard"}
+)
+
+// reconcileDashboards deploys the grafana dashboards as ConfigMaps or GrafanaDashboards according to the
+// monitoring settings, and removes the dashboards of the other mode or when they are disabled. The
+// dashboards are named after their file, so that the clusters sharing a namespace share the dashboards.
+func (c *Cluster) reconcileDashboards() error {
+	spec := c.spec.Monitoring.Dashboards
+	enabled := c.spec.Monitoring.Enabled && spec != nil && spec.Enabled
+	namespace := c.clusterInfo.Namespace
+	mode := cephv1.GrafanaDashboardsConfigMap
+	if spec != nil {
+		if spec.Namespace != "" {
+			namespace = spec.Namespace
+		}
+		if spec.Mode != "" {
+			mode = spec.Mode
+		}
+	}
+
+	dashboards, err := loadDashboards(dashboardsPath)
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for name := range dashboards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		configMapEnabled := enabled && mode == cephv1.GrafanaDashboardsConfigMap
+		if err := c.reconcileDashboardConfigMap(namespace, name, dashboards[name], configMapEnabled); err != nil {
+			return err
+		}
+		crEnabled := enabled && mode == cephv1.GrafanaDashboardsCR
+		if err := c.reconcileGrafanaDashboard(namespace, name, dashboards[name], crEnabled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadDashboards returns the json of the dashboards of a directory keyed by dashboard name
+func loadDashboards(dir string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the dashboards of %q", dir)
+	}
+	dashboards := map[string]string{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read dashboard %q", file)
+		}
+		if !json.Valid(content) {
+			return nil, errors.Errorf("invalid json of dashboard %q", file)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".json") + dashboardSuffix
+		dashboards[name] = string(content)
+	}
+	return dashboards, nil
+}
+
+// setDashboardOwner sets the cluster as owner of a dashboard of the cluster namespace, the dashboards of
+// another namespace are removed when the dashboards are disabled
+func (c *Cluster) setDashboardOwner(object metav1.Object) error {
+	if object.GetNamespace() != c.clusterInfo.Namespace {
+		return nil
+	}
+	return c.clusterInfo.OwnerInfo.SetControllerReference(object)
+}
+
+func (c *Cluster) reconcileDashboardConfigMap(namespace, name, dashboard string, enabled bool) error {
+	ctx := c.clusterInfo.Context
+	if !enabled {
+		err := c.context.Clientset.CoreV1().ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete dashboard configmap %q", name)
+		}
+		return nil
+	}
+
+	spec := c.spec.Monitoring.Dashboards
+	labels := map[string]string{grafanaDashboardLabel: "1"}
+	if len(spec.Labels) != 0 {
+		labels = map[string]string{}
+		for k, v := range spec.Labels {
+			labels[k] = v
+		}
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Data:       map[string]string{name + ".json": dashboard},
+	}
+	if spec.Folder != "" {
+		configMap.Annotations = map[string]string{grafanaFolderAnnotation: spec.Folder}
+	}
+	cephv1.GetMonitoringLabels(c.spec.Labels).ApplyToObjectMeta(&configMap.ObjectMeta)
+	if err := c.setDashboardOwner(configMap); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to dashboard configmap %q", name)
+	}
+	_, err := k8sutil.CreateOrUpdateConfigMap(ctx, c.context.Clientset, configMap)
+	return err
+}
+
+func (c *Cluster) reconcileGrafanaDashboard(namespace, name, dashboard string, enabled bool) error {
+	ctx := c.clusterInfo.Context
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(GrafanaDashboardGVK)
+	err := c.context.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		// Nothing to remove when the grafana operator is not installed
+		if meta.IsNoMatchError(err) && !enabled {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get grafana dashboard %q, is the grafana operator installed?", name)
+	}
+	found := err == nil
+
+	if !enabled {
+		if !found {
+			return nil
+		}
+		if err := c.context.Client.Delete(ctx, existing); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete grafana dashboard %q", name)
+		}
+		return nil
+	}
+
+	grafanaDashboard, err := newGrafanaDashboard(namespace, name, dashboard, c.spec.Monitoring.Dashboards)
+	if err != nil {
+		return err
+	}
+	if err := c.setDashboardOwner(grafanaDashboard); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to grafana dashboard %q", name)
+	}
+	if !found {
+		if err := c.context.Client.Create(ctx, grafanaDashboard); err != nil {
+			return errors.Wrapf(err, "failed to create grafana dashboard %q", name)
+		}
+		return nil
+	}
+	existing.Object["spec"] = grafanaDashboard.Object["spec"]
+	existing.SetLabels(grafanaDashboard.GetLabels())
+	existing.SetOwnerReferences(grafanaDashboard.GetOwnerReferences())
+	if err := c.context.Client.Update(ctx, existing); err != nil {
+		return errors.Wrapf(err, "failed to update grafana dashboard %q", name)
+	}
+	return nil
+}
+
+// newGrafanaDashboard returns the GrafanaDashboard importing a dashboard in the selected grafana instances
+func newGrafanaDashboard(namespace, name, dashboard string, spec *cephv1.GrafanaDashboardsSpec) (*unstructured.Unstructured, error) {
+	selector := map[string]interface{}{}
+	if spec.InstanceSelector != nil {
+		var err error
+		selector, err = runtime.DefaultUnstructuredConverter.ToUnstructured(spec.InstanceSelector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert the instance selector of grafana dashboard %q", name)
+		}
+	}
+	dashboardSpec := map[string]interface{}{
+		"instanceSelector": selector,
+		"json":             dashboard,
+	}
+	if spec.Folder != "" {
+		dashboardSpec["folder"] = spec.Folder
+	}
+
+	grafanaDashboard := &unstructured.Unstructured{Object: map[string]interface{}{"spec": dashboardSpec}}
+	grafanaDashboard.SetGroupVersionKind(GrafanaDashboardGVK)
+	grafanaDashboard.SetName(name)
+	grafanaDashboard.SetNamespace(namespace)
+	grafanaDashboard.SetLabels(spec.Labels)
+	return grafanaDashboard, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileDashboards(t *testing.T) {
+	ctx := context.TODO()
+	previousPath := dashboardsPath
+	dashboardsPath = "../../../../../deploy/examples/monitoring/grafana"
+	defer func() { dashboardsPath = previousPath }()
+
+	s := runtime.NewScheme()
+	s.AddKnownTypeWithName(GrafanaDashboardGVK, &unstructured.Unstructured{})
+	clientset := testop.New(t, 1)
+	c := &Cluster{
+		context:     &clusterd.Context{Clientset: clientset, Client: fake.NewClientBuilder().WithScheme(s).Build()},
+		clusterInfo: &cephclient.ClusterInfo{Namespace: "rook-ceph", OwnerInfo: cephclient.NewMinimumOwnerInfo(t), Context: ctx},
+	}
+
+	// the dashboards are not deployed by default
+	assert.NoError(t, c.reconcileDashboards())
+	configMaps, err := clientset.CoreV1().ConfigMaps("rook-ceph").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, configMaps.Items)
+
+	t.Run("configmaps", func(t *testing.T) {
+		c.spec.Monitoring = cephv1.MonitoringSpec{Enabled: true, Dashboards: &cephv1.GrafanaDashboardsSpec{Enabled: true, Folder: "Ceph"}}
+		assert.NoError(t, c.reconcileDashboards())
+		configMap, err := clientset.CoreV1().ConfigMaps("rook-ceph").Get(ctx, "ceph-cluster-dashboard", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "1", configMap.Labels[grafanaDashboardLabel])
+		assert.Equal(t, "Ceph", configMap.Annotations[grafanaFolderAnnotation])
+		assert.Contains(t, configMap.Data["ceph-cluster-dashboard.json"], `"uid": "rook-ceph-cluster"`)
+		assert.Len(t, configMap.OwnerReferences, 1)
+		configMaps, err := clientset.CoreV1().ConfigMaps("rook-ceph").List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, configMaps.Items, 3)
+	})
+
+	t.Run("grafana dashboards", func(t *testing.T) {
+		c.spec.Monitoring.Dashboards.Mode = cephv1.GrafanaDashboardsCR
+		c.spec.Monitoring.Dashboards.InstanceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"dashboards": "ceph"}}
+		assert.NoError(t, c.reconcileDashboards())
+		configMaps, err := clientset.CoreV1().ConfigMaps("rook-ceph").List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, configMaps.Items)
+
+		dashboard := &unstructured.Unstructured{}
+		dashboard.SetGroupVersionKind(GrafanaDashboardGVK)
+		require.NoError(t, c.context.Client.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: "ceph-osd-dashboard"}, dashboard))
+		selector, _, _ := unstructured.NestedStringMap(dashboard.Object, "spec", "instanceSelector", "matchLabels")
+		assert.Equal(t, map[string]string{"dashboards": "ceph"}, selector)
+		folder, _, _ := unstructured.NestedString(dashboard.Object, "spec", "folder")
+		assert.Equal(t, "Ceph", folder)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c.spec.Monitoring.Dashboards.Enabled = false
+		assert.NoError(t, c.reconcileDashboards())
+		dashboard := &unstructured.Unstructured{}
+		dashboard.SetGroupVersionKind(GrafanaDashboardGVK)
+		err := c.context.Client.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: "ceph-osd-dashboard"}, dashboard)
+		assert.True(t, kerrors.IsNotFound(err))
+	})
+}
@@ -20,6 +20,7 @@ package mgr
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -222,6 +223,12 @@ func (c *Cluster) Start() error {
 		}
 		logger.Debugf("ended monitoring deployment")
 	}
+	if err := c.reconcileDashboards(); err != nil {
+		logger.Errorf("failed to deploy the grafana dashboards. %v", err)
+	}
+	if err := c.reconcileSNMPGateway(); err != nil {
+		logger.Errorf("failed to deploy the snmp gateway. %v", err)
+	}
 	return nil
 }
 
@@ -355,6 +362,7 @@ func (c *Cluster) configureModules(daemonIDs []string) {
 		startModuleConfiguration("balancer", c.enableBalancerModule)
 	}
 	startModuleConfiguration("mgr module(s) from the spec", c.configureMgrModules)
+	startModuleConfiguration("telemetry", c.configureTelemetry)
 }
 
 func startModuleConfiguration(description string, configureModules func() error) {
@@ -412,7 +420,7 @@ func (c *Cluster) configureMgrModules() error {
 		if module.Name == "" {
 			return errors.New("name not specified for the mgr module configuration")
 		}
-		if wellKnownModule(module.Name) {
+		if wellKnownModule(module.Name) || (module.Name == telemetryModuleName && c.spec.Mgr.Telemetry != nil) {
 			return errors.Errorf("cannot configure mgr module %q that is configured with other cluster settings", module.Name)
 		}
 		minVersion, versionOK := c.moduleMeetsMinVersion(module.Name)
@@ -504,6 +512,10 @@ func (c *Cluster) EnableServiceMonitor(activeDaemon string) error {
 	serviceMonitor.Spec.Selector.MatchLabels = c.selectorLabels(activeDaemon)
 
 	applyMonitoringLabels(c, serviceMonitor)
+	if err := c.spec.Monitoring.ValidateExternalLabels(); err != nil {
+		return errors.Wrap(err, "service monitor could not be enabled")
+	}
+	applyExternalLabels(c, serviceMonitor)
 
 	if _, err = k8sutil.CreateOrUpdateServiceMonitor(c.clusterInfo.Context, serviceMonitor); err != nil {
 		return errors.Wrap(err, "service monitor could not be enabled")
@@ -523,6 +535,9 @@ func (c *Cluster) DeployPrometheusRule(name, namespace string) error {
 	}
 	prometheusRule.SetName(name)
 	prometheusRule.SetNamespace(namespace)
+	if err := customizeAlerts(prometheusRule, c.spec.Monitoring.Alerts); err != nil {
+		return errors.Wrapf(err, "failed to customize the alerts of prometheus rule %q", name)
+	}
 	err = c.clusterInfo.OwnerInfo.SetControllerReference(prometheusRule)
 	if err != nil {
 		return errors.Wrapf(err, "failed to set owner reference to prometheus rule %q", prometheusRule.Name)
@@ -547,6 +562,20 @@ func IsModuleInSpec(modules []cephv1.Module, moduleName string) bool {
 
 // ApplyMonitoringLabels function adds the name of the resource that manages
 // cephcluster, as a label on the ceph metrics
+// applyExternalLabels adds the external labels of the cluster to the metrics scraped by the service
+// monitor, sorted by name so that the service monitor is only updated when the labels change
+func applyExternalLabels(c *Cluster, serviceMonitor *monitoringv1.ServiceMonitor) {
+	names := make([]string, 0, len(c.spec.Monitoring.ExternalLabels))
+	for name := range c.spec.Monitoring.ExternalLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		serviceMonitor.Spec.Endpoints[0].RelabelConfigs = append(serviceMonitor.Spec.Endpoints[0].RelabelConfigs,
+			&monitoringv1.RelabelConfig{TargetLabel: name, Replacement: c.spec.Monitoring.ExternalLabels[name]})
+	}
+}
+
 func applyMonitoringLabels(c *Cluster, serviceMonitor *monitoringv1.ServiceMonitor) {
 	if c.spec.Labels != nil {
 		if monitoringLabels, ok := c.spec.Labels["monitoring"]; ok {
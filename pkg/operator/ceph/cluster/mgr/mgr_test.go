@@ -434,6 +434,21 @@ func TestApplyMonitoringLabels(t *testing.T) {
 	assert.Nil(t, sm.Spec.Endpoints[0].RelabelConfigs)
 }
 
+func TestApplyExternalLabels(t *testing.T) {
+	c := &Cluster{}
+	sm := &monitoringv1.ServiceMonitor{Spec: monitoringv1.ServiceMonitorSpec{
+		Endpoints: []monitoringv1.Endpoint{{}}}}
+	applyExternalLabels(c, sm)
+	assert.Nil(t, sm.Spec.Endpoints[0].RelabelConfigs)
+
+	c.spec.Monitoring.ExternalLabels = map[string]string{"region": "eu-west-1", "cluster": "prod-a"}
+	applyExternalLabels(c, sm)
+	assert.Equal(t, []*monitoringv1.RelabelConfig{
+		{TargetLabel: "cluster", Replacement: "prod-a"},
+		{TargetLabel: "region", Replacement: "eu-west-1"},
+	}, sm.Spec.Endpoints[0].RelabelConfigs)
+}
+
 func TestCluster_enableBalancerModule(t *testing.T) {
 	c := &Cluster{
 		context:     &clusterd.Context{Executor: &exectest.MockExecutor{}, Clientset: testop.New(t, 3)},
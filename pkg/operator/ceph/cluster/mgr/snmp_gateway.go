@@ -0,0 +1,282 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	snmpGatewayAppName = "rook-ceph-snmp-gateway"
+	// the image of the snmp gateway deployed by cephadm
+	defaultSNMPGatewayImage = "docker.io/maxwo/snmp-notifier:v1.2.1"
+	snmpGatewayPort         = 9464
+	// the path of the webhook receiving the alerts from alertmanager
+	snmpGatewayAlertsPath = "/alerts"
+	snmpGatewayReceiver   = "snmp-gateway"
+
+	// the keys of the credentials secret of the snmp gateway
+	snmpCommunityKey       = "community"
+	snmpUsernameKey        = "username"
+	snmpPasswordKey        = "password"
+	snmpPrivacyPasswordKey = "privacyPassword"
+)
+
+// AlertmanagerConfigGVK is the kind of the alertmanager configs of the prometheus operator
+var AlertmanagerConfigGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1alpha1", Kind: "AlertmanagerConfig"}
+
+// reconcileSNMPGateway deploys the snmp gateway of the alerts and its alertmanager config according to
+// the monitoring settings, and removes them when they are disabled
+func (c *Cluster) reconcileSNMPGateway() error {
+	spec := c.spec.Monitoring.SNMPGateway
+	enabled := spec != nil && spec.Enabled
+	if !enabled {
+		if err := c.removeSNMPGateway(); err != nil {
+			return err
+		}
+		return c.reconcileSNMPAlertmanagerConfig(false)
+	}
+	if spec.Destination == "" || spec.CredentialsSecretName == "" {
+		return errors.New("the destination and the credentials secret of the snmp gateway must be set")
+	}
+
+	deployment, err := c.makeSNMPGatewayDeployment(spec)
+	if err != nil {
+		return err
+	}
+	if _, err := k8sutil.CreateOrUpdateDeployment(c.clusterInfo.Context, c.context.Clientset, deployment); err != nil {
+		return errors.Wrap(err, "failed to create or update the snmp gateway deployment")
+	}
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      snmpGatewayAppName,
+			Namespace: c.clusterInfo.Namespace,
+			Labels:    controller.AppLabels(snmpGatewayAppName, c.clusterInfo.Namespace),
+		},
+		Spec: v1.ServiceSpec{
+			Selector: controller.AppLabels(snmpGatewayAppName, c.clusterInfo.Namespace),
+			Ports: []v1.ServicePort{
+				{Name: "http", Port: snmpGatewayPort, TargetPort: intstr.FromInt(snmpGatewayPort), Protocol: v1.ProtocolTCP},
+			},
+		},
+	}
+	if err := c.clusterInfo.OwnerInfo.SetControllerReference(service); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to snmp gateway service %q", service.Name)
+	}
+	if _, err := k8sutil.CreateOrUpdateService(c.clusterInfo.Context, c.context.Clientset, c.clusterInfo.Namespace, service); err != nil {
+		return errors.Wrap(err, "failed to create or update the snmp gateway service")
+	}
+
+	return c.reconcileSNMPAlertmanagerConfig(spec.AlertmanagerConfig)
+}
+
+// makeSNMPGatewayDeployment returns the deployment of the snmp_notifier, which sends the alerts it
+// receives on its webhook as traps to the destination
+func (c *Cluster) makeSNMPGatewayDeployment(spec *cephv1.SNMPGatewaySpec) (*apps.Deployment, error) {
+	image := spec.Image
+	if image == "" {
+		image = defaultSNMPGatewayImage
+	}
+	version := spec.Version
+	if version == "" {
+		version = cephv1.SNMPVersionV2c
+	}
+
+	args := []string{
+		fmt.Sprintf("--web.listen-address=:%d", snmpGatewayPort),
+		fmt.Sprintf("--snmp.destination=%s", spec.Destination),
+		fmt.Sprintf("--snmp.version=%s", version),
+		"--log.level=info",
+	}
+	var env []v1.EnvVar
+	if version == cephv1.SNMPVersionV3 {
+		authProtocol := spec.AuthProtocol
+		if authProtocol == "" {
+			authProtocol = "MD5"
+		}
+		args = append(args, "--snmp.authentication-enabled", fmt.Sprintf("--snmp.authentication-protocol=%s", authProtocol))
+		env = append(env,
+			snmpSecretEnvVar("SNMP_NOTIFIER_AUTH_USERNAME", spec.CredentialsSecretName, snmpUsernameKey),
+			snmpSecretEnvVar("SNMP_NOTIFIER_AUTH_PASSWORD", spec.CredentialsSecretName, snmpPasswordKey))
+		if spec.PrivacyProtocol != "" {
+			args = append(args, "--snmp.private-enabled", fmt.Sprintf("--snmp.private-protocol=%s", spec.PrivacyProtocol))
+			env = append(env, snmpSecretEnvVar("SNMP_NOTIFIER_PRIV_PASSWORD", spec.CredentialsSecretName, snmpPrivacyPasswordKey))
+		}
+		if spec.EngineID != "" {
+			args = append(args, fmt.Sprintf("--snmp.security-engine-id=%s", spec.EngineID))
+		}
+	} else {
+		env = append(env, snmpSecretEnvVar("SNMP_NOTIFIER_COMMUNITY", spec.CredentialsSecretName, snmpCommunityKey))
+	}
+
+	labels := controller.AppLabels(snmpGatewayAppName, c.clusterInfo.Namespace)
+	podSpec := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   snmpGatewayAppName,
+			Labels: labels,
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:      "snmp-gateway",
+					Image:     image,
+					Args:      args,
+					Env:       env,
+					Resources: spec.Resources,
+					Ports: []v1.ContainerPort{
+						{Name: "http", ContainerPort: snmpGatewayPort, Protocol: v1.ProtocolTCP},
+					},
+					LivenessProbe: &v1.Probe{
+						Handler: v1.Handler{
+							TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt(snmpGatewayPort)},
+						},
+						InitialDelaySeconds: 10,
+					},
+				},
+			},
+			RestartPolicy: v1.RestartPolicyAlways,
+		},
+	}
+	cephv1.GetMonitoringLabels(c.spec.Labels).ApplyToObjectMeta(&podSpec.ObjectMeta)
+
+	replicas := int32(1)
+	d := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      snmpGatewayAppName,
+			Namespace: c.clusterInfo.Namespace,
+			Labels:    labels,
+		},
+		Spec: apps.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: podSpec,
+			Replicas: &replicas,
+		},
+	}
+	k8sutil.AddRookVersionLabelToDeployment(d)
+	cephv1.GetMonitoringLabels(c.spec.Labels).ApplyToObjectMeta(&d.ObjectMeta)
+	if err := c.clusterInfo.OwnerInfo.SetControllerReference(d); err != nil {
+		return nil, errors.Wrapf(err, "failed to set owner reference to snmp gateway deployment %q", d.Name)
+	}
+	return d, nil
+}
+
+func snmpSecretEnvVar(name, secretName, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: secretName}, Key: key},
+		},
+	}
+}
+
+func (c *Cluster) removeSNMPGateway() error {
+	ctx := c.clusterInfo.Context
+	err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Delete(ctx, snmpGatewayAppName, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete the snmp gateway deployment")
+	}
+	err = c.context.Clientset.CoreV1().Services(c.clusterInfo.Namespace).Delete(ctx, snmpGatewayAppName, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete the snmp gateway service")
+	}
+	return nil
+}
+
+// reconcileSNMPAlertmanagerConfig creates the AlertmanagerConfig routing the alerts of the cluster
+// namespace to the webhook of the snmp gateway, or removes it when not enabled
+func (c *Cluster) reconcileSNMPAlertmanagerConfig(enabled bool) error {
+	ctx := c.clusterInfo.Context
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(AlertmanagerConfigGVK)
+	err := c.context.Client.Get(ctx, types.NamespacedName{Namespace: c.clusterInfo.Namespace, Name: snmpGatewayAppName}, existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		// Nothing to remove when the prometheus operator is not installed
+		if meta.IsNoMatchError(err) && !enabled {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get alertmanager config %q, is the prometheus operator installed?", snmpGatewayAppName)
+	}
+	found := err == nil
+
+	if !enabled {
+		if !found {
+			return nil
+		}
+		if err := c.context.Client.Delete(ctx, existing); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete alertmanager config %q", snmpGatewayAppName)
+		}
+		return nil
+	}
+
+	config := newSNMPAlertmanagerConfig(c.clusterInfo.Namespace)
+	if err := c.clusterInfo.OwnerInfo.SetControllerReference(config); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to alertmanager config %q", snmpGatewayAppName)
+	}
+	if !found {
+		if err := c.context.Client.Create(ctx, config); err != nil {
+			return errors.Wrapf(err, "failed to create alertmanager config %q", snmpGatewayAppName)
+		}
+		return nil
+	}
+	existing.Object["spec"] = config.Object["spec"]
+	existing.SetOwnerReferences(config.GetOwnerReferences())
+	if err := c.context.Client.Update(ctx, existing); err != nil {
+		return errors.Wrapf(err, "failed to update alertmanager config %q", snmpGatewayAppName)
+	}
+	return nil
+}
+
+// newSNMPAlertmanagerConfig returns the AlertmanagerConfig sending the alerts to the snmp gateway. The
+// prometheus operator only routes the alerts of the namespace of the config to its receivers.
+func newSNMPAlertmanagerConfig(namespace string) *unstructured.Unstructured {
+	url := fmt.Sprintf("http://%s.%s.svc:%d%s", snmpGatewayAppName, namespace, snmpGatewayPort, snmpGatewayAlertsPath)
+	config := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"route": map[string]interface{}{
+				"receiver": snmpGatewayReceiver,
+				"groupBy":  []interface{}{"alertname"},
+			},
+			"receivers": []interface{}{
+				map[string]interface{}{
+					"name": snmpGatewayReceiver,
+					"webhookConfigs": []interface{}{
+						map[string]interface{}{"url": url, "sendResolved": true},
+					},
+				},
+			},
+		},
+	}}
+	config.SetGroupVersionKind(AlertmanagerConfigGVK)
+	config.SetName(snmpGatewayAppName)
+	config.SetNamespace(namespace)
+	return config
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileSNMPGateway(t *testing.T) {
+	ctx := context.TODO()
+	s := runtime.NewScheme()
+	s.AddKnownTypeWithName(AlertmanagerConfigGVK, &unstructured.Unstructured{})
+	clientset := testop.New(t, 1)
+	c := &Cluster{
+		context:     &clusterd.Context{Clientset: clientset, Client: fake.NewClientBuilder().WithScheme(s).Build()},
+		clusterInfo: &cephclient.ClusterInfo{Namespace: "rook-ceph", OwnerInfo: cephclient.NewMinimumOwnerInfo(t), Context: ctx},
+	}
+	getConfig := func() error {
+		config := &unstructured.Unstructured{}
+		config.SetGroupVersionKind(AlertmanagerConfigGVK)
+		return c.context.Client.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: snmpGatewayAppName}, config)
+	}
+
+	// the gateway is not deployed by default
+	assert.NoError(t, c.reconcileSNMPGateway())
+	_, err := clientset.AppsV1().Deployments("rook-ceph").Get(ctx, snmpGatewayAppName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	t.Run("missing destination", func(t *testing.T) {
+		c.spec.Monitoring.SNMPGateway = &cephv1.SNMPGatewaySpec{Enabled: true, CredentialsSecretName: "snmp-credentials"}
+		assert.Error(t, c.reconcileSNMPGateway())
+	})
+
+	t.Run("v2c", func(t *testing.T) {
+		c.spec.Monitoring.SNMPGateway.Destination = "snmp.example.com:162"
+		assert.NoError(t, c.reconcileSNMPGateway())
+		d, err := clientset.AppsV1().Deployments("rook-ceph").Get(ctx, snmpGatewayAppName, metav1.GetOptions{})
+		require.NoError(t, err)
+		container := d.Spec.Template.Spec.Containers[0]
+		assert.Equal(t, defaultSNMPGatewayImage, container.Image)
+		assert.Contains(t, container.Args, "--snmp.destination=snmp.example.com:162")
+		assert.Contains(t, container.Args, "--snmp.version=V2c")
+		assert.Equal(t, 1, len(container.Env))
+		assert.Equal(t, "SNMP_NOTIFIER_COMMUNITY", container.Env[0].Name)
+		assert.Equal(t, snmpCommunityKey, container.Env[0].ValueFrom.SecretKeyRef.Key)
+		assert.Len(t, d.OwnerReferences, 1)
+		_, err = clientset.CoreV1().Services("rook-ceph").Get(ctx, snmpGatewayAppName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		// the alertmanager config is not created by default
+		assert.True(t, kerrors.IsNotFound(getConfig()))
+	})
+
+	t.Run("v3 with alertmanager config", func(t *testing.T) {
+		c.spec.Monitoring.SNMPGateway.Version = cephv1.SNMPVersionV3
+		c.spec.Monitoring.SNMPGateway.PrivacyProtocol = "AES"
+		c.spec.Monitoring.SNMPGateway.AlertmanagerConfig = true
+		assert.NoError(t, c.reconcileSNMPGateway())
+		d, err := clientset.AppsV1().Deployments("rook-ceph").Get(ctx, snmpGatewayAppName, metav1.GetOptions{})
+		require.NoError(t, err)
+		container := d.Spec.Template.Spec.Containers[0]
+		assert.Contains(t, container.Args, "--snmp.authentication-protocol=MD5")
+		assert.Contains(t, container.Args, "--snmp.private-protocol=AES")
+		assert.Equal(t, 3, len(container.Env))
+
+		config := &unstructured.Unstructured{}
+		config.SetGroupVersionKind(AlertmanagerConfigGVK)
+		require.NoError(t, c.context.Client.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: snmpGatewayAppName}, config))
+		receivers, _, _ := unstructured.NestedSlice(config.Object, "spec", "receivers")
+		webhooks := receivers[0].(map[string]interface{})["webhookConfigs"].([]interface{})
+		assert.Equal(t, "http://rook-ceph-snmp-gateway.rook-ceph.svc:9464/alerts", webhooks[0].(map[string]interface{})["url"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c.spec.Monitoring.SNMPGateway.Enabled = false
+		assert.NoError(t, c.reconcileSNMPGateway())
+		_, err := clientset.AppsV1().Deployments("rook-ceph").Get(ctx, snmpGatewayAppName, metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err))
+		_, err = clientset.CoreV1().Services("rook-ceph").Get(ctx, snmpGatewayAppName, metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err))
+		assert.True(t, kerrors.IsNotFound(getConfig()))
+	})
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+const (
+	telemetryModuleName = "telemetry"
+	// the settings of the telemetry module are options of the mgr
+	telemetryOptionPrefix = "mgr/telemetry/"
+)
+
+// the channels sent when none is set in the spec, the defaults of the telemetry module
+var defaultTelemetryChannels = []cephv1.TelemetryChannel{cephv1.TelemetryChannelBasic, cephv1.TelemetryChannelCrash, cephv1.TelemetryChannelDevice}
+
+// Ceph docs about the telemetry module: https://docs.ceph.com/en/latest/mgr/telemetry/
+func (c *Cluster) configureTelemetry() error {
+	spec := c.spec.Mgr.Telemetry
+	if spec == nil {
+		return nil
+	}
+	if !spec.Enabled {
+		// only turn off the reports, the settings are kept in case the telemetry is turned on again
+		if err := cephclient.MgrSetTelemetry(c.context, c.clusterInfo, false); err != nil {
+			return errors.Wrap(err, "failed to turn off the telemetry")
+		}
+		return nil
+	}
+
+	// configure the channels and the contact before the first report is sent
+	monStore := config.GetMonStore(c.context, c.clusterInfo)
+	channels := spec.Channels
+	if len(channels) == 0 {
+		channels = defaultTelemetryChannels
+	}
+	enabledChannels := map[cephv1.TelemetryChannel]bool{}
+	for _, channel := range channels {
+		if channel == cephv1.TelemetryChannelPerf && !c.clusterInfo.CephVersion.IsAtLeastQuincy() {
+			return errors.Errorf("telemetry channel %q requires at least Ceph Quincy", channel)
+		}
+		enabledChannels[channel] = true
+	}
+	allChannels := []cephv1.TelemetryChannel{cephv1.TelemetryChannelBasic, cephv1.TelemetryChannelCrash, cephv1.TelemetryChannelDevice, cephv1.TelemetryChannelIdent}
+	if c.clusterInfo.CephVersion.IsAtLeastQuincy() {
+		allChannels = append(allChannels, cephv1.TelemetryChannelPerf)
+	}
+	for _, channel := range allChannels {
+		option := telemetryOptionPrefix + "channel_" + string(channel)
+		if _, err := monStore.SetIfChanged("mgr", option, strconv.FormatBool(enabledChannels[channel])); err != nil {
+			return errors.Wrapf(err, "failed to configure the telemetry channel %q", channel)
+		}
+	}
+
+	settings := map[string]string{
+		"contact":      spec.Contact,
+		"organization": spec.Organization,
+		"description":  spec.Description,
+		"proxy":        spec.Proxy,
+	}
+	for name, value := range settings {
+		if err := setOrDeleteMgrOption(monStore, telemetryOptionPrefix+name, value); err != nil {
+			return errors.Wrapf(err, "failed to configure the telemetry %s", name)
+		}
+	}
+
+	if err := cephclient.MgrSetTelemetry(c.context, c.clusterInfo, true); err != nil {
+		return errors.Wrap(err, "failed to turn on the telemetry")
+	}
+	return nil
+}
+
+// setOrDeleteMgrOption sets a mgr option if changed, or deletes it if the value is empty
+func setOrDeleteMgrOption(monStore *config.MonStore, option, value string) error {
+	if value != "" {
+		_, err := monStore.SetIfChanged("mgr", option, value)
+		return err
+	}
+	current, err := monStore.Get("mgr", option)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %q", option)
+	}
+	if current == "" {
+		return nil
+	}
+	return monStore.Delete("mgr", option)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureTelemetry(t *testing.T) {
+	telemetry := ""
+	mgrConfig := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "telemetry" {
+				telemetry = args[1]
+				if args[1] == "on" {
+					assert.Equal(t, []string{"--license", "sharing-1-0"}, args[2:4])
+				}
+			}
+			return "", nil
+		},
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			if args[0] == "config" && args[2] == "mgr" {
+				switch args[1] {
+				case "get":
+					return mgrConfig[args[3]], nil
+				case "set":
+					mgrConfig[args[3]] = args[4]
+				case "rm":
+					delete(mgrConfig, args[3])
+				}
+			}
+			return "", nil
+		},
+	}
+	clusterInfo := cephclient.AdminTestClusterInfo("mycluster")
+	clusterInfo.CephVersion = cephver.Pacific
+	c := &Cluster{context: &clusterd.Context{Executor: executor}, clusterInfo: clusterInfo}
+
+	// the telemetry is left unchanged if not set
+	assert.NoError(t, c.configureTelemetry())
+	assert.Equal(t, "", telemetry)
+
+	t.Run("off", func(t *testing.T) {
+		c.spec.Mgr.Telemetry = &cephv1.TelemetrySpec{}
+		assert.NoError(t, c.configureTelemetry())
+		assert.Equal(t, "off", telemetry)
+		assert.Empty(t, mgrConfig)
+	})
+
+	t.Run("default channels", func(t *testing.T) {
+		c.spec.Mgr.Telemetry = &cephv1.TelemetrySpec{Enabled: true, Proxy: "http://proxy.example.com:3128"}
+		assert.NoError(t, c.configureTelemetry())
+		assert.Equal(t, "on", telemetry)
+		assert.Equal(t, map[string]string{
+			"mgr/telemetry/channel_basic":  "true",
+			"mgr/telemetry/channel_crash":  "true",
+			"mgr/telemetry/channel_device": "true",
+			"mgr/telemetry/channel_ident":  "false",
+			"mgr/telemetry/proxy":          "http://proxy.example.com:3128",
+		}, mgrConfig)
+	})
+
+	t.Run("ident channel", func(t *testing.T) {
+		c.spec.Mgr.Telemetry = &cephv1.TelemetrySpec{
+			Enabled:      true,
+			Channels:     []cephv1.TelemetryChannel{cephv1.TelemetryChannelBasic, cephv1.TelemetryChannelIdent},
+			Contact:      "storage@example.com",
+			Organization: "Example",
+		}
+		assert.NoError(t, c.configureTelemetry())
+		assert.Equal(t, "true", mgrConfig["mgr/telemetry/channel_ident"])
+		assert.Equal(t, "false", mgrConfig["mgr/telemetry/channel_crash"])
+		assert.Equal(t, "storage@example.com", mgrConfig["mgr/telemetry/contact"])
+		assert.Equal(t, "Example", mgrConfig["mgr/telemetry/organization"])
+		// the proxy is removed from the spec
+		_, ok := mgrConfig["mgr/telemetry/proxy"]
+		assert.False(t, ok)
+	})
+
+	t.Run("perf channel", func(t *testing.T) {
+		c.spec.Mgr.Telemetry.Channels = []cephv1.TelemetryChannel{cephv1.TelemetryChannelPerf}
+		assert.Error(t, c.configureTelemetry())
+
+		c.clusterInfo.CephVersion = cephver.Quincy
+		assert.NoError(t, c.configureTelemetry())
+		assert.Equal(t, "true", mgrConfig["mgr/telemetry/channel_perf"])
+		assert.Equal(t, "false", mgrConfig["mgr/telemetry/channel_basic"])
+	})
+
+	t.Run("the module cannot be configured in the modules", func(t *testing.T) {
+		c.spec.Mgr.Modules = []cephv1.Module{{Name: "telemetry", Enabled: true}}
+		assert.Error(t, c.configureMgrModules())
+	})
+}
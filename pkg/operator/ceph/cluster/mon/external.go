@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ExternalConnection is the info needed to connect to the mons of an external cluster
+type ExternalConnection struct {
+	// FSID is the fsid of the external cluster
+	FSID string
+	// Endpoints are the mon endpoints in the <mon-name>=<mon-endpoint> form, separated by commas
+	Endpoints string
+	// Username is the ceph user the operator connects with, either the admin or the health checker
+	Username string
+	// Secret is the key of the ceph user
+	Secret string
+}
+
+// ValidateExternalEndpoints checks the mon endpoints of an external cluster
+func ValidateExternalEndpoints(endpoints string) error {
+	if endpoints == "" {
+		return errors.New("no mon endpoints")
+	}
+	for _, rawMon := range strings.Split(endpoints, ",") {
+		parts := strings.Split(rawMon, "=")
+		if len(parts) != 2 || parts[0] == "" {
+			return errors.Errorf("invalid mon endpoint %q, the <mon-name>=<ip>:<port> form is expected", rawMon)
+		}
+		if _, _, err := net.SplitHostPort(parts[1]); err != nil {
+			return errors.Wrapf(err, "invalid address of mon %q", parts[0])
+		}
+	}
+	return nil
+}
+
+// ImportExternalConnection creates or updates the mon secret of an external cluster and creates its
+// mon endpoints configmap. The endpoints of an existing configmap are kept since they are updated
+// from the quorum of the external cluster by the mon health checker.
+func ImportExternalConnection(ctx context.Context, clientset kubernetes.Interface, namespace string, conn *ExternalConnection, ownerInfo *k8sutil.OwnerInfo) error {
+	secrets := map[string][]byte{
+		fsidSecretNameKey: []byte(conn.FSID),
+		// the operator never needs the mon key of an external cluster
+		monSecretNameKey:  []byte(monSecretNameKey),
+		cephUsernameKey:   []byte(conn.Username),
+		cephUserSecretKey: []byte(conn.Secret),
+	}
+	if conn.Username == cephclient.AdminUsername {
+		secrets[adminSecretNameKey] = []byte(conn.Secret)
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AppName,
+			Namespace: namespace,
+		},
+		Data: secrets,
+		Type: k8sutil.RookType,
+	}
+	err := ownerInfo.SetControllerReference(secret)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to mon secret %q", secret.Name)
+	}
+	if _, err = k8sutil.CreateOrUpdateSecret(ctx, clientset, secret); err != nil {
+		return errors.Wrap(err, "failed to save mon secrets")
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EndpointConfigMapName,
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			EndpointDataKey: conn.Endpoints,
+			MaxMonIDKey:     "0",
+			MappingKey:      "{}",
+		},
+	}
+	err = ownerInfo.SetControllerReference(configMap)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to mon configmap %q", configMap.Name)
+	}
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{})
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create mon endpoints configmap")
+	}
+
+	logger.Infof("imported the connection info of external cluster %q", conn.FSID)
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateExternalEndpoints(t *testing.T) {
+	assert.NoError(t, ValidateExternalEndpoints("a=10.0.0.1:6789"))
+	assert.NoError(t, ValidateExternalEndpoints("a=10.0.0.1:6789,b=[fd00::1]:3300"))
+	assert.Error(t, ValidateExternalEndpoints(""))
+	assert.Error(t, ValidateExternalEndpoints("10.0.0.1:6789"))
+	assert.Error(t, ValidateExternalEndpoints("a=10.0.0.1"))
+	assert.Error(t, ValidateExternalEndpoints("a=10.0.0.1:6789,"))
+}
+
+func TestImportExternalConnection(t *testing.T) {
+	ctx := context.TODO()
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	ownerInfo := cephclient.NewMinimumOwnerInfoWithOwnerRef()
+	conn := &ExternalConnection{
+		FSID:      "b5a4c9c5-4d2e-4a1e-9c5e-1f3c7f7d1a2b",
+		Endpoints: "a=10.0.0.1:6789",
+		Username:  "client.healthchecker",
+		Secret:    "AQDFkbNeft5bFRAATndLNUSEKruozxiZi3lrdA==",
+	}
+
+	err := ImportExternalConnection(ctx, clientset, "ns", conn, ownerInfo)
+	assert.NoError(t, err)
+	clusterInfo, _, _, err := CreateOrLoadClusterInfo(context, ctx, "ns", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, conn.FSID, clusterInfo.FSID)
+	assert.Equal(t, conn.Username, clusterInfo.CephCred.Username)
+	assert.Equal(t, conn.Secret, clusterInfo.CephCred.Secret)
+	assert.Equal(t, "10.0.0.1:6789", clusterInfo.Monitors["a"].Endpoint)
+
+	// the mon endpoints updated by the health checker are kept
+	cm, err := clientset.CoreV1().ConfigMaps("ns").Get(ctx, EndpointConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	cm.Data[EndpointDataKey] = "b=10.0.0.2:6789"
+	_, err = clientset.CoreV1().ConfigMaps("ns").Update(ctx, cm, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	conn.Username = cephclient.AdminUsername
+	err = ImportExternalConnection(ctx, clientset, "ns", conn, ownerInfo)
+	assert.NoError(t, err)
+	clusterInfo, _, _, err = CreateOrLoadClusterInfo(context, ctx, "ns", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, cephclient.AdminUsername, clusterInfo.CephCred.Username)
+	assert.Equal(t, 1, len(clusterInfo.Monitors))
+	assert.Equal(t, "10.0.0.2:6789", clusterInfo.Monitors["b"].Endpoint)
+	secret, err := clientset.CoreV1().Secrets("ns").Get(ctx, AppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, conn.Secret, string(secret.Data[adminSecretNameKey]))
+}
@@ -647,8 +647,13 @@ func (c *Cluster) addOrRemoveExternalMonitor(status cephclient.MonStatusResponse
 				changed = true
 			} else {
 				// this mon was in clusterInfo and is still in the quorum
-				// add it again
-				c.ClusterInfo.Monitors[mon.Name] = oldClusterInfoMonitors[mon.Name]
+				// add it again, with its new endpoint if the mon was moved
+				monInfo := oldClusterInfoMonitors[mon.Name]
+				if endpoint, moved := movedExternalMonEndpoint(mon, monInfo.Endpoint); moved {
+					logger.Infof("external mon %q moved from %s to %s, updating its endpoint", mon.Name, monInfo.Endpoint, endpoint)
+					monInfo = &cephclient.MonInfo{Name: mon.Name, Endpoint: endpoint}
+				}
+				c.ClusterInfo.Monitors[mon.Name] = monInfo
 				logger.Debugf("everything is fine mon %q in the clusterInfo and its quorum status is %v", mon.Name, inQuorum)
 			}
 		}
@@ -670,6 +675,40 @@ func (c *Cluster) addOrRemoveExternalMonitor(status cephclient.MonStatusResponse
 	return changed, nil
 }
 
+// movedExternalMonEndpoint returns the new endpoint of an external mon when its current endpoint is
+// not one of the addresses of the mon anymore. The port of the current endpoint is kept if the mon
+// still listens on it, so that the choice between the msgr2 and legacy ports is kept.
+func movedExternalMonEndpoint(mon cephclient.MonMapEntry, current string) (string, bool) {
+	// FYI the addresses are "10.97.171.131:6789/0", the '/0' must be removed
+	publicAddr := strings.Split(mon.PublicAddr, "/")[0]
+	addresses := []string{}
+	for _, addr := range mon.PublicAddrs.Addrvec {
+		addresses = append(addresses, strings.Split(addr.Addr, "/")[0])
+	}
+	if publicAddr != "" {
+		addresses = append(addresses, publicAddr)
+	}
+	if len(addresses) == 0 {
+		return "", false
+	}
+
+	for _, addr := range addresses {
+		if addr == current {
+			return "", false
+		}
+	}
+	currentPort := cephutil.GetPortFromEndpoint(current)
+	for _, addr := range addresses {
+		if cephutil.GetPortFromEndpoint(addr) == currentPort {
+			return addr, true
+		}
+	}
+	if publicAddr != "" {
+		return publicAddr, true
+	}
+	return addresses[0], true
+}
+
 func (c *Cluster) evictMonIfMultipleOnSameNode() error {
 	if c.spec.Mon.AllowMultiplePerNode {
 		logger.Debug("skipping check for multiple mons on same node since multiple mons are allowed")
@@ -431,7 +431,7 @@ func TestAddOrRemoveExternalMonitor(t *testing.T) {
 			Name: "a",
 		},
 	}
-	fakeResp.MonMap.Mons[0].PublicAddr = "172.17.0.4:3300"
+	fakeResp.MonMap.Mons[0].PublicAddr = "1.2.3.1:6789/0"
 
 	// populate fake ClusterInfo
 	c := &Cluster{ClusterInfo: &cephclient.ClusterInfo{}}
@@ -479,6 +479,43 @@ func TestAddOrRemoveExternalMonitor(t *testing.T) {
 	assert.True(t, changed)
 	// ClusterInfo should now have 2 monitors
 	assert.Equal(t, 2, len(c.ClusterInfo.Monitors))
+
+	//
+	// TEST 4
+	//
+	// The mon "a" of the external cluster was replaced by a mon with another ip
+	// ClusterInfo should be updated with the new endpoint
+	fakeResp.MonMap.Mons[0].PublicAddr = "172.17.0.4:6789/0"
+	changed, err = c.addOrRemoveExternalMonitor(fakeResp)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "172.17.0.4:6789", c.ClusterInfo.Monitors["a"].Endpoint)
+	assert.Equal(t, "172.17.0.5:3300", c.ClusterInfo.Monitors["b"].Endpoint)
+
+	//
+	// TEST 5
+	//
+	// The msgr2 endpoint of a mon is kept while the mon listens on it
+	c.ClusterInfo.Monitors["a"].Endpoint = "172.17.0.4:3300"
+	fakeResp.MonMap.Mons[0].PublicAddrs.Addrvec = []cephclient.AddrvecEntry{
+		{Type: "v2", Addr: "172.17.0.4:3300"},
+		{Type: "v1", Addr: "172.17.0.4:6789"},
+	}
+	changed, err = c.addOrRemoveExternalMonitor(fakeResp)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "172.17.0.4:3300", c.ClusterInfo.Monitors["a"].Endpoint)
+
+	// and the msgr2 port is still used when the mon moves
+	fakeResp.MonMap.Mons[0].PublicAddr = "172.17.0.6:6789/0"
+	fakeResp.MonMap.Mons[0].PublicAddrs.Addrvec = []cephclient.AddrvecEntry{
+		{Type: "v2", Addr: "172.17.0.6:3300"},
+		{Type: "v1", Addr: "172.17.0.6:6789"},
+	}
+	changed, err = c.addOrRemoveExternalMonitor(fakeResp)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "172.17.0.6:3300", c.ClusterInfo.Monitors["a"].Endpoint)
 }
 
 func TestNewHealthChecker(t *testing.T) {
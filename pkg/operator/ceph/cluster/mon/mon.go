@@ -632,6 +632,7 @@ func scheduleMonitor(c *Cluster, mon *monConfig) (*apps.Deployment, error) {
 	d.Spec.Template.Spec.Containers[0].Args = []string{"3600"}
 	// remove the liveness probe on the canary pod
 	d.Spec.Template.Spec.Containers[0].LivenessProbe = nil
+	controller.ApplySecurityProfile(c.spec.Security.Profiles, cephv1.KeyMon, &d.Spec.Template.ObjectMeta, &d.Spec.Template.Spec)
 
 	// setup affinity settings for pod scheduling
 	p := c.getMonPlacement(mon.Zone)
@@ -1040,13 +1041,26 @@ func (c *Cluster) saveMonConfig() error {
 		return errors.Wrap(err, "failed to write connection config for new mons")
 	}
 
-	if err := csi.SaveClusterConfig(c.context.Clientset, c.Namespace, c.ClusterInfo, &csi.CsiClusterConfigEntry{Monitors: csi.MonEndpoints(c.ClusterInfo.Monitors)}); err != nil {
+	if err := csi.SaveClusterConfig(c.context.Clientset, c.Namespace, c.ClusterInfo, &csi.CsiClusterConfigEntry{Monitors: csi.MonEndpoints(c.ClusterInfo.Monitors), ReadAffinity: c.csiReadAffinity(), CephFS: csi.CephFSMountOptions(&c.spec)}); err != nil {
 		return errors.Wrap(err, "failed to update csi cluster config")
 	}
 
 	return nil
 }
 
+// csiReadAffinity returns the read affinity of the csi config of the cluster. The crush location
+// labels of an external cluster are imported again from its CRUSH map when they are not configured.
+func (c *Cluster) csiReadAffinity() *csi.CsiReadAffinity {
+	if !c.spec.External.Enable || !c.spec.CSI.ReadAffinity.Enabled || len(c.spec.CSI.ReadAffinity.CrushLocationLabels) != 0 {
+		return csi.ReadAffinity(&c.spec)
+	}
+	labels, err := csi.ImportCrushLocationLabels(c.context, c.ClusterInfo)
+	if err != nil {
+		logger.Warningf("failed to import the crush location labels of the external cluster, using the default labels. %v", err)
+	}
+	return csi.ExternalReadAffinity(&c.spec, labels)
+}
+
 func (c *Cluster) persistExpectedMonDaemons() error {
 	configMap := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -92,6 +92,9 @@ func (c *ClusterController) startMonitoringCheck(cluster *cluster, clusterInfo *
 
 	case "status":
 		cephChecker := newCephStatusChecker(c.context, clusterInfo, cluster.Spec)
+		if cephChecker.slowOps != nil {
+			cephChecker.slowOps.recorder = c.recorder
+		}
 		logger.Infof("enabling ceph %s monitoring goroutine for cluster %q", daemon, cluster.Namespace)
 		go cephChecker.checkCephStatus(cluster.monitoringRoutines[daemon].internalCtx)
 	}
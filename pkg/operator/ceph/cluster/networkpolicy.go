@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	"github.com/rook/rook/pkg/operator/ceph/nfs"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// the label set by kubernetes on the namespaces with their name
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+	toolboxAppName     = "rook-ceph-tools"
+)
+
+// the daemons the network policies are generated for, the policies are named after their app
+var networkPolicyApps = []string{mon.AppName, mgr.AppName, osd.AppName, mds.AppName, object.AppName, nfs.AppName}
+
+// reconcileNetworkPolicies creates the network policies allowing the expected traffic to each type of
+// daemon, or removes them when they are disabled
+func (c *cluster) reconcileNetworkPolicies() error {
+	var policies map[string]*networkingv1.NetworkPolicy
+	if c.Spec.Network.NetworkPoliciesEnabled() {
+		var err error
+		policies, err = generateNetworkPolicies(c.Namespace, os.Getenv(k8sutil.PodNamespaceEnvVar), c.Spec)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, app := range networkPolicyApps {
+		policy, ok := policies[app]
+		if !ok {
+			if err := k8sutil.DeleteNetworkPolicy(c.ClusterInfo.Context, c.context.Clientset, c.Namespace, app); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.ownerInfo.SetControllerReference(policy); err != nil {
+			return errors.Wrapf(err, "failed to set owner reference to network policy %q", app)
+		}
+		if err := k8sutil.CreateOrUpdateNetworkPolicy(c.ClusterInfo.Context, c.context.Clientset, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateNetworkPolicies returns the network policies of the daemons keyed by their app. The ceph
+// clients are the pods of the cluster namespace, the operator and the csi drivers in the operator
+// namespace and the nodes for the clients on the host network.
+func generateNetworkPolicies(namespace, operatorNamespace string, spec *cephv1.ClusterSpec) (map[string]*networkingv1.NetworkPolicy, error) {
+	nodes := []networkingv1.NetworkPolicyPeer{}
+	for _, cidr := range spec.Network.NetworkPolicies.NodeCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return nil, errors.Wrapf(err, "invalid node cidr %q of the network policies", cidr)
+		}
+		nodes = append(nodes, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+	}
+
+	operator := []networkingv1.NetworkPolicyPeer{namespacePeer(operatorNamespace)}
+	// all the pods of the cluster namespace
+	clusterPods := []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}
+	// the ceph daemons and the toolbox
+	daemons := []networkingv1.NetworkPolicyPeer{
+		{PodSelector: &metav1.LabelSelector{
+			MatchLabels:      map[string]string{k8sutil.ClusterAttr: namespace},
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: controller.DaemonIDLabel, Operator: metav1.LabelSelectorOpExists}},
+		}},
+		{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{k8sutil.AppAttr: toolboxAppName}}},
+	}
+	clients := peers(clusterPods, operator, nodes)
+
+	objectStoreClients := peers(clusterPods, operator)
+	for _, ns := range spec.Network.NetworkPolicies.ObjectStoreNamespaces {
+		objectStoreClients = append(objectStoreClients, namespacePeer(ns))
+	}
+	anyNamespace := []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}}
+
+	policies := map[string]*networkingv1.NetworkPolicy{
+		mon.AppName: newNetworkPolicy(namespace, mon.AppName,
+			networkingv1.NetworkPolicyIngressRule{From: clients, Ports: tcpPorts(int(mon.DefaultMsgr2Port), int(mon.DefaultMsgr1Port))}),
+		// the mgr, osd and mds daemons bind random ports of the 6800-7300 range
+		mgr.AppName: newNetworkPolicy(namespace, mgr.AppName,
+			networkingv1.NetworkPolicyIngressRule{From: clients},
+			networkingv1.NetworkPolicyIngressRule{From: anyNamespace, Ports: tcpPorts(int(mgr.DefaultMetricsPort), mgr.DashboardPort(spec.Dashboard))}),
+		osd.AppName: newNetworkPolicy(namespace, osd.AppName,
+			networkingv1.NetworkPolicyIngressRule{From: peers(daemons, operator, nodes)}),
+		mds.AppName: newNetworkPolicy(namespace, mds.AppName,
+			networkingv1.NetworkPolicyIngressRule{From: clients}),
+		object.AppName: newNetworkPolicy(namespace, object.AppName,
+			networkingv1.NetworkPolicyIngressRule{From: objectStoreClients}),
+		nfs.AppName: newNetworkPolicy(namespace, nfs.AppName,
+			networkingv1.NetworkPolicyIngressRule{From: peers(anyNamespace, nodes), Ports: tcpPorts(nfs.Port)}),
+	}
+	return policies, nil
+}
+
+func newNetworkPolicy(namespace, app string, rules ...networkingv1.NetworkPolicyIngressRule) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app,
+			Namespace: namespace,
+			Labels:    controller.AppLabels(app, namespace),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{k8sutil.AppAttr: app}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     rules,
+		},
+	}
+}
+
+func namespacePeer(namespace string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: namespace}},
+	}
+}
+
+func peers(groups ...[]networkingv1.NetworkPolicyPeer) []networkingv1.NetworkPolicyPeer {
+	all := []networkingv1.NetworkPolicyPeer{}
+	for _, group := range groups {
+		all = append(all, group...)
+	}
+	return all
+}
+
+func tcpPorts(ports ...int) []networkingv1.NetworkPolicyPort {
+	policyPorts := []networkingv1.NetworkPolicyPort{}
+	for _, port := range ports {
+		protocol := v1.ProtocolTCP
+		p := intstr.FromInt(port)
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p})
+	}
+	return policyPorts
+}
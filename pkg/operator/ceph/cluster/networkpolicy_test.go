@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateNetworkPolicies(t *testing.T) {
+	spec := &cephv1.ClusterSpec{
+		Network: cephv1.NetworkSpec{NetworkPolicies: &cephv1.NetworkPoliciesSpec{
+			Enabled:               true,
+			ObjectStoreNamespaces: []string{"app"},
+			NodeCIDRs:             []string{"10.0.0.0/24"},
+		}},
+		Dashboard: cephv1.DashboardSpec{SSL: true},
+	}
+	policies, err := generateNetworkPolicies("rook-ceph", "rook-operator", spec)
+	assert.NoError(t, err)
+	assert.Len(t, policies, len(networkPolicyApps))
+
+	monPolicy := policies["rook-ceph-mon"]
+	assert.Equal(t, "rook-ceph-mon", monPolicy.Spec.PodSelector.MatchLabels["app"])
+	assert.Len(t, monPolicy.Spec.Ingress, 1)
+	ports := monPolicy.Spec.Ingress[0].Ports
+	assert.Len(t, ports, 2)
+	assert.Equal(t, 3300, ports[0].Port.IntValue())
+	assert.Equal(t, 6789, ports[1].Port.IntValue())
+	// the pods of the cluster namespace, the operator namespace and the nodes
+	from := monPolicy.Spec.Ingress[0].From
+	assert.Len(t, from, 3)
+	assert.Equal(t, &metav1.LabelSelector{}, from[0].PodSelector)
+	assert.Equal(t, "rook-operator", from[1].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	assert.Equal(t, "10.0.0.0/24", from[2].IPBlock.CIDR)
+
+	// only the daemons and the toolbox of the cluster namespace
+	from = policies["rook-ceph-osd"].Spec.Ingress[0].From
+	assert.Len(t, from, 4)
+	assert.Equal(t, "rook-ceph", from[0].PodSelector.MatchLabels["rook_cluster"])
+	assert.Equal(t, "ceph_daemon_id", from[0].PodSelector.MatchExpressions[0].Key)
+	assert.Equal(t, "rook-ceph-tools", from[1].PodSelector.MatchLabels["app"])
+
+	mgrPolicy := policies["rook-ceph-mgr"]
+	assert.Len(t, mgrPolicy.Spec.Ingress, 2)
+	assert.Equal(t, 9283, mgrPolicy.Spec.Ingress[1].Ports[0].Port.IntValue())
+	assert.Equal(t, 8443, mgrPolicy.Spec.Ingress[1].Ports[1].Port.IntValue())
+
+	// the allowed namespaces but not the nodes
+	from = policies["rook-ceph-rgw"].Spec.Ingress[0].From
+	assert.Len(t, from, 3)
+	assert.Equal(t, "app", from[2].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+
+	t.Run("invalid cidr", func(t *testing.T) {
+		spec.Network.NetworkPolicies.NodeCIDRs = []string{"10.0.0.0"}
+		_, err := generateNetworkPolicies("rook-ceph", "rook-operator", spec)
+		assert.Error(t, err)
+	})
+}
+
+func TestReconcileNetworkPolicies(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	clientset := testop.New(t, 1)
+	c := &cluster{
+		ClusterInfo: cephclient.AdminTestClusterInfo(ns),
+		Namespace:   ns,
+		context:     &clusterd.Context{Clientset: clientset},
+		ownerInfo:   k8sutil.NewOwnerInfoWithOwnerRef(&metav1.OwnerReference{Name: "testing", UID: "uid"}, ns),
+		Spec: &cephv1.ClusterSpec{
+			Network: cephv1.NetworkSpec{NetworkPolicies: &cephv1.NetworkPoliciesSpec{Enabled: true}},
+		},
+	}
+
+	assert.NoError(t, c.reconcileNetworkPolicies())
+	policies, err := clientset.NetworkingV1().NetworkPolicies(ns).List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, policies.Items, len(networkPolicyApps))
+	assert.Equal(t, "testing", policies.Items[0].OwnerReferences[0].Name)
+
+	// updated
+	assert.NoError(t, c.reconcileNetworkPolicies())
+
+	// removed when disabled
+	c.Spec.Network.NetworkPolicies.Enabled = false
+	assert.NoError(t, c.reconcileNetworkPolicies())
+	policies, err = clientset.NetworkingV1().NetworkPolicies(ns).List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, policies.Items)
+}
@@ -18,14 +18,17 @@ package osd
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	kms "github.com/rook/rook/pkg/daemon/ceph/osd/kms"
 	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 )
@@ -218,13 +221,11 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig, errs *provi
 
 			// We could set an env var in the Operator or a global var instead of the API call?
 			// Hopefully, the API is cheap and we can always retrieve the token if it has changed...
-			if c.spec.Security.KeyManagementService.IsTokenAuthEnabled() && c.spec.Security.KeyManagementService.IsVaultKMS() {
-				err := kms.SetTokenToEnvVar(c.clusterInfo.Context, c.context, c.spec.Security.KeyManagementService.TokenSecretName, kmsConfig.Provider, c.clusterInfo.Namespace)
-				if err != nil {
-					errMsg := fmt.Sprintf("failed to fetch kms token secret %q. %v", c.spec.Security.KeyManagementService.TokenSecretName, err)
-					errs.addError(errMsg)
-					continue
-				}
+			err = kms.LoadToken(c.clusterInfo.Context, c.context, &c.spec.Security.KeyManagementService, c.clusterInfo.Namespace)
+			if err != nil {
+				errMsg := fmt.Sprintf("failed to fetch kms token secret %q. %v", c.spec.Security.KeyManagementService.TokenSecretName, err)
+				errs.addError(errMsg)
+				continue
 			}
 
 			// Generate and store the encrypted key in whatever KMS is configured
@@ -402,3 +403,61 @@ func createDaemonOnNode(c *Cluster, osd OSDInfo, nodeName string, config *provis
 	_, err = k8sutil.CreateDeployment(c.clusterInfo.Context, c.context.Clientset, d)
 	return errors.Wrapf(err, "failed to create deployment for OSD %d on node %q", osd.ID, nodeName)
 }
+
+// updateKMSStatus reports the KMS storing the keys of the encrypted OSDs and the versions of the keys
+// in the CephCluster status
+func (c *Cluster) updateKMSStatus() {
+	var kmsConfig *kms.Config
+	kmsStatus := &cephv1.KMSStatus{}
+	for _, deviceSet := range c.deviceSets {
+		if !deviceSet.Encrypted {
+			continue
+		}
+		if kmsConfig == nil {
+			if err := kms.LoadToken(c.clusterInfo.Context, c.context, &c.spec.Security.KeyManagementService, c.clusterInfo.Namespace); err != nil {
+				logger.Warningf("failed to fetch kms token secret %q to report the kms status. %v", c.spec.Security.KeyManagementService.TokenSecretName, err)
+				return
+			}
+			kmsConfig = kms.NewConfig(c.context, &c.spec, c.clusterInfo)
+			kmsStatus.Provider = kmsConfig.Provider
+		}
+		for _, pvcSource := range deviceSet.PVCSources {
+			version, err := kmsConfig.SecretVersion(pvcSource.ClaimName)
+			if err != nil {
+				logger.Warningf("failed to get the version of the encryption key of osd claim %q. %v", pvcSource.ClaimName, err)
+				continue
+			}
+			if version == "" {
+				continue
+			}
+			if kmsStatus.KeyVersions == nil {
+				kmsStatus.KeyVersions = map[string]string{}
+			}
+			kmsStatus.KeyVersions[pvcSource.ClaimName] = version
+		}
+	}
+	if kmsConfig == nil {
+		return
+	}
+
+	cephCluster := cephv1.CephCluster{}
+	err := c.context.Client.Get(c.clusterInfo.Context, c.clusterInfo.NamespacedName(), &cephCluster)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Errorf("failed to retrieve ceph cluster %q to update the kms status. %v", c.clusterInfo.NamespacedName().Name, err)
+		return
+	}
+	err = reporting.PatchStatus(c.context.Client, &cephCluster, func() bool {
+		if reflect.DeepEqual(cephCluster.Status.KMS, kmsStatus) {
+			return false
+		}
+		cephCluster.Status.KMS = kmsStatus
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to update cluster %q kms status. %v", c.clusterInfo.NamespacedName().Name, err)
+	}
+}
@@ -201,11 +201,19 @@ func (m *OSDHealthMonitor) updateCephStatus(devices []string) {
 		logger.Errorf("failed to retrieve ceph cluster %q to update ceph Storage. %v", m.clusterInfo.NamespacedName().Name, err)
 		return
 	}
-	if !reflect.DeepEqual(cephCluster.Status.CephStorage, cephClusterStorage) {
-		cephCluster.Status.CephStorage = &cephClusterStorage
-		if err := reporting.UpdateStatus(m.context.Client, &cephCluster); err != nil {
-			logger.Errorf("failed to update cluster %q Storage. %v", m.clusterInfo.NamespacedName().Name, err)
-			return
+	err = reporting.PatchStatus(m.context.Client, &cephCluster, func() bool {
+		// keep the progress of the osd migration reported by the orchestration
+		storage := cephClusterStorage
+		if cephCluster.Status.CephStorage != nil {
+			storage.OSDMigration = cephCluster.Status.CephStorage.OSDMigration
+		}
+		if reflect.DeepEqual(cephCluster.Status.CephStorage, &storage) {
+			return false
 		}
+		cephCluster.Status.CephStorage = &storage
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to update cluster %q Storage. %v", m.clusterInfo.NamespacedName().Name, err)
 	}
 }
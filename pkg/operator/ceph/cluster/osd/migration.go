@@ -0,0 +1,473 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// the configmap holding the state of the migration of the OSDs across the reconciles
+	osdMigrationConfigName  = "rook-ceph-osd-migration"
+	osdMigrationIDKey       = "osd"
+	osdMigrationPVCKey      = "pvc"
+	osdMigrationPhaseKey    = "phase"
+	osdMigrationMigratedKey = "migrated"
+
+	// the config-key of the LUKS key of an OSD encrypted by ceph-volume on a host
+	dmcryptConfigKeyFormat = "dm-crypt/osd/%s/luks"
+
+	// OSDMigrationPhaseDraining is the phase where the OSD is out until its data is moved to the other OSDs
+	OSDMigrationPhaseDraining = "Draining"
+	// OSDMigrationPhaseRemoving is the phase where the OSD is removed with its PVCs
+	OSDMigrationPhaseRemoving = "Removing"
+	// OSDMigrationPhaseRecovering is the phase where the OSD is provisioned again on new PVCs until
+	// the placement groups are clean
+	OSDMigrationPhaseRecovering = "Recovering"
+
+	// OSDMigrationRequeueAfter is the interval at which the cluster is reconciled during the migration
+	OSDMigrationRequeueAfter = time.Minute
+)
+
+var (
+	// allow unit tests to override these values
+	pvcDeletionInterval = 5 * time.Second
+	pvcDeletionTimeout  = 5 * time.Minute
+)
+
+// osdIsEncrypted returns whether the deployment of an OSD on PVC opens an encrypted device
+func osdIsEncrypted(d *appsv1.Deployment) bool {
+	for _, c := range d.Spec.Template.Spec.InitContainers {
+		if c.Name == blockEncryptionOpenInitContainer {
+			return true
+		}
+	}
+	return false
+}
+
+// getUnencryptedOSDs returns the OSDs on PVC provisioned before their device set was encrypted, with
+// the names of their data PVCs
+func (c *Cluster) getUnencryptedOSDs() (map[int]string, error) {
+	unencryptedOSDs := map[int]string{}
+	encryptedDeviceSets := sets.NewString()
+	for _, deviceSet := range c.spec.Storage.StorageClassDeviceSets {
+		if deviceSet.Encrypted {
+			encryptedDeviceSets.Insert(deviceSet.Name)
+		}
+	}
+	if encryptedDeviceSets.Len() == 0 {
+		return unencryptedOSDs, nil
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s,%s", k8sutil.AppAttr, AppName, OSDOverPVCLabelKey)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).List(c.clusterInfo.Context, listOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the osd deployments on pvc")
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if !encryptedDeviceSets.Has(d.Labels[CephDeviceSetLabelKey]) || osdIsEncrypted(d) {
+			continue
+		}
+		osdID, err := getOSDID(d)
+		if err != nil {
+			return nil, err
+		}
+		unencryptedOSDs[osdID] = d.Labels[OSDOverPVCLabelKey]
+	}
+	return unencryptedOSDs, nil
+}
+
+// getUnencryptedHostOSDs returns the OSDs on hosts that are not encrypted while the storage config
+// asks for it. ceph-volume stores the key of an encrypted OSD in the config-key store, the OSDs
+// without a key were provisioned before the encryption was enabled.
+func (c *Cluster) getUnencryptedHostOSDs() ([]int, error) {
+	if !c.spec.Storage.IsOnHostEncrypted() {
+		return nil, nil
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s,!%s", k8sutil.AppAttr, AppName, OSDOverPVCLabelKey)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).List(c.clusterInfo.Context, listOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the osd deployments on hosts")
+	}
+	if len(deployments.Items) == 0 {
+		return nil, nil
+	}
+
+	osdDump, err := cephclient.GetOSDDump(c.context, c.clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	osdUUIDs := map[string]string{}
+	for _, osd := range osdDump.OSDs {
+		osdUUIDs[osd.OSD.String()] = osd.UUID
+	}
+	buf, err := cephclient.NewCephCommand(c.context, c.clusterInfo, []string{"config-key", "ls"}).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the config keys")
+	}
+	var keys []string
+	if err := json.Unmarshal(buf, &keys); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the config keys")
+	}
+	configKeys := sets.NewString(keys...)
+
+	unencryptedOSDs := []int{}
+	for i := range deployments.Items {
+		osdID, err := getOSDID(&deployments.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		uuid, ok := osdUUIDs[strconv.Itoa(osdID)]
+		if !ok || configKeys.Has(fmt.Sprintf(dmcryptConfigKeyFormat, uuid)) {
+			continue
+		}
+		unencryptedOSDs = append(unencryptedOSDs, osdID)
+	}
+	sort.Ints(unencryptedOSDs)
+	return unencryptedOSDs, nil
+}
+
+// migrateOSDs migrates the OSDs provisioned before their device set was encrypted, one at a time and
+// one step per reconcile. The OSD is marked out until its data is moved to the other OSDs, then it is
+// removed with its PVCs so that new encrypted PVCs are provisioned for its device set. The next OSD is
+// migrated once the placement groups are clean again.
+func (c *Cluster) migrateOSDs() error {
+	unencryptedOSDs, err := c.getUnencryptedOSDs()
+	if err != nil {
+		return err
+	}
+	hostOSDs, err := c.getUnencryptedHostOSDs()
+	if err != nil {
+		return err
+	}
+	c.unencryptedOSDs = sets.NewInt()
+	for osdID := range unencryptedOSDs {
+		c.unencryptedOSDs.Insert(osdID)
+	}
+	c.MigrationInProgress = false
+
+	state, err := c.kv.GetStore(c.clusterInfo.Context, osdMigrationConfigName)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to get the state of the osd migration")
+		}
+		state = map[string]string{}
+	}
+	if len(unencryptedOSDs) == 0 && len(state) == 0 && len(hostOSDs) == 0 {
+		// no migration, a previous rejection of the confirmation is cleared
+		if c.spec.Storage.IsMigrationConfirmed() {
+			c.updateOSDMigrationStatus(nil, c.osdMigrationRejection(nil))
+		}
+		return nil
+	}
+
+	status := &cephv1.OSDMigrationStatus{Pending: len(unencryptedOSDs)}
+	status.Migrated, _ = strconv.Atoi(state[osdMigrationMigratedKey])
+	err = c.migrateNextStep(unencryptedOSDs, hostOSDs, state, status)
+	c.updateOSDMigrationStatus(status, c.osdMigrationRejection(hostOSDs))
+	return err
+}
+
+// osdMigrationRejection returns the OSDMigrationRejected condition of the cluster. The confirmation
+// is rejected while OSDs on hosts are not encrypted, they are not migrated since rook cannot zap and
+// provision the devices of a host again.
+func (c *Cluster) osdMigrationRejection(hostOSDs []int) cephv1.Condition {
+	if len(hostOSDs) > 0 && c.spec.Storage.IsMigrationConfirmed() {
+		return cephv1.Condition{
+			Type:    cephv1.ConditionOSDMigrationRejected,
+			Status:  v1.ConditionTrue,
+			Reason:  cephv1.OSDMigrationHostOSDsReason,
+			Message: fmt.Sprintf("the osds %v on hosts are not encrypted, only the osds on pvc can be migrated", hostOSDs),
+		}
+	}
+	return cephv1.Condition{
+		Type:    cephv1.ConditionOSDMigrationRejected,
+		Status:  v1.ConditionFalse,
+		Reason:  cephv1.OSDMigrationAcceptedReason,
+		Message: "the osd migration is not rejected",
+	}
+}
+
+func (c *Cluster) migrateNextStep(unencryptedOSDs map[int]string, hostOSDs []int, state map[string]string, status *cephv1.OSDMigrationStatus) error {
+	if idStr, ok := state[osdMigrationIDKey]; ok {
+		osdID, err := strconv.Atoi(idStr)
+		if err != nil {
+			return errors.Wrapf(err, "invalid osd %q in the state of the osd migration", idStr)
+		}
+		status.OSD = &osdID
+		status.Phase = state[osdMigrationPhaseKey]
+		c.MigrationInProgress = true
+
+		switch status.Phase {
+		case OSDMigrationPhaseDraining:
+			safeToDestroy, err := cephclient.OsdSafeToDestroy(c.context, c.clusterInfo, osdID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to check if osd %d is safe to destroy", osdID)
+			}
+			if !safeToDestroy {
+				status.Message = "waiting for the data of the osd to move to the other osds"
+				logger.Infof("osd migration: waiting for osd %d to be safe to destroy", osdID)
+				return nil
+			}
+			status.Phase = OSDMigrationPhaseRemoving
+			if err := c.saveOSDMigrationState(state, osdID, state[osdMigrationPVCKey], status.Phase); err != nil {
+				return err
+			}
+			fallthrough
+
+		case OSDMigrationPhaseRemoving:
+			if err := c.removeMigratedOSD(osdID, state[osdMigrationPVCKey]); err != nil {
+				return errors.Wrapf(err, "failed to remove osd %d to migrate it", osdID)
+			}
+			delete(unencryptedOSDs, osdID)
+			c.unencryptedOSDs.Delete(osdID)
+			status.Pending = len(unencryptedOSDs)
+			status.Phase = OSDMigrationPhaseRecovering
+			status.Message = "provisioning the osd again on new pvcs"
+			return c.saveOSDMigrationState(state, osdID, state[osdMigrationPVCKey], status.Phase)
+
+		case OSDMigrationPhaseRecovering:
+			msg, clean, err := cephclient.IsClusterClean(c.context, c.clusterInfo)
+			if err != nil {
+				return errors.Wrap(err, "failed to check if the placement groups are clean")
+			}
+			if !clean {
+				status.Message = fmt.Sprintf("waiting for the placement groups to recover. %s", msg)
+				logger.Infof("osd migration: waiting for the placement groups to recover after migrating osd %d", osdID)
+				return nil
+			}
+			logger.Infof("osd migration: osd %d is migrated", osdID)
+			status.Migrated++
+			status.OSD = nil
+			status.Phase = ""
+			state = map[string]string{osdMigrationMigratedKey: strconv.Itoa(status.Migrated)}
+			if err := c.kv.ClearStore(c.clusterInfo.Context, osdMigrationConfigName); err != nil {
+				return errors.Wrap(err, "failed to clear the state of the osd migration")
+			}
+			if err := c.kv.SetValue(c.clusterInfo.Context, osdMigrationConfigName, osdMigrationMigratedKey, state[osdMigrationMigratedKey]); err != nil {
+				return errors.Wrap(err, "failed to save the state of the osd migration")
+			}
+			c.MigrationInProgress = false
+
+		default:
+			return errors.Errorf("invalid phase %q in the state of the osd migration", status.Phase)
+		}
+	}
+
+	if len(hostOSDs) > 0 {
+		logger.Warningf("osds %v on hosts are not encrypted while the storage config asks for it. they cannot be migrated, the osds on hosts must be removed and provisioned again manually", hostOSDs)
+		if c.spec.Storage.IsMigrationConfirmed() {
+			status.Message = fmt.Sprintf("the confirmation is rejected, the osds %v on hosts cannot be migrated", hostOSDs)
+			return nil
+		}
+	}
+	if len(unencryptedOSDs) == 0 {
+		status.Message = "all the osds are migrated"
+		return nil
+	}
+	if !c.spec.Storage.IsMigrationConfirmed() {
+		status.Message = fmt.Sprintf("waiting for the confirmation %q of the migration", cephv1.MigrationConfirmation)
+		logger.Warningf("%d osds were provisioned before their device set was encrypted. set the storage migration confirmation to %q to migrate them", len(unencryptedOSDs), cephv1.MigrationConfirmation)
+		return nil
+	}
+	if c.spec.Storage.Migration.Paused {
+		status.Message = "the migration is paused"
+		logger.Info("osd migration: paused")
+		return nil
+	}
+
+	// the next OSD is only drained once the data of the previous one is fully recovered
+	c.MigrationInProgress = true
+	msg, clean, err := cephclient.IsClusterClean(c.context, c.clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to check if the placement groups are clean")
+	}
+	if !clean {
+		status.Message = fmt.Sprintf("waiting for the placement groups to be clean before migrating the next osd. %s", msg)
+		logger.Info("osd migration: waiting for the placement groups to be clean before migrating the next osd")
+		return nil
+	}
+
+	osdIDs := []int{}
+	for osdID := range unencryptedOSDs {
+		osdIDs = append(osdIDs, osdID)
+	}
+	sort.Ints(osdIDs)
+	osdID := osdIDs[0]
+
+	logger.Infof("osd migration: draining osd %d on pvc %q", osdID, unencryptedOSDs[osdID])
+	if _, err := cephclient.OSDOut(c.context, c.clusterInfo, osdID); err != nil {
+		return errors.Wrapf(err, "failed to mark osd %d out", osdID)
+	}
+	status.OSD = &osdID
+	status.Phase = OSDMigrationPhaseDraining
+	status.Message = "waiting for the data of the osd to move to the other osds"
+	return c.saveOSDMigrationState(state, osdID, unencryptedOSDs[osdID], status.Phase)
+}
+
+func (c *Cluster) saveOSDMigrationState(state map[string]string, osdID int, pvcName, phase string) error {
+	values := map[string]string{
+		osdMigrationIDKey:    strconv.Itoa(osdID),
+		osdMigrationPVCKey:   pvcName,
+		osdMigrationPhaseKey: phase,
+	}
+	for key, value := range values {
+		if state[key] == value {
+			continue
+		}
+		if err := c.kv.SetValue(c.clusterInfo.Context, osdMigrationConfigName, key, value); err != nil {
+			return errors.Wrap(err, "failed to save the state of the osd migration")
+		}
+		state[key] = value
+	}
+	return nil
+}
+
+// removeMigratedOSD removes the drained OSD with its prepare job and its PVCs, so that new PVCs are
+// provisioned for its device set
+func (c *Cluster) removeMigratedOSD(osdID int, pvcName string) error {
+	hostName, err := cephclient.GetCrushHostName(c.context, c.clusterInfo, osdID)
+	if err != nil {
+		logger.Warningf("failed to get the crush host of osd %d. %v", osdID, err)
+	}
+
+	logger.Infof("osd migration: removing osd %d", osdID)
+	if err := k8sutil.DeleteDeployment(c.clusterInfo.Context, c.context.Clientset, c.clusterInfo.Namespace, deploymentName(osdID)); err != nil {
+		return errors.Wrapf(err, "failed to delete the deployment of osd %d", osdID)
+	}
+
+	labelSelector := fmt.Sprintf("%s=%s", OSDOverPVCLabelKey, pvcName)
+	jobs, err := c.context.Clientset.BatchV1().Jobs(c.clusterInfo.Namespace).List(c.clusterInfo.Context, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the prepare jobs of pvc %q", pvcName)
+	}
+	for _, job := range jobs.Items {
+		if err := k8sutil.DeleteBatchJob(c.clusterInfo.Context, c.context.Clientset, c.clusterInfo.Namespace, job.Name, false); err != nil {
+			return errors.Wrapf(err, "failed to delete prepare job %q", job.Name)
+		}
+	}
+
+	if err := c.deleteOSDPVCs(pvcName); err != nil {
+		return err
+	}
+
+	purgeArgs := []string{"osd", "purge", fmt.Sprintf("osd.%d", osdID), "--force", "--yes-i-really-mean-it"}
+	if _, err := cephclient.NewCephCommand(c.context, c.clusterInfo, purgeArgs).Run(); err != nil {
+		return errors.Wrapf(err, "failed to purge osd %d", osdID)
+	}
+
+	// the host is only removed from the crush map if no other osd is left in it
+	if hostName != "" {
+		if _, err := cephclient.NewCephCommand(c.context, c.clusterInfo, []string{"osd", "crush", "rm", hostName}).Run(); err != nil {
+			logger.Debugf("did not remove crush host %q. %v", hostName, err)
+		}
+	}
+	return nil
+}
+
+// deleteOSDPVCs deletes the data, metadata and wal PVCs of an OSD and waits for their deletion, so
+// that they are not reused when the PVCs of the device set are provisioned
+func (c *Cluster) deleteOSDPVCs(dataPVCName string) error {
+	pvcClient := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.clusterInfo.Namespace)
+	dataPVC, err := pvcClient.Get(c.clusterInfo.Context, dataPVCName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get pvc %q", dataPVCName)
+	}
+
+	labelSelector := fmt.Sprintf("%s=%s,%s=%s", CephDeviceSetLabelKey, dataPVC.Labels[CephDeviceSetLabelKey], CephSetIndexLabelKey, dataPVC.Labels[CephSetIndexLabelKey])
+	listOpts := metav1.ListOptions{LabelSelector: labelSelector}
+	pvcs, err := pvcClient.List(c.clusterInfo.Context, listOpts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the pvcs of pvc %q", dataPVCName)
+	}
+	for _, pvc := range pvcs.Items {
+		logger.Infof("osd migration: removing pvc %q", pvc.Name)
+		if err := pvcClient.Delete(c.clusterInfo.Context, pvc.Name, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete pvc %q", pvc.Name)
+		}
+	}
+
+	err = wait.PollImmediate(pvcDeletionInterval, pvcDeletionTimeout, func() (bool, error) {
+		pvcs, err := pvcClient.List(c.clusterInfo.Context, listOpts)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to list the pvcs of pvc %q", dataPVCName)
+		}
+		return len(pvcs.Items) == 0, nil
+	})
+	return errors.Wrapf(err, "failed to wait for the deletion of the pvcs of pvc %q", dataPVCName)
+}
+
+// updateOSDMigrationStatus reports the progress of the migration of the OSDs and its rejection in the
+// status of the cluster
+func (c *Cluster) updateOSDMigrationStatus(migrationStatus *cephv1.OSDMigrationStatus, rejection cephv1.Condition) {
+	cephCluster := cephv1.CephCluster{}
+	err := c.context.Client.Get(c.clusterInfo.Context, c.clusterInfo.NamespacedName(), &cephCluster)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Errorf("failed to retrieve ceph cluster %q to update the osd migration status. %v", c.clusterInfo.NamespacedName().Name, err)
+		return
+	}
+	err = reporting.PatchStatus(c.context.Client, &cephCluster, func() bool {
+		var current *cephv1.OSDMigrationStatus
+		if cephCluster.Status.CephStorage != nil {
+			current = cephCluster.Status.CephStorage.OSDMigration
+		}
+		// the condition is only added once the confirmation is rejected
+		currentRejection := cephv1.FindStatusCondition(cephCluster.Status.Conditions, rejection.Type)
+		updateRejection := currentRejection == nil && rejection.Status == v1.ConditionTrue ||
+			currentRejection != nil && (currentRejection.Status != rejection.Status || currentRejection.Message != rejection.Message)
+		if reflect.DeepEqual(current, migrationStatus) && !updateRejection {
+			return false
+		}
+		if cephCluster.Status.CephStorage == nil {
+			cephCluster.Status.CephStorage = &cephv1.CephStorage{}
+		}
+		cephCluster.Status.CephStorage.OSDMigration = migrationStatus
+		if updateRejection {
+			cephv1.SetStatusCondition(&cephCluster.Status.Conditions, rejection)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to update cluster %q osd migration status. %v", c.clusterInfo.NamespacedName().Name, err)
+	}
+}
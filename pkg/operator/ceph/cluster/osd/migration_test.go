@@ -0,0 +1,275 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMigrateOSDs(t *testing.T) {
+	pvcDeletionInterval = time.Millisecond
+	pvcDeletionTimeout = 100 * time.Millisecond
+	defer func() {
+		pvcDeletionInterval = 5 * time.Second
+		pvcDeletionTimeout = 5 * time.Minute
+	}()
+
+	ns := "rook-ceph"
+	clusterInfo := cephclient.AdminTestClusterInfo(ns)
+	clientset := fake.NewSimpleClientset()
+	ctx := context.TODO()
+
+	osdDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploymentName(0),
+			Namespace: ns,
+			Labels: map[string]string{
+				k8sutil.AppAttr:       AppName,
+				OsdIdLabelKey:         "0",
+				OSDOverPVCLabelKey:    "set1-data-0",
+				CephDeviceSetLabelKey: "set1",
+			},
+		},
+	}
+	_, err := clientset.AppsV1().Deployments(ns).Create(ctx, osdDeployment, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "set1-data-0",
+			Namespace: ns,
+			Labels: map[string]string{
+				CephDeviceSetLabelKey: "set1",
+				CephSetIndexLabelKey:  "0",
+			},
+		},
+	}
+	_, err = clientset.CoreV1().PersistentVolumeClaims(ns).Create(ctx, pvc, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	safeToDestroy := false
+	clean := true
+	purged := false
+	configKeys := `[]`
+	executeCommand := func(command string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "status":
+			state := "active+clean"
+			if !clean {
+				state = "active+undersized+degraded"
+			}
+			return `{"pgmap":{"num_pgs":1,"pgs_by_state":[{"state_name":"` + state + `","count":1}]}}`, nil
+		case args[0] == "osd" && args[1] == "safe-to-destroy":
+			if safeToDestroy {
+				return `{"safe_to_destroy":[0]}`, nil
+			}
+			return `{"safe_to_destroy":[]}`, nil
+		case args[0] == "osd" && args[1] == "find":
+			return `{"osd":0,"host":"node1","crush_location":{"host":"node1","root":"default"}}`, nil
+		case args[0] == "osd" && args[1] == "purge":
+			purged = true
+		case args[0] == "osd" && args[1] == "dump":
+			return `{"osds":[{"osd":1,"uuid":"uuid-1","up":1,"in":1},{"osd":2,"uuid":"uuid-2","up":1,"in":1}]}`, nil
+		case args[0] == "config-key" && args[1] == "ls":
+			return configKeys, nil
+		}
+		return "", nil
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: executeCommand,
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			return executeCommand(command, args...)
+		},
+	}
+
+	s := scheme.Scheme
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: ns}}
+	client := ctrlfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cephCluster).Build()
+	context := &clusterd.Context{Clientset: clientset, Client: client, Executor: executor}
+	spec := cephv1.ClusterSpec{
+		Storage: cephv1.StorageScopeSpec{
+			StorageClassDeviceSets: []cephv1.StorageClassDeviceSet{{Name: "set1", Count: 1, Encrypted: true}},
+		},
+	}
+	migrationStatus := func() *cephv1.OSDMigrationStatus {
+		cluster := &cephv1.CephCluster{}
+		err := client.Get(ctx, types.NamespacedName{Name: "testing", Namespace: ns}, cluster)
+		assert.NoError(t, err)
+		if cluster.Status.CephStorage == nil {
+			return nil
+		}
+		return cluster.Status.CephStorage.OSDMigration
+	}
+
+	t.Run("waiting for the confirmation", func(t *testing.T) {
+		c := New(context, clusterInfo, spec, "rook/rook:master")
+		err := c.migrateOSDs()
+		assert.NoError(t, err)
+		assert.False(t, c.MigrationInProgress)
+		assert.True(t, c.unencryptedOSDs.Has(0))
+		status := migrationStatus()
+		assert.Equal(t, 1, status.Pending)
+		assert.Nil(t, status.OSD)
+		assert.Contains(t, status.Message, cephv1.MigrationConfirmation)
+	})
+
+	spec.Storage.Migration.Confirmation = cephv1.MigrationConfirmation
+
+	t.Run("paused", func(t *testing.T) {
+		spec.Storage.Migration.Paused = true
+		defer func() { spec.Storage.Migration.Paused = false }()
+		c := New(context, clusterInfo, spec, "rook/rook:master")
+		err := c.migrateOSDs()
+		assert.NoError(t, err)
+		assert.False(t, c.MigrationInProgress)
+		assert.Equal(t, "the migration is paused", migrationStatus().Message)
+	})
+
+	t.Run("waiting for clean pgs before draining", func(t *testing.T) {
+		clean = false
+		defer func() { clean = true }()
+		c := New(context, clusterInfo, spec, "rook/rook:master")
+		err := c.migrateOSDs()
+		assert.NoError(t, err)
+		assert.True(t, c.MigrationInProgress)
+		status := migrationStatus()
+		assert.Nil(t, status.OSD)
+		assert.Contains(t, status.Message, "waiting for the placement groups to be clean")
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			c := New(context, clusterInfo, spec, "rook/rook:master")
+			err := c.migrateOSDs()
+			assert.NoError(t, err)
+			assert.True(t, c.MigrationInProgress)
+			status := migrationStatus()
+			assert.Equal(t, 0, *status.OSD)
+			assert.Equal(t, OSDMigrationPhaseDraining, status.Phase)
+		}
+		_, err := clientset.AppsV1().Deployments(ns).Get(ctx, deploymentName(0), metav1.GetOptions{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("removing", func(t *testing.T) {
+		safeToDestroy = true
+		c := New(context, clusterInfo, spec, "rook/rook:master")
+		err := c.migrateOSDs()
+		assert.NoError(t, err)
+		assert.True(t, c.MigrationInProgress)
+		assert.False(t, c.unencryptedOSDs.Has(0))
+		assert.True(t, purged)
+		status := migrationStatus()
+		assert.Equal(t, 0, status.Pending)
+		assert.Equal(t, OSDMigrationPhaseRecovering, status.Phase)
+
+		_, err = clientset.AppsV1().Deployments(ns).Get(ctx, deploymentName(0), metav1.GetOptions{})
+		assert.Error(t, err)
+		pvcs, err := clientset.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(pvcs.Items))
+	})
+
+	t.Run("recovering", func(t *testing.T) {
+		clean = false
+		c := New(context, clusterInfo, spec, "rook/rook:master")
+		err := c.migrateOSDs()
+		assert.NoError(t, err)
+		assert.True(t, c.MigrationInProgress)
+		assert.Equal(t, OSDMigrationPhaseRecovering, migrationStatus().Phase)
+
+		clean = true
+		c = New(context, clusterInfo, spec, "rook/rook:master")
+		err = c.migrateOSDs()
+		assert.NoError(t, err)
+		assert.False(t, c.MigrationInProgress)
+		status := migrationStatus()
+		assert.Equal(t, 1, status.Migrated)
+		assert.Nil(t, status.OSD)
+		assert.Equal(t, "all the osds are migrated", status.Message)
+	})
+
+	t.Run("encrypted osds are not migrated", func(t *testing.T) {
+		osdDeployment.Name = deploymentName(1)
+		osdDeployment.Labels[OsdIdLabelKey] = "1"
+		osdDeployment.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: blockEncryptionOpenInitContainer}}
+		_, err := clientset.AppsV1().Deployments(ns).Create(ctx, osdDeployment, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		c := New(context, clusterInfo, spec, "rook/rook:master")
+		unencryptedOSDs, err := c.getUnencryptedOSDs()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(unencryptedOSDs))
+	})
+
+	t.Run("osds on hosts reject the confirmation", func(t *testing.T) {
+		hostDeployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      deploymentName(2),
+				Namespace: ns,
+				Labels:    map[string]string{k8sutil.AppAttr: AppName, OsdIdLabelKey: "2"},
+			},
+		}
+		_, err := clientset.AppsV1().Deployments(ns).Create(ctx, hostDeployment, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		rejection := func() *cephv1.Condition {
+			cluster := &cephv1.CephCluster{}
+			assert.NoError(t, client.Get(ctx, types.NamespacedName{Name: "testing", Namespace: ns}, cluster))
+			return cephv1.FindStatusCondition(cluster.Status.Conditions, cephv1.ConditionOSDMigrationRejected)
+		}
+
+		// the osds on hosts are not checked when the storage config does not ask for the encryption
+		c := New(context, clusterInfo, spec, "rook/rook:master")
+		hostOSDs, err := c.getUnencryptedHostOSDs()
+		assert.NoError(t, err)
+		assert.Empty(t, hostOSDs)
+
+		spec.Storage.Config = map[string]string{"encryptedDevice": "true"}
+		defer func() { spec.Storage.Config = nil }()
+		c = New(context, clusterInfo, spec, "rook/rook:master")
+		err = c.migrateOSDs()
+		assert.NoError(t, err)
+		assert.False(t, c.MigrationInProgress)
+		assert.Contains(t, migrationStatus().Message, "the confirmation is rejected")
+		condition := rejection()
+		assert.Equal(t, corev1.ConditionTrue, condition.Status)
+		assert.Equal(t, cephv1.OSDMigrationHostOSDsReason, condition.Reason)
+		assert.Contains(t, condition.Message, "[2]")
+
+		// the rejection is cleared once the osd on the host is encrypted
+		configKeys = `["dm-crypt/osd/uuid-2/luks"]`
+		c = New(context, clusterInfo, spec, "rook/rook:master")
+		err = c.migrateOSDs()
+		assert.NoError(t, err)
+		assert.Equal(t, corev1.ConditionFalse, rejection().Status)
+	})
+}
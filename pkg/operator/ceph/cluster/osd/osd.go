@@ -77,6 +77,10 @@ type Cluster struct {
 	ValidStorage cephv1.StorageScopeSpec // valid subset of `Storage`, computed at runtime
 	kv           *k8sutil.ConfigMapKVStore
 	deviceSets   []deviceSet
+	// the OSDs on PVC provisioned before their device set was encrypted, until they are migrated
+	unencryptedOSDs sets.Int
+	// MigrationInProgress is whether the migration of the OSDs is waiting for its next step
+	MigrationInProgress bool
 }
 
 // New creates an instance of the OSD manager
@@ -189,6 +193,12 @@ func (c *Cluster) Start() error {
 	}
 	logger.Infof("wait timeout for healthy OSDs during upgrade or restart is %q", c.clusterInfo.OsdUpgradeTimeout)
 
+	// migrate the OSDs provisioned before their device set was encrypted, before provisioning the
+	// new PVCs of the migrated OSD
+	if err := c.migrateOSDs(); err != nil {
+		return errors.Wrap(err, "failed to migrate the osds")
+	}
+
 	// prepare for updating existing OSDs
 	updateQueue, deployments, err := c.getOSDUpdateInfo(errs)
 	if err != nil {
@@ -234,6 +244,9 @@ func (c *Cluster) Start() error {
 	// The following block is used to apply any command(s) required by an upgrade
 	c.applyUpgradeOSDFunctionality()
 
+	// Report the KMS storing the keys of the encrypted OSDs
+	c.updateKMSStatus()
+
 	logger.Infof("finished running OSDs in namespace %q", namespace)
 	return nil
 }
@@ -284,6 +297,11 @@ func deploymentOnPVC(c *Cluster, osd OSDInfo, pvcName string, config *provisionC
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to generate config for %s", osdLongName)
 	}
+	// the OSDs provisioned before their device set was encrypted keep their unencrypted device
+	// until they are migrated
+	if c.unencryptedOSDs.Has(osd.ID) {
+		osdProps.encrypted = false
+	}
 
 	d, err := c.makeDeployment(osdProps, osd, config)
 	if err != nil {
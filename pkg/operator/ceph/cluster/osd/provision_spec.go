@@ -51,6 +51,7 @@ func (c *Cluster) makeJob(osdProps osdProperties, provisionConfig *provisionConf
 			podSpec.Spec.InitContainers = append(podSpec.Spec.InitContainers, c.getPVCWalInitContainer("/wal", osdProps))
 		}
 	}
+	controller.ApplySecurityProfile(c.spec.Security.Profiles, cephv1.KeyOSDPrepare, &podSpec.ObjectMeta, &podSpec.Spec)
 
 	job := &batch.Job{
 		ObjectMeta: metav1.ObjectMeta{
@@ -120,10 +121,8 @@ func (c *Cluster) provisionPodTemplateSpec(osdProps osdProperties, restart v1.Re
 		if osdProps.encrypted {
 			// If a KMS is configured we populate
 			if c.spec.Security.KeyManagementService.IsEnabled() {
-				if c.spec.Security.KeyManagementService.IsVaultKMS() {
-					volumeTLS, _ := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails, "")
-					volumes = append(volumes, volumeTLS)
-				}
+				kmsVolumes, _ := kms.VolumesAndMounts(&c.spec.Security.KeyManagementService)
+				volumes = append(volumes, kmsVolumes...)
 			}
 		}
 	}
@@ -278,10 +277,8 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 		if osdProps.encrypted {
 			// If a KMS is configured we populate volume mounts and env variables
 			if c.spec.Security.KeyManagementService.IsEnabled() {
-				if c.spec.Security.KeyManagementService.IsVaultKMS() {
-					_, volumeMountsTLS := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails, "")
-					volumeMounts = append(volumeMounts, volumeMountsTLS)
-				}
+				_, kmsVolumeMounts := kms.VolumesAndMounts(&c.spec.Security.KeyManagementService)
+				volumeMounts = append(volumeMounts, kmsVolumeMounts...)
 				envVars = append(envVars, kms.ConfigToEnvVar(c.spec)...)
 			} else {
 				envVars = append(envVars, cephVolumeRawEncryptedEnvVarFromSecret(osdProps))
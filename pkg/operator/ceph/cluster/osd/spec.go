@@ -292,12 +292,11 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 		if osdProps.encrypted && osd.CVMode == "raw" {
 			encryptedVol, _ := c.getEncryptionVolume(osdProps)
 			volumes = append(volumes, encryptedVol)
-			// We don't need to pass the Volume with projection for TLS when TLS is not enabled
-			// Somehow when this happens and we try to update a deployment spec it fails with:
-			//  ValidationError(Pod.spec.volumes[7].projected): missing required field "sources"
-			if c.spec.Security.KeyManagementService.IsEnabled() && c.spec.Security.KeyManagementService.IsTLSEnabled() {
-				encryptedVol, _ := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails, "")
-				volumes = append(volumes, encryptedVol)
+			// The KMS volumes, e.g. the Vault TLS secrets, are only needed by the init container
+			// fetching the KEK
+			if c.spec.Security.KeyManagementService.IsEnabled() {
+				kmsVolumes, _ := kms.VolumesAndMounts(&c.spec.Security.KeyManagementService)
+				volumes = append(volumes, kmsVolumes...)
 			}
 		}
 	}
@@ -626,6 +625,7 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	cephv1.GetOSDAnnotations(c.spec.Annotations).ApplyToObjectMeta(&deployment.Spec.Template.ObjectMeta)
 	cephv1.GetOSDLabels(c.spec.Labels).ApplyToObjectMeta(&deployment.ObjectMeta)
 	cephv1.GetOSDLabels(c.spec.Labels).ApplyToObjectMeta(&deployment.Spec.Template.ObjectMeta)
+	controller.ApplySecurityProfile(c.spec.Security.Profiles, cephv1.KeyOSD, &deployment.Spec.Template.ObjectMeta, &deployment.Spec.Template.Spec)
 	controller.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, deployment)
 	controller.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, deployment)
 	err := c.clusterInfo.OwnerInfo.SetControllerReference(deployment)
@@ -897,13 +897,9 @@ func (c *Cluster) getPVCEncryptionOpenInitContainerActivate(mountPath string, os
 		_, volMount := c.getEncryptionVolume(osdProps)
 		getKEKFromKMSContainer.VolumeMounts = append(getKEKFromKMSContainer.VolumeMounts, volMount)
 
-		if c.spec.Security.KeyManagementService.IsVaultKMS() {
-			// Now let's see if there is a TLS config we need to mount as well
-			if c.spec.Security.KeyManagementService.IsTLSEnabled() {
-				_, vaultVolMount := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails, "")
-				getKEKFromKMSContainer.VolumeMounts = append(getKEKFromKMSContainer.VolumeMounts, vaultVolMount)
-			}
-		}
+		// Now let's see if there is a KMS config we need to mount as well
+		_, kmsVolumeMounts := kms.VolumesAndMounts(&c.spec.Security.KeyManagementService)
+		getKEKFromKMSContainer.VolumeMounts = append(getKEKFromKMSContainer.VolumeMounts, kmsVolumeMounts...)
 		// Add the container to the list of containers
 		containers = append(containers, getKEKFromKMSContainer)
 	}
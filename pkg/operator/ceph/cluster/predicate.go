@@ -88,6 +88,100 @@ func predicateForHotPlugCMWatcher(client client.Client) predicate.Funcs {
 	}
 }
 
+// predicateForBootstrapSecretWatcher is the predicate function to trigger reconcile on the changes of
+// the bootstrap secret of an external cluster, so that its rotated keys are applied immediately
+func predicateForBootstrapSecretWatcher(ctx context.Context, c client.Client) predicate.Funcs {
+	return predicateForSecretDataWatcher(func(obj client.Object) bool {
+		return isBootstrapSecret(ctx, c, obj)
+	})
+}
+
+// predicateForDashboardCertificateWatcher is the predicate function to trigger reconcile on the
+// changes of the certificate secret of the dashboard, so that the renewed certificate is served
+// immediately
+func predicateForDashboardCertificateWatcher(ctx context.Context, c client.Client) predicate.Funcs {
+	return predicateForSecretDataWatcher(func(obj client.Object) bool {
+		return isDashboardCertificateSecret(ctx, c, obj)
+	})
+}
+
+// predicateForSecretDataWatcher is the predicate function to trigger reconcile on the creation of the
+// watched secrets and on the changes of their data
+func predicateForSecretDataWatcher(isWatched func(obj client.Object) bool) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isWatched(e.Object)
+		},
+
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+			if !ok {
+				return false
+			}
+			newSecret, ok := e.ObjectNew.(*corev1.Secret)
+			if !ok {
+				return false
+			}
+			if cmp.Equal(oldSecret.Data, newSecret.Data) {
+				return false
+			}
+			return isWatched(newSecret)
+		},
+
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// isBootstrapSecret informs whether the object is the bootstrap secret of an external cluster
+func isBootstrapSecret(ctx context.Context, c client.Client, obj client.Object) bool {
+	if _, ok := obj.(*corev1.Secret); !ok {
+		return false
+	}
+
+	cephClusters := &cephv1.CephClusterList{}
+	err := c.List(ctx, cephClusters, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		logger.Debugf("failed to list ceph clusters to check secret %q. %v", obj.GetName(), err)
+		return false
+	}
+	for _, cephCluster := range cephClusters.Items {
+		if cephCluster.Spec.External.Enable && cephCluster.Spec.External.BootstrapSecret == obj.GetName() {
+			logger.Infof("bootstrap secret %q of external cluster %q changed", obj.GetName(), cephCluster.Name)
+			return true
+		}
+	}
+	return false
+}
+
+// isDashboardCertificateSecret informs whether the object is the certificate secret of the dashboard
+// of a cluster
+func isDashboardCertificateSecret(ctx context.Context, c client.Client, obj client.Object) bool {
+	if _, ok := obj.(*corev1.Secret); !ok {
+		return false
+	}
+
+	cephClusters := &cephv1.CephClusterList{}
+	err := c.List(ctx, cephClusters, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		logger.Debugf("failed to list ceph clusters to check secret %q. %v", obj.GetName(), err)
+		return false
+	}
+	for _, cephCluster := range cephClusters.Items {
+		dashboard := cephCluster.Spec.Dashboard
+		if dashboard.Enabled && dashboard.SSL && dashboard.CertificateSecretName == obj.GetName() {
+			logger.Infof("dashboard certificate secret %q of cluster %q changed", obj.GetName(), cephCluster.Name)
+			return true
+		}
+	}
+	return false
+}
+
 // isHotPlugCM informs whether the object is the cm for hot-plug disk
 func isHotPlugCM(obj runtime.Object) bool {
 	// If not a ConfigMap, let's not reconcile
@@ -139,9 +233,10 @@ func watchControllerPredicate(ctx context.Context, c client.Client) predicate.Fu
 				isDoNotReconcile := controller.IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", controller.DoNotReconcileLabelName, objNew.Name)
+					controller.RecordSkippedEvent("CephCluster", controller.SkippedEventDoNotReconcile)
 					return false
 				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
+				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer, controller.IgnoreTasks)
 				if diff != "" {
 					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
 
@@ -166,6 +261,7 @@ func watchControllerPredicate(ctx context.Context, c client.Client) predicate.Fu
 				} else if objOld.GetGeneration() != objNew.GetGeneration() {
 					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
 				}
+				controller.RecordSkippedEvent("CephCluster", controller.SkippedEventUnchanged)
 			}
 
 			return false
@@ -17,11 +17,15 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestIsHotPlugCM(t *testing.T) {
@@ -40,3 +44,50 @@ func TestIsHotPlugCM(t *testing.T) {
 	cm.Labels["app"] = "rook-discover"
 	assert.True(t, isHotPlugCM(cm))
 }
+
+func TestIsBootstrapSecret(t *testing.T) {
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "ns"},
+		Spec: cephv1.ClusterSpec{
+			External: cephv1.ExternalSpec{Enable: true, BootstrapSecret: "bootstrap"},
+		},
+	}
+	scheme := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(cephCluster).Build()
+	ctx := context.TODO()
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "bootstrap", Namespace: "ns"}}
+	assert.True(t, isBootstrapSecret(ctx, c, secret))
+
+	secret.Namespace = "other"
+	assert.False(t, isBootstrapSecret(ctx, c, secret))
+
+	secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "ns"}}
+	assert.False(t, isBootstrapSecret(ctx, c, secret))
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "bootstrap", Namespace: "ns"}}
+	assert.False(t, isBootstrapSecret(ctx, c, cm))
+}
+
+func TestIsDashboardCertificateSecret(t *testing.T) {
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"},
+		Spec: cephv1.ClusterSpec{
+			Dashboard: cephv1.DashboardSpec{Enabled: true, SSL: true, CertificateSecretName: "dashboard-tls"},
+		},
+	}
+	scheme := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(cephCluster).Build()
+	ctx := context.TODO()
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "dashboard-tls", Namespace: "ns"}}
+	assert.True(t, isDashboardCertificateSecret(ctx, c, secret))
+
+	secret.Namespace = "other"
+	assert.False(t, isDashboardCertificateSecret(ctx, c, secret))
+
+	secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "ns"}}
+	assert.False(t, isDashboardCertificateSecret(ctx, c, secret))
+}
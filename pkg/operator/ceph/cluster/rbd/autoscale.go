@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultImagesPerDaemon   = 100
+	defaultAutoscaleInterval = 5 * time.Minute
+)
+
+// autoscaleStatus is the number of daemons chosen by the autoscaling and the numbers of mirrored
+// images and pools it was computed from
+type autoscaleStatus struct {
+	count          int
+	mirroredPools  int
+	mirroredImages int
+}
+
+// desiredCount returns the number of rbd-mirror daemons to run. Without autoscaling, it is the count
+// of the spec. With autoscaling, it is the number of daemons needed for the mirrored images and
+// pools, within the count and the max count of the spec. The daemons are scaled down one at a time,
+// since the images replayed by a stopped daemon are all restarted by the other daemons. Only the
+// images of the pools assigned to the daemons are counted.
+func (r *ReconcileCephRBDMirror) desiredCount(cephRBDMirror *cephv1.CephRBDMirror, assignments []poolAssignment) (*autoscaleStatus, error) {
+	spec := &cephRBDMirror.Spec
+	if spec.Autoscale == nil {
+		return &autoscaleStatus{count: spec.Count}, nil
+	}
+
+	var pools, images int
+	var err error
+	if len(assignments) == 0 {
+		pools, images, err = r.countMirroredImages(cephRBDMirror.Namespace)
+	} else {
+		pools, images = r.countAssignedImages(assignments)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	current := spec.Count
+	if cephRBDMirror.Status != nil && cephRBDMirror.Status.Count > 0 {
+		current = cephRBDMirror.Status.Count
+	}
+	count := scaledCount(spec.Count, spec.Autoscale, current, pools, images)
+	if count != current {
+		logger.Infof("scaling rbd-mirror %q from %d to %d daemons for %d mirrored images in %d pools", cephRBDMirror.Name, current, count, images, pools)
+	}
+	return &autoscaleStatus{count: count, mirroredPools: pools, mirroredImages: images}, nil
+}
+
+func scaledCount(minCount int, autoscale *cephv1.RBDMirrorAutoscaleSpec, current, pools, images int) int {
+	imagesPerDaemon := autoscale.ImagesPerDaemon
+	if imagesPerDaemon == 0 {
+		imagesPerDaemon = defaultImagesPerDaemon
+	}
+	count := divRoundUp(images, imagesPerDaemon)
+	if autoscale.PoolsPerDaemon > 0 {
+		if byPools := divRoundUp(pools, autoscale.PoolsPerDaemon); byPools > count {
+			count = byPools
+		}
+	}
+
+	if count < current {
+		count = current - 1
+	}
+	if count < minCount {
+		count = minCount
+	}
+	if count > autoscale.MaxCount {
+		count = autoscale.MaxCount
+	}
+	return count
+}
+
+// countMirroredImages returns the number of mirrored pools of the cluster and of their mirrored images
+func (r *ReconcileCephRBDMirror) countMirroredImages(namespace string) (int, int, error) {
+	pools := &cephv1.CephBlockPoolList{}
+	err := r.client.List(r.opManagerContext, pools, client.InNamespace(namespace))
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "failed to list the pools in namespace %q", namespace)
+	}
+
+	mirroredPools := 0
+	mirroredImages := 0
+	for _, pool := range pools.Items {
+		if !pool.Spec.Mirroring.Enabled {
+			continue
+		}
+		mirroredPools++
+
+		// the name of the ceph pool may be overridden in the spec
+		poolName := pool.Name
+		if pool.Spec.Name != "" {
+			poolName = pool.Spec.Name
+		}
+		mirroredImages += r.countPoolImages(poolName)
+	}
+	return mirroredPools, mirroredImages, nil
+}
+
+// countAssignedImages returns the number of pools assigned to the daemons and of their mirrored images
+func (r *ReconcileCephRBDMirror) countAssignedImages(assignments []poolAssignment) (int, int) {
+	mirroredImages := 0
+	for _, assignment := range assignments {
+		poolName := assignment.cephName
+		if assignment.namespace != "" {
+			poolName = fmt.Sprintf("%s/%s", poolName, assignment.namespace)
+		}
+		mirroredImages += r.countPoolImages(poolName)
+	}
+	return len(assignments), mirroredImages
+}
+
+// countPoolImages returns the number of mirrored images of a pool, or of a "<pool>/<namespace>"
+func (r *ReconcileCephRBDMirror) countPoolImages(poolName string) int {
+	mirrorStatus, err := cephclient.GetPoolMirroringStatus(r.context, r.clusterInfo, poolName)
+	if err != nil {
+		// the pool may not be mirrored yet
+		logger.Warningf("failed to count the mirrored images of pool %q. %v", poolName, err)
+		return 0
+	}
+	if mirrorStatus.Summary == nil {
+		return 0
+	}
+	states := mirrorStatus.Summary.States
+	return states.StartingReplay + states.Replaying + states.Syncing + states.StopReplaying + states.Stopped + states.Unknown + states.Error
+}
+
+func divRoundUp(n, d int) int {
+	return (n + d - 1) / d
+}
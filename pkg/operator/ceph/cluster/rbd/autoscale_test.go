@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScaledCount(t *testing.T) {
+	autoscale := &cephv1.RBDMirrorAutoscaleSpec{MaxCount: 5}
+
+	// no image
+	assert.Equal(t, 1, scaledCount(1, autoscale, 1, 0, 0))
+	// 100 images per daemon by default
+	assert.Equal(t, 1, scaledCount(1, autoscale, 1, 1, 100))
+	assert.Equal(t, 2, scaledCount(1, autoscale, 1, 1, 101))
+	// within the max count
+	assert.Equal(t, 5, scaledCount(1, autoscale, 1, 1, 1000))
+	// within the count
+	assert.Equal(t, 2, scaledCount(2, autoscale, 2, 1, 10))
+	// scaled down one daemon at a time
+	assert.Equal(t, 4, scaledCount(1, autoscale, 5, 1, 10))
+
+	autoscale.ImagesPerDaemon = 10
+	assert.Equal(t, 3, scaledCount(1, autoscale, 1, 1, 25))
+
+	// scaled by the pools when they need more daemons than the images
+	autoscale.PoolsPerDaemon = 2
+	assert.Equal(t, 3, scaledCount(1, autoscale, 1, 6, 10))
+	assert.Equal(t, 3, scaledCount(1, autoscale, 1, 1, 25))
+}
+
+func TestDesiredCount(t *testing.T) {
+	pools := []runtime.Object{
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Namespace: "rook-ceph"},
+			Spec:       cephv1.NamedBlockPoolSpec{PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}}},
+		},
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "renamed", Namespace: "rook-ceph"},
+			Spec:       cephv1.NamedBlockPoolSpec{Name: "other", PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}}},
+		},
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "unmirrored", Namespace: "rook-ceph"},
+		},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pools...).Build()
+
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "mirror" && args[1] == "pool" && args[2] == "status" {
+				switch args[3] {
+				case "mirrored":
+					return `{"summary":{"health":"OK","states":{"replaying":120,"syncing":30}}}`, nil
+				case "other":
+					return `{"summary":{"health":"WARNING","states":{"starting_replay":10,"error":5}}}`, nil
+				}
+			}
+			return "", errors.New("unknown command")
+		},
+	}
+	r := &ReconcileCephRBDMirror{
+		client:           cl,
+		scheme:           s,
+		context:          &clusterd.Context{Executor: executor},
+		clusterInfo:      cephclient.AdminTestClusterInfo("rook-ceph"),
+		opManagerContext: context.TODO(),
+	}
+	rbdMirror := &cephv1.CephRBDMirror{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-rbd-mirror", Namespace: "rook-ceph"},
+		Spec:       cephv1.RBDMirroringSpec{Count: 1},
+	}
+
+	t.Run("no autoscaling", func(t *testing.T) {
+		status, err := r.desiredCount(rbdMirror, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, status.count)
+		assert.Equal(t, 0, status.mirroredImages)
+	})
+
+	t.Run("scale up", func(t *testing.T) {
+		rbdMirror.Spec.Autoscale = &cephv1.RBDMirrorAutoscaleSpec{MaxCount: 3}
+		status, err := r.desiredCount(rbdMirror, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, status.count)
+		assert.Equal(t, 2, status.mirroredPools)
+		assert.Equal(t, 165, status.mirroredImages)
+	})
+
+	t.Run("scale down from the current count", func(t *testing.T) {
+		rbdMirror.Spec.Autoscale.ImagesPerDaemon = 1000
+		rbdMirror.Status = &cephv1.RBDMirrorStatus{Count: 3}
+		status, err := r.desiredCount(rbdMirror, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, status.count)
+	})
+
+	t.Run("only the assigned pools are counted", func(t *testing.T) {
+		rbdMirror.Spec.Pools = []string{"renamed"}
+		assignments, err := r.assignedPools(rbdMirror)
+		assert.NoError(t, err)
+		status, err := r.desiredCount(rbdMirror, assignments)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, status.mirroredPools)
+		assert.Equal(t, 15, status.mirroredImages)
+	})
+}
@@ -37,7 +37,7 @@ const (
 [client.rbd-mirror.%s]
 	key = %s
 	caps mon = "profile rbd-mirror"
-	caps osd = "profile rbd"
+	caps osd = "%s"
 `
 )
 
@@ -46,12 +46,14 @@ type daemonConfig struct {
 	ResourceName string              // the name rook gives to mirror resources in k8s metadata
 	DaemonID     string              // the ID of the Ceph daemon ("a", "b", ...)
 	DataPathMap  *config.DataPathMap // location to store data in container
+	Count        int                 // the number of replicas of the daemon
+	OSDCaps      string              // the osd caps of the daemon, restricted to its pools
 	ownerInfo    *k8sutil.OwnerInfo
 }
 
 func (r *ReconcileCephRBDMirror) generateKeyring(clusterInfo *client.ClusterInfo, daemonConfig *daemonConfig) (string, error) {
 	user := fullDaemonName(daemonConfig.DaemonID)
-	access := []string{"mon", "profile rbd-mirror", "osd", "profile rbd"}
+	access := []string{"mon", "profile rbd-mirror", "osd", daemonConfig.OSDCaps}
 	s := keyring.GetSecretStore(r.context, clusterInfo, daemonConfig.ownerInfo)
 
 	key, err := s.GenerateKey(user, access)
@@ -69,7 +71,7 @@ func (r *ReconcileCephRBDMirror) generateKeyring(clusterInfo *client.ClusterInfo
 		}
 	}
 
-	keyring := fmt.Sprintf(keyringTemplate, daemonConfig.DaemonID, key)
+	keyring := fmt.Sprintf(keyringTemplate, daemonConfig.DaemonID, key, daemonConfig.OSDCaps)
 	return keyring, s.CreateOrUpdate(daemonConfig.ResourceName, keyring)
 }
 
@@ -126,10 +128,24 @@ func (r *ReconcileCephRBDMirror) addPeer(peerSecret string, data map[string][]by
 	return nil
 }
 
-func validateSpec(r *cephv1.RBDMirroringSpec) error {
+// ValidateRBDMirrorSpec validates the rbd mirror settings
+func ValidateRBDMirrorSpec(r *cephv1.RBDMirroringSpec) error {
 	if r.Count == 0 {
 		return errors.New("rbd-mirror count must be at least one")
 	}
+	if r.Autoscale != nil && r.Autoscale.MaxCount < r.Count {
+		return errors.Errorf("rbd-mirror autoscale max count %d must not be lower than the count %d", r.Autoscale.MaxCount, r.Count)
+	}
+	pools := map[string]bool{}
+	for _, entry := range r.Pools {
+		if _, _, err := parsePoolAssignment(entry); err != nil {
+			return err
+		}
+		if pools[entry] {
+			return errors.Errorf("pool %q is listed more than once", entry)
+		}
+		pools[entry] = true
+	}
 
 	return nil
 }
@@ -26,21 +26,44 @@ import (
 func TestValidateSpec(t *testing.T) {
 	// Invalid count
 	r := &cephv1.RBDMirroringSpec{Count: 0}
-	err := validateSpec(r)
+	err := ValidateRBDMirrorSpec(r)
 	assert.Error(t, err)
 
 	// Correct count
 	r.Count = 1
-	err = validateSpec(r)
+	err = ValidateRBDMirrorSpec(r)
 	assert.NoError(t, err)
 
 	// Valid only a single peer
 	r.Peers.SecretNames = append(r.Peers.SecretNames, "foo")
-	err = validateSpec(r)
+	err = ValidateRBDMirrorSpec(r)
 	assert.NoError(t, err)
 
 	// Multiple pools mirroring are supported with the same peer is supported
 	r.Peers.SecretNames = append(r.Peers.SecretNames, "bar")
-	err = validateSpec(r)
+	err = ValidateRBDMirrorSpec(r)
 	assert.NoError(t, err)
+
+	// Max count lower than the count
+	r.Count = 2
+	r.Autoscale = &cephv1.RBDMirrorAutoscaleSpec{MaxCount: 1}
+	err = ValidateRBDMirrorSpec(r)
+	assert.Error(t, err)
+
+	r.Autoscale.MaxCount = 2
+	err = ValidateRBDMirrorSpec(r)
+	assert.NoError(t, err)
+
+	// Pools and namespaces assigned to the daemons
+	r.Pools = []string{"replicapool", "other/tenant-a"}
+	err = ValidateRBDMirrorSpec(r)
+	assert.NoError(t, err)
+
+	r.Pools = []string{"replicapool", "replicapool"}
+	err = ValidateRBDMirrorSpec(r)
+	assert.Error(t, err)
+
+	r.Pools = []string{"other/tenant-a/b"}
+	err = ValidateRBDMirrorSpec(r)
+	assert.Error(t, err)
 }
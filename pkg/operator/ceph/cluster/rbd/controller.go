@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/pkg/errors"
@@ -31,12 +32,14 @@ import (
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -78,6 +81,7 @@ type ReconcileCephRBDMirror struct {
 	peers            map[string]*peerSpec
 	opManagerContext context.Context
 	opConfig         opcontroller.OperatorConfig
+	recorder         record.EventRecorder
 }
 
 // peerSpec represents peer details
@@ -102,12 +106,14 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 		peers:            make(map[string]*peerSpec),
 		opConfig:         opConfig,
 		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephRBDMirror{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephRBDMirror{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -139,26 +145,26 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephRBDMirror) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
+	reconcileResponse, cephRBDMirror, err := r.reconcile(request)
 	if err != nil {
 		r.updateStatus(r.client, request.NamespacedName, k8sutil.FailedStatus)
-		logger.Errorf("failed to reconcile %v", err)
 	}
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephRBDMirror, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileCephRBDMirror) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileCephRBDMirror) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephRBDMirror, error) {
 	// Fetch the cephRBDMirror instance
 	cephRBDMirror := &cephv1.CephRBDMirror{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephRBDMirror)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("cephRBDMirror resource not found. Ignoring since object must be deleted.")
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephRBDMirror, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get cephRBDMirror")
+		return reconcile.Result{}, cephRBDMirror, errors.Wrap(err, "failed to get cephRBDMirror")
 	}
 
 	// The CR was just created, initializing status fields
@@ -167,23 +173,23 @@ func (r *ReconcileCephRBDMirror) reconcile(request reconcile.Request) (reconcile
 	}
 
 	// validate the pool settings
-	if err := validateSpec(&cephRBDMirror.Spec); err != nil {
-		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "invalid rbd-mirror CR %q spec", cephRBDMirror.Name)
+	if err := ValidateRBDMirrorSpec(&cephRBDMirror.Spec); err != nil {
+		return opcontroller.ImmediateRetryResult, cephRBDMirror, errors.Wrapf(err, "invalid rbd-mirror CR %q spec", cephRBDMirror.Name)
 	}
 
 	// Make sure a CephCluster is present otherwise do nothing
 	cephCluster, isReadyToReconcile, _, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
 	if !isReadyToReconcile {
 		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
-		return reconcileResponse, nil
+		return reconcileResponse, cephRBDMirror, nil
 	}
 	r.cephClusterSpec = &cephCluster.Spec
 
 	// Populate clusterInfo
 	// Always populate it during each reconcile
-	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephRBDMirror", request.Namespace, request.Name), request.NamespacedName.Namespace)
 	if err != nil {
-		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to populate cluster info")
+		return opcontroller.ImmediateRetryResult, cephRBDMirror, errors.Wrap(err, "failed to populate cluster info")
 	}
 
 	// Detect desired CephCluster version
@@ -200,9 +206,9 @@ func (r *ReconcileCephRBDMirror) reconcile(request reconcile.Request) (reconcile
 	if err != nil {
 		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
 			logger.Info(opcontroller.OperatorNotInitializedMessage)
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephRBDMirror, nil
 		}
-		return reconcile.Result{}, errors.Wrap(err, "failed to detect running and desired ceph version")
+		return reconcile.Result{}, cephRBDMirror, errors.Wrap(err, "failed to detect running and desired ceph version")
 	}
 
 	// If the version of the Ceph monitor differs from the CephCluster CR image version we assume
@@ -211,7 +217,7 @@ func (r *ReconcileCephRBDMirror) reconcile(request reconcile.Request) (reconcile
 	if !reflect.DeepEqual(*runningCephVersion, *desiredCephVersion) {
 		// Upgrade is in progress, let's wait for the mons to be done
 		return opcontroller.WaitForRequeueIfCephClusterIsUpgrading,
-			opcontroller.ErrorCephUpgradingRequeue(desiredCephVersion, runningCephVersion)
+			cephRBDMirror, opcontroller.ErrorCephUpgradingRequeue(desiredCephVersion, runningCephVersion)
 	}
 	r.clusterInfo.CephVersion = *runningCephVersion
 
@@ -219,26 +225,52 @@ func (r *ReconcileCephRBDMirror) reconcile(request reconcile.Request) (reconcile
 	logger.Debug("reconciling ceph rbd mirror peers addition")
 	reconcileResponse, err = r.reconcileAddBoostrapPeer(cephRBDMirror, request.NamespacedName)
 	if err != nil {
-		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to add ceph rbd mirror peer")
+		return opcontroller.ImmediateRetryResult, cephRBDMirror, errors.Wrap(err, "failed to add ceph rbd mirror peer")
+	}
+
+	// Assign the pools to the daemons
+	err = r.validatePoolAssignment(cephRBDMirror)
+	if err != nil {
+		return reconcile.Result{}, cephRBDMirror, errors.Wrapf(err, "invalid rbd-mirror CR %q pools", cephRBDMirror.Name)
+	}
+	assignments, err := r.assignedPools(cephRBDMirror)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, cephRBDMirror, errors.Wrap(err, "failed to get the pools of the rbd mirror daemons")
+	}
+
+	// Scale the daemons with the mirrored images
+	autoscale, err := r.desiredCount(cephRBDMirror, assignments)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, cephRBDMirror, errors.Wrap(err, "failed to compute the number of rbd mirror daemons")
 	}
 
 	// CREATE/UPDATE
 	logger.Debug("reconciling ceph rbd mirror deployments")
-	reconcileResponse, err = r.reconcileCreateCephRBDMirror(cephRBDMirror)
+	reconcileResponse, err = r.reconcileCreateCephRBDMirror(cephRBDMirror, autoscale.count, assignments)
 	if err != nil {
-		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to create ceph rbd mirror deployments")
+		return opcontroller.ImmediateRetryResult, cephRBDMirror, errors.Wrap(err, "failed to create ceph rbd mirror deployments")
 	}
 
 	// Set Ready status, we are done reconciling
 	r.updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
+	if cephRBDMirror.Spec.Autoscale == nil {
+		// Return and do not requeue
+		logger.Debug("done reconciling ceph rbd mirror")
+		return reconcile.Result{}, cephRBDMirror, nil
+	}
 
-	// Return and do not requeue
-	logger.Debug("done reconciling ceph rbd mirror")
-	return reconcile.Result{}, nil
+	// Requeue to scale the daemons when the number of mirrored images changes
+	r.updateAutoscaleStatus(r.client, request.NamespacedName, autoscale)
+	interval := defaultAutoscaleInterval
+	if cephRBDMirror.Spec.Autoscale.Interval != nil {
+		interval = cephRBDMirror.Spec.Autoscale.Interval.Duration
+	}
+	logger.Debugf("done reconciling ceph rbd mirror, checking the mirrored images again in %s", interval.String())
+	return reconcile.Result{RequeueAfter: interval}, cephRBDMirror, nil
 
 }
 
-func (r *ReconcileCephRBDMirror) reconcileCreateCephRBDMirror(cephRBDMirror *cephv1.CephRBDMirror) (reconcile.Result, error) {
+func (r *ReconcileCephRBDMirror) reconcileCreateCephRBDMirror(cephRBDMirror *cephv1.CephRBDMirror, count int, assignments []poolAssignment) (reconcile.Result, error) {
 	if r.cephClusterSpec.External.Enable {
 		_, err := opcontroller.ValidateCephVersionsBetweenLocalAndExternalClusters(r.context, r.clusterInfo)
 		if err != nil {
@@ -248,7 +280,7 @@ func (r *ReconcileCephRBDMirror) reconcileCreateCephRBDMirror(cephRBDMirror *cep
 		}
 	}
 
-	err := r.start(cephRBDMirror)
+	err := r.start(cephRBDMirror, count, assignments)
 	if err != nil {
 		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to start rbd mirror")
 	}
@@ -269,14 +301,55 @@ func (r *ReconcileCephRBDMirror) updateStatus(client client.Client, name types.N
 		return
 	}
 
-	if rbdMirror.Status == nil {
-		rbdMirror.Status = &cephv1.Status{}
+	replicas, selector, scaleErr := opcontroller.ScaleStatus(r.opManagerContext, client, rbdMirror.Namespace, opcontroller.AppLabels(AppName, rbdMirror.Namespace))
+	if scaleErr != nil {
+		// the replicas are updated again by the next reconcile
+		logger.Warningf("failed to get the replicas of rbd mirror %q. %v", name, scaleErr)
 	}
-
-	rbdMirror.Status.Phase = status
-	if err := reporting.UpdateStatus(client, rbdMirror); err != nil {
+	err = reporting.PatchStatus(client, rbdMirror, func() bool {
+		if rbdMirror.Status == nil {
+			rbdMirror.Status = &cephv1.RBDMirrorStatus{}
+		}
+		rbdMirror.Status.Phase = status
+		if scaleErr == nil {
+			rbdMirror.Status.Replicas = replicas
+		}
+		rbdMirror.Status.Selector = selector
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set rbd mirror %q status to %q. %v", rbdMirror.Name, status, err)
 		return
 	}
 	logger.Debugf("rbd mirror %q status updated to %q", name, status)
 }
+
+// updateAutoscaleStatus records the number of daemons chosen by the autoscaling
+func (r *ReconcileCephRBDMirror) updateAutoscaleStatus(client client.Client, name types.NamespacedName, autoscale *autoscaleStatus) {
+	rbdMirror := &cephv1.CephRBDMirror{}
+	err := client.Get(r.opManagerContext, name, rbdMirror)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephRBDMirror resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve rbd mirror %q to update the autoscale status. %v", name, err)
+		return
+	}
+
+	err = reporting.PatchStatus(client, rbdMirror, func() bool {
+		if rbdMirror.Status == nil {
+			rbdMirror.Status = &cephv1.RBDMirrorStatus{}
+		}
+		rbdMirror.Status.Count = autoscale.count
+		rbdMirror.Status.MirroredPools = autoscale.mirroredPools
+		rbdMirror.Status.MirroredImages = autoscale.mirroredImages
+		rbdMirror.Status.LastChecked = time.Now().UTC().Format(time.RFC3339)
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set rbd mirror %q autoscale status. %v", rbdMirror.Name, err)
+		return
+	}
+	logger.Debugf("rbd mirror %q autoscale status updated to %d daemons", name, autoscale.count)
+}
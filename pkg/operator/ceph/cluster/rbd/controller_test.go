@@ -37,6 +37,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -143,7 +144,7 @@ func TestCephRBDMirrorController(t *testing.T) {
 		cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 
 		// Create a ReconcileCephRBDMirror object with the scheme and fake client.
-		r := &ReconcileCephRBDMirror{client: cl, scheme: s, context: c, opManagerContext: ctx}
+		r := &ReconcileCephRBDMirror{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
 		res, err := r.Reconcile(ctx, req)
 		assert.NoError(t, err)
 		assert.True(t, res.Requeue)
@@ -154,7 +155,7 @@ func TestCephRBDMirrorController(t *testing.T) {
 		// Create a fake client to mock API calls.
 		cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 		// Create a ReconcileCephRBDMirror object with the scheme and fake client.
-		r := &ReconcileCephRBDMirror{client: cl, scheme: s, context: c, opManagerContext: ctx}
+		r := &ReconcileCephRBDMirror{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
 		res, err := r.Reconcile(ctx, req)
 		assert.NoError(t, err)
 		assert.True(t, res.Requeue)
@@ -187,6 +188,7 @@ func TestCephRBDMirrorController(t *testing.T) {
 
 		// Create a ReconcileCephRBDMirror object with the scheme and fake client.
 		r := &ReconcileCephRBDMirror{
+			recorder:         &record.FakeRecorder{},
 			client:           cl,
 			scheme:           s,
 			context:          c,
@@ -207,6 +209,7 @@ func TestCephRBDMirrorController(t *testing.T) {
 		// Create a fake client to mock API calls.
 		cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 		r := &ReconcileCephRBDMirror{
+			recorder:         &record.FakeRecorder{},
 			client:           cl,
 			scheme:           s,
 			context:          c,
@@ -42,22 +42,24 @@ const (
 var updateDeploymentAndWait = mon.UpdateCephDeploymentAndWait
 
 // Start begins the process of running rbd mirroring daemons.
-func (r *ReconcileCephRBDMirror) start(cephRBDMirror *cephv1.CephRBDMirror) error {
+func (r *ReconcileCephRBDMirror) start(cephRBDMirror *cephv1.CephRBDMirror, count int, assignments []poolAssignment) error {
 	// Validate pod's memory if specified
 	err := controller.CheckPodMemory(cephv1.ResourcesKeyRBDMirror, cephRBDMirror.Spec.Resources, cephRbdMirrorPodMinimumMemory)
 	if err != nil {
 		return errors.Wrap(err, "error checking pod memory")
 	}
 
-	logger.Infof("configure rbd-mirroring with %d workers", cephRBDMirror.Spec.Count)
+	logger.Infof("configure rbd-mirroring with %d workers", count)
 
 	ownerInfo := k8sutil.NewOwnerInfo(cephRBDMirror, r.scheme)
-	daemonID := k8sutil.IndexToName(0)
+	daemonID := daemonID(cephRBDMirror)
 	resourceName := fmt.Sprintf("%s-%s", AppName, daemonID)
 	daemonConf := &daemonConfig{
 		DaemonID:     daemonID,
 		ResourceName: resourceName,
 		DataPathMap:  config.NewDatalessDaemonDataPathMap(cephRBDMirror.Namespace, r.cephClusterSpec.DataDirHostPath),
+		Count:        count,
+		OSDCaps:      osdCaps(assignments),
 		ownerInfo:    ownerInfo,
 	}
 
@@ -104,5 +106,31 @@ func (r *ReconcileCephRBDMirror) start(cephRBDMirror *cephv1.CephRBDMirror) erro
 	}
 
 	logger.Infof("%q deployment started", resourceName)
+
+	// The daemon ID changes when the pools are assigned to the daemons or unassigned
+	err = r.removeStaleDeployments(cephRBDMirror, resourceName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove the stale deployments of rbd-mirror %q", cephRBDMirror.Name)
+	}
+	return nil
+}
+
+// removeStaleDeployments removes the rbd-mirror deployments of the CephRBDMirror with another daemon ID
+func (r *ReconcileCephRBDMirror) removeStaleDeployments(cephRBDMirror *cephv1.CephRBDMirror, resourceName string) error {
+	deployments, err := r.context.Clientset.AppsV1().Deployments(cephRBDMirror.Namespace).List(r.opManagerContext, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the rbd-mirror deployments")
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if d.Name == resourceName || !metav1.IsControlledBy(d, cephRBDMirror) {
+			continue
+		}
+		logger.Infof("removing stale rbd-mirror deployment %q", d.Name)
+		err = r.context.Clientset.AppsV1().Deployments(cephRBDMirror.Namespace).Delete(r.opManagerContext, d.Name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete rbd-mirror deployment %q", d.Name)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultDaemonID is the ID of the daemons of the CephRBDMirror replicating all the mirrored pools
+var defaultDaemonID = k8sutil.IndexToName(0)
+
+// poolAssignment is a pool, or a RADOS namespace of a pool, assigned to the daemons of a CephRBDMirror
+type poolAssignment struct {
+	cephName  string // the name of the ceph pool
+	namespace string // the RADOS namespace, all the namespaces of the pool when empty
+}
+
+// parsePoolAssignment splits a "<pool>[/<namespace>]" entry of the spec
+func parsePoolAssignment(entry string) (string, string, error) {
+	parts := strings.Split(entry, "/")
+	if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+		return "", "", errors.Errorf("invalid pool %q, expected \"<pool>\" or \"<pool>/<namespace>\"", entry)
+	}
+	if len(parts) == 1 {
+		return parts[0], "", nil
+	}
+	return parts[0], parts[1], nil
+}
+
+// daemonID returns the ID of the daemons of a CephRBDMirror. The daemons replicating only some
+// pools have their own cephx user with the caps of their pools, named after the CephRBDMirror.
+func daemonID(cephRBDMirror *cephv1.CephRBDMirror) string {
+	if len(cephRBDMirror.Spec.Pools) == 0 {
+		return defaultDaemonID
+	}
+	return cephRBDMirror.Name
+}
+
+// assignedPools returns the pools replicated by the daemons of a CephRBDMirror, with the name of
+// their ceph pool. A nil list is returned when all the pools are replicated.
+func (r *ReconcileCephRBDMirror) assignedPools(cephRBDMirror *cephv1.CephRBDMirror) ([]poolAssignment, error) {
+	var assignments []poolAssignment
+	for _, entry := range cephRBDMirror.Spec.Pools {
+		poolName, namespace, err := parsePoolAssignment(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		// the name of the ceph pool may be overridden in the spec of the CephBlockPool
+		cephName := poolName
+		pool := &cephv1.CephBlockPool{}
+		err = r.client.Get(r.opManagerContext, types.NamespacedName{Name: poolName, Namespace: cephRBDMirror.Namespace}, pool)
+		if err != nil {
+			if !kerrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to get ceph block pool %q", poolName)
+			}
+			logger.Warningf("ceph block pool %q assigned to rbd-mirror %q not found, using it as the ceph pool name", poolName, cephRBDMirror.Name)
+		} else if pool.Spec.Name != "" {
+			cephName = pool.Spec.Name
+		}
+		assignments = append(assignments, poolAssignment{cephName: cephName, namespace: namespace})
+	}
+	return assignments, nil
+}
+
+// validatePoolAssignment checks that the pools of a CephRBDMirror are not assigned to the daemons
+// of another CephRBDMirror
+func (r *ReconcileCephRBDMirror) validatePoolAssignment(cephRBDMirror *cephv1.CephRBDMirror) error {
+	if len(cephRBDMirror.Spec.Pools) == 0 {
+		return nil
+	}
+
+	rbdMirrors := &cephv1.CephRBDMirrorList{}
+	err := r.client.List(r.opManagerContext, rbdMirrors, client.InNamespace(cephRBDMirror.Namespace))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the rbd mirrors in namespace %q", cephRBDMirror.Namespace)
+	}
+
+	for _, entry := range cephRBDMirror.Spec.Pools {
+		poolName, namespace, _ := parsePoolAssignment(entry)
+		for _, other := range rbdMirrors.Items {
+			if other.Name == cephRBDMirror.Name {
+				continue
+			}
+			for _, otherEntry := range other.Spec.Pools {
+				otherPoolName, otherNamespace, _ := parsePoolAssignment(otherEntry)
+				// a pool overlaps with all its namespaces
+				if poolName == otherPoolName && (namespace == "" || otherNamespace == "" || namespace == otherNamespace) {
+					return errors.Errorf("pool %q is already assigned to rbd-mirror %q with %q", entry, other.Name, otherEntry)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// osdCaps returns the osd caps of the cephx user of the daemons, restricted to the assigned pools
+func osdCaps(assignments []poolAssignment) string {
+	if len(assignments) == 0 {
+		return "profile rbd"
+	}
+
+	caps := []string{}
+	for _, assignment := range assignments {
+		c := fmt.Sprintf("profile rbd pool=%s", assignment.cephName)
+		if assignment.namespace != "" {
+			c = fmt.Sprintf("%s namespace=%s", c, assignment.namespace)
+		}
+		caps = append(caps, c)
+	}
+	return strings.Join(caps, ", ")
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbd
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParsePoolAssignment(t *testing.T) {
+	pool, namespace, err := parsePoolAssignment("replicapool")
+	assert.NoError(t, err)
+	assert.Equal(t, "replicapool", pool)
+	assert.Equal(t, "", namespace)
+
+	pool, namespace, err = parsePoolAssignment("replicapool/tenant-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "replicapool", pool)
+	assert.Equal(t, "tenant-a", namespace)
+
+	for _, entry := range []string{"", "/tenant-a", "replicapool/", "a/b/c"} {
+		_, _, err = parsePoolAssignment(entry)
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestOSDCaps(t *testing.T) {
+	assert.Equal(t, "profile rbd", osdCaps(nil))
+	assert.Equal(t, "profile rbd pool=replicapool, profile rbd pool=other namespace=tenant-a", osdCaps([]poolAssignment{
+		{cephName: "replicapool"},
+		{cephName: "other", namespace: "tenant-a"},
+	}))
+}
+
+func TestPoolAssignment(t *testing.T) {
+	s := scheme.Scheme
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "renamed", Namespace: "rook-ceph"},
+			Spec:       cephv1.NamedBlockPoolSpec{Name: "other"},
+		},
+		&cephv1.CephRBDMirror{
+			ObjectMeta: metav1.ObjectMeta{Name: "heavy", Namespace: "rook-ceph"},
+			Spec:       cephv1.RBDMirroringSpec{Count: 1, Pools: []string{"heavypool", "shared/tenant-a"}},
+		},
+	).Build()
+	r := &ReconcileCephRBDMirror{client: cl, scheme: s, opManagerContext: context.TODO()}
+	rbdMirror := &cephv1.CephRBDMirror{
+		ObjectMeta: metav1.ObjectMeta{Name: "light", Namespace: "rook-ceph"},
+		Spec:       cephv1.RBDMirroringSpec{Count: 1},
+	}
+
+	t.Run("all the pools", func(t *testing.T) {
+		assert.Equal(t, "a", daemonID(rbdMirror))
+		assignments, err := r.assignedPools(rbdMirror)
+		assert.NoError(t, err)
+		assert.Nil(t, assignments)
+		assert.NoError(t, r.validatePoolAssignment(rbdMirror))
+	})
+
+	t.Run("assigned pools", func(t *testing.T) {
+		rbdMirror.Spec.Pools = []string{"renamed", "unknown", "shared/tenant-b"}
+		assert.Equal(t, "light", daemonID(rbdMirror))
+		assignments, err := r.assignedPools(rbdMirror)
+		assert.NoError(t, err)
+		assert.Equal(t, []poolAssignment{{cephName: "other"}, {cephName: "unknown"}, {cephName: "shared", namespace: "tenant-b"}}, assignments)
+		assert.NoError(t, r.validatePoolAssignment(rbdMirror))
+	})
+
+	t.Run("pools assigned to another rbd mirror", func(t *testing.T) {
+		rbdMirror.Spec.Pools = []string{"heavypool/tenant-a"}
+		assert.Error(t, r.validatePoolAssignment(rbdMirror))
+		rbdMirror.Spec.Pools = []string{"shared"}
+		assert.Error(t, r.validatePoolAssignment(rbdMirror))
+		rbdMirror.Spec.Pools = []string{"shared/tenant-a"}
+		assert.Error(t, r.validatePoolAssignment(rbdMirror))
+	})
+}
+
+func TestRemoveStaleDeployments(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	rbdMirror := &cephv1.CephRBDMirror{ObjectMeta: metav1.ObjectMeta{Name: "light", Namespace: "rook-ceph", UID: "light-uid"}}
+	other := &cephv1.CephRBDMirror{ObjectMeta: metav1.ObjectMeta{Name: "heavy", Namespace: "rook-ceph", UID: "heavy-uid"}}
+	newDeployment := func(name string, owner *cephv1.CephRBDMirror) *apps.Deployment {
+		controller := true
+		return &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "rook-ceph",
+			Labels:          map[string]string{k8sutil.AppAttr: AppName},
+			OwnerReferences: []metav1.OwnerReference{{Name: owner.Name, UID: owner.UID, Controller: &controller}},
+		}}
+	}
+	for _, d := range []*apps.Deployment{
+		newDeployment("rook-ceph-rbd-mirror-a", rbdMirror),
+		newDeployment("rook-ceph-rbd-mirror-light", rbdMirror),
+		newDeployment("rook-ceph-rbd-mirror-heavy", other),
+	} {
+		_, err := clientset.AppsV1().Deployments("rook-ceph").Create(ctx, d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	r := &ReconcileCephRBDMirror{context: &clusterd.Context{Clientset: clientset}, opManagerContext: ctx}
+
+	err := r.removeStaleDeployments(rbdMirror, "rook-ceph-rbd-mirror-light")
+	assert.NoError(t, err)
+	deployments, err := clientset.AppsV1().Deployments("rook-ceph").List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	names := []string{}
+	for _, d := range deployments.Items {
+		names = append(names, d.Name)
+	}
+	assert.ElementsMatch(t, []string{"rook-ceph-rbd-mirror-light", "rook-ceph-rbd-mirror-heavy"}, names)
+}
@@ -55,10 +55,15 @@ func (r *ReconcileCephRBDMirror) makeDeployment(daemonConfig *daemonConfig, rbdM
 		podSpec.Spec.Containers = append(podSpec.Spec.Containers, *controller.LogCollectorContainer(fmt.Sprintf("ceph-client.rbd-mirror.%s", daemonConfig.DaemonID), r.clusterInfo.Namespace, *r.cephClusterSpec))
 	}
 
+	if r.cephClusterSpec.Security.IsRestricted() {
+		controller.ApplyRestrictedPodSecurity(&podSpec.Spec)
+	}
+
 	// Replace default unreachable node toleration
 	k8sutil.AddUnreachableNodeToleration(&podSpec.Spec)
 	rbdMirror.Spec.Annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
 	rbdMirror.Spec.Labels.ApplyToObjectMeta(&podSpec.ObjectMeta)
+	controller.ApplySecurityProfile(r.cephClusterSpec.Security.Profiles, cephv1.KeyRBDMirror, &podSpec.ObjectMeta, &podSpec.Spec)
 
 	if r.cephClusterSpec.Network.IsHost() {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
@@ -69,7 +74,7 @@ func (r *ReconcileCephRBDMirror) makeDeployment(daemonConfig *daemonConfig, rbdM
 	}
 	rbdMirror.Spec.Placement.ApplyToPodSpec(&podSpec.Spec)
 
-	replicas := int32(rbdMirror.Spec.Count)
+	replicas := int32(daemonConfig.Count)
 	d := &apps.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        daemonConfig.ResourceName,
@@ -40,6 +40,7 @@ func TestPodSpec(t *testing.T) {
 		DaemonID:     "a",
 		ResourceName: "rook-ceph-rbd-mirror-a",
 		DataPathMap:  config.NewDatalessDaemonDataPathMap("rook-ceph", "/var/lib/rook"),
+		Count:        1,
 	}
 	cephCluster := &cephv1.CephCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -87,6 +88,7 @@ func TestPodSpec(t *testing.T) {
 	d, err := r.makeDeployment(&daemonConf, rbdMirror)
 	assert.NoError(t, err)
 	assert.Equal(t, "rook-ceph-rbd-mirror-a", d.Name)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
 	assert.Equal(t, 4, len(d.Spec.Template.Spec.Volumes))
 	assert.Equal(t, 1, len(d.Spec.Template.Spec.Volumes[0].Projected.Sources))
 	assert.Equal(t, 4, len(d.Spec.Template.Spec.Containers[0].VolumeMounts))
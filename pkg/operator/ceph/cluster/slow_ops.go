@@ -0,0 +1,393 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	slowOpsCheck = "SLOW_OPS"
+	// the reasons of the events recorded on the CephCluster
+	slowOpsReason         = "SlowOps"
+	slowOpsClearedReason  = "SlowOpsCleared"
+	stuckPGsReason        = "StuckPGs"
+	stuckPGsClearedReason = "StuckPGsCleared"
+	// the number of OSDs with the most stuck pgs listed in the events
+	maxStuckPGOSDsInEvent = 5
+	// the id of a missing OSD in the acting set of a pg
+	crushItemNone = 2147483647
+)
+
+var (
+	// the summary of the SLOW_OPS health check, such as "3 slow ops, oldest one blocked for 34 sec, daemons
+	// [osd.0,osd.3] have slow ops." or "1 slow ops, oldest one blocked for 31 sec, osd.2 has slow ops"
+	slowOpsSummaryRegex = regexp.MustCompile(`^(\d+) slow ops, oldest one blocked for (\d+) sec, (?:daemons \[([^\]]*)\] have|(\S+) has) slow ops`)
+
+	slowOps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_slow_ops",
+		Help: "The number of slow ops in the cluster",
+	}, []string{"namespace"})
+	slowOpsOldestBlocked = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_slow_ops_oldest_blocked_seconds",
+		Help: "The number of seconds the oldest slow op of the cluster is blocked for",
+	}, []string{"namespace"})
+	daemonSlowOps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_daemon_slow_ops",
+		Help: "Whether the daemon has slow ops, only set for the daemons with slow ops",
+	}, []string{"namespace", "daemon"})
+	osdHistoricSlowOps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_osd_historic_slow_ops",
+		Help: "The number of recent slow ops kept by an OSD with slow ops",
+	}, []string{"namespace", "osd"})
+	osdHistoricSlowOpsMaxDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_osd_historic_slow_ops_max_duration_seconds",
+		Help: "The duration of the slowest of the recent slow ops kept by an OSD with slow ops",
+	}, []string{"namespace", "osd"})
+	stuckPGs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_stuck_pgs",
+		Help: "The number of placement groups stuck in the state",
+	}, []string{"namespace", "state"})
+	osdStuckPGs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_osd_stuck_pgs",
+		Help: "The number of stuck placement groups in the acting set of the OSD, only set for the OSDs with stuck pgs",
+	}, []string{"namespace", "osd"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(slowOps, slowOpsOldestBlocked, daemonSlowOps, osdHistoricSlowOps, osdHistoricSlowOpsMaxDuration, stuckPGs, osdStuckPGs)
+}
+
+// slowOpsSummary is the slow ops of the cluster found in its SLOW_OPS health check
+type slowOpsSummary struct {
+	count          int
+	oldestBlocked  int
+	daemons        []string
+	historicByOSDs map[string][]cephclient.HistoricOp
+}
+
+// stuckPGsSummary is the breakdown of the stuck pgs of the cluster by state and by OSD
+type stuckPGsSummary struct {
+	total   int
+	byState map[string]int
+	byOSD   map[string]int
+}
+
+// slowOpsCollector exports the slow ops and the stuck pgs of a cluster with the daemons they implicate,
+// and records an event on the CephCluster when the implicated daemons change, so that the performance
+// regressions point to the daemons to look at.
+type slowOpsCollector struct {
+	namespace string
+	recorder  record.EventRecorder
+	// the series exported, to remove them when gone
+	exportedDaemons      map[string]bool
+	exportedHistoricOSDs map[string]bool
+	exportedStuckOSDs    map[string]bool
+	// the daemons with slow ops and the OSDs with stuck pgs of the last events, to only record an event
+	// when they change
+	slowOpsDaemons string
+	stuckPGOSDs    string
+}
+
+func newSlowOpsCollector(namespace string) *slowOpsCollector {
+	return &slowOpsCollector{
+		namespace:            namespace,
+		exportedDaemons:      map[string]bool{},
+		exportedHistoricOSDs: map[string]bool{},
+		exportedStuckOSDs:    map[string]bool{},
+	}
+}
+
+// collect exports the slow ops and the stuck pgs of the cluster from its status, and records the events
+// on the cluster if not nil
+func (s *slowOpsCollector) collect(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, status *cephclient.CephStatus, cluster *cephv1.CephCluster) error {
+	ops := parseSlowOps(status)
+	ops.historicByOSDs = map[string][]cephclient.HistoricOp{}
+	for _, daemon := range ops.daemons {
+		id, ok := osdID(daemon)
+		if !ok {
+			continue
+		}
+		historic, err := cephclient.GetOSDHistoricSlowOps(context, clusterInfo, id)
+		if err != nil {
+			logger.Warningf("failed to get the historic slow ops of %q. %v", daemon, err)
+			continue
+		}
+		ops.historicByOSDs[daemon] = historic
+	}
+	s.setSlowOps(ops)
+	s.recordSlowOpsEvent(ops, cluster)
+
+	pgs := []cephclient.StuckPG{}
+	if !allPGsActiveClean(status) {
+		var err error
+		pgs, err = cephclient.GetStuckPGs(context, clusterInfo)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the stuck pgs")
+		}
+	}
+	stuck := summarizeStuckPGs(pgs)
+	s.setStuckPGs(stuck)
+	s.recordStuckPGsEvent(stuck, cluster)
+	return nil
+}
+
+// parseSlowOps returns the slow ops from the summary of the SLOW_OPS health check of the status
+func parseSlowOps(status *cephclient.CephStatus) slowOpsSummary {
+	check, ok := status.Health.Checks[slowOpsCheck]
+	if !ok {
+		return slowOpsSummary{}
+	}
+	match := slowOpsSummaryRegex.FindStringSubmatch(check.Summary.Message)
+	if match == nil {
+		logger.Warningf("failed to parse the slow ops summary %q", check.Summary.Message)
+		return slowOpsSummary{count: check.Summary.Count}
+	}
+	ops := slowOpsSummary{}
+	ops.count, _ = strconv.Atoi(match[1])
+	ops.oldestBlocked, _ = strconv.Atoi(match[2])
+	daemons := match[3]
+	if daemons == "" {
+		daemons = match[4]
+	}
+	for _, daemon := range strings.Split(daemons, ",") {
+		if daemon = strings.TrimSpace(daemon); daemon != "" {
+			ops.daemons = append(ops.daemons, daemon)
+		}
+	}
+	sort.Strings(ops.daemons)
+	return ops
+}
+
+func osdID(daemon string) (int, bool) {
+	if !strings.HasPrefix(daemon, "osd.") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(daemon, "osd."))
+	return id, err == nil
+}
+
+func allPGsActiveClean(status *cephclient.CephStatus) bool {
+	for _, pg := range status.PgMap.PgsByState {
+		states := pgStates(pg.StateName)
+		if !states["active"] || !states["clean"] {
+			return false
+		}
+	}
+	return true
+}
+
+func pgStates(state string) map[string]bool {
+	states := map[string]bool{}
+	for _, s := range strings.Split(state, "+") {
+		states[s] = true
+	}
+	return states
+}
+
+// summarizeStuckPGs counts the stuck pgs in each of the stuck states and in the acting set of each OSD
+func summarizeStuckPGs(pgs []cephclient.StuckPG) stuckPGsSummary {
+	stuck := stuckPGsSummary{total: len(pgs), byState: map[string]int{}, byOSD: map[string]int{}}
+	for _, state := range cephclient.StuckPGStates {
+		stuck.byState[state] = 0
+	}
+	for _, pg := range pgs {
+		states := pgStates(pg.State)
+		if !states["active"] {
+			stuck.byState["inactive"]++
+		}
+		if !states["clean"] {
+			stuck.byState["unclean"]++
+		}
+		for _, state := range []string{"stale", "undersized", "degraded"} {
+			if states[state] {
+				stuck.byState[state]++
+			}
+		}
+		for _, id := range pg.Acting {
+			if id != crushItemNone {
+				stuck.byOSD[fmt.Sprintf("osd.%d", id)]++
+			}
+		}
+	}
+	return stuck
+}
+
+func (s *slowOpsCollector) setSlowOps(ops slowOpsSummary) {
+	slowOps.WithLabelValues(s.namespace).Set(float64(ops.count))
+	slowOpsOldestBlocked.WithLabelValues(s.namespace).Set(float64(ops.oldestBlocked))
+
+	daemons := map[string]bool{}
+	for _, daemon := range ops.daemons {
+		daemonSlowOps.WithLabelValues(s.namespace, daemon).Set(1)
+		daemons[daemon] = true
+	}
+	for daemon := range s.exportedDaemons {
+		if !daemons[daemon] {
+			daemonSlowOps.DeleteLabelValues(s.namespace, daemon)
+		}
+	}
+	s.exportedDaemons = daemons
+
+	osds := map[string]bool{}
+	for osd, historic := range ops.historicByOSDs {
+		maxDuration := 0.0
+		for _, op := range historic {
+			if op.Duration > maxDuration {
+				maxDuration = op.Duration
+			}
+		}
+		osdHistoricSlowOps.WithLabelValues(s.namespace, osd).Set(float64(len(historic)))
+		osdHistoricSlowOpsMaxDuration.WithLabelValues(s.namespace, osd).Set(maxDuration)
+		osds[osd] = true
+	}
+	for osd := range s.exportedHistoricOSDs {
+		if !osds[osd] {
+			osdHistoricSlowOps.DeleteLabelValues(s.namespace, osd)
+			osdHistoricSlowOpsMaxDuration.DeleteLabelValues(s.namespace, osd)
+		}
+	}
+	s.exportedHistoricOSDs = osds
+}
+
+func (s *slowOpsCollector) setStuckPGs(stuck stuckPGsSummary) {
+	for state, count := range stuck.byState {
+		stuckPGs.WithLabelValues(s.namespace, state).Set(float64(count))
+	}
+	osds := map[string]bool{}
+	for osd, count := range stuck.byOSD {
+		osdStuckPGs.WithLabelValues(s.namespace, osd).Set(float64(count))
+		osds[osd] = true
+	}
+	for osd := range s.exportedStuckOSDs {
+		if !osds[osd] {
+			osdStuckPGs.DeleteLabelValues(s.namespace, osd)
+		}
+	}
+	s.exportedStuckOSDs = osds
+}
+
+// recordSlowOpsEvent records a warning event when the daemons with slow ops change, and a normal event
+// when the slow ops are cleared
+func (s *slowOpsCollector) recordSlowOpsEvent(ops slowOpsSummary, cluster *cephv1.CephCluster) {
+	daemons := strings.Join(ops.daemons, ", ")
+	if daemons == s.slowOpsDaemons || cluster == nil || s.recorder == nil {
+		return
+	}
+	if daemons == "" {
+		s.recorder.Event(cluster, v1.EventTypeNormal, slowOpsClearedReason, "no daemon has slow ops anymore")
+	} else {
+		message := fmt.Sprintf("%d slow ops, oldest one blocked for %d sec, on daemons %s", ops.count, ops.oldestBlocked, daemons)
+		for _, daemon := range ops.daemons {
+			if historic, ok := ops.historicByOSDs[daemon]; ok && len(historic) > 0 {
+				slowest := historic[0]
+				for _, op := range historic {
+					if op.Duration > slowest.Duration {
+						slowest = op
+					}
+				}
+				message += fmt.Sprintf(". %s: %d recent slow ops, slowest %.1f sec %q", daemon, len(historic), slowest.Duration, slowest.Description)
+			}
+		}
+		s.recorder.Event(cluster, v1.EventTypeWarning, slowOpsReason, message)
+	}
+	s.slowOpsDaemons = daemons
+}
+
+// recordStuckPGsEvent records a warning event when the OSDs with stuck pgs change, and a normal event
+// when no pg is stuck anymore
+func (s *slowOpsCollector) recordStuckPGsEvent(stuck stuckPGsSummary, cluster *cephv1.CephCluster) {
+	osds := make([]string, 0, len(stuck.byOSD))
+	for osd := range stuck.byOSD {
+		osds = append(osds, osd)
+	}
+	// the OSDs with the most stuck pgs first
+	sort.Slice(osds, func(i, j int) bool {
+		if stuck.byOSD[osds[i]] != stuck.byOSD[osds[j]] {
+			return stuck.byOSD[osds[i]] > stuck.byOSD[osds[j]]
+		}
+		return osds[i] < osds[j]
+	})
+	key := strings.Join(osds, ",")
+	if stuck.total == 0 {
+		key = ""
+	}
+	if key == s.stuckPGOSDs || cluster == nil || s.recorder == nil {
+		return
+	}
+	if key == "" {
+		s.recorder.Event(cluster, v1.EventTypeNormal, stuckPGsClearedReason, "no placement group is stuck anymore")
+	} else {
+		states := []string{}
+		for _, state := range cephclient.StuckPGStates {
+			if stuck.byState[state] > 0 {
+				states = append(states, fmt.Sprintf("%s: %d", state, stuck.byState[state]))
+			}
+		}
+		implicated := []string{}
+		for i, osd := range osds {
+			if i == maxStuckPGOSDsInEvent {
+				implicated = append(implicated, fmt.Sprintf("and %d more", len(osds)-i))
+				break
+			}
+			implicated = append(implicated, fmt.Sprintf("%s (%d)", osd, stuck.byOSD[osd]))
+		}
+		message := fmt.Sprintf("%d placement groups are stuck (%s)", stuck.total, strings.Join(states, ", "))
+		if len(implicated) > 0 {
+			message += fmt.Sprintf(" on OSDs %s", strings.Join(implicated, ", "))
+		}
+		s.recorder.Event(cluster, v1.EventTypeWarning, stuckPGsReason, message)
+	}
+	s.stuckPGOSDs = key
+}
+
+// clear removes the metrics of the cluster, when it is not monitored anymore
+func (s *slowOpsCollector) clear() {
+	slowOps.DeleteLabelValues(s.namespace)
+	slowOpsOldestBlocked.DeleteLabelValues(s.namespace)
+	for daemon := range s.exportedDaemons {
+		daemonSlowOps.DeleteLabelValues(s.namespace, daemon)
+	}
+	for osd := range s.exportedHistoricOSDs {
+		osdHistoricSlowOps.DeleteLabelValues(s.namespace, osd)
+		osdHistoricSlowOpsMaxDuration.DeleteLabelValues(s.namespace, osd)
+	}
+	for _, state := range cephclient.StuckPGStates {
+		stuckPGs.DeleteLabelValues(s.namespace, state)
+	}
+	for osd := range s.exportedStuckOSDs {
+		osdStuckPGs.DeleteLabelValues(s.namespace, osd)
+	}
+	s.exportedDaemons = map[string]bool{}
+	s.exportedHistoricOSDs = map[string]bool{}
+	s.exportedStuckOSDs = map[string]bool{}
+	s.slowOpsDaemons = ""
+	s.stuckPGOSDs = ""
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestParseSlowOps(t *testing.T) {
+	status := &cephclient.CephStatus{Health: cephclient.HealthStatus{Checks: map[string]cephclient.CheckMessage{}}}
+	assert.Equal(t, slowOpsSummary{}, parseSlowOps(status))
+
+	status.Health.Checks[slowOpsCheck] = cephclient.CheckMessage{Summary: cephclient.Summary{Message: "3 slow ops, oldest one blocked for 34 sec, daemons [osd.3,osd.0,mon.a] have slow ops."}}
+	assert.Equal(t, slowOpsSummary{count: 3, oldestBlocked: 34, daemons: []string{"mon.a", "osd.0", "osd.3"}}, parseSlowOps(status))
+
+	status.Health.Checks[slowOpsCheck] = cephclient.CheckMessage{Summary: cephclient.Summary{Message: "1 slow ops, oldest one blocked for 31 sec, osd.2 has slow ops"}}
+	assert.Equal(t, slowOpsSummary{count: 1, oldestBlocked: 31, daemons: []string{"osd.2"}}, parseSlowOps(status))
+}
+
+func TestSummarizeStuckPGs(t *testing.T) {
+	stuck := summarizeStuckPGs([]cephclient.StuckPG{
+		{PGID: "1.0", State: "active+undersized+degraded", Acting: []int{0, 1}},
+		{PGID: "1.1", State: "stale+peering", Acting: []int{1, crushItemNone}},
+	})
+	assert.Equal(t, 2, stuck.total)
+	assert.Equal(t, map[string]int{"inactive": 1, "unclean": 2, "stale": 1, "undersized": 1, "degraded": 1}, stuck.byState)
+	assert.Equal(t, map[string]int{"osd.0": 1, "osd.1": 2}, stuck.byOSD)
+}
+
+func TestCollectSlowOps(t *testing.T) {
+	stuckPGsOutput := `{"stuck_pg_stats":[{"pgid":"1.0","state":"active+undersized+degraded","up":[0,1],"acting":[0,1],"up_primary":0,"acting_primary":0},
+		{"pgid":"1.1","state":"active+undersized+degraded","up":[1,2],"acting":[1,2],"up_primary":1,"acting_primary":1}]}`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "pg" && args[1] == "dump_stuck" {
+				return stuckPGsOutput, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			if args[0] == "tell" && args[1] == "osd.3" && args[2] == "dump_historic_slow_ops" {
+				return `{"num to keep":20,"threshold to keep":10,"Ops":[{"description":"osd_op(client.4123.0:12 2.5 2.a8f5 (undecoded) ondisk+write+known_if_redirected e52)","initiated_at":"2022-03-01T10:12:04.51Z","age":40.2,"duration":32.5},
+					{"description":"osd_op(client.4123.0:13 2.5 2.a8f5 (undecoded) ondisk+write+known_if_redirected e52)","initiated_at":"2022-03-01T10:12:05.51Z","age":39.2,"duration":12.1}]}`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := cephclient.AdminTestClusterInfo("rook-ceph")
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "rook-ceph"}}
+	recorder := record.NewFakeRecorder(10)
+	s := newSlowOpsCollector("rook-ceph")
+	s.recorder = recorder
+	defer s.clear()
+
+	status := &cephclient.CephStatus{
+		Health: cephclient.HealthStatus{Checks: map[string]cephclient.CheckMessage{
+			slowOpsCheck: {Summary: cephclient.Summary{Message: "2 slow ops, oldest one blocked for 34 sec, osd.3 has slow ops"}},
+		}},
+		PgMap: cephclient.PgMap{PgsByState: []cephclient.PgStateEntry{{StateName: "active+clean", Count: 30}, {StateName: "active+undersized+degraded", Count: 2}}},
+	}
+
+	t.Run("slow ops and stuck pgs", func(t *testing.T) {
+		assert.NoError(t, s.collect(context, clusterInfo, status, cluster))
+		assert.Equal(t, float64(2), testutil.ToFloat64(slowOps.WithLabelValues("rook-ceph")))
+		assert.Equal(t, float64(34), testutil.ToFloat64(slowOpsOldestBlocked.WithLabelValues("rook-ceph")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(daemonSlowOps.WithLabelValues("rook-ceph", "osd.3")))
+		assert.Equal(t, float64(2), testutil.ToFloat64(osdHistoricSlowOps.WithLabelValues("rook-ceph", "osd.3")))
+		assert.Equal(t, 32.5, testutil.ToFloat64(osdHistoricSlowOpsMaxDuration.WithLabelValues("rook-ceph", "osd.3")))
+		assert.Equal(t, float64(2), testutil.ToFloat64(stuckPGs.WithLabelValues("rook-ceph", "degraded")))
+		assert.Equal(t, float64(0), testutil.ToFloat64(stuckPGs.WithLabelValues("rook-ceph", "inactive")))
+		assert.Equal(t, float64(2), testutil.ToFloat64(osdStuckPGs.WithLabelValues("rook-ceph", "osd.1")))
+		assert.Equal(t, 3, testutil.CollectAndCount(osdStuckPGs))
+
+		assert.Len(t, recorder.Events, 2)
+		assert.Contains(t, <-recorder.Events, "Warning SlowOps 2 slow ops, oldest one blocked for 34 sec, on daemons osd.3. osd.3: 2 recent slow ops, slowest 32.5 sec")
+		assert.Equal(t, "Warning StuckPGs 2 placement groups are stuck (unclean: 2, undersized: 2, degraded: 2) on OSDs osd.1 (2), osd.0 (1), osd.2 (1)", <-recorder.Events)
+	})
+
+	t.Run("no new event when the implicated daemons are the same", func(t *testing.T) {
+		assert.NoError(t, s.collect(context, clusterInfo, status, cluster))
+		assert.Len(t, recorder.Events, 0)
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		status.Health.Checks = map[string]cephclient.CheckMessage{}
+		status.PgMap.PgsByState = []cephclient.PgStateEntry{{StateName: "active+clean", Count: 32}}
+		assert.NoError(t, s.collect(context, clusterInfo, status, cluster))
+		assert.Equal(t, float64(0), testutil.ToFloat64(slowOps.WithLabelValues("rook-ceph")))
+		assert.Equal(t, 0, testutil.CollectAndCount(daemonSlowOps))
+		assert.Equal(t, 0, testutil.CollectAndCount(osdHistoricSlowOps))
+		assert.Equal(t, 0, testutil.CollectAndCount(osdStuckPGs))
+		assert.Equal(t, float64(0), testutil.ToFloat64(stuckPGs.WithLabelValues("rook-ceph", "degraded")))
+
+		assert.Len(t, recorder.Events, 2)
+		assert.Equal(t, "Normal SlowOpsCleared no daemon has slow ops anymore", <-recorder.Events)
+		assert.Equal(t, "Normal StuckPGsCleared no placement group is stuck anymore", <-recorder.Events)
+	})
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	taskControllerName = "ceph-cluster-task-controller"
+	// purgeOSDServiceAccount is the service account of the jobs purging OSDs, with the permissions to
+	// delete the OSD deployments and PVCs
+	purgeOSDServiceAccount = "rook-ceph-purge-osd"
+)
+
+// migratePoolScript migrates the rbd images of a pool to another pool with the live migration of rbd.
+// The images prepared by a previous attempt are not in the source pool anymore, their migration is
+// resumed from the target pool. An image already in the target pool is not migrated again.
+const migratePoolScript = `set -e
+source='%[1]s'
+target='%[2]s'
+images='%[3]s'
+
+finish() {
+  if ! rbd status "$target/$1" | grep -q "state: executed"; then
+    echo "copying the data of image $1"
+    rbd migration execute "$target/$1"
+  fi
+  rbd migration commit "$target/$1"
+  echo "image $1 is migrated"
+}
+
+for image in $(rbd ls --pool "$target"); do
+  if rbd status "$target/$image" | grep -q "source: $source/"; then
+    echo "resuming the migration of image $image"
+    finish "$image"
+  fi
+done
+
+if [ -z "$images" ]; then
+  images=$(rbd ls --pool "$source")
+fi
+for image in $images; do
+  if rbd info "$target/$image" >/dev/null 2>&1; then
+    echo "image $image is already in pool $target"
+    continue
+  fi
+  echo "preparing the migration of image $image"
+  rbd migration prepare "$source/$image" "$target/$image"
+  finish "$image"
+done
+echo "the images of pool $source are migrated to pool $target"
+`
+
+// the names of the pools and images passed to the migration script
+var migratePoolNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// addTaskController adds the controller running the tasks of the clusters
+func addTaskController(mgr manager.Manager, rookImage string) error {
+	return opcontroller.AddTaskController(mgr, taskControllerName, opcontroller.TaskKind{
+		Kind:   "cluster",
+		Object: &cephv1.CephCluster{TypeMeta: ControllerTypeMeta},
+		Tasks: func(object client.Object) ([]cephv1.TaskSpec, []cephv1.TaskStatus) {
+			cephCluster := object.(*cephv1.CephCluster)
+			return cephCluster.Spec.Tasks, cephCluster.Status.Tasks
+		},
+		SetTaskStatuses: func(object client.Object, statuses []cephv1.TaskStatus) {
+			object.(*cephv1.CephCluster).Status.Tasks = statuses
+		},
+		JobBuilder: func(_ context.Context, object client.Object) (opcontroller.TaskJobBuilder, error) {
+			cephCluster := object.(*cephv1.CephCluster)
+			external := cephCluster.Spec.External.Enable
+			cephImage := cephCluster.Spec.CephVersion.Image
+			return func(task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+				if external {
+					return nil, errors.New("tasks are not supported by an external cluster")
+				}
+				if task.Type == cephv1.TaskTypeMigratePool {
+					return migratePoolTaskPodTemplate(cephImage, task)
+				}
+				return purgeOSDTaskPodTemplate(rookImage, task)
+			}, nil
+		},
+	})
+}
+
+// purgeOSDTaskPodTemplate returns the pod template of a purge-osd task, which runs the osd removal of
+// the rook image like the osd-purge.yaml example job. The args of the task are the comma-separated
+// ids of the OSDs to purge, whether to preserve their PVCs and whether to force the removal of the
+// OSDs that are not safe to destroy.
+func purgeOSDTaskPodTemplate(rookImage string, task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+	if task.Type != cephv1.TaskTypePurgeOSD {
+		return nil, errors.Errorf("task type %q is not supported by a cluster", task.Type)
+	}
+
+	osdIDs := strings.Split(task.Args["osds"], ",")
+	for _, id := range osdIDs {
+		if i, err := strconv.Atoi(id); err != nil || i < 0 {
+			return nil, errors.Errorf("invalid purge osds %q, the osds must be comma-separated osd ids", task.Args["osds"])
+		}
+	}
+	flags := map[string]bool{}
+	for _, arg := range []string{"preservePVC", "force"} {
+		value, ok := task.Args[arg]
+		if !ok {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid purge %s %q", arg, value)
+		}
+		flags[arg] = b
+	}
+
+	return &v1.PodTemplateSpec{
+		Spec: v1.PodSpec{
+			ServiceAccountName: purgeOSDServiceAccount,
+			Containers: []v1.Container{
+				{
+					Name:  "osd-removal",
+					Image: rookImage,
+					Args: []string{
+						"ceph", "osd", "remove",
+						"--preserve-pvc", strconv.FormatBool(flags["preservePVC"]),
+						"--force-osd-removal", strconv.FormatBool(flags["force"]),
+						"--osd-ids", strings.Join(osdIDs, ","),
+					},
+					Env: []v1.EnvVar{
+						k8sutil.NamespaceEnvVar(),
+						mon.EndpointEnvVar(),
+						mon.CephUsernameEnvVar(),
+						mon.CephSecretEnvVar(),
+						k8sutil.ConfigDirEnvVar(k8sutil.DataDir),
+						k8sutil.ConfigOverrideEnvVar(),
+						{Name: "ROOK_FSID", ValueFrom: &v1.EnvVarSource{
+							SecretKeyRef: &v1.SecretKeySelector{
+								LocalObjectReference: v1.LocalObjectReference{Name: mon.AppName},
+								Key:                  "fsid",
+							},
+						}},
+					},
+					VolumeMounts: []v1.VolumeMount{
+						{Name: "ceph-conf-emptydir", MountPath: "/etc/ceph"},
+						{Name: "rook-config", MountPath: k8sutil.DataDir},
+					},
+				},
+			},
+			Volumes: []v1.Volume{
+				{Name: "ceph-conf-emptydir", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+				{Name: "rook-config", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+			},
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}, nil
+}
+
+// migratePoolTaskPodTemplate returns the pod template of a migrate-pool task. The args of the task are
+// the source pool, the target pool and the comma-separated images to migrate, all the images of the
+// source pool by default.
+func migratePoolTaskPodTemplate(cephImage string, task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+	if task.Type != cephv1.TaskTypeMigratePool {
+		return nil, errors.Errorf("task type %q is not supported by a cluster", task.Type)
+	}
+
+	source, target := task.Args["pool"], task.Args["targetPool"]
+	for _, pool := range []string{source, target} {
+		if !migratePoolNameRegex.MatchString(pool) {
+			return nil, errors.Errorf("invalid migration pool %q, the pool and the target pool must be set", pool)
+		}
+	}
+	if source == target {
+		return nil, errors.Errorf("invalid migration target pool %q, it must not be the migrated pool", target)
+	}
+	images := []string{}
+	if task.Args["images"] != "" {
+		images = strings.Split(task.Args["images"], ",")
+	}
+	for _, image := range images {
+		if !migratePoolNameRegex.MatchString(image) {
+			return nil, errors.Errorf("invalid migration images %q, the images must be comma-separated image names", task.Args["images"])
+		}
+	}
+
+	return opcontroller.CephTaskPodTemplate(cephImage, fmt.Sprintf(migratePoolScript, source, target, strings.Join(images, " "))), nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeOSDTaskPodTemplate(t *testing.T) {
+	purge := func(args map[string]string) cephv1.TaskSpec {
+		return cephv1.TaskSpec{Name: "purge", Type: cephv1.TaskTypePurgeOSD, Args: args}
+	}
+
+	template, err := purgeOSDTaskPodTemplate("rook/ceph:master", purge(map[string]string{"osds": "0,2"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-purge-osd", template.Spec.ServiceAccountName)
+	assert.Equal(t, []string{"ceph", "osd", "remove", "--preserve-pvc", "false", "--force-osd-removal", "false", "--osd-ids", "0,2"}, template.Spec.Containers[0].Args)
+
+	template, err = purgeOSDTaskPodTemplate("rook/ceph:master", purge(map[string]string{"osds": "1", "preservePVC": "true", "force": "true"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ceph", "osd", "remove", "--preserve-pvc", "true", "--force-osd-removal", "true", "--osd-ids", "1"}, template.Spec.Containers[0].Args)
+
+	for _, args := range []map[string]string{{}, {"osds": "1,"}, {"osds": "osd.1"}, {"osds": "-1"}, {"osds": "1", "force": "maybe"}} {
+		_, err := purgeOSDTaskPodTemplate("rook/ceph:master", purge(args))
+		assert.Error(t, err, args)
+	}
+
+	_, err = purgeOSDTaskPodTemplate("rook/ceph:master", cephv1.TaskSpec{Name: "scrub", Type: cephv1.TaskTypeScrub})
+	assert.Error(t, err)
+}
+
+func TestMigratePoolTaskPodTemplate(t *testing.T) {
+	migrate := func(args map[string]string) cephv1.TaskSpec {
+		return cephv1.TaskSpec{Name: "migrate", Type: cephv1.TaskTypeMigratePool, Args: args}
+	}
+
+	template, err := migratePoolTaskPodTemplate("quay.io/ceph/ceph:v17", migrate(map[string]string{"pool": "replicapool", "targetPool": "ec-pool"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "quay.io/ceph/ceph:v17", template.Spec.Containers[0].Image)
+	script := template.Spec.Containers[0].Command[2]
+	assert.True(t, strings.HasPrefix(script, "set -e\nsource='replicapool'\ntarget='ec-pool'\nimages=''\n"), script)
+	assert.Contains(t, script, "rbd migration prepare")
+
+	template, err = migratePoolTaskPodTemplate("quay.io/ceph/ceph:v17", migrate(map[string]string{"pool": "replicapool", "targetPool": "ec-pool", "images": "csi-vol-1,csi-vol-2"}))
+	assert.NoError(t, err)
+	assert.Contains(t, template.Spec.Containers[0].Command[2], "images='csi-vol-1 csi-vol-2'")
+
+	for _, args := range []map[string]string{
+		{},
+		{"pool": "replicapool"},
+		{"pool": "replicapool", "targetPool": "replicapool"},
+		{"pool": "replicapool", "targetPool": "ec'; rm -rf /"},
+		{"pool": "replicapool", "targetPool": "ec-pool", "images": "csi-vol-1,"},
+	} {
+		_, err := migratePoolTaskPodTemplate("quay.io/ceph/ceph:v17", migrate(args))
+		assert.Error(t, err, args)
+	}
+
+	_, err = migratePoolTaskPodTemplate("quay.io/ceph/ceph:v17", cephv1.TaskSpec{Name: "purge", Type: cephv1.TaskTypePurgeOSD})
+	assert.Error(t, err)
+}
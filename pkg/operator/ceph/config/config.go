@@ -138,6 +138,13 @@ func SetOrRemoveDefaultConfigs(
 		}
 	}
 
+	// Encrypt the connections with the algorithms approved by FIPS
+	if clusterSpec.Security.IsFIPSEnabled() {
+		if err := monStore.SetAll(FIPSConfigs()...); err != nil {
+			return errors.Wrap(err, "failed to apply fips configuration")
+		}
+	}
+
 	// Apply Multus if needed
 	if clusterSpec.Network.IsMultus() {
 		logger.Info("configuring ceph network(s) with multus")
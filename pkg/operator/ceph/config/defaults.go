@@ -80,6 +80,16 @@ func DefaultCentralizedConfigs(cephVersion version.CephVersion) []Option {
 	return overrides
 }
 
+// FIPSConfigs returns the configuration options Rook will set in Ceph's centralized config store
+// when the FIPS mode is enabled, encrypting all the connections with AES-GCM
+func FIPSConfigs() []Option {
+	overrides := []Option{}
+	for _, mode := range connectionModeOptions {
+		overrides = append(overrides, configOverride("global", mode, secureConnectionMode))
+	}
+	return overrides
+}
+
 // LegacyConfigs represents old configuration that were applied to a cluster and not needed anymore
 func LegacyConfigs() []Option {
 	return []Option{
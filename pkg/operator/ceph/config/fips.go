@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ini/ini"
+	"github.com/pkg/errors"
+)
+
+const (
+	// the msgr2 mode encrypting the connections with AES-GCM, the "crc" mode only checks their integrity
+	secureConnectionMode = "secure"
+
+	dmcryptKeySizeOption = "osd_dmcrypt_key_size"
+)
+
+// the options setting the modes of the connections between the daemons, to the daemons and to the mons
+var connectionModeOptions = []string{
+	"ms_cluster_mode",
+	"ms_service_mode",
+	"ms_client_mode",
+	"ms_mon_cluster_mode",
+	"ms_mon_service_mode",
+	"ms_mon_client_mode",
+}
+
+// the sizes of the LUKS keys of the OSDs encrypted with AES in XTS mode, being XTS-AES-128 and
+// XTS-AES-256
+var fipsDmcryptKeySizes = []string{"256", "512"}
+
+// FIPSOverrideViolations returns the options of the rook-config-override config relying on
+// algorithms not approved by FIPS 140. The config file takes precedence over the centralized config
+// store, so that the options overriding the FIPS configs would be applied to the daemons.
+func FIPSOverrideViolations(override string) ([]string, error) {
+	if override == "" {
+		return nil, nil
+	}
+	configFile, err := ini.Load([]byte(override))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the config override")
+	}
+
+	violations := []string{}
+	for _, section := range configFile.Sections() {
+		for _, key := range section.Keys() {
+			option := normalizeKey(key.Name())
+			value := strings.TrimSpace(key.Value())
+			if isConnectionModeOption(option) && !isSecureConnectionMode(value) {
+				violations = append(violations, fmt.Sprintf("%q in section %q of the config override does not encrypt the connections with the %q mode only", option, section.Name(), secureConnectionMode))
+			}
+			if option == dmcryptKeySizeOption && !isFIPSDmcryptKeySize(value) {
+				violations = append(violations, fmt.Sprintf("%q in section %q of the config override is %q, the OSDs must be encrypted with a key of %s bits", option, section.Name(), value, strings.Join(fipsDmcryptKeySizes, " or ")))
+			}
+		}
+	}
+	return violations, nil
+}
+
+func isConnectionModeOption(option string) bool {
+	for _, mode := range connectionModeOptions {
+		if option == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecureConnectionMode returns whether all the modes of the space separated list are secure
+func isSecureConnectionMode(value string) bool {
+	modes := strings.Fields(value)
+	if len(modes) == 0 {
+		return false
+	}
+	for _, mode := range modes {
+		if mode != secureConnectionMode {
+			return false
+		}
+	}
+	return true
+}
+
+func isFIPSDmcryptKeySize(value string) bool {
+	for _, size := range fipsDmcryptKeySizes {
+		if value == size {
+			return true
+		}
+	}
+	return false
+}
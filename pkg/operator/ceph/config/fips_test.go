@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFIPSOverrideViolations(t *testing.T) {
+	t.Run("no override", func(t *testing.T) {
+		violations, err := FIPSOverrideViolations("")
+		assert.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("compliant override", func(t *testing.T) {
+		violations, err := FIPSOverrideViolations(`
+[global]
+ms cluster mode = secure
+osd_pool_default_size = 3
+[osd]
+osd-dmcrypt-key-size = 512
+`)
+		assert.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("crc connections", func(t *testing.T) {
+		violations, err := FIPSOverrideViolations(`
+[global]
+ms_client_mode = secure crc
+[mon]
+ms mon service mode = crc
+`)
+		assert.NoError(t, err)
+		assert.Len(t, violations, 2)
+		assert.Contains(t, violations[0], `"ms_client_mode" in section "global"`)
+		assert.Contains(t, violations[1], `"ms_mon_service_mode" in section "mon"`)
+	})
+
+	t.Run("luks key size", func(t *testing.T) {
+		violations, err := FIPSOverrideViolations(`
+[osd]
+osd_dmcrypt_key_size = 128
+`)
+		assert.NoError(t, err)
+		assert.Len(t, violations, 1)
+		assert.Contains(t, violations[0], `"osd_dmcrypt_key_size" in section "osd" of the config override is "128"`)
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		_, err := FIPSOverrideViolations("[global")
+		assert.Error(t, err)
+	})
+}
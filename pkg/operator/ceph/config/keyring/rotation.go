@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyring
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	keyringEntityRegex = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+	keyringKeyRegex    = regexp.MustCompile(`(?m)^(\s*key\s*=\s*)\S+`)
+)
+
+// the keys of the mons and of the admin are shared by all the daemons and are never rotated
+var keyringEntitiesNotRotated = map[string]bool{"mon.": true, client.AdminUsername: true}
+
+// RotateDaemonKeys rotates the keys of the daemons whose keyrings are stored by the store, the keys
+// of the mons and of the admin excepted. The keyring secrets are updated with the new keys, the
+// daemons must be restarted to use them. It returns the names of the updated secrets.
+func (k *SecretStore) RotateDaemonKeys() ([]string, error) {
+	secrets, err := k.context.Clientset.CoreV1().Secrets(k.clusterInfo.Namespace).List(k.clusterInfo.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the keyring secrets")
+	}
+	sort.Slice(secrets.Items, func(i, j int) bool { return secrets.Items[i].Name < secrets.Items[j].Name })
+
+	rotated := []string{}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if secret.Type != k8sutil.RookType || !strings.HasSuffix(secret.Name, keyringSecretName("")) {
+			continue
+		}
+		keyring := string(secret.Data[keyringFileName])
+		entity := keyringEntity(keyring)
+		if entity == "" || keyringEntitiesNotRotated[entity] {
+			continue
+		}
+
+		key, err := client.AuthRotateKey(k.context, k.clusterInfo, entity)
+		if err != nil {
+			return rotated, errors.Wrapf(err, "failed to rotate the key of %q", entity)
+		}
+		secret.Data[keyringFileName] = []byte(keyringKeyRegex.ReplaceAllString(keyring, "${1}"+key))
+		if _, err := k.context.Clientset.CoreV1().Secrets(k.clusterInfo.Namespace).Update(k.clusterInfo.Context, secret, metav1.UpdateOptions{}); err != nil {
+			return rotated, errors.Wrapf(err, "failed to update keyring secret %q with the new key of %q", secret.Name, entity)
+		}
+		logger.Infof("rotated the key of %q", entity)
+		rotated = append(rotated, secret.Name)
+	}
+	return rotated, nil
+}
+
+// keyringEntity returns the name of the entity of a keyring
+func keyringEntity(keyring string) string {
+	match := keyringEntityRegex.FindStringSubmatch(keyring)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyring
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRotateDaemonKeys(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	clientset := testop.New(t, 1)
+	imported := []string{}
+	mockExecutor := func(command string, args ...string) (string, error) {
+		if command == "ceph-authtool" {
+			return "new-key\n", nil
+		}
+		switch args[1] {
+		case "get":
+			return `[{"entity":"mgr.a","key":"old-key","caps":{"mon":"allow profile mgr","osd":"allow *"}}]`, nil
+		case "import":
+			keyring, err := ioutil.ReadFile(args[3])
+			assert.NoError(t, err)
+			imported = append(imported, string(keyring))
+		}
+		return "", nil
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: mockExecutor,
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			return mockExecutor(command, args...)
+		},
+	}
+	k := GetSecretStore(&clusterd.Context{Clientset: clientset, Executor: executor}, cephclient.AdminTestClusterInfo(ns), &k8sutil.OwnerInfo{})
+
+	for name, keyring := range map[string]string{
+		"rook-ceph-mgr-a-keyring": "\n[mgr.a]\n\tkey = old-key\n\tcaps mon = \"allow profile mgr\"\n",
+		"rook-ceph-mons-keyring":  "[mon.]\n\tkey = mon-key\n",
+		"rook-ceph-admin-keyring": "\n[client.admin]\n\tkey = admin-key\n",
+		"rook-ceph-config":        "[mgr.a]\n\tkey = old-key\n",
+	} {
+		_, err := clientset.CoreV1().Secrets(ns).Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Data:       map[string][]byte{"keyring": []byte(keyring)},
+			Type:       k8sutil.RookType,
+		}, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	rotated, err := k.RotateDaemonKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rook-ceph-mgr-a-keyring"}, rotated)
+	assert.Equal(t, []string{"[mgr.a]\n\tkey = new-key\n\tcaps mon = \"allow profile mgr\"\n\tcaps osd = \"allow *\"\n"}, imported)
+
+	secret, err := clientset.CoreV1().Secrets(ns).Get(ctx, "rook-ceph-mgr-a-keyring", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "\n[mgr.a]\n\tkey = new-key\n\tcaps mon = \"allow profile mgr\"\n", string(secret.Data["keyring"]))
+	secret, err = clientset.CoreV1().Secrets(ns).Get(ctx, "rook-ceph-mons-keyring", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "[mon.]\n\tkey = mon-key\n", string(secret.Data["keyring"]))
+}
@@ -18,6 +18,7 @@ package operator
 
 import (
 	"context"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -27,8 +28,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/discover"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util"
@@ -67,7 +70,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileLogFields(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -80,6 +83,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for CephOperatorConfig, it has the same name as the operator configmap
+	err = c.Watch(&source.Kind{
+		Type: &cephv1.CephOperatorConfig{TypeMeta: metav1.TypeMeta{Kind: "CephOperatorConfig", APIVersion: cephv1.SchemeGroupVersion.String()}}}, &handler.EnqueueRequestForObject{}, predicateController(mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
 	// Watch for Secret (admission controller secret)
 	err = c.Watch(&source.Kind{
 		Type: &v1.Secret{TypeMeta: metav1.TypeMeta{Kind: "Secret", APIVersion: v1.SchemeGroupVersion.String()}}}, &handler.EnqueueRequestForObject{}, predicateController(mgr.GetClient()))
@@ -121,9 +131,51 @@ func (r *ReconcileConfig) reconcile(request reconcile.Request) (reconcile.Result
 		r.config.Parameters = opConfig.Data
 	}
 
+	// Fetch the CephOperatorConfig, its settings take precedence over the configmap and the env
+	// vars wherever the operator settings are read
+	operatorConfig := &cephv1.CephOperatorConfig{}
+	operatorConfigFound := false
+	err = r.client.Get(r.opManagerContext, request.NamespacedName, operatorConfig)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to get the CephOperatorConfig")
+		}
+		logger.Debug("CephOperatorConfig resource not found. will use the configmap, the env vars or the default values.")
+	} else {
+		operatorConfigFound = true
+	}
+	crSettings := map[string]string{}
+	var invalidConfigErr error
+	if operatorConfigFound {
+		invalidConfigErr = ValidateOperatorConfig(operatorConfig)
+		if invalidConfigErr != nil {
+			logger.Errorf("not applying the settings of the CephOperatorConfig. %v", invalidConfigErr)
+		} else {
+			crSettings = operatorConfig.Spec.OperatorSettings()
+		}
+	}
+	k8sutil.SetOperatorSettingOverrides(crSettings)
+
 	// Reconcile Ceph CLI timeout, since the clusterd context is passed to by pointer to all CRD
 	// controllers they will receive the update
 	opcontroller.SetCephCommandsTimeout(r.config.Parameters)
+	opcontroller.SetCephCommandsRetries(r.config.Parameters)
+	opcontroller.SetCephCommandsCacheTTL(r.config.Parameters)
+
+	// Reconcile how long the reconciles wait for the resources they depend on
+	opcontroller.SetReconcileOrderTimeout(r.config.Parameters)
+
+	// Reconcile how long the controllers may fail before they are reported not ready
+	opcontroller.SetHealthMaxReconcileAge(r.config.Parameters)
+
+	// Reconcile after how long the resources in deletion are reported as stuck
+	opcontroller.SetStuckFinalizerTimeout(r.config.Parameters)
+
+	// Reconcile the audit log of the Ceph commands
+	opcontroller.SetAuditLog(r.config.Parameters)
+
+	// Reconcile the export of the traces of the reconciles
+	opcontroller.SetTracing(r.config.Parameters)
 
 	// Reconcile Operator's logging level
 	reconcileOperatorLogLevel(opConfig.Data)
@@ -137,13 +189,49 @@ func (r *ReconcileConfig) reconcile(request reconcile.Request) (reconcile.Result
 	// Reconcile webhook secret
 	// This is done in the predicate function
 
+	if operatorConfigFound {
+		r.updateOperatorConfigStatus(operatorConfig, crSettings, invalidConfigErr)
+	}
+
 	logger.Infof("%s done reconciling", controllerName)
 	return reconcile.Result{}, nil
 }
 
+// updateOperatorConfigStatus reports the settings applied by the operator in the status of the
+// CephOperatorConfig
+func (r *ReconcileConfig) updateOperatorConfigStatus(operatorConfig *cephv1.CephOperatorConfig, crSettings map[string]string, invalidConfigErr error) {
+	effectiveSettings := effectiveOperatorSettings(crSettings, r.config.Parameters)
+	lastApplied := time.Now().UTC().Format(time.RFC3339)
+	err := reporting.PatchStatus(r.client, operatorConfig, func() bool {
+		status := &cephv1.CephOperatorConfigStatus{
+			EffectiveSettings: effectiveSettings,
+			LastApplied:       lastApplied,
+		}
+		if operatorConfig.Status != nil {
+			status.Conditions = operatorConfig.Status.Conditions
+			status.ObservedGeneration = operatorConfig.Status.ObservedGeneration
+		}
+		if invalidConfigErr != nil {
+			status.Phase = cephv1.ConditionFailure
+			status.Message = invalidConfigErr.Error()
+		} else {
+			status.Phase = cephv1.ConditionReady
+		}
+		operatorConfig.Status = status
+		opcontroller.SetStandardConditions(operatorConfig, false, invalidConfigErr)
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to update the status of the CephOperatorConfig. %v", err)
+	}
+}
+
 func reconcileOperatorLogLevel(data map[string]string) {
+	util.SetLogFormat(k8sutil.GetValue(data, "ROOK_LOG_FORMAT", util.DefaultLogFormat), logger)
 	rookLogLevel := k8sutil.GetValue(data, "ROOK_LOG_LEVEL", util.DefaultLogLevel.String())
 	util.SetGlobalLogLevel(rookLogLevel, logger)
+	// the log levels of the loggers are set over the global log level, e.g. to debug a controller
+	util.SetLogLevels(k8sutil.GetValue(data, "ROOK_LOG_LEVELS", ""), logger)
 }
 
 func (r *ReconcileConfig) reconcileDiscoveryDaemon() error {
@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/util/tracing"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// defaultBackpressureRetryInterval is the interval the paused reconciles are retried at when the
+	// policy does not set it
+	defaultBackpressureRetryInterval = time.Minute
+)
+
+var (
+	// the kinds of the resources whose reconciles are paused by the backpressure policy, the resources
+	// creating churn on the cluster that are not needed to keep the data available
+	backpressureKinds = map[string]bool{
+		"CephObjectStoreUser":          true,
+		"CephBucketTopic":              true,
+		"CephBucketNotification":       true,
+		"CephFilesystemSubVolumeGroup": true,
+		"CephFilesystemSubVolume":      true,
+		"CephBlockPoolRadosNamespace":  true,
+		"CephClient":                   true,
+	}
+	// the health checks raised while the data of the cluster is recovering
+	recoveryHealthChecks = []string{"PG_DEGRADED", "PG_AVAILABILITY"}
+
+	pausedReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rook_ceph_paused_reconciles_total",
+		Help: "The number of reconciles of a resource paused by the backpressure policy of the CephCluster",
+	}, []string{"controller"})
+	pausedResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_paused_resources",
+		Help: "The number of resources of a controller whose reconciles are paused by the backpressure policy of the CephCluster",
+	}, []string{"controller"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(pausedReconciles, pausedResources)
+}
+
+// BackpressureReason returns the reason and the message of the pause of the reconciles of the
+// resources creating churn on the cluster by its backpressure policy, or an empty reason when the
+// reconciles are not paused. The reconciles are paused while the health of the cluster is HEALTH_ERR
+// and, when the policy pauses them on recovery, while the data of the cluster is recovering.
+func BackpressureReason(cluster *cephv1.CephCluster) (cephv1.ConditionReason, string) {
+	policy := cluster.Spec.HealthCheck.Backpressure
+	if policy == nil || !policy.Enabled || cluster.Status.CephStatus == nil || !cluster.DeletionTimestamp.IsZero() {
+		return "", ""
+	}
+
+	status := cluster.Status.CephStatus
+	if status.Health == "HEALTH_ERR" {
+		return cephv1.ClusterHealthErrorReason, fmt.Sprintf("the reconciles are paused while the health of CephCluster %q is HEALTH_ERR", cluster.Name)
+	}
+	if policy.PauseOnRecovery {
+		var raised []string
+		for _, check := range recoveryHealthChecks {
+			if _, ok := status.Details[check]; ok {
+				raised = append(raised, check)
+			}
+		}
+		if len(raised) > 0 {
+			return cephv1.ClusterRecoveringReason, fmt.Sprintf("the reconciles are paused while the data of CephCluster %q is recovering (%s)", cluster.Name, strings.Join(raised, ", "))
+		}
+	}
+	return "", ""
+}
+
+// BackpressureRetryInterval returns the interval the reconciles paused by the backpressure policy of
+// the cluster are retried at
+func BackpressureRetryInterval(cluster *cephv1.CephCluster) time.Duration {
+	policy := cluster.Spec.HealthCheck.Backpressure
+	if policy == nil || policy.RetryInterval == nil || policy.RetryInterval.Duration <= 0 {
+		return defaultBackpressureRetryInterval
+	}
+	return policy.RetryInterval.Duration
+}
+
+// backpressureGate pauses the reconciles of the resources creating churn on the cluster while their
+// CephCluster is unhealthy, when its backpressure policy is enabled
+type backpressureGate struct {
+	gatedResource
+	reconciler reconcile.Reconciler
+	mutex      sync.Mutex
+	// the resources whose reconciles are paused
+	paused map[types.NamespacedName]bool
+}
+
+func newBackpressureGate(resource gatedResource, r reconcile.Reconciler) reconcile.Reconciler {
+	if !backpressureKinds[resource.kind] {
+		return r
+	}
+	pausedResources.WithLabelValues(resource.controllerName).Set(0)
+	return &backpressureGate{gatedResource: resource, reconciler: r, paused: map[types.NamespacedName]bool{}}
+}
+
+// Reconcile reconciles the resource with the wrapped reconciler unless its reconciles are paused
+func (g *backpressureGate) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	retryAfter, err := g.pauseReconcile(ctx, request)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if retryAfter > 0 {
+		if g.debug() {
+			ctrllog.FromContext(ctx).Info("pausing the reconcile until the cluster recovers", "retryAfter", retryAfter.String())
+		}
+		tracing.FromContext(ctx).SetAttribute("rook.reconcile.paused", true)
+		return reconcile.Result{RequeueAfter: retryAfter}, nil
+	}
+	return g.reconciler.Reconcile(ctx, request)
+}
+
+// pauseReconcile returns the interval a resource is requeued after when its reconcile is paused by
+// the backpressure policy of the CephCluster of its namespace, or 0 when the resource can be
+// reconciled. The ReconcilePaused condition of a paused resource is set, it is cleared by the first
+// reconcile after the cluster recovers. The deleted resources are not paused.
+func (g *backpressureGate) pauseReconcile(ctx context.Context, request reconcile.Request) (time.Duration, error) {
+	clusters := &cephv1.CephClusterList{}
+	if err := g.client.List(ctx, clusters, client.InNamespace(request.Namespace)); err != nil {
+		return 0, err
+	}
+	var cluster *cephv1.CephCluster
+	var reason cephv1.ConditionReason
+	var message string
+	if len(clusters.Items) > 0 {
+		cluster = &clusters.Items[0]
+		reason, message = BackpressureReason(cluster)
+	}
+
+	object, err := g.get(ctx, request.NamespacedName)
+	if err != nil {
+		return 0, err
+	}
+	if object == nil || reason == "" || !object.GetDeletionTimestamp().IsZero() {
+		g.setPaused(request.NamespacedName, false)
+		if obj, ok := object.(cephv1.StatusConditionGetter); ok {
+			err := reporting.PatchStatus(g.client, obj, func() bool {
+				return clearPausedCondition(obj)
+			})
+			if err != nil {
+				// the condition is cleared again by the next reconcile
+				logger.Debugf("failed to clear the paused condition of %s %q. %v", g.kind, request.NamespacedName, err)
+			}
+		}
+		return 0, nil
+	}
+
+	pausedReconciles.WithLabelValues(g.controllerName).Inc()
+	g.setPaused(request.NamespacedName, true)
+	if obj, ok := object.(cephv1.StatusConditionGetter); ok {
+		err := reporting.PatchStatus(g.client, obj, func() bool {
+			return setPausedCondition(obj, v1.ConditionTrue, reason, message)
+		})
+		if err != nil {
+			// the condition is set again by the next paused reconcile
+			logger.Debugf("failed to set the paused condition of %s %q. %v", g.kind, request.NamespacedName, err)
+		}
+	}
+	return BackpressureRetryInterval(cluster), nil
+}
+
+// setPausedCondition sets the ReconcilePaused condition of a resource, it returns whether the
+// condition changed
+func setPausedCondition(obj cephv1.StatusConditionGetter, status v1.ConditionStatus, reason cephv1.ConditionReason, message string) bool {
+	conditions := obj.GetStatusConditions()
+	existing := cephv1.FindStatusCondition(*conditions, cephv1.ConditionReconcilePaused)
+	if existing != nil && existing.Status == status && existing.Reason == reason && existing.Message == message {
+		return false
+	}
+	cephv1.SetStatusCondition(conditions, cephv1.Condition{
+		Type:               cephv1.ConditionReconcilePaused,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: obj.GetGeneration(),
+	})
+	return true
+}
+
+// clearPausedCondition sets the ReconcilePaused condition of a resource that was paused to false, it
+// returns whether the condition changed. The resources that were never paused get no condition.
+func clearPausedCondition(obj cephv1.StatusConditionGetter) bool {
+	existing := cephv1.FindStatusCondition(*obj.GetStatusConditions(), cephv1.ConditionReconcilePaused)
+	if existing == nil || existing.Status != v1.ConditionTrue {
+		return false
+	}
+	return setPausedCondition(obj, v1.ConditionFalse, cephv1.ClusterHealthyReason, "the reconciles resumed")
+}
+
+func (g *backpressureGate) setPaused(name types.NamespacedName, paused bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if paused {
+		g.paused[name] = true
+	} else {
+		delete(g.paused, name)
+	}
+	pausedResources.WithLabelValues(g.controllerName).Set(float64(len(g.paused)))
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestBackpressureReason(t *testing.T) {
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "rook-ceph"}}
+	cluster.Status.CephStatus = &cephv1.CephStatus{Health: "HEALTH_ERR"}
+
+	// disabled by default
+	reason, _ := BackpressureReason(cluster)
+	assert.Empty(t, reason)
+	assert.Equal(t, time.Minute, BackpressureRetryInterval(cluster))
+
+	cluster.Spec.HealthCheck.Backpressure = &cephv1.BackpressureSpec{Enabled: true, RetryInterval: &metav1.Duration{Duration: 30 * time.Second}}
+	reason, message := BackpressureReason(cluster)
+	assert.Equal(t, cephv1.ClusterHealthErrorReason, reason)
+	assert.Contains(t, message, "HEALTH_ERR")
+	assert.Equal(t, 30*time.Second, BackpressureRetryInterval(cluster))
+
+	// recovering
+	cluster.Status.CephStatus = &cephv1.CephStatus{Health: "HEALTH_WARN", Details: map[string]cephv1.CephHealthMessage{"PG_DEGRADED": {Severity: "HEALTH_WARN"}}}
+	reason, _ = BackpressureReason(cluster)
+	assert.Empty(t, reason)
+	cluster.Spec.HealthCheck.Backpressure.PauseOnRecovery = true
+	reason, message = BackpressureReason(cluster)
+	assert.Equal(t, cephv1.ClusterRecoveringReason, reason)
+	assert.Contains(t, message, "PG_DEGRADED")
+
+	cluster.Status.CephStatus = &cephv1.CephStatus{Health: "HEALTH_OK"}
+	reason, _ = BackpressureReason(cluster)
+	assert.Empty(t, reason)
+}
+
+func TestPauseReconcile(t *testing.T) {
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	namespace := "rook-ceph"
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: namespace}}
+	cluster.Spec.HealthCheck.Backpressure = &cephv1.BackpressureSpec{Enabled: true}
+	cluster.Status.CephStatus = &cephv1.CephStatus{Health: "HEALTH_ERR"}
+	user := &cephv1.CephObjectStoreUser{ObjectMeta: metav1.ObjectMeta{Name: "user", Namespace: namespace}}
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace}}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cluster, user, pool).Build()
+
+	reconciled := map[string]int{}
+	newReconciler := func(object cephv1.StatusConditionGetter) reconcile.Reconciler {
+		return WithReconcileGates("backpressure-test-controller", c, object, reconcile.Func(func(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
+			reconciled[request.String()]++
+			return reconcile.Result{}, nil
+		}))
+	}
+	userReconciler := newReconciler(&cephv1.CephObjectStoreUser{})
+	poolReconciler := newReconciler(&cephv1.CephBlockPool{})
+	userRequest := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "user"}}
+	poolRequest := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "replicapool"}}
+
+	t.Run("the user is paused while the cluster is in error", func(t *testing.T) {
+		result, err := userReconciler.Reconcile(context.TODO(), userRequest)
+		assert.NoError(t, err)
+		assert.Equal(t, defaultBackpressureRetryInterval, result.RequeueAfter)
+		assert.Equal(t, 0, reconciled[userRequest.String()])
+
+		assert.NoError(t, c.Get(context.TODO(), userRequest.NamespacedName, user))
+		condition := cephv1.FindStatusCondition(user.Status.Conditions, cephv1.ConditionReconcilePaused)
+		assert.NotNil(t, condition)
+		assert.Equal(t, v1.ConditionTrue, condition.Status)
+		assert.Equal(t, cephv1.ClusterHealthErrorReason, condition.Reason)
+	})
+
+	t.Run("the pool is not paused", func(t *testing.T) {
+		result, err := poolReconciler.Reconcile(context.TODO(), poolRequest)
+		assert.NoError(t, err)
+		assert.True(t, result.IsZero())
+		assert.Equal(t, 1, reconciled[poolRequest.String()])
+	})
+
+	t.Run("the user resumes when the cluster recovers", func(t *testing.T) {
+		assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "my-cluster"}, cluster))
+		cluster.Status.CephStatus.Health = "HEALTH_OK"
+		assert.NoError(t, c.Update(context.TODO(), cluster))
+
+		result, err := userReconciler.Reconcile(context.TODO(), userRequest)
+		assert.NoError(t, err)
+		assert.True(t, result.IsZero())
+		assert.Equal(t, 1, reconciled[userRequest.String()])
+
+		assert.NoError(t, c.Get(context.TODO(), userRequest.NamespacedName, user))
+		condition := cephv1.FindStatusCondition(user.Status.Conditions, cephv1.ConditionReconcilePaused)
+		assert.NotNil(t, condition)
+		assert.Equal(t, v1.ConditionFalse, condition.Status)
+		assert.Equal(t, cephv1.ClusterHealthyReason, condition.Reason)
+	})
+}
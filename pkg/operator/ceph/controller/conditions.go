@@ -45,6 +45,20 @@ func UpdateCondition(ctx context.Context, c *clusterd.Context, namespaceName typ
 func UpdateClusterCondition(c *clusterd.Context, cluster *cephv1.CephCluster, namespaceName types.NamespacedName, conditionType cephv1.ConditionType, status v1.ConditionStatus,
 	reason cephv1.ConditionReason, message string, preserveAllConditions bool) {
 
+	err := reporting.PatchStatus(c.Client, cluster, func() bool {
+		SetClusterCondition(cluster, namespaceName, conditionType, status, reason, message, preserveAllConditions)
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to update cluster condition to %q %q. %v", conditionType, status, err)
+	}
+}
+
+// SetClusterCondition sets the condition in the status of the cluster custom resource, along with
+// its phase, state and message
+func SetClusterCondition(cluster *cephv1.CephCluster, namespaceName types.NamespacedName, conditionType cephv1.ConditionType, status v1.ConditionStatus,
+	reason cephv1.ConditionReason, message string, preserveAllConditions bool) {
+
 	// Keep the conditions that already existed if they are in the list of long-term conditions,
 	// otherwise discard the temporary conditions
 	var currentCondition *cephv1.Condition
@@ -58,7 +72,10 @@ func UpdateClusterCondition(c *clusterd.Context, cluster *cephv1.CephCluster, na
 			condition.Reason == cephv1.ClusterCreatedReason ||
 			condition.Reason == cephv1.ClusterConnectedReason ||
 			condition.Type == cephv1.ConditionDeleting ||
-			condition.Type == cephv1.ConditionDeletionIsBlocked {
+			condition.Type == cephv1.ConditionDeletionIsBlocked ||
+			condition.Type == cephv1.ConditionKMSConnected ||
+			condition.Type == cephv1.ConditionOSDMigrationRejected ||
+			condition.Type == cephv1.ConditionDegraded {
 			if conditionType != condition.Type {
 				conditions = append(conditions, condition)
 				continue
@@ -98,10 +115,6 @@ func UpdateClusterCondition(c *clusterd.Context, cluster *cephv1.CephCluster, na
 		cluster.Status.Message = currentCondition.Message
 		logger.Debugf("CephCluster %q status: %q. %q", namespaceName.Namespace, cluster.Status.Phase, cluster.Status.Message)
 	}
-
-	if err := reporting.UpdateStatus(c.Client, cluster); err != nil {
-		logger.Errorf("failed to update cluster condition to %+v. %v", *currentCondition, err)
-	}
 }
 
 // translatePhasetoState convert the Phases to corresponding State
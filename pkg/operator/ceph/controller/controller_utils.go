@@ -19,6 +19,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -27,6 +28,7 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/exec"
+	"github.com/rook/rook/pkg/util/tracing"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -39,6 +41,9 @@ type OperatorConfig struct {
 	Image             string
 	ServiceAccount    string
 	NamespaceToWatch  string
+	// NamespacesToWatch is the list of namespaces watched by the operator when it does not watch
+	// either its own namespace or all the namespaces, NamespaceToWatch is then empty
+	NamespacesToWatch []string
 	Parameters        map[string]string
 }
 
@@ -46,6 +51,13 @@ const (
 	// OperatorSettingConfigMapName refers to ConfigMap that configures rook ceph operator
 	OperatorSettingConfigMapName string = "rook-ceph-operator-config"
 
+	// OBCProvisionerSetting is the operator setting enabling the provisioning of the ObjectBucketClaims
+	OBCProvisionerSetting = "ROOK_ENABLE_OBC_PROVISIONER"
+
+	// WatchNamespacesSetting is the operator setting of the comma separated list of the namespaces
+	// watched by the operator, overriding ROOK_CURRENT_NAMESPACE_ONLY
+	WatchNamespacesSetting = "ROOK_WATCH_NAMESPACES"
+
 	// UninitializedCephConfigError refers to the error message printed by the Ceph CLI when there is no ceph configuration file
 	// This typically is raised when the operator has not finished initializing
 	UninitializedCephConfigError = "error calling conf_read_file"
@@ -91,9 +103,126 @@ func SetCephCommandsTimeout(data map[string]string) {
 		timeoutSeconds = 15
 	}
 	exec.CephCommandsTimeout = time.Duration(timeoutSeconds) * time.Second
+
+	strMaxDurationSeconds := k8sutil.GetValue(data, "ROOK_CEPH_COMMANDS_MAX_DURATION_SECONDS", "300")
+	maxDurationSeconds, err := strconv.Atoi(strMaxDurationSeconds)
+	if err != nil || maxDurationSeconds < timeoutSeconds {
+		logger.Warningf("ROOK_CEPH_COMMANDS_MAX_DURATION_SECONDS is %q but it should be >= ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS, set the default value 300", strMaxDurationSeconds)
+		maxDurationSeconds = 300
+	}
+	exec.CephCommandsMaxDuration = time.Duration(maxDurationSeconds) * time.Second
+}
+
+// SetCephCommandsRetries sets how the Ceph commands failing to reach the mons are retried, and after
+// how many consecutive failures the commands are short-circuited
+func SetCephCommandsRetries(data map[string]string) {
+	retries, err := strconv.Atoi(k8sutil.GetValue(data, "ROOK_CEPH_COMMANDS_RETRIES", "3"))
+	if err != nil || retries < 0 {
+		logger.Warningf("ROOK_CEPH_COMMANDS_RETRIES should be >= 0, set the default value 3")
+		retries = 3
+	}
+	exec.CephCommandsRetries = retries
+
+	threshold, err := strconv.Atoi(k8sutil.GetValue(data, "ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_THRESHOLD", "5"))
+	if err != nil || threshold < 0 {
+		logger.Warningf("ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_THRESHOLD should be >= 0, set the default value 5")
+		threshold = 5
+	}
+	exec.CephCommandsCircuitBreakerThreshold = threshold
+
+	cooldownSeconds, err := strconv.Atoi(k8sutil.GetValue(data, "ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_COOLDOWN_SECONDS", "30"))
+	if err != nil || cooldownSeconds < 1 {
+		logger.Warningf("ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_COOLDOWN_SECONDS should be >= 1, set the default value 30")
+		cooldownSeconds = 30
+	}
+	exec.CephCommandsCircuitBreakerCooldown = time.Duration(cooldownSeconds) * time.Second
+}
+
+// SetCephCommandsCacheTTL sets how long the output of the frequently repeated read-only Ceph commands is
+// shared by the reconciles
+func SetCephCommandsCacheTTL(data map[string]string) {
+	ttlSeconds, err := strconv.Atoi(k8sutil.GetValue(data, "ROOK_CEPH_COMMANDS_CACHE_TTL_SECONDS", "5"))
+	if err != nil || ttlSeconds < 0 {
+		logger.Warningf("ROOK_CEPH_COMMANDS_CACHE_TTL_SECONDS should be >= 0, set the default value 5")
+		ttlSeconds = 5
+	}
+	exec.CephCommandsCacheTTL = time.Duration(ttlSeconds) * time.Second
+}
+
+// the audit log settings currently applied, so the audit log file is only reopened when they change
+var auditLogSettings string
+
+// SetAuditLog sets the stream the Ceph commands executed by Rook are recorded to: stdout, a file
+// rotated when it reaches its max size, or none
+func SetAuditLog(data map[string]string) {
+	auditLog := k8sutil.GetValue(data, "ROOK_AUDIT_LOG", "")
+	maxSizeMB, err := strconv.Atoi(k8sutil.GetValue(data, "ROOK_AUDIT_LOG_MAX_SIZE_MB", "100"))
+	if err != nil || maxSizeMB < 1 {
+		logger.Warningf("ROOK_AUDIT_LOG_MAX_SIZE_MB should be >= 1, set the default value 100")
+		maxSizeMB = 100
+	}
+	maxBackups, err := strconv.Atoi(k8sutil.GetValue(data, "ROOK_AUDIT_LOG_MAX_BACKUPS", "5"))
+	if err != nil || maxBackups < 0 {
+		logger.Warningf("ROOK_AUDIT_LOG_MAX_BACKUPS should be >= 0, set the default value 5")
+		maxBackups = 5
+	}
+
+	settings := fmt.Sprintf("%s:%d:%d", auditLog, maxSizeMB, maxBackups)
+	if settings == auditLogSettings {
+		return
+	}
+	switch auditLog {
+	case "":
+		exec.SetAuditLog(nil)
+	case "stdout":
+		exec.SetAuditLog(os.Stdout)
+	default:
+		file, err := exec.NewRotatingFile(auditLog, int64(maxSizeMB)*1024*1024, maxBackups)
+		if err != nil {
+			logger.Errorf("failed to open the audit log, the ceph commands are not audited. %v", err)
+			exec.SetAuditLog(nil)
+			auditLogSettings = ""
+			return
+		}
+		exec.SetAuditLog(file)
+	}
+	auditLogSettings = settings
+	if auditLog != "" {
+		logger.Infof("recording the ceph commands to audit log %q", auditLog)
+	}
+}
+
+// the tracing settings currently applied, so the exporter is only restarted when they change
+var tracingSettings string
+
+// SetTracing sets the OTLP/HTTP endpoint of the OpenTelemetry collector the spans of the reconciles and
+// of their Ceph commands are exported to. Tracing is disabled if not set.
+func SetTracing(data map[string]string) {
+	endpoint := k8sutil.GetValue(data, "ROOK_TRACING_OTLP_ENDPOINT", "")
+	serviceName := k8sutil.GetValue(data, "ROOK_TRACING_SERVICE_NAME", "rook-ceph-operator")
+
+	settings := fmt.Sprintf("%s:%s", endpoint, serviceName)
+	if settings == tracingSettings {
+		return
+	}
+	tracingSettings = settings
+	if endpoint == "" {
+		tracing.SetExporter(nil)
+		return
+	}
+	tracing.SetExporter(tracing.NewExporter(endpoint, serviceName))
+	logger.Infof("exporting the traces of the reconciles to %q", endpoint)
 }
 
 // canIgnoreHealthErrStatusInReconcile determines whether a status of HEALTH_ERR in the CephCluster can be ignored safely.
+// objectOnlyControllers are the controllers that only need the admin ops API of the gateways, the
+// only ones running for an object-only external cluster
+var objectOnlyControllers = []string{
+	"ceph-object-controller",
+	"ceph-object-store-user-controller",
+	"rook-ceph-operator-bucket-controller",
+}
+
 func canIgnoreHealthErrStatusInReconcile(cephCluster cephv1.CephCluster, controllerName string) bool {
 	// Get a list of all the keys causing the HEALTH_ERR status.
 	var healthErrKeys = make([]string, 0)
@@ -140,6 +269,31 @@ func IsReadyToReconcile(ctx context.Context, c client.Client, namespacedName typ
 	cephClusterExists = true
 	logger.Debugf("%q: CephCluster resource %q found in namespace %q", controllerName, cephCluster.Name, namespacedName.Namespace)
 
+	// The daemons run the ceph image pinned by the cluster controller, they are not updated until the
+	// image is pinned
+	if cephCluster.Spec.Security.ImagePolicy.IsEnabled() && cephCluster.Spec.CephVersion.Image != "" {
+		pinned := cephv1.FindImage(cephCluster.Status.Images, cephCluster.Spec.CephVersion.Image)
+		if pinned == nil {
+			logger.Infof("%q: skipping reconcile since the ceph image of CephCluster %q is not pinned yet", controllerName, cephCluster.Name)
+			return cephCluster, false, cephClusterExists, WaitForRequeueIfCephClusterNotReady
+		}
+		cephCluster.Spec.CephVersion.Image = pinned.PinnedImage()
+	}
+
+	// An object-only external cluster has no ceph status, only the object controllers can reconcile
+	// once the operator is connected to its gateways
+	if cephCluster.Spec.External.ObjectOnly {
+		if !contains(objectOnlyControllers, controllerName) {
+			logger.Debugf("%q: skipping reconcile since CephCluster %q is an object-only external cluster", controllerName, cephCluster.Name)
+			return cephCluster, false, cephClusterExists, WaitForRequeueIfCephClusterNotReady
+		}
+		if cephCluster.Status.Phase != cephv1.ConditionConnected {
+			logger.Debugf("%q: object-only external cluster %q is not connected yet", controllerName, cephCluster.Name)
+			return cephCluster, false, cephClusterExists, WaitForRequeueIfCephClusterNotReady
+		}
+		return cephCluster, true, cephClusterExists, WaitForRequeueIfCephClusterNotReady
+	}
+
 	// read the CR status of the cluster
 	if cephCluster.Status.CephStatus != nil {
 		var operatorDeploymentOk = cephCluster.Status.CephStatus.Health == "HEALTH_OK" || cephCluster.Status.CephStatus.Health == "HEALTH_WARN"
@@ -18,6 +18,8 @@ package controller
 
 import (
 	ctx "context"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -90,6 +92,54 @@ func TestSetCephCommandsTimeout(t *testing.T) {
 	exec.CephCommandsTimeout = 0
 	SetCephCommandsTimeout(map[string]string{"ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS": "1"})
 	assert.Equal(t, 1*time.Second, exec.CephCommandsTimeout)
+	assert.Equal(t, 5*time.Minute, exec.CephCommandsMaxDuration)
+
+	SetCephCommandsTimeout(map[string]string{"ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS": "20", "ROOK_CEPH_COMMANDS_MAX_DURATION_SECONDS": "10"})
+	assert.Equal(t, 5*time.Minute, exec.CephCommandsMaxDuration)
+
+	SetCephCommandsTimeout(map[string]string{"ROOK_CEPH_COMMANDS_MAX_DURATION_SECONDS": "60"})
+	assert.Equal(t, time.Minute, exec.CephCommandsMaxDuration)
+	SetCephCommandsTimeout(map[string]string{})
+}
+
+func TestSetCephCommandsRetries(t *testing.T) {
+	defer SetCephCommandsRetries(map[string]string{})
+
+	SetCephCommandsRetries(map[string]string{})
+	assert.Equal(t, 3, exec.CephCommandsRetries)
+	assert.Equal(t, 5, exec.CephCommandsCircuitBreakerThreshold)
+	assert.Equal(t, 30*time.Second, exec.CephCommandsCircuitBreakerCooldown)
+
+	SetCephCommandsRetries(map[string]string{
+		"ROOK_CEPH_COMMANDS_RETRIES":                          "0",
+		"ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_THRESHOLD":        "0",
+		"ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_COOLDOWN_SECONDS": "60",
+	})
+	assert.Equal(t, 0, exec.CephCommandsRetries)
+	assert.Equal(t, 0, exec.CephCommandsCircuitBreakerThreshold)
+	assert.Equal(t, time.Minute, exec.CephCommandsCircuitBreakerCooldown)
+
+	SetCephCommandsRetries(map[string]string{"ROOK_CEPH_COMMANDS_RETRIES": "-1", "ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_COOLDOWN_SECONDS": "0"})
+	assert.Equal(t, 3, exec.CephCommandsRetries)
+	assert.Equal(t, 30*time.Second, exec.CephCommandsCircuitBreakerCooldown)
+}
+
+func TestSetAuditLog(t *testing.T) {
+	defer SetAuditLog(map[string]string{})
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditCtx := exec.WithAuditInitiator(ctx.TODO(), "CephCluster", "rook-ceph", "my-cluster")
+
+	SetAuditLog(map[string]string{"ROOK_AUDIT_LOG": path})
+	exec.AuditCommand(auditCtx, "ceph", []string{"status"}, time.Now(), nil)
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"command":"ceph","args":["status"]`)
+
+	SetAuditLog(map[string]string{})
+	exec.AuditCommand(auditCtx, "ceph", []string{"health"}, time.Now(), nil)
+	content, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "health")
 }
 
 func TestIsReadyToReconcile(t *testing.T) {
@@ -175,4 +225,72 @@ func TestIsReadyToReconcile(t *testing.T) {
 		assert.False(t, ready)
 		assert.False(t, clusterExists)
 	})
+
+	t.Run("object-only external cephcluster", func(t *testing.T) {
+		cephCluster := &cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterName.Name,
+				Namespace: clusterName.Namespace,
+			},
+			Spec: cephv1.ClusterSpec{
+				External: cephv1.ExternalSpec{Enable: true, ObjectOnly: true},
+			},
+			Status: cephv1.ClusterStatus{Phase: cephv1.ConditionConnecting},
+		}
+		objects := []runtime.Object{cephCluster}
+		client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+		_, ready, clusterExists, _ := IsReadyToReconcile(ctx.TODO(), client, clusterName, "ceph-object-controller")
+		assert.False(t, ready)
+		assert.True(t, clusterExists)
+
+		cephCluster.Status.Phase = cephv1.ConditionConnected
+		objects = []runtime.Object{cephCluster}
+		client = fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+		_, ready, _, _ = IsReadyToReconcile(ctx.TODO(), client, clusterName, "ceph-object-controller")
+		assert.True(t, ready)
+
+		// the other controllers need the mons
+		_, ready, clusterExists, _ = IsReadyToReconcile(ctx.TODO(), client, clusterName, "ceph-block-pool-controller")
+		assert.False(t, ready)
+		assert.True(t, clusterExists)
+	})
+	t.Run("cephcluster with image policy", func(t *testing.T) {
+		cephCluster := &cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterName.Name,
+				Namespace: clusterName.Namespace,
+			},
+			Spec: cephv1.ClusterSpec{
+				CephVersion: cephv1.CephVersionSpec{Image: "quay.io/ceph/ceph:v16.2.7"},
+				Security:    cephv1.SecuritySpec{ImagePolicy: &cephv1.ImagePolicySpec{PinDigests: true}},
+			},
+			Status: cephv1.ClusterStatus{CephStatus: &cephv1.CephStatus{Health: "HEALTH_OK"}},
+		}
+		objects := []runtime.Object{cephCluster}
+		client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+		_, ready, clusterExists, _ := IsReadyToReconcile(ctx.TODO(), client, clusterName, controllerName)
+		assert.False(t, ready)
+		assert.True(t, clusterExists)
+
+		// the daemons run the pinned image
+		cephCluster.Status.Images = []cephv1.ImageStatus{{Image: "quay.io/ceph/ceph:v16.2.7", Digest: "sha256:1234"}}
+		objects = []runtime.Object{cephCluster}
+		client = fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+		c, ready, _, _ := IsReadyToReconcile(ctx.TODO(), client, clusterName, controllerName)
+		assert.True(t, ready)
+		assert.Equal(t, "quay.io/ceph/ceph:v16.2.7@sha256:1234", c.Spec.CephVersion.Image)
+	})
+}
+
+func TestSetCephCommandsCacheTTL(t *testing.T) {
+	defer func() { exec.CephCommandsCacheTTL = 0 }()
+
+	SetCephCommandsCacheTTL(map[string]string{})
+	assert.Equal(t, 5*time.Second, exec.CephCommandsCacheTTL)
+
+	SetCephCommandsCacheTTL(map[string]string{"ROOK_CEPH_COMMANDS_CACHE_TTL_SECONDS": "0"})
+	assert.Equal(t, time.Duration(0), exec.CephCommandsCacheTTL)
+
+	SetCephCommandsCacheTTL(map[string]string{"ROOK_CEPH_COMMANDS_CACHE_TTL_SECONDS": "-1"})
+	assert.Equal(t, 5*time.Second, exec.CephCommandsCacheTTL)
 }
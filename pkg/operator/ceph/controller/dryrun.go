@@ -0,0 +1,38 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DryRunAnnotation is the annotation of a CR whose reconciles report the Ceph commands they would
+// run to apply its spec without running them, e.g. to review the changes of the failure domain of a
+// pool before applying them
+const DryRunAnnotation = "ceph.rook.io/dry-run"
+
+// the kinds whose reconciles only change the cluster with Ceph commands, and can therefore be fully
+// reported by a dry run
+var dryRunKinds = map[string]bool{
+	"CephBlockPool": true,
+}
+
+// IsDryRun returns whether the CR is in dry run, the annotation is ignored on the kinds that do not
+// support it
+func IsDryRun(obj client.Object) bool {
+	return dryRunKinds[ObjectKind(obj)] && obj.GetAnnotations()[DryRunAnnotation] == "true"
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// pushSecretSuffix is appended to the name of a Secret to name its PushSecret
+	pushSecretSuffix = "-push"
+	// defaultPushSecretRefreshInterval is the interval the credentials are pushed at by default
+	defaultPushSecretRefreshInterval = time.Hour
+)
+
+// PushSecretGVK is the kind of the PushSecrets of the External Secrets Operator
+var PushSecretGVK = schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1alpha1", Kind: "PushSecret"}
+
+// ReconcilePushSecret pushes a Secret of credentials generated by Rook to the external secret store
+// of the cluster with a PushSecret owned by the owners of the Secret. The PushSecret is removed when
+// the credentials are not pushed anymore. The keys of the Secret are stored under
+// "<prefix>/<namespace>/<secret name>" in the secret store.
+func ReconcilePushSecret(ctx context.Context, c client.Client, clusterNamespace string, securitySpec *cephv1.SecuritySpec, credentials cephv1.ExternalSecretCredentials, secret *v1.Secret) error {
+	name := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name + pushSecretSuffix}
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(PushSecretGVK)
+	err := c.Get(ctx, name, existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		// Nothing to remove when the External Secrets Operator is not installed
+		if meta.IsNoMatchError(err) && !securitySpec.PushesCredentials(credentials) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get push secret %q, is the external secrets operator installed?", name.String())
+	}
+	found := err == nil
+
+	if !securitySpec.PushesCredentials(credentials) {
+		if !found {
+			return nil
+		}
+		if err := c.Delete(ctx, existing); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete push secret %q", name.String())
+		}
+		logger.Infof("deleted push secret %q", name.String())
+		return nil
+	}
+
+	pushSecret := newPushSecret(clusterNamespace, securitySpec.ExternalSecrets, secret)
+	if !found {
+		if err := c.Create(ctx, pushSecret); err != nil {
+			return errors.Wrapf(err, "failed to create push secret %q", name.String())
+		}
+		logger.Infof("created push secret %q", name.String())
+		return nil
+	}
+	existing.Object["spec"] = pushSecret.Object["spec"]
+	existing.SetOwnerReferences(pushSecret.GetOwnerReferences())
+	if err := c.Update(ctx, existing); err != nil {
+		return errors.Wrapf(err, "failed to update push secret %q", name.String())
+	}
+	return nil
+}
+
+// newPushSecret returns the PushSecret pushing every key of a Secret to the secret store
+func newPushSecret(clusterNamespace string, spec *cephv1.ExternalSecretsSpec, secret *v1.Secret) *unstructured.Unstructured {
+	prefix := spec.RemoteKeyPrefix
+	if prefix == "" {
+		prefix = path.Join("rook", clusterNamespace)
+	}
+	remoteKey := path.Join(prefix, secret.Namespace, secret.Name)
+	refreshInterval := defaultPushSecretRefreshInterval
+	if spec.RefreshInterval != nil {
+		refreshInterval = spec.RefreshInterval.Duration
+	}
+	storeKind := spec.SecretStoreRef.Kind
+	if storeKind == "" {
+		storeKind = "SecretStore"
+	}
+
+	keys := []string{}
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	for key := range secret.StringData {
+		if _, ok := secret.Data[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	data := []interface{}{}
+	for _, key := range keys {
+		data = append(data, map[string]interface{}{
+			"match": map[string]interface{}{
+				"secretKey": key,
+				"remoteRef": map[string]interface{}{
+					"remoteKey": remoteKey,
+					"property":  key,
+				},
+			},
+		})
+	}
+
+	pushSecret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"refreshInterval": refreshInterval.String(),
+			"secretStoreRefs": []interface{}{
+				map[string]interface{}{"name": spec.SecretStoreRef.Name, "kind": storeKind},
+			},
+			"selector": map[string]interface{}{
+				"secret": map[string]interface{}{"name": secret.Name},
+			},
+			"data": data,
+		},
+	}}
+	pushSecret.SetGroupVersionKind(PushSecretGVK)
+	pushSecret.SetName(secret.Name + pushSecretSuffix)
+	pushSecret.SetNamespace(secret.Namespace)
+	pushSecret.SetLabels(map[string]string{"rook_cluster": clusterNamespace})
+	pushSecret.SetOwnerReferences(secret.OwnerReferences)
+	return pushSecret
+}
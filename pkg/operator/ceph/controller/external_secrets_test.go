@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcilePushSecret(t *testing.T) {
+	ctx := context.TODO()
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "rook-csi-rbd-node",
+			Namespace:       "rook-ceph",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "CephCluster", Name: "my-cluster", UID: "uid"}},
+		},
+		Data:       map[string][]byte{"userKey": []byte("key")},
+		StringData: map[string]string{"userID": "csi-rbd-node"},
+	}
+	spec := &cephv1.SecuritySpec{}
+	pushSecret := func() (*unstructured.Unstructured, error) {
+		p := &unstructured.Unstructured{}
+		p.SetGroupVersionKind(PushSecretGVK)
+		err := c.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: "rook-csi-rbd-node-push"}, p)
+		return p, err
+	}
+
+	t.Run("not pushed", func(t *testing.T) {
+		assert.NoError(t, ReconcilePushSecret(ctx, c, "rook-ceph", spec, cephv1.ExternalSecretCSICredentials, secret))
+		_, err := pushSecret()
+		assert.True(t, kerrors.IsNotFound(err))
+	})
+
+	spec.ExternalSecrets = &cephv1.ExternalSecretsSpec{SecretStoreRef: cephv1.ExternalSecretStoreRef{Name: "vault"}}
+	t.Run("pushed", func(t *testing.T) {
+		assert.NoError(t, ReconcilePushSecret(ctx, c, "rook-ceph", spec, cephv1.ExternalSecretCSICredentials, secret))
+		p, err := pushSecret()
+		assert.NoError(t, err)
+		assert.Equal(t, "CephCluster", p.GetOwnerReferences()[0].Kind)
+		interval, _, _ := unstructured.NestedString(p.Object, "spec", "refreshInterval")
+		assert.Equal(t, "1h0m0s", interval)
+		stores, _, _ := unstructured.NestedSlice(p.Object, "spec", "secretStoreRefs")
+		assert.Equal(t, map[string]interface{}{"name": "vault", "kind": "SecretStore"}, stores[0])
+		data, _, _ := unstructured.NestedSlice(p.Object, "spec", "data")
+		assert.Equal(t, 2, len(data))
+		remoteKey, _, _ := unstructured.NestedString(data[0].(map[string]interface{}), "match", "remoteRef", "remoteKey")
+		assert.Equal(t, "rook/rook-ceph/rook-ceph/rook-csi-rbd-node", remoteKey)
+		property, _, _ := unstructured.NestedString(data[0].(map[string]interface{}), "match", "remoteRef", "property")
+		assert.Equal(t, "userID", property)
+	})
+
+	t.Run("updated", func(t *testing.T) {
+		spec.ExternalSecrets.RefreshInterval = &metav1.Duration{Duration: 10 * time.Minute}
+		spec.ExternalSecrets.RemoteKeyPrefix = "ceph"
+		assert.NoError(t, ReconcilePushSecret(ctx, c, "rook-ceph", spec, cephv1.ExternalSecretCSICredentials, secret))
+		p, err := pushSecret()
+		assert.NoError(t, err)
+		interval, _, _ := unstructured.NestedString(p.Object, "spec", "refreshInterval")
+		assert.Equal(t, "10m0s", interval)
+		data, _, _ := unstructured.NestedSlice(p.Object, "spec", "data")
+		remoteKey, _, _ := unstructured.NestedString(data[0].(map[string]interface{}), "match", "remoteRef", "remoteKey")
+		assert.Equal(t, "ceph/rook-ceph/rook-csi-rbd-node", remoteKey)
+	})
+
+	t.Run("removed when the credentials are not pushed anymore", func(t *testing.T) {
+		spec.ExternalSecrets.Credentials = []cephv1.ExternalSecretCredentials{cephv1.ExternalSecretObjectStoreUserCredentials}
+		assert.NoError(t, ReconcilePushSecret(ctx, c, "rook-ceph", spec, cephv1.ExternalSecretCSICredentials, secret))
+		_, err := pushSecret()
+		assert.True(t, kerrors.IsNotFound(err))
+	})
+}
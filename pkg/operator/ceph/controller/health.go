@@ -0,0 +1,217 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// HealthProbeBindAddressSetting is the operator setting of the address the liveness and readiness
+	// endpoints of the operator listen on, empty to disable them
+	HealthProbeBindAddressSetting = "ROOK_HEALTH_PROBE_BIND_ADDRESS"
+	// DefaultHealthProbeBindAddress is the default address of the liveness and readiness endpoints
+	DefaultHealthProbeBindAddress = ":8081"
+	// HealthMaxReconcileAgeSetting is the operator setting of the max duration a controller may fail
+	// to reconcile its resources since its last successful reconcile before it is reported not ready
+	HealthMaxReconcileAgeSetting = "ROOK_HEALTH_MAX_RECONCILE_AGE_SECONDS"
+	defaultHealthMaxReconcileAge = 30 * time.Minute
+
+	// ControllersReadyCheck is the name of the readiness check of the controllers being started
+	ControllersReadyCheck = "controllers"
+)
+
+var health = &controllersHealth{maxReconcileAge: defaultHealthMaxReconcileAge, controllers: map[string]*controllerHealth{}}
+
+// controllersHealth tracks the health of the controllers of the manager
+type controllersHealth struct {
+	mutex           sync.Mutex
+	maxReconcileAge time.Duration
+	// the time the controllers started, after the caches of their watches synced, zero until then
+	started     time.Time
+	controllers map[string]*controllerHealth
+}
+
+// controllerHealth is the health of a controller from the result of its reconciles
+type controllerHealth struct {
+	gate          *healthGate
+	lastReconcile time.Time
+	lastSuccess   time.Time
+	lastError     string
+}
+
+// ResetControllersHealth resets the health of the controllers when the manager is set up again, the
+// controllers being registered with WithReconcileGates
+func ResetControllersHealth() {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+	health.started = time.Time{}
+	health.controllers = map[string]*controllerHealth{}
+}
+
+// SetControllersStarted records the controllers started, their watches being synced
+func SetControllersStarted() {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+	health.started = time.Now()
+}
+
+// SetHealthMaxReconcileAge sets the max duration a controller may fail to reconcile its resources
+func SetHealthMaxReconcileAge(data map[string]string) {
+	ageSeconds, err := strconv.Atoi(k8sutil.GetValue(data, HealthMaxReconcileAgeSetting, "1800"))
+	if err != nil || ageSeconds <= 0 {
+		logger.Warningf("%s should be > 0, set the default value 1800", HealthMaxReconcileAgeSetting)
+		ageSeconds = 1800
+	}
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+	health.maxReconcileAge = time.Duration(ageSeconds) * time.Second
+}
+
+// ControllerHealthNames returns the names of the controllers whose health is tracked, sorted
+func ControllerHealthNames() []string {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+	names := make([]string, 0, len(health.controllers))
+	for name := range health.controllers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (h *controllersHealth) register(g *healthGate) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.controllers[g.controllerName] = &controllerHealth{gate: g}
+}
+
+func (h *controllersHealth) recordReconcile(controllerName string, failed bool, err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	c, ok := h.controllers[controllerName]
+	if !ok {
+		return
+	}
+	c.lastReconcile = time.Now()
+	if !failed {
+		c.lastSuccess = c.lastReconcile
+		c.lastError = ""
+		return
+	}
+	if err != nil {
+		c.lastError = err.Error()
+	}
+}
+
+// ControllersStartedCheck is the readiness check of the controllers being started and watching their
+// resources, which fails until the caches of the watches of the manager synced
+func ControllersStartedCheck(_ *http.Request) error {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+	if health.started.IsZero() {
+		return errors.New("the controllers are not started, their watches are not synced yet")
+	}
+	return nil
+}
+
+// ControllerHealthCheck returns the readiness check of a controller, which fails when the controller is
+// not started or when it has resources failing to reconcile and has not successfully reconciled a
+// resource for longer than the max reconcile age. A controller without any resource is healthy.
+func ControllerHealthCheck(controllerName string) healthz.Checker {
+	return func(_ *http.Request) error {
+		health.mutex.Lock()
+		defer health.mutex.Unlock()
+		return health.check(controllerName, time.Now())
+	}
+}
+
+func (h *controllersHealth) check(controllerName string, now time.Time) error {
+	if h.started.IsZero() {
+		return errors.Errorf("the %q controller is not started", controllerName)
+	}
+	c, ok := h.controllers[controllerName]
+	if !ok {
+		return errors.Errorf("the %q controller is not running", controllerName)
+	}
+	blocked := c.gate.blockedCount()
+	if blocked == 0 {
+		return nil
+	}
+	// the controller had until the max age to reconcile its resources after it started
+	since := c.lastSuccess
+	if since.Before(h.started) {
+		since = h.started
+	}
+	age := now.Sub(since)
+	if age <= h.maxReconcileAge {
+		return nil
+	}
+	if c.lastSuccess.IsZero() {
+		return errors.Errorf("the %q controller is failing to reconcile %d resources, without any successful reconcile since it started %s ago. last error: %s", controllerName, blocked, age.Round(time.Second), c.lastError)
+	}
+	return errors.Errorf("the %q controller is failing to reconcile %d resources, its last successful reconcile was %s ago. last error: %s", controllerName, blocked, now.Sub(c.lastSuccess).Round(time.Second), c.lastError)
+}
+
+// healthGate records the result of the reconciles of the resources of a controller for its readiness
+// check
+type healthGate struct {
+	gatedResource
+	reconciler reconcile.Reconciler
+	mutex      sync.Mutex
+	// the resources whose last reconcile failed or requeued them to retry
+	blocked map[types.NamespacedName]bool
+}
+
+func newHealthGate(resource gatedResource, r reconcile.Reconciler) *healthGate {
+	g := &healthGate{gatedResource: resource, reconciler: r, blocked: map[types.NamespacedName]bool{}}
+	health.register(g)
+	return g
+}
+
+// Reconcile reconciles the resource with the wrapped reconciler and records the result
+func (g *healthGate) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	result, err := g.reconciler.Reconcile(ctx, request)
+	failed := err != nil || result.Requeue
+	health.recordReconcile(g.controllerName, failed, err)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if failed {
+		g.blocked[request.NamespacedName] = true
+	} else {
+		delete(g.blocked, request.NamespacedName)
+	}
+	return result, err
+}
+
+func (g *healthGate) blockedCount() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return len(g.blocked)
+}
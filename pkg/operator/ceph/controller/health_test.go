@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestControllerHealthCheck(t *testing.T) {
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pool).Build()
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "rook-ceph", Name: "replicapool"}}
+
+	ResetControllersHealth()
+	defer ResetControllersHealth()
+	var reconcileErr error
+	r := WithReconcileGates("health-test-controller", c, &cephv1.CephBlockPool{}, reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{}, reconcileErr
+	}))
+	assert.Equal(t, []string{"health-test-controller"}, ControllerHealthNames())
+	check := ControllerHealthCheck("health-test-controller")
+
+	t.Run("not ready until the controllers start", func(t *testing.T) {
+		assert.Error(t, ControllersStartedCheck(nil))
+		assert.Error(t, check(nil))
+		SetControllersStarted()
+		assert.NoError(t, ControllersStartedCheck(nil))
+		assert.NoError(t, check(nil))
+		assert.Error(t, ControllerHealthCheck("unknown-controller")(nil))
+	})
+
+	t.Run("failing for less than the max age", func(t *testing.T) {
+		reconcileErr = errors.New("failed to create pool")
+		_, err := r.Reconcile(context.TODO(), request)
+		assert.Error(t, err)
+		assert.NoError(t, check(nil))
+	})
+
+	t.Run("failing for longer than the max age", func(t *testing.T) {
+		health.mutex.Lock()
+		err := health.check("health-test-controller", time.Now().Add(defaultHealthMaxReconcileAge+time.Minute))
+		health.mutex.Unlock()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failing to reconcile 1 resources")
+		assert.Contains(t, err.Error(), "failed to create pool")
+	})
+
+	t.Run("healthy again when the resource reconciles", func(t *testing.T) {
+		reconcileErr = nil
+		_, err := r.Reconcile(context.TODO(), request)
+		assert.NoError(t, err)
+		health.mutex.Lock()
+		err = health.check("health-test-controller", time.Now().Add(defaultHealthMaxReconcileAge+time.Minute))
+		health.mutex.Unlock()
+		assert.NoError(t, err)
+	})
+
+	// the deleted resource is not blocked anymore
+	assert.NoError(t, c.Delete(context.TODO(), pool))
+	_, err := r.Reconcile(context.TODO(), request)
+	assert.NoError(t, err)
+
+	t.Run("max age setting", func(t *testing.T) {
+		SetHealthMaxReconcileAge(map[string]string{HealthMaxReconcileAgeSetting: "60"})
+		assert.Equal(t, time.Minute, health.maxReconcileAge)
+		SetHealthMaxReconcileAge(map[string]string{HealthMaxReconcileAgeSetting: "0"})
+		assert.Equal(t, defaultHealthMaxReconcileAge, health.maxReconcileAge)
+	})
+}
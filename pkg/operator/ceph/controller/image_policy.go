@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/util/registry"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// the client the registries are queried with, overridden by the tests
+var imageRegistryHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// ApplyImagePolicy pins the images to their digests and verifies their signatures according to the image
+// policy. The images already pinned in the recorded statuses are not resolved again until they change,
+// so that the daemons keep running the same digests when the tags are moved. It returns the statuses of
+// the images, in the order of the images, or an error if an image cannot be resolved or verified.
+func ApplyImagePolicy(ctx context.Context, clientset kubernetes.Interface, namespace string, policy *cephv1.ImagePolicySpec, images []string, recorded []cephv1.ImageStatus) ([]cephv1.ImageStatus, error) {
+	if !policy.IsEnabled() {
+		return nil, nil
+	}
+
+	var client *registry.Client
+	var keys []crypto.PublicKey
+	statuses := []cephv1.ImageStatus{}
+	for _, image := range images {
+		if status := cephv1.FindImage(recorded, image); status != nil && (status.Verified || !policy.VerifiesSignatures()) {
+			statuses = append(statuses, *status)
+			continue
+		}
+
+		// the secrets are only read when an image must be resolved
+		if client == nil {
+			var err error
+			client, keys, err = newImagePolicyClient(ctx, clientset, namespace, policy)
+			if err != nil {
+				return nil, err
+			}
+		}
+		digest, err := client.Resolve(ctx, image)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve the digest of image %q", image)
+		}
+		status := cephv1.ImageStatus{Image: image, Digest: digest}
+		if policy.VerifiesSignatures() {
+			if err := client.VerifySignature(ctx, image, digest, keys); err != nil {
+				return nil, errors.Wrapf(err, "failed to verify the signature of image %q", image)
+			}
+			status.Verified = true
+		}
+		logger.Infof("image %q pinned to digest %q", image, digest)
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// newImagePolicyClient returns the registry client authenticated with the pull secret and the keys of
+// the verification keys secret
+func newImagePolicyClient(ctx context.Context, clientset kubernetes.Interface, namespace string, policy *cephv1.ImagePolicySpec) (*registry.Client, []crypto.PublicKey, error) {
+	var credentials map[string]registry.Credentials
+	if policy.PullSecretName != "" {
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, policy.PullSecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get pull secret %q", policy.PullSecretName)
+		}
+		credentials, err = registry.ParseDockerConfig(secret.Data[v1.DockerConfigJsonKey])
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid pull secret %q", policy.PullSecretName)
+		}
+	}
+
+	var keys []crypto.PublicKey
+	if policy.VerifiesSignatures() {
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, policy.VerificationKeysSecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get verification keys secret %q", policy.VerificationKeysSecretName)
+		}
+		for name, data := range secret.Data {
+			secretKeys, err := registry.ParsePublicKeys(data)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid key %q of verification keys secret %q", name, policy.VerificationKeysSecretName)
+			}
+			keys = append(keys, secretKeys...)
+		}
+		if len(keys) == 0 {
+			return nil, nil, errors.Errorf("no key in verification keys secret %q", policy.VerificationKeysSecretName)
+		}
+	}
+	return registry.NewClient(imageRegistryHTTPClient, credentials), keys, nil
+}
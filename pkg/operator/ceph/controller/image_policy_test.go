@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestApplyImagePolicy(t *testing.T) {
+	ctx := context.TODO()
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/ceph/ceph/manifests/v16.2.7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+	}))
+	defer server.Close()
+	previousClient := imageRegistryHTTPClient
+	imageRegistryHTTPClient = server.Client()
+	defer func() { imageRegistryHTTPClient = previousClient }()
+	registry := strings.TrimPrefix(server.URL, "https://")
+	image := registry + "/ceph/ceph:v16.2.7"
+	clientset := fake.NewSimpleClientset()
+
+	t.Run("disabled", func(t *testing.T) {
+		images, err := ApplyImagePolicy(ctx, clientset, "ns", &cephv1.ImagePolicySpec{}, []string{image}, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, images)
+	})
+
+	t.Run("pin the digest", func(t *testing.T) {
+		images, err := ApplyImagePolicy(ctx, clientset, "ns", &cephv1.ImagePolicySpec{PinDigests: true}, []string{image}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []cephv1.ImageStatus{{Image: image, Digest: digest}}, images)
+		assert.Equal(t, registry+"/ceph/ceph:v16.2.7@"+digest, images[0].PinnedImage())
+	})
+
+	t.Run("keep the recorded digest", func(t *testing.T) {
+		recorded := []cephv1.ImageStatus{{Image: image, Digest: "sha256:1234"}}
+		images, err := ApplyImagePolicy(ctx, clientset, "ns", &cephv1.ImagePolicySpec{PinDigests: true}, []string{image}, recorded)
+		assert.NoError(t, err)
+		assert.Equal(t, recorded, images)
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		_, err := ApplyImagePolicy(ctx, clientset, "ns", &cephv1.ImagePolicySpec{PinDigests: true}, []string{registry + "/ceph/ceph:v17"}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("unverified recorded digest", func(t *testing.T) {
+		// the keys secret is read since the recorded digest was not verified
+		recorded := []cephv1.ImageStatus{{Image: image, Digest: digest}}
+		_, err := ApplyImagePolicy(ctx, clientset, "ns", &cephv1.ImagePolicySpec{VerificationKeysSecretName: "keys"}, []string{image}, recorded)
+		assert.Error(t, err)
+
+		secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "ns"}, Data: map[string][]byte{"cosign.pub": []byte("invalid")}}
+		_, err = clientset.CoreV1().Secrets("ns").Create(ctx, secret, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		_, err = ApplyImagePolicy(ctx, clientset, "ns", &cephv1.ImagePolicySpec{VerificationKeysSecretName: "keys"}, []string{image}, recorded)
+		assert.Error(t, err)
+
+		// a verified digest is not verified again
+		recorded[0].Verified = true
+		images, err := ApplyImagePolicy(ctx, clientset, "ns", &cephv1.ImagePolicySpec{VerificationKeysSecretName: "keys"}, []string{image}, recorded)
+		assert.NoError(t, err)
+		assert.Equal(t, recorded, images)
+	})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RotateKeyAnnotation is the annotation of a resource requesting the rotation of its keys once, the
+// annotation is removed after the rotation
+const RotateKeyAnnotation = "ceph.rook.io/rotate-key"
+
+// KeyRotation applies a key rotation policy to the status of the keys. It returns the status of the
+// keys after the rotation and whether the keys must be rotated. The keys are rotated when the
+// generation of the policy is increased above the generation of the keys, or when the keys are older
+// than the period of the policy. The keys without status are considered created now.
+func KeyRotation(policy *cephv1.KeyRotationPolicySpec, status *cephv1.KeyRotationStatus, now time.Time) (*cephv1.KeyRotationStatus, bool) {
+	if status == nil || status.LastRotationTime == nil {
+		status = &cephv1.KeyRotationStatus{LastRotationTime: &metav1.Time{Time: now}}
+	}
+	if policy == nil {
+		return status, false
+	}
+
+	rotate := policy.Generation > status.Generation
+	if policy.Period != nil && policy.Period.Duration > 0 && !now.Before(status.LastRotationTime.Add(policy.Period.Duration)) {
+		rotate = true
+	}
+	if !rotate {
+		return status, false
+	}
+
+	generation := status.Generation + 1
+	if policy.Generation > generation {
+		generation = policy.Generation
+	}
+	return &cephv1.KeyRotationStatus{Generation: generation, LastRotationTime: &metav1.Time{Time: now}}, true
+}
+
+// KeyRotationRequeueAfter returns the time until the next periodic rotation of the keys, or zero when
+// the keys are not rotated periodically
+func KeyRotationRequeueAfter(policy *cephv1.KeyRotationPolicySpec, status *cephv1.KeyRotationStatus, now time.Time) time.Duration {
+	if policy == nil || policy.Period == nil || policy.Period.Duration <= 0 || status == nil || status.LastRotationTime == nil {
+		return 0
+	}
+	next := status.LastRotationTime.Add(policy.Period.Duration).Sub(now)
+	if next < time.Second {
+		return time.Second
+	}
+	return next
+}
+
+// IsKeyRotationRequested returns whether the rotation of the keys of the resource is requested with the
+// rotate-key annotation
+func IsKeyRotationRequested(obj client.Object) bool {
+	return obj.GetAnnotations()[RotateKeyAnnotation] == "true"
+}
+
+// RequestedKeyRotation applies the key rotation policy like KeyRotation, and also rotates the keys when
+// the rotation is requested with the rotate-key annotation of the resource
+func RequestedKeyRotation(obj client.Object, policy *cephv1.KeyRotationPolicySpec, status *cephv1.KeyRotationStatus, now time.Time) (*cephv1.KeyRotationStatus, bool) {
+	status, rotate := KeyRotation(policy, status, now)
+	if rotate || !IsKeyRotationRequested(obj) {
+		return status, rotate
+	}
+	return &cephv1.KeyRotationStatus{Generation: status.Generation + 1, LastRotationTime: &metav1.Time{Time: now}}, true
+}
+
+// RemoveKeyRotationAnnotation removes the rotate-key annotation of a resource once its keys are rotated
+func RemoveKeyRotationAnnotation(ctx context.Context, c client.Client, obj client.Object) error {
+	if _, ok := obj.GetAnnotations()[RotateKeyAnnotation]; !ok {
+		return nil
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotations := obj.GetAnnotations()
+	delete(annotations, RotateKeyAnnotation)
+	obj.SetAnnotations(annotations)
+	if err := c.Patch(ctx, obj, patch); err != nil {
+		return errors.Wrapf(err, "failed to remove the %q annotation of %q", RotateKeyAnnotation, obj.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	created := &metav1.Time{Time: now.Add(-24 * time.Hour)}
+
+	// the keys without status are considered created now
+	status, rotate := KeyRotation(nil, nil, now)
+	assert.False(t, rotate)
+	assert.Equal(t, &cephv1.KeyRotationStatus{LastRotationTime: &metav1.Time{Time: now}}, status)
+	status, rotate = KeyRotation(&cephv1.KeyRotationPolicySpec{Period: &metav1.Duration{Duration: time.Hour}}, nil, now)
+	assert.False(t, rotate)
+	assert.Equal(t, 0, status.Generation)
+
+	// on demand
+	current := &cephv1.KeyRotationStatus{Generation: 1, LastRotationTime: created}
+	status, rotate = KeyRotation(&cephv1.KeyRotationPolicySpec{Generation: 1}, current, now)
+	assert.False(t, rotate)
+	assert.Equal(t, current, status)
+	status, rotate = KeyRotation(&cephv1.KeyRotationPolicySpec{Generation: 3}, current, now)
+	assert.True(t, rotate)
+	assert.Equal(t, &cephv1.KeyRotationStatus{Generation: 3, LastRotationTime: &metav1.Time{Time: now}}, status)
+	_, rotate = KeyRotation(&cephv1.KeyRotationPolicySpec{Generation: 1}, nil, now)
+	assert.True(t, rotate)
+
+	// periodic
+	policy := &cephv1.KeyRotationPolicySpec{Period: &metav1.Duration{Duration: 48 * time.Hour}}
+	_, rotate = KeyRotation(policy, current, now)
+	assert.False(t, rotate)
+	assert.Equal(t, 24*time.Hour, KeyRotationRequeueAfter(policy, current, now))
+	policy.Period.Duration = 24 * time.Hour
+	status, rotate = KeyRotation(policy, current, now)
+	assert.True(t, rotate)
+	assert.Equal(t, 2, status.Generation)
+	assert.Equal(t, 24*time.Hour, KeyRotationRequeueAfter(policy, status, now))
+	assert.Equal(t, time.Second, KeyRotationRequeueAfter(policy, current, now))
+
+	assert.Equal(t, time.Duration(0), KeyRotationRequeueAfter(nil, current, now))
+	assert.Equal(t, time.Duration(0), KeyRotationRequeueAfter(&cephv1.KeyRotationPolicySpec{Generation: 1}, current, now))
+}
+
+func TestRequestedKeyRotation(t *testing.T) {
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	current := &cephv1.KeyRotationStatus{Generation: 1, LastRotationTime: &metav1.Time{Time: now.Add(-time.Hour)}}
+	obj := &cephv1.CephClient{ObjectMeta: metav1.ObjectMeta{Name: "client1", Namespace: "rook-ceph"}}
+
+	status, rotate := RequestedKeyRotation(obj, nil, current, now)
+	assert.False(t, rotate)
+	assert.Equal(t, current, status)
+
+	obj.Annotations = map[string]string{RotateKeyAnnotation: "true"}
+	status, rotate = RequestedKeyRotation(obj, nil, current, now)
+	assert.True(t, rotate)
+	assert.Equal(t, &cephv1.KeyRotationStatus{Generation: 2, LastRotationTime: &metav1.Time{Time: now}}, status)
+
+	// the rotation of the policy is not done twice
+	status, rotate = RequestedKeyRotation(obj, &cephv1.KeyRotationPolicySpec{Generation: 3}, current, now)
+	assert.True(t, rotate)
+	assert.Equal(t, 3, status.Generation)
+
+	obj.Annotations[RotateKeyAnnotation] = "false"
+	_, rotate = RequestedKeyRotation(obj, nil, current, now)
+	assert.False(t, rotate)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	mirrorPeerConnected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_mirror_peer_connected",
+		Help: "Whether the remote cluster of the bootstrap peer token secret of a mirrored resource can be reached: 1 if connected, 0 otherwise",
+	}, []string{"namespace", "kind", "name", "secret"})
+
+	// probeMirrorPeer is a variable so the unit tests can mock the connection to the peers
+	probeMirrorPeer = cephclient.ProbeMirrorPeer
+
+	// mirrorPeerProbeTimeout is the time to connect to the remote cluster of a peer
+	mirrorPeerProbeTimeout = 15 * time.Second
+)
+
+func init() {
+	metrics.Registry.MustRegister(mirrorPeerConnected)
+}
+
+// MirrorPeerProber checks the connectivity and the authentication to the remote clusters of the
+// mirror peers of a resource with the bootstrap peer tokens of the peers. The result of the last
+// probe of each peer is kept to record an event only when the connectivity of the peer changes.
+type MirrorPeerProber struct {
+	context        *clusterd.Context
+	recorder       record.EventRecorder
+	kind           string
+	namespacedName types.NamespacedName
+	connected      map[string]bool
+}
+
+// NewMirrorPeerProber creates a prober for the peers of the resource of the given kind and name
+func NewMirrorPeerProber(context *clusterd.Context, recorder record.EventRecorder, kind string, namespacedName types.NamespacedName) *MirrorPeerProber {
+	return &MirrorPeerProber{
+		context:        context,
+		recorder:       recorder,
+		kind:           kind,
+		namespacedName: namespacedName,
+		connected:      map[string]bool{},
+	}
+}
+
+// Probe connects to the remote cluster of each bootstrap peer token secret and returns the
+// PeerConnected condition of the resource. The metric of each peer is updated, and an event is
+// recorded on the resource when a peer cannot be reached anymore or can be reached again.
+func (p *MirrorPeerProber) Probe(ctx context.Context, obj client.Object, secretNames []string) cephv1.Condition {
+	failures := []string{}
+	probed := map[string]bool{}
+	for _, secretName := range secretNames {
+		probed[secretName] = true
+		err := p.probeSecret(ctx, secretName)
+		connected := err == nil
+
+		value := 0.0
+		if connected {
+			value = 1
+		}
+		mirrorPeerConnected.WithLabelValues(p.namespacedName.Namespace, p.kind, p.namespacedName.Name, secretName).Set(value)
+
+		previous, probedBefore := p.connected[secretName]
+		p.connected[secretName] = connected
+		if !connected {
+			message := fmt.Sprintf("failed to connect to mirror peer of secret %q. %v", secretName, err)
+			failures = append(failures, message)
+			if !probedBefore || previous {
+				logger.Warningf("%s %q: %s", p.kind, p.namespacedName, message)
+				p.recorder.Event(obj, v1.EventTypeWarning, string(cephv1.PeerConnectionFailedReason), message)
+			}
+			continue
+		}
+		if probedBefore && !previous {
+			message := fmt.Sprintf("connected to mirror peer of secret %q again", secretName)
+			logger.Infof("%s %q: %s", p.kind, p.namespacedName, message)
+			p.recorder.Event(obj, v1.EventTypeNormal, string(cephv1.PeersConnectedReason), message)
+		}
+	}
+
+	// The peers that are not configured anymore are not reported
+	for secretName := range p.connected {
+		if !probed[secretName] {
+			p.clearSecret(secretName)
+		}
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return cephv1.Condition{
+			Type:    cephv1.ConditionPeerConnected,
+			Status:  v1.ConditionFalse,
+			Reason:  cephv1.PeerConnectionFailedReason,
+			Message: strings.Join(failures, "; "),
+		}
+	}
+	return cephv1.Condition{
+		Type:    cephv1.ConditionPeerConnected,
+		Status:  v1.ConditionTrue,
+		Reason:  cephv1.PeersConnectedReason,
+		Message: "the remote clusters of all the mirror peers can be reached",
+	}
+}
+
+func (p *MirrorPeerProber) probeSecret(ctx context.Context, secretName string) error {
+	s, err := p.context.Clientset.CoreV1().Secrets(p.namespacedName.Namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch kubernetes secret %q bootstrap peer", secretName)
+	}
+	token, ok := s.Data["token"]
+	if !ok {
+		return errors.Errorf("failed to find the token in the kubernetes secret %q bootstrap peer", secretName)
+	}
+	return probeMirrorPeer(p.context, token, mirrorPeerProbeTimeout)
+}
+
+// Clear removes the metrics of all the peers of the resource, when its peers are not probed anymore
+func (p *MirrorPeerProber) Clear() {
+	for secretName := range p.connected {
+		p.clearSecret(secretName)
+	}
+}
+
+func (p *MirrorPeerProber) clearSecret(secretName string) {
+	mirrorPeerConnected.DeleteLabelValues(p.namespacedName.Namespace, p.kind, p.namespacedName.Name, secretName)
+	delete(p.connected, secretName)
+}
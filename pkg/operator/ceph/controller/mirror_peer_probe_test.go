@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestMirrorPeerProber(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	for _, name := range []string{"site-a", "site-b"} {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "rook-ceph"},
+			Data:       map[string][]byte{"token": []byte(name)},
+		}
+		_, err := clientset.CoreV1().Secrets("rook-ceph").Create(ctx, secret, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	unreachable := map[string]bool{}
+	probeMirrorPeer = func(context *clusterd.Context, token []byte, timeout time.Duration) error {
+		if unreachable[string(token)] {
+			return errors.New("timed out")
+		}
+		return nil
+	}
+	defer func() { probeMirrorPeer = cephclient.ProbeMirrorPeer }()
+
+	recorder := record.NewFakeRecorder(10)
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Namespace: "rook-ceph"}}
+	p := NewMirrorPeerProber(&clusterd.Context{Clientset: clientset}, recorder, "CephBlockPool", types.NamespacedName{Name: "mirrored", Namespace: "rook-ceph"})
+	defer p.Clear()
+	connected := func(secret string) float64 {
+		return testutil.ToFloat64(mirrorPeerConnected.WithLabelValues("rook-ceph", "CephBlockPool", "mirrored", secret))
+	}
+
+	t.Run("peers connected", func(t *testing.T) {
+		cond := p.Probe(ctx, pool, []string{"site-a", "site-b"})
+		assert.Equal(t, cephv1.ConditionPeerConnected, cond.Type)
+		assert.Equal(t, v1.ConditionTrue, cond.Status)
+		assert.Equal(t, cephv1.PeersConnectedReason, cond.Reason)
+		assert.Equal(t, float64(1), connected("site-a"))
+		assert.Equal(t, float64(1), connected("site-b"))
+		assert.Len(t, recorder.Events, 0)
+	})
+
+	t.Run("peer unreachable", func(t *testing.T) {
+		unreachable["site-b"] = true
+		cond := p.Probe(ctx, pool, []string{"site-a", "site-b"})
+		assert.Equal(t, v1.ConditionFalse, cond.Status)
+		assert.Equal(t, cephv1.PeerConnectionFailedReason, cond.Reason)
+		assert.Contains(t, cond.Message, `secret "site-b"`)
+		assert.Equal(t, float64(1), connected("site-a"))
+		assert.Equal(t, float64(0), connected("site-b"))
+		assert.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, "Warning PeerConnectionFailed")
+
+		// the event is only recorded when the peer becomes unreachable
+		p.Probe(ctx, pool, []string{"site-a", "site-b"})
+		assert.Len(t, recorder.Events, 0)
+	})
+
+	t.Run("peer reachable again", func(t *testing.T) {
+		unreachable["site-b"] = false
+		cond := p.Probe(ctx, pool, []string{"site-a", "site-b"})
+		assert.Equal(t, v1.ConditionTrue, cond.Status)
+		assert.Equal(t, float64(1), connected("site-b"))
+		assert.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, "Normal PeersConnected")
+	})
+
+	t.Run("secret not found", func(t *testing.T) {
+		cond := p.Probe(ctx, pool, []string{"site-a", "site-c"})
+		assert.Equal(t, v1.ConditionFalse, cond.Status)
+		assert.Contains(t, cond.Message, `secret "site-c"`)
+		assert.Len(t, recorder.Events, 1)
+		<-recorder.Events
+
+		// the peer removed from the spec is not reported anymore
+		assert.NotContains(t, p.connected, "site-b")
+	})
+}
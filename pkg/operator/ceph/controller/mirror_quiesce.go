@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DRActionPools returns the names of the CephBlockPools of a CephDRAction, the pools of
+// its images included
+func DRActionPools(action *cephv1.CephDRAction) []string {
+	pools := append([]string{}, action.Spec.Pools...)
+	for _, image := range action.Spec.Images {
+		if !contains(pools, image.Pool) {
+			pools = append(pools, image.Pool)
+		}
+	}
+	return pools
+}
+
+// DRActionFilesystems returns the names of the CephFilesystems of a CephDRAction, the
+// filesystems of its subvolumes included
+func DRActionFilesystems(action *cephv1.CephDRAction) []string {
+	filesystems := append([]string{}, action.Spec.Filesystems...)
+	for _, subvolume := range action.Spec.Subvolumes {
+		if !contains(filesystems, subvolume.Filesystem) {
+			filesystems = append(filesystems, subvolume.Filesystem)
+		}
+	}
+	return filesystems
+}
+
+// IsPoolQuiesced returns whether a CephBlockPool is quiesced for a planned failover. The snapshot
+// schedules of the pool stay paused as long as a quiesce CephDRAction of the pool exists.
+func IsPoolQuiesced(ctx context.Context, c client.Client, pool types.NamespacedName) (bool, error) {
+	return isQuiesced(ctx, c, pool, DRActionPools)
+}
+
+// IsFilesystemQuiesced returns whether a CephFilesystem is quiesced for a planned failover. The
+// snapshot schedules of the filesystem stay paused as long as a quiesce CephDRAction of the
+// filesystem exists.
+func IsFilesystemQuiesced(ctx context.Context, c client.Client, fs types.NamespacedName) (bool, error) {
+	return isQuiesced(ctx, c, fs, DRActionFilesystems)
+}
+
+func isQuiesced(ctx context.Context, c client.Client, name types.NamespacedName, quiesced func(*cephv1.CephDRAction) []string) (bool, error) {
+	actions := &cephv1.CephDRActionList{}
+	err := c.List(ctx, actions, client.InNamespace(name.Namespace))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list the dr actions in namespace %q", name.Namespace)
+	}
+
+	for i := range actions.Items {
+		action := &actions.Items[i]
+		// the schedules are resumed when the action is deleted
+		if action.Spec.Action != cephv1.DRActionQuiesce || !action.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if contains(quiesced(action), name.Name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
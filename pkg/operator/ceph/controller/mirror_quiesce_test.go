@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsQuiesced(t *testing.T) {
+	ctx := context.TODO()
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&cephv1.CephDRAction{
+			ObjectMeta: metav1.ObjectMeta{Name: "failover", Namespace: "rook-ceph"},
+			Spec: cephv1.DRActionSpec{
+				Action:     cephv1.DRActionQuiesce,
+				Images:     []cephv1.DRActionImageSpec{{Pool: "replicapool", Image: "image1"}},
+				Subvolumes: []cephv1.DRActionSubvolumeSpec{{Filesystem: "myfs", Name: "subvol1"}},
+			},
+		},
+		&cephv1.CephDRAction{
+			ObjectMeta: metav1.ObjectMeta{Name: "promote", Namespace: "rook-ceph"},
+			Spec:       cephv1.DRActionSpec{Action: cephv1.DRActionPromote, Pools: []string{"other"}},
+		},
+	).Build()
+
+	quiesced, err := IsPoolQuiesced(ctx, cl, types.NamespacedName{Name: "replicapool", Namespace: "rook-ceph"})
+	assert.NoError(t, err)
+	assert.True(t, quiesced)
+
+	quiesced, err = IsPoolQuiesced(ctx, cl, types.NamespacedName{Name: "replicapool", Namespace: "other-ns"})
+	assert.NoError(t, err)
+	assert.False(t, quiesced)
+
+	// only the quiesce actions pause the schedules
+	quiesced, err = IsPoolQuiesced(ctx, cl, types.NamespacedName{Name: "other", Namespace: "rook-ceph"})
+	assert.NoError(t, err)
+	assert.False(t, quiesced)
+
+	quiesced, err = IsFilesystemQuiesced(ctx, cl, types.NamespacedName{Name: "myfs", Namespace: "rook-ceph"})
+	assert.NoError(t, err)
+	assert.True(t, quiesced)
+
+	quiesced, err = IsFilesystemQuiesced(ctx, cl, types.NamespacedName{Name: "replicapool", Namespace: "rook-ceph"})
+	assert.NoError(t, err)
+	assert.False(t, quiesced)
+}
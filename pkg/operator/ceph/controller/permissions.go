@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// RequireControllerPermissionsSetting is the operator setting refusing to start the controllers
+	// the operator does not have the permissions for
+	RequireControllerPermissionsSetting = "ROOK_REQUIRE_CONTROLLER_PERMISSIONS"
+)
+
+// Permission is an access to a kind of resource a controller requires to reconcile
+type Permission struct {
+	Group    string
+	Resource string
+	Verb     string
+}
+
+func (p Permission) String() string {
+	if p.Group == "" {
+		return fmt.Sprintf("%s %s", p.Verb, p.Resource)
+	}
+	return fmt.Sprintf("%s %s.%s", p.Verb, p.Resource, p.Group)
+}
+
+// NewPermissions returns the permissions of the given verbs on the resources of a group
+func NewPermissions(group string, resources []string, verbs ...string) []Permission {
+	permissions := []Permission{}
+	for _, resource := range resources {
+		for _, verb := range verbs {
+			permissions = append(permissions, Permission{Group: group, Resource: resource, Verb: verb})
+		}
+	}
+	return permissions
+}
+
+// CustomResourcePermissions returns the permissions required to reconcile a Rook custom resource:
+// watching it, adding its finalizer and updating or patching its status
+func CustomResourcePermissions(resource string) []Permission {
+	permissions := NewPermissions("ceph.rook.io", []string{resource}, "get", "list", "watch", "update")
+	return append(permissions, NewPermissions("ceph.rook.io", []string{resource + "/status"}, "update", "patch")...)
+}
+
+// MissingPermissions returns the permissions the operator does not have in the namespace, which
+// is empty to check the permissions in all the namespaces
+func MissingPermissions(ctx context.Context, clientset kubernetes.Interface, namespace string, permissions []Permission) ([]Permission, error) {
+	missing := []Permission{}
+	for _, p := range permissions {
+		review := &authv1.SelfSubjectAccessReview{
+			Spec: authv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authv1.ResourceAttributes{
+					Namespace: namespace,
+					Group:     p.Group,
+					Resource:  p.Resource,
+					Verb:      p.Verb,
+				},
+			},
+		}
+		// a resource "foo/bar" is the subresource "bar" of the resource "foo"
+		if i := strings.Index(p.Resource, "/"); i >= 0 {
+			review.Spec.ResourceAttributes.Resource = p.Resource[:i]
+			review.Spec.ResourceAttributes.Subresource = p.Resource[i+1:]
+		}
+		result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to review the permission to %s", p.String())
+		}
+		if !result.Status.Allowed {
+			missing = append(missing, p)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	reviewed := []authv1.ResourceAttributes{}
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authv1.SelfSubjectAccessReview)
+		attributes := *review.Spec.ResourceAttributes
+		reviewed = append(reviewed, attributes)
+		// only the rook resources are allowed
+		review.Status.Allowed = attributes.Group == "ceph.rook.io"
+		return true, review, nil
+	})
+
+	permissions := append(CustomResourcePermissions("cephblockpools"), NewPermissions("objectbucket.io", []string{"objectbucketclaims"}, "list", "watch")...)
+	missing, err := MissingPermissions(context.TODO(), clientset, "rook-ceph", permissions)
+	assert.NoError(t, err)
+	assert.Equal(t, []Permission{
+		{Group: "objectbucket.io", Resource: "objectbucketclaims", Verb: "list"},
+		{Group: "objectbucket.io", Resource: "objectbucketclaims", Verb: "watch"},
+	}, missing)
+	assert.Equal(t, "list objectbucketclaims.objectbucket.io", missing[0].String())
+
+	assert.Equal(t, 8, len(reviewed))
+	assert.Equal(t, "rook-ceph", reviewed[0].Namespace)
+	// the status is a subresource of the block pools
+	assert.Equal(t, "cephblockpools", reviewed[4].Resource)
+	assert.Equal(t, "status", reviewed[4].Subresource)
+	assert.Equal(t, "update", reviewed[4].Verb)
+	assert.Equal(t, "patch", reviewed[5].Verb)
+}
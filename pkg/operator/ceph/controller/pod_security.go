@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the uid and gid of the ceph user in the ceph container image
+	cephUserID int64 = 167
+
+	chownContainerName = "chown-container-data-dir"
+)
+
+// the flags telling the daemons to drop the root privileges, which they do not have in the
+// restricted mode
+var dropPrivilegesFlags = []string{
+	config.NewFlag("setuser", ""),
+	config.NewFlag("setgroup", ""),
+	config.NewFlag("setuser-match-path", ""),
+}
+
+// ApplyRestrictedPodSecurity makes the pod comply with the "restricted" Pod Security Standard. The
+// containers run as the ceph user without privileges nor capabilities, the logs and crashes are no
+// longer stored on the host and the init container changing the owner of the data directories is
+// removed since the volumes are owned by the ceph group.
+func ApplyRestrictedPodSecurity(podSpec *v1.PodSpec) {
+	runAsNonRoot := true
+	cephUser := cephUserID
+	podSpec.SecurityContext = &v1.PodSecurityContext{
+		RunAsNonRoot:   &runAsNonRoot,
+		RunAsUser:      &cephUser,
+		RunAsGroup:     &cephUser,
+		FSGroup:        &cephUser,
+		SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+	}
+
+	initContainers := []v1.Container{}
+	for _, c := range podSpec.InitContainers {
+		if c.Name == chownContainerName {
+			continue
+		}
+		initContainers = append(initContainers, restrictedContainer(c))
+	}
+	podSpec.InitContainers = initContainers
+	for i := range podSpec.Containers {
+		podSpec.Containers[i] = restrictedContainer(podSpec.Containers[i])
+	}
+
+	for i := range podSpec.Volumes {
+		volume := &podSpec.Volumes[i]
+		if volume.HostPath != nil && (volume.Name == logVolumeName || volume.Name == crashVolumeName) {
+			volume.VolumeSource = v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+		}
+	}
+}
+
+// ApplySecurityProfile confines the containers of the pod of a daemon with the seccomp and AppArmor
+// profiles of the daemon type. It must be applied after the restricted Pod Security Standard, the
+// seccomp profile replacing the RuntimeDefault profile, and again when the containers change since
+// the AppArmor annotations must only name the containers of the pod.
+func ApplySecurityProfile(profiles cephv1.SecurityProfilesSpec, daemonType cephv1.KeyType, objectMeta *metav1.ObjectMeta, podSpec *v1.PodSpec) {
+	profile := profiles.Get(daemonType)
+	if profile == nil {
+		return
+	}
+	for key := range objectMeta.Annotations {
+		if strings.HasPrefix(key, v1.AppArmorBetaContainerAnnotationKeyPrefix) {
+			delete(objectMeta.Annotations, key)
+		}
+	}
+
+	if profile.Seccomp != nil {
+		if podSpec.SecurityContext == nil {
+			podSpec.SecurityContext = &v1.PodSecurityContext{}
+		}
+		podSpec.SecurityContext.SeccompProfile = profile.Seccomp.DeepCopy()
+	}
+
+	if profile.AppArmor != "" {
+		if objectMeta.Annotations == nil {
+			objectMeta.Annotations = map[string]string{}
+		}
+		for _, containers := range [][]v1.Container{podSpec.InitContainers, podSpec.Containers} {
+			for _, c := range containers {
+				objectMeta.Annotations[v1.AppArmorBetaContainerAnnotationKeyPrefix+c.Name] = profile.AppArmor
+			}
+		}
+	}
+}
+
+// RestrictedContainerSecurityContext returns the security context of a container complying with
+// the "restricted" Pod Security Standard
+func RestrictedContainerSecurityContext() *v1.SecurityContext {
+	privileged := false
+	allowPrivilegeEscalation := false
+	runAsNonRoot := true
+	return &v1.SecurityContext{
+		Privileged:               &privileged,
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		RunAsNonRoot:             &runAsNonRoot,
+		Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+	}
+}
+
+func restrictedContainer(c v1.Container) v1.Container {
+	c.SecurityContext = RestrictedContainerSecurityContext()
+	args := []string{}
+	for _, arg := range c.Args {
+		if !isDropPrivilegesFlag(arg) {
+			args = append(args, arg)
+		}
+	}
+	c.Args = args
+	return c
+}
+
+func isDropPrivilegesFlag(arg string) bool {
+	for _, flag := range dropPrivilegesFlags {
+		if strings.HasPrefix(arg, flag) {
+			return true
+		}
+	}
+	return false
+}
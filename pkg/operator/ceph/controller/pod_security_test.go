@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyRestrictedPodSecurity(t *testing.T) {
+	dataPathMap := config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "rook-ceph", "/var/lib/rook")
+	podSpec := v1.PodSpec{
+		InitContainers: []v1.Container{
+			{Name: "init", Args: []string{"--foo=bar"}},
+			ChownCephDataDirsInitContainer(*dataPathMap, "ceph/ceph", nil, v1.ResourceRequirements{}, PodSecurityContext()),
+		},
+		Containers: []v1.Container{
+			{Name: "mgr", Args: []string{"--id=a", "--setuser=ceph", "--setgroup=ceph", "--setuser-match-path=/var/lib/ceph/mon/store.db"}, SecurityContext: PrivilegedContext(true)},
+		},
+		Volumes: DaemonVolumes(dataPathMap, "rook-ceph-mgr-a-keyring"),
+	}
+
+	ApplyRestrictedPodSecurity(&podSpec)
+
+	assert.True(t, *podSpec.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, int64(167), *podSpec.SecurityContext.RunAsUser)
+	assert.Equal(t, int64(167), *podSpec.SecurityContext.FSGroup)
+	assert.Equal(t, v1.SeccompProfileTypeRuntimeDefault, podSpec.SecurityContext.SeccompProfile.Type)
+	assert.Len(t, podSpec.InitContainers, 1)
+	assert.Equal(t, []string{"--foo=bar"}, podSpec.InitContainers[0].Args)
+	assert.Equal(t, []string{"--id=a"}, podSpec.Containers[0].Args)
+	for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+		assert.False(t, *c.SecurityContext.Privileged)
+		assert.False(t, *c.SecurityContext.AllowPrivilegeEscalation)
+		assert.Nil(t, c.SecurityContext.RunAsUser)
+		assert.Equal(t, []v1.Capability{"ALL"}, c.SecurityContext.Capabilities.Drop)
+	}
+	for _, volume := range podSpec.Volumes {
+		assert.Nil(t, volume.HostPath, volume.Name)
+	}
+}
+
+func TestApplySecurityProfile(t *testing.T) {
+	newPod := func() (metav1.ObjectMeta, v1.PodSpec) {
+		return metav1.ObjectMeta{}, v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init"}},
+			Containers:     []v1.Container{{Name: "mon"}},
+		}
+	}
+
+	t.Run("no profile", func(t *testing.T) {
+		objectMeta, podSpec := newPod()
+		ApplySecurityProfile(nil, cephv1.KeyMon, &objectMeta, &podSpec)
+		assert.Nil(t, podSpec.SecurityContext)
+		assert.Empty(t, objectMeta.Annotations)
+	})
+
+	t.Run("daemon profile", func(t *testing.T) {
+		objectMeta, podSpec := newPod()
+		profiles := cephv1.SecurityProfilesSpec{
+			cephv1.KeyAll: {AppArmor: "runtime/default"},
+			cephv1.KeyMon: {
+				Seccomp:  &v1.SeccompProfile{Type: v1.SeccompProfileTypeLocalhost, LocalhostProfile: &[]string{"rook/ceph-daemon.json"}[0]},
+				AppArmor: "localhost/rook-ceph-daemon",
+			},
+		}
+		ApplySecurityProfile(profiles, cephv1.KeyMon, &objectMeta, &podSpec)
+		assert.Equal(t, v1.SeccompProfileTypeLocalhost, podSpec.SecurityContext.SeccompProfile.Type)
+		assert.Equal(t, "rook/ceph-daemon.json", *podSpec.SecurityContext.SeccompProfile.LocalhostProfile)
+		assert.Equal(t, "localhost/rook-ceph-daemon", objectMeta.Annotations["container.apparmor.security.beta.kubernetes.io/init"])
+		assert.Equal(t, "localhost/rook-ceph-daemon", objectMeta.Annotations["container.apparmor.security.beta.kubernetes.io/mon"])
+
+		// only the remaining containers are annotated
+		podSpec.InitContainers = nil
+		ApplySecurityProfile(profiles, cephv1.KeyMon, &objectMeta, &podSpec)
+		assert.Len(t, objectMeta.Annotations, 1)
+		assert.Contains(t, objectMeta.Annotations, "container.apparmor.security.beta.kubernetes.io/mon")
+	})
+
+	t.Run("replaces the restricted seccomp profile", func(t *testing.T) {
+		objectMeta, podSpec := newPod()
+		ApplyRestrictedPodSecurity(&podSpec)
+		profiles := cephv1.SecurityProfilesSpec{cephv1.KeyAll: {Seccomp: &v1.SeccompProfile{Type: v1.SeccompProfileTypeLocalhost}}}
+		ApplySecurityProfile(profiles, cephv1.KeyMgr, &objectMeta, &podSpec)
+		assert.Equal(t, v1.SeccompProfileTypeLocalhost, podSpec.SecurityContext.SeccompProfile.Type)
+		assert.True(t, *podSpec.SecurityContext.RunAsNonRoot)
+		assert.Empty(t, objectMeta.Annotations)
+	})
+}
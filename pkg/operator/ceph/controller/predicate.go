@@ -49,6 +49,11 @@ const (
 // WatchControllerPredicate is a special update filter for update events
 // do not reconcile if the the status changes, this avoids a reconcile storm loop
 //
+// The update events of all the CRs are filtered the same: a CR is reconciled when its spec, its
+// labels, its deletion timestamp or its dry run annotation change. The updates of its status or of
+// the rest of its metadata, such as its other annotations, finalizers or managed fields, are skipped
+// since a reconcile would re-run all the Ceph commands for nothing.
+//
 // returning 'true' means triggering a reconciliation
 // returning 'false' means do NOT trigger a reconciliation
 func WatchControllerPredicate() predicate.Funcs {
@@ -63,353 +68,54 @@ func WatchControllerPredicate() predicate.Funcs {
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			logger.Debugf("update event from a CR: %q", e.ObjectOld.GetName())
-			// resource.Quantity has non-exportable fields, so we use its comparator method
-			resourceQtyComparer := cmp.Comparer(func(x, y resource.Quantity) bool { return x.Cmp(y) == 0 })
-
-			switch objOld := e.ObjectOld.(type) {
-			case *cephv1.CephObjectStore:
-				objNew := e.ObjectNew.(*cephv1.CephObjectStore)
-				logger.Debug("update event on CephObjectStore CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
-					return true
-				}
-
-			case *cephv1.CephObjectStoreUser:
-				objNew := e.ObjectNew.(*cephv1.CephObjectStoreUser)
-				logger.Debug("update event on CephObjectStoreUser CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-
-			case *cephv1.CephObjectRealm:
-				objNew := e.ObjectNew.(*cephv1.CephObjectRealm)
-				logger.Debug("update event on CephObjectRealm")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-
-			case *cephv1.CephObjectZoneGroup:
-				objNew := e.ObjectNew.(*cephv1.CephObjectZoneGroup)
-				logger.Debug("update event on CephObjectZoneGroup")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-
-			case *cephv1.CephObjectZone:
-				objNew := e.ObjectNew.(*cephv1.CephObjectZone)
-				logger.Debug("update event on CephObjectZone")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-
-			case *cephv1.CephBlockPool:
-				objNew := e.ObjectNew.(*cephv1.CephBlockPool)
-				logger.Debug("update event on CephBlockPool CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-
-			case *cephv1.CephFilesystem:
-				objNew := e.ObjectNew.(*cephv1.CephFilesystem)
-				logger.Debug("update event on CephFilesystem CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
-					return true
-				}
-
-			case *cephv1.CephNFS:
-				objNew := e.ObjectNew.(*cephv1.CephNFS)
-				logger.Debug("update event on CephNFS CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
-					return true
-				}
-
-			case *cephv1.CephRBDMirror:
-				objNew := e.ObjectNew.(*cephv1.CephRBDMirror)
-				logger.Debug("update event on CephRBDMirror CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
-					return true
-				}
-
-			case *cephv1.CephClient:
-				objNew := e.ObjectNew.(*cephv1.CephClient)
-				logger.Debug("update event on CephClient CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
-					return true
-				}
-
-			case *cephv1.CephFilesystemMirror:
-				objNew := e.ObjectNew.(*cephv1.CephFilesystemMirror)
-				logger.Debug("update event on CephFilesystemMirror CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", DoNotReconcileLabelName, objNew.Name)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
-					return true
-				}
-
-			case *cephv1.CephBucketTopic:
-				objNew := e.ObjectNew.(*cephv1.CephBucketTopic)
-				logger.Debug("update event on CephBucketTopic CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", objNew.Name, DoNotReconcileLabelName)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
-					return true
-				}
+			objOld, objNew := e.ObjectOld, e.ObjectNew
+			kind := ObjectKind(objNew)
+
+			// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
+			if IsDoNotReconcile(objNew.GetLabels()) {
+				logger.Debugf("object %q matched on update but %q label is set, doing nothing", objNew.GetName(), DoNotReconcileLabelName)
+				RecordSkippedEvent(kind, SkippedEventDoNotReconcile)
+				return false
+			}
 
-			case *cephv1.CephBucketNotification:
-				objNew := e.ObjectNew.(*cephv1.CephBucketNotification)
-				logger.Debug("update event on CephBucketNotification CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", objNew.Name, DoNotReconcileLabelName)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+			if obcOld, ok := objOld.(*bktv1alpha1.ObjectBucketClaim); ok {
+				obcNew := objNew.(*bktv1alpha1.ObjectBucketClaim)
+				if !reflect.DeepEqual(obcOld.Labels, obcNew.Labels) {
+					logger.Infof("CR labels has changed for %q", obcNew.Name)
 					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
-				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
+				} else if obcOld.Spec.ObjectBucketName != obcNew.Spec.ObjectBucketName {
+					logger.Infof("CR %q bucket name changed from %q to %q", obcNew.Name, obcOld.Spec.ObjectBucketName, obcNew.Spec.ObjectBucketName)
 					return true
 				}
+				logger.Debugf("no change in CR %q", obcNew.Name)
+				RecordSkippedEvent(kind, SkippedEventUnchanged)
+				return false
+			}
 
-			case *cephv1.CephFilesystemSubVolumeGroup:
-				objNew := e.ObjectNew.(*cephv1.CephFilesystemSubVolumeGroup)
-				logger.Debug("update event on CephFilesystemSubVolumeGroup CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", objNew.Name, DoNotReconcileLabelName)
-					return false
-				}
-				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
-				if diff != "" {
-					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
-					return true
-				} else if objectToBeDeleted(objOld, objNew) {
-					logger.Debugf("CR %q is going be deleted", objNew.Name)
-					return true
-				} else if objOld.GetGeneration() != objNew.GetGeneration() {
-					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
-				}
+			if diff := specDiff(objOld, objNew); diff != "" {
+				logger.Infof("CR has changed for %q. diff=%s", objNew.GetName(), diff)
+				return true
+			} else if objectToBeDeleted(objOld, objNew) {
+				logger.Debugf("CR %q is going be deleted", objNew.GetName())
+				return true
+			} else if isUpgrade(objOld.GetLabels(), objNew.GetLabels()) {
 				// Handling upgrades
-				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
-				if isUpgrade {
-					return true
-				}
-
-			case *bktv1alpha1.ObjectBucketClaim:
-				objNew := e.ObjectNew.(*bktv1alpha1.ObjectBucketClaim)
-				logger.Debug("update event on ObjectBucketClaim CR")
-				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				IsDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
-				if IsDoNotReconcile {
-					logger.Debugf("object %q matched on update but %q label is set, doing nothing", objNew.Name, DoNotReconcileLabelName)
-					return false
-				}
-				if !reflect.DeepEqual(objOld.Labels, objNew.Labels) {
-					logger.Infof("CR labels has changed for %q", objNew.Name)
-					return true
-				} else if objOld.Spec.ObjectBucketName != objNew.Spec.ObjectBucketName {
-					logger.Infof("CR %q bucket name changed from %q to %q", objNew.Name, objOld.Spec.ObjectBucketName, objNew.Spec.ObjectBucketName)
-					return true
-				}
-				logger.Debugf("no change in CR %q", objNew.Name)
+				return true
+			} else if !reflect.DeepEqual(objOld.GetLabels(), objNew.GetLabels()) {
+				logger.Infof("CR labels have changed for %q", objNew.GetName())
+				return true
+			} else if IsDryRun(objOld) != IsDryRun(objNew) {
+				logger.Infof("CR %q dry run annotation has changed", objNew.GetName())
+				return true
+			} else if isRemoveFinalizerRequested(objOld) != isRemoveFinalizerRequested(objNew) {
+				logger.Infof("CR %q remove finalizer annotation has changed", objNew.GetName())
+				return true
+			} else if IsKeyRotationRequested(objOld) != IsKeyRotationRequested(objNew) {
+				logger.Infof("CR %q rotate key annotation has changed", objNew.GetName())
+				return true
 			}
+			logger.Debugf("skipping resource %q update with unchanged spec and labels", objNew.GetName())
+			RecordSkippedEvent(kind, SkippedEventUnchanged)
 			return false
 		},
 		GenericFunc: func(e event.GenericEvent) bool {
@@ -418,6 +124,30 @@ func WatchControllerPredicate() predicate.Funcs {
 	}
 }
 
+// specDiff returns the diff between the specs of the old and the new CR, empty if unchanged. All the
+// CRDs have the status subresource, so their generation is only bumped when their spec changes and
+// the specs are only compared when it was bumped. The objects created without a generation, such as
+// by the tests, are always compared.
+func specDiff(objOld, objNew client.Object) string {
+	if objNew.GetGeneration() != 0 && objOld.GetGeneration() == objNew.GetGeneration() {
+		return ""
+	}
+	specOld := reflect.Indirect(reflect.ValueOf(objOld)).FieldByName("Spec")
+	specNew := reflect.Indirect(reflect.ValueOf(objNew)).FieldByName("Spec")
+	if !specOld.IsValid() || !specNew.IsValid() {
+		return ""
+	}
+	// resource.Quantity has non-exportable fields, so we use its comparator method
+	resourceQtyComparer := cmp.Comparer(func(x, y resource.Quantity) bool { return x.Cmp(y) == 0 })
+	return cmp.Diff(specOld.Interface(), specNew.Interface(), resourceQtyComparer, IgnoreTasks)
+}
+
+// IgnoreTasks ignores the tasks of a spec when comparing it, the tasks are run by the task
+// controllers without reconciling the rest of the resource
+var IgnoreTasks = cmp.FilterPath(func(path cmp.Path) bool {
+	return len(path) == 2 && path.Last().String() == ".Tasks"
+}, cmp.Ignore())
+
 func objectToBeDeleted(oldObj, newObj client.Object) bool {
 	return !oldObj.GetDeletionTimestamp().Equal(newObj.GetDeletionTimestamp())
 }
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/operator/ceph/config"
@@ -30,6 +31,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 var (
@@ -224,6 +226,92 @@ func TestIsDoNotReconcile(t *testing.T) {
 	assert.True(t, b)
 }
 
+func TestWatchControllerPredicate(t *testing.T) {
+	p := WatchControllerPredicate()
+	newGroup := func() *cephv1.CephFilesystemSubVolumeGroup {
+		return &cephv1.CephFilesystemSubVolumeGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "group-a", Namespace: namespace, Generation: 1, Labels: map[string]string{"foo": "bar"}},
+			Spec:       cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs"},
+		}
+	}
+	skipped := func(reason string) float64 {
+		return testutil.ToFloat64(skippedEvents.WithLabelValues("CephFilesystemSubVolumeGroup", reason))
+	}
+
+	t.Run("status and metadata only", func(t *testing.T) {
+		objOld, objNew := newGroup(), newGroup()
+		objNew.Status = &cephv1.CephFilesystemSubVolumeGroupStatus{Phase: "Ready"}
+		objNew.Annotations = map[string]string{"foo": "bar"}
+		objNew.Finalizers = []string{"cephfilesystemsubvolumegroup.ceph.rook.io"}
+		before := skipped(SkippedEventUnchanged)
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+		assert.Equal(t, before+1, skipped(SkippedEventUnchanged))
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		objOld, objNew := newGroup(), newGroup()
+		objNew.Generation = 2
+		objNew.Spec.FilesystemName = "otherfs"
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+
+		// the generation is not bumped without a spec change
+		objNew = newGroup()
+		objNew.Spec.FilesystemName = "otherfs"
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+	})
+
+	t.Run("tasks only", func(t *testing.T) {
+		fsOld := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: namespace, Generation: 1}}
+		fsNew := fsOld.DeepCopy()
+		fsNew.Generation = 2
+		fsNew.Spec.Tasks = []cephv1.TaskSpec{{Name: "scrub", Type: cephv1.TaskTypeScrub}}
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: fsOld, ObjectNew: fsNew}))
+
+		fsNew.Spec.PreserveFilesystemOnDelete = true
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: fsOld, ObjectNew: fsNew}))
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		objOld, objNew := newGroup(), newGroup()
+		objNew.Labels["foo"] = "baz"
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+
+		objNew.Labels[DoNotReconcileLabelName] = "true"
+		before := skipped(SkippedEventDoNotReconcile)
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+		assert.Equal(t, before+1, skipped(SkippedEventDoNotReconcile))
+
+		// the resource is reconciled once the label is removed
+		objOld, objNew = objNew, newGroup()
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+	})
+
+	t.Run("deletion", func(t *testing.T) {
+		objOld, objNew := newGroup(), newGroup()
+		now := metav1.Now()
+		objNew.DeletionTimestamp = &now
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		objOld := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: namespace, Generation: 1}}
+		objNew := objOld.DeepCopy()
+		objNew.Annotations = map[string]string{DryRunAnnotation: "true"}
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+
+		// the spec is applied once the annotation is removed
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: objNew, ObjectNew: objOld}))
+	})
+
+	t.Run("kinds without a specific case", func(t *testing.T) {
+		objOld := &cephv1.CephBlockPoolRadosNamespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: namespace, Generation: 1}, Spec: cephv1.CephBlockPoolRadosNamespaceSpec{BlockPoolName: "pool-a"}}
+		objNew := objOld.DeepCopy()
+		objNew.Generation = 2
+		objNew.Spec.BlockPoolName = "pool-b"
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: objOld, ObjectNew: objNew}))
+	})
+}
+
 func TestDuplicateCephClusters(t *testing.T) {
 	ctx := context.TODO()
 	namespace := "rook-ceph"
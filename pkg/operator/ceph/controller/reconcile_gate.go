@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/util"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// WithReconcileGates wraps the reconciler of a controller with the gates run around the reconciles of
+// its resources, from the outermost:
+//   - the reconcile order, deferring the reconciles until the resources they depend on converge
+//   - the backpressure, pausing the reconciles creating churn while the CephCluster is unhealthy
+//   - the health, reporting the controllers failing to reconcile in the readiness check
+//   - the stuck finalizers, reporting and removing on request the finalizers of the deleted resources
+//   - the standard conditions, set from the result of the reconciles
+func WithReconcileGates(controllerName string, c client.Client, object client.Object, r reconcile.Reconciler) reconcile.Reconciler {
+	resource := gatedResource{controllerName: controllerName, client: c, object: object, kind: ObjectKind(object)}
+	r = &conditionsGate{gatedResource: resource, reconciler: r}
+	r = newStuckFinalizerGate(resource, r)
+	r = newHealthGate(resource, r)
+	r = newBackpressureGate(resource, r)
+	return &orderGate{gatedResource: resource, reconciler: r}
+}
+
+// gatedResource is the kind of the resources of a controller whose reconciles are wrapped by a gate
+type gatedResource struct {
+	controllerName string
+	client         client.Client
+	object         client.Object
+	kind           string
+}
+
+// get returns the latest version of a resource, or nil when it is not found
+func (g *gatedResource) get(ctx context.Context, name types.NamespacedName) (client.Object, error) {
+	object := g.object.DeepCopyObject().(client.Object)
+	if err := g.client.Get(ctx, name, object); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return object, nil
+}
+
+// debug returns whether the gates log their decisions, when the logger of the controller is at the
+// debug level
+func (g *gatedResource) debug() bool {
+	return util.LoggerLevelAt(g.controllerName, capnslog.DEBUG)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rook/rook/pkg/util"
+	"github.com/rook/rook/pkg/util/tracing"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The reconcile metrics of controller-runtime are only labeled per controller, the metrics of the
+// operator are also labeled per resource to find the resource a controller is failing on
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rook_ceph_reconcile_duration_seconds",
+		Help:    "Duration of the reconciles of a resource by a controller",
+		Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"controller", "namespace", "name"})
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rook_ceph_reconcile_errors_total",
+		Help: "The number of reconciles of a resource by a controller that returned an error",
+	}, []string{"controller", "namespace", "name"})
+	reconcileRequeues = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rook_ceph_reconcile_requeues_total",
+		Help: "The number of reconciles of a resource by a controller that requeued the resource to retry",
+	}, []string{"controller", "namespace", "name"})
+	blockedResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_blocked_resources",
+		Help: "The number of resources of a controller whose last reconcile failed or is waiting to be retried",
+	}, []string{"controller"})
+	skippedEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rook_ceph_skipped_events_total",
+		Help: "The number of update events of the resources of a kind that did not trigger a reconcile",
+	}, []string{"kind", "reason"})
+)
+
+const (
+	// SkippedEventUnchanged is the reason of the skipped update events changing neither the spec, the
+	// labels nor the deletion timestamp of the resource, such as the updates of its status
+	SkippedEventUnchanged = "unchanged"
+	// SkippedEventDoNotReconcile is the reason of the skipped update events of the resources with the
+	// do_not_reconcile label
+	SkippedEventDoNotReconcile = "do_not_reconcile"
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDuration, reconcileErrors, reconcileRequeues, blockedResources, skippedEvents)
+}
+
+// RecordSkippedEvent counts an update event of a resource of the kind that did not trigger a reconcile
+func RecordSkippedEvent(kind, reason string) {
+	skippedEvents.WithLabelValues(kind, reason).Inc()
+}
+
+// ObjectKind returns the kind of the object from its type, since the objects of the events do not
+// always have their TypeMeta set
+func ObjectKind(object runtime.Object) string {
+	return reflect.TypeOf(object).Elem().Name()
+}
+
+// metricsReconciler exports the reconcile metrics of the resources of a controller
+type metricsReconciler struct {
+	controllerName string
+	client         client.Client
+	object         client.Object
+	reconciler     reconcile.Reconciler
+	mutex          sync.Mutex
+	blocked        map[types.NamespacedName]bool
+	// the kind of the resources, whose commands are attributed to their reconcile span
+	kind string
+}
+
+// WithReconcileMetrics returns a reconciler exporting the duration, the errors and the requeues of the
+// reconciles of the resources of the given type, and the number of resources blocked, whose last
+// reconcile returned an error or requeued the resource to retry. The metrics of a resource are removed
+// when it is not found after a successful reconcile. Each reconcile is traced as the parent span of the
+// commands of the resource, and its context holds the logger of the resource, logging the reconciles
+// at the debug level. The lines of the package loggers written by the reconcile get the same fields.
+func WithReconcileMetrics(controllerName string, c client.Client, object client.Object, r reconcile.Reconciler) reconcile.Reconciler {
+	blockedResources.WithLabelValues(controllerName).Set(0)
+	return &metricsReconciler{
+		controllerName: controllerName,
+		client:         c,
+		object:         object,
+		reconciler:     r,
+		blocked:        map[types.NamespacedName]bool{},
+		kind:           ObjectKind(object),
+	}
+}
+
+// Reconcile reconciles the resource with the wrapped reconciler and updates the metrics
+func (r *metricsReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	ctx, span := tracing.StartResource(ctx, r.kind, request.Namespace, request.Name, "reconcile "+r.kind)
+	span.SetAttribute("rook.controller", r.controllerName)
+	span.SetAttribute("k8s.namespace.name", request.Namespace)
+	span.SetAttribute("rook.resource.name", request.Name)
+	log := ctrllog.Log.WithName(r.controllerName).WithValues("controller", r.controllerName, "kind", r.kind, "namespace", request.Namespace, "name", request.Name)
+	ctx = ctrllog.IntoContext(ctx, log)
+	debug := util.LoggerLevelAt(r.controllerName, capnslog.DEBUG)
+	if debug {
+		log.Info("reconciling")
+	}
+	start := time.Now()
+	done := util.WithLogFields("controller", r.controllerName, "kind", r.kind, "namespace", request.Namespace, "name", request.Name)
+	result, err := r.reconciler.Reconcile(ctx, request)
+	done()
+	duration := time.Since(start)
+	if debug {
+		log.Info("reconciled", "duration", duration.String(), "requeue", result.Requeue, "requeueAfter", result.RequeueAfter.String(), "error", errorString(err))
+	}
+	span.SetAttribute("rook.reconcile.requeue", result.Requeue || result.RequeueAfter > 0)
+	span.SetError(err)
+	span.End()
+
+	labels := []string{r.controllerName, request.Namespace, request.Name}
+	reconcileDuration.WithLabelValues(labels...).Observe(duration.Seconds())
+	if err != nil {
+		reconcileErrors.WithLabelValues(labels...).Inc()
+	}
+	if result.Requeue {
+		reconcileRequeues.WithLabelValues(labels...).Inc()
+	}
+
+	if err == nil && result.IsZero() {
+		object := r.object.DeepCopyObject().(client.Object)
+		if kerrors.IsNotFound(r.client.Get(ctx, request.NamespacedName, object)) {
+			r.forget(request.NamespacedName)
+			return result, err
+		}
+	}
+	r.setBlocked(request.NamespacedName, err != nil || result.Requeue)
+	return result, err
+}
+
+// logFieldsReconciler attaches the controller and the reconciled resource to the lines of the package
+// loggers written by the reconciles of a controller
+type logFieldsReconciler struct {
+	controllerName string
+	reconciler     reconcile.Reconciler
+}
+
+// WithReconcileLogFields returns a reconciler attaching the controller, the namespace and the name of
+// the reconciled resource to the lines of the package loggers, for the controllers that do not export
+// the metrics of WithReconcileMetrics
+func WithReconcileLogFields(controllerName string, r reconcile.Reconciler) reconcile.Reconciler {
+	return &logFieldsReconciler{controllerName: controllerName, reconciler: r}
+}
+
+// Reconcile reconciles the resource with the wrapped reconciler
+func (r *logFieldsReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	defer util.WithLogFields("controller", r.controllerName, "namespace", request.Namespace, "name", request.Name)()
+	return r.reconciler.Reconcile(ctx, request)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (r *metricsReconciler) setBlocked(name types.NamespacedName, blocked bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if blocked {
+		r.blocked[name] = true
+	} else {
+		delete(r.blocked, name)
+	}
+	blockedResources.WithLabelValues(r.controllerName).Set(float64(len(r.blocked)))
+}
+
+// forget removes the metrics of a deleted resource
+func (r *metricsReconciler) forget(name types.NamespacedName) {
+	labels := []string{r.controllerName, name.Namespace, name.Name}
+	reconcileDuration.DeleteLabelValues(labels...)
+	reconcileErrors.DeleteLabelValues(labels...)
+	reconcileRequeues.DeleteLabelValues(labels...)
+	r.setBlocked(name, false)
+}
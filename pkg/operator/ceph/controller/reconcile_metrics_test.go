@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileMetrics(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{})
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"}}
+	client := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pool).Build()
+
+	var result reconcile.Result
+	var err error
+	r := WithReconcileMetrics("test-controller", client, &cephv1.CephBlockPool{}, reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		return result, err
+	}))
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "replicapool", Namespace: "rook-ceph"}}
+	errorsTotal := func() float64 {
+		return testutil.ToFloat64(reconcileErrors.WithLabelValues("test-controller", "rook-ceph", "replicapool"))
+	}
+	requeuesTotal := func() float64 {
+		return testutil.ToFloat64(reconcileRequeues.WithLabelValues("test-controller", "rook-ceph", "replicapool"))
+	}
+	blocked := func() float64 {
+		return testutil.ToFloat64(blockedResources.WithLabelValues("test-controller"))
+	}
+	assert.Equal(t, float64(0), blocked())
+
+	t.Run("failed reconcile", func(t *testing.T) {
+		result, err = reconcile.Result{}, errors.New("failed")
+		_, _ = r.Reconcile(context.TODO(), request)
+		assert.Equal(t, float64(1), errorsTotal())
+		assert.Equal(t, float64(0), requeuesTotal())
+		assert.Equal(t, float64(1), blocked())
+
+	})
+
+	t.Run("waiting reconcile", func(t *testing.T) {
+		result, err = WaitForRequeueIfCephClusterNotReady, nil
+		_, _ = r.Reconcile(context.TODO(), request)
+		assert.Equal(t, float64(1), errorsTotal())
+		assert.Equal(t, float64(1), requeuesTotal())
+		assert.Equal(t, float64(1), blocked())
+	})
+
+	t.Run("periodic reconcile", func(t *testing.T) {
+		result, err = reconcile.Result{RequeueAfter: time.Minute}, nil
+		_, _ = r.Reconcile(context.TODO(), request)
+		assert.Equal(t, float64(1), requeuesTotal())
+		assert.Equal(t, float64(0), blocked())
+		assert.Equal(t, 1, testutil.CollectAndCount(reconcileErrors))
+	})
+
+	t.Run("deleted resource", func(t *testing.T) {
+		result, err = reconcile.Result{}, errors.New("failed")
+		_, _ = r.Reconcile(context.TODO(), request)
+		assert.Equal(t, float64(1), blocked())
+
+		assert.NoError(t, client.Delete(context.TODO(), pool))
+		result, err = reconcile.Result{}, nil
+		_, _ = r.Reconcile(context.TODO(), request)
+		assert.Equal(t, float64(0), blocked())
+		assert.Equal(t, 0, testutil.CollectAndCount(reconcileErrors))
+		assert.Equal(t, 0, testutil.CollectAndCount(reconcileDuration))
+	})
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/tracing"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ReconcileOrderTimeoutSetting is the operator setting of the max duration the reconciles of a
+	// resource wait for the resources it depends on to converge, 0 to disable the ordering
+	ReconcileOrderTimeoutSetting = "ROOK_RECONCILE_ORDER_TIMEOUT_SECONDS"
+	defaultReconcileOrderTimeout = 10 * time.Minute
+)
+
+var (
+	// reconcileOrderWait is the interval the deferred reconciles are requeued after
+	reconcileOrderWait = 10 * time.Second
+
+	// the kinds of the resources waited for by the resources referencing them
+	orderedKinds = map[string]func() client.Object{
+		"CephCluster":         func() client.Object { return &cephv1.CephCluster{} },
+		"CephBlockPool":       func() client.Object { return &cephv1.CephBlockPool{} },
+		"CephFilesystem":      func() client.Object { return &cephv1.CephFilesystem{} },
+		"CephObjectStore":     func() client.Object { return &cephv1.CephObjectStore{} },
+		"CephObjectRealm":     func() client.Object { return &cephv1.CephObjectRealm{} },
+		"CephObjectZoneGroup": func() client.Object { return &cephv1.CephObjectZoneGroup{} },
+		"CephObjectZone":      func() client.Object { return &cephv1.CephObjectZone{} },
+	}
+	// the resources of its namespace a resource of a kind references in its spec, besides its CephCluster
+	orderReferences = map[string]func(obj client.Object) []orderReference{
+		"CephBlockPoolRadosNamespace": func(obj client.Object) []orderReference {
+			return []orderReference{{"CephBlockPool", obj.(*cephv1.CephBlockPoolRadosNamespace).Spec.BlockPoolName}}
+		},
+		"CephFilesystemSubVolumeGroup": func(obj client.Object) []orderReference {
+			return []orderReference{{"CephFilesystem", obj.(*cephv1.CephFilesystemSubVolumeGroup).Spec.FilesystemName}}
+		},
+		"CephFilesystemSubVolume": func(obj client.Object) []orderReference {
+			return []orderReference{{"CephFilesystem", obj.(*cephv1.CephFilesystemSubVolume).Spec.FilesystemName}}
+		},
+		"CephObjectStoreUser": func(obj client.Object) []orderReference {
+			return []orderReference{{"CephObjectStore", obj.(*cephv1.CephObjectStoreUser).Spec.Store}}
+		},
+		"CephBucketTopic": func(obj client.Object) []orderReference {
+			return []orderReference{{"CephObjectStore", obj.(*cephv1.CephBucketTopic).Spec.ObjectStoreName}}
+		},
+		"CephObjectZoneGroup": func(obj client.Object) []orderReference {
+			return []orderReference{{"CephObjectRealm", obj.(*cephv1.CephObjectZoneGroup).Spec.Realm}}
+		},
+		"CephObjectZone": func(obj client.Object) []orderReference {
+			return []orderReference{{"CephObjectZoneGroup", obj.(*cephv1.CephObjectZone).Spec.ZoneGroup}}
+		},
+		"CephObjectStore": func(obj client.Object) []orderReference {
+			return []orderReference{{"CephObjectZone", obj.(*cephv1.CephObjectStore).Spec.Zone.Name}}
+		},
+	}
+
+	deferredReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rook_ceph_deferred_reconciles_total",
+		Help: "The number of reconciles of a resource deferred until the resources it depends on converge",
+	}, []string{"controller"})
+
+	order = &reconcileOrder{timeout: defaultReconcileOrderTimeout}
+)
+
+func init() {
+	metrics.Registry.MustRegister(deferredReconciles)
+}
+
+// orderReference is a resource of the namespace of a resource it depends on
+type orderReference struct {
+	kind string
+	name string
+}
+
+// reconcileOrder tracks whether the resources waited for converged, i.e. their last reconcile succeeded
+// without a retry, during the window after the start of the operator
+type reconcileOrder struct {
+	mutex   sync.Mutex
+	enabled bool
+	timeout time.Duration
+	// the time the ordering started, the resources are only deferred until the timeout after it
+	start time.Time
+	// whether the resources converged since the ordering started
+	converged map[string]bool
+}
+
+func orderKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// StartReconcileOrder starts the ordering of the reconciles of the resources when the controllers
+// start: until the resources a resource depends on converge, its reconciles are deferred, so the pools,
+// filesystems and object stores are reconciled after their CephCluster, and the resources referencing
+// them after them, instead of failing until their parents are ready.
+func StartReconcileOrder() {
+	order.mutex.Lock()
+	defer order.mutex.Unlock()
+	order.enabled = true
+	order.start = time.Now()
+	order.converged = map[string]bool{}
+}
+
+// StopReconcileOrder stops the ordering of the reconciles
+func StopReconcileOrder() {
+	order.mutex.Lock()
+	defer order.mutex.Unlock()
+	order.enabled = false
+}
+
+// SetReconcileOrderTimeout sets the max duration the reconciles wait for the resources they depend on
+func SetReconcileOrderTimeout(data map[string]string) {
+	timeoutSeconds, err := strconv.Atoi(k8sutil.GetValue(data, ReconcileOrderTimeoutSetting, "600"))
+	if err != nil || timeoutSeconds < 0 {
+		logger.Warningf("%s should be >= 0, set the default value 600", ReconcileOrderTimeoutSetting)
+		timeoutSeconds = 600
+	}
+	order.mutex.Lock()
+	defer order.mutex.Unlock()
+	order.timeout = time.Duration(timeoutSeconds) * time.Second
+}
+
+// recordReconcile records whether a resource converged. A resource failing after it converged, like a
+// CephCluster failing to recover, makes the resources depending on it wait again until the window
+// closes.
+func (o *reconcileOrder) recordReconcile(kind, namespace, name string, result reconcile.Result, err error) {
+	if _, ok := orderedKinds[kind]; !ok {
+		return
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if !o.open() {
+		return
+	}
+	o.converged[orderKey(kind, namespace, name)] = err == nil && !result.Requeue
+}
+
+// open returns whether the reconciles are ordered, during the window after the start of the operator
+func (o *reconcileOrder) open() bool {
+	return o.enabled && o.timeout > 0 && time.Since(o.start) < o.timeout
+}
+
+// isOpen returns whether the reconciles are ordered
+func (o *reconcileOrder) isOpen() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.open()
+}
+
+// waiting returns whether the resources depending on a resource must wait for it
+func (o *reconcileOrder) waiting(kind, namespace, name string) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.open() && !o.converged[orderKey(kind, namespace, name)]
+}
+
+// orderGate defers the reconciles of the resources of a controller until the resources they depend on
+// converge, and records whether the resources depended on converged
+type orderGate struct {
+	gatedResource
+	reconciler reconcile.Reconciler
+}
+
+// Reconcile reconciles the resource with the wrapped reconciler unless its reconcile is deferred
+func (g *orderGate) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	waitFor, err := g.deferReconcile(ctx, request)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if waitFor != "" {
+		if g.debug() {
+			ctrllog.FromContext(ctx).Info("deferring the reconcile until its dependency converges", "waitFor", waitFor)
+		}
+		tracing.FromContext(ctx).SetAttribute("rook.reconcile.deferred", waitFor)
+		return reconcile.Result{RequeueAfter: reconcileOrderWait}, nil
+	}
+	result, err := g.reconciler.Reconcile(ctx, request)
+	order.recordReconcile(g.kind, request.Namespace, request.Name, result, err)
+	return result, err
+}
+
+// deferReconcile returns the resource a resource waits for before it is reconciled, or an empty string
+// when the resource can be reconciled. A resource waits for the CephCluster of its namespace and the
+// resources referenced by its spec that exist. The deleted resources do not wait.
+func (g *orderGate) deferReconcile(ctx context.Context, request reconcile.Request) (string, error) {
+	if g.kind == "CephCluster" || !order.isOpen() {
+		return "", nil
+	}
+
+	object, err := g.get(ctx, request.NamespacedName)
+	if err != nil || object == nil || !object.GetDeletionTimestamp().IsZero() {
+		return "", err
+	}
+
+	clusters := &cephv1.CephClusterList{}
+	if err := g.client.List(ctx, clusters, client.InNamespace(request.Namespace)); err != nil {
+		return "", err
+	}
+	waitFor := ""
+	for _, cluster := range clusters.Items {
+		if cluster.DeletionTimestamp.IsZero() && order.waiting("CephCluster", cluster.Namespace, cluster.Name) {
+			waitFor = "CephCluster " + cluster.Name
+			break
+		}
+	}
+	if references, ok := orderReferences[g.kind]; ok && waitFor == "" {
+		for _, reference := range references(object) {
+			waiting, err := g.waitingForReference(ctx, request.Namespace, reference)
+			if err != nil {
+				return "", err
+			}
+			if waiting {
+				waitFor = reference.kind + " " + reference.name
+				break
+			}
+		}
+	}
+	if waitFor != "" {
+		deferredReconciles.WithLabelValues(g.controllerName).Inc()
+	}
+	return waitFor, nil
+}
+
+// waitingForReference returns whether a resource waits for a resource it references. A resource
+// referencing a resource that does not exist does not wait, its reconcile reports the missing resource.
+func (g *orderGate) waitingForReference(ctx context.Context, namespace string, reference orderReference) (bool, error) {
+	if reference.name == "" {
+		return false, nil
+	}
+	if !order.waiting(reference.kind, namespace, reference.name) {
+		return false, nil
+	}
+	referenced := orderedKinds[reference.kind]()
+	if err := g.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: reference.name}, referenced); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return referenced.GetDeletionTimestamp().IsZero(), nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileOrder(t *testing.T) {
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	namespace := "rook-ceph"
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: namespace}}
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace}}
+	otherPool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "otherpool", Namespace: namespace}}
+	cephClient := &cephv1.CephClient{ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: namespace}}
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: namespace}, Spec: cephv1.CephBlockPoolRadosNamespaceSpec{BlockPoolName: "replicapool"}}
+	missingPoolNamespace := &cephv1.CephBlockPoolRadosNamespace{ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: namespace}, Spec: cephv1.CephBlockPoolRadosNamespaceSpec{BlockPoolName: "missing"}}
+	poolOfOtherNamespace := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "other"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cluster, pool, otherPool, cephClient, radosNamespace, missingPoolNamespace, poolOfOtherNamespace).Build()
+
+	// the results of the reconciles of the resources by name
+	results := map[string]error{}
+	reconciled := map[string]int{}
+	newReconciler := func(object cephv1.StatusConditionGetter) reconcile.Reconciler {
+		return WithReconcileGates("order-test-controller", c, object, reconcile.Func(func(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
+			reconciled[request.String()]++
+			return reconcile.Result{}, results[request.String()]
+		}))
+	}
+	clusterReconciler := newReconciler(&cephv1.CephCluster{})
+	poolReconciler := newReconciler(&cephv1.CephBlockPool{})
+	clientReconciler := newReconciler(&cephv1.CephClient{})
+	radosNamespaceReconciler := newReconciler(&cephv1.CephBlockPoolRadosNamespace{})
+	request := func(namespace, name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+	}
+	clusterRequest, poolRequest, clientRequest := request(namespace, "my-cluster"), request(namespace, "replicapool"), request(namespace, "client")
+	radosNamespaceRequest, missingPoolRequest := request(namespace, "ns"), request(namespace, "missing")
+
+	t.Run("not ordered until the controllers start", func(t *testing.T) {
+		result, err := poolReconciler.Reconcile(context.TODO(), poolRequest)
+		assert.NoError(t, err)
+		assert.True(t, result.IsZero())
+		assert.Equal(t, 1, reconciled[poolRequest.String()])
+	})
+
+	StartReconcileOrder()
+	defer StopReconcileOrder()
+
+	t.Run("the resources wait for the cluster", func(t *testing.T) {
+		result, err := poolReconciler.Reconcile(context.TODO(), poolRequest)
+		assert.NoError(t, err)
+		assert.Equal(t, reconcileOrderWait, result.RequeueAfter)
+		assert.Equal(t, 1, reconciled[poolRequest.String()])
+
+		result, err = clientReconciler.Reconcile(context.TODO(), clientRequest)
+		assert.NoError(t, err)
+		assert.Equal(t, reconcileOrderWait, result.RequeueAfter)
+		assert.Equal(t, 0, reconciled[clientRequest.String()])
+
+		// the resources of the other namespaces do not wait
+		_, err = poolReconciler.Reconcile(context.TODO(), request("other", "replicapool"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, reconciled[request("other", "replicapool").String()])
+	})
+
+	t.Run("the pool is reconciled after the cluster", func(t *testing.T) {
+		results[clusterRequest.String()] = errors.New("failed")
+		_, _ = clusterReconciler.Reconcile(context.TODO(), clusterRequest)
+		result, _ := poolReconciler.Reconcile(context.TODO(), poolRequest)
+		assert.Equal(t, reconcileOrderWait, result.RequeueAfter)
+
+		results[clusterRequest.String()] = nil
+		_, _ = clusterReconciler.Reconcile(context.TODO(), clusterRequest)
+		result, err := poolReconciler.Reconcile(context.TODO(), poolRequest)
+		assert.NoError(t, err)
+		assert.True(t, result.IsZero())
+		assert.Equal(t, 2, reconciled[poolRequest.String()])
+	})
+
+	t.Run("only the referenced resources are waited for", func(t *testing.T) {
+		// the client does not reference the pool that has not converged
+		_, _ = clientReconciler.Reconcile(context.TODO(), clientRequest)
+		assert.Equal(t, 1, reconciled[clientRequest.String()])
+
+		// the rados namespace waits for its pool until the pool converges
+		results[poolRequest.String()] = errors.New("failed")
+		_, _ = poolReconciler.Reconcile(context.TODO(), poolRequest)
+		result, _ := radosNamespaceReconciler.Reconcile(context.TODO(), radosNamespaceRequest)
+		assert.Equal(t, reconcileOrderWait, result.RequeueAfter)
+		assert.Equal(t, 0, reconciled[radosNamespaceRequest.String()])
+		results[poolRequest.String()] = nil
+		_, _ = poolReconciler.Reconcile(context.TODO(), poolRequest)
+		result, _ = radosNamespaceReconciler.Reconcile(context.TODO(), radosNamespaceRequest)
+		assert.True(t, result.IsZero())
+		assert.Equal(t, 1, reconciled[radosNamespaceRequest.String()])
+
+		// a missing pool is not waited for
+		result, _ = radosNamespaceReconciler.Reconcile(context.TODO(), missingPoolRequest)
+		assert.True(t, result.IsZero())
+		assert.Equal(t, 1, reconciled[missingPoolRequest.String()])
+	})
+
+	t.Run("the pool waits again when the cluster fails", func(t *testing.T) {
+		results[clusterRequest.String()] = errors.New("failed")
+		_, _ = clusterReconciler.Reconcile(context.TODO(), clusterRequest)
+		result, _ := poolReconciler.Reconcile(context.TODO(), poolRequest)
+		assert.Equal(t, reconcileOrderWait, result.RequeueAfter)
+		assert.Equal(t, 4, reconciled[poolRequest.String()])
+	})
+
+	t.Run("not ordered after the window", func(t *testing.T) {
+		order.mutex.Lock()
+		order.start = time.Now().Add(-defaultReconcileOrderTimeout)
+		order.mutex.Unlock()
+		result, _ := poolReconciler.Reconcile(context.TODO(), poolRequest)
+		assert.True(t, result.IsZero())
+		assert.Equal(t, 5, reconciled[poolRequest.String()])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		StartReconcileOrder()
+		SetReconcileOrderTimeout(map[string]string{ReconcileOrderTimeoutSetting: "0"})
+		defer SetReconcileOrderTimeout(map[string]string{})
+		result, _ := poolReconciler.Reconcile(context.TODO(), poolRequest)
+		assert.True(t, result.IsZero())
+		assert.Equal(t, 6, reconciled[poolRequest.String()])
+	})
+}
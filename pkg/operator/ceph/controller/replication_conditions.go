@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// replicationReasonsBySeverity orders the reasons of the replication issues, the reason of the
+// conditions is the reason of the most severe issue
+var replicationReasonsBySeverity = []cephv1.ConditionReason{
+	cephv1.PeerConnectionFailedReason,
+	cephv1.ReplicationDaemonUnhealthyReason,
+	cephv1.ReplicationSyncBehindReason,
+	cephv1.ReplicationStatusUnknownReason,
+}
+
+// ReplicationIssue is an issue of the replication of a resource to its peer sites
+type ReplicationIssue struct {
+	Reason  cephv1.ConditionReason
+	Message string
+}
+
+// PeerConnectionIssue returns the issue of a PeerConnected condition that is not true, nil otherwise
+func PeerConnectionIssue(conditions []cephv1.Condition) *ReplicationIssue {
+	condition := cephv1.FindStatusCondition(conditions, cephv1.ConditionPeerConnected)
+	if condition == nil || condition.Status != v1.ConditionFalse {
+		return nil
+	}
+	return &ReplicationIssue{Reason: cephv1.PeerConnectionFailedReason, Message: condition.Message}
+}
+
+// SetReplicationConditions sets the DRReady and ReplicationDegraded conditions of a replicated
+// resource from the issues found by the checks of its replication. All the subsystems report their
+// replication with the same conditions and reasons, so a DR orchestrator can watch any resource
+// the same way.
+func SetReplicationConditions(conditions *[]cephv1.Condition, issues []ReplicationIssue) {
+	if len(issues) == 0 {
+		message := "the resource is replicated to its peer sites"
+		cephv1.SetStatusCondition(conditions, cephv1.Condition{
+			Type:    cephv1.ConditionDRReady,
+			Status:  v1.ConditionTrue,
+			Reason:  cephv1.ReplicationHealthyReason,
+			Message: message,
+		})
+		cephv1.SetStatusCondition(conditions, cephv1.Condition{
+			Type:    cephv1.ConditionReplicationDegraded,
+			Status:  v1.ConditionFalse,
+			Reason:  cephv1.ReplicationHealthyReason,
+			Message: message,
+		})
+		return
+	}
+
+	reason := issues[0].Reason
+	for _, r := range replicationReasonsBySeverity {
+		if hasReplicationIssue(issues, r) {
+			reason = r
+			break
+		}
+	}
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	message := strings.Join(messages, "; ")
+
+	// The replication is only known to be degraded when its status could be checked
+	degraded := v1.ConditionTrue
+	if reason == cephv1.ReplicationStatusUnknownReason {
+		degraded = v1.ConditionUnknown
+	}
+	cephv1.SetStatusCondition(conditions, cephv1.Condition{
+		Type:    cephv1.ConditionDRReady,
+		Status:  v1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+	cephv1.SetStatusCondition(conditions, cephv1.Condition{
+		Type:    cephv1.ConditionReplicationDegraded,
+		Status:  degraded,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// RemoveReplicationConditions removes the DRReady and ReplicationDegraded conditions of a resource
+// that is not replicated anymore. It returns whether a condition was removed.
+func RemoveReplicationConditions(conditions *[]cephv1.Condition) bool {
+	if conditions == nil {
+		return false
+	}
+	kept := []cephv1.Condition{}
+	for _, condition := range *conditions {
+		if condition.Type == cephv1.ConditionDRReady || condition.Type == cephv1.ConditionReplicationDegraded {
+			continue
+		}
+		kept = append(kept, condition)
+	}
+	removed := len(kept) != len(*conditions)
+	*conditions = kept
+	return removed
+}
+
+func hasReplicationIssue(issues []ReplicationIssue, reason cephv1.ConditionReason) bool {
+	for _, issue := range issues {
+		if issue.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestPeerConnectionIssue(t *testing.T) {
+	assert.Nil(t, PeerConnectionIssue(nil))
+
+	conditions := []cephv1.Condition{{Type: cephv1.ConditionPeerConnected, Status: v1.ConditionTrue}}
+	assert.Nil(t, PeerConnectionIssue(conditions))
+
+	conditions[0].Status = v1.ConditionFalse
+	conditions[0].Message = "peer site-b is unreachable"
+	issue := PeerConnectionIssue(conditions)
+	assert.NotNil(t, issue)
+	assert.Equal(t, cephv1.PeerConnectionFailedReason, issue.Reason)
+	assert.Equal(t, "peer site-b is unreachable", issue.Message)
+}
+
+func TestSetReplicationConditions(t *testing.T) {
+	conditions := []cephv1.Condition{{Type: cephv1.ConditionReady, Status: v1.ConditionTrue}}
+
+	t.Run("healthy", func(t *testing.T) {
+		SetReplicationConditions(&conditions, nil)
+		drReady := cephv1.FindStatusCondition(conditions, cephv1.ConditionDRReady)
+		assert.Equal(t, v1.ConditionTrue, drReady.Status)
+		assert.Equal(t, cephv1.ReplicationHealthyReason, drReady.Reason)
+		degraded := cephv1.FindStatusCondition(conditions, cephv1.ConditionReplicationDegraded)
+		assert.Equal(t, v1.ConditionFalse, degraded.Status)
+	})
+
+	t.Run("most severe reason", func(t *testing.T) {
+		SetReplicationConditions(&conditions, []ReplicationIssue{
+			{Reason: cephv1.ReplicationSyncBehindReason, Message: "images are behind"},
+			{Reason: cephv1.ReplicationDaemonUnhealthyReason, Message: "daemon is down"},
+		})
+		drReady := cephv1.FindStatusCondition(conditions, cephv1.ConditionDRReady)
+		assert.Equal(t, v1.ConditionFalse, drReady.Status)
+		assert.Equal(t, cephv1.ReplicationDaemonUnhealthyReason, drReady.Reason)
+		assert.Equal(t, "images are behind; daemon is down", drReady.Message)
+		degraded := cephv1.FindStatusCondition(conditions, cephv1.ConditionReplicationDegraded)
+		assert.Equal(t, v1.ConditionTrue, degraded.Status)
+		assert.Equal(t, cephv1.ReplicationDaemonUnhealthyReason, degraded.Reason)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		SetReplicationConditions(&conditions, []ReplicationIssue{{Reason: cephv1.ReplicationStatusUnknownReason, Message: "not checked yet"}})
+		assert.Equal(t, v1.ConditionFalse, cephv1.FindStatusCondition(conditions, cephv1.ConditionDRReady).Status)
+		assert.Equal(t, v1.ConditionUnknown, cephv1.FindStatusCondition(conditions, cephv1.ConditionReplicationDegraded).Status)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		assert.True(t, RemoveReplicationConditions(&conditions))
+		assert.Equal(t, []cephv1.Condition{{Type: cephv1.ConditionReady, Status: v1.ConditionTrue}}, conditions)
+		assert.False(t, RemoveReplicationConditions(&conditions))
+		assert.False(t, RemoveReplicationConditions(nil))
+	})
+}
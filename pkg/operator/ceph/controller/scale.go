@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ScaleStatus returns the replicas and the label selector reported in the status of a resource with
+// a scale subresource, for the HPA and the autoscalers. The replicas are the replicas of the
+// deployments of the daemons of the resource, which are matched by the selector labels, the same
+// labels selecting their pods.
+func ScaleStatus(ctx context.Context, c client.Client, namespace string, selectorLabels map[string]string) (int32, string, error) {
+	selector := labels.SelectorFromSet(selectorLabels).String()
+	deployments := &apps.DeploymentList{}
+	if err := c.List(ctx, deployments, client.InNamespace(namespace), client.MatchingLabels(selectorLabels)); err != nil {
+		return 0, selector, errors.Wrapf(err, "failed to list the deployments matching %q", selector)
+	}
+
+	var replicas int32
+	for _, d := range deployments.Items {
+		replicas += d.Status.Replicas
+	}
+	return replicas, selector, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScaleStatus(t *testing.T) {
+	deployment := func(name, store string, replicas int32) *apps.Deployment {
+		return &apps.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "rook-ceph",
+				Labels:    map[string]string{"app": "rook-ceph-rgw", "rook_object_store": store},
+			},
+			Status: apps.DeploymentStatus{Replicas: replicas},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(
+		deployment("rook-ceph-rgw-store-a", "store", 2),
+		deployment("rook-ceph-rgw-store-b", "store", 1),
+		deployment("rook-ceph-rgw-other-a", "other", 3),
+	).Build()
+
+	replicas, selector, err := ScaleStatus(context.TODO(), c, "rook-ceph", map[string]string{"app": "rook-ceph-rgw", "rook_object_store": "store"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), replicas)
+	assert.Equal(t, "app=rook-ceph-rgw,rook_object_store=store", selector)
+
+	// no daemon is running yet
+	replicas, _, err = ScaleStatus(context.TODO(), c, "rook-ceph", map[string]string{"app": "rook-ceph-rgw", "rook_object_store": "new"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), replicas)
+}
@@ -468,7 +468,7 @@ func ChownCephDataDirsInitContainer(
 		args = append(args, dpm.ContainerDataDir)
 	}
 	return v1.Container{
-		Name:            "chown-container-data-dir",
+		Name:            chownContainerName,
 		Command:         []string{"chown"},
 		Args:            args,
 		Image:           containerImage,
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// standardReasons are the reasons of the standard conditions set from the result of the reconciles.
+// A condition with the expected status but another reason was set by the controller itself, which
+// knows better, so its reason and message are kept.
+// readyHeartbeatInterval is how often the heartbeat of the Ready condition is refreshed by the
+// reconciles that do not change it, so the time of the last reconcile is printed by kubectl without
+// updating the status on every reconcile
+const readyHeartbeatInterval = time.Minute
+
+var standardReasons = map[cephv1.ConditionReason]bool{
+	cephv1.ReconcileSucceeded: true,
+	cephv1.ReconcileFailed:    true,
+	cephv1.ReconcileRequeuing: true,
+	cephv1.ReconcileDryRun:    true,
+}
+
+// SetStandardConditions sets the Ready, Progressing and Degraded conditions of a resource from the
+// result of its latest reconcile, the same for all the resources so tools following the status of
+// the resources by their conditions and observed generation work with any of them. The observed
+// generation of the status is set when the reconcile succeeded. The conditions of a resource being
+// deleted are left to the deletion. It returns whether the status changed, so it is only updated
+// when needed.
+func SetStandardConditions(obj cephv1.StatusConditionGetter, requeue bool, reconcileErr error) bool {
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return false
+	}
+
+	generation := obj.GetGeneration()
+	ready, progressing, degraded := v1.ConditionFalse, v1.ConditionFalse, v1.ConditionFalse
+	var reason cephv1.ConditionReason
+	var message string
+	switch {
+	case reconcileErr != nil:
+		degraded = v1.ConditionTrue
+		reason, message = cephv1.ReconcileFailed, reconcileErr.Error()
+	case requeue:
+		progressing = v1.ConditionTrue
+		reason, message = cephv1.ReconcileRequeuing, "the reconcile is waiting to be retried"
+	case IsDryRun(obj):
+		// the spec is not applied until the dry run annotation is removed
+		progressing = v1.ConditionTrue
+		reason, message = cephv1.ReconcileDryRun, "the resource is in dry run, the changes of its spec are reported but not applied"
+	default:
+		ready = v1.ConditionTrue
+		reason, message = cephv1.ReconcileSucceeded, "the resource is reconciled"
+	}
+
+	conditions := obj.GetStatusConditions()
+	changed := false
+	for _, condition := range []struct {
+		conditionType cephv1.ConditionType
+		status        v1.ConditionStatus
+	}{
+		{cephv1.ConditionReady, ready},
+		{cephv1.ConditionProgressing, progressing},
+		{cephv1.ConditionDegraded, degraded},
+	} {
+		if setStandardCondition(conditions, condition.conditionType, condition.status, reason, message, generation) {
+			changed = true
+		}
+	}
+
+	if getter, ok := obj.(cephv1.StatusObservedGenerationGetter); ok && ready == v1.ConditionTrue {
+		if observedGeneration := getter.GetStatusObservedGeneration(); *observedGeneration != generation {
+			*observedGeneration = generation
+			changed = true
+		}
+	}
+	return changed
+}
+
+// setStandardCondition sets a standard condition, it returns whether the condition changed
+func setStandardCondition(conditions *[]cephv1.Condition, conditionType cephv1.ConditionType, status v1.ConditionStatus, reason cephv1.ConditionReason, message string, generation int64) bool {
+	existing := cephv1.FindStatusCondition(*conditions, conditionType)
+	if existing != nil && existing.Status == status && !standardReasons[existing.Reason] {
+		reason, message = existing.Reason, existing.Message
+	}
+	if existing != nil && existing.Status == status && existing.Reason == reason && existing.Message == message && existing.ObservedGeneration == generation {
+		if conditionType != cephv1.ConditionReady || time.Since(existing.LastHeartbeatTime.Time) < readyHeartbeatInterval {
+			return false
+		}
+	}
+
+	now := metav1.Now()
+	cephv1.SetStatusCondition(conditions, cephv1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastHeartbeatTime:  now,
+		ObservedGeneration: generation,
+	})
+	return true
+}
+
+// conditionsGate sets the standard conditions of the resources of a controller from the result of
+// their reconciles
+type conditionsGate struct {
+	gatedResource
+	reconciler reconcile.Reconciler
+}
+
+// Reconcile reconciles the resource with the wrapped reconciler and updates its standard conditions
+func (g *conditionsGate) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	result, err := g.reconciler.Reconcile(ctx, request)
+	object, getErr := g.get(ctx, request.NamespacedName)
+	if getErr != nil || object == nil {
+		return result, err
+	}
+	obj, ok := object.(cephv1.StatusConditionGetter)
+	if !ok {
+		return result, err
+	}
+	patchErr := reporting.PatchStatus(g.client, obj, func() bool {
+		return SetStandardConditions(obj, result.Requeue, err)
+	})
+	if patchErr != nil {
+		// the conditions are set again by the next reconcile
+		logger.Debugf("failed to update the conditions of %s %q. %v", g.kind, request.NamespacedName, patchErr)
+	}
+	return result, err
+}
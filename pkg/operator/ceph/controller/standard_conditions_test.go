@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSetStandardConditions(t *testing.T) {
+	nfs := &cephv1.CephNFS{ObjectMeta: metav1.ObjectMeta{Name: "nfs", Namespace: "rook-ceph", Generation: 2}}
+	status := func(conditionType cephv1.ConditionType) (v1.ConditionStatus, cephv1.ConditionReason) {
+		condition := cephv1.FindStatusCondition(nfs.Status.Conditions, conditionType)
+		assert.NotNil(t, condition)
+		assert.Equal(t, int64(2), condition.ObservedGeneration)
+		return condition.Status, condition.Reason
+	}
+
+	t.Run("failed reconcile", func(t *testing.T) {
+		assert.True(t, SetStandardConditions(nfs, false, errors.New("failed")))
+		assert.Len(t, nfs.Status.Conditions, 3)
+		s, reason := status(cephv1.ConditionReady)
+		assert.Equal(t, v1.ConditionFalse, s)
+		assert.Equal(t, cephv1.ReconcileFailed, reason)
+		s, _ = status(cephv1.ConditionDegraded)
+		assert.Equal(t, v1.ConditionTrue, s)
+		assert.Equal(t, "failed", nfs.Status.Conditions[2].Message)
+		assert.Equal(t, int64(0), nfs.Status.ObservedGeneration)
+
+		// the same failure does not change the status
+		assert.False(t, SetStandardConditions(nfs, false, errors.New("failed")))
+		assert.True(t, SetStandardConditions(nfs, false, errors.New("failed again")))
+	})
+
+	t.Run("waiting reconcile", func(t *testing.T) {
+		assert.True(t, SetStandardConditions(nfs, true, nil))
+		s, reason := status(cephv1.ConditionProgressing)
+		assert.Equal(t, v1.ConditionTrue, s)
+		assert.Equal(t, cephv1.ReconcileRequeuing, reason)
+		s, _ = status(cephv1.ConditionDegraded)
+		assert.Equal(t, v1.ConditionFalse, s)
+	})
+
+	t.Run("successful reconcile", func(t *testing.T) {
+		assert.True(t, SetStandardConditions(nfs, false, nil))
+		s, reason := status(cephv1.ConditionReady)
+		assert.Equal(t, v1.ConditionTrue, s)
+		assert.Equal(t, cephv1.ReconcileSucceeded, reason)
+		s, _ = status(cephv1.ConditionProgressing)
+		assert.Equal(t, v1.ConditionFalse, s)
+		assert.Equal(t, int64(2), nfs.Status.ObservedGeneration)
+		// the legacy phase is left to the controller
+		assert.Equal(t, "", nfs.Status.Phase)
+		assert.False(t, SetStandardConditions(nfs, false, nil))
+
+		// the heartbeat of the Ready condition is refreshed by the later reconciles
+		condition := cephv1.FindStatusCondition(nfs.Status.Conditions, cephv1.ConditionReady)
+		condition.LastHeartbeatTime = metav1.NewTime(time.Now().Add(-2 * readyHeartbeatInterval))
+		transition := condition.LastTransitionTime
+		assert.True(t, SetStandardConditions(nfs, false, nil))
+		condition = cephv1.FindStatusCondition(nfs.Status.Conditions, cephv1.ConditionReady)
+		assert.True(t, time.Since(condition.LastHeartbeatTime.Time) < readyHeartbeatInterval)
+		assert.Equal(t, transition, condition.LastTransitionTime)
+		assert.False(t, SetStandardConditions(nfs, false, nil))
+	})
+
+	t.Run("condition set by the controller", func(t *testing.T) {
+		cephv1.SetStatusCondition(&nfs.Status.Conditions, cephv1.Condition{Type: cephv1.ConditionReady, Status: v1.ConditionTrue, Reason: cephv1.ClusterCreatedReason, Message: "created"})
+		nfs.Generation = 3
+		assert.True(t, SetStandardConditions(nfs, false, nil))
+		condition := cephv1.FindStatusCondition(nfs.Status.Conditions, cephv1.ConditionReady)
+		assert.Equal(t, cephv1.ClusterCreatedReason, condition.Reason)
+		assert.Equal(t, "created", condition.Message)
+		assert.Equal(t, int64(3), condition.ObservedGeneration)
+		nfs.Generation = 2
+		assert.True(t, SetStandardConditions(nfs, false, nil))
+	})
+
+	t.Run("deleted resource", func(t *testing.T) {
+		now := metav1.Now()
+		nfs.DeletionTimestamp = &now
+		assert.False(t, SetStandardConditions(nfs, false, errors.New("failed")))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "pool", Generation: 2, Annotations: map[string]string{DryRunAnnotation: "true"}}}
+		pool.Status = &cephv1.CephBlockPoolStatus{ObservedGeneration: 1}
+		assert.True(t, SetStandardConditions(pool, false, nil))
+		condition := cephv1.FindStatusCondition(pool.Status.Conditions, cephv1.ConditionProgressing)
+		assert.Equal(t, v1.ConditionTrue, condition.Status)
+		assert.Equal(t, cephv1.ReconcileDryRun, condition.Reason)
+		condition = cephv1.FindStatusCondition(pool.Status.Conditions, cephv1.ConditionReady)
+		assert.Equal(t, v1.ConditionFalse, condition.Status)
+		// the spec is not applied
+		assert.Equal(t, int64(1), pool.Status.ObservedGeneration)
+
+		// the annotation is ignored by the kinds without dry run
+		nfs := &cephv1.CephNFS{ObjectMeta: metav1.ObjectMeta{Name: "nfs", Generation: 2, Annotations: map[string]string{DryRunAnnotation: "true"}}}
+		assert.True(t, SetStandardConditions(nfs, false, nil))
+		condition = cephv1.FindStatusCondition(nfs.Status.Conditions, cephv1.ConditionReady)
+		assert.Equal(t, v1.ConditionTrue, condition.Status)
+	})
+
+	t.Run("observed generation set by the controller", func(t *testing.T) {
+		peer := &cephv1.CephRBDMirrorPeer{ObjectMeta: metav1.ObjectMeta{Name: "peer", Generation: 2}}
+		peer.Status = &cephv1.CephRBDMirrorPeerStatus{ObservedGeneration: 1}
+		assert.True(t, SetStandardConditions(peer, false, nil))
+		assert.Equal(t, int64(1), peer.Status.ObservedGeneration)
+	})
+}
+
+func TestConditionsGate(t *testing.T) {
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pool).Build()
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "rook-ceph", Name: "replicapool"}}
+	resource := gatedResource{controllerName: "conditions-test-controller", client: c, object: &cephv1.CephBlockPool{}, kind: "CephBlockPool"}
+	var reconcileErr error
+	r := &conditionsGate{gatedResource: resource, reconciler: reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{}, reconcileErr
+	})}
+	condition := func(conditionType cephv1.ConditionType) *cephv1.Condition {
+		updated := &cephv1.CephBlockPool{}
+		assert.NoError(t, c.Get(context.TODO(), request.NamespacedName, updated))
+		return cephv1.FindStatusCondition(updated.Status.Conditions, conditionType)
+	}
+
+	reconcileErr = errors.New("failed")
+	_, err := r.Reconcile(context.TODO(), request)
+	assert.Error(t, err)
+	assert.Equal(t, v1.ConditionTrue, condition(cephv1.ConditionDegraded).Status)
+	assert.Equal(t, "failed", condition(cephv1.ConditionDegraded).Message)
+
+	reconcileErr = nil
+	_, err = r.Reconcile(context.TODO(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ConditionTrue, condition(cephv1.ConditionReady).Status)
+	assert.Equal(t, v1.ConditionFalse, condition(cephv1.ConditionDegraded).Status)
+}
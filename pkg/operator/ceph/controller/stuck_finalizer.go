@@ -0,0 +1,281 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// StuckFinalizerTimeoutSetting is the operator setting of the duration after which a resource still
+	// deleted because of its Rook finalizers is reported as stuck
+	StuckFinalizerTimeoutSetting = "ROOK_STUCK_FINALIZER_TIMEOUT_SECONDS"
+	defaultStuckFinalizerTimeout = 10 * time.Minute
+
+	// RemoveFinalizerAnnotation is the annotation of a resource stuck in deletion asking the operator to
+	// remove its Rook finalizers, when the cleanup of the resource cannot complete and skipping it is safe
+	RemoveFinalizerAnnotation = "ceph.rook.io/remove-finalizer"
+
+	// the reasons of the events of the resources stuck in deletion
+	finalizerStuckReason          = "FinalizerStuck"
+	finalizerRemovedReason        = "FinalizerRemoved"
+	finalizerRemovalRefusedReason = "FinalizerRemovalRefused"
+)
+
+var (
+	stuckFinalizers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_stuck_finalizers",
+		Help: "The number of resources of a controller stuck in deletion because of their Rook finalizers",
+	}, []string{"controller"})
+
+	stuck = &stuckFinalizerSettings{timeout: defaultStuckFinalizerTimeout, cleanups: map[string]FinalizerCleanup{}}
+)
+
+func init() {
+	metrics.Registry.MustRegister(stuckFinalizers)
+}
+
+// FinalizerCleanup cleans up what a resource stuck in deletion leaves behind before its Rook finalizers
+// are removed on request, such as the finalizers of the resources it owns
+type FinalizerCleanup func(ctx context.Context, c client.Client, obj client.Object) error
+
+type stuckFinalizerSettings struct {
+	mutex    sync.Mutex
+	timeout  time.Duration
+	recorder record.EventRecorder
+	cleanups map[string]FinalizerCleanup
+}
+
+// SetStuckFinalizerTimeout sets the duration after which a resource in deletion is reported as stuck
+func SetStuckFinalizerTimeout(data map[string]string) {
+	timeoutSeconds, err := strconv.Atoi(k8sutil.GetValue(data, StuckFinalizerTimeoutSetting, "600"))
+	if err != nil || timeoutSeconds <= 0 {
+		logger.Warningf("%s should be > 0, set the default value 600", StuckFinalizerTimeoutSetting)
+		timeoutSeconds = 600
+	}
+	stuck.mutex.Lock()
+	defer stuck.mutex.Unlock()
+	stuck.timeout = time.Duration(timeoutSeconds) * time.Second
+}
+
+// SetStuckFinalizerEventRecorder sets the recorder of the events of the resources stuck in deletion
+func SetStuckFinalizerEventRecorder(recorder record.EventRecorder) {
+	stuck.mutex.Lock()
+	defer stuck.mutex.Unlock()
+	stuck.recorder = recorder
+}
+
+// RegisterFinalizerCleanup registers the cleanup run before the finalizers of a resource of the kind
+// are removed on request
+func RegisterFinalizerCleanup(kind string, cleanup FinalizerCleanup) {
+	stuck.mutex.Lock()
+	defer stuck.mutex.Unlock()
+	stuck.cleanups[kind] = cleanup
+}
+
+func (s *stuckFinalizerSettings) get(kind string) (time.Duration, record.EventRecorder, FinalizerCleanup) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.timeout, s.recorder, s.cleanups[kind]
+}
+
+// rookFinalizers returns the Rook finalizers of a resource
+func rookFinalizers(obj client.Object) []string {
+	finalizers := []string{}
+	for _, finalizer := range obj.GetFinalizers() {
+		if strings.HasSuffix(finalizer, "."+cephv1.CustomResourceGroup) || strings.HasPrefix(finalizer, cephv1.CustomResourceGroup+"/") {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	return finalizers
+}
+
+// isRemoveFinalizerRequested returns whether the removal of the finalizers of the resource is requested
+func isRemoveFinalizerRequested(obj client.Object) bool {
+	return obj.GetAnnotations()[RemoveFinalizerAnnotation] == "true"
+}
+
+// stuckReason returns why a resource is stuck in deletion, and whether removing its finalizers is safe,
+// i.e. the cleanup of the resource cannot complete anyway and no resource depends on it
+func stuckReason(ctx context.Context, c client.Client, obj client.Object, reconcileErr error) (string, bool, error) {
+	if getter, ok := obj.(cephv1.StatusConditionGetter); ok {
+		blocked := cephv1.FindStatusCondition(*getter.GetStatusConditions(), cephv1.ConditionDeletionIsBlocked)
+		if blocked != nil && blocked.Status == corev1.ConditionTrue {
+			return fmt.Sprintf("its deletion is blocked by the resources depending on it: %s", blocked.Message), false, nil
+		}
+	}
+	if ObjectKind(obj) == "CephCluster" {
+		return "the cleanup of the cluster did not complete, removing its finalizer leaves the data of the cluster on its hosts", true, nil
+	}
+
+	clusters := &cephv1.CephClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(obj.GetNamespace())); err != nil {
+		return "", false, errors.Wrapf(err, "failed to list the CephClusters of namespace %q", obj.GetNamespace())
+	}
+	if len(clusters.Items) == 0 {
+		return fmt.Sprintf("the CephCluster of namespace %q does not exist anymore, the resource cannot be cleaned up from Ceph", obj.GetNamespace()), true, nil
+	}
+	cluster := clusters.Items[0]
+	if !cluster.DeletionTimestamp.IsZero() {
+		return fmt.Sprintf("the CephCluster %q is being deleted, the resource cannot be cleaned up from Ceph", cluster.Name), true, nil
+	}
+	reason := fmt.Sprintf("the CephCluster %q exists, the resource is deleted once its cleanup from Ceph succeeds", cluster.Name)
+	if reconcileErr != nil {
+		reason += ": " + reconcileErr.Error()
+	}
+	return reason, false, nil
+}
+
+// stuckFinalizerGate reports the resources of a controller stuck in deletion because of their
+// finalizers, and removes their finalizers on request
+type stuckFinalizerGate struct {
+	gatedResource
+	reconciler reconcile.Reconciler
+	mutex      sync.Mutex
+	// the reasons of the resources stuck in deletion
+	stuck map[types.NamespacedName]string
+}
+
+func newStuckFinalizerGate(resource gatedResource, r reconcile.Reconciler) *stuckFinalizerGate {
+	stuckFinalizers.WithLabelValues(resource.controllerName).Set(0)
+	return &stuckFinalizerGate{gatedResource: resource, reconciler: r, stuck: map[types.NamespacedName]string{}}
+}
+
+// Reconcile reconciles the resource with the wrapped reconciler and checks whether it is stuck in
+// deletion
+func (g *stuckFinalizerGate) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	result, err := g.reconciler.Reconcile(ctx, request)
+	object, getErr := g.get(ctx, request.NamespacedName)
+	if getErr != nil {
+		return result, err
+	}
+	if object == nil {
+		g.setStuck(request.NamespacedName, "")
+		return result, err
+	}
+	checkAfter, stuckErr := g.checkStuckFinalizer(ctx, object, err)
+	if stuckErr != nil {
+		logger.Errorf("failed to check if %s %q is stuck in deletion. %v", g.kind, request.NamespacedName, stuckErr)
+	}
+	return requeueStuckFinalizer(result, checkAfter), err
+}
+
+// checkStuckFinalizer reports a resource still deleted because of its Rook finalizers after the stuck
+// finalizer timeout with a FinalizerStuck event. When the resource has the remove-finalizer annotation
+// and removing its finalizers is safe, the cleanup registered for its kind runs and its finalizers are
+// removed, else the removal is refused, both recorded as events. It returns the duration after which the
+// resource must be checked again, 0 when it does not need to be.
+func (g *stuckFinalizerGate) checkStuckFinalizer(ctx context.Context, obj client.Object, reconcileErr error) (time.Duration, error) {
+	name := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	finalizers := rookFinalizers(obj)
+	if obj.GetDeletionTimestamp().IsZero() || len(finalizers) == 0 {
+		g.setStuck(name, "")
+		return 0, nil
+	}
+	timeout, recorder, cleanup := stuck.get(g.kind)
+	deleting := time.Since(obj.GetDeletionTimestamp().Time)
+	if deleting < timeout {
+		return timeout - deleting, nil
+	}
+
+	reason, safe, err := stuckReason(ctx, g.client, obj, reconcileErr)
+	if err != nil {
+		return 0, err
+	}
+	requested := isRemoveFinalizerRequested(obj)
+	if !requested || !safe {
+		eventType, eventReason := corev1.EventTypeWarning, finalizerStuckReason
+		message := fmt.Sprintf("deleted for %s but its finalizers %v are not removed: %s", deleting.Round(time.Second), finalizers, reason)
+		if requested {
+			eventReason = finalizerRemovalRefusedReason
+			message = fmt.Sprintf("not removing the finalizers %v requested by the %q annotation: %s", finalizers, RemoveFinalizerAnnotation, reason)
+		}
+		// the event is only recorded when the resource gets stuck or the reason changes
+		if g.setStuck(name, eventReason+": "+reason) {
+			logger.Warningf("%s %q is stuck in deletion, %s", g.kind, name, message)
+			g.recordEvent(recorder, obj, eventType, eventReason, message)
+		}
+		return timeout, nil
+	}
+
+	if cleanup != nil {
+		if err := cleanup(ctx, g.client, obj); err != nil {
+			return 0, errors.Wrapf(err, "failed to clean up %s %q before removing its finalizers", g.kind, name)
+		}
+	}
+	// the event is recorded first since the resource is gone once its finalizers are removed
+	g.recordEvent(recorder, obj, corev1.EventTypeNormal, finalizerRemovedReason, fmt.Sprintf("removing the finalizers %v requested by the %q annotation: %s", finalizers, RemoveFinalizerAnnotation, reason))
+	for _, finalizer := range finalizers {
+		if err := RemoveFinalizerWithName(ctx, g.client, obj, finalizer); err != nil {
+			return 0, err
+		}
+	}
+	logger.Infof("removed the finalizers %v of %s %q stuck in deletion: %s", finalizers, g.kind, name, reason)
+	g.setStuck(name, "")
+	return 0, nil
+}
+
+func (g *stuckFinalizerGate) recordEvent(recorder record.EventRecorder, obj client.Object, eventType, reason, message string) {
+	if recorder != nil {
+		recorder.Event(obj, eventType, reason, message)
+	}
+}
+
+// setStuck records why a resource is stuck in deletion, an empty reason when it is not, and returns
+// whether the reason changed
+func (g *stuckFinalizerGate) setStuck(name types.NamespacedName, reason string) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.stuck[name] == reason {
+		return false
+	}
+	if reason == "" {
+		delete(g.stuck, name)
+	} else {
+		g.stuck[name] = reason
+	}
+	stuckFinalizers.WithLabelValues(g.controllerName).Set(float64(len(g.stuck)))
+	return true
+}
+
+// requeueStuckFinalizer returns the result of a reconcile requeuing the resource to check again whether
+// it is stuck in deletion, when the reconcile does not requeue it earlier
+func requeueStuckFinalizer(result reconcile.Result, after time.Duration) reconcile.Result {
+	if after <= 0 || result.Requeue {
+		return result
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > after {
+		result.RequeueAfter = after
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestCheckStuckFinalizer(t *testing.T) {
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	namespace := "rook-ceph"
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "replicapool"}}
+	deleted := metav1.NewTime(time.Now().Add(-time.Hour))
+	newPool := func() *cephv1.CephBlockPool {
+		return &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{
+			Name:              "replicapool",
+			Namespace:         namespace,
+			DeletionTimestamp: &deleted,
+			Finalizers:        []string{"cephblockpool.ceph.rook.io", "kubernetes.io/other"},
+		}}
+	}
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: namespace}}
+
+	recorder := record.NewFakeRecorder(10)
+	SetStuckFinalizerEventRecorder(recorder)
+	defer SetStuckFinalizerEventRecorder(nil)
+	reconcileErr := errors.New("failed to delete pool")
+	newReconciler := func(objects ...runtime.Object) (*stuckFinalizerGate, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
+		resource := gatedResource{controllerName: "finalizer-test-controller", client: c, object: &cephv1.CephBlockPool{}, kind: "CephBlockPool"}
+		r := newStuckFinalizerGate(resource, reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, reconcileErr
+		}))
+		return r, c
+	}
+	event := func() string {
+		select {
+		case e := <-recorder.Events:
+			return e
+		default:
+			return ""
+		}
+	}
+
+	t.Run("not stuck before the timeout", func(t *testing.T) {
+		pool := newPool()
+		recent := metav1.NewTime(time.Now().Add(-time.Minute))
+		pool.DeletionTimestamp = &recent
+		r, _ := newReconciler(pool, cluster)
+		checkAfter, err := r.checkStuckFinalizer(context.TODO(), pool, reconcileErr)
+		assert.NoError(t, err)
+		assert.True(t, checkAfter > 8*time.Minute && checkAfter <= 9*time.Minute)
+		assert.Equal(t, "", event())
+	})
+
+	t.Run("stuck while the cluster exists", func(t *testing.T) {
+		r, _ := newReconciler(newPool(), cluster)
+		result, err := r.Reconcile(context.TODO(), request)
+		assert.Error(t, err)
+		assert.Equal(t, defaultStuckFinalizerTimeout, result.RequeueAfter)
+		e := event()
+		assert.Contains(t, e, "Warning FinalizerStuck")
+		assert.Contains(t, e, "[cephblockpool.ceph.rook.io]")
+		assert.Contains(t, e, "failed to delete pool")
+		assert.Len(t, r.stuck, 1)
+
+		// the event is not recorded again while the reason is the same
+		_, _ = r.Reconcile(context.TODO(), request)
+		assert.Equal(t, "", event())
+	})
+
+	t.Run("removal refused while the cluster exists", func(t *testing.T) {
+		pool := newPool()
+		pool.Annotations = map[string]string{RemoveFinalizerAnnotation: "true"}
+		r, c := newReconciler(pool, cluster)
+		_, _ = r.Reconcile(context.TODO(), request)
+		assert.Contains(t, event(), "Warning FinalizerRemovalRefused")
+		assert.NoError(t, c.Get(context.TODO(), request.NamespacedName, pool))
+		assert.Contains(t, pool.Finalizers, "cephblockpool.ceph.rook.io")
+	})
+
+	t.Run("removal refused when blocked by dependents", func(t *testing.T) {
+		pool := newPool()
+		pool.Annotations = map[string]string{RemoveFinalizerAnnotation: "true"}
+		pool.Status = &cephv1.CephBlockPoolStatus{Conditions: []cephv1.Condition{{Type: cephv1.ConditionDeletionIsBlocked, Status: v1.ConditionTrue, Message: "rados namespaces [ns]"}}}
+		r, _ := newReconciler(pool)
+		_, _ = r.Reconcile(context.TODO(), request)
+		e := event()
+		assert.Contains(t, e, "Warning FinalizerRemovalRefused")
+		assert.Contains(t, e, "rados namespaces [ns]")
+	})
+
+	t.Run("stuck without the cluster", func(t *testing.T) {
+		r, _ := newReconciler(newPool())
+		_, _ = r.Reconcile(context.TODO(), request)
+		assert.Contains(t, event(), "does not exist anymore")
+	})
+
+	t.Run("removed on request without the cluster", func(t *testing.T) {
+		pool := newPool()
+		pool.Annotations = map[string]string{RemoveFinalizerAnnotation: "true"}
+		r, c := newReconciler(pool)
+		cleaned := false
+		RegisterFinalizerCleanup("CephBlockPool", func(context.Context, client.Client, client.Object) error {
+			cleaned = true
+			return nil
+		})
+		defer RegisterFinalizerCleanup("CephBlockPool", nil)
+		result, _ := r.Reconcile(context.TODO(), request)
+		assert.True(t, cleaned)
+		assert.Equal(t, time.Duration(0), result.RequeueAfter)
+		assert.Contains(t, event(), "Normal FinalizerRemoved")
+		err := c.Get(context.TODO(), request.NamespacedName, pool)
+		if err == nil {
+			// only the Rook finalizers are removed
+			assert.Equal(t, []string{"kubernetes.io/other"}, pool.Finalizers)
+		} else {
+			assert.True(t, kerrors.IsNotFound(err))
+		}
+		assert.Len(t, r.stuck, 0)
+	})
+}
+
+func TestRequeueStuckFinalizer(t *testing.T) {
+	assert.Equal(t, reconcile.Result{}, requeueStuckFinalizer(reconcile.Result{}, 0))
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Minute}, requeueStuckFinalizer(reconcile.Result{}, time.Minute))
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, requeueStuckFinalizer(reconcile.Result{RequeueAfter: time.Second}, time.Minute))
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Minute}, requeueStuckFinalizer(reconcile.Result{RequeueAfter: time.Hour}, time.Minute))
+	assert.Equal(t, reconcile.Result{Requeue: true}, requeueStuckFinalizer(reconcile.Result{Requeue: true}, time.Minute))
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TaskLabel is the label of the jobs of a task with the name of the task
+	TaskLabel = "ceph.rook.io/task"
+	// TaskOwnerLabel is the label of the jobs of a task with the kind and the name of the resource of
+	// the task
+	TaskOwnerLabel = "ceph.rook.io/task-owner"
+	// DefaultTaskRetries is the number of retries of a task without retries in its spec
+	DefaultTaskRetries = 3
+)
+
+// TaskOwner is the resource running tasks. The jobs of the tasks are created in its namespace and are
+// owned by it.
+type TaskOwner struct {
+	// Kind is the short kind of the resource in the names of the jobs, like "fs" for a CephFilesystem
+	Kind      string
+	Object    metav1.Object
+	OwnerInfo *k8sutil.OwnerInfo
+}
+
+// TaskJobBuilder returns the pod template of the job of a task. The task fails without retry when
+// the template cannot be built, like when the type or the arguments of the task are not valid.
+type TaskJobBuilder func(task cephv1.TaskSpec) (*v1.PodTemplateSpec, error)
+
+// ReconcileTasks runs the tasks of a resource in jobs and returns the status of the tasks, which the
+// caller reports in the status of the resource. A job is created for each attempt of a task, and a
+// failed job is replaced by a new one until the retries of the task are exhausted. A canceled task
+// has its job deleted and is not retried. The tasks removed from the spec have their jobs deleted
+// and their status dropped. The completed tasks are not run again. ReconcileTasks returns the first
+// error met, the other tasks are still reconciled.
+func ReconcileTasks(ctx context.Context, c client.Client, owner TaskOwner, tasks []cephv1.TaskSpec, current []cephv1.TaskStatus, build TaskJobBuilder) ([]cephv1.TaskStatus, error) {
+	var firstErr error
+	keepErr := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	statuses := []cephv1.TaskStatus{}
+	for _, task := range tasks {
+		status := cephv1.TaskStatus{Name: task.Name, Type: task.Type, Phase: cephv1.TaskPhasePending}
+		if s := findTaskStatus(current, task.Name); s != nil {
+			status = *s
+		}
+		if err := reconcileTask(ctx, c, owner, task, &status, build); err != nil {
+			keepErr(errors.Wrapf(err, "failed to reconcile task %q of %q", task.Name, owner.Object.GetName()))
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, status := range current {
+		if findTaskSpec(tasks, status.Name) != nil {
+			continue
+		}
+		logger.Infof("deleting the jobs of task %q removed from %q", status.Name, owner.Object.GetName())
+		if err := deleteTaskJobs(ctx, c, owner, status.Name); err != nil {
+			keepErr(err)
+			// keep the status until the jobs are deleted
+			statuses = append(statuses, status)
+		}
+	}
+
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	return statuses, firstErr
+}
+
+// IsTaskCompleted returns whether a task succeeded, failed or was canceled
+func IsTaskCompleted(status cephv1.TaskStatus) bool {
+	switch status.Phase {
+	case cephv1.TaskPhaseSucceeded, cephv1.TaskPhaseFailed, cephv1.TaskPhaseCanceled:
+		return true
+	}
+	return false
+}
+
+func reconcileTask(ctx context.Context, c client.Client, owner TaskOwner, task cephv1.TaskSpec, status *cephv1.TaskStatus, build TaskJobBuilder) error {
+	if IsTaskCompleted(*status) {
+		return nil
+	}
+
+	if task.Cancel {
+		if status.JobName != "" {
+			if err := deleteTaskJob(ctx, c, owner.Object.GetNamespace(), status.JobName); err != nil {
+				return err
+			}
+		}
+		logger.Infof("task %q of %q canceled", task.Name, owner.Object.GetName())
+		status.Phase = cephv1.TaskPhaseCanceled
+		status.Message = "canceled"
+		status.CompletionTime = taskTime()
+		return nil
+	}
+
+	if status.JobName == "" {
+		return startTaskAttempt(ctx, c, owner, task, status, build)
+	}
+
+	job := &batch.Job{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: owner.Object.GetNamespace(), Name: status.JobName}, job)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get job %q", status.JobName)
+	}
+
+	var failure string
+	switch {
+	case kerrors.IsNotFound(err):
+		// the job was deleted before it completed
+		failure = fmt.Sprintf("job %q not found", status.JobName)
+	case job.Status.Succeeded > 0:
+		logger.Infof("task %q of %q succeeded", task.Name, owner.Object.GetName())
+		status.Phase = cephv1.TaskPhaseSucceeded
+		status.Message = ""
+		status.CompletionTime = taskTime()
+		return nil
+	default:
+		failure = jobFailure(job)
+		if failure == "" {
+			status.Phase = cephv1.TaskPhaseRunning
+			return nil
+		}
+	}
+
+	status.Message = fmt.Sprintf("attempt %d failed. %s", status.Attempts, failure)
+	if status.Attempts > taskRetries(task) {
+		logger.Errorf("task %q of %q failed after %d attempts. %s", task.Name, owner.Object.GetName(), status.Attempts, failure)
+		status.Phase = cephv1.TaskPhaseFailed
+		status.CompletionTime = taskTime()
+		return nil
+	}
+	logger.Warningf("retrying task %q of %q. %s", task.Name, owner.Object.GetName(), status.Message)
+	return startTaskAttempt(ctx, c, owner, task, status, build)
+}
+
+// startTaskAttempt creates the job of the next attempt of a task
+func startTaskAttempt(ctx context.Context, c client.Client, owner TaskOwner, task cephv1.TaskSpec, status *cephv1.TaskStatus, build TaskJobBuilder) error {
+	template, err := build(task)
+	if err != nil {
+		logger.Errorf("task %q of %q failed. %v", task.Name, owner.Object.GetName(), err)
+		status.Phase = cephv1.TaskPhaseFailed
+		status.Message = err.Error()
+		status.CompletionTime = taskTime()
+		return nil
+	}
+
+	attempt := status.Attempts + 1
+	job := taskJob(owner, task, attempt, template)
+	if err := owner.OwnerInfo.SetControllerReference(job); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference of job %q", job.Name)
+	}
+	if err := c.Create(ctx, job); err != nil && !kerrors.IsAlreadyExists(err) {
+		// the attempt is created again at the next reconcile
+		status.Phase = cephv1.TaskPhasePending
+		status.Message = fmt.Sprintf("failed to create job %q. %v", job.Name, err)
+		return errors.Wrapf(err, "failed to create job %q", job.Name)
+	}
+
+	logger.Infof("started attempt %d of task %q of %q in job %q", attempt, task.Name, owner.Object.GetName(), job.Name)
+	if status.StartTime == "" {
+		status.StartTime = taskTime()
+	}
+	status.Phase = cephv1.TaskPhaseRunning
+	status.JobName = job.Name
+	status.Attempts = attempt
+	return nil
+}
+
+// taskJob returns the job of an attempt of a task. The job is not retried by kubernetes, the retries
+// of the task are new jobs.
+func taskJob(owner TaskOwner, task cephv1.TaskSpec, attempt int32, template *v1.PodTemplateSpec) *batch.Job {
+	labels := taskLabels(owner, task.Name)
+	template = template.DeepCopy()
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		template.Labels[k] = v
+	}
+	template.Spec.RestartPolicy = v1.RestartPolicyNever
+
+	var backoffLimit int32
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      taskJobName(owner, task.Name, attempt),
+			Namespace: owner.Object.GetNamespace(),
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: task.TimeoutSeconds,
+			Template:              *template,
+		},
+	}
+	k8sutil.AddRookVersionLabelToJob(job)
+	return job
+}
+
+func taskJobName(owner TaskOwner, taskName string, attempt int32) string {
+	return k8sutil.TruncateNodeNameForJob("rook-ceph-task-%s", fmt.Sprintf("%s-%s-%s-%d", owner.Kind, owner.Object.GetName(), taskName, attempt))
+}
+
+func taskLabels(owner TaskOwner, taskName string) map[string]string {
+	return map[string]string{
+		TaskOwnerLabel: k8sutil.TruncateNodeName("%s", fmt.Sprintf("%s-%s", owner.Kind, owner.Object.GetName())),
+		TaskLabel:      taskName,
+	}
+}
+
+// jobFailure returns the reason of the failure of a job, empty while the job is running
+func jobFailure(job *batch.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batch.JobFailed && condition.Status == v1.ConditionTrue {
+			return fmt.Sprintf("job %q failed. %s: %s", job.Name, condition.Reason, condition.Message)
+		}
+	}
+	if job.Status.Failed > 0 && job.Status.Active == 0 {
+		return fmt.Sprintf("job %q failed", job.Name)
+	}
+	return ""
+}
+
+func deleteTaskJob(ctx context.Context, c client.Client, namespace, name string) error {
+	job := &batch.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if err := c.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete job %q", name)
+	}
+	return nil
+}
+
+// deleteTaskJobs deletes all the jobs of a task
+func deleteTaskJobs(ctx context.Context, c client.Client, owner TaskOwner, taskName string) error {
+	jobs := &batch.JobList{}
+	if err := c.List(ctx, jobs, client.InNamespace(owner.Object.GetNamespace()), client.MatchingLabels(taskLabels(owner, taskName))); err != nil {
+		return errors.Wrapf(err, "failed to list the jobs of task %q", taskName)
+	}
+	for _, job := range jobs.Items {
+		if err := deleteTaskJob(ctx, c, job.Namespace, job.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func taskRetries(task cephv1.TaskSpec) int32 {
+	if task.Retries == nil {
+		return DefaultTaskRetries
+	}
+	return *task.Retries
+}
+
+func findTaskStatus(statuses []cephv1.TaskStatus, name string) *cephv1.TaskStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+func findTaskSpec(tasks []cephv1.TaskSpec, name string) *cephv1.TaskSpec {
+	for i := range tasks {
+		if tasks[i].Name == name {
+			return &tasks[i]
+		}
+	}
+	return nil
+}
+
+func taskTime() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// CephTaskPodTemplate returns the pod template of a task running a bash script with the ceph tools of
+// the ceph image, connected to the cluster with the admin keyring
+func CephTaskPodTemplate(image, script string) *v1.PodTemplateSpec {
+	return &v1.PodTemplateSpec{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:            "task",
+					Image:           image,
+					Command:         []string{"/bin/bash", "-c", script},
+					Env:             append(DaemonEnvVars(image), v1.EnvVar{Name: "CEPH_ARGS", Value: fmt.Sprintf("-m $(ROOK_CEPH_MON_HOST) -k %s", keyring.VolumeMount().AdminKeyringFilePath())}),
+					VolumeMounts:    []v1.VolumeMount{keyring.VolumeMount().Admin()},
+					SecurityContext: PodSecurityContext(),
+				},
+			},
+			Volumes:       []v1.Volume{keyring.Volume().Admin()},
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+}
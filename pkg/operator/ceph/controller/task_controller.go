@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// TaskKind is a kind of resource running tasks
+type TaskKind struct {
+	// Kind is the short kind of the resources in the names of the jobs, like "fs" for a CephFilesystem
+	Kind string
+	// Object is an empty resource of the kind, with its type meta
+	Object client.Object
+	// Tasks returns the tasks in the spec of a resource and their status
+	Tasks func(object client.Object) ([]cephv1.TaskSpec, []cephv1.TaskStatus)
+	// SetTaskStatuses sets the status of the tasks of a resource
+	SetTaskStatuses func(object client.Object, statuses []cephv1.TaskStatus)
+	// JobBuilder returns the builder of the jobs of the tasks of a resource. It is only called when a
+	// task has to be run, and the resource is reconciled again when it returns an error.
+	JobBuilder func(ctx context.Context, object client.Object) (TaskJobBuilder, error)
+}
+
+type taskReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+	kind   TaskKind
+}
+
+// AddTaskController adds the controller running the tasks of a kind of resource. The tasks are
+// reconciled when they change in the spec and when their jobs complete or are deleted, without
+// reconciling the rest of the resource.
+func AddTaskController(mgr manager.Manager, controllerName string, kind TaskKind) error {
+	r := &taskReconciler{client: mgr.GetClient(), scheme: mgr.GetScheme(), kind: kind}
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: WithReconcileMetrics(controllerName, mgr.GetClient(), kind.Object, r)})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes on the tasks of the resources
+	err = c.Watch(&source.Kind{Type: kind.Object}, &handler.EnqueueRequestForObject{}, taskPredicate(kind))
+	if err != nil {
+		return err
+	}
+
+	// Watch for the completion of the jobs of the tasks
+	err = c.Watch(&source.Kind{Type: &batch.Job{TypeMeta: metav1.TypeMeta{Kind: "Job", APIVersion: batch.SchemeGroupVersion.String()}}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    kind.Object,
+	}, taskJobPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile runs the tasks of a resource and reports them in its status
+func (r *taskReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	object := r.kind.Object.DeepCopyObject().(client.Object)
+	if err := r.client.Get(ctx, request.NamespacedName, object); err != nil {
+		if kerrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get %q", request.NamespacedName)
+	}
+	// the jobs of a deleted resource are deleted with it
+	if !object.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	tasks, current := r.kind.Tasks(object)
+	if len(tasks) == 0 && len(current) == 0 {
+		return reconcile.Result{}, nil
+	}
+
+	var build TaskJobBuilder
+	if hasRunnableTasks(tasks, current) {
+		var err error
+		build, err = r.kind.JobBuilder(ctx, object)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to run the tasks of %q", request.NamespacedName)
+		}
+	}
+
+	owner := TaskOwner{Kind: r.kind.Kind, Object: object, OwnerInfo: k8sutil.NewOwnerInfo(object, r.scheme)}
+	statuses, reconcileErr := ReconcileTasks(ctx, r.client, owner, tasks, current, build)
+
+	err := reporting.PatchStatus(r.client, object, func() bool {
+		r.kind.SetTaskStatuses(object, statuses)
+		return true
+	})
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to update the status of the tasks of %q", request.NamespacedName)
+	}
+	return reconcile.Result{}, reconcileErr
+}
+
+// hasRunnableTasks returns whether a task of the spec is not completed, and may need a job
+func hasRunnableTasks(tasks []cephv1.TaskSpec, current []cephv1.TaskStatus) bool {
+	for _, task := range tasks {
+		status := findTaskStatus(current, task.Name)
+		if status == nil || !IsTaskCompleted(*status) {
+			return true
+		}
+	}
+	return false
+}
+
+// taskPredicate filters the events of the resources running tasks. The resources are reconciled
+// when their tasks change, and at startup for the tasks that were running.
+func taskPredicate(kind TaskKind) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			tasks, current := kind.Tasks(e.Object)
+			return len(tasks) > 0 || len(current) > 0
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			tasksOld, _ := kind.Tasks(e.ObjectOld)
+			tasksNew, _ := kind.Tasks(e.ObjectNew)
+			return !reflect.DeepEqual(tasksOld, tasksNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// taskJobPredicate filters the events of the jobs of the tasks. The owner of a job is reconciled when
+// the job completes or is deleted, the job of a task is created by its owner.
+func taskJobPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			jobOld, ok := e.ObjectOld.(*batch.Job)
+			if !ok || !isTaskJob(jobOld) {
+				return false
+			}
+			jobNew := e.ObjectNew.(*batch.Job)
+			return isJobCompleted(jobOld) != isJobCompleted(jobNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isTaskJob(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func isTaskJob(object client.Object) bool {
+	_, ok := object.GetLabels()[TaskLabel]
+	return ok
+}
+
+func isJobCompleted(job *batch.Job) bool {
+	return job.Status.Succeeded > 0 || jobFailure(job) != ""
+}
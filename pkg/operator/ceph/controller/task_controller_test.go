@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func fsTaskKind(builds *int, buildErr *error) TaskKind {
+	return TaskKind{
+		Kind:   "fs",
+		Object: &cephv1.CephFilesystem{},
+		Tasks: func(object client.Object) ([]cephv1.TaskSpec, []cephv1.TaskStatus) {
+			fs := object.(*cephv1.CephFilesystem)
+			if fs.Status == nil {
+				return fs.Spec.Tasks, nil
+			}
+			return fs.Spec.Tasks, fs.Status.Tasks
+		},
+		SetTaskStatuses: func(object client.Object, statuses []cephv1.TaskStatus) {
+			fs := object.(*cephv1.CephFilesystem)
+			if fs.Status == nil {
+				fs.Status = &cephv1.CephFilesystemStatus{}
+			}
+			fs.Status.Tasks = statuses
+		},
+		JobBuilder: func(_ context.Context, _ client.Object) (TaskJobBuilder, error) {
+			*builds++
+			if *buildErr != nil {
+				return nil, *buildErr
+			}
+			return func(task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+				return CephTaskPodTemplate("quay.io/ceph/ceph:v17", "ceph status"), nil
+			}, nil
+		},
+	}
+}
+
+func TestTaskReconciler(t *testing.T) {
+	ctx := context.TODO()
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	assert.NoError(t, batch.AddToScheme(s))
+	fs := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph", UID: "123"}}
+	fs.Spec.Tasks = []cephv1.TaskSpec{{Name: "scrub", Type: cephv1.TaskTypeScrub}}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(fs).Build()
+	builds := 0
+	var buildErr error
+	r := &taskReconciler{client: c, scheme: s, kind: fsTaskKind(&builds, &buildErr)}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "rook-ceph", Name: "myfs"}}
+	getStatus := func() cephv1.TaskStatus {
+		fs := &cephv1.CephFilesystem{}
+		assert.NoError(t, c.Get(ctx, request.NamespacedName, fs))
+		assert.Len(t, fs.Status.Tasks, 1)
+		return fs.Status.Tasks[0]
+	}
+
+	// the resource is reconciled again when the jobs cannot be built
+	buildErr = errors.New("the cluster is not ready")
+	_, err := r.Reconcile(ctx, request)
+	assert.Error(t, err)
+	jobs := &batch.JobList{}
+	assert.NoError(t, c.List(ctx, jobs))
+	assert.Empty(t, jobs.Items)
+
+	// the job of the task is started, and the resource is not requeued while it runs
+	buildErr = nil
+	result, err := r.Reconcile(ctx, request)
+	assert.NoError(t, err)
+	assert.True(t, result.IsZero())
+	status := getStatus()
+	assert.Equal(t, cephv1.TaskPhaseRunning, status.Phase)
+	job := &batch.Job{}
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: status.JobName}, job))
+	assert.Equal(t, "myfs", job.OwnerReferences[0].Name)
+	assert.True(t, *job.OwnerReferences[0].Controller)
+
+	// the completion of the job completes the task
+	job.Status.Succeeded = 1
+	assert.NoError(t, c.Update(ctx, job))
+	result, err = r.Reconcile(ctx, request)
+	assert.NoError(t, err)
+	assert.True(t, result.IsZero())
+	assert.Equal(t, cephv1.TaskPhaseSucceeded, getStatus().Phase)
+
+	// the jobs are not built for the completed tasks
+	builds = 0
+	_, err = r.Reconcile(ctx, request)
+	assert.NoError(t, err)
+	assert.Zero(t, builds)
+
+	// a deleted resource is ignored
+	assert.NoError(t, c.Delete(ctx, fs))
+	_, err = r.Reconcile(ctx, request)
+	assert.NoError(t, err)
+}
+
+func TestTaskPredicate(t *testing.T) {
+	var builds int
+	var buildErr error
+	p := taskPredicate(fsTaskKind(&builds, &buildErr))
+	fs := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph"}}
+	assert.False(t, p.Create(event.CreateEvent{Object: fs}))
+
+	withTask := fs.DeepCopy()
+	withTask.Spec.Tasks = []cephv1.TaskSpec{{Name: "scrub", Type: cephv1.TaskTypeScrub}}
+	assert.True(t, p.Create(event.CreateEvent{Object: withTask}))
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: fs, ObjectNew: withTask}))
+
+	// the other changes of the spec are reconciled by the controller of the resource
+	changed := withTask.DeepCopy()
+	changed.Spec.PreserveFilesystemOnDelete = true
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: withTask, ObjectNew: changed}))
+	assert.False(t, p.Delete(event.DeleteEvent{Object: withTask}))
+}
+
+func TestTaskJobPredicate(t *testing.T) {
+	p := taskJobPredicate()
+	job := &batch.Job{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-task-fs-myfs-scrub-1", Labels: map[string]string{TaskLabel: "scrub"}}}
+	assert.False(t, p.Create(event.CreateEvent{Object: job}))
+
+	running := job.DeepCopy()
+	running.Status.Active = 1
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: job, ObjectNew: running}))
+
+	succeeded := running.DeepCopy()
+	succeeded.Status.Active = 0
+	succeeded.Status.Succeeded = 1
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: succeeded}))
+
+	failed := running.DeepCopy()
+	failed.Status.Active = 0
+	failed.Status.Failed = 1
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: failed}))
+	assert.True(t, p.Delete(event.DeleteEvent{Object: running}))
+
+	// the other jobs are ignored
+	other := &batch.Job{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-prepare"}}
+	otherSucceeded := other.DeepCopy()
+	otherSucceeded.Status.Succeeded = 1
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: otherSucceeded}))
+	assert.False(t, p.Delete(event.DeleteEvent{Object: other}))
+}
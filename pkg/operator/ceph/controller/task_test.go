@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileTasks(t *testing.T) {
+	ctx := context.TODO()
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	fs := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph", UID: "123"}}
+	owner := TaskOwner{Kind: "fs", Object: fs, OwnerInfo: k8sutil.NewOwnerInfoWithOwnerRef(&metav1.OwnerReference{APIVersion: "ceph.rook.io/v1", Kind: "CephFilesystem", Name: "myfs", UID: "123"}, "rook-ceph")}
+	build := func(task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+		if task.Type != cephv1.TaskTypeScrub {
+			return nil, errors.Errorf("task type %q is not supported", task.Type)
+		}
+		return CephTaskPodTemplate("quay.io/ceph/ceph:v17", "ceph status"), nil
+	}
+	getJob := func(name string) *batch.Job {
+		job := &batch.Job{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: name}, job)
+		assert.NoError(t, err)
+		return job
+	}
+	setJobStatus := func(name string, jobStatus batch.JobStatus) {
+		job := getJob(name)
+		job.Status = jobStatus
+		assert.NoError(t, c.Update(ctx, job))
+	}
+	retries := int32(1)
+	tasks := []cephv1.TaskSpec{{Name: "scrub", Type: cephv1.TaskTypeScrub, Retries: &retries}}
+
+	// the first attempt is started
+	statuses, err := ReconcileTasks(ctx, c, owner, tasks, nil, build)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, cephv1.TaskPhaseRunning, statuses[0].Phase)
+	assert.Equal(t, int32(1), statuses[0].Attempts)
+	assert.Equal(t, "rook-ceph-task-fs-myfs-scrub-1", statuses[0].JobName)
+	assert.NotEmpty(t, statuses[0].StartTime)
+	job := getJob(statuses[0].JobName)
+	assert.Equal(t, "scrub", job.Labels[TaskLabel])
+	assert.Equal(t, "fs-myfs", job.Labels[TaskOwnerLabel])
+	assert.Equal(t, "scrub", job.Spec.Template.Labels[TaskLabel])
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	assert.Equal(t, v1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+	assert.Equal(t, "myfs", job.OwnerReferences[0].Name)
+
+	// the running job is waited for
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks, statuses, build)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.TaskPhaseRunning, statuses[0].Phase)
+	assert.Equal(t, int32(1), statuses[0].Attempts)
+
+	// the failed job is retried
+	setJobStatus(statuses[0].JobName, batch.JobStatus{Failed: 1, Conditions: []batch.JobCondition{{Type: batch.JobFailed, Status: v1.ConditionTrue, Reason: "DeadlineExceeded"}}})
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks, statuses, build)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.TaskPhaseRunning, statuses[0].Phase)
+	assert.Equal(t, int32(2), statuses[0].Attempts)
+	assert.Equal(t, "rook-ceph-task-fs-myfs-scrub-2", statuses[0].JobName)
+	assert.Contains(t, statuses[0].Message, "DeadlineExceeded")
+
+	// the task fails when the retries are exhausted
+	setJobStatus(statuses[0].JobName, batch.JobStatus{Failed: 1})
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks, statuses, build)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.TaskPhaseFailed, statuses[0].Phase)
+	assert.Equal(t, int32(2), statuses[0].Attempts)
+	assert.NotEmpty(t, statuses[0].CompletionTime)
+
+	// the failed task is not run again
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks, statuses, build)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.TaskPhaseFailed, statuses[0].Phase)
+	assert.Equal(t, int32(2), statuses[0].Attempts)
+
+	// a new task succeeds
+	tasks = append(tasks, cephv1.TaskSpec{Name: "scrub-again", Type: cephv1.TaskTypeScrub})
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks, statuses, build)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	setJobStatus(statuses[1].JobName, batch.JobStatus{Succeeded: 1})
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks, statuses, build)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.TaskPhaseSucceeded, statuses[1].Phase)
+	assert.Empty(t, statuses[1].Message)
+
+	// the jobs of the removed tasks are deleted
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks[1:], statuses, build)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "scrub-again", statuses[0].Name)
+	jobs := &batch.JobList{}
+	assert.NoError(t, c.List(ctx, jobs, client.MatchingLabels{TaskLabel: "scrub"}))
+	assert.Empty(t, jobs.Items)
+
+	statuses, err = ReconcileTasks(ctx, c, owner, nil, statuses, build)
+	assert.NoError(t, err)
+	assert.Nil(t, statuses)
+}
+
+func TestReconcileTasksCancel(t *testing.T) {
+	ctx := context.TODO()
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	store := &cephv1.CephObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "store", Namespace: "rook-ceph", UID: "123"}}
+	owner := TaskOwner{Kind: "objectstore", Object: store, OwnerInfo: k8sutil.NewOwnerInfoWithOwnerRef(&metav1.OwnerReference{APIVersion: "ceph.rook.io/v1", Kind: "CephObjectStore", Name: "store", UID: "123"}, "rook-ceph")}
+	build := func(task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+		if task.Args["bucket"] == "" {
+			return nil, errors.New("the bucket of the reshard is not set")
+		}
+		return CephTaskPodTemplate("quay.io/ceph/ceph:v17", "radosgw-admin bucket reshard"), nil
+	}
+
+	tasks := []cephv1.TaskSpec{
+		{Name: "reshard", Type: cephv1.TaskTypeReshard, Args: map[string]string{"bucket": "b"}},
+		{Name: "invalid", Type: cephv1.TaskTypeReshard},
+	}
+	statuses, err := ReconcileTasks(ctx, c, owner, tasks, nil, build)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.TaskPhaseRunning, statuses[0].Phase)
+	// the task failing to build is not retried
+	assert.Equal(t, cephv1.TaskPhaseFailed, statuses[1].Phase)
+	assert.Equal(t, "the bucket of the reshard is not set", statuses[1].Message)
+	assert.Equal(t, int32(0), statuses[1].Attempts)
+
+	// the job of the canceled task is deleted
+	jobName := statuses[0].JobName
+	tasks[0].Cancel = true
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks, statuses, build)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.TaskPhaseCanceled, statuses[0].Phase)
+	assert.NotEmpty(t, statuses[0].CompletionTime)
+	err = c.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: jobName}, &batch.Job{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// a task deleted before it started is canceled
+	tasks = []cephv1.TaskSpec{{Name: "never", Type: cephv1.TaskTypeReshard, Cancel: true}}
+	statuses, err = ReconcileTasks(ctx, c, owner, tasks, nil, build)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.TaskPhaseCanceled, statuses[0].Phase)
+	assert.Empty(t, statuses[0].JobName)
+}
+
+func TestTaskJobName(t *testing.T) {
+	owner := TaskOwner{Kind: "objectstore", Object: &metav1.ObjectMeta{Name: "a-very-long-object-store-name-for-the-tests"}}
+	name := taskJobName(owner, "reshard-bucket-with-a-long-name", 1)
+	assert.LessOrEqual(t, len(name), 53)
+	assert.NotEqual(t, name, taskJobName(owner, "reshard-bucket-with-a-long-name", 2))
+}
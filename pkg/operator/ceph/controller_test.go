@@ -28,6 +28,7 @@ import (
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/operator/test"
 	"github.com/rook/rook/pkg/util/exec"
 	"github.com/stretchr/testify/assert"
@@ -127,9 +128,9 @@ func TestOperatorController(t *testing.T) {
 		}
 
 		// Register operator types with the runtime scheme.
-		// s := scheme.Scheme
-		s := clientgoscheme.Scheme
-		// s.AddKnownTypes(cephv1.SchemeGroupVersion, &v1.ConfigMap{})
+		s := runtime.NewScheme()
+		assert.NoError(t, clientgoscheme.AddToScheme(s))
+		assert.NoError(t, scheme.AddToScheme(s))
 
 		opConfigCM := &v1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
@@ -199,4 +200,81 @@ func TestOperatorController(t *testing.T) {
 		assert.Equal(t, 1, len(ds.Items), ds)
 		assert.Equal(t, "rook-discover", ds.Items[0].Name, ds)
 	})
+	t.Run("success - CephOperatorConfig overrides the cm", func(t *testing.T) {
+		fakeClientSet := test.New(t, 1)
+		test.SetFakeKubernetesVersion(fakeClientSet, "v1.21.0")
+		c := &clusterd.Context{
+			Clientset:     fakeClientSet,
+			RookClientset: rookclient.NewSimpleClientset(),
+		}
+
+		s := runtime.NewScheme()
+		assert.NoError(t, clientgoscheme.AddToScheme(s))
+		assert.NoError(t, scheme.AddToScheme(s))
+
+		opConfigCM := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      controller.OperatorSettingConfigMapName,
+				Namespace: namespace,
+			},
+			Data: map[string]string{
+				"ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS": "20",
+				"CSI_LOG_LEVEL":                      "5",
+			},
+		}
+		timeout := 25
+		operatorConfig := &cephv1.CephOperatorConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       cephv1.OperatorConfigName,
+				Namespace:  namespace,
+				Generation: 2,
+			},
+			Spec: cephv1.OperatorConfigSpec{
+				CephCommands: &cephv1.OperatorCephCommandsSpec{TimeoutSeconds: &timeout},
+			},
+		}
+
+		cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(opConfigCM, operatorConfig).Build()
+		r := &ReconcileConfig{
+			client:           cl,
+			context:          c,
+			opManagerContext: ctx,
+			config: controller.OperatorConfig{
+				OperatorNamespace: namespace,
+				Image:             "rook",
+				ServiceAccount:    "foo",
+			},
+		}
+		defer k8sutil.SetOperatorSettingOverrides(nil)
+
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.False(t, res.Requeue)
+		assert.Equal(t, time.Second*25, exec.CephCommandsTimeout)
+
+		// the settings applied are reported with their source
+		err = cl.Get(ctx, req.NamespacedName, operatorConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionReady, operatorConfig.Status.Phase)
+		assert.Equal(t, int64(2), operatorConfig.Status.ObservedGeneration)
+		settings := map[string]cephv1.OperatorSettingStatus{}
+		for _, setting := range operatorConfig.Status.EffectiveSettings {
+			settings[setting.Name] = setting
+		}
+		assert.Equal(t, cephv1.OperatorSettingStatus{Name: "ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS", Value: "25", Source: cephv1.OperatorSettingSourceCR}, settings["ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS"])
+		assert.Equal(t, cephv1.OperatorSettingSourceConfigMap, settings["CSI_LOG_LEVEL"].Source)
+		assert.Equal(t, cephv1.OperatorSettingSourceDefault, settings["ROOK_CEPH_COMMANDS_RETRIES"].Source)
+
+		// an invalid spec is not applied
+		operatorConfig.Spec.Settings = map[string]string{"ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS": "30"}
+		err = cl.Update(ctx, operatorConfig)
+		assert.NoError(t, err)
+		_, err = r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Second*20, exec.CephCommandsTimeout)
+		err = cl.Get(ctx, req.NamespacedName, operatorConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionFailure, operatorConfig.Status.Phase)
+		assert.Contains(t, operatorConfig.Status.Message, "ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS")
+	})
 }
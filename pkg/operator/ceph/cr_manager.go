@@ -21,22 +21,27 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/adoption"
 	"github.com/rook/rook/pkg/operator/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/crash"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/csi/staticvolume"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/clusterdisruption"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/machinedisruption"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/machinelabel"
+	"github.com/rook/rook/pkg/operator/ceph/draction"
 	"github.com/rook/rook/pkg/operator/ceph/file"
 	"github.com/rook/rook/pkg/operator/ceph/file/mirror"
+	"github.com/rook/rook/pkg/operator/ceph/file/subvolume"
 	"github.com/rook/rook/pkg/operator/ceph/file/subvolumegroup"
 	"github.com/rook/rook/pkg/operator/ceph/nfs"
 	"github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/ceph/object/bucket"
+	"github.com/rook/rook/pkg/operator/ceph/object/cosi"
 	"github.com/rook/rook/pkg/operator/ceph/object/notification"
 	"github.com/rook/rook/pkg/operator/ceph/object/realm"
 	"github.com/rook/rook/pkg/operator/ceph/object/topic"
@@ -44,15 +49,23 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/object/zone"
 	"github.com/rook/rook/pkg/operator/ceph/object/zonegroup"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"github.com/rook/rook/pkg/operator/ceph/pool/mirrorpeer"
+	"github.com/rook/rook/pkg/operator/ceph/pool/peertoken"
+	"github.com/rook/rook/pkg/operator/ceph/pool/radosnamespace"
+	pooltopology "github.com/rook/rook/pkg/operator/ceph/pool/topology"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
 	mapiv1 "github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
 	healthchecking "github.com/openshift/machine-api-operator/pkg/apis/healthchecking/v1alpha1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephv2alpha1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v2alpha1"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
-	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 const (
@@ -65,11 +78,40 @@ var (
 		mapiv1.AddToScheme,
 		healthchecking.AddToScheme,
 		cephv1.AddToScheme,
+		cephv2alpha1.AddToScheme,
+		bktv1alpha1.AddToScheme,
 	}
 )
 
 var (
-	webhookResources = []webhook.Validator{&cephv1.CephCluster{}, &cephv1.CephBlockPool{}, &cephv1.CephObjectStore{}}
+	// the resources validated by the admission webhook, all the CRs with settings that can be
+	// validated before they are reconciled. The /convert endpoint of the conversion webhook is
+	// registered along with them for the types that have other versions than the v1 hub.
+	webhookResources = []runtime.Object{
+		&cephv1.CephCluster{},
+		&cephv1.CephBlockPool{},
+		&cephv1.CephBlockPoolRadosNamespace{},
+		&cephv1.CephBlockPoolTopology{},
+		&cephv1.CephRBDMirror{},
+		&cephv1.CephRBDMirrorPeer{},
+		&cephv1.CephRBDMirrorPeerToken{},
+		&cephv1.CephDRAction{},
+		&cephv1.CephFilesystem{},
+		&cephv1.CephFilesystemSubVolumeGroup{},
+		&cephv1.CephFilesystemSubVolume{},
+		&cephv1.CephObjectStore{},
+		&cephv1.CephObjectStoreUser{},
+		&cephv1.CephObjectRealm{},
+		&cephv1.CephObjectZoneGroup{},
+		&cephv1.CephObjectZone{},
+		&cephv1.CephBucketTopic{},
+		&cephv1.CephBucketNotification{},
+		&cephv1.CephNFS{},
+		&cephv1.CephClient{},
+		&cephv1.CephCSIDriver{},
+		&cephv1.CephStaticVolume{},
+		&cephv1.CephOperatorConfig{},
+	}
 )
 
 var (
@@ -77,43 +119,116 @@ var (
 	EnableMachineDisruptionBudget bool
 )
 
+// cephController is a controller with the permissions it requires to reconcile
+type cephController struct {
+	name string
+	add  func(manager.Manager, *clusterd.Context, context.Context, opcontroller.OperatorConfig) error
+	// the permissions checked in the namespaces watched by the operator
+	permissions []opcontroller.Permission
+	// whether the controller provisions or watches the ObjectBucketClaims
+	obc bool
+}
+
+// maintenanceController is a controller of the disruptions of the nodes with the permissions it
+// requires to reconcile
+type maintenanceController struct {
+	name        string
+	add         func(manager.Manager, *controllerconfig.Context) error
+	permissions []opcontroller.Permission
+}
+
+var (
+	nodePermissions         = opcontroller.NewPermissions("", []string{"nodes"}, "get", "list", "watch")
+	obcPermissions          = opcontroller.NewPermissions("objectbucket.io", []string{"objectbucketclaims"}, "get", "list", "watch", "update")
+	obPermissions           = opcontroller.NewPermissions("objectbucket.io", []string{"objectbuckets"}, "get", "list", "watch", "create", "update", "delete")
+	csiDriverPermissions    = opcontroller.NewPermissions("storage.k8s.io", []string{"csidrivers"}, "get", "create", "update", "delete")
+	storageClassPermissions = opcontroller.NewPermissions("storage.k8s.io", []string{"storageclasses"}, "get", "list", "watch", "create", "update", "delete")
+)
+
+// permissions concatenates the permissions of a controller
+func permissions(lists ...[]opcontroller.Permission) []opcontroller.Permission {
+	all := []opcontroller.Permission{}
+	for _, l := range lists {
+		all = append(all, l...)
+	}
+	return all
+}
+
 // AddToManagerFuncsMaintenance is a list of functions to add all Controllers to the Manager (entrypoint for controller)
-var AddToManagerFuncsMaintenance = []func(manager.Manager, *controllerconfig.Context) error{
-	clusterdisruption.Add,
+var AddToManagerFuncsMaintenance = []maintenanceController{
+	{"clusterdisruption", clusterdisruption.Add, opcontroller.NewPermissions("policy", []string{"poddisruptionbudgets"}, "get", "list", "watch", "create", "update", "delete")},
 }
 
 // MachineDisruptionBudgetAddToManagerFuncs is a list of fencing related functions to add all Controllers to the Manager (entrypoint for controller)
-var MachineDisruptionBudgetAddToManagerFuncs = []func(manager.Manager, *controllerconfig.Context) error{
-	machinelabel.Add,
-	machinedisruption.Add,
+var MachineDisruptionBudgetAddToManagerFuncs = []maintenanceController{
+	{"machinelabel", machinelabel.Add, opcontroller.NewPermissions("machine.openshift.io", []string{"machines"}, "get", "list", "watch", "update")},
+	{"machinedisruption", machinedisruption.Add, opcontroller.NewPermissions("healthchecking.openshift.io", []string{"machinedisruptionbudgets"}, "get", "list", "watch", "create", "update", "delete")},
 }
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager (entrypoint for controller)
-var AddToManagerFuncs = []func(manager.Manager, *clusterd.Context, context.Context, opcontroller.OperatorConfig) error{
-	crash.Add,
-	pool.Add,
-	objectuser.Add,
-	realm.Add,
-	zonegroup.Add,
-	zone.Add,
-	object.Add,
-	file.Add,
-	nfs.Add,
-	rbd.Add,
-	client.Add,
-	mirror.Add,
-	Add,
-	csi.Add,
-	bucket.Add,
-	topic.Add,
-	notification.Add,
-	subvolumegroup.Add,
+var AddToManagerFuncs = []cephController{
+	{name: "crash", add: crash.Add, permissions: permissions(opcontroller.NewPermissions("ceph.rook.io", []string{"cephclusters"}, "get", "list", "watch"), nodePermissions)},
+	{name: "pool", add: pool.Add, permissions: opcontroller.CustomResourcePermissions("cephblockpools")},
+	{name: "objectuser", add: objectuser.Add, permissions: opcontroller.CustomResourcePermissions("cephobjectstoreusers")},
+	{name: "realm", add: realm.Add, permissions: opcontroller.CustomResourcePermissions("cephobjectrealms")},
+	{name: "zonegroup", add: zonegroup.Add, permissions: opcontroller.CustomResourcePermissions("cephobjectzonegroups")},
+	{name: "zone", add: zone.Add, permissions: opcontroller.CustomResourcePermissions("cephobjectzones")},
+	{name: "object", add: object.Add, permissions: opcontroller.CustomResourcePermissions("cephobjectstores")},
+	{name: "file", add: file.Add, permissions: opcontroller.CustomResourcePermissions("cephfilesystems")},
+	{name: "nfs", add: nfs.Add, permissions: opcontroller.CustomResourcePermissions("cephnfses")},
+	{name: "rbd", add: rbd.Add, permissions: opcontroller.CustomResourcePermissions("cephrbdmirrors")},
+	{name: "client", add: client.Add, permissions: opcontroller.CustomResourcePermissions("cephclients")},
+	{name: "mirror", add: mirror.Add, permissions: opcontroller.CustomResourcePermissions("cephfilesystemmirrors")},
+	{name: "operator-config", add: Add, permissions: permissions(opcontroller.NewPermissions("", []string{"configmaps", "secrets"}, "get", "list", "watch"), opcontroller.CustomResourcePermissions("cephoperatorconfigs"))},
+	{name: "csi", add: csi.Add, permissions: permissions(opcontroller.CustomResourcePermissions("cephcsidrivers"), csiDriverPermissions, opcontroller.NewPermissions("storage.k8s.io", []string{"csinodes"}, "get"))},
+	{name: "bucket", add: bucket.Add, permissions: permissions(obcPermissions, obPermissions), obc: true},
+	{name: "topic", add: topic.Add, permissions: opcontroller.CustomResourcePermissions("cephbuckettopics")},
+	{name: "notification", add: notification.Add, permissions: permissions(opcontroller.CustomResourcePermissions("cephbucketnotifications"), obcPermissions), obc: true},
+	{name: "cosimigration", add: cosi.Add, permissions: permissions(obcPermissions, obPermissions), obc: true},
+	{name: "subvolumegroup", add: subvolumegroup.Add, permissions: opcontroller.CustomResourcePermissions("cephfilesystemsubvolumegroups")},
+	{name: "subvolume", add: subvolume.Add, permissions: opcontroller.CustomResourcePermissions("cephfilesystemsubvolumes")},
+	{name: "pooltopology", add: pooltopology.Add, permissions: permissions(opcontroller.CustomResourcePermissions("cephblockpooltopologies"), opcontroller.NewPermissions("ceph.rook.io", []string{"cephblockpools"}, "create", "delete"), storageClassPermissions)},
+	{name: "staticvolume", add: staticvolume.Add, permissions: permissions(opcontroller.CustomResourcePermissions("cephstaticvolumes"), opcontroller.NewPermissions("", []string{"persistentvolumes"}, "get", "list", "watch", "create", "update", "delete"))},
+	{name: "mirrorpeer", add: mirrorpeer.Add, permissions: opcontroller.CustomResourcePermissions("cephrbdmirrorpeers")},
+	{name: "peertoken", add: peertoken.Add, permissions: opcontroller.CustomResourcePermissions("cephrbdmirrorpeertokens")},
+	{name: "draction", add: draction.Add, permissions: opcontroller.CustomResourcePermissions("cephdractions")},
+	{name: "radosnamespace", add: radosnamespace.Add, permissions: opcontroller.CustomResourcePermissions("cephblockpoolradosnamespaces")},
+	{name: "adoption", add: adoption.Add, permissions: permissions(opcontroller.CustomResourcePermissions("cephclusters"), opcontroller.NewPermissions("ceph.rook.io", []string{"cephfilesystems", "cephobjectstores"}, "get", "list", "watch"),
+		opcontroller.NewPermissions("ceph.rook.io", []string{"cephblockpools", "cephblockpoolradosnamespaces", "cephfilesystemsubvolumegroups", "cephobjectstoreusers"}, "get", "list", "watch", "create"))},
 }
 
+// the permissions of the CephCluster controller
+var clusterPermissions = permissions(opcontroller.CustomResourcePermissions("cephclusters"), nodePermissions)
+
 // AddToManagerOpFunc is a list of functions to add all Controllers to the Manager (entrypoint for
 // controller)
 // var AddToManagerOpFunc = []func(manager.Manager, *clusterd.Context, opcontroller.OperatorConfig) error{}
 
+// controllerAllowed returns whether a controller can be started. When the operator requires the
+// permissions of the controllers, a controller lacking permissions is not started so that it does
+// not fail in the middle of its reconciles.
+func (o *Operator) controllerAllowed(ctx context.Context, requirePermissions bool, name string, permissions []opcontroller.Permission) (bool, error) {
+	if !requirePermissions {
+		return true, nil
+	}
+	// the permissions are granted per namespace when the operator watches a list of namespaces
+	namespaces := o.config.NamespacesToWatch
+	if len(namespaces) == 0 {
+		namespaces = []string{o.config.NamespaceToWatch}
+	}
+	for _, namespace := range namespaces {
+		missing, err := opcontroller.MissingPermissions(ctx, o.context.Clientset, namespace, permissions)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to check the permissions of the %q controller", name)
+		}
+		if len(missing) > 0 {
+			logger.Errorf("not starting the %q controller, the operator is missing the permissions to %v in namespace %q", name, missing, namespace)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // AddToManager adds all the registered controllers to the passed manager.
 // each controller package will have an Add method listed in AddToManagerFuncs
 // which will setup all the necessary watch
@@ -122,31 +237,56 @@ func (o *Operator) addToManager(m manager.Manager, c *controllerconfig.Context,
 		return errors.New("nil context passed")
 	}
 
+	requirePermissionsSetting, _ := k8sutil.GetOperatorSetting(opManagerContext, o.context.Clientset, opcontroller.OperatorSettingConfigMapName, opcontroller.RequireControllerPermissionsSetting, "false")
+	requirePermissions := requirePermissionsSetting == "true"
+	obcProvisionerSetting, _ := k8sutil.GetOperatorSetting(opManagerContext, o.context.Clientset, opcontroller.OperatorSettingConfigMapName, opcontroller.OBCProvisionerSetting, "true")
+	obcProvisioner := obcProvisionerSetting == "true"
+
 	// Run CephCluster CR
-	if err := cluster.Add(m, c.ClusterdContext, o.clusterController, opManagerContext); err != nil {
+	allowed, err := o.controllerAllowed(opManagerContext, requirePermissions, "cluster", clusterPermissions)
+	if err != nil {
 		return err
 	}
+	if allowed {
+		if err := cluster.Add(m, c.ClusterdContext, o.clusterController, opManagerContext); err != nil {
+			return err
+		}
+	}
 
 	// Add Ceph child CR controllers
 	for _, f := range AddToManagerFuncs {
-		if err := f(m, c.ClusterdContext, opManagerContext, *o.config); err != nil {
+		if f.obc && !obcProvisioner {
+			logger.Infof("not starting the %q controller, the object bucket claims are disabled", f.name)
+			continue
+		}
+		allowed, err := o.controllerAllowed(opManagerContext, requirePermissions, f.name, f.permissions)
+		if err != nil {
 			return err
 		}
-	}
-
-	// Add maintenance controllers
-	for _, f := range AddToManagerFuncsMaintenance {
-		if err := f(m, c); err != nil {
+		if !allowed {
+			continue
+		}
+		if err := f.add(m, c.ClusterdContext, opManagerContext, *o.config); err != nil {
 			return err
 		}
 	}
 
+	// Add maintenance controllers
+	maintenanceControllers := AddToManagerFuncsMaintenance
 	// If machine disruption budget is enabled let's add the controllers
 	if EnableMachineDisruptionBudget {
-		for _, f := range MachineDisruptionBudgetAddToManagerFuncs {
-			if err := f(m, c); err != nil {
-				return err
-			}
+		maintenanceControllers = append(maintenanceControllers, MachineDisruptionBudgetAddToManagerFuncs...)
+	}
+	for _, f := range maintenanceControllers {
+		allowed, err := o.controllerAllowed(opManagerContext, requirePermissions, f.name, f.permissions)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			continue
+		}
+		if err := f.add(m, c); err != nil {
+			return err
 		}
 	}
 
@@ -165,12 +305,18 @@ func (o *Operator) startCRDManager(context context.Context, mgrErrorCh chan erro
 		}
 	}
 
+	healthProbeBindAddress, _ := k8sutil.GetOperatorSetting(context, o.context.Clientset, opcontroller.OperatorSettingConfigMapName, opcontroller.HealthProbeBindAddressSetting, opcontroller.DefaultHealthProbeBindAddress)
+
 	// Set up a manager
 	mgrOpts := manager.Options{
-		LeaderElection: false,
-		Namespace:      o.config.NamespaceToWatch,
-		Scheme:         scheme,
-		CertDir:        certDir,
+		LeaderElection:         false,
+		Namespace:              o.config.NamespaceToWatch,
+		Scheme:                 scheme,
+		CertDir:                certDir,
+		HealthProbeBindAddress: healthProbeBindAddress,
+	}
+	if len(o.config.NamespacesToWatch) > 0 {
+		mgrOpts.NewCache = cache.MultiNamespacedCacheBuilder(o.config.NamespacesToWatch)
 	}
 
 	logger.Info("setting up the controller-runtime manager")
@@ -193,15 +339,22 @@ func (o *Operator) startCRDManager(context context.Context, mgrErrorCh chan erro
 			return
 		}
 		logger.Info("setting up admission webhooks")
+		validator := &admissionValidator{context: o.context, reader: mgr.GetAPIReader()}
 		for _, resource := range webhookResources {
-			err = ctrl.NewWebhookManagedBy(mgr).For(resource).Complete()
+			err = ctrl.NewWebhookManagedBy(mgr).For(resource).WithValidator(validator).Complete()
 			if err != nil {
-				mgrErrorCh <- errors.Wrapf(err, "failed to register webhook for %q", resource.GetObjectKind().GroupVersionKind().Kind)
+				mgrErrorCh <- errors.Wrapf(err, "failed to register webhook for %T", resource)
 				return
 			}
 		}
 	}
 
+	// the policies are applied along with the admission webhook if both are enabled
+	if err := o.reconcileAdmissionPolicies(context, mgr.GetClient()); err != nil {
+		mgrErrorCh <- errors.Wrap(err, "failed to apply the admission policies")
+		return
+	}
+
 	// options to pass to the controllers
 	controllerOpts := &controllerconfig.Context{
 		ClusterdContext:   o.context,
@@ -210,12 +363,24 @@ func (o *Operator) startCRDManager(context context.Context, mgrErrorCh chan erro
 	}
 
 	// Add the registered controllers to the manager (entrypoint for controllers)
+	opcontroller.ResetControllersHealth()
+	opcontroller.SetStuckFinalizerEventRecorder(mgr.GetEventRecorderFor("rook-ceph-operator"))
 	err = o.addToManager(mgr, controllerOpts, context)
 	if err != nil {
 		mgrErrorCh <- errors.Wrap(err, "failed to add controllers to controller-runtime manager")
 		return
 	}
 
+	if healthProbeBindAddress != "" {
+		if err := addHealthChecks(mgr); err != nil {
+			mgrErrorCh <- errors.Wrap(err, "failed to add the health checks to the controller-runtime manager")
+			return
+		}
+	}
+
+	// the resources are reconciled in the order of their dependencies after the controllers start
+	opcontroller.StartReconcileOrder()
+
 	logger.Info("starting the controller-runtime manager")
 	if err := mgr.Start(context); err != nil {
 		mgrErrorCh <- errors.Wrap(err, "failed to run the controller-runtime manager")
@@ -224,3 +389,28 @@ func (o *Operator) startCRDManager(context context.Context, mgrErrorCh chan erro
 
 	logger.Info("successfully started the controller-runtime manager")
 }
+
+// addHealthChecks adds the liveness check of the operator, and the readiness checks of the controllers
+// and of the csi config, each served on its own path under the readiness endpoint
+func addHealthChecks(mgr manager.Manager) error {
+	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		return errors.Wrap(err, "failed to add the liveness check")
+	}
+	if err := mgr.AddReadyzCheck(opcontroller.ControllersReadyCheck, opcontroller.ControllersStartedCheck); err != nil {
+		return errors.Wrap(err, "failed to add the readiness check of the controllers")
+	}
+	for _, name := range opcontroller.ControllerHealthNames() {
+		if err := mgr.AddReadyzCheck(name, opcontroller.ControllerHealthCheck(name)); err != nil {
+			return errors.Wrapf(err, "failed to add the readiness check of the %q controller", name)
+		}
+	}
+	if err := mgr.AddReadyzCheck("csi-config", csi.ConfigHealthCheck); err != nil {
+		return errors.Wrap(err, "failed to add the readiness check of the csi config")
+	}
+	// the runnables are started after the caches of the watches synced, with the controllers
+	return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		opcontroller.SetControllersStarted()
+		<-ctx.Done()
+		return nil
+	}))
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the node architectures the csi images can be overridden for
+var supportedNodeArchs = []string{"amd64", "arm64", "ppc64le", "s390x"}
+
+// parseArchImages parses the images of the csi containers by node architecture, in the
+// "arm64=quay.io/cephcsi/cephcsi:v3.5.1-arm64" format
+func parseArchImages(params map[string]string, key string) (map[string]string, error) {
+	images := k8sutil.ParseStringToLabels(k8sutil.GetValue(params, key, ""))
+	for arch, image := range images {
+		if !isSupportedNodeArch(arch) {
+			return nil, errors.Errorf("invalid architecture %q in %q, it must be one of %v", arch, key, supportedNodeArchs)
+		}
+		if image == "" {
+			return nil, errors.Errorf("missing image of architecture %q in %q", arch, key)
+		}
+	}
+	return images, nil
+}
+
+func isSupportedNodeArch(arch string) bool {
+	for _, supported := range supportedNodeArchs {
+		if arch == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginArchs returns the sorted node architectures with their own csi plugin images
+func pluginArchs(tp templateParam) []string {
+	archs := []string{}
+	for arch := range tp.ArchPluginImages {
+		archs = append(archs, arch)
+	}
+	for arch := range tp.ArchRegistrarImages {
+		if _, ok := tp.ArchPluginImages[arch]; !ok {
+			archs = append(archs, arch)
+		}
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+// archPluginDaemonSets returns a copy of the plugin daemonset for each node architecture with its
+// own images. The copies only run on the nodes of their architecture and are named after it.
+func archPluginDaemonSets(ds *apps.DaemonSet, tp templateParam) []*apps.DaemonSet {
+	daemonSets := []*apps.DaemonSet{}
+	for _, arch := range pluginArchs(tp) {
+		archDS := ds.DeepCopy()
+		archDS.Name = archResourceName(ds.Name, arch)
+		// The pods of each daemonset must be selected by their own daemonset only
+		for _, labels := range []map[string]string{archDS.Labels, archDS.Spec.Selector.MatchLabels, archDS.Spec.Template.Labels} {
+			if app, ok := labels["app"]; ok {
+				labels["app"] = archResourceName(app, arch)
+			}
+		}
+
+		podSpec := &archDS.Spec.Template.Spec
+		for i := range podSpec.Containers {
+			container := &podSpec.Containers[i]
+			switch container.Image {
+			case tp.CSIPluginImage:
+				if image, ok := tp.ArchPluginImages[arch]; ok {
+					container.Image = image
+				}
+			case tp.RegistrarImage:
+				if image, ok := tp.ArchRegistrarImages[arch]; ok {
+					container.Image = image
+				}
+			}
+		}
+		setPluginNodeLabel(podSpec, corev1.LabelArchStable, arch)
+		daemonSets = append(daemonSets, archDS)
+	}
+	return daemonSets
+}
+
+// excludeNodeArchs keeps the pods of a csi component off the nodes of the architectures with their
+// own images, which are served by the daemonsets of these architectures
+func excludeNodeArchs(podSpec *corev1.PodSpec, archs []string) {
+	if len(archs) == 0 {
+		return
+	}
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      corev1.LabelArchStable,
+		Operator: corev1.NodeSelectorOpNotIn,
+		Values:   archs,
+	}
+
+	// The affinity may be shared with the other csi components
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	} else {
+		podSpec.Affinity = podSpec.Affinity.DeepCopy()
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+	// The terms are ORed, the requirement must be part of each of them
+	for i := range selector.NodeSelectorTerms {
+		selector.NodeSelectorTerms[i].MatchExpressions = append(selector.NodeSelectorTerms[i].MatchExpressions, requirement)
+	}
+}
+
+// createArchPlugins creates the plugin daemonsets of the node architectures with their own images
+func (r *ReconcileCSI) createArchPlugins(daemonSets []*apps.DaemonSet, tp templateParam) error {
+	for _, ds := range daemonSets {
+		if tp.PluginMaxUnavailablePerZone > 0 {
+			if err := setPluginTemplateHash(ds); err != nil {
+				return err
+			}
+		}
+		err := k8sutil.CreateDaemonSet(r.opManagerContext, ds.Name, r.opConfig.OperatorNamespace, r.context.Clientset, ds)
+		if err != nil {
+			return errors.Wrapf(err, "failed to start csi plugin daemonset %q", ds.Name)
+		}
+		k8sutil.AddRookVersionLabelToDaemonSet(ds)
+	}
+	return nil
+}
+
+// deleteArchPlugins removes the plugin daemonsets of the node architectures that do not have their
+// own images anymore. All of them are removed when no architecture is given.
+func (r *ReconcileCSI) deleteArchPlugins(pluginName string, archs []string) error {
+	expected := map[string]bool{}
+	for _, arch := range archs {
+		expected[arch] = true
+	}
+	for _, arch := range supportedNodeArchs {
+		if expected[arch] {
+			continue
+		}
+		name := archResourceName(pluginName, arch)
+		_, err := r.context.Clientset.AppsV1().DaemonSets(r.opConfig.OperatorNamespace).Get(r.opManagerContext, name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		err = k8sutil.DeleteDaemonset(r.opManagerContext, r.context.Clientset, r.opConfig.OperatorNamespace, name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete the %q", name)
+		}
+	}
+	return nil
+}
+
+func archResourceName(name, arch string) string {
+	return fmt.Sprintf("%s-%s", name, arch)
+}
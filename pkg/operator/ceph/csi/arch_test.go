@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseArchImages(t *testing.T) {
+	images, err := parseArchImages(map[string]string{}, "ROOK_CSI_ARCH_CEPH_IMAGES")
+	assert.NoError(t, err)
+	assert.Empty(t, images)
+
+	params := map[string]string{"ROOK_CSI_ARCH_CEPH_IMAGES": "arm64=quay.io/cephcsi/cephcsi:v3.5.1-arm64,s390x=quay.io/cephcsi/cephcsi:v3.5.1-s390x"}
+	images, err = parseArchImages(params, "ROOK_CSI_ARCH_CEPH_IMAGES")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"arm64": "quay.io/cephcsi/cephcsi:v3.5.1-arm64", "s390x": "quay.io/cephcsi/cephcsi:v3.5.1-s390x"}, images)
+
+	params["ROOK_CSI_ARCH_CEPH_IMAGES"] = "riscv64=quay.io/cephcsi/cephcsi:v3.5.1"
+	_, err = parseArchImages(params, "ROOK_CSI_ARCH_CEPH_IMAGES")
+	assert.Error(t, err)
+}
+
+func TestArchPluginDaemonSets(t *testing.T) {
+	tp := templateParam{
+		Param:     CSIParam,
+		Namespace: "rook-ceph",
+	}
+	tp.CSIPluginImage = "quay.io/cephcsi/cephcsi:v3.5.1"
+	tp.RegistrarImage = "k8s.gcr.io/sig-storage/csi-node-driver-registrar:v2.4.0"
+	tp.ArchPluginImages = map[string]string{"arm64": "quay.io/cephcsi/cephcsi:v3.5.1-arm64"}
+	tp.ArchRegistrarImages = map[string]string{"ppc64le": "registrar:ppc64le"}
+	assert.Equal(t, []string{"arm64", "ppc64le"}, pluginArchs(tp))
+
+	ds, err := templateToDaemonSet("rbdplugin", RBDPluginTemplatePath, tp)
+	assert.NoError(t, err)
+	setDedicatedDaemonSet("tenant-a", ds)
+
+	daemonSets := archPluginDaemonSets(ds, tp)
+	assert.Len(t, daemonSets, 2)
+	arm64 := daemonSets[0]
+	assert.Equal(t, "tenant-a-csi-rbdplugin-arm64", arm64.Name)
+	assert.Equal(t, "tenant-a-csi-rbdplugin-arm64", arm64.Spec.Selector.MatchLabels["app"])
+	assert.Equal(t, "tenant-a-csi-rbdplugin-arm64", arm64.Spec.Template.Labels["app"])
+	assert.Equal(t, "arm64", arm64.Spec.Template.Spec.NodeSelector[corev1.LabelArchStable])
+	images := map[string]string{}
+	for _, container := range arm64.Spec.Template.Spec.Containers {
+		images[container.Name] = container.Image
+	}
+	assert.Equal(t, "quay.io/cephcsi/cephcsi:v3.5.1-arm64", images["csi-rbdplugin"])
+	assert.Equal(t, tp.RegistrarImage, images["driver-registrar"])
+
+	ppc64le := daemonSets[1]
+	assert.Equal(t, "tenant-a-csi-rbdplugin-ppc64le", ppc64le.Name)
+	for _, container := range ppc64le.Spec.Template.Spec.Containers {
+		images[container.Name] = container.Image
+	}
+	assert.Equal(t, tp.CSIPluginImage, images["csi-rbdplugin"])
+	assert.Equal(t, "registrar:ppc64le", images["driver-registrar"])
+
+	// the original daemonset is not modified
+	assert.Equal(t, "tenant-a-csi-rbdplugin", ds.Spec.Selector.MatchLabels["app"])
+	assert.Empty(t, ds.Spec.Template.Spec.NodeSelector[corev1.LabelArchStable])
+}
+
+func TestExcludeNodeArchs(t *testing.T) {
+	podSpec := &corev1.PodSpec{}
+	excludeNodeArchs(podSpec, nil)
+	assert.Nil(t, podSpec.Affinity)
+
+	excludeNodeArchs(podSpec, []string{"arm64"})
+	terms := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Len(t, terms, 1)
+	assert.Equal(t, []corev1.NodeSelectorRequirement{{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"arm64"}}}, terms[0].MatchExpressions)
+
+	// the requirement is added to each term, without modifying the shared affinity
+	shared := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+		NodeSelectorTerms: []corev1.NodeSelectorTerm{
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "role", Operator: corev1.NodeSelectorOpIn, Values: []string{"storage"}}}},
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "role", Operator: corev1.NodeSelectorOpIn, Values: []string{"compute"}}}},
+		},
+	}}}
+	podSpec = &corev1.PodSpec{Affinity: shared}
+	excludeNodeArchs(podSpec, []string{"arm64", "s390x"})
+	terms = podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Len(t, terms[0].MatchExpressions, 2)
+	assert.Len(t, terms[1].MatchExpressions, 2)
+	assert.Equal(t, []string{"arm64", "s390x"}, terms[1].MatchExpressions[1].Values)
+	assert.Len(t, shared.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions, 1)
+}
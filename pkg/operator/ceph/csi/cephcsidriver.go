@@ -0,0 +1,267 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// CephCSIDriverName is the name of the CephCSIDriver configuring the drivers of the operator
+	CephCSIDriverName = "ceph-csi-driver"
+)
+
+// getCephCSIDriver returns the CephCSIDriver of the operator namespace, nil if it does not exist
+func (r *ReconcileCSI) getCephCSIDriver() (*cephv1.CephCSIDriver, error) {
+	cephCSIDriver := &cephv1.CephCSIDriver{}
+	err := r.client.Get(r.opManagerContext, types.NamespacedName{Name: CephCSIDriverName, Namespace: r.opConfig.OperatorNamespace}, cephCSIDriver)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get ceph csi driver %q", CephCSIDriverName)
+	}
+	return cephCSIDriver, nil
+}
+
+// ValidateCephCSIDriver checks the settings of the CephCSIDriver that cannot be validated by the CRD schema
+func ValidateCephCSIDriver(spec *cephv1.CephCSIDriverSpec) error {
+	for component, resources := range map[string][]cephv1.CSIContainerResources{"provisioner": spec.Provisioner.Resources, "plugin": spec.Plugin.Resources} {
+		names := map[string]bool{}
+		for _, resource := range resources {
+			if resource.Name == "" {
+				return errors.Errorf("missing container name in the %s resources", component)
+			}
+			if names[resource.Name] {
+				return errors.Errorf("duplicate container %q in the %s resources", resource.Name, component)
+			}
+			names[resource.Name] = true
+		}
+	}
+	for component, levels := range map[string][]cephv1.CSIContainerLogLevel{"provisioner": spec.Provisioner.LogLevels, "plugin": spec.Plugin.LogLevels} {
+		names := map[string]bool{}
+		for _, level := range levels {
+			if level.Name == "" {
+				return errors.Errorf("missing container name in the %s log levels", component)
+			}
+			if names[level.Name] {
+				return errors.Errorf("duplicate container %q in the %s log levels", level.Name, component)
+			}
+			names[level.Name] = true
+		}
+	}
+	archs := map[string]bool{}
+	for _, images := range spec.Images.Architectures {
+		if archs[images.Arch] {
+			return errors.Errorf("duplicate architecture %q in the images", images.Arch)
+		}
+		archs[images.Arch] = true
+		if images.CephCSI == "" && images.Registrar == "" {
+			return errors.Errorf("missing images of architecture %q", images.Arch)
+		}
+	}
+	if spec.Provisioner.UpdateStrategy != "" {
+		return errors.New("the update strategy only applies to the plugin daemonsets")
+	}
+	if spec.Provisioner.MaxUnavailablePerZone != nil {
+		return errors.New("the max unavailable pods per zone only applies to the plugin daemonsets")
+	}
+	return nil
+}
+
+// applyCephCSIDriverSettings returns the operator settings overridden by the settings of the CephCSIDriver
+func applyCephCSIDriverSettings(params map[string]string, spec *cephv1.CephCSIDriverSpec) (map[string]string, error) {
+	settings := map[string]string{}
+	for key, value := range params {
+		settings[key] = value
+	}
+
+	setString := func(key, value string) {
+		if value != "" {
+			settings[key] = value
+		}
+	}
+	setBool := func(key string, value *bool) {
+		if value != nil {
+			settings[key] = strconv.FormatBool(*value)
+		}
+	}
+	setLogLevels := func(key string, levels []cephv1.CSIContainerLogLevel) {
+		if len(levels) == 0 {
+			return
+		}
+		values := make([]string, 0, len(levels))
+		for _, level := range levels {
+			values = append(values, fmt.Sprintf("%s=%d", level.Name, level.Level))
+		}
+		settings[key] = strings.Join(values, ",")
+	}
+	setArchImages := func(key string, image func(cephv1.CSIArchImagesSpec) string) {
+		values := []string{}
+		for _, images := range spec.Images.Architectures {
+			if image(images) != "" {
+				values = append(values, fmt.Sprintf("%s=%s", images.Arch, image(images)))
+			}
+		}
+		if len(values) > 0 {
+			settings[key] = strings.Join(values, ",")
+		}
+	}
+	setResources := func(keys []string, resources []cephv1.CSIContainerResources) error {
+		if len(resources) == 0 {
+			return nil
+		}
+		raw, err := json.Marshal(resources)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal csi container resources")
+		}
+		for _, key := range keys {
+			settings[key] = string(raw)
+		}
+		return nil
+	}
+
+	setBool("ROOK_CSI_ENABLE_RBD", spec.EnableRBD)
+	setBool("ROOK_CSI_ENABLE_CEPHFS", spec.EnableCephFS)
+
+	setString("ROOK_CSI_CEPH_IMAGE", spec.Images.CephCSI)
+	setString("ROOK_CSI_REGISTRAR_IMAGE", spec.Images.Registrar)
+	setString("ROOK_CSI_PROVISIONER_IMAGE", spec.Images.Provisioner)
+	setString("ROOK_CSI_ATTACHER_IMAGE", spec.Images.Attacher)
+	setString("ROOK_CSI_SNAPSHOTTER_IMAGE", spec.Images.Snapshotter)
+	setString("ROOK_CSI_RESIZER_IMAGE", spec.Images.Resizer)
+	setString("CSI_VOLUME_REPLICATION_IMAGE", spec.Images.VolumeReplication)
+	setString("ROOK_CSIADDONS_IMAGE", spec.Images.CSIAddons)
+	setString("ROOK_CSI_WINDOWS_CEPH_IMAGE", spec.Images.WindowsCephCSI)
+	setArchImages("ROOK_CSI_ARCH_CEPH_IMAGES", func(images cephv1.CSIArchImagesSpec) string { return images.CephCSI })
+	setArchImages("ROOK_CSI_ARCH_REGISTRAR_IMAGES", func(images cephv1.CSIArchImagesSpec) string { return images.Registrar })
+
+	setString("ROOK_CSI_KUBELET_DIR_PATH", spec.KubeletDirPath)
+	setString("ROOK_CSI_WINDOWS_KUBELET_DIR_PATH", spec.WindowsKubeletDirPath)
+	if spec.LogLevel != nil {
+		settings["CSI_LOG_LEVEL"] = strconv.Itoa(*spec.LogLevel)
+	}
+	setBool("CSI_ENABLE_LOG_ROTATION", spec.LogRotation.Enabled)
+	setString("CSI_LOG_ROTATION_MAX_SIZE", spec.LogRotation.MaxSize)
+	if spec.LogRotation.MaxAge != nil {
+		settings["CSI_LOG_ROTATION_MAX_AGE"] = strconv.Itoa(*spec.LogRotation.MaxAge)
+	}
+	if spec.ProvisionerReplicas != nil {
+		settings["CSI_PROVISIONER_REPLICAS"] = strconv.Itoa(int(*spec.ProvisionerReplicas))
+	}
+
+	setBool("ROOK_CSI_ENABLE_GRPC_METRICS", spec.FeatureGates.GRPCMetrics)
+	setBool("CSI_ENABLE_HOST_NETWORK", spec.FeatureGates.HostNetwork)
+	setBool("CSI_ENABLE_OMAP_GENERATOR", spec.FeatureGates.OMAPGenerator)
+	setBool("CSI_ENABLE_RBD_SNAPSHOTTER", spec.FeatureGates.RBDSnapshotter)
+	setBool("CSI_ENABLE_CEPHFS_SNAPSHOTTER", spec.FeatureGates.CephFSSnapshotter)
+	setBool("CSI_ENABLE_VOLUME_GROUP_SNAPSHOT", spec.FeatureGates.VolumeGroupSnapshot)
+	setBool("CSI_ENABLE_VOLUME_REPLICATION", spec.FeatureGates.VolumeReplication)
+	setBool("CSI_ENABLE_CSIADDONS", spec.FeatureGates.CSIAddons)
+	setBool("CSI_PLUGIN_ENABLE_SELINUX_HOST_MOUNT", spec.FeatureGates.PluginSELinuxHostMount)
+	setBool("CSI_FORCE_CEPHFS_KERNEL_CLIENT", spec.FeatureGates.ForceCephFSKernelClient)
+	setBool("CSI_ENABLE_RBD_WINDOWS_PLUGIN", spec.FeatureGates.RBDWindowsPlugin)
+
+	setString("CSI_PROVISIONER_PRIORITY_CLASSNAME", spec.Provisioner.PriorityClassName)
+	setString("CSI_PLUGIN_PRIORITY_CLASSNAME", spec.Plugin.PriorityClassName)
+	setString("CSI_RBD_PLUGIN_UPDATE_STRATEGY", spec.Plugin.UpdateStrategy)
+	setString("CSI_CEPHFS_PLUGIN_UPDATE_STRATEGY", spec.Plugin.UpdateStrategy)
+	if spec.Plugin.MaxUnavailablePerZone != nil {
+		settings["CSI_PLUGIN_MAX_UNAVAILABLE_PER_ZONE"] = strconv.Itoa(*spec.Plugin.MaxUnavailablePerZone)
+	}
+	setLogLevels("CSI_PROVISIONER_LOG_LEVELS", spec.Provisioner.LogLevels)
+	setLogLevels("CSI_PLUGIN_LOG_LEVELS", spec.Plugin.LogLevels)
+
+	if err := setResources([]string{rbdProvisionerResource, cephFSProvisionerResource}, spec.Provisioner.Resources); err != nil {
+		return nil, err
+	}
+	if err := setResources([]string{rbdPluginResource, cephFSPluginResource}, spec.Plugin.Resources); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// applyCSIComponentPlacement replaces the tolerations and node affinity of the csi pods by the
+// placement of the CephCSIDriver, if any
+func applyCSIComponentPlacement(component *cephv1.CSIComponentSpec, podSpec *corev1.PodSpec) {
+	if component == nil || component.Placement == nil {
+		return
+	}
+	podSpec.Tolerations = nil
+	podSpec.Affinity = &corev1.Affinity{}
+	component.Placement.ApplyToPodSpec(podSpec)
+}
+
+// provisionerSpec returns the provisioner settings of the CephCSIDriver, nil if there is none
+func (r *ReconcileCSI) provisionerSpec() *cephv1.CSIComponentSpec {
+	if r.cephCSIDriver == nil {
+		return nil
+	}
+	return &r.cephCSIDriver.Spec.Provisioner
+}
+
+// pluginSpec returns the plugin settings of the CephCSIDriver, nil if there is none
+func (r *ReconcileCSI) pluginSpec() *cephv1.CSIComponentSpec {
+	if r.cephCSIDriver == nil {
+		return nil
+	}
+	return &r.cephCSIDriver.Spec.Plugin
+}
+
+// updateCephCSIDriverStatus reports the result of the configuration of the drivers on the CephCSIDriver
+func (r *ReconcileCSI) updateCephCSIDriverStatus(cephCSIDriver *cephv1.CephCSIDriver, reconcileErr error) {
+	if cephCSIDriver == nil {
+		return
+	}
+	phase := cephv1.ConditionReady
+	err := reporting.PatchStatus(r.client, cephCSIDriver, func() bool {
+		status := &cephv1.CephCSIDriverStatus{
+			Phase:              cephv1.ConditionReady,
+			ObservedGeneration: cephCSIDriver.Generation,
+		}
+		if reconcileErr != nil {
+			status.Phase = cephv1.ConditionFailure
+			status.Message = reconcileErr.Error()
+		}
+		// the pinned images are kept until the image policy is disabled
+		if cephCSIDriver.Status != nil {
+			status.Images = cephCSIDriver.Status.Images
+			status.Conditions = cephCSIDriver.Status.Conditions
+		}
+		cephCSIDriver.Status = status
+		opcontroller.SetStandardConditions(cephCSIDriver, false, reconcileErr)
+		phase = status.Phase
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set ceph csi driver %q status to %q. %v", cephCSIDriver.Name, phase, err)
+		return
+	}
+	logger.Debugf("ceph csi driver %q status updated to %q", cephCSIDriver.Name, phase)
+}
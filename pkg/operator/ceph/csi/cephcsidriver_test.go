@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestValidateCephCSIDriver(t *testing.T) {
+	spec := &cephv1.CephCSIDriverSpec{}
+	assert.NoError(t, ValidateCephCSIDriver(spec))
+
+	spec.Plugin.UpdateStrategy = onDelete
+	spec.Plugin.Resources = []cephv1.CSIContainerResources{{Name: "csi-rbdplugin"}, {Name: "driver-registrar"}}
+	assert.NoError(t, ValidateCephCSIDriver(spec))
+
+	spec.Plugin.Resources = append(spec.Plugin.Resources, cephv1.CSIContainerResources{Name: "csi-rbdplugin"})
+	assert.Error(t, ValidateCephCSIDriver(spec))
+
+	spec.Plugin.Resources = []cephv1.CSIContainerResources{{}}
+	assert.Error(t, ValidateCephCSIDriver(spec))
+
+	spec.Plugin.Resources = nil
+	spec.Provisioner.UpdateStrategy = onDelete
+	assert.Error(t, ValidateCephCSIDriver(spec))
+
+	maxUnavailable := 1
+	spec.Provisioner.UpdateStrategy = ""
+	spec.Plugin.MaxUnavailablePerZone = &maxUnavailable
+	assert.NoError(t, ValidateCephCSIDriver(spec))
+	spec.Provisioner.MaxUnavailablePerZone = &maxUnavailable
+	assert.Error(t, ValidateCephCSIDriver(spec))
+
+	spec.Provisioner.MaxUnavailablePerZone = nil
+	spec.Provisioner.LogLevels = []cephv1.CSIContainerLogLevel{{Name: "csi-provisioner", Level: 5}}
+	assert.NoError(t, ValidateCephCSIDriver(spec))
+	spec.Provisioner.LogLevels = append(spec.Provisioner.LogLevels, cephv1.CSIContainerLogLevel{Name: "csi-provisioner", Level: 1})
+	assert.Error(t, ValidateCephCSIDriver(spec))
+
+	spec.Provisioner.LogLevels = nil
+	spec.Images.Architectures = []cephv1.CSIArchImagesSpec{{Arch: "arm64", CephCSI: "quay.io/cephcsi/cephcsi:v3.5.1-arm64"}}
+	assert.NoError(t, ValidateCephCSIDriver(spec))
+	spec.Images.Architectures = append(spec.Images.Architectures, cephv1.CSIArchImagesSpec{Arch: "arm64", Registrar: "registrar:arm64"})
+	assert.Error(t, ValidateCephCSIDriver(spec))
+	spec.Images.Architectures = []cephv1.CSIArchImagesSpec{{Arch: "arm64"}}
+	assert.Error(t, ValidateCephCSIDriver(spec))
+}
+
+func TestApplyCephCSIDriverSettings(t *testing.T) {
+	enabled, disabled := true, false
+	logLevel := 5
+	replicas := int32(3)
+	params := map[string]string{
+		"ROOK_CSI_CEPH_IMAGE": "quay.io/cephcsi/cephcsi:v3.4.0",
+		"CSI_LOG_LEVEL":       "1",
+		"ROOK_CSI_ENABLE_RBD": "true",
+	}
+	spec := &cephv1.CephCSIDriverSpec{
+		EnableCephFS: &disabled,
+		Images: cephv1.CSIDriverImagesSpec{
+			CephCSI: "quay.io/cephcsi/cephcsi:v3.5.1",
+			Architectures: []cephv1.CSIArchImagesSpec{
+				{Arch: "arm64", CephCSI: "quay.io/cephcsi/cephcsi:v3.5.1-arm64"},
+				{Arch: "ppc64le", CephCSI: "quay.io/cephcsi/cephcsi:v3.5.1-ppc64le", Registrar: "registrar:ppc64le"},
+			},
+		},
+		KubeletDirPath:      "/var/lib/k0s/kubelet",
+		LogLevel:            &logLevel,
+		ProvisionerReplicas: &replicas,
+		FeatureGates:        cephv1.CSIFeatureGatesSpec{GRPCMetrics: &enabled, HostNetwork: &disabled, VolumeGroupSnapshot: &enabled, RBDWindowsPlugin: &enabled},
+		LogRotation:         cephv1.CSILogRotationSpec{Enabled: &enabled, MaxAge: &logLevel},
+		Provisioner: cephv1.CSIComponentSpec{
+			LogLevels: []cephv1.CSIContainerLogLevel{{Name: "csi-provisioner", Level: 5}, {Name: "csi-snapshotter", Level: 4}},
+		},
+		Plugin: cephv1.CSIComponentSpec{
+			UpdateStrategy: onDelete,
+			Resources: []cephv1.CSIContainerResources{
+				{Name: "csi-rbdplugin", Resource: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}}},
+			},
+		},
+	}
+
+	settings, err := applyCephCSIDriverSettings(params, spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "quay.io/cephcsi/cephcsi:v3.5.1", settings["ROOK_CSI_CEPH_IMAGE"])
+	assert.Equal(t, "arm64=quay.io/cephcsi/cephcsi:v3.5.1-arm64,ppc64le=quay.io/cephcsi/cephcsi:v3.5.1-ppc64le", settings["ROOK_CSI_ARCH_CEPH_IMAGES"])
+	assert.Equal(t, "ppc64le=registrar:ppc64le", settings["ROOK_CSI_ARCH_REGISTRAR_IMAGES"])
+	assert.Equal(t, "5", settings["CSI_LOG_LEVEL"])
+	assert.Equal(t, "3", settings["CSI_PROVISIONER_REPLICAS"])
+	assert.Equal(t, "/var/lib/k0s/kubelet", settings["ROOK_CSI_KUBELET_DIR_PATH"])
+	assert.Equal(t, "true", settings["ROOK_CSI_ENABLE_RBD"])
+	assert.Equal(t, "false", settings["ROOK_CSI_ENABLE_CEPHFS"])
+	assert.Equal(t, "true", settings["ROOK_CSI_ENABLE_GRPC_METRICS"])
+	assert.Equal(t, "false", settings["CSI_ENABLE_HOST_NETWORK"])
+	assert.Equal(t, "true", settings["CSI_ENABLE_VOLUME_GROUP_SNAPSHOT"])
+	assert.Equal(t, "true", settings["CSI_ENABLE_RBD_WINDOWS_PLUGIN"])
+	assert.Equal(t, "true", settings["CSI_ENABLE_LOG_ROTATION"])
+	assert.Equal(t, "5", settings["CSI_LOG_ROTATION_MAX_AGE"])
+	assert.NotContains(t, settings, "CSI_LOG_ROTATION_MAX_SIZE")
+	assert.Equal(t, "csi-provisioner=5,csi-snapshotter=4", settings["CSI_PROVISIONER_LOG_LEVELS"])
+	assert.NotContains(t, settings, "CSI_PLUGIN_LOG_LEVELS")
+	assert.Equal(t, onDelete, settings["CSI_RBD_PLUGIN_UPDATE_STRATEGY"])
+	assert.Equal(t, onDelete, settings["CSI_CEPHFS_PLUGIN_UPDATE_STRATEGY"])
+	assert.NotContains(t, settings, rbdProvisionerResource)
+	// the operator settings are not modified
+	assert.Equal(t, "1", params["CSI_LOG_LEVEL"])
+
+	// the resources are parsed like the resources of the operator settings
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "csi-rbdplugin"}, {Name: "driver-registrar"}}}
+	applyResourcesToContainers(settings, rbdPluginResource, podSpec)
+	assert.Equal(t, "1Gi", podSpec.Containers[0].Resources.Limits.Memory().String())
+	assert.Empty(t, podSpec.Containers[1].Resources.Limits)
+}
+
+func TestApplyCSIComponentPlacement(t *testing.T) {
+	podSpec := &corev1.PodSpec{}
+	applyToPodSpec(podSpec, &corev1.NodeAffinity{}, []corev1.Toleration{{Key: "operator-config"}})
+
+	applyCSIComponentPlacement(nil, podSpec)
+	assert.Len(t, podSpec.Tolerations, 1)
+
+	component := &cephv1.CSIComponentSpec{
+		Placement: &cephv1.Placement{Tolerations: []corev1.Toleration{{Key: "storage-node", Operator: corev1.TolerationOpExists}}},
+	}
+	applyCSIComponentPlacement(component, podSpec)
+	assert.Equal(t, []corev1.Toleration{{Key: "storage-node", Operator: corev1.TolerationOpExists}}, podSpec.Tolerations)
+	assert.Nil(t, podSpec.Affinity.NodeAffinity)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+// KernelSecureMountOption is the mount option of the kernel client encrypting the connections
+const KernelSecureMountOption = "ms_mode=secure"
+
+// CephFSMountOptions returns the cephFS settings of the csi cluster config entry of a cluster,
+// holding how its volumes are mounted
+func CephFSMountOptions(spec *cephv1.ClusterSpec) *CsiCephFSSpec {
+	kernelMountOptions := spec.CSI.CephFS.KernelMountOptions
+	// in FIPS mode the kernel client must connect in secure mode, the daemons refusing the others
+	if spec.Security.IsFIPSEnabled() && KernelMountOption(kernelMountOptions, "ms_mode") == "" {
+		kernelMountOptions = strings.TrimPrefix(kernelMountOptions+","+KernelSecureMountOption, ",")
+	}
+	return &CsiCephFSSpec{
+		Mounter:            spec.CSI.CephFS.Mounter,
+		KernelMountOptions: kernelMountOptions,
+		FuseMountOptions:   spec.CSI.CephFS.FuseMountOptions,
+	}
+}
+
+// KernelMountOption returns the value of an option of the comma separated kernel mount options
+func KernelMountOption(mountOptions, name string) string {
+	for _, option := range strings.Split(mountOptions, ",") {
+		kv := strings.SplitN(strings.TrimSpace(option), "=", 2)
+		if len(kv) == 2 && kv[0] == name {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+// updateCephFSMountOptions returns the cephFS settings of an entry with the mount options of the
+// new entry, nil if no setting is left
+func updateCephFSMountOptions(curr, new *CsiCephFSSpec) *CsiCephFSSpec {
+	cephFS := CsiCephFSSpec{}
+	if curr != nil {
+		cephFS.SubvolumeGroup = curr.SubvolumeGroup
+	}
+	if new != nil {
+		cephFS.Mounter = new.Mounter
+		cephFS.KernelMountOptions = new.KernelMountOptions
+		cephFS.FuseMountOptions = new.FuseMountOptions
+	}
+	if cephFS == (CsiCephFSSpec{}) {
+		return nil
+	}
+	return &cephFS
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCephFSMountOptions(t *testing.T) {
+	spec := &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{FIPS: &cephv1.FIPSSpec{Enabled: true}}}
+	assert.Equal(t, KernelSecureMountOption, CephFSMountOptions(spec).KernelMountOptions)
+
+	spec.CSI.CephFS.KernelMountOptions = "recover_session=clean"
+	assert.Equal(t, "recover_session=clean,ms_mode=secure", CephFSMountOptions(spec).KernelMountOptions)
+
+	spec.CSI.CephFS.KernelMountOptions = "ms_mode=prefer-secure"
+	assert.Equal(t, "ms_mode=prefer-secure", CephFSMountOptions(spec).KernelMountOptions)
+
+	spec.Security.FIPS.Enabled = false
+	spec.CSI.CephFS.KernelMountOptions = ""
+	assert.Empty(t, CephFSMountOptions(spec).KernelMountOptions)
+}
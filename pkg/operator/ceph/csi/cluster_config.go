@@ -19,7 +19,9 @@ package csi
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"os"
+	"sort"
 	"sync"
 
 	"github.com/coreos/pkg/capnslog"
@@ -35,17 +37,29 @@ import (
 var (
 	logger      = capnslog.NewPackageLogger("github.com/rook/rook", "ceph-csi")
 	configMutex sync.Mutex
+	// the result of the last update of the csi config map for each cluster namespace
+	configStatusMutex sync.Mutex
+	configStatus      = map[string]error{}
 )
 
 type CsiClusterConfigEntry struct {
-	ClusterID      string         `json:"clusterID"`
-	Monitors       []string       `json:"monitors"`
-	CephFS         *CsiCephFSSpec `json:"cephFS,omitempty"`
-	RadosNamespace string         `json:"radosNamespace,omitempty"`
+	ClusterID      string           `json:"clusterID"`
+	Monitors       []string         `json:"monitors"`
+	CephFS         *CsiCephFSSpec   `json:"cephFS,omitempty"`
+	RadosNamespace string           `json:"radosNamespace,omitempty"`
+	ReadAffinity   *CsiReadAffinity `json:"readAffinity,omitempty"`
 }
 
 type CsiCephFSSpec struct {
-	SubvolumeGroup string `json:"subvolumeGroup,omitempty"`
+	SubvolumeGroup     string `json:"subvolumeGroup,omitempty"`
+	Mounter            string `json:"mounter,omitempty"`
+	KernelMountOptions string `json:"kernelMountOptions,omitempty"`
+	FuseMountOptions   string `json:"fuseMountOptions,omitempty"`
+}
+
+type CsiReadAffinity struct {
+	Enabled             bool     `json:"enabled"`
+	CrushLocationLabels []string `json:"crushLocationLabels,omitempty"`
 }
 
 type csiClusterConfig []CsiClusterConfigEntry
@@ -121,9 +135,13 @@ func updateCsiClusterConfig(curr, clusterKey string, newCsiClusterConfigEntry *C
 				break
 			}
 			centry.Monitors = newCsiClusterConfigEntry.Monitors
+			// The read affinity follows the cluster settings, so it is always updated
+			centry.ReadAffinity = newCsiClusterConfigEntry.ReadAffinity
 			if newCsiClusterConfigEntry.CephFS != nil && newCsiClusterConfigEntry.CephFS.SubvolumeGroup != "" {
 				centry.CephFS = newCsiClusterConfigEntry.CephFS
 			}
+			// The mount options follow the cluster settings, so they are always updated
+			centry.CephFS = updateCephFSMountOptions(centry.CephFS, newCsiClusterConfigEntry.CephFS)
 			if newCsiClusterConfigEntry.RadosNamespace != "" {
 				centry.RadosNamespace = newCsiClusterConfigEntry.RadosNamespace
 			}
@@ -138,10 +156,12 @@ func updateCsiClusterConfig(curr, clusterKey string, newCsiClusterConfigEntry *C
 		if newCsiClusterConfigEntry != nil {
 			centry.ClusterID = clusterKey
 			centry.Monitors = newCsiClusterConfigEntry.Monitors
+			centry.ReadAffinity = newCsiClusterConfigEntry.ReadAffinity
 			// Add a condition not to fill with empty values
 			if newCsiClusterConfigEntry.CephFS != nil && newCsiClusterConfigEntry.CephFS.SubvolumeGroup != "" {
 				centry.CephFS = newCsiClusterConfigEntry.CephFS
 			}
+			centry.CephFS = updateCephFSMountOptions(centry.CephFS, newCsiClusterConfigEntry.CephFS)
 			if newCsiClusterConfigEntry.RadosNamespace != "" {
 				centry.RadosNamespace = newCsiClusterConfigEntry.RadosNamespace
 			}
@@ -195,6 +215,12 @@ func SaveClusterConfig(clientset kubernetes.Interface, clusterNamespace string,
 		return nil
 	}
 
+	err := saveClusterConfig(clientset, clusterNamespace, clusterInfo, newCsiClusterConfigEntry)
+	setConfigStatus(clusterNamespace, err)
+	return err
+}
+
+func saveClusterConfig(clientset kubernetes.Interface, clusterNamespace string, clusterInfo *cephclient.ClusterInfo, newCsiClusterConfigEntry *CsiClusterConfigEntry) error {
 	configMutex.Lock()
 	defer configMutex.Unlock()
 
@@ -230,3 +256,31 @@ func SaveClusterConfig(clientset kubernetes.Interface, clusterNamespace string,
 
 	return nil
 }
+
+func setConfigStatus(clusterNamespace string, err error) {
+	configStatusMutex.Lock()
+	defer configStatusMutex.Unlock()
+	configStatus[clusterNamespace] = err
+}
+
+// ConfigHealthCheck is the readiness check of the propagation of the csi config, which fails when the
+// last update of the csi config map for a cluster failed, the csi drivers not getting the monitors of
+// the cluster until it succeeds
+func ConfigHealthCheck(_ *http.Request) error {
+	if !CSIEnabled() {
+		return nil
+	}
+	configStatusMutex.Lock()
+	defer configStatusMutex.Unlock()
+	namespaces := []string{}
+	for namespace, err := range configStatus {
+		if err != nil {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil
+	}
+	sort.Strings(namespaces)
+	return errors.Wrapf(configStatus[namespaces[0]], "failed to update the csi config of the clusters in namespaces %v", namespaces)
+}
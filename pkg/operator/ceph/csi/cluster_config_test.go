@@ -17,8 +17,12 @@ limitations under the License.
 package csi
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -153,8 +157,105 @@ func TestUpdateCsiClusterConfig(t *testing.T) {
 		assert.Equal(t, 3, len(cc[2].Monitors))
 	})
 
+	t.Run("enable and disable read affinity", func(t *testing.T) {
+		csiClusterConfigEntry.ReadAffinity = &CsiReadAffinity{Enabled: true, CrushLocationLabels: []string{"topology.kubernetes.io/zone"}}
+		s, err = updateCsiClusterConfig(s, "alpha", &csiClusterConfigEntry)
+		assert.NoError(t, err)
+		assert.Contains(t, s, `"readAffinity":{"enabled":true,"crushLocationLabels":["topology.kubernetes.io/zone"]}`)
+		cc, err := parseCsiClusterConfig(s)
+		assert.NoError(t, err)
+		assert.Equal(t, "alpha", cc[0].ClusterID)
+		assert.True(t, cc[0].ReadAffinity.Enabled)
+		assert.Nil(t, cc[1].ReadAffinity)
+
+		csiClusterConfigEntry.ReadAffinity = nil
+		s, err = updateCsiClusterConfig(s, "alpha", &csiClusterConfigEntry)
+		assert.NoError(t, err)
+		assert.NotContains(t, s, "readAffinity")
+	})
+
+	t.Run("set and unset the cephfs mount options", func(t *testing.T) {
+		csiClusterConfigEntry.CephFS = &CsiCephFSSpec{Mounter: "fuse", FuseMountOptions: "debug"}
+		s, err = updateCsiClusterConfig(s, "alpha", &csiClusterConfigEntry)
+		assert.NoError(t, err)
+		assert.Contains(t, s, `"cephFS":{"mounter":"fuse","fuseMountOptions":"debug"}`)
+
+		// the subvolumegroup is preserved when the mount options are updated by the mons
+		s, err = updateCsiClusterConfig(s, "quatre", &CsiClusterConfigEntry{CephFS: &CsiCephFSSpec{KernelMountOptions: "ms_mode=secure"}})
+		assert.NoError(t, err)
+		cc, err := parseCsiClusterConfig(s)
+		assert.NoError(t, err)
+		assert.Equal(t, "quatre", cc[2].ClusterID)
+		assert.Equal(t, CsiCephFSSpec{SubvolumeGroup: "mygroup2", KernelMountOptions: "ms_mode=secure"}, *cc[2].CephFS)
+
+		csiClusterConfigEntry.CephFS = CephFSMountOptions(&cephv1.ClusterSpec{})
+		s, err = updateCsiClusterConfig(s, "alpha", &csiClusterConfigEntry)
+		assert.NoError(t, err)
+		cc, err = parseCsiClusterConfig(s)
+		assert.NoError(t, err)
+		assert.Nil(t, cc[0].CephFS)
+		csiClusterConfigEntry.CephFS = nil
+	})
+
 	t.Run("does it return error on garbage input?", func(t *testing.T) {
 		_, err = updateCsiClusterConfig("qqq", "beta", &csiClusterConfigEntry2)
 		assert.Error(t, err)
 	})
 }
+
+func TestReadAffinity(t *testing.T) {
+	spec := &cephv1.ClusterSpec{}
+	assert.Nil(t, ReadAffinity(spec))
+
+	spec.CSI.ReadAffinity.Enabled = true
+	readAffinity := ReadAffinity(spec)
+	assert.True(t, readAffinity.Enabled)
+	assert.Equal(t, DefaultCrushLocationLabels, readAffinity.CrushLocationLabels)
+
+	spec.CSI.ReadAffinity.CrushLocationLabels = []string{"topology.rook.io/rack"}
+	readAffinity = ReadAffinity(spec)
+	assert.Equal(t, []string{"topology.rook.io/rack"}, readAffinity.CrushLocationLabels)
+}
+
+func TestExternalReadAffinity(t *testing.T) {
+	var crushMap cephclient.CrushMap
+	err := json.Unmarshal([]byte(`{"buckets":[{"name":"default","type_name":"root"},{"name":"a","type_name":"zone"},{"name":"node1","type_name":"host"}]}`), &crushMap)
+	assert.NoError(t, err)
+	labels := crushLocationLabels(crushMap)
+	assert.Equal(t, []string{"kubernetes.io/hostname", "topology.kubernetes.io/zone"}, labels)
+
+	spec := &cephv1.ClusterSpec{}
+	assert.Nil(t, ExternalReadAffinity(spec, labels))
+
+	spec.CSI.ReadAffinity.Enabled = true
+	assert.Equal(t, labels, ExternalReadAffinity(spec, labels).CrushLocationLabels)
+	// the default labels are used when the crush map could not be imported
+	assert.Equal(t, DefaultCrushLocationLabels, ExternalReadAffinity(spec, nil).CrushLocationLabels)
+
+	spec.CSI.ReadAffinity.CrushLocationLabels = []string{"topology.rook.io/rack"}
+	assert.Equal(t, []string{"topology.rook.io/rack"}, ExternalReadAffinity(spec, labels).CrushLocationLabels)
+}
+
+func TestConfigHealthCheck(t *testing.T) {
+	defer func() { configStatus = map[string]error{} }()
+	enableRBD, enableCephFS := EnableRBD, EnableCephFS
+	defer func() { EnableRBD, EnableCephFS = enableRBD, enableCephFS }()
+	EnableRBD, EnableCephFS = true, false
+
+	assert.NoError(t, ConfigHealthCheck(nil))
+	setConfigStatus("rook-ceph", nil)
+	assert.NoError(t, ConfigHealthCheck(nil))
+
+	setConfigStatus("other", errors.New("failed to update csi config map"))
+	err := ConfigHealthCheck(nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "[other]")
+
+	// the check is skipped when csi is disabled
+	EnableRBD = false
+	assert.NoError(t, ConfigHealthCheck(nil))
+	EnableRBD = true
+
+	setConfigStatus("other", nil)
+	assert.NoError(t, ConfigHealthCheck(nil))
+}
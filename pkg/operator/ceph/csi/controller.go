@@ -49,6 +49,8 @@ type ReconcileCSI struct {
 	context          *clusterd.Context
 	opManagerContext context.Context
 	opConfig         opcontroller.OperatorConfig
+	// the CephCSIDriver overriding the csi settings of the operator, if any
+	cephCSIDriver *cephv1.CephCSIDriver
 }
 
 // Add creates a new Ceph CSI Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -69,7 +71,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 
 func add(ctx context.Context, mgr manager.Manager, r reconcile.Reconciler, opConfig opcontroller.OperatorConfig) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileLogFields(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -89,6 +91,34 @@ func add(ctx context.Context, mgr manager.Manager, r reconcile.Reconciler, opCon
 		return err
 	}
 
+	// Watch for CephBlockPoolTopology since the RBD driver must report their domain labels
+	err = c.Watch(&source.Kind{
+		Type: &cephv1.CephBlockPoolTopology{TypeMeta: metav1.TypeMeta{Kind: "CephBlockPoolTopology", APIVersion: v1.SchemeGroupVersion.String()}}}, &handler.EnqueueRequestForObject{}, predicateController(ctx, mgr.GetClient(), opConfig.OperatorNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch for CephFilesystem since a VolumeGroupSnapshotClass is generated for each filesystem
+	err = c.Watch(&source.Kind{
+		Type: &cephv1.CephFilesystem{TypeMeta: metav1.TypeMeta{Kind: "CephFilesystem", APIVersion: v1.SchemeGroupVersion.String()}}}, &handler.EnqueueRequestForObject{}, predicateController(ctx, mgr.GetClient(), opConfig.OperatorNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch for CephBlockPool since a VolumeGroupReplicationClass is generated for each mirrored pool
+	err = c.Watch(&source.Kind{
+		Type: &cephv1.CephBlockPool{TypeMeta: metav1.TypeMeta{Kind: "CephBlockPool", APIVersion: v1.SchemeGroupVersion.String()}}}, &handler.EnqueueRequestForObject{}, predicateController(ctx, mgr.GetClient(), opConfig.OperatorNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch for the CephCSIDriver overriding the csi settings of the operator
+	err = c.Watch(&source.Kind{
+		Type: &cephv1.CephCSIDriver{TypeMeta: metav1.TypeMeta{Kind: "CephCSIDriver", APIVersion: v1.SchemeGroupVersion.String()}}}, &handler.EnqueueRequestForObject{}, predicateController(ctx, mgr.GetClient(), opConfig.OperatorNamespace))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -118,7 +148,7 @@ func (r *ReconcileCSI) reconcile(request reconcile.Request) (reconcile.Result, e
 		if kerrors.IsNotFound(err) {
 			logger.Debug("no ceph cluster found not deploying ceph csi driver")
 			EnableRBD, EnableCephFS = false, false
-			err = r.stopDrivers(serverVersion)
+			err = r.stopDrivers(serverVersion, nil)
 			if err != nil {
 				return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to stop Drivers")
 			}
@@ -133,24 +163,22 @@ func (r *ReconcileCSI) reconcile(request reconcile.Request) (reconcile.Result, e
 	if len(cephClusters.Items) == 0 {
 		logger.Debug("no ceph cluster found not deploying ceph csi driver")
 		EnableRBD, EnableCephFS = false, false
-		err = r.stopDrivers(serverVersion)
+		err = r.stopDrivers(serverVersion, nil)
 		if err != nil {
 			return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to stop Drivers")
 		}
 
-		return reconcile.Result{}, nil
-	} else {
-		for _, cluster := range cephClusters.Items {
-			if !cluster.DeletionTimestamp.IsZero() {
-				logger.Debug("ceph cluster is being deleting, no need to reconcile the csi driver")
-				return reconcile.Result{}, nil
-			}
-
-			if !cluster.Spec.External.Enable && cluster.Spec.CleanupPolicy.HasDataDirCleanPolicy() {
-				logger.Debug("ceph cluster has cleanup policy, the cluster will soon go away, no need to reconcile the csi driver")
-				return reconcile.Result{}, nil
-			}
+		err = r.configureSnapshotClasses(nil)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to remove volume snapshot classes")
 		}
+
+		return reconcile.Result{}, nil
+	} else if !hasActiveCluster(cephClusters.Items) {
+		// The drivers keep serving the clusters going away, the other clusters of the operator, like
+		// the external clusters consumed next to a local cluster, keep being reconciled
+		logger.Debug("all the ceph clusters are being deleted or will soon go away, no need to reconcile the csi driver")
+		return reconcile.Result{}, nil
 	}
 
 	// Fetch the operator's configmap. We force the NamespaceName to the operator since the request
@@ -172,6 +200,22 @@ func (r *ReconcileCSI) reconcile(request reconcile.Request) (reconcile.Result, e
 		r.opConfig.Parameters = opConfig.Data
 	}
 
+	// The settings of the CephCSIDriver take precedence over the operator's config
+	r.cephCSIDriver, err = r.getCephCSIDriver()
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, err
+	}
+	if r.cephCSIDriver != nil {
+		err = ValidateCephCSIDriver(&r.cephCSIDriver.Spec)
+		if err == nil {
+			r.opConfig.Parameters, err = applyCephCSIDriverSettings(r.opConfig.Parameters, &r.cephCSIDriver.Spec)
+		}
+		if err != nil {
+			r.updateCephCSIDriverStatus(r.cephCSIDriver, err)
+			return reconcile.Result{}, errors.Wrapf(err, "invalid ceph csi driver %q", CephCSIDriverName)
+		}
+	}
+
 	ownerRef, err := k8sutil.GetDeploymentOwnerReference(r.opManagerContext, r.context.Clientset, os.Getenv(k8sutil.PodNameEnvVar), r.opConfig.OperatorNamespace)
 	if err != nil {
 		logger.Warningf("could not find deployment owner reference to assign to csi drivers. %v", err)
@@ -200,10 +244,26 @@ func (r *ReconcileCSI) reconcile(request reconcile.Request) (reconcile.Result, e
 	}
 	CustomCSICephConfigExists = exists
 
-	err = r.validateAndConfigureDrivers(serverVersion, ownerInfo)
+	err = r.validateAndConfigureDrivers(serverVersion, ownerInfo, cephClusters.Items)
+	r.updateCephCSIDriverStatus(r.cephCSIDriver, err)
 	if err != nil {
 		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to configure ceph csi")
 	}
 
 	return reconcile.Result{}, nil
 }
+
+// hasActiveCluster returns whether one of the clusters is neither being deleted nor has a cleanup
+// policy, which means it will soon go away
+func hasActiveCluster(cephClusters []cephv1.CephCluster) bool {
+	for _, cluster := range cephClusters {
+		if !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if !cluster.Spec.External.Enable && cluster.Spec.CleanupPolicy.HasDataDirCleanPolicy() {
+			continue
+		}
+		return true
+	}
+	return false
+}
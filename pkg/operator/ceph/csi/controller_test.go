@@ -20,6 +20,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -155,4 +156,23 @@ func TestCephCSIController(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 2, len(ds.Items), ds)
 	})
+
+	t.Run("local cluster going away next to an external cluster", func(t *testing.T) {
+		local := cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: "rook-ceph"},
+			Spec: cephv1.ClusterSpec{
+				CleanupPolicy: cephv1.CleanupPolicySpec{Confirmation: cephv1.DeleteDataDirOnHostsConfirmation},
+			},
+		}
+		assert.False(t, hasActiveCluster([]cephv1.CephCluster{local}))
+
+		external := cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "rook-ceph-external"},
+			Spec:       cephv1.ClusterSpec{External: cephv1.ExternalSpec{Enable: true}},
+		}
+		assert.True(t, hasActiveCluster([]cephv1.CephCluster{local, external}))
+
+		external.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		assert.False(t, hasActiveCluster([]cephv1.CephCluster{local, external}))
+	})
 }
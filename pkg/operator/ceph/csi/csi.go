@@ -20,11 +20,12 @@ import (
 	"strconv"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/apimachinery/pkg/version"
 )
 
-func (r *ReconcileCSI) validateAndConfigureDrivers(serverVersion *version.Info, ownerInfo *k8sutil.OwnerInfo) error {
+func (r *ReconcileCSI) validateAndConfigureDrivers(serverVersion *version.Info, ownerInfo *k8sutil.OwnerInfo, cephClusters []cephv1.CephCluster) error {
 	var (
 		v   *CephCSIVersion
 		err error
@@ -34,10 +35,20 @@ func (r *ReconcileCSI) validateAndConfigureDrivers(serverVersion *version.Info,
 		return errors.Wrapf(err, "failed to configure CSI parameters")
 	}
 
+	// The grpc metrics must be exposed for the csi drivers to be monitored
+	if csiMonitoringEnabled(cephClusters) {
+		EnableCSIGRPCMetrics = true
+	}
+
 	if err = validateCSIParam(); err != nil {
 		return errors.Wrapf(err, "failed to validate CSI parameters")
 	}
 
+	// The images are pinned before the version check, which runs the pinned ceph-csi image
+	if err = r.applyImagePolicy(); err != nil {
+		return errors.Wrap(err, "failed to apply the image policy to the csi images")
+	}
+
 	if !AllowUnsupported && CSIEnabled() {
 		if v, err = r.validateCSIVersion(ownerInfo); err != nil {
 			return errors.Wrapf(err, "invalid csi version")
@@ -47,13 +58,34 @@ func (r *ReconcileCSI) validateAndConfigureDrivers(serverVersion *version.Info,
 	}
 
 	if CSIEnabled() {
-		if err = r.startDrivers(serverVersion, ownerInfo, v); err != nil {
+		if err = r.startDrivers(serverVersion, ownerInfo, v, cephClusters); err != nil {
 			return errors.Wrap(err, "failed to start ceph csi drivers")
 		}
 	}
 
+	// The service monitor, the snapshot classes and the replication classes are removed along with the drivers
+	clusters := cephClusters
+	if !CSIEnabled() {
+		clusters = nil
+	}
+	if err = r.configureCSIServiceMonitor(clusters, ownerInfo); err != nil {
+		return errors.Wrap(err, "failed to configure csi metrics service monitor")
+	}
+
+	if err = r.configureSnapshotClasses(clusters); err != nil {
+		return errors.Wrap(err, "failed to configure volume snapshot classes")
+	}
+
+	if err = r.configureGroupReplicationClasses(clusters); err != nil {
+		return errors.Wrap(err, "failed to configure volume group replication classes")
+	}
+
+	if err = r.configureKMS(clusters, ownerInfo); err != nil {
+		return errors.Wrap(err, "failed to configure csi kms")
+	}
+
 	// Check whether RBD or CephFS needs to be disabled
-	return r.stopDrivers(serverVersion)
+	return r.stopDrivers(serverVersion, clusters)
 }
 
 func (r *ReconcileCSI) setParams() error {
@@ -79,12 +111,24 @@ func (r *ReconcileCSI) setParams() error {
 		return errors.Wrap(err, "failed to parse value for 'CSI_ENABLE_HOST_NETWORK'")
 	}
 
+	if CSIParam.EnableVolumeGroupSnapshot, err = strconv.ParseBool(k8sutil.GetValue(r.opConfig.Parameters, "CSI_ENABLE_VOLUME_GROUP_SNAPSHOT", "false")); err != nil {
+		return errors.Wrap(err, "failed to parse value for 'CSI_ENABLE_VOLUME_GROUP_SNAPSHOT'")
+	}
+
+	// The windows plugin runs on the windows nodes of the cluster and mounts rbd volumes via csi-proxy
+	if CSIParam.EnableRBDWindowsPlugin, err = strconv.ParseBool(k8sutil.GetValue(r.opConfig.Parameters, "CSI_ENABLE_RBD_WINDOWS_PLUGIN", "false")); err != nil {
+		return errors.Wrap(err, "failed to parse value for 'CSI_ENABLE_RBD_WINDOWS_PLUGIN'")
+	}
+
 	CSIParam.CSIPluginImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_CEPH_IMAGE", DefaultCSIPluginImage)
 	CSIParam.RegistrarImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_REGISTRAR_IMAGE", DefaultRegistrarImage)
 	CSIParam.ProvisionerImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_PROVISIONER_IMAGE", DefaultProvisionerImage)
 	CSIParam.AttacherImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_ATTACHER_IMAGE", DefaultAttacherImage)
 	CSIParam.SnapshotterImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_SNAPSHOTTER_IMAGE", DefaultSnapshotterImage)
+	CSIParam.ResizerImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_RESIZER_IMAGE", DefaultResizerImage)
 	CSIParam.KubeletDirPath = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_KUBELET_DIR_PATH", DefaultKubeletDirPath)
+	CSIParam.WindowsCSIPluginImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_WINDOWS_CEPH_IMAGE", "")
+	CSIParam.WindowsKubeletDirPath = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_WINDOWS_KUBELET_DIR_PATH", DefaultWindowsKubeletDirPath)
 	CSIParam.VolumeReplicationImage = k8sutil.GetValue(r.opConfig.Parameters, "CSI_VOLUME_REPLICATION_IMAGE", DefaultVolumeReplicationImage)
 	CSIParam.CSIAddonsImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSIADDONS_IMAGE", DefaultCSIAddonsImage)
 	csiCephFSPodLabels := k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_CEPHFS_POD_LABELS", "")
@@ -92,5 +136,13 @@ func (r *ReconcileCSI) setParams() error {
 	csiRBDPodLabels := k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_RBD_POD_LABELS", "")
 	CSIParam.CSIRBDPodLabels = k8sutil.ParseStringToLabels(csiRBDPodLabels)
 
+	// The plugins of the node architectures without a multi-arch image run in their own daemonsets
+	if CSIParam.ArchPluginImages, err = parseArchImages(r.opConfig.Parameters, "ROOK_CSI_ARCH_CEPH_IMAGES"); err != nil {
+		return err
+	}
+	if CSIParam.ArchRegistrarImages, err = parseArchImages(r.opConfig.Parameters, "ROOK_CSI_ARCH_REGISTRAR_IMAGES"); err != nil {
+		return err
+	}
+
 	return nil
 }
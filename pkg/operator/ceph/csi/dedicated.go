@@ -0,0 +1,243 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+const (
+	// dedicatedDriverLabel is the label of the resources of the drivers dedicated to a cluster,
+	// holding their driver name prefix
+	dedicatedDriverLabel = "ceph.rook.io/csi-driver-prefix"
+)
+
+// RBDDriverNameForCluster returns the name of the rbd driver provisioning the volumes of a cluster
+func RBDDriverNameForCluster(spec *cephv1.ClusterSpec) string {
+	if spec.CSI.DriverNamePrefix != "" {
+		return fmt.Sprintf("%s.rbd.csi.ceph.com", spec.CSI.DriverNamePrefix)
+	}
+	return RBDDriverName
+}
+
+// CephFSDriverNameForCluster returns the name of the cephfs driver provisioning the volumes of a cluster
+func CephFSDriverNameForCluster(spec *cephv1.ClusterSpec) string {
+	if spec.CSI.DriverNamePrefix != "" {
+		return fmt.Sprintf("%s.cephfs.csi.ceph.com", spec.CSI.DriverNamePrefix)
+	}
+	return CephFSDriverName
+}
+
+// validateDedicatedDrivers checks that the drivers dedicated to the clusters do not conflict with
+// each other nor with the shared drivers, whose driver name prefix is the operator namespace
+func validateDedicatedDrivers(clusters []cephv1.CephCluster, operatorNamespace string, hostNetwork bool) error {
+	prefixes := map[string]string{operatorNamespace: ""}
+	portOffsets := map[int]string{0: ""}
+	for _, cluster := range clusters {
+		prefix := cluster.Spec.CSI.DriverNamePrefix
+		if prefix == "" {
+			if !reflect.DeepEqual(cluster.Spec.CSI.Provisioner, cephv1.CSIPodSchedulingSpec{}) || !reflect.DeepEqual(cluster.Spec.CSI.Plugin, cephv1.CSIPodSchedulingSpec{}) {
+				logger.Warningf("ignoring the csi pod scheduling of cluster %q, it only applies to dedicated drivers", cluster.Namespace)
+			}
+			continue
+		}
+		if owner, ok := prefixes[prefix]; ok {
+			if owner == "" {
+				return errors.Errorf("driver name prefix %q of cluster %q is used by the shared drivers", prefix, cluster.Namespace)
+			}
+			return errors.Errorf("driver name prefix %q of cluster %q is already used by cluster %q", prefix, cluster.Namespace, owner)
+		}
+		prefixes[prefix] = cluster.Namespace
+
+		// The ports of the drivers only conflict when they are bound on the host
+		if !hostNetwork {
+			continue
+		}
+		offset := cluster.Spec.CSI.PortOffset
+		if owner, ok := portOffsets[offset]; ok {
+			if owner == "" {
+				return errors.Errorf("dedicated drivers of cluster %q must set a port offset when the drivers use the host network", cluster.Namespace)
+			}
+			return errors.Errorf("port offset %d of cluster %q is already used by cluster %q", offset, cluster.Namespace, owner)
+		}
+		portOffsets[offset] = cluster.Namespace
+	}
+
+	return nil
+}
+
+// dedicatedDriverParam returns the template parameters of the drivers dedicated to a cluster
+func dedicatedDriverParam(tp templateParam, spec *cephv1.ClusterSpec) templateParam {
+	offset := uint16(spec.CSI.PortOffset)
+	tp.DriverNamePrefix = spec.CSI.DriverNamePrefix + "."
+	tp.CephFSGRPCMetricsPort += offset
+	tp.CephFSLivenessMetricsPort += offset
+	tp.RBDGRPCMetricsPort += offset
+	tp.RBDLivenessMetricsPort += offset
+	tp.CSIAddonsPort += offset
+	return tp
+}
+
+// applyCSIPodScheduling replaces the node selector, tolerations and affinity of the csi pods by the
+// ones set for the dedicated drivers of a cluster
+func applyCSIPodScheduling(scheduling *cephv1.CSIPodSchedulingSpec, podSpec *corev1.PodSpec) {
+	if scheduling == nil {
+		return
+	}
+	if len(scheduling.NodeSelector) > 0 {
+		podSpec.NodeSelector = scheduling.NodeSelector
+	}
+	if scheduling.Tolerations != nil {
+		podSpec.Tolerations = scheduling.Tolerations
+	}
+	if scheduling.Affinity != nil {
+		podSpec.Affinity = scheduling.Affinity.DeepCopy()
+	}
+}
+
+// dedicatedResourceName returns the name of a resource of the drivers with the given prefix, the
+// resources of the shared drivers have no prefix
+func dedicatedResourceName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%s", prefix, name)
+}
+
+// setDedicatedLabels prefixes the app and metrics labels of a dedicated driver so that its pods
+// are not selected by the services and anti-affinity of the other drivers
+func setDedicatedLabels(prefix string, labels map[string]string) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for _, key := range []string{"app", "contains"} {
+		// The metrics services of all the drivers are selected by the csi service monitor
+		if value, ok := labels[key]; ok && value != csiMetricsAppLabel {
+			labels[key] = dedicatedResourceName(prefix, value)
+		}
+	}
+	labels[dedicatedDriverLabel] = prefix
+	return labels
+}
+
+func setDedicatedDaemonSet(prefix string, ds *apps.DaemonSet) {
+	ds.Name = dedicatedResourceName(prefix, ds.Name)
+	ds.Labels = setDedicatedLabels(prefix, ds.Labels)
+	ds.Spec.Selector.MatchLabels = setDedicatedLabels(prefix, ds.Spec.Selector.MatchLabels)
+	ds.Spec.Template.Labels = setDedicatedLabels(prefix, ds.Spec.Template.Labels)
+}
+
+func setDedicatedDeployment(prefix string, d *apps.Deployment) {
+	d.Name = dedicatedResourceName(prefix, d.Name)
+	d.Labels = setDedicatedLabels(prefix, d.Labels)
+	d.Spec.Selector.MatchLabels = setDedicatedLabels(prefix, d.Spec.Selector.MatchLabels)
+	d.Spec.Template.Labels = setDedicatedLabels(prefix, d.Spec.Template.Labels)
+}
+
+func setDedicatedService(prefix string, svc *corev1.Service) {
+	svc.Name = dedicatedResourceName(prefix, svc.Name)
+	svc.Labels = setDedicatedLabels(prefix, svc.Labels)
+	svc.Spec.Selector = setDedicatedLabels(prefix, svc.Spec.Selector)
+}
+
+// dedicatedDriverPrefixes returns the driver name prefixes of the dedicated drivers deployed by the operator
+func (r *ReconcileCSI) dedicatedDriverPrefixes() (map[string]bool, error) {
+	prefixes := map[string]bool{}
+	opts := metav1.ListOptions{LabelSelector: dedicatedDriverLabel}
+
+	daemonsets, err := r.context.Clientset.AppsV1().DaemonSets(r.opConfig.OperatorNamespace).List(r.opManagerContext, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list dedicated csi plugin daemonsets")
+	}
+	for _, ds := range daemonsets.Items {
+		prefixes[ds.Labels[dedicatedDriverLabel]] = true
+	}
+
+	deployments, err := r.context.Clientset.AppsV1().Deployments(r.opConfig.OperatorNamespace).List(r.opManagerContext, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list dedicated csi provisioner deployments")
+	}
+	for _, d := range deployments.Items {
+		prefixes[d.Labels[dedicatedDriverLabel]] = true
+	}
+
+	return prefixes, nil
+}
+
+// stopDedicatedDrivers removes the dedicated drivers that are disabled or not used by any cluster
+func (r *ReconcileCSI) stopDedicatedDrivers(ver *version.Info, cephClusters []cephv1.CephCluster) error {
+	expected := map[string]bool{}
+	for _, cluster := range cephClusters {
+		if cluster.Spec.CSI.DriverNamePrefix != "" {
+			expected[cluster.Spec.CSI.DriverNamePrefix] = true
+		}
+	}
+
+	prefixes, err := r.dedicatedDriverPrefixes()
+	if err != nil {
+		return err
+	}
+	for prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		if !expected[prefix] || !EnableRBD {
+			err = r.deleteCSIDriverResources(ver,
+				dedicatedResourceName(prefix, csiRBDPlugin),
+				dedicatedResourceName(prefix, csiRBDProvisioner),
+				dedicatedResourceName(prefix, "csi-rbdplugin-metrics"),
+				fmt.Sprintf("%s.rbd.csi.ceph.com", prefix))
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove dedicated CSI Ceph RBD driver %q", prefix)
+			}
+			if err = r.deleteArchPlugins(dedicatedResourceName(prefix, csiRBDPlugin), nil); err != nil {
+				return errors.Wrapf(err, "failed to remove dedicated CSI Ceph RBD plugins of the node architectures %q", prefix)
+			}
+		}
+		if !expected[prefix] || !EnableRBD || !CSIParam.EnableRBDWindowsPlugin {
+			if err = r.deleteRBDWindowsPlugin(prefix); err != nil {
+				return errors.Wrapf(err, "failed to remove dedicated CSI Ceph RBD windows plugin %q", prefix)
+			}
+		}
+		if !expected[prefix] || !EnableCephFS {
+			err = r.deleteCSIDriverResources(ver,
+				dedicatedResourceName(prefix, csiCephFSPlugin),
+				dedicatedResourceName(prefix, csiCephFSProvisioner),
+				dedicatedResourceName(prefix, "csi-cephfsplugin-metrics"),
+				fmt.Sprintf("%s.cephfs.csi.ceph.com", prefix))
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove dedicated CSI CephFS driver %q", prefix)
+			}
+			if err = r.deleteArchPlugins(dedicatedResourceName(prefix, csiCephFSPlugin), nil); err != nil {
+				return errors.Wrapf(err, "failed to remove dedicated CSI CephFS plugins of the node architectures %q", prefix)
+			}
+		}
+		if !expected[prefix] {
+			logger.Infof("successfully removed dedicated CSI drivers %q", prefix)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func dedicatedCluster(namespace, prefix string, portOffset int) cephv1.CephCluster {
+	return cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+		Spec:       cephv1.ClusterSpec{CSI: cephv1.CSIDriverSpec{DriverNamePrefix: prefix, PortOffset: portOffset}},
+	}
+}
+
+func TestDriverNameForCluster(t *testing.T) {
+	RBDDriverName, CephFSDriverName = "rook-ceph.rbd.csi.ceph.com", "rook-ceph.cephfs.csi.ceph.com"
+	spec := &cephv1.ClusterSpec{}
+	assert.Equal(t, "rook-ceph.rbd.csi.ceph.com", RBDDriverNameForCluster(spec))
+	assert.Equal(t, "rook-ceph.cephfs.csi.ceph.com", CephFSDriverNameForCluster(spec))
+
+	spec.CSI.DriverNamePrefix = "tenant-a"
+	assert.Equal(t, "tenant-a.rbd.csi.ceph.com", RBDDriverNameForCluster(spec))
+	assert.Equal(t, "tenant-a.cephfs.csi.ceph.com", CephFSDriverNameForCluster(spec))
+}
+
+func TestValidateDedicatedDrivers(t *testing.T) {
+	clusters := []cephv1.CephCluster{
+		dedicatedCluster("a", "tenant-a", 10),
+		dedicatedCluster("b", "tenant-b", 20),
+		dedicatedCluster("c", "", 0),
+	}
+	assert.NoError(t, validateDedicatedDrivers(clusters, "rook-ceph", true))
+
+	// the prefix of the shared drivers
+	clusters[1].Spec.CSI.DriverNamePrefix = "rook-ceph"
+	assert.Error(t, validateDedicatedDrivers(clusters, "rook-ceph", true))
+
+	clusters[1].Spec.CSI.DriverNamePrefix = "tenant-a"
+	assert.Error(t, validateDedicatedDrivers(clusters, "rook-ceph", true))
+
+	// conflicting ports on the host network
+	clusters[1].Spec.CSI.DriverNamePrefix = "tenant-b"
+	clusters[1].Spec.CSI.PortOffset = 10
+	assert.Error(t, validateDedicatedDrivers(clusters, "rook-ceph", true))
+	assert.NoError(t, validateDedicatedDrivers(clusters, "rook-ceph", false))
+	clusters[1].Spec.CSI.PortOffset = 0
+	assert.Error(t, validateDedicatedDrivers(clusters, "rook-ceph", true))
+}
+
+func TestDedicatedDriverResources(t *testing.T) {
+	tp := templateParam{
+		Param:     CSIParam,
+		Namespace: "rook-ceph",
+	}
+	tp.RBDGRPCMetricsPort = DefaultRBDGRPCMerticsPort
+	tp.RBDLivenessMetricsPort = DefaultRBDLivenessMerticsPort
+	spec := &cephv1.ClusterSpec{CSI: cephv1.CSIDriverSpec{DriverNamePrefix: "tenant-a", PortOffset: 100}}
+	tp = dedicatedDriverParam(tp, spec)
+	assert.Equal(t, "tenant-a.", tp.DriverNamePrefix)
+	assert.Equal(t, DefaultRBDGRPCMerticsPort+100, tp.RBDGRPCMetricsPort)
+
+	rbdPlugin, err := templateToDaemonSet("rbdplugin", RBDPluginTemplatePath, tp)
+	assert.NoError(t, err)
+	setDedicatedDaemonSet("tenant-a", rbdPlugin)
+	assert.Equal(t, "tenant-a-csi-rbdplugin", rbdPlugin.Name)
+	assert.Equal(t, "tenant-a-csi-rbdplugin", rbdPlugin.Spec.Selector.MatchLabels["app"])
+	assert.Equal(t, "tenant-a-csi-rbdplugin", rbdPlugin.Spec.Template.Labels["app"])
+	assert.Equal(t, "tenant-a-csi-rbdplugin-metrics", rbdPlugin.Spec.Template.Labels["contains"])
+	assert.Equal(t, "tenant-a", rbdPlugin.Labels[dedicatedDriverLabel])
+
+	rbdService, err := templateToService("rbd-service", RBDPluginServiceTemplatePath, tp)
+	assert.NoError(t, err)
+	setDedicatedService("tenant-a", rbdService)
+	assert.Equal(t, "tenant-a-csi-rbdplugin-metrics", rbdService.Name)
+	assert.Equal(t, "tenant-a-csi-rbdplugin-metrics", rbdService.Spec.Selector["contains"])
+	// still scraped by the csi service monitor
+	assert.Equal(t, csiMetricsAppLabel, rbdService.Labels["app"])
+}
+
+func TestStopDedicatedDrivers(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	r := &ReconcileCSI{
+		context:          &clusterd.Context{Clientset: clientset},
+		opManagerContext: ctx,
+		opConfig:         controller.OperatorConfig{OperatorNamespace: "rook-ceph"},
+	}
+	ver := &version.Info{Major: "1", Minor: "22"}
+	EnableRBD, EnableCephFS = true, true
+
+	tp := templateParam{Param: CSIParam, Namespace: "rook-ceph"}
+	for _, prefix := range []string{"tenant-a", "tenant-b"} {
+		rbdPlugin, err := templateToDaemonSet("rbdplugin", RBDPluginTemplatePath, tp)
+		assert.NoError(t, err)
+		setDedicatedDaemonSet(prefix, rbdPlugin)
+		_, err = clientset.AppsV1().DaemonSets("rook-ceph").Create(ctx, rbdPlugin, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	// the shared driver
+	rbdPlugin, err := templateToDaemonSet("rbdplugin", RBDPluginTemplatePath, tp)
+	assert.NoError(t, err)
+	_, err = clientset.AppsV1().DaemonSets("rook-ceph").Create(ctx, rbdPlugin, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.stopDedicatedDrivers(ver, []cephv1.CephCluster{dedicatedCluster("a", "tenant-a", 10)}))
+	_, err = clientset.AppsV1().DaemonSets("rook-ceph").Get(ctx, "tenant-a-csi-rbdplugin", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = clientset.AppsV1().DaemonSets("rook-ceph").Get(ctx, "tenant-b-csi-rbdplugin", metav1.GetOptions{})
+	assert.Error(t, err)
+	_, err = clientset.AppsV1().DaemonSets("rook-ceph").Get(ctx, csiRBDPlugin, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// the rbd driver is disabled
+	EnableRBD = false
+	assert.NoError(t, r.stopDedicatedDrivers(ver, []cephv1.CephCluster{dedicatedCluster("a", "tenant-a", 10)}))
+	_, err = clientset.AppsV1().DaemonSets("rook-ceph").Get(ctx, "tenant-a-csi-rbdplugin", metav1.GetOptions{})
+	assert.Error(t, err)
+	EnableRBD = true
+}
+
+func TestApplyCSIPodScheduling(t *testing.T) {
+	podSpec := &corev1.PodSpec{}
+	applyToPodSpec(podSpec, &corev1.NodeAffinity{}, []corev1.Toleration{{Key: "operator-config"}})
+
+	applyCSIPodScheduling(nil, podSpec)
+	applyCSIPodScheduling(&cephv1.CSIPodSchedulingSpec{}, podSpec)
+	assert.Equal(t, []corev1.Toleration{{Key: "operator-config"}}, podSpec.Tolerations)
+	assert.NotNil(t, podSpec.Affinity.NodeAffinity)
+	assert.Nil(t, podSpec.NodeSelector)
+
+	scheduling := &cephv1.CSIPodSchedulingSpec{
+		NodeSelector: map[string]string{"node-pool": "storage"},
+		Tolerations:  []corev1.Toleration{{Key: "storage-node", Operator: corev1.TolerationOpExists}},
+		Affinity:     &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{}},
+	}
+	applyCSIPodScheduling(scheduling, podSpec)
+	assert.Equal(t, map[string]string{"node-pool": "storage"}, podSpec.NodeSelector)
+	assert.Equal(t, scheduling.Tolerations, podSpec.Tolerations)
+	assert.Nil(t, podSpec.Affinity.NodeAffinity)
+	assert.NotNil(t, podSpec.Affinity.PodAntiAffinity)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"sort"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+)
+
+// applyImagePolicy pins the images of the drivers to their digests and verifies their signatures when
+// the image policy of the CephCSIDriver is enabled. The pinned images are recorded in the status of the
+// CephCSIDriver and replace the images of the parameters of the drivers.
+func (r *ReconcileCSI) applyImagePolicy() error {
+	if r.cephCSIDriver == nil {
+		return nil
+	}
+	if r.cephCSIDriver.Status == nil {
+		r.cephCSIDriver.Status = &cephv1.CephCSIDriverStatus{}
+	}
+	policy := r.cephCSIDriver.Spec.ImagePolicy
+	if !policy.IsEnabled() {
+		r.cephCSIDriver.Status.Images = nil
+		return nil
+	}
+
+	images := csiImages()
+	statuses, err := controller.ApplyImagePolicy(r.opManagerContext, r.context.Clientset, r.opConfig.OperatorNamespace, policy, images, r.cephCSIDriver.Status.Images)
+	if err != nil {
+		return err
+	}
+	r.cephCSIDriver.Status.Images = statuses
+
+	pinned := map[string]string{}
+	for _, status := range statuses {
+		pinned[status.Image] = status.PinnedImage()
+	}
+	for _, image := range csiImageParams() {
+		if p, ok := pinned[*image]; ok {
+			*image = p
+		}
+	}
+	for _, archImages := range []map[string]string{CSIParam.ArchPluginImages, CSIParam.ArchRegistrarImages} {
+		for arch, image := range archImages {
+			archImages[arch] = pinned[image]
+		}
+	}
+	return nil
+}
+
+// csiImageParams returns the parameters of the images of the drivers
+func csiImageParams() []*string {
+	return []*string{
+		&CSIParam.CSIPluginImage,
+		&CSIParam.WindowsCSIPluginImage,
+		&CSIParam.RegistrarImage,
+		&CSIParam.ProvisionerImage,
+		&CSIParam.AttacherImage,
+		&CSIParam.SnapshotterImage,
+		&CSIParam.ResizerImage,
+		&CSIParam.VolumeReplicationImage,
+		&CSIParam.CSIAddonsImage,
+	}
+}
+
+// csiImages returns the distinct images of the drivers, sorted
+func csiImages() []string {
+	unique := map[string]bool{}
+	for _, image := range csiImageParams() {
+		if *image != "" {
+			unique[*image] = true
+		}
+	}
+	for _, archImages := range []map[string]string{CSIParam.ArchPluginImages, CSIParam.ArchRegistrarImages} {
+		for _, image := range archImages {
+			unique[image] = true
+		}
+	}
+	images := []string{}
+	for image := range unique {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
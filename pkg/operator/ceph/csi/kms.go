@@ -0,0 +1,297 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KMSConfigMapName is the name of the ConfigMap ceph-csi reads the KMS connection details from
+	KMSConfigMapName = "csi-kms-connection-details"
+	// the annotation of the KMS ConfigMap listing the KMS IDs generated from the CephClusters, so
+	// that the entries added by hand are preserved
+	kmsManagedIDsAnnotation = "ceph.rook.io/managed-kms-ids"
+
+	kmsTypeKey             = "encryptionKMSType"
+	vaultAuthKubernetes    = "kubernetes"
+	vaultAuthTokens        = "tokens"
+	kmsConnectivityTimeout = 5 * time.Second
+)
+
+// dialKMS checks that the KMS endpoint accepts connections, it can be overridden by the unit tests
+var dialKMS = func(address string) error {
+	conn, err := net.DialTimeout("tcp", address, kmsConnectivityTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// kmsConnectionDetails returns the ceph-csi connection details of a KMS and its endpoint, if any
+func kmsConnectionDetails(kms *cephv1.CSIKMSSpec) (map[string]string, string, error) {
+	providers := 0
+	for _, set := range []bool{kms.Vault != nil, kms.KMIP != nil, kms.AzureKeyVault != nil, kms.SecretsMetadata != nil} {
+		if set {
+			providers++
+		}
+	}
+	if providers != 1 {
+		return nil, "", errors.Errorf("kms %q must set exactly one provider, found %d", kms.ID, providers)
+	}
+
+	switch {
+	case kms.Vault != nil:
+		return vaultConnectionDetails(kms.ID, kms.Vault)
+
+	case kms.KMIP != nil:
+		if kms.KMIP.Endpoint == "" || kms.KMIP.SecretName == "" {
+			return nil, "", errors.Errorf("kms %q must set the kmip endpoint and secret name", kms.ID)
+		}
+		if _, _, err := net.SplitHostPort(kms.KMIP.Endpoint); err != nil {
+			return nil, "", errors.Wrapf(err, "invalid kmip endpoint %q of kms %q", kms.KMIP.Endpoint, kms.ID)
+		}
+		details := map[string]string{
+			kmsTypeKey:         "kmip",
+			"KMIP_ENDPOINT":    kms.KMIP.Endpoint,
+			"KMIP_SECRET_NAME": kms.KMIP.SecretName,
+		}
+		setIfNotEmpty(details, "TLS_SERVER_NAME", kms.KMIP.TLSServerName)
+		if kms.KMIP.ReadTimeout > 0 {
+			details["READ_TIMEOUT"] = strconv.Itoa(kms.KMIP.ReadTimeout)
+		}
+		if kms.KMIP.WriteTimeout > 0 {
+			details["WRITE_TIMEOUT"] = strconv.Itoa(kms.KMIP.WriteTimeout)
+		}
+		return details, kms.KMIP.Endpoint, nil
+
+	case kms.AzureKeyVault != nil:
+		azure := kms.AzureKeyVault
+		if azure.VaultURL == "" || azure.ClientID == "" || azure.TenantID == "" || azure.CertSecretName == "" {
+			return nil, "", errors.Errorf("kms %q must set the azure vault url, client id, tenant id and certificate secret name", kms.ID)
+		}
+		endpoint, err := urlEndpoint(azure.VaultURL)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid azure vault url of kms %q", kms.ID)
+		}
+		return map[string]string{
+			kmsTypeKey:               "azure-kv",
+			"AZURE_VAULT_URL":        azure.VaultURL,
+			"AZURE_CLIENT_ID":        azure.ClientID,
+			"AZURE_TENANT_ID":        azure.TenantID,
+			"AZURE_CERT_SECRET_NAME": azure.CertSecretName,
+		}, endpoint, nil
+
+	default:
+		if kms.SecretsMetadata.SecretName == "" {
+			return nil, "", errors.Errorf("kms %q must set the secret name", kms.ID)
+		}
+		details := map[string]string{
+			kmsTypeKey:   "metadata",
+			"secretName": kms.SecretsMetadata.SecretName,
+		}
+		setIfNotEmpty(details, "secretNamespace", kms.SecretsMetadata.SecretNamespace)
+		return details, "", nil
+	}
+}
+
+func vaultConnectionDetails(id string, vault *cephv1.CSIVaultKMSSpec) (map[string]string, string, error) {
+	endpoint, err := urlEndpoint(vault.Address)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "invalid vault address of kms %q", id)
+	}
+
+	details := map[string]string{
+		"vaultAddress": vault.Address,
+	}
+	setIfNotEmpty(details, "vaultBackendPath", vault.BackendPath)
+	setIfNotEmpty(details, "vaultNamespace", vault.Namespace)
+	setIfNotEmpty(details, "vaultTLSServerName", vault.TLSServerName)
+	setIfNotEmpty(details, "vaultCAFromSecret", vault.CAFromSecret)
+	if vault.CAVerify != nil {
+		details["vaultCAVerify"] = strconv.FormatBool(*vault.CAVerify)
+	}
+	if vault.DestroyKeys != nil {
+		details["vaultDestroyKeys"] = strconv.FormatBool(*vault.DestroyKeys)
+	}
+
+	switch vault.AuthMethod {
+	case "", vaultAuthKubernetes:
+		if vault.TokenName != "" || vault.Backend != "" {
+			return nil, "", errors.Errorf("kms %q cannot set the vault token name or backend with the kubernetes auth method", id)
+		}
+		details[kmsTypeKey] = "vault"
+		setIfNotEmpty(details, "vaultAuthPath", vault.AuthPath)
+		setIfNotEmpty(details, "vaultRole", vault.Role)
+	case vaultAuthTokens:
+		if vault.AuthPath != "" || vault.Role != "" {
+			return nil, "", errors.Errorf("kms %q cannot set the vault auth path or role with the tokens auth method", id)
+		}
+		details[kmsTypeKey] = "vaulttokens"
+		setIfNotEmpty(details, "vaultBackend", vault.Backend)
+		setIfNotEmpty(details, "tenantTokenName", vault.TokenName)
+	default:
+		return nil, "", errors.Errorf("invalid vault auth method %q of kms %q", vault.AuthMethod, id)
+	}
+
+	return details, endpoint, nil
+}
+
+func setIfNotEmpty(details map[string]string, key, value string) {
+	if value != "" {
+		details[key] = value
+	}
+}
+
+// urlEndpoint returns the host:port to connect to for a http(s) url
+func urlEndpoint(address string) (string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", errors.Errorf("%q is not a http(s) url", address)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "http" {
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	}
+	return net.JoinHostPort(u.Hostname(), "443"), nil
+}
+
+// generateKMSConfig returns the ConfigMap data of the KMS of all the clusters, keyed by KMS ID, and
+// the endpoints of the KMS
+func generateKMSConfig(clusters []cephv1.CephCluster) (map[string]string, map[string]string, error) {
+	config := map[string]string{}
+	endpoints := map[string]string{}
+	owners := map[string]string{}
+	for _, cluster := range clusters {
+		for i := range cluster.Spec.CSI.KMS {
+			kms := &cluster.Spec.CSI.KMS[i]
+			if owner, ok := owners[kms.ID]; ok {
+				return nil, nil, errors.Errorf("kms %q of cluster %q is already defined by cluster %q", kms.ID, cluster.Namespace, owner)
+			}
+			owners[kms.ID] = cluster.Namespace
+
+			details, endpoint, err := kmsConnectionDetails(kms)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid kms of cluster %q", cluster.Namespace)
+			}
+			detailsJSON, err := json.Marshal(details)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to marshal connection details of kms %q", kms.ID)
+			}
+			config[kms.ID] = string(detailsJSON)
+			if endpoint != "" {
+				endpoints[kms.ID] = endpoint
+			}
+		}
+	}
+
+	return config, endpoints, nil
+}
+
+// configureKMS renders the KMS of the clusters into the ceph-csi KMS ConfigMap and checks that the
+// KMS endpoints are reachable
+func (r *ReconcileCSI) configureKMS(clusters []cephv1.CephCluster, ownerInfo *k8sutil.OwnerInfo) error {
+	config, endpoints, err := generateKMSConfig(clusters)
+	if err != nil {
+		return err
+	}
+
+	configMaps := r.context.Clientset.CoreV1().ConfigMaps(r.opConfig.OperatorNamespace)
+	configMap, err := configMaps.Get(r.opManagerContext, KMSConfigMapName, metav1.GetOptions{})
+	create := kerrors.IsNotFound(err)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get configmap %q", KMSConfigMapName)
+		}
+		if len(config) == 0 {
+			return nil
+		}
+		configMap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      KMSConfigMapName,
+				Namespace: r.opConfig.OperatorNamespace,
+			},
+		}
+		err = ownerInfo.SetControllerReference(configMap)
+		if err != nil {
+			return errors.Wrapf(err, "failed to set owner reference to configmap %q", KMSConfigMapName)
+		}
+	} else if _, ok := configMap.Annotations[kmsManagedIDsAnnotation]; !ok && len(config) == 0 {
+		// the configmap is fully managed by hand
+		return nil
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	// Remove the KMS that were previously generated and do not exist anymore
+	for _, id := range strings.Split(configMap.Annotations[kmsManagedIDsAnnotation], ",") {
+		if _, ok := config[id]; !ok {
+			delete(configMap.Data, id)
+		}
+	}
+	ids := []string{}
+	for id, details := range config {
+		configMap.Data[id] = details
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations[kmsManagedIDsAnnotation] = strings.Join(ids, ",")
+
+	if create {
+		_, err = configMaps.Create(r.opManagerContext, configMap, metav1.CreateOptions{})
+	} else {
+		_, err = configMaps.Update(r.opManagerContext, configMap, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to save configmap %q", KMSConfigMapName)
+	}
+
+	// An unreachable KMS only fails the provisioning of the encrypted volumes using it, so only report it
+	for _, id := range ids {
+		endpoint, ok := endpoints[id]
+		if !ok {
+			continue
+		}
+		if err := dialKMS(endpoint); err != nil {
+			logger.Errorf("failed to connect to kms %q at %q, encrypted volumes using it cannot be provisioned. %v", id, endpoint, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKMSConnectionDetails(t *testing.T) {
+	caVerify := false
+	kms := &cephv1.CSIKMSSpec{
+		ID:    "vault",
+		Vault: &cephv1.CSIVaultKMSSpec{Address: "https://vault.default.svc:8200", BackendPath: "secret/", Role: "csi", CAVerify: &caVerify},
+	}
+	details, endpoint, err := kmsConnectionDetails(kms)
+	assert.NoError(t, err)
+	assert.Equal(t, "vault.default.svc:8200", endpoint)
+	assert.Equal(t, map[string]string{
+		"encryptionKMSType": "vault",
+		"vaultAddress":      "https://vault.default.svc:8200",
+		"vaultBackendPath":  "secret/",
+		"vaultRole":         "csi",
+		"vaultCAVerify":     "false",
+	}, details)
+
+	// the tokens auth method does not use roles
+	kms.Vault.AuthMethod = "tokens"
+	_, _, err = kmsConnectionDetails(kms)
+	assert.Error(t, err)
+	kms.Vault.Role = ""
+	kms.Vault.TokenName = "vault-token"
+	details, _, err = kmsConnectionDetails(kms)
+	assert.NoError(t, err)
+	assert.Equal(t, "vaulttokens", details["encryptionKMSType"])
+	assert.Equal(t, "vault-token", details["tenantTokenName"])
+
+	// only one provider
+	kms.SecretsMetadata = &cephv1.CSISecretsMetadataKMSSpec{SecretName: "passphrase"}
+	_, _, err = kmsConnectionDetails(kms)
+	assert.Error(t, err)
+
+	kms = &cephv1.CSIKMSSpec{ID: "kmip", KMIP: &cephv1.CSIKMIPKMSSpec{Endpoint: "kmip.example.com", SecretName: "kmip-certs"}}
+	_, _, err = kmsConnectionDetails(kms)
+	assert.Error(t, err)
+	kms.KMIP.Endpoint = "kmip.example.com:5696"
+	kms.KMIP.ReadTimeout = 10
+	details, endpoint, err = kmsConnectionDetails(kms)
+	assert.NoError(t, err)
+	assert.Equal(t, "kmip.example.com:5696", endpoint)
+	assert.Equal(t, "10", details["READ_TIMEOUT"])
+	assert.Equal(t, "kmip-certs", details["KMIP_SECRET_NAME"])
+
+	kms = &cephv1.CSIKMSSpec{ID: "azure", AzureKeyVault: &cephv1.CSIAzureKeyVaultKMSSpec{VaultURL: "https://myvault.vault.azure.net", ClientID: "id", TenantID: "tenant", CertSecretName: "azure-cert"}}
+	details, endpoint, err = kmsConnectionDetails(kms)
+	assert.NoError(t, err)
+	assert.Equal(t, "myvault.vault.azure.net:443", endpoint)
+	assert.Equal(t, "azure-kv", details["encryptionKMSType"])
+
+	kms = &cephv1.CSIKMSSpec{ID: "metadata", SecretsMetadata: &cephv1.CSISecretsMetadataKMSSpec{SecretName: "passphrase"}}
+	details, endpoint, err = kmsConnectionDetails(kms)
+	assert.NoError(t, err)
+	assert.Equal(t, "", endpoint)
+	assert.Equal(t, map[string]string{"encryptionKMSType": "metadata", "secretName": "passphrase"}, details)
+}
+
+func TestConfigureKMS(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	r := &ReconcileCSI{
+		context:          &clusterd.Context{Clientset: clientset},
+		opManagerContext: ctx,
+		opConfig:         controller.OperatorConfig{OperatorNamespace: "rook-ceph"},
+	}
+	ownerInfo := k8sutil.NewOwnerInfoWithOwnerRef(&metav1.OwnerReference{}, "rook-ceph")
+	dialed := []string{}
+	dialKMSOrig := dialKMS
+	defer func() { dialKMS = dialKMSOrig }()
+	dialKMS = func(address string) error {
+		dialed = append(dialed, address)
+		return errors.New("unreachable")
+	}
+
+	clusters := []cephv1.CephCluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "a"},
+			Spec: cephv1.ClusterSpec{CSI: cephv1.CSIDriverSpec{KMS: []cephv1.CSIKMSSpec{
+				{ID: "vault", Vault: &cephv1.CSIVaultKMSSpec{Address: "http://vault:8200"}},
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "b"},
+			Spec: cephv1.ClusterSpec{CSI: cephv1.CSIDriverSpec{KMS: []cephv1.CSIKMSSpec{
+				{ID: "metadata", SecretsMetadata: &cephv1.CSISecretsMetadataKMSSpec{SecretName: "passphrase"}},
+			}}},
+		},
+	}
+	getConfigMap := func() *v1.ConfigMap {
+		cm, err := clientset.CoreV1().ConfigMaps("rook-ceph").Get(ctx, KMSConfigMapName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return cm
+	}
+
+	t.Run("no kms", func(t *testing.T) {
+		assert.NoError(t, r.configureKMS(nil, ownerInfo))
+		_, err := clientset.CoreV1().ConfigMaps("rook-ceph").Get(ctx, KMSConfigMapName, metav1.GetOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("kms of all the clusters", func(t *testing.T) {
+		assert.NoError(t, r.configureKMS(clusters, ownerInfo))
+		cm := getConfigMap()
+		assert.Len(t, cm.Data, 2)
+		details := map[string]string{}
+		assert.NoError(t, json.Unmarshal([]byte(cm.Data["vault"]), &details))
+		assert.Equal(t, "http://vault:8200", details["vaultAddress"])
+		assert.Equal(t, "metadata,vault", cm.Annotations[kmsManagedIDsAnnotation])
+		// only the kms with an endpoint are checked, an unreachable kms is not an error
+		assert.Equal(t, []string{"vault:8200"}, dialed)
+	})
+
+	t.Run("kms added by hand are preserved", func(t *testing.T) {
+		cm := getConfigMap()
+		cm.Data["manual"] = `{"encryptionKMSType":"metadata","secretName":"manual"}`
+		_, err := clientset.CoreV1().ConfigMaps("rook-ceph").Update(ctx, cm, metav1.UpdateOptions{})
+		assert.NoError(t, err)
+
+		assert.NoError(t, r.configureKMS(clusters[:1], ownerInfo))
+		cm = getConfigMap()
+		assert.Len(t, cm.Data, 2)
+		assert.Contains(t, cm.Data, "manual")
+		assert.Contains(t, cm.Data, "vault")
+		assert.Equal(t, "vault", cm.Annotations[kmsManagedIDsAnnotation])
+	})
+
+	t.Run("duplicate kms id", func(t *testing.T) {
+		clusters[1].Spec.CSI.KMS[0].ID = "vault"
+		assert.Error(t, r.configureKMS(clusters, ownerInfo))
+	})
+}
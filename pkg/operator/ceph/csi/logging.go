@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// the host directory of the log files of the csi containers when the logs are rotated
+	csiLogDirPath = "/var/log/ceph-csi"
+	// the directory of the log files in the csi containers
+	csiLogMountPath = "/csi-logs"
+	csiLogVolume    = "csi-log"
+
+	logCollectorContainer = "log-collector"
+	// the number of rotated log files kept for each container
+	logRotateCount = 5
+
+	defaultLogRotationMaxSize = "100M"
+	defaultLogRotationMaxAge  = 7
+
+	logLevelArg = "--v="
+)
+
+var logRotationMaxSizeRegex = regexp.MustCompile(`^[0-9]+[kMG]?$`)
+
+// parseContainerLogLevels parses the log levels of the csi containers by container name, in the
+// "csi-provisioner=5,csi-snapshotter=5" format
+func parseContainerLogLevels(params map[string]string, key string) (map[string]uint8, error) {
+	levels := map[string]uint8{}
+	for name, value := range k8sutil.ParseStringToLabels(k8sutil.GetValue(params, key, "")) {
+		level, err := strconv.ParseUint(value, 10, 8)
+		if err != nil || level > 5 {
+			return nil, errors.Errorf("invalid log level %q of container %q in %q, it must be from 0 to 5", value, name, key)
+		}
+		levels[name] = uint8(level)
+	}
+	return levels, nil
+}
+
+// parseLogRotation parses the log rotation settings of the csi containers
+func parseLogRotation(params map[string]string, tp *templateParam) error {
+	var err error
+	tp.EnableLogRotation, err = strconv.ParseBool(k8sutil.GetValue(params, "CSI_ENABLE_LOG_ROTATION", "false"))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse value for 'CSI_ENABLE_LOG_ROTATION'")
+	}
+
+	tp.LogRotationMaxSize = k8sutil.GetValue(params, "CSI_LOG_ROTATION_MAX_SIZE", defaultLogRotationMaxSize)
+	if !logRotationMaxSizeRegex.MatchString(tp.LogRotationMaxSize) {
+		return errors.Errorf("invalid value %q for 'CSI_LOG_ROTATION_MAX_SIZE', it must be a number of bytes with an optional k, M or G suffix", tp.LogRotationMaxSize)
+	}
+
+	tp.LogRotationMaxAge, err = strconv.Atoi(k8sutil.GetValue(params, "CSI_LOG_ROTATION_MAX_AGE", strconv.Itoa(defaultLogRotationMaxAge)))
+	if err != nil || tp.LogRotationMaxAge < 1 {
+		return errors.Errorf("invalid value for 'CSI_LOG_ROTATION_MAX_AGE', it must be a number of days greater than 0")
+	}
+	return nil
+}
+
+// applyContainerLogLevels overrides the log level of the csi containers given by name
+func applyContainerLogLevels(podSpec *corev1.PodSpec, levels map[string]uint8) {
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		level, ok := levels[container.Name]
+		if !ok {
+			continue
+		}
+		for j, arg := range container.Args {
+			if strings.HasPrefix(arg, logLevelArg) {
+				container.Args[j] = fmt.Sprintf("%s%d", logLevelArg, level)
+			}
+		}
+	}
+}
+
+// applyLogRotation writes the logs of the csi containers to files of the host, which are rotated by
+// a log collector sidecar. The logs are still written to stderr for kubectl logs. The log files are
+// named after the app label of the pod, which is unique for each driver.
+func applyLogRotation(podSpec *corev1.PodSpec, app string, tp templateParam) {
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if !hasLogLevelArg(container) {
+			continue
+		}
+		container.Args = append(container.Args,
+			"--logtostderr=false",
+			"--alsologtostderr=true",
+			fmt.Sprintf("--log_file=%s/%s-%s.log", csiLogMountPath, app, container.Name),
+		)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: csiLogVolume, MountPath: csiLogMountPath})
+	}
+
+	podSpec.Containers = append(podSpec.Containers, logCollectorSidecar(app, tp))
+	hostPathType := corev1.HostPathDirectoryOrCreate
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         csiLogVolume,
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: csiLogDirPath, Type: &hostPathType}},
+	})
+}
+
+func hasLogLevelArg(container *corev1.Container) bool {
+	for _, arg := range container.Args {
+		if strings.HasPrefix(arg, logLevelArg) {
+			return true
+		}
+	}
+	return false
+}
+
+// logCollectorSidecar returns the sidecar rotating the log files of the csi containers of a pod
+func logCollectorSidecar(app string, tp templateParam) corev1.Container {
+	config := fmt.Sprintf(`%s/%s-*.log {
+    size %s
+    maxage %d
+    rotate %d
+    missingok
+    notifempty
+    compress
+    copytruncate
+}`, csiLogMountPath, app, tp.LogRotationMaxSize, tp.LogRotationMaxAge, logRotateCount)
+	privileged := true
+
+	return corev1.Container{
+		Name:    logCollectorContainer,
+		Image:   tp.CSIPluginImage,
+		Command: []string{"/bin/sh", "-c"},
+		Args: []string{fmt.Sprintf(`echo '%s' > /tmp/logrotate.conf
+while true; do
+    logrotate --state /tmp/logrotate.state /tmp/logrotate.conf
+    sleep 15m
+done`, config)},
+		SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		VolumeMounts:    []corev1.VolumeMount{{Name: csiLogVolume, MountPath: csiLogMountPath}},
+	}
+}
+
+// applyCSILogging applies the log levels and the log rotation settings to the pods of a driver
+func applyCSILogging(podSpec *corev1.PodSpec, podLabels map[string]string, levels map[string]uint8, tp templateParam) {
+	applyContainerLogLevels(podSpec, levels)
+	if tp.EnableLogRotation {
+		applyLogRotation(podSpec, podLabels["app"], tp)
+	}
+}
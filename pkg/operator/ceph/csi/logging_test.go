@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func getContainer(podSpec *corev1.PodSpec, name string) *corev1.Container {
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == name {
+			return &podSpec.Containers[i]
+		}
+	}
+	return nil
+}
+
+func TestParseContainerLogLevels(t *testing.T) {
+	levels, err := parseContainerLogLevels(map[string]string{}, "CSI_PROVISIONER_LOG_LEVELS")
+	assert.NoError(t, err)
+	assert.Empty(t, levels)
+
+	params := map[string]string{"CSI_PROVISIONER_LOG_LEVELS": "csi-provisioner=5,csi-snapshotter=4"}
+	levels, err = parseContainerLogLevels(params, "CSI_PROVISIONER_LOG_LEVELS")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint8{"csi-provisioner": 5, "csi-snapshotter": 4}, levels)
+
+	for _, value := range []string{"csi-provisioner=6", "csi-provisioner", "csi-provisioner=high"} {
+		_, err = parseContainerLogLevels(map[string]string{"CSI_PROVISIONER_LOG_LEVELS": value}, "CSI_PROVISIONER_LOG_LEVELS")
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseLogRotation(t *testing.T) {
+	tp := templateParam{}
+	assert.NoError(t, parseLogRotation(map[string]string{}, &tp))
+	assert.False(t, tp.EnableLogRotation)
+	assert.Equal(t, defaultLogRotationMaxSize, tp.LogRotationMaxSize)
+	assert.Equal(t, defaultLogRotationMaxAge, tp.LogRotationMaxAge)
+
+	params := map[string]string{"CSI_ENABLE_LOG_ROTATION": "true", "CSI_LOG_ROTATION_MAX_SIZE": "1G", "CSI_LOG_ROTATION_MAX_AGE": "3"}
+	assert.NoError(t, parseLogRotation(params, &tp))
+	assert.True(t, tp.EnableLogRotation)
+	assert.Equal(t, "1G", tp.LogRotationMaxSize)
+	assert.Equal(t, 3, tp.LogRotationMaxAge)
+
+	params["CSI_LOG_ROTATION_MAX_SIZE"] = "1Gi"
+	assert.Error(t, parseLogRotation(params, &tp))
+	params["CSI_LOG_ROTATION_MAX_SIZE"] = "1G"
+	params["CSI_LOG_ROTATION_MAX_AGE"] = "0"
+	assert.Error(t, parseLogRotation(params, &tp))
+}
+
+func TestApplyCSILogging(t *testing.T) {
+	tp := templateParam{Param: CSIParam, Namespace: "rook-ceph"}
+	tp.LogLevel = 0
+	tp.LogRotationMaxSize = "100M"
+	tp.LogRotationMaxAge = 7
+
+	t.Run("log levels of the provisioner containers", func(t *testing.T) {
+		provisioner, err := templateToDeployment("rbd-provisioner", RBDProvisionerDepTemplatePath, tp)
+		assert.NoError(t, err)
+		podSpec := &provisioner.Spec.Template.Spec
+		applyCSILogging(podSpec, provisioner.Spec.Template.Labels, map[string]uint8{"csi-provisioner": 5}, tp)
+		assert.Contains(t, getContainer(podSpec, "csi-provisioner").Args, "--v=5")
+		assert.Contains(t, getContainer(podSpec, "csi-rbdplugin").Args, "--v=0")
+		// the logs are not rotated by default
+		assert.Nil(t, getContainer(podSpec, logCollectorContainer))
+	})
+
+	t.Run("rotated logs of the plugin containers", func(t *testing.T) {
+		tp.EnableLogRotation = true
+		plugin, err := templateToDaemonSet("rbdplugin", RBDPluginTemplatePath, tp)
+		assert.NoError(t, err)
+		podSpec := &plugin.Spec.Template.Spec
+		applyCSILogging(podSpec, plugin.Spec.Template.Labels, nil, tp)
+
+		rbdPlugin := getContainer(podSpec, "csi-rbdplugin")
+		assert.Contains(t, rbdPlugin.Args, "--log_file=/csi-logs/csi-rbdplugin-csi-rbdplugin.log")
+		assert.Contains(t, rbdPlugin.Args, "--alsologtostderr=true")
+		assert.Contains(t, rbdPlugin.VolumeMounts, corev1.VolumeMount{Name: csiLogVolume, MountPath: csiLogMountPath})
+		// the liveness container has no log level
+		assert.NotContains(t, getContainer(podSpec, "liveness-prometheus").Args, "--logtostderr=false")
+
+		collector := getContainer(podSpec, logCollectorContainer)
+		assert.NotNil(t, collector)
+		assert.Contains(t, collector.Args[0], "/csi-logs/csi-rbdplugin-*.log")
+		assert.Contains(t, collector.Args[0], "size 100M")
+		assert.Contains(t, collector.Args[0], "maxage 7")
+		assert.Equal(t, csiLogDirPath, podSpec.Volumes[len(podSpec.Volumes)-1].HostPath.Path)
+	})
+}
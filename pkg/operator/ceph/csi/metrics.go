@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CSIMetricsServiceMonitorName is the name of the ServiceMonitor scraping the csi metrics services
+	CSIMetricsServiceMonitorName = "csi-metrics"
+	// csiMetricsAppLabel is the app label of the rbd and cephfs metrics services
+	csiMetricsAppLabel = "csi-metrics"
+
+	csiHTTPMetricsPortName    = "csi-http-metrics"
+	csiGRPCMetricsPortName    = "csi-grpc-metrics"
+	defaultCSIMetricsInterval = 5 * time.Second
+)
+
+// csiMonitoringEnabled returns whether any of the CephClusters asks for the monitoring of the csi drivers
+func csiMonitoringEnabled(clusters []cephv1.CephCluster) bool {
+	for _, cluster := range clusters {
+		if cluster.Spec.Monitoring.CSI.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// generateCSIServiceMonitor builds the ServiceMonitor scraping the liveness and grpc metrics of the
+// csi drivers. The drivers are shared by all the CephClusters, so the monitoring labels of all the
+// clusters monitoring the drivers are applied and the shortest scrape interval wins.
+func generateCSIServiceMonitor(namespace string, clusters []cephv1.CephCluster) *monitoringv1.ServiceMonitor {
+	interval := time.Duration(0)
+	serviceMonitor := &monitoringv1.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CSIMetricsServiceMonitorName,
+			Namespace: namespace,
+		},
+	}
+	for _, cluster := range clusters {
+		if !cluster.Spec.Monitoring.CSI.Enabled {
+			continue
+		}
+		clusterInterval := defaultCSIMetricsInterval
+		if cluster.Spec.Monitoring.CSI.Interval != nil && cluster.Spec.Monitoring.CSI.Interval.Duration > 0 {
+			clusterInterval = cluster.Spec.Monitoring.CSI.Interval.Duration
+		}
+		if interval == 0 || clusterInterval < interval {
+			interval = clusterInterval
+		}
+		cephv1.GetMonitoringLabels(cluster.Spec.Labels).OverwriteApplyToObjectMeta(&serviceMonitor.ObjectMeta)
+	}
+
+	// prometheus does not accept fractional durations
+	seconds := int(interval.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	scrapeInterval := fmt.Sprintf("%ds", seconds)
+
+	serviceMonitor.Spec = monitoringv1.ServiceMonitorSpec{
+		NamespaceSelector: monitoringv1.NamespaceSelector{
+			MatchNames: []string{namespace},
+		},
+		Selector: metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": csiMetricsAppLabel},
+		},
+		Endpoints: []monitoringv1.Endpoint{
+			{Port: csiHTTPMetricsPortName, Path: "/metrics", Interval: scrapeInterval},
+			{Port: csiGRPCMetricsPortName, Path: "/metrics", Interval: scrapeInterval},
+		},
+	}
+
+	return serviceMonitor
+}
+
+// configureCSIServiceMonitor creates the ServiceMonitor of the csi metrics services if a CephCluster
+// monitors the csi drivers, and removes it otherwise
+func (r *ReconcileCSI) configureCSIServiceMonitor(clusters []cephv1.CephCluster, ownerInfo *k8sutil.OwnerInfo) error {
+	if !csiMonitoringEnabled(clusters) {
+		// The prometheus types may not even exist if the monitoring was never enabled
+		err := k8sutil.DeleteServiceMonitor(r.opManagerContext, r.opConfig.OperatorNamespace, CSIMetricsServiceMonitorName)
+		if err != nil {
+			logger.Debugf("failed to delete csi metrics service monitor. %v", err)
+		}
+		return nil
+	}
+
+	serviceMonitor := generateCSIServiceMonitor(r.opConfig.OperatorNamespace, clusters)
+	err := ownerInfo.SetControllerReference(serviceMonitor)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to service monitor %q", serviceMonitor.Name)
+	}
+
+	if _, err = k8sutil.CreateOrUpdateServiceMonitor(r.opManagerContext, serviceMonitor); err != nil {
+		return errors.Wrap(err, "csi metrics service monitor could not be enabled")
+	}
+	logger.Infof("csi metrics service monitor %q configured", serviceMonitor.Name)
+
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateCSIServiceMonitor(t *testing.T) {
+	clusters := []cephv1.CephCluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "unmonitored", Namespace: "ns1"}},
+	}
+	assert.False(t, csiMonitoringEnabled(clusters))
+
+	clusters = append(clusters,
+		cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "monitored", Namespace: "ns2"},
+			Spec: cephv1.ClusterSpec{
+				Monitoring: cephv1.MonitoringSpec{CSI: cephv1.CSIMonitoringSpec{Enabled: true}},
+				Labels:     cephv1.LabelsSpec{cephv1.KeyMonitoring: {"prometheus": "k8s"}},
+			},
+		},
+		cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "fast", Namespace: "ns3"},
+			Spec: cephv1.ClusterSpec{
+				Monitoring: cephv1.MonitoringSpec{CSI: cephv1.CSIMonitoringSpec{Enabled: true, Interval: &metav1.Duration{Duration: 2500 * time.Millisecond}}},
+				Labels:     cephv1.LabelsSpec{cephv1.KeyMonitoring: {"team": "storage"}},
+			},
+		},
+	)
+	assert.True(t, csiMonitoringEnabled(clusters))
+
+	sm := generateCSIServiceMonitor("rook-ceph", clusters)
+	assert.Equal(t, CSIMetricsServiceMonitorName, sm.Name)
+	assert.Equal(t, "rook-ceph", sm.Namespace)
+	assert.Equal(t, map[string]string{"prometheus": "k8s", "team": "storage"}, sm.Labels)
+	assert.Equal(t, []string{"rook-ceph"}, sm.Spec.NamespaceSelector.MatchNames)
+	assert.Equal(t, map[string]string{"app": "csi-metrics"}, sm.Spec.Selector.MatchLabels)
+	assert.Len(t, sm.Spec.Endpoints, 2)
+	assert.Equal(t, "csi-http-metrics", sm.Spec.Endpoints[0].Port)
+	assert.Equal(t, "csi-grpc-metrics", sm.Spec.Endpoints[1].Port)
+	// the shortest interval is used, rounded to the second
+	assert.Equal(t, "2s", sm.Spec.Endpoints[0].Interval)
+
+	sm = generateCSIServiceMonitor("rook-ceph", clusters[:2])
+	assert.Equal(t, "5s", sm.Spec.Endpoints[1].Interval)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// pluginTemplateHashAnnotation is the hash of the pod template of a plugin daemonset rolled by
+	// the operator, the pods with another hash are not updated yet
+	pluginTemplateHashAnnotation = "ceph.rook.io/csi-plugin-template-hash"
+)
+
+var (
+	// the interval and timeout of the wait for a restarted plugin to re-register its driver
+	pluginRestartInterval = 5 * time.Second
+	pluginRestartTimeout  = 10 * time.Minute
+)
+
+// setPluginTemplateHash annotates the pod template of a plugin daemonset with its hash, so that the
+// operator finds the plugin pods to restart
+func setPluginTemplateHash(ds *apps.DaemonSet) error {
+	delete(ds.Spec.Template.Annotations, pluginTemplateHashAnnotation)
+	template, err := json.Marshal(ds.Spec.Template)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the pod template of daemonset %q", ds.Name)
+	}
+	if ds.Spec.Template.Annotations == nil {
+		ds.Spec.Template.Annotations = map[string]string{}
+	}
+	ds.Spec.Template.Annotations[pluginTemplateHashAnnotation] = k8sutil.Hash(string(template))
+	return nil
+}
+
+// isPodReady returns whether all the containers of the pod are ready
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// rollPluginDaemonSet restarts the plugin pods that do not run the pod template of the daemonset,
+// zone after zone and at most maxUnavailable pods of a zone at a time. Each restarted plugin must
+// be ready and re-register the driver on its node before the next pods are restarted, so that a
+// bad upgrade stops after breaking the mounts of a few nodes instead of the whole cluster.
+func (r *ReconcileCSI) rollPluginDaemonSet(ds *apps.DaemonSet, driverName string, maxUnavailable int, zoneLabel string) error {
+	hash := ds.Spec.Template.Annotations[pluginTemplateHashAnnotation]
+	selector := labels.SelectorFromSet(ds.Spec.Selector.MatchLabels).String()
+	pods, err := r.context.Clientset.CoreV1().Pods(r.opConfig.OperatorNamespace).List(r.opManagerContext, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the pods of csi plugin %q", ds.Name)
+	}
+
+	outdated, updated := []corev1.Pod{}, []corev1.Pod{}
+	for _, pod := range pods.Items {
+		if pod.Annotations[pluginTemplateHashAnnotation] != hash {
+			outdated = append(outdated, pod)
+		} else {
+			updated = append(updated, pod)
+		}
+	}
+	if len(outdated) == 0 {
+		return nil
+	}
+
+	// Do not go on with an upgrade that already failed on a node
+	for i := range updated {
+		pod := &updated[i]
+		registered, err := r.isDriverRegistered(driverName, pod.Spec.NodeName)
+		if err != nil {
+			return err
+		}
+		if !isPodReady(pod) || !registered {
+			return errors.Errorf("updated csi plugin %q on node %q is not ready, not restarting the other plugins", ds.Name, pod.Spec.NodeName)
+		}
+	}
+
+	zones, err := r.pluginPodsByZone(outdated, zoneLabel)
+	if err != nil {
+		return err
+	}
+	zoneNames := make([]string, 0, len(zones))
+	for zone := range zones {
+		zoneNames = append(zoneNames, zone)
+	}
+	sort.Strings(zoneNames)
+
+	logger.Infof("restarting %d pods of csi plugin %q in %d zones", len(outdated), ds.Name, len(zoneNames))
+	for _, zone := range zoneNames {
+		zonePods := zones[zone]
+		for start := 0; start < len(zonePods); start += maxUnavailable {
+			end := start + maxUnavailable
+			if end > len(zonePods) {
+				end = len(zonePods)
+			}
+			batch := zonePods[start:end]
+			for _, pod := range batch {
+				logger.Infof("restarting csi plugin pod %q on node %q", pod.Name, pod.Spec.NodeName)
+				err = r.context.Clientset.CoreV1().Pods(pod.Namespace).Delete(r.opManagerContext, pod.Name, metav1.DeleteOptions{})
+				if err != nil && !kerrors.IsNotFound(err) {
+					return errors.Wrapf(err, "failed to delete csi plugin pod %q", pod.Name)
+				}
+			}
+			for _, pod := range batch {
+				if err = r.waitForPluginRestart(ds, selector, hash, driverName, pod.Spec.NodeName); err != nil {
+					return err
+				}
+			}
+		}
+		logger.Infof("csi plugin %q updated in zone %q", ds.Name, zone)
+	}
+
+	return nil
+}
+
+// pluginPodsByZone groups the plugin pods by the zone label of their node, sorted by node name. The
+// pods on the nodes without the label are in the "" zone
+func (r *ReconcileCSI) pluginPodsByZone(pods []corev1.Pod, zoneLabel string) (map[string][]corev1.Pod, error) {
+	nodeZones := map[string]string{}
+	zones := map[string][]corev1.Pod{}
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		zone, ok := nodeZones[nodeName]
+		if !ok && nodeName != "" {
+			node, err := r.context.Clientset.CoreV1().Nodes().Get(r.opManagerContext, nodeName, metav1.GetOptions{})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to get node %q", nodeName)
+			}
+			if err == nil {
+				zone = node.Labels[zoneLabel]
+			}
+			nodeZones[nodeName] = zone
+		}
+		zones[zone] = append(zones[zone], pod)
+	}
+	for _, zonePods := range zones {
+		sort.Slice(zonePods, func(i, j int) bool { return zonePods[i].Spec.NodeName < zonePods[j].Spec.NodeName })
+	}
+	return zones, nil
+}
+
+// waitForPluginRestart waits for the plugin pod of a node to run the pod template of the daemonset,
+// to be ready and to register its driver on the node
+func (r *ReconcileCSI) waitForPluginRestart(ds *apps.DaemonSet, selector, hash, driverName, nodeName string) error {
+	err := wait.PollImmediate(pluginRestartInterval, pluginRestartTimeout, func() (bool, error) {
+		pods, err := r.context.Clientset.CoreV1().Pods(r.opConfig.OperatorNamespace).List(r.opManagerContext, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to list the pods of csi plugin %q", ds.Name)
+		}
+		ready := false
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Spec.NodeName == nodeName && pod.Annotations[pluginTemplateHashAnnotation] == hash && isPodReady(pod) {
+				ready = true
+				break
+			}
+		}
+		if !ready {
+			logger.Debugf("waiting for csi plugin %q to be ready on node %q", ds.Name, nodeName)
+			return false, nil
+		}
+
+		registered, err := r.isDriverRegistered(driverName, nodeName)
+		if err != nil {
+			return false, err
+		}
+		if !registered {
+			logger.Debugf("waiting for driver %q to be registered on node %q", driverName, nodeName)
+		}
+		return registered, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "csi plugin %q did not re-register driver %q on node %q", ds.Name, driverName, nodeName)
+	}
+	return nil
+}
+
+// isDriverRegistered returns whether the driver is registered by its plugin in the CSINode of the node
+func (r *ReconcileCSI) isDriverRegistered(driverName, nodeName string) (bool, error) {
+	csiNode, err := r.context.Clientset.StorageV1().CSINodes().Get(r.opManagerContext, nodeName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to get csi node %q", nodeName)
+	}
+	for _, driver := range csiNode.Spec.Drivers {
+		if driver.Name == driverName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
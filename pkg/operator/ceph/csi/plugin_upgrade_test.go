@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testDriverName = "rook-ceph.rbd.csi.ceph.com"
+
+func pluginPod(name, nodeName, hash string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "rook-ceph",
+			Labels:      map[string]string{"app": csiRBDPlugin},
+			Annotations: map[string]string{pluginTemplateHashAnnotation: hash},
+		},
+		Spec:   corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+	}
+}
+
+func TestSetPluginTemplateHash(t *testing.T) {
+	ds := &apps.DaemonSet{}
+	ds.Spec.Template.Spec.Containers = []corev1.Container{{Name: "csi-rbdplugin", Image: "quay.io/cephcsi/cephcsi:v3.5.0"}}
+	assert.NoError(t, setPluginTemplateHash(ds))
+	hash := ds.Spec.Template.Annotations[pluginTemplateHashAnnotation]
+	assert.NotEmpty(t, hash)
+
+	// the hash does not change with the annotation itself
+	assert.NoError(t, setPluginTemplateHash(ds))
+	assert.Equal(t, hash, ds.Spec.Template.Annotations[pluginTemplateHashAnnotation])
+
+	ds.Spec.Template.Spec.Containers[0].Image = "quay.io/cephcsi/cephcsi:v3.5.1"
+	assert.NoError(t, setPluginTemplateHash(ds))
+	assert.NotEqual(t, hash, ds.Spec.Template.Annotations[pluginTemplateHashAnnotation])
+}
+
+func TestRollPluginDaemonSet(t *testing.T) {
+	pluginRestartInterval, pluginRestartTimeout = time.Millisecond, 50*time.Millisecond
+	defer func() { pluginRestartInterval, pluginRestartTimeout = 5*time.Second, 10*time.Minute }()
+
+	ctx := context.TODO()
+	clientset := fake.NewSimpleClientset()
+	r := &ReconcileCSI{
+		context:          &clusterd.Context{Clientset: clientset},
+		opManagerContext: ctx,
+		opConfig:         controller.OperatorConfig{OperatorNamespace: "rook-ceph"},
+	}
+	ds := &apps.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: csiRBDPlugin, Namespace: "rook-ceph"}}
+	ds.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": csiRBDPlugin}}
+	ds.Spec.Template.Annotations = map[string]string{pluginTemplateHashAnnotation: "new"}
+
+	for i, zone := range []string{"a", "a", "a", "b", ""} {
+		nodeName := fmt.Sprintf("node%d", i)
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+		if zone != "" {
+			node.Labels = map[string]string{corev1.LabelTopologyZone: zone}
+		}
+		_, err := clientset.CoreV1().Nodes().Create(ctx, node, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		_, err = clientset.CoreV1().Pods("rook-ceph").Create(ctx, pluginPod("plugin-"+nodeName, nodeName, "old"), metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	// the daemonset controller replaces the deleted pods with pods of the new template
+	restarted := []string{}
+	clientset.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.DeleteAction).GetName()
+		obj, err := clientset.Tracker().Get(corev1.SchemeGroupVersion.WithResource("pods"), "rook-ceph", name)
+		if err != nil {
+			return false, nil, nil
+		}
+		nodeName := obj.(*corev1.Pod).Spec.NodeName
+		restarted = append(restarted, nodeName)
+		hash := ds.Spec.Template.Annotations[pluginTemplateHashAnnotation]
+		return false, nil, clientset.Tracker().Add(pluginPod(fmt.Sprintf("plugin-%s-%s", nodeName, hash), nodeName, hash))
+	})
+
+	t.Run("pods are restarted zone after zone", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			csiNode := &storagev1.CSINode{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node%d", i)}}
+			csiNode.Spec.Drivers = []storagev1.CSINodeDriver{{Name: testDriverName}}
+			_, err := clientset.StorageV1().CSINodes().Create(ctx, csiNode, metav1.CreateOptions{})
+			assert.NoError(t, err)
+		}
+
+		assert.NoError(t, r.rollPluginDaemonSet(ds, testDriverName, 2, corev1.LabelTopologyZone))
+		// the nodes without a zone come first
+		assert.Equal(t, []string{"node4", "node0", "node1", "node2", "node3"}, restarted)
+
+		// nothing left to restart
+		assert.NoError(t, r.rollPluginDaemonSet(ds, testDriverName, 2, corev1.LabelTopologyZone))
+		assert.Len(t, restarted, 5)
+	})
+
+	t.Run("the roll stops when a driver is not re-registered", func(t *testing.T) {
+		restarted = []string{}
+		ds.Spec.Template.Annotations[pluginTemplateHashAnnotation] = "newer"
+		assert.NoError(t, clientset.StorageV1().CSINodes().Delete(ctx, "node4", metav1.DeleteOptions{}))
+
+		assert.Error(t, r.rollPluginDaemonSet(ds, testDriverName, 1, corev1.LabelTopologyZone))
+		assert.Equal(t, []string{"node4"}, restarted)
+	})
+
+	t.Run("the roll does not resume after a failed node", func(t *testing.T) {
+		restarted = []string{}
+		// node4 runs the new template but its driver is not registered
+		assert.Error(t, r.rollPluginDaemonSet(ds, testDriverName, 1, corev1.LabelTopologyZone))
+		assert.Empty(t, restarted)
+	})
+}
@@ -18,6 +18,7 @@ package csi
 
 import (
 	"context"
+	"reflect"
 	"regexp"
 
 	"github.com/google/go-cmp/cmp"
@@ -70,6 +71,25 @@ func predicateController(ctx context.Context, c client.Client, opNamespace strin
 				return cephCluster.Generation == 1
 			}
 
+			// A new block pool topology may require a new domain label for the RBD driver
+			if _, ok := e.Object.(*cephv1.CephBlockPoolTopology); ok {
+				return true
+			}
+
+			if cephCSIDriver, ok := e.Object.(*cephv1.CephCSIDriver); ok {
+				return isCephCSIDriver(cephCSIDriver, opNamespace)
+			}
+
+			// A new filesystem may require a volume group snapshot class
+			if _, ok := e.Object.(*cephv1.CephFilesystem); ok {
+				return CSIParam.EnableVolumeGroupSnapshot
+			}
+
+			// A new mirrored pool may require a volume group replication class
+			if pool, ok := e.Object.(*cephv1.CephBlockPool); ok {
+				return isGroupReplicatedPool(pool)
+			}
+
 			return false
 		},
 
@@ -85,6 +105,38 @@ func predicateController(ctx context.Context, c client.Client, opNamespace strin
 				}
 			}
 
+			// The csi metrics service monitor, the volume snapshot classes and the kms config follow the
+			// settings of the CephClusters
+			if old, ok := e.ObjectOld.(*cephv1.CephCluster); ok {
+				if new, ok := e.ObjectNew.(*cephv1.CephCluster); ok {
+					if !reflect.DeepEqual(old.Spec.CSI, new.Spec.CSI) {
+						return true
+					}
+					return !reflect.DeepEqual(old.Spec.Monitoring.CSI, new.Spec.Monitoring.CSI) ||
+						(new.Spec.Monitoring.CSI.Enabled && !reflect.DeepEqual(cephv1.GetMonitoringLabels(old.Spec.Labels), cephv1.GetMonitoringLabels(new.Spec.Labels)))
+				}
+			}
+
+			if old, ok := e.ObjectOld.(*cephv1.CephBlockPoolTopology); ok {
+				if new, ok := e.ObjectNew.(*cephv1.CephBlockPoolTopology); ok {
+					return old.Spec.DomainLabel != new.Spec.DomainLabel || !old.GetDeletionTimestamp().Equal(new.GetDeletionTimestamp())
+				}
+			}
+
+			// The volume group replication class follows the mirroring of the pool
+			if old, ok := e.ObjectOld.(*cephv1.CephBlockPool); ok {
+				if new, ok := e.ObjectNew.(*cephv1.CephBlockPool); ok {
+					return isGroupReplicatedPool(old) != isGroupReplicatedPool(new) || old.Spec.Name != new.Spec.Name
+				}
+			}
+
+			// Only the changes of the spec are applied, not the status updates
+			if old, ok := e.ObjectOld.(*cephv1.CephCSIDriver); ok {
+				if new, ok := e.ObjectNew.(*cephv1.CephCSIDriver); ok {
+					return isCephCSIDriver(new, opNamespace) && old.Generation != new.Generation
+				}
+			}
+
 			return false
 		},
 
@@ -101,6 +153,26 @@ func predicateController(ctx context.Context, c client.Client, opNamespace strin
 				return true
 			}
 
+			// the domain label of a deleted block pool topology may not be needed anymore
+			if _, ok := e.Object.(*cephv1.CephBlockPoolTopology); ok {
+				return true
+			}
+
+			// the operator's config applies again once the CephCSIDriver is deleted
+			if cephCSIDriver, ok := e.Object.(*cephv1.CephCSIDriver); ok {
+				return isCephCSIDriver(cephCSIDriver, opNamespace)
+			}
+
+			// the volume group snapshot class of a deleted filesystem is removed
+			if _, ok := e.Object.(*cephv1.CephFilesystem); ok {
+				return CSIParam.EnableVolumeGroupSnapshot
+			}
+
+			// the volume group replication class of a deleted pool is removed
+			if pool, ok := e.Object.(*cephv1.CephBlockPool); ok {
+				return pool.Spec.Mirroring.Enabled
+			}
+
 			return false
 		},
 
@@ -110,6 +182,11 @@ func predicateController(ctx context.Context, c client.Client, opNamespace strin
 	}
 }
 
+// isCephCSIDriver returns whether the CephCSIDriver configures the drivers of the operator
+func isCephCSIDriver(cephCSIDriver *cephv1.CephCSIDriver, opNamespace string) bool {
+	return cephCSIDriver.Name == CephCSIDriverName && cephCSIDriver.Namespace == opNamespace
+}
+
 func findCSIChange(str string) bool {
 	var re = regexp.MustCompile(`(?m)^(\+|-).*(\"ROOK_CSI_|\"CSI_).*,$`)
 	found := re.FindAllString(str, -1)
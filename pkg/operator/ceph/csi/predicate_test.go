@@ -247,4 +247,51 @@ func Test_predicateController(t *testing.T) {
 		p = predicateController(context.TODO(), client, "rook-ceph")
 		assert.False(t, p.Create(c))
 	})
+
+	t.Run("update event is a CephCluster and the csi monitoring changed", func(t *testing.T) {
+		oldCluster := cluster.DeepCopy()
+		newCluster := cluster.DeepCopy()
+		u = event.UpdateEvent{ObjectOld: oldCluster, ObjectNew: newCluster}
+		p = predicateController(context.TODO(), client, "rook-ceph")
+		assert.False(t, p.Update(u))
+
+		newCluster.Spec.Monitoring.CSI.Enabled = true
+		assert.True(t, p.Update(u))
+
+		oldCluster.Spec.Monitoring.CSI.Enabled = true
+		newCluster.Spec.Labels = cephv1.LabelsSpec{cephv1.KeyMonitoring: {"prometheus": "k8s"}}
+		assert.True(t, p.Update(u))
+	})
+
+	t.Run("events of the ceph csi driver of the operator", func(t *testing.T) {
+		driver := &cephv1.CephCSIDriver{}
+		driver.Name = CephCSIDriverName
+		driver.Namespace = "rook-ceph"
+		p = predicateController(context.TODO(), client, "rook-ceph")
+		assert.True(t, p.Create(event.CreateEvent{Object: driver}))
+		assert.True(t, p.Delete(event.DeleteEvent{Object: driver}))
+
+		// status updates are ignored
+		newDriver := driver.DeepCopy()
+		newDriver.Status = &cephv1.CephCSIDriverStatus{Phase: cephv1.ConditionReady}
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: driver, ObjectNew: newDriver}))
+		newDriver.Generation = 2
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: driver, ObjectNew: newDriver}))
+
+		// another namespace
+		driver.Namespace = "other"
+		assert.False(t, p.Create(event.CreateEvent{Object: driver}))
+	})
+
+	t.Run("events of the filesystems with volume group snapshots", func(t *testing.T) {
+		fs := &cephv1.CephFilesystem{}
+		p = predicateController(context.TODO(), client, "rook-ceph")
+		assert.False(t, p.Create(event.CreateEvent{Object: fs}))
+
+		CSIParam.EnableVolumeGroupSnapshot = true
+		defer func() { CSIParam.EnableVolumeGroupSnapshot = false }()
+		assert.True(t, p.Create(event.CreateEvent{Object: fs}))
+		assert.True(t, p.Delete(event.DeleteEvent{Object: fs}))
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: fs, ObjectNew: fs.DeepCopy()}))
+	})
 }
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+// DefaultCrushLocationLabels are the node labels from which the crush location of a client is
+// derived when the read affinity is enabled without labels. They match the node labels rook uses
+// to build the CRUSH hierarchy of the OSDs.
+var DefaultCrushLocationLabels = []string{
+	"kubernetes.io/hostname",
+	"topology.kubernetes.io/region",
+	"topology.kubernetes.io/zone",
+	"topology.rook.io/chassis",
+	"topology.rook.io/rack",
+	"topology.rook.io/row",
+	"topology.rook.io/pdu",
+	"topology.rook.io/pod",
+	"topology.rook.io/room",
+	"topology.rook.io/datacenter",
+}
+
+// ReadAffinity returns the read affinity settings of the csi cluster config entry of a cluster,
+// nil if the read affinity is disabled
+func ReadAffinity(spec *cephv1.ClusterSpec) *CsiReadAffinity {
+	if !spec.CSI.ReadAffinity.Enabled {
+		return nil
+	}
+
+	labels := spec.CSI.ReadAffinity.CrushLocationLabels
+	if len(labels) == 0 {
+		labels = DefaultCrushLocationLabels
+	}
+
+	return &CsiReadAffinity{
+		Enabled:             true,
+		CrushLocationLabels: labels,
+	}
+}
+
+// ExternalReadAffinity returns the read affinity settings of the csi cluster config entry of an
+// external cluster. Without crush location labels in the spec, only the labels matching the CRUSH
+// map of the external cluster are used, the imported labels.
+func ExternalReadAffinity(spec *cephv1.ClusterSpec, importedLabels []string) *CsiReadAffinity {
+	readAffinity := ReadAffinity(spec)
+	if readAffinity == nil || len(spec.CSI.ReadAffinity.CrushLocationLabels) != 0 || len(importedLabels) == 0 {
+		return readAffinity
+	}
+	readAffinity.CrushLocationLabels = importedLabels
+	return readAffinity
+}
+
+// ImportCrushLocationLabels returns the node labels of the default crush location labels whose type
+// is the type of a CRUSH bucket of the external cluster, in the order of the default labels
+func ImportCrushLocationLabels(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo) ([]string, error) {
+	crushMap, err := cephclient.GetCrushMap(context, clusterInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the crush map of the external cluster")
+	}
+	return crushLocationLabels(crushMap), nil
+}
+
+func crushLocationLabels(crushMap cephclient.CrushMap) []string {
+	bucketTypes := map[string]bool{}
+	for _, bucket := range crushMap.Buckets {
+		bucketTypes[bucket.TypeName] = true
+	}
+
+	labels := []string{}
+	for _, label := range DefaultCrushLocationLabels {
+		if bucketTypes[crushTypeOfLabel(label)] {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// crushTypeOfLabel returns the CRUSH bucket type rook builds from a node label
+func crushTypeOfLabel(label string) string {
+	if label == "kubernetes.io/hostname" {
+		return "host"
+	}
+	return label[strings.LastIndex(label, "/")+1:]
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ReplicationGroup is the api group of the csi-addons replication resources
+	ReplicationGroup = "replication.storage.openshift.io"
+	// VolumeGroupReplicationKind is the kind of the csi-addons volume group replications
+	VolumeGroupReplicationKind = "VolumeGroupReplication"
+	// VolumeGroupReplicationClassKind is the kind of the csi-addons volume group replication classes
+	VolumeGroupReplicationClassKind    = "VolumeGroupReplicationClass"
+	rbdGroupReplicationClassSuffix     = "rbdplugin-groupreplicationclass"
+	defaultGroupSchedulingInterval     = "5m"
+	groupReplicationSecretNameKey      = "replication.storage.openshift.io/group-replication-secret-name"
+	groupReplicationSecretNamespaceKey = "replication.storage.openshift.io/group-replication-secret-namespace"
+)
+
+// the VolumeGroupReplicationClass versions known to the csi-addons controller, from the most preferred
+var groupReplicationVersions = []string{"v1alpha1"}
+
+// GroupReplicationVersion returns the version of the VolumeGroupReplication API served by the
+// cluster, or an empty string if the VolumeGroupReplication CRDs are not installed
+func GroupReplicationVersion(discoveryClient discovery.DiscoveryInterface) (string, error) {
+	return servedVersion(discoveryClient, ReplicationGroup, groupReplicationVersions)
+}
+
+// RBDGroupReplicationClassName returns the name of the RBD VolumeGroupReplicationClass generated
+// for a block pool of a cluster
+func RBDGroupReplicationClassName(clusterNamespace, poolName string) string {
+	return fmt.Sprintf("%s-%s-%s", clusterNamespace, poolName, rbdGroupReplicationClassSuffix)
+}
+
+func newGroupReplicationClass(version, name string) *unstructured.Unstructured {
+	groupReplicationClass := &unstructured.Unstructured{}
+	groupReplicationClass.SetGroupVersionKind(schema.GroupVersionKind{Group: ReplicationGroup, Version: version, Kind: VolumeGroupReplicationClassKind})
+	groupReplicationClass.SetName(name)
+	return groupReplicationClass
+}
+
+// isGroupReplicatedPool returns whether the volumes of the block pool can be replicated in groups,
+// which requires the mirroring of the images of the pool
+func isGroupReplicatedPool(pool *cephv1.CephBlockPool) bool {
+	return pool.DeletionTimestamp.IsZero() && pool.Spec.Mirroring.Enabled && pool.Spec.Mirroring.Mode == "image"
+}
+
+// blockPoolCephName returns the name of the ceph pool of a block pool
+func blockPoolCephName(pool *cephv1.CephBlockPool) string {
+	if pool.Spec.Name != "" {
+		return pool.Spec.Name
+	}
+	return pool.Name
+}
+
+// setGroupReplicationClassSpec sets the provisioner, pool, snapshot schedule and secrets of an RBD
+// VolumeGroupReplicationClass of the cluster
+func setGroupReplicationClassSpec(groupReplicationClass *unstructured.Unstructured, cluster *cephv1.CephCluster, driverName, poolName string) {
+	labels := groupReplicationClass.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[k8sutil.ClusterAttr] = cluster.Namespace
+	groupReplicationClass.SetLabels(labels)
+
+	replicationClasses := cluster.Spec.CSI.ReplicationClasses
+	schedulingInterval := replicationClasses.SchedulingInterval
+	if schedulingInterval == "" {
+		schedulingInterval = defaultGroupSchedulingInterval
+	}
+	parameters := map[string]interface{}{
+		"clusterID":                        cluster.Namespace,
+		"pool":                             poolName,
+		"mirroringMode":                    "snapshot",
+		"schedulingInterval":               schedulingInterval,
+		groupReplicationSecretNameKey:      CsiRBDProvisionerSecret,
+		groupReplicationSecretNamespaceKey: cluster.Namespace,
+	}
+	if replicationClasses.SchedulingStartTime != "" {
+		parameters["schedulingStartTime"] = replicationClasses.SchedulingStartTime
+	}
+	groupReplicationClass.Object["spec"] = map[string]interface{}{
+		"provisioner": driverName,
+		"parameters":  parameters,
+	}
+}
+
+// configureGroupReplicationClasses creates the RBD VolumeGroupReplicationClasses of the pools
+// mirrored in image mode of the clusters enabling them, and deletes the generated classes that are
+// not needed anymore
+func (r *ReconcileCSI) configureGroupReplicationClasses(clusters []cephv1.CephCluster) error {
+	// the operator is only granted the rbac of the csi-addons resources when csi-addons is enabled
+	if !strings.EqualFold(k8sutil.GetValue(r.opConfig.Parameters, "CSI_ENABLE_CSIADDONS", "false"), "true") {
+		logger.Debug("csi-addons is disabled, not generating volume group replication classes")
+		return nil
+	}
+
+	version, err := GroupReplicationVersion(r.context.Clientset.Discovery())
+	if err != nil {
+		return errors.Wrap(err, "failed to detect the volume group replication class api")
+	}
+	if version == "" {
+		logger.Debug("volume group replication crds not found, not generating volume group replication classes")
+		return nil
+	}
+
+	expected := map[string]bool{}
+	for i := range clusters {
+		cluster := &clusters[i]
+		driverName := RBDDriverNameForCluster(&cluster.Spec)
+		if !EnableRBD || driverName == "" || !cluster.Spec.CSI.ReplicationClasses.Enabled || !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+		pools := &cephv1.CephBlockPoolList{}
+		err = r.client.List(r.opManagerContext, pools, client.InNamespace(cluster.Namespace))
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the block pools of cluster %q", cluster.Namespace)
+		}
+		for j := range pools.Items {
+			pool := &pools.Items[j]
+			if !isGroupReplicatedPool(pool) {
+				continue
+			}
+			name := RBDGroupReplicationClassName(cluster.Namespace, pool.Name)
+			groupReplicationClass := newGroupReplicationClass(version, name)
+			err = r.createOrUpdateClass(groupReplicationClass, cluster, func() {
+				setGroupReplicationClassSpec(groupReplicationClass, cluster, driverName, blockPoolCephName(pool))
+			})
+			if err != nil {
+				return err
+			}
+			expected[name] = true
+		}
+	}
+
+	// Remove the classes of the pools and clusters that were deleted or disabled them
+	return r.deleteStaleClasses(schema.GroupVersionKind{Group: ReplicationGroup, Version: version, Kind: VolumeGroupReplicationClassKind}, expected)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigureGroupReplicationClasses(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	mirrored := cephv1.MirroringSpec{Enabled: true, Mode: "image"}
+	cl := clientfake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Namespace: "rook-ceph"}, Spec: cephv1.NamedBlockPoolSpec{PoolSpec: cephv1.PoolSpec{Mirroring: mirrored}}},
+		&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "renamed", Namespace: "rook-ceph"}, Spec: cephv1.NamedBlockPoolSpec{Name: ".nfs", PoolSpec: cephv1.PoolSpec{Mirroring: mirrored}}},
+		&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "pool-mode", Namespace: "rook-ceph"}, Spec: cephv1.NamedBlockPoolSpec{PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "pool"}}}},
+		&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "not-mirrored", Namespace: "rook-ceph"}},
+		&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "otherpool", Namespace: "other"}, Spec: cephv1.NamedBlockPoolSpec{PoolSpec: cephv1.PoolSpec{Mirroring: mirrored}}},
+	).Build()
+	r := &ReconcileCSI{
+		client:           cl,
+		context:          &clusterd.Context{Clientset: clientset},
+		opManagerContext: ctx,
+		opConfig:         opcontroller.OperatorConfig{Parameters: map[string]string{"CSI_ENABLE_CSIADDONS": "true"}},
+	}
+	EnableRBD = true
+	RBDDriverName = "rook-ceph.rbd.csi.ceph.com"
+	clusters := []cephv1.CephCluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "rook-ceph"},
+			Spec: cephv1.ClusterSpec{
+				CSI: cephv1.CSIDriverSpec{ReplicationClasses: cephv1.ReplicationClassesSpec{Enabled: true, SchedulingInterval: "1h"}},
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}},
+	}
+	getGroupReplicationClass := func(name string) (*unstructured.Unstructured, error) {
+		groupReplicationClass := newGroupReplicationClass("v1alpha1", name)
+		err := cl.Get(ctx, types.NamespacedName{Name: name}, groupReplicationClass)
+		return groupReplicationClass, err
+	}
+
+	t.Run("no replication crds", func(t *testing.T) {
+		assert.NoError(t, r.configureGroupReplicationClasses(clusters))
+		_, err := getGroupReplicationClass(RBDGroupReplicationClassName("rook-ceph", "mirrored"))
+		assert.Error(t, err)
+	})
+
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: "replication.storage.openshift.io/v1alpha1", APIResources: []metav1.APIResource{{Name: "volumegroupreplicationclasses", Kind: "VolumeGroupReplicationClass"}}},
+	}
+
+	t.Run("csi-addons disabled", func(t *testing.T) {
+		r.opConfig.Parameters["CSI_ENABLE_CSIADDONS"] = "false"
+		defer func() { r.opConfig.Parameters["CSI_ENABLE_CSIADDONS"] = "true" }()
+		assert.NoError(t, r.configureGroupReplicationClasses(clusters))
+		_, err := getGroupReplicationClass(RBDGroupReplicationClassName("rook-ceph", "mirrored"))
+		assert.Error(t, err)
+	})
+
+	t.Run("classes are created for the pools mirrored in image mode", func(t *testing.T) {
+		assert.NoError(t, r.configureGroupReplicationClasses(clusters))
+
+		class, err := getGroupReplicationClass("rook-ceph-mirrored-rbdplugin-groupreplicationclass")
+		assert.NoError(t, err)
+		assert.Equal(t, "rook-ceph", class.GetLabels()["rook_cluster"])
+		provisioner, _, _ := unstructured.NestedString(class.Object, "spec", "provisioner")
+		assert.Equal(t, RBDDriverName, provisioner)
+		params, _, _ := unstructured.NestedStringMap(class.Object, "spec", "parameters")
+		assert.Equal(t, "rook-ceph", params["clusterID"])
+		assert.Equal(t, "mirrored", params["pool"])
+		assert.Equal(t, "snapshot", params["mirroringMode"])
+		assert.Equal(t, "1h", params["schedulingInterval"])
+		assert.NotContains(t, params, "schedulingStartTime")
+		assert.Equal(t, CsiRBDProvisionerSecret, params["replication.storage.openshift.io/group-replication-secret-name"])
+		assert.Equal(t, "rook-ceph", params["replication.storage.openshift.io/group-replication-secret-namespace"])
+
+		// the ceph name of the pool is used
+		class, err = getGroupReplicationClass(RBDGroupReplicationClassName("rook-ceph", "renamed"))
+		assert.NoError(t, err)
+		params, _, _ = unstructured.NestedStringMap(class.Object, "spec", "parameters")
+		assert.Equal(t, ".nfs", params["pool"])
+
+		for _, name := range []string{
+			RBDGroupReplicationClassName("rook-ceph", "pool-mode"),
+			RBDGroupReplicationClassName("rook-ceph", "not-mirrored"),
+			RBDGroupReplicationClassName("other", "otherpool"),
+		} {
+			_, err = getGroupReplicationClass(name)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("schedule defaults", func(t *testing.T) {
+		clusters[0].Spec.CSI.ReplicationClasses = cephv1.ReplicationClassesSpec{Enabled: true, SchedulingStartTime: "14:00:00-05:00"}
+		assert.NoError(t, r.configureGroupReplicationClasses(clusters))
+
+		class, err := getGroupReplicationClass("rook-ceph-mirrored-rbdplugin-groupreplicationclass")
+		assert.NoError(t, err)
+		params, _, _ := unstructured.NestedStringMap(class.Object, "spec", "parameters")
+		assert.Equal(t, "5m", params["schedulingInterval"])
+		assert.Equal(t, "14:00:00-05:00", params["schedulingStartTime"])
+	})
+
+	t.Run("classes are removed when disabled", func(t *testing.T) {
+		clusters[0].Spec.CSI.ReplicationClasses.Enabled = false
+		assert.NoError(t, r.configureGroupReplicationClasses(clusters))
+
+		_, err := getGroupReplicationClass("rook-ceph-mirrored-rbdplugin-groupreplicationclass")
+		assert.Error(t, err)
+		_, err = getGroupReplicationClass(RBDGroupReplicationClassName("rook-ceph", "renamed"))
+		assert.Error(t, err)
+	})
+}
@@ -17,12 +17,18 @@ limitations under the License.
 package csi
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -42,8 +48,8 @@ const (
 	CsiCephFSProvisionerSecret          = "rook-csi-cephfs-provisioner"
 )
 
-func createCSIKeyringRBDNode(s *keyring.SecretStore) (string, error) {
-	key, err := s.GenerateKey(csiKeyringRBDNodeUsername, cephCSIKeyringRBDNodeCaps())
+func createCSIKeyringRBDNode(s *keyring.SecretStore, generation int) (string, error) {
+	key, err := s.GenerateKey(csiUsername(csiKeyringRBDNodeUsername, generation), cephCSIKeyringRBDNodeCaps())
 	if err != nil {
 		return "", err
 	}
@@ -51,8 +57,8 @@ func createCSIKeyringRBDNode(s *keyring.SecretStore) (string, error) {
 	return key, nil
 }
 
-func createCSIKeyringRBDProvisioner(s *keyring.SecretStore) (string, error) {
-	key, err := s.GenerateKey(csiKeyringRBDProvisionerUsername, cephCSIKeyringRBDProvisionerCaps())
+func createCSIKeyringRBDProvisioner(s *keyring.SecretStore, generation int) (string, error) {
+	key, err := s.GenerateKey(csiUsername(csiKeyringRBDProvisionerUsername, generation), cephCSIKeyringRBDProvisionerCaps())
 	if err != nil {
 		return "", err
 	}
@@ -60,8 +66,8 @@ func createCSIKeyringRBDProvisioner(s *keyring.SecretStore) (string, error) {
 	return key, nil
 }
 
-func createCSIKeyringCephFSNode(s *keyring.SecretStore) (string, error) {
-	key, err := s.GenerateKey(csiKeyringCephFSNodeUsername, cephCSIKeyringCephFSNodeCaps())
+func createCSIKeyringCephFSNode(s *keyring.SecretStore, generation int) (string, error) {
+	key, err := s.GenerateKey(csiUsername(csiKeyringCephFSNodeUsername, generation), cephCSIKeyringCephFSNodeCaps())
 	if err != nil {
 		return "", err
 	}
@@ -69,8 +75,8 @@ func createCSIKeyringCephFSNode(s *keyring.SecretStore) (string, error) {
 	return key, nil
 }
 
-func createCSIKeyringCephFSProvisioner(s *keyring.SecretStore) (string, error) {
-	key, err := s.GenerateKey(csiKeyringCephFSProvisionerUsername, cephCSIKeyringCephFSProvisionerCaps())
+func createCSIKeyringCephFSProvisioner(s *keyring.SecretStore, generation int) (string, error) {
+	key, err := s.GenerateKey(csiUsername(csiKeyringCephFSProvisionerUsername, generation), cephCSIKeyringCephFSProvisionerCaps())
 	if err != nil {
 		return "", err
 	}
@@ -111,36 +117,54 @@ func cephCSIKeyringCephFSProvisionerCaps() []string {
 	}
 }
 
-func createOrUpdateCSISecret(clusterInfo *client.ClusterInfo, csiRBDProvisionerSecretKey, csiRBDNodeSecretKey, csiCephFSProvisionerSecretKey, csiCephFSNodeSecretKey string, k *keyring.SecretStore) error {
+// csiUsername returns the name of the user of a generation of the keys of a csi driver. The keys are
+// rotated by creating new users so that the volumes mounted with the keys of the previous users can
+// still be unmounted.
+func csiUsername(username string, generation int) string {
+	if generation == 0 {
+		return username
+	}
+	return fmt.Sprintf("%s.%d", username, generation)
+}
+
+// csiUserID returns the id the csi drivers read from the secrets, which is the username without prefix
+func csiUserID(username string, generation int) []byte {
+	return []byte(strings.TrimPrefix(csiUsername(username, generation), "client."))
+}
+
+func createOrUpdateCSISecret(clusterInfo *client.ClusterInfo, generation int, csiRBDProvisionerSecretKey, csiRBDNodeSecretKey, csiCephFSProvisionerSecretKey, csiCephFSNodeSecretKey string, k *keyring.SecretStore) error {
 	csiRBDProvisionerSecrets := map[string][]byte{
 		// userID is expected for the rbd provisioner driver
-		"userID":  []byte("csi-rbd-provisioner"),
+		"userID":  csiUserID(csiKeyringRBDProvisionerUsername, generation),
 		"userKey": []byte(csiRBDProvisionerSecretKey),
 	}
 
 	csiRBDNodeSecrets := map[string][]byte{
 		// userID is expected for the rbd node driver
-		"userID":  []byte("csi-rbd-node"),
+		"userID":  csiUserID(csiKeyringRBDNodeUsername, generation),
 		"userKey": []byte(csiRBDNodeSecretKey),
 	}
 
 	csiCephFSProvisionerSecrets := map[string][]byte{
 		// adminID is expected for the cephfs provisioner driver
-		"adminID":  []byte("csi-cephfs-provisioner"),
+		"adminID":  csiUserID(csiKeyringCephFSProvisionerUsername, generation),
 		"adminKey": []byte(csiCephFSProvisionerSecretKey),
 	}
 
 	csiCephFSNodeSecrets := map[string][]byte{
 		// adminID is expected for the cephfs node driver
-		"adminID":  []byte("csi-cephfs-node"),
+		"adminID":  csiUserID(csiKeyringCephFSNodeUsername, generation),
 		"adminKey": []byte(csiCephFSNodeSecretKey),
 	}
 
 	keyringSecretMap := make(map[string]map[string][]byte)
 	keyringSecretMap[CsiRBDProvisionerSecret] = csiRBDProvisionerSecrets
 	keyringSecretMap[CsiRBDNodeSecret] = csiRBDNodeSecrets
-	keyringSecretMap[CsiCephFSProvisionerSecret] = csiCephFSProvisionerSecrets
-	keyringSecretMap[CsiCephFSNodeSecret] = csiCephFSNodeSecrets
+	// the cephfs users of an external cluster without filesystem are not imported
+	if csiCephFSProvisionerSecretKey != "" && csiCephFSNodeSecretKey != "" {
+		keyringSecretMap[CsiCephFSProvisionerSecret] = csiCephFSProvisionerSecrets
+		keyringSecretMap[CsiCephFSNodeSecret] = csiCephFSNodeSecrets
+	}
 
 	for secretName, secret := range keyringSecretMap {
 		s := &v1.Secret{
@@ -168,38 +192,174 @@ func createOrUpdateCSISecret(clusterInfo *client.ClusterInfo, csiRBDProvisionerS
 	return nil
 }
 
-// CreateCSISecrets creates all the Kubernetes CSI Secrets
-func CreateCSISecrets(context *clusterd.Context, clusterInfo *client.ClusterInfo) error {
+// CreateCSISecrets creates the csi users of a generation of keys and all the Kubernetes CSI Secrets
+// with their keys
+func CreateCSISecrets(context *clusterd.Context, clusterInfo *client.ClusterInfo, generation int) error {
 	k := keyring.GetSecretStore(context, clusterInfo, clusterInfo.OwnerInfo)
 
 	// Create CSI RBD Provisioner Ceph key
-	csiRBDProvisionerSecretKey, err := createCSIKeyringRBDProvisioner(k)
+	csiRBDProvisionerSecretKey, err := createCSIKeyringRBDProvisioner(k, generation)
 	if err != nil {
 		return errors.Wrap(err, "failed to create csi rbd provisioner ceph keyring")
 	}
 
 	// Create CSI RBD Node Ceph key
-	csiRBDNodeSecretKey, err := createCSIKeyringRBDNode(k)
+	csiRBDNodeSecretKey, err := createCSIKeyringRBDNode(k, generation)
 	if err != nil {
 		return errors.Wrap(err, "failed to create csi rbd node ceph keyring")
 	}
 
 	// Create CSI Cephfs provisioner Ceph key
-	csiCephFSProvisionerSecretKey, err := createCSIKeyringCephFSProvisioner(k)
+	csiCephFSProvisionerSecretKey, err := createCSIKeyringCephFSProvisioner(k, generation)
 	if err != nil {
 		return errors.Wrap(err, "failed to create csi cephfs provisioner ceph keyring")
 	}
 
 	// Create CSI Cephfs node Ceph key
-	csiCephFSNodeSecretKey, err := createCSIKeyringCephFSNode(k)
+	csiCephFSNodeSecretKey, err := createCSIKeyringCephFSNode(k, generation)
 	if err != nil {
 		return errors.Wrap(err, "failed to create csi cephfs node ceph keyring")
 	}
 
 	// Create or update Kubernetes CSI secret
-	if err := createOrUpdateCSISecret(clusterInfo, csiRBDProvisionerSecretKey, csiRBDNodeSecretKey, csiCephFSProvisionerSecretKey, csiCephFSNodeSecretKey, k); err != nil {
+	if err := createOrUpdateCSISecret(clusterInfo, generation, csiRBDProvisionerSecretKey, csiRBDNodeSecretKey, csiCephFSProvisionerSecretKey, csiCephFSNodeSecretKey, k); err != nil {
 		return errors.Wrap(err, "failed to create kubernetes csi secret")
 	}
 
 	return nil
 }
+
+// DeleteCSIUsers removes the csi users of the given generations of keys, once the volumes were
+// remounted with the keys of a later generation
+func DeleteCSIUsers(context *clusterd.Context, clusterInfo *client.ClusterInfo, generations []int) error {
+	for _, generation := range generations {
+		for _, username := range []string{csiKeyringRBDProvisionerUsername, csiKeyringRBDNodeUsername, csiKeyringCephFSProvisionerUsername, csiKeyringCephFSNodeUsername} {
+			if err := client.AuthDelete(context, clusterInfo, csiUsername(username, generation)); err != nil {
+				return errors.Wrapf(err, "failed to remove the csi users of key generation %d", generation)
+			}
+		}
+	}
+	return nil
+}
+
+// ImportCSISecrets creates or updates the Kubernetes CSI Secrets with the keys of the csi users that
+// were created in an external cluster
+func ImportCSISecrets(context *clusterd.Context, clusterInfo *client.ClusterInfo, csiRBDProvisionerSecretKey, csiRBDNodeSecretKey, csiCephFSProvisionerSecretKey, csiCephFSNodeSecretKey string) error {
+	k := keyring.GetSecretStore(context, clusterInfo, clusterInfo.OwnerInfo)
+
+	if err := createOrUpdateCSISecret(clusterInfo, 0, csiRBDProvisionerSecretKey, csiRBDNodeSecretKey, csiCephFSProvisionerSecretKey, csiCephFSNodeSecretKey, k); err != nil {
+		return errors.Wrap(err, "failed to import kubernetes csi secret")
+	}
+
+	return nil
+}
+
+// csiUser is a ceph user of a csi driver and the kubernetes secret the driver reads its key from
+type csiUser struct {
+	username   string
+	caps       []string
+	secretName string
+	// the keys of the id and key of the user in the secret
+	idKey, keyKey string
+	enabled       bool
+}
+
+func externalCSIUsers(users *cephv1.ExternalUsersSpec) []csiUser {
+	return []csiUser{
+		{username: csiKeyringRBDProvisionerUsername, caps: cephCSIKeyringRBDProvisionerCaps(), secretName: CsiRBDProvisionerSecret, idKey: "userID", keyKey: "userKey", enabled: users.RBD},
+		{username: csiKeyringRBDNodeUsername, caps: cephCSIKeyringRBDNodeCaps(), secretName: CsiRBDNodeSecret, idKey: "userID", keyKey: "userKey", enabled: users.RBD},
+		{username: csiKeyringCephFSProvisionerUsername, caps: cephCSIKeyringCephFSProvisionerCaps(), secretName: CsiCephFSProvisionerSecret, idKey: "adminID", keyKey: "adminKey", enabled: users.CephFS},
+		{username: csiKeyringCephFSNodeUsername, caps: cephCSIKeyringCephFSNodeCaps(), secretName: CsiCephFSNodeSecret, idKey: "adminID", keyKey: "adminKey", enabled: users.CephFS},
+	}
+}
+
+// ReconcileExternalCSIUsers creates the users of the enabled csi drivers in an external cluster and
+// keeps their caps up to date. The users of the disabled drivers are removed with their secrets.
+func ReconcileExternalCSIUsers(context *clusterd.Context, clusterInfo *client.ClusterInfo, users *cephv1.ExternalUsersSpec) error {
+	k := keyring.GetSecretStore(context, clusterInfo, clusterInfo.OwnerInfo)
+
+	for _, user := range externalCSIUsers(users) {
+		if !user.enabled {
+			if err := removeExternalCSIUser(context, clusterInfo, user); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key, err := k.GenerateKey(user.username, user.caps)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create csi user %q", user.username)
+		}
+		if err := updateCaps(context, clusterInfo, user.username, user.caps); err != nil {
+			return err
+		}
+
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      user.secretName,
+				Namespace: clusterInfo.Namespace,
+				Labels:    map[string]string{k8sutil.ExternalUserLabelKey: "true"},
+			},
+			Data: map[string][]byte{
+				user.idKey:  []byte(strings.TrimPrefix(user.username, "client.")),
+				user.keyKey: []byte(key),
+			},
+			Type: k8sutil.RookType,
+		}
+		err = clusterInfo.OwnerInfo.SetControllerReference(secret)
+		if err != nil {
+			return errors.Wrapf(err, "failed to set owner reference to keyring secret %q", secret.Name)
+		}
+		if err := k.CreateSecret(secret); err != nil {
+			return errors.Wrapf(err, "failed to create kubernetes secret %q for cluster %q", secret.Name, clusterInfo.Namespace)
+		}
+	}
+
+	logger.Infof("reconciled the csi users of external cluster %q", clusterInfo.Namespace)
+	return nil
+}
+
+// updateCaps sets the caps of a user when they differ from the expected caps, so that the caps
+// required by a new version are granted to the existing users
+func updateCaps(context *clusterd.Context, clusterInfo *client.ClusterInfo, username string, caps []string) error {
+	currentCaps, err := client.AuthGetCaps(context, clusterInfo, username)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the caps of user %q", username)
+	}
+	expectedCaps := map[string]string{}
+	for i := 0; i+1 < len(caps); i += 2 {
+		expectedCaps[caps[i]] = caps[i+1]
+	}
+	if reflect.DeepEqual(currentCaps, expectedCaps) {
+		return nil
+	}
+	if err := client.AuthUpdateCaps(context, clusterInfo, username, caps); err != nil {
+		return errors.Wrapf(err, "failed to update the caps of user %q", username)
+	}
+	return nil
+}
+
+// removeExternalCSIUser removes a csi user that was created by the operator, which is known from the
+// label of its secret. The users imported from the external cluster are kept.
+func removeExternalCSIUser(context *clusterd.Context, clusterInfo *client.ClusterInfo, user csiUser) error {
+	secret, err := context.Clientset.CoreV1().Secrets(clusterInfo.Namespace).Get(clusterInfo.Context, user.secretName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get secret %q", user.secretName)
+	}
+	if secret.Labels[k8sutil.ExternalUserLabelKey] != "true" {
+		return nil
+	}
+
+	if err := client.AuthDelete(context, clusterInfo, user.username); err != nil {
+		return errors.Wrapf(err, "failed to remove csi user %q of a disabled driver", user.username)
+	}
+	err = context.Clientset.CoreV1().Secrets(clusterInfo.Namespace).Delete(clusterInfo.Context, user.secretName, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete secret %q", user.secretName)
+	}
+	logger.Infof("removed csi user %q of a disabled driver from external cluster %q", user.username, clusterInfo.Namespace)
+	return nil
+}
@@ -17,9 +17,18 @@ limitations under the License.
 package csi
 
 import (
+	"context"
 	"testing"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestCephCSIKeyringRBDNodeCaps(t *testing.T) {
@@ -41,3 +50,59 @@ func TestCephCSIKeyringCephFSProvisionerCaps(t *testing.T) {
 	caps := cephCSIKeyringCephFSProvisionerCaps()
 	assert.Equal(t, caps, []string{"mon", "allow r", "mgr", "allow rw", "osd", "allow rw tag cephfs metadata=*"})
 }
+
+func TestReconcileExternalCSIUsers(t *testing.T) {
+	deleted := []string{}
+	updatedCaps := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			switch {
+			case args[0] == "auth" && args[1] == "get-or-create-key":
+				return `{"key":"AQDFkbNeft5bFRAATndLNUSEKruozxiZi3lrdA=="}`, nil
+			case args[0] == "auth" && args[1] == "get":
+				// the rbd node user was created with outdated caps
+				if args[2] == csiKeyringRBDNodeUsername {
+					return `[{"caps":{"mon":"profile rbd","osd":"profile rbd"}}]`, nil
+				}
+				return `[{"caps":{"mon":"profile rbd","mgr":"allow rw","osd":"profile rbd"}}]`, nil
+			case args[0] == "auth" && args[1] == "caps":
+				updatedCaps = append(updatedCaps, args[2])
+			case args[0] == "auth" && args[1] == "del":
+				deleted = append(deleted, args[2])
+			}
+			return "", nil
+		},
+	}
+	clientset := test.New(t, 1)
+	c := &clusterd.Context{Executor: executor, Clientset: clientset}
+	clusterInfo := cephclient.AdminTestClusterInfo("external")
+	clusterInfo.OwnerInfo = k8sutil.NewOwnerInfoWithOwnerRef(&metav1.OwnerReference{}, "external")
+	secretExists := func(name string) bool {
+		_, err := clientset.CoreV1().Secrets("external").Get(context.TODO(), name, metav1.GetOptions{})
+		return err == nil
+	}
+
+	// the cephfs secret imported from the external cluster is not managed by the operator
+	imported := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: CsiCephFSNodeSecret, Namespace: "external"}}
+	_, err := clientset.CoreV1().Secrets("external").Create(context.TODO(), imported, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	err = ReconcileExternalCSIUsers(c, clusterInfo, &cephv1.ExternalUsersSpec{RBD: true})
+	assert.NoError(t, err)
+	assert.True(t, secretExists(CsiRBDNodeSecret))
+	assert.True(t, secretExists(CsiRBDProvisionerSecret))
+	assert.True(t, secretExists(CsiCephFSNodeSecret))
+	assert.False(t, secretExists(CsiCephFSProvisionerSecret))
+	assert.Equal(t, []string{csiKeyringRBDNodeUsername}, updatedCaps)
+	assert.Empty(t, deleted)
+
+	// the users of the disabled rbd driver are removed
+	err = ReconcileExternalCSIUsers(c, clusterInfo, &cephv1.ExternalUsersSpec{CephFS: true})
+	assert.NoError(t, err)
+	assert.False(t, secretExists(CsiRBDNodeSecret))
+	assert.False(t, secretExists(CsiRBDProvisionerSecret))
+	assert.ElementsMatch(t, []string{csiKeyringRBDNodeUsername, csiKeyringRBDProvisionerUsername}, deleted)
+	secret, err := clientset.CoreV1().Secrets("external").Get(context.TODO(), CsiCephFSNodeSecret, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "csi-cephfs-node", string(secret.Data["adminID"]))
+}
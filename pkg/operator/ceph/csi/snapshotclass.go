@@ -0,0 +1,296 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	snapshotGroup                  = "snapshot.storage.k8s.io"
+	groupSnapshotGroup             = "groupsnapshot.storage.k8s.io"
+	volumeSnapshotClassKind        = "VolumeSnapshotClass"
+	volumeGroupSnapshotClassKind   = "VolumeGroupSnapshotClass"
+	defaultSnapshotDeletion        = "Delete"
+	rbdSnapshotClassSuffix         = "rbdplugin-snapclass"
+	cephfsSnapshotClassSuffix      = "cephfsplugin-snapclass"
+	cephfsGroupSnapshotClassSuffix = "cephfsplugin-groupsnapclass"
+)
+
+var (
+	// the VolumeSnapshotClass versions known to the csi snapshotter, from the most preferred
+	snapshotVersions = []string{"v1", "v1beta1"}
+	// the VolumeGroupSnapshotClass versions known to the csi snapshotter, from the most preferred
+	groupSnapshotVersions = []string{"v1beta1", "v1alpha1"}
+)
+
+// snapshotClassVersion returns the version of the VolumeSnapshotClass API served by the cluster,
+// or an empty string if the VolumeSnapshot CRDs are not installed
+func snapshotClassVersion(discoveryClient discovery.DiscoveryInterface) (string, error) {
+	return servedVersion(discoveryClient, snapshotGroup, snapshotVersions)
+}
+
+// groupSnapshotClassVersion returns the version of the VolumeGroupSnapshotClass API served by the
+// cluster, or an empty string if the VolumeGroupSnapshot CRDs are not installed
+func groupSnapshotClassVersion(discoveryClient discovery.DiscoveryInterface) (string, error) {
+	return servedVersion(discoveryClient, groupSnapshotGroup, groupSnapshotVersions)
+}
+
+// servedVersion returns the first of the versions of the api group that is served by the cluster
+func servedVersion(discoveryClient discovery.DiscoveryInterface, groupName string, versions []string) (string, error) {
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to discover the server api groups")
+	}
+	for _, group := range groups.Groups {
+		if group.Name != groupName {
+			continue
+		}
+		for _, version := range versions {
+			for _, served := range group.Versions {
+				if served.Version == version {
+					return version, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// RBDSnapshotClassName returns the name of the RBD VolumeSnapshotClass generated for a cluster
+func RBDSnapshotClassName(clusterNamespace string) string {
+	return fmt.Sprintf("%s-%s", clusterNamespace, rbdSnapshotClassSuffix)
+}
+
+// CephFSSnapshotClassName returns the name of the CephFS VolumeSnapshotClass generated for a cluster
+func CephFSSnapshotClassName(clusterNamespace string) string {
+	return fmt.Sprintf("%s-%s", clusterNamespace, cephfsSnapshotClassSuffix)
+}
+
+// CephFSGroupSnapshotClassName returns the name of the CephFS VolumeGroupSnapshotClass generated for
+// a filesystem of a cluster
+func CephFSGroupSnapshotClassName(clusterNamespace, fsName string) string {
+	return fmt.Sprintf("%s-%s-%s", clusterNamespace, fsName, cephfsGroupSnapshotClassSuffix)
+}
+
+func newSnapshotClass(version, name string) *unstructured.Unstructured {
+	snapshotClass := &unstructured.Unstructured{}
+	snapshotClass.SetGroupVersionKind(schema.GroupVersionKind{Group: snapshotGroup, Version: version, Kind: volumeSnapshotClassKind})
+	snapshotClass.SetName(name)
+	return snapshotClass
+}
+
+func newGroupSnapshotClass(version, name string) *unstructured.Unstructured {
+	groupSnapshotClass := &unstructured.Unstructured{}
+	groupSnapshotClass.SetGroupVersionKind(schema.GroupVersionKind{Group: groupSnapshotGroup, Version: version, Kind: volumeGroupSnapshotClassKind})
+	groupSnapshotClass.SetName(name)
+	return groupSnapshotClass
+}
+
+// setSnapshotClassSpec sets the driver, secrets and deletion policy of a VolumeSnapshotClass of the cluster
+func setSnapshotClassSpec(snapshotClass *unstructured.Unstructured, cluster *cephv1.CephCluster, driverName, secretName string) {
+	setClusterClassSpec(snapshotClass, cluster, driverName)
+	snapshotClass.Object["parameters"] = map[string]interface{}{
+		"clusterID": cluster.Namespace,
+		"csi.storage.k8s.io/snapshotter-secret-name":      secretName,
+		"csi.storage.k8s.io/snapshotter-secret-namespace": cluster.Namespace,
+	}
+}
+
+// setGroupSnapshotClassSpec sets the driver, filesystem, secrets and deletion policy of a CephFS
+// VolumeGroupSnapshotClass of the cluster
+func setGroupSnapshotClassSpec(groupSnapshotClass *unstructured.Unstructured, cluster *cephv1.CephCluster, driverName, fsName string) {
+	setClusterClassSpec(groupSnapshotClass, cluster, driverName)
+	groupSnapshotClass.Object["parameters"] = map[string]interface{}{
+		"clusterID": cluster.Namespace,
+		"fsName":    fsName,
+		"csi.storage.k8s.io/group-snapshotter-secret-name":      CsiCephFSProvisionerSecret,
+		"csi.storage.k8s.io/group-snapshotter-secret-namespace": cluster.Namespace,
+	}
+}
+
+// setClusterClassSpec sets the cluster label, driver and deletion policy shared by the snapshot
+// classes and the group snapshot classes of the cluster
+func setClusterClassSpec(class *unstructured.Unstructured, cluster *cephv1.CephCluster, driverName string) {
+	deletionPolicy := cluster.Spec.CSI.SnapshotClasses.DeletionPolicy
+	if deletionPolicy == "" {
+		deletionPolicy = defaultSnapshotDeletion
+	}
+
+	labels := class.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[k8sutil.ClusterAttr] = cluster.Namespace
+	class.SetLabels(labels)
+
+	class.Object["driver"] = driverName
+	class.Object["deletionPolicy"] = deletionPolicy
+}
+
+// configureSnapshotClasses creates the RBD and CephFS VolumeSnapshotClasses of the clusters
+// enabling them, and deletes the generated classes that are not needed anymore
+func (r *ReconcileCSI) configureSnapshotClasses(clusters []cephv1.CephCluster) error {
+	version, err := snapshotClassVersion(r.context.Clientset.Discovery())
+	if err != nil {
+		return errors.Wrap(err, "failed to detect the volume snapshot class api")
+	}
+	if version == "" {
+		logger.Debug("volume snapshot crds not found, not generating volume snapshot classes")
+		return nil
+	}
+
+	expected := map[string]bool{}
+	for i := range clusters {
+		cluster := &clusters[i]
+		if !cluster.Spec.CSI.SnapshotClasses.Enabled || !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if driverName := RBDDriverNameForCluster(&cluster.Spec); EnableRBD && driverName != "" {
+			name := RBDSnapshotClassName(cluster.Namespace)
+			if err := r.createOrUpdateSnapshotClass(version, name, cluster, driverName, CsiRBDProvisionerSecret); err != nil {
+				return err
+			}
+			expected[name] = true
+		}
+		if driverName := CephFSDriverNameForCluster(&cluster.Spec); EnableCephFS && driverName != "" {
+			name := CephFSSnapshotClassName(cluster.Namespace)
+			if err := r.createOrUpdateSnapshotClass(version, name, cluster, driverName, CsiCephFSProvisionerSecret); err != nil {
+				return err
+			}
+			expected[name] = true
+		}
+	}
+
+	// Remove the classes of the clusters that were deleted or disabled them
+	err = r.deleteStaleClasses(schema.GroupVersionKind{Group: snapshotGroup, Version: version, Kind: volumeSnapshotClassKind}, expected)
+	if err != nil {
+		return err
+	}
+
+	return r.configureGroupSnapshotClasses(clusters)
+}
+
+// configureGroupSnapshotClasses creates the CephFS VolumeGroupSnapshotClasses of the filesystems of
+// the clusters enabling the snapshot classes when the volume group snapshots are enabled, and
+// deletes the generated classes that are not needed anymore
+func (r *ReconcileCSI) configureGroupSnapshotClasses(clusters []cephv1.CephCluster) error {
+	version, err := groupSnapshotClassVersion(r.context.Clientset.Discovery())
+	if err != nil {
+		return errors.Wrap(err, "failed to detect the volume group snapshot class api")
+	}
+	if version == "" {
+		logger.Debug("volume group snapshot crds not found, not generating volume group snapshot classes")
+		return nil
+	}
+
+	expected := map[string]bool{}
+	// The RBD group snapshot classes are not generated since they are bound to a pool
+	if CSIParam.EnableVolumeGroupSnapshot && EnableCephFS {
+		for i := range clusters {
+			cluster := &clusters[i]
+			if !cluster.Spec.CSI.SnapshotClasses.Enabled || !cluster.DeletionTimestamp.IsZero() {
+				continue
+			}
+			filesystems := &cephv1.CephFilesystemList{}
+			err = r.client.List(r.opManagerContext, filesystems, client.InNamespace(cluster.Namespace))
+			if err != nil {
+				return errors.Wrapf(err, "failed to list the filesystems of cluster %q", cluster.Namespace)
+			}
+			for _, fs := range filesystems.Items {
+				if !fs.DeletionTimestamp.IsZero() {
+					continue
+				}
+				name := CephFSGroupSnapshotClassName(cluster.Namespace, fs.Name)
+				groupSnapshotClass := newGroupSnapshotClass(version, name)
+				driverName := CephFSDriverNameForCluster(&cluster.Spec)
+				err = r.createOrUpdateClass(groupSnapshotClass, cluster, func() {
+					setGroupSnapshotClassSpec(groupSnapshotClass, cluster, driverName, fs.Name)
+				})
+				if err != nil {
+					return err
+				}
+				expected[name] = true
+			}
+		}
+	}
+
+	// Remove the classes of the filesystems and clusters that were deleted or disabled them
+	return r.deleteStaleClasses(schema.GroupVersionKind{Group: groupSnapshotGroup, Version: version, Kind: volumeGroupSnapshotClassKind}, expected)
+}
+
+func (r *ReconcileCSI) createOrUpdateSnapshotClass(version, name string, cluster *cephv1.CephCluster, driverName, secretName string) error {
+	snapshotClass := newSnapshotClass(version, name)
+	return r.createOrUpdateClass(snapshotClass, cluster, func() {
+		setSnapshotClassSpec(snapshotClass, cluster, driverName, secretName)
+	})
+}
+
+// createOrUpdateClass creates or updates a snapshot class or group snapshot class of the cluster
+func (r *ReconcileCSI) createOrUpdateClass(class *unstructured.Unstructured, cluster *cephv1.CephCluster, setSpec func()) error {
+	kind := class.GetKind()
+	name := class.GetName()
+	op, err := controllerutil.CreateOrUpdate(r.opManagerContext, r.client, class, func() error {
+		// Do not take over a class created by the user with the same name
+		if class.GetResourceVersion() != "" && class.GetLabels()[k8sutil.ClusterAttr] != cluster.Namespace {
+			return errors.Errorf("%s %q already exists and is not managed by cluster %q", kind, name, cluster.Namespace)
+		}
+		setSpec()
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create or update %s %q", kind, name)
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Infof("%s %q %s", kind, name, op)
+	}
+
+	return nil
+}
+
+// deleteStaleClasses deletes the classes of the given kind generated for the clusters that are not expected
+func (r *ReconcileCSI) deleteStaleClasses(gvk schema.GroupVersionKind, expected map[string]bool) error {
+	classes := &unstructured.UnstructuredList{}
+	classes.SetGroupVersionKind(schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind + "List"})
+	err := r.client.List(r.opManagerContext, classes, client.HasLabels{k8sutil.ClusterAttr})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list %s", gvk.Kind)
+	}
+	for i := range classes.Items {
+		class := &classes.Items[i]
+		if expected[class.GetName()] {
+			continue
+		}
+		logger.Infof("deleting %s %q", gvk.Kind, class.GetName())
+		err = r.client.Delete(r.opManagerContext, class)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete %s %q", gvk.Kind, class.GetName())
+		}
+	}
+
+	return nil
+}
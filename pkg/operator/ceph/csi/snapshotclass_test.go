@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigureSnapshotClasses(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	cl := clientfake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	r := &ReconcileCSI{
+		client:           cl,
+		context:          &clusterd.Context{Clientset: clientset},
+		opManagerContext: ctx,
+	}
+	EnableRBD, EnableCephFS = true, true
+	RBDDriverName, CephFSDriverName = "rook-ceph.rbd.csi.ceph.com", "rook-ceph.cephfs.csi.ceph.com"
+	clusters := []cephv1.CephCluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "rook-ceph"},
+			Spec: cephv1.ClusterSpec{
+				CSI: cephv1.CSIDriverSpec{SnapshotClasses: cephv1.SnapshotClassesSpec{Enabled: true, DeletionPolicy: "Retain"}},
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}},
+	}
+	getSnapshotClass := func(name string) (*unstructured.Unstructured, error) {
+		snapshotClass := newSnapshotClass("v1", name)
+		err := cl.Get(ctx, types.NamespacedName{Name: name}, snapshotClass)
+		return snapshotClass, err
+	}
+
+	t.Run("no snapshot crds", func(t *testing.T) {
+		assert.NoError(t, r.configureSnapshotClasses(clusters))
+		_, err := getSnapshotClass(RBDSnapshotClassName("rook-ceph"))
+		assert.Error(t, err)
+	})
+
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: "snapshot.storage.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "volumesnapshotclasses", Kind: "VolumeSnapshotClass"}}},
+	}
+
+	t.Run("snapshot classes are created", func(t *testing.T) {
+		assert.NoError(t, r.configureSnapshotClasses(clusters))
+
+		rbd, err := getSnapshotClass("rook-ceph-rbdplugin-snapclass")
+		assert.NoError(t, err)
+		assert.Equal(t, RBDDriverName, rbd.Object["driver"])
+		assert.Equal(t, "Retain", rbd.Object["deletionPolicy"])
+		params, _, _ := unstructured.NestedStringMap(rbd.Object, "parameters")
+		assert.Equal(t, "rook-ceph", params["clusterID"])
+		assert.Equal(t, CsiRBDProvisionerSecret, params["csi.storage.k8s.io/snapshotter-secret-name"])
+
+		cephfs, err := getSnapshotClass("rook-ceph-cephfsplugin-snapclass")
+		assert.NoError(t, err)
+		assert.Equal(t, CephFSDriverName, cephfs.Object["driver"])
+		params, _, _ = unstructured.NestedStringMap(cephfs.Object, "parameters")
+		assert.Equal(t, CsiCephFSProvisionerSecret, params["csi.storage.k8s.io/snapshotter-secret-name"])
+
+		_, err = getSnapshotClass(RBDSnapshotClassName("other"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rbd disabled and deletion policy changed", func(t *testing.T) {
+		EnableRBD = false
+		clusters[0].Spec.CSI.SnapshotClasses.DeletionPolicy = ""
+		assert.NoError(t, r.configureSnapshotClasses(clusters))
+
+		_, err := getSnapshotClass("rook-ceph-rbdplugin-snapclass")
+		assert.Error(t, err)
+		cephfs, err := getSnapshotClass("rook-ceph-cephfsplugin-snapclass")
+		assert.NoError(t, err)
+		assert.Equal(t, "Delete", cephfs.Object["deletionPolicy"])
+		EnableRBD = true
+	})
+
+	t.Run("snapshot class not managed by the cluster", func(t *testing.T) {
+		snapshotClass := newSnapshotClass("v1", RBDSnapshotClassName("rook-ceph"))
+		snapshotClass.Object["driver"] = "manual"
+		assert.NoError(t, cl.Create(ctx, snapshotClass))
+
+		assert.Error(t, r.configureSnapshotClasses(clusters))
+	})
+
+	t.Run("snapshot classes are removed with the clusters", func(t *testing.T) {
+		assert.NoError(t, r.configureSnapshotClasses(nil))
+
+		snapshotClasses := &unstructured.UnstructuredList{}
+		snapshotClasses.SetAPIVersion("snapshot.storage.k8s.io/v1")
+		snapshotClasses.SetKind("VolumeSnapshotClassList")
+		assert.NoError(t, cl.List(ctx, snapshotClasses, &client.ListOptions{}))
+		// only the class not managed by rook is left
+		assert.Len(t, snapshotClasses.Items, 1)
+		assert.Equal(t, "manual", snapshotClasses.Items[0].Object["driver"])
+	})
+}
+
+func TestConfigureGroupSnapshotClasses(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	cl := clientfake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph"}},
+		&cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "otherfs", Namespace: "other"}},
+	).Build()
+	r := &ReconcileCSI{
+		client:           cl,
+		context:          &clusterd.Context{Clientset: clientset},
+		opManagerContext: ctx,
+	}
+	EnableRBD, EnableCephFS = true, true
+	CSIParam.EnableVolumeGroupSnapshot = true
+	defer func() { CSIParam.EnableVolumeGroupSnapshot = false }()
+	CephFSDriverName = "rook-ceph.cephfs.csi.ceph.com"
+	clusters := []cephv1.CephCluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "rook-ceph"},
+			Spec:       cephv1.ClusterSpec{CSI: cephv1.CSIDriverSpec{SnapshotClasses: cephv1.SnapshotClassesSpec{Enabled: true}}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}},
+	}
+	getGroupSnapshotClass := func(name string) (*unstructured.Unstructured, error) {
+		groupSnapshotClass := newGroupSnapshotClass("v1beta1", name)
+		err := cl.Get(ctx, types.NamespacedName{Name: name}, groupSnapshotClass)
+		return groupSnapshotClass, err
+	}
+
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: "snapshot.storage.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "volumesnapshotclasses", Kind: "VolumeSnapshotClass"}}},
+		{GroupVersion: "groupsnapshot.storage.k8s.io/v1beta1", APIResources: []metav1.APIResource{{Name: "volumegroupsnapshotclasses", Kind: "VolumeGroupSnapshotClass"}}},
+	}
+
+	t.Run("group snapshot classes are created for the filesystems", func(t *testing.T) {
+		assert.NoError(t, r.configureSnapshotClasses(clusters))
+
+		cephfs, err := getGroupSnapshotClass("rook-ceph-myfs-cephfsplugin-groupsnapclass")
+		assert.NoError(t, err)
+		assert.Equal(t, CephFSDriverName, cephfs.Object["driver"])
+		assert.Equal(t, "Delete", cephfs.Object["deletionPolicy"])
+		params, _, _ := unstructured.NestedStringMap(cephfs.Object, "parameters")
+		assert.Equal(t, "rook-ceph", params["clusterID"])
+		assert.Equal(t, "myfs", params["fsName"])
+		assert.Equal(t, CsiCephFSProvisionerSecret, params["csi.storage.k8s.io/group-snapshotter-secret-name"])
+
+		_, err = getGroupSnapshotClass(CephFSGroupSnapshotClassName("other", "otherfs"))
+		assert.Error(t, err)
+	})
+
+	t.Run("group snapshot classes are removed when disabled", func(t *testing.T) {
+		CSIParam.EnableVolumeGroupSnapshot = false
+		assert.NoError(t, r.configureSnapshotClasses(clusters))
+
+		_, err := getGroupSnapshotClass("rook-ceph-myfs-cephfsplugin-groupsnapclass")
+		assert.Error(t, err)
+		// the volume snapshot classes are kept
+		snapshotClass := newSnapshotClass("v1", CephFSSnapshotClassName("rook-ceph"))
+		assert.NoError(t, cl.Get(ctx, types.NamespacedName{Name: snapshotClass.GetName()}, snapshotClass))
+	})
+}
@@ -24,6 +24,7 @@ import (
 	"strings"
 	"time"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/operator/k8sutil/cmdreporter"
 
@@ -37,6 +38,7 @@ import (
 
 type Param struct {
 	CSIPluginImage                 string
+	WindowsCSIPluginImage          string
 	RegistrarImage                 string
 	ProvisionerImage               string
 	AttacherImage                  string
@@ -45,21 +47,29 @@ type Param struct {
 	DriverNamePrefix               string
 	EnableCSIGRPCMetrics           string
 	KubeletDirPath                 string
+	WindowsKubeletDirPath          string
 	ForceCephFSKernelClient        string
 	CephFSPluginUpdateStrategy     string
 	RBDPluginUpdateStrategy        string
+	PluginZoneLabel                string
+	LogRotationMaxSize             string
 	PluginPriorityClassName        string
 	ProvisionerPriorityClassName   string
 	VolumeReplicationImage         string
 	CSIAddonsImage                 string
+	CSIDomainLabels                string
 	EnablePluginSelinuxHostMount   bool
 	EnableCSIHostNetwork           bool
 	EnableOMAPGenerator            bool
 	EnableRBDSnapshotter           bool
 	EnableCephFSSnapshotter        bool
+	EnableVolumeGroupSnapshot      bool
 	EnableVolumeReplicationSideCar bool
 	EnableCSIAddonsSideCar         bool
+	EnableCSITopology              bool
+	EnableRBDWindowsPlugin         bool
 	MountCustomCephConf            bool
+	EnableLogRotation              bool
 	LogLevel                       uint8
 	CephFSGRPCMetricsPort          uint16
 	CephFSLivenessMetricsPort      uint16
@@ -67,8 +77,14 @@ type Param struct {
 	CSIAddonsPort                  uint16
 	RBDLivenessMetricsPort         uint16
 	ProvisionerReplicas            int32
+	PluginMaxUnavailablePerZone    int
+	LogRotationMaxAge              int
+	ProvisionerLogLevels           map[string]uint8
+	PluginLogLevels                map[string]uint8
 	CSICephFSPodLabels             map[string]string
 	CSIRBDPodLabels                map[string]string
+	ArchPluginImages               map[string]string
+	ArchRegistrarImages            map[string]string
 }
 
 type templateParam struct {
@@ -115,6 +131,8 @@ var (
 	// Local package template path for RBD
 	//go:embed template/rbd/csi-rbdplugin.yaml
 	RBDPluginTemplatePath string
+	//go:embed template/rbd/csi-rbdplugin-windows.yaml
+	RBDWindowsPluginTemplatePath string
 	//go:embed template/rbd/csi-rbdplugin-provisioner-dep.yaml
 	RBDProvisionerDepTemplatePath string
 	//go:embed template/rbd/csi-rbdplugin-svc.yaml
@@ -162,6 +180,8 @@ const (
 
 	// kubelet directory path
 	DefaultKubeletDirPath = "/var/lib/kubelet"
+	// kubelet directory path of the windows nodes
+	DefaultWindowsKubeletDirPath = `C:\var\lib\kubelet`
 
 	// grpc metrics and liveness port for cephfs  and rbd
 	DefaultCephFSGRPCMerticsPort     uint16 = 9091
@@ -182,8 +202,9 @@ const (
 	onDelete      = "OnDelete"
 
 	// driver daemonset names
-	csiRBDPlugin    = "csi-rbdplugin"
-	csiCephFSPlugin = "csi-cephfsplugin"
+	csiRBDPlugin        = "csi-rbdplugin"
+	csiRBDWindowsPlugin = "csi-rbdplugin-windows"
+	csiCephFSPlugin     = "csi-cephfsplugin"
 
 	// driver deployment names
 	csiRBDProvisioner    = "csi-rbdplugin-provisioner"
@@ -207,17 +228,15 @@ func validateCSIParam() error {
 	if len(CSIParam.AttacherImage) == 0 {
 		return errors.New("missing csi attacher image")
 	}
+	if CSIParam.EnableRBDWindowsPlugin && len(CSIParam.WindowsCSIPluginImage) == 0 {
+		return errors.New("missing csi windows plugin image, 'ROOK_CSI_WINDOWS_CEPH_IMAGE' must be set to deploy the rbd windows plugin")
+	}
 
 	return nil
 }
 
-func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerInfo, v *CephCSIVersion) error {
-	var (
-		err                                                   error
-		rbdPlugin, cephfsPlugin                               *apps.DaemonSet
-		rbdProvisionerDeployment, cephfsProvisionerDeployment *apps.Deployment
-		rbdService, cephfsService                             *corev1.Service
-	)
+func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerInfo, v *CephCSIVersion, cephClusters []cephv1.CephCluster) error {
+	var err error
 
 	tp := templateParam{
 		Param:     CSIParam,
@@ -308,6 +327,14 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		tp.EnableCephFSSnapshotter = false
 	}
 
+	if tp.EnableVolumeGroupSnapshot {
+		if !tp.EnableRBDSnapshotter && !tp.EnableCephFSSnapshotter {
+			logger.Warning("volume group snapshots are enabled but the csi snapshotters are disabled")
+		}
+		// The group snapshots of RBD volumes rely on the omap mapping of the PVs to their RBD images
+		tp.EnableOMAPGenerator = true
+	}
+
 	tp.EnableVolumeReplicationSideCar = false
 	if strings.EqualFold(k8sutil.GetValue(r.opConfig.Parameters, "CSI_ENABLE_VOLUME_REPLICATION", "false"), "true") {
 		tp.EnableVolumeReplicationSideCar = true
@@ -318,6 +345,12 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		tp.EnableCSIAddonsSideCar = true
 	}
 
+	tp.CSIDomainLabels, err = r.getTopologyDomainLabels()
+	if err != nil {
+		return errors.Wrap(err, "failed to get csi topology domain labels")
+	}
+	tp.EnableCSITopology = tp.CSIDomainLabels != ""
+
 	if strings.EqualFold(k8sutil.GetValue(r.opConfig.Parameters, "CSI_CEPHFS_PLUGIN_UPDATE_STRATEGY", rollingUpdate), onDelete) {
 		tp.CephFSPluginUpdateStrategy = onDelete
 	} else {
@@ -330,14 +363,23 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		tp.RBDPluginUpdateStrategy = rollingUpdate
 	}
 
+	// The operator restarts the plugin pods itself, zone after zone
+	tp.PluginMaxUnavailablePerZone, err = strconv.Atoi(k8sutil.GetValue(r.opConfig.Parameters, "CSI_PLUGIN_MAX_UNAVAILABLE_PER_ZONE", "0"))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse value for 'CSI_PLUGIN_MAX_UNAVAILABLE_PER_ZONE'")
+	}
+	if tp.PluginMaxUnavailablePerZone > 0 {
+		tp.CephFSPluginUpdateStrategy = onDelete
+		tp.RBDPluginUpdateStrategy = onDelete
+	}
+	tp.PluginZoneLabel = k8sutil.GetValue(r.opConfig.Parameters, "CSI_PLUGIN_ZONE_LABEL", corev1.LabelTopologyZone)
+
 	if strings.EqualFold(k8sutil.GetValue(r.opConfig.Parameters, "CSI_PLUGIN_ENABLE_SELINUX_HOST_MOUNT", "false"), "true") {
 		tp.EnablePluginSelinuxHostMount = true
 	}
 
 	logger.Infof("Kubernetes version is %s.%s", ver.Major, ver.Minor)
 
-	tp.ResizerImage = k8sutil.GetValue(r.opConfig.Parameters, "ROOK_CSI_RESIZER_IMAGE", DefaultResizerImage)
-
 	logLevel := k8sutil.GetValue(r.opConfig.Parameters, "CSI_LOG_LEVEL", "")
 	tp.LogLevel = defaultLogLevel
 	if logLevel != "" {
@@ -348,6 +390,18 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 			tp.LogLevel = uint8(l)
 		}
 	}
+	// The log levels of single containers, to debug a container without flooding the logs of the others
+	tp.ProvisionerLogLevels, err = parseContainerLogLevels(r.opConfig.Parameters, "CSI_PROVISIONER_LOG_LEVELS")
+	if err != nil {
+		return err
+	}
+	tp.PluginLogLevels, err = parseContainerLogLevels(r.opConfig.Parameters, "CSI_PLUGIN_LOG_LEVELS")
+	if err != nil {
+		return err
+	}
+	if err = parseLogRotation(r.opConfig.Parameters, &tp); err != nil {
+		return err
+	}
 
 	if CustomCSICephConfigExists {
 		tp.MountCustomCephConf = v.SupportsCustomCephConf()
@@ -370,6 +424,46 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		logger.Errorf("failed to get nodes. Defaulting the number of replicas of provisioner pods to %d. %v", tp.ProvisionerReplicas, err)
 	}
 
+	if err = validateDedicatedDrivers(cephClusters, r.opConfig.OperatorNamespace, tp.EnableCSIHostNetwork); err != nil {
+		return errors.Wrap(err, "invalid dedicated csi drivers")
+	}
+
+	if err = r.deployDrivers(tp, ownerInfo, nil); err != nil {
+		return err
+	}
+
+	for _, cluster := range cephClusters {
+		if cluster.Spec.CSI.DriverNamePrefix == "" {
+			continue
+		}
+		if err = r.deployDrivers(dedicatedDriverParam(tp, &cluster.Spec), ownerInfo, &cluster.Spec.CSI); err != nil {
+			return errors.Wrapf(err, "failed to start the dedicated csi drivers of cluster %q", cluster.Namespace)
+		}
+	}
+
+	return nil
+}
+
+// deployDrivers creates or updates the rbd and cephfs drivers. The drivers dedicated to a cluster
+// are given the csi settings of the cluster, and their resources are prefixed by their driver name prefix.
+func (r *ReconcileCSI) deployDrivers(tp templateParam, ownerInfo *k8sutil.OwnerInfo, clusterCSI *cephv1.CSIDriverSpec) error {
+	var (
+		err                                                   error
+		rbdPlugin, rbdWindowsPlugin, cephfsPlugin             *apps.DaemonSet
+		rbdArchPlugins, cephfsArchPlugins                     []*apps.DaemonSet
+		rbdProvisionerDeployment, cephfsProvisionerDeployment *apps.Deployment
+		rbdService, cephfsService                             *corev1.Service
+		prefix                                                string
+		provisionerScheduling, pluginScheduling               *cephv1.CSIPodSchedulingSpec
+	)
+	if clusterCSI != nil {
+		prefix = clusterCSI.DriverNamePrefix
+		provisionerScheduling = &clusterCSI.Provisioner
+		pluginScheduling = &clusterCSI.Plugin
+	}
+	rbdDriverName := tp.DriverNamePrefix + "rbd.csi.ceph.com"
+	cephFSDriverName := tp.DriverNamePrefix + "cephfs.csi.ceph.com"
+
 	if EnableRBD {
 		rbdPlugin, err = templateToDaemonSet("rbdplugin", RBDPluginTemplatePath, tp)
 		if err != nil {
@@ -386,6 +480,11 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 			return errors.Wrap(err, "failed to load rbd plugin service template")
 		}
 		rbdService.Namespace = r.opConfig.OperatorNamespace
+		if prefix != "" {
+			setDedicatedDaemonSet(prefix, rbdPlugin)
+			setDedicatedDeployment(prefix, rbdProvisionerDeployment)
+			setDedicatedService(prefix, rbdService)
+		}
 	}
 	if EnableCephFS {
 		cephfsPlugin, err = templateToDaemonSet("cephfsplugin", CephFSPluginTemplatePath, tp)
@@ -403,6 +502,11 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 			return errors.Wrap(err, "failed to load cephfs plugin service template")
 		}
 		cephfsService.Namespace = r.opConfig.OperatorNamespace
+		if prefix != "" {
+			setDedicatedDaemonSet(prefix, cephfsPlugin)
+			setDedicatedDeployment(prefix, cephfsProvisionerDeployment)
+			setDedicatedService(prefix, cephfsService)
+		}
 	}
 
 	// get common provisioner tolerations and node affinity
@@ -418,7 +522,13 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		rbdPluginNodeAffinity := getNodeAffinity(r.opConfig.Parameters, rbdPluginNodeAffinityEnv, pluginNodeAffinity)
 		// apply RBD plugin tolerations and node affinity
 		applyToPodSpec(&rbdPlugin.Spec.Template.Spec, rbdPluginNodeAffinity, rbdPluginTolerations)
+		applyCSIComponentPlacement(r.pluginSpec(), &rbdPlugin.Spec.Template.Spec)
+		applyCSIPodScheduling(pluginScheduling, &rbdPlugin.Spec.Template.Spec)
+		if tp.EnableRBDWindowsPlugin {
+			setPluginNodeOS(&rbdPlugin.Spec.Template.Spec, linuxNodeOS)
+		}
 		// apply resource request and limit to rbdplugin containers
+		applyCSILogging(&rbdPlugin.Spec.Template.Spec, rbdPlugin.Spec.Template.Labels, tp.PluginLogLevels, tp)
 		applyResourcesToContainers(r.opConfig.Parameters, rbdPluginResource, &rbdPlugin.Spec.Template.Spec)
 		err = ownerInfo.SetControllerReference(rbdPlugin)
 		if err != nil {
@@ -431,11 +541,31 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		if multusApplied {
 			rbdPlugin.Spec.Template.Spec.HostNetwork = false
 		}
-		err = k8sutil.CreateDaemonSet(r.opManagerContext, csiRBDPlugin, r.opConfig.OperatorNamespace, r.context.Clientset, rbdPlugin)
+		rbdArchPlugins = archPluginDaemonSets(rbdPlugin, tp)
+		excludeNodeArchs(&rbdPlugin.Spec.Template.Spec, pluginArchs(tp))
+		if tp.PluginMaxUnavailablePerZone > 0 {
+			if err = setPluginTemplateHash(rbdPlugin); err != nil {
+				return err
+			}
+		}
+		err = k8sutil.CreateDaemonSet(r.opManagerContext, rbdPlugin.Name, r.opConfig.OperatorNamespace, r.context.Clientset, rbdPlugin)
 		if err != nil {
 			return errors.Wrapf(err, "failed to start rbdplugin daemonset %q", rbdPlugin.Name)
 		}
 		k8sutil.AddRookVersionLabelToDaemonSet(rbdPlugin)
+		if err = r.createArchPlugins(rbdArchPlugins, tp); err != nil {
+			return err
+		}
+		if err = r.deleteArchPlugins(rbdPlugin.Name, pluginArchs(tp)); err != nil {
+			return err
+		}
+
+		if tp.EnableRBDWindowsPlugin {
+			rbdWindowsPlugin, err = r.deployRBDWindowsPlugin(tp, ownerInfo, prefix, pluginScheduling)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	if rbdProvisionerDeployment != nil {
@@ -444,14 +574,21 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		rbdProvisionerNodeAffinity := getNodeAffinity(r.opConfig.Parameters, rbdProvisionerNodeAffinityEnv, provisionerNodeAffinity)
 		// apply RBD provisioner tolerations and node affinity
 		applyToPodSpec(&rbdProvisionerDeployment.Spec.Template.Spec, rbdProvisionerNodeAffinity, rbdProvisionerTolerations)
+		applyCSIComponentPlacement(r.provisionerSpec(), &rbdProvisionerDeployment.Spec.Template.Spec)
+		applyCSIPodScheduling(provisionerScheduling, &rbdProvisionerDeployment.Spec.Template.Spec)
+		excludeNodeArchs(&rbdProvisionerDeployment.Spec.Template.Spec, pluginArchs(tp))
 		// apply resource request and limit to rbd provisioner containers
+		applyCSILogging(&rbdProvisionerDeployment.Spec.Template.Spec, rbdProvisionerDeployment.Spec.Template.Labels, tp.ProvisionerLogLevels, tp)
 		applyResourcesToContainers(r.opConfig.Parameters, rbdProvisionerResource, &rbdProvisionerDeployment.Spec.Template.Spec)
 		err = ownerInfo.SetControllerReference(rbdProvisionerDeployment)
 		if err != nil {
 			return errors.Wrapf(err, "failed to set owner reference to rbd provisioner deployment %q", rbdProvisionerDeployment.Name)
 		}
-		antiAffinity := GetPodAntiAffinity("app", csiRBDProvisioner)
-		rbdProvisionerDeployment.Spec.Template.Spec.Affinity.PodAntiAffinity = &antiAffinity
+		// Spread the provisioners on different nodes unless the anti-affinity is configured
+		if rbdProvisionerDeployment.Spec.Template.Spec.Affinity.PodAntiAffinity == nil {
+			antiAffinity := GetPodAntiAffinity("app", rbdProvisionerDeployment.Spec.Template.Labels["app"])
+			rbdProvisionerDeployment.Spec.Template.Spec.Affinity.PodAntiAffinity = &antiAffinity
+		}
 		rbdProvisionerDeployment.Spec.Strategy = apps.DeploymentStrategy{
 			Type: apps.RecreateDeploymentStrategyType,
 		}
@@ -465,7 +602,7 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 			return errors.Wrapf(err, "failed to start rbd provisioner deployment %q", rbdProvisionerDeployment.Name)
 		}
 		k8sutil.AddRookVersionLabelToDeployment(rbdProvisionerDeployment)
-		logger.Info("successfully started CSI Ceph RBD driver")
+		logger.Infof("successfully started CSI Ceph RBD driver %q", rbdDriverName)
 	}
 
 	if rbdService != nil {
@@ -486,7 +623,10 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		cephFSPluginNodeAffinity := getNodeAffinity(r.opConfig.Parameters, cephFSPluginNodeAffinityEnv, pluginNodeAffinity)
 		// apply CephFS plugin tolerations and node affinity
 		applyToPodSpec(&cephfsPlugin.Spec.Template.Spec, cephFSPluginNodeAffinity, cephFSPluginTolerations)
+		applyCSIComponentPlacement(r.pluginSpec(), &cephfsPlugin.Spec.Template.Spec)
+		applyCSIPodScheduling(pluginScheduling, &cephfsPlugin.Spec.Template.Spec)
 		// apply resource request and limit to cephfs plugin containers
+		applyCSILogging(&cephfsPlugin.Spec.Template.Spec, cephfsPlugin.Spec.Template.Labels, tp.PluginLogLevels, tp)
 		applyResourcesToContainers(r.opConfig.Parameters, cephFSPluginResource, &cephfsPlugin.Spec.Template.Spec)
 		err = ownerInfo.SetControllerReference(cephfsPlugin)
 		if err != nil {
@@ -499,11 +639,24 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		if multusApplied {
 			cephfsPlugin.Spec.Template.Spec.HostNetwork = false
 		}
-		err = k8sutil.CreateDaemonSet(r.opManagerContext, csiCephFSPlugin, r.opConfig.OperatorNamespace, r.context.Clientset, cephfsPlugin)
+		cephfsArchPlugins = archPluginDaemonSets(cephfsPlugin, tp)
+		excludeNodeArchs(&cephfsPlugin.Spec.Template.Spec, pluginArchs(tp))
+		if tp.PluginMaxUnavailablePerZone > 0 {
+			if err = setPluginTemplateHash(cephfsPlugin); err != nil {
+				return err
+			}
+		}
+		err = k8sutil.CreateDaemonSet(r.opManagerContext, cephfsPlugin.Name, r.opConfig.OperatorNamespace, r.context.Clientset, cephfsPlugin)
 		if err != nil {
 			return errors.Wrapf(err, "failed to start cephfs plugin daemonset %q", cephfsPlugin.Name)
 		}
 		k8sutil.AddRookVersionLabelToDaemonSet(cephfsPlugin)
+		if err = r.createArchPlugins(cephfsArchPlugins, tp); err != nil {
+			return err
+		}
+		if err = r.deleteArchPlugins(cephfsPlugin.Name, pluginArchs(tp)); err != nil {
+			return err
+		}
 	}
 
 	if cephfsProvisionerDeployment != nil {
@@ -512,15 +665,22 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 		cephFSProvisionerNodeAffinity := getNodeAffinity(r.opConfig.Parameters, cephFSProvisionerNodeAffinityEnv, provisionerNodeAffinity)
 		// apply CephFS provisioner tolerations and node affinity
 		applyToPodSpec(&cephfsProvisionerDeployment.Spec.Template.Spec, cephFSProvisionerNodeAffinity, cephFSProvisionerTolerations)
+		applyCSIComponentPlacement(r.provisionerSpec(), &cephfsProvisionerDeployment.Spec.Template.Spec)
+		applyCSIPodScheduling(provisionerScheduling, &cephfsProvisionerDeployment.Spec.Template.Spec)
+		excludeNodeArchs(&cephfsProvisionerDeployment.Spec.Template.Spec, pluginArchs(tp))
 		// get resource details for cephfs provisioner
 		// apply resource request and limit to cephfs provisioner containers
+		applyCSILogging(&cephfsProvisionerDeployment.Spec.Template.Spec, cephfsProvisionerDeployment.Spec.Template.Labels, tp.ProvisionerLogLevels, tp)
 		applyResourcesToContainers(r.opConfig.Parameters, cephFSProvisionerResource, &cephfsProvisionerDeployment.Spec.Template.Spec)
 		err = ownerInfo.SetControllerReference(cephfsProvisionerDeployment)
 		if err != nil {
 			return errors.Wrapf(err, "failed to set owner reference to cephfs provisioner deployment %q", cephfsProvisionerDeployment.Name)
 		}
-		antiAffinity := GetPodAntiAffinity("app", csiCephFSProvisioner)
-		cephfsProvisionerDeployment.Spec.Template.Spec.Affinity.PodAntiAffinity = &antiAffinity
+		// Spread the provisioners on different nodes unless the anti-affinity is configured
+		if cephfsProvisionerDeployment.Spec.Template.Spec.Affinity.PodAntiAffinity == nil {
+			antiAffinity := GetPodAntiAffinity("app", cephfsProvisionerDeployment.Spec.Template.Labels["app"])
+			cephfsProvisionerDeployment.Spec.Template.Spec.Affinity.PodAntiAffinity = &antiAffinity
+		}
 		cephfsProvisionerDeployment.Spec.Strategy = apps.DeploymentStrategy{
 			Type: apps.RecreateDeploymentStrategyType,
 		}
@@ -534,7 +694,7 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 			return errors.Wrapf(err, "failed to start cephfs provisioner deployment %q", cephfsProvisionerDeployment.Name)
 		}
 		k8sutil.AddRookVersionLabelToDeployment(cephfsProvisionerDeployment)
-		logger.Info("successfully started CSI CephFS driver")
+		logger.Infof("successfully started CSI CephFS driver %q", cephFSDriverName)
 	}
 	if cephfsService != nil {
 		err = ownerInfo.SetControllerReference(cephfsService)
@@ -548,22 +708,53 @@ func (r *ReconcileCSI) startDrivers(ver *version.Info, ownerInfo *k8sutil.OwnerI
 	}
 
 	if EnableRBD {
-		err = csiDriverobj.createCSIDriverInfo(r.opManagerContext, r.context.Clientset, RBDDriverName, k8sutil.GetValue(r.opConfig.Parameters, "CSI_RBD_FSGROUPPOLICY", string(k8scsi.ReadWriteOnceWithFSTypeFSGroupPolicy)))
+		err = csiDriverobj.createCSIDriverInfo(r.opManagerContext, r.context.Clientset, rbdDriverName, k8sutil.GetValue(r.opConfig.Parameters, "CSI_RBD_FSGROUPPOLICY", string(k8scsi.ReadWriteOnceWithFSTypeFSGroupPolicy)))
 		if err != nil {
-			return errors.Wrapf(err, "failed to create CSI driver object for %q", RBDDriverName)
+			return errors.Wrapf(err, "failed to create CSI driver object for %q", rbdDriverName)
 		}
 	}
 	if EnableCephFS {
-		err = csiDriverobj.createCSIDriverInfo(r.opManagerContext, r.context.Clientset, CephFSDriverName, k8sutil.GetValue(r.opConfig.Parameters, "CSI_CEPHFS_FSGROUPPOLICY", string(k8scsi.ReadWriteOnceWithFSTypeFSGroupPolicy)))
+		err = csiDriverobj.createCSIDriverInfo(r.opManagerContext, r.context.Clientset, cephFSDriverName, k8sutil.GetValue(r.opConfig.Parameters, "CSI_CEPHFS_FSGROUPPOLICY", string(k8scsi.ReadWriteOnceWithFSTypeFSGroupPolicy)))
 		if err != nil {
-			return errors.Wrapf(err, "failed to create CSI driver object for %q", CephFSDriverName)
+			return errors.Wrapf(err, "failed to create CSI driver object for %q", cephFSDriverName)
+		}
+	}
+
+	// The plugins are restarted last, since their roll waits for the plugin of each node
+	if tp.PluginMaxUnavailablePerZone > 0 {
+		if rbdPlugin != nil {
+			if err = r.rollPluginDaemonSet(rbdPlugin, rbdDriverName, tp.PluginMaxUnavailablePerZone, tp.PluginZoneLabel); err != nil {
+				return errors.Wrapf(err, "failed to update rbd plugin daemonset %q", rbdPlugin.Name)
+			}
+		}
+		if rbdWindowsPlugin != nil {
+			if err = r.rollPluginDaemonSet(rbdWindowsPlugin, rbdDriverName, tp.PluginMaxUnavailablePerZone, tp.PluginZoneLabel); err != nil {
+				return errors.Wrapf(err, "failed to update rbd windows plugin daemonset %q", rbdWindowsPlugin.Name)
+			}
+		}
+		for _, ds := range rbdArchPlugins {
+			if err = r.rollPluginDaemonSet(ds, rbdDriverName, tp.PluginMaxUnavailablePerZone, tp.PluginZoneLabel); err != nil {
+				return errors.Wrapf(err, "failed to update rbd plugin daemonset %q", ds.Name)
+			}
+		}
+		if cephfsPlugin != nil {
+			if err = r.rollPluginDaemonSet(cephfsPlugin, cephFSDriverName, tp.PluginMaxUnavailablePerZone, tp.PluginZoneLabel); err != nil {
+				return errors.Wrapf(err, "failed to update cephfs plugin daemonset %q", cephfsPlugin.Name)
+			}
+		}
+		for _, ds := range cephfsArchPlugins {
+			if err = r.rollPluginDaemonSet(ds, cephFSDriverName, tp.PluginMaxUnavailablePerZone, tp.PluginZoneLabel); err != nil {
+				return errors.Wrapf(err, "failed to update cephfs plugin daemonset %q", ds.Name)
+			}
 		}
 	}
 
 	return nil
 }
 
-func (r *ReconcileCSI) stopDrivers(ver *version.Info) error {
+// stopDrivers removes the drivers that are disabled, and the dedicated drivers of the clusters
+// that do not exist or do not use them anymore
+func (r *ReconcileCSI) stopDrivers(ver *version.Info, cephClusters []cephv1.CephCluster) error {
 	RBDDriverName = fmt.Sprintf("%s.rbd.csi.ceph.com", r.opConfig.OperatorNamespace)
 	CephFSDriverName = fmt.Sprintf("%s.cephfs.csi.ceph.com", r.opConfig.OperatorNamespace)
 
@@ -573,19 +764,31 @@ func (r *ReconcileCSI) stopDrivers(ver *version.Info) error {
 		if err != nil {
 			return errors.Wrap(err, "failed to remove CSI Ceph RBD driver")
 		}
+		if err = r.deleteArchPlugins(csiRBDPlugin, nil); err != nil {
+			return errors.Wrap(err, "failed to remove CSI Ceph RBD plugins of the node architectures")
+		}
 		logger.Info("successfully removed CSI Ceph RBD driver")
 	}
 
+	if !EnableRBD || !CSIParam.EnableRBDWindowsPlugin {
+		if err := r.deleteRBDWindowsPlugin(""); err != nil {
+			return errors.Wrap(err, "failed to remove CSI Ceph RBD windows plugin")
+		}
+	}
+
 	if !EnableCephFS {
 		logger.Info("CSI CephFS driver disabled")
 		err := r.deleteCSIDriverResources(ver, csiCephFSPlugin, csiCephFSProvisioner, "csi-cephfsplugin-metrics", CephFSDriverName)
 		if err != nil {
 			return errors.Wrap(err, "failed to remove CSI CephFS driver")
 		}
+		if err = r.deleteArchPlugins(csiCephFSPlugin, nil); err != nil {
+			return errors.Wrap(err, "failed to remove CSI CephFS plugins of the node architectures")
+		}
 		logger.Info("successfully removed CSI CephFS driver")
 	}
 
-	return nil
+	return r.stopDedicatedDrivers(ver, cephClusters)
 }
 
 func (r *ReconcileCSI) deleteCSIDriverResources(ver *version.Info, daemonset, deployment, service, driverName string) error {
@@ -664,6 +867,7 @@ func (r *ReconcileCSI) validateCSIVersion(ownerInfo *k8sutil.OwnerInfo) (*CephCS
 	job.Spec.Template.Spec.Affinity = &corev1.Affinity{
 		NodeAffinity: getNodeAffinity(r.opConfig.Parameters, provisionerNodeAffinityEnv, &corev1.NodeAffinity{}),
 	}
+	applyCSIComponentPlacement(r.provisionerSpec(), &job.Spec.Template.Spec)
 	stdout, _, retcode, err := versionReporter.Run(r.opManagerContext, timeout)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to complete ceph CSI version job")
@@ -0,0 +1,349 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package staticvolume to generate the static PersistentVolumes of existing RBD images and CephFS subvolumes
+package staticvolume
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-static-volume-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephStaticVolumeKind = reflect.TypeOf(cephv1.CephStaticVolume{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephStaticVolumeKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephStaticVolume reconciles a CephStaticVolume object
+type ReconcileCephStaticVolume struct {
+	client           client.Client
+	scheme           *runtime.Scheme
+	context          *clusterd.Context
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+}
+
+// Add creates a new CephStaticVolume Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	return add(mgr, newReconciler(mgr, context, opManagerContext))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context) reconcile.Reconciler {
+	return &ReconcileCephStaticVolume{
+		client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephStaticVolume{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephStaticVolume{}, reconciler)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephStaticVolume CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephStaticVolume{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephStaticVolume object and makes changes based on the state read
+// and what is in the CephStaticVolume.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephStaticVolume) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, cephStaticVolume, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephStaticVolume, reconcileResponse, err)
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephStaticVolume) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephStaticVolume, error) {
+	// Fetch the CephStaticVolume instance
+	cephStaticVolume := &cephv1.CephStaticVolume{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephStaticVolume)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephStaticVolume resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, cephStaticVolume, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, cephStaticVolume, errors.Wrap(err, "failed to get cephStaticVolume")
+	}
+
+	// No finalizer is needed, the PersistentVolume is kept when the CR is deleted since it may still
+	// be bound to a claim
+	if !cephStaticVolume.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, cephStaticVolume, nil
+	}
+
+	// The CR was just created, initializing status fields
+	if cephStaticVolume.Status == nil {
+		r.updateStatus(request.NamespacedName, &cephv1.CephStaticVolumeStatus{Phase: cephv1.ConditionProgressing})
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	cephCluster, isReadyToReconcile, _, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, cephStaticVolume, nil
+	}
+
+	err = ValidateStaticVolume(cephStaticVolume)
+	if err != nil {
+		r.updateFailedStatus(request.NamespacedName, err)
+		return reconcile.Result{}, cephStaticVolume, errors.Wrapf(err, "invalid static volume %q", request.NamespacedName)
+	}
+
+	// The PersistentVolume references the driver of this operator, which is only known once the csi
+	// controller has started the drivers
+	var driverName string
+	if cephStaticVolume.Spec.RBD != nil {
+		driverName = csi.RBDDriverNameForCluster(&cephCluster.Spec)
+	} else {
+		driverName = csi.CephFSDriverNameForCluster(&cephCluster.Spec)
+	}
+	if driverName == "" {
+		logger.Infof("waiting for the ceph-csi driver to be started before generating static volume %q", request.NamespacedName)
+		return opcontroller.WaitForRequeueIfCephClusterNotReady, cephStaticVolume, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	if err != nil {
+		return reconcile.Result{}, cephStaticVolume, errors.Wrap(err, "failed to populate cluster info")
+	}
+	clusterInfo.Context = exec.WithAuditInitiator(r.opManagerContext, "CephStaticVolume", request.Namespace, request.Name)
+
+	pv, err := r.generatePersistentVolume(cephStaticVolume, &cephCluster, clusterInfo, driverName)
+	if err != nil {
+		r.updateFailedStatus(request.NamespacedName, err)
+		return reconcile.Result{}, cephStaticVolume, errors.Wrapf(err, "failed to generate persistent volume of static volume %q", request.NamespacedName)
+	}
+	pvc := persistentVolumeClaim(cephStaticVolume, pv)
+
+	manifests, err := renderManifests(pv, pvc)
+	if err != nil {
+		r.updateFailedStatus(request.NamespacedName, err)
+		return reconcile.Result{}, cephStaticVolume, errors.Wrapf(err, "failed to render manifests of static volume %q", request.NamespacedName)
+	}
+
+	if cephStaticVolume.Spec.Create {
+		err = r.createVolume(pv, pvc)
+		if err != nil {
+			r.updateFailedStatus(request.NamespacedName, err)
+			return reconcile.Result{}, cephStaticVolume, errors.Wrapf(err, "failed to create persistent volume of static volume %q", request.NamespacedName)
+		}
+	}
+
+	// Success! Let's update the status
+	r.updateStatus(request.NamespacedName, &cephv1.CephStaticVolumeStatus{
+		Phase:                cephv1.ConditionReady,
+		PersistentVolumeName: pv.Name,
+		Manifests:            manifests,
+		ObservedGeneration:   cephStaticVolume.Generation,
+	})
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, cephStaticVolume, nil
+}
+
+// ValidateStaticVolume validates the static volume settings
+func ValidateStaticVolume(v *cephv1.CephStaticVolume) error {
+	if (v.Spec.RBD == nil) == (v.Spec.CephFS == nil) {
+		return errors.New("exactly one of rbd and cephfs must be specified")
+	}
+	if v.Spec.CephFS != nil {
+		if v.Spec.VolumeMode != nil && *v.Spec.VolumeMode == corev1.PersistentVolumeBlock {
+			return errors.New("the block volume mode is only valid for an rbd image")
+		}
+		if v.Spec.FSType != "" {
+			return errors.New("the filesystem type is only valid for an rbd image")
+		}
+	}
+
+	pvName := persistentVolumeName(v)
+	if errs := validation.IsDNS1123Subdomain(pvName); len(errs) > 0 {
+		return errors.Errorf("invalid persistent volume name %q. %v", pvName, errs)
+	}
+
+	return nil
+}
+
+// generatePersistentVolume returns the PersistentVolume of the existing image or subvolume, which
+// must exist in the cluster
+func (r *ReconcileCephStaticVolume) generatePersistentVolume(v *cephv1.CephStaticVolume, cephCluster *cephv1.CephCluster, clusterInfo *cephclient.ClusterInfo, driverName string) (*corev1.PersistentVolume, error) {
+	if v.Spec.RBD != nil {
+		image, err := cephclient.GetImageInfo(r.context, clusterInfo, v.Spec.RBD.Image, v.Spec.RBD.Pool)
+		if err != nil {
+			return nil, err
+		}
+		return rbdPersistentVolume(v, driverName, image), nil
+	}
+
+	subvolume, err := cephclient.GetCephFSSubvolumeInfo(r.context, clusterInfo, v.Spec.CephFS.FilesystemName, v.Spec.CephFS.SubvolumeGroup, v.Spec.CephFS.Subvolume)
+	if err != nil {
+		return nil, err
+	}
+	err = r.createCephFSStaticNodeSecret(cephCluster)
+	if err != nil {
+		return nil, err
+	}
+	return cephFSPersistentVolume(v, driverName, subvolume)
+}
+
+// createCephFSStaticNodeSecret creates the secret of the cephfs node driver with the keys expected
+// for a static volume. It is shared by the static volumes of the cluster.
+func (r *ReconcileCephStaticVolume) createCephFSStaticNodeSecret(cephCluster *cephv1.CephCluster) error {
+	nodeSecret, err := r.context.Clientset.CoreV1().Secrets(cephCluster.Namespace).Get(r.opManagerContext, csi.CsiCephFSNodeSecret, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get secret %q", csi.CsiCephFSNodeSecret)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cephFSStaticNodeSecret,
+			Namespace: cephCluster.Namespace,
+		},
+		Data: map[string][]byte{
+			"userID":  nodeSecret.Data["adminID"],
+			"userKey": nodeSecret.Data["adminKey"],
+		},
+		Type: k8sutil.RookType,
+	}
+	err = k8sutil.NewOwnerInfo(cephCluster, r.scheme).SetControllerReference(secret)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to secret %q", secret.Name)
+	}
+
+	_, err = k8sutil.CreateOrUpdateSecret(r.opManagerContext, r.context.Clientset, secret)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create secret %q", secret.Name)
+	}
+	return nil
+}
+
+// createVolume creates the PersistentVolume and its claim if they do not exist. They are not updated
+// when they exist, most of the spec of a PersistentVolume is immutable.
+func (r *ReconcileCephStaticVolume) createVolume(pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim) error {
+	_, err := r.context.Clientset.CoreV1().PersistentVolumes().Create(r.opManagerContext, pv, metav1.CreateOptions{})
+	if err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create persistent volume %q", pv.Name)
+		}
+		logger.Debugf("persistent volume %q already exists", pv.Name)
+	} else {
+		logger.Infof("created persistent volume %q", pv.Name)
+	}
+
+	if pvc == nil {
+		return nil
+	}
+	_, err = r.context.Clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(r.opManagerContext, pvc, metav1.CreateOptions{})
+	if err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create persistent volume claim %q in namespace %q", pvc.Name, pvc.Namespace)
+		}
+		logger.Debugf("persistent volume claim %q already exists in namespace %q", pvc.Name, pvc.Namespace)
+	} else {
+		logger.Infof("created persistent volume claim %q in namespace %q", pvc.Name, pvc.Namespace)
+	}
+	return nil
+}
+
+func (r *ReconcileCephStaticVolume) updateFailedStatus(name types.NamespacedName, err error) {
+	r.updateStatus(name, &cephv1.CephStaticVolumeStatus{Phase: cephv1.ConditionFailure, Message: err.Error()})
+}
+
+// updateStatus updates an object with a given status. The manifests of a failed CR are kept.
+func (r *ReconcileCephStaticVolume) updateStatus(name types.NamespacedName, status *cephv1.CephStaticVolumeStatus) {
+	cephStaticVolume := &cephv1.CephStaticVolume{}
+	if err := r.client.Get(r.opManagerContext, name, cephStaticVolume); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephStaticVolume resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve static volume %q to update status to %q. %v", name, status.Phase, err)
+		return
+	}
+
+	err := reporting.PatchStatus(r.client, cephStaticVolume, func() bool {
+		if status.Phase == cephv1.ConditionFailure && cephStaticVolume.Status != nil {
+			status.PersistentVolumeName = cephStaticVolume.Status.PersistentVolumeName
+			status.Manifests = cephStaticVolume.Status.Manifests
+			status.ObservedGeneration = cephStaticVolume.Status.ObservedGeneration
+		}
+		// the standard conditions are set from the result of the reconcile
+		if cephStaticVolume.Status != nil && status.Conditions == nil {
+			status.Conditions = cephStaticVolume.Status.Conditions
+		}
+		cephStaticVolume.Status = status
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set static volume %q status to %q. %v", name, status.Phase, err)
+		return
+	}
+	logger.Debugf("static volume %q status updated to %q", name, status.Phase)
+}
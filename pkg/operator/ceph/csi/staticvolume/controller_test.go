@@ -0,0 +1,292 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package staticvolume
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newRBDStaticVolume() *cephv1.CephStaticVolume {
+	return &cephv1.CephStaticVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "legacy-db",
+			Namespace: "rook-ceph",
+		},
+		Spec: cephv1.CephStaticVolumeSpec{
+			RBD: &cephv1.StaticRBDVolumeSource{Pool: "replicapool", Image: "db-image"},
+		},
+	}
+}
+
+func newCephFSStaticVolume() *cephv1.CephStaticVolume {
+	return &cephv1.CephStaticVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shared-data",
+			Namespace: "rook-ceph",
+		},
+		Spec: cephv1.CephStaticVolumeSpec{
+			CephFS: &cephv1.StaticCephFSVolumeSource{FilesystemName: "myfs", SubvolumeGroup: "csi", Subvolume: "data"},
+		},
+	}
+}
+
+func TestValidateStaticVolume(t *testing.T) {
+	v := newRBDStaticVolume()
+	assert.NoError(t, ValidateStaticVolume(v))
+
+	v.Spec.CephFS = newCephFSStaticVolume().Spec.CephFS
+	assert.Error(t, ValidateStaticVolume(v))
+	v.Spec.RBD = nil
+	assert.NoError(t, ValidateStaticVolume(v))
+	v.Spec.CephFS = nil
+	assert.Error(t, ValidateStaticVolume(v))
+
+	block := corev1.PersistentVolumeBlock
+	v = newCephFSStaticVolume()
+	v.Spec.VolumeMode = &block
+	assert.Error(t, ValidateStaticVolume(v))
+	v = newCephFSStaticVolume()
+	v.Spec.FSType = "xfs"
+	assert.Error(t, ValidateStaticVolume(v))
+
+	v = newRBDStaticVolume()
+	v.Spec.VolumeMode = &block
+	assert.NoError(t, ValidateStaticVolume(v))
+	v.Spec.PersistentVolumeName = "Invalid_Name"
+	assert.Error(t, ValidateStaticVolume(v))
+}
+
+func TestRBDPersistentVolume(t *testing.T) {
+	v := newRBDStaticVolume()
+	image := &cephclient.CephBlockImage{InfoName: "db-image", Size: 1073741824, Features: []string{"layering", "exclusive-lock"}}
+
+	pv := rbdPersistentVolume(v, "rook-ceph.rbd.csi.ceph.com", image)
+	assert.Equal(t, "rook-ceph-legacy-db", pv.Name)
+	assert.Equal(t, "1Gi", pv.Spec.Capacity.Storage().String())
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, pv.Spec.AccessModes)
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, pv.Spec.PersistentVolumeReclaimPolicy)
+	assert.Equal(t, "", pv.Spec.StorageClassName)
+	assert.Nil(t, pv.Spec.ClaimRef)
+	source := pv.Spec.CSI
+	assert.Equal(t, "rook-ceph.rbd.csi.ceph.com", source.Driver)
+	assert.Equal(t, "db-image", source.VolumeHandle)
+	assert.Equal(t, "ext4", source.FSType)
+	assert.Equal(t, map[string]string{
+		"clusterID":     "rook-ceph",
+		"pool":          "replicapool",
+		"staticVolume":  "true",
+		"imageFeatures": "layering,exclusive-lock",
+	}, source.VolumeAttributes)
+	assert.Equal(t, csi.CsiRBDNodeSecret, source.NodeStageSecretRef.Name)
+	assert.Nil(t, persistentVolumeClaim(v, pv))
+
+	block := corev1.PersistentVolumeBlock
+	capacity := resource.MustParse("500Mi")
+	v.Spec.VolumeMode = &block
+	v.Spec.Capacity = &capacity
+	v.Spec.PersistentVolumeName = "db"
+	v.Spec.Claim = &cephv1.StaticVolumeClaim{Name: "db", Namespace: "app"}
+	pv = rbdPersistentVolume(v, "rook-ceph.rbd.csi.ceph.com", image)
+	assert.Equal(t, "db", pv.Name)
+	assert.Equal(t, "500Mi", pv.Spec.Capacity.Storage().String())
+	assert.Equal(t, "", pv.Spec.CSI.FSType)
+	assert.Equal(t, "app", pv.Spec.ClaimRef.Namespace)
+
+	pvc := persistentVolumeClaim(v, pv)
+	assert.Equal(t, "db", pvc.Name)
+	assert.Equal(t, "app", pvc.Namespace)
+	assert.Equal(t, "db", pvc.Spec.VolumeName)
+	assert.Equal(t, "", *pvc.Spec.StorageClassName)
+	assert.Equal(t, block, *pvc.Spec.VolumeMode)
+	assert.Equal(t, "500Mi", pvc.Spec.Resources.Requests.Storage().String())
+
+	manifests, err := renderManifests(pv, pvc)
+	assert.NoError(t, err)
+	assert.Contains(t, manifests, "kind: PersistentVolume\n")
+	assert.Contains(t, manifests, "---\n")
+	assert.Contains(t, manifests, "kind: PersistentVolumeClaim\n")
+}
+
+func TestCephFSPersistentVolume(t *testing.T) {
+	v := newCephFSStaticVolume()
+	subvolume := &cephclient.CephFSSubvolumeInfo{Path: "/volumes/csi/data/4c8a", BytesQuota: []byte("2147483648")}
+
+	pv, err := cephFSPersistentVolume(v, "rook-ceph.cephfs.csi.ceph.com", subvolume)
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-shared-data", pv.Name)
+	assert.Equal(t, "2Gi", pv.Spec.Capacity.Storage().String())
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, pv.Spec.AccessModes)
+	source := pv.Spec.CSI
+	assert.Equal(t, "rook-ceph-shared-data", source.VolumeHandle)
+	assert.Equal(t, "", source.FSType)
+	assert.Equal(t, map[string]string{
+		"clusterID":    "rook-ceph",
+		"fsName":       "myfs",
+		"staticVolume": "true",
+		"rootPath":     "/volumes/csi/data/4c8a",
+	}, source.VolumeAttributes)
+	assert.Equal(t, cephFSStaticNodeSecret, source.NodeStageSecretRef.Name)
+
+	// the capacity is required without a quota
+	subvolume.BytesQuota = []byte(`"infinite"`)
+	_, err = cephFSPersistentVolume(v, "rook-ceph.cephfs.csi.ceph.com", subvolume)
+	assert.Error(t, err)
+	capacity := resource.MustParse("10Gi")
+	v.Spec.Capacity = &capacity
+	pv, err = cephFSPersistentVolume(v, "rook-ceph.cephfs.csi.ceph.com", subvolume)
+	assert.NoError(t, err)
+	assert.Equal(t, "10Gi", pv.Spec.Capacity.Storage().String())
+}
+
+func TestCephStaticVolumeController(t *testing.T) {
+	ctx := context.TODO()
+	rbdVolume := newRBDStaticVolume()
+	rbdVolume.Spec.Create = true
+	rbdVolume.Spec.Claim = &cephv1.StaticVolumeClaim{Name: "db", Namespace: "app"}
+	cephfsVolume := newCephFSStaticVolume()
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph",
+			Namespace: "rook-ceph",
+		},
+		Status: cephv1.ClusterStatus{
+			Phase: cephv1.ConditionReady,
+			CephStatus: &cephv1.CephStatus{
+				Health: "HEALTH_OK",
+			},
+		},
+	}
+
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if command == "rbd" && args[0] == "info" {
+				return `{"name":"db-image","size":1073741824,"format":2,"features":["layering"]}`, nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "info" {
+				return `{"bytes_quota": "infinite", "path": "/volumes/csi/data/4c8a"}`, nil
+			}
+			return "", errors.Errorf("unexpected command %q %q", command, args)
+		},
+	}
+	c := &clusterd.Context{Executor: executor, Clientset: testop.New(t, 1)}
+	_, err := c.Clientset.CoreV1().Secrets("rook-ceph").Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "rook-ceph"},
+		Data: map[string][]byte{
+			"fsid":         []byte("fsid"),
+			"mon-secret":   []byte("monsecret"),
+			"admin-secret": []byte("adminsecret"),
+		},
+		Type: k8sutil.RookType,
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = c.Clientset.CoreV1().Secrets("rook-ceph").Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: csi.CsiCephFSNodeSecret, Namespace: "rook-ceph"},
+		Data: map[string][]byte{
+			"adminID":  []byte("csi-cephfs-node"),
+			"adminKey": []byte("cephfsnodekey"),
+		},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{}, &cephv1.CephStaticVolume{}, &cephv1.CephStaticVolumeList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects([]runtime.Object{rbdVolume, cephfsVolume, cephCluster}...).Build()
+	r := &ReconcileCephStaticVolume{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
+
+	t.Run("rbd volume and claim are created", func(t *testing.T) {
+		csi.RBDDriverName = "rook-ceph.rbd.csi.ceph.com"
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: rbdVolume.Name, Namespace: rbdVolume.Namespace}}
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.False(t, res.Requeue)
+
+		pv, err := c.Clientset.CoreV1().PersistentVolumes().Get(ctx, "rook-ceph-legacy-db", metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "db-image", pv.Spec.CSI.VolumeHandle)
+		assert.Equal(t, "layering", pv.Spec.CSI.VolumeAttributes["imageFeatures"])
+		pvc, err := c.Clientset.CoreV1().PersistentVolumeClaims("app").Get(ctx, "db", metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, pv.Name, pvc.Spec.VolumeName)
+
+		err = cl.Get(ctx, req.NamespacedName, rbdVolume)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionReady, rbdVolume.Status.Phase)
+		assert.Equal(t, "rook-ceph-legacy-db", rbdVolume.Status.PersistentVolumeName)
+		assert.Contains(t, rbdVolume.Status.Manifests, "volumeHandle: db-image")
+
+		// the existing volume is left untouched
+		_, err = r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("cephfs volume without quota nor capacity", func(t *testing.T) {
+		csi.CephFSDriverName = "rook-ceph.cephfs.csi.ceph.com"
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cephfsVolume.Name, Namespace: cephfsVolume.Namespace}}
+		_, err := r.Reconcile(ctx, req)
+		assert.Error(t, err)
+
+		err = cl.Get(ctx, req.NamespacedName, cephfsVolume)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionFailure, cephfsVolume.Status.Phase)
+		assert.Contains(t, cephfsVolume.Status.Message, "has no quota")
+
+		// the static node secret has the keys of the cephfs node secret
+		secret, err := c.Clientset.CoreV1().Secrets("rook-ceph").Get(ctx, cephFSStaticNodeSecret, metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("csi-cephfs-node"), secret.Data["userID"])
+		assert.Equal(t, []byte("cephfsnodekey"), secret.Data["userKey"])
+	})
+
+	t.Run("cephfs manifests are generated", func(t *testing.T) {
+		capacity := resource.MustParse("10Gi")
+		cephfsVolume.Spec.Capacity = &capacity
+		assert.NoError(t, cl.Update(ctx, cephfsVolume))
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cephfsVolume.Name, Namespace: cephfsVolume.Namespace}}
+		_, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+
+		err = cl.Get(ctx, req.NamespacedName, cephfsVolume)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionReady, cephfsVolume.Status.Phase)
+		assert.Contains(t, cephfsVolume.Status.Manifests, "rootPath: /volumes/csi/data/4c8a")
+		assert.NotContains(t, cephfsVolume.Status.Manifests, "PersistentVolumeClaim")
+
+		// the volume is not created
+		_, err = c.Clientset.CoreV1().PersistentVolumes().Get(ctx, "rook-ceph-shared-data", metav1.GetOptions{})
+		assert.Error(t, err)
+	})
+}
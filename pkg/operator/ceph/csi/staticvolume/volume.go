@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package staticvolume
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// the ceph-csi cephfs driver expects the userID and userKey of a static volume, while the node
+	// secret of the cephfs driver only has the adminID and adminKey
+	// #nosec because of the word `Secret`
+	cephFSStaticNodeSecret = "rook-csi-cephfs-static-node"
+
+	defaultRBDFSType = "ext4"
+)
+
+// persistentVolumeName returns the name of the PersistentVolume of a static volume
+func persistentVolumeName(v *cephv1.CephStaticVolume) string {
+	if v.Spec.PersistentVolumeName != "" {
+		return v.Spec.PersistentVolumeName
+	}
+	return fmt.Sprintf("%s-%s", v.Namespace, v.Name)
+}
+
+// rbdPersistentVolume returns the PersistentVolume of an existing rbd image
+func rbdPersistentVolume(v *cephv1.CephStaticVolume, driverName string, image *cephclient.CephBlockImage) *corev1.PersistentVolume {
+	attributes := map[string]string{
+		"clusterID":    v.Namespace,
+		"pool":         v.Spec.RBD.Pool,
+		"staticVolume": "true",
+	}
+	// the image is mapped with its own features
+	if len(image.Features) > 0 {
+		attributes["imageFeatures"] = strings.Join(image.Features, ",")
+	}
+
+	source := &corev1.CSIPersistentVolumeSource{
+		Driver:           driverName,
+		VolumeHandle:     v.Spec.RBD.Image,
+		VolumeAttributes: attributes,
+		NodeStageSecretRef: &corev1.SecretReference{
+			Name:      csi.CsiRBDNodeSecret,
+			Namespace: v.Namespace,
+		},
+	}
+	if v.Spec.VolumeMode == nil || *v.Spec.VolumeMode == corev1.PersistentVolumeFilesystem {
+		source.FSType = v.Spec.FSType
+		if source.FSType == "" {
+			source.FSType = defaultRBDFSType
+		}
+	}
+
+	capacity := v.Spec.Capacity
+	if capacity == nil {
+		capacity = resource.NewQuantity(int64(image.Size), resource.BinarySI)
+	}
+
+	return newPersistentVolume(v, source, *capacity, corev1.ReadWriteOnce)
+}
+
+// cephFSPersistentVolume returns the PersistentVolume of an existing cephfs subvolume
+func cephFSPersistentVolume(v *cephv1.CephStaticVolume, driverName string, subvolume *cephclient.CephFSSubvolumeInfo) (*corev1.PersistentVolume, error) {
+	source := &corev1.CSIPersistentVolumeSource{
+		Driver: driverName,
+		// the handle of a static cephfs volume is only required to be unique
+		VolumeHandle: persistentVolumeName(v),
+		VolumeAttributes: map[string]string{
+			"clusterID":    v.Namespace,
+			"fsName":       v.Spec.CephFS.FilesystemName,
+			"staticVolume": "true",
+			"rootPath":     subvolume.Path,
+		},
+		NodeStageSecretRef: &corev1.SecretReference{
+			Name:      cephFSStaticNodeSecret,
+			Namespace: v.Namespace,
+		},
+	}
+
+	capacity := v.Spec.Capacity
+	if capacity == nil {
+		quota, ok := subvolume.Quota()
+		if !ok {
+			return nil, errors.Errorf("subvolume %q has no quota, the capacity of the volume must be set", v.Spec.CephFS.Subvolume)
+		}
+		capacity = resource.NewQuantity(int64(quota), resource.BinarySI)
+	}
+
+	return newPersistentVolume(v, source, *capacity, corev1.ReadWriteMany), nil
+}
+
+func newPersistentVolume(v *cephv1.CephStaticVolume, source *corev1.CSIPersistentVolumeSource, capacity resource.Quantity, defaultAccessMode corev1.PersistentVolumeAccessMode) *corev1.PersistentVolume {
+	accessModes := v.Spec.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{defaultAccessMode}
+	}
+
+	pv := &corev1.PersistentVolume{
+		TypeMeta: metav1.TypeMeta{Kind: "PersistentVolume", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: persistentVolumeName(v),
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:               corev1.ResourceList{corev1.ResourceStorage: capacity},
+			AccessModes:            accessModes,
+			VolumeMode:             v.Spec.VolumeMode,
+			PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: source},
+			// the existing image or subvolume must never be deleted by the driver
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+		},
+	}
+	if v.Spec.Claim != nil {
+		// pre-bind the volume so that no other claim can bind it
+		pv.Spec.ClaimRef = &corev1.ObjectReference{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+			Name:       v.Spec.Claim.Name,
+			Namespace:  v.Spec.Claim.Namespace,
+		}
+	}
+	return pv
+}
+
+// persistentVolumeClaim returns the PersistentVolumeClaim bound to the PersistentVolume, nil if no
+// claim is requested
+func persistentVolumeClaim(v *cephv1.CephStaticVolume, pv *corev1.PersistentVolume) *corev1.PersistentVolumeClaim {
+	if v.Spec.Claim == nil {
+		return nil
+	}
+
+	storageClassName := ""
+	return &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      v.Spec.Claim.Name,
+			Namespace: v.Spec.Claim.Namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: pv.Spec.AccessModes,
+			VolumeMode:  pv.Spec.VolumeMode,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: pv.Spec.Capacity[corev1.ResourceStorage]},
+			},
+			VolumeName: pv.Name,
+			// an empty storage class disables the dynamic provisioning of the claim
+			StorageClassName: &storageClassName,
+		},
+	}
+}
+
+// renderManifests returns the yaml documents of the PersistentVolume and its claim
+func renderManifests(pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	manifest, err := yaml.Marshal(pv)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render persistent volume %q", pv.Name)
+	}
+	manifests := string(manifest)
+	if pvc != nil {
+		manifest, err = yaml.Marshal(pvc)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to render persistent volume claim %q", pvc.Name)
+		}
+		manifests = fmt.Sprintf("%s---\n%s", manifests, string(manifest))
+	}
+	return manifests, nil
+}
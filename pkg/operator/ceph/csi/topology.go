@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultTopologyDomainLabel is the domain label of a CephBlockPoolTopology that does not set one
+	DefaultTopologyDomainLabel = "topology.kubernetes.io/zone"
+)
+
+// getTopologyDomainLabels returns the comma separated list of node labels the RBD plugin reports as
+// the node topology. The labels are the ones from CSI_TOPOLOGY_DOMAIN_LABELS if topology is enabled
+// with CSI_ENABLE_TOPOLOGY, merged with the domain labels of all the CephBlockPoolTopologies.
+// An empty list means topology aware provisioning is disabled.
+func (r *ReconcileCSI) getTopologyDomainLabels() (string, error) {
+	labels := map[string]bool{}
+
+	if strings.EqualFold(k8sutil.GetValue(r.opConfig.Parameters, "CSI_ENABLE_TOPOLOGY", "false"), "true") {
+		domainLabels := k8sutil.GetValue(r.opConfig.Parameters, "CSI_TOPOLOGY_DOMAIN_LABELS", "")
+		if domainLabels == "" {
+			importedLabels, err := r.getExternalTopologyDomainLabels()
+			if err != nil {
+				return "", err
+			}
+			if len(importedLabels) == 0 {
+				return "", errors.New("CSI_TOPOLOGY_DOMAIN_LABELS must be set when CSI_ENABLE_TOPOLOGY is enabled")
+			}
+			domainLabels = strings.Join(importedLabels, ",")
+		}
+		for _, label := range strings.Split(domainLabels, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				labels[label] = true
+			}
+		}
+	}
+
+	topologies := &cephv1.CephBlockPoolTopologyList{}
+	err := r.client.List(r.opManagerContext, topologies, &client.ListOptions{})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list block pool topologies")
+	}
+	for _, topology := range topologies.Items {
+		if !topology.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if topology.Spec.DomainLabel == "" {
+			labels[DefaultTopologyDomainLabel] = true
+		} else {
+			labels[topology.Spec.DomainLabel] = true
+		}
+	}
+
+	domainLabels := []string{}
+	for label := range labels {
+		domainLabels = append(domainLabels, label)
+	}
+	// keep a stable order to not restart the plugin pods needlessly
+	sort.Strings(domainLabels)
+
+	return strings.Join(domainLabels, ","), nil
+}
+
+// getExternalTopologyDomainLabels returns the crush location labels imported from the CRUSH maps of
+// the external clusters, without the hostname label since a host is not a topology domain
+func (r *ReconcileCSI) getExternalTopologyDomainLabels() ([]string, error) {
+	clusters := &cephv1.CephClusterList{}
+	err := r.client.List(r.opManagerContext, clusters, &client.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list ceph clusters")
+	}
+
+	labels := []string{}
+	for _, cluster := range clusters.Items {
+		if !cluster.Spec.External.Enable || cluster.Status.External == nil {
+			continue
+		}
+		for _, label := range cluster.Status.External.CrushLocationLabels {
+			if label != "kubernetes.io/hostname" {
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetTopologyDomainLabels(t *testing.T) {
+	newReconciler := func(params map[string]string, objects ...runtime.Object) *ReconcileCSI {
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objects...).Build()
+		return &ReconcileCSI{
+			client:           cl,
+			opManagerContext: context.TODO(),
+			opConfig:         controller.OperatorConfig{Parameters: params},
+		}
+	}
+
+	t.Run("topology disabled", func(t *testing.T) {
+		r := newReconciler(map[string]string{"CSI_TOPOLOGY_DOMAIN_LABELS": "topology.rook.io/rack"})
+		labels, err := r.getTopologyDomainLabels()
+		assert.NoError(t, err)
+		assert.Equal(t, "", labels)
+	})
+
+	t.Run("topology enabled without labels", func(t *testing.T) {
+		r := newReconciler(map[string]string{"CSI_ENABLE_TOPOLOGY": "true"})
+		_, err := r.getTopologyDomainLabels()
+		assert.Error(t, err)
+	})
+
+	t.Run("topology enabled with the labels of an external cluster", func(t *testing.T) {
+		external := &cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "rook-ceph-external"},
+			Spec:       cephv1.ClusterSpec{External: cephv1.ExternalSpec{Enable: true}},
+			Status: cephv1.ClusterStatus{External: &cephv1.ExternalClusterStatus{
+				CrushLocationLabels: []string{"kubernetes.io/hostname", "topology.kubernetes.io/zone"},
+			}},
+		}
+		r := newReconciler(map[string]string{"CSI_ENABLE_TOPOLOGY": "true"}, external)
+		labels, err := r.getTopologyDomainLabels()
+		assert.NoError(t, err)
+		assert.Equal(t, "topology.kubernetes.io/zone", labels)
+	})
+
+	t.Run("labels from settings and block pool topologies", func(t *testing.T) {
+		zonal := &cephv1.CephBlockPoolTopology{ObjectMeta: metav1.ObjectMeta{Name: "zonal", Namespace: "rook-ceph"}}
+		racks := &cephv1.CephBlockPoolTopology{
+			ObjectMeta: metav1.ObjectMeta{Name: "racks", Namespace: "rook-ceph"},
+			Spec:       cephv1.CephBlockPoolTopologySpec{DomainLabel: "topology.rook.io/rack"},
+		}
+		r := newReconciler(map[string]string{
+			"CSI_ENABLE_TOPOLOGY":        "true",
+			"CSI_TOPOLOGY_DOMAIN_LABELS": "kubernetes.io/hostname, topology.rook.io/rack",
+		}, zonal, racks)
+		labels, err := r.getTopologyDomainLabels()
+		assert.NoError(t, err)
+		assert.Equal(t, "kubernetes.io/hostname,topology.kubernetes.io/zone,topology.rook.io/rack", labels)
+	})
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	linuxNodeOS   = "linux"
+	windowsNodeOS = "windows"
+)
+
+// setPluginNodeOS restricts the plugin pods to the nodes of an operating system, on top of the node
+// selector of the plugin scheduling settings
+func setPluginNodeOS(podSpec *corev1.PodSpec, os string) {
+	setPluginNodeLabel(podSpec, corev1.LabelOSStable, os)
+}
+
+// setPluginNodeLabel restricts the plugin pods to the nodes with the given label
+func setPluginNodeLabel(podSpec *corev1.PodSpec, key, value string) {
+	// The node selector may be shared with the scheduling settings of the cluster
+	nodeSelector := map[string]string{}
+	for k, v := range podSpec.NodeSelector {
+		nodeSelector[k] = v
+	}
+	nodeSelector[key] = value
+	podSpec.NodeSelector = nodeSelector
+}
+
+// deployRBDWindowsPlugin deploys the rbd plugin of the windows nodes. It is scheduled like the rbd
+// plugin of the linux nodes, except that it only runs on windows.
+func (r *ReconcileCSI) deployRBDWindowsPlugin(tp templateParam, ownerInfo *k8sutil.OwnerInfo, prefix string, scheduling *cephv1.CSIPodSchedulingSpec) (*apps.DaemonSet, error) {
+	rbdWindowsPlugin, err := templateToDaemonSet("rbdplugin-windows", RBDWindowsPluginTemplatePath, tp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load rbd windows plugin template")
+	}
+	if prefix != "" {
+		setDedicatedDaemonSet(prefix, rbdWindowsPlugin)
+	}
+
+	pluginTolerations := getToleration(r.opConfig.Parameters, pluginTolerationsEnv, []corev1.Toleration{})
+	pluginNodeAffinity := getNodeAffinity(r.opConfig.Parameters, pluginNodeAffinityEnv, &corev1.NodeAffinity{})
+	rbdPluginTolerations := getToleration(r.opConfig.Parameters, rbdPluginTolerationsEnv, pluginTolerations)
+	rbdPluginNodeAffinity := getNodeAffinity(r.opConfig.Parameters, rbdPluginNodeAffinityEnv, pluginNodeAffinity)
+	podSpec := &rbdWindowsPlugin.Spec.Template.Spec
+	applyToPodSpec(podSpec, rbdPluginNodeAffinity, rbdPluginTolerations)
+	applyCSIComponentPlacement(r.pluginSpec(), podSpec)
+	applyCSIPodScheduling(scheduling, podSpec)
+	setPluginNodeOS(podSpec, windowsNodeOS)
+	// The logs of the windows plugin are not rotated by the operator
+	applyContainerLogLevels(podSpec, tp.PluginLogLevels)
+	applyResourcesToContainers(r.opConfig.Parameters, rbdPluginResource, podSpec)
+
+	err = ownerInfo.SetControllerReference(rbdWindowsPlugin)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to set owner reference to rbd windows plugin daemonset %q", rbdWindowsPlugin.Name)
+	}
+	if tp.PluginMaxUnavailablePerZone > 0 {
+		if err = setPluginTemplateHash(rbdWindowsPlugin); err != nil {
+			return nil, err
+		}
+	}
+	err = k8sutil.CreateDaemonSet(r.opManagerContext, rbdWindowsPlugin.Name, r.opConfig.OperatorNamespace, r.context.Clientset, rbdWindowsPlugin)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to start rbd windows plugin daemonset %q", rbdWindowsPlugin.Name)
+	}
+	k8sutil.AddRookVersionLabelToDaemonSet(rbdWindowsPlugin)
+
+	return rbdWindowsPlugin, nil
+}
+
+// deleteRBDWindowsPlugin removes the rbd windows plugin of the drivers with the given prefix, the
+// plugin of the shared driver has no prefix
+func (r *ReconcileCSI) deleteRBDWindowsPlugin(prefix string) error {
+	name := dedicatedResourceName(prefix, csiRBDWindowsPlugin)
+	err := k8sutil.DeleteDaemonset(r.opManagerContext, r.context.Clientset, r.opConfig.OperatorNamespace, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete the %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRBDWindowsPluginTemplate(t *testing.T) {
+	tp := templateParam{
+		Param:     CSIParam,
+		Namespace: "rook-ceph",
+	}
+	tp.WindowsCSIPluginImage = "quay.io/cephcsi/cephcsi:windows"
+	tp.WindowsKubeletDirPath = DefaultWindowsKubeletDirPath
+	tp.DriverNamePrefix = "rook-ceph."
+
+	ds, err := templateToDaemonSet("rbdplugin-windows", RBDWindowsPluginTemplatePath, tp)
+	assert.NoError(t, err)
+	assert.Equal(t, csiRBDWindowsPlugin, ds.Name)
+	podSpec := ds.Spec.Template.Spec
+	assert.Equal(t, windowsNodeOS, podSpec.NodeSelector[corev1.LabelOSStable])
+	assert.Equal(t, "quay.io/cephcsi/cephcsi:windows", podSpec.Containers[1].Image)
+	assert.Contains(t, podSpec.Containers[0].Args, `--kubelet-registration-path=C:\var\lib\kubelet\plugins\rook-ceph.rbd.csi.ceph.com\csi.sock`)
+
+	paths := map[string]string{}
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			paths[volume.Name] = volume.HostPath.Path
+		}
+	}
+	assert.Equal(t, `C:\var\lib\kubelet\plugins\rook-ceph.rbd.csi.ceph.com`, paths["plugin-dir"])
+	assert.Equal(t, `\\.\pipe\csi-proxy-disk-v1`, paths["csi-proxy-disk-pipe"])
+
+	setDedicatedDaemonSet("tenant-a", ds)
+	assert.Equal(t, "tenant-a-csi-rbdplugin-windows", ds.Name)
+}
+
+func TestSetPluginNodeOS(t *testing.T) {
+	scheduling := map[string]string{"node-pool": "storage"}
+	podSpec := &corev1.PodSpec{NodeSelector: scheduling}
+	setPluginNodeOS(podSpec, windowsNodeOS)
+	assert.Equal(t, map[string]string{"node-pool": "storage", corev1.LabelOSStable: windowsNodeOS}, podSpec.NodeSelector)
+	// the scheduling settings are not modified
+	assert.Len(t, scheduling, 1)
+
+	podSpec = &corev1.PodSpec{}
+	setPluginNodeOS(podSpec, linuxNodeOS)
+	assert.Equal(t, map[string]string{corev1.LabelOSStable: linuxNodeOS}, podSpec.NodeSelector)
+}
+
+func TestValidateRBDWindowsPluginParam(t *testing.T) {
+	saved := CSIParam
+	defer func() { CSIParam = saved }()
+	CSIParam = Param{
+		CSIPluginImage:   "image",
+		RegistrarImage:   "image",
+		ProvisionerImage: "image",
+		AttacherImage:    "image",
+	}
+	assert.NoError(t, validateCSIParam())
+
+	CSIParam.EnableRBDWindowsPlugin = true
+	assert.Error(t, validateCSIParam())
+	CSIParam.WindowsCSIPluginImage = "quay.io/cephcsi/cephcsi:windows"
+	assert.NoError(t, validateCSIParam())
+}
@@ -19,6 +19,7 @@ package machinedisruption
 import (
 	healthchecking "github.com/openshift/machine-api-operator/pkg/apis/healthchecking/v1alpha1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -40,7 +41,7 @@ func Add(mgr manager.Manager, ctx *controllerconfig.Context) error {
 	// TODO CHANGE ME (the context)
 	reconciler := reconcile.Reconciler(reconcileMachineDisruption)
 	// create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileLogFields(controllerName, reconciler)})
 	if err != nil {
 		return err
 	}
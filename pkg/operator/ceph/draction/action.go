@@ -0,0 +1,257 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package draction
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// stepFunc runs a step of an action and reports its result in the status
+type stepFunc func(stepName string, run func() (string, error)) error
+
+// runAction checks the mirroring of all the resources of the action before promoting, demoting or
+// quiescing any of them, so that a failed check leaves all the resources untouched. Each step is
+// reported in the status as soon as it completes.
+func (r *ReconcileCephDRAction) runAction(name types.NamespacedName, action *cephv1.CephDRAction, status *cephv1.CephDRActionStatus) error {
+	spec := &action.Spec
+	step := func(stepName string, run func() (string, error)) error {
+		message, err := run()
+		stepStatus := cephv1.DRActionStepStatus{Name: stepName, Succeeded: err == nil, Message: message, Time: now()}
+		if err != nil {
+			stepStatus.Message = err.Error()
+		}
+		status.Steps = append(status.Steps, stepStatus)
+		r.updateStatus(name, status)
+		if err != nil {
+			return errors.Wrapf(err, "failed to %s", stepName)
+		}
+		return nil
+	}
+
+	// The pre-checks are skipped by a forced promotion, the peer is usually lost
+	if !spec.Force {
+		for _, poolName := range spec.Pools {
+			poolName := poolName
+			err := step(fmt.Sprintf("check pool %s", poolName), func() (string, error) {
+				return r.checkPool(action.Namespace, poolName, nil)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		pools, images := imagesByPool(spec.Images)
+		for _, pool := range pools {
+			pool := pool
+			err := step(fmt.Sprintf("check images of pool %s", pool), func() (string, error) {
+				return r.checkPool(action.Namespace, pool, images[pool])
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, fsName := range opcontroller.DRActionFilesystems(action) {
+			fsName := fsName
+			err := step(fmt.Sprintf("check filesystem %s", fsName), func() (string, error) {
+				return r.checkFilesystem(action.Namespace, fsName)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if spec.Action == cephv1.DRActionQuiesce {
+		return r.quiesce(action, status, step)
+	}
+
+	for _, poolName := range spec.Pools {
+		poolName := poolName
+		err := step(fmt.Sprintf("%s pool %s", spec.Action, poolName), func() (string, error) {
+			if spec.Action == cephv1.DRActionPromote {
+				return "", cephclient.PromotePoolMirroring(r.context, r.clusterInfo, poolName, spec.Force)
+			}
+			return "", cephclient.DemotePoolMirroring(r.context, r.clusterInfo, poolName)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, image := range spec.Images {
+		image := image
+		err := step(fmt.Sprintf("%s image %s/%s", spec.Action, image.Pool, image.Image), func() (string, error) {
+			if spec.Action == cephv1.DRActionPromote {
+				return "", cephclient.PromoteImageMirroring(r.context, r.clusterInfo, image.Pool, image.Image, spec.Force)
+			}
+			return "", cephclient.DemoteImageMirroring(r.context, r.clusterInfo, image.Pool, image.Image)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPool checks that the peer of a mirrored pool is reachable and that the given images, or
+// all the images of the pool, are synced with the peer
+func (r *ReconcileCephDRAction) checkPool(namespace, poolName string, imageNames []string) (string, error) {
+	pool := &cephv1.CephBlockPool{}
+	err := r.client.Get(r.opManagerContext, types.NamespacedName{Name: poolName, Namespace: namespace}, pool)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", errors.Errorf("pool %q not found", poolName)
+		}
+		return "", errors.Wrapf(err, "failed to get pool %q", poolName)
+	}
+	if !pool.Spec.Mirroring.Enabled {
+		return "", errors.Errorf("mirroring is not enabled in pool %q", poolName)
+	}
+
+	info, err := cephclient.GetPoolMirroringInfo(r.context, r.clusterInfo, poolName)
+	if err != nil {
+		return "", err
+	}
+	if len(info.Peers) == 0 {
+		return "", errors.Errorf("pool %q has no mirroring peer", poolName)
+	}
+
+	mirrorStatus, err := cephclient.GetPoolMirroringStatus(r.context, r.clusterInfo, poolName)
+	if err != nil {
+		return "", err
+	}
+	if mirrorStatus.Summary == nil || mirrorStatus.Summary.DaemonHealth != "OK" {
+		daemonHealth := "unknown"
+		if mirrorStatus.Summary != nil {
+			daemonHealth = mirrorStatus.Summary.DaemonHealth
+		}
+		return "", errors.Errorf("the health of the rbd-mirror daemons of pool %q is %s", poolName, daemonHealth)
+	}
+
+	imagesStatus, err := cephclient.GetPoolMirroringImagesStatus(r.context, r.clusterInfo, poolName)
+	if err != nil {
+		return "", err
+	}
+	images := map[string]cephclient.MirroredImageStatus{}
+	for _, image := range imagesStatus.Images {
+		images[image.Name] = image
+	}
+	if imageNames == nil {
+		for imageName := range images {
+			imageNames = append(imageNames, imageName)
+		}
+	}
+	for _, imageName := range imageNames {
+		image, ok := images[imageName]
+		if !ok {
+			return "", errors.Errorf("image %q is not mirrored in pool %q", imageName, poolName)
+		}
+		if err := checkImageSynced(image); err != nil {
+			return "", errors.Wrapf(err, "image %q of pool %q is not ready", imageName, poolName)
+		}
+	}
+
+	return fmt.Sprintf("%d images synced with %d peers", len(imageNames), len(info.Peers)), nil
+}
+
+// checkImageSynced checks that the image is reported by the rbd-mirror daemon of the peer site and
+// that its replay is not in error or in progress
+func checkImageSynced(image cephclient.MirroredImageStatus) error {
+	if len(image.PeerSites) == 0 {
+		return errors.New("the peer site is not reachable")
+	}
+	states := map[string]string{"local": image.State}
+	descriptions := []string{image.Description}
+	for _, site := range image.PeerSites {
+		if !strings.HasPrefix(site.State, "up+") {
+			return errors.Errorf("the rbd-mirror daemon of peer site %q is not up: %s", site.SiteName, site.State)
+		}
+		states[site.SiteName] = site.State
+		descriptions = append(descriptions, site.Description)
+	}
+
+	for site, state := range states {
+		for _, unclean := range []string{"error", "syncing", "starting_replay"} {
+			if strings.Contains(state, unclean) {
+				return errors.Errorf("the state of the image in site %q is %s", site, state)
+			}
+		}
+	}
+
+	for _, description := range descriptions {
+		replay, ok := cephclient.ParseImageReplayStatus(description)
+		if !ok {
+			continue
+		}
+		if replay.EntriesBehindPrimary > 0 {
+			return errors.Errorf("%d journal entries are not replayed yet", replay.EntriesBehindPrimary)
+		}
+		if replay.RemoteSnapshotTimestamp > replay.LocalSnapshotTimestamp {
+			return errors.New("the last mirror snapshot is not synced yet")
+		}
+	}
+	return nil
+}
+
+// checkFilesystem checks the sync of a mirrored filesystem with its peers, as last reported in the
+// status of the filesystem. The snapshot mirroring of a filesystem has no primary to promote or
+// demote.
+func (r *ReconcileCephDRAction) checkFilesystem(namespace, fsName string) (string, error) {
+	fs := &cephv1.CephFilesystem{}
+	err := r.client.Get(r.opManagerContext, types.NamespacedName{Name: fsName, Namespace: namespace}, fs)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", errors.Errorf("filesystem %q not found", fsName)
+		}
+		return "", errors.Wrapf(err, "failed to get filesystem %q", fsName)
+	}
+	if fs.Spec.Mirroring == nil || !fs.Spec.Mirroring.Enabled {
+		return "", errors.Errorf("mirroring is not enabled in filesystem %q", fsName)
+	}
+	if fs.Status == nil || fs.Status.MirroringStatus == nil || len(fs.Status.MirroringStatus.Peers) == 0 {
+		return "", errors.Errorf("filesystem %q has no mirroring peer in its status", fsName)
+	}
+
+	snapsSynced := 0
+	for _, peer := range fs.Status.MirroringStatus.Peers {
+		if peer.FailedDirectories > 0 {
+			return "", errors.Errorf("the sync of %d directories of filesystem %q with peer %q failed", peer.FailedDirectories, fsName, peer.UUID)
+		}
+		snapsSynced += peer.SnapsSynced
+	}
+
+	return fmt.Sprintf("%d snapshots synced with %d peers, last checked at %s", snapsSynced, len(fs.Status.MirroringStatus.Peers), fs.Status.MirroringStatus.LastChecked), nil
+}
+
+// imagesByPool groups the images of the action by pool, in the order of the spec
+func imagesByPool(images []cephv1.DRActionImageSpec) ([]string, map[string][]string) {
+	pools := []string{}
+	imagesOfPool := map[string][]string{}
+	for _, image := range images {
+		if _, ok := imagesOfPool[image.Pool]; !ok {
+			pools = append(pools, image.Pool)
+		}
+		imagesOfPool[image.Pool] = append(imagesOfPool[image.Pool], image.Image)
+	}
+	return pools, imagesOfPool
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package draction to run the promotions, demotions and quiesces of the mirrored resources for DR
+package draction
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/util/exec"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-dr-action-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephDRActionKind = reflect.TypeOf(cephv1.CephDRAction{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephDRActionKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephDRAction reconciles a CephDRAction object
+type ReconcileCephDRAction struct {
+	client           client.Client
+	scheme           *runtime.Scheme
+	context          *clusterd.Context
+	clusterInfo      *cephclient.ClusterInfo
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+}
+
+// Add creates a new CephDRAction Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	return add(mgr, newReconciler(mgr, context, opManagerContext))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context) reconcile.Reconciler {
+	return &ReconcileCephDRAction{
+		client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephDRAction{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephDRAction{}, reconciler)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephDRAction CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephDRAction{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephDRAction object and makes changes based on the state read
+// and what is in the CephDRAction.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephDRAction) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, cephDRAction, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephDRAction, reconcileResponse, err)
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephDRAction) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephDRAction, error) {
+	// Fetch the CephDRAction instance
+	cephDRAction := &cephv1.CephDRAction{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephDRAction)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephDRAction resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, cephDRAction, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, cephDRAction, errors.Wrap(err, "failed to get cephDRAction")
+	}
+
+	// Only a quiesce action has something to clean up, the snapshot schedules it paused
+	quiesce := cephDRAction.Spec.Action == cephv1.DRActionQuiesce
+	deleted := !cephDRAction.GetDeletionTimestamp().IsZero()
+	if deleted && !quiesce {
+		logger.Debugf("dr action %q is being deleted", request.NamespacedName)
+		return reconcile.Result{}, cephDRAction, nil
+	}
+
+	// The action is run only once. A failed action is not retried since the state of the mirrored
+	// resources may have changed, a new action must be created.
+	if cephDRAction.Status != nil && cephDRAction.Status.ObservedGeneration == cephDRAction.Generation && !deleted {
+		switch cephDRAction.Status.Phase {
+		case cephv1.DRActionPhaseSucceeded, cephv1.DRActionPhaseFailed:
+			logger.Debugf("dr action %q already completed with phase %q", request.NamespacedName, cephDRAction.Status.Phase)
+			return reconcile.Result{}, cephDRAction, nil
+		}
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// There is no schedule to resume once the CephCluster is gone
+		if deleted && !cephClusterExists {
+			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephDRAction)
+			if err != nil {
+				return opcontroller.ImmediateRetryResult, cephDRAction, errors.Wrap(err, "failed to remove finalizer")
+			}
+			return reconcile.Result{}, cephDRAction, nil
+		}
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, cephDRAction, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephDRAction", request.Namespace, request.Name), request.NamespacedName.Namespace)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, cephDRAction, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	if deleted {
+		logger.Infof("resuming the snapshot schedules paused by dr action %q", request.NamespacedName)
+		err = r.resumeSchedules(cephDRAction)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, cephDRAction, errors.Wrapf(err, "failed to resume the snapshot schedules paused by dr action %q", request.NamespacedName)
+		}
+		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephDRAction)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, cephDRAction, errors.Wrap(err, "failed to remove finalizer")
+		}
+		return reconcile.Result{}, cephDRAction, nil
+	}
+
+	if quiesce {
+		// Set a finalizer to resume the snapshot schedules when the action is deleted
+		err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephDRAction)
+		if err != nil {
+			return reconcile.Result{}, cephDRAction, errors.Wrap(err, "failed to add finalizer")
+		}
+
+		// The final snapshots were taken, only their sync remains to be checked
+		status := cephDRAction.Status
+		if status != nil && status.ObservedGeneration == cephDRAction.Generation && status.Phase == cephv1.DRActionPhaseRunning && status.Quiesce != nil {
+			return r.waitForCatchUp(request.NamespacedName, cephDRAction, status), cephDRAction, nil
+		}
+	}
+
+	status := &cephv1.CephDRActionStatus{
+		Phase:              cephv1.DRActionPhaseRunning,
+		StartTime:          now(),
+		ObservedGeneration: cephDRAction.Generation,
+	}
+	if err := ValidateDRActionSpec(&cephDRAction.Spec); err != nil {
+		r.complete(request.NamespacedName, status, err)
+		return reconcile.Result{}, cephDRAction, nil
+	}
+	r.updateStatus(request.NamespacedName, status)
+
+	logger.Infof("running dr action %q to %s the mirrored resources", request.NamespacedName, cephDRAction.Spec.Action)
+	err = r.runAction(request.NamespacedName, cephDRAction, status)
+	if err == nil && quiesce {
+		return r.waitForCatchUp(request.NamespacedName, cephDRAction, status), cephDRAction, nil
+	}
+	r.complete(request.NamespacedName, status, err)
+
+	// Return and do not requeue, the failure is reported in the status
+	return reconcile.Result{}, cephDRAction, nil
+}
+
+// ValidateDRActionSpec validates the dr action settings
+func ValidateDRActionSpec(spec *cephv1.DRActionSpec) error {
+	switch spec.Action {
+	case cephv1.DRActionPromote, cephv1.DRActionDemote, cephv1.DRActionQuiesce:
+	default:
+		return errors.Errorf("unknown action %q", spec.Action)
+	}
+	if spec.Force && spec.Action != cephv1.DRActionPromote {
+		return errors.New("force is only supported to promote")
+	}
+	if len(spec.Subvolumes) > 0 && spec.Action != cephv1.DRActionQuiesce {
+		return errors.New("subvolumes are only supported to quiesce")
+	}
+	if len(spec.Pools) == 0 && len(spec.Images) == 0 && len(spec.Filesystems) == 0 && len(spec.Subvolumes) == 0 {
+		return errors.New("at least one pool, image, filesystem or subvolume must be specified")
+	}
+	for _, image := range spec.Images {
+		if image.Pool == "" || image.Image == "" {
+			return errors.New("the pool and the name of the images must be specified")
+		}
+	}
+	for _, subvolume := range spec.Subvolumes {
+		if subvolume.Filesystem == "" || subvolume.Name == "" {
+			return errors.New("the filesystem and the name of the subvolumes must be specified")
+		}
+	}
+	return nil
+}
+
+// complete records the end of the action
+func (r *ReconcileCephDRAction) complete(name types.NamespacedName, status *cephv1.CephDRActionStatus, err error) {
+	status.CompletionTime = now()
+	if err != nil {
+		logger.Errorf("dr action %q failed. %v", name, err)
+		status.Phase = cephv1.DRActionPhaseFailed
+		status.Message = err.Error()
+	} else {
+		logger.Infof("dr action %q succeeded", name)
+		status.Phase = cephv1.DRActionPhaseSucceeded
+	}
+	r.updateStatus(name, status)
+}
+
+// updateStatus updates an object with a given status
+func (r *ReconcileCephDRAction) updateStatus(name types.NamespacedName, status *cephv1.CephDRActionStatus) {
+	cephDRAction := &cephv1.CephDRAction{}
+	if err := r.client.Get(r.opManagerContext, name, cephDRAction); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephDRAction resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve dr action %q to update status to %q. %v", name, status.Phase, err)
+		return
+	}
+
+	err := reporting.PatchStatus(r.client, cephDRAction, func() bool {
+		// the standard conditions are set from the result of the reconcile
+		if cephDRAction.Status != nil && status.Conditions == nil {
+			status.Conditions = cephDRAction.Status.Conditions
+		}
+		cephDRAction.Status = status
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set dr action %q status to %q. %v", name, status.Phase, err)
+		return
+	}
+	logger.Debugf("dr action %q status updated to %q", name, status.Phase)
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package draction
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	peerInfo      = `{"mode":"image","site_name":"local","peers":[{"uuid":"4a6983c0-3c9d-40f5-b2a9-2334a4659827","direction":"rx-tx","site_name":"remote"}]}`
+	poolStatus    = `{"summary":{"health":"OK","daemon_health":"OK","image_health":"OK"}}`
+	syncedImages  = `{"images":[{"name":"image1","state":"up+stopped","description":"local image is primary","peer_sites":[{"site_name":"remote","state":"up+replaying","description":"replaying, {\"local_snapshot_timestamp\":1646128800,\"remote_snapshot_timestamp\":1646128800}"}]}]}`
+	syncingImages = `{"images":[{"name":"image1","state":"up+stopped","description":"local image is primary","peer_sites":[{"site_name":"remote","state":"up+replaying","description":"replaying, {\"local_snapshot_timestamp\":1646128800,\"remote_snapshot_timestamp\":1646129100}"}]}]}`
+)
+
+func TestValidateSpec(t *testing.T) {
+	spec := &cephv1.DRActionSpec{Action: cephv1.DRActionDemote, Pools: []string{"a"}}
+	assert.NoError(t, ValidateDRActionSpec(spec))
+
+	spec.Force = true
+	assert.Error(t, ValidateDRActionSpec(spec))
+
+	spec.Action = cephv1.DRActionPromote
+	assert.NoError(t, ValidateDRActionSpec(spec))
+
+	spec.Action = "failover"
+	assert.Error(t, ValidateDRActionSpec(spec))
+
+	spec = &cephv1.DRActionSpec{Action: cephv1.DRActionPromote}
+	assert.Error(t, ValidateDRActionSpec(spec))
+
+	spec.Images = []cephv1.DRActionImageSpec{{Pool: "a"}}
+	assert.Error(t, ValidateDRActionSpec(spec))
+
+	spec = &cephv1.DRActionSpec{Action: cephv1.DRActionQuiesce, Subvolumes: []cephv1.DRActionSubvolumeSpec{{Filesystem: "myfs", Name: "subvol1"}}}
+	assert.NoError(t, ValidateDRActionSpec(spec))
+
+	spec.Subvolumes[0].Filesystem = ""
+	assert.Error(t, ValidateDRActionSpec(spec))
+
+	spec = &cephv1.DRActionSpec{Action: cephv1.DRActionDemote, Subvolumes: []cephv1.DRActionSubvolumeSpec{{Filesystem: "myfs", Name: "subvol1"}}}
+	assert.Error(t, ValidateDRActionSpec(spec))
+}
+
+func TestCheckImageSynced(t *testing.T) {
+	peerSite := cephclient.MirroredImagePeerSiteStatus{SiteName: "remote", State: "up+replaying", Description: "replaying"}
+	image := cephclient.MirroredImageStatus{Name: "image1", State: "up+stopped", Description: "local image is primary"}
+	assert.Error(t, checkImageSynced(image))
+
+	image.PeerSites = []cephclient.MirroredImagePeerSiteStatus{peerSite}
+	assert.NoError(t, checkImageSynced(image))
+
+	image.PeerSites[0].State = "down+unknown"
+	assert.Error(t, checkImageSynced(image))
+
+	image.PeerSites[0] = peerSite
+	image.State = "up+error"
+	assert.Error(t, checkImageSynced(image))
+
+	image.State = "up+stopped"
+	image.PeerSites[0].Description = `replaying, {"entries_behind_primary":3}`
+	assert.Error(t, checkImageSynced(image))
+
+	image.PeerSites[0].Description = `replaying, {"local_snapshot_timestamp":1646128800,"remote_snapshot_timestamp":1646129100}`
+	assert.Error(t, checkImageSynced(image))
+}
+
+func TestRunAction(t *testing.T) {
+	ctx := context.TODO()
+	objects := []runtime.Object{
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Namespace: "rook-ceph"},
+			Spec:       cephv1.NamedBlockPoolSpec{PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}}},
+		},
+		&cephv1.CephFilesystem{
+			ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph"},
+			Spec:       cephv1.FilesystemSpec{Mirroring: &cephv1.FSMirroringSpec{Enabled: true}},
+			Status: &cephv1.CephFilesystemStatus{MirroringStatus: &cephv1.FilesystemMirroringInfoSpec{
+				Peers: []cephv1.FilesystemMirrorPeerStatus{{UUID: "4a6983c0-3c9d-40f5-b2a9-2334a4659827", SnapsSynced: 4}},
+			}},
+		},
+	}
+	action := &cephv1.CephDRAction{
+		ObjectMeta: metav1.ObjectMeta{Name: "failover", Namespace: "rook-ceph"},
+		Spec: cephv1.DRActionSpec{
+			Action:      cephv1.DRActionDemote,
+			Pools:       []string{"mirrored"},
+			Images:      []cephv1.DRActionImageSpec{{Pool: "mirrored", Image: "image1"}},
+			Filesystems: []string{"myfs"},
+		},
+	}
+	objects = append(objects, action)
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{}, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{}, &cephv1.CephDRAction{}, &cephv1.CephDRActionList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
+
+	images := syncedImages
+	var actions []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if len(args) < 4 || args[0] != "mirror" {
+				return "", errors.New("unknown command")
+			}
+			switch {
+			case args[1] == "pool" && args[2] == "info":
+				return peerInfo, nil
+			case args[1] == "pool" && args[2] == "status" && args[4] == "--verbose":
+				return images, nil
+			case args[1] == "pool" && args[2] == "status":
+				return poolStatus, nil
+			case args[2] == "promote" || args[2] == "demote":
+				action := args[1] + " " + args[2] + " " + args[3]
+				if args[4] == "--force" {
+					action += " --force"
+				}
+				actions = append(actions, action)
+				return "", nil
+			}
+			return "", errors.New("unknown command")
+		},
+	}
+	r := &ReconcileCephDRAction{
+		client:           cl,
+		scheme:           s,
+		context:          &clusterd.Context{Executor: executor},
+		clusterInfo:      cephclient.AdminTestClusterInfo("rook-ceph"),
+		opManagerContext: ctx,
+	}
+	name := types.NamespacedName{Name: "failover", Namespace: "rook-ceph"}
+
+	t.Run("demote after the pre-checks", func(t *testing.T) {
+		status := &cephv1.CephDRActionStatus{}
+		err := r.runAction(name, action, status)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"pool demote mirrored", "image demote mirrored/image1"}, actions)
+		assert.Equal(t, 5, len(status.Steps))
+		assert.Equal(t, "check pool mirrored", status.Steps[0].Name)
+		assert.Equal(t, "1 images synced with 1 peers", status.Steps[0].Message)
+		assert.Equal(t, "check images of pool mirrored", status.Steps[1].Name)
+		assert.Equal(t, "check filesystem myfs", status.Steps[2].Name)
+		assert.Contains(t, status.Steps[2].Message, "4 snapshots synced with 1 peers")
+		assert.Equal(t, "demote pool mirrored", status.Steps[3].Name)
+		assert.Equal(t, "demote image mirrored/image1", status.Steps[4].Name)
+		for _, step := range status.Steps {
+			assert.True(t, step.Succeeded)
+		}
+
+		// the steps are reported in the status of the CR
+		err = cl.Get(ctx, name, action)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, len(action.Status.Steps))
+	})
+
+	t.Run("nothing is demoted when an image is not synced", func(t *testing.T) {
+		actions = nil
+		images = syncingImages
+		status := &cephv1.CephDRActionStatus{}
+		err := r.runAction(name, action, status)
+		assert.Error(t, err)
+		assert.Nil(t, actions)
+		assert.Equal(t, 1, len(status.Steps))
+		assert.False(t, status.Steps[0].Succeeded)
+		assert.Contains(t, status.Steps[0].Message, "the last mirror snapshot is not synced yet")
+	})
+
+	t.Run("forced promotion skips the pre-checks", func(t *testing.T) {
+		actions = nil
+		action.Spec = cephv1.DRActionSpec{Action: cephv1.DRActionPromote, Force: true, Pools: []string{"mirrored"}}
+		status := &cephv1.CephDRActionStatus{}
+		err := r.runAction(name, action, status)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"pool promote mirrored --force"}, actions)
+		assert.Equal(t, 1, len(status.Steps))
+	})
+}
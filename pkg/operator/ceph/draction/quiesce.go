@@ -0,0 +1,467 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package draction
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/file"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	// quiesceCheckInterval is the interval at which the sync of the final snapshots is checked
+	quiesceCheckInterval = 30 * time.Second
+
+	// maxPendingReported is the maximum number of resources not synced yet listed in the status
+	maxPendingReported = 20
+
+	// peerSyncStatus returns the sync status of the mirrored directories of a filesystem with a peer
+	peerSyncStatus = file.PeerSyncStatus
+)
+
+// quiesce pauses the snapshot schedules of the pools and filesystems of the action, then takes a
+// final mirror snapshot of the images and subvolumes. The schedules are paused first so that no
+// scheduled snapshot is taken after the final one.
+func (r *ReconcileCephDRAction) quiesce(action *cephv1.CephDRAction, status *cephv1.CephDRActionStatus, step stepFunc) error {
+	spec := &action.Spec
+	for _, poolName := range opcontroller.DRActionPools(action) {
+		poolName := poolName
+		err := step(fmt.Sprintf("pause snapshot schedules of pool %s", poolName), func() (string, error) {
+			pool, err := r.getPool(action.Namespace, poolName)
+			if err != nil {
+				return "", err
+			}
+			paused, err := cephclient.PauseSnapshotSchedules(r.context, r.clusterInfo, cephPoolSpec(pool))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d snapshot schedules paused", paused), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, fsName := range opcontroller.DRActionFilesystems(action) {
+		fsName := fsName
+		err := step(fmt.Sprintf("pause snapshot schedules of filesystem %s", fsName), func() (string, error) {
+			fs, err := r.getFilesystem(action.Namespace, fsName)
+			if err != nil {
+				return "", err
+			}
+			paths := schedulePaths(fs)
+			for _, schedulePath := range paths {
+				if err := cephclient.DeactivateSnapshotSchedule(r.context, r.clusterInfo, schedulePath, fsName); err != nil {
+					return "", err
+				}
+			}
+			return fmt.Sprintf("snapshot schedules of %d paths paused", len(paths)), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	status.Quiesce = &cephv1.DRActionQuiesceStatus{
+		SnapshotName: fmt.Sprintf("quiesce-%s-%d", action.Name, action.Generation),
+		QuiescedTime: now(),
+	}
+
+	for _, poolName := range spec.Pools {
+		poolName := poolName
+		err := step(fmt.Sprintf("snapshot pool %s", poolName), func() (string, error) {
+			return r.snapshotImages(poolName, nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	pools, images := imagesByPool(spec.Images)
+	for _, pool := range pools {
+		pool := pool
+		err := step(fmt.Sprintf("snapshot images of pool %s", pool), func() (string, error) {
+			return r.snapshotImages(pool, images[pool])
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, subvolume := range spec.Subvolumes {
+		subvolume := subvolume
+		err := step(fmt.Sprintf("snapshot subvolume %s", subvolumeName(subvolume)), func() (string, error) {
+			err := cephclient.CreateCephFSSubvolumeSnapshot(r.context, r.clusterInfo, subvolume.Filesystem, subvolume.Group, subvolume.Name, status.Quiesce.SnapshotName)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("snapshot %s taken", status.Quiesce.SnapshotName), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotImages takes a mirror snapshot of the given images, or of all the mirrored images of the
+// pool. The images mirrored with journaling have no snapshot to take, the peers replay the journal.
+func (r *ReconcileCephDRAction) snapshotImages(poolName string, imageNames []string) (string, error) {
+	all := imageNames == nil
+	if all {
+		imagesStatus, err := cephclient.GetPoolMirroringImagesStatus(r.context, r.clusterInfo, poolName)
+		if err != nil {
+			return "", err
+		}
+		for _, image := range imagesStatus.Images {
+			imageNames = append(imageNames, image.Name)
+		}
+	}
+
+	snapshots, journals := 0, 0
+	for _, imageName := range imageNames {
+		info, err := cephclient.GetImageInfo(r.context, r.clusterInfo, imageName, poolName)
+		if err != nil {
+			return "", err
+		}
+		if info.Mirroring == nil {
+			if all {
+				continue
+			}
+			return "", errors.Errorf("image %q of pool %q is not mirrored", imageName, poolName)
+		}
+		if !info.Mirroring.Primary {
+			return "", errors.Errorf("image %q of pool %q is not primary, the quiesce must run in the primary cluster", imageName, poolName)
+		}
+		if info.Mirroring.Mode != "snapshot" {
+			journals++
+			continue
+		}
+		if err := cephclient.CreateMirrorImageSnapshot(r.context, r.clusterInfo, poolName, imageName); err != nil {
+			return "", err
+		}
+		snapshots++
+	}
+
+	return fmt.Sprintf("%d mirror snapshots taken, %d images mirrored with journaling", snapshots, journals), nil
+}
+
+// waitForCatchUp checks whether the peers synced all the final snapshots of a quiesce action. The
+// action succeeds once they did, otherwise the resources not synced yet are reported in the status
+// and the check is requeued.
+func (r *ReconcileCephDRAction) waitForCatchUp(name types.NamespacedName, action *cephv1.CephDRAction, status *cephv1.CephDRActionStatus) reconcile.Result {
+	pending := r.pendingSync(action, status.Quiesce)
+	status.Quiesce.LastChecked = now()
+	if len(pending) > maxPendingReported {
+		pending = append(pending[:maxPendingReported], fmt.Sprintf("and %d more", len(pending)-maxPendingReported))
+	}
+	status.Quiesce.Pending = pending
+
+	if len(pending) > 0 {
+		logger.Infof("waiting for the peers to sync the final snapshots of dr action %q. %s", name, pending[0])
+		r.updateStatus(name, status)
+		return reconcile.Result{RequeueAfter: quiesceCheckInterval}
+	}
+
+	status.Quiesce.CaughtUpTime = now()
+	status.Steps = append(status.Steps, cephv1.DRActionStepStatus{
+		Name:      "wait for the peers to catch up",
+		Succeeded: true,
+		Message:   "the peers synced all the final snapshots",
+		Time:      status.Quiesce.CaughtUpTime,
+	})
+	r.complete(name, status, nil)
+	return reconcile.Result{}
+}
+
+// pendingSync returns the images, subvolumes and filesystems of a quiesce action whose final
+// snapshot is not synced by the peers yet
+func (r *ReconcileCephDRAction) pendingSync(action *cephv1.CephDRAction, quiesce *cephv1.DRActionQuiesceStatus) []string {
+	spec := &action.Spec
+	quiescedTime, err := time.Parse(time.RFC3339, quiesce.QuiescedTime)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid quiesce time %q", quiesce.QuiescedTime)}
+	}
+
+	pending := []string{}
+	for _, poolName := range spec.Pools {
+		pending = append(pending, r.pendingImages(poolName, nil, quiescedTime)...)
+	}
+	pools, images := imagesByPool(spec.Images)
+	for _, pool := range pools {
+		pending = append(pending, r.pendingImages(pool, images[pool], quiescedTime)...)
+	}
+	for _, fsName := range opcontroller.DRActionFilesystems(action) {
+		subvolumes := []cephv1.DRActionSubvolumeSpec{}
+		for _, subvolume := range spec.Subvolumes {
+			if subvolume.Filesystem == fsName {
+				subvolumes = append(subvolumes, subvolume)
+			}
+		}
+		pending = append(pending, r.pendingFilesystem(action.Namespace, fsName, subvolumes, quiesce.SnapshotName)...)
+	}
+	return pending
+}
+
+// pendingImages returns the given images, or the mirrored images of the pool, that the peers did not
+// sync up to the final snapshot yet
+func (r *ReconcileCephDRAction) pendingImages(poolName string, imageNames []string, quiescedTime time.Time) []string {
+	imagesStatus, err := cephclient.GetPoolMirroringImagesStatus(r.context, r.clusterInfo, poolName)
+	if err != nil {
+		return []string{fmt.Sprintf("pool %s: %v", poolName, err)}
+	}
+
+	pending := []string{}
+	wanted := map[string]bool{}
+	for _, imageName := range imageNames {
+		wanted[imageName] = true
+	}
+	for _, image := range imagesStatus.Images {
+		if imageNames != nil && !wanted[image.Name] {
+			continue
+		}
+		if err := checkImageCaughtUp(image, quiescedTime); err != nil {
+			pending = append(pending, fmt.Sprintf("image %s/%s: %v", poolName, image.Name, err))
+		}
+	}
+	return pending
+}
+
+// checkImageCaughtUp checks that the peer sites synced an image up to its final snapshot. An image
+// mirrored with snapshots reports the time of the last snapshot of the primary image known by the
+// peer, which must not be older than the final snapshot. An image mirrored with journaling is
+// caught up when no journal entry is left to replay.
+func checkImageCaughtUp(image cephclient.MirroredImageStatus, quiescedTime time.Time) error {
+	if err := checkImageSynced(image); err != nil {
+		return err
+	}
+	for _, site := range image.PeerSites {
+		replay, ok := cephclient.ParseImageReplayStatus(site.Description)
+		if !ok {
+			return errors.Errorf("the peer site %q reports no replay progress", site.SiteName)
+		}
+		// only the images mirrored with snapshots report snapshot times
+		if replay.RemoteSnapshotTimestamp == 0 && replay.LocalSnapshotTimestamp == 0 {
+			continue
+		}
+		if replay.RemoteSnapshotTimestamp < quiescedTime.Unix() {
+			return errors.Errorf("the peer site %q did not see the final snapshot yet", site.SiteName)
+		}
+	}
+	return nil
+}
+
+// pendingFilesystem returns the directories of a mirrored filesystem that are not synced with the
+// peers yet, and the subvolumes whose final snapshot is not synced yet
+func (r *ReconcileCephDRAction) pendingFilesystem(namespace, fsName string, subvolumes []cephv1.DRActionSubvolumeSpec, snapshotName string) []string {
+	fs, err := r.getFilesystem(namespace, fsName)
+	if err != nil {
+		return []string{fmt.Sprintf("filesystem %s: %v", fsName, err)}
+	}
+	daemonFS := mirroredFilesystem(fs)
+	if daemonFS == nil {
+		return []string{fmt.Sprintf("filesystem %s: no cephfs-mirror daemon reports the filesystem", fsName)}
+	}
+
+	subvolumePaths := map[string]string{}
+	for _, subvolume := range subvolumes {
+		info, err := cephclient.GetCephFSSubvolumeInfo(r.context, r.clusterInfo, fsName, subvolume.Group, subvolume.Name)
+		if err != nil {
+			return []string{fmt.Sprintf("subvolume %s: %v", subvolumeName(subvolume), err)}
+		}
+		subvolumePaths[subvolumeName(subvolume)] = info.Path
+	}
+
+	pending := []string{}
+	for _, peer := range daemonFS.Peers {
+		directories, err := peerSyncStatus(r.context, namespace, fsName, daemonFS.FilesystemID, peer.UUID)
+		if err != nil {
+			pending = append(pending, fmt.Sprintf("filesystem %s peer %s: %v", fsName, peer.UUID, err))
+			continue
+		}
+		for directory, directoryStatus := range directories {
+			if directoryStatus.State != "idle" {
+				pending = append(pending, fmt.Sprintf("directory %s of filesystem %s: %s with peer %s", directory, fsName, directoryStatus.State, peer.UUID))
+			}
+		}
+		for subvolume, subvolumePath := range subvolumePaths {
+			if err := checkSubvolumeCaughtUp(directories, subvolumePath, snapshotName); err != nil {
+				pending = append(pending, fmt.Sprintf("subvolume %s: %v with peer %s", subvolume, err, peer.UUID))
+			}
+		}
+	}
+	return pending
+}
+
+// checkSubvolumeCaughtUp checks that the final snapshot of a subvolume was synced with a peer. The
+// snapshots of a subvolume are taken in its base directory, the parent of its data path, they are
+// found in the data path with the name of the snapshot in the middle.
+func checkSubvolumeCaughtUp(directories map[string]cephclient.FSMirrorPeerDirectoryStatus, subvolumePath, snapshotName string) error {
+	for _, directory := range []string{subvolumePath, path.Dir(subvolumePath)} {
+		directoryStatus, ok := directories[directory]
+		if !ok {
+			continue
+		}
+		if !strings.Contains(directoryStatus.LastSynced.Name, snapshotName) {
+			return errors.Errorf("the last synced snapshot is %q", directoryStatus.LastSynced.Name)
+		}
+		return nil
+	}
+	return errors.Errorf("neither %q nor its parent is a mirrored directory", subvolumePath)
+}
+
+// resumeSchedules adds again or activates the snapshot schedules paused by a deleted quiesce action,
+// except for the resources still quiesced by another action
+func (r *ReconcileCephDRAction) resumeSchedules(action *cephv1.CephDRAction) error {
+	for _, poolName := range opcontroller.DRActionPools(action) {
+		name := types.NamespacedName{Name: poolName, Namespace: action.Namespace}
+		quiesced, err := opcontroller.IsPoolQuiesced(r.opManagerContext, r.client, name)
+		if err != nil {
+			return err
+		}
+		if quiesced {
+			logger.Infof("not resuming the snapshot schedules of pool %q, it is quiesced by another dr action", poolName)
+			continue
+		}
+		pool, err := r.getPool(action.Namespace, poolName)
+		if err != nil {
+			logger.Warningf("not resuming the snapshot schedules of pool %q. %v", poolName, err)
+			continue
+		}
+		if !pool.Spec.Mirroring.Enabled || !pool.Spec.Mirroring.SnapshotSchedulesEnabled() {
+			continue
+		}
+		resumed, err := cephclient.RepairSnapshotSchedules(r.context, r.clusterInfo, cephPoolSpec(pool))
+		if err != nil {
+			return errors.Wrapf(err, "failed to resume the snapshot schedules of pool %q", poolName)
+		}
+		logger.Infof("resumed %d snapshot schedules of pool %q", resumed, poolName)
+	}
+
+	for _, fsName := range opcontroller.DRActionFilesystems(action) {
+		name := types.NamespacedName{Name: fsName, Namespace: action.Namespace}
+		quiesced, err := opcontroller.IsFilesystemQuiesced(r.opManagerContext, r.client, name)
+		if err != nil {
+			return err
+		}
+		if quiesced {
+			logger.Infof("not resuming the snapshot schedules of filesystem %q, it is quiesced by another dr action", fsName)
+			continue
+		}
+		fs, err := r.getFilesystem(action.Namespace, fsName)
+		if err != nil {
+			logger.Warningf("not resuming the snapshot schedules of filesystem %q. %v", fsName, err)
+			continue
+		}
+		if fs.Spec.Mirroring == nil || !fs.Spec.Mirroring.Enabled {
+			continue
+		}
+		// the schedules added to the spec while the filesystem was quiesced are added now
+		for _, snap := range fs.Spec.Mirroring.SnapshotSchedules {
+			err = cephclient.AddSnapshotSchedule(r.context, r.clusterInfo, snap.Path, snap.Interval, snap.StartTime, fsName)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add snapshot schedules on filesystem %q", fsName)
+			}
+		}
+		for _, schedulePath := range schedulePaths(fs) {
+			if err := cephclient.ActivateSnapshotSchedule(r.context, r.clusterInfo, schedulePath, fsName); err != nil {
+				return errors.Wrapf(err, "failed to resume the snapshot schedules of filesystem %q", fsName)
+			}
+		}
+		logger.Infof("resumed the snapshot schedules of filesystem %q", fsName)
+	}
+	return nil
+}
+
+func (r *ReconcileCephDRAction) getPool(namespace, poolName string) (*cephv1.CephBlockPool, error) {
+	pool := &cephv1.CephBlockPool{}
+	err := r.client.Get(r.opManagerContext, types.NamespacedName{Name: poolName, Namespace: namespace}, pool)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, errors.Errorf("pool %q not found", poolName)
+		}
+		return nil, errors.Wrapf(err, "failed to get pool %q", poolName)
+	}
+	return pool, nil
+}
+
+func (r *ReconcileCephDRAction) getFilesystem(namespace, fsName string) (*cephv1.CephFilesystem, error) {
+	fs := &cephv1.CephFilesystem{}
+	err := r.client.Get(r.opManagerContext, types.NamespacedName{Name: fsName, Namespace: namespace}, fs)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, errors.Errorf("filesystem %q not found", fsName)
+		}
+		return nil, errors.Wrapf(err, "failed to get filesystem %q", fsName)
+	}
+	return fs, nil
+}
+
+// cephPoolSpec returns the spec of a pool with the name of its ceph pool
+func cephPoolSpec(pool *cephv1.CephBlockPool) cephv1.NamedPoolSpec {
+	spec := pool.Spec.ToNamedPoolSpec()
+	if spec.Name == "" {
+		spec.Name = pool.Name
+	}
+	return spec
+}
+
+// schedulePaths returns the paths of the snapshot schedules of a filesystem
+func schedulePaths(fs *cephv1.CephFilesystem) []string {
+	paths := []string{}
+	if fs.Spec.Mirroring == nil {
+		return paths
+	}
+	seen := map[string]bool{}
+	for _, snap := range fs.Spec.Mirroring.SnapshotSchedules {
+		if !seen[snap.Path] {
+			seen[snap.Path] = true
+			paths = append(paths, snap.Path)
+		}
+	}
+	return paths
+}
+
+// mirroredFilesystem returns the filesystem as reported by the cephfs-mirror daemons in the status of
+// the filesystem, with its ID and peers
+func mirroredFilesystem(fs *cephv1.CephFilesystem) *cephv1.FilesystemsSpec {
+	if fs.Status == nil || fs.Status.MirroringStatus == nil {
+		return nil
+	}
+	for _, daemon := range fs.Status.MirroringStatus.FilesystemMirroringAllInfo {
+		for i := range daemon.Filesystems {
+			if daemon.Filesystems[i].Name == fs.Name {
+				return &daemon.Filesystems[i]
+			}
+		}
+	}
+	return nil
+}
+
+func subvolumeName(subvolume cephv1.DRActionSubvolumeSpec) string {
+	if subvolume.Group == "" {
+		return fmt.Sprintf("%s/%s", subvolume.Filesystem, subvolume.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", subvolume.Filesystem, subvolume.Group, subvolume.Name)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package draction
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckImageCaughtUp(t *testing.T) {
+	quiescedTime := time.Unix(1646129000, 0)
+	image := cephclient.MirroredImageStatus{
+		Name:        "image1",
+		State:       "up+stopped",
+		Description: "local image is primary",
+		PeerSites: []cephclient.MirroredImagePeerSiteStatus{{
+			SiteName:    "remote",
+			State:       "up+replaying",
+			Description: `replaying, {"local_snapshot_timestamp":1646128800,"remote_snapshot_timestamp":1646128800}`,
+		}},
+	}
+	// the peer did not see the final snapshot yet
+	assert.Error(t, checkImageCaughtUp(image, quiescedTime))
+
+	image.PeerSites[0].Description = `replaying, {"local_snapshot_timestamp":1646129100,"remote_snapshot_timestamp":1646129100}`
+	assert.NoError(t, checkImageCaughtUp(image, quiescedTime))
+
+	// journaling
+	image.PeerSites[0].Description = `replaying, {"entries_behind_primary":0}`
+	assert.NoError(t, checkImageCaughtUp(image, quiescedTime))
+
+	image.PeerSites[0].Description = "replaying"
+	assert.Error(t, checkImageCaughtUp(image, quiescedTime))
+}
+
+func TestCheckSubvolumeCaughtUp(t *testing.T) {
+	subvolumePath := "/volumes/csi/subvol1/5b5d1d3c-8f4a-4a0b-b0a4-2ff6b5a2b9a1"
+	directories := map[string]cephclient.FSMirrorPeerDirectoryStatus{}
+	assert.Error(t, checkSubvolumeCaughtUp(directories, subvolumePath, "quiesce-failover-1"))
+
+	status := cephclient.FSMirrorPeerDirectoryStatus{State: "idle"}
+	status.LastSynced.Name = "scheduled-2022-03-01-10_00_00"
+	directories["/volumes/csi/subvol1"] = status
+	assert.Error(t, checkSubvolumeCaughtUp(directories, subvolumePath, "quiesce-failover-1"))
+
+	status.LastSynced.Name = "quiesce-failover-1"
+	directories["/volumes/csi/subvol1"] = status
+	assert.NoError(t, checkSubvolumeCaughtUp(directories, subvolumePath, "quiesce-failover-1"))
+
+	// the snapshot of the subvolume seen from its data path
+	delete(directories, "/volumes/csi/subvol1")
+	status.LastSynced.Name = "_quiesce-failover-1_1099511627776"
+	directories[subvolumePath] = status
+	assert.NoError(t, checkSubvolumeCaughtUp(directories, subvolumePath, "quiesce-failover-1"))
+}
+
+func TestQuiesce(t *testing.T) {
+	ctx := context.TODO()
+	pool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Namespace: "rook-ceph"},
+		Spec: cephv1.NamedBlockPoolSpec{PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{
+			Enabled:           true,
+			Mode:              "image",
+			SnapshotSchedules: []cephv1.SnapshotScheduleSpec{{Interval: "1d"}},
+		}}},
+	}
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph"},
+		Spec: cephv1.FilesystemSpec{Mirroring: &cephv1.FSMirroringSpec{
+			Enabled:           true,
+			SnapshotSchedules: []cephv1.SnapshotScheduleSpec{{Path: "/", Interval: "1h"}},
+		}},
+		Status: &cephv1.CephFilesystemStatus{MirroringStatus: &cephv1.FilesystemMirroringInfoSpec{
+			FilesystemMirroringAllInfo: []cephv1.FilesystemMirroringInfo{{Filesystems: []cephv1.FilesystemsSpec{{
+				FilesystemID: 1,
+				Name:         "myfs",
+				Peers:        []cephv1.FilesystemMirrorInfoPeerSpec{{UUID: "4a6983c0-3c9d-40f5-b2a9-2334a4659827"}},
+			}}}},
+			Peers: []cephv1.FilesystemMirrorPeerStatus{{UUID: "4a6983c0-3c9d-40f5-b2a9-2334a4659827"}},
+		}},
+	}
+	action := &cephv1.CephDRAction{
+		ObjectMeta: metav1.ObjectMeta{Name: "failover", Namespace: "rook-ceph", Generation: 1},
+		Spec: cephv1.DRActionSpec{
+			Action:     cephv1.DRActionQuiesce,
+			Pools:      []string{"mirrored"},
+			Subvolumes: []cephv1.DRActionSubvolumeSpec{{Filesystem: "myfs", Group: "csi", Name: "subvol1"}},
+		},
+	}
+	s := scheme.Scheme
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(pool, fs, action).Build()
+
+	images := syncedImages
+	var commands []string
+	// records a command without the cluster flags
+	record := func(args []string) {
+		for i, arg := range args {
+			if strings.HasPrefix(arg, "--connect-timeout") || strings.HasPrefix(arg, "--cluster") {
+				args = args[:i]
+				break
+			}
+		}
+		commands = append(commands, strings.Join(args, " "))
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			switch {
+			case args[0] == "info":
+				return `{"name":"image1","mirroring":{"mode":"snapshot","state":"enabled","primary":true}}`, nil
+			case args[0] == "fs" && args[2] == "info":
+				return `{"path":"/volumes/csi/subvol1/5b5d1d3c-8f4a-4a0b-b0a4-2ff6b5a2b9a1"}`, nil
+			case args[0] == "fs":
+				record(args)
+				return "", nil
+			case args[0] != "mirror":
+			case args[1] == "pool" && args[2] == "info":
+				return peerInfo, nil
+			case args[1] == "pool" && args[2] == "status" && args[4] == "--verbose":
+				return images, nil
+			case args[1] == "pool" && args[2] == "status":
+				return poolStatus, nil
+			case args[1] == "snapshot" && args[3] == "ls":
+				return `[{"pool":"mirrored","namespace":"-","image":"-","items":[{"interval":"1d","start_time":""}]}]`, nil
+			case args[1] == "snapshot" && args[3] == "remove", args[1] == "image" && args[2] == "snapshot":
+				record(args)
+				return "", nil
+			}
+			return "", errors.New("unknown command")
+		},
+	}
+	r := &ReconcileCephDRAction{
+		client:           cl,
+		scheme:           s,
+		context:          &clusterd.Context{Executor: executor},
+		clusterInfo:      cephclient.AdminTestClusterInfo("rook-ceph"),
+		opManagerContext: ctx,
+	}
+	name := types.NamespacedName{Name: "failover", Namespace: "rook-ceph"}
+
+	directoryStatus := cephclient.FSMirrorPeerDirectoryStatus{State: "idle"}
+	peerSyncStatus = func(context *clusterd.Context, namespace, fsName string, fsID int, peerUUID string) (map[string]cephclient.FSMirrorPeerDirectoryStatus, error) {
+		return map[string]cephclient.FSMirrorPeerDirectoryStatus{"/volumes/csi/subvol1": directoryStatus}, nil
+	}
+
+	status := &cephv1.CephDRActionStatus{ObservedGeneration: 1, Phase: cephv1.DRActionPhaseRunning}
+	t.Run("pause the schedules and take the final snapshots", func(t *testing.T) {
+		err := r.runAction(name, action, status)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"mirror snapshot schedule remove --pool mirrored 1d",
+			"fs snap-schedule deactivate / fs=myfs",
+			"mirror image snapshot mirrored/image1",
+			"fs subvolume snapshot create myfs subvol1 quiesce-failover-1 --group_name csi",
+		}, commands)
+		assert.Equal(t, "quiesce-failover-1", status.Quiesce.SnapshotName)
+		for _, step := range status.Steps {
+			assert.True(t, step.Succeeded, step.Name)
+		}
+	})
+
+	t.Run("wait for the peers to catch up", func(t *testing.T) {
+		result := r.waitForCatchUp(name, action, status)
+		assert.Equal(t, quiesceCheckInterval, result.RequeueAfter)
+		assert.Equal(t, cephv1.DRActionPhaseRunning, status.Phase)
+		// neither the image nor the subvolume are synced
+		assert.Equal(t, 2, len(status.Quiesce.Pending))
+
+		images = strings.ReplaceAll(syncedImages, "1646128800", "9646128800")
+		directoryStatus.LastSynced.Name = status.Quiesce.SnapshotName
+		result = r.waitForCatchUp(name, action, status)
+		assert.Zero(t, result.RequeueAfter)
+		assert.Equal(t, cephv1.DRActionPhaseSucceeded, status.Phase)
+		assert.Empty(t, status.Quiesce.Pending)
+		assert.NotEmpty(t, status.Quiesce.CaughtUpTime)
+	})
+
+	t.Run("resume the schedules", func(t *testing.T) {
+		commands = nil
+		// the schedules are resumed when the action is deleted
+		err := cl.Delete(ctx, action)
+		assert.NoError(t, err)
+		err = r.resumeSchedules(action)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"fs snap-schedule add / 1h fs=myfs", "fs snap-schedule activate / fs=myfs"}, commands)
+	})
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	fsClientLabels     = []string{"namespace", "filesystem", "client_id", "ip", "hostname"}
+	fsClientInfoLabels = append(append([]string{}, fsClientLabels...), "state", "kernel_version", "ceph_version", "entity_id", "root")
+
+	fsClientCaps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_fs_client_caps",
+		Help: "The number of capabilities held by a client of the filesystem on its active MDS daemons",
+	}, fsClientLabels)
+	fsClientLeases = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_fs_client_leases",
+		Help: "The number of dentry leases held by a client of the filesystem on its active MDS daemons",
+	}, fsClientLabels)
+	fsClientRequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_fs_client_requests_in_flight",
+		Help: "The number of requests of a client of the filesystem in flight on its active MDS daemons",
+	}, fsClientLabels)
+	fsClientSessionAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_fs_client_session_age_seconds",
+		Help: "The age of the oldest session of a client of the filesystem with its active MDS daemons",
+	}, fsClientLabels)
+	fsClientInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_fs_client_info",
+		Help: "The state of the session and the versions of a client of the filesystem, always 1",
+	}, fsClientInfoLabels)
+
+	fsClientMetrics = []*prometheus.GaugeVec{fsClientCaps, fsClientLeases, fsClientRequestsInFlight, fsClientSessionAge}
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		fsClientCaps,
+		fsClientLeases,
+		fsClientRequestsInFlight,
+		fsClientSessionAge,
+		fsClientInfo,
+	)
+}
+
+// clientMetrics exports the sessions of the clients of a filesystem, listed from its active MDS
+// daemons. It keeps the labels of the clients it exported to remove them when they are disconnected.
+type clientMetrics struct {
+	context        *clusterd.Context
+	client         client.Client
+	clusterInfo    *cephclient.ClusterInfo
+	namespacedName types.NamespacedName
+	clients        map[string][]string
+	infos          map[string][]string
+}
+
+func newClientMetrics(context *clusterd.Context, client client.Client, clusterInfo *cephclient.ClusterInfo, namespacedName types.NamespacedName) *clientMetrics {
+	return &clientMetrics{
+		context:        context,
+		client:         client,
+		clusterInfo:    clusterInfo,
+		namespacedName: namespacedName,
+		clients:        map[string][]string{},
+		infos:          map[string][]string{},
+	}
+}
+
+// run periodically exports the metrics of the clients of the filesystem until the filesystem is deleted
+func (m *clientMetrics) run(ctx context.Context) {
+	for {
+		interval := m.checkClients()
+		select {
+		case <-ctx.Done():
+			logger.Infof("stopping exporting the client metrics of filesystem %q", m.namespacedName.Name)
+			m.clear()
+			return
+
+		case <-time.After(interval):
+		}
+	}
+}
+
+// checkClients exports the metrics of the clients if they are enabled in the spec of the filesystem,
+// which is read again to follow its updates, and returns the interval until the next check
+func (m *clientMetrics) checkClients() time.Duration {
+	fs := &cephv1.CephFilesystem{}
+	if err := m.client.Get(m.clusterInfo.Context, m.namespacedName, fs); err != nil {
+		logger.Debugf("failed to retrieve ceph filesystem %q to export its client metrics. %v", m.namespacedName.Name, err)
+		return defaultHealthCheckInterval
+	}
+	if fs.Spec.Monitoring == nil || !fs.Spec.Monitoring.ClientMetrics {
+		m.clear()
+		return defaultHealthCheckInterval
+	}
+
+	interval := defaultHealthCheckInterval
+	if fs.Spec.Monitoring.Interval != nil {
+		interval = fs.Spec.Monitoring.Interval.Duration
+	}
+	logger.Debugf("exporting the client metrics of filesystem %q", m.namespacedName.Name)
+	if err := m.collect(); err != nil {
+		logger.Debugf("failed to export the client metrics of filesystem %q. %v", m.namespacedName.Name, err)
+	}
+	return interval
+}
+
+// collect exports the sessions of the clients with the active MDS daemons of the filesystem. A client
+// has a session with each active MDS it uses, its caps, leases and requests are the sum of its sessions.
+func (m *clientMetrics) collect() error {
+	fs, err := cephclient.GetFilesystem(m.context, m.clusterInfo, m.namespacedName.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get filesystem %q", m.namespacedName.Name)
+	}
+
+	sessions := map[string]*cephclient.MDSSession{}
+	for _, gid := range fs.MDSMap.Up {
+		info, ok := fs.MDSMap.Info[fmt.Sprintf("gid_%d", gid)]
+		if !ok {
+			continue
+		}
+		mdsSessions, err := cephclient.ListMDSSessions(m.context, m.clusterInfo, info.Name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the sessions of the clients of filesystem %q", m.namespacedName.Name)
+		}
+		for i := range mdsSessions {
+			session := mdsSessions[i]
+			id := strconv.FormatInt(session.ID, 10)
+			if previous, ok := sessions[id]; ok {
+				previous.NumCaps += session.NumCaps
+				previous.NumLeases += session.NumLeases
+				previous.RequestsInFlight += session.RequestsInFlight
+				if session.Uptime > previous.Uptime {
+					previous.Uptime = session.Uptime
+				}
+				continue
+			}
+			sessions[id] = &session
+		}
+	}
+
+	clients := map[string][]string{}
+	infos := map[string][]string{}
+	for id, session := range sessions {
+		labels := []string{m.namespacedName.Namespace, m.namespacedName.Name, id, sessionIP(session.Inst), session.ClientMetadata.Hostname}
+		clients[id] = labels
+		fsClientCaps.WithLabelValues(labels...).Set(float64(session.NumCaps))
+		fsClientLeases.WithLabelValues(labels...).Set(float64(session.NumLeases))
+		fsClientRequestsInFlight.WithLabelValues(labels...).Set(float64(session.RequestsInFlight))
+		fsClientSessionAge.WithLabelValues(labels...).Set(session.Uptime)
+
+		metadata := session.ClientMetadata
+		infoLabels := append(append([]string{}, labels...), session.State, metadata.KernelVersion, metadata.CephVersion, metadata.EntityID, metadata.Root)
+		infos[id] = infoLabels
+		fsClientInfo.WithLabelValues(infoLabels...).Set(1)
+	}
+	m.clients = deleteGoneClients(m.clients, clients, fsClientMetrics)
+	m.infos = deleteGoneClients(m.infos, infos, []*prometheus.GaugeVec{fsClientInfo})
+	return nil
+}
+
+// sessionIP returns the ip of a client from the instance of its session, such as
+// "client.4305 v1:10.244.0.12:0/2831795632"
+func sessionIP(inst string) string {
+	fields := strings.Fields(inst)
+	if len(fields) != 2 {
+		return ""
+	}
+	addr := fields[1]
+	for _, addrType := range []string{"v1:", "v2:", "any:"} {
+		addr = strings.TrimPrefix(addr, addrType)
+	}
+	if i := strings.LastIndex(addr, "/"); i >= 0 {
+		addr = addr[:i]
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// deleteGoneClients removes the series of the clients that are not connected anymore or whose labels
+// changed, and returns the current labels
+func deleteGoneClients(previous, current map[string][]string, metricVecs []*prometheus.GaugeVec) map[string][]string {
+	for id, labels := range previous {
+		if currentLabels, ok := current[id]; ok && strings.Join(currentLabels, "\x00") == strings.Join(labels, "\x00") {
+			continue
+		}
+		for _, metricVec := range metricVecs {
+			metricVec.DeleteLabelValues(labels...)
+		}
+	}
+	return current
+}
+
+// clear removes the client metrics of the filesystem, when they are disabled or the filesystem is deleted
+func (m *clientMetrics) clear() {
+	m.clients = deleteGoneClients(m.clients, map[string][]string{}, fsClientMetrics)
+	m.infos = deleteGoneClients(m.infos, map[string][]string{}, []*prometheus.GaugeVec{fsClientInfo})
+}
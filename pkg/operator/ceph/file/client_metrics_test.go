@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClientMetrics(t *testing.T) {
+	fsGet := `{"mdsmap":{"fs_name":"myfs","max_mds":2,"up":{"mds_0":4200,"mds_1":4300},
+		"info":{"gid_4200":{"gid":4200,"name":"myfs-a","rank":0,"state":"up:active"},"gid_4300":{"gid":4300,"name":"myfs-b","rank":1,"state":"up:active"}}},"id":1}`
+	sessions := map[string]string{
+		"mds.myfs-a": `[{"id":4305,"state":"open","num_leases":1,"num_caps":12,"uptime":3600.5,"requests_in_flight":1,"inst":"client.4305 v1:10.244.0.12:0/2831795632",
+			"client_metadata":{"entity_id":"csi-cephfs-node","hostname":"node-1","kernel_version":"5.10.0-11-amd64","root":"/volumes/csi"}},
+			{"id":4410,"state":"stale","num_leases":0,"num_caps":3,"uptime":60,"requests_in_flight":0,"inst":"client.4410 v1:10.244.0.15:0/812937",
+			"client_metadata":{"entity_id":"admin","hostname":"node-2","ceph_version":"ceph version 16.2.7","root":"/"}}]`,
+		"mds.myfs-b": `[{"id":4305,"state":"open","num_leases":0,"num_caps":8,"uptime":1800,"requests_in_flight":2,"inst":"client.4305 v1:10.244.0.12:0/2831795632",
+			"client_metadata":{"entity_id":"csi-cephfs-node","hostname":"node-1","kernel_version":"5.10.0-11-amd64","root":"/volumes/csi"}}]`,
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "get" {
+				return fsGet, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			if args[0] == "tell" && args[2] == "session" {
+				return sessions[args[1]], nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph"},
+		Spec:       cephv1.FilesystemSpec{Monitoring: &cephv1.FilesystemMonitoringSpec{ClientMetrics: true}},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(fs).Build()
+	clusterInfo := cephclient.AdminTestClusterInfo("rook-ceph")
+	clusterInfo.Context = context.TODO()
+
+	m := newClientMetrics(&clusterd.Context{Executor: executor}, c, clusterInfo, types.NamespacedName{Namespace: "rook-ceph", Name: "myfs"})
+	defer m.clear()
+	assert.Equal(t, defaultHealthCheckInterval, m.checkClients())
+
+	t.Run("sessions of the active mds", func(t *testing.T) {
+		labels := []string{"rook-ceph", "myfs", "4305", "10.244.0.12", "node-1"}
+		// the client has a session with both ranks
+		assert.Equal(t, float64(20), testutil.ToFloat64(fsClientCaps.WithLabelValues(labels...)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(fsClientLeases.WithLabelValues(labels...)))
+		assert.Equal(t, float64(3), testutil.ToFloat64(fsClientRequestsInFlight.WithLabelValues(labels...)))
+		assert.Equal(t, 3600.5, testutil.ToFloat64(fsClientSessionAge.WithLabelValues(labels...)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(fsClientInfo.WithLabelValues(append(labels, "open", "5.10.0-11-amd64", "", "csi-cephfs-node", "/volumes/csi")...)))
+		assert.Equal(t, 2, testutil.CollectAndCount(fsClientCaps))
+		assert.Equal(t, 2, testutil.CollectAndCount(fsClientInfo))
+	})
+
+	t.Run("disconnected clients are removed", func(t *testing.T) {
+		sessions["mds.myfs-a"] = `[]`
+		assert.NoError(t, m.collect())
+		assert.Equal(t, 1, testutil.CollectAndCount(fsClientCaps))
+		assert.Equal(t, float64(8), testutil.ToFloat64(fsClientCaps.WithLabelValues("rook-ceph", "myfs", "4305", "10.244.0.12", "node-1")))
+		assert.Equal(t, 1, testutil.CollectAndCount(fsClientInfo))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		fs.Spec.Monitoring.ClientMetrics = false
+		assert.NoError(t, c.Update(context.TODO(), fs))
+		m.checkClients()
+		assert.Equal(t, 0, testutil.CollectAndCount(fsClientCaps))
+		assert.Equal(t, 0, testutil.CollectAndCount(fsClientInfo))
+	})
+}
+
+func TestSessionIP(t *testing.T) {
+	assert.Equal(t, "10.244.0.12", sessionIP("client.4305 v1:10.244.0.12:0/2831795632"))
+	assert.Equal(t, "fd00::12", sessionIP("client.4305 v2:[fd00::12]:0/2831795632"))
+	assert.Equal(t, "", sessionIP("client.4305"))
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/file/mirror"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -91,12 +92,17 @@ type fsHealth struct {
 	internalCtx    context.Context
 	internalCancel context.CancelFunc
 	started        bool
+	// whether the client metrics of the filesystem are exported
+	clientMetricsStarted bool
 }
 
 // Add creates a new CephFilesystem Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
-	return add(opManagerContext, mgr, newReconciler(mgr, context, opManagerContext, opConfig))
+	if err := add(opManagerContext, mgr, newReconciler(mgr, context, opManagerContext, opConfig)); err != nil {
+		return err
+	}
+	return addTaskController(mgr)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -114,7 +120,8 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 
 func add(opManagerContext context.Context, mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephFilesystem{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephFilesystem{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -159,15 +166,13 @@ func add(opManagerContext context.Context, mgr manager.Manager, r reconcile.Reco
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephFilesystem) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
-	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
-	}
+	reconcileResponse, cephFilesystem, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephFilesystem, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephFilesystem, error) {
 	// Fetch the cephFilesystem instance
 	cephFilesystem := &cephv1.CephFilesystem{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephFilesystem)
@@ -180,16 +185,16 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 			cephFilesystem.Name = request.Name
 			cephFilesystem.Namespace = request.Namespace
 			r.cancelMirrorMonitoring(cephFilesystem)
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephFilesystem, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get cephFilesystem")
+		return reconcile.Result{}, cephFilesystem, errors.Wrap(err, "failed to get cephFilesystem")
 	}
 
 	// Set a finalizer so we can do cleanup before the object goes away
 	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephFilesystem)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+		return reconcile.Result{}, cephFilesystem, errors.Wrap(err, "failed to add finalizer")
 	}
 
 	// The CR was just created, initializing status fields
@@ -213,13 +218,13 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 			// Remove finalizer
 			err := opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephFilesystem)
 			if err != nil {
-				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+				return reconcile.Result{}, cephFilesystem, errors.Wrap(err, "failed to remove finalizer")
 			}
 
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephFilesystem, nil
 		}
-		return reconcileResponse, nil
+		return reconcileResponse, cephFilesystem, nil
 	}
 	r.cephClusterSpec = &cephCluster.Spec
 
@@ -235,9 +240,9 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 
 	// Populate clusterInfo
 	// Always populate it during each reconcile
-	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephFilesystem", request.Namespace, request.Name), request.NamespacedName.Namespace)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+		return reconcile.Result{}, cephFilesystem, errors.Wrap(err, "failed to populate cluster info")
 	}
 	r.clusterInfo = clusterInfo
 
@@ -245,17 +250,17 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 	if !cephFilesystem.GetDeletionTimestamp().IsZero() {
 		deps, err := cephFilesystemDependents(r.context, r.clusterInfo, cephFilesystem)
 		if err != nil {
-			return reconcile.Result{}, err
+			return reconcile.Result{}, cephFilesystem, err
 		}
 		if !deps.Empty() {
 			err := reporting.ReportDeletionBlockedDueToDependents(logger, r.client, cephFilesystem, deps)
-			return opcontroller.WaitForRequeueIfFinalizerBlocked, err
+			return opcontroller.WaitForRequeueIfFinalizerBlocked, cephFilesystem, err
 		}
 		reporting.ReportDeletionNotBlockedDueToDependents(logger, r.client, r.recorder, cephFilesystem)
 
 		runningCephVersion, err := cephclient.LeastUptodateDaemonVersion(r.context, clusterInfo, config.MonType)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "failed to retrieve current ceph %q version", config.MonType)
+			return reconcile.Result{}, cephFilesystem, errors.Wrapf(err, "failed to retrieve current ceph %q version", config.MonType)
 		}
 		r.clusterInfo.CephVersion = runningCephVersion
 
@@ -263,7 +268,7 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 		logger.Debugf("deleting filesystem %q", cephFilesystem.Name)
 		err = r.reconcileDeleteFilesystem(cephFilesystem)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "failed to delete filesystem %q. ", cephFilesystem.Name)
+			return reconcile.Result{}, cephFilesystem, errors.Wrapf(err, "failed to delete filesystem %q. ", cephFilesystem.Name)
 		}
 
 		// If the ceph fs still in the map, we must remove it during CR deletion
@@ -272,11 +277,11 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 		// Remove finalizer
 		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephFilesystem)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			return reconcile.Result{}, cephFilesystem, errors.Wrap(err, "failed to remove finalizer")
 		}
 
 		// Return and do not requeue. Successful deletion.
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, cephFilesystem, nil
 	}
 
 	// Detect desired CephCluster version
@@ -293,9 +298,9 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 	if err != nil {
 		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
 			logger.Info(opcontroller.OperatorNotInitializedMessage)
-			return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephFilesystem, nil
 		}
-		return reconcile.Result{}, errors.Wrap(err, "failed to detect running and desired ceph version")
+		return reconcile.Result{}, cephFilesystem, errors.Wrap(err, "failed to detect running and desired ceph version")
 	}
 	r.clusterInfo.CephVersion = *runningCephVersion
 
@@ -305,16 +310,16 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 	if !reflect.DeepEqual(*runningCephVersion, *desiredCephVersion) {
 		// Upgrade is in progress, let's wait for the mons to be done
 		return opcontroller.WaitForRequeueIfCephClusterIsUpgrading,
-			opcontroller.ErrorCephUpgradingRequeue(desiredCephVersion, runningCephVersion)
+			cephFilesystem, opcontroller.ErrorCephUpgradingRequeue(desiredCephVersion, runningCephVersion)
 	}
 
 	// validate the filesystem settings
 	if err := validateFilesystem(r.context, r.clusterInfo, r.cephClusterSpec, cephFilesystem); err != nil {
 		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
 			logger.Info(opcontroller.OperatorNotInitializedMessage)
-			return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephFilesystem, nil
 		}
-		return reconcile.Result{}, errors.Wrapf(err, "invalid object filesystem %q arguments", cephFilesystem.Name)
+		return reconcile.Result{}, cephFilesystem, errors.Wrapf(err, "invalid object filesystem %q arguments", cephFilesystem.Name)
 	}
 
 	// RECONCILE
@@ -322,7 +327,7 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 	reconcileResponse, err = r.reconcileCreateFilesystem(cephFilesystem)
 	if err != nil {
 		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure, nil)
-		return reconcileResponse, err
+		return reconcileResponse, cephFilesystem, err
 	}
 
 	statusUpdated := false
@@ -334,13 +339,14 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 			if !cephFilesystem.Spec.Mirroring.Enabled {
 				err = cephclient.DisableFilesystemSnapshotMirror(r.context, r.clusterInfo, cephFilesystem.Name)
 				if err != nil {
-					return reconcile.Result{}, errors.Wrapf(err, "failed to disable mirroring on filesystem %q", cephFilesystem.Name)
+					return reconcile.Result{}, cephFilesystem, errors.Wrapf(err, "failed to disable mirroring on filesystem %q", cephFilesystem.Name)
 				}
+				r.removeReplicationConditions(request.NamespacedName)
 			} else {
 				logger.Info("reconciling cephfs-mirror mirroring configuration")
 				err = r.reconcileMirroring(cephFilesystem, request.NamespacedName)
 				if err != nil {
-					return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to configure mirroring for filesystem %q.", cephFilesystem.Name)
+					return opcontroller.ImmediateRetryResult, cephFilesystem, errors.Wrapf(err, "failed to configure mirroring for filesystem %q.", cephFilesystem.Name)
 				}
 
 				// Always create a bootstrap peer token in case another cluster wants to add us as a peer
@@ -348,13 +354,13 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 				reconcileResponse, err = opcontroller.CreateBootstrapPeerSecret(r.context, r.clusterInfo, cephFilesystem, k8sutil.NewOwnerInfo(cephFilesystem, r.scheme))
 				if err != nil {
 					r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure, nil)
-					return reconcileResponse, errors.Wrapf(err, "failed to create cephfs-mirror bootstrap peer for filesystem %q.", cephFilesystem.Name)
+					return reconcileResponse, cephFilesystem, errors.Wrapf(err, "failed to create cephfs-mirror bootstrap peer for filesystem %q.", cephFilesystem.Name)
 				}
 
 				logger.Info("reconciling add cephfs-mirror peer configuration")
 				err = r.reconcileAddBoostrapPeer(cephFilesystem, request.NamespacedName)
 				if err != nil {
-					return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to configure mirroring for filesystem %q.", cephFilesystem.Name)
+					return opcontroller.ImmediateRetryResult, cephFilesystem, errors.Wrapf(err, "failed to configure mirroring for filesystem %q.", cephFilesystem.Name)
 				}
 
 				// Set Ready status, we are done reconciling
@@ -367,7 +373,7 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 					if r.fsContexts[fsChannelKeyName(cephFilesystem)].started {
 						logger.Debug("ceph filesystem mirror status monitoring go routine already running!")
 					} else {
-						checker := newMirrorChecker(r.context, r.client, r.clusterInfo, request.NamespacedName, &cephFilesystem.Spec, cephFilesystem.Name)
+						checker := newMirrorChecker(r.context, r.client, r.recorder, r.clusterInfo, request.NamespacedName, &cephFilesystem.Spec, cephFilesystem.Name)
 						go checker.checkMirroring(r.fsContexts[fsChannelKeyName(cephFilesystem)].internalCtx)
 						r.fsContexts[fsChannelKeyName(cephFilesystem)].started = true
 					}
@@ -375,6 +381,18 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 			}
 		}
 	}
+	// Run go routine exporting the metrics of the clients of the filesystem, it stops when the
+	// filesystem is deleted and clears the metrics when they are disabled
+	if cephFilesystem.Spec.Monitoring != nil && cephFilesystem.Spec.Monitoring.ClientMetrics {
+		if r.fsContexts[fsChannelKeyName(cephFilesystem)].clientMetricsStarted {
+			logger.Debug("ceph filesystem client metrics go routine already running!")
+		} else {
+			clientMetrics := newClientMetrics(r.context, r.client, r.clusterInfo, request.NamespacedName)
+			go clientMetrics.run(r.fsContexts[fsChannelKeyName(cephFilesystem)].internalCtx)
+			r.fsContexts[fsChannelKeyName(cephFilesystem)].clientMetricsStarted = true
+		}
+	}
+
 	if !statusUpdated {
 		// Set Ready status, we are done reconciling$
 		// TODO: set status to Ready **only** if the filesystem is ready
@@ -383,7 +401,7 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 
 	// Return and do not requeue
 	logger.Debug("done reconciling")
-	return reconcile.Result{}, nil
+	return reconcile.Result{}, cephFilesystem, nil
 }
 
 func (r *ReconcileCephFilesystem) reconcileCreateFilesystem(cephFilesystem *cephv1.CephFilesystem) (reconcile.Result, error) {
@@ -437,8 +455,18 @@ func (r *ReconcileCephFilesystem) reconcileMirroring(cephFilesystem *cephv1.Ceph
 		return errors.Wrapf(err, "failed to enable mirroring on filesystem %q", cephFilesystem.Name)
 	}
 
+	// The snapshot schedules are paused while the filesystem is quiesced for a planned failover,
+	// they are activated again when the quiesce action is deleted
+	quiesced, err := opcontroller.IsFilesystemQuiesced(r.opManagerContext, r.client, types.NamespacedName{Name: cephFilesystem.Name, Namespace: cephFilesystem.Namespace})
+	if err != nil {
+		return errors.Wrapf(err, "failed to check whether filesystem %q is quiesced", cephFilesystem.Name)
+	}
+	if quiesced {
+		logger.Infof("not adding the snapshot schedules of filesystem %q, it is quiesced by a dr action", cephFilesystem.Name)
+	}
+
 	// Add snapshot schedules
-	if cephFilesystem.Spec.Mirroring.SnapShotScheduleEnabled() {
+	if cephFilesystem.Spec.Mirroring.SnapShotScheduleEnabled() && !quiesced {
 		// Enable the snap_schedule module
 		err = cephclient.MgrEnableModule(r.context, r.clusterInfo, "snap_schedule", false)
 		if err != nil {
@@ -464,13 +492,23 @@ func (r *ReconcileCephFilesystem) reconcileMirroring(cephFilesystem *cephv1.Ceph
 	return nil
 }
 
+// reconcileAddBoostrapPeer imports the peer tokens of the secrets that are not peers of the
+// filesystem yet, and removes the peers that were imported from secrets that are not listed anymore
 func (r *ReconcileCephFilesystem) reconcileAddBoostrapPeer(cephFilesystem *cephv1.CephFilesystem, namespacedName types.NamespacedName) error {
-	if cephFilesystem.Spec.Mirroring.Peers == nil {
-		return nil
+	peers, err := cephclient.ListFSMirrorPeers(r.context, r.clusterInfo, cephFilesystem.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list cephfs-mirror peers of filesystem %q", cephFilesystem.Name)
 	}
+
+	var secretNames []string
+	if cephFilesystem.Spec.Mirroring.Peers != nil {
+		secretNames = cephFilesystem.Spec.Mirroring.Peers.SecretNames
+	}
+
 	// List all the peers secret, we can have more than one peer we might want to configure
 	// For each, get the Kubernetes Secret and import the "peer token" so that we can configure the mirroring
-	for _, peerSecret := range cephFilesystem.Spec.Mirroring.Peers.SecretNames {
+	desiredPeers := []cephv1.FilesystemMirrorPeerStatus{}
+	for _, peerSecret := range secretNames {
 		logger.Debugf("fetching bootstrap peer kubernetes secret %q", peerSecret)
 		s, err := r.context.Clientset.CoreV1().Secrets(r.clusterInfo.Namespace).Get(r.opManagerContext, peerSecret, metav1.GetOptions{})
 		// We don't care about IsNotFound here, we still need to fail
@@ -484,13 +522,61 @@ func (r *ReconcileCephFilesystem) reconcileAddBoostrapPeer(cephFilesystem *cephv
 			return errors.Wrapf(err, "failed to validate fs-mirror bootstrap peer secret %q data", peerSecret)
 		}
 
-		// Add fs-mirror peer
-		err = cephclient.ImportFSMirrorBootstrapPeer(r.context, r.clusterInfo, cephFilesystem.Name, string(s.Data["token"]))
+		token, err := cephclient.DecodeFSMirrorBootstrapPeerToken(string(s.Data["token"]))
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode fs-mirror bootstrap peer secret %q", peerSecret)
+		}
+
+		// Add fs-mirror peer, unless the remote filesystem is already a peer since the import of a
+		// token fails for an existing peer
+		uuid := cephclient.FindFSMirrorPeer(peers, token.SiteName, token.Filesystem)
+		if uuid == "" {
+			err = cephclient.ImportFSMirrorBootstrapPeer(r.context, r.clusterInfo, cephFilesystem.Name, string(s.Data["token"]))
+			if err != nil {
+				return errors.Wrap(err, "failed to import filesystem bootstrap peer token")
+			}
+
+			peers, err = cephclient.ListFSMirrorPeers(r.context, r.clusterInfo, cephFilesystem.Name)
+			if err != nil {
+				return errors.Wrapf(err, "failed to list cephfs-mirror peers of filesystem %q", cephFilesystem.Name)
+			}
+			uuid = cephclient.FindFSMirrorPeer(peers, token.SiteName, token.Filesystem)
+			if uuid == "" {
+				return errors.Errorf("failed to find the cephfs-mirror peer imported from secret %q", peerSecret)
+			}
+		}
+		desiredPeers = append(desiredPeers, cephv1.FilesystemMirrorPeerStatus{UUID: uuid, SecretName: peerSecret, SiteName: token.SiteName, FSName: token.Filesystem})
+	}
+
+	// Remove the peers imported from the secrets that were removed from the spec. The peers that
+	// were not added by the operator are left untouched.
+	var currentPeers []cephv1.FilesystemMirrorPeerStatus
+	if cephFilesystem.Status != nil && cephFilesystem.Status.MirroringStatus != nil {
+		currentPeers = cephFilesystem.Status.MirroringStatus.Peers
+	}
+	for _, current := range currentPeers {
+		if current.SecretName == "" || findPeerStatus(desiredPeers, current.UUID) != nil {
+			continue
+		}
+		if _, ok := peers[current.UUID]; !ok {
+			continue
+		}
+		err = cephclient.RemoveFilesystemMirrorPeer(r.context, r.clusterInfo, cephFilesystem.Name, current.UUID)
 		if err != nil {
-			return errors.Wrap(err, "failed to import filesystem bootstrap peer token")
+			return errors.Wrapf(err, "failed to remove cephfs-mirror peer of secret %q", current.SecretName)
 		}
 	}
 
+	r.updateStatusMirroringPeers(namespacedName, desiredPeers)
+	return nil
+}
+
+func findPeerStatus(peers []cephv1.FilesystemMirrorPeerStatus, uuid string) *cephv1.FilesystemMirrorPeerStatus {
+	for i := range peers {
+		if peers[i].UUID == uuid {
+			return &peers[i]
+		}
+	}
 	return nil
 }
 
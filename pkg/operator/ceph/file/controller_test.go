@@ -19,10 +19,12 @@ package file
 
 import (
 	"context"
+	"encoding/base64"
 	"os"
 	"testing"
 
 	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
@@ -37,6 +39,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -204,7 +207,7 @@ func TestCephFilesystemController(t *testing.T) {
 	// Create a fake client to mock API calls.
 	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 	// Create a ReconcileCephFilesystem object with the scheme and fake client.
-	r := &ReconcileCephFilesystem{client: cl, scheme: s, context: c, fsContexts: make(map[string]*fsHealth), opManagerContext: context.TODO()}
+	r := &ReconcileCephFilesystem{client: cl, scheme: s, context: c, fsContexts: make(map[string]*fsHealth), opManagerContext: context.TODO(), recorder: &record.FakeRecorder{}}
 
 	// Mock request to simulate Reconcile() being called on an event for a
 	// watched resource .
@@ -238,7 +241,7 @@ func TestCephFilesystemController(t *testing.T) {
 		// Create a fake client to mock API calls.
 		cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 		// Create a ReconcileCephFilesystem object with the scheme and fake client.
-		r = &ReconcileCephFilesystem{client: cl, scheme: s, context: c, opManagerContext: context.TODO()}
+		r = &ReconcileCephFilesystem{client: cl, scheme: s, context: c, opManagerContext: context.TODO(), recorder: &record.FakeRecorder{}}
 		res, err := r.Reconcile(ctx, req)
 		assert.NoError(t, err)
 		assert.True(t, res.Requeue)
@@ -290,7 +293,7 @@ func TestCephFilesystemController(t *testing.T) {
 		c.Executor = executor
 
 		// Create a ReconcileCephFilesystem object with the scheme and fake client.
-		r = &ReconcileCephFilesystem{client: cl, scheme: s, context: c, fsContexts: make(map[string]*fsHealth), opManagerContext: context.TODO()}
+		r = &ReconcileCephFilesystem{client: cl, scheme: s, context: c, fsContexts: make(map[string]*fsHealth), opManagerContext: context.TODO(), recorder: &record.FakeRecorder{}}
 
 		res, err := r.Reconcile(ctx, req)
 		assert.NoError(t, err)
@@ -300,3 +303,87 @@ func TestCephFilesystemController(t *testing.T) {
 		assert.Equal(t, cephv1.ConditionType("Ready"), fs.Status.Phase, fs)
 	})
 }
+
+func TestReconcileAddBootstrapPeer(t *testing.T) {
+	ctx := context.TODO()
+	token := base64.StdEncoding.EncodeToString([]byte(`{"fsid": "82b7ed92-73b0-4b22-a8b7-ed9483e28756", "filesystem": "backup", "user": "client.mirror", "site_name": "remote"}`))
+	clientset := test.New(t, 3)
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "peer", Namespace: namespace},
+		Data:       map[string][]byte{"token": []byte(token)},
+	}
+	_, err := clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: cephv1.FilesystemSpec{
+			Mirroring: &cephv1.FSMirroringSpec{Enabled: true, Peers: &cephv1.MirroringPeerSpec{SecretNames: []string{"peer"}}},
+		},
+		Status: &cephv1.CephFilesystemStatus{},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(fs).Build()
+
+	peers := `{}`
+	imported := 0
+	removed := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "snapshot" && args[2] == "mirror" {
+				switch args[3] {
+				case "peer_list":
+					return peers, nil
+				case "peer_remove":
+					removed = args[5]
+					peers = `{}`
+					return "", nil
+				}
+			}
+			return "", errors.New("unknown command")
+		},
+		MockExecuteCommandWithCombinedOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[3] == "peer_bootstrap" && args[4] == "import" {
+				assert.Equal(t, name, args[5])
+				imported++
+				peers = `{"4a6983c0-3c9d-40f5-b2a9-2334a4659827": {"client_name": "client.mirror", "site_name": "remote", "fs_name": "backup"}}`
+				return "", nil
+			}
+			return "", errors.New("unknown command")
+		},
+	}
+	c := &clusterd.Context{Executor: executor, Clientset: clientset}
+	r := &ReconcileCephFilesystem{client: cl, scheme: s, context: c, clusterInfo: client.AdminTestClusterInfo(namespace), opManagerContext: ctx, recorder: &record.FakeRecorder{}}
+	req := types.NamespacedName{Name: name, Namespace: namespace}
+
+	t.Run("peer is imported", func(t *testing.T) {
+		err := r.reconcileAddBoostrapPeer(fs, req)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, imported)
+
+		err = cl.Get(ctx, req, fs)
+		assert.NoError(t, err)
+		assert.Equal(t, []cephv1.FilesystemMirrorPeerStatus{
+			{UUID: "4a6983c0-3c9d-40f5-b2a9-2334a4659827", SecretName: "peer", SiteName: "remote", FSName: "backup"},
+		}, fs.Status.MirroringStatus.Peers)
+	})
+
+	t.Run("peer is not imported again", func(t *testing.T) {
+		err := r.reconcileAddBoostrapPeer(fs, req)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, imported)
+		assert.Equal(t, "", removed)
+	})
+
+	t.Run("peer is removed with its secret", func(t *testing.T) {
+		fs.Spec.Mirroring.Peers.SecretNames = nil
+		err := r.reconcileAddBoostrapPeer(fs, req)
+		assert.NoError(t, err)
+		assert.Equal(t, "4a6983c0-3c9d-40f5-b2a9-2334a4659827", removed)
+
+		err = cl.Get(ctx, req, fs)
+		assert.NoError(t, err)
+		assert.Empty(t, fs.Status.MirroringStatus.Peers)
+	})
+}
@@ -27,7 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// CephFilesystemDependents returns the subvolume group(s) which exist in the ceph filesystem that should block
+// CephFilesystemDependents returns the subvolume group(s) and subvolume(s) which exist in the ceph filesystem that should block
 // deletion.
 func CephFilesystemDependents(clusterdCtx *clusterd.Context, clusterInfo *client.ClusterInfo, filesystem *v1.CephFilesystem) (*dependents.DependentList, error) {
 	nsName := fmt.Sprintf("%s/%s", filesystem.Namespace, filesystem.Name)
@@ -47,5 +47,17 @@ func CephFilesystemDependents(clusterdCtx *clusterd.Context, clusterInfo *client
 		logger.Debugf("found CephFilesystemSubVolumeGroups %q that does not depend on CephFilesystem %q", subVolumeGroup.Name, nsName)
 	}
 
+	// CephFilesystemSubVolumes
+	subVolumes, err := clusterdCtx.RookClientset.CephV1().CephFilesystemSubVolumes(filesystem.Namespace).List(clusterInfo.Context, metav1.ListOptions{})
+	if err != nil {
+		return deps, errors.Wrapf(err, "%s. failed to list CephFilesystemSubVolumes for CephFilesystem %q", baseErrMsg, nsName)
+	}
+	for _, subVolume := range subVolumes.Items {
+		if subVolume.Spec.FilesystemName == filesystem.Name {
+			deps.Add("CephFilesystemSubVolumes", subVolume.Name)
+		}
+		logger.Debugf("found CephFilesystemSubVolumes %q that does not depend on CephFilesystem %q", subVolume.Name, nsName)
+	}
+
 	return deps, nil
 }
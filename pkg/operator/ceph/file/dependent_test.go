@@ -91,4 +91,13 @@ func TestCephFilesystemDependents(t *testing.T) {
 		assert.NoError(t, err)
 		assert.False(t, deps.Empty())
 	})
+
+	t.Run("one subvolume", func(t *testing.T) {
+		c = newClusterdCtx(&cephv1.CephFilesystemSubVolume{ObjectMeta: meta("subvol1")})
+		_, err := c.RookClientset.CephV1().CephFilesystemSubVolumes(clusterInfo.Namespace).Create(ctx, &cephv1.CephFilesystemSubVolume{ObjectMeta: meta("subvol1"), Spec: cephv1.CephFilesystemSubVolumeSpec{FilesystemName: "myfs"}}, v1.CreateOptions{})
+		assert.NoError(t, err)
+		deps, err := CephFilesystemDependents(c, clusterInfo, fs)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"subvol1"}, deps.OfKind("CephFilesystemSubVolumes"))
+	})
 }
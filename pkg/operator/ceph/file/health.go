@@ -19,12 +19,18 @@ package file
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/file/mirror"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -40,10 +46,11 @@ type mirrorChecker struct {
 	namespacedName types.NamespacedName
 	fsSpec         *cephv1.FilesystemSpec
 	fsName         string
+	peers          *opcontroller.MirrorPeerProber
 }
 
 // newMirrorChecker creates a new HealthChecker
-func newMirrorChecker(context *clusterd.Context, client client.Client, clusterInfo *cephclient.ClusterInfo, namespacedName types.NamespacedName, fsSpec *cephv1.FilesystemSpec, fsName string) *mirrorChecker {
+func newMirrorChecker(context *clusterd.Context, client client.Client, recorder record.EventRecorder, clusterInfo *cephclient.ClusterInfo, namespacedName types.NamespacedName, fsSpec *cephv1.FilesystemSpec, fsName string) *mirrorChecker {
 	c := &mirrorChecker{
 		context:        context,
 		interval:       defaultHealthCheckInterval,
@@ -52,6 +59,7 @@ func newMirrorChecker(context *clusterd.Context, client client.Client, clusterIn
 		client:         client,
 		fsSpec:         fsSpec,
 		fsName:         fsName,
+		peers:          opcontroller.NewMirrorPeerProber(context, recorder, cephFilesystemKind, namespacedName),
 	}
 
 	// allow overriding the check interval
@@ -69,23 +77,28 @@ func (c *mirrorChecker) checkMirroring(context context.Context) {
 	// check the mirroring health immediately before starting the loop
 	err := c.checkMirroringHealth()
 	if err != nil {
-		c.updateStatusMirroring(nil, nil, err.Error())
+		c.updateStatusMirroring(nil, nil, nil, err.Error())
 		logger.Debugf("failed to check filesystem mirroring status %q. %v", c.namespacedName.Name, err)
 	}
+	c.checkPeersConnection()
+	c.updateStatusReplication()
 
 	for {
 		select {
 		case <-context.Done():
 			logger.Infof("stopping monitoring filesystem mirroring status %q", c.namespacedName.Name)
+			c.peers.Clear()
 			return
 
 		case <-time.After(c.interval):
 			logger.Debugf("checking filesystem mirroring status %q", c.namespacedName.Name)
 			err := c.checkMirroringHealth()
 			if err != nil {
-				c.updateStatusMirroring(nil, nil, err.Error())
+				c.updateStatusMirroring(nil, nil, nil, err.Error())
 				logger.Debugf("failed to check filesystem %q mirroring status. %v", c.namespacedName.Name, err)
 			}
+			c.checkPeersConnection()
+			c.updateStatusReplication()
 		}
 	}
 }
@@ -93,7 +106,7 @@ func (c *mirrorChecker) checkMirroring(context context.Context) {
 func (c *mirrorChecker) checkMirroringHealth() error {
 	mirrorStatus, err := cephclient.GetFSMirrorDaemonStatus(c.context, c.clusterInfo, c.fsName)
 	if err != nil {
-		c.updateStatusMirroring(nil, nil, err.Error())
+		c.updateStatusMirroring(nil, nil, nil, err.Error())
 		return err
 	}
 
@@ -101,13 +114,96 @@ func (c *mirrorChecker) checkMirroringHealth() error {
 	if c.fsSpec.Mirroring.SnapShotScheduleEnabled() {
 		snapSchedStatus, err = cephclient.GetSnapshotScheduleStatus(c.context, c.clusterInfo, c.fsName)
 		if err != nil {
-			c.updateStatusMirroring(nil, nil, err.Error())
+			c.updateStatusMirroring(nil, nil, nil, err.Error())
 			return err
 		}
 	}
 
 	// On success
-	c.updateStatusMirroring(mirrorStatus, snapSchedStatus, "")
+	c.updateStatusMirroring(mirrorStatus, snapSchedStatus, c.peersStatus(mirrorStatus), "")
 
 	return nil
 }
+
+// checkPeersConnection probes the remote clusters of the peers of the filesystem, a rotated key or
+// an unreachable peer site is reported in the PeerConnected condition of the filesystem
+func (c *mirrorChecker) checkPeersConnection() {
+	if c.fsSpec.Mirroring == nil || c.fsSpec.Mirroring.Peers == nil || len(c.fsSpec.Mirroring.Peers.SecretNames) == 0 {
+		c.peers.Clear()
+		return
+	}
+
+	fs := &cephv1.CephFilesystem{}
+	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, fs); err != nil {
+		logger.Debugf("failed to retrieve ceph filesystem %q to probe its mirror peers. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	condition := c.peers.Probe(c.clusterInfo.Context, fs, c.fsSpec.Mirroring.Peers.SecretNames)
+	c.updateStatusPeerConnected(condition)
+}
+
+// peersStatus returns the mirroring status of the filesystem with each of its peers. The failures
+// are reported by the mirror daemon status, the snapshots synced with a peer are only reported by
+// the admin socket of the cephfs-mirror daemon.
+func (c *mirrorChecker) peersStatus(mirrorStatus []cephv1.FilesystemMirroringInfo) []cephv1.FilesystemMirrorPeerStatus {
+	peers := []cephv1.FilesystemMirrorPeerStatus{}
+	for _, daemon := range mirrorStatus {
+		for _, fs := range daemon.Filesystems {
+			if fs.Name != c.fsName {
+				continue
+			}
+			for _, peer := range fs.Peers {
+				status := cephv1.FilesystemMirrorPeerStatus{UUID: peer.UUID, Directories: fs.DirectoryCount}
+				if peer.Remote != nil {
+					status.SiteName = peer.Remote.ClusterName
+					status.FSName = peer.Remote.FsName
+				}
+				if peer.Stats != nil {
+					status.FailureCount = peer.Stats.FailureCount
+					status.RecoveryCount = peer.Stats.RecoveryCount
+				}
+
+				directories, err := getPeerSyncStatus(c.context, c.namespacedName.Namespace, c.fsName, fs.FilesystemID, peer.UUID)
+				if err != nil {
+					logger.Debugf("failed to get the sync status of filesystem %q with peer %q. %v", c.fsName, peer.UUID, err)
+				}
+				for _, directory := range directories {
+					status.SnapsSynced += directory.SnapsSynced
+					status.SnapsDeleted += directory.SnapsDeleted
+					status.SnapsRenamed += directory.SnapsRenamed
+					if directory.State == "failed" {
+						status.FailedDirectories++
+					}
+				}
+				peers = append(peers, status)
+			}
+		}
+	}
+	return peers
+}
+
+// PeerSyncStatus returns the sync status of each mirrored directory of a filesystem with a peer,
+// read from the admin socket of the cephfs-mirror daemon
+func PeerSyncStatus(context *clusterd.Context, namespace, fsName string, fsID int, peerUUID string) (map[string]cephclient.FSMirrorPeerDirectoryStatus, error) {
+	return getPeerSyncStatus(context, namespace, fsName, fsID, peerUUID)
+}
+
+// getPeerSyncStatus returns the sync status of each directory of a filesystem with a peer from the
+// admin socket of the cephfs-mirror daemon, whose name contains the pid of the daemon
+var getPeerSyncStatus = func(context *clusterd.Context, namespace, fsName string, fsID int, peerUUID string) (map[string]cephclient.FSMirrorPeerDirectoryStatus, error) {
+	if context.RemoteExecutor.ClientSet == nil {
+		return nil, errors.New("no remote executor")
+	}
+	command := fmt.Sprintf("ceph --admin-daemon /run/ceph/ceph-client.fs-mirror.*.asok fs mirror peer status %s@%d %s", fsName, fsID, peerUUID)
+	output, stderr, err := context.RemoteExecutor.ExecCommandInContainerWithFullOutput(mirror.AppName, "fs-mirror", namespace, "sh", "-c", command)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run %q in the cephfs-mirror daemon. %s", command, stderr)
+	}
+
+	directories := map[string]cephclient.FSMirrorPeerDirectoryStatus{}
+	if err := json.Unmarshal([]byte(output), &directories); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the peer status of the cephfs-mirror daemon. %s", output)
+	}
+	return directories, nil
+}
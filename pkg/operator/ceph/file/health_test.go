@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPeersStatus(t *testing.T) {
+	defer func(f func(*clusterd.Context, string, string, int, string) (map[string]cephclient.FSMirrorPeerDirectoryStatus, error)) {
+		getPeerSyncStatus = f
+	}(getPeerSyncStatus)
+	getPeerSyncStatus = func(context *clusterd.Context, namespace, fsName string, fsID int, peerUUID string) (map[string]cephclient.FSMirrorPeerDirectoryStatus, error) {
+		assert.Equal(t, "rook-ceph", namespace)
+		assert.Equal(t, "myfs", fsName)
+		assert.Equal(t, 1, fsID)
+		if peerUUID == "unknown" {
+			return nil, errors.New("no admin socket")
+		}
+		return map[string]cephclient.FSMirrorPeerDirectoryStatus{
+			"/volumes/a": {State: "idle", SnapsSynced: 3, SnapsDeleted: 1},
+			"/volumes/b": {State: "failed", SnapsSynced: 2, SnapsRenamed: 1},
+		}, nil
+	}
+
+	c := &mirrorChecker{context: &clusterd.Context{}, namespacedName: types.NamespacedName{Namespace: "rook-ceph", Name: "myfs"}, fsName: "myfs"}
+	mirrorStatus := []cephv1.FilesystemMirroringInfo{
+		{
+			DaemonID: 25103,
+			Filesystems: []cephv1.FilesystemsSpec{
+				{
+					FilesystemID:   1,
+					Name:           "myfs",
+					DirectoryCount: 2,
+					Peers: []cephv1.FilesystemMirrorInfoPeerSpec{
+						{
+							UUID:   "4a6983c0-3c9d-40f5-b2a9-2334a4659827",
+							Remote: &cephv1.PeerRemoteSpec{ClusterName: "site-remote", FsName: "backup_fs"},
+							Stats:  &cephv1.PeerStatSpec{FailureCount: 1, RecoveryCount: 1},
+						},
+						{UUID: "unknown"},
+					},
+				},
+				{FilesystemID: 2, Name: "otherfs", Peers: []cephv1.FilesystemMirrorInfoPeerSpec{{UUID: "other"}}},
+			},
+		},
+	}
+
+	peers := c.peersStatus(mirrorStatus)
+	assert.Equal(t, []cephv1.FilesystemMirrorPeerStatus{
+		{
+			UUID:              "4a6983c0-3c9d-40f5-b2a9-2334a4659827",
+			SiteName:          "site-remote",
+			FSName:            "backup_fs",
+			Directories:       2,
+			FailedDirectories: 1,
+			SnapsSynced:       5,
+			SnapsDeleted:      1,
+			SnapsRenamed:      1,
+			FailureCount:      1,
+			RecoveryCount:     1,
+		},
+		{UUID: "unknown", Directories: 2},
+	}, peers)
+}
@@ -31,12 +31,14 @@ import (
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -77,6 +79,7 @@ type ReconcileFilesystemMirror struct {
 	cephClusterSpec  *cephv1.ClusterSpec
 	opManagerContext context.Context
 	opConfig         opcontroller.OperatorConfig
+	recorder         record.EventRecorder
 }
 
 // Add creates a new CephFilesystemMirror Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -93,12 +96,14 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 		context:          context,
 		opConfig:         opConfig,
 		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephFilesystemMirror{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephFilesystemMirror{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -130,26 +135,26 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileFilesystemMirror) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
+	reconcileResponse, filesystemMirror, err := r.reconcile(request)
 	if err != nil {
 		r.updateStatus(r.client, request.NamespacedName, k8sutil.FailedStatus)
-		logger.Errorf("failed to reconcile %v", err)
 	}
+	reporting.RecordReconcileResult(logger, r.recorder, request, filesystemMirror, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileFilesystemMirror) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileFilesystemMirror) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephFilesystemMirror, error) {
 	// Fetch the CephFilesystemMirror instance
 	filesystemMirror := &cephv1.CephFilesystemMirror{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, filesystemMirror)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("CephFilesystemMirror resource not found. Ignoring since object must be deleted.")
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, filesystemMirror, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get CephFilesystemMirror")
+		return reconcile.Result{}, filesystemMirror, errors.Wrap(err, "failed to get CephFilesystemMirror")
 	}
 
 	// The CR was just created, initializing status fields
@@ -161,16 +166,16 @@ func (r *ReconcileFilesystemMirror) reconcile(request reconcile.Request) (reconc
 	cephCluster, isReadyToReconcile, _, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
 	if !isReadyToReconcile {
 		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
-		return reconcileResponse, nil
+		return reconcileResponse, filesystemMirror, nil
 	}
 
 	// Assign the clusterSpec
 	r.cephClusterSpec = &cephCluster.Spec
 
 	// Populate clusterInfo
-	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephFilesystemMirror", request.Namespace, request.Name), request.NamespacedName.Namespace)
 	if err != nil {
-		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to populate cluster info")
+		return opcontroller.ImmediateRetryResult, filesystemMirror, errors.Wrap(err, "failed to populate cluster info")
 	}
 
 	// Detect desired CephCluster version
@@ -187,9 +192,9 @@ func (r *ReconcileFilesystemMirror) reconcile(request reconcile.Request) (reconc
 	if err != nil {
 		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
 			logger.Info(opcontroller.OperatorNotInitializedMessage)
-			return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, filesystemMirror, nil
 		}
-		return reconcile.Result{}, errors.Wrap(err, "failed to detect running and desired ceph version")
+		return reconcile.Result{}, filesystemMirror, errors.Wrap(err, "failed to detect running and desired ceph version")
 	}
 
 	// If the version of the Ceph monitor differs from the CephCluster CR image version we assume
@@ -198,20 +203,20 @@ func (r *ReconcileFilesystemMirror) reconcile(request reconcile.Request) (reconc
 	if !reflect.DeepEqual(*runningCephVersion, *desiredCephVersion) {
 		// Upgrade is in progress, let's wait for the mons to be done
 		return opcontroller.WaitForRequeueIfCephClusterIsUpgrading,
-			opcontroller.ErrorCephUpgradingRequeue(desiredCephVersion, runningCephVersion)
+			filesystemMirror, opcontroller.ErrorCephUpgradingRequeue(desiredCephVersion, runningCephVersion)
 	}
 	r.clusterInfo.CephVersion = *runningCephVersion
 
 	// Validate Ceph version
 	if !r.clusterInfo.CephVersion.IsAtLeastPacific() {
-		return opcontroller.ImmediateRetryResult, errors.Errorf("ceph pacific version is required to deploy cephfs mirroring, current cluster runs %q", r.clusterInfo.CephVersion.String())
+		return opcontroller.ImmediateRetryResult, filesystemMirror, errors.Errorf("ceph pacific version is required to deploy cephfs mirroring, current cluster runs %q", r.clusterInfo.CephVersion.String())
 	}
 
 	// CREATE/UPDATE
 	logger.Debug("reconciling ceph filesystem mirror deployments")
 	reconcileResponse, err = r.reconcileFilesystemMirror(filesystemMirror)
 	if err != nil {
-		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to create ceph filesystem mirror deployments")
+		return opcontroller.ImmediateRetryResult, filesystemMirror, errors.Wrap(err, "failed to create ceph filesystem mirror deployments")
 	}
 
 	// Set Ready status, we are done reconciling
@@ -219,7 +224,7 @@ func (r *ReconcileFilesystemMirror) reconcile(request reconcile.Request) (reconc
 
 	// Return and do not requeue
 	logger.Debug("done reconciling ceph filesystem mirror")
-	return reconcile.Result{}, nil
+	return reconcile.Result{}, filesystemMirror, nil
 
 }
 
@@ -254,12 +259,14 @@ func (r *ReconcileFilesystemMirror) updateStatus(client client.Client, name type
 		return
 	}
 
-	if fsMirror.Status == nil {
-		fsMirror.Status = &cephv1.Status{}
-	}
-
-	fsMirror.Status.Phase = status
-	if err := reporting.UpdateStatus(client, fsMirror); err != nil {
+	err = reporting.PatchStatus(client, fsMirror, func() bool {
+		if fsMirror.Status == nil {
+			fsMirror.Status = &cephv1.Status{}
+		}
+		fsMirror.Status.Phase = status
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set filesystem mirror %q status to %q. %v", fsMirror.Name, status, err)
 		return
 	}
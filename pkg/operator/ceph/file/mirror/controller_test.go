@@ -38,6 +38,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -104,9 +105,10 @@ func TestCephFilesystemMirrorController(t *testing.T) {
 
 	// Create a ReconcileFilesystemMirror object with the scheme and fake client.
 	r := &ReconcileFilesystemMirror{
-		client:  cl,
-		scheme:  s,
-		context: c,
+		recorder: &record.FakeRecorder{},
+		client:   cl,
+		scheme:   s,
+		context:  c,
 		opConfig: controller.OperatorConfig{
 			OperatorNamespace: namespace,
 			Image:             "rook",
@@ -154,9 +156,10 @@ func TestCephFilesystemMirrorController(t *testing.T) {
 		cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 		// Create a ReconcileFilesystemMirror object with the scheme and fake client.
 		r = &ReconcileFilesystemMirror{
-			client:  cl,
-			scheme:  s,
-			context: c,
+			recorder: &record.FakeRecorder{},
+			client:   cl,
+			scheme:   s,
+			context:  c,
 			opConfig: controller.OperatorConfig{
 				OperatorNamespace: namespace,
 				Image:             "rook",
@@ -196,9 +199,10 @@ func TestCephFilesystemMirrorController(t *testing.T) {
 
 		// Create a ReconcileFilesystemMirror object with the scheme and fake client.
 		r = &ReconcileFilesystemMirror{
-			client:  cl,
-			scheme:  s,
-			context: c,
+			recorder: &record.FakeRecorder{},
+			client:   cl,
+			scheme:   s,
+			context:  c,
 			opConfig: controller.OperatorConfig{
 				OperatorNamespace: namespace,
 				Image:             "rook",
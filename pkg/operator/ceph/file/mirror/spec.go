@@ -56,10 +56,15 @@ func (r *ReconcileFilesystemMirror) makeDeployment(daemonConfig *daemonConfig, f
 		podSpec.Spec.Containers = append(podSpec.Spec.Containers, *controller.LogCollectorContainer(fmt.Sprintf("ceph-%s", user), r.clusterInfo.Namespace, *r.cephClusterSpec))
 	}
 
+	if r.cephClusterSpec.Security.IsRestricted() {
+		controller.ApplyRestrictedPodSecurity(&podSpec.Spec)
+	}
+
 	// Replace default unreachable node toleration
 	k8sutil.AddUnreachableNodeToleration(&podSpec.Spec)
 	fsMirror.Spec.Annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
 	fsMirror.Spec.Labels.ApplyToObjectMeta(&podSpec.ObjectMeta)
+	controller.ApplySecurityProfile(r.cephClusterSpec.Security.Profiles, cephv1.KeyFSMirror, &podSpec.ObjectMeta, &podSpec.Spec)
 
 	if r.cephClusterSpec.Network.IsHost() {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
@@ -18,9 +18,11 @@ limitations under the License.
 package file
 
 import (
+	"fmt"
 	"time"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -40,21 +42,72 @@ func (r *ReconcileCephFilesystem) updateStatus(client client.Client, namespacedN
 		return
 	}
 
-	if fs.Status == nil {
-		fs.Status = &cephv1.CephFilesystemStatus{}
-	}
-
-	fs.Status.Phase = status
-	fs.Status.Info = info
-	if err := reporting.UpdateStatus(client, fs); err != nil {
+	err = reporting.PatchStatus(client, fs, func() bool {
+		if fs.Status == nil {
+			fs.Status = &cephv1.CephFilesystemStatus{}
+		}
+		fs.Status.Phase = status
+		fs.Status.Info = info
+		return true
+	})
+	if err != nil {
 		logger.Warningf("failed to set filesystem %q status to %q. %v", fs.Name, status, err)
 		return
 	}
 	logger.Debugf("filesystem %q status updated to %q", fs.Name, status)
 }
 
+// updateStatusMirroringPeers records the peers imported from the secrets of the spec. The sync
+// status of the peers is kept until the mirroring status is checked again.
+func (r *ReconcileCephFilesystem) updateStatusMirroringPeers(namespacedName types.NamespacedName, desiredPeers []cephv1.FilesystemMirrorPeerStatus) {
+	fs := &cephv1.CephFilesystem{}
+	if err := r.client.Get(r.opManagerContext, namespacedName, fs); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystem resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph filesystem %q to update mirroring peers status. %v", namespacedName.Name, err)
+		return
+	}
+	err := reporting.PatchStatus(r.client, fs, func() bool {
+		if fs.Status == nil {
+			fs.Status = &cephv1.CephFilesystemStatus{}
+		}
+		if fs.Status.MirroringStatus == nil {
+			fs.Status.MirroringStatus = &cephv1.FilesystemMirroringInfoSpec{}
+		}
+		fs.Status.MirroringStatus.Peers = toPeersStatus(fs.Status.MirroringStatus.Peers, desiredPeers)
+		return true
+	})
+	if err != nil {
+		logger.Warningf("failed to set ceph filesystem %q mirroring peers status. %v", namespacedName.Name, err)
+		return
+	}
+	logger.Debugf("ceph filesystem %q mirroring peers status updated", namespacedName.Name)
+}
+
+// toPeersStatus returns the imported peers with their last known sync status, followed by the
+// peers that were not added by the operator
+func toPeersStatus(currentPeers, desiredPeers []cephv1.FilesystemMirrorPeerStatus) []cephv1.FilesystemMirrorPeerStatus {
+	peers := []cephv1.FilesystemMirrorPeerStatus{}
+	for _, desired := range desiredPeers {
+		peer := desired
+		if current := findPeerStatus(currentPeers, desired.UUID); current != nil {
+			peer = *current
+			peer.SecretName = desired.SecretName
+		}
+		peers = append(peers, peer)
+	}
+	for _, current := range currentPeers {
+		if current.SecretName == "" && findPeerStatus(desiredPeers, current.UUID) == nil {
+			peers = append(peers, current)
+		}
+	}
+	return peers
+}
+
 // updateStatusBucket updates an object with a given status
-func (c *mirrorChecker) updateStatusMirroring(mirrorStatus []cephv1.FilesystemMirroringInfo, snapSchedStatus []cephv1.FilesystemSnapshotSchedulesSpec, details string) {
+func (c *mirrorChecker) updateStatusMirroring(mirrorStatus []cephv1.FilesystemMirroringInfo, snapSchedStatus []cephv1.FilesystemSnapshotSchedulesSpec, peers []cephv1.FilesystemMirrorPeerStatus, details string) {
 	fs := &cephv1.CephFilesystem{}
 	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, fs); err != nil {
 		if kerrors.IsNotFound(err) {
@@ -64,13 +117,15 @@ func (c *mirrorChecker) updateStatusMirroring(mirrorStatus []cephv1.FilesystemMi
 		logger.Warningf("failed to retrieve ceph filesystem %q to update mirroring status. %v", c.namespacedName.Name, err)
 		return
 	}
-	if fs.Status == nil {
-		fs.Status = &cephv1.CephFilesystemStatus{}
-	}
-
-	// Update the CephFilesystem CR status field
-	fs.Status = toCustomResourceStatus(fs.Status, mirrorStatus, snapSchedStatus, details)
-	if err := reporting.UpdateStatus(c.client, fs); err != nil {
+	err := reporting.PatchStatus(c.client, fs, func() bool {
+		if fs.Status == nil {
+			fs.Status = &cephv1.CephFilesystemStatus{}
+		}
+		// Update the CephFilesystem CR status field
+		fs.Status = toCustomResourceStatus(fs.Status, mirrorStatus, snapSchedStatus, peers, details)
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set ceph filesystem %q mirroring status. %v", c.namespacedName.Name, err)
 		return
 	}
@@ -78,7 +133,115 @@ func (c *mirrorChecker) updateStatusMirroring(mirrorStatus []cephv1.FilesystemMi
 	logger.Debugf("ceph filesystem %q mirroring status updated", c.namespacedName.Name)
 }
 
-func toCustomResourceStatus(currentStatus *cephv1.CephFilesystemStatus, mirrorStatus []cephv1.FilesystemMirroringInfo, snapSchedStatus []cephv1.FilesystemSnapshotSchedulesSpec, details string) *cephv1.CephFilesystemStatus {
+// updateStatusPeerConnected sets the PeerConnected condition of the filesystem
+func (c *mirrorChecker) updateStatusPeerConnected(condition cephv1.Condition) {
+	fs := &cephv1.CephFilesystem{}
+	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, fs); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystem resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph filesystem %q to update the peer connection status. %v", c.namespacedName.Name, err)
+		return
+	}
+	err := reporting.PatchStatus(c.client, fs, func() bool {
+		if fs.Status == nil {
+			fs.Status = &cephv1.CephFilesystemStatus{}
+		}
+		cephv1.SetStatusCondition(&fs.Status.Conditions, condition)
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set ceph filesystem %q peer connection status. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	logger.Debugf("ceph filesystem %q peer connection status updated to %q", c.namespacedName.Name, condition.Status)
+}
+
+// updateStatusReplication sets the DRReady and ReplicationDegraded conditions of the filesystem
+// from its mirroring status
+func (c *mirrorChecker) updateStatusReplication() {
+	fs := &cephv1.CephFilesystem{}
+	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, fs); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystem resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph filesystem %q to update the replication status. %v", c.namespacedName.Name, err)
+		return
+	}
+	err := reporting.PatchStatus(c.client, fs, func() bool {
+		if fs.Status == nil {
+			fs.Status = &cephv1.CephFilesystemStatus{}
+		}
+		opcontroller.SetReplicationConditions(&fs.Status.Conditions, replicationIssues(fs.Name, fs.Status))
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set ceph filesystem %q replication status. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	logger.Debugf("ceph filesystem %q replication status updated", c.namespacedName.Name)
+}
+
+// removeReplicationConditions removes the DRReady and ReplicationDegraded conditions of a
+// filesystem that is not mirrored anymore
+func (r *ReconcileCephFilesystem) removeReplicationConditions(namespacedName types.NamespacedName) {
+	fs := &cephv1.CephFilesystem{}
+	if err := r.client.Get(r.opManagerContext, namespacedName, fs); err != nil {
+		logger.Debugf("failed to retrieve ceph filesystem %q to remove the replication status. %v", namespacedName.Name, err)
+		return
+	}
+	err := reporting.PatchStatus(r.client, fs, func() bool {
+		return fs.Status != nil && opcontroller.RemoveReplicationConditions(&fs.Status.Conditions)
+	})
+	if err != nil {
+		logger.Errorf("failed to remove ceph filesystem %q replication status. %v", namespacedName.Name, err)
+	}
+}
+
+// replicationIssues returns the issues of the mirroring of the filesystem found by the last checks
+func replicationIssues(fsName string, status *cephv1.CephFilesystemStatus) []opcontroller.ReplicationIssue {
+	issues := []opcontroller.ReplicationIssue{}
+	if issue := opcontroller.PeerConnectionIssue(status.Conditions); issue != nil {
+		issues = append(issues, *issue)
+	}
+
+	mirroringStatus := status.MirroringStatus
+	switch {
+	case mirroringStatus == nil:
+		issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationStatusUnknownReason, Message: "the mirroring status was not checked yet"})
+	case mirroringStatus.Details != "":
+		issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationStatusUnknownReason, Message: fmt.Sprintf("failed to check the mirroring status. %s", mirroringStatus.Details)})
+	case !isMirroredByDaemon(fsName, mirroringStatus.FilesystemMirroringAllInfo):
+		issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationDaemonUnhealthyReason, Message: "no cephfs-mirror daemon is mirroring the filesystem"})
+	}
+
+	if mirroringStatus != nil {
+		for _, peer := range mirroringStatus.Peers {
+			if peer.FailedDirectories > 0 {
+				issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationSyncBehindReason, Message: fmt.Sprintf("%d directories failed to sync with peer %q", peer.FailedDirectories, peer.SiteName)})
+			}
+		}
+	}
+	return issues
+}
+
+// isMirroredByDaemon returns whether a cephfs-mirror daemon reports the filesystem
+func isMirroredByDaemon(fsName string, daemons []cephv1.FilesystemMirroringInfo) bool {
+	for _, daemon := range daemons {
+		for _, fs := range daemon.Filesystems {
+			if fs.Name == fsName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toCustomResourceStatus(currentStatus *cephv1.CephFilesystemStatus, mirrorStatus []cephv1.FilesystemMirroringInfo, snapSchedStatus []cephv1.FilesystemSnapshotSchedulesSpec, peers []cephv1.FilesystemMirrorPeerStatus, details string) *cephv1.CephFilesystemStatus {
 	mirrorStatusSpec := &cephv1.FilesystemMirroringInfoSpec{}
 	mirrorSnapScheduleStatusSpec := &cephv1.FilesystemSnapshotScheduleStatusSpec{}
 	now := time.Now().UTC().Format(time.RFC3339)
@@ -92,9 +255,13 @@ func toCustomResourceStatus(currentStatus *cephv1.CephFilesystemStatus, mirrorSt
 	// Always display the details, typically an error
 	mirrorStatusSpec.Details = details
 
+	// peers will be nil in case of an error to fetch the mirror status
+	mirrorStatusSpec.Peers = peers
+
 	if currentStatus != nil {
 		if currentStatus.MirroringStatus != nil {
 			mirrorStatusSpec.LastChanged = currentStatus.MirroringStatus.LastChanged
+			mirrorStatusSpec.Peers = mergePeersStatus(currentStatus.MirroringStatus.Peers, peers)
 		}
 		if currentStatus.SnapshotScheduleStatus != nil {
 			mirrorStatusSpec.LastChanged = currentStatus.SnapshotScheduleStatus.LastChanged
@@ -109,5 +276,21 @@ func toCustomResourceStatus(currentStatus *cephv1.CephFilesystemStatus, mirrorSt
 	// Always display the details, typically an error
 	mirrorSnapScheduleStatusSpec.Details = details
 
-	return &cephv1.CephFilesystemStatus{MirroringStatus: mirrorStatusSpec, SnapshotScheduleStatus: mirrorSnapScheduleStatusSpec, Phase: currentStatus.Phase, Info: currentStatus.Info}
+	return &cephv1.CephFilesystemStatus{MirroringStatus: mirrorStatusSpec, SnapshotScheduleStatus: mirrorSnapScheduleStatusSpec, Phase: currentStatus.Phase, Info: currentStatus.Info, Conditions: currentStatus.Conditions}
+}
+
+// mergePeersStatus keeps the secrets the peers were imported from, which are only known by the
+// reconcile of the filesystem, and the current peers when their status could not be checked
+func mergePeersStatus(currentPeers, peers []cephv1.FilesystemMirrorPeerStatus) []cephv1.FilesystemMirrorPeerStatus {
+	if peers == nil {
+		return currentPeers
+	}
+	for i := range peers {
+		for _, current := range currentPeers {
+			if current.UUID == peers[i].UUID {
+				peers[i].SecretName = current.SecretName
+			}
+		}
+	}
+	return peers
 }
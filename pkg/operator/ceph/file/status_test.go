@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToPeersStatus(t *testing.T) {
+	current := []cephv1.FilesystemMirrorPeerStatus{
+		{UUID: "a", SecretName: "secret-a", SnapsSynced: 3},
+		{UUID: "b", SecretName: "secret-b"},
+		{UUID: "c", SnapsSynced: 1},
+	}
+	desired := []cephv1.FilesystemMirrorPeerStatus{
+		{UUID: "a", SecretName: "secret-a"},
+		{UUID: "d", SecretName: "secret-d", SiteName: "remote", FSName: "backup"},
+	}
+
+	peers := toPeersStatus(current, desired)
+	assert.Equal(t, []cephv1.FilesystemMirrorPeerStatus{
+		// the sync status is kept
+		{UUID: "a", SecretName: "secret-a", SnapsSynced: 3},
+		// the new peer
+		{UUID: "d", SecretName: "secret-d", SiteName: "remote", FSName: "backup"},
+		// the peer not added by the operator, "b" was removed
+		{UUID: "c", SnapsSynced: 1},
+	}, peers)
+}
+
+func TestMergePeersStatus(t *testing.T) {
+	current := []cephv1.FilesystemMirrorPeerStatus{{UUID: "a", SecretName: "secret-a"}}
+
+	// the status could not be checked
+	assert.Equal(t, current, mergePeersStatus(current, nil))
+
+	peers := mergePeersStatus(current, []cephv1.FilesystemMirrorPeerStatus{{UUID: "a", SnapsSynced: 2}, {UUID: "b"}})
+	assert.Equal(t, []cephv1.FilesystemMirrorPeerStatus{{UUID: "a", SecretName: "secret-a", SnapsSynced: 2}, {UUID: "b"}}, peers)
+
+	status := toCustomResourceStatus(&cephv1.CephFilesystemStatus{MirroringStatus: &cephv1.FilesystemMirroringInfoSpec{Peers: current}}, nil, nil, nil, "failed")
+	assert.Equal(t, current, status.MirroringStatus.Peers)
+	assert.Equal(t, "failed", status.MirroringStatus.Details)
+}
+
+func TestReplicationIssues(t *testing.T) {
+	status := &cephv1.CephFilesystemStatus{}
+	issues := replicationIssues("myfs", status)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, cephv1.ReplicationStatusUnknownReason, issues[0].Reason)
+
+	status.MirroringStatus = &cephv1.FilesystemMirroringInfoSpec{}
+	issues = replicationIssues("myfs", status)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, cephv1.ReplicationDaemonUnhealthyReason, issues[0].Reason)
+
+	status.MirroringStatus.FilesystemMirroringAllInfo = []cephv1.FilesystemMirroringInfo{{Filesystems: []cephv1.FilesystemsSpec{{Name: "myfs"}}}}
+	status.MirroringStatus.Peers = []cephv1.FilesystemMirrorPeerStatus{{UUID: "a", SiteName: "remote"}}
+	assert.Empty(t, replicationIssues("myfs", status))
+
+	status.MirroringStatus.Peers[0].FailedDirectories = 2
+	issues = replicationIssues("myfs", status)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, cephv1.ReplicationSyncBehindReason, issues[0].Reason)
+	assert.Equal(t, `2 directories failed to sync with peer "remote"`, issues[0].Message)
+}
@@ -0,0 +1,340 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package subvolume to manage CephFS subvolumes
+package subvolume
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/util/exec"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	controllerName = "ceph-fs-subvolume-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephFilesystemSubVolumeKind = reflect.TypeOf(cephv1.CephFilesystemSubVolume{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephFilesystemSubVolumeKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephFilesystemSubVolume reconciles a CephFilesystemSubVolume object
+type ReconcileCephFilesystemSubVolume struct {
+	client           client.Client
+	scheme           *runtime.Scheme
+	context          *clusterd.Context
+	clusterInfo      *cephclient.ClusterInfo
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+}
+
+// Add creates a new CephFilesystemSubVolume Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	return add(mgr, newReconciler(mgr, context, opManagerContext))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context) reconcile.Reconciler {
+	return &ReconcileCephFilesystemSubVolume{
+		client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephFilesystemSubVolume{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephFilesystemSubVolume{}, reconciler)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephFilesystemSubVolume CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephFilesystemSubVolume{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephFilesystemSubVolume object and makes changes based on the state read
+// and what is in the CephFilesystemSubVolume.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephFilesystemSubVolume) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, cephFilesystemSubVolume, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephFilesystemSubVolume, reconcileResponse, err)
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephFilesystemSubVolume) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephFilesystemSubVolume, error) {
+	// Fetch the CephFilesystemSubVolume instance
+	cephFilesystemSubVolume := &cephv1.CephFilesystemSubVolume{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephFilesystemSubVolume)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephFilesystemSubVolume resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, cephFilesystemSubVolume, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, cephFilesystemSubVolume, errors.Wrap(err, "failed to get cephFilesystemSubVolume")
+	}
+
+	// Set a finalizer so we can do cleanup before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephFilesystemSubVolume)
+	if err != nil {
+		return reconcile.Result{}, cephFilesystemSubVolume, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// The CR was just created, initializing status fields
+	if cephFilesystemSubVolume.Status == nil {
+		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionProgressing, "")
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	cephCluster, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// This handles the case where the Ceph Cluster is gone and we want to delete that CR
+		// We skip the deleteSubVolume() function since everything is gone already
+		//
+		// Also, only remove the finalizer if the CephCluster is gone
+		// If not, we should wait for it to be ready
+		// This handles the case where the operator is not ready to accept Ceph command but the cluster exists
+		if !cephFilesystemSubVolume.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			// Remove finalizer
+			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephFilesystemSubVolume)
+			if err != nil {
+				return opcontroller.ImmediateRetryResult, cephFilesystemSubVolume, errors.Wrap(err, "failed to remove finalizer")
+			}
+
+			// Return and do not requeue. Successful deletion.
+			return reconcile.Result{}, cephFilesystemSubVolume, nil
+		}
+		return reconcileResponse, cephFilesystemSubVolume, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	if err != nil {
+		return reconcile.Result{}, cephFilesystemSubVolume, errors.Wrap(err, "failed to populate cluster info")
+	}
+	r.clusterInfo.Context = exec.WithAuditInitiator(r.opManagerContext, cephFilesystemSubVolumeKind, request.Namespace, request.Name)
+
+	// DELETE: the CR was deleted
+	if !cephFilesystemSubVolume.GetDeletionTimestamp().IsZero() {
+		logger.Debugf("deleting subvolume %q", cephFilesystemSubVolume.Name)
+		// On external cluster, we don't delete the subvolume, it has to be deleted manually
+		if cephCluster.Spec.External.Enable {
+			logger.Warning("external subvolume deletion is not supported, delete it manually")
+		} else {
+			err := r.deleteSubVolume(cephFilesystemSubVolume)
+			if err != nil {
+				if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
+					logger.Info(opcontroller.OperatorNotInitializedMessage)
+					return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephFilesystemSubVolume, nil
+				}
+				return reconcile.Result{}, cephFilesystemSubVolume, errors.Wrapf(err, "failed to delete ceph filesystem subvolume %q", cephFilesystemSubVolume.Name)
+			}
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephFilesystemSubVolume)
+		if err != nil {
+			return reconcile.Result{}, cephFilesystemSubVolume, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, cephFilesystemSubVolume, nil
+	}
+
+	// Build the NamespacedName to fetch the Filesystem and make sure it exists, if not we cannot
+	// create the subvolume
+	// On external mode the filesystem is created externally, so we don't need to check for CRD and
+	// assume it's there
+	if !cephCluster.Spec.External.Enable {
+		cephFilesystem := &cephv1.CephFilesystem{}
+		cephFilesystemNamespacedName := types.NamespacedName{Name: cephFilesystemSubVolume.Spec.FilesystemName, Namespace: request.Namespace}
+		err = r.client.Get(r.opManagerContext, cephFilesystemNamespacedName, cephFilesystem)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return reconcile.Result{}, cephFilesystemSubVolume, errors.Wrapf(err, "failed to fetch ceph filesystem %q, cannot create subvolume %q", cephFilesystemSubVolume.Spec.FilesystemName, cephFilesystemSubVolume.Name)
+			}
+			// Error reading the object - requeue the request.
+			return reconcile.Result{}, cephFilesystemSubVolume, errors.Wrap(err, "failed to get cephFilesystem")
+		}
+
+		// If the CephFilesystem is not ready to accept commands, we should wait for it to be ready
+		if cephFilesystem.Status == nil || cephFilesystem.Status.Phase != cephv1.ConditionReady {
+			// We know the CR is present so it should a matter of second for it to become ready
+			return reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}, cephFilesystemSubVolume, nil
+		}
+	}
+
+	// Create or Update ceph filesystem subvolume
+	path, err := r.createOrUpdateSubVolume(cephFilesystemSubVolume)
+	if err != nil {
+		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
+			logger.Info(opcontroller.OperatorNotInitializedMessage)
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephFilesystemSubVolume, nil
+		}
+		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure, "")
+		return reconcile.Result{}, cephFilesystemSubVolume, errors.Wrapf(err, "failed to create or update ceph filesystem subvolume %q", cephFilesystemSubVolume.Name)
+	}
+
+	// Success! Let's update the status
+	if cephCluster.Spec.External.Enable {
+		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionConnected, path)
+	} else {
+		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionReady, path)
+	}
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, cephFilesystemSubVolume, nil
+}
+
+// createOrUpdateSubVolume creates the ceph filesystem subvolume, or updates the attributes of the
+// existing subvolume, and returns its path
+func (r *ReconcileCephFilesystemSubVolume) createOrUpdateSubVolume(cephFilesystemSubVolume *cephv1.CephFilesystemSubVolume) (string, error) {
+	fsName := cephFilesystemSubVolume.Spec.FilesystemName
+	groupName := cephFilesystemSubVolume.Spec.SubVolumeGroupName
+	subvolName := cephFilesystemSubVolume.GetSubVolumeName()
+	logger.Infof("creating ceph filesystem subvolume %s in namespace %s", subvolName, cephFilesystemSubVolume.Namespace)
+
+	// The creation of an existing subvolume sets its size, owner and mode
+	size := sizeBytes(cephFilesystemSubVolume)
+	spec := cephFilesystemSubVolume.Spec
+	err := cephclient.CreateCephFSSubvolume(r.context, r.clusterInfo, fsName, groupName, subvolName, size, spec.UID, spec.GID, spec.Mode, spec.NamespaceIsolated)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create ceph filesystem subvolume %q", subvolName)
+	}
+
+	info, err := cephclient.GetCephFSSubvolumeInfo(r.context, r.clusterInfo, fsName, groupName, subvolName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the info of ceph filesystem subvolume %q", subvolName)
+	}
+
+	// The quota removed from the spec is not removed by the creation
+	if current, _ := info.Quota(); current != size {
+		err = cephclient.ResizeCephFSSubvolume(r.context, r.clusterInfo, fsName, groupName, subvolName, size)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to update the size of ceph filesystem subvolume %q", subvolName)
+		}
+	}
+
+	return info.Path, nil
+}
+
+// sizeBytes returns the quota of the subvolume in bytes, 0 for no quota
+func sizeBytes(cephFilesystemSubVolume *cephv1.CephFilesystemSubVolume) uint64 {
+	size := cephFilesystemSubVolume.Spec.Size
+	if size == nil || size.Sign() <= 0 {
+		return 0
+	}
+	return uint64(size.Value())
+}
+
+// Delete the ceph filesystem subvolume
+func (r *ReconcileCephFilesystemSubVolume) deleteSubVolume(cephFilesystemSubVolume *cephv1.CephFilesystemSubVolume) error {
+	subvolName := cephFilesystemSubVolume.GetSubVolumeName()
+	logger.Infof("deleting ceph filesystem subvolume object %q", subvolName)
+	err := cephclient.DeleteCephFSSubvolume(r.context, r.clusterInfo, cephFilesystemSubVolume.Spec.FilesystemName, cephFilesystemSubVolume.Spec.SubVolumeGroupName, subvolName)
+	if err != nil {
+		code, ok := exec.ExitStatus(errors.Cause(err))
+		// If the subvolume or its filesystem do not exist, we should not return an error
+		if ok && code == int(syscall.ENOENT) {
+			logger.Debugf("ceph filesystem subvolume %q do not exist", subvolName)
+			return nil
+		}
+		// If the subvolume has snapshots the command will fail with:
+		// Error ENOTEMPTY: subvolume 'subvol1' has snapshots
+		if ok && code == int(syscall.ENOTEMPTY) {
+			return errors.Wrapf(err, "failed to delete ceph filesystem subvolume %q, remove its snapshots first", subvolName)
+		}
+		return err
+	}
+
+	logger.Infof("deleted ceph filesystem subvolume %q", subvolName)
+	return nil
+}
+
+// updateStatus updates an object with a given status and the path of the subvolume, the path is
+// kept when empty
+func (r *ReconcileCephFilesystemSubVolume) updateStatus(client client.Client, name types.NamespacedName, status cephv1.ConditionType, path string) {
+	cephFilesystemSubVolume := &cephv1.CephFilesystemSubVolume{}
+	if err := client.Get(r.opManagerContext, name, cephFilesystemSubVolume); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystemSubVolume resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph filesystem subvolume %q to update status to %q. %v", name, status, err)
+		return
+	}
+	err := reporting.PatchStatus(client, cephFilesystemSubVolume, func() bool {
+		if cephFilesystemSubVolume.Status == nil {
+			cephFilesystemSubVolume.Status = &cephv1.CephFilesystemSubVolumeStatus{}
+		}
+		cephFilesystemSubVolume.Status.Phase = status
+		if path != "" {
+			cephFilesystemSubVolume.Status.Path = path
+		}
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set ceph filesystem subvolume %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("ceph filesystem subvolume %q status updated to %q", name, status)
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subvolume
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const subvolumePath = "/volumes/csi/subvol-a/5b5d1d3c-8f4a-4a0b-b0a4-2ff6b5a2b9a1"
+
+func TestCephFilesystemSubVolumeController(t *testing.T) {
+	ctx := context.TODO()
+	// Set DEBUG logging
+	capnslog.SetGlobalLogLevel(capnslog.DEBUG)
+	os.Setenv("ROOK_LOG_LEVEL", "DEBUG")
+
+	var (
+		name      = "subvol-a"
+		namespace = "rook-ceph"
+	)
+
+	// A cephFilesystemSubVolume resource with metadata and spec.
+	cephFilesystemSubVolume := &cephv1.CephFilesystemSubVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID("c47cac40-9bee-4d52-823b-ccd803ba5bfe"),
+		},
+		Spec: cephv1.CephFilesystemSubVolumeSpec{
+			FilesystemName:     namespace,
+			SubVolumeGroupName: "csi",
+		},
+		Status: &cephv1.CephFilesystemSubVolumeStatus{
+			Phase: "",
+		},
+	}
+
+	// Objects to track in the fake client.
+	object := []runtime.Object{
+		cephFilesystemSubVolume,
+	}
+
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "status" {
+				return `{"fsid":"c47cac40-9bee-4d52-823b-ccd803ba5bfe","health":{"checks":{},"status":"HEALTH_ERR"},"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+			}
+
+			return "", nil
+		},
+	}
+	c := &clusterd.Context{
+		Executor:      executor,
+		Clientset:     testop.New(t, 1),
+		RookClientset: rookclient.NewSimpleClientset(),
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
+
+	// Create a ReconcileCephFilesystemSubVolume object with the scheme and fake client.
+	r := &ReconcileCephFilesystemSubVolume{
+		recorder:         &record.FakeRecorder{},
+		client:           cl,
+		scheme:           s,
+		context:          c,
+		opManagerContext: ctx,
+	}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource .
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namespace,
+			Namespace: namespace,
+		},
+		Status: cephv1.ClusterStatus{
+			Phase: "",
+			CephVersion: &cephv1.ClusterVersion{
+				Version: "14.2.9-0",
+			},
+			CephStatus: &cephv1.CephStatus{
+				Health: "",
+			},
+		},
+	}
+
+	cephFilesystem := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namespace,
+			Namespace: namespace,
+		},
+		Status: &cephv1.CephFilesystemStatus{
+			Phase: "",
+		},
+	}
+
+	t.Run("error - no ceph cluster", func(t *testing.T) {
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+	})
+
+	t.Run("error - ceph cluster not ready", func(t *testing.T) {
+		object = append(object, cephCluster)
+		// Create a fake client to mock API calls.
+		cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
+		r = &ReconcileCephFilesystemSubVolume{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+
+		cephCluster.Status.Phase = cephv1.ConditionReady
+		cephCluster.Status.CephStatus.Health = "HEALTH_OK"
+	})
+
+	// Mock clusterInfo
+	secrets := map[string][]byte{
+		"fsid":         []byte(name),
+		"mon-secret":   []byte("monsecret"),
+		"admin-secret": []byte("adminsecret"),
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph-mon",
+			Namespace: namespace,
+		},
+		Data: secrets,
+		Type: k8sutil.RookType,
+	}
+	_, err := c.Clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	t.Run("error - ceph filesystem not ready", func(t *testing.T) {
+		objects := []runtime.Object{
+			cephFilesystemSubVolume,
+			cephCluster,
+			cephFilesystem,
+		}
+		cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
+		c.Client = cl
+		r = &ReconcileCephFilesystemSubVolume{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+		cephFilesystem.Status.Phase = cephv1.ConditionReady
+	})
+
+	t.Run("success - ceph cluster ready, mds are running and subvolume created", func(t *testing.T) {
+		objects := []runtime.Object{
+			cephFilesystemSubVolume,
+			cephCluster,
+			cephFilesystem,
+		}
+		cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
+		c.Client = cl
+
+		created := false
+		c.Executor = &exectest.MockExecutor{
+			MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+				if args[0] == "fs" && args[1] == "subvolume" && args[2] == "create" {
+					assert.Equal(t, []string{namespace, name, "--group_name", "csi"}, args[3:7])
+					created = true
+					return "", nil
+				}
+				if args[0] == "fs" && args[1] == "subvolume" && args[2] == "info" {
+					return `{"bytes_quota": "infinite", "bytes_used": 0, "path": "` + subvolumePath + `"}`, nil
+				}
+
+				return "", errors.Errorf("unknown command. %v", args)
+			},
+		}
+		r = &ReconcileCephFilesystemSubVolume{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
+
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.False(t, res.Requeue)
+		assert.True(t, created)
+
+		err = r.client.Get(ctx, req.NamespacedName, cephFilesystemSubVolume)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionReady, cephFilesystemSubVolume.Status.Phase)
+		assert.Equal(t, subvolumePath, cephFilesystemSubVolume.Status.Path)
+	})
+
+	t.Run("failure - subvolume group does not exist", func(t *testing.T) {
+		c.Executor = &exectest.MockExecutor{
+			MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+				if args[0] == "fs" && args[1] == "subvolume" && args[2] == "create" {
+					return "Error ENOENT: subvolume group 'csi' does not exist", errors.New("exit status 2")
+				}
+				return "", errors.Errorf("unknown command. %v", args)
+			},
+		}
+
+		_, err := r.Reconcile(ctx, req)
+		assert.Error(t, err)
+
+		err = r.client.Get(ctx, req.NamespacedName, cephFilesystemSubVolume)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionFailure, cephFilesystemSubVolume.Status.Phase)
+		// the path of the subvolume is kept
+		assert.Equal(t, subvolumePath, cephFilesystemSubVolume.Status.Path)
+	})
+}
+
+// import TestMockExecHelperProcess
+func TestMockExecHelperProcess(t *testing.T) {
+	exectest.TestMockExecHelperProcess(t)
+}
+
+func TestCreateOrUpdateSubVolume(t *testing.T) {
+	quota := `"infinite"`
+	var created, resized []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "create" {
+				created = args[3:]
+				return "", nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "info" {
+				return `{"bytes_quota": ` + quota + `, "bytes_used": 0, "path": "` + subvolumePath + `"}`, nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "resize" {
+				resized = append(resized, args[5])
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	r := &ReconcileCephFilesystemSubVolume{
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminTestClusterInfo("rook-ceph"),
+	}
+	uid, gid := int64(1000), int64(2000)
+	size := resource.MustParse("1Gi")
+	subvolume := &cephv1.CephFilesystemSubVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "subvol-a"},
+		Spec: cephv1.CephFilesystemSubVolumeSpec{
+			Name:              "data",
+			FilesystemName:    "myfs",
+			Size:              &size,
+			UID:               &uid,
+			GID:               &gid,
+			Mode:              "750",
+			NamespaceIsolated: true,
+		},
+	}
+
+	t.Run("the subvolume is created with its attributes", func(t *testing.T) {
+		quota = `1073741824`
+		path, err := r.createOrUpdateSubVolume(subvolume)
+		assert.NoError(t, err)
+		assert.Equal(t, subvolumePath, path)
+		assert.Equal(t, []string{"myfs", "data", "--size", "1073741824", "--uid", "1000", "--gid", "2000", "--mode", "750", "--namespace-isolated"}, created[:11])
+		assert.Empty(t, resized)
+	})
+
+	t.Run("the quota removed from the spec is removed", func(t *testing.T) {
+		subvolume.Spec.Size = nil
+		_, err := r.createOrUpdateSubVolume(subvolume)
+		assert.NoError(t, err)
+		assert.NotContains(t, created, "--size")
+		assert.Equal(t, []string{"inf"}, resized)
+	})
+}
+
+func TestDeleteSubVolume(t *testing.T) {
+	var deleted []string
+	executor := &exectest.MockExecutor{}
+	r := &ReconcileCephFilesystemSubVolume{
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminTestClusterInfo("rook-ceph"),
+	}
+	subvolume := &cephv1.CephFilesystemSubVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "subvol-a"},
+		Spec:       cephv1.CephFilesystemSubVolumeSpec{FilesystemName: "myfs", SubVolumeGroupName: "csi"},
+	}
+
+	t.Run("the subvolume is deleted", func(t *testing.T) {
+		executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "rm" {
+				deleted = args[3:8]
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		}
+		assert.NoError(t, r.deleteSubVolume(subvolume))
+		assert.Equal(t, []string{"myfs", "subvol-a", "--force", "--group_name", "csi"}, deleted)
+	})
+
+	t.Run("the subvolume does not exist", func(t *testing.T) {
+		executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+			return "", exectest.MockExecCommandReturns(t, "", "Error ENOENT: subvolume 'subvol-a' does not exist", int(syscall.ENOENT))
+		}
+		assert.NoError(t, r.deleteSubVolume(subvolume))
+	})
+
+	t.Run("the deletion fails while the subvolume has snapshots", func(t *testing.T) {
+		executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+			return "", exectest.MockExecCommandReturns(t, "", "Error ENOTEMPTY: subvolume 'subvol-a' has snapshots", int(syscall.ENOTEMPTY))
+		}
+		err := r.deleteSubVolume(subvolume)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "remove its snapshots first")
+	})
+}
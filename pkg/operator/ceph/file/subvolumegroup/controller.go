@@ -21,6 +21,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -43,14 +45,24 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
 	controllerName = "ceph-fs-subvolumegroup-controller"
+
+	// usageRefreshInterval is the interval the usage of the subvolume groups in their status is
+	// refreshed at
+	usageRefreshInterval = 5 * time.Minute
+
+	// snapshotPruningFailedReason is the reason of the event reporting a failure to prune the
+	// snapshots of the subvolumes of a group
+	snapshotPruningFailedReason = "SnapshotPruningFailed"
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
@@ -70,6 +82,7 @@ type ReconcileCephFilesystemSubVolumeGroup struct {
 	context          *clusterd.Context
 	clusterInfo      *cephclient.ClusterInfo
 	opManagerContext context.Context
+	recorder         record.EventRecorder
 }
 
 // Add creates a new CephFilesystemSubVolumeGroup Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -85,12 +98,14 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 		scheme:           mgr.GetScheme(),
 		context:          context,
 		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephFilesystemSubVolumeGroup{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephFilesystemSubVolumeGroup{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -111,31 +126,29 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephFilesystemSubVolumeGroup) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
-	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
-	}
+	reconcileResponse, cephFilesystemSubVolumeGroup, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephFilesystemSubVolumeGroup, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileCephFilesystemSubVolumeGroup) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileCephFilesystemSubVolumeGroup) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephFilesystemSubVolumeGroup, error) {
 	// Fetch the CephFilesystemSubVolumeGroup instance
 	cephFilesystemSubVolumeGroup := &cephv1.CephFilesystemSubVolumeGroup{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephFilesystemSubVolumeGroup)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("cephFilesystemSubVolumeGroup resource not found. Ignoring since object must be deleted.")
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephFilesystemSubVolumeGroup, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get cephFilesystemSubVolumeGroup")
+		return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrap(err, "failed to get cephFilesystemSubVolumeGroup")
 	}
 
 	// Set a finalizer so we can do cleanup before the object goes away
 	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephFilesystemSubVolumeGroup)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+		return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrap(err, "failed to add finalizer")
 	}
 
 	// The CR was just created, initializing status fields
@@ -156,21 +169,32 @@ func (r *ReconcileCephFilesystemSubVolumeGroup) reconcile(request reconcile.Requ
 			// Remove finalizer
 			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephFilesystemSubVolumeGroup)
 			if err != nil {
-				return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to remove finalizer")
+				return opcontroller.ImmediateRetryResult, cephFilesystemSubVolumeGroup, errors.Wrap(err, "failed to remove finalizer")
 			}
 
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephFilesystemSubVolumeGroup, nil
 		}
-		return reconcileResponse, nil
+		return reconcileResponse, cephFilesystemSubVolumeGroup, nil
 	}
 
 	// Populate clusterInfo during each reconcile
 	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+		return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrap(err, "failed to populate cluster info")
+	}
+	r.clusterInfo.Context = exec.WithAuditInitiator(r.opManagerContext, "CephFilesystemSubVolumeGroup", request.Namespace, request.Name)
+
+	// The name of the subvolume group cannot be changed, changing it would create another subvolume
+	// group and orphan the one of the CR. The webhook rejects the change, but it may not be deployed.
+	if adopted := adoptedSubVolumeGroupName(cephFilesystemSubVolumeGroup); adopted != "" && adopted != cephFilesystemSubVolumeGroup.GetSubVolumeGroupName() {
+		if cephFilesystemSubVolumeGroup.GetDeletionTimestamp().IsZero() {
+			r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure)
+			return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Errorf("the name of ceph filesystem subvolume group %q cannot be changed from %q to %q, restore it in the spec", cephFilesystemSubVolumeGroup.Name, adopted, cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
+		}
+		// the subvolume group of the CR is the one deleted
+		cephFilesystemSubVolumeGroup.Spec.Name = adopted
 	}
-	r.clusterInfo.Context = r.opManagerContext
 
 	// DELETE: the CR was deleted
 	if !cephFilesystemSubVolumeGroup.GetDeletionTimestamp().IsZero() {
@@ -178,30 +202,32 @@ func (r *ReconcileCephFilesystemSubVolumeGroup) reconcile(request reconcile.Requ
 		// On external cluster, we don't delete the subvolume group, it has to be deleted manually
 		if cephCluster.Spec.External.Enable {
 			logger.Warning("external subvolume group deletion is not supported, delete it manually")
+		} else if cephFilesystemSubVolumeGroup.Spec.DeletionPolicy == cephv1.SubVolumeGroupDeletionPolicyRetain {
+			logger.Infof("retaining ceph filesystem subvolume group %q with its subvolumes, the deletion policy is %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), cephv1.SubVolumeGroupDeletionPolicyRetain)
 		} else {
 			err := r.deleteSubVolumeGroup(cephFilesystemSubVolumeGroup)
 			if err != nil {
 				if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
 					logger.Info(opcontroller.OperatorNotInitializedMessage)
-					return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+					return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephFilesystemSubVolumeGroup, nil
 				}
-				return reconcile.Result{}, errors.Wrapf(err, "failed to delete ceph ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.Name)
+				return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrapf(err, "failed to delete ceph ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.Name)
 			}
 		}
 
 		err = csi.SaveClusterConfig(r.context.Clientset, buildClusterID(cephFilesystemSubVolumeGroup), r.clusterInfo, nil)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrap(err, "failed to save cluster config")
+			return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrap(err, "failed to save cluster config")
 		}
 
 		// Remove finalizer
 		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephFilesystemSubVolumeGroup)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrap(err, "failed to remove finalizer")
 		}
 
 		// Return and do not requeue. Successful deletion.
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, cephFilesystemSubVolumeGroup, nil
 	}
 
 	// Build the NamespacedName to fetch the Filesystem and make sure it exists, if not we cannot
@@ -214,48 +240,51 @@ func (r *ReconcileCephFilesystemSubVolumeGroup) reconcile(request reconcile.Requ
 		err = r.client.Get(r.opManagerContext, cephFilesystemNamespacedName, cephFilesystem)
 		if err != nil {
 			if kerrors.IsNotFound(err) {
-				return reconcile.Result{}, errors.Wrapf(err, "failed to fetch ceph filesystem %q, cannot create subvolume group %q", cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.Name)
+				return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrapf(err, "failed to fetch ceph filesystem %q, cannot create subvolume group %q", cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.Name)
 			}
 			// Error reading the object - requeue the request.
-			return reconcile.Result{}, errors.Wrap(err, "failed to get cephFilesystemSubVolumeGroup")
+			return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrap(err, "failed to get cephFilesystemSubVolumeGroup")
 		}
 
 		// If the CephFilesystem is not ready to accept commands, we should wait for it to be ready
 		if cephFilesystem.Status.Phase != cephv1.ConditionReady {
 			// We know the CR is present so it should a matter of second for it to become ready
-			return reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}, errors.Wrapf(err, "failed to fetch ceph filesystem %q, cannot create subvolume group %q", cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.Name)
+			return reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}, cephFilesystemSubVolumeGroup, errors.Wrapf(err, "failed to fetch ceph filesystem %q, cannot create subvolume group %q", cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.Name)
 		}
 	}
 
 	// Create or Update ceph filesystem subvolume group
-	// On external mode the subvolume group is created externally, so we don't need to try to create
-	// it and assume it's there already
-	if cephCluster.Spec.External.Enable {
-		logger.Debug("external subvolume group creation is not supported, create it manually, the controller will assume it's there")
-	} else {
-		err = r.createOrUpdateSubVolumeGroup(cephFilesystemSubVolumeGroup)
-		if err != nil {
-			if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
-				logger.Info(opcontroller.OperatorNotInitializedMessage)
-				return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
-			}
+	// On external mode the subvolume group is created with the imported credentials if their caps
+	// allow it, otherwise it must be created externally and the controller assumes it's there
+	err = r.createOrUpdateSubVolumeGroup(cephFilesystemSubVolumeGroup)
+	if err != nil {
+		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
+			logger.Info(opcontroller.OperatorNotInitializedMessage)
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephFilesystemSubVolumeGroup, nil
+		}
+		if cephCluster.Spec.External.Enable && isPermissionDenied(err) {
+			logger.Warningf("the external cluster user is not allowed to create subvolume group %q, create it manually, the controller will assume it's there. %v", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), err)
+		} else {
 			r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure)
-			return reconcile.Result{}, errors.Wrapf(err, "failed to create or update ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.Name)
+			return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrapf(err, "failed to create or update ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.Name)
 		}
 	}
 
+	r.reconcileSnapshotRetention(&cephCluster, cephFilesystemSubVolumeGroup)
+
 	// Update CSI config map
 	// If the mon endpoints change, the mon health check go routine will take care of updating the
 	// config map, so no special care is needed in this controller
+	cephFS := csi.CephFSMountOptions(&cephCluster.Spec)
+	cephFS.SubvolumeGroup = cephFilesystemSubVolumeGroup.GetSubVolumeGroupName()
 	csiClusterConfigEntry := csi.CsiClusterConfigEntry{
-		Monitors: csi.MonEndpoints(r.clusterInfo.Monitors),
-		CephFS: &csi.CsiCephFSSpec{
-			SubvolumeGroup: cephFilesystemSubVolumeGroup.Name,
-		},
+		Monitors:     csi.MonEndpoints(r.clusterInfo.Monitors),
+		CephFS:       cephFS,
+		ReadAffinity: csi.ReadAffinity(&cephCluster.Spec),
 	}
 	err = csi.SaveClusterConfig(r.context.Clientset, buildClusterID(cephFilesystemSubVolumeGroup), r.clusterInfo, &csiClusterConfigEntry)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to save cluster config")
+		return reconcile.Result{}, cephFilesystemSubVolumeGroup, errors.Wrap(err, "failed to save cluster config")
 	}
 
 	// Success! Let's update the status
@@ -265,46 +294,367 @@ func (r *ReconcileCephFilesystemSubVolumeGroup) reconcile(request reconcile.Requ
 		r.updateStatus(r.client, request.NamespacedName, cephv1.ConditionReady)
 	}
 
-	// Return and do not requeue
+	// Requeue to refresh the usage of the subvolume group in the status
 	logger.Debug("done reconciling")
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: usageRefreshInterval}, cephFilesystemSubVolumeGroup, nil
 }
 
 // Create the ceph filesystem subvolume group
 func (r *ReconcileCephFilesystemSubVolumeGroup) createOrUpdateSubVolumeGroup(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) error {
-	logger.Infof("creating ceph filesystem subvolume group %s in namespace %s", cephFilesystemSubVolumeGroup.Name, cephFilesystemSubVolumeGroup.Namespace)
+	logger.Infof("creating ceph filesystem subvolume group %s in namespace %s", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), cephFilesystemSubVolumeGroup.Namespace)
+
+	dataPool, err := r.dataPoolName(cephFilesystemSubVolumeGroup)
+	if err != nil {
+		return err
+	}
 
-	err := cephclient.CreateCephFSSubVolumeGroup(r.context, r.clusterInfo, cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.Name)
+	quota := quotaBytes(cephFilesystemSubVolumeGroup)
+	err = cephclient.CreateCephFSSubVolumeGroup(r.context, r.clusterInfo, cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), dataPool, quota)
 	if err != nil {
-		return errors.Wrapf(err, "failed to create ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.Name)
+		return errors.Wrapf(err, "failed to create ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
+	}
+
+	// The quota of an existing subvolume group is not changed by the creation
+	err = r.updateQuota(cephFilesystemSubVolumeGroup, quota)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the quota of ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
+	}
+
+	err = r.pinSubVolumeGroup(cephFilesystemSubVolumeGroup)
+	if err != nil {
+		return errors.Wrapf(err, "failed to pin ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
 	}
 
 	return nil
 }
 
+// reconcileSnapshotRetention prunes the snapshots of the subvolumes of the group. A failure to prune
+// them does not fail the reconcile, it is reported with an event and retried by the next reconcile.
+func (r *ReconcileCephFilesystemSubVolumeGroup) reconcileSnapshotRetention(cephCluster *cephv1.CephCluster, cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) {
+	retention := cephFilesystemSubVolumeGroup.Spec.SnapshotRetention
+	if retention == nil {
+		return
+	}
+	if cephCluster.Spec.External.Enable && !retention.PruneOnExternalCluster {
+		logger.Debugf("not pruning the snapshots of subvolume group %q of the external cluster", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
+		return
+	}
+
+	err := r.pruneSnapshots(cephFilesystemSubVolumeGroup)
+	if err != nil {
+		logger.Errorf("failed to prune the snapshots of ceph filesystem subvolume group %q. %v", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), err)
+		r.recorder.Event(cephFilesystemSubVolumeGroup, corev1.EventTypeWarning, snapshotPruningFailedReason, err.Error())
+	}
+}
+
+// subVolumeSnapshot is a snapshot of a subvolume considered for pruning
+type subVolumeSnapshot struct {
+	name             string
+	created          time.Time
+	hasPendingClones bool
+}
+
+// pruneSnapshots deletes the snapshots of the subvolumes of the group beyond the count or older than
+// the duration of the snapshot retention of the spec. It runs on every reconcile, so at least every
+// usageRefreshInterval.
+func (r *ReconcileCephFilesystemSubVolumeGroup) pruneSnapshots(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) error {
+	retention := cephFilesystemSubVolumeGroup.Spec.SnapshotRetention
+	if retention == nil {
+		return nil
+	}
+
+	fsName := cephFilesystemSubVolumeGroup.Spec.FilesystemName
+	groupName := cephFilesystemSubVolumeGroup.GetSubVolumeGroupName()
+	subvolumes, err := cephclient.ListCephFSSubvolumes(r.context, r.clusterInfo, fsName, groupName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the subvolumes of ceph filesystem subvolume group %q", groupName)
+	}
+
+	pruned := 0
+	for _, subvolume := range subvolumes {
+		snapshots, err := r.subVolumeSnapshots(fsName, groupName, subvolume)
+		if err != nil {
+			return err
+		}
+		// The newest snapshots are kept first
+		sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].created.After(snapshots[j].created) })
+		for i, snapshot := range snapshots {
+			if !snapshotExpired(retention, i, snapshot.created) {
+				continue
+			}
+			// A snapshot cannot be deleted until its clones complete
+			if snapshot.hasPendingClones {
+				logger.Infof("keeping expired snapshot %q of subvolume %q in group %q until its clones complete", snapshot.name, subvolume, groupName)
+				continue
+			}
+			if err := cephclient.DeleteCephFSSubvolumeSnapshot(r.context, r.clusterInfo, fsName, groupName, subvolume, snapshot.name); err != nil {
+				return err
+			}
+			pruned++
+		}
+	}
+	if pruned > 0 {
+		logger.Infof("pruned %d snapshots of the subvolumes of ceph filesystem subvolume group %q", pruned, groupName)
+	}
+	return nil
+}
+
+// subVolumeSnapshots returns the snapshots of a subvolume with their creation time
+func (r *ReconcileCephFilesystemSubVolumeGroup) subVolumeSnapshots(fsName, groupName, subvolume string) ([]subVolumeSnapshot, error) {
+	names, err := cephclient.ListCephFSSubvolumeSnapshots(r.context, r.clusterInfo, fsName, groupName, subvolume)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]subVolumeSnapshot, 0, len(names))
+	for _, name := range names {
+		info, err := cephclient.GetCephFSSubvolumeSnapshotInfo(r.context, r.clusterInfo, fsName, groupName, subvolume, name)
+		if err != nil {
+			return nil, err
+		}
+		created, err := info.Created()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the creation time of snapshot %q of subvolume %q", name, subvolume)
+		}
+		snapshots = append(snapshots, subVolumeSnapshot{name: name, created: created, hasPendingClones: info.HasPendingClones == "yes"})
+	}
+	return snapshots, nil
+}
+
+// snapshotExpired returns whether the snapshot at the given index of the snapshots of a subvolume,
+// newest first, is beyond the count or older than the duration of the retention
+func snapshotExpired(retention *cephv1.CephFilesystemSubVolumeGroupSnapshotRetention, index int, created time.Time) bool {
+	if retention.Count != nil && index >= *retention.Count {
+		return true
+	}
+	return retention.Duration != nil && time.Since(created) > retention.Duration.Duration
+}
+
+// pinSubVolumeGroup applies the pinning policy of the spec to the subvolume group. The pinning is
+// idempotent, it is applied on every reconcile so the changes made outside of the CR are reverted. The
+// policy applied is recorded in the status, so that its pin is removed when the policy is removed or
+// changed in the spec.
+func (r *ReconcileCephFilesystemSubVolumeGroup) pinSubVolumeGroup(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) error {
+	fsName := cephFilesystemSubVolumeGroup.Spec.FilesystemName
+	groupName := cephFilesystemSubVolumeGroup.GetSubVolumeGroupName()
+	pinning := cephFilesystemSubVolumeGroup.Spec.Pinning
+	var applied *cephv1.CephFilesystemSubVolumeGroupPinning
+	if cephFilesystemSubVolumeGroup.Status != nil {
+		applied = cephFilesystemSubVolumeGroup.Status.Pinning
+	}
+
+	pinType, setting := pinSetting(pinning)
+	if appliedType, _ := pinSetting(applied); appliedType != "" && appliedType != pinType {
+		logger.Infof("removing the %s pin of ceph filesystem subvolume group %q", appliedType, groupName)
+		if err := cephclient.PinCephFSSubVolumeGroup(r.context, r.clusterInfo, fsName, groupName, appliedType, unpinSettings[appliedType]); err != nil {
+			return err
+		}
+	}
+	if pinType != "" {
+		if err := cephclient.PinCephFSSubVolumeGroup(r.context, r.clusterInfo, fsName, groupName, pinType, setting); err != nil {
+			return err
+		}
+	}
+
+	if reflect.DeepEqual(applied, pinning) {
+		return nil
+	}
+	err := reporting.PatchStatus(r.client, cephFilesystemSubVolumeGroup, func() bool {
+		if cephFilesystemSubVolumeGroup.Status == nil {
+			cephFilesystemSubVolumeGroup.Status = &cephv1.CephFilesystemSubVolumeGroupStatus{}
+		}
+		cephFilesystemSubVolumeGroup.Status.Pinning = pinning.DeepCopy()
+		return true
+	})
+	return errors.Wrapf(err, "failed to record the pinning of ceph filesystem subvolume group %q", groupName)
+}
+
+// unpinSettings are the settings removing the pin of each pinning policy
+var unpinSettings = map[string]string{"export": "-1", "distributed": "0", "random": "0"}
+
+// pinSetting returns the type and the setting of a pinning policy, an empty type when not pinned
+func pinSetting(pinning *cephv1.CephFilesystemSubVolumeGroupPinning) (string, string) {
+	switch {
+	case pinning == nil:
+		return "", ""
+	case pinning.Export != nil:
+		return "export", strconv.Itoa(*pinning.Export)
+	case pinning.Distributed != nil:
+		return "distributed", strconv.Itoa(*pinning.Distributed)
+	case pinning.Random != nil:
+		return "random", strconv.FormatFloat(*pinning.Random, 'f', -1, 64)
+	}
+	return "", ""
+}
+
+// dataPoolName returns the name in Ceph of the data pool of the spec, after checking it is a data pool
+// of the filesystem, or an empty name for the default data pool of the filesystem
+func (r *ReconcileCephFilesystemSubVolumeGroup) dataPoolName(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) (string, error) {
+	dataPool := cephFilesystemSubVolumeGroup.Spec.DataPoolName
+	if dataPool == "" {
+		return "", nil
+	}
+
+	fsName := cephFilesystemSubVolumeGroup.Spec.FilesystemName
+	filesystems, err := cephclient.ListFilesystems(r.context, r.clusterInfo)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check the data pool %q of filesystem %q", dataPool, fsName)
+	}
+	for _, filesystem := range filesystems {
+		if filesystem.Name != fsName {
+			continue
+		}
+		for _, pool := range filesystem.DataPools {
+			// The pools of the CephFilesystem are named after the filesystem in Ceph
+			if pool == dataPool || pool == fmt.Sprintf("%s-%s", fsName, dataPool) {
+				return pool, nil
+			}
+		}
+		return "", errors.Errorf("data pool %q is not a data pool of filesystem %q", dataPool, fsName)
+	}
+	return "", errors.Errorf("filesystem %q not found", fsName)
+}
+
+// updateQuota resizes the subvolume group when its quota differs from the quota of the spec, in bytes
+// or 0 for no quota
+func (r *ReconcileCephFilesystemSubVolumeGroup) updateQuota(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup, quota uint64) error {
+	info, err := cephclient.GetCephFSSubVolumeGroupInfo(r.context, r.clusterInfo, cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
+	if err != nil {
+		// The versions of Ceph without the info of the subvolume groups do not support their quota
+		if quota == 0 {
+			logger.Debugf("failed to get the info of ceph filesystem subvolume group %q, assuming it has no quota. %v", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), err)
+			return nil
+		}
+		return err
+	}
+
+	current, _ := info.Quota()
+	if current == quota {
+		return nil
+	}
+	return cephclient.ResizeCephFSSubVolumeGroup(r.context, r.clusterInfo, cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), quota)
+}
+
+// quotaBytes returns the quota of the subvolume group in bytes, 0 for no quota
+func quotaBytes(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) uint64 {
+	quota := cephFilesystemSubVolumeGroup.Spec.Quota
+	if quota == nil || quota.Sign() <= 0 {
+		return 0
+	}
+	return uint64(quota.Value())
+}
+
 // Delete the ceph filesystem subvolume group
 func (r *ReconcileCephFilesystemSubVolumeGroup) deleteSubVolumeGroup(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) error {
-	logger.Infof("deleting ceph filesystem subvolume group object %q", cephFilesystemSubVolumeGroup.Name)
-	if err := cephclient.DeleteCephFSSubVolumeGroup(r.context, r.clusterInfo, cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.Name); err != nil {
+	logger.Infof("deleting ceph filesystem subvolume group object %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
+	// The subvolume group is kept while another CR manages it, its subvolumes may be used through the other CR
+	other, err := r.otherSubVolumeGroupCR(cephFilesystemSubVolumeGroup)
+	if err != nil {
+		return err
+	}
+	if other != "" {
+		logger.Warningf("retaining ceph filesystem subvolume group %q with its subvolumes, it is also managed by the CR %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), other)
+		return nil
+	}
+	if cephFilesystemSubVolumeGroup.Spec.DeletionPolicy == cephv1.SubVolumeGroupDeletionPolicyForce {
+		// The webhook refuses this policy, it is checked again when the webhook is not deployed
+		if cephFilesystemSubVolumeGroup.GetSubVolumeGroupName() == cephv1.CSISubVolumeGroupName {
+			return errors.Errorf("refusing to delete the subvolumes of the %q subvolume group of the CSI driver, set the deletion policy to %q or %q", cephv1.CSISubVolumeGroupName, cephv1.SubVolumeGroupDeletionPolicyDelete, cephv1.SubVolumeGroupDeletionPolicyRetain)
+		}
+		if err := r.deleteSubVolumes(cephFilesystemSubVolumeGroup); err != nil {
+			return err
+		}
+	}
+	if err := cephclient.DeleteCephFSSubVolumeGroup(r.context, r.clusterInfo, cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.GetSubVolumeGroupName()); err != nil {
 		code, ok := exec.ExitStatus(err)
 		// If the subvolume group does not exit, we should not return an error
 		if ok && code == int(syscall.ENOENT) {
-			logger.Debugf("ceph filesystem subvolume group %q do not exist", cephFilesystemSubVolumeGroup.Name)
+			logger.Debugf("ceph filesystem subvolume group %q do not exist", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
 			return nil
 		}
 		// If the subvolume group has subvolumes the command will fail with:
 		// Error ENOTEMPTY: error in rmdir /volumes/csi
 		if ok && (code == int(syscall.ENOTEMPTY)) {
-			return errors.Wrapf(err, "failed to delete ceph filesystem subvolume group %q, remove the subvolumes first", cephFilesystemSubVolumeGroup.Name)
+			return errors.Wrapf(err, "failed to delete ceph filesystem subvolume group %q, remove the subvolumes first or set the deletion policy to %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName(), cephv1.SubVolumeGroupDeletionPolicyForce)
+		}
+
+		return errors.Wrapf(err, "failed to delete ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
+	}
+
+	logger.Infof("deleted ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.GetSubVolumeGroupName())
+	return nil
+}
+
+// otherSubVolumeGroupCR returns the name of another CR managing the same subvolume group in the
+// same filesystem, or an empty string
+func (r *ReconcileCephFilesystemSubVolumeGroup) otherSubVolumeGroupCR(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) (string, error) {
+	groups := &cephv1.CephFilesystemSubVolumeGroupList{}
+	err := r.client.List(r.opManagerContext, groups, client.InNamespace(cephFilesystemSubVolumeGroup.Namespace))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list the ceph filesystem subvolume groups")
+	}
+	for i := range groups.Items {
+		group := &groups.Items[i]
+		if group.Name == cephFilesystemSubVolumeGroup.Name || group.Spec.FilesystemName != cephFilesystemSubVolumeGroup.Spec.FilesystemName {
+			continue
 		}
+		name := adoptedSubVolumeGroupName(group)
+		if name == "" {
+			name = group.GetSubVolumeGroupName()
+		}
+		if name == cephFilesystemSubVolumeGroup.GetSubVolumeGroupName() {
+			return group.Name, nil
+		}
+	}
+	return "", nil
+}
 
-		return errors.Wrapf(err, "failed to delete ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.Name)
+// deleteSubVolumes deletes the subvolumes of the subvolume group and their snapshots
+func (r *ReconcileCephFilesystemSubVolumeGroup) deleteSubVolumes(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) error {
+	fsName := cephFilesystemSubVolumeGroup.Spec.FilesystemName
+	groupName := cephFilesystemSubVolumeGroup.GetSubVolumeGroupName()
+	subvolumes, err := cephclient.ListCephFSSubvolumes(r.context, r.clusterInfo, fsName, groupName)
+	if err != nil {
+		// The subvolume group was deleted already
+		if code, ok := exec.ExitStatus(errors.Cause(err)); ok && code == int(syscall.ENOENT) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to list the subvolumes of ceph filesystem subvolume group %q", groupName)
 	}
 
-	logger.Infof("deleted ceph filesystem subvolume group %q", cephFilesystemSubVolumeGroup.Name)
+	for _, subvolume := range subvolumes {
+		snapshots, err := cephclient.ListCephFSSubvolumeSnapshots(r.context, r.clusterInfo, fsName, groupName, subvolume)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the snapshots of subvolume %q", subvolume)
+		}
+		for _, snapshot := range snapshots {
+			if err := cephclient.DeleteCephFSSubvolumeSnapshot(r.context, r.clusterInfo, fsName, groupName, subvolume, snapshot); err != nil {
+				return err
+			}
+		}
+		if err := cephclient.DeleteCephFSSubvolume(r.context, r.clusterInfo, fsName, groupName, subvolume); err != nil {
+			return err
+		}
+	}
+	logger.Infof("deleted the %d subvolumes of ceph filesystem subvolume group %q", len(subvolumes), groupName)
 	return nil
 }
 
+// adoptedSubVolumeGroupName returns the name of the subvolume group created or adopted by the CR, empty
+// until the subvolume group is ready
+func adoptedSubVolumeGroupName(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) string {
+	if cephFilesystemSubVolumeGroup.Status == nil {
+		return ""
+	}
+	return cephFilesystemSubVolumeGroup.Status.Name
+}
+
+// isPermissionDenied returns whether a ceph command failed because the user is missing the caps to run it
+func isPermissionDenied(err error) bool {
+	code, ok := exec.ExitStatus(errors.Cause(err))
+	return ok && (code == int(syscall.EACCES) || code == int(syscall.EPERM))
+}
+
 // updateStatus updates an object with a given status
 func (r *ReconcileCephFilesystemSubVolumeGroup) updateStatus(client client.Client, name types.NamespacedName, status cephv1.ConditionType) {
 	cephFilesystemSubVolumeGroup := &cephv1.CephFilesystemSubVolumeGroup{}
@@ -316,19 +666,62 @@ func (r *ReconcileCephFilesystemSubVolumeGroup) updateStatus(client client.Clien
 		logger.Warningf("failed to retrieve ceph filesystem subvolume group %q to update status to %q. %v", name, status, err)
 		return
 	}
-	if cephFilesystemSubVolumeGroup.Status == nil {
-		cephFilesystemSubVolumeGroup.Status = &cephv1.CephFilesystemSubVolumeGroupStatus{}
+	// The usage is retrieved once, the ceph commands are not run again when the patch is retried
+	info := map[string]string{"clusterID": buildClusterID(cephFilesystemSubVolumeGroup)}
+	if status == cephv1.ConditionReady || status == cephv1.ConditionConnected {
+		r.addUsage(cephFilesystemSubVolumeGroup, info)
 	}
-
-	cephFilesystemSubVolumeGroup.Status.Phase = status
-	cephFilesystemSubVolumeGroup.Status.Info = map[string]string{"clusterID": buildClusterID(cephFilesystemSubVolumeGroup)}
-	if err := reporting.UpdateStatus(client, cephFilesystemSubVolumeGroup); err != nil {
+	err := reporting.PatchStatus(client, cephFilesystemSubVolumeGroup, func() bool {
+		if cephFilesystemSubVolumeGroup.Status == nil {
+			cephFilesystemSubVolumeGroup.Status = &cephv1.CephFilesystemSubVolumeGroupStatus{}
+		}
+		cephFilesystemSubVolumeGroup.Status.Phase = status
+		cephFilesystemSubVolumeGroup.Status.Info = info
+		if status == cephv1.ConditionReady || status == cephv1.ConditionConnected {
+			cephFilesystemSubVolumeGroup.Status.Name = cephFilesystemSubVolumeGroup.GetSubVolumeGroupName()
+		}
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set ceph filesystem subvolume group %q status to %q. %v", name, status, err)
 		return
 	}
 	logger.Debugf("ceph ceph filesystem subvolume group %q status updated to %q", name, status)
 }
 
+// addUsage adds the usage of the subvolume group to the info of its status: the bytes used, the
+// quota, the number of subvolumes and the fsid of the cluster. The usage that cannot be retrieved is
+// not added, it does not fail the update of the status.
+func (r *ReconcileCephFilesystemSubVolumeGroup) addUsage(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup, info map[string]string) {
+	if r.clusterInfo == nil {
+		return
+	}
+	if r.clusterInfo.FSID != "" {
+		info["fsid"] = r.clusterInfo.FSID
+	}
+
+	fsName := cephFilesystemSubVolumeGroup.Spec.FilesystemName
+	groupName := cephFilesystemSubVolumeGroup.GetSubVolumeGroupName()
+	groupInfo, err := cephclient.GetCephFSSubVolumeGroupInfo(r.context, r.clusterInfo, fsName, groupName)
+	if err != nil {
+		logger.Debugf("failed to get the usage of ceph filesystem subvolume group %q. %v", groupName, err)
+	} else {
+		info["bytesUsed"] = strconv.FormatUint(groupInfo.BytesUsed, 10)
+		if quota, ok := groupInfo.Quota(); ok {
+			info["bytesQuota"] = strconv.FormatUint(quota, 10)
+		} else {
+			info["bytesQuota"] = "infinite"
+		}
+	}
+
+	subvolumes, err := cephclient.ListCephFSSubvolumes(r.context, r.clusterInfo, fsName, groupName)
+	if err != nil {
+		logger.Debugf("failed to list the subvolumes of ceph filesystem subvolume group %q. %v", groupName, err)
+	} else {
+		info["subvolumeCount"] = strconv.Itoa(len(subvolumes))
+	}
+}
+
 func buildClusterID(cephFilesystemSubVolumeGroup *cephv1.CephFilesystemSubVolumeGroup) string {
 	clusterID := fmt.Sprintf("%s-%s-file-%s", cephFilesystemSubVolumeGroup.Namespace, cephFilesystemSubVolumeGroup.Spec.FilesystemName, cephFilesystemSubVolumeGroup.Name)
 	return k8sutil.Hash(clusterID)
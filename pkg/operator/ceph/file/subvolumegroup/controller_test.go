@@ -19,7 +19,9 @@ package subvolumegroup
 import (
 	"context"
 	"os"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -28,15 +30,18 @@ import (
 	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -96,6 +101,7 @@ func TestCephClientController(t *testing.T) {
 
 	// Create a ReconcileCephFilesystemSubVolumeGroup object with the scheme and fake client.
 	r := &ReconcileCephFilesystemSubVolumeGroup{
+		recorder:         &record.FakeRecorder{},
 		client:           cl,
 		scheme:           s,
 		context:          c,
@@ -149,7 +155,7 @@ func TestCephClientController(t *testing.T) {
 		// Create a fake client to mock API calls.
 		cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 		// Create a ReconcileCephFilesystem object with the scheme and fake client.
-		r = &ReconcileCephFilesystemSubVolumeGroup{client: cl, scheme: s, context: c, opManagerContext: context.TODO()}
+		r = &ReconcileCephFilesystemSubVolumeGroup{client: cl, scheme: s, context: c, opManagerContext: context.TODO(), recorder: &record.FakeRecorder{}}
 		res, err := r.Reconcile(ctx, req)
 		assert.NoError(t, err)
 		assert.True(t, res.Requeue)
@@ -196,6 +202,13 @@ func TestCephClientController(t *testing.T) {
 				if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "create" {
 					return "", nil
 				}
+				if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "info" {
+					return `{"bytes_quota": "infinite", "bytes_used": 4096}`, nil
+				}
+				if args[0] == "fs" && args[1] == "subvolume" && args[2] == "ls" {
+					assert.Equal(t, []string{"--group_name", "group-a"}, args[4:6])
+					return `[{"name": "csi-vol-1"}, {"name": "csi-vol-2"}]`, nil
+				}
 
 				return "", errors.Errorf("unknown command. %v", args)
 			},
@@ -205,6 +218,7 @@ func TestCephClientController(t *testing.T) {
 		s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPoolList{})
 		// Create a ReconcileCephFilesystemSubVolumeGroup object with the scheme and fake client.
 		r = &ReconcileCephFilesystemSubVolumeGroup{
+			recorder:         &record.FakeRecorder{},
 			client:           cl,
 			scheme:           s,
 			context:          c,
@@ -228,6 +242,11 @@ func TestCephClientController(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, cephv1.ConditionReady, cephFilesystemSubVolumeGroup.Status.Phase)
 		assert.NotEmpty(t, cephFilesystemSubVolumeGroup.Status.Info["clusterID"])
+		assert.Equal(t, "4096", cephFilesystemSubVolumeGroup.Status.Info["bytesUsed"])
+		assert.Equal(t, "infinite", cephFilesystemSubVolumeGroup.Status.Info["bytesQuota"])
+		assert.Equal(t, "2", cephFilesystemSubVolumeGroup.Status.Info["subvolumeCount"])
+		assert.Equal(t, name, cephFilesystemSubVolumeGroup.Status.Info["fsid"])
+		assert.Equal(t, "group-a", cephFilesystemSubVolumeGroup.Status.Name)
 
 		// test that csi configmap is created
 		cm, err := c.Clientset.CoreV1().ConfigMaps(namespace).Get(ctx, csi.ConfigName, metav1.GetOptions{})
@@ -239,6 +258,29 @@ func TestCephClientController(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("failure - the name of the subvolume group is changed", func(t *testing.T) {
+		created := []string{}
+		c.Executor = &exectest.MockExecutor{
+			MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+				if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "create" {
+					created = append(created, args[4])
+				}
+				return "", nil
+			},
+		}
+		group := &cephv1.CephFilesystemSubVolumeGroup{}
+		assert.NoError(t, r.client.Get(ctx, req.NamespacedName, group))
+		group.Spec.Name = "group-b"
+		assert.NoError(t, r.client.Update(ctx, group))
+
+		_, err := r.Reconcile(ctx, req)
+		assert.Error(t, err)
+		assert.Empty(t, created)
+		assert.NoError(t, r.client.Get(ctx, req.NamespacedName, group))
+		assert.Equal(t, cephv1.ConditionFailure, group.Status.Phase)
+		assert.Equal(t, "group-a", group.Status.Name)
+	})
+
 	t.Run("success - external mode csi config is updated", func(t *testing.T) {
 		cephCluster.Spec.External.Enable = true
 		objects := []runtime.Object{
@@ -252,6 +294,7 @@ func TestCephClientController(t *testing.T) {
 		s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPoolList{})
 		// Create a ReconcileCephFilesystemSubVolumeGroup object with the scheme and fake client.
 		r = &ReconcileCephFilesystemSubVolumeGroup{
+			recorder:         &record.FakeRecorder{},
 			client:           cl,
 			scheme:           s,
 			context:          c,
@@ -283,6 +326,50 @@ func TestCephClientController(t *testing.T) {
 		assert.Contains(t, cm.Data[csi.ConfigKey], "clusterID")
 		assert.Contains(t, cm.Data[csi.ConfigKey], "group-a")
 	})
+
+	t.Run("success - external mode user not allowed to create the subvolumegroup", func(t *testing.T) {
+		c.Executor = &exectest.MockExecutor{
+			MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+				if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "create" {
+					return "", exectest.MockExecCommandReturns(t, "", "", int(syscall.EACCES))
+				}
+
+				return "", errors.Errorf("unknown command. %v", args)
+			},
+		}
+
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.False(t, res.Requeue)
+
+		err = r.client.Get(ctx, req.NamespacedName, cephFilesystemSubVolumeGroup)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionConnected, cephFilesystemSubVolumeGroup.Status.Phase)
+	})
+
+	t.Run("failure - external mode subvolumegroup creation fails", func(t *testing.T) {
+		c.Executor = &exectest.MockExecutor{
+			MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+				if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "create" {
+					return "", exectest.MockExecCommandReturns(t, "", "", int(syscall.ENOENT))
+				}
+
+				return "", errors.Errorf("unknown command. %v", args)
+			},
+		}
+
+		_, err := r.Reconcile(ctx, req)
+		assert.Error(t, err)
+
+		err = r.client.Get(ctx, req.NamespacedName, cephFilesystemSubVolumeGroup)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionFailure, cephFilesystemSubVolumeGroup.Status.Phase)
+	})
+}
+
+// import TestMockExecHelperProcess
+func TestMockExecHelperProcess(t *testing.T) {
+	exectest.TestMockExecHelperProcess(t)
 }
 
 func Test_buildClusterID(t *testing.T) {
@@ -291,3 +378,357 @@ func Test_buildClusterID(t *testing.T) {
 	clusterID := buildClusterID(cephFilesystemSubVolumeGroup)
 	assert.Equal(t, "29e92135b7e8c014079b9f9f3566777d", clusterID)
 }
+
+func TestUpdateQuota(t *testing.T) {
+	quota := `"infinite"`
+	resized := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "info" {
+				return `{"bytes_quota": ` + quota + `, "bytes_used": 0}`, nil
+			}
+			if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "resize" {
+				resized = append(resized, args[5])
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	r := &ReconcileCephFilesystemSubVolumeGroup{
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminTestClusterInfo("rook-ceph"),
+	}
+	group := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "group-a"}, Spec: cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs"}}
+
+	// no quota
+	assert.NoError(t, r.updateQuota(group, quotaBytes(group)))
+	assert.Empty(t, resized)
+
+	// the quota is set
+	size := resource.MustParse("10Gi")
+	group.Spec.Quota = &size
+	assert.NoError(t, r.updateQuota(group, quotaBytes(group)))
+	assert.Equal(t, []string{"10737418240"}, resized)
+
+	// the quota is up to date
+	quota = `10737418240`
+	assert.NoError(t, r.updateQuota(group, quotaBytes(group)))
+	assert.Equal(t, []string{"10737418240"}, resized)
+
+	// the quota is removed
+	group.Spec.Quota = nil
+	assert.NoError(t, r.updateQuota(group, quotaBytes(group)))
+	assert.Equal(t, []string{"10737418240", "inf"}, resized)
+}
+
+func TestPinSubVolumeGroup(t *testing.T) {
+	ctx := context.TODO()
+	pinned := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "pin" {
+				assert.Equal(t, []string{"myfs", "group-a"}, args[3:5])
+				pinned = append(pinned, args[5]+"="+args[6])
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	group := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "group-a"}, Spec: cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs"}}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystemSubVolumeGroup{}, &cephv1.CephFilesystemSubVolumeGroupList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(group).Build()
+	r := &ReconcileCephFilesystemSubVolumeGroup{
+		client:      cl,
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminTestClusterInfo("rook-ceph"),
+	}
+	pin := func(pinning *cephv1.CephFilesystemSubVolumeGroupPinning) {
+		assert.NoError(t, cl.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: "group-a"}, group))
+		group.Spec.Pinning = pinning
+		pinned = []string{}
+		assert.NoError(t, r.pinSubVolumeGroup(group))
+		assert.NoError(t, cl.Get(ctx, types.NamespacedName{Namespace: "rook-ceph", Name: "group-a"}, group))
+		if pinning == nil {
+			assert.True(t, group.Status == nil || group.Status.Pinning == nil)
+		} else {
+			assert.Equal(t, pinning, group.Status.Pinning)
+		}
+	}
+
+	// no pinning
+	pin(nil)
+	assert.Empty(t, pinned)
+
+	distributed := 1
+	pin(&cephv1.CephFilesystemSubVolumeGroupPinning{Distributed: &distributed})
+	assert.Equal(t, []string{"distributed=1"}, pinned)
+
+	// the distributed pin is removed when changing the policy
+	export := 2
+	pin(&cephv1.CephFilesystemSubVolumeGroupPinning{Export: &export})
+	assert.Equal(t, []string{"distributed=0", "export=2"}, pinned)
+
+	// the pin is applied again
+	pin(&cephv1.CephFilesystemSubVolumeGroupPinning{Export: &export})
+	assert.Equal(t, []string{"export=2"}, pinned)
+
+	random := 0.25
+	pin(&cephv1.CephFilesystemSubVolumeGroupPinning{Random: &random})
+	assert.Equal(t, []string{"export=-1", "random=0.25"}, pinned)
+
+	// the pin is removed with the pinning
+	pin(nil)
+	assert.Equal(t, []string{"random=0"}, pinned)
+
+	// nothing is left to remove
+	pin(nil)
+	assert.Empty(t, pinned)
+}
+
+func TestCreateOrUpdateSubVolumeGroup(t *testing.T) {
+	groups := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "create" {
+				groups = append(groups, args[4])
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	r := &ReconcileCephFilesystemSubVolumeGroup{
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminTestClusterInfo("rook-ceph"),
+	}
+	group := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "group-a"}, Spec: cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs"}}
+
+	// the name of the CR by default
+	assert.NoError(t, r.createOrUpdateSubVolumeGroup(group))
+
+	// the name of the spec
+	group.Spec.Name = "csi"
+	assert.NoError(t, r.createOrUpdateSubVolumeGroup(group))
+	assert.Equal(t, []string{"group-a", "csi"}, groups)
+}
+
+func TestDeleteSubVolumeGroup(t *testing.T) {
+	deleted := []string{}
+	notEmpty := true
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "ls" {
+				return `[{"name": "csi-vol-1"}, {"name": "csi-vol-2"}]`, nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "snapshot" && args[3] == "ls" {
+				if args[5] == "csi-vol-1" {
+					return `[{"name": "snap-1"}]`, nil
+				}
+				return `[]`, nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "snapshot" && args[3] == "rm" {
+				deleted = append(deleted, args[5]+"@"+args[6])
+				return "", nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "rm" {
+				deleted = append(deleted, args[4])
+				notEmpty = false
+				return "", nil
+			}
+			if args[0] == "fs" && args[1] == "subvolumegroup" && args[2] == "rm" {
+				if notEmpty {
+					return "", exectest.MockExecCommandReturns(t, "", "Error ENOTEMPTY: error in rmdir /volumes/group-a", int(syscall.ENOTEMPTY))
+				}
+				deleted = append(deleted, args[4])
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	group := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "group-a"}, Spec: cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs"}}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystemSubVolumeGroup{}, &cephv1.CephFilesystemSubVolumeGroupList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(group).Build()
+	r := &ReconcileCephFilesystemSubVolumeGroup{
+		client:           cl,
+		context:          &clusterd.Context{Executor: executor},
+		clusterInfo:      cephclient.AdminTestClusterInfo("rook-ceph"),
+		opManagerContext: context.TODO(),
+	}
+
+	t.Run("the subvolume group is kept while another CR manages it", func(t *testing.T) {
+		other := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "group-b"}, Spec: cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs", Name: "group-a"}}
+		assert.NoError(t, cl.Create(context.TODO(), other))
+		g := group.DeepCopy()
+		g.Spec.DeletionPolicy = cephv1.SubVolumeGroupDeletionPolicyForce
+		assert.NoError(t, r.deleteSubVolumeGroup(g))
+		assert.Empty(t, deleted)
+
+		// the subvolume groups of the other filesystems are not shared
+		other.Spec.FilesystemName = "otherfs"
+		assert.NoError(t, cl.Update(context.TODO(), other))
+		name, err := r.otherSubVolumeGroupCR(g)
+		assert.NoError(t, err)
+		assert.Equal(t, "", name)
+		assert.NoError(t, cl.Delete(context.TODO(), other))
+	})
+
+	t.Run("the subvolumes of the subvolume group of the CSI driver are not deleted", func(t *testing.T) {
+		g := group.DeepCopy()
+		g.Spec.Name = cephv1.CSISubVolumeGroupName
+		g.Spec.DeletionPolicy = cephv1.SubVolumeGroupDeletionPolicyForce
+		err := r.deleteSubVolumeGroup(g)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CSI driver")
+		assert.Empty(t, deleted)
+	})
+
+	t.Run("the deletion fails while the subvolume group has subvolumes", func(t *testing.T) {
+		err := r.deleteSubVolumeGroup(group)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Force")
+		assert.Empty(t, deleted)
+	})
+
+	t.Run("the subvolumes and their snapshots are deleted with the force policy", func(t *testing.T) {
+		group.Spec.DeletionPolicy = cephv1.SubVolumeGroupDeletionPolicyForce
+		assert.NoError(t, r.deleteSubVolumeGroup(group))
+		assert.Equal(t, []string{"csi-vol-1@snap-1", "csi-vol-1", "csi-vol-2", "group-a"}, deleted)
+	})
+}
+
+func TestDataPoolName(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "ls" {
+				return `[{"name": "myfs", "metadata_pool": "myfs-metadata", "data_pools": ["myfs-replicated", "myfs-ec"]}]`, nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	r := &ReconcileCephFilesystemSubVolumeGroup{
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminTestClusterInfo("rook-ceph"),
+	}
+	group := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "group-a"}, Spec: cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs"}}
+
+	// the default data pool
+	pool, err := r.dataPoolName(group)
+	assert.NoError(t, err)
+	assert.Equal(t, "", pool)
+
+	// the name of the pool in ceph
+	group.Spec.DataPoolName = "myfs-ec"
+	pool, err = r.dataPoolName(group)
+	assert.NoError(t, err)
+	assert.Equal(t, "myfs-ec", pool)
+
+	// the name of the pool in the CephFilesystem
+	group.Spec.DataPoolName = "ec"
+	pool, err = r.dataPoolName(group)
+	assert.NoError(t, err)
+	assert.Equal(t, "myfs-ec", pool)
+
+	group.Spec.DataPoolName = "other"
+	_, err = r.dataPoolName(group)
+	assert.Error(t, err)
+
+	group.Spec.FilesystemName = "otherfs"
+	group.Spec.DataPoolName = "ec"
+	_, err = r.dataPoolName(group)
+	assert.Error(t, err)
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	now := time.Now().UTC()
+	// the age of the snapshots of the subvolumes, snap-1 is the newest
+	ages := map[string]time.Duration{"snap-1": time.Hour, "snap-2": 2 * 24 * time.Hour, "snap-3": 10 * 24 * time.Hour}
+	pendingClones := map[string]bool{}
+	var pruned []string
+	var removeErr error
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "ls" {
+				return `[{"name": "csi-vol-1"}]`, nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "snapshot" && args[3] == "ls" {
+				return `[{"name": "snap-3"}, {"name": "snap-1"}, {"name": "snap-2"}]`, nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "snapshot" && args[3] == "info" {
+				clones := "no"
+				if pendingClones[args[6]] {
+					clones = "yes"
+				}
+				created := now.Add(-ages[args[6]]).Format("2006-01-02 15:04:05.000000")
+				return `{"created_at": "` + created + `", "has_pending_clones": "` + clones + `"}`, nil
+			}
+			if args[0] == "fs" && args[1] == "subvolume" && args[2] == "snapshot" && args[3] == "rm" {
+				if removeErr != nil {
+					return "", removeErr
+				}
+				pruned = append(pruned, args[6])
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	recorder := record.NewFakeRecorder(5)
+	r := &ReconcileCephFilesystemSubVolumeGroup{
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminTestClusterInfo("rook-ceph"),
+		recorder:    recorder,
+	}
+	group := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "group-a"}, Spec: cephv1.CephFilesystemSubVolumeGroupSpec{FilesystemName: "myfs"}}
+	cephCluster := &cephv1.CephCluster{}
+
+	t.Run("no retention", func(t *testing.T) {
+		pruned = nil
+		assert.NoError(t, r.pruneSnapshots(group))
+		assert.Empty(t, pruned)
+	})
+
+	t.Run("the newest snapshots are kept", func(t *testing.T) {
+		pruned = nil
+		count := 1
+		group.Spec.SnapshotRetention = &cephv1.CephFilesystemSubVolumeGroupSnapshotRetention{Count: &count}
+		assert.NoError(t, r.pruneSnapshots(group))
+		assert.Equal(t, []string{"snap-2", "snap-3"}, pruned)
+	})
+
+	t.Run("the snapshots older than the duration are pruned", func(t *testing.T) {
+		pruned = nil
+		group.Spec.SnapshotRetention = &cephv1.CephFilesystemSubVolumeGroupSnapshotRetention{Duration: &metav1.Duration{Duration: 7 * 24 * time.Hour}}
+		assert.NoError(t, r.pruneSnapshots(group))
+		assert.Equal(t, []string{"snap-3"}, pruned)
+	})
+
+	t.Run("the snapshots with pending clones are kept", func(t *testing.T) {
+		pruned = nil
+		count := 1
+		pendingClones["snap-3"] = true
+		group.Spec.SnapshotRetention.Count = &count
+		assert.NoError(t, r.pruneSnapshots(group))
+		assert.Equal(t, []string{"snap-2"}, pruned)
+		pendingClones = map[string]bool{}
+	})
+
+	t.Run("external cluster not pruned unless enabled", func(t *testing.T) {
+		pruned = nil
+		cephCluster.Spec.External.Enable = true
+		r.reconcileSnapshotRetention(cephCluster, group)
+		assert.Empty(t, pruned)
+
+		group.Spec.SnapshotRetention.PruneOnExternalCluster = true
+		r.reconcileSnapshotRetention(cephCluster, group)
+		assert.Equal(t, []string{"snap-2", "snap-3"}, pruned)
+		cephCluster.Spec.External.Enable = false
+	})
+
+	t.Run("pruning failure reported with an event", func(t *testing.T) {
+		removeErr = errors.New("failed to remove")
+		r.reconcileSnapshotRetention(cephCluster, group)
+		assert.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, snapshotPruningFailedReason)
+		removeErr = nil
+	})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const taskControllerName = "ceph-file-task-controller"
+
+// scrubScript starts the scrub of a path of the filesystem and waits for the scrub to complete, the
+// scrub runs in the mds and is not stopped when the job is
+const scrubScript = `set -e
+ceph tell mds.%[1]s:0 scrub start '%[2]s' %[3]s
+while ! ceph tell mds.%[1]s:0 scrub status | grep -q "no active scrubs running"; do
+  echo "waiting for the scrub of %[2]s to complete"
+  sleep 10
+done
+echo "scrub of %[2]s completed"
+`
+
+// addTaskController adds the controller running the tasks of the filesystems
+func addTaskController(mgr manager.Manager) error {
+	c := mgr.GetClient()
+	return opcontroller.AddTaskController(mgr, taskControllerName, opcontroller.TaskKind{
+		Kind:   "fs",
+		Object: &cephv1.CephFilesystem{TypeMeta: controllerTypeMeta},
+		Tasks: func(object client.Object) ([]cephv1.TaskSpec, []cephv1.TaskStatus) {
+			cephFilesystem := object.(*cephv1.CephFilesystem)
+			if cephFilesystem.Status == nil {
+				return cephFilesystem.Spec.Tasks, nil
+			}
+			return cephFilesystem.Spec.Tasks, cephFilesystem.Status.Tasks
+		},
+		SetTaskStatuses: func(object client.Object, statuses []cephv1.TaskStatus) {
+			cephFilesystem := object.(*cephv1.CephFilesystem)
+			if cephFilesystem.Status == nil {
+				cephFilesystem.Status = &cephv1.CephFilesystemStatus{}
+			}
+			cephFilesystem.Status.Tasks = statuses
+		},
+		JobBuilder: func(ctx context.Context, object client.Object) (opcontroller.TaskJobBuilder, error) {
+			cephCluster, isReadyToReconcile, _, _ := opcontroller.IsReadyToReconcile(ctx, c, client.ObjectKeyFromObject(object), taskControllerName)
+			if !isReadyToReconcile {
+				return nil, errors.Errorf("the CephCluster in namespace %q is not ready", object.GetNamespace())
+			}
+			image := cephCluster.Spec.CephVersion.Image
+			return func(task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+				return scrubTaskPodTemplate(image, object.GetName(), task)
+			}, nil
+		},
+	})
+}
+
+// scrubTaskPodTemplate returns the pod template of a scrub task. The args of the task are the path
+// to scrub, "/" by default, and whether to repair the damaged metadata.
+func scrubTaskPodTemplate(image, fsName string, task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+	if task.Type != cephv1.TaskTypeScrub {
+		return nil, errors.Errorf("task type %q is not supported by a filesystem", task.Type)
+	}
+
+	path := "/"
+	if p, ok := task.Args["path"]; ok {
+		path = p
+	}
+	if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, "'\n") {
+		return nil, errors.Errorf("invalid scrub path %q, the path must be absolute", path)
+	}
+
+	options := "recursive"
+	if repair, ok := task.Args["repair"]; ok {
+		r, err := strconv.ParseBool(repair)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid scrub repair %q", repair)
+		}
+		if r {
+			options += ",repair"
+		}
+	}
+
+	return opcontroller.CephTaskPodTemplate(image, fmt.Sprintf(scrubScript, fsName, path, options)), nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrubTaskPodTemplate(t *testing.T) {
+	script := func(task cephv1.TaskSpec) string {
+		template, err := scrubTaskPodTemplate("quay.io/ceph/ceph:v17", "myfs", task)
+		assert.NoError(t, err)
+		return template.Spec.Containers[0].Command[2]
+	}
+
+	s := script(cephv1.TaskSpec{Name: "scrub", Type: cephv1.TaskTypeScrub})
+	assert.Contains(t, s, "ceph tell mds.myfs:0 scrub start '/' recursive\n")
+	assert.Contains(t, s, "ceph tell mds.myfs:0 scrub status")
+
+	s = script(cephv1.TaskSpec{Name: "scrub", Type: cephv1.TaskTypeScrub, Args: map[string]string{"path": "/volumes/csi", "repair": "true"}})
+	assert.Contains(t, s, "scrub start '/volumes/csi' recursive,repair\n")
+
+	for _, args := range []map[string]string{{"path": "volumes"}, {"path": "/a'; rm -rf /'"}, {"repair": "yes please"}} {
+		_, err := scrubTaskPodTemplate("quay.io/ceph/ceph:v17", "myfs", cephv1.TaskSpec{Name: "scrub", Type: cephv1.TaskTypeScrub, Args: args})
+		assert.Error(t, err, args)
+	}
+
+	_, err := scrubTaskPodTemplate("quay.io/ceph/ceph:v17", "myfs", cephv1.TaskSpec{Name: "reshard", Type: cephv1.TaskTypeReshard})
+	assert.Error(t, err)
+}
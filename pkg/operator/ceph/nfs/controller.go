@@ -33,12 +33,14 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -82,6 +84,7 @@ type ReconcileCephNFS struct {
 	clusterInfo      *cephclient.ClusterInfo
 	opManagerContext context.Context
 	opConfig         opcontroller.OperatorConfig
+	recorder         record.EventRecorder
 }
 
 // Add creates a new cephNFS Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -98,12 +101,14 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 		context:          context,
 		opManagerContext: opManagerContext,
 		opConfig:         opConfig,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephNFS{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephNFS{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -135,31 +140,29 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephNFS) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
-	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
-	}
+	reconcileResponse, cephNFS, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephNFS, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephNFS, error) {
 	// Fetch the cephNFS instance
 	cephNFS := &cephv1.CephNFS{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephNFS)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("cephNFS resource not found. Ignoring since object must be deleted.")
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephNFS, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get cephNFS")
+		return reconcile.Result{}, cephNFS, errors.Wrap(err, "failed to get cephNFS")
 	}
 
 	// Set a finalizer so we can do cleanup before the object goes away
 	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephNFS)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+		return reconcile.Result{}, cephNFS, errors.Wrap(err, "failed to add finalizer")
 	}
 
 	// The CR was just created, initializing status fields
@@ -180,21 +183,21 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 			// Remove finalizer
 			err := opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephNFS)
 			if err != nil {
-				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+				return reconcile.Result{}, cephNFS, errors.Wrap(err, "failed to remove finalizer")
 			}
 
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephNFS, nil
 		}
-		return reconcileResponse, nil
+		return reconcileResponse, cephNFS, nil
 	}
 	r.cephClusterSpec = &cephCluster.Spec
 
 	// Populate clusterInfo
 	// Always populate it during each reconcile
-	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephNFS", request.Namespace, request.Name), request.NamespacedName.Namespace)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+		return reconcile.Result{}, cephNFS, errors.Wrap(err, "failed to populate cluster info")
 	}
 
 	// DELETE: the CR was deleted
@@ -204,23 +207,23 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 		// Detect running Ceph version
 		runningCephVersion, err := cephclient.LeastUptodateDaemonVersion(r.context, r.clusterInfo, config.MonType)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "failed to retrieve current ceph %q version", config.MonType)
+			return reconcile.Result{}, cephNFS, errors.Wrapf(err, "failed to retrieve current ceph %q version", config.MonType)
 		}
 		r.clusterInfo.CephVersion = runningCephVersion
 
 		err = r.removeServersFromDatabase(cephNFS, 0)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "failed to delete filesystem %q. ", cephNFS.Name)
+			return reconcile.Result{}, cephNFS, errors.Wrapf(err, "failed to delete filesystem %q. ", cephNFS.Name)
 		}
 
 		// Remove finalizer
 		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephNFS)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			return reconcile.Result{}, cephNFS, errors.Wrap(err, "failed to remove finalizer")
 		}
 
 		// Return and do not requeue. Successful deletion.
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, cephNFS, nil
 	}
 
 	// Detect desired CephCluster version
@@ -237,9 +240,9 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 	if err != nil {
 		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
 			logger.Info(opcontroller.OperatorNotInitializedMessage)
-			return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephNFS, nil
 		}
-		return reconcile.Result{}, errors.Wrap(err, "failed to detect running and desired ceph version")
+		return reconcile.Result{}, cephNFS, errors.Wrap(err, "failed to detect running and desired ceph version")
 	}
 
 	// If the version of the Ceph monitor differs from the CephCluster CR image version we assume
@@ -248,7 +251,7 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 	if !reflect.DeepEqual(*runningCephVersion, *desiredCephVersion) {
 		// Upgrade is in progress, let's wait for the mons to be done
 		return opcontroller.WaitForRequeueIfCephClusterIsUpgrading,
-			opcontroller.ErrorCephUpgradingRequeue(desiredCephVersion, runningCephVersion)
+			cephNFS, opcontroller.ErrorCephUpgradingRequeue(desiredCephVersion, runningCephVersion)
 	}
 	r.clusterInfo.CephVersion = *runningCephVersion
 
@@ -276,13 +279,13 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 
 	// validate the store settings
 	if err := validateGanesha(r.context, r.clusterInfo, cephNFS); err != nil {
-		return reconcile.Result{}, errors.Wrapf(err, "invalid ceph nfs %q arguments", cephNFS.Name)
+		return reconcile.Result{}, cephNFS, errors.Wrapf(err, "invalid ceph nfs %q arguments", cephNFS.Name)
 	}
 
 	// Check for the existence of the .nfs pool
 	err = r.configureNFSPool(cephNFS)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrapf(err, "failed to configure nfs pool %q", cephNFS.Spec.RADOS.Pool)
+		return reconcile.Result{}, cephNFS, errors.Wrapf(err, "failed to configure nfs pool %q", cephNFS.Spec.RADOS.Pool)
 	}
 
 	// CREATE/UPDATE
@@ -290,7 +293,7 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 	_, err = r.reconcileCreateCephNFS(cephNFS)
 	if err != nil {
 		updateStatus(r.client, request.NamespacedName, k8sutil.FailedStatus)
-		return reconcile.Result{}, errors.Wrap(err, "failed to create ceph nfs deployments")
+		return reconcile.Result{}, cephNFS, errors.Wrap(err, "failed to create ceph nfs deployments")
 	}
 
 	// Set Ready status, we are done reconciling
@@ -298,7 +301,7 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 
 	// Return and do not requeue
 	logger.Debug("done reconciling ceph nfs")
-	return reconcile.Result{}, nil
+	return reconcile.Result{}, cephNFS, nil
 
 }
 
@@ -356,12 +359,23 @@ func updateStatus(client client.Client, name types.NamespacedName, status string
 		logger.Warningf("failed to retrieve nfs %q to update status to %q. %v", name, status, err)
 		return
 	}
-	if nfs.Status == nil {
-		nfs.Status = &cephv1.Status{}
+	replicas, selector, scaleErr := opcontroller.ScaleStatus(context.TODO(), client, nfs.Namespace, scaleSelectorLabels(nfs))
+	if scaleErr != nil {
+		// the replicas are updated again by the next reconcile
+		logger.Warningf("failed to get the replicas of nfs %q. %v", name, scaleErr)
 	}
-
-	nfs.Status.Phase = status
-	if err := reporting.UpdateStatus(client, nfs); err != nil {
+	err = reporting.PatchStatus(client, nfs, func() bool {
+		if nfs.Status == nil {
+			nfs.Status = &cephv1.NFSStatus{}
+		}
+		nfs.Status.Phase = status
+		if scaleErr == nil {
+			nfs.Status.Replicas = replicas
+		}
+		nfs.Status.Selector = selector
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set nfs %q status to %q. %v", nfs.Name, status, err)
 	}
 	logger.Debugf("nfs %q status updated to %q", name, status)
@@ -38,6 +38,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -105,7 +106,7 @@ func TestCephNFSController(t *testing.T) {
 	// Create a fake client to mock API calls.
 	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 	// Create a ReconcileCephNFS object with the scheme and fake client.
-	r := &ReconcileCephNFS{client: cl, scheme: s, context: c, opManagerContext: ctx}
+	r := &ReconcileCephNFS{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
 
 	// Mock request to simulate Reconcile() being called on an event for a
 	// watched resource .
@@ -144,7 +145,7 @@ func TestCephNFSController(t *testing.T) {
 		// Create a fake client to mock API calls.
 		cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 		// Create a ReconcileCephNFS object with the scheme and fake client.
-		r = &ReconcileCephNFS{client: cl, scheme: s, context: c, opManagerContext: ctx}
+		r = &ReconcileCephNFS{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
 		res, err := r.Reconcile(ctx, req)
 		assert.NoError(t, err)
 		assert.True(t, res.Requeue)
@@ -216,7 +217,7 @@ func TestCephNFSController(t *testing.T) {
 		c.Executor = executor
 
 		// Create a ReconcileCephNFS object with the scheme and fake client.
-		r = &ReconcileCephNFS{client: cl, scheme: s, context: c, opManagerContext: ctx}
+		r = &ReconcileCephNFS{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
 		res, err := r.Reconcile(ctx, req)
 		assert.NoError(t, err)
 		assert.False(t, res.Requeue)
@@ -35,8 +35,9 @@ const (
 	// AppName is the name of the app
 	AppName             = "rook-ceph-nfs"
 	ganeshaConfigVolume = "ganesha-config"
-	nfsPort             = 2049
 	ganeshaPid          = "/var/run/ganesha/ganesha.pid"
+	// Port is the port the NFS servers listen on
+	Port = 2049
 )
 
 func (r *ReconcileCephNFS) generateCephNFSService(nfs *cephv1.CephNFS, cfg daemonConfig) *v1.Service {
@@ -53,8 +54,8 @@ func (r *ReconcileCephNFS) generateCephNFSService(nfs *cephv1.CephNFS, cfg daemo
 			Ports: []v1.ServicePort{
 				{
 					Name:       "nfs",
-					Port:       nfsPort,
-					TargetPort: intstr.FromInt(int(nfsPort)),
+					Port:       Port,
+					TargetPort: intstr.FromInt(int(Port)),
 					Protocol:   v1.ProtocolTCP,
 				},
 			},
@@ -86,7 +87,7 @@ func (r *ReconcileCephNFS) createCephNFSService(nfs *cephv1.CephNFS, cfg daemonC
 		return nil
 	}
 
-	logger.Infof("ceph nfs service running at %s:%d", svc.Spec.ClusterIP, nfsPort)
+	logger.Infof("ceph nfs service running at %s:%d", svc.Spec.ClusterIP, Port)
 	return nil
 }
 
@@ -254,6 +255,14 @@ func getLabels(n *cephv1.CephNFS, name string, includeNewLabels bool) map[string
 	return labels
 }
 
+// scaleSelectorLabels returns the labels selecting the pods of all the servers of a CephNFS
+func scaleSelectorLabels(n *cephv1.CephNFS) map[string]string {
+	return map[string]string{
+		k8sutil.AppAttr: AppName,
+		"ceph_nfs":      n.Name,
+	}
+}
+
 func cephConfigVolumeAndMount() (v1.Volume, v1.VolumeMount) {
 	// nfs ganesha produces its own ceph config file, so cannot use controller.DaemonVolume or
 	// controller.DaemonVolumeMounts since that will bring in global ceph config file
@@ -24,6 +24,7 @@ import (
 	"net/http/httputil"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/ceph/go-ceph/rgw/admin"
 	"github.com/coreos/pkg/capnslog"
@@ -32,8 +33,11 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/exec"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -98,9 +102,11 @@ const (
 	// RGWAdminOpsUserSecretName is the secret name of the admin ops user
 	// #nosec G101 since this is not leaking any hardcoded credentials, it's just the secret name
 	RGWAdminOpsUserSecretName = "rgw-admin-ops-user"
-	rgwAdminOpsUserAccessKey  = "accessKey"
-	rgwAdminOpsUserSecretKey  = "secretKey"
-	rgwAdminOpsUserCaps       = "buckets=*;users=*;usage=read;metadata=read;zone=read"
+	// RGWAdminOpsUserAccessKey is the key of the access key in the secret of the admin ops user
+	RGWAdminOpsUserAccessKey = "accessKey"
+	// RGWAdminOpsUserSecretKey is the key of the secret key in the secret of the admin ops user
+	RGWAdminOpsUserSecretKey = "secretKey"
+	rgwAdminOpsUserCaps      = "buckets=*;users=*;usage=read;metadata=read;zone=read"
 )
 
 var (
@@ -213,6 +219,7 @@ func extractJSON(output string) (string, error) {
 func RunAdminCommandNoMultisite(c *Context, expectJSON bool, args ...string) (string, error) {
 	var output, stderr string
 	var err error
+	start := time.Now()
 
 	// If Multus is enabled we proxy all the command to the mgr sidecar
 	if c.CephClusterSpec.Network.IsMultus() {
@@ -221,6 +228,8 @@ func RunAdminCommandNoMultisite(c *Context, expectJSON bool, args ...string) (st
 		command, args := cephclient.FinalizeCephCommandArgs("radosgw-admin", c.clusterInfo, args, c.Context.ConfigDir)
 		output, err = c.Context.Executor.ExecuteCommandWithTimeout(exec.CephCommandsTimeout, command, args...)
 	}
+	exec.AuditCommand(c.clusterInfo.Context, "radosgw-admin", args, start, err)
+	exec.TraceCommand(c.clusterInfo.Context, "radosgw-admin", args, start, err)
 
 	if err != nil {
 		return fmt.Sprintf("%s. %s", output, stderr), err
@@ -423,13 +432,13 @@ func GetAdminOPSUserCredentials(objContext *Context, spec *cephv1.ObjectStoreSpe
 			return "", "", err
 		}
 
-		accessKey, ok := s.Data[rgwAdminOpsUserAccessKey]
+		accessKey, ok := s.Data[RGWAdminOpsUserAccessKey]
 		if !ok {
-			return "", "", errors.Errorf("failed to find accessKey %q for rgw admin ops in secret %q", rgwAdminOpsUserAccessKey, RGWAdminOpsUserSecretName)
+			return "", "", errors.Errorf("failed to find accessKey %q for rgw admin ops in secret %q", RGWAdminOpsUserAccessKey, RGWAdminOpsUserSecretName)
 		}
-		secretKey, ok := s.Data[rgwAdminOpsUserSecretKey]
+		secretKey, ok := s.Data[RGWAdminOpsUserSecretKey]
 		if !ok {
-			return "", "", errors.Errorf("failed to find secretKey %q for rgw admin ops in secret %q", rgwAdminOpsUserSecretKey, RGWAdminOpsUserSecretName)
+			return "", "", errors.Errorf("failed to find secretKey %q for rgw admin ops in secret %q", RGWAdminOpsUserSecretKey, RGWAdminOpsUserSecretName)
 		}
 
 		// Set the keys for further usage
@@ -468,3 +477,71 @@ func GetAdminOPSUserCredentials(objContext *Context, spec *cephv1.ObjectStoreSpe
 	}
 	return *user.AccessKey, *user.SecretKey, nil
 }
+
+// ReconcileExternalAdminOpsUser creates the rgw admin ops user in an external cluster the operator
+// connects to with the admin key, and saves its keys in the secret read by the external object
+// stores. The user and its secret are removed when the object stores are not enabled anymore, only
+// if the operator created them.
+func ReconcileExternalAdminOpsUser(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, clusterSpec *cephv1.ClusterSpec, enabled bool) error {
+	objContext := NewContext(context, clusterInfo, "")
+	objContext.CephClusterSpec = *clusterSpec
+
+	if !enabled {
+		secret, err := context.Clientset.CoreV1().Secrets(clusterInfo.Namespace).Get(clusterInfo.Context, RGWAdminOpsUserSecretName, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return nil
+			}
+			return errors.Wrapf(err, "failed to get secret %q", RGWAdminOpsUserSecretName)
+		}
+		if secret.Labels[k8sutil.ExternalUserLabelKey] != "true" {
+			return nil
+		}
+		if _, err := DeleteUser(objContext, RGWAdminOpsUserSecretName); err != nil {
+			return errors.Wrapf(err, "failed to remove rgw admin ops user %q", RGWAdminOpsUserSecretName)
+		}
+		err = context.Clientset.CoreV1().Secrets(clusterInfo.Namespace).Delete(clusterInfo.Context, RGWAdminOpsUserSecretName, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete secret %q", RGWAdminOpsUserSecretName)
+		}
+		logger.Infof("removed rgw admin ops user from external cluster %q", clusterInfo.Namespace)
+		return nil
+	}
+
+	userConfig := ObjectUser{
+		UserID:       RGWAdminOpsUserSecretName,
+		DisplayName:  &rgwAdminOpsUserDisplayName,
+		AdminOpsUser: true,
+	}
+	user, rgwerr, err := CreateUser(objContext, userConfig, false)
+	if err != nil {
+		if rgwerr != ErrorCodeFileExists {
+			return errors.Wrapf(err, "failed to create rgw admin ops user %q. error code %d", userConfig.UserID, rgwerr)
+		}
+		user, _, err = GetUser(objContext, userConfig.UserID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get rgw admin ops user %q", userConfig.UserID)
+		}
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RGWAdminOpsUserSecretName,
+			Namespace: clusterInfo.Namespace,
+			Labels:    map[string]string{k8sutil.ExternalUserLabelKey: "true"},
+		},
+		Data: map[string][]byte{
+			RGWAdminOpsUserAccessKey: []byte(*user.AccessKey),
+			RGWAdminOpsUserSecretKey: []byte(*user.SecretKey),
+		},
+		Type: k8sutil.RookType,
+	}
+	err = clusterInfo.OwnerInfo.SetControllerReference(secret)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to secret %q", secret.Name)
+	}
+	if _, err = k8sutil.CreateOrUpdateSecret(clusterInfo.Context, context.Clientset, secret); err != nil {
+		return errors.Wrapf(err, "failed to save secret %q", secret.Name)
+	}
+	return nil
+}
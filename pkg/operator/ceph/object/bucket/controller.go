@@ -18,6 +18,7 @@ package bucket
 
 import (
 	"context"
+	"sync"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,6 +33,7 @@ import (
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	cephObject "github.com/rook/rook/pkg/operator/ceph/object"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,6 +51,9 @@ type ReconcileBucket struct {
 	clusterInfo      *cephclient.ClusterInfo
 	opConfig         opcontroller.OperatorConfig
 	opManagerContext context.Context
+	// the stop functions of the bucket provisioners running, by namespace of their cluster
+	mutex            sync.Mutex
+	stopProvisioners map[string]context.CancelFunc
 }
 
 // Add creates a new Ceph CSI Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -69,7 +74,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 
 func add(ctx context.Context, mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileLogFields(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -120,6 +125,7 @@ func (r *ReconcileBucket) reconcile(request reconcile.Request) (reconcile.Result
 
 	if !cephCluster.DeletionTimestamp.IsZero() {
 		logger.Debug("ceph cluster is being deleted, no need to reconcile the bucket provisioner")
+		r.stopProvisioner(cephCluster.Namespace)
 		return reconcile.Result{}, nil
 	}
 
@@ -148,7 +154,7 @@ func (r *ReconcileBucket) reconcile(request reconcile.Request) (reconcile.Result
 	}
 
 	// Populate clusterInfo during each reconcile
-	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, r.opManagerContext, cephCluster.Namespace)
+	clusterInfo, err := cephObject.LoadClusterInfo(r.context, r.opManagerContext, cephCluster)
 	if err != nil {
 		// This avoids a requeue with exponential backoff and allows the controller to reconcile
 		// more quickly when the cluster is ready.
@@ -163,15 +169,20 @@ func (r *ReconcileBucket) reconcile(request reconcile.Request) (reconcile.Result
 	bucketProvisioner := NewProvisioner(r.context, clusterInfo)
 	// If cluster is external, pass down the user to the bucket controller
 
-	// note: the error return below is ignored and is expected to be removed from the
-	//   bucket library's `NewProvisioner` function
-	bucketController, _ := NewBucketController(r.context.KubeConfig, bucketProvisioner, r.opConfig.Parameters)
+	bucketController, err := NewBucketController(r.context.KubeConfig, bucketProvisioner, r.opConfig.Parameters)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to create bucket controller")
+	}
+
+	// The bucket provisioner started by a previous reconcile is stopped, so that a single provisioner
+	// handles the OBCs with the latest settings, instead of several provisioners racing on each OBC
+	provisionerContext := r.startProvisioner(cephCluster.Namespace)
 
 	// We must run this in a go routine since RunWithContext() blocks and waits for the context to
 	// be Done. However, since it has a context, the go routine will exit on reload with SIGHUP
-	errChan := make(chan error)
+	errChan := make(chan error, 1)
 	go func() {
-		err = bucketController.RunWithContext(r.opManagerContext)
+		err := bucketController.RunWithContext(provisionerContext)
 		if err != nil {
 			logger.Errorf("failed to run bucket controller. %v", err)
 			errChan <- err
@@ -180,10 +191,38 @@ func (r *ReconcileBucket) reconcile(request reconcile.Request) (reconcile.Result
 
 	// Check for errors when running the bucket controller
 	select {
-	case <-errChan:
+	case err := <-errChan:
 		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to run bucket controller")
 	default:
 		logger.Info("successfully reconciled bucket provisioner")
 		return reconcile.Result{}, nil
 	}
 }
+
+// startProvisioner stops the bucket provisioner of the cluster if it runs, and returns the context of
+// the new one
+func (r *ReconcileBucket) startProvisioner(namespace string) context.Context {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if stop, ok := r.stopProvisioners[namespace]; ok {
+		logger.Infof("stopping the previous bucket provisioner of the cluster in namespace %q", namespace)
+		stop()
+	}
+	if r.stopProvisioners == nil {
+		r.stopProvisioners = map[string]context.CancelFunc{}
+	}
+	ctx, stop := context.WithCancel(r.opManagerContext)
+	r.stopProvisioners[namespace] = stop
+	return ctx
+}
+
+// stopProvisioner stops the bucket provisioner of the cluster if it runs
+func (r *ReconcileBucket) stopProvisioner(namespace string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if stop, ok := r.stopProvisioners[namespace]; ok {
+		logger.Infof("stopping the bucket provisioner of the cluster in namespace %q", namespace)
+		stop()
+		delete(r.stopProvisioners, namespace)
+	}
+}
@@ -179,3 +179,33 @@ func TestCephBucketController(t *testing.T) {
 		cancel()
 	})
 }
+
+func TestProvisionerThreads(t *testing.T) {
+	assert.Equal(t, defaultProvisionerThreads, provisionerThreads(map[string]string{}))
+	assert.Equal(t, 20, provisionerThreads(map[string]string{ProvisionerThreadsSetting: "20"}))
+	assert.Equal(t, maxProvisionerThreads, provisionerThreads(map[string]string{ProvisionerThreadsSetting: "1000"}))
+	assert.Equal(t, defaultProvisionerThreads, provisionerThreads(map[string]string{ProvisionerThreadsSetting: "0"}))
+
+	// the env var of the lib is still honored when the setting is not set
+	os.Setenv(libProvisionerThreadsEnv, "3")
+	defer os.Unsetenv(libProvisionerThreadsEnv)
+	assert.Equal(t, 3, provisionerThreads(map[string]string{}))
+	assert.Equal(t, 8, provisionerThreads(map[string]string{ProvisionerThreadsSetting: "8"}))
+}
+
+func TestStartProvisioner(t *testing.T) {
+	r := &ReconcileBucket{opManagerContext: context.TODO()}
+	first := r.startProvisioner("rook-ceph")
+	other := r.startProvisioner("other")
+	assert.NoError(t, first.Err())
+
+	// a single provisioner runs per cluster
+	second := r.startProvisioner("rook-ceph")
+	assert.Error(t, first.Err())
+	assert.NoError(t, second.Err())
+
+	r.stopProvisioner("rook-ceph")
+	assert.Error(t, second.Err())
+	assert.NoError(t, other.Err())
+	r.stopProvisioner("rook-ceph")
+}
@@ -53,12 +53,16 @@ func predicateController(ctx context.Context, c client.Client) predicate.Funcs {
 			if old, ok := e.ObjectOld.(*v1.ConfigMap); ok {
 				if new, ok := e.ObjectNew.(*v1.ConfigMap); ok {
 					if old.Name == controller.OperatorSettingConfigMapName && new.Name == controller.OperatorSettingConfigMapName {
-						if old.Data[rookOBCWatchOperatorNamespace] != new.Data[rookOBCWatchOperatorNamespace] {
-							logger.Infof("%s changed. reconciling bucket controller", rookOBCWatchOperatorNamespace)
+						for _, setting := range []string{rookOBCWatchOperatorNamespace, ProvisionerThreadsSetting} {
+							if old.Data[setting] == new.Data[setting] {
+								continue
+							}
+							logger.Infof("%s changed. reconciling bucket controller", setting)
 
 							// We reload the manager so that the controller restarts and goes
 							// through the CreateFunc again. Then the CephCluster watcher will be triggered
 							controller.ReloadManager()
+							break
 						}
 					}
 				}
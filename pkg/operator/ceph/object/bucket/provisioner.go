@@ -23,14 +23,18 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/ceph/go-ceph/rgw/admin"
 	"github.com/coreos/pkg/capnslog"
 	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
 	apibkt "github.com/kube-object-storage/lib-bucket-provisioner/pkg/provisioner/api"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/object"
+	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
@@ -76,29 +80,39 @@ func (p Provisioner) Provision(options *apibkt.BucketOptions) (*bktv1alpha1.Obje
 	}
 	logger.Infof("Provision: creating bucket %q for OBC %q", p.bucketName, options.ObjectBucketClaim.Name)
 
-	// dynamically create a new ceph user
-	p.accessKeyID, p.secretAccessKey, err = p.createCephUser("")
+	// the bucket exists when a previous provisioning of the OBC was interrupted, e.g. by a restart of
+	// the operator, before the secret of the OBC was created
+	recovered, err := p.recoverBucketOwner(options.ObjectBucketClaim)
 	if err != nil {
-		return nil, errors.Wrap(err, "Provision: can't create ceph user")
-	}
-
-	s3svc, err := cephObject.NewS3Agent(p.accessKeyID, p.secretAccessKey, p.getObjectStoreEndpoint(), p.region, logger.LevelAt(capnslog.DEBUG), p.tlsCert)
-	if err != nil {
-		p.deleteOBCResourceLogError("")
 		return nil, err
 	}
+	if !recovered {
+		// dynamically create a new ceph user
+		p.accessKeyID, p.secretAccessKey, err = p.createCephUser("", obcUserDisplayName(options.ObjectBucketClaim))
+		if err != nil {
+			return nil, errors.Wrap(err, "Provision: can't create ceph user")
+		}
 
-	// create the bucket
-	err = s3svc.CreateBucket(p.bucketName)
-	if err != nil {
-		err = errors.Wrapf(err, "error creating bucket %q", p.bucketName)
-		logger.Errorf(err.Error())
-		p.deleteOBCResourceLogError("")
-		return nil, err
+		s3svc, err := cephObject.NewS3Agent(p.accessKeyID, p.secretAccessKey, p.getObjectStoreEndpoint(), p.region, logger.LevelAt(capnslog.DEBUG), p.tlsCert)
+		if err != nil {
+			p.deleteOBCResourceLogError("")
+			return nil, err
+		}
+
+		// create the bucket
+		err = retryRGW(fmt.Sprintf("create bucket %q", p.bucketName), func() error {
+			return s3svc.CreateBucket(p.bucketName)
+		})
+		if err != nil {
+			err = errors.Wrapf(err, "error creating bucket %q", p.bucketName)
+			logger.Errorf(err.Error())
+			p.deleteOBCResourceLogError("")
+			return nil, err
+		}
 	}
 
 	singleBucketQuota := 1
-	_, err = p.adminOpsClient.ModifyUser(p.clusterInfo.Context, admin.User{ID: p.cephUserName, MaxBuckets: &singleBucketQuota})
+	err = p.setMaxBuckets(singleBucketQuota)
 	if err != nil {
 		p.deleteOBCResourceLogError(p.bucketName)
 		return nil, err
@@ -112,9 +126,43 @@ func (p Provisioner) Provision(options *apibkt.BucketOptions) (*bktv1alpha1.Obje
 		return nil, err
 	}
 
+	p.pushOBCCredentials(options)
 	return p.composeObjectBucket(), nil
 }
 
+// recoverBucketOwner reuses the user owning the bucket of the OBC when the bucket already exists and
+// was created for the OBC, as left by a provisioning interrupted before the secret of the OBC was
+// created. It returns whether the bucket exists, and an error when it is owned by a user that was not
+// created for the OBC, whose credentials must not be given to the OBC.
+func (p *Provisioner) recoverBucketOwner(obc *bktv1alpha1.ObjectBucketClaim) (bool, error) {
+	bucket, err := p.getBucketInfo(p.bucketName)
+	if err != nil {
+		if errors.Is(err, admin.ErrNoSuchBucket) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to check whether bucket %q exists", p.bucketName)
+	}
+	owner, err := p.getUser(bucket.Owner)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get user %q owning the existing bucket %q", bucket.Owner, p.bucketName)
+	}
+	if owner.DisplayName != obcUserDisplayName(obc) || len(owner.Keys) == 0 {
+		return false, errors.Errorf("bucket %q already exists and is owned by user %q which was not created for OBC %q in namespace %q", p.bucketName, bucket.Owner, obc.Name, obc.Namespace)
+	}
+
+	logger.Infof("Provision: recovering bucket %q and user %q of OBC %q left by a previous provisioning", p.bucketName, owner.ID, obc.Name)
+	p.cephUserName = owner.ID
+	p.accessKeyID = owner.Keys[0].AccessKey
+	p.secretAccessKey = owner.Keys[0].SecretKey
+	return true, nil
+}
+
+// obcUserDisplayName returns the display name of the users created for the buckets of an OBC, which
+// identifies the buckets created for the OBC when its provisioning is retried
+func obcUserDisplayName(obc *bktv1alpha1.ObjectBucketClaim) string {
+	return fmt.Sprintf("obc-%s-%s", obc.Namespace, obc.Name)
+}
+
 // Grant attaches to an existing rgw bucket and returns a connection info
 // representing the bucket's endpoint and user access credentials.
 func (p Provisioner) Grant(options *apibkt.BucketOptions) (*bktv1alpha1.ObjectBucket, error) {
@@ -133,27 +181,27 @@ func (p Provisioner) Grant(options *apibkt.BucketOptions) (*bktv1alpha1.ObjectBu
 		return nil, errors.Wrapf(err, "bucket %s does not exist", p.bucketName)
 	}
 
-	p.accessKeyID, p.secretAccessKey, err = p.createCephUser("")
+	p.accessKeyID, p.secretAccessKey, err = p.createCephUser("", "")
 	if err != nil {
 		return nil, err
 	}
 
 	// need to quota into -1 for restricting creation of new buckets in rgw
 	restrictBucketCreation := -1
-	_, err = p.adminOpsClient.ModifyUser(p.clusterInfo.Context, admin.User{ID: p.cephUserName, MaxBuckets: &restrictBucketCreation})
+	err = p.setMaxBuckets(restrictBucketCreation)
 	if err != nil {
 		p.deleteOBCResourceLogError("")
 		return nil, err
 	}
 
 	// get the bucket's owner via the bucket metadata
-	stats, err := p.adminOpsClient.GetBucketInfo(p.clusterInfo.Context, admin.Bucket{Bucket: p.bucketName})
+	stats, err := p.getBucketInfo(p.bucketName)
 	if err != nil {
 		p.deleteOBCResourceLogError("")
 		return nil, errors.Wrapf(err, "failed to get bucket %q stats", p.bucketName)
 	}
 
-	objectUser, err := p.adminOpsClient.GetUser(p.clusterInfo.Context, admin.User{ID: stats.Owner})
+	objectUser, err := p.getUser(stats.Owner)
 	if err != nil {
 		p.deleteOBCResourceLogError("")
 		return nil, errors.Wrapf(err, "failed to get user %q", stats.Owner)
@@ -188,7 +236,12 @@ func (p Provisioner) Grant(options *apibkt.BucketOptions) (*bktv1alpha1.ObjectBu
 	} else {
 		policy = policy.ModifyBucketPolicy(*statement)
 	}
-	out, err := s3svc.PutBucketPolicy(p.bucketName, *policy)
+	var out *s3.PutBucketPolicyOutput
+	err = retryRGW(fmt.Sprintf("put bucket %q policy", p.bucketName), func() error {
+		var err error
+		out, err = s3svc.PutBucketPolicy(p.bucketName, *policy)
+		return err
+	})
 
 	logger.Infof("PutBucketPolicy output: %v", out)
 	if err != nil {
@@ -203,6 +256,7 @@ func (p Provisioner) Grant(options *apibkt.BucketOptions) (*bktv1alpha1.ObjectBu
 		return nil, err
 	}
 
+	p.pushOBCCredentials(options)
 	// returned ob with connection info
 	return p.composeObjectBucket(), nil
 }
@@ -212,6 +266,10 @@ func (p Provisioner) Grant(options *apibkt.BucketOptions) (*bktv1alpha1.ObjectBu
 // the bucket controller needs to clean up before retrying.
 func (p Provisioner) Delete(ob *bktv1alpha1.ObjectBucket) error {
 	logger.Debugf("Delete event for OB: %+v", ob)
+	if isMigratedToCOSI(ob) {
+		logger.Infof("Delete: keeping bucket %q of OB %q migrated to the COSI Bucket %q", getBucketName(ob), ob.Name, ob.Annotations[COSIBucketAnnotation])
+		return nil
+	}
 
 	err := p.initializeDeleteOrRevoke(ob)
 	if err != nil {
@@ -229,6 +287,10 @@ func (p Provisioner) Delete(ob *bktv1alpha1.ObjectBucket) error {
 // Note: cleanup order below matters.
 func (p Provisioner) Revoke(ob *bktv1alpha1.ObjectBucket) error {
 	logger.Debugf("Revoke event for OB: %+v", ob)
+	if isMigratedToCOSI(ob) {
+		logger.Infof("Revoke: keeping user %q of OB %q migrated to the COSI Bucket %q", getCephUser(ob), ob.Name, ob.Annotations[COSIBucketAnnotation])
+		return nil
+	}
 
 	err := p.initializeDeleteOrRevoke(ob)
 	if err != nil {
@@ -236,7 +298,7 @@ func (p Provisioner) Revoke(ob *bktv1alpha1.ObjectBucket) error {
 	}
 	logger.Infof("Revoke: denying access to bucket %q for OB %q", p.bucketName, ob.Name)
 
-	bucket, err := p.adminOpsClient.GetBucketInfo(p.clusterInfo.Context, admin.Bucket{Bucket: p.bucketName})
+	bucket, err := p.getBucketInfo(p.bucketName)
 	if err != nil {
 		logger.Errorf("%v", err)
 	} else {
@@ -244,7 +306,7 @@ func (p Provisioner) Revoke(ob *bktv1alpha1.ObjectBucket) error {
 			return errors.Errorf("failed to find bucket %q owner", p.bucketName)
 		}
 
-		user, err := p.adminOpsClient.GetUser(p.clusterInfo.Context, admin.User{ID: bucket.Owner})
+		user, err := p.getUser(bucket.Owner)
 		if err != nil {
 			if errors.Is(err, admin.ErrNoSuchUser) {
 				// The user may not exist. Ignore this in order to ensure the PolicyStatement does not contain the
@@ -312,6 +374,27 @@ func (p Provisioner) Revoke(ob *bktv1alpha1.ObjectBucket) error {
 	return nil
 }
 
+// pushOBCCredentials pushes the credentials of an OBC to the external secret store of the cluster.
+// The secret of the credentials is created by the lib after the provisioning, the PushSecret
+// referencing it is owned by the OBC. The bucket is provisioned even if the push fails.
+func (p *Provisioner) pushOBCCredentials(options *apibkt.BucketOptions) {
+	obc := options.ObjectBucketClaim
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obc.Name,
+			Namespace: obc.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(obc, bktv1alpha1.SchemeGroupVersion.WithKind("ObjectBucketClaim")),
+			},
+		},
+		Data: map[string][]byte{bktv1alpha1.AwsKeyField: nil, bktv1alpha1.AwsSecretField: nil},
+	}
+	err := opcontroller.ReconcilePushSecret(p.clusterInfo.Context, p.context.Client, p.clusterInfo.Namespace, &p.objectContext.CephClusterSpec.Security, cephv1.ExternalSecretObjectBucketClaimCredentials, secret)
+	if err != nil {
+		logger.Errorf("failed to push the credentials of OBC %q in namespace %q. %v", obc.Name, obc.Namespace, err)
+	}
+}
+
 // Return the OB struct with minimal fields filled in.
 // initializeCreateOrGrant sets common provisioner receiver fields and
 // the services and sessions needed to provision.
@@ -336,6 +419,12 @@ func (p *Provisioner) initializeCreateOrGrant(options *apibkt.BucketOptions) err
 		p.setBucketName(bucketName)
 	}
 
+	// the buckets are provisioned again by the retries once the cluster recovers
+	err = p.checkBackpressure()
+	if err != nil {
+		return err
+	}
+
 	p.setObjectStoreName(sc)
 	p.setRegion(sc)
 	p.setAdditionalConfigData(obc.Spec.AdditionalConfig)
@@ -561,7 +650,7 @@ func (p Provisioner) setAdditionalSettings(options *apibkt.BucketOptions) error
 	}
 
 	// Enabling quota for the user
-	err := p.adminOpsClient.SetUserQuota(p.clusterInfo.Context, admin.QuotaSpec{UID: p.cephUserName, Enabled: &quotaEnabled})
+	err := p.setUserQuota(admin.QuotaSpec{UID: p.cephUserName, Enabled: &quotaEnabled})
 	if err != nil {
 		return errors.Wrapf(err, "failed to enable user %q quota for obc", p.cephUserName)
 	}
@@ -572,7 +661,7 @@ func (p Provisioner) setAdditionalSettings(options *apibkt.BucketOptions) error
 			return errors.Wrap(err, "failed to convert maxObjects to integer")
 		}
 		maxObjectsInt64 := int64(maxObjectsInt)
-		err = p.adminOpsClient.SetUserQuota(p.clusterInfo.Context, admin.QuotaSpec{UID: p.cephUserName, MaxObjects: &maxObjectsInt64})
+		err = p.setUserQuota(admin.QuotaSpec{UID: p.cephUserName, MaxObjects: &maxObjectsInt64})
 		if err != nil {
 			return errors.Wrapf(err, "failed to set MaxObject to user %q", p.cephUserName)
 		}
@@ -582,7 +671,7 @@ func (p Provisioner) setAdditionalSettings(options *apibkt.BucketOptions) error
 		if err != nil {
 			return errors.Wrapf(err, "failed to parse maxSize quota for user %q", p.cephUserName)
 		}
-		err = p.adminOpsClient.SetUserQuota(p.clusterInfo.Context, admin.QuotaSpec{UID: p.cephUserName, MaxSize: &maxSizeInt})
+		err = p.setUserQuota(admin.QuotaSpec{UID: p.cephUserName, MaxSize: &maxSizeInt})
 		if err != nil {
 			return errors.Wrapf(err, "failed to set MaxSize to user %q", p.cephUserName)
 		}
@@ -685,7 +774,7 @@ func (p Provisioner) updateAdditionalSettings(ob *bktv1alpha1.ObjectBucket) erro
 			return errors.Wrapf(err, "failed to parse maxSize quota for user %q", p.cephUserName)
 		}
 	}
-	objectUser, err := p.adminOpsClient.GetUser(p.clusterInfo.Context, admin.User{ID: ob.Spec.Connection.AdditionalState[CephUser]})
+	objectUser, err := p.getUser(ob.Spec.Connection.AdditionalState[CephUser])
 	if err != nil {
 		return errors.Wrapf(err, "failed to fetch user %q", p.cephUserName)
 	}
@@ -693,7 +782,7 @@ func (p Provisioner) updateAdditionalSettings(ob *bktv1alpha1.ObjectBucket) erro
 		(maxObjects == "" || maxObjectsInt64 < 0) &&
 		(maxSize == "" || maxSizeInt64 < 0) {
 		quotaEnabled = false
-		err = p.adminOpsClient.SetUserQuota(p.clusterInfo.Context, admin.QuotaSpec{UID: p.cephUserName, Enabled: &quotaEnabled})
+		err = p.setUserQuota(admin.QuotaSpec{UID: p.cephUserName, Enabled: &quotaEnabled})
 		if err != nil {
 			return errors.Wrapf(err, "failed to disable quota to user %q", p.cephUserName)
 		}
@@ -712,7 +801,7 @@ func (p Provisioner) updateAdditionalSettings(ob *bktv1alpha1.ObjectBucket) erro
 	if maxSize != "" && (maxSizeInt64 != *objectUser.UserQuota.MaxSize) {
 		quotaSpec.MaxSize = &maxSizeInt64
 	}
-	err = p.adminOpsClient.SetUserQuota(p.clusterInfo.Context, quotaSpec)
+	err = p.setUserQuota(quotaSpec)
 	if err != nil {
 		return errors.Wrapf(err, "failed to update quota to user %q", p.cephUserName)
 	}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"testing"
 
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
@@ -105,6 +106,20 @@ func TestPopulateDomainAndPort(t *testing.T) {
 	assert.Equal(t, "rook-ceph-rgw-test-store.ns.svc", p.storeDomainName)
 }
 
+func TestDeleteMigratedToCOSI(t *testing.T) {
+	// the bucket and the user of an OB migrated to COSI are kept without reaching the object store
+	p := NewProvisioner(&clusterd.Context{}, nil)
+	ob := &bktv1alpha1.ObjectBucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "obc-app-photos", Annotations: map[string]string{COSIBucketAnnotation: "obc-app-photos"}},
+		Spec: bktv1alpha1.ObjectBucketSpec{
+			StorageClassName: "missing",
+			Connection:       &bktv1alpha1.Connection{Endpoint: &bktv1alpha1.Endpoint{BucketName: "photos"}},
+		},
+	}
+	assert.NoError(t, p.Delete(ob))
+	assert.NoError(t, p.Revoke(ob))
+}
+
 func TestMaxSizeToInt64(t *testing.T) {
 	type args struct {
 		maxSize string
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/ceph/go-ceph/rgw/admin"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// rgwRetryBackoff is the backoff of the retries of the rgw requests failing with a transient error,
+// about 30s in total
+var rgwRetryBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+}
+
+// retryRGW runs an rgw request, retrying it with backoff while it fails with a transient error. The
+// other errors are returned immediately.
+func retryRGW(description string, request func() error) error {
+	var err error
+	backoff := rgwRetryBackoff
+	for {
+		err = request()
+		if err == nil || !isTransientRGWError(err) {
+			return err
+		}
+		if backoff.Steps <= 1 {
+			return errors.Wrapf(err, "failed to %s after retries", description)
+		}
+		delay := backoff.Step()
+		logger.Warningf("failed to %s, retrying in %s. %v", description, delay.Round(time.Millisecond), err)
+		time.Sleep(delay)
+	}
+}
+
+// isTransientRGWError returns whether an error of an rgw request may succeed when retried: the
+// connection errors, the timeouts and the server errors of the rgw
+func isTransientRGWError(err error) bool {
+	if errors.Is(err, admin.ErrInternalError) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var awsFailure awserr.RequestFailure
+	if errors.As(err, &awsFailure) {
+		return awsFailure.StatusCode() >= http.StatusInternalServerError || awsFailure.StatusCode() == http.StatusTooManyRequests
+	}
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		switch awsErr.Code() {
+		case request.ErrCodeRequestError, request.ErrCodeResponseTimeout, "SlowDown", "ServiceUnavailable", "InternalError":
+			return true
+		}
+		return false
+	}
+	// the admin ops api fails to decode the responses of a gateway or a proxy in front of the rgw when
+	// it is unavailable, e.g. a 503 html page
+	return strings.Contains(err.Error(), "failed to unmarshal radosgw http response")
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/ceph/go-ceph/rgw/admin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestIsTransientRGWError(t *testing.T) {
+	assert.True(t, isTransientRGWError(errors.Wrap(admin.ErrInternalError, "failed")))
+	assert.True(t, isTransientRGWError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	assert.True(t, isTransientRGWError(errors.Wrap(awserr.NewRequestFailure(awserr.New("ServiceUnavailable", "down", nil), 503, "id"), "failed")))
+	assert.True(t, isTransientRGWError(awserr.New("RequestError", "send request failed", nil)))
+	assert.True(t, isTransientRGWError(errors.New("failed to unmarshal radosgw http response. <html>503</html>")))
+
+	assert.False(t, isTransientRGWError(admin.ErrNoSuchUser))
+	assert.False(t, isTransientRGWError(awserr.NewRequestFailure(awserr.New("AccessDenied", "denied", nil), 403, "id")))
+	assert.False(t, isTransientRGWError(awserr.New("NoSuchBucketPolicy", "no policy", nil)))
+	assert.False(t, isTransientRGWError(errors.New("failed")))
+}
+
+func TestRetryRGW(t *testing.T) {
+	defer func(backoff wait.Backoff) { rgwRetryBackoff = backoff }(rgwRetryBackoff)
+	rgwRetryBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	t.Run("succeeds after transient errors", func(t *testing.T) {
+		attempts := 0
+		err := retryRGW("create bucket", func() error {
+			attempts++
+			if attempts < 3 {
+				return admin.ErrInternalError
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("gives up after the retries", func(t *testing.T) {
+		attempts := 0
+		err := retryRGW("create bucket", func() error {
+			attempts++
+			return admin.ErrInternalError
+		})
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, admin.ErrInternalError))
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		attempts := 0
+		err := retryRGW("get user", func() error {
+			attempts++
+			return admin.ErrNoSuchUser
+		})
+		assert.True(t, errors.Is(err, admin.ErrNoSuchUser))
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -8,7 +8,10 @@ import (
 )
 
 func (p *Provisioner) bucketExists(name string) (bool, error) {
-	_, err := p.adminOpsClient.GetBucketInfo(p.clusterInfo.Context, admin.Bucket{Bucket: name})
+	err := retryRGW(fmt.Sprintf("get ceph bucket %q", name), func() error {
+		_, err := p.adminOpsClient.GetBucketInfo(p.clusterInfo.Context, admin.Bucket{Bucket: name})
+		return err
+	})
 	if err != nil {
 		if errors.Is(err, admin.ErrNoSuchBucket) {
 			return false, nil
@@ -19,7 +22,10 @@ func (p *Provisioner) bucketExists(name string) (bool, error) {
 }
 
 func (p *Provisioner) userExists(name string) (bool, error) {
-	_, err := p.adminOpsClient.GetUser(p.clusterInfo.Context, admin.User{ID: name})
+	err := retryRGW(fmt.Sprintf("get ceph user %q", name), func() error {
+		_, err := p.adminOpsClient.GetUser(p.clusterInfo.Context, admin.User{ID: name})
+		return err
+	})
 	if err != nil {
 		if errors.Is(err, admin.ErrNoSuchUser) {
 			return false, nil
@@ -31,9 +37,9 @@ func (p *Provisioner) userExists(name string) (bool, error) {
 	return true, nil
 }
 
-// Create a Ceph user based on the passed-in name or a generated name. Return the
-// accessKeys and set user name and keys in receiver.
-func (p *Provisioner) createCephUser(username string) (accKey string, secKey string, err error) {
+// Create a Ceph user based on the passed-in name or a generated name, with the display name when
+// not empty. Return the accessKeys and set user name and keys in receiver.
+func (p *Provisioner) createCephUser(username, displayName string) (accKey string, secKey string, err error) {
 	if len(username) == 0 {
 		username, err = p.genUserName()
 		if len(username) == 0 || err != nil {
@@ -43,22 +49,34 @@ func (p *Provisioner) createCephUser(username string) (accKey string, secKey str
 	p.cephUserName = username
 
 	logger.Infof("creating Ceph user %q", username)
+	if displayName == "" {
+		displayName = p.cephUserName
+	}
 	userConfig := admin.User{
 		ID:          username,
-		DisplayName: p.cephUserName,
+		DisplayName: displayName,
 	}
 
+	// the user is created if it does not exist at each attempt, in case the creation succeeded but
+	// its response was lost
 	var u admin.User
-	u, err = p.adminOpsClient.GetUser(p.clusterInfo.Context, userConfig)
-	if err != nil {
-		if errors.Is(err, admin.ErrNoSuchUser) {
-			u, err = p.adminOpsClient.CreateUser(p.clusterInfo.Context, userConfig)
-			if err != nil {
-				return "", "", errors.Wrapf(err, "failed to create ceph object user %v", userConfig.ID)
+	err = retryRGW(fmt.Sprintf("create ceph user %q", username), func() error {
+		var err error
+		u, err = p.adminOpsClient.GetUser(p.clusterInfo.Context, userConfig)
+		if err != nil {
+			if errors.Is(err, admin.ErrNoSuchUser) {
+				u, err = p.adminOpsClient.CreateUser(p.clusterInfo.Context, userConfig)
+				if err != nil {
+					return errors.Wrapf(err, "failed to create ceph object user %v", userConfig.ID)
+				}
+				return nil
 			}
-		} else {
-			return "", "", errors.Wrapf(err, "failed to get ceph user %q", username)
+			return errors.Wrapf(err, "failed to get ceph user %q", username)
 		}
+		return nil
+	})
+	if err != nil {
+		return "", "", err
 	}
 
 	logger.Infof("successfully created Ceph user %q with access keys", username)
@@ -95,7 +113,9 @@ func (p *Provisioner) deleteOBCResource(bucketName string) error {
 	if len(bucketName) > 0 {
 		// delete bucket with purge option to remove all objects
 		thePurge := true
-		err := p.adminOpsClient.RemoveBucket(p.clusterInfo.Context, admin.Bucket{Bucket: bucketName, PurgeObject: &thePurge})
+		err := retryRGW(fmt.Sprintf("delete bucket %q", bucketName), func() error {
+			return p.adminOpsClient.RemoveBucket(p.clusterInfo.Context, admin.Bucket{Bucket: bucketName, PurgeObject: &thePurge})
+		})
 		if err == nil {
 			logger.Infof("bucket %q successfully deleted", p.bucketName)
 		} else if errors.Is(err, admin.ErrNoSuchBucket) {
@@ -116,7 +136,9 @@ func (p *Provisioner) deleteOBCResource(bucketName string) error {
 		}
 	}
 	if len(p.cephUserName) > 0 {
-		err := p.adminOpsClient.RemoveUser(p.clusterInfo.Context, admin.User{ID: p.cephUserName})
+		err := retryRGW(fmt.Sprintf("delete user %q", p.cephUserName), func() error {
+			return p.adminOpsClient.RemoveUser(p.clusterInfo.Context, admin.User{ID: p.cephUserName})
+		})
 		if err != nil {
 			if errors.Is(err, admin.ErrNoSuchUser) {
 				logger.Warningf("user %q does not exist, nothing to delete. %v", p.cephUserName, err)
@@ -128,3 +150,40 @@ func (p *Provisioner) deleteOBCResource(bucketName string) error {
 	}
 	return nil
 }
+
+// getBucketInfo returns the info of a bucket, retrying on transient errors
+func (p *Provisioner) getBucketInfo(name string) (admin.Bucket, error) {
+	var bucket admin.Bucket
+	err := retryRGW(fmt.Sprintf("get bucket %q info", name), func() error {
+		var err error
+		bucket, err = p.adminOpsClient.GetBucketInfo(p.clusterInfo.Context, admin.Bucket{Bucket: name})
+		return err
+	})
+	return bucket, err
+}
+
+// getUser returns a ceph user, retrying on transient errors
+func (p *Provisioner) getUser(id string) (admin.User, error) {
+	var user admin.User
+	err := retryRGW(fmt.Sprintf("get user %q", id), func() error {
+		var err error
+		user, err = p.adminOpsClient.GetUser(p.clusterInfo.Context, admin.User{ID: id})
+		return err
+	})
+	return user, err
+}
+
+// setMaxBuckets sets the max number of buckets of the ceph user, retrying on transient errors
+func (p *Provisioner) setMaxBuckets(maxBuckets int) error {
+	return retryRGW(fmt.Sprintf("set user %q max buckets", p.cephUserName), func() error {
+		_, err := p.adminOpsClient.ModifyUser(p.clusterInfo.Context, admin.User{ID: p.cephUserName, MaxBuckets: &maxBuckets})
+		return err
+	})
+}
+
+// setUserQuota sets the quota of a ceph user, retrying on transient errors
+func (p *Provisioner) setUserQuota(quota admin.QuotaSpec) error {
+	return retryRGW(fmt.Sprintf("set user %q quota", quota.UID), func() error {
+		return p.adminOpsClient.SetUserQuota(p.clusterInfo.Context, quota)
+	})
+}
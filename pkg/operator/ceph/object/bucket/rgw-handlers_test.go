@@ -25,12 +25,14 @@ import (
 	"testing"
 
 	"github.com/ceph/go-ceph/rgw/admin"
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
 	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephobject "github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type statusError struct {
@@ -90,3 +92,60 @@ func TestDeleteOBCResource(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestRecoverBucketOwner(t *testing.T) {
+	clusterInfo := client.AdminTestClusterInfo("ns")
+	p := NewProvisioner(&clusterd.Context{RookClientset: rookclient.NewSimpleClientset(), Clientset: test.New(t, 1)}, clusterInfo)
+	obc := &bktv1alpha1.ObjectBucketClaim{ObjectMeta: metav1.ObjectMeta{Name: "my-obc", Namespace: "apps"}}
+	mockClient := func(bucketExists bool, ownerDisplayName string) *cephobject.MockClient {
+		return &cephobject.MockClient{
+			MockDo: func(req *http.Request) (*http.Response, error) {
+				if req.Method == http.MethodGet && req.URL.Path == "rook-ceph-rgw-my-store.mycluster.svc/admin/bucket" {
+					if !bucketExists {
+						status, _ := json.Marshal(statusError{"NoSuchBucket", "requestid", "hostid"})
+						return &http.Response{StatusCode: 404, Body: ioutil.NopCloser(bytes.NewReader(status))}, nil
+					}
+					return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader([]byte(`{"bucket":"my-bucket","owner":"ceph-user-12345678"}`)))}, nil
+				}
+				if req.Method == http.MethodGet && req.URL.Path == "rook-ceph-rgw-my-store.mycluster.svc/admin/user" {
+					user := fmt.Sprintf(`{"user_id":"ceph-user-12345678","display_name":%q,"keys":[{"user":"ceph-user-12345678","access_key":"AK","secret_key":"SK"}]}`, ownerDisplayName)
+					return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader([]byte(user)))}, nil
+				}
+				return nil, fmt.Errorf("unexpected request: %q. method %q. path %q", req.URL.RawQuery, req.Method, req.URL.Path)
+			},
+		}
+	}
+	setClient := func(bucketExists bool, ownerDisplayName string) {
+		adminClient, err := admin.New("rook-ceph-rgw-my-store.mycluster.svc", "53S6B9S809NUP19IJ2K3", "1bXPegzsGClvoGAiJdHQD1uOW2sQBLAZM9j9VtXR", mockClient(bucketExists, ownerDisplayName))
+		assert.NoError(t, err)
+		p.adminOpsClient = adminClient
+		p.bucketName = "my-bucket"
+		p.cephUserName, p.accessKeyID, p.secretAccessKey = "", "", ""
+	}
+
+	t.Run("bucket does not exist", func(t *testing.T) {
+		setClient(false, "")
+		recovered, err := p.recoverBucketOwner(obc)
+		assert.NoError(t, err)
+		assert.False(t, recovered)
+		assert.Equal(t, "", p.cephUserName)
+	})
+
+	t.Run("bucket left by a previous provisioning of the OBC", func(t *testing.T) {
+		setClient(true, "obc-apps-my-obc")
+		recovered, err := p.recoverBucketOwner(obc)
+		assert.NoError(t, err)
+		assert.True(t, recovered)
+		assert.Equal(t, "ceph-user-12345678", p.cephUserName)
+		assert.Equal(t, "AK", p.accessKeyID)
+		assert.Equal(t, "SK", p.secretAccessKey)
+	})
+
+	t.Run("bucket owned by another user", func(t *testing.T) {
+		setClient(true, "someone-else")
+		recovered, err := p.recoverBucketOwner(obc)
+		assert.Error(t, err)
+		assert.False(t, recovered)
+		assert.Equal(t, "", p.accessKeyID)
+	})
+}
@@ -18,13 +18,17 @@ package bucket
 
 import (
 	"crypto/rand"
+	"os"
+	"strconv"
 
 	"github.com/coreos/pkg/capnslog"
 	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
 	"github.com/kube-object-storage/lib-bucket-provisioner/pkg/provisioner"
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	cephObject "github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	storagev1 "k8s.io/api/storage/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,16 +43,52 @@ const (
 	objectStoreName      = "objectStoreName"
 	objectStoreNamespace = "objectStoreNamespace"
 	objectStoreEndpoint  = "endpoint"
+	// COSIBucketAnnotation is the annotation of the ObjectBuckets migrated to a COSI Bucket with the
+	// name of the Bucket. The bucket and the user of a migrated OB are kept when its OBC is deleted.
+	COSIBucketAnnotation = "ceph.rook.io/cosi-bucket"
+
+	// ProvisionerThreadsSetting is the operator setting of the number of OBCs provisioned concurrently
+	ProvisionerThreadsSetting = "ROOK_OBC_PROVISIONER_THREADS"
+	// the env var of the number of workers of the lib bucket provisioner, which used to be set in the
+	// operator deployment
+	libProvisionerThreadsEnv  = "LIB_BUCKET_PROVISIONER_THREADS"
+	defaultProvisionerThreads = 5
+	maxProvisionerThreads     = 50
 )
 
 func NewBucketController(cfg *rest.Config, p *Provisioner, data map[string]string) (*provisioner.Provisioner, error) {
 	const allNamespaces = ""
 	provName := cephObject.GetObjectBucketProvisioner(data, p.clusterInfo.Namespace)
 
-	logger.Infof("ceph bucket provisioner launched watching for provisioner %q", provName)
+	// the lib bucket provisioner reads the number of its workers from the env when it starts
+	threads := provisionerThreads(data)
+	if err := os.Setenv(libProvisionerThreadsEnv, strconv.Itoa(threads)); err != nil {
+		return nil, errors.Wrapf(err, "failed to set %q", libProvisionerThreadsEnv)
+	}
+
+	logger.Infof("ceph bucket provisioner launched watching for provisioner %q with %d workers", provName, threads)
 	return provisioner.NewProvisioner(cfg, provName, p, allNamespaces)
 }
 
+// provisionerThreads returns the number of OBCs provisioned concurrently, from the operator setting or
+// else from the env var of the lib bucket provisioner, bounded to protect the rgw
+func provisionerThreads(data map[string]string) int {
+	defaultThreads := strconv.Itoa(defaultProvisionerThreads)
+	if libThreads, ok := os.LookupEnv(libProvisionerThreadsEnv); ok {
+		defaultThreads = libThreads
+	}
+	threads, err := strconv.Atoi(k8sutil.GetValue(data, ProvisionerThreadsSetting, defaultThreads))
+	if err != nil || threads <= 0 {
+		logger.Warningf("%s should be > 0, set the default value %d", ProvisionerThreadsSetting, defaultProvisionerThreads)
+		return defaultProvisionerThreads
+	}
+	if threads > maxProvisionerThreads {
+		logger.Warningf("%s %d is above the maximum, set the maximum %d", ProvisionerThreadsSetting, threads, maxProvisionerThreads)
+		return maxProvisionerThreads
+	}
+	return threads
+}
+
 func getObjectStoreName(sc *storagev1.StorageClass) string {
 	return sc.Parameters[objectStoreName]
 }
@@ -71,6 +111,12 @@ func isStaticBucket(sc *storagev1.StorageClass) (string, bool) {
 	return val, ok
 }
 
+// isMigratedToCOSI returns whether the bucket of the OB was migrated to a COSI Bucket, which owns
+// the bucket and the user of the OBC since then
+func isMigratedToCOSI(ob *bktv1alpha1.ObjectBucket) bool {
+	return ob.Annotations[COSIBucketAnnotation] != ""
+}
+
 func getCephUser(ob *bktv1alpha1.ObjectBucket) string {
 	return ob.Spec.AdditionalState[CephUser]
 }
@@ -101,6 +147,19 @@ func (p *Provisioner) getCephCluster() (*cephv1.CephCluster, error) {
 	return &cephCluster.Items[0], err
 }
 
+// checkBackpressure returns an error when the provisioning of the buckets is paused by the
+// backpressure policy of the ceph cluster
+func (p *Provisioner) checkBackpressure() error {
+	cephCluster, err := p.getCephCluster()
+	if err != nil {
+		return err
+	}
+	if reason, message := opcontroller.BackpressureReason(cephCluster); reason != "" {
+		return errors.New(message)
+	}
+	return nil
+}
+
 func randomString(n int) string {
 
 	var letterRunes = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
@@ -54,6 +54,20 @@ caps osd = "allow rwx"
 	HttpTimeOut                     = time.Second * 15
 	rgwVaultVolumeName              = "rgw-vault-volume"
 	rgwVaultDirName                 = "/etc/vault/rgw/"
+	rgwKMIPVolumeName               = "rgw-kmip-volume"
+	rgwKMIPDirName                  = "/etc/ceph/kmip/"
+	rgwKMIPCAFileName               = "ca.crt"
+	rgwKMIPCertFileName             = "client.crt"
+	rgwKMIPKeyFileName              = "client.key"
+)
+
+const (
+	rgwVaultAgentContainerName = "vault-agent"
+	rgwVaultAgentAddress       = "127.0.0.1:8100"
+	// VaultAgentImageKey is the connection detail overriding the image of the vault agent
+	VaultAgentImageKey = "VAULT_AGENT_IMAGE"
+	// DefaultVaultAgentImage is the image of the vault agent authenticating rgw to Vault
+	DefaultVaultAgentImage = "vault:1.9.3"
 )
 
 var (
@@ -30,7 +30,6 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
-	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
@@ -102,7 +101,10 @@ type objectStoreHealth struct {
 // Add creates a new cephObjectStore Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
-	return add(mgr, newReconciler(mgr, context, opManagerContext, opConfig))
+	if err := add(mgr, newReconciler(mgr, context, opManagerContext, opConfig)); err != nil {
+		return err
+	}
+	return addTaskController(mgr, context)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -122,7 +124,8 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephObjectStore{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephObjectStore{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -156,7 +159,7 @@ func (r *ReconcileCephObjectStore) Reconcile(context context.Context, request re
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
 	reconcileResponse, objectStore, err := r.reconcile(request)
 
-	return reporting.ReportReconcileResult(logger, r.recorder, objectStore, reconcileResponse, err)
+	return reporting.ReportReconcileResult(logger, r.recorder, request, objectStore, reconcileResponse, err)
 }
 
 func (r *ReconcileCephObjectStore) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephObjectStore, error) {
@@ -229,7 +232,8 @@ func (r *ReconcileCephObjectStore) reconcile(request reconcile.Request) (reconci
 	}
 
 	// Populate clusterInfo during each reconcile
-	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	auditCtx := exec.WithAuditInitiator(r.opManagerContext, "CephObjectStore", request.Namespace, request.Name)
+	r.clusterInfo, err = LoadClusterInfo(r.context, auditCtx, &cephCluster)
 	if err != nil {
 		return reconcile.Result{}, cephObjectStore, errors.Wrap(err, "failed to populate cluster info")
 	}
@@ -238,13 +242,15 @@ func (r *ReconcileCephObjectStore) reconcile(request reconcile.Request) (reconci
 	if !cephObjectStore.GetDeletionTimestamp().IsZero() {
 		updateStatus(r.client, request.NamespacedName, cephv1.ConditionDeleting, buildStatusInfo(cephObjectStore))
 
-		// Detect running Ceph version
-		runningCephVersion, err := cephclient.LeastUptodateDaemonVersion(r.context, r.clusterInfo, config.MonType)
-		if err != nil {
-			return reconcile.Result{}, cephObjectStore, errors.Wrapf(err, "failed to retrieve current ceph %q version", config.MonType)
+		// Detect running Ceph version, there is no mon to ask for an object-only external cluster
+		if !cephCluster.Spec.External.ObjectOnly {
+			runningCephVersion, err := cephclient.LeastUptodateDaemonVersion(r.context, r.clusterInfo, config.MonType)
+			if err != nil {
+				return reconcile.Result{}, cephObjectStore, errors.Wrapf(err, "failed to retrieve current ceph %q version", config.MonType)
+			}
+			r.clusterInfo.CephVersion = runningCephVersion
 		}
-		r.clusterInfo.CephVersion = runningCephVersion
-		r.clusterInfo.Context = r.opManagerContext
+		r.clusterInfo.Context = auditCtx
 
 		// get the latest version of the object to check dependencies
 		err = r.client.Get(r.opManagerContext, request.NamespacedName, cephObjectStore)
@@ -290,7 +296,13 @@ func (r *ReconcileCephObjectStore) reconcile(request reconcile.Request) (reconci
 		return reconcile.Result{}, cephObjectStore, nil
 	}
 
-	if cephObjectStore.Spec.IsExternal() {
+	if cephCluster.Spec.External.ObjectOnly && !cephObjectStore.Spec.IsExternal() {
+		return reconcile.Result{}, cephObjectStore, errors.New("only external object stores can be created in an object-only external cluster, externalRgwEndpoints must be set")
+	}
+
+	if cephCluster.Spec.External.ObjectOnly {
+		logger.Debugf("skipping ceph version detection of object-only external cluster %q", cephCluster.Name)
+	} else if cephObjectStore.Spec.IsExternal() {
 		// Check the ceph version of the running monitors
 		desiredCephVersion, err := cephclient.LeastUptodateDaemonVersion(r.context, r.clusterInfo, config.MonType)
 		if err != nil {
@@ -0,0 +1,285 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cosi migrates the ObjectBucketClaims to COSI BucketClaims
+package cosi
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/object/bucket"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	packageName    = "ceph-cosi-migration"
+	controllerName = packageName + "-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", packageName)
+
+var waitForRequeueIfObjectBucketNotReady = reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}
+
+// ReconcileMigration migrates the annotated ObjectBucketClaims to COSI BucketClaims
+type ReconcileMigration struct {
+	client           client.Client
+	context          *clusterd.Context
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+}
+
+// Add creates a new ObjectBucketClaim migration controller and adds it to the Manager. The Manager
+// will set fields on the Controller and start it when the Manager is started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	return add(mgr, &ReconcileMigration{
+		client:           mgr.GetClient(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor(controllerName),
+	})
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileLogFields(controllerName, r)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for the ObjectBucketClaims to migrate
+	return c.Watch(&source.Kind{Type: &bktv1alpha1.ObjectBucketClaim{}}, &handler.EnqueueRequestForObject{}, predicateMigration())
+}
+
+// predicateMigration triggers the migration of the OBCs annotated to be migrated, when they are
+// annotated or bound. The failed migrations are retried with the backoff of the controller.
+func predicateMigration() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return migrationRequested(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !migrationRequested(e.ObjectNew) {
+				return false
+			}
+			if e.ObjectOld.GetAnnotations()[MigrateAnnotation] != e.ObjectNew.GetAnnotations()[MigrateAnnotation] {
+				return true
+			}
+			oldOBC, okOld := e.ObjectOld.(*bktv1alpha1.ObjectBucketClaim)
+			newOBC, okNew := e.ObjectNew.(*bktv1alpha1.ObjectBucketClaim)
+			return okOld && okNew && oldOBC.Status.Phase != newOBC.Status.Phase
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// migrationRequested returns whether an OBC is annotated to be migrated and not migrated yet
+func migrationRequested(obj client.Object) bool {
+	annotations := obj.GetAnnotations()
+	return annotations[MigrateAnnotation] != "" && annotations[MigrationStatusAnnotation] != MigrationSucceeded
+}
+
+// Reconcile migrates an ObjectBucketClaim to a COSI BucketClaim
+func (r *ReconcileMigration) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		logger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileMigration) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	obc := &bktv1alpha1.ObjectBucketClaim{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, obc)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debugf("ObjectBucketClaim %q resource not found. Ignoring since resource must be deleted.", request.NamespacedName)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "failed to retrieve ObjectBucketClaim %q", request.NamespacedName)
+	}
+	if !obc.GetDeletionTimestamp().IsZero() || !migrationRequested(obc) {
+		return reconcile.Result{}, nil
+	}
+
+	// the bucket of the OBC must be provisioned
+	if obc.Status.Phase != bktv1alpha1.ObjectBucketClaimStatusPhaseBound || obc.Spec.ObjectBucketName == "" {
+		if err := r.setStatus(obc, MigrationPending, "waiting for the ObjectBucketClaim to be bound"); err != nil {
+			return reconcile.Result{}, err
+		}
+		return waitForRequeueIfObjectBucketNotReady, nil
+	}
+
+	bucketName, err := r.migrate(obc)
+	if err != nil {
+		if statusErr := r.setStatus(obc, MigrationFailed, err.Error()); statusErr != nil {
+			logger.Errorf("failed to set the migration status of ObjectBucketClaim %q. %v", request.NamespacedName, statusErr)
+		}
+		r.recorder.Eventf(obc, v1.EventTypeWarning, "COSIMigrationFailed", "Failed to migrate to COSI: %v", err)
+		return reconcile.Result{}, errors.Wrapf(err, "failed to migrate ObjectBucketClaim %q to COSI", request.NamespacedName)
+	}
+
+	message := "migrated to the BucketClaim " + obc.Name + " of the Bucket " + bucketName
+	if err := r.setStatus(obc, MigrationSucceeded, message); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.recorder.Event(obc, v1.EventTypeNormal, "COSIMigrated", "Successfully "+message)
+	logger.Infof("ObjectBucketClaim %q %s", request.NamespacedName, message)
+	return reconcile.Result{}, nil
+}
+
+// migrate creates the COSI Bucket of the existing bucket of an OBC, the BucketClaim replacing the
+// OBC and the Secret with the existing credentials of the OBC in the COSI format, then marks the OB
+// so that deleting the OBC keeps its bucket and user. Each step is skipped when it is done already
+// so that a failed migration can be retried.
+func (r *ReconcileMigration) migrate(obc *bktv1alpha1.ObjectBucketClaim) (string, error) {
+	ctx := r.opManagerContext
+	ob := &bktv1alpha1.ObjectBucket{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: obc.Spec.ObjectBucketName}, ob); err != nil {
+		return "", errors.Wrapf(err, "failed to get ObjectBucket %q", obc.Spec.ObjectBucketName)
+	}
+	if ob.Spec.Connection == nil || ob.Spec.Endpoint == nil || ob.Spec.Endpoint.BucketName == "" {
+		return "", errors.Errorf("ObjectBucket %q has no bucket", ob.Name)
+	}
+
+	classObj := &unstructured.Unstructured{}
+	classObj.SetGroupVersionKind(bucketClassKind)
+	className := obc.Annotations[MigrateAnnotation]
+	if err := r.client.Get(ctx, types.NamespacedName{Name: className}, classObj); err != nil {
+		if meta.IsNoMatchError(err) {
+			return "", errors.New("the COSI CRDs are not installed")
+		}
+		return "", errors.Wrapf(err, "failed to get BucketClass %q", className)
+	}
+	class, err := parseBucketClass(classObj)
+	if err != nil {
+		return "", err
+	}
+
+	obcSecret := &v1.Secret{}
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: obc.Namespace, Name: obc.Name}, obcSecret); err != nil {
+		return "", errors.Wrapf(err, "failed to get the secret of the credentials of the ObjectBucketClaim")
+	}
+
+	bucket, err := r.createOrGet(newBucket(obc, ob, class), ob.Spec.Endpoint.BucketName, "spec", "existingBucketID")
+	if err != nil {
+		return "", err
+	}
+	claim, err := r.createOrGet(newBucketClaim(obc, class), bucket.GetName(), "spec", "existingBucketName")
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := newCredentialsSecret(obc, ob, obcSecret, claim)
+	if err != nil {
+		return "", err
+	}
+	if err := r.client.Create(ctx, secret); err != nil && !kerrors.IsAlreadyExists(err) {
+		return "", errors.Wrapf(err, "failed to create the secret %q of the credentials", secret.Name)
+	}
+
+	if err := r.markObjectBucket(ob, bucket.GetName()); err != nil {
+		return "", err
+	}
+	return bucket.GetName(), nil
+}
+
+// createOrGet creates a COSI object, or returns the existing one if it refers to the same bucket
+func (r *ReconcileMigration) createOrGet(obj *unstructured.Unstructured, expected string, fields ...string) (*unstructured.Unstructured, error) {
+	kind := obj.GetKind()
+	err := r.client.Create(r.opManagerContext, obj)
+	if err == nil {
+		logger.Infof("created %s %q", kind, client.ObjectKeyFromObject(obj))
+		return obj, nil
+	}
+	if meta.IsNoMatchError(err) {
+		return nil, errors.New("the COSI CRDs are not installed")
+	}
+	if !kerrors.IsAlreadyExists(err) {
+		return nil, errors.Wrapf(err, "failed to create %s %q", kind, client.ObjectKeyFromObject(obj))
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := r.client.Get(r.opManagerContext, client.ObjectKeyFromObject(obj), existing); err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s %q", kind, client.ObjectKeyFromObject(obj))
+	}
+	value, _, _ := unstructured.NestedString(existing.Object, fields...)
+	if value != expected {
+		return nil, errors.Errorf("%s %q exists already for %s %q instead of %q", kind, client.ObjectKeyFromObject(obj), fields[len(fields)-1], value, expected)
+	}
+	return existing, nil
+}
+
+// markObjectBucket annotates the OB with its COSI Bucket and retains it, so that deleting the
+// migrated OBC keeps the bucket and the user of the credentials migrated to COSI
+func (r *ReconcileMigration) markObjectBucket(ob *bktv1alpha1.ObjectBucket, bucketName string) error {
+	retain := v1.PersistentVolumeReclaimRetain
+	if ob.Annotations[bucket.COSIBucketAnnotation] == bucketName && ob.Spec.ReclaimPolicy != nil && *ob.Spec.ReclaimPolicy == retain {
+		return nil
+	}
+	if ob.Annotations == nil {
+		ob.Annotations = map[string]string{}
+	}
+	ob.Annotations[bucket.COSIBucketAnnotation] = bucketName
+	ob.Spec.ReclaimPolicy = &retain
+	if err := r.client.Update(r.opManagerContext, ob); err != nil {
+		return errors.Wrapf(err, "failed to mark ObjectBucket %q as migrated", ob.Name)
+	}
+	return nil
+}
+
+// setStatus reports the status of the migration of an OBC in its annotations
+func (r *ReconcileMigration) setStatus(obc *bktv1alpha1.ObjectBucketClaim, status, message string) error {
+	annotations := obc.GetAnnotations()
+	if annotations[MigrationStatusAnnotation] == status && annotations[MigrationMessageAnnotation] == message {
+		return nil
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[MigrationStatusAnnotation] = status
+	annotations[MigrationMessageAnnotation] = message
+	obc.SetAnnotations(annotations)
+	if err := r.client.Update(r.opManagerContext, obc); err != nil {
+		return errors.Wrapf(err, "failed to set the migration status of ObjectBucketClaim %q", client.ObjectKeyFromObject(obc))
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/ceph/object/bucket"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const testNamespace = "app"
+
+func newTestReconciler(objects ...runtime.Object) *ReconcileMigration {
+	s := runtime.NewScheme()
+	_ = v1.AddToScheme(s)
+	_ = bktv1alpha1.AddToScheme(s)
+	return &ReconcileMigration{
+		client:           fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build(),
+		opManagerContext: context.TODO(),
+		recorder:         record.NewFakeRecorder(10),
+	}
+}
+
+func newTestOBC(phase bktv1alpha1.ObjectBucketClaimStatusPhase) *bktv1alpha1.ObjectBucketClaim {
+	return &bktv1alpha1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "photos",
+			Namespace:   testNamespace,
+			Annotations: map[string]string{MigrateAnnotation: "ceph-bucket"},
+		},
+		Spec:   bktv1alpha1.ObjectBucketClaimSpec{ObjectBucketName: "obc-app-photos"},
+		Status: bktv1alpha1.ObjectBucketClaimStatus{Phase: phase},
+	}
+}
+
+func newTestOB() *bktv1alpha1.ObjectBucket {
+	reclaimPolicy := v1.PersistentVolumeReclaimDelete
+	return &bktv1alpha1.ObjectBucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "obc-app-photos"},
+		Spec: bktv1alpha1.ObjectBucketSpec{
+			ReclaimPolicy: &reclaimPolicy,
+			Connection: &bktv1alpha1.Connection{
+				Endpoint: &bktv1alpha1.Endpoint{
+					BucketHost: "rook-ceph-rgw-store.rook-ceph.svc",
+					BucketPort: 80,
+					BucketName: "photos-1234",
+					Region:     "us-east-1",
+				},
+				AdditionalState: map[string]string{bucket.CephUser: "obc-app-photos-user"},
+			},
+		},
+	}
+}
+
+func newTestBucketClass() *unstructured.Unstructured {
+	class := &unstructured.Unstructured{Object: map[string]interface{}{
+		"driverName":     "ceph.objectstorage.k8s.io",
+		"deletionPolicy": "Delete",
+		"parameters":     map[string]interface{}{"objectStoreUserSecretName": "cosi-user"},
+	}}
+	class.SetGroupVersionKind(bucketClassKind)
+	class.SetName("ceph-bucket")
+	return class
+}
+
+func newTestOBCSecret() *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "photos", Namespace: testNamespace},
+		Data: map[string][]byte{
+			bktv1alpha1.AwsKeyField:    []byte("access"),
+			bktv1alpha1.AwsSecretField: []byte("secret"),
+		},
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.TODO()
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "photos"}}
+
+	t.Run("obc not bound", func(t *testing.T) {
+		r := newTestReconciler(newTestOBC(bktv1alpha1.ObjectBucketClaimStatusPhasePending))
+		result, err := r.Reconcile(ctx, request)
+		assert.NoError(t, err)
+		assert.True(t, result.Requeue)
+		obc := &bktv1alpha1.ObjectBucketClaim{}
+		assert.NoError(t, r.client.Get(ctx, request.NamespacedName, obc))
+		assert.Equal(t, MigrationPending, obc.Annotations[MigrationStatusAnnotation])
+	})
+
+	t.Run("bucket class not found", func(t *testing.T) {
+		r := newTestReconciler(newTestOBC(bktv1alpha1.ObjectBucketClaimStatusPhaseBound), newTestOB(), newTestOBCSecret())
+		_, err := r.Reconcile(ctx, request)
+		assert.Error(t, err)
+		obc := &bktv1alpha1.ObjectBucketClaim{}
+		assert.NoError(t, r.client.Get(ctx, request.NamespacedName, obc))
+		assert.Equal(t, MigrationFailed, obc.Annotations[MigrationStatusAnnotation])
+		assert.Contains(t, obc.Annotations[MigrationMessageAnnotation], "ceph-bucket")
+	})
+
+	t.Run("migrated", func(t *testing.T) {
+		r := newTestReconciler(newTestOBC(bktv1alpha1.ObjectBucketClaimStatusPhaseBound), newTestOB(), newTestOBCSecret())
+		assert.NoError(t, r.client.Create(ctx, newTestBucketClass()))
+		_, err := r.Reconcile(ctx, request)
+		assert.NoError(t, err)
+
+		obc := &bktv1alpha1.ObjectBucketClaim{}
+		assert.NoError(t, r.client.Get(ctx, request.NamespacedName, obc))
+		assert.Equal(t, MigrationSucceeded, obc.Annotations[MigrationStatusAnnotation])
+
+		// the bucket keeps its name and the settings of the class
+		b := &unstructured.Unstructured{}
+		b.SetGroupVersionKind(bucketKind)
+		assert.NoError(t, r.client.Get(ctx, types.NamespacedName{Name: "obc-app-photos"}, b))
+		bucketID, _, _ := unstructured.NestedString(b.Object, "spec", "existingBucketID")
+		assert.Equal(t, "photos-1234", bucketID)
+		driver, _, _ := unstructured.NestedString(b.Object, "spec", "driverName")
+		assert.Equal(t, "ceph.objectstorage.k8s.io", driver)
+		policy, _, _ := unstructured.NestedString(b.Object, "spec", "deletionPolicy")
+		assert.Equal(t, "Delete", policy)
+
+		claim := &unstructured.Unstructured{}
+		claim.SetGroupVersionKind(bucketClaimKind)
+		assert.NoError(t, r.client.Get(ctx, request.NamespacedName, claim))
+		existing, _, _ := unstructured.NestedString(claim.Object, "spec", "existingBucketName")
+		assert.Equal(t, "obc-app-photos", existing)
+
+		// the credentials of the OBC are kept
+		secret := &v1.Secret{}
+		assert.NoError(t, r.client.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: "photos-cosi"}, secret))
+		info := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(secret.Data[bucketInfoKey], &info))
+		s3, _, _ := unstructured.NestedMap(info, "spec", "secretS3")
+		assert.Equal(t, "access", s3["accessKeyID"])
+		assert.Equal(t, "secret", s3["accessSecretKey"])
+		assert.Equal(t, "http://rook-ceph-rgw-store.rook-ceph.svc:80", s3["endpoint"])
+		assert.Equal(t, "BucketClaim", secret.OwnerReferences[0].Kind)
+
+		// the bucket and the user are kept when the OBC is deleted
+		ob := &bktv1alpha1.ObjectBucket{}
+		assert.NoError(t, r.client.Get(ctx, types.NamespacedName{Name: "obc-app-photos"}, ob))
+		assert.Equal(t, "obc-app-photos", ob.Annotations[bucket.COSIBucketAnnotation])
+		assert.Equal(t, v1.PersistentVolumeReclaimRetain, *ob.Spec.ReclaimPolicy)
+
+		// a migration retried after a failure reuses the objects created
+		obc.Annotations[MigrationStatusAnnotation] = MigrationFailed
+		assert.NoError(t, r.client.Update(ctx, obc))
+		_, err = r.Reconcile(ctx, request)
+		assert.NoError(t, err)
+
+		// a COSI object of another bucket is not taken over
+		other := newTestOB()
+		other.Spec.Endpoint.BucketName = "other"
+		_, err = r.createOrGet(newBucket(obc, other, bucketClass{name: "ceph-bucket", driverName: "d"}), "other", "spec", "existingBucketID")
+		assert.Error(t, err)
+	})
+}
+
+func TestPredicateMigration(t *testing.T) {
+	p := predicateMigration()
+	obc := newTestOBC(bktv1alpha1.ObjectBucketClaimStatusPhasePending)
+	assert.True(t, p.Create(event.CreateEvent{Object: obc}))
+	assert.False(t, p.Create(event.CreateEvent{Object: &bktv1alpha1.ObjectBucketClaim{}}))
+
+	// the updates of the status of the migration are ignored
+	updated := obc.DeepCopy()
+	updated.Annotations[MigrationStatusAnnotation] = MigrationPending
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: obc, ObjectNew: updated}))
+
+	// the OBC is migrated when it is bound
+	bound := updated.DeepCopy()
+	bound.Status.Phase = bktv1alpha1.ObjectBucketClaimStatusPhaseBound
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: updated, ObjectNew: bound}))
+
+	// a migrated OBC is not reconciled again
+	migrated := bound.DeepCopy()
+	migrated.Annotations[MigrationStatusAnnotation] = MigrationSucceeded
+	migrated.Annotations[MigrateAnnotation] = "other"
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: bound, ObjectNew: migrated}))
+}
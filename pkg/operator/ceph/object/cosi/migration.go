@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// MigrateAnnotation is the annotation of the ObjectBucketClaims to migrate to COSI, with the
+	// name of the BucketClass of the BucketClaim replacing the OBC
+	MigrateAnnotation = "ceph.rook.io/migrate-to-cosi"
+	// MigrationStatusAnnotation is the annotation with the status of the migration of an OBC
+	MigrationStatusAnnotation = "ceph.rook.io/cosi-migration-status"
+	// MigrationMessageAnnotation is the annotation with the details of the status of the migration
+	MigrationMessageAnnotation = "ceph.rook.io/cosi-migration-message"
+
+	// MigrationPending is the status of an OBC waiting to be migrated
+	MigrationPending = "Pending"
+	// MigrationFailed is the status of an OBC whose migration failed, it is retried
+	MigrationFailed = "Failed"
+	// MigrationSucceeded is the status of an OBC whose bucket was migrated to COSI
+	MigrationSucceeded = "Migrated"
+
+	// the key of the COSI BucketInfo in the Secret of the credentials of a BucketAccess
+	bucketInfoKey = "BucketInfo"
+	// the suffix of the name of the Secret of the credentials of a migrated OBC
+	credentialsSecretSuffix = "-cosi"
+)
+
+var (
+	cosiGroupVersion = schema.GroupVersion{Group: "objectstorage.k8s.io", Version: "v1alpha1"}
+	bucketClassKind  = cosiGroupVersion.WithKind("BucketClass")
+	bucketKind       = cosiGroupVersion.WithKind("Bucket")
+	bucketClaimKind  = cosiGroupVersion.WithKind("BucketClaim")
+)
+
+// bucketClass is the settings of a COSI BucketClass used by the migration
+type bucketClass struct {
+	name           string
+	driverName     string
+	deletionPolicy string
+	parameters     map[string]interface{}
+}
+
+// parseBucketClass returns the settings of a BucketClass
+func parseBucketClass(u *unstructured.Unstructured) (bucketClass, error) {
+	class := bucketClass{name: u.GetName()}
+	class.driverName, _, _ = unstructured.NestedString(u.Object, "driverName")
+	if class.driverName == "" {
+		return class, errors.Errorf("BucketClass %q has no driverName", u.GetName())
+	}
+	class.deletionPolicy, _, _ = unstructured.NestedString(u.Object, "deletionPolicy")
+	if class.deletionPolicy == "" {
+		// the default of COSI
+		class.deletionPolicy = "Retain"
+	}
+	class.parameters, _, _ = unstructured.NestedMap(u.Object, "parameters")
+	return class, nil
+}
+
+// bucketName returns the name of the cluster-wide COSI Bucket of the bucket of an OBC
+func bucketName(obc *bktv1alpha1.ObjectBucketClaim) string {
+	return fmt.Sprintf("obc-%s-%s", obc.Namespace, obc.Name)
+}
+
+// newBucket returns the COSI Bucket of the existing bucket of an OB, the bucket name being the
+// bucket ID of the ceph COSI driver
+func newBucket(obc *bktv1alpha1.ObjectBucketClaim, ob *bktv1alpha1.ObjectBucket, class bucketClass) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"driverName":       class.driverName,
+		"bucketClassName":  class.name,
+		"deletionPolicy":   class.deletionPolicy,
+		"protocols":        []interface{}{"S3"},
+		"existingBucketID": ob.Spec.Endpoint.BucketName,
+	}
+	if len(class.parameters) > 0 {
+		spec["parameters"] = class.parameters
+	}
+	bucket := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	bucket.SetGroupVersionKind(bucketKind)
+	bucket.SetName(bucketName(obc))
+	bucket.SetAnnotations(map[string]string{MigrateAnnotation: obc.Namespace + "/" + obc.Name})
+	return bucket
+}
+
+// newBucketClaim returns the COSI BucketClaim replacing an OBC, with the same name in its namespace
+func newBucketClaim(obc *bktv1alpha1.ObjectBucketClaim, class bucketClass) *unstructured.Unstructured {
+	claim := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"bucketClassName":    class.name,
+			"protocols":          []interface{}{"S3"},
+			"existingBucketName": bucketName(obc),
+		},
+	}}
+	claim.SetGroupVersionKind(bucketClaimKind)
+	claim.SetName(obc.Name)
+	claim.SetNamespace(obc.Namespace)
+	claim.SetAnnotations(map[string]string{MigrateAnnotation: obc.Name})
+	return claim
+}
+
+// bucketEndpoint returns the s3 endpoint of the bucket of an OB
+func bucketEndpoint(ob *bktv1alpha1.ObjectBucket) string {
+	scheme := "http"
+	if ob.Spec.Endpoint.BucketPort == 443 {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, ob.Spec.Endpoint.BucketHost, ob.Spec.Endpoint.BucketPort)
+}
+
+// newCredentialsSecret returns the Secret with the existing credentials of an OBC in the BucketInfo
+// format of the Secrets of the COSI BucketAccesses, owned by the BucketClaim replacing the OBC
+func newCredentialsSecret(obc *bktv1alpha1.ObjectBucketClaim, ob *bktv1alpha1.ObjectBucket, obcSecret *v1.Secret, claim *unstructured.Unstructured) (*v1.Secret, error) {
+	accessKey := string(obcSecret.Data[bktv1alpha1.AwsKeyField])
+	secretKey := string(obcSecret.Data[bktv1alpha1.AwsSecretField])
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.Errorf("secret %q of the OBC has no s3 credentials", obcSecret.Name)
+	}
+	info := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": bucketName(obc)},
+		"spec": map[string]interface{}{
+			"bucketName":         ob.Spec.Endpoint.BucketName,
+			"authenticationType": "KEY",
+			"protocols":          []string{"s3"},
+			"secretS3": map[string]interface{}{
+				"endpoint":        bucketEndpoint(ob),
+				"region":          ob.Spec.Endpoint.Region,
+				"accessKeyID":     accessKey,
+				"accessSecretKey": secretKey,
+			},
+		},
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the bucket info")
+	}
+
+	blockOwnerDeletion := true
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obc.Name + credentialsSecretSuffix,
+			Namespace: obc.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion:         claim.GetAPIVersion(),
+				Kind:               claim.GetKind(),
+				Name:               claim.GetName(),
+				UID:                claim.GetUID(),
+				BlockOwnerDeletion: &blockOwnerDeletion,
+			}},
+		},
+		Data: map[string][]byte{bucketInfoKey: data},
+	}, nil
+}
@@ -19,12 +19,15 @@ package object
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ceph/go-ceph/rgw/admin"
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -40,6 +43,8 @@ const (
 
 var (
 	defaultHealthCheckInterval = 1 * time.Minute
+	// the time an external rgw endpoint has to answer the health check
+	externalEndpointCheckTimeout = 5 * time.Second
 )
 
 // bucketChecker aggregates the mon/cluster info needed to check the health of the monitors
@@ -51,6 +56,7 @@ type bucketChecker struct {
 	client          client.Client
 	namespacedName  types.NamespacedName
 	objectStoreSpec *cephv1.ObjectStoreSpec
+	usage           *usageMetrics
 }
 
 // newbucketChecker creates a new HealthChecker object
@@ -75,6 +81,7 @@ func newBucketChecker(
 		namespacedName:  namespacedName,
 		client:          client,
 		objectStoreSpec: objectStoreSpec,
+		usage:           newUsageMetrics(namespacedName),
 	}
 
 	// allow overriding the check interval
@@ -95,6 +102,8 @@ func (c *bucketChecker) checkObjectStore(context context.Context) {
 		updateStatusBucket(c.client, c.namespacedName, cephv1.ConditionFailure, err.Error())
 		logger.Debugf("failed to check rgw health for object store %q. %v", c.namespacedName.Name, err)
 	}
+	c.checkSyncStatus()
+	c.checkUsage()
 
 	for {
 		select {
@@ -102,6 +111,7 @@ func (c *bucketChecker) checkObjectStore(context context.Context) {
 			// purge bucket and s3 user
 			// Needed for external mode where in converged everything goes away with the CR deletion
 			c.cleanupHealthCheck()
+			c.usage.clear()
 			logger.Infof("stopping monitoring of rgw endpoints for object store %q", c.namespacedName.Name)
 			return
 
@@ -112,10 +122,67 @@ func (c *bucketChecker) checkObjectStore(context context.Context) {
 				updateStatusBucket(c.client, c.namespacedName, cephv1.ConditionFailure, err.Error())
 				logger.Debugf("failed to check rgw health for object store %q. %v", c.namespacedName.Name, err)
 			}
+			c.checkSyncStatus()
+			c.checkUsage()
 		}
 	}
 }
 
+// checkUsage exports the usage of the users and the buckets of the object store if enabled. The spec
+// is read from the CephObjectStore so that the usage metrics can be enabled without restarting the
+// health checker.
+func (c *bucketChecker) checkUsage() {
+	objectStore := &cephv1.CephObjectStore{}
+	if err := c.client.Get(c.objContext.clusterInfo.Context, c.namespacedName, objectStore); err != nil {
+		logger.Debugf("failed to get object store %q to collect its usage. %v", c.namespacedName.String(), err)
+		return
+	}
+	spec := objectStore.Spec.Monitoring
+	if spec == nil || !spec.UsageMetrics {
+		c.usage.clear()
+		return
+	}
+	if err := c.usage.collect(c.objContext.clusterInfo.Context, c.objContext.AdminOpsClient, c.client, spec); err != nil {
+		logger.Errorf("failed to collect the usage of object store %q. %v", c.namespacedName.String(), err)
+	}
+}
+
+// checkSyncStatus reports the multisite sync status of the zone of the object store with the DRReady
+// and ReplicationDegraded conditions
+func (c *bucketChecker) checkSyncStatus() {
+	if !c.objectStoreSpec.IsMultisite() {
+		updateStatusReplication(c.client, c.namespacedName, nil)
+		return
+	}
+
+	var issues []opcontroller.ReplicationIssue
+	output, err := runAdminCommand(&c.objContext.Context, false, "sync", "status")
+	if err != nil {
+		logger.Debugf("failed to get the sync status of object store %q. %v", c.namespacedName.Name, err)
+		issues = []opcontroller.ReplicationIssue{{Reason: cephv1.ReplicationStatusUnknownReason, Message: fmt.Sprintf("failed to get the sync status. %v", err)}}
+	} else {
+		issues = syncStatusIssues(output)
+	}
+	updateStatusReplication(c.client, c.namespacedName, issues)
+}
+
+// syncStatusIssues returns the replication issues found in the output of "radosgw-admin sync status".
+// The command has no json output, the shards behind and the sources that could not be reached are
+// reported on their own lines.
+func syncStatusIssues(output string) []opcontroller.ReplicationIssue {
+	issues := []opcontroller.ReplicationIssue{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.Contains(line, "is behind on"):
+			issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationSyncBehindReason, Message: line})
+		case strings.HasPrefix(line, "failed to") || strings.HasPrefix(line, "ERROR:"):
+			issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.PeerConnectionFailedReason, Message: line})
+		}
+	}
+	return issues
+}
+
 func (c *bucketChecker) checkObjectStoreHealth() error {
 	/*
 		0. purge the s3 object by default
@@ -181,12 +248,53 @@ func (c *bucketChecker) checkObjectStoreHealth() error {
 
 	logger.Debugf("successfully checked object store endpoint for object store %q", c.namespacedName.String())
 
+	// The bucket check goes through the service which balances the requests between the external
+	// endpoints, each of them must be checked to find the unreachable ones
+	if c.objectStoreSpec.IsExternal() {
+		err = c.checkExternalEndpoints()
+		if err != nil {
+			return errors.Wrapf(err, "failed to check external rgw endpoints of object store %q", c.namespacedName.String())
+		}
+	}
+
 	// Update the EndpointStatus in the CR to reflect the healthyness
 	updateStatusBucket(c.client, c.namespacedName, cephv1.ConditionConnected, "")
 
 	return nil
 }
 
+// checkExternalEndpoints checks that each external rgw endpoint answers http requests. Any answer is
+// fine, the s3 requests themselves are checked through the service.
+func (c *bucketChecker) checkExternalEndpoints() error {
+	// The endpoints are reached by address, the certificate of the gateways may not be valid for it.
+	// The identity of the gateways does not matter since no credentials are sent.
+	httpClient := &http.Client{
+		Timeout:   externalEndpointCheckTimeout,
+		Transport: BuildTransportTLS(c.objContext.TlsCert, true),
+	}
+
+	unreachable := []string{}
+	for _, address := range c.objectStoreSpec.Gateway.ExternalRgwEndpoints {
+		host := address.IP
+		if host == "" {
+			host = address.Hostname
+		}
+		endpoint := BuildDNSEndpoint(host, c.port, c.objectStoreSpec.IsTLSEnabled())
+		resp, err := httpClient.Get(endpoint)
+		if err != nil {
+			logger.Debugf("external rgw endpoint %q of object store %q is unreachable. %v", endpoint, c.namespacedName.String(), err)
+			unreachable = append(unreachable, endpoint)
+			continue
+		}
+		resp.Body.Close()
+	}
+
+	if len(unreachable) > 0 {
+		return errors.Errorf("external rgw endpoints %s are unreachable", strings.Join(unreachable, ", "))
+	}
+	return nil
+}
+
 func cleanupObjectHealthCheck(s3client *S3Agent, objectStoreUID string) {
 	bucketToDelete := genHealthCheckerBucketName(objectStoreUID)
 	logger.Debugf("deleting object %q from bucket %q", s3HealthCheckObjectKey, bucketToDelete)
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCheckExternalEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	host, rawPort, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(rawPort)
+	assert.NoError(t, err)
+
+	spec := &cephv1.ObjectStoreSpec{
+		Gateway: cephv1.GatewaySpec{
+			Port:                 int32(port),
+			ExternalRgwEndpoints: []v1.EndpointAddress{{IP: host}},
+		},
+	}
+	c := &bucketChecker{
+		objContext:      &AdminOpsContext{},
+		port:            int32(port),
+		namespacedName:  types.NamespacedName{Namespace: "rook-ceph", Name: "store"},
+		objectStoreSpec: spec,
+	}
+
+	t.Run("all endpoints reachable", func(t *testing.T) {
+		assert.NoError(t, c.checkExternalEndpoints())
+	})
+
+	t.Run("an endpoint is unreachable", func(t *testing.T) {
+		// the server only listens on the first loopback address
+		spec.Gateway.ExternalRgwEndpoints = append(spec.Gateway.ExternalRgwEndpoints, v1.EndpointAddress{IP: "127.0.0.2"})
+		err := c.checkExternalEndpoints()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "http://127.0.0.2:"+rawPort)
+		assert.NotContains(t, err.Error(), "http://"+host)
+	})
+}
+
+func TestSyncStatusIssues(t *testing.T) {
+	caughtUp := `          realm 4c1ac4ec-2cc0-44bd-9b4f-4b4dcb2c8a1d (my-realm)
+      zonegroup 3c5e4e6c-2e44-4fb3-b3a6-0ec7e1c0a4a1 (my-zonegroup)
+           zone 8cc3a4f2-3a2e-4f3c-9d0e-1b4cf2b0b6a2 (zone-b)
+  metadata sync syncing
+                full sync: 0/64 shards
+                incremental sync: 64/64 shards
+                metadata is caught up with master
+      data sync source: 1b4cf2b0-3a2e-4f3c-9d0e-8cc3a4f2b6a2 (zone-a)
+                        syncing
+                        full sync: 0/128 shards
+                        incremental sync: 128/128 shards
+                        data is caught up with source`
+	assert.Empty(t, syncStatusIssues(caughtUp))
+
+	behind := `  metadata sync syncing
+                metadata is behind on 1 shards
+      data sync source: 1b4cf2b0-3a2e-4f3c-9d0e-8cc3a4f2b6a2 (zone-a)
+                        failed to retrieve sync info: (5) Input/output error`
+	issues := syncStatusIssues(behind)
+	assert.Len(t, issues, 2)
+	assert.Equal(t, cephv1.ReplicationSyncBehindReason, issues[0].Reason)
+	assert.Equal(t, "metadata is behind on 1 shards", issues[0].Message)
+	assert.Equal(t, cephv1.PeerConnectionFailedReason, issues[1].Reason)
+}
@@ -88,7 +88,8 @@ func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext contex
 
 func addNotificationReconciler(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephBucketNotification{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephBucketNotification{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -112,7 +113,7 @@ func (r *ReconcileNotifications) Reconcile(context context.Context, request reco
 		logger.Errorf("failed to reconcile %v", err)
 	}
 
-	return reporting.ReportReconcileResult(logger, r.recorder, notification, reconcileResponse, err)
+	return reporting.ReportReconcileResult(logger, r.recorder, request, notification, reconcileResponse, err)
 }
 
 func (r *ReconcileNotifications) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephBucketNotification, error) {
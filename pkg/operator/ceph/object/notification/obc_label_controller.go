@@ -57,7 +57,7 @@ type ReconcileOBCLabels struct {
 
 func addOBCLabelReconciler(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileLogFields(controllerName, r)})
 	if err != nil {
 		return err
 	}
@@ -182,7 +182,8 @@ func (r *ReconcileOBCLabels) reconcile(request reconcile.Request) (reconcile.Res
 	// add new notifications to the list
 	for _, label := range labelList {
 		reconcileResponse, notification, err := r.addNewNotification(p, ob, label, objectStoreName, obc.Namespace)
-		_, _ = reporting.ReportReconcileResult(logger, r.recorder, notification, reconcileResponse, err)
+		notificationRequest := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: obc.Namespace, Name: label}}
+		_, _ = reporting.ReportReconcileResult(logger, r.recorder, notificationRequest, notification, reconcileResponse, err)
 		if err != nil {
 			return reconcileResponse, err
 		}
@@ -30,6 +30,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util"
@@ -953,3 +954,17 @@ func errorOrIsNotFound(err error, msg string, args ...string) error {
 	}
 	return errors.Wrapf(err, msg, args)
 }
+
+// LoadClusterInfo loads the info of the cluster the object stores of a namespace belong to. There is
+// no mon to connect to for an object-only external cluster, the object stores only call the admin ops
+// API of its gateways.
+func LoadClusterInfo(context *clusterd.Context, ctx context.Context, cephCluster *cephv1.CephCluster) (*cephclient.ClusterInfo, error) {
+	if cephCluster.Spec.External.ObjectOnly {
+		clusterInfo := cephclient.NewClusterInfo(cephCluster.Namespace, cephCluster.Name)
+		clusterInfo.Context = ctx
+		return clusterInfo, nil
+	}
+
+	clusterInfo, _, _, err := mon.LoadClusterInfo(context, ctx, cephCluster.Namespace)
+	return clusterInfo, err
+}
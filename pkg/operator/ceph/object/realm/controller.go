@@ -49,6 +49,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -77,6 +78,7 @@ type ReconcileObjectRealm struct {
 	context          *clusterd.Context
 	clusterInfo      *cephclient.ClusterInfo
 	opManagerContext context.Context
+	recorder         record.EventRecorder
 }
 
 // Add creates a new CephObjectRealm Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -92,12 +94,14 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 		scheme:           mgr.GetScheme(),
 		context:          context,
 		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephObjectRealm{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephObjectRealm{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -118,25 +122,23 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileObjectRealm) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
-	if err != nil {
-		logger.Errorf("failed to reconcile CephObjectRealm %q. %v", request.NamespacedName.String(), err)
-	}
+	reconcileResponse, cephObjectRealm, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephObjectRealm, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileObjectRealm) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileObjectRealm) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephObjectRealm, error) {
 	// Fetch the CephObjectRealm instance
 	cephObjectRealm := &cephv1.CephObjectRealm{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephObjectRealm)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("CephObjectRealm %q resource not found. Ignoring since object must be deleted", request.NamespacedName.String())
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephObjectRealm, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get CephObjectRealm")
+		return reconcile.Result{}, cephObjectRealm, errors.Wrap(err, "failed to get CephObjectRealm")
 	}
 
 	// The CR was just created, initializing status fields
@@ -150,9 +152,9 @@ func (r *ReconcileObjectRealm) reconcile(request reconcile.Request) (reconcile.R
 		// This handles the case where the Ceph Cluster is gone and we want to delete that CR
 		if !cephObjectRealm.GetDeletionTimestamp().IsZero() && !cephClusterExists {
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephObjectRealm, nil
 		}
-		return reconcileResponse, nil
+		return reconcileResponse, cephObjectRealm, nil
 	}
 
 	// DELETE: the CR was deleted
@@ -160,20 +162,20 @@ func (r *ReconcileObjectRealm) reconcile(request reconcile.Request) (reconcile.R
 		logger.Debugf("deleting realm CR %q", cephObjectRealm.Name)
 
 		// Return and do not requeue. Successful deletion.
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, cephObjectRealm, nil
 	}
 
 	// Populate clusterInfo during each reconcile
-	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephObjectRealm", request.Namespace, request.Name), request.NamespacedName.Namespace)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+		return reconcile.Result{}, cephObjectRealm, errors.Wrap(err, "failed to populate cluster info")
 	}
 
 	// validate the realm settings
 	err = validateRealmCR(cephObjectRealm)
 	if err != nil {
 		r.updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
-		return reconcile.Result{}, errors.Wrapf(err, "invalid CephObjectRealm CR %q", cephObjectRealm.Name)
+		return reconcile.Result{}, cephObjectRealm, errors.Wrapf(err, "invalid CephObjectRealm CR %q", cephObjectRealm.Name)
 	}
 
 	// Start object reconciliation, updating status for this
@@ -184,17 +186,17 @@ func (r *ReconcileObjectRealm) reconcile(request reconcile.Request) (reconcile.R
 		logger.Debug("pull section in realm %q spec found", request.NamespacedName)
 		_, err = r.pullCephRealm(cephObjectRealm)
 		if err != nil {
-			return reconcile.Result{}, err
+			return reconcile.Result{}, cephObjectRealm, err
 		}
 	} else {
 		_, err = r.createRealmKeys(cephObjectRealm)
 		if err != nil {
-			return r.setFailedStatus(request.NamespacedName, "failed to create keys for realm", err)
+			return reconcile.Result{}, cephObjectRealm, r.setFailedStatus(request.NamespacedName, "failed to create keys for realm", err)
 		}
 
 		_, err = r.createCephRealm(cephObjectRealm)
 		if err != nil {
-			return r.setFailedStatus(request.NamespacedName, "failed to create ceph realm", err)
+			return reconcile.Result{}, cephObjectRealm, r.setFailedStatus(request.NamespacedName, "failed to create ceph realm", err)
 		}
 	}
 
@@ -203,7 +205,7 @@ func (r *ReconcileObjectRealm) reconcile(request reconcile.Request) (reconcile.R
 
 	// Return and do not requeue
 	logger.Debug("realm %q done reconciling", request.NamespacedName)
-	return reconcile.Result{}, nil
+	return reconcile.Result{}, cephObjectRealm, nil
 }
 
 func (r *ReconcileObjectRealm) pullCephRealm(realm *cephv1.CephObjectRealm) (reconcile.Result, error) {
@@ -321,9 +323,9 @@ func validateRealmCR(u *cephv1.CephObjectRealm) error {
 	return nil
 }
 
-func (r *ReconcileObjectRealm) setFailedStatus(name types.NamespacedName, errMessage string, err error) (reconcile.Result, error) {
+func (r *ReconcileObjectRealm) setFailedStatus(name types.NamespacedName, errMessage string, err error) error {
 	r.updateStatus(r.client, name, k8sutil.ReconcileFailedStatus)
-	return reconcile.Result{}, errors.Wrapf(err, "%s", errMessage)
+	return errors.Wrapf(err, "%s", errMessage)
 }
 
 // updateStatus updates an realm with a given status
@@ -337,12 +339,14 @@ func (r *ReconcileObjectRealm) updateStatus(client client.Client, name types.Nam
 		logger.Warningf("failed to retrieve object realm %q to update status to %q. %v", name, status, err)
 		return
 	}
-	if objectRealm.Status == nil {
-		objectRealm.Status = &cephv1.Status{}
-	}
-
-	objectRealm.Status.Phase = status
-	if err := reporting.UpdateStatus(client, objectRealm); err != nil {
+	err := reporting.PatchStatus(client, objectRealm, func() bool {
+		if objectRealm.Status == nil {
+			objectRealm.Status = &cephv1.Status{}
+		}
+		objectRealm.Status.Phase = status
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set object realm %q status to %q. %v", name, status, err)
 		return
 	}
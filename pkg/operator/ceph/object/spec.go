@@ -166,7 +166,17 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) (v1.PodTemplateSpec
 		return v1.PodTemplateSpec{}, err
 	}
 	if kmsEnabled {
-		if c.store.Spec.Security.KeyManagementService.IsTokenAuthEnabled() {
+		if c.store.Spec.Security.KeyManagementService.IsKMIPKMS() {
+			podSpec.Volumes = append(podSpec.Volumes, c.kmipVolume())
+		} else if c.useVaultAgent() {
+			kmsSpec := &c.store.Spec.Security.KeyManagementService
+			// The volume has no source with the kubernetes auth without TLS
+			if kmsSpec.IsAppRoleAuthEnabled() || kmsSpec.IsTLSEnabled() {
+				vaultVol, _ := kms.VaultVolumeAndMount(kmsSpec.ConnectionDetails, kmsSpec.TokenSecretName)
+				podSpec.Volumes = append(podSpec.Volumes, vaultVol)
+			}
+			podSpec.Containers = append(podSpec.Containers, c.vaultAgentContainer())
+		} else if c.store.Spec.Security.KeyManagementService.IsTokenAuthEnabled() {
 			vaultFileVol, _ := kms.VaultVolumeAndMount(c.store.Spec.Security.KeyManagementService.ConnectionDetails,
 				c.store.Spec.Security.KeyManagementService.TokenSecretName)
 			tmpvolume := v1.Volume{
@@ -181,6 +191,10 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) (v1.PodTemplateSpec
 				c.vaultTokenInitContainer(rgwConfig))
 		}
 	}
+	if c.clusterSpec.Security.IsRestricted() {
+		controller.ApplyRestrictedPodSecurity(&podSpec)
+	}
+
 	c.store.Spec.Gateway.Placement.ApplyToPodSpec(&podSpec)
 
 	// If host networking is not enabled, preferred pod anti-affinity is added to the rgw daemons
@@ -196,6 +210,7 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) (v1.PodTemplateSpec
 	}
 	c.store.Spec.Gateway.Annotations.ApplyToObjectMeta(&podTemplateSpec.ObjectMeta)
 	c.store.Spec.Gateway.Labels.ApplyToObjectMeta(&podTemplateSpec.ObjectMeta)
+	controller.ApplySecurityProfile(c.clusterSpec.Security.Profiles, cephv1.KeyRgw, &podTemplateSpec.ObjectMeta, &podTemplateSpec.Spec)
 
 	if c.clusterSpec.Network.IsHost() {
 		podTemplateSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
@@ -254,6 +269,163 @@ func (c *clusterConfig) vaultTokenInitContainer(rgwConfig *rgwConfig) v1.Contain
 	}
 }
 
+// kmipVolume returns the volume of the certificates of the KMIP server from the kms token secret
+func (c *clusterConfig) kmipVolume() v1.Volume {
+	mode := int32(0444)
+	return v1.Volume{
+		Name: rgwKMIPVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: c.store.Spec.Security.KeyManagementService.TokenSecretName,
+				Items: []v1.KeyToPath{
+					{Key: kms.KmipCACertSecretKey, Path: rgwKMIPCAFileName, Mode: &mode},
+					{Key: kms.KmipClientCertSecretKey, Path: rgwKMIPCertFileName, Mode: &mode},
+					{Key: kms.KmipClientKeySecretKey, Path: rgwKMIPKeyFileName, Mode: &mode},
+				},
+			},
+		},
+	}
+}
+
+// kmipArgs returns the rgw flags to fetch the SSE-KMS keys from the KMIP server
+func (c *clusterConfig) kmipArgs() []string {
+	details := c.store.Spec.Security.KeyManagementService.ConnectionDetails
+	args := []string{
+		cephconfig.NewFlag("rgw crypt s3 kms backend", kms.TypeKMIP),
+		cephconfig.NewFlag("rgw crypt kmip addr", details[kms.KmipEndpointKey]),
+		cephconfig.NewFlag("rgw crypt kmip ca path", path.Join(rgwKMIPDirName, rgwKMIPCAFileName)),
+		cephconfig.NewFlag("rgw crypt kmip client cert", path.Join(rgwKMIPDirName, rgwKMIPCertFileName)),
+		cephconfig.NewFlag("rgw crypt kmip client key", path.Join(rgwKMIPDirName, rgwKMIPKeyFileName)),
+	}
+	if template := kms.GetParam(details, kms.KmipKeyTemplateKey); template != "" {
+		args = append(args, cephconfig.NewFlag("rgw crypt kmip kms key template", template))
+	}
+	return args
+}
+
+// useVaultAgent returns whether rgw reaches Vault through a vault agent sidecar, rgw only
+// authenticates to Vault with a token file so the agent logs in with the other auth methods
+func (c *clusterConfig) useVaultAgent() bool {
+	kmsSpec := &c.store.Spec.Security.KeyManagementService
+	return kmsSpec.IsVaultKMS() && (kmsSpec.IsK8sAuthEnabled() || kmsSpec.IsAppRoleAuthEnabled())
+}
+
+// vaultAgentArgs returns the rgw flags to fetch the SSE-KMS keys from Vault through the vault agent
+func (c *clusterConfig) vaultAgentArgs() []string {
+	details := c.store.Spec.Security.KeyManagementService.ConnectionDetails
+	args := []string{
+		cephconfig.NewFlag("rgw crypt s3 kms backend", details[kms.Provider]),
+		cephconfig.NewFlag("rgw crypt vault auth", "agent"),
+		cephconfig.NewFlag("rgw crypt vault addr", "http://"+rgwVaultAgentAddress),
+		cephconfig.NewFlag("rgw crypt vault prefix", c.vaultPrefixRGW()),
+		cephconfig.NewFlag("rgw crypt vault secret engine", details[kms.VaultSecretEngineKey]),
+	}
+	if namespace := kms.GetParam(details, api.EnvVaultNamespace); namespace != "" {
+		args = append(args, cephconfig.NewFlag("rgw crypt vault namespace", namespace))
+	}
+	return args
+}
+
+// vaultAgentConfig returns the config of the vault agent, which logs in Vault with the kubernetes
+// or the AppRole auth and forwards the requests of rgw with its token
+func (c *clusterConfig) vaultAgentConfig() string {
+	details := c.store.Spec.Security.KeyManagementService.ConnectionDetails
+	var b strings.Builder
+	b.WriteString("exit_after_auth = false\n")
+	b.WriteString("vault {\n")
+	fmt.Fprintf(&b, "  address = %q\n", kms.GetParam(details, api.EnvVaultAddress))
+	for _, option := range cephv1.VaultTLSConnectionDetails {
+		if kms.GetParam(details, option) != "" {
+			fmt.Fprintf(&b, "  %s = %q\n", vaultAgentTLSSetting(option), path.Join(kms.EtcVaultDir, vaultTLSFileName(option)))
+		}
+	}
+	if serverName := kms.GetParam(details, api.EnvVaultTLSServerName); serverName != "" {
+		fmt.Fprintf(&b, "  tls_server_name = %q\n", serverName)
+	}
+	if kms.GetParam(details, api.EnvVaultSkipVerify) == "true" {
+		b.WriteString("  tls_skip_verify = true\n")
+	}
+	b.WriteString("}\n")
+
+	method := vault.AuthMethodKubernetes
+	if c.store.Spec.Security.KeyManagementService.IsAppRoleAuthEnabled() {
+		method = cephv1.VaultAuthMethodAppRole
+	}
+	mountPath := kms.GetParam(details, vault.AuthMountPath)
+	if mountPath == "" {
+		mountPath = method
+	}
+	b.WriteString("auto_auth {\n  method {\n")
+	fmt.Fprintf(&b, "    type = %q\n", method)
+	fmt.Fprintf(&b, "    mount_path = %q\n", path.Join("auth", mountPath))
+	if namespace := kms.GetParam(details, api.EnvVaultNamespace); namespace != "" {
+		fmt.Fprintf(&b, "    namespace = %q\n", namespace)
+	}
+	b.WriteString("    config = {\n")
+	if method == cephv1.VaultAuthMethodAppRole {
+		fmt.Fprintf(&b, "      role_id_file_path = %q\n", path.Join(kms.EtcVaultDir, kms.VaultRoleIDFileName))
+		fmt.Fprintf(&b, "      secret_id_file_path = %q\n", path.Join(kms.EtcVaultDir, kms.VaultSecretIDFileName))
+		b.WriteString("      remove_secret_id_file_after_reading = false\n")
+	} else {
+		fmt.Fprintf(&b, "      role = %q\n", kms.GetParam(details, vault.AuthKubernetesRole))
+		if tokenPath := kms.GetParam(details, vault.AuthKubernetesTokenPath); tokenPath != "" {
+			fmt.Fprintf(&b, "      token_path = %q\n", tokenPath)
+		}
+	}
+	b.WriteString("    }\n  }\n}\n")
+	b.WriteString("cache {\n  use_auto_auth_token = \"force\"\n}\n")
+	fmt.Fprintf(&b, "listener \"tcp\" {\n  address = %q\n  tls_disable = true\n}\n", rgwVaultAgentAddress)
+	return b.String()
+}
+
+// vaultAgentContainer returns the vault agent sidecar of rgw
+func (c *clusterConfig) vaultAgentContainer() v1.Container {
+	kmsSpec := &c.store.Spec.Security.KeyManagementService
+	image := kms.GetParam(kmsSpec.ConnectionDetails, VaultAgentImageKey)
+	if image == "" {
+		image = DefaultVaultAgentImage
+	}
+	container := v1.Container{
+		Name:  rgwVaultAgentContainerName,
+		Image: image,
+		Command: []string{
+			"/bin/sh",
+			"-c",
+			`echo "$VAULT_AGENT_CONFIG" > /tmp/vault-agent.hcl && exec vault agent -config=/tmp/vault-agent.hcl`,
+		},
+		Env: []v1.EnvVar{{Name: "VAULT_AGENT_CONFIG", Value: c.vaultAgentConfig()}},
+	}
+	if kmsSpec.IsAppRoleAuthEnabled() || kmsSpec.IsTLSEnabled() {
+		_, vaultMount := kms.VaultVolumeAndMount(kmsSpec.ConnectionDetails, "")
+		container.VolumeMounts = []v1.VolumeMount{vaultMount}
+	}
+	return container
+}
+
+// vaultTLSFileName returns the file name of a Vault TLS connection detail in the vault volume
+func vaultTLSFileName(option string) string {
+	switch option {
+	case api.EnvVaultCACert:
+		return kms.VaultCAFileName
+	case api.EnvVaultClientCert:
+		return kms.VaultCertFileName
+	default:
+		return kms.VaultKeyFileName
+	}
+}
+
+// vaultAgentTLSSetting returns the vault agent setting of a Vault TLS connection detail
+func vaultAgentTLSSetting(option string) string {
+	switch option {
+	case api.EnvVaultCACert:
+		return "ca_cert"
+	case api.EnvVaultClientCert:
+		return "client_cert"
+	default:
+		return "client_key"
+	}
+}
+
 func (c *clusterConfig) makeChownInitContainer(rgwConfig *rgwConfig) v1.Container {
 	return controller.ChownCephDataDirsInitContainer(
 		*c.DataPathMap,
@@ -316,13 +488,22 @@ func (c *clusterConfig) makeDaemonContainer(rgwConfig *rgwConfig) v1.Container {
 		logger.Errorf("failed to enable KMS. %v", err)
 		return v1.Container{}
 	}
-	if kmsEnabled {
+	if kmsEnabled && c.store.Spec.Security.KeyManagementService.IsKMIPKMS() {
+		container.Args = append(container.Args, c.kmipArgs()...)
+		kmipVolMount := v1.VolumeMount{Name: rgwKMIPVolumeName, MountPath: rgwKMIPDirName, ReadOnly: true}
+		container.VolumeMounts = append(container.VolumeMounts, kmipVolMount)
+	} else if kmsEnabled && c.useVaultAgent() {
+		container.Args = append(container.Args, c.vaultAgentArgs()...)
+	} else if kmsEnabled {
 		container.Args = append(container.Args,
 			cephconfig.NewFlag("rgw crypt s3 kms backend",
 				c.store.Spec.Security.KeyManagementService.ConnectionDetails[kms.Provider]),
 			cephconfig.NewFlag("rgw crypt vault addr",
 				c.store.Spec.Security.KeyManagementService.ConnectionDetails[api.EnvVaultAddress]),
 		)
+		if namespace := kms.GetParam(c.store.Spec.Security.KeyManagementService.ConnectionDetails, api.EnvVaultNamespace); namespace != "" {
+			container.Args = append(container.Args, cephconfig.NewFlag("rgw crypt vault namespace", namespace))
+		}
 		if c.store.Spec.Security.KeyManagementService.IsTokenAuthEnabled() {
 			container.Args = append(container.Args,
 				cephconfig.NewFlag("rgw crypt vault auth", kms.KMSTokenSecretNameKey),
@@ -549,6 +730,15 @@ func (c *clusterConfig) CheckRGWKMS() (bool, error) {
 		if err != nil {
 			return false, err
 		}
+
+		// rgw fetches the keys from the kmip server since pacific
+		if c.store.Spec.Security.KeyManagementService.IsKMIPKMS() {
+			if !c.clusterInfo.CephVersion.IsAtLeastPacific() {
+				return false, errors.New("failed to validate kms, kmip requires ceph pacific or newer")
+			}
+			return true, nil
+		}
+
 		secretEngine := c.store.Spec.Security.KeyManagementService.ConnectionDetails[kms.VaultSecretEngineKey]
 
 		// currently RGW supports kv(version 2) and transit secret engines in vault
@@ -568,6 +568,85 @@ func TestCheckRGWKMS(t *testing.T) {
 	})
 }
 
+func TestKMIPRGW(t *testing.T) {
+	store := simpleStore()
+	store.Spec.Security = &cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{
+		ConnectionDetails: map[string]string{"KMS_PROVIDER": "kmip", "KMIP_ENDPOINT": "kmip.example.com:5696"},
+		TokenSecretName:   "kmip-certs",
+	}}
+	c := &clusterConfig{store: store}
+
+	assert.Equal(t, []string{
+		"--rgw-crypt-s3-kms-backend=kmip",
+		"--rgw-crypt-kmip-addr=kmip.example.com:5696",
+		"--rgw-crypt-kmip-ca-path=/etc/ceph/kmip/ca.crt",
+		"--rgw-crypt-kmip-client-cert=/etc/ceph/kmip/client.crt",
+		"--rgw-crypt-kmip-client-key=/etc/ceph/kmip/client.key",
+	}, c.kmipArgs())
+	store.Spec.Security.KeyManagementService.ConnectionDetails["KMIP_KEY_TEMPLATE"] = "rgw-$keyid"
+	assert.Contains(t, c.kmipArgs(), "--rgw-crypt-kmip-kms-key-template=rgw-$keyid")
+
+	vol := c.kmipVolume()
+	assert.Equal(t, "kmip-certs", vol.Secret.SecretName)
+	assert.Len(t, vol.Secret.Items, 3)
+	assert.Equal(t, "CLIENT_KEY", vol.Secret.Items[2].Key)
+	assert.Equal(t, "client.key", vol.Secret.Items[2].Path)
+}
+
+func TestVaultAgentRGW(t *testing.T) {
+	store := simpleStore()
+	store.Spec.Security = &cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{
+		ConnectionDetails: map[string]string{
+			"KMS_PROVIDER":               "vault",
+			"VAULT_ADDR":                 "https://vault.example.com:8200",
+			"VAULT_SECRET_ENGINE":        "transit",
+			"VAULT_AUTH_METHOD":          "kubernetes",
+			"VAULT_AUTH_KUBERNETES_ROLE": "rook-rgw",
+			"VAULT_NAMESPACE":            "team-a",
+		},
+	}}
+	c := &clusterConfig{store: store, clusterInfo: &client.ClusterInfo{CephVersion: cephver.Pacific}}
+	assert.True(t, c.useVaultAgent())
+	assert.Equal(t, []string{
+		"--rgw-crypt-s3-kms-backend=vault",
+		"--rgw-crypt-vault-auth=agent",
+		"--rgw-crypt-vault-addr=http://127.0.0.1:8100",
+		"--rgw-crypt-vault-prefix=/v1/transit",
+		"--rgw-crypt-vault-secret-engine=transit",
+		"--rgw-crypt-vault-namespace=team-a",
+	}, c.vaultAgentArgs())
+
+	config := c.vaultAgentConfig()
+	assert.Contains(t, config, `address = "https://vault.example.com:8200"`)
+	assert.Contains(t, config, `type = "kubernetes"`)
+	assert.Contains(t, config, `mount_path = "auth/kubernetes"`)
+	assert.Contains(t, config, `namespace = "team-a"`)
+	assert.Contains(t, config, `role = "rook-rgw"`)
+	assert.NotContains(t, config, "ca_cert")
+	container := c.vaultAgentContainer()
+	assert.Equal(t, DefaultVaultAgentImage, container.Image)
+	assert.Empty(t, container.VolumeMounts)
+
+	// the approle auth reads the ids from the token secret mounted with the tls secrets
+	store.Spec.Security.KeyManagementService.TokenSecretName = "vault-approle"
+	store.Spec.Security.KeyManagementService.ConnectionDetails["VAULT_AUTH_METHOD"] = "approle"
+	store.Spec.Security.KeyManagementService.ConnectionDetails["VAULT_CACERT"] = "vault-ca"
+	store.Spec.Security.KeyManagementService.ConnectionDetails["VAULT_AGENT_IMAGE"] = "hashicorp/vault:1.10.0"
+	assert.True(t, c.useVaultAgent())
+	config = c.vaultAgentConfig()
+	assert.Contains(t, config, `ca_cert = "/etc/vault/vault.ca"`)
+	assert.Contains(t, config, `type = "approle"`)
+	assert.Contains(t, config, `role_id_file_path = "/etc/vault/vault.role-id"`)
+	assert.Contains(t, config, `secret_id_file_path = "/etc/vault/vault.secret-id"`)
+	container = c.vaultAgentContainer()
+	assert.Equal(t, "hashicorp/vault:1.10.0", container.Image)
+	assert.Equal(t, "/etc/vault", container.VolumeMounts[0].MountPath)
+
+	// rgw reads the token file itself with the token auth
+	store.Spec.Security.KeyManagementService.ConnectionDetails["VAULT_AUTH_METHOD"] = "token"
+	assert.False(t, c.useVaultAgent())
+}
+
 func TestGetDaemonName(t *testing.T) {
 	context := &clusterd.Context{Clientset: test.New(t, 3)}
 	store := simpleStore()
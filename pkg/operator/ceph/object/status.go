@@ -21,10 +21,12 @@ import (
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -36,36 +38,37 @@ func (r *ReconcileCephObjectStore) setFailedStatus(name types.NamespacedName, er
 
 // updateStatus updates an object with a given status
 func updateStatus(client client.Client, namespacedName types.NamespacedName, status cephv1.ConditionType, info map[string]string) {
-	// Updating the status is important to users, but we can still keep operating if there is a
-	// failure. Retry a few times to give it our best effort attempt.
-	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		objectStore := &cephv1.CephObjectStore{}
-		if err := client.Get(context.TODO(), namespacedName, objectStore); err != nil {
-			if kerrors.IsNotFound(err) {
-				logger.Debug("CephObjectStore resource not found. Ignoring since object must be deleted.")
-				return nil
-			}
-			return errors.Wrapf(err, "failed to retrieve object store %q to update status to %q", namespacedName.String(), status)
+	objectStore := &cephv1.CephObjectStore{}
+	if err := client.Get(context.TODO(), namespacedName, objectStore); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephObjectStore resource not found. Ignoring since object must be deleted.")
+			return
 		}
+		logger.Errorf("failed to retrieve object store %q to update status to %q. %v", namespacedName.String(), status, err)
+		return
+	}
+
+	// Updating the status is important to users, but we can still keep operating if there is a
+	// failure. The patch is retried on conflict to give it our best effort attempt.
+	err := reporting.PatchStatus(client, objectStore, func() bool {
 		if objectStore.Status == nil {
 			objectStore.Status = &cephv1.ObjectStoreStatus{}
 		}
 
 		if objectStore.Status.Phase == cephv1.ConditionDeleting {
 			logger.Debugf("object store %q status not updated to %q because it is deleting", namespacedName.String(), status)
-			return nil // do not transition to other statuses once deletion begins
+			return false // do not transition to other statuses once deletion begins
 		}
 
 		objectStore.Status.Phase = status
 		objectStore.Status.Info = info
-
-		if err := reporting.UpdateStatus(client, objectStore); err != nil {
-			return errors.Wrapf(err, "failed to set object store %q status to %q", namespacedName.String(), status)
-		}
-		return nil
+		objectStore.Status.KMS = buildKMSStatus(objectStore)
+		objectStore.Status.Replicas, objectStore.Status.Selector = buildScaleStatus(client, objectStore)
+		return true
 	})
 	if err != nil {
-		logger.Error(err)
+		logger.Errorf("failed to set object store %q status to %q. %v", namespacedName.String(), status, err)
+		return
 	}
 
 	logger.Debugf("object store %q status updated to %q", namespacedName.String(), status)
@@ -73,17 +76,19 @@ func updateStatus(client client.Client, namespacedName types.NamespacedName, sta
 
 // updateStatusBucket updates an object with a given status
 func updateStatusBucket(client client.Client, name types.NamespacedName, status cephv1.ConditionType, details string) {
-	// Updating the status is important to users, but we can still keep operating if there is a
-	// failure. Retry a few times to give it our best effort attempt.
-	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		objectStore := &cephv1.CephObjectStore{}
-		if err := client.Get(context.TODO(), name, objectStore); err != nil {
-			if kerrors.IsNotFound(err) {
-				logger.Debug("CephObjectStore resource not found. Ignoring since object must be deleted.")
-				return nil
-			}
-			return errors.Wrapf(err, "failed to retrieve object store %q to update status to %v", name.String(), status)
+	objectStore := &cephv1.CephObjectStore{}
+	if err := client.Get(context.TODO(), name, objectStore); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephObjectStore resource not found. Ignoring since object must be deleted.")
+			return
 		}
+		logger.Errorf("failed to retrieve object store %q to update status to %v. %v", name.String(), status, err)
+		return
+	}
+
+	// Updating the status is important to users, but we can still keep operating if there is a
+	// failure. The patch is retried on conflict to give it our best effort attempt.
+	err := reporting.PatchStatus(client, objectStore, func() bool {
 		if objectStore.Status == nil {
 			objectStore.Status = &cephv1.ObjectStoreStatus{}
 		}
@@ -95,18 +100,44 @@ func updateStatusBucket(client client.Client, name types.NamespacedName, status
 		}
 
 		// but we still need to update the health checker status
-		if err := reporting.UpdateStatus(client, objectStore); err != nil {
-			return errors.Wrapf(err, "failed to set object store %q status to %v", name.String(), status)
-		}
-		return nil
+		return true
 	})
 	if err != nil {
-		logger.Error(err)
+		logger.Errorf("failed to set object store %q status to %v. %v", name.String(), status, err)
+		return
 	}
 
 	logger.Debugf("object store %q status updated to %v", name.String(), status)
 }
 
+// updateStatusReplication sets the DRReady and ReplicationDegraded conditions of a multisite object
+// store from the issues of its sync, or removes them when the issues are nil
+func updateStatusReplication(client client.Client, name types.NamespacedName, issues []opcontroller.ReplicationIssue) {
+	objectStore := &cephv1.CephObjectStore{}
+	if err := client.Get(context.TODO(), name, objectStore); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephObjectStore resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Errorf("failed to retrieve object store %q to update the replication status. %v", name.String(), err)
+		return
+	}
+
+	err := reporting.PatchStatus(client, objectStore, func() bool {
+		if objectStore.Status == nil {
+			objectStore.Status = &cephv1.ObjectStoreStatus{}
+		}
+		if issues == nil {
+			return opcontroller.RemoveReplicationConditions(&objectStore.Status.Conditions)
+		}
+		opcontroller.SetReplicationConditions(&objectStore.Status.Conditions, issues)
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set object store %q replication status. %v", name.String(), err)
+	}
+}
+
 func buildStatusInfo(cephObjectStore *cephv1.CephObjectStore) map[string]string {
 	m := make(map[string]string)
 
@@ -121,3 +152,27 @@ func buildStatusInfo(cephObjectStore *cephv1.CephObjectStore) map[string]string
 
 	return m
 }
+
+// buildScaleStatus returns the number of rgw daemons running and the selector of their pods for
+// the scale subresource, the replicas are kept when they cannot be read
+func buildScaleStatus(client client.Client, cephObjectStore *cephv1.CephObjectStore) (int32, string) {
+	selectorLabels := map[string]string{
+		k8sutil.AppAttr:     AppName,
+		"rook_object_store": cephObjectStore.Name,
+	}
+	replicas, selector, err := opcontroller.ScaleStatus(context.TODO(), client, cephObjectStore.Namespace, selectorLabels)
+	if err != nil {
+		logger.Warningf("failed to get the replicas of object store %q. %v", cephObjectStore.Name, err)
+		return cephObjectStore.Status.Replicas, selector
+	}
+	return replicas, selector
+}
+
+// buildKMSStatus returns the KMS storing the encryption keys of the object store, the keys are
+// owned by the users of the buckets so their versions are not reported
+func buildKMSStatus(cephObjectStore *cephv1.CephObjectStore) *cephv1.KMSStatus {
+	if cephObjectStore.Spec.Security == nil || !cephObjectStore.Spec.Security.KeyManagementService.IsEnabled() {
+		return nil
+	}
+	return &cephv1.KMSStatus{Provider: kms.GetParam(cephObjectStore.Spec.Security.KeyManagementService.ConnectionDetails, kms.Provider)}
+}
@@ -58,3 +58,14 @@ func TestBuildStatusInfo(t *testing.T) {
 	assert.Equal(t, "http://rook-ceph-rgw-my-store.rook-ceph.svc:80", statusInfo["endpoint"])
 	assert.Equal(t, "https://rook-ceph-rgw-my-store.rook-ceph.svc:443", statusInfo["secureEndpoint"])
 }
+
+func TestBuildKMSStatus(t *testing.T) {
+	cephObjectStore := &cephv1.CephObjectStore{}
+	assert.Nil(t, buildKMSStatus(cephObjectStore))
+
+	cephObjectStore.Spec.Security = &cephv1.SecuritySpec{}
+	assert.Nil(t, buildKMSStatus(cephObjectStore))
+
+	cephObjectStore.Spec.Security.KeyManagementService.ConnectionDetails = map[string]string{"KMS_PROVIDER": "kmip"}
+	assert.Equal(t, &cephv1.KMSStatus{Provider: "kmip"}, buildKMSStatus(cephObjectStore))
+}
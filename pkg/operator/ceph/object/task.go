@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const taskControllerName = "ceph-object-task-controller"
+
+// reshardScript reshards the index of a bucket, radosgw-admin waits for the reshard to complete
+const reshardScript = `set -e
+radosgw-admin bucket reshard --bucket '%s' --num-shards %d --rgw-realm='%s' --rgw-zonegroup='%s' --rgw-zone='%s'
+`
+
+var bucketNameRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// addTaskController adds the controller running the tasks of the object stores
+func addTaskController(mgr manager.Manager, clusterdContext *clusterd.Context) error {
+	c := mgr.GetClient()
+	return opcontroller.AddTaskController(mgr, taskControllerName, opcontroller.TaskKind{
+		Kind:   "objectstore",
+		Object: &cephv1.CephObjectStore{TypeMeta: controllerTypeMeta},
+		Tasks: func(object client.Object) ([]cephv1.TaskSpec, []cephv1.TaskStatus) {
+			cephObjectStore := object.(*cephv1.CephObjectStore)
+			if cephObjectStore.Status == nil {
+				return cephObjectStore.Spec.Tasks, nil
+			}
+			return cephObjectStore.Spec.Tasks, cephObjectStore.Status.Tasks
+		},
+		SetTaskStatuses: func(object client.Object, statuses []cephv1.TaskStatus) {
+			cephObjectStore := object.(*cephv1.CephObjectStore)
+			if cephObjectStore.Status == nil {
+				cephObjectStore.Status = &cephv1.ObjectStoreStatus{}
+			}
+			cephObjectStore.Status.Tasks = statuses
+		},
+		JobBuilder: func(ctx context.Context, object client.Object) (opcontroller.TaskJobBuilder, error) {
+			cephObjectStore := object.(*cephv1.CephObjectStore)
+			if cephObjectStore.Spec.IsExternal() {
+				return func(task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+					return nil, errors.New("tasks are not supported by an external object store")
+				}, nil
+			}
+
+			cephCluster, isReadyToReconcile, _, _ := opcontroller.IsReadyToReconcile(ctx, c, client.ObjectKeyFromObject(object), taskControllerName)
+			if !isReadyToReconcile {
+				return nil, errors.Errorf("the CephCluster in namespace %q is not ready", object.GetNamespace())
+			}
+			realmName, zoneGroupName, zoneName, err := getMultisiteForObjectStore(clusterdContext, &cephObjectStore.Spec, cephObjectStore.Namespace, cephObjectStore.Name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get realm/zone group/zone for object store %q", cephObjectStore.Name)
+			}
+			objContext := &Context{Name: cephObjectStore.Name, Realm: realmName, ZoneGroup: zoneGroupName, Zone: zoneName}
+			image := cephCluster.Spec.CephVersion.Image
+			return func(task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+				return reshardTaskPodTemplate(image, objContext, task)
+			}, nil
+		},
+	})
+}
+
+// reshardTaskPodTemplate returns the pod template of a reshard task. The args of the task are the
+// bucket to reshard and the new number of shards of its index.
+func reshardTaskPodTemplate(image string, objContext *Context, task cephv1.TaskSpec) (*v1.PodTemplateSpec, error) {
+	if task.Type != cephv1.TaskTypeReshard {
+		return nil, errors.Errorf("task type %q is not supported by an object store", task.Type)
+	}
+
+	bucket := task.Args["bucket"]
+	if !bucketNameRegex.MatchString(bucket) {
+		return nil, errors.Errorf("invalid reshard bucket %q", bucket)
+	}
+	shards, err := strconv.Atoi(task.Args["shards"])
+	if err != nil || shards < 1 {
+		return nil, errors.Errorf("invalid reshard shards %q, the number of shards must be a positive integer", task.Args["shards"])
+	}
+
+	script := fmt.Sprintf(reshardScript, bucket, shards, objContext.Realm, objContext.ZoneGroup, objContext.Zone)
+	return opcontroller.CephTaskPodTemplate(image, script), nil
+}
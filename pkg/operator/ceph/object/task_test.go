@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReshardTaskPodTemplate(t *testing.T) {
+	objContext := &Context{Name: "store", Realm: "store", ZoneGroup: "store", Zone: "store"}
+	reshard := func(args map[string]string) cephv1.TaskSpec {
+		return cephv1.TaskSpec{Name: "reshard", Type: cephv1.TaskTypeReshard, Args: args}
+	}
+
+	template, err := reshardTaskPodTemplate("quay.io/ceph/ceph:v17", objContext, reshard(map[string]string{"bucket": "my-bucket", "shards": "101"}))
+	assert.NoError(t, err)
+	assert.Contains(t, template.Spec.Containers[0].Command[2], "radosgw-admin bucket reshard --bucket 'my-bucket' --num-shards 101 --rgw-realm='store' --rgw-zonegroup='store' --rgw-zone='store'")
+
+	for _, args := range []map[string]string{
+		{"shards": "101"},
+		{"bucket": "b'; rm -rf /", "shards": "101"},
+		{"bucket": "my-bucket"},
+		{"bucket": "my-bucket", "shards": "0"},
+	} {
+		_, err := reshardTaskPodTemplate("quay.io/ceph/ceph:v17", objContext, reshard(args))
+		assert.Error(t, err, args)
+	}
+
+	_, err = reshardTaskPodTemplate("quay.io/ceph/ceph:v17", objContext, cephv1.TaskSpec{Name: "scrub", Type: cephv1.TaskTypeScrub})
+	assert.Error(t, err)
+}
@@ -29,8 +29,10 @@ import (
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -53,6 +55,7 @@ type ReconcileBucketTopic struct {
 	clusterInfo      *cephclient.ClusterInfo
 	clusterSpec      *cephv1.ClusterSpec
 	opManagerContext context.Context
+	recorder         record.EventRecorder
 }
 
 // Add creates a new CephBucketTopic Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -62,12 +65,14 @@ func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext contex
 		client:           mgr.GetClient(),
 		context:          context,
 		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	})
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephBucketTopic{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephBucketTopic{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -88,31 +93,29 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileBucketTopic) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
-	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
-	}
+	reconcileResponse, cephBucketTopic, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephBucketTopic, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileBucketTopic) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileBucketTopic) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephBucketTopic, error) {
 	// Fetch the CephBucketTopic instance
 	cephBucketTopic := &cephv1.CephBucketTopic{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephBucketTopic)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debugf("CephBucketTopic %q not found. Ignoring since resource must be deleted", request.NamespacedName)
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephBucketTopic, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrapf(err, "failed to get CephBucketTopic %q", request.NamespacedName)
+		return reconcile.Result{}, cephBucketTopic, errors.Wrapf(err, "failed to get CephBucketTopic %q", request.NamespacedName)
 	}
 
 	// Set a finalizer so we can do cleanup before the object goes away
 	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephBucketTopic)
 	if err != nil {
-		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to add finalizer to CephBucketTopic %q", request.NamespacedName)
+		return opcontroller.ImmediateRetryResult, cephBucketTopic, errors.Wrapf(err, "failed to add finalizer to CephBucketTopic %q", request.NamespacedName)
 	}
 
 	// The CR was just created, initializing status fields
@@ -133,20 +136,20 @@ func (r *ReconcileBucketTopic) reconcile(request reconcile.Request) (reconcile.R
 			// Remove finalizer
 			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephBucketTopic)
 			if err != nil {
-				return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to remove finalizer for CephBucketTopic %q", request.NamespacedName)
+				return opcontroller.ImmediateRetryResult, cephBucketTopic, errors.Wrapf(err, "failed to remove finalizer for CephBucketTopic %q", request.NamespacedName)
 			}
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephBucketTopic, nil
 		}
 		logger.Debugf("Ceph cluster not yet present, cannot create CephBucketTopic %q", request.NamespacedName)
-		return reconcileResponse, nil
+		return reconcileResponse, cephBucketTopic, nil
 	}
 	r.clusterSpec = &cephCluster.Spec
 
 	// Populate clusterInfo during each reconcile
-	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, cephCluster.Namespace)
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephBucketTopic", request.Namespace, request.Name), cephCluster.Namespace)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+		return reconcile.Result{}, cephBucketTopic, errors.Wrap(err, "failed to populate cluster info")
 	}
 
 	// DELETE: the CR was deleted
@@ -154,23 +157,23 @@ func (r *ReconcileBucketTopic) reconcile(request reconcile.Request) (reconcile.R
 		logger.Debugf("deleting CephBucketTopic: %q", request.NamespacedName)
 		err = r.deleteCephBucketTopic(cephBucketTopic)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "failed to delete CephBucketTopic %q", request.NamespacedName)
+			return reconcile.Result{}, cephBucketTopic, errors.Wrapf(err, "failed to delete CephBucketTopic %q", request.NamespacedName)
 		}
 		// Remove finalizer
 		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephBucketTopic)
 		if err != nil {
-			return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to remove finalizer for CephBucketTopic %q", request.NamespacedName)
+			return opcontroller.ImmediateRetryResult, cephBucketTopic, errors.Wrapf(err, "failed to remove finalizer for CephBucketTopic %q", request.NamespacedName)
 		}
 
 		// Return and do not requeue. Successful deletion.
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, cephBucketTopic, nil
 	}
 
 	// validate the topic settings
 	err = cephBucketTopic.ValidateCreate()
 	if err != nil {
 		r.updateStatus(request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
-		return reconcile.Result{}, errors.Wrapf(err, "invalid CephBucketTopic %q", request.NamespacedName)
+		return reconcile.Result{}, cephBucketTopic, errors.Wrapf(err, "invalid CephBucketTopic %q", request.NamespacedName)
 	}
 
 	// Start object reconciliation, updating status for this
@@ -179,14 +182,14 @@ func (r *ReconcileBucketTopic) reconcile(request reconcile.Request) (reconcile.R
 	// create topic
 	topicARN, err := r.createCephBucketTopic(cephBucketTopic)
 	if err != nil {
-		return r.setFailedStatus(request.NamespacedName, "failed to create topic for bucket notifications", err)
+		return reconcile.Result{}, cephBucketTopic, r.setFailedStatus(request.NamespacedName, "failed to create topic for bucket notifications", err)
 	}
 
 	// Set Ready status, we are done reconciling
 	r.updateStatus(request.NamespacedName, k8sutil.ReadyStatus, topicARN)
 
 	// Return and do not requeue
-	return reconcile.Result{}, nil
+	return reconcile.Result{}, cephBucketTopic, nil
 }
 
 func (r *ReconcileBucketTopic) createCephBucketTopic(topic *cephv1.CephBucketTopic) (topicARN *string, err error) {
@@ -216,9 +219,9 @@ func (r *ReconcileBucketTopic) deleteCephBucketTopic(topic *cephv1.CephBucketTop
 	)
 }
 
-func (r *ReconcileBucketTopic) setFailedStatus(name types.NamespacedName, errMessage string, err error) (reconcile.Result, error) {
+func (r *ReconcileBucketTopic) setFailedStatus(name types.NamespacedName, errMessage string, err error) error {
 	r.updateStatus(name, k8sutil.ReconcileFailedStatus, nil)
-	return reconcile.Result{}, errors.Wrapf(err, "%s", errMessage)
+	return errors.Wrapf(err, "%s", errMessage)
 }
 
 // updateStatus updates the topic with a given status
@@ -232,13 +235,15 @@ func (r *ReconcileBucketTopic) updateStatus(nsName types.NamespacedName, status
 		logger.Warningf("failed to retrieve CephBucketTopic %q to update status to %q. error %v", nsName, status, err)
 		return
 	}
-	if topic.Status == nil {
-		topic.Status = &cephv1.BucketTopicStatus{}
-	}
-
-	topic.Status.ARN = topicARN
-	topic.Status.Phase = status
-	if err := reporting.UpdateStatus(r.client, topic); err != nil {
+	err := reporting.PatchStatus(r.client, topic, func() bool {
+		if topic.Status == nil {
+			topic.Status = &cephv1.BucketTopicStatus{}
+		}
+		topic.Status.ARN = topicARN
+		topic.Status.Phase = status
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set CephBucketTopic %q status to %q. error %v", nsName, status, err)
 		return
 	}
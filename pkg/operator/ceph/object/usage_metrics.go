@@ -0,0 +1,278 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"context"
+	"time"
+
+	"github.com/ceph/go-ceph/rgw/admin"
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// defaultUsageMetricsInterval is the interval the usage of the users and the buckets is collected at
+	defaultUsageMetricsInterval = 5 * time.Minute
+
+	userLabels   = []string{"namespace", "object_store", "user", "object_store_user"}
+	bucketLabels = []string{"namespace", "object_store", "bucket", "owner", "obc_namespace", "obc_name"}
+
+	objectUserSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_object_user_size_bytes",
+		Help: "The size of the objects of the buckets of an object store user",
+	}, userLabels)
+	objectUserObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_object_user_objects",
+		Help: "The number of objects of the buckets of an object store user",
+	}, userLabels)
+	objectUserQuotaSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_object_user_quota_max_size_bytes",
+		Help: "The maximum size of the objects of an object store user, not set without quota",
+	}, userLabels)
+	objectUserQuotaObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_object_user_quota_max_objects",
+		Help: "The maximum number of objects of an object store user, not set without quota",
+	}, userLabels)
+	objectBucketSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_object_bucket_size_bytes",
+		Help: "The size of the objects of a bucket",
+	}, bucketLabels)
+	objectBucketObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_object_bucket_objects",
+		Help: "The number of objects of a bucket",
+	}, bucketLabels)
+	objectBucketQuotaSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_object_bucket_quota_max_size_bytes",
+		Help: "The maximum size of the objects of a bucket, not set without quota",
+	}, bucketLabels)
+	objectBucketQuotaObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_object_bucket_quota_max_objects",
+		Help: "The maximum number of objects of a bucket, not set without quota",
+	}, bucketLabels)
+
+	userMetrics   = []*prometheus.GaugeVec{objectUserSize, objectUserObjects, objectUserQuotaSize, objectUserQuotaObjects}
+	bucketMetrics = []*prometheus.GaugeVec{objectBucketSize, objectBucketObjects, objectBucketQuotaSize, objectBucketQuotaObjects}
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		objectUserSize,
+		objectUserObjects,
+		objectUserQuotaSize,
+		objectUserQuotaObjects,
+		objectBucketSize,
+		objectBucketObjects,
+		objectBucketQuotaSize,
+		objectBucketQuotaObjects,
+	)
+}
+
+// usageMetrics exports the usage and the quotas of the users and the buckets of an object store. It
+// keeps the labels of the users and the buckets it exported to remove them when they are gone.
+type usageMetrics struct {
+	namespacedName types.NamespacedName
+	lastCollected  time.Time
+	users          map[string][]string
+	buckets        map[string][]string
+}
+
+func newUsageMetrics(namespacedName types.NamespacedName) *usageMetrics {
+	return &usageMetrics{
+		namespacedName: namespacedName,
+		users:          map[string][]string{},
+		buckets:        map[string][]string{},
+	}
+}
+
+// collect exports the usage of the users and the buckets of the object store if the interval elapsed
+// since the last collection
+func (m *usageMetrics) collect(ctx context.Context, adminOpsClient *admin.API, c client.Client, spec *cephv1.ObjectStoreMonitoringSpec) error {
+	interval := defaultUsageMetricsInterval
+	if spec.Interval != nil {
+		interval = spec.Interval.Duration
+	}
+	if time.Since(m.lastCollected) < interval {
+		return nil
+	}
+	if err := m.collectUsers(ctx, adminOpsClient, c); err != nil {
+		return errors.Wrap(err, "failed to collect the usage of the users")
+	}
+	if err := m.collectBuckets(ctx, adminOpsClient, c); err != nil {
+		return errors.Wrap(err, "failed to collect the usage of the buckets")
+	}
+	m.lastCollected = time.Now()
+	return nil
+}
+
+// collectUsers exports the usage and the quota of the users, labeled with the CephObjectStoreUser the
+// user was created for if any
+func (m *usageMetrics) collectUsers(ctx context.Context, adminOpsClient *admin.API, c client.Client) error {
+	storeUsers := &cephv1.CephObjectStoreUserList{}
+	if err := c.List(ctx, storeUsers, client.InNamespace(m.namespacedName.Namespace)); err != nil {
+		return errors.Wrap(err, "failed to list the CephObjectStoreUsers")
+	}
+	crNames := map[string]bool{}
+	for _, storeUser := range storeUsers.Items {
+		if storeUser.Spec.Store == m.namespacedName.Name {
+			crNames[storeUser.Name] = true
+		}
+	}
+
+	uids, err := adminOpsClient.GetUsers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list the users")
+	}
+	stats := true
+	users := map[string][]string{}
+	for _, uid := range *uids {
+		user, err := adminOpsClient.GetUser(ctx, admin.User{ID: uid, GenerateStat: &stats})
+		if err != nil {
+			if errors.Is(err, admin.ErrNoSuchUser) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get user %q", uid)
+		}
+		crName := ""
+		if crNames[uid] {
+			crName = uid
+		}
+		labels := []string{m.namespacedName.Namespace, m.namespacedName.Name, uid, crName}
+		users[uid] = labels
+		setUint(objectUserSize, labels, user.Stat.Size)
+		setUint(objectUserObjects, labels, user.Stat.NumObjects)
+		setQuota(objectUserQuotaSize, objectUserQuotaObjects, labels, user.UserQuota)
+	}
+	m.users = deleteGoneSeries(m.users, users, userMetrics)
+	return nil
+}
+
+// collectBuckets exports the usage and the quota of the buckets, labeled with the ObjectBucketClaim the
+// bucket was provisioned for if any
+func (m *usageMetrics) collectBuckets(ctx context.Context, adminOpsClient *admin.API, c client.Client) error {
+	// the buckets are still exported without their claims if the ObjectBuckets cannot be listed, such
+	// as when the operator does not watch the ObjectBucketClaims
+	claims, err := m.bucketClaims(ctx, c)
+	if err != nil {
+		logger.Debugf("failed to find the ObjectBucketClaims of the buckets of object store %q. %v", m.namespacedName, err)
+	}
+
+	names, err := adminOpsClient.ListBuckets(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list the buckets")
+	}
+	buckets := map[string][]string{}
+	for _, name := range names {
+		bucket, err := adminOpsClient.GetBucketInfo(ctx, admin.Bucket{Bucket: name})
+		if err != nil {
+			if errors.Is(err, admin.ErrNoSuchBucket) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get bucket %q", name)
+		}
+		claim := claims[name]
+		labels := []string{m.namespacedName.Namespace, m.namespacedName.Name, name, bucket.Owner, claim.Namespace, claim.Name}
+		buckets[name] = labels
+		setUint(objectBucketSize, labels, bucket.Usage.RgwMain.Size)
+		setUint(objectBucketObjects, labels, bucket.Usage.RgwMain.NumObjects)
+		setQuota(objectBucketQuotaSize, objectBucketQuotaObjects, labels, bucket.BucketQuota)
+	}
+	m.buckets = deleteGoneSeries(m.buckets, buckets, bucketMetrics)
+	return nil
+}
+
+// bucketClaims returns the ObjectBucketClaims of the buckets of the object store, by bucket name. The
+// object store of a bucket is found in the host of its ObjectBucket.
+func (m *usageMetrics) bucketClaims(ctx context.Context, c client.Client) (map[string]types.NamespacedName, error) {
+	objectBuckets := &bktv1alpha1.ObjectBucketList{}
+	if err := c.List(ctx, objectBuckets); err != nil {
+		return nil, errors.Wrap(err, "failed to list the ObjectBuckets")
+	}
+	claims := map[string]types.NamespacedName{}
+	for _, ob := range objectBuckets.Items {
+		if ob.Spec.ClaimRef == nil || ob.Spec.Connection == nil || ob.Spec.Endpoint == nil {
+			continue
+		}
+		objectStoreName, err := ParseDomainName(ob.Spec.Endpoint.BucketHost)
+		if err != nil || objectStoreName != m.namespacedName {
+			continue
+		}
+		claims[ob.Spec.Endpoint.BucketName] = types.NamespacedName{Namespace: ob.Spec.ClaimRef.Namespace, Name: ob.Spec.ClaimRef.Name}
+	}
+	return claims, nil
+}
+
+func setUint(metric *prometheus.GaugeVec, labels []string, value *uint64) {
+	if value != nil {
+		metric.WithLabelValues(labels...).Set(float64(*value))
+	} else {
+		metric.DeleteLabelValues(labels...)
+	}
+}
+
+// setQuota exports the maximum size and objects of the quota when it is enabled and they are limited
+func setQuota(sizeMetric, objectsMetric *prometheus.GaugeVec, labels []string, quota admin.QuotaSpec) {
+	enabled := quota.Enabled != nil && *quota.Enabled
+	if enabled && quota.MaxSize != nil && *quota.MaxSize >= 0 {
+		sizeMetric.WithLabelValues(labels...).Set(float64(*quota.MaxSize))
+	} else {
+		sizeMetric.DeleteLabelValues(labels...)
+	}
+	if enabled && quota.MaxObjects != nil && *quota.MaxObjects >= 0 {
+		objectsMetric.WithLabelValues(labels...).Set(float64(*quota.MaxObjects))
+	} else {
+		objectsMetric.DeleteLabelValues(labels...)
+	}
+}
+
+// deleteGoneSeries removes the series of the previous labels that were not exported again or whose
+// labels changed, and returns the current labels
+func deleteGoneSeries(previous, current map[string][]string, metricVecs []*prometheus.GaugeVec) map[string][]string {
+	for name, labels := range previous {
+		if currentLabels, ok := current[name]; ok && equalLabels(labels, currentLabels) {
+			continue
+		}
+		for _, metricVec := range metricVecs {
+			metricVec.DeleteLabelValues(labels...)
+		}
+	}
+	return current
+}
+
+func equalLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// clear removes all the usage metrics of the object store, when it is not monitored anymore
+func (m *usageMetrics) clear() {
+	m.users = deleteGoneSeries(m.users, map[string][]string{}, userMetrics)
+	m.buckets = deleteGoneSeries(m.buckets, map[string][]string{}, bucketMetrics)
+	m.lastCollected = time.Time{}
+}
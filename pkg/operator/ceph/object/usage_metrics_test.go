@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ceph/go-ceph/rgw/admin"
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUsageMetrics(t *testing.T) {
+	users := `["my-user","obc-default-my-claim"]`
+	buckets := `["my-bucket","other-bucket"]`
+	responses := map[string]string{
+		"/admin/metadata/user":                 users,
+		"/admin/user?uid=my-user":              `{"user_id":"my-user","stats":{"size":1024,"num_objects":2},"user_quota":{"enabled":true,"max_size":4096,"max_objects":-1}}`,
+		"/admin/user?uid=obc-default-my-claim": `{"user_id":"obc-default-my-claim","stats":{"size":2048,"num_objects":1},"user_quota":{"enabled":false,"max_size":-1,"max_objects":-1}}`,
+		"/admin/bucket":                        buckets,
+		"/admin/bucket?bucket=my-bucket":       `{"bucket":"my-bucket","owner":"obc-default-my-claim","usage":{"rgw.main":{"size":2048,"num_objects":1}},"bucket_quota":{"enabled":true,"max_size":-1,"max_objects":10}}`,
+		"/admin/bucket?bucket=other-bucket":    `{"bucket":"other-bucket","owner":"my-user","usage":{}}`,
+	}
+	mockClient := &MockClient{
+		MockDo: func(req *http.Request) (*http.Response, error) {
+			// the requests are keyed by their path and the user or bucket they get
+			key := req.URL.Path[strings.Index(req.URL.Path, "/admin"):]
+			if uid := req.URL.Query().Get("uid"); uid != "" {
+				key += "?uid=" + uid
+			}
+			if bucket := req.URL.Query().Get("bucket"); bucket != "" {
+				key += "?bucket=" + bucket
+			}
+			if response, ok := responses[key]; ok {
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader([]byte(response)))}, nil
+			}
+			return nil, fmt.Errorf("unexpected request: %q. method %q. path %q", req.URL.RawQuery, req.Method, req.URL.Path)
+		},
+	}
+	adminClient, err := admin.New("rook-ceph-rgw-my-store.rook-ceph.svc", "53S6B9S809NUP19IJ2K3", "1bXPegzsGClvoGAiJdHQD1uOW2sQBLAZM9j9VtXR", mockClient)
+	assert.NoError(t, err)
+
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	storeUser := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-user", Namespace: "rook-ceph"},
+		Spec:       cephv1.ObjectStoreUserSpec{Store: "my-store"},
+	}
+	ob := &bktv1alpha1.ObjectBucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "obc-default-my-claim"},
+		Spec: bktv1alpha1.ObjectBucketSpec{
+			ClaimRef: &v1.ObjectReference{Namespace: "default", Name: "my-claim"},
+			Connection: &bktv1alpha1.Connection{
+				Endpoint: &bktv1alpha1.Endpoint{BucketHost: "rook-ceph-rgw-my-store.rook-ceph.svc", BucketName: "my-bucket"},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(storeUser, ob).Build()
+
+	m := newUsageMetrics(types.NamespacedName{Namespace: "rook-ceph", Name: "my-store"})
+	defer m.clear()
+	assert.NoError(t, m.collect(context.TODO(), adminClient, c, &cephv1.ObjectStoreMonitoringSpec{UsageMetrics: true}))
+
+	t.Run("users", func(t *testing.T) {
+		userLabels := []string{"rook-ceph", "my-store", "my-user", "my-user"}
+		assert.Equal(t, float64(1024), testutil.ToFloat64(objectUserSize.WithLabelValues(userLabels...)))
+		assert.Equal(t, float64(2), testutil.ToFloat64(objectUserObjects.WithLabelValues(userLabels...)))
+		assert.Equal(t, float64(4096), testutil.ToFloat64(objectUserQuotaSize.WithLabelValues(userLabels...)))
+		obcUserLabels := []string{"rook-ceph", "my-store", "obc-default-my-claim", ""}
+		assert.Equal(t, float64(2048), testutil.ToFloat64(objectUserSize.WithLabelValues(obcUserLabels...)))
+		assert.Equal(t, 2, testutil.CollectAndCount(objectUserSize))
+		// the quotas are only exported when enabled and limited
+		assert.Equal(t, 1, testutil.CollectAndCount(objectUserQuotaSize))
+		assert.Equal(t, 0, testutil.CollectAndCount(objectUserQuotaObjects))
+	})
+
+	t.Run("buckets", func(t *testing.T) {
+		bucketLabels := []string{"rook-ceph", "my-store", "my-bucket", "obc-default-my-claim", "default", "my-claim"}
+		assert.Equal(t, float64(2048), testutil.ToFloat64(objectBucketSize.WithLabelValues(bucketLabels...)))
+		assert.Equal(t, float64(10), testutil.ToFloat64(objectBucketQuotaObjects.WithLabelValues(bucketLabels...)))
+		assert.Equal(t, 0, testutil.CollectAndCount(objectBucketQuotaSize))
+		// an empty bucket has no usage
+		assert.Equal(t, 1, testutil.CollectAndCount(objectBucketSize))
+	})
+
+	t.Run("collected at the interval", func(t *testing.T) {
+		responses["/admin/metadata/user"] = `["my-user"]`
+		assert.NoError(t, m.collect(context.TODO(), adminClient, c, &cephv1.ObjectStoreMonitoringSpec{UsageMetrics: true}))
+		assert.Equal(t, 2, testutil.CollectAndCount(objectUserSize))
+	})
+
+	t.Run("deleted users are removed", func(t *testing.T) {
+		m.lastCollected = m.lastCollected.Add(-defaultUsageMetricsInterval)
+		assert.NoError(t, m.collect(context.TODO(), adminClient, c, &cephv1.ObjectStoreMonitoringSpec{UsageMetrics: true}))
+		assert.Equal(t, 1, testutil.CollectAndCount(objectUserSize))
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		m.clear()
+		assert.Equal(t, 0, testutil.CollectAndCount(objectUserSize))
+		assert.Equal(t, 0, testutil.CollectAndCount(objectBucketSize))
+		assert.Equal(t, 0, testutil.CollectAndCount(objectBucketQuotaObjects))
+	})
+}
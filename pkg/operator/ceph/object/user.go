@@ -101,6 +101,19 @@ func GetUser(c *Context, id string) (*ObjectUser, int, error) {
 	return decodeUser(match)
 }
 
+// ListUsers returns the IDs of the users of the object store
+func ListUsers(c *Context) ([]string, error) {
+	result, err := runAdminCommand(c, true, "user", "list")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list s3 users. %s", result)
+	}
+	var users []string
+	if err := json.Unmarshal([]byte(result), &users); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal s3 users. %s", result)
+	}
+	return users, nil
+}
+
 // CreateUser creates a new user with the information given.
 // The function is used **ONCE** only to provision so the RGW Admin Ops User
 // Subsequent interaction with the API will be done with the created user
@@ -197,3 +210,59 @@ func DeleteUser(c *Context, id string, opts ...string) (string, error) {
 
 	return result, errors.Wrapf(err, "failed to delete s3 user uid=%q", id)
 }
+
+// RotateUserKey rotates the s3 key of the user with the given ID. A new key is generated for the user
+// before the given key is removed, the other keys of the user are kept. It returns the access key and
+// the secret key of the new key.
+func RotateUserKey(c *Context, id, accessKey string) (string, string, error) {
+	logger.Debugf("rotating the s3 key of user %q", id)
+	result, err := runAdminCommand(c, false, "user", "info", "--uid", id)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to get s3 user %q. %s", id, result)
+	}
+	match, err := extractJSON(result)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to get json")
+	}
+	previousKeys, err := decodeUserKeys(match)
+	if err != nil {
+		return "", "", err
+	}
+	previousAccessKeys := map[string]bool{}
+	for _, key := range previousKeys {
+		previousAccessKeys[key.AccessKey] = true
+	}
+
+	result, err = runAdminCommand(c, true, "key", "create", "--uid", id, "--key-type", "s3", "--gen-access-key", "--gen-secret")
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to create a new s3 key for user %q. %s", id, result)
+	}
+	keys, err := decodeUserKeys(result)
+	if err != nil {
+		return "", "", err
+	}
+	var newKey *admin.UserKeySpec
+	for i := range keys {
+		if !previousAccessKeys[keys[i].AccessKey] {
+			newKey = &keys[i]
+		}
+	}
+	if newKey == nil {
+		return "", "", errors.Errorf("failed to find the new s3 key of user %q", id)
+	}
+
+	result, err = runAdminCommand(c, false, "key", "rm", "--uid", id, "--key-type", "s3", "--access-key", accessKey)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to remove the previous s3 key of user %q. %s", id, result)
+	}
+	return newKey.AccessKey, newKey.SecretKey, nil
+}
+
+// decodeUserKeys returns the s3 keys of a user
+func decodeUserKeys(data string) ([]admin.UserKeySpec, error) {
+	var user admin.User
+	if err := json.Unmarshal([]byte(data), &user); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal json. %s", data)
+	}
+	return user.Keys, nil
+}
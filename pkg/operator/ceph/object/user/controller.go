@@ -21,13 +21,15 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/ceph/go-ceph/rgw/admin"
-	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/util/exec"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -73,9 +75,11 @@ type ReconcileObjectStoreUser struct {
 	context          *clusterd.Context
 	objContext       *object.AdminOpsContext
 	userConfig       *admin.User
+	keyRotation      *cephv1.KeyRotationStatus
 	cephClusterSpec  *cephv1.ClusterSpec
 	clusterInfo      *cephclient.ClusterInfo
 	opManagerContext context.Context
+	recorder         record.EventRecorder
 }
 
 // Add creates a new CephObjectStoreUser Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -91,12 +95,14 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 		scheme:           mgr.GetScheme(),
 		context:          context,
 		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephObjectStoreUser{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephObjectStoreUser{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -126,31 +132,29 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileObjectStoreUser) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
-	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
-	}
+	reconcileResponse, cephObjectStoreUser, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephObjectStoreUser, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileObjectStoreUser) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileObjectStoreUser) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephObjectStoreUser, error) {
 	// Fetch the CephObjectStoreUser instance
 	cephObjectStoreUser := &cephv1.CephObjectStoreUser{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephObjectStoreUser)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("CephObjectStoreUser resource not found. Ignoring since object must be deleted.")
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephObjectStoreUser, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get CephObjectStoreUser")
+		return reconcile.Result{}, cephObjectStoreUser, errors.Wrap(err, "failed to get CephObjectStoreUser")
 	}
 
 	// Set a finalizer so we can do cleanup before the object goes away
 	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephObjectStoreUser)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+		return reconcile.Result{}, cephObjectStoreUser, errors.Wrap(err, "failed to add finalizer")
 	}
 
 	// The CR was just created, initializing status fields
@@ -171,20 +175,20 @@ func (r *ReconcileObjectStoreUser) reconcile(request reconcile.Request) (reconci
 			// Remove finalizer
 			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephObjectStoreUser)
 			if err != nil {
-				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+				return reconcile.Result{}, cephObjectStoreUser, errors.Wrap(err, "failed to remove finalizer")
 			}
 
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephObjectStoreUser, nil
 		}
-		return reconcileResponse, nil
+		return reconcileResponse, cephObjectStoreUser, nil
 	}
 	r.cephClusterSpec = &cephCluster.Spec
 
 	// Populate clusterInfo during each reconcile
-	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	r.clusterInfo, err = object.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephObjectStoreUser", request.Namespace, request.Name), &cephCluster)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+		return reconcile.Result{}, cephObjectStoreUser, errors.Wrap(err, "failed to populate cluster info")
 	}
 
 	// Validate the object store has been initialized
@@ -194,16 +198,16 @@ func (r *ReconcileObjectStoreUser) reconcile(request reconcile.Request) (reconci
 			// Remove finalizer
 			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephObjectStoreUser)
 			if err != nil {
-				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+				return reconcile.Result{}, cephObjectStoreUser, errors.Wrap(err, "failed to remove finalizer")
 			}
 
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephObjectStoreUser, nil
 		}
 		logger.Debugf("ObjectStore resource not ready in namespace %q, retrying in %q. %v",
 			request.NamespacedName.Namespace, opcontroller.WaitForRequeueIfCephClusterNotReady.RequeueAfter.String(), err)
 		r.updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
-		return opcontroller.WaitForRequeueIfCephClusterNotReady, nil
+		return opcontroller.WaitForRequeueIfCephClusterNotReady, cephObjectStoreUser, nil
 	}
 
 	// Generate user config
@@ -215,46 +219,46 @@ func (r *ReconcileObjectStoreUser) reconcile(request reconcile.Request) (reconci
 		logger.Debugf("deleting pool %q", cephObjectStoreUser.Name)
 		err := r.deleteUser(cephObjectStoreUser)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "failed to delete ceph object user %q", cephObjectStoreUser.Name)
+			return reconcile.Result{}, cephObjectStoreUser, errors.Wrapf(err, "failed to delete ceph object user %q", cephObjectStoreUser.Name)
 		}
 
 		// Remove finalizer
 		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephObjectStoreUser)
 		if err != nil {
-			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			return reconcile.Result{}, cephObjectStoreUser, errors.Wrap(err, "failed to remove finalizer")
 		}
 
 		// Return and do not requeue. Successful deletion.
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, cephObjectStoreUser, nil
 	}
 
 	// validate the user settings
 	err = r.validateUser(cephObjectStoreUser)
 	if err != nil {
 		r.updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
-		return reconcile.Result{}, errors.Wrapf(err, "invalid pool CR %q spec", cephObjectStoreUser.Name)
+		return reconcile.Result{}, cephObjectStoreUser, errors.Wrapf(err, "invalid pool CR %q spec", cephObjectStoreUser.Name)
 	}
 
 	// CREATE/UPDATE CEPH USER
 	reconcileResponse, err = r.reconcileCephUser(cephObjectStoreUser)
 	if err != nil {
 		r.updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
-		return reconcileResponse, err
+		return reconcileResponse, cephObjectStoreUser, err
 	}
 
 	// CREATE/UPDATE KUBERNETES SECRET
 	reconcileResponse, err = r.reconcileCephUserSecret(cephObjectStoreUser)
 	if err != nil {
 		r.updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
-		return reconcileResponse, err
+		return reconcileResponse, cephObjectStoreUser, err
 	}
 
 	// Set Ready status, we are done reconciling
 	r.updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
 
-	// Return and do not requeue
+	// Return and only requeue for the next periodic rotation of the key
 	logger.Debug("done reconciling")
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: opcontroller.KeyRotationRequeueAfter(cephObjectStoreUser.Spec.KeyRotation, r.keyRotation, time.Now())}, cephObjectStoreUser, nil
 }
 
 func (r *ReconcileObjectStoreUser) reconcileCephUser(cephObjectStoreUser *cephv1.CephObjectStoreUser) (reconcile.Result, error) {
@@ -334,6 +338,23 @@ func (r *ReconcileObjectStoreUser) createorUpdateCephUser(u *cephv1.CephObjectSt
 		return errors.Wrapf(err, "failed to set quotas for user %q", u.Name)
 	}
 
+	// Rotate the access and secret key when due
+	var currentKeyRotation *cephv1.KeyRotationStatus
+	if u.Status != nil {
+		currentKeyRotation = u.Status.KeyRotation
+	}
+	keyRotation, rotate := opcontroller.KeyRotation(u.Spec.KeyRotation, currentKeyRotation, time.Now())
+	if rotate {
+		accessKey, secretKey, err := object.RotateUserKey(&r.objContext.Context, u.Name, user.Keys[0].AccessKey)
+		if err != nil {
+			return errors.Wrapf(err, "failed to rotate the key of ceph object user %q", u.Name)
+		}
+		user.Keys[0].AccessKey = accessKey
+		user.Keys[0].SecretKey = secretKey
+		logCreateOrUpdate = fmt.Sprintf("rotated the key of ceph object user %q to generation %d", u.Name, keyRotation.Generation)
+	}
+	r.keyRotation = keyRotation
+
 	// Set access and secret key
 	r.userConfig.Keys[0].AccessKey = user.Keys[0].AccessKey
 	r.userConfig.Keys[0].SecretKey = user.Keys[0].SecretKey
@@ -462,19 +483,25 @@ func (r *ReconcileObjectStoreUser) reconcileCephUserSecret(cephObjectStoreUser *
 		return reconcile.Result{}, errors.Wrapf(err, "failed to create or update ceph object user %q secret", secret.Name)
 	}
 
+	// Push the keys to the external secret store of the cluster
+	err = opcontroller.ReconcilePushSecret(r.opManagerContext, r.client, cephObjectStoreUser.Namespace, &r.cephClusterSpec.Security, cephv1.ExternalSecretObjectStoreUserCredentials, secret)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to push ceph object user %q secret", secret.Name)
+	}
+
 	return reconcile.Result{}, nil
 }
 
 func (r *ReconcileObjectStoreUser) objectStoreInitialized(cephObjectStoreUser *cephv1.CephObjectStoreUser) error {
-	_, err := r.getObjectStore(cephObjectStoreUser.Spec.Store)
+	store, err := r.getObjectStore(cephObjectStoreUser.Spec.Store)
 	if err != nil {
 		return err
 	}
 	logger.Debug("CephObjectStore exists")
 
-	// If the cluster is external just return
+	// If the cluster or the gateways are external just return
 	// since there are no pods running
-	if r.cephClusterSpec.External.Enable {
+	if r.cephClusterSpec.External.Enable || store.Spec.IsExternal() {
 		return nil
 	}
 
@@ -582,15 +609,20 @@ func (r *ReconcileObjectStoreUser) updateStatus(client client.Client, name types
 		logger.Warningf("failed to retrieve object store user %q to update status to %q. %v", name, status, err)
 		return
 	}
-	if user.Status == nil {
-		user.Status = &cephv1.ObjectStoreUserStatus{}
-	}
-
-	user.Status.Phase = status
-	if user.Status.Phase == k8sutil.ReadyStatus {
-		user.Status.Info = generateStatusInfo(user)
-	}
-	if err := reporting.UpdateStatus(client, user); err != nil {
+	err := reporting.PatchStatus(client, user, func() bool {
+		if user.Status == nil {
+			user.Status = &cephv1.ObjectStoreUserStatus{}
+		}
+		user.Status.Phase = status
+		if user.Status.Phase == k8sutil.ReadyStatus {
+			user.Status.Info = generateStatusInfo(user)
+			if r.keyRotation != nil {
+				user.Status.KeyRotation = r.keyRotation
+			}
+		}
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set object store user %q status to %q. %v", name, status, err)
 		return
 	}
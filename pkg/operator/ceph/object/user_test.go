@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateUserKey(t *testing.T) {
+	removed := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			switch strings.Join(args[:2], " ") {
+			case "user info":
+				return `{"user_id":"my-user","keys":[{"user":"my-user","access_key":"OLDKEY","secret_key":"oldsecret"}]}`, nil
+			case "key create":
+				return `{"user_id":"my-user","keys":[{"user":"my-user","access_key":"NEWKEY","secret_key":"newsecret"},{"user":"my-user","access_key":"OLDKEY","secret_key":"oldsecret"}]}`, nil
+			case "key rm":
+				removed = args[7]
+				return "", nil
+			}
+			t.Fatalf("unexpected command %v", args)
+			return "", nil
+		},
+	}
+	objContext := &Context{
+		Context:     &clusterd.Context{Executor: executor},
+		clusterInfo: client.AdminTestClusterInfo("mycluster"),
+	}
+
+	accessKey, secretKey, err := RotateUserKey(objContext, "my-user", "OLDKEY")
+	assert.NoError(t, err)
+	assert.Equal(t, "NEWKEY", accessKey)
+	assert.Equal(t, "newsecret", secretKey)
+	assert.Equal(t, "OLDKEY", removed)
+}
@@ -45,6 +45,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -71,6 +72,7 @@ type ReconcileObjectZoneGroup struct {
 	context          *clusterd.Context
 	clusterInfo      *cephclient.ClusterInfo
 	opManagerContext context.Context
+	recorder         record.EventRecorder
 }
 
 // Add creates a new CephObjectZoneGroup Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -86,12 +88,14 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerCont
 		scheme:           mgr.GetScheme(),
 		context:          context,
 		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephObjectZoneGroup{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephObjectZoneGroup{}, reconciler)})
 	if err != nil {
 		return err
 	}
@@ -112,25 +116,23 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileObjectZoneGroup) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
-	reconcileResponse, err := r.reconcile(request)
-	if err != nil {
-		logger.Errorf("failed to reconcile: %v", err)
-	}
+	reconcileResponse, cephObjectZoneGroup, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephObjectZoneGroup, reconcileResponse, err)
 
 	return reconcileResponse, err
 }
 
-func (r *ReconcileObjectZoneGroup) reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileObjectZoneGroup) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephObjectZoneGroup, error) {
 	// Fetch the CephObjectZoneGroup instance
 	cephObjectZoneGroup := &cephv1.CephObjectZoneGroup{}
 	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephObjectZoneGroup)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("CephObjectZoneGroup resource not found. Ignoring since object must be deleted.")
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephObjectZoneGroup, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, errors.Wrap(err, "failed to get CephObjectZoneGroup")
+		return reconcile.Result{}, cephObjectZoneGroup, errors.Wrap(err, "failed to get CephObjectZoneGroup")
 	}
 
 	// The CR was just created, initializing status fields
@@ -144,9 +146,9 @@ func (r *ReconcileObjectZoneGroup) reconcile(request reconcile.Request) (reconci
 		// This handles the case where the Ceph Cluster is gone and we want to delete that CR
 		if !cephObjectZoneGroup.GetDeletionTimestamp().IsZero() && !cephClusterExists {
 			// Return and do not requeue. Successful deletion.
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, cephObjectZoneGroup, nil
 		}
-		return reconcileResponse, nil
+		return reconcileResponse, cephObjectZoneGroup, nil
 	}
 
 	// DELETE: the CR was deleted
@@ -154,20 +156,20 @@ func (r *ReconcileObjectZoneGroup) reconcile(request reconcile.Request) (reconci
 		logger.Debugf("deleting zone group CR %q", cephObjectZoneGroup.Name)
 
 		// Return and do not requeue. Successful deletion.
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, cephObjectZoneGroup, nil
 	}
 
 	// Populate clusterInfo during each reconcile
-	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephObjectZoneGroup", request.Namespace, request.Name), request.NamespacedName.Namespace)
 	if err != nil {
-		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+		return reconcile.Result{}, cephObjectZoneGroup, errors.Wrap(err, "failed to populate cluster info")
 	}
 
 	// validate the zone group settings
 	err = validateZoneGroup(cephObjectZoneGroup)
 	if err != nil {
 		r.updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
-		return reconcile.Result{}, errors.Wrapf(err, "invalid CephObjectZoneGroup CR %q", cephObjectZoneGroup.Name)
+		return reconcile.Result{}, cephObjectZoneGroup, errors.Wrapf(err, "invalid CephObjectZoneGroup CR %q", cephObjectZoneGroup.Name)
 	}
 
 	// Start object reconciliation, updating status for this
@@ -176,19 +178,19 @@ func (r *ReconcileObjectZoneGroup) reconcile(request reconcile.Request) (reconci
 	// Make sure an ObjectRealm Resource is present
 	reconcileResponse, err = r.reconcileObjectRealm(cephObjectZoneGroup)
 	if err != nil {
-		return reconcileResponse, err
+		return reconcileResponse, cephObjectZoneGroup, err
 	}
 
 	// Make sure Realm has been created in Ceph Cluster
 	reconcileResponse, err = r.reconcileCephRealm(cephObjectZoneGroup)
 	if err != nil {
-		return reconcileResponse, err
+		return reconcileResponse, cephObjectZoneGroup, err
 	}
 
 	// Create/Update Ceph Zone Group
 	_, err = r.createCephZoneGroup(cephObjectZoneGroup)
 	if err != nil {
-		return r.setFailedStatus(request.NamespacedName, "failed to create ceph zone group", err)
+		return reconcile.Result{}, cephObjectZoneGroup, r.setFailedStatus(request.NamespacedName, "failed to create ceph zone group", err)
 	}
 
 	// Set Ready status, we are done reconciling
@@ -196,7 +198,7 @@ func (r *ReconcileObjectZoneGroup) reconcile(request reconcile.Request) (reconci
 
 	// Return and do not requeue
 	logger.Debug("zone group done reconciling")
-	return reconcile.Result{}, nil
+	return reconcile.Result{}, cephObjectZoneGroup, nil
 }
 
 func (r *ReconcileObjectZoneGroup) createCephZoneGroup(zoneGroup *cephv1.CephObjectZoneGroup) (reconcile.Result, error) {
@@ -290,9 +292,9 @@ func (r *ReconcileObjectZoneGroup) reconcileCephRealm(zoneGroup *cephv1.CephObje
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileObjectZoneGroup) setFailedStatus(name types.NamespacedName, errMessage string, err error) (reconcile.Result, error) {
+func (r *ReconcileObjectZoneGroup) setFailedStatus(name types.NamespacedName, errMessage string, err error) error {
 	r.updateStatus(r.client, name, k8sutil.ReconcileFailedStatus)
-	return reconcile.Result{}, errors.Wrapf(err, "%s", errMessage)
+	return errors.Wrapf(err, "%s", errMessage)
 }
 
 // updateStatus updates an zone group with a given status
@@ -306,12 +308,14 @@ func (r *ReconcileObjectZoneGroup) updateStatus(client client.Client, name types
 		logger.Warningf("failed to retrieve object zone group %q to update status to %q. %v", name, status, err)
 		return
 	}
-	if objectZoneGroup.Status == nil {
-		objectZoneGroup.Status = &cephv1.Status{}
-	}
-
-	objectZoneGroup.Status.Phase = status
-	if err := reporting.UpdateStatus(client, objectZoneGroup); err != nil {
+	err := reporting.PatchStatus(client, objectZoneGroup, func() bool {
+		if objectZoneGroup.Status == nil {
+			objectZoneGroup.Status = &cephv1.Status{}
+		}
+		objectZoneGroup.Status.Phase = status
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set object zone group %q status to %q. %v", name, status, err)
 		return
 	}
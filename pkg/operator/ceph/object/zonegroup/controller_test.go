@@ -37,6 +37,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -178,7 +179,7 @@ func TestCephObjectZoneGroupController(t *testing.T) {
 	// Create a ReconcileObjectZoneGroup object with the scheme and fake client.
 	clusterInfo := cephclient.AdminTestClusterInfo("rook")
 
-	r := &ReconcileObjectZoneGroup{client: cl, scheme: s, context: c, clusterInfo: clusterInfo}
+	r := &ReconcileObjectZoneGroup{client: cl, scheme: s, context: c, clusterInfo: clusterInfo, recorder: &record.FakeRecorder{}}
 
 	// Mock request to simulate Reconcile() being called on an event for a
 	// watched resource .
@@ -220,7 +221,7 @@ func TestCephObjectZoneGroupController(t *testing.T) {
 	cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 
 	// Create a ReconcileObjectZoneGroup object with the scheme and fake client.
-	r = &ReconcileObjectZoneGroup{client: cl, scheme: r.scheme, context: r.context}
+	r = &ReconcileObjectZoneGroup{client: cl, scheme: r.scheme, context: r.context, recorder: &record.FakeRecorder{}}
 	res, err = r.Reconcile(ctx, req)
 	assert.NoError(t, err)
 	assert.True(t, res.Requeue)
@@ -275,7 +276,7 @@ func TestCephObjectZoneGroupController(t *testing.T) {
 	r.context.Executor = executor
 
 	// Create a ReconcileObjectZoneGroup
-	r = &ReconcileObjectZoneGroup{client: cl, scheme: r.scheme, context: r.context}
+	r = &ReconcileObjectZoneGroup{client: cl, scheme: r.scheme, context: r.context, recorder: &record.FakeRecorder{}}
 
 	res, err = r.Reconcile(ctx, req)
 	assert.Error(t, err)
@@ -344,7 +345,7 @@ func TestCephObjectZoneGroupController(t *testing.T) {
 
 	cl = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
 
-	r = &ReconcileObjectZoneGroup{client: cl, scheme: s, context: c, clusterInfo: clusterInfo}
+	r = &ReconcileObjectZoneGroup{client: cl, scheme: s, context: c, clusterInfo: clusterInfo, recorder: &record.FakeRecorder{}}
 
 	err = r.client.Get(context.TODO(), types.NamespacedName{Name: realm, Namespace: namespace}, objectRealm)
 	assert.NoError(t, err, objectRealm)
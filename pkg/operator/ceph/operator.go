@@ -22,6 +22,8 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
@@ -32,6 +34,7 @@ import (
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -129,6 +132,9 @@ func (o *Operator) runCRDManager() {
 	// Create the context and the cancellation function
 	opManagerContext, opManagerStop = context.WithCancel(context.Background())
 
+	// The settings of the CephOperatorConfig read when the manager starts must be applied first
+	o.loadOperatorConfig(opManagerContext)
+
 	// The operator config manager is also watching for changes here so if the operator config map
 	// content changes for ROOK_CURRENT_NAMESPACE_ONLY we must reload the operator CRD manager
 	o.namespaceToWatch(opManagerContext)
@@ -149,6 +155,25 @@ func (o *Operator) runCRDManager() {
 }
 
 func (o *Operator) namespaceToWatch(context context.Context) {
+	o.config.NamespacesToWatch = nil
+	watchNamespaces, _ := k8sutil.GetOperatorSetting(opManagerContext, o.context.Clientset, opcontroller.OperatorSettingConfigMapName, opcontroller.WatchNamespacesSetting, "")
+	namespaces, err := parseWatchNamespaces(watchNamespaces)
+	if err != nil {
+		logger.Errorf("ignoring the invalid list of namespaces to watch %q. %v", watchNamespaces, err)
+		namespaces = nil
+	}
+	if len(namespaces) > 0 {
+		// the operator always watches its own namespace for its settings
+		if !sliceContains(namespaces, o.config.OperatorNamespace) {
+			namespaces = append(namespaces, o.config.OperatorNamespace)
+			sort.Strings(namespaces)
+		}
+		o.config.NamespaceToWatch = ""
+		o.config.NamespacesToWatch = namespaces
+		logger.Infof("watching the namespaces %v for Ceph CRs", namespaces)
+		return
+	}
+
 	currentNamespaceOnly, _ := k8sutil.GetOperatorSetting(opManagerContext, o.context.Clientset, opcontroller.OperatorSettingConfigMapName, "ROOK_CURRENT_NAMESPACE_ONLY", "true")
 	if currentNamespaceOnly == "true" {
 		o.config.NamespaceToWatch = o.config.OperatorNamespace
@@ -158,3 +183,30 @@ func (o *Operator) namespaceToWatch(context context.Context) {
 		logger.Infof("watching all namespaces for Ceph CRs")
 	}
 }
+
+// parseWatchNamespaces parses the comma separated list of the namespaces to watch, returned sorted
+// without duplicates
+func parseWatchNamespaces(value string) ([]string, error) {
+	namespaces := []string{}
+	for _, namespace := range strings.Split(value, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" || sliceContains(namespaces, namespace) {
+			continue
+		}
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return nil, errors.Errorf("invalid namespace %q. %v", namespace, errs)
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+func sliceContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
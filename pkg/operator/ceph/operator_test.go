@@ -17,7 +17,9 @@ limitations under the License.
 package operator
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/rook/rook/pkg/clusterd"
@@ -42,3 +44,47 @@ func TestOperator(t *testing.T) {
 		}
 	}
 }
+
+func TestNamespaceToWatch(t *testing.T) {
+	opManagerContext = context.TODO()
+	clientset := test.New(t, 1)
+	o := New(&clusterd.Context{Clientset: clientset}, "", "")
+	o.config.OperatorNamespace = "rook-ceph"
+
+	// the current namespace by default
+	o.namespaceToWatch(opManagerContext)
+	assert.Equal(t, "rook-ceph", o.config.NamespaceToWatch)
+	assert.Empty(t, o.config.NamespacesToWatch)
+
+	os.Setenv("ROOK_CURRENT_NAMESPACE_ONLY", "false")
+	defer os.Unsetenv("ROOK_CURRENT_NAMESPACE_ONLY")
+	o.namespaceToWatch(opManagerContext)
+	assert.Equal(t, "", o.config.NamespaceToWatch)
+	assert.Empty(t, o.config.NamespacesToWatch)
+
+	// a list of namespaces, with the namespace of the operator
+	os.Setenv("ROOK_WATCH_NAMESPACES", "team-b, team-a,team-b")
+	defer os.Unsetenv("ROOK_WATCH_NAMESPACES")
+	o.namespaceToWatch(opManagerContext)
+	assert.Equal(t, "", o.config.NamespaceToWatch)
+	assert.Equal(t, []string{"rook-ceph", "team-a", "team-b"}, o.config.NamespacesToWatch)
+
+	// an invalid list is ignored
+	os.Setenv("ROOK_WATCH_NAMESPACES", "team-a,Team_B")
+	o.namespaceToWatch(opManagerContext)
+	assert.Equal(t, "", o.config.NamespaceToWatch)
+	assert.Empty(t, o.config.NamespacesToWatch)
+}
+
+func TestParseWatchNamespaces(t *testing.T) {
+	namespaces, err := parseWatchNamespaces("")
+	assert.NoError(t, err)
+	assert.Empty(t, namespaces)
+
+	namespaces, err = parseWatchNamespaces("team-b,team-a, ,team-a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"team-a", "team-b"}, namespaces)
+
+	_, err = parseWatchNamespaces("team-a,team.b")
+	assert.Error(t, err)
+}
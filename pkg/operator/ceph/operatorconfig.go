@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the default values of the settings of the fields of the CephOperatorConfig spec, reported in its
+// status when they are not set anywhere
+var operatorSettingDefaults = map[string]string{
+	"ROOK_LOG_LEVEL":                                      "INFO",
+	"ROOK_LOG_FORMAT":                                     util.DefaultLogFormat,
+	"ROOK_LOG_LEVELS":                                     "",
+	"ROOK_CURRENT_NAMESPACE_ONLY":                         "true",
+	"ROOK_WATCH_NAMESPACES":                               "",
+	"ROOK_ENABLE_DISCOVERY_DAEMON":                        "false",
+	"ROOK_ENABLE_OBC_PROVISIONER":                         "true",
+	"ROOK_OBC_WATCH_OPERATOR_NAMESPACE":                   "false",
+	"ROOK_REQUIRE_CONTROLLER_PERMISSIONS":                 "false",
+	"ROOK_ENABLE_ADMISSION_POLICIES":                      "false",
+	"ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS":                  "15",
+	"ROOK_CEPH_COMMANDS_MAX_DURATION_SECONDS":             "300",
+	"ROOK_CEPH_COMMANDS_RETRIES":                          "3",
+	"ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_THRESHOLD":        "5",
+	"ROOK_CEPH_COMMANDS_CIRCUIT_BREAKER_COOLDOWN_SECONDS": "30",
+	"ROOK_CEPH_COMMANDS_CACHE_TTL_SECONDS":                "5",
+	"ROOK_AUDIT_LOG":                                      "",
+	"ROOK_AUDIT_LOG_MAX_SIZE_MB":                          "100",
+	"ROOK_AUDIT_LOG_MAX_BACKUPS":                          "5",
+	"ROOK_TRACING_OTLP_ENDPOINT":                          "",
+	"ROOK_TRACING_SERVICE_NAME":                           "rook-ceph-operator",
+}
+
+// the prefixes of the names of the settings of spec.settings
+var operatorSettingPrefixes = []string{"ROOK_", "CSI_"}
+
+// ValidateOperatorConfig validates the settings of a CephOperatorConfig
+func ValidateOperatorConfig(config *cephv1.CephOperatorConfig) error {
+	if config.Name != cephv1.OperatorConfigName {
+		return errors.Errorf("the CephOperatorConfig must be named %q", cephv1.OperatorConfigName)
+	}
+
+	spec := config.Spec
+	fields := spec
+	fields.Settings = nil
+	fieldSettings := fields.OperatorSettings()
+	// the settings of the fields of the spec are validated by the schema of the CRD, the same
+	// settings in spec.settings are checked here
+	for name, value := range spec.Settings {
+		if !hasOperatorSettingPrefix(name) {
+			return errors.Errorf("invalid setting %q, the name of a setting starts with one of %v", name, operatorSettingPrefixes)
+		}
+		if _, ok := fieldSettings[name]; ok {
+			return errors.Errorf("setting %q is set both in spec.settings and in a field of the spec", name)
+		}
+		if defaultValue, ok := operatorSettingDefaults[name]; ok {
+			if _, err := strconv.Atoi(defaultValue); err == nil {
+				if _, err := strconv.Atoi(value); err != nil {
+					return errors.Errorf("invalid setting %s=%q, not an integer", name, value)
+				}
+			}
+		}
+	}
+
+	settings := spec.OperatorSettings()
+	if format, ok := settings["ROOK_LOG_FORMAT"]; ok && format != util.LogFormatConsole && format != util.LogFormatJSON {
+		return errors.Errorf("invalid log format %q, expected %q or %q", format, util.LogFormatConsole, util.LogFormatJSON)
+	}
+	if _, err := util.ParseLogLevels(settings["ROOK_LOG_LEVELS"]); err != nil {
+		return errors.Wrap(err, "invalid log levels")
+	}
+	if _, err := parseWatchNamespaces(settings["ROOK_WATCH_NAMESPACES"]); err != nil {
+		return errors.Wrap(err, "invalid namespaces to watch")
+	}
+
+	if spec.CephCommands != nil && spec.CephCommands.TimeoutSeconds != nil && spec.CephCommands.MaxDurationSeconds != nil &&
+		*spec.CephCommands.MaxDurationSeconds < *spec.CephCommands.TimeoutSeconds {
+		return errors.New("cephCommands.maxDurationSeconds must be >= cephCommands.timeoutSeconds")
+	}
+	return nil
+}
+
+func hasOperatorSettingPrefix(name string) bool {
+	for _, prefix := range operatorSettingPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveOperatorSettings returns the settings applied by the operator with their source: the
+// settings of the CephOperatorConfig, of the ConfigMap and the settings of the fields of the
+// CephOperatorConfig set by an env var or to their default value
+func effectiveOperatorSettings(crSettings, configMapSettings map[string]string) []cephv1.OperatorSettingStatus {
+	names := map[string]bool{}
+	for _, settings := range []map[string]string{crSettings, configMapSettings, operatorSettingDefaults} {
+		for name := range settings {
+			names[name] = true
+		}
+	}
+
+	effective := []cephv1.OperatorSettingStatus{}
+	for name := range names {
+		setting := cephv1.OperatorSettingStatus{Name: name}
+		if value, ok := crSettings[name]; ok {
+			setting.Value, setting.Source = value, cephv1.OperatorSettingSourceCR
+		} else if value, ok := configMapSettings[name]; ok {
+			setting.Value, setting.Source = value, cephv1.OperatorSettingSourceConfigMap
+		} else if value, ok := os.LookupEnv(name); ok {
+			setting.Value, setting.Source = value, cephv1.OperatorSettingSourceEnv
+		} else {
+			setting.Value, setting.Source = operatorSettingDefaults[name], cephv1.OperatorSettingSourceDefault
+		}
+		effective = append(effective, setting)
+	}
+	sort.Slice(effective, func(i, j int) bool { return effective[i].Name < effective[j].Name })
+	return effective
+}
+
+// loadOperatorConfig applies the settings of the CephOperatorConfig before the controllers are
+// started, for the settings read when the manager starts
+func (o *Operator) loadOperatorConfig(ctx context.Context) {
+	config, err := o.context.RookClientset.CephV1().CephOperatorConfigs(o.config.OperatorNamespace).Get(ctx, cephv1.OperatorConfigName, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			logger.Warningf("failed to get the CephOperatorConfig, using the settings of the ConfigMap and env vars. %v", err)
+		}
+		k8sutil.SetOperatorSettingOverrides(nil)
+		return
+	}
+	if err := ValidateOperatorConfig(config); err != nil {
+		logger.Errorf("invalid CephOperatorConfig, using the settings of the ConfigMap and env vars. %v", err)
+		k8sutil.SetOperatorSettingOverrides(nil)
+		return
+	}
+	k8sutil.SetOperatorSettingOverrides(config.Spec.OperatorSettings())
+}
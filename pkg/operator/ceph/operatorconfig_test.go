@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"os"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateOperatorConfig(t *testing.T) {
+	config := &cephv1.CephOperatorConfig{ObjectMeta: metav1.ObjectMeta{Name: cephv1.OperatorConfigName}}
+	assert.NoError(t, ValidateOperatorConfig(config))
+
+	// the name of the CR is fixed
+	config.Name = "other"
+	assert.Error(t, ValidateOperatorConfig(config))
+	config.Name = cephv1.OperatorConfigName
+
+	// unknown settings
+	config.Spec.Settings = map[string]string{"CSI_LOG_LEVEL": "5"}
+	assert.NoError(t, ValidateOperatorConfig(config))
+	config.Spec.Settings = map[string]string{"LOG_LEVEL": "5"}
+	assert.Error(t, ValidateOperatorConfig(config))
+
+	// settings of the fields of the spec
+	config.Spec.Settings = map[string]string{"ROOK_CEPH_COMMANDS_RETRIES": "three"}
+	assert.Error(t, ValidateOperatorConfig(config))
+	config.Spec.Settings = map[string]string{"ROOK_CEPH_COMMANDS_RETRIES": "3"}
+	assert.NoError(t, ValidateOperatorConfig(config))
+	retries := 5
+	config.Spec.CephCommands = &cephv1.OperatorCephCommandsSpec{Retries: &retries}
+	assert.Error(t, ValidateOperatorConfig(config))
+	config.Spec.Settings = nil
+
+	// the max duration of the commands must be more than their timeout
+	timeout, maxDuration := 30, 20
+	config.Spec.CephCommands = &cephv1.OperatorCephCommandsSpec{TimeoutSeconds: &timeout, MaxDurationSeconds: &maxDuration}
+	assert.Error(t, ValidateOperatorConfig(config))
+	maxDuration = 60
+	assert.NoError(t, ValidateOperatorConfig(config))
+
+	// the log format and the log levels of the loggers
+	config.Spec.LogLevels = map[string]string{"ceph-block-pool-controller": "DEBUG"}
+	assert.NoError(t, ValidateOperatorConfig(config))
+	config.Spec.LogLevels = map[string]string{"ceph-block-pool-controller": "LOUD"}
+	assert.Error(t, ValidateOperatorConfig(config))
+	config.Spec.LogLevels = nil
+	config.Spec.Settings = map[string]string{"ROOK_LOG_FORMAT": "xml"}
+	assert.Error(t, ValidateOperatorConfig(config))
+	config.Spec.Settings = map[string]string{"ROOK_LOG_FORMAT": "json", "ROOK_LOG_LEVELS": "op-mon=DEBUG"}
+	assert.NoError(t, ValidateOperatorConfig(config))
+	config.Spec.Settings = nil
+
+	// the namespaces to watch
+	config.Spec.WatchNamespaces = []string{"team-a", "team-b"}
+	assert.NoError(t, ValidateOperatorConfig(config))
+	config.Spec.WatchNamespaces = []string{"team-a", "Team_B"}
+	assert.Error(t, ValidateOperatorConfig(config))
+	config.Spec.WatchNamespaces = nil
+}
+
+func TestEffectiveOperatorSettings(t *testing.T) {
+	os.Setenv("ROOK_ENABLE_DISCOVERY_DAEMON", "true")
+	defer os.Unsetenv("ROOK_ENABLE_DISCOVERY_DAEMON")
+	settings := effectiveOperatorSettings(
+		map[string]string{"ROOK_LOG_LEVEL": "DEBUG"},
+		map[string]string{"ROOK_LOG_LEVEL": "WARNING", "CSI_LOG_LEVEL": "5"})
+
+	sources := map[string]cephv1.OperatorSettingStatus{}
+	for i, setting := range settings {
+		if i > 0 {
+			assert.True(t, settings[i-1].Name < setting.Name)
+		}
+		sources[setting.Name] = setting
+	}
+	assert.Equal(t, cephv1.OperatorSettingStatus{Name: "ROOK_LOG_LEVEL", Value: "DEBUG", Source: cephv1.OperatorSettingSourceCR}, sources["ROOK_LOG_LEVEL"])
+	assert.Equal(t, cephv1.OperatorSettingStatus{Name: "CSI_LOG_LEVEL", Value: "5", Source: cephv1.OperatorSettingSourceConfigMap}, sources["CSI_LOG_LEVEL"])
+	assert.Equal(t, cephv1.OperatorSettingStatus{Name: "ROOK_ENABLE_DISCOVERY_DAEMON", Value: "true", Source: cephv1.OperatorSettingSourceEnv}, sources["ROOK_ENABLE_DISCOVERY_DAEMON"])
+	assert.Equal(t, cephv1.OperatorSettingStatus{Name: "ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS", Value: "15", Source: cephv1.OperatorSettingSourceDefault}, sources["ROOK_CEPH_COMMANDS_TIMEOUT_SECONDS"])
+}
@@ -19,6 +19,7 @@ package pool
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,6 +41,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -245,6 +247,7 @@ func TestCephBlockPoolController(t *testing.T) {
 		context:           c,
 		blockPoolContexts: make(map[string]*blockPoolHealth),
 		opManagerContext:  context.TODO(),
+		recorder:          record.NewFakeRecorder(5),
 	}
 
 	// Mock request to simulate Reconcile() being called on an event for a
@@ -300,6 +303,7 @@ func TestCephBlockPoolController(t *testing.T) {
 			context:           c,
 			blockPoolContexts: make(map[string]*blockPoolHealth),
 			opManagerContext:  context.TODO(),
+			recorder:          record.NewFakeRecorder(5),
 		}
 
 		res, err := r.Reconcile(ctx, req)
@@ -349,7 +353,7 @@ func TestCephBlockPoolController(t *testing.T) {
 		_, err := c.Clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
 		assert.NoError(t, err)
 
-		s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPoolList{})
+		s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPoolList{}, &cephv1.CephDRActionList{})
 		// Create a ReconcileCephBlockPool object with the scheme and fake client.
 		r = &ReconcileCephBlockPool{
 			client:            cl,
@@ -357,6 +361,7 @@ func TestCephBlockPoolController(t *testing.T) {
 			context:           c,
 			blockPoolContexts: make(map[string]*blockPoolHealth),
 			opManagerContext:  context.TODO(),
+			recorder:          record.NewFakeRecorder(5),
 		}
 		res, err := r.Reconcile(ctx, req)
 		assert.NoError(t, err)
@@ -367,6 +372,50 @@ func TestCephBlockPoolController(t *testing.T) {
 		assert.Equal(t, cephv1.ConditionReady, pool.Status.Phase)
 	})
 
+	t.Run("dry run", func(t *testing.T) {
+		dryRunPool := pool.DeepCopy()
+		dryRunPool.Annotations = map[string]string{opcontroller.DryRunAnnotation: "true"}
+		dryRunPool.Spec.Replicated.Size = 2
+		cl = fake.NewClientBuilder().WithRuntimeObjects(dryRunPool, cephCluster).Build()
+		c.Client = cl
+
+		var executed []string
+		c.Executor = &exectest.MockExecutor{
+			MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+				executed = append(executed, command+" "+strings.Join(args, " "))
+				if args[0] == "status" {
+					return `{"fsid":"c47cac40-9bee-4d52-823b-ccd803ba5bfe","health":{"checks":{},"status":"HEALTH_OK"},"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+				}
+				return "", nil
+			},
+		}
+		defer func() { c.Executor = executor }()
+
+		recorder := record.NewFakeRecorder(5)
+		r = &ReconcileCephBlockPool{
+			client:            cl,
+			scheme:            s,
+			context:           c,
+			blockPoolContexts: make(map[string]*blockPoolHealth),
+			opManagerContext:  context.TODO(),
+			recorder:          recorder,
+		}
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.False(t, res.Requeue)
+
+		// only the commands reading the cluster were run
+		for _, command := range executed {
+			assert.NotContains(t, command, "osd pool set")
+			assert.NotContains(t, command, "pool init")
+		}
+		event := <-recorder.Events
+		assert.Contains(t, event, "ReconcileDryRun")
+		assert.Contains(t, event, "ceph osd pool set my-pool size 2")
+		assert.Contains(t, event, "rbd pool init my-pool")
+		assert.Empty(t, recorder.Events)
+	})
+
 	t.Run("failure no mirror mode", func(t *testing.T) {
 		pool.Spec.Mirroring.Enabled = true
 		err := r.client.Update(context.TODO(), pool)
@@ -428,6 +477,7 @@ func TestCephBlockPoolController(t *testing.T) {
 			context:           c,
 			blockPoolContexts: make(map[string]*blockPoolHealth),
 			opManagerContext:  context.TODO(),
+			recorder:          record.NewFakeRecorder(5),
 		}
 
 		pool.Spec.Mirroring.Mode = "image"
@@ -469,6 +519,7 @@ func TestCephBlockPoolController(t *testing.T) {
 			context:           c,
 			blockPoolContexts: make(map[string]*blockPoolHealth),
 			opManagerContext:  context.TODO(),
+			recorder:          record.NewFakeRecorder(5),
 		}
 
 		pool.Spec.Mirroring.Peers.SecretNames = []string{peerSecretName}
@@ -506,6 +557,7 @@ func TestCephBlockPoolController(t *testing.T) {
 			context:           c,
 			blockPoolContexts: make(map[string]*blockPoolHealth),
 			opManagerContext:  context.TODO(),
+			recorder:          record.NewFakeRecorder(5),
 		}
 		pool.Spec.Mirroring.Enabled = false
 		pool.Spec.Mirroring.Mode = "image"
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// groupReplicationStatus aggregates the csi-addons VolumeGroupReplications of the
+// VolumeGroupReplicationClass generated for the pool. A nil status is returned when the class was
+// not generated, since the groups of the pool are not managed by the cluster.
+func (c *mirrorChecker) groupReplicationStatus() (*cephv1.GroupReplicationStatusSpec, error) {
+	version, err := csi.GroupReplicationVersion(c.context.Clientset.Discovery())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect the volume group replication api")
+	}
+	if version == "" {
+		return nil, nil
+	}
+
+	className := csi.RBDGroupReplicationClassName(c.namespacedName.Namespace, c.namespacedName.Name)
+	class := &unstructured.Unstructured{}
+	class.SetGroupVersionKind(schema.GroupVersionKind{Group: csi.ReplicationGroup, Version: version, Kind: csi.VolumeGroupReplicationClassKind})
+	err = c.client.Get(c.clusterInfo.Context, types.NamespacedName{Name: className}, class)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get volume group replication class %q", className)
+	}
+
+	groups := &unstructured.UnstructuredList{}
+	groups.SetGroupVersionKind(schema.GroupVersionKind{Group: csi.ReplicationGroup, Version: version, Kind: csi.VolumeGroupReplicationKind + "List"})
+	err = c.client.List(c.clusterInfo.Context, groups)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the volume group replications")
+	}
+
+	status := &cephv1.GroupReplicationStatusSpec{
+		ClassName:   className,
+		LastChecked: time.Now().UTC().Format(time.RFC3339),
+	}
+	for i := range groups.Items {
+		group := &groups.Items[i]
+		groupClassName, _, _ := unstructured.NestedString(group.Object, "spec", "volumeGroupReplicationClassName")
+		if groupClassName != className {
+			continue
+		}
+		status.Groups++
+
+		// The state reported by the csi-addons controller is preferred to the desired state
+		state, _, _ := unstructured.NestedString(group.Object, "status", "state")
+		if state == "" {
+			state, _, _ = unstructured.NestedString(group.Object, "spec", "replicationState")
+		}
+		switch strings.ToLower(state) {
+		case "primary":
+			status.Primary++
+		case "secondary":
+			status.Secondary++
+		}
+
+		if isGroupReplicationDegraded(group) {
+			status.Degraded++
+		}
+
+		pvcs, _, _ := unstructured.NestedSlice(group.Object, "status", "persistentVolumeClaimsRefList")
+		status.PersistentVolumeClaims += len(pvcs)
+	}
+
+	return status, nil
+}
+
+// isGroupReplicationDegraded returns whether the Degraded condition of a VolumeGroupReplication is true
+func isGroupReplicationDegraded(group *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(group.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Degraded" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGroupReplication reports the VolumeGroupReplications of the pool in its status
+func (c *mirrorChecker) checkGroupReplication() {
+	// Only the images of a pool in image mode can be replicated in groups
+	if c.poolSpec.Mirroring.Mode != "image" {
+		return
+	}
+
+	status, err := c.groupReplicationStatus()
+	if err != nil {
+		logger.Debugf("failed to check the volume group replications of ceph block pool %q. %v", c.namespacedName.Name, err)
+		status = &cephv1.GroupReplicationStatusSpec{
+			ClassName: csi.RBDGroupReplicationClassName(c.namespacedName.Namespace, c.namespacedName.Name),
+			Details:   err.Error(),
+		}
+	}
+	c.updateStatusGroupReplication(status)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newVolumeGroupReplication(name, className, state string, degraded bool, pvcs int) *unstructured.Unstructured {
+	group := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "replication.storage.openshift.io/v1alpha1",
+		"kind":       "VolumeGroupReplication",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "app"},
+		"spec": map[string]interface{}{
+			"volumeGroupReplicationClassName": className,
+			"replicationState":                state,
+		},
+	}}
+	refs := []interface{}{}
+	for i := 0; i < pvcs; i++ {
+		refs = append(refs, map[string]interface{}{"name": "pvc"})
+	}
+	degradedStatus := "False"
+	if degraded {
+		degradedStatus = "True"
+	}
+	group.Object["status"] = map[string]interface{}{
+		"persistentVolumeClaimsRefList": refs,
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Completed", "status": "True"},
+			map[string]interface{}{"type": "Degraded", "status": degradedStatus},
+		},
+	}
+	return group
+}
+
+func TestGroupReplicationStatus(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	className := "rook-ceph-mypool-rbdplugin-groupreplicationclass"
+	class := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "replication.storage.openshift.io/v1alpha1",
+		"kind":       "VolumeGroupReplicationClass",
+		"metadata":   map[string]interface{}{"name": className},
+	}}
+	cl := clientfake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	c := &mirrorChecker{
+		context:        &clusterd.Context{Clientset: clientset},
+		client:         cl,
+		clusterInfo:    &cephclient.ClusterInfo{Context: ctx},
+		namespacedName: types.NamespacedName{Name: "mypool", Namespace: "rook-ceph"},
+		poolSpec:       &cephv1.NamedPoolSpec{Name: "mypool", PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}}},
+	}
+
+	t.Run("no replication crds", func(t *testing.T) {
+		status, err := c.groupReplicationStatus()
+		assert.NoError(t, err)
+		assert.Nil(t, status)
+	})
+
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: "replication.storage.openshift.io/v1alpha1", APIResources: []metav1.APIResource{{Name: "volumegroupreplications", Kind: "VolumeGroupReplication"}}},
+	}
+
+	t.Run("no class generated for the pool", func(t *testing.T) {
+		status, err := c.groupReplicationStatus()
+		assert.NoError(t, err)
+		assert.Nil(t, status)
+	})
+
+	t.Run("groups of the class are aggregated", func(t *testing.T) {
+		assert.NoError(t, cl.Create(ctx, class))
+		for _, group := range []*unstructured.Unstructured{
+			newVolumeGroupReplication("db", className, "primary", false, 3),
+			newVolumeGroupReplication("queue", className, "primary", true, 2),
+			newVolumeGroupReplication("standby", className, "secondary", false, 1),
+			newVolumeGroupReplication("other", "other-class", "primary", true, 4),
+		} {
+			assert.NoError(t, cl.Create(ctx, group))
+		}
+
+		status, err := c.groupReplicationStatus()
+		assert.NoError(t, err)
+		assert.Equal(t, className, status.ClassName)
+		assert.Equal(t, 3, status.Groups)
+		assert.Equal(t, 2, status.Primary)
+		assert.Equal(t, 1, status.Secondary)
+		assert.Equal(t, 1, status.Degraded)
+		assert.Equal(t, 6, status.PersistentVolumeClaims)
+		assert.NotEmpty(t, status.LastChecked)
+	})
+}
@@ -23,7 +23,9 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -38,10 +40,15 @@ type mirrorChecker struct {
 	clusterInfo    *cephclient.ClusterInfo
 	namespacedName types.NamespacedName
 	poolSpec       *cephv1.NamedPoolSpec
+	metrics        *poolMirrorMetrics
+	peers          *opcontroller.MirrorPeerProber
+	// imagesWithoutSchedule is the number of snapshot mirrored images without snapshot schedule
+	// found by the last check
+	imagesWithoutSchedule int
 }
 
 // newMirrorChecker creates a new HealthChecker object
-func newMirrorChecker(context *clusterd.Context, client client.Client, clusterInfo *cephclient.ClusterInfo, namespacedName types.NamespacedName, poolSpec *cephv1.NamedPoolSpec) *mirrorChecker {
+func newMirrorChecker(context *clusterd.Context, client client.Client, recorder record.EventRecorder, clusterInfo *cephclient.ClusterInfo, namespacedName types.NamespacedName, poolSpec *cephv1.NamedPoolSpec) *mirrorChecker {
 	c := &mirrorChecker{
 		context:        context,
 		interval:       &defaultHealthCheckInterval,
@@ -49,6 +56,8 @@ func newMirrorChecker(context *clusterd.Context, client client.Client, clusterIn
 		namespacedName: namespacedName,
 		client:         client,
 		poolSpec:       poolSpec,
+		metrics:        newPoolMirrorMetrics(namespacedName.Namespace, poolSpec.Name),
+		peers:          opcontroller.NewMirrorPeerProber(context, recorder, cephBlockPoolKind, namespacedName),
 	}
 
 	// allow overriding the check interval
@@ -69,11 +78,16 @@ func (c *mirrorChecker) checkMirroring(context context.Context) {
 		c.updateStatusMirroring(nil, nil, nil, err.Error())
 		logger.Debugf("failed to check pool mirroring status for ceph block pool %q. %v", c.namespacedName.Name, err)
 	}
+	c.checkPeersConnection()
+	c.checkGroupReplication()
+	c.updateStatusReplication()
 
 	for {
 		select {
 		case <-context.Done():
 			logger.Infof("stopping monitoring pool mirroring status %q", c.namespacedName.Name)
+			c.metrics.clear()
+			c.peers.Clear()
 			return
 
 		case <-time.After(*c.interval):
@@ -83,6 +97,9 @@ func (c *mirrorChecker) checkMirroring(context context.Context) {
 				c.updateStatusMirroring(nil, nil, nil, err.Error())
 				logger.Debugf("failed to check pool mirroring status for ceph block pool %q. %v", c.namespacedName.Name, err)
 			}
+			c.checkPeersConnection()
+			c.checkGroupReplication()
+			c.updateStatusReplication()
 		}
 	}
 }
@@ -91,7 +108,8 @@ func (c *mirrorChecker) checkMirroringHealth() error {
 	// Check mirroring status
 	mirrorStatus, err := cephclient.GetPoolMirroringStatus(c.context, c.clusterInfo, c.poolSpec.Name)
 	if err != nil {
-		c.updateStatusMirroring(nil, nil, nil, err.Error())
+		// the status is updated with the error by the caller
+		return err
 	}
 
 	// Check mirroring info
@@ -104,14 +122,72 @@ func (c *mirrorChecker) checkMirroringHealth() error {
 	// snapSchedStatus := cephclient.SnapshotScheduleStatus{}
 	snapSchedStatus := []cephv1.SnapshotSchedulesSpec{}
 	if c.poolSpec.Mirroring.SnapshotSchedulesEnabled() {
+		// Add again the schedules of the spec that went missing since the pool was reconciled, unless
+		// they are paused by a quiesce dr action
+		quiesced, err := opcontroller.IsPoolQuiesced(c.clusterInfo.Context, c.client, c.namespacedName)
+		if err != nil {
+			logger.Warningf("failed to check whether ceph block pool %q is quiesced. %v", c.namespacedName.Name, err)
+		} else if !quiesced {
+			repaired, err := cephclient.RepairSnapshotSchedules(c.context, c.clusterInfo, *c.poolSpec)
+			if err != nil {
+				logger.Warningf("failed to repair the snapshot schedules of ceph block pool %q. %v", c.namespacedName.Name, err)
+			} else if repaired > 0 {
+				logger.Infof("added %d missing snapshot schedule(s) to ceph block pool %q", repaired, c.namespacedName.Name)
+			}
+		}
+
 		snapSchedStatus, err = cephclient.ListSnapshotSchedulesRecursively(c.context, c.clusterInfo, c.poolSpec.Name)
 		if err != nil {
 			c.updateStatusMirroring(nil, nil, nil, err.Error())
 		}
 	}
 
+	// Only the images of a pool in image mode can be mirrored with snapshots
+	if c.poolSpec.Mirroring.Mode == "image" {
+		count, err := cephclient.CountImagesWithoutSnapshotSchedule(c.context, c.clusterInfo, c.poolSpec.Name)
+		if err != nil {
+			logger.Debugf("failed to count the images without snapshot schedule of ceph block pool %q. %v", c.namespacedName.Name, err)
+		} else {
+			if count > 0 {
+				logger.Debugf("%d snapshot mirrored image(s) of ceph block pool %q have no snapshot schedule", count, c.namespacedName.Name)
+			}
+			c.imagesWithoutSchedule = count
+			c.metrics.setImagesWithoutSchedule(count)
+		}
+	}
+
 	// On success
 	c.updateStatusMirroring(mirrorStatus.Summary, mirrorInfo, snapSchedStatus, "")
 
+	// Export the mirroring state and the replication lag of the images as metrics
+	if mirrorStatus.Summary != nil {
+		c.metrics.setSummary(mirrorStatus.Summary)
+	}
+	imagesStatus, err := cephclient.GetPoolMirroringImagesStatus(c.context, c.clusterInfo, c.poolSpec.Name)
+	if err != nil {
+		logger.Debugf("failed to check the mirroring status of the images of ceph block pool %q. %v", c.namespacedName.Name, err)
+		return nil
+	}
+	c.metrics.setImages(imagesStatus)
+	c.checkImageModeMigration(imagesStatus)
+
 	return nil
 }
+
+// checkPeersConnection probes the remote clusters of the peers of the pool, a rotated key or an
+// unreachable peer site is reported in the PeerConnected condition of the pool
+func (c *mirrorChecker) checkPeersConnection() {
+	if c.poolSpec.Mirroring.Peers == nil || len(c.poolSpec.Mirroring.Peers.SecretNames) == 0 {
+		c.peers.Clear()
+		return
+	}
+
+	blockPool := &cephv1.CephBlockPool{}
+	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, blockPool); err != nil {
+		logger.Debugf("failed to retrieve ceph block pool %q to probe its mirror peers. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	condition := c.peers.Probe(c.clusterInfo.Context, blockPool, c.poolSpec.Mirroring.Peers.SecretNames)
+	c.updateStatusPeerConnected(condition)
+}
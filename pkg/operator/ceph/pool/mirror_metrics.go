@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// The mirroring metrics of the pools are exported by the operator from the mirroring status it
+// checks, the mgr only exports the rbd-mirror daemon counters
+var (
+	poolMirroringHealth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_pool_mirroring_health",
+		Help: "Mirroring health of the pool: 0 for OK, 1 for WARNING and 2 for ERROR",
+	}, []string{"namespace", "pool"})
+	poolMirroringImages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_pool_mirroring_images",
+		Help: "The number of mirrored images of the pool in each mirroring state",
+	}, []string{"namespace", "pool", "state"})
+	poolMirroringBytesBehind = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_pool_mirroring_bytes_behind",
+		Help: "Estimation of the bytes of the snapshots being synced that are not synced yet, for all the images of the pool",
+	}, []string{"namespace", "pool"})
+	poolMirroringEntriesBehind = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_pool_mirroring_entries_behind",
+		Help: "The number of journal entries not replayed yet, for all the journal mirrored images of the pool",
+	}, []string{"namespace", "pool"})
+	poolMirroringSnapshotSyncAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_pool_mirroring_snapshot_sync_age_seconds",
+		Help: "Age of the last synced snapshot of the image of the pool that was synced the longest time ago",
+	}, []string{"namespace", "pool"})
+	poolMirroringImagesWithoutSchedule = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_pool_mirroring_images_without_snapshot_schedule",
+		Help: "The number of primary images of the pool mirrored with snapshots that have no snapshot schedule",
+	}, []string{"namespace", "pool"})
+)
+
+var mirroringHealthValues = map[string]float64{
+	"OK":      0,
+	"WARNING": 1,
+	"ERROR":   2,
+}
+
+func init() {
+	metrics.Registry.MustRegister(
+		poolMirroringHealth,
+		poolMirroringImages,
+		poolMirroringBytesBehind,
+		poolMirroringEntriesBehind,
+		poolMirroringSnapshotSyncAge,
+		poolMirroringImagesWithoutSchedule,
+	)
+}
+
+// poolMirrorMetrics exports the mirroring status of a pool
+type poolMirrorMetrics struct {
+	namespace string
+	pool      string
+	now       func() time.Time
+}
+
+func newPoolMirrorMetrics(namespace, pool string) *poolMirrorMetrics {
+	return &poolMirrorMetrics{namespace: namespace, pool: pool, now: time.Now}
+}
+
+// setSummary reports the mirroring health and the number of images in each state
+func (m *poolMirrorMetrics) setSummary(summary *cephv1.PoolMirroringStatusSummarySpec) {
+	if value, ok := mirroringHealthValues[summary.Health]; ok {
+		poolMirroringHealth.WithLabelValues(m.namespace, m.pool).Set(value)
+	} else {
+		poolMirroringHealth.DeleteLabelValues(m.namespace, m.pool)
+	}
+
+	for state, count := range imageStateCounts(summary.States) {
+		poolMirroringImages.WithLabelValues(m.namespace, m.pool, state).Set(float64(count))
+	}
+}
+
+func imageStateCounts(states cephv1.StatesSpec) map[string]int {
+	return map[string]int{
+		"starting_replay": states.StartingReplay,
+		"replaying":       states.Replaying,
+		"syncing":         states.Syncing,
+		"stopping_replay": states.StopReplaying,
+		"stopped":         states.Stopped,
+		"unknown":         states.Unknown,
+		"error":           states.Error,
+	}
+}
+
+// setImages reports the replication lag of the images. The replay progress of an image is reported
+// by the rbd-mirror daemon of the site of the non-primary image, in the description of the image
+// on the secondary site and in the description of the peer site on the primary site.
+func (m *poolMirrorMetrics) setImages(images *cephclient.PoolMirroringImagesStatus) {
+	var bytesBehind float64
+	var entriesBehind int64
+	var oldestSync int64
+	snapshotMirrored := false
+	for _, image := range images.Images {
+		descriptions := []string{image.Description}
+		for _, site := range image.PeerSites {
+			descriptions = append(descriptions, site.Description)
+		}
+
+		for _, description := range descriptions {
+			replay, ok := cephclient.ParseImageReplayStatus(description)
+			if !ok {
+				continue
+			}
+			entriesBehind += replay.EntriesBehindPrimary
+			if replay.LocalSnapshotTimestamp == 0 {
+				continue
+			}
+			snapshotMirrored = true
+			if oldestSync == 0 || replay.LocalSnapshotTimestamp < oldestSync {
+				oldestSync = replay.LocalSnapshotTimestamp
+			}
+			if replay.RemoteSnapshotTimestamp > replay.LocalSnapshotTimestamp {
+				bytesBehind += replay.BytesPerSnapshot * (100 - replay.SyncingPercent) / 100
+			}
+		}
+	}
+
+	poolMirroringBytesBehind.WithLabelValues(m.namespace, m.pool).Set(bytesBehind)
+	poolMirroringEntriesBehind.WithLabelValues(m.namespace, m.pool).Set(float64(entriesBehind))
+	if snapshotMirrored {
+		age := m.now().Sub(time.Unix(oldestSync, 0)).Seconds()
+		poolMirroringSnapshotSyncAge.WithLabelValues(m.namespace, m.pool).Set(age)
+	} else {
+		poolMirroringSnapshotSyncAge.DeleteLabelValues(m.namespace, m.pool)
+	}
+}
+
+// setImagesWithoutSchedule reports the number of snapshot mirrored images without snapshot schedule
+func (m *poolMirrorMetrics) setImagesWithoutSchedule(count int) {
+	poolMirroringImagesWithoutSchedule.WithLabelValues(m.namespace, m.pool).Set(float64(count))
+}
+
+// clear removes all the mirroring metrics of the pool, when its mirroring is not monitored anymore
+func (m *poolMirrorMetrics) clear() {
+	poolMirroringHealth.DeleteLabelValues(m.namespace, m.pool)
+	for state := range imageStateCounts(cephv1.StatesSpec{}) {
+		poolMirroringImages.DeleteLabelValues(m.namespace, m.pool, state)
+	}
+	poolMirroringBytesBehind.DeleteLabelValues(m.namespace, m.pool)
+	poolMirroringEntriesBehind.DeleteLabelValues(m.namespace, m.pool)
+	poolMirroringSnapshotSyncAge.DeleteLabelValues(m.namespace, m.pool)
+	poolMirroringImagesWithoutSchedule.DeleteLabelValues(m.namespace, m.pool)
+}
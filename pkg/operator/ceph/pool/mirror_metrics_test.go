@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolMirrorMetrics(t *testing.T) {
+	m := newPoolMirrorMetrics("rook-ceph", "mirrored")
+	m.now = func() time.Time { return time.Unix(1000, 0) }
+	defer m.clear()
+
+	m.setSummary(&cephv1.PoolMirroringStatusSummarySpec{
+		Health: "WARNING",
+		States: cephv1.StatesSpec{Replaying: 2, Error: 1},
+	})
+	assert.Equal(t, float64(1), testutil.ToFloat64(poolMirroringHealth.WithLabelValues("rook-ceph", "mirrored")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(poolMirroringImages.WithLabelValues("rook-ceph", "mirrored", "replaying")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(poolMirroringImages.WithLabelValues("rook-ceph", "mirrored", "error")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(poolMirroringImages.WithLabelValues("rook-ceph", "mirrored", "stopped")))
+
+	t.Run("secondary site", func(t *testing.T) {
+		m.setImages(&cephclient.PoolMirroringImagesStatus{Images: []cephclient.MirroredImageStatus{
+			// synced
+			{Name: "a", Description: `replaying, {"bytes_per_snapshot":1000.0,"local_snapshot_timestamp":900,"remote_snapshot_timestamp":900,"replay_state":"idle"}`},
+			// syncing the next snapshot
+			{Name: "b", Description: `syncing, {"bytes_per_snapshot":2000.0,"local_snapshot_timestamp":400,"remote_snapshot_timestamp":950,"syncing_percent":25}`},
+			// journal
+			{Name: "c", Description: `replaying, {"entries_behind_primary":7,"primary_position":{}}`},
+			{Name: "d", Description: "local image is primary"},
+		}})
+		assert.Equal(t, float64(1500), testutil.ToFloat64(poolMirroringBytesBehind.WithLabelValues("rook-ceph", "mirrored")))
+		assert.Equal(t, float64(7), testutil.ToFloat64(poolMirroringEntriesBehind.WithLabelValues("rook-ceph", "mirrored")))
+		assert.Equal(t, float64(600), testutil.ToFloat64(poolMirroringSnapshotSyncAge.WithLabelValues("rook-ceph", "mirrored")))
+	})
+
+	t.Run("primary site", func(t *testing.T) {
+		m.setImages(&cephclient.PoolMirroringImagesStatus{Images: []cephclient.MirroredImageStatus{
+			{Name: "a", Description: "local image is primary", PeerSites: []cephclient.MirroredImagePeerSiteStatus{
+				{SiteName: "secondary", Description: `replaying, {"bytes_per_snapshot":1000.0,"local_snapshot_timestamp":800,"remote_snapshot_timestamp":800}`},
+			}},
+		}})
+		assert.Equal(t, float64(0), testutil.ToFloat64(poolMirroringBytesBehind.WithLabelValues("rook-ceph", "mirrored")))
+		assert.Equal(t, float64(200), testutil.ToFloat64(poolMirroringSnapshotSyncAge.WithLabelValues("rook-ceph", "mirrored")))
+	})
+
+	t.Run("no snapshot mirrored image", func(t *testing.T) {
+		m.setImages(&cephclient.PoolMirroringImagesStatus{})
+		assert.Equal(t, 0, testutil.CollectAndCount(poolMirroringSnapshotSyncAge))
+	})
+
+	t.Run("images without snapshot schedule", func(t *testing.T) {
+		m.setImagesWithoutSchedule(3)
+		assert.Equal(t, float64(3), testutil.ToFloat64(poolMirroringImagesWithoutSchedule.WithLabelValues("rook-ceph", "mirrored")))
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		m.clear()
+		assert.Equal(t, 0, testutil.CollectAndCount(poolMirroringHealth))
+		assert.Equal(t, 0, testutil.CollectAndCount(poolMirroringImages))
+		assert.Equal(t, 0, testutil.CollectAndCount(poolMirroringBytesBehind))
+		assert.Equal(t, 0, testutil.CollectAndCount(poolMirroringEntriesBehind))
+		assert.Equal(t, 0, testutil.CollectAndCount(poolMirroringImagesWithoutSchedule))
+	})
+}
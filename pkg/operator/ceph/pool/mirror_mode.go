@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+)
+
+// imageModeMigrationBatchSize is the maximum number of images migrated to another mirroring mode
+// at each mirroring status check. Each migrated image is synced again from scratch by the peers, so
+// fewer images are migrated while the images of the previous checks are still syncing.
+var imageModeMigrationBatchSize = 5
+
+// checkImageModeMigration migrates the primary images of the pool mirrored in another mode than
+// the image mode of the spec, and reports the progress in the pool status
+func (c *mirrorChecker) checkImageModeMigration(imagesStatus *cephclient.PoolMirroringImagesStatus) {
+	if c.poolSpec.Mirroring.Mode != "image" || c.poolSpec.Mirroring.ImageMode == "" {
+		c.updateStatusImageModeMigration(nil)
+		return
+	}
+	c.updateStatusImageModeMigration(c.migrateImageMode(imagesStatus))
+}
+
+func (c *mirrorChecker) migrateImageMode(imagesStatus *cephclient.PoolMirroringImagesStatus) *cephv1.ImageModeMigrationStatusSpec {
+	mode := c.poolSpec.Mirroring.ImageMode
+	status := &cephv1.ImageModeMigrationStatusSpec{
+		ImageMode:   mode,
+		LastChecked: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	pending := []string{}
+	syncing := 0
+	for _, image := range imagesStatus.Images {
+		if isImageSyncing(image) {
+			syncing++
+		}
+		info, err := cephclient.GetImageInfo(c.context, c.clusterInfo, image.Name, c.poolSpec.Name)
+		if err != nil {
+			status.Details = fmt.Sprintf("failed to get the mirroring mode of image %q. %v", image.Name, err)
+			return status
+		}
+		// the peers follow the mode of the primary images
+		if info.Mirroring == nil || !info.Mirroring.Primary || info.Mirroring.Mode == mode {
+			continue
+		}
+		pending = append(pending, image.Name)
+	}
+	status.Pending = len(pending)
+	if len(pending) == 0 {
+		return status
+	}
+
+	// A planned failover must not wait for the full sync of migrated images
+	quiesced, err := opcontroller.IsPoolQuiesced(c.clusterInfo.Context, c.client, c.namespacedName)
+	if err != nil {
+		status.Details = err.Error()
+		return status
+	}
+	if quiesced {
+		status.Details = "the migration is paused while the pool is quiesced for a failover"
+		return status
+	}
+
+	batch := imageModeMigrationBatchSize - syncing
+	if batch <= 0 {
+		status.Details = fmt.Sprintf("waiting for %d image(s) to sync before migrating more images", syncing)
+		return status
+	}
+	if batch < len(pending) {
+		pending = pending[:batch]
+	}
+	for _, imageName := range pending {
+		err := cephclient.MigrateImageMirroringMode(c.context, c.clusterInfo, c.poolSpec.Name, imageName, mode)
+		if err != nil {
+			status.Details = err.Error()
+			logger.Warningf("failed to migrate the mirroring of an image of ceph block pool %q. %v", c.namespacedName.Name, err)
+			break
+		}
+		status.Migrated++
+		status.Pending--
+	}
+	if status.Migrated > 0 {
+		logger.Infof("migrated %d image(s) of ceph block pool %q to %q mirroring mode, %d remaining", status.Migrated, c.namespacedName.Name, mode, status.Pending)
+	}
+
+	return status
+}
+
+// isImageSyncing returns whether a peer of an image is doing the initial sync of the image, like
+// after the migration of the image to another mirroring mode
+func isImageSyncing(image cephclient.MirroredImageStatus) bool {
+	for _, peer := range image.PeerSites {
+		if strings.Contains(peer.State, "syncing") || strings.Contains(peer.State, "starting_replay") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMigrateImageMode(t *testing.T) {
+	ctx := context.TODO()
+	modes := map[string]string{}
+	imagesStatus := &cephclient.PoolMirroringImagesStatus{}
+	for i := 1; i <= 8; i++ {
+		name := fmt.Sprintf("image%d", i)
+		modes[name] = "journal"
+		imagesStatus.Images = append(imagesStatus.Images, cephclient.MirroredImageStatus{
+			Name:      name,
+			PeerSites: []cephclient.MirroredImagePeerSiteStatus{{State: "up+replaying"}},
+		})
+	}
+	// a non-primary image is migrated by its peer
+	modes["image8"] = "non-primary"
+
+	migrated := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			switch args[0] {
+			case "info":
+				name := args[1][len("mypool/"):]
+				if modes[name] == "non-primary" {
+					return `{"mirroring":{"mode":"journal","primary":false}}`, nil
+				}
+				return fmt.Sprintf(`{"features":["journaling"],"mirroring":{"mode":%q,"primary":true}}`, modes[name]), nil
+			case "feature":
+				return "", nil
+			case "mirror":
+				if args[2] == "enable" {
+					name := args[3][len("mypool/"):]
+					modes[name] = args[4]
+					migrated = append(migrated, name)
+				}
+				return "", nil
+			}
+			return "", errors.New("unknown command")
+		},
+	}
+	cl := clientfake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	c := &mirrorChecker{
+		context:        &clusterd.Context{Executor: executor},
+		client:         cl,
+		clusterInfo:    cephclient.AdminTestClusterInfo("rook-ceph"),
+		namespacedName: types.NamespacedName{Name: "mypool", Namespace: "rook-ceph"},
+		poolSpec: &cephv1.NamedPoolSpec{Name: "mypool", PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{
+			Enabled:   true,
+			Mode:      "image",
+			ImageMode: "snapshot",
+		}}},
+	}
+	c.clusterInfo.Context = ctx
+
+	t.Run("migrate a first batch", func(t *testing.T) {
+		status := c.migrateImageMode(imagesStatus)
+		assert.Equal(t, "snapshot", status.ImageMode)
+		assert.Equal(t, 5, status.Migrated)
+		assert.Equal(t, 2, status.Pending)
+		assert.Empty(t, status.Details)
+		assert.Equal(t, []string{"image1", "image2", "image3", "image4", "image5"}, migrated)
+	})
+
+	t.Run("wait for the migrated images to sync", func(t *testing.T) {
+		migrated = []string{}
+		for i := 0; i < 4; i++ {
+			imagesStatus.Images[i].PeerSites[0].State = "up+syncing"
+		}
+		status := c.migrateImageMode(imagesStatus)
+		assert.Equal(t, 1, status.Migrated)
+		assert.Equal(t, 1, status.Pending)
+		assert.Equal(t, []string{"image6"}, migrated)
+
+		imagesStatus.Images[4].PeerSites[0].State = "up+starting_replay"
+		status = c.migrateImageMode(imagesStatus)
+		assert.Equal(t, 0, status.Migrated)
+		assert.Equal(t, 1, status.Pending)
+		assert.Contains(t, status.Details, "waiting for 5 image(s) to sync")
+	})
+
+	t.Run("paused while the pool is quiesced", func(t *testing.T) {
+		for i := range imagesStatus.Images {
+			imagesStatus.Images[i].PeerSites[0].State = "up+replaying"
+		}
+		action := &cephv1.CephDRAction{
+			ObjectMeta: metav1.ObjectMeta{Name: "failover", Namespace: "rook-ceph"},
+			Spec:       cephv1.DRActionSpec{Action: cephv1.DRActionQuiesce, Pools: []string{"mypool"}},
+		}
+		assert.NoError(t, cl.Create(ctx, action))
+		status := c.migrateImageMode(imagesStatus)
+		assert.Equal(t, 0, status.Migrated)
+		assert.Equal(t, 1, status.Pending)
+		assert.Contains(t, status.Details, "quiesced")
+
+		assert.NoError(t, cl.Delete(ctx, action))
+		status = c.migrateImageMode(imagesStatus)
+		assert.Equal(t, 1, status.Migrated)
+		assert.Equal(t, 0, status.Pending)
+	})
+}
@@ -0,0 +1,312 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mirrorpeer to manage the peers of the rbd mirroring of the pools
+package mirrorpeer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/util/exec"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-rbd-mirror-peer-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephRBDMirrorPeerKind = reflect.TypeOf(cephv1.CephRBDMirrorPeer{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephRBDMirrorPeerKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+var defaultHealthCheckInterval = 1 * time.Minute
+
+// ReconcileCephRBDMirrorPeer reconciles a CephRBDMirrorPeer object
+type ReconcileCephRBDMirrorPeer struct {
+	client           client.Client
+	scheme           *runtime.Scheme
+	context          *clusterd.Context
+	clusterInfo      *cephclient.ClusterInfo
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+	probers          map[string]*opcontroller.MirrorPeerProber
+}
+
+// Add creates a new CephRBDMirrorPeer Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	return add(mgr, newReconciler(mgr, context, opManagerContext))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context) reconcile.Reconciler {
+	return &ReconcileCephRBDMirrorPeer{
+		client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
+		probers:          map[string]*opcontroller.MirrorPeerProber{},
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephRBDMirrorPeer{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephRBDMirrorPeer{}, reconciler)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephRBDMirrorPeer CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephRBDMirrorPeer{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephRBDMirrorPeer object and makes changes based on the state read
+// and what is in the CephRBDMirrorPeer.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephRBDMirrorPeer) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, cephRBDMirrorPeer, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephRBDMirrorPeer, reconcileResponse, err)
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephRBDMirrorPeer) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephRBDMirrorPeer, error) {
+	// Fetch the CephRBDMirrorPeer instance
+	cephRBDMirrorPeer := &cephv1.CephRBDMirrorPeer{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephRBDMirrorPeer)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephRBDMirrorPeer resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, cephRBDMirrorPeer, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, cephRBDMirrorPeer, errors.Wrap(err, "failed to get cephRBDMirrorPeer")
+	}
+
+	// Set a finalizer so we can remove the peer from the pools before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephRBDMirrorPeer)
+	if err != nil {
+		return reconcile.Result{}, cephRBDMirrorPeer, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// The CR was just created, initializing status fields
+	if cephRBDMirrorPeer.Status == nil {
+		cephRBDMirrorPeer.Status = &cephv1.CephRBDMirrorPeerStatus{Phase: cephv1.ConditionProgressing}
+		r.updateStatus(request.NamespacedName, cephRBDMirrorPeer.Status)
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// The peers are gone with the pools of the cluster, only remove the finalizer
+		if !cephRBDMirrorPeer.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephRBDMirrorPeer)
+			if err != nil {
+				return opcontroller.ImmediateRetryResult, cephRBDMirrorPeer, errors.Wrap(err, "failed to remove finalizer")
+			}
+			return reconcile.Result{}, cephRBDMirrorPeer, nil
+		}
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, cephRBDMirrorPeer, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephRBDMirrorPeer", request.Namespace, request.Name), request.NamespacedName.Namespace)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, cephRBDMirrorPeer, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	// DELETE: the CR was deleted
+	if !cephRBDMirrorPeer.GetDeletionTimestamp().IsZero() {
+		logger.Debugf("deleting rbd mirror peer %q", request.NamespacedName)
+		err = r.removePeers(cephRBDMirrorPeer.Status.Pools, nil)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, cephRBDMirrorPeer, errors.Wrapf(err, "failed to remove rbd mirror peer %q", request.NamespacedName)
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephRBDMirrorPeer)
+		if err != nil {
+			return reconcile.Result{}, cephRBDMirrorPeer, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// The peer is not probed anymore
+		if prober, ok := r.probers[request.NamespacedName.String()]; ok {
+			prober.Clear()
+			delete(r.probers, request.NamespacedName.String())
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, cephRBDMirrorPeer, nil
+	}
+
+	if err := ValidateMirrorPeerSpec(&cephRBDMirrorPeer.Spec); err != nil {
+		cephRBDMirrorPeer.Status.Phase = cephv1.ConditionFailure
+		cephRBDMirrorPeer.Status.Message = err.Error()
+		r.updateStatus(request.NamespacedName, cephRBDMirrorPeer.Status)
+		return reconcile.Result{}, cephRBDMirrorPeer, errors.Wrapf(err, "invalid rbd mirror peer %q", request.NamespacedName)
+	}
+
+	// Remove the peer from the pools that are not listed anymore
+	err = r.removePeers(cephRBDMirrorPeer.Status.Pools, cephRBDMirrorPeer.Spec.Pools)
+	if err != nil {
+		cephRBDMirrorPeer.Status.Phase = cephv1.ConditionFailure
+		cephRBDMirrorPeer.Status.Message = err.Error()
+		r.updateStatus(request.NamespacedName, cephRBDMirrorPeer.Status)
+		return opcontroller.ImmediateRetryResult, cephRBDMirrorPeer, errors.Wrapf(err, "failed to remove rbd mirror peer %q from the unlisted pools", request.NamespacedName)
+	}
+
+	token, direction, err := r.getPeerToken(cephRBDMirrorPeer)
+	if err != nil {
+		cephRBDMirrorPeer.Status.Phase = cephv1.ConditionFailure
+		cephRBDMirrorPeer.Status.Message = err.Error()
+		r.updateStatus(request.NamespacedName, cephRBDMirrorPeer.Status)
+		return opcontroller.ImmediateRetryResult, cephRBDMirrorPeer, errors.Wrapf(err, "failed to get the token of rbd mirror peer %q", request.NamespacedName)
+	}
+
+	// Add the peer to the pools and check their mirroring health. The peer is imported again when
+	// the spec changed, the mirroring health is checked at each reconcile.
+	importToken := cephRBDMirrorPeer.Status.ObservedGeneration != cephRBDMirrorPeer.Generation
+	status := &cephv1.CephRBDMirrorPeerStatus{
+		Phase:              cephv1.ConditionReady,
+		ObservedGeneration: cephRBDMirrorPeer.Generation,
+		LastChecked:        time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, poolName := range cephRBDMirrorPeer.Spec.Pools {
+		poolStatus := r.reconcilePoolPeer(cephRBDMirrorPeer, poolName, token, direction, importToken)
+		if poolStatus.Message != "" {
+			status.Phase = cephv1.ConditionFailure
+			status.Message = fmt.Sprintf("failed to add the peer to pool %q", poolName)
+		}
+		status.Pools = append(status.Pools, poolStatus)
+	}
+
+	// Check that the remote cluster can still be reached with the token, a failure does not
+	// prevent the mirroring of the pools already set up with the peer
+	status.Conditions = cephRBDMirrorPeer.Status.Conditions
+	cephv1.SetStatusCondition(&status.Conditions, r.prober(request.NamespacedName).Probe(r.opManagerContext, cephRBDMirrorPeer, []string{cephRBDMirrorPeer.Spec.SecretName}))
+	r.updateStatus(request.NamespacedName, status)
+
+	if status.Phase != cephv1.ConditionReady {
+		logger.Warningf("rbd mirror peer %q is not ready. %s", request.NamespacedName, status.Message)
+		return opcontroller.WaitForRequeueIfCephClusterNotReady, cephRBDMirrorPeer, nil
+	}
+
+	// Requeue to keep reporting the mirroring health
+	healthCheck := cephRBDMirrorPeer.Spec.StatusCheck.Mirror
+	if healthCheck.Disabled {
+		logger.Debug("done reconciling")
+		return reconcile.Result{}, cephRBDMirrorPeer, nil
+	}
+	interval := defaultHealthCheckInterval
+	if healthCheck.Interval != nil {
+		interval = healthCheck.Interval.Duration
+	}
+	return reconcile.Result{RequeueAfter: interval}, cephRBDMirrorPeer, nil
+}
+
+// ValidateMirrorPeerSpec validates the rbd mirror peer settings
+func ValidateMirrorPeerSpec(spec *cephv1.RBDMirrorPeerSpec) error {
+	if spec.SecretName == "" {
+		return errors.New("secretName must be specified")
+	}
+	if len(spec.Pools) == 0 {
+		return errors.New("at least one pool must be specified")
+	}
+	seen := map[string]bool{}
+	for _, pool := range spec.Pools {
+		if seen[pool] {
+			return errors.Errorf("pool %q is specified more than once", pool)
+		}
+		seen[pool] = true
+	}
+	return nil
+}
+
+// prober returns the prober of the remote cluster of the peer, which keeps the result of the last
+// probe across the reconciles
+func (r *ReconcileCephRBDMirrorPeer) prober(name types.NamespacedName) *opcontroller.MirrorPeerProber {
+	prober, ok := r.probers[name.String()]
+	if !ok {
+		prober = opcontroller.NewMirrorPeerProber(r.context, r.recorder, cephRBDMirrorPeerKind, name)
+		r.probers[name.String()] = prober
+	}
+	return prober
+}
+
+// updateStatus updates an object with a given status
+func (r *ReconcileCephRBDMirrorPeer) updateStatus(name types.NamespacedName, status *cephv1.CephRBDMirrorPeerStatus) {
+	cephRBDMirrorPeer := &cephv1.CephRBDMirrorPeer{}
+	if err := r.client.Get(r.opManagerContext, name, cephRBDMirrorPeer); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephRBDMirrorPeer resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve rbd mirror peer %q to update status to %q. %v", name, status.Phase, err)
+		return
+	}
+
+	err := reporting.PatchStatus(r.client, cephRBDMirrorPeer, func() bool {
+		// the standard conditions are set from the result of the reconcile
+		if cephRBDMirrorPeer.Status != nil && status.Conditions == nil {
+			status.Conditions = cephRBDMirrorPeer.Status.Conditions
+		}
+		cephRBDMirrorPeer.Status = status
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set rbd mirror peer %q status to %q. %v", name, status.Phase, err)
+		return
+	}
+	logger.Debugf("rbd mirror peer %q status updated to %q", name, status.Phase)
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirrorpeer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	// token of the remote cluster c6b087f2-7829-4dbb-bcfc-53dc34e0b35d
+	peerToken      = `eyJmc2lkIjoiYzZiMDg3ZjItNzgyOS00ZGJiLWJjZmMtNTNkYzM0ZTBiMzVkIiwiY2xpZW50X2lkIjoicmJkLW1pcnJvci1wZWVyIiwia2V5IjoiQVFBV1lsWmZVQ1Q2RGhBQVBtVnAwbGtubDA5YVZWS3lyRVV1NEE9PSIsIm1vbl9ob3N0IjoiW3YyOjE5Mi4xNjguMTExLjEwOjMzMDAsdjE6MTkyLjE2OC4xMTEuMTA6Njc4OV0sW3YyOjE5Mi4xNjguMTExLjEyOjMzMDAsdjE6MTkyLjE2OC4xMTEuMTI6Njc4OV0sW3YyOjE5Mi4xNjguMTExLjExOjMzMDAsdjE6MTkyLjE2OC4xMTEuMTE6Njc4OV0ifQ==` //nolint:gosec // not a real token
+	remoteFSID     = "c6b087f2-7829-4dbb-bcfc-53dc34e0b35d"
+	noPeerInfo     = `{"mode":"image","site_name":"local","peers":[]}`
+	otherPeerInfo  = `{"mode":"image","site_name":"local","peers":[{"uuid":"4a6983c0-3c9d-40f5-b2a9-2334a4659827","direction":"rx-tx","site_name":"other"}]}`
+	addedPeerInfo  = `{"mode":"image","site_name":"local","peers":[{"uuid":"4a6983c0-3c9d-40f5-b2a9-2334a4659827","direction":"rx-tx","site_name":"other"},{"uuid":"9d0a5e1c-0f3e-4e8c-9a53-6c1f1b2a7d41","direction":"rx-only","site_name":"c6b087f2-7829-4dbb-bcfc-53dc34e0b35d"}]}`
+	mirrorStatus   = `{"summary":{"health":"WARNING","daemon_health":"OK","image_health":"WARNING"}}`
+	addedPeerUUID  = "9d0a5e1c-0f3e-4e8c-9a53-6c1f1b2a7d41"
+	otherPeerUUID  = "4a6983c0-3c9d-40f5-b2a9-2334a4659827"
+	mirroredPool   = "mirrored-pool"
+	unmirroredPool = "unmirrored-pool"
+)
+
+func TestValidateSpec(t *testing.T) {
+	spec := &cephv1.RBDMirrorPeerSpec{SecretName: "token", Pools: []string{"a", "b"}}
+	assert.NoError(t, ValidateMirrorPeerSpec(spec))
+
+	spec.Pools = []string{"a", "a"}
+	assert.Error(t, ValidateMirrorPeerSpec(spec))
+
+	spec.Pools = nil
+	assert.Error(t, ValidateMirrorPeerSpec(spec))
+
+	spec = &cephv1.RBDMirrorPeerSpec{Pools: []string{"a"}}
+	assert.Error(t, ValidateMirrorPeerSpec(spec))
+}
+
+func TestFindImportedPeer(t *testing.T) {
+	other := cephv1.PeersSpec{UUID: otherPeerUUID, SiteName: "other"}
+	added := cephv1.PeersSpec{UUID: addedPeerUUID, SiteName: remoteFSID}
+
+	// the new peer
+	peer := findImportedPeer([]cephv1.PeersSpec{other}, []cephv1.PeersSpec{other, added}, "", remoteFSID)
+	assert.Equal(t, addedPeerUUID, peer.UUID)
+
+	// the peer was updated by the import
+	peer = findImportedPeer([]cephv1.PeersSpec{other, added}, []cephv1.PeersSpec{other, added}, addedPeerUUID, "")
+	assert.Equal(t, addedPeerUUID, peer.UUID)
+
+	// the peer was added outside of the CR
+	peer = findImportedPeer([]cephv1.PeersSpec{other, added}, []cephv1.PeersSpec{other, added}, "", remoteFSID)
+	assert.Equal(t, addedPeerUUID, peer.UUID)
+
+	// the only peer
+	peer = findImportedPeer([]cephv1.PeersSpec{other}, []cephv1.PeersSpec{other}, "", remoteFSID)
+	assert.Equal(t, otherPeerUUID, peer.UUID)
+
+	// unknown peer
+	peer = findImportedPeer([]cephv1.PeersSpec{other, added}, []cephv1.PeersSpec{other, added}, "", "")
+	assert.Nil(t, peer)
+}
+
+func TestTokenFSID(t *testing.T) {
+	assert.Equal(t, remoteFSID, tokenFSID([]byte(peerToken)))
+	assert.Equal(t, "", tokenFSID([]byte("invalid")))
+}
+
+func TestCheckTokenGrant(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	encode := func(peerToken cephclient.PeerToken) []byte {
+		b, _ := json.Marshal(peerToken)
+		return []byte(base64.StdEncoding.EncodeToString(b))
+	}
+
+	// the tokens of the ceph bootstrap are not restricted
+	assert.NoError(t, checkTokenGrant([]byte(peerToken), mirroredPool, "rx-only", now))
+	assert.Error(t, checkTokenGrant([]byte("invalid"), mirroredPool, "rx-tx", now))
+
+	token := encode(cephclient.PeerToken{ClusterFSID: remoteFSID, Pools: []string{mirroredPool}, Expires: "2022-03-01T12:00:00Z"})
+	assert.NoError(t, checkTokenGrant(token, mirroredPool, "rx-tx", now))
+	assert.NoError(t, checkTokenGrant(token, mirroredPool, "", now))
+	assert.EqualError(t, checkTokenGrant(token, mirroredPool, "rx-tx", now.Add(3*time.Hour)), "the peer token expired at 2022-03-01T12:00:00Z, request a new token from the remote cluster")
+	assert.Error(t, checkTokenGrant(token, unmirroredPool, "rx-tx", now))
+	assert.Error(t, checkTokenGrant(token, mirroredPool, "rx-only", now))
+}
+
+func TestReconcilePoolPeer(t *testing.T) {
+	ctx := context.TODO()
+	pools := []runtime.Object{
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: mirroredPool, Namespace: "rook-ceph"},
+			Spec:       cephv1.NamedBlockPoolSpec{PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}}},
+		},
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: unmirroredPool, Namespace: "rook-ceph"},
+		},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{}, &cephv1.CephRBDMirrorPeer{}, &cephv1.CephRBDMirrorPeerList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pools...).Build()
+
+	imported := false
+	removed := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if len(args) < 3 || args[0] != "mirror" || args[1] != "pool" {
+				return "", errors.New("unknown command")
+			}
+			switch {
+			case args[2] == "info":
+				if imported {
+					return addedPeerInfo, nil
+				}
+				return otherPeerInfo, nil
+			case args[2] == "status":
+				return mirrorStatus, nil
+			case args[2] == "peer" && args[3] == "bootstrap" && args[4] == "import":
+				assert.Equal(t, mirroredPool, args[5])
+				assert.Equal(t, "rx-only", args[8])
+				imported = true
+				return "", nil
+			case args[2] == "peer" && args[3] == "remove":
+				removed = args[5]
+				return "", nil
+			}
+			return "", errors.New("unknown command")
+		},
+	}
+	r := &ReconcileCephRBDMirrorPeer{
+		client:           cl,
+		scheme:           s,
+		context:          &clusterd.Context{Executor: executor},
+		clusterInfo:      cephclient.AdminTestClusterInfo("rook-ceph"),
+		opManagerContext: ctx,
+	}
+	peer := &cephv1.CephRBDMirrorPeer{
+		ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: "rook-ceph"},
+		Spec:       cephv1.RBDMirrorPeerSpec{SecretName: "token", Pools: []string{mirroredPool}, Direction: "rx-only"},
+		Status:     &cephv1.CephRBDMirrorPeerStatus{},
+	}
+
+	t.Run("peer is added", func(t *testing.T) {
+		status := r.reconcilePoolPeer(peer, mirroredPool, []byte(peerToken), "rx-only", false)
+		assert.True(t, imported)
+		assert.Equal(t, "", status.Message)
+		assert.Equal(t, addedPeerUUID, status.UUID)
+		assert.Equal(t, remoteFSID, status.SiteName)
+		assert.Equal(t, "rx-only", status.Direction)
+		assert.Equal(t, "WARNING", status.Health)
+		assert.Equal(t, "OK", status.DaemonHealth)
+		peer.Status.Pools = []cephv1.RBDMirrorPeerPoolStatus{status}
+	})
+
+	t.Run("peer is not imported again", func(t *testing.T) {
+		executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+			if args[2] == "info" {
+				return addedPeerInfo, nil
+			}
+			if args[2] == "status" {
+				return mirrorStatus, nil
+			}
+			return "", errors.New("unexpected command")
+		}
+		status := r.reconcilePoolPeer(peer, mirroredPool, []byte(peerToken), "rx-only", false)
+		assert.Equal(t, "", status.Message)
+		assert.Equal(t, addedPeerUUID, status.UUID)
+	})
+
+	t.Run("mirroring not enabled", func(t *testing.T) {
+		status := r.reconcilePoolPeer(peer, unmirroredPool, []byte(peerToken), "rx-only", false)
+		assert.Equal(t, "mirroring is not enabled in the pool", status.Message)
+	})
+
+	t.Run("pool not found", func(t *testing.T) {
+		status := r.reconcilePoolPeer(peer, "missing", []byte(peerToken), "rx-only", false)
+		assert.Equal(t, "pool not found", status.Message)
+	})
+
+	t.Run("peer is removed from the unlisted pools", func(t *testing.T) {
+		executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+			if args[2] == "info" {
+				return addedPeerInfo, nil
+			}
+			if args[2] == "peer" && args[3] == "remove" {
+				removed = args[5]
+				return "", nil
+			}
+			return "", errors.New("unexpected command")
+		}
+		err := r.removePeers(peer.Status.Pools, []string{mirroredPool})
+		assert.NoError(t, err)
+		assert.Equal(t, "", removed)
+
+		err = r.removePeers(peer.Status.Pools, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, addedPeerUUID, removed)
+	})
+
+	t.Run("peer already gone", func(t *testing.T) {
+		removed = ""
+		executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+			if args[2] == "info" {
+				return noPeerInfo, nil
+			}
+			return "", errors.New("unexpected command")
+		}
+		err := r.removePeers(peer.Status.Pools, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "", removed)
+	})
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirrorpeer
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// getPeerToken returns the bootstrap peer token of the remote cluster and the mirroring direction
+func (r *ReconcileCephRBDMirrorPeer) getPeerToken(peer *cephv1.CephRBDMirrorPeer) ([]byte, string, error) {
+	s, err := r.context.Clientset.CoreV1().Secrets(peer.Namespace).Get(r.opManagerContext, peer.Spec.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to fetch kubernetes secret %q bootstrap peer", peer.Spec.SecretName)
+	}
+
+	err = opcontroller.ValidatePeerToken(peer, s.Data)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to validate rbd-mirror bootstrap peer secret %q data", peer.Spec.SecretName)
+	}
+
+	direction := peer.Spec.Direction
+	if direction == "" {
+		direction = string(s.Data["direction"])
+	}
+	return s.Data["token"], direction, nil
+}
+
+// reconcilePoolPeer adds the peer to a pool when it was not added yet or when the spec changed,
+// then reports the peer and the mirroring health of the pool
+func (r *ReconcileCephRBDMirrorPeer) reconcilePoolPeer(peer *cephv1.CephRBDMirrorPeer, poolName string, token []byte, direction string, importToken bool) cephv1.RBDMirrorPeerPoolStatus {
+	status := cephv1.RBDMirrorPeerPoolStatus{Pool: poolName}
+	if previous := findPoolStatus(peer.Status.Pools, poolName); previous != nil {
+		// keep the peer uuid so the peer can still be removed if it cannot be added again
+		status.UUID = previous.UUID
+	}
+
+	pool := &cephv1.CephBlockPool{}
+	err := r.client.Get(r.opManagerContext, types.NamespacedName{Name: poolName, Namespace: peer.Namespace}, pool)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			status.Message = "pool not found"
+		} else {
+			status.Message = err.Error()
+		}
+		return status
+	}
+	if !pool.Spec.Mirroring.Enabled {
+		status.Message = "mirroring is not enabled in the pool"
+		return status
+	}
+
+	before, err := cephclient.GetPoolMirroringInfo(r.context, r.clusterInfo, poolName)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+
+	added := findPeerByUUID(before.Peers, status.UUID)
+	if added == nil || importToken {
+		err = checkTokenGrant(token, poolName, direction, time.Now())
+		if err != nil {
+			status.Message = err.Error()
+			return status
+		}
+		err = cephclient.ImportRBDMirrorBootstrapPeer(r.context, r.clusterInfo, poolName, direction, token)
+		if err != nil {
+			status.Message = err.Error()
+			return status
+		}
+
+		after, err := cephclient.GetPoolMirroringInfo(r.context, r.clusterInfo, poolName)
+		if err != nil {
+			status.Message = err.Error()
+			return status
+		}
+		added = findImportedPeer(before.Peers, after.Peers, status.UUID, tokenFSID(token))
+		if added == nil {
+			status.Message = "failed to find the imported peer in the pool"
+			return status
+		}
+	}
+	status.UUID = added.UUID
+	status.SiteName = added.SiteName
+	status.Direction = added.Direction
+
+	mirrorStatus, err := cephclient.GetPoolMirroringStatus(r.context, r.clusterInfo, poolName)
+	if err != nil {
+		// the peer was added, only its health is unknown
+		logger.Warningf("failed to check the mirroring status of pool %q. %v", poolName, err)
+		return status
+	}
+	if mirrorStatus.Summary != nil {
+		status.Health = mirrorStatus.Summary.Health
+		status.DaemonHealth = mirrorStatus.Summary.DaemonHealth
+		status.ImageHealth = mirrorStatus.Summary.ImageHealth
+	}
+	return status
+}
+
+// removePeers removes the peer from the pools of the status that are not in the given list of pools
+func (r *ReconcileCephRBDMirrorPeer) removePeers(pools []cephv1.RBDMirrorPeerPoolStatus, keep []string) error {
+	for _, pool := range pools {
+		if pool.UUID == "" || contains(keep, pool.Pool) {
+			continue
+		}
+
+		info, err := cephclient.GetPoolMirroringInfo(r.context, r.clusterInfo, pool.Pool)
+		if err != nil {
+			// the pool or its mirroring may be gone already, and the peer with it
+			logger.Warningf("failed to get the mirroring info of pool %q, not removing peer %q. %v", pool.Pool, pool.UUID, err)
+			continue
+		}
+		if findPeerByUUID(info.Peers, pool.UUID) == nil {
+			continue
+		}
+
+		logger.Infof("removing rbd mirror peer %q from pool %q", pool.UUID, pool.Pool)
+		err = cephclient.RemoveClusterPeer(r.context, r.clusterInfo, pool.Pool, pool.UUID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove peer from pool %q", pool.Pool)
+		}
+	}
+	return nil
+}
+
+// findImportedPeer finds the peer added by the import of a token. It is the peer that is new in the
+// pool, or the peer already added before when the import only updated it. When the peer was added
+// outside of the CR, it is recognized by the fsid of the remote cluster, the default site name.
+func findImportedPeer(before, after []cephv1.PeersSpec, uuid, fsid string) *cephv1.PeersSpec {
+	for i := range after {
+		if findPeerByUUID(before, after[i].UUID) == nil {
+			return &after[i]
+		}
+	}
+	if peer := findPeerByUUID(after, uuid); peer != nil {
+		return peer
+	}
+	for i := range after {
+		if fsid != "" && after[i].SiteName == fsid {
+			return &after[i]
+		}
+	}
+	if len(after) == 1 {
+		return &after[0]
+	}
+	return nil
+}
+
+func findPeerByUUID(peers []cephv1.PeersSpec, uuid string) *cephv1.PeersSpec {
+	if uuid == "" {
+		return nil
+	}
+	for i := range peers {
+		if peers[i].UUID == uuid {
+			return &peers[i]
+		}
+	}
+	return nil
+}
+
+func findPoolStatus(pools []cephv1.RBDMirrorPeerPoolStatus, poolName string) *cephv1.RBDMirrorPeerPoolStatus {
+	for i := range pools {
+		if pools[i].Pool == poolName {
+			return &pools[i]
+		}
+	}
+	return nil
+}
+
+// tokenFSID returns the fsid of the remote cluster of a bootstrap peer token
+func tokenFSID(token []byte) string {
+	peerToken, err := cephclient.ParsePeerToken(token)
+	if err != nil {
+		return ""
+	}
+	return peerToken.ClusterFSID
+}
+
+// checkTokenGrant checks that a token issued by a CephRBDMirrorPeerToken of the remote cluster can
+// still be imported in a pool. The tokens of the ceph bootstrap are not restricted.
+func checkTokenGrant(token []byte, poolName, direction string, now time.Time) error {
+	peerToken, err := cephclient.ParsePeerToken(token)
+	if err != nil {
+		return err
+	}
+	if peerToken.Expires != "" {
+		expires, err := time.Parse(time.RFC3339, peerToken.Expires)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the expiration time %q of the peer token", peerToken.Expires)
+		}
+		if now.After(expires) {
+			return errors.Errorf("the peer token expired at %s, request a new token from the remote cluster", peerToken.Expires)
+		}
+	}
+	if len(peerToken.Pools) == 0 {
+		return nil
+	}
+	if !contains(peerToken.Pools, poolName) {
+		return errors.Errorf("the peer token does not grant access to pool %q, only to %v", poolName, peerToken.Pools)
+	}
+	// The remote cluster revokes the token when it does not see this cluster added as its peer
+	if direction == "rx-only" {
+		return errors.New("a peer token restricted to pools must be imported with the rx-tx direction")
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
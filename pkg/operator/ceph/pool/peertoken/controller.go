@@ -0,0 +1,273 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package peertoken to issue the bootstrap peer tokens of the rbd mirroring to remote clusters
+package peertoken
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/util/exec"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-rbd-mirror-peer-token-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephRBDMirrorPeerTokenKind = reflect.TypeOf(cephv1.CephRBDMirrorPeerToken{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephRBDMirrorPeerTokenKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+var (
+	defaultExpiration = 24 * time.Hour
+	// acceptanceCheckInterval is the interval of the check of the peers of the pools while the
+	// token is pending
+	acceptanceCheckInterval = 1 * time.Minute
+)
+
+// ReconcileCephRBDMirrorPeerToken reconciles a CephRBDMirrorPeerToken object
+type ReconcileCephRBDMirrorPeerToken struct {
+	client           client.Client
+	scheme           *runtime.Scheme
+	context          *clusterd.Context
+	clusterInfo      *cephclient.ClusterInfo
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+}
+
+// Add creates a new CephRBDMirrorPeerToken Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	return add(mgr, newReconciler(mgr, context, opManagerContext))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context) reconcile.Reconciler {
+	return &ReconcileCephRBDMirrorPeerToken{
+		client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephRBDMirrorPeerToken{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephRBDMirrorPeerToken{}, reconciler)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephRBDMirrorPeerToken CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephRBDMirrorPeerToken{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephRBDMirrorPeerToken object and makes changes based on the state read
+// and what is in the CephRBDMirrorPeerToken.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephRBDMirrorPeerToken) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, peerToken, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, peerToken, reconcileResponse, err)
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephRBDMirrorPeerToken) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephRBDMirrorPeerToken, error) {
+	// Fetch the CephRBDMirrorPeerToken instance
+	peerToken := &cephv1.CephRBDMirrorPeerToken{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, peerToken)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephRBDMirrorPeerToken resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, peerToken, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, peerToken, errors.Wrap(err, "failed to get cephRBDMirrorPeerToken")
+	}
+
+	// Set a finalizer so we can delete the user of the token before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, peerToken)
+	if err != nil {
+		return reconcile.Result{}, peerToken, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// The user of the token is gone with the cluster, only remove the finalizer
+		if !peerToken.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, peerToken)
+			if err != nil {
+				return opcontroller.ImmediateRetryResult, peerToken, errors.Wrap(err, "failed to remove finalizer")
+			}
+			return reconcile.Result{}, peerToken, nil
+		}
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, peerToken, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, exec.WithAuditInitiator(r.opManagerContext, "CephRBDMirrorPeerToken", request.Namespace, request.Name), request.NamespacedName.Namespace)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, peerToken, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	// DELETE: the CR was deleted, the remote cluster cannot connect with the token anymore
+	if !peerToken.GetDeletionTimestamp().IsZero() {
+		logger.Debugf("deleting rbd mirror peer token %q", request.NamespacedName)
+		err = r.revoke(peerToken)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, peerToken, errors.Wrapf(err, "failed to revoke rbd mirror peer token %q", request.NamespacedName)
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, peerToken)
+		if err != nil {
+			return reconcile.Result{}, peerToken, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, peerToken, nil
+	}
+
+	if err := ValidatePeerTokenSpec(&peerToken.Spec); err != nil {
+		r.updateStatus(request.NamespacedName, &cephv1.CephRBDMirrorPeerTokenStatus{
+			Phase:              cephv1.RBDMirrorPeerTokenPhaseFailed,
+			Message:            err.Error(),
+			ObservedGeneration: peerToken.Generation,
+		})
+		return reconcile.Result{}, peerToken, errors.Wrapf(err, "invalid rbd mirror peer token %q", request.NamespacedName)
+	}
+
+	status := peerToken.Status
+	switch {
+	case status == nil || status.ObservedGeneration != peerToken.Generation && status.Phase != cephv1.RBDMirrorPeerTokenPhaseAccepted:
+		// A new token is issued for a new or updated spec, unless the remote cluster accepted it
+		// already
+		status, err = r.issue(peerToken)
+		if err != nil {
+			r.updateStatus(request.NamespacedName, &cephv1.CephRBDMirrorPeerTokenStatus{
+				Phase:              cephv1.RBDMirrorPeerTokenPhaseFailed,
+				Message:            err.Error(),
+				ObservedGeneration: peerToken.Generation,
+			})
+			return opcontroller.WaitForRequeueIfCephClusterNotReady, peerToken, errors.Wrapf(err, "failed to issue rbd mirror peer token %q", request.NamespacedName)
+		}
+
+	case status.Phase == cephv1.RBDMirrorPeerTokenPhaseAccepted && status.ObservedGeneration != peerToken.Generation:
+		// The remote cluster keeps using the token, only the pools it can access change
+		err = r.updatePools(peerToken, status)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, peerToken, errors.Wrapf(err, "failed to update the pools of rbd mirror peer token %q", request.NamespacedName)
+		}
+		r.updateStatus(request.NamespacedName, status)
+		return reconcile.Result{}, peerToken, nil
+
+	case status.Phase != cephv1.RBDMirrorPeerTokenPhasePending:
+		logger.Debugf("rbd mirror peer token %q is %s", request.NamespacedName, status.Phase)
+		return reconcile.Result{}, peerToken, nil
+	}
+
+	result, err := r.checkAcceptance(peerToken, status)
+	r.updateStatus(request.NamespacedName, status)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, peerToken, errors.Wrapf(err, "failed to check the acceptance of rbd mirror peer token %q", request.NamespacedName)
+	}
+	return result, peerToken, nil
+}
+
+// ValidatePeerTokenSpec validates the rbd mirror peer token settings
+func ValidatePeerTokenSpec(spec *cephv1.RBDMirrorPeerTokenSpec) error {
+	if len(spec.Pools) == 0 {
+		return errors.New("at least one pool must be specified")
+	}
+	seen := map[string]bool{}
+	for _, pool := range spec.Pools {
+		if seen[pool] {
+			return errors.Errorf("pool %q is specified more than once", pool)
+		}
+		seen[pool] = true
+	}
+	if spec.Expiration != nil && spec.Expiration.Duration <= 0 {
+		return errors.New("expiration must be positive")
+	}
+	return nil
+}
+
+// updateStatus updates an object with a given status
+func (r *ReconcileCephRBDMirrorPeerToken) updateStatus(name types.NamespacedName, status *cephv1.CephRBDMirrorPeerTokenStatus) {
+	peerToken := &cephv1.CephRBDMirrorPeerToken{}
+	if err := r.client.Get(r.opManagerContext, name, peerToken); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephRBDMirrorPeerToken resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve rbd mirror peer token %q to update status to %q. %v", name, status.Phase, err)
+		return
+	}
+
+	err := reporting.PatchStatus(r.client, peerToken, func() bool {
+		// the standard conditions are set from the result of the reconcile
+		if peerToken.Status != nil && status.Conditions == nil {
+			status.Conditions = peerToken.Status.Conditions
+		}
+		peerToken.Status = status
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set rbd mirror peer token %q status to %q. %v", name, status.Phase, err)
+		return
+	}
+	logger.Debugf("rbd mirror peer token %q status updated to %q", name, status.Phase)
+}
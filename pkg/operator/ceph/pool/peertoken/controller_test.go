@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package peertoken
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	issuedPeerInfo   = `{"mode":"image","site_name":"local","peers":[{"uuid":"4a6983c0-3c9d-40f5-b2a9-2334a4659827","direction":"rx-tx","site_name":"other"}]}`
+	acceptedPeerInfo = `{"mode":"image","site_name":"local","peers":[{"uuid":"4a6983c0-3c9d-40f5-b2a9-2334a4659827","direction":"rx-tx","site_name":"other"},{"uuid":"9d0a5e1c-0f3e-4e8c-9a53-6c1f1b2a7d41","direction":"rx-tx","site_name":"dr-site"}]}`
+)
+
+func TestValidateSpec(t *testing.T) {
+	spec := &cephv1.RBDMirrorPeerTokenSpec{Pools: []string{"a", "b"}}
+	assert.NoError(t, ValidatePeerTokenSpec(spec))
+
+	spec.Pools = []string{"a", "a"}
+	assert.Error(t, ValidatePeerTokenSpec(spec))
+
+	spec.Pools = nil
+	assert.Error(t, ValidatePeerTokenSpec(spec))
+
+	spec = &cephv1.RBDMirrorPeerTokenSpec{Pools: []string{"a"}, Expiration: &metav1.Duration{Duration: -time.Hour}}
+	assert.Error(t, ValidatePeerTokenSpec(spec))
+}
+
+func TestPeerToken(t *testing.T) {
+	ctx := context.TODO()
+	peerToken := &cephv1.CephRBDMirrorPeerToken{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr", Namespace: "rook-ceph", Generation: 1},
+		Spec:       cephv1.RBDMirrorPeerTokenSpec{Pools: []string{"mirrored", "other"}},
+	}
+	s := scheme.Scheme
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Namespace: "rook-ceph"},
+			Spec:       cephv1.NamedBlockPoolSpec{PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}}},
+		},
+		&cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "rook-ceph"},
+			Spec:       cephv1.NamedBlockPoolSpec{Name: "renamed", PoolSpec: cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}}},
+		},
+	).Build()
+
+	accepted := false
+	var authCommands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			switch {
+			case args[0] == "mirror" && args[2] == "info":
+				if accepted && args[3] == "renamed" {
+					return acceptedPeerInfo, nil
+				}
+				return issuedPeerInfo, nil
+			case args[0] == "auth" && args[1] == "get-key":
+				return "", errors.New("ENOENT")
+			case args[0] == "auth":
+				authCommands = append(authCommands, strings.Join(args[:3], " "))
+				if args[1] == "caps" {
+					authCommands = append(authCommands, args[6])
+				}
+				return `{"key":"AQBsH5ZiAAAAABAAdqgcK7lwCLzUnFbKWd3Y5A=="}`, nil
+			}
+			return "", errors.New("unknown command")
+		},
+	}
+	clientset := test.New(t, 1)
+	r := &ReconcileCephRBDMirrorPeerToken{
+		client:           cl,
+		scheme:           s,
+		context:          &clusterd.Context{Executor: executor, Clientset: clientset},
+		clusterInfo:      cephclient.AdminTestClusterInfo("rook-ceph"),
+		opManagerContext: ctx,
+	}
+
+	var status *cephv1.CephRBDMirrorPeerTokenStatus
+	t.Run("issue", func(t *testing.T) {
+		var err error
+		status, err = r.issue(peerToken)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.RBDMirrorPeerTokenPhasePending, status.Phase)
+		assert.Equal(t, "client.rbd-mirror-peer-token-dr", status.User)
+		assert.Equal(t, []string{"auth get-or-create-key client.rbd-mirror-peer-token-dr"}, authCommands)
+		assert.Equal(t, []cephv1.RBDMirrorPeerTokenPoolStatus{
+			{Pool: "mirrored", IssuedPeers: []string{"4a6983c0-3c9d-40f5-b2a9-2334a4659827"}},
+			{Pool: "other", IssuedPeers: []string{"4a6983c0-3c9d-40f5-b2a9-2334a4659827"}},
+		}, status.Pools)
+
+		secret, err := clientset.CoreV1().Secrets("rook-ceph").Get(ctx, status.SecretName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		token, err := cephclient.ParsePeerToken(secret.Data["token"])
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"mirrored", "renamed"}, token.Pools)
+		assert.Equal(t, status.ExpirationTime, token.Expires)
+		assert.Equal(t, "rx-tx", string(secret.Data["direction"]))
+	})
+
+	t.Run("pending", func(t *testing.T) {
+		result, err := r.checkAcceptance(peerToken, status)
+		assert.NoError(t, err)
+		assert.Equal(t, acceptanceCheckInterval, result.RequeueAfter)
+		assert.Equal(t, cephv1.RBDMirrorPeerTokenPhasePending, status.Phase)
+	})
+
+	t.Run("accepted", func(t *testing.T) {
+		accepted = true
+		result, err := r.checkAcceptance(peerToken, status)
+		assert.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.Equal(t, cephv1.RBDMirrorPeerTokenPhaseAccepted, status.Phase)
+		assert.Equal(t, "dr-site", status.Pools[1].AcceptedPeer)
+		assert.NotEmpty(t, status.AcceptedTime)
+
+		// the copy of the token is not needed anymore
+		_, err = clientset.CoreV1().Secrets("rook-ceph").Get(ctx, status.SecretName, metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err))
+	})
+
+	t.Run("remove a pool from an accepted token", func(t *testing.T) {
+		authCommands = nil
+		peerToken.Generation = 2
+		peerToken.Spec.Pools = []string{"other", "new"}
+		err := r.updatePools(peerToken, status)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"auth caps client.rbd-mirror-peer-token-dr", "profile rbd pool=renamed"}, authCommands)
+		assert.Equal(t, 1, len(status.Pools))
+		assert.Contains(t, status.Message, "[new] cannot be added")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		accepted = false
+		authCommands = nil
+		status, err := r.issue(peerToken)
+		assert.Error(t, err)
+		assert.Nil(t, status)
+
+		peerToken.Spec.Pools = []string{"mirrored"}
+		status, err = r.issue(peerToken)
+		assert.NoError(t, err)
+		status.ExpirationTime = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		peerToken.Status = status
+
+		result, err := r.checkAcceptance(peerToken, status)
+		assert.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.Equal(t, cephv1.RBDMirrorPeerTokenPhaseExpired, status.Phase)
+		assert.Contains(t, authCommands, "auth del client.rbd-mirror-peer-token-dr")
+		_, err = clientset.CoreV1().Secrets("rook-ceph").Get(ctx, status.SecretName, metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err))
+	})
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package peertoken
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// issue creates the user of the token with access to the pools of the spec only, and stores the
+// token in a Secret to be copied to the remote cluster
+func (r *ReconcileCephRBDMirrorPeerToken) issue(peerToken *cephv1.CephRBDMirrorPeerToken) (*cephv1.CephRBDMirrorPeerTokenStatus, error) {
+	status := &cephv1.CephRBDMirrorPeerTokenStatus{
+		Phase:              cephv1.RBDMirrorPeerTokenPhasePending,
+		SecretName:         secretName(peerToken),
+		User:               "client." + userID(peerToken),
+		ObservedGeneration: peerToken.Generation,
+	}
+
+	cephNames := []string{}
+	for _, poolName := range peerToken.Spec.Pools {
+		cephName, err := r.mirroredPoolCephName(peerToken.Namespace, poolName)
+		if err != nil {
+			return nil, err
+		}
+		cephNames = append(cephNames, cephName)
+
+		// the remote cluster adds itself as a new peer of the pool when it accepts the token
+		info, err := cephclient.GetPoolMirroringInfo(r.context, r.clusterInfo, cephName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the mirroring peers of pool %q", poolName)
+		}
+		poolStatus := cephv1.RBDMirrorPeerTokenPoolStatus{Pool: poolName}
+		for _, peer := range info.Peers {
+			poolStatus.IssuedPeers = append(poolStatus.IssuedPeers, peer.UUID)
+		}
+		status.Pools = append(status.Pools, poolStatus)
+	}
+
+	expiration := defaultExpiration
+	if peerToken.Spec.Expiration != nil {
+		expiration = peerToken.Spec.Expiration.Duration
+	}
+	expirationTime := time.Now().Add(expiration)
+	status.ExpirationTime = expirationTime.UTC().Format(time.RFC3339)
+
+	token, err := cephclient.CreateRBDMirrorPeerToken(r.context, r.clusterInfo, userID(peerToken), cephNames, expirationTime)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      status.SecretName,
+			Namespace: peerToken.Namespace,
+		},
+		Data: map[string][]byte{
+			"token": token,
+			// the remote cluster is only seen accepting the token when it adds itself as a peer
+			"direction": []byte("rx-tx"),
+		},
+		Type: k8sutil.RookType,
+	}
+	err = k8sutil.NewOwnerInfo(peerToken, r.scheme).SetControllerReference(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to set owner reference of rbd mirror peer token secret %q", s.Name)
+	}
+	_, err = k8sutil.CreateOrUpdateSecret(r.opManagerContext, r.context.Clientset, s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create rbd mirror peer token secret %q", s.Name)
+	}
+
+	logger.Infof("issued rbd mirror peer token %q for pools %v, expiring at %s", peerToken.Name, peerToken.Spec.Pools, status.ExpirationTime)
+	return status, nil
+}
+
+// checkAcceptance checks whether the remote cluster added itself as a peer of the pools with the
+// token. The Secret of the token is deleted once it is accepted, and the user of a token not
+// accepted in time is deleted too.
+func (r *ReconcileCephRBDMirrorPeerToken) checkAcceptance(peerToken *cephv1.CephRBDMirrorPeerToken, status *cephv1.CephRBDMirrorPeerTokenStatus) (reconcile.Result, error) {
+	accepted := []string{}
+	for i := range status.Pools {
+		poolStatus := &status.Pools[i]
+		cephName, err := r.mirroredPoolCephName(peerToken.Namespace, poolStatus.Pool)
+		if err != nil {
+			logger.Debugf("failed to check the acceptance of rbd mirror peer token %q in pool %q. %v", peerToken.Name, poolStatus.Pool, err)
+			continue
+		}
+		info, err := cephclient.GetPoolMirroringInfo(r.context, r.clusterInfo, cephName)
+		if err != nil {
+			logger.Debugf("failed to check the acceptance of rbd mirror peer token %q in pool %q. %v", peerToken.Name, poolStatus.Pool, err)
+			continue
+		}
+		for _, peer := range info.Peers {
+			if !contains(poolStatus.IssuedPeers, peer.UUID) {
+				poolStatus.AcceptedPeer = peer.SiteName
+				accepted = append(accepted, peer.SiteName)
+				break
+			}
+		}
+	}
+
+	if len(accepted) > 0 {
+		status.Phase = cephv1.RBDMirrorPeerTokenPhaseAccepted
+		status.AcceptedTime = time.Now().UTC().Format(time.RFC3339)
+		status.Message = fmt.Sprintf("accepted by peer %q", accepted[0])
+		logger.Infof("rbd mirror peer token %q was accepted by peer %q", peerToken.Name, accepted[0])
+		// the remote cluster keeps the token in its pools, the copy of this cluster is not needed
+		return reconcile.Result{}, r.deleteSecret(peerToken.Namespace, status.SecretName)
+	}
+
+	expirationTime, err := time.Parse(time.RFC3339, status.ExpirationTime)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to parse the expiration time %q", status.ExpirationTime)
+	}
+	remaining := time.Until(expirationTime)
+	if remaining > 0 {
+		if remaining > acceptanceCheckInterval {
+			remaining = acceptanceCheckInterval
+		}
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	err = r.revoke(peerToken)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	status.Phase = cephv1.RBDMirrorPeerTokenPhaseExpired
+	status.Message = "the token was not accepted before its expiration, its user was deleted"
+	logger.Infof("rbd mirror peer token %q expired", peerToken.Name)
+	return reconcile.Result{}, nil
+}
+
+// updatePools restricts the access of an accepted token to the pools of the spec. Pools cannot be
+// added to an accepted token, the remote cluster imports a new token in new pools.
+func (r *ReconcileCephRBDMirrorPeerToken) updatePools(peerToken *cephv1.CephRBDMirrorPeerToken, status *cephv1.CephRBDMirrorPeerTokenStatus) error {
+	pools := []cephv1.RBDMirrorPeerTokenPoolStatus{}
+	cephNames := []string{}
+	added := []string{}
+	for _, poolName := range peerToken.Spec.Pools {
+		poolStatus := findPoolStatus(status.Pools, poolName)
+		if poolStatus == nil {
+			added = append(added, poolName)
+			continue
+		}
+		cephName, err := r.mirroredPoolCephName(peerToken.Namespace, poolName)
+		if err != nil {
+			return err
+		}
+		pools = append(pools, *poolStatus)
+		cephNames = append(cephNames, cephName)
+	}
+	if len(cephNames) == 0 {
+		return errors.New("an accepted token must keep at least one of its pools")
+	}
+
+	err := cephclient.AuthUpdateCaps(r.context, r.clusterInfo, status.User, cephclient.RBDMirrorPeerTokenCaps(cephNames))
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the caps of user %q", status.User)
+	}
+
+	status.Pools = pools
+	status.ObservedGeneration = peerToken.Generation
+	status.Message = ""
+	if len(added) > 0 {
+		status.Message = fmt.Sprintf("pools %v cannot be added to an accepted token, issue a new token for them", added)
+	}
+	return nil
+}
+
+// revoke deletes the user and the Secret of the token, the remote cluster cannot connect with the
+// token anymore
+func (r *ReconcileCephRBDMirrorPeerToken) revoke(peerToken *cephv1.CephRBDMirrorPeerToken) error {
+	if peerToken.Status == nil || peerToken.Status.User == "" {
+		return nil
+	}
+	if peerToken.Status.Phase != cephv1.RBDMirrorPeerTokenPhaseExpired {
+		err := cephclient.AuthDelete(r.context, r.clusterInfo, peerToken.Status.User)
+		if err != nil {
+			return err
+		}
+	}
+	return r.deleteSecret(peerToken.Namespace, peerToken.Status.SecretName)
+}
+
+func (r *ReconcileCephRBDMirrorPeerToken) deleteSecret(namespace, name string) error {
+	err := r.context.Clientset.CoreV1().Secrets(namespace).Delete(r.opManagerContext, name, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete rbd mirror peer token secret %q", name)
+	}
+	return nil
+}
+
+// mirroredPoolCephName returns the name in ceph of a CephBlockPool with mirroring enabled
+func (r *ReconcileCephRBDMirrorPeerToken) mirroredPoolCephName(namespace, poolName string) (string, error) {
+	pool := &cephv1.CephBlockPool{}
+	err := r.client.Get(r.opManagerContext, types.NamespacedName{Name: poolName, Namespace: namespace}, pool)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get pool %q", poolName)
+	}
+	if !pool.Spec.Mirroring.Enabled {
+		return "", errors.Errorf("mirroring is not enabled in pool %q", poolName)
+	}
+	if pool.Spec.Name != "" {
+		return pool.Spec.Name, nil
+	}
+	return pool.Name, nil
+}
+
+func userID(peerToken *cephv1.CephRBDMirrorPeerToken) string {
+	return "rbd-mirror-peer-token-" + peerToken.Name
+}
+
+func secretName(peerToken *cephv1.CephRBDMirrorPeerToken) string {
+	return "rbd-mirror-peer-token-" + peerToken.Name
+}
+
+func findPoolStatus(pools []cephv1.RBDMirrorPeerTokenPoolStatus, poolName string) *cephv1.RBDMirrorPeerTokenPoolStatus {
+	for i := range pools {
+		if pools[i].Pool == poolName {
+			return &pools[i]
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,445 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package radosnamespace to manage the RADOS namespaces of the block pools and their mirroring
+package radosnamespace
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-block-pool-rados-namespace-controller"
+	// mirroringDisabled is the mirroring mode reported by ceph for a namespace that is not mirrored
+	mirroringDisabled = "disabled"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephBlockPoolRadosNamespaceKind = reflect.TypeOf(cephv1.CephBlockPoolRadosNamespace{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephBlockPoolRadosNamespaceKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephBlockPoolRadosNamespace reconciles a CephBlockPoolRadosNamespace object
+type ReconcileCephBlockPoolRadosNamespace struct {
+	client           client.Client
+	scheme           *runtime.Scheme
+	context          *clusterd.Context
+	clusterInfo      *cephclient.ClusterInfo
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+}
+
+// Add creates a new CephBlockPoolRadosNamespace Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	return add(mgr, newReconciler(mgr, context, opManagerContext))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context) reconcile.Reconciler {
+	return &ReconcileCephBlockPoolRadosNamespace{
+		client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephBlockPoolRadosNamespace{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephBlockPoolRadosNamespace{}, reconciler)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephBlockPoolRadosNamespace CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephBlockPoolRadosNamespace{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephBlockPoolRadosNamespace object and makes changes based on the state read
+// and what is in the CephBlockPoolRadosNamespace.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephBlockPoolRadosNamespace) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, cephBlockPoolRadosNamespace, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephBlockPoolRadosNamespace, reconcileResponse, err)
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephBlockPoolRadosNamespace) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephBlockPoolRadosNamespace, error) {
+	// Fetch the CephBlockPoolRadosNamespace instance
+	cephBlockPoolRadosNamespace := &cephv1.CephBlockPoolRadosNamespace{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephBlockPoolRadosNamespace)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephBlockPoolRadosNamespace resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, cephBlockPoolRadosNamespace, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrap(err, "failed to get cephBlockPoolRadosNamespace")
+	}
+
+	// Set a finalizer so we can do cleanup before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephBlockPoolRadosNamespace)
+	if err != nil {
+		return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// The CR was just created, initializing status fields
+	if cephBlockPoolRadosNamespace.Status == nil {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionProgressing, nil)
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	cephCluster, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// The namespace is gone with the pools of the cluster, only remove the finalizer
+		if !cephBlockPoolRadosNamespace.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephBlockPoolRadosNamespace)
+			if err != nil {
+				return opcontroller.ImmediateRetryResult, cephBlockPoolRadosNamespace, errors.Wrap(err, "failed to remove finalizer")
+			}
+
+			// Return and do not requeue. Successful deletion.
+			return reconcile.Result{}, cephBlockPoolRadosNamespace, nil
+		}
+		return reconcileResponse, cephBlockPoolRadosNamespace, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, r.opManagerContext, request.NamespacedName.Namespace)
+	if err != nil {
+		return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrap(err, "failed to populate cluster info")
+	}
+	r.clusterInfo.Context = exec.WithAuditInitiator(r.opManagerContext, "CephBlockPoolRadosNamespace", request.Namespace, request.Name)
+
+	// DELETE: the CR was deleted
+	if !cephBlockPoolRadosNamespace.GetDeletionTimestamp().IsZero() {
+		logger.Debugf("deleting rados namespace %q", request.NamespacedName)
+		// On external cluster, we don't delete the rados namespace, it has to be deleted manually
+		if cephCluster.Spec.External.Enable {
+			logger.Warning("external rados namespace deletion is not supported, delete it manually")
+		} else {
+			poolName, err := r.cephPoolName(cephBlockPoolRadosNamespace)
+			if err != nil {
+				return reconcile.Result{}, cephBlockPoolRadosNamespace, err
+			}
+			err = r.deleteRadosNamespace(cephBlockPoolRadosNamespace, poolName)
+			if err != nil {
+				if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
+					logger.Info(opcontroller.OperatorNotInitializedMessage)
+					return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephBlockPoolRadosNamespace, nil
+				}
+				return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrapf(err, "failed to delete rados namespace %q", request.NamespacedName)
+			}
+		}
+
+		err = csi.SaveClusterConfig(r.context.Clientset, buildClusterID(cephBlockPoolRadosNamespace), r.clusterInfo, nil)
+		if err != nil {
+			return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrap(err, "failed to save cluster config")
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephBlockPoolRadosNamespace)
+		if err != nil {
+			return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, cephBlockPoolRadosNamespace, nil
+	}
+
+	// Make sure the CephBlockPool exists and is ready, if not we cannot create the namespace
+	// On external mode the pool is created externally, so we don't need to check for CRD and
+	// assume it's there
+	poolName := cephBlockPoolRadosNamespace.Spec.BlockPoolName
+	if !cephCluster.Spec.External.Enable {
+		cephBlockPool := &cephv1.CephBlockPool{}
+		err = r.client.Get(r.opManagerContext, types.NamespacedName{Name: cephBlockPoolRadosNamespace.Spec.BlockPoolName, Namespace: request.Namespace}, cephBlockPool)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				r.updateStatus(request.NamespacedName, cephv1.ConditionFailure, nil)
+			}
+			return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrapf(err, "failed to fetch ceph block pool %q, cannot create rados namespace %q", cephBlockPoolRadosNamespace.Spec.BlockPoolName, request.NamespacedName)
+		}
+
+		// If the CephBlockPool is not ready to accept commands, we should wait for it to be ready
+		if cephBlockPool.Status == nil || cephBlockPool.Status.Phase != cephv1.ConditionReady {
+			logger.Infof("waiting for ceph block pool %q to be ready before creating rados namespace %q", cephBlockPool.Name, request.NamespacedName)
+			return reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}, cephBlockPoolRadosNamespace, nil
+		}
+
+		err = validateMirroring(cephBlockPoolRadosNamespace, cephBlockPool)
+		if err != nil {
+			r.updateStatus(request.NamespacedName, cephv1.ConditionFailure, nil)
+			return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrapf(err, "invalid rados namespace %q", request.NamespacedName)
+		}
+		poolName = cephPoolName(cephBlockPool)
+	}
+
+	// Create the rados namespace
+	err = cephclient.CreateRadosNamespace(r.context, r.clusterInfo, poolName, cephBlockPoolRadosNamespace.Name)
+	if err != nil {
+		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
+			logger.Info(opcontroller.OperatorNotInitializedMessage)
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, cephBlockPoolRadosNamespace, nil
+		}
+		r.updateStatus(request.NamespacedName, cephv1.ConditionFailure, nil)
+		return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrapf(err, "failed to create rados namespace %q", request.NamespacedName)
+	}
+
+	// Configure the mirroring of the namespace, only the namespaces that are listed are mirrored
+	mirroringInfo, err := r.reconcileMirroring(cephBlockPoolRadosNamespace, poolName)
+	if err != nil {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionFailure, mirroringInfo)
+		return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrapf(err, "failed to configure the mirroring of rados namespace %q", request.NamespacedName)
+	}
+
+	// Update CSI config map
+	// If the mon endpoints change, the mon health check go routine will take care of updating the
+	// config map, so no special care is needed in this controller
+	csiClusterConfigEntry := csi.CsiClusterConfigEntry{
+		Monitors:       csi.MonEndpoints(r.clusterInfo.Monitors),
+		RadosNamespace: cephBlockPoolRadosNamespace.Name,
+		ReadAffinity:   csi.ReadAffinity(&cephCluster.Spec),
+	}
+	err = csi.SaveClusterConfig(r.context.Clientset, buildClusterID(cephBlockPoolRadosNamespace), r.clusterInfo, &csiClusterConfigEntry)
+	if err != nil {
+		return reconcile.Result{}, cephBlockPoolRadosNamespace, errors.Wrap(err, "failed to save cluster config")
+	}
+
+	// Success! Let's update the status
+	if cephCluster.Spec.External.Enable {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionConnected, mirroringInfo)
+	} else {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionReady, mirroringInfo)
+	}
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, cephBlockPoolRadosNamespace, nil
+}
+
+// validateMirroring checks that the namespace can be mirrored with the peers of its pool
+func validateMirroring(cephBlockPoolRadosNamespace *cephv1.CephBlockPoolRadosNamespace, cephBlockPool *cephv1.CephBlockPool) error {
+	mirroring := cephBlockPoolRadosNamespace.Spec.Mirroring
+	if mirroring == nil {
+		return nil
+	}
+	if !cephBlockPool.Spec.Mirroring.Enabled {
+		return errors.Errorf("mirroring of the rados namespace requires the mirroring of ceph block pool %q to be enabled", cephBlockPool.Name)
+	}
+	if mirroring.Mode != cephv1.RadosNamespaceMirroringModePool && mirroring.Mode != cephv1.RadosNamespaceMirroringModeImage {
+		return errors.Errorf("unrecognized mirroring mode %q. only 'image' and 'pool' are supported", mirroring.Mode)
+	}
+	return nil
+}
+
+// reconcileMirroring enables or disables the mirroring of the namespace and sets its snapshot
+// schedules. The mirroring is disabled first when the remote namespace changes since ceph does not
+// allow changing the remote namespace of a mirrored namespace.
+func (r *ReconcileCephBlockPoolRadosNamespace) reconcileMirroring(cephBlockPoolRadosNamespace *cephv1.CephBlockPoolRadosNamespace, poolName string) (*cephv1.RadosNamespaceMirroringInfoSpec, error) {
+	namespace := cephBlockPoolRadosNamespace.Name
+	mirroring := cephBlockPoolRadosNamespace.Spec.Mirroring
+	status := &cephv1.RadosNamespaceMirroringInfoSpec{
+		Mode:        mirroringDisabled,
+		LastChecked: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	current, err := cephclient.GetRadosNamespaceMirroringInfo(r.context, r.clusterInfo, poolName, namespace)
+	if err != nil {
+		status.Details = err.Error()
+		return status, err
+	}
+	enabled := current.Mode != "" && current.Mode != mirroringDisabled
+
+	if mirroring == nil {
+		if enabled {
+			err = cephclient.DisableRadosNamespaceMirroring(r.context, r.clusterInfo, poolName, namespace)
+			if err != nil {
+				status.Details = err.Error()
+				return status, err
+			}
+		}
+		return nil, nil
+	}
+
+	remoteNamespace := namespace
+	if mirroring.RemoteNamespace != nil {
+		remoteNamespace = *mirroring.RemoteNamespace
+	}
+	currentRemoteNamespace := current.RemoteNamespace
+	if currentRemoteNamespace == "" {
+		currentRemoteNamespace = namespace
+	}
+	if enabled && currentRemoteNamespace != remoteNamespace {
+		logger.Infof("remote namespace of rados namespace %q of pool %q changed from %q to %q", namespace, poolName, currentRemoteNamespace, remoteNamespace)
+		err = cephclient.DisableRadosNamespaceMirroring(r.context, r.clusterInfo, poolName, namespace)
+		if err != nil {
+			status.Details = err.Error()
+			return status, err
+		}
+		enabled = false
+	}
+	if !enabled || current.Mode != string(mirroring.Mode) {
+		err = cephclient.EnableRadosNamespaceMirroring(r.context, r.clusterInfo, poolName, namespace, mirroring)
+		if err != nil {
+			status.Details = err.Error()
+			return status, err
+		}
+	}
+	status.Mode = string(mirroring.Mode)
+	status.RemoteNamespace = remoteNamespace
+
+	err = cephclient.ConfigureRadosNamespaceSnapshotSchedules(r.context, r.clusterInfo, poolName, namespace, mirroring.SnapshotSchedules)
+	if err != nil {
+		status.Details = err.Error()
+		return status, err
+	}
+
+	return status, nil
+}
+
+// deleteRadosNamespace disables the mirroring of the namespace and deletes it from its pool
+func (r *ReconcileCephBlockPoolRadosNamespace) deleteRadosNamespace(cephBlockPoolRadosNamespace *cephv1.CephBlockPoolRadosNamespace, poolName string) error {
+	namespace := cephBlockPoolRadosNamespace.Name
+	// A mirrored namespace cannot be removed
+	if cephBlockPoolRadosNamespace.Spec.Mirroring != nil {
+		info, err := cephclient.GetRadosNamespaceMirroringInfo(r.context, r.clusterInfo, poolName, namespace)
+		if err == nil && info.Mode != "" && info.Mode != mirroringDisabled {
+			err = cephclient.DisableRadosNamespaceMirroring(r.context, r.clusterInfo, poolName, namespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err := cephclient.DeleteRadosNamespace(r.context, r.clusterInfo, poolName, namespace)
+	if err != nil {
+		code, ok := exec.ExitStatus(err)
+		// If the namespace does not exist, we should not return an error
+		if ok && code == int(syscall.ENOENT) {
+			logger.Debugf("rados namespace %q does not exist in pool %q", namespace, poolName)
+			return nil
+		}
+		// If the namespace still has images the command will fail with EBUSY
+		if ok && (code == int(syscall.EBUSY) || code == int(syscall.ENOTEMPTY)) {
+			return errors.Wrapf(err, "failed to delete rados namespace %q of pool %q, remove the images first", namespace, poolName)
+		}
+		return errors.Wrapf(err, "failed to delete rados namespace %q of pool %q", namespace, poolName)
+	}
+
+	return nil
+}
+
+// cephPoolName returns the name of the ceph pool of the namespace. The pool CR may be gone already
+// when the namespace is deleted, its name is then used as the name of the pool.
+func (r *ReconcileCephBlockPoolRadosNamespace) cephPoolName(cephBlockPoolRadosNamespace *cephv1.CephBlockPoolRadosNamespace) (string, error) {
+	cephBlockPool := &cephv1.CephBlockPool{}
+	err := r.client.Get(r.opManagerContext, types.NamespacedName{Name: cephBlockPoolRadosNamespace.Spec.BlockPoolName, Namespace: cephBlockPoolRadosNamespace.Namespace}, cephBlockPool)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return cephBlockPoolRadosNamespace.Spec.BlockPoolName, nil
+		}
+		return "", errors.Wrapf(err, "failed to get ceph block pool %q", cephBlockPoolRadosNamespace.Spec.BlockPoolName)
+	}
+	return cephPoolName(cephBlockPool), nil
+}
+
+func cephPoolName(cephBlockPool *cephv1.CephBlockPool) string {
+	// If the name is not overridden in the pool spec.name, the name of the pool CR is used
+	if cephBlockPool.Spec.Name == "" {
+		return cephBlockPool.Name
+	}
+	return cephBlockPool.Spec.Name
+}
+
+// updateStatus updates an object with a given status
+func (r *ReconcileCephBlockPoolRadosNamespace) updateStatus(name types.NamespacedName, status cephv1.ConditionType, mirroringInfo *cephv1.RadosNamespaceMirroringInfoSpec) {
+	cephBlockPoolRadosNamespace := &cephv1.CephBlockPoolRadosNamespace{}
+	if err := r.client.Get(r.opManagerContext, name, cephBlockPoolRadosNamespace); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPoolRadosNamespace resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve rados namespace %q to update status to %q. %v", name, status, err)
+		return
+	}
+	err := reporting.PatchStatus(r.client, cephBlockPoolRadosNamespace, func() bool {
+		if cephBlockPoolRadosNamespace.Status == nil {
+			cephBlockPoolRadosNamespace.Status = &cephv1.CephBlockPoolRadosNamespaceStatus{}
+		}
+		cephBlockPoolRadosNamespace.Status.Phase = status
+		cephBlockPoolRadosNamespace.Status.Info = map[string]string{"clusterID": buildClusterID(cephBlockPoolRadosNamespace)}
+		if status != cephv1.ConditionProgressing {
+			cephBlockPoolRadosNamespace.Status.MirroringInfo = mirroringInfo
+		}
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set rados namespace %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("rados namespace %q status updated to %q", name, status)
+}
+
+func buildClusterID(cephBlockPoolRadosNamespace *cephv1.CephBlockPoolRadosNamespace) string {
+	clusterID := fmt.Sprintf("%s-%s-block-%s", cephBlockPoolRadosNamespace.Namespace, cephBlockPoolRadosNamespace.Spec.BlockPoolName, cephBlockPoolRadosNamespace.Name)
+	return k8sutil.Hash(clusterID)
+}
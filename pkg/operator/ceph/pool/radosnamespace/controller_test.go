@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package radosnamespace
+
+import (
+	"context"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileMirroring(t *testing.T) {
+	var commands []string
+	currentInfo := `{"mode":"disabled"}`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "mirror" && args[1] == "pool" && args[2] == "info" {
+				return currentInfo, nil
+			}
+			if args[0] == "mirror" && args[1] == "snapshot" && args[3] == "ls" {
+				return "[]", nil
+			}
+			if args[0] == "mirror" {
+				commands = append(commands, strings.Join(args, " "))
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	r := &ReconcileCephBlockPoolRadosNamespace{
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminTestClusterInfo("mycluster"),
+	}
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Namespace: "rook-ceph"},
+		Spec:       cephv1.CephBlockPoolRadosNamespaceSpec{BlockPoolName: "replicapool"},
+	}
+
+	t.Run("mirroring not requested", func(t *testing.T) {
+		commands = nil
+		status, err := r.reconcileMirroring(radosNamespace, "replicapool")
+		assert.NoError(t, err)
+		assert.Nil(t, status)
+		assert.Empty(t, commands)
+	})
+
+	t.Run("mirroring enabled with the remote namespace", func(t *testing.T) {
+		commands = nil
+		remote := "tenant-b"
+		radosNamespace.Spec.Mirroring = &cephv1.RadosNamespaceMirroring{
+			Mode:              cephv1.RadosNamespaceMirroringModeImage,
+			RemoteNamespace:   &remote,
+			SnapshotSchedules: []cephv1.SnapshotScheduleSpec{{Interval: "1h"}},
+		}
+		status, err := r.reconcileMirroring(radosNamespace, "replicapool")
+		assert.NoError(t, err)
+		assert.Equal(t, "image", status.Mode)
+		assert.Equal(t, "tenant-b", status.RemoteNamespace)
+		assert.NotEmpty(t, status.LastChecked)
+		assert.Equal(t, 2, len(commands))
+		assert.True(t, strings.HasPrefix(commands[0], "mirror pool enable replicapool/tenant-a image --remote-namespace tenant-b"))
+		assert.True(t, strings.HasPrefix(commands[1], "mirror snapshot schedule add --pool replicapool --namespace tenant-a 1h"))
+	})
+
+	t.Run("mirroring already enabled", func(t *testing.T) {
+		commands = nil
+		currentInfo = `{"mode":"image","remote_namespace":"tenant-b"}`
+		radosNamespace.Spec.Mirroring.SnapshotSchedules = nil
+		_, err := r.reconcileMirroring(radosNamespace, "replicapool")
+		assert.NoError(t, err)
+		assert.Empty(t, commands)
+	})
+
+	t.Run("remote namespace changed", func(t *testing.T) {
+		commands = nil
+		radosNamespace.Spec.Mirroring.RemoteNamespace = nil
+		status, err := r.reconcileMirroring(radosNamespace, "replicapool")
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant-a", status.RemoteNamespace)
+		assert.Equal(t, 2, len(commands))
+		assert.True(t, strings.HasPrefix(commands[0], "mirror pool disable replicapool/tenant-a"))
+		assert.True(t, strings.HasPrefix(commands[1], "mirror pool enable replicapool/tenant-a image"))
+		assert.NotContains(t, commands[1], "--remote-namespace")
+	})
+
+	t.Run("mirroring removed from the spec", func(t *testing.T) {
+		commands = nil
+		radosNamespace.Spec.Mirroring = nil
+		status, err := r.reconcileMirroring(radosNamespace, "replicapool")
+		assert.NoError(t, err)
+		assert.Nil(t, status)
+		assert.Equal(t, 1, len(commands))
+		assert.True(t, strings.HasPrefix(commands[0], "mirror pool disable replicapool/tenant-a"))
+	})
+}
+
+func TestDeleteRadosNamespace(t *testing.T) {
+	retcode := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "namespace" && args[1] == "remove" {
+				assert.Equal(t, "replicapool/tenant-a", args[2])
+				if retcode != 0 {
+					return "", exectest.MockExecCommandReturns(t, "", "", retcode)
+				}
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command. %v", args)
+		},
+	}
+	r := &ReconcileCephBlockPoolRadosNamespace{
+		context:          &clusterd.Context{Executor: executor},
+		clusterInfo:      cephclient.AdminTestClusterInfo("mycluster"),
+		opManagerContext: context.TODO(),
+	}
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Namespace: "rook-ceph"},
+		Spec:       cephv1.CephBlockPoolRadosNamespaceSpec{BlockPoolName: "replicapool"},
+	}
+
+	assert.NoError(t, r.deleteRadosNamespace(radosNamespace, "replicapool"))
+
+	retcode = int(syscall.ENOENT)
+	assert.NoError(t, r.deleteRadosNamespace(radosNamespace, "replicapool"))
+
+	retcode = int(syscall.EBUSY)
+	err := r.deleteRadosNamespace(radosNamespace, "replicapool")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "remove the images first")
+}
+
+// import TestMockExecHelperProcess
+func TestMockExecHelperProcess(t *testing.T) {
+	exectest.TestMockExecHelperProcess(t)
+}
+
+func Test_buildClusterID(t *testing.T) {
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "tenant-a"}, Spec: cephv1.CephBlockPoolRadosNamespaceSpec{BlockPoolName: "replicapool"}}
+	other := &cephv1.CephBlockPoolRadosNamespace{ObjectMeta: metav1.ObjectMeta{Namespace: "rook-ceph", Name: "tenant-a"}, Spec: cephv1.CephBlockPoolRadosNamespaceSpec{BlockPoolName: "otherpool"}}
+	assert.Len(t, buildClusterID(radosNamespace), 32)
+	assert.NotEqual(t, buildClusterID(radosNamespace), buildClusterID(other))
+}
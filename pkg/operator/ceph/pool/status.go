@@ -19,9 +19,11 @@ package pool
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/reporting"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -41,13 +43,15 @@ func updateStatus(client client.Client, poolName types.NamespacedName, status ce
 		return
 	}
 
-	if pool.Status == nil {
-		pool.Status = &cephv1.CephBlockPoolStatus{}
-	}
-
-	pool.Status.Phase = status
-	pool.Status.Info = info
-	if err := reporting.UpdateStatus(client, pool); err != nil {
+	err = reporting.PatchStatus(client, pool, func() bool {
+		if pool.Status == nil {
+			pool.Status = &cephv1.CephBlockPoolStatus{}
+		}
+		pool.Status.Phase = status
+		pool.Status.Info = info
+		return true
+	})
+	if err != nil {
 		logger.Warningf("failed to set pool %q status to %q. %v", pool.Name, status, err)
 		return
 	}
@@ -65,13 +69,16 @@ func (c *mirrorChecker) updateStatusMirroring(mirrorStatus *cephv1.PoolMirroring
 		logger.Warningf("failed to retrieve ceph block pool %q to update mirroring status. %v", c.namespacedName.Name, err)
 		return
 	}
-	if blockPool.Status == nil {
-		blockPool.Status = &cephv1.CephBlockPoolStatus{}
-	}
-
-	// Update the CephBlockPool CR status field
-	blockPool.Status.MirroringStatus, blockPool.Status.MirroringInfo, blockPool.Status.SnapshotScheduleStatus = toCustomResourceStatus(blockPool.Status.MirroringStatus, mirrorStatus, blockPool.Status.MirroringInfo, mirrorInfo, blockPool.Status.SnapshotScheduleStatus, snapSchedStatus, details)
-	if err := reporting.UpdateStatus(c.client, blockPool); err != nil {
+	err := reporting.PatchStatus(c.client, blockPool, func() bool {
+		if blockPool.Status == nil {
+			blockPool.Status = &cephv1.CephBlockPoolStatus{}
+		}
+		// Update the CephBlockPool CR status field
+		blockPool.Status.MirroringStatus, blockPool.Status.MirroringInfo, blockPool.Status.SnapshotScheduleStatus = toCustomResourceStatus(blockPool.Status.MirroringStatus, mirrorStatus, blockPool.Status.MirroringInfo, mirrorInfo, blockPool.Status.SnapshotScheduleStatus, snapSchedStatus, details)
+		blockPool.Status.SnapshotScheduleStatus.ImagesWithoutSchedule = c.imagesWithoutSchedule
+		return true
+	})
+	if err != nil {
 		logger.Errorf("failed to set ceph block pool %q mirroring status. %v", c.namespacedName.Name, err)
 		return
 	}
@@ -79,6 +86,150 @@ func (c *mirrorChecker) updateStatusMirroring(mirrorStatus *cephv1.PoolMirroring
 	logger.Debugf("ceph block pool %q mirroring status updated", c.namespacedName.Name)
 }
 
+// updateStatusPeerConnected sets the PeerConnected condition of the pool
+func (c *mirrorChecker) updateStatusPeerConnected(condition cephv1.Condition) {
+	blockPool := &cephv1.CephBlockPool{}
+	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, blockPool); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPool resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph block pool %q to update the peer connection status. %v", c.namespacedName.Name, err)
+		return
+	}
+	err := reporting.PatchStatus(c.client, blockPool, func() bool {
+		if blockPool.Status == nil {
+			blockPool.Status = &cephv1.CephBlockPoolStatus{}
+		}
+		cephv1.SetStatusCondition(&blockPool.Status.Conditions, condition)
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set ceph block pool %q peer connection status. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	logger.Debugf("ceph block pool %q peer connection status updated to %q", c.namespacedName.Name, condition.Status)
+}
+
+// updateStatusGroupReplication sets the status of the volume group replications of the pool
+func (c *mirrorChecker) updateStatusGroupReplication(status *cephv1.GroupReplicationStatusSpec) {
+	blockPool := &cephv1.CephBlockPool{}
+	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, blockPool); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPool resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph block pool %q to update the group replication status. %v", c.namespacedName.Name, err)
+		return
+	}
+	err := reporting.PatchStatus(c.client, blockPool, func() bool {
+		if blockPool.Status == nil {
+			blockPool.Status = &cephv1.CephBlockPoolStatus{}
+		}
+		if blockPool.Status.GroupReplicationStatus == nil && status == nil {
+			return false
+		}
+		blockPool.Status.GroupReplicationStatus = status
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set ceph block pool %q group replication status. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	logger.Debugf("ceph block pool %q group replication status updated", c.namespacedName.Name)
+}
+
+// updateStatusImageModeMigration sets the progress of the migration of the images to another
+// mirroring mode, or removes it when no image mode is set
+func (c *mirrorChecker) updateStatusImageModeMigration(status *cephv1.ImageModeMigrationStatusSpec) {
+	blockPool := &cephv1.CephBlockPool{}
+	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, blockPool); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPool resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph block pool %q to update the image mode migration status. %v", c.namespacedName.Name, err)
+		return
+	}
+	err := reporting.PatchStatus(c.client, blockPool, func() bool {
+		if blockPool.Status == nil {
+			blockPool.Status = &cephv1.CephBlockPoolStatus{}
+		}
+		if blockPool.Status.ImageModeMigrationStatus == nil && status == nil {
+			return false
+		}
+		blockPool.Status.ImageModeMigrationStatus = status
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set ceph block pool %q image mode migration status. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	logger.Debugf("ceph block pool %q image mode migration status updated", c.namespacedName.Name)
+}
+
+// updateStatusReplication sets the DRReady and ReplicationDegraded conditions of the pool from its
+// mirroring status, or removes them when the mirroring is disabled
+func (c *mirrorChecker) updateStatusReplication() {
+	blockPool := &cephv1.CephBlockPool{}
+	if err := c.client.Get(c.clusterInfo.Context, c.namespacedName, blockPool); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPool resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph block pool %q to update the replication status. %v", c.namespacedName.Name, err)
+		return
+	}
+	err := reporting.PatchStatus(c.client, blockPool, func() bool {
+		if blockPool.Status == nil {
+			blockPool.Status = &cephv1.CephBlockPoolStatus{}
+		}
+		if !c.poolSpec.Mirroring.Enabled {
+			return opcontroller.RemoveReplicationConditions(&blockPool.Status.Conditions)
+		}
+		opcontroller.SetReplicationConditions(&blockPool.Status.Conditions, replicationIssues(blockPool.Status))
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set ceph block pool %q replication status. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	logger.Debugf("ceph block pool %q replication status updated", c.namespacedName.Name)
+}
+
+// replicationIssues returns the issues of the mirroring of the pool found by the last checks
+func replicationIssues(status *cephv1.CephBlockPoolStatus) []opcontroller.ReplicationIssue {
+	issues := []opcontroller.ReplicationIssue{}
+	if issue := opcontroller.PeerConnectionIssue(status.Conditions); issue != nil {
+		issues = append(issues, *issue)
+	}
+
+	mirroringStatus := status.MirroringStatus
+	switch {
+	case mirroringStatus == nil || (mirroringStatus.Summary == nil && mirroringStatus.Details == ""):
+		issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationStatusUnknownReason, Message: "the mirroring status was not checked yet"})
+	case mirroringStatus.Details != "":
+		issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationStatusUnknownReason, Message: fmt.Sprintf("failed to check the mirroring status. %s", mirroringStatus.Details)})
+	default:
+		summary := mirroringStatus.Summary
+		if summary.DaemonHealth != "OK" {
+			issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationDaemonUnhealthyReason, Message: fmt.Sprintf("the rbd-mirror daemon health is %q", summary.DaemonHealth)})
+		}
+		if summary.ImageHealth != "OK" {
+			issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationSyncBehindReason, Message: fmt.Sprintf("the mirrored images health is %q", summary.ImageHealth)})
+		}
+	}
+
+	if status.GroupReplicationStatus != nil && status.GroupReplicationStatus.Degraded > 0 {
+		issues = append(issues, opcontroller.ReplicationIssue{Reason: cephv1.ReplicationSyncBehindReason, Message: fmt.Sprintf("%d volume group replication(s) are degraded", status.GroupReplicationStatus.Degraded)})
+	}
+	return issues
+}
+
 func toCustomResourceStatus(currentStatus *cephv1.MirroringStatusSpec, mirroringStatus *cephv1.PoolMirroringStatusSummarySpec,
 	currentInfo *cephv1.MirroringInfoSpec, mirroringInfo *cephv1.PoolMirroringInfo,
 	currentSnapSchedStatus *cephv1.SnapshotScheduleStatusSpec, snapSchedStatus []cephv1.SnapshotSchedulesSpec,
@@ -22,6 +22,7 @@ import (
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 )
 
 func TestToCustomResourceStatus(t *testing.T) {
@@ -58,3 +59,30 @@ func TestToCustomResourceStatus(t *testing.T) {
 		assert.NotEmpty(t, newSnapshotScheduleStatus)
 	}
 }
+
+func TestReplicationIssues(t *testing.T) {
+	status := &cephv1.CephBlockPoolStatus{}
+	issues := replicationIssues(status)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, cephv1.ReplicationStatusUnknownReason, issues[0].Reason)
+
+	status.MirroringStatus = &cephv1.MirroringStatusSpec{PoolMirroringStatus: cephv1.PoolMirroringStatus{Summary: &cephv1.PoolMirroringStatusSummarySpec{DaemonHealth: "OK", ImageHealth: "OK"}}}
+	assert.Empty(t, replicationIssues(status))
+
+	status.MirroringStatus.Summary.DaemonHealth = "WARNING"
+	status.MirroringStatus.Summary.ImageHealth = "WARNING"
+	status.GroupReplicationStatus = &cephv1.GroupReplicationStatusSpec{Degraded: 2}
+	issues = replicationIssues(status)
+	assert.Len(t, issues, 3)
+	assert.Equal(t, cephv1.ReplicationDaemonUnhealthyReason, issues[0].Reason)
+	assert.Equal(t, cephv1.ReplicationSyncBehindReason, issues[1].Reason)
+	assert.Equal(t, cephv1.ReplicationSyncBehindReason, issues[2].Reason)
+
+	status.MirroringStatus = &cephv1.MirroringStatusSpec{Details: "timeout"}
+	status.GroupReplicationStatus = nil
+	status.Conditions = []cephv1.Condition{{Type: cephv1.ConditionPeerConnected, Status: v1.ConditionFalse, Message: "unreachable"}}
+	issues = replicationIssues(status)
+	assert.Len(t, issues, 2)
+	assert.Equal(t, cephv1.PeerConnectionFailedReason, issues[0].Reason)
+	assert.Equal(t, cephv1.ReplicationStatusUnknownReason, issues[1].Reason)
+}
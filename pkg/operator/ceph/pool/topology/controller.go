@@ -0,0 +1,362 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package topology to manage the per failure domain pools and StorageClass of a CephBlockPoolTopology
+package topology
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/reporting"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-block-pool-topology-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephBlockPoolTopologyKind = reflect.TypeOf(cephv1.CephBlockPoolTopology{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephBlockPoolTopologyKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephBlockPoolTopology reconciles a CephBlockPoolTopology object
+type ReconcileCephBlockPoolTopology struct {
+	client           client.Client
+	scheme           *runtime.Scheme
+	context          *clusterd.Context
+	opManagerContext context.Context
+	recorder         record.EventRecorder
+}
+
+// Add creates a new CephBlockPoolTopology Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context, opConfig opcontroller.OperatorConfig) error {
+	return add(mgr, newReconciler(mgr, context, opManagerContext))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context, opManagerContext context.Context) reconcile.Reconciler {
+	return &ReconcileCephBlockPoolTopology{
+		client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		context:          context,
+		opManagerContext: opManagerContext,
+		recorder:         mgr.GetEventRecorderFor("rook-" + controllerName),
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	reconciler := opcontroller.WithReconcileGates(controllerName, mgr.GetClient(), &cephv1.CephBlockPoolTopology{}, r)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: opcontroller.WithReconcileMetrics(controllerName, mgr.GetClient(), &cephv1.CephBlockPoolTopology{}, reconciler)})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephBlockPoolTopology CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephBlockPoolTopology{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	// Watch the pools owned by a CephBlockPoolTopology so that they are restored if modified
+	err = c.Watch(&source.Kind{Type: &cephv1.CephBlockPool{TypeMeta: metav1.TypeMeta{Kind: "CephBlockPool", APIVersion: controllerTypeMeta.APIVersion}}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &cephv1.CephBlockPoolTopology{},
+	}, opcontroller.WatchPredicateForNonCRDObject(&cephv1.CephBlockPoolTopology{TypeMeta: controllerTypeMeta}, mgr.GetScheme()))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephBlockPoolTopology object and makes changes based on the state read
+// and what is in the CephBlockPoolTopology.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephBlockPoolTopology) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, cephBlockPoolTopology, err := r.reconcile(request)
+	reporting.RecordReconcileResult(logger, r.recorder, request, cephBlockPoolTopology, reconcileResponse, err)
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephBlockPoolTopology) reconcile(request reconcile.Request) (reconcile.Result, *cephv1.CephBlockPoolTopology, error) {
+	// Fetch the CephBlockPoolTopology instance
+	cephBlockPoolTopology := &cephv1.CephBlockPoolTopology{}
+	err := r.client.Get(r.opManagerContext, request.NamespacedName, cephBlockPoolTopology)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephBlockPoolTopology resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, cephBlockPoolTopology, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, cephBlockPoolTopology, errors.Wrap(err, "failed to get cephBlockPoolTopology")
+	}
+
+	// Set a finalizer so we can do cleanup before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.opManagerContext, r.client, cephBlockPoolTopology)
+	if err != nil {
+		return reconcile.Result{}, cephBlockPoolTopology, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// The CR was just created, initializing status fields
+	if cephBlockPoolTopology.Status == nil {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionProgressing, nil)
+	}
+
+	// DELETE: the CR was deleted
+	// The pools are owned by the CR and are garbage collected, only the cluster scoped StorageClass
+	// must be removed explicitly
+	if !cephBlockPoolTopology.GetDeletionTimestamp().IsZero() {
+		logger.Debugf("deleting block pool topology %q", request.NamespacedName)
+		err = r.deleteStorageClasses(cephBlockPoolTopology, "")
+		if err != nil {
+			return reconcile.Result{}, cephBlockPoolTopology, errors.Wrapf(err, "failed to delete storage class of block pool topology %q", request.NamespacedName)
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.opManagerContext, r.client, cephBlockPoolTopology)
+		if err != nil {
+			return reconcile.Result{}, cephBlockPoolTopology, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, cephBlockPoolTopology, nil
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	cephCluster, isReadyToReconcile, _, reconcileResponse := opcontroller.IsReadyToReconcile(r.opManagerContext, r.client, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, cephBlockPoolTopology, nil
+	}
+	if cephCluster.Spec.External.Enable {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionFailure, nil)
+		return reconcile.Result{}, cephBlockPoolTopology, errors.Errorf("block pool topology %q is not supported on an external cluster", request.NamespacedName)
+	}
+
+	err = ValidateBlockPoolTopology(cephBlockPoolTopology)
+	if err != nil {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionFailure, nil)
+		return reconcile.Result{}, cephBlockPoolTopology, errors.Wrapf(err, "invalid block pool topology %q", request.NamespacedName)
+	}
+
+	// The StorageClass references the RBD driver of this operator, which is only known once the
+	// csi controller has started the drivers
+	driverName := csi.RBDDriverNameForCluster(&cephCluster.Spec)
+	if driverName == "" {
+		logger.Infof("waiting for the ceph-csi rbd driver to be started before configuring block pool topology %q", request.NamespacedName)
+		return opcontroller.WaitForRequeueIfCephClusterNotReady, cephBlockPoolTopology, nil
+	}
+
+	pools, err := r.createOrUpdatePools(cephBlockPoolTopology)
+	if err != nil {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionFailure, nil)
+		return reconcile.Result{}, cephBlockPoolTopology, errors.Wrapf(err, "failed to configure pools of block pool topology %q", request.NamespacedName)
+	}
+
+	err = r.createOrUpdateStorageClass(cephBlockPoolTopology, pools, driverName)
+	if err != nil {
+		r.updateStatus(request.NamespacedName, cephv1.ConditionFailure, nil)
+		return reconcile.Result{}, cephBlockPoolTopology, errors.Wrapf(err, "failed to configure storage class of block pool topology %q", request.NamespacedName)
+	}
+
+	// The StorageClass can be consumed as soon as all the pools are ready
+	for _, pool := range pools {
+		if pool.Status == nil || pool.Status.Phase != cephv1.ConditionReady {
+			logger.Infof("waiting for pool %q of block pool topology %q to be ready", pool.Name, request.NamespacedName)
+			r.updateStatus(request.NamespacedName, cephv1.ConditionProgressing, pools)
+			return opcontroller.WaitForRequeueIfCephClusterNotReady, cephBlockPoolTopology, nil
+		}
+	}
+
+	// Success! Let's update the status
+	r.updateStatus(request.NamespacedName, cephv1.ConditionReady, pools)
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, cephBlockPoolTopology, nil
+}
+
+// ValidateBlockPoolTopology validates the block pool topology settings
+func ValidateBlockPoolTopology(t *cephv1.CephBlockPoolTopology) error {
+	if len(t.Spec.FailureDomains) == 0 {
+		return errors.New("at least one failure domain must be specified")
+	}
+
+	seen := map[string]bool{}
+	for _, domain := range t.Spec.FailureDomains {
+		if seen[domain] {
+			return errors.Errorf("failure domain %q is specified more than once", domain)
+		}
+		seen[domain] = true
+
+		// the failure domain is part of the name of the CephBlockPool
+		poolName := poolNameForDomain(t, domain)
+		if errs := validation.IsDNS1123Subdomain(poolName); len(errs) > 0 {
+			return errors.Errorf("invalid pool name %q for failure domain %q. %v", poolName, domain, errs)
+		}
+	}
+
+	if t.Spec.Replicated.Size == 0 {
+		return errors.New("replicated size must be specified")
+	}
+
+	return nil
+}
+
+// createOrUpdatePools ensures that a CephBlockPool exists for each failure domain. The pools are
+// created as CRs so the pool controller takes care of configuring them in Ceph.
+func (r *ReconcileCephBlockPoolTopology) createOrUpdatePools(t *cephv1.CephBlockPoolTopology) ([]cephv1.CephBlockPool, error) {
+	pools := []cephv1.CephBlockPool{}
+	for _, domain := range t.Spec.FailureDomains {
+		pool := &cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      poolNameForDomain(t, domain),
+				Namespace: t.Namespace,
+			},
+		}
+
+		op, err := controllerutil.CreateOrUpdate(r.opManagerContext, r.client, pool, func() error {
+			pool.Spec.PoolSpec = poolSpecForDomain(t, domain)
+			return controllerutil.SetControllerReference(t, pool, r.scheme)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create or update pool %q for failure domain %q", pool.Name, domain)
+		}
+		logger.Debugf("pool %q for failure domain %q %s", pool.Name, domain, op)
+
+		pools = append(pools, *pool)
+	}
+
+	// Remove the pools of the failure domains that are no longer part of the spec
+	poolList := &cephv1.CephBlockPoolList{}
+	err := r.client.List(r.opManagerContext, poolList, client.InNamespace(t.Namespace))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list pools")
+	}
+	for i := range poolList.Items {
+		pool := &poolList.Items[i]
+		if !metav1.IsControlledBy(pool, t) || isPoolInSpec(t, pool.Name) {
+			continue
+		}
+		logger.Infof("deleting pool %q of block pool topology %q since its failure domain was removed", pool.Name, t.Name)
+		err = r.client.Delete(r.opManagerContext, pool)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to delete pool %q", pool.Name)
+		}
+	}
+
+	return pools, nil
+}
+
+func isPoolInSpec(t *cephv1.CephBlockPoolTopology, poolName string) bool {
+	for _, domain := range t.Spec.FailureDomains {
+		if poolNameForDomain(t, domain) == poolName {
+			return true
+		}
+	}
+	return false
+}
+
+func poolNameForDomain(t *cephv1.CephBlockPoolTopology, domain string) string {
+	return fmt.Sprintf("%s-%s", t.Name, domain)
+}
+
+// poolSpecForDomain returns the spec of the pool of a failure domain. The CRUSH root of the pool is
+// the bucket of the failure domain so that all the replicas are kept in that failure domain.
+func poolSpecForDomain(t *cephv1.CephBlockPoolTopology, domain string) cephv1.PoolSpec {
+	failureDomain := t.Spec.FailureDomain
+	if failureDomain == "" {
+		failureDomain = cephv1.DefaultFailureDomain
+	}
+
+	return cephv1.PoolSpec{
+		FailureDomain: failureDomain,
+		CrushRoot:     domain,
+		DeviceClass:   t.Spec.DeviceClass,
+		Replicated:    t.Spec.Replicated,
+	}
+}
+
+func domainLabel(t *cephv1.CephBlockPoolTopology) string {
+	if t.Spec.DomainLabel == "" {
+		return csi.DefaultTopologyDomainLabel
+	}
+	return t.Spec.DomainLabel
+}
+
+// updateStatus updates an object with a given status
+func (r *ReconcileCephBlockPoolTopology) updateStatus(name types.NamespacedName, status cephv1.ConditionType, pools []cephv1.CephBlockPool) {
+	cephBlockPoolTopology := &cephv1.CephBlockPoolTopology{}
+	if err := r.client.Get(r.opManagerContext, name, cephBlockPoolTopology); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPoolTopology resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve block pool topology %q to update status to %q. %v", name, status, err)
+		return
+	}
+	err := reporting.PatchStatus(r.client, cephBlockPoolTopology, func() bool {
+		if cephBlockPoolTopology.Status == nil {
+			cephBlockPoolTopology.Status = &cephv1.CephBlockPoolTopologyStatus{}
+		}
+		cephBlockPoolTopology.Status.Phase = status
+		if pools != nil {
+			cephBlockPoolTopology.Status.Pools = []string{}
+			for _, pool := range pools {
+				cephBlockPoolTopology.Status.Pools = append(cephBlockPoolTopology.Status.Pools, pool.Name)
+			}
+			cephBlockPoolTopology.Status.StorageClassName = storageClassName(cephBlockPoolTopology)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Errorf("failed to set block pool topology %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("block pool topology %q status updated to %q", name, status)
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newTopology() *cephv1.CephBlockPoolTopology {
+	return &cephv1.CephBlockPoolTopology{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "zonal",
+			Namespace: "rook-ceph",
+			UID:       types.UID("c47cac40-9bee-4d52-823b-ccd803ba5bfe"),
+		},
+		Spec: cephv1.CephBlockPoolTopologySpec{
+			FailureDomains: []string{"zone-a", "zone-b"},
+			Replicated:     cephv1.ReplicatedSpec{Size: 3},
+		},
+	}
+}
+
+func TestValidateBlockPoolTopology(t *testing.T) {
+	topology := newTopology()
+	assert.NoError(t, ValidateBlockPoolTopology(topology))
+
+	// duplicate failure domain
+	topology.Spec.FailureDomains = []string{"zone-a", "zone-a"}
+	assert.Error(t, ValidateBlockPoolTopology(topology))
+
+	// failure domain cannot be part of a pool name
+	topology.Spec.FailureDomains = []string{"Zone_A"}
+	assert.Error(t, ValidateBlockPoolTopology(topology))
+
+	// no failure domain
+	topology.Spec.FailureDomains = []string{}
+	assert.Error(t, ValidateBlockPoolTopology(topology))
+
+	// no replica
+	topology = newTopology()
+	topology.Spec.Replicated.Size = 0
+	assert.Error(t, ValidateBlockPoolTopology(topology))
+}
+
+func TestPoolSpecForDomain(t *testing.T) {
+	topology := newTopology()
+	spec := poolSpecForDomain(topology, "zone-a")
+	assert.Equal(t, "zone-a", spec.CrushRoot)
+	assert.Equal(t, cephv1.DefaultFailureDomain, spec.FailureDomain)
+	assert.Equal(t, uint(3), spec.Replicated.Size)
+
+	topology.Spec.FailureDomain = "rack"
+	topology.Spec.DeviceClass = "ssd"
+	spec = poolSpecForDomain(topology, "zone-b")
+	assert.Equal(t, "zone-b", spec.CrushRoot)
+	assert.Equal(t, "rack", spec.FailureDomain)
+	assert.Equal(t, "ssd", spec.DeviceClass)
+}
+
+func TestGenerateStorageClass(t *testing.T) {
+	topology := newTopology()
+	pools := []cephv1.CephBlockPool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "zonal-zone-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "zonal-zone-b"}},
+	}
+
+	sc, err := generateStorageClass(topology, pools, "rook-ceph.rbd.csi.ceph.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "zonal", sc.Name)
+	assert.Equal(t, "rook-ceph.rbd.csi.ceph.com", sc.Provisioner)
+	assert.Equal(t, storagev1.VolumeBindingWaitForFirstConsumer, *sc.VolumeBindingMode)
+	assert.Equal(t, corev1.PersistentVolumeReclaimDelete, *sc.ReclaimPolicy)
+	assert.Equal(t, "rook-ceph", sc.Parameters["clusterID"])
+	assert.Equal(t, csi.CsiRBDNodeSecret, sc.Parameters["csi.storage.k8s.io/node-stage-secret-name"])
+	assert.Equal(t, `[{"poolName":"zonal-zone-a","domainSegments":[{"domainLabel":"zone","value":"zone-a"}]},{"poolName":"zonal-zone-b","domainSegments":[{"domainLabel":"zone","value":"zone-b"}]}]`, sc.Parameters[topologyConstrainedPoolsParam])
+	assert.True(t, isOwnedStorageClass(topology, sc))
+
+	topology.Spec.DomainLabel = "failure-domain/rack"
+	topology.Spec.StorageClass = cephv1.TopologyStorageClassSpec{
+		Name:                 "rbd-zonal",
+		ReclaimPolicy:        corev1.PersistentVolumeReclaimRetain,
+		AllowVolumeExpansion: true,
+		Parameters: map[string]string{
+			"imageFeatures":               "layering,exclusive-lock",
+			topologyConstrainedPoolsParam: "ignored",
+			"csi.storage.k8s.io/fstype":   "xfs",
+		},
+	}
+	sc, err = generateStorageClass(topology, pools, "rook-ceph.rbd.csi.ceph.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "rbd-zonal", sc.Name)
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, *sc.ReclaimPolicy)
+	assert.True(t, *sc.AllowVolumeExpansion)
+	assert.Equal(t, "layering,exclusive-lock", sc.Parameters["imageFeatures"])
+	assert.Equal(t, "xfs", sc.Parameters["csi.storage.k8s.io/fstype"])
+	assert.Contains(t, sc.Parameters[topologyConstrainedPoolsParam], `{"domainLabel":"rack","value":"zone-a"}`)
+}
+
+func TestCephBlockPoolTopologyController(t *testing.T) {
+	ctx := context.TODO()
+	topology := newTopology()
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph",
+			Namespace: "rook-ceph",
+		},
+		Status: cephv1.ClusterStatus{
+			Phase: cephv1.ConditionReady,
+			CephStatus: &cephv1.CephStatus{
+				Health: "HEALTH_OK",
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{}, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{}, &cephv1.CephBlockPoolTopology{}, &cephv1.CephBlockPoolTopologyList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects([]runtime.Object{topology, cephCluster}...).Build()
+	c := &clusterd.Context{Clientset: testop.New(t, 1)}
+	r := &ReconcileCephBlockPoolTopology{client: cl, scheme: s, context: c, opManagerContext: ctx, recorder: &record.FakeRecorder{}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: topology.Name, Namespace: topology.Namespace}}
+
+	t.Run("wait for the rbd driver", func(t *testing.T) {
+		csi.RBDDriverName = ""
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.True(t, res.Requeue)
+	})
+
+	t.Run("pools and storage class are created", func(t *testing.T) {
+		csi.RBDDriverName = "rook-ceph.rbd.csi.ceph.com"
+		res, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		// the pools are not ready yet
+		assert.True(t, res.Requeue)
+
+		for _, domain := range topology.Spec.FailureDomains {
+			pool := &cephv1.CephBlockPool{}
+			err = cl.Get(ctx, types.NamespacedName{Name: "zonal-" + domain, Namespace: topology.Namespace}, pool)
+			assert.NoError(t, err)
+			assert.Equal(t, domain, pool.Spec.CrushRoot)
+			assert.True(t, metav1.IsControlledBy(pool, topology))
+
+			pool.Status = &cephv1.CephBlockPoolStatus{Phase: cephv1.ConditionReady}
+			assert.NoError(t, cl.Update(ctx, pool))
+		}
+
+		sc, err := c.Clientset.StorageV1().StorageClasses().Get(ctx, "zonal", metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, csi.RBDDriverName, sc.Provisioner)
+
+		res, err = r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		assert.False(t, res.Requeue)
+		err = cl.Get(ctx, req.NamespacedName, topology)
+		assert.NoError(t, err)
+		assert.Equal(t, cephv1.ConditionReady, topology.Status.Phase)
+		assert.Equal(t, []string{"zonal-zone-a", "zonal-zone-b"}, topology.Status.Pools)
+		assert.Equal(t, "zonal", topology.Status.StorageClassName)
+	})
+
+	t.Run("removed failure domain and renamed storage class", func(t *testing.T) {
+		topology.Spec.FailureDomains = []string{"zone-a"}
+		topology.Spec.StorageClass.Name = "rbd-zonal"
+		assert.NoError(t, cl.Update(ctx, topology))
+
+		_, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err)
+
+		pool := &cephv1.CephBlockPool{}
+		err = cl.Get(ctx, types.NamespacedName{Name: "zonal-zone-b", Namespace: topology.Namespace}, pool)
+		assert.Error(t, err)
+
+		_, err = c.Clientset.StorageV1().StorageClasses().Get(ctx, "zonal", metav1.GetOptions{})
+		assert.Error(t, err)
+		sc, err := c.Clientset.StorageV1().StorageClasses().Get(ctx, "rbd-zonal", metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.NotContains(t, sc.Parameters[topologyConstrainedPoolsParam], "zone-b")
+	})
+
+	t.Run("storage class not managed by the topology", func(t *testing.T) {
+		sc := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "manual"}}
+		_, err := c.Clientset.StorageV1().StorageClasses().Create(ctx, sc, metav1.CreateOptions{})
+		assert.NoError(t, err)
+
+		topology.Spec.StorageClass.Name = "manual"
+		err = r.createOrUpdateStorageClass(topology, []cephv1.CephBlockPool{{ObjectMeta: metav1.ObjectMeta{Name: "zonal-zone-a"}}}, csi.RBDDriverName)
+		assert.Error(t, err)
+
+		// the storage class is left untouched on deletion
+		assert.NoError(t, r.deleteStorageClasses(topology, ""))
+		_, err = c.Clientset.StorageV1().StorageClasses().Get(ctx, "manual", metav1.GetOptions{})
+		assert.NoError(t, err)
+		_, err = c.Clientset.StorageV1().StorageClasses().Get(ctx, "rbd-zonal", metav1.GetOptions{})
+		assert.Error(t, err)
+	})
+}
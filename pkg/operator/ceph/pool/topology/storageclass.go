@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// labels identifying the CephBlockPoolTopology a StorageClass belongs to
+	topologyNameLabel      = "ceph.rook.io/block-pool-topology"
+	topologyNamespaceLabel = "ceph.rook.io/block-pool-topology-namespace"
+
+	topologyConstrainedPoolsParam = "topologyConstrainedPools"
+)
+
+// topologyConstrainedPool is the ceph-csi representation of a pool restricted to a failure domain
+type topologyConstrainedPool struct {
+	PoolName       string          `json:"poolName"`
+	DomainSegments []domainSegment `json:"domainSegments"`
+}
+
+type domainSegment struct {
+	DomainLabel string `json:"domainLabel"`
+	Value       string `json:"value"`
+}
+
+func storageClassName(t *cephv1.CephBlockPoolTopology) string {
+	if t.Spec.StorageClass.Name != "" {
+		return t.Spec.StorageClass.Name
+	}
+	return t.Name
+}
+
+// generateStorageClass builds the StorageClass provisioning RBD volumes from the pool of the
+// failure domain the volume is scheduled in
+func generateStorageClass(t *cephv1.CephBlockPoolTopology, pools []cephv1.CephBlockPool, driverName string) (*storagev1.StorageClass, error) {
+	// ceph-csi only knows about the last segment of the domain label, e.g. "zone" for
+	// "topology.kubernetes.io/zone"
+	label := domainLabel(t)
+	label = label[strings.LastIndex(label, "/")+1:]
+
+	constrainedPools := []topologyConstrainedPool{}
+	for i, domain := range t.Spec.FailureDomains {
+		constrainedPools = append(constrainedPools, topologyConstrainedPool{
+			PoolName:       pools[i].Name,
+			DomainSegments: []domainSegment{{DomainLabel: label, Value: domain}},
+		})
+	}
+	constrainedPoolsJSON, err := json.Marshal(constrainedPools)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal topology constrained pools")
+	}
+
+	params := map[string]string{
+		"clusterID":     t.Namespace,
+		"imageFormat":   "2",
+		"imageFeatures": "layering",
+		"csi.storage.k8s.io/provisioner-secret-name":            csi.CsiRBDProvisionerSecret,
+		"csi.storage.k8s.io/provisioner-secret-namespace":       t.Namespace,
+		"csi.storage.k8s.io/controller-expand-secret-name":      csi.CsiRBDProvisionerSecret,
+		"csi.storage.k8s.io/controller-expand-secret-namespace": t.Namespace,
+		"csi.storage.k8s.io/node-stage-secret-name":             csi.CsiRBDNodeSecret,
+		"csi.storage.k8s.io/node-stage-secret-namespace":        t.Namespace,
+		"csi.storage.k8s.io/fstype":                             "ext4",
+	}
+	for k, v := range t.Spec.StorageClass.Parameters {
+		params[k] = v
+	}
+	// the pools are always those of the topology
+	params[topologyConstrainedPoolsParam] = string(constrainedPoolsJSON)
+
+	reclaimPolicy := corev1.PersistentVolumeReclaimDelete
+	if t.Spec.StorageClass.ReclaimPolicy != "" {
+		reclaimPolicy = t.Spec.StorageClass.ReclaimPolicy
+	}
+	// The pool can only be chosen once the node the volume is consumed on is known
+	bindingMode := storagev1.VolumeBindingWaitForFirstConsumer
+	allowVolumeExpansion := t.Spec.StorageClass.AllowVolumeExpansion
+
+	return &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: storageClassName(t),
+			Labels: map[string]string{
+				topologyNameLabel:      t.Name,
+				topologyNamespaceLabel: t.Namespace,
+			},
+		},
+		Provisioner:          driverName,
+		Parameters:           params,
+		ReclaimPolicy:        &reclaimPolicy,
+		VolumeBindingMode:    &bindingMode,
+		AllowVolumeExpansion: &allowVolumeExpansion,
+	}, nil
+}
+
+func isOwnedStorageClass(t *cephv1.CephBlockPoolTopology, sc *storagev1.StorageClass) bool {
+	return sc.Labels[topologyNameLabel] == t.Name && sc.Labels[topologyNamespaceLabel] == t.Namespace
+}
+
+// createOrUpdateStorageClass creates the StorageClass of the topology. Since the parameters of a
+// StorageClass are immutable, the StorageClass is re-created if they changed.
+func (r *ReconcileCephBlockPoolTopology) createOrUpdateStorageClass(t *cephv1.CephBlockPoolTopology, pools []cephv1.CephBlockPool, driverName string) error {
+	sc, err := generateStorageClass(t, pools, driverName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate storage class %q", storageClassName(t))
+	}
+
+	// Remove the StorageClass previously created under another name
+	err = r.deleteStorageClasses(t, sc.Name)
+	if err != nil {
+		return err
+	}
+
+	storageClasses := r.context.Clientset.StorageV1().StorageClasses()
+	existing, err := storageClasses.Get(r.opManagerContext, sc.Name, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get storage class %q", sc.Name)
+		}
+		logger.Infof("creating storage class %q for block pool topology %q", sc.Name, t.Name)
+		if _, err := storageClasses.Create(r.opManagerContext, sc, metav1.CreateOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to create storage class %q", sc.Name)
+		}
+		return nil
+	}
+
+	if !isOwnedStorageClass(t, existing) {
+		return errors.Errorf("storage class %q already exists and is not managed by block pool topology %q", sc.Name, t.Name)
+	}
+
+	if existing.Provisioner != sc.Provisioner ||
+		!reflect.DeepEqual(existing.Parameters, sc.Parameters) ||
+		!reflect.DeepEqual(existing.ReclaimPolicy, sc.ReclaimPolicy) ||
+		!reflect.DeepEqual(existing.VolumeBindingMode, sc.VolumeBindingMode) {
+		logger.Infof("re-creating storage class %q for block pool topology %q since its parameters changed", sc.Name, t.Name)
+		if err := storageClasses.Delete(r.opManagerContext, sc.Name, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete storage class %q", sc.Name)
+		}
+		if _, err := storageClasses.Create(r.opManagerContext, sc, metav1.CreateOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to create storage class %q", sc.Name)
+		}
+		return nil
+	}
+
+	if !reflect.DeepEqual(existing.AllowVolumeExpansion, sc.AllowVolumeExpansion) {
+		existing.AllowVolumeExpansion = sc.AllowVolumeExpansion
+		if _, err := storageClasses.Update(r.opManagerContext, existing, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to update storage class %q", sc.Name)
+		}
+	}
+
+	return nil
+}
+
+// deleteStorageClasses deletes the StorageClasses managed by the topology, except the one named keep
+func (r *ReconcileCephBlockPoolTopology) deleteStorageClasses(t *cephv1.CephBlockPoolTopology, keep string) error {
+	storageClasses := r.context.Clientset.StorageV1().StorageClasses()
+	selector := fmt.Sprintf("%s=%s,%s=%s", topologyNameLabel, t.Name, topologyNamespaceLabel, t.Namespace)
+	scList, err := storageClasses.List(r.opManagerContext, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrap(err, "failed to list storage classes")
+	}
+
+	for _, sc := range scList.Items {
+		if sc.Name == keep {
+			continue
+		}
+		logger.Infof("deleting storage class %q of block pool topology %q", sc.Name, t.Name)
+		err = storageClasses.Delete(r.opManagerContext, sc.Name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete storage class %q", sc.Name)
+		}
+	}
+
+	return nil
+}
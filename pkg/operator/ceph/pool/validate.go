@@ -169,6 +169,19 @@ func ValidatePoolSpec(context *clusterd.Context, clusterInfo *cephclient.Cluster
 				if snapSchedule.Interval == "" && snapSchedule.StartTime != "" {
 					return errors.New("schedule interval cannot be empty if start time is specified")
 				}
+				if snapSchedule.Image != "" && p.Mirroring.Mode != "image" {
+					return errors.Errorf("snapshot schedule of image %q requires the 'image' mirroring mode", snapSchedule.Image)
+				}
+			}
+		}
+
+		if p.Mirroring.ImageMode != "" {
+			if p.Mirroring.Mode != "image" {
+				return errors.Errorf("image mirroring mode %q requires the 'image' mirroring mode", p.Mirroring.ImageMode)
+			}
+			// the migrated images are only snapshotted by the schedules of the whole pool
+			if p.Mirroring.ImageMode == "snapshot" && !hasPoolSnapshotSchedule(p.Mirroring) {
+				return errors.New("image mirroring mode 'snapshot' requires a snapshot schedule of the pool")
 			}
 		}
 	}
@@ -180,6 +193,15 @@ func ValidatePoolSpec(context *clusterd.Context, clusterInfo *cephclient.Cluster
 	return nil
 }
 
+func hasPoolSnapshotSchedule(mirroring cephv1.MirroringSpec) bool {
+	for _, snapSchedule := range mirroring.SnapshotSchedules {
+		if snapSchedule.Image == "" && snapSchedule.Interval != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // validateDeviceClasses validates the primary and secondary device classes in the HybridStorageSpec
 func validateDeviceClasses(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, p *cephv1.PoolSpec) error {
 
@@ -190,6 +190,41 @@ func TestValidatePool(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("image mirroring mode", func(t *testing.T) {
+		p := cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: clusterInfo.Namespace}}
+		p.Spec.Mirroring.Enabled = true
+		p.Spec.Mirroring.Mode = "pool"
+		p.Spec.Mirroring.ImageMode = "journal"
+		err := validatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "image mirroring mode \"journal\" requires the 'image' mirroring mode")
+
+		p.Spec.Mirroring.Mode = "image"
+		err = validatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+
+		p.Spec.Mirroring.ImageMode = "snapshot"
+		p.Spec.Mirroring.SnapshotSchedules = []cephv1.SnapshotScheduleSpec{{Interval: "24h", Image: "myimage"}}
+		err = validatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "image mirroring mode 'snapshot' requires a snapshot schedule of the pool")
+
+		p.Spec.Mirroring.SnapshotSchedules = append(p.Spec.Mirroring.SnapshotSchedules, cephv1.SnapshotScheduleSpec{Interval: "1h"})
+		err = validatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail image snapshot schedule in pool mirroring mode", func(t *testing.T) {
+		p := cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: clusterInfo.Namespace}}
+		p.Spec.Mirroring.Enabled = true
+		p.Spec.Mirroring.Mode = "pool"
+		p.Spec.Mirroring.SnapshotSchedules = []cephv1.SnapshotScheduleSpec{{Interval: "24h", Image: "myimage"}}
+		err := validatePool(context, clusterInfo, clusterSpec, &p)
+		assert.Error(t, err)
+
+		p.Spec.Mirroring.Mode = "image"
+		err = validatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+	})
+
 	t.Run("failure and subfailure domains", func(t *testing.T) {
 		p := cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: clusterInfo.Namespace}}
 		p.Spec.FailureDomain = "host"
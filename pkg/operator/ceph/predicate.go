@@ -20,6 +20,7 @@ package operator
 import (
 	"context"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/controller"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -29,12 +30,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
+// the operator settings applied when the manager starts its controllers
+var managerSettings = []string{"ROOK_CURRENT_NAMESPACE_ONLY", controller.WatchNamespacesSetting, controller.OBCProvisionerSetting, controller.RequireControllerPermissionsSetting, controller.HealthProbeBindAddressSetting, admissionPoliciesSetting}
+
 // predicateOpController is the predicate function to trigger reconcile on operator configuration cm change
 func predicateController(client client.Client) predicate.Funcs {
 	return predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
 			if cm, ok := e.Object.(*v1.ConfigMap); ok {
 				return cm.Name == controller.OperatorSettingConfigMapName
+			} else if config, ok := e.Object.(*cephv1.CephOperatorConfig); ok {
+				return config.Name == cephv1.OperatorConfigName
 			} else if s, ok := e.Object.(*v1.Secret); ok {
 				if s.Name == admissionControllerAppName {
 					err := client.Get(context.TODO(), types.NamespacedName{Name: admissionControllerAppName, Namespace: e.Object.GetNamespace()}, &v1.Service{})
@@ -65,13 +71,15 @@ func predicateController(client client.Client) predicate.Funcs {
 			if old, ok := e.ObjectOld.(*v1.ConfigMap); ok {
 				if new, ok := e.ObjectNew.(*v1.ConfigMap); ok {
 					if old.Name == controller.OperatorSettingConfigMapName && new.Name == controller.OperatorSettingConfigMapName {
-						if old.Data["ROOK_CURRENT_NAMESPACE_ONLY"] != new.Data["ROOK_CURRENT_NAMESPACE_ONLY"] {
-							logger.Debug("ROOK_CURRENT_NAMESPACE_ONLY config updated, reloading the manager")
-							controller.ReloadManager()
-
-							// No need to ask for reconciliation since the context is going to be terminated when
-							// the signal is caught and the reconcile will run when the controller starts.
-							return false
+						for _, setting := range managerSettings {
+							if old.Data[setting] != new.Data[setting] {
+								logger.Debugf("%s config updated, reloading the manager", setting)
+								controller.ReloadManager()
+
+								// No need to ask for reconciliation since the context is going to be terminated when
+								// the signal is caught and the reconcile will run when the controller starts.
+								return false
+							}
 						}
 
 						// We still want to reconcile the operator manager if the configmap is updated
@@ -93,6 +101,22 @@ func predicateController(client client.Client) predicate.Funcs {
 				}
 			}
 
+			if old, ok := e.ObjectOld.(*cephv1.CephOperatorConfig); ok {
+				if new, ok := e.ObjectNew.(*cephv1.CephOperatorConfig); ok && new.Name == cephv1.OperatorConfigName {
+					oldSettings, newSettings := old.Spec.OperatorSettings(), new.Spec.OperatorSettings()
+					for _, setting := range managerSettings {
+						if oldSettings[setting] != newSettings[setting] {
+							logger.Debugf("%s setting of the CephOperatorConfig updated, reloading the manager", setting)
+							controller.ReloadManager()
+							return false
+						}
+					}
+
+					// The status updates are not reconciled
+					return old.GetGeneration() != new.GetGeneration()
+				}
+			}
+
 			return false
 		},
 
@@ -103,6 +127,19 @@ func predicateController(client client.Client) predicate.Funcs {
 					return false
 				}
 			}
+			if config, ok := e.Object.(*cephv1.CephOperatorConfig); ok && config.Name == cephv1.OperatorConfigName {
+				settings := config.Spec.OperatorSettings()
+				for _, setting := range managerSettings {
+					if _, ok := settings[setting]; ok {
+						logger.Debugf("CephOperatorConfig with the %s setting deleted, reloading the manager", setting)
+						controller.ReloadManager()
+						return false
+					}
+				}
+
+				// The settings of the CephOperatorConfig are not applied anymore
+				return true
+			}
 			if s, ok := e.Object.(*v1.Secret); ok {
 				if s.Name == admissionControllerAppName {
 					logger.Debug("webhook secret deleted, reloading the manager")
@@ -20,6 +20,7 @@ package reporting
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/pkg/errors"
@@ -40,11 +41,35 @@ import (
 // error returned by the reconcile.
 // The function is designed to return the appropriate values needed for the controller-runtime
 // framework's Reconcile() method.
-func ReportReconcileResult(logger *capnslog.PackageLogger, recorder record.EventRecorder,
+func ReportReconcileResult(logger *capnslog.PackageLogger, recorder record.EventRecorder, reconcileRequest reconcile.Request,
 	obj client.Object, reconcileResponse reconcile.Result, err error,
 ) (reconcile.Result, error) {
+	RecordReconcileResult(logger, recorder, reconcileRequest, obj, reconcileResponse, err)
+
+	if err != nil && !reconcileResponse.IsZero() {
+		// The framework will requeue immediately if there is an error. If we get an error with
+		// a non-empty reconcile response, just return the response with the error now logged as
+		// an event so that the framework can pause before the next reconcile per the response's
+		// intent.
+		return reconcileResponse, nil
+	}
+
+	return reconcileResponse, err
+}
+
+// RecordReconcileResult reports the result of an object's reconcile to the given logger and as an
+// event on the object like ReportReconcileResult, for the controllers returning the reconcile
+// response and error to the framework as is. A response requeuing the object without an error is
+// reported as waiting to be retried rather than as a success, and the success of the reconcile of an
+// object being deleted as its deletion. No success event is recorded when the object could not be
+// read, such as after it was deleted.
+func RecordReconcileResult(logger *capnslog.PackageLogger, recorder record.EventRecorder, reconcileRequest reconcile.Request,
+	obj client.Object, reconcileResponse reconcile.Result, err error,
+) {
 	kind := obj.GetObjectKind().GroupVersionKind().Kind
-	nsName := fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+	nsName := reconcileRequest.NamespacedName.String()
+	// the objects read from the api server always have a resource version
+	objectFound := obj.GetResourceVersion() != ""
 
 	if err != nil {
 		// 1. log
@@ -52,25 +77,61 @@ func ReportReconcileResult(logger *capnslog.PackageLogger, recorder record.Event
 
 		// 2. event
 		recorder.Event(obj, corev1.EventTypeWarning, string(cephv1.ReconcileFailed), err.Error())
+	} else if reconcileResponse.Requeue {
+		requeueMsg := fmt.Sprintf("%s %q is waiting to be configured, the reconcile will be retried", kind, nsName)
 
-		if !reconcileResponse.IsZero() {
-			// The framework will requeue immediately if there is an error. If we get an error with
-			// a non-empty reconcile response, just return the response with the error now logged as
-			// an event so that the framework can pause before the next reconcile per the response's
-			// intent.
-			return reconcileResponse, nil
+		// 1. log
+		logger.Debug(requeueMsg)
+
+		// 2. event
+		if objectFound {
+			recorder.Event(obj, corev1.EventTypeNormal, string(cephv1.ReconcileRequeuing), requeueMsg)
 		}
 	} else {
 		successMsg := fmt.Sprintf("successfully configured %s %q", kind, nsName)
+		if !obj.GetDeletionTimestamp().IsZero() {
+			successMsg = fmt.Sprintf("successfully deleted %s %q", kind, nsName)
+		}
 
 		// 1. log
 		logger.Debug(successMsg)
 
 		// 2. event
-		recorder.Event(obj, corev1.EventTypeNormal, string(cephv1.ReconcileSucceeded), successMsg)
+		if objectFound {
+			recorder.Event(obj, corev1.EventTypeNormal, string(cephv1.ReconcileSucceeded), successMsg)
+		}
 	}
+}
 
-	return reconcileResponse, err
+// the max number of commands listed in the event of a dry run, all of them are logged
+const maxDryRunEventCommands = 20
+
+// ReportDryRun reports the commands a reconcile of an object in dry run would have run in 2 ways:
+// 1. to the given logger
+// 2. as an event on the object (via the given event recorder)
+func ReportDryRun(logger *capnslog.PackageLogger, recorder record.EventRecorder, obj client.Object, commands []string) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	nsName := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}.String()
+	if len(commands) == 0 {
+		msg := fmt.Sprintf("dry run of %s %q, the reconcile would not change the cluster", kind, nsName)
+		logger.Info(msg)
+		recorder.Event(obj, corev1.EventTypeNormal, string(cephv1.ReconcileDryRun), msg)
+		return
+	}
+
+	// 1. log
+	logger.Infof("dry run of %s %q, the reconcile would run:\n%s", kind, nsName, strings.Join(commands, "\n"))
+
+	// 2. event
+	listed := commands
+	if len(listed) > maxDryRunEventCommands {
+		listed = listed[:maxDryRunEventCommands]
+	}
+	msg := fmt.Sprintf("dry run of %s %q, the reconcile would run: %s", kind, nsName, strings.Join(listed, "; "))
+	if len(commands) > len(listed) {
+		msg += fmt.Sprintf("; and %d more commands listed in the operator log", len(commands)-len(listed))
+	}
+	recorder.Event(obj, corev1.EventTypeNormal, string(cephv1.ReconcileDryRun), msg)
 }
 
 // ReportDeletionBlockedDueToDependents reports that deletion of a Rook-Ceph object is blocked due
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reporting
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRecordReconcileResult(t *testing.T) {
+	logger := capnslog.NewPackageLogger("github.com/rook/rook", "reporting-test")
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "rook-ceph", Name: "replicapool"}}
+	pool := &cephv1.CephBlockPool{
+		TypeMeta:   metav1.TypeMeta{Kind: "CephBlockPool"},
+		ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph", ResourceVersion: "1"},
+	}
+
+	lastEvent := func(recorder *record.FakeRecorder) string {
+		select {
+		case event := <-recorder.Events:
+			return event
+		default:
+			return ""
+		}
+	}
+
+	t.Run("failed reconcile", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(1)
+		RecordReconcileResult(logger, recorder, request, pool, reconcile.Result{}, errors.New("failed to create pool"))
+		assert.Equal(t, "Warning ReconcileFailed failed to create pool", lastEvent(recorder))
+	})
+
+	t.Run("waiting reconcile", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(1)
+		RecordReconcileResult(logger, recorder, request, pool, reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}, nil)
+		assert.Equal(t, `Normal ReconcileRequeuing CephBlockPool "rook-ceph/replicapool" is waiting to be configured, the reconcile will be retried`, lastEvent(recorder))
+	})
+
+	t.Run("successful reconcile", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(1)
+		RecordReconcileResult(logger, recorder, request, pool, reconcile.Result{RequeueAfter: time.Minute}, nil)
+		assert.Equal(t, `Normal ReconcileSucceeded successfully configured CephBlockPool "rook-ceph/replicapool"`, lastEvent(recorder))
+	})
+
+	t.Run("deleting object", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(1)
+		deleting := pool.DeepCopy()
+		deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		RecordReconcileResult(logger, recorder, request, deleting, reconcile.Result{}, nil)
+		assert.Equal(t, `Normal ReconcileSucceeded successfully deleted CephBlockPool "rook-ceph/replicapool"`, lastEvent(recorder))
+	})
+
+	t.Run("deleted object", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(1)
+		RecordReconcileResult(logger, recorder, request, &cephv1.CephBlockPool{}, reconcile.Result{}, nil)
+		assert.Equal(t, "", lastEvent(recorder))
+	})
+
+	t.Run("error with a requeue", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(1)
+		result, err := ReportReconcileResult(logger, recorder, request, pool, reconcile.Result{RequeueAfter: time.Minute}, errors.New("failed"))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, result.RequeueAfter)
+		assert.Equal(t, "Warning ReconcileFailed failed", lastEvent(recorder))
+	})
+}
+
+func TestReportDryRun(t *testing.T) {
+	logger := capnslog.NewPackageLogger("github.com/rook/rook", "reporting-test")
+	pool := &cephv1.CephBlockPool{
+		TypeMeta:   metav1.TypeMeta{Kind: "CephBlockPool"},
+		ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"},
+	}
+
+	recorder := record.NewFakeRecorder(1)
+	ReportDryRun(logger, recorder, pool, []string{"ceph osd pool set replicapool size 3", "rbd pool init replicapool"})
+	assert.Equal(t, `Normal ReconcileDryRun dry run of CephBlockPool "rook-ceph/replicapool", the reconcile would run: ceph osd pool set replicapool size 3; rbd pool init replicapool`, <-recorder.Events)
+
+	ReportDryRun(logger, recorder, pool, nil)
+	assert.Equal(t, `Normal ReconcileDryRun dry run of CephBlockPool "rook-ceph/replicapool", the reconcile would not change the cluster`, <-recorder.Events)
+
+	commands := make([]string, maxDryRunEventCommands+2)
+	for i := range commands {
+		commands[i] = "ceph osd pool set replicapool size 3"
+	}
+	ReportDryRun(logger, recorder, pool, commands)
+	assert.True(t, strings.HasSuffix(<-recorder.Events, "; and 2 more commands listed in the operator log"))
+}
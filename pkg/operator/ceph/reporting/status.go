@@ -24,11 +24,13 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // UpdateStatus updates an object with a given status. The object is updated with the latest version
-// from the server on a successful update.
+// from the server on a successful update. A conflict is returned when the object was modified since
+// it was read, PatchStatus is used instead to apply the changes to the latest version of the object.
 func UpdateStatus(client client.Client, obj client.Object) error {
 	nsName := types.NamespacedName{
 		Namespace: obj.GetNamespace(),
@@ -48,6 +50,37 @@ func UpdateStatus(client client.Client, obj client.Object) error {
 	return nil
 }
 
+// PatchStatus patches the status of an object with the changes made to it by update. The object
+// must have been read before, update changes its status and returns false if there is nothing to
+// update. Only the fields changed by update are sent, so the fields updated concurrently by
+// another goroutine, such as a health checker, are not overwritten. If the object was modified
+// since it was read, the latest version is read again and update is called again on it. The object
+// is updated with the latest version from the server on a successful patch.
+func PatchStatus(c client.Client, obj client.Object, update func() bool) error {
+	nsName := client.ObjectKeyFromObject(obj)
+	// the object is read from the cache, which can take a moment to have the latest version
+	first := true
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if !first {
+			if err := c.Get(context.Background(), nsName, obj); err != nil {
+				return errors.Wrapf(err, "failed to get the latest version of object %q", nsName.String())
+			}
+		}
+		first = false
+
+		base := obj.DeepCopyObject().(client.Object)
+		if !update() {
+			return nil
+		}
+		return c.Status().Patch(context.Background(), obj, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to patch object %q status", nsName.String())
+	}
+
+	return nil
+}
+
 // UpdateStatusCondition updates (or adds to) the status condition to the given object. The object
 // is updated with the latest version from the server on a successful update.
 func UpdateStatusCondition(
@@ -56,8 +89,11 @@ func UpdateStatusCondition(
 	kind := obj.GetObjectKind().GroupVersionKind().Kind
 	nsName := fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
 
-	cephv1.SetStatusCondition(obj.GetStatusConditions(), newCond)
-	if err := UpdateStatus(client, obj); err != nil {
+	err := PatchStatus(client, obj, func() bool {
+		cephv1.SetStatusCondition(obj.GetStatusConditions(), newCond)
+		return true
+	})
+	if err != nil {
 		return errors.Wrapf(err, "failed to update %s %q status condition %s=%s", kind, nsName, newCond.Type, newCond.Status)
 	}
 
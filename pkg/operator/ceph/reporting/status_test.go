@@ -21,10 +21,12 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -175,3 +177,86 @@ func TestUpdateStatusCondition(t *testing.T) {
 		assert.Equal(t, "update", cond.Message)
 	})
 }
+
+func TestPatchStatus(t *testing.T) {
+	fakeObject := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "rook-ceph",
+		},
+		Status: &cephv1.CephBlockPoolStatus{
+			Phase: cephv1.ConditionProgressing,
+		},
+	}
+	nsName := types.NamespacedName{
+		Namespace: fakeObject.Namespace,
+		Name:      fakeObject.Name,
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, fakeObject)
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(fakeObject.DeepCopy()).Build()
+
+	t.Run("concurrent update", func(t *testing.T) {
+		// the health checker reads the pool before the reconcile updates it
+		checkerObj := &cephv1.CephBlockPool{}
+		assert.NoError(t, cl.Get(context.TODO(), nsName, checkerObj))
+
+		reconcileObj := &cephv1.CephBlockPool{}
+		assert.NoError(t, cl.Get(context.TODO(), nsName, reconcileObj))
+		err := PatchStatus(cl, reconcileObj, func() bool {
+			reconcileObj.Status.Phase = cephv1.ConditionReady
+			return true
+		})
+		assert.NoError(t, err)
+
+		// the patch of the stale object is retried without overwriting the phase
+		calls := 0
+		err = PatchStatus(cl, checkerObj, func() bool {
+			calls++
+			checkerObj.Status.Info = map[string]string{"checked": "true"}
+			return true
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+
+		updated := &cephv1.CephBlockPool{}
+		assert.NoError(t, cl.Get(context.TODO(), nsName, updated))
+		assert.Equal(t, cephv1.ConditionReady, updated.Status.Phase)
+		assert.Equal(t, "true", updated.Status.Info["checked"])
+	})
+
+	t.Run("nothing to update", func(t *testing.T) {
+		obj := &cephv1.CephBlockPool{}
+		assert.NoError(t, cl.Get(context.TODO(), nsName, obj))
+		resourceVersion := obj.ResourceVersion
+		err := PatchStatus(cl, obj, func() bool { return false })
+		assert.NoError(t, err)
+		assert.NoError(t, cl.Get(context.TODO(), nsName, obj))
+		assert.Equal(t, resourceVersion, obj.ResourceVersion)
+	})
+
+	t.Run("update of a stale object", func(t *testing.T) {
+		staleObj := &cephv1.CephBlockPool{}
+		assert.NoError(t, cl.Get(context.TODO(), nsName, staleObj))
+		obj := staleObj.DeepCopy()
+		obj.Status.Phase = cephv1.ConditionFailure
+		assert.NoError(t, UpdateStatus(cl, obj))
+
+		// the stale status is not written over the latest status
+		staleObj.Status.Phase = cephv1.ConditionDeleting
+		err := UpdateStatus(cl, staleObj)
+		assert.True(t, kerrors.IsConflict(errors.Cause(err)))
+		assert.NoError(t, cl.Get(context.TODO(), nsName, obj))
+		assert.Equal(t, cephv1.ConditionFailure, obj.Status.Phase)
+
+		// the change is applied to the latest version with a patch
+		err = PatchStatus(cl, staleObj, func() bool {
+			staleObj.Status.Phase = cephv1.ConditionDeleting
+			return true
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, cl.Get(context.TODO(), nsName, obj))
+		assert.Equal(t, cephv1.ConditionDeleting, obj.Status.Phase)
+	})
+}
@@ -20,8 +20,11 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -40,9 +43,62 @@ func DeleteConfigMap(ctx context.Context, clientset kubernetes.Interface, cmName
 	return DeleteResource(delete, verify, resource, opts, defaultWaitOptions)
 }
 
-// GetOperatorSetting gets the operator setting from ConfigMap or Env Var
+// CreateOrUpdateConfigMap creates a ConfigMap or updates the ConfigMap declaratively if it already exists
+func CreateOrUpdateConfigMap(ctx context.Context, clientset kubernetes.Interface, configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+	name := configMap.Name
+	created, err := clientset.CoreV1().ConfigMaps(configMap.Namespace).Create(ctx, configMap, metav1.CreateOptions{})
+	if err == nil {
+		logger.Debugf("created configmap %s", name)
+		return created, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return nil, errors.Wrapf(err, "failed to create configmap %q", name)
+	}
+
+	existing, err := clientset.CoreV1().ConfigMaps(configMap.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get configmap %q", name)
+	}
+	configMap.ResourceVersion = existing.ResourceVersion
+	updated, err := clientset.CoreV1().ConfigMaps(configMap.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update configmap %q", name)
+	}
+	logger.Debugf("updated configmap %s", name)
+	return updated, nil
+}
+
+var (
+	// the operator settings of the CephOperatorConfig, they take precedence over the ConfigMap and
+	// the env vars
+	operatorSettingOverrides     = map[string]string{}
+	operatorSettingOverridesLock sync.RWMutex
+)
+
+// SetOperatorSettingOverrides sets the operator settings of the CephOperatorConfig
+func SetOperatorSettingOverrides(settings map[string]string) {
+	operatorSettingOverridesLock.Lock()
+	defer operatorSettingOverridesLock.Unlock()
+	operatorSettingOverrides = map[string]string{}
+	for name, value := range settings {
+		operatorSettingOverrides[name] = value
+	}
+}
+
+func operatorSettingOverride(settingName string) (string, bool) {
+	operatorSettingOverridesLock.RLock()
+	defer operatorSettingOverridesLock.RUnlock()
+	value, ok := operatorSettingOverrides[settingName]
+	return value, ok
+}
+
+// GetOperatorSetting gets the operator setting from the CephOperatorConfig, ConfigMap or Env Var
 // returns defaultValue if setting is not found
 func GetOperatorSetting(context context.Context, clientset kubernetes.Interface, configMapName, settingName, defaultValue string) (string, error) {
+	if settingValue, ok := operatorSettingOverride(settingName); ok {
+		logger.Infof("%s=%q (CephOperatorConfig)", settingName, settingValue)
+		return settingValue, nil
+	}
 	// config must be in operator pod namespace
 	namespace := os.Getenv(PodNamespaceEnvVar)
 	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(context, configMapName, metav1.GetOptions{})
@@ -62,6 +118,10 @@ func GetOperatorSetting(context context.Context, clientset kubernetes.Interface,
 }
 
 func GetValue(data map[string]string, settingName, defaultValue string) string {
+	if settingValue, ok := operatorSettingOverride(settingName); ok {
+		logger.Infof("%s=%q (CephOperatorConfig)", settingName, settingValue)
+		return settingValue
+	}
 	if settingValue, ok := data[settingName]; ok {
 		logger.Infof("%s=%q (configmap)", settingName, settingValue)
 		return settingValue
@@ -120,4 +120,12 @@ func TestGetOperatorSetting(t *testing.T) {
 	setting, err = GetOperatorSetting(ctx, k8s, operatorSettingConfigMapName, podAffinity, defaultValue)
 	assert.NoError(t, err)
 	assert.Equal(t, envSettingValue, setting)
+
+	// Setting exists in the CephOperatorConfig
+	SetOperatorSettingOverrides(map[string]string{nodeAffinity: "cr"})
+	defer SetOperatorSettingOverrides(nil)
+	setting, err = GetOperatorSetting(ctx, k8s, operatorSettingConfigMapName, nodeAffinity, defaultValue)
+	assert.NoError(t, err)
+	assert.Equal(t, "cr", setting)
+	assert.Equal(t, "cr", GetValue(cm.Data, nodeAffinity, defaultValue))
 }
@@ -30,6 +30,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -38,6 +39,9 @@ var (
 	waitForDeploymentTimeout = 60 * time.Second
 )
 
+// the annotation set by "kubectl rollout restart"
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
 // GetDeploymentImage returns the version of the image running in the pod spec for the desired container
 func GetDeploymentImage(ctx context.Context, clientset kubernetes.Interface, namespace, name, container string) (string, error) {
 	d, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
@@ -392,6 +396,18 @@ func DeleteDeployment(ctx context.Context, clientset kubernetes.Interface, names
 	return deleteResourceAndWait(namespace, name, "deployment", deleteAction, getAction)
 }
 
+// RestartDeployment restarts the pods of a deployment with a rolling update, by setting the restart
+// time in the annotations of the pod template like "kubectl rollout restart"
+func RestartDeployment(ctx context.Context, clientset kubernetes.Interface, namespace, name string) error {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`, restartedAtAnnotation, time.Now().Format(time.RFC3339))
+	_, err := clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to restart deployment %q", name)
+	}
+	logger.Infof("restarted deployment %q", name)
+	return nil
+}
+
 // GetDeploymentOwnerReference returns an OwnerReference to the deployment that is running the given pod name
 func GetDeploymentOwnerReference(ctx context.Context, clientset kubernetes.Interface, podName, namespace string) (*metav1.OwnerReference, error) {
 	var deploymentRef *metav1.OwnerReference
@@ -470,6 +470,19 @@ func Test_maxInt32Ptr(t *testing.T) {
 	}
 }
 
+func TestRestartDeployment(t *testing.T) {
+	ctx := context.TODO()
+	clientset := fake.NewSimpleClientset()
+	createDeploymentOrDie(clientset, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mgr-a", Namespace: "rook-ceph"}})
+
+	assert.NoError(t, RestartDeployment(ctx, clientset, "rook-ceph", "rook-ceph-mgr-a"))
+	d, err := clientset.AppsV1().Deployments("rook-ceph").Get(ctx, "rook-ceph-mgr-a", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, d.Spec.Template.Annotations[restartedAtAnnotation])
+
+	assert.Error(t, RestartDeployment(ctx, clientset, "rook-ceph", "rook-ceph-mgr-b"))
+}
+
 func newInt32(i int32) *int32 {
 	return &i
 }
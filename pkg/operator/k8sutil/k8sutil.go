@@ -58,6 +58,10 @@ const (
 	// RookVersionLabelKey is the key used for reporting the Rook version which last created or
 	// modified a resource.
 	RookVersionLabelKey = "rook-version"
+
+	// ExternalUserLabelKey is the label of the secrets of the users the operator created in an
+	// external cluster, which are removed with their user when their feature is disabled
+	ExternalUserLabelKey = "rook.io/external-user"
 )
 
 // GetK8SVersion gets the version of the running K8S cluster
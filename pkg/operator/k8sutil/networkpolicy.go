@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateOrUpdateNetworkPolicy creates a network policy or updates the network policy declaratively
+// if it already exists.
+func CreateOrUpdateNetworkPolicy(ctx context.Context, clientset kubernetes.Interface, policy *networkingv1.NetworkPolicy) error {
+	name := policy.Name
+	logger.Debugf("creating network policy %s", name)
+
+	_, err := clientset.NetworkingV1().NetworkPolicies(policy.Namespace).Create(ctx, policy, metav1.CreateOptions{})
+	if err == nil {
+		logger.Debugf("created network policy %s", name)
+		return nil
+	}
+	if !kerrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create network policy %q", name)
+	}
+
+	existing, err := clientset.NetworkingV1().NetworkPolicies(policy.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get network policy %q", name)
+	}
+	policy.ResourceVersion = existing.ResourceVersion
+	_, err = clientset.NetworkingV1().NetworkPolicies(policy.Namespace).Update(ctx, policy, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update network policy %q", name)
+	}
+	logger.Debugf("updated network policy %s", name)
+	return nil
+}
+
+// DeleteNetworkPolicy deletes a network policy, it is not an error if the policy does not exist
+func DeleteNetworkPolicy(ctx context.Context, clientset kubernetes.Interface, namespace, name string) error {
+	err := clientset.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete network policy %q", name)
+	}
+	return nil
+}
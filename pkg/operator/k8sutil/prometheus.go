@@ -87,6 +87,20 @@ func CreateOrUpdateServiceMonitor(ctx context.Context, serviceMonitorDefinition
 	return sm, nil
 }
 
+// DeleteServiceMonitor deletes the serviceMonitor object if it exists
+func DeleteServiceMonitor(ctx context.Context, namespace, name string) error {
+	logger.Debugf("deleting servicemonitor %s", name)
+	client, err := getMonitoringClient()
+	if err != nil {
+		return fmt.Errorf("failed to get monitoring client. %v", err)
+	}
+	err = client.MonitoringV1().ServiceMonitors(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete servicemonitor. %v", err)
+	}
+	return nil
+}
+
 // GetPrometheusRule returns provided prometheus rules or an error
 func GetPrometheusRule(ruleFilePath string) (*monitoringv1.PrometheusRule, error) {
 	ruleFile, err := ioutil.ReadFile(filepath.Clean(ruleFilePath))
@@ -0,0 +1,262 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const redacted = "<redacted>"
+
+// the flags whose values are secrets, redacted from the audit log
+var auditSecretFlags = map[string]bool{
+	"key":        true,
+	"secret":     true,
+	"secret-key": true,
+	"password":   true,
+	"token":      true,
+}
+
+// the commands whose last arg is a secret, e.g. "config-key set <key> <secret>"
+var auditSecretCommands = [][]string{
+	{"config-key", "set"},
+	{"peer_bootstrap", "import"},
+}
+
+// AuditInitiator is the custom resource whose reconcile executed a command
+type AuditInitiator struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// AuditEntry is the record of a command in the audit log
+type AuditEntry struct {
+	Time       time.Time       `json:"time"`
+	Command    string          `json:"command"`
+	Args       []string        `json:"args"`
+	Initiator  *AuditInitiator `json:"initiator,omitempty"`
+	Result     string          `json:"result"`
+	ExitCode   int             `json:"exitCode"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"durationMs"`
+}
+
+type auditInitiatorKey struct{}
+
+var (
+	auditMutex sync.Mutex
+	auditLog   io.Writer
+)
+
+// SetAuditLog sets the stream the audit log of the commands is written to. The audit log is
+// disabled if nil.
+func SetAuditLog(w io.Writer) {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	if closer, ok := auditLog.(io.Closer); ok && auditLog != w && auditLog != os.Stdout {
+		if err := closer.Close(); err != nil {
+			logger.Warningf("failed to close the audit log. %v", err)
+		}
+	}
+	auditLog = w
+}
+
+// WithAuditInitiator returns a context whose commands are attributed to the given custom resource
+// in the audit log
+func WithAuditInitiator(ctx context.Context, kind, namespace, name string) context.Context {
+	if ctx == nil {
+		return nil
+	}
+	return context.WithValue(ctx, auditInitiatorKey{}, &AuditInitiator{Kind: kind, Namespace: namespace, Name: name})
+}
+
+// AuditCommand records a command that started at the given time in the audit log, with the secrets
+// of its args redacted
+func AuditCommand(ctx context.Context, command string, args []string, start time.Time, err error) {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	if auditLog == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Time:       start.UTC(),
+		Command:    command,
+		Args:       redactArgs(args),
+		Result:     "success",
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if ctx != nil {
+		entry.Initiator, _ = ctx.Value(auditInitiatorKey{}).(*AuditInitiator)
+	}
+	if err != nil {
+		entry.Result = "failure"
+		entry.ExitCode = commandExitCode(err)
+		entry.Error = err.Error()
+	}
+
+	line, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		logger.Warningf("failed to marshal the audit entry of command %q. %v", command, jsonErr)
+		return
+	}
+	if _, writeErr := fmt.Fprintln(auditLog, string(line)); writeErr != nil {
+		logger.Warningf("failed to write the audit entry of command %q. %v", command, writeErr)
+	}
+}
+
+// commandExitCode returns the exit code of a failed command, -1 if it did not exit
+func commandExitCode(err error) int {
+	if code, codeErr := ExtractExitCode(errors.Cause(err)); codeErr == nil {
+		return code
+	}
+	return -1
+}
+
+// RotatingFile is an audit log file rotated when it reaches its max size. The rotated files are
+// suffixed with their index, the oldest being removed beyond the max number of backups.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFile opens the audit log file, appending to it if it exists
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the directory of audit log %q", path)
+	}
+	r := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	// #nosec G304 the path is set by the admin of the operator
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open audit log %q", r.path)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to stat audit log %q", r.path)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write writes to the audit log file, rotating it first if the write would exceed its max size
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the audit log file
+func (r *RotatingFile) Close() error {
+	return r.file.Close()
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close audit log %q", r.path)
+	}
+	// shift the backups, the oldest one being overwritten
+	for i := r.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to rotate audit log %q", r.path)
+		}
+	}
+	if r.maxBackups > 0 {
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return errors.Wrapf(err, "failed to rotate audit log %q", r.path)
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return errors.Wrapf(err, "failed to remove audit log %q", r.path)
+	}
+	return r.open()
+}
+
+// redactArgs returns a copy of the args with the values of the secret flags and the secret args of
+// the commands replaced
+func redactArgs(args []string) []string {
+	result := make([]string, len(args))
+	copy(result, args)
+
+	for i := 0; i < len(result); i++ {
+		if !strings.HasPrefix(result[i], "-") {
+			continue
+		}
+		flag := strings.SplitN(result[i], "=", 2)
+		if len(flag) == 2 {
+			if auditSecretFlags[strings.TrimLeft(flag[0], "-")] {
+				result[i] = flag[0] + "=" + redacted
+			}
+			continue
+		}
+		if auditSecretFlags[strings.TrimLeft(flag[0], "-")] && i+1 < len(result) {
+			result[i+1] = redacted
+			i++
+		}
+	}
+
+	for _, secretCommand := range auditSecretCommands {
+		if len(result) > len(secretCommand) && isSubcommand(result, secretCommand) {
+			result[len(result)-1] = redacted
+		}
+	}
+	return result
+}
+
+// isSubcommand returns whether the args contain the words of the subcommand in a row
+func isSubcommand(args, subcommand []string) bool {
+	for i := 0; i+len(subcommand) <= len(args); i++ {
+		match := true
+		for j, word := range subcommand {
+			if args[i+j] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
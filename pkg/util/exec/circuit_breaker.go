@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// CephCommandsRetries is the number of times a command is retried when it fails to reach the
+	// mons, 0 to never retry
+	CephCommandsRetries = 3
+	// CephCommandsRetryDelay is the delay before the first retry, doubled for each retry
+	CephCommandsRetryDelay = time.Second
+	// CephCommandsCircuitBreakerThreshold is the number of consecutive commands failing to reach
+	// the mons of a cluster before the circuit breaker opens, 0 to disable it
+	CephCommandsCircuitBreakerThreshold = 5
+	// CephCommandsCircuitBreakerCooldown is how long the commands are short-circuited once the
+	// circuit breaker opens, before a command is tried again
+	CephCommandsCircuitBreakerCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned instead of running a command while the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker short-circuits the commands to a cluster whose mons are unreachable so they fail
+// right away instead of each waiting for its timeout. It opens after a number of consecutive
+// failures, then lets one command through once its cooldown expired: the circuit closes if this
+// command reaches the mons and opens again otherwise.
+type CircuitBreaker struct {
+	name     string
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time
+	// when the command trying the mons after the cooldown was let through, zero if none
+	probeAt time.Time
+	now     func() time.Time
+}
+
+// NewCircuitBreaker returns a closed circuit breaker, the name identifying it in the logs
+func NewCircuitBreaker(name string) *CircuitBreaker {
+	return &CircuitBreaker{name: name, now: time.Now}
+}
+
+// Allow returns ErrCircuitOpen if the command must not be run
+func (b *CircuitBreaker) Allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if CephCommandsCircuitBreakerThreshold == 0 || b.failures < CephCommandsCircuitBreakerThreshold {
+		return nil
+	}
+	now := b.now()
+	if now.Sub(b.openedAt) < CephCommandsCircuitBreakerCooldown {
+		return ErrCircuitOpen
+	}
+	// only one command tries the mons at a time, another one is let through if it never reported
+	if !b.probeAt.IsZero() && now.Sub(b.probeAt) < CephCommandsCircuitBreakerCooldown {
+		return ErrCircuitOpen
+	}
+	b.probeAt = now
+	return nil
+}
+
+// Success records a command that reached the mons, closing the circuit
+func (b *CircuitBreaker) Success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if CephCommandsCircuitBreakerThreshold != 0 && b.failures >= CephCommandsCircuitBreakerThreshold {
+		logger.Infof("the mons of %s are reachable again, closing the circuit breaker", b.name)
+	}
+	b.failures = 0
+	b.probeAt = time.Time{}
+}
+
+// Failure records a command that failed to reach the mons, opening the circuit after too many
+func (b *CircuitBreaker) Failure() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failures++
+	b.probeAt = time.Time{}
+	if CephCommandsCircuitBreakerThreshold == 0 || b.failures < CephCommandsCircuitBreakerThreshold {
+		return
+	}
+	if b.failures == CephCommandsCircuitBreakerThreshold {
+		logger.Warningf("%d consecutive commands failed to reach the mons of %s, short-circuiting the commands for %s", b.failures, b.name, CephCommandsCircuitBreakerCooldown)
+	}
+	b.openedAt = b.now()
+}
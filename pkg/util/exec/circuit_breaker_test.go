@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker("the cluster in namespace \"rook-ceph\"")
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < CephCommandsCircuitBreakerThreshold-1; i++ {
+		assert.NoError(t, b.Allow())
+		b.Failure()
+	}
+	assert.NoError(t, b.Allow())
+	b.Success()
+	for i := 0; i < CephCommandsCircuitBreakerThreshold; i++ {
+		assert.NoError(t, b.Allow())
+		b.Failure()
+	}
+	assert.Equal(t, ErrCircuitOpen, b.Allow())
+
+	// a single command tries the mons after the cooldown
+	now = now.Add(CephCommandsCircuitBreakerCooldown)
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, ErrCircuitOpen, b.Allow())
+	b.Failure()
+	assert.Equal(t, ErrCircuitOpen, b.Allow())
+
+	now = now.Add(CephCommandsCircuitBreakerCooldown)
+	assert.NoError(t, b.Allow())
+	b.Success()
+	assert.NoError(t, b.Allow())
+	assert.NoError(t, b.Allow())
+
+	t.Run("disabled", func(t *testing.T) {
+		CephCommandsCircuitBreakerThreshold = 0
+		defer func() { CephCommandsCircuitBreakerThreshold = 5 }()
+		for i := 0; i < 10; i++ {
+			b.Failure()
+		}
+		assert.NoError(t, b.Allow())
+	})
+}
+
+func TestExecuteCommandWithOutputContext(t *testing.T) {
+	executor := &CommandExecutor{}
+	output, err := executor.ExecuteCommandWithOutputContext(context.TODO(), "echo", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", output)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	_, err = executor.ExecuteCommandWithOutputContext(ctx, "sleep", "10")
+	assert.Error(t, err)
+	assert.True(t, IsTimeout(err))
+	assert.Equal(t, "timeout waiting for the command sleep to return", err.Error())
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type dryRunKey struct{}
+
+// DryRun records the commands that would change the cluster instead of executing them, so the
+// changes a reconcile would make can be reported before they are applied
+type DryRun struct {
+	mutex    sync.Mutex
+	commands []string
+}
+
+// WithDryRun returns a context whose commands changing the cluster are recorded by the returned
+// dry run instead of being executed
+func WithDryRun(ctx context.Context) (context.Context, *DryRun) {
+	dryRun := &DryRun{}
+	if ctx == nil {
+		return nil, dryRun
+	}
+	return context.WithValue(ctx, dryRunKey{}, dryRun), dryRun
+}
+
+// DryRunFromContext returns the dry run of the context, nil if its commands are executed
+func DryRunFromContext(ctx context.Context) *DryRun {
+	if ctx == nil {
+		return nil
+	}
+	dryRun, _ := ctx.Value(dryRunKey{}).(*DryRun)
+	return dryRun
+}
+
+// Record records a command that was not executed, with the secrets of its args redacted
+func (d *DryRun) Record(command string, args []string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.commands = append(d.commands, strings.Join(append([]string{command}, redactArgs(args)...), " "))
+}
+
+// Commands returns the commands recorded in the order they would have been executed
+func (d *DryRun) Commands() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string{}, d.commands...)
+}
@@ -21,6 +21,9 @@ import (
 	"fmt"
 	"os/exec"
 	"syscall"
+
+	"github.com/pkg/errors"
+	kexec "k8s.io/utils/exec"
 )
 
 // CephCLIError is Ceph CLI Error type
@@ -33,6 +36,21 @@ func (e *CephCLIError) Error() string {
 	return fmt.Sprintf("%v", e.output)
 }
 
+// TimeoutError is returned when a command is stopped for running past its timeout
+type TimeoutError struct {
+	command string
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timeout waiting for the command %s to return", e.command)
+}
+
+// IsTimeout returns whether the command was stopped for running past its timeout
+func IsTimeout(err error) bool {
+	_, ok := errors.Cause(err).(*TimeoutError)
+	return ok
+}
+
 // ExitStatus looks for the exec error code
 func ExitStatus(err error) (int, bool) {
 	switch e := err.(type) {
@@ -44,6 +62,9 @@ func ExitStatus(err error) (int, bool) {
 
 	case *CephCLIError:
 		return ExitStatus(e.err)
+
+	case *kexec.CodeExitError:
+		return e.ExitStatus(), true
 	}
 	return 0, false
 }
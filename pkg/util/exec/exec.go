@@ -19,6 +19,7 @@ package exec
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -36,6 +37,11 @@ import (
 
 var (
 	CephCommandsTimeout = 15 * time.Second
+	// CephCommandsMaxDuration is how long a command without its own timeout can run before it is stopped
+	CephCommandsMaxDuration = 5 * time.Minute
+	// CephCommandsCacheTTL is how long the output of the frequently repeated read-only commands is
+	// shared by the commands to the same cluster, 0 to disable the cache
+	CephCommandsCacheTTL time.Duration
 )
 
 // Executor is the main interface for all the exec commands
@@ -47,6 +53,12 @@ type Executor interface {
 	ExecuteCommandWithTimeout(timeout time.Duration, command string, arg ...string) (string, error)
 }
 
+// ContextExecutor is implemented by the executors able to stop a command when its context is done
+type ContextExecutor interface {
+	ExecuteCommandWithOutputContext(ctx context.Context, command string, arg ...string) (string, error)
+	ExecuteCommandWithCombinedOutputContext(ctx context.Context, command string, arg ...string) (string, error)
+}
+
 // CommandExecutor is the type of the Executor
 type CommandExecutor struct{}
 
@@ -101,7 +113,7 @@ func (*CommandExecutor) ExecuteCommandWithTimeout(timeout time.Duration, command
 					logger.Errorf("Failed to kill process %s: %v", command, err)
 					e = fmt.Errorf("timeout waiting for the command %s to return after interrupt signal was sent. Tried to kill the process but that failed: %v", command, err)
 				} else {
-					e = fmt.Errorf("timeout waiting for the command %s to return", command)
+					e = &TimeoutError{command: command}
 				}
 				return strings.TrimSpace(b.String()), e
 			}
@@ -117,7 +129,7 @@ func (*CommandExecutor) ExecuteCommandWithTimeout(timeout time.Duration, command
 				return strings.TrimSpace(b.String()), err
 			}
 			if interruptSent {
-				return strings.TrimSpace(b.String()), fmt.Errorf("timeout waiting for the command %s to return", command)
+				return strings.TrimSpace(b.String()), &TimeoutError{command: command}
 			}
 			return strings.TrimSpace(b.String()), nil
 		}
@@ -140,6 +152,34 @@ func (*CommandExecutor) ExecuteCommandWithCombinedOutput(command string, arg ...
 	return runCommandWithOutput(cmd, true)
 }
 
+// ExecuteCommandWithOutputContext executes a command with output, killing it when the context is done
+func (*CommandExecutor) ExecuteCommandWithOutputContext(ctx context.Context, command string, arg ...string) (string, error) {
+	logCommand(command, arg...)
+	// #nosec G204 Rook controls the input to the exec arguments
+	cmd := exec.CommandContext(ctx, command, arg...)
+	return runCommandWithOutputContext(ctx, cmd, false)
+}
+
+// ExecuteCommandWithCombinedOutputContext executes a command with combined output, killing it when
+// the context is done
+func (*CommandExecutor) ExecuteCommandWithCombinedOutputContext(ctx context.Context, command string, arg ...string) (string, error) {
+	logCommand(command, arg...)
+	// #nosec G204 Rook controls the input to the exec arguments
+	cmd := exec.CommandContext(ctx, command, arg...)
+	return runCommandWithOutputContext(ctx, cmd, true)
+}
+
+func runCommandWithOutputContext(ctx context.Context, cmd *exec.Cmd, combinedOutput bool) (string, error) {
+	out, err := runCommandWithOutput(cmd, combinedOutput)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return out, &TimeoutError{command: cmd.Args[0]}
+	}
+	if err != nil && ctx.Err() != nil {
+		return out, errors.Wrapf(ctx.Err(), "command %s was stopped", cmd.Args[0])
+	}
+	return out, err
+}
+
 func startCommand(env []string, command string, arg ...string) (*exec.Cmd, io.ReadCloser, io.ReadCloser, error) {
 	logCommand(command, arg...)
 
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rook/rook/pkg/util/tracing"
+)
+
+// the number of subcommand words of a command in the name of its span, the other args such as the
+// names of the pools are only in the attributes of the span
+const traceSubcommandWords = 2
+
+// TraceCommand records a command that started at the given time as a child span of the reconcile that
+// executed it, with its args redacted, its duration and its exit code. The parent is the span of the
+// context, or the span of the reconcile of the initiator of the context. The commands executed
+// outside of a traced reconcile, such as by the health checkers, are not recorded.
+func TraceCommand(ctx context.Context, command string, args []string, start time.Time, err error) {
+	if ctx == nil || !tracing.Enabled() {
+		return
+	}
+	parent := tracing.FromContext(ctx)
+	if parent == nil {
+		if initiator, ok := ctx.Value(auditInitiatorKey{}).(*AuditInitiator); ok {
+			parent = tracing.ResourceSpan(initiator.Kind, initiator.Namespace, initiator.Name)
+		}
+	}
+	if parent == nil {
+		return
+	}
+
+	name := []string{command}
+	for _, arg := range args {
+		if len(name) > traceSubcommandWords || strings.HasPrefix(arg, "-") {
+			break
+		}
+		name = append(name, arg)
+	}
+	span := tracing.StartAt(parent, strings.Join(name, " "), tracing.SpanKindClient, start)
+	span.SetAttribute("rook.command", command)
+	span.SetAttribute("rook.command.args", strings.Join(redactArgs(args), " "))
+	exitCode := 0
+	if err != nil {
+		exitCode = commandExitCode(err)
+	}
+	span.SetAttribute("rook.command.exit_code", exitCode)
+	span.SetError(err)
+	span.End()
+}
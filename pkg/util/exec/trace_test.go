@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/util/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kexec "k8s.io/utils/exec"
+)
+
+func TestTraceCommand(t *testing.T) {
+	type span struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Attributes   []struct {
+			Key   string `json:"key"`
+			Value struct {
+				StringValue string `json:"stringValue"`
+				IntValue    string `json:"intValue"`
+			} `json:"value"`
+		} `json:"attributes"`
+	}
+	var mutex sync.Mutex
+	spans := []span{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traces := struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&traces))
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, resourceSpans := range traces.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				spans = append(spans, scopeSpans.Spans...)
+			}
+		}
+	}))
+	defer server.Close()
+	tracing.SetExporter(tracing.NewExporter(server.URL, "rook-ceph-operator"))
+
+	ctx := WithAuditInitiator(context.TODO(), "CephObjectStoreUser", "rook-ceph", "my-user")
+	_, reconcile := tracing.StartResource(context.TODO(), "CephObjectStoreUser", "rook-ceph", "my-user", "reconcile CephObjectStoreUser")
+	TraceCommand(ctx, "radosgw-admin", []string{"user", "create", "--uid=my-user", "--secret-key=mysecret"}, time.Now(), errors.Wrap(kexec.CodeExitError{Err: errors.New("failed"), Code: 22}, "failed to create user"))
+	reconcile.End()
+	// the commands outside of a reconcile are not traced
+	TraceCommand(ctx, "ceph", []string{"status"}, time.Now(), nil)
+	tracing.SetExporter(nil)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, 2, len(spans))
+	command := spans[0]
+	assert.Equal(t, "radosgw-admin user create", command.Name)
+	assert.Equal(t, spans[1].TraceID, command.TraceID)
+	assert.Equal(t, spans[1].SpanID, command.ParentSpanID)
+	require.Equal(t, 3, len(command.Attributes))
+	assert.Equal(t, "user create --uid=my-user --secret-key=<redacted>", command.Attributes[1].Value.StringValue)
+	assert.Equal(t, "rook.command.exit_code", command.Attributes[2].Key)
+	assert.Equal(t, "22", command.Attributes[2].Value.IntValue)
+}
@@ -17,10 +17,52 @@ limitations under the License.
 package util
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
 	"github.com/coreos/pkg/capnslog"
+	"github.com/go-logr/zapr"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	DefaultLogLevel = capnslog.INFO
+
+	// LogFormatConsole is the log format of the lines readable by humans
+	LogFormatConsole = "console"
+	// LogFormatJSON is the log format of the lines as JSON objects, whose fields can be queried by
+	// the log collectors
+	LogFormatJSON = "json"
+	// DefaultLogFormat is the log format when none is set
+	DefaultLogFormat = LogFormatConsole
+
+	// the repo of the package loggers of rook
+	logRepo = "github.com/rook/rook"
+	// the suffix of the name of the package loggers of the controllers
+	controllerLoggerSuffix = "-controller"
 )
 
-const DefaultLogLevel = capnslog.INFO
+var (
+	logFormatMutex   sync.Mutex
+	currentLogFormat string
+	// the level of the logs of controller-runtime, following the global log level of rook
+	runtimeLogLevel = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+	// the fields attached to the lines of the package loggers per goroutine, and their number to skip
+	// the lookup of the goroutine when there are none
+	goroutineLogFields      sync.Map
+	goroutineLogFieldsCount int64
+)
 
 func SetGlobalLogLevel(userLogLevelSelection string, logger *capnslog.PackageLogger) {
 	// capnslog supports trace level logging, but in Rook we want to treat trace logging as insecure
@@ -51,4 +93,221 @@ func SetGlobalLogLevel(userLogLevelSelection string, logger *capnslog.PackageLog
 	}
 
 	capnslog.SetGlobalLogLevel(logLevel)
+	runtimeLogLevel.SetLevel(zapLevel(logLevel))
+}
+
+// ParseLogLevels parses the log levels of the package loggers in the format
+// "<logger>=<level>,<logger>=<level>", for example "ceph-block-pool-controller=DEBUG,op-mon=WARNING".
+// The loggers of the controllers are named after the controllers. TRACE is treated as DEBUG like for
+// the global log level.
+func ParseLogLevels(levels string) (map[string]capnslog.LogLevel, error) {
+	parsed := map[string]capnslog.LogLevel{}
+	for _, setting := range strings.Split(levels, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		nameAndLevel := strings.Split(setting, "=")
+		if len(nameAndLevel) != 2 || strings.TrimSpace(nameAndLevel[0]) == "" {
+			return nil, errors.Errorf("invalid log level %q, expected <logger>=<level>", setting)
+		}
+		selection := strings.ToUpper(strings.TrimSpace(nameAndLevel[1]))
+		if selection == "TRACE" {
+			selection = "DEBUG"
+		}
+		level, err := capnslog.ParseLevel(selection)
+		if err != nil || level > capnslog.DEBUG {
+			return nil, errors.Errorf("invalid log level %q of logger %q", nameAndLevel[1], nameAndLevel[0])
+		}
+		parsed[strings.TrimSpace(nameAndLevel[0])] = level
+	}
+	return parsed, nil
+}
+
+// FormatLogLevels formats the log levels of the package loggers in the format parsed by
+// ParseLogLevels, sorted by the name of the loggers
+func FormatLogLevels(levels map[string]string) string {
+	settings := []string{}
+	for name, level := range levels {
+		settings = append(settings, name+"="+level)
+	}
+	sort.Strings(settings)
+	return strings.Join(settings, ",")
+}
+
+// SetLogLevels sets the log levels of the given package loggers over the global log level, for
+// example to debug a single controller. It must be called after SetGlobalLogLevel, which resets the
+// level of all the loggers. The unknown loggers are reported and ignored.
+func SetLogLevels(userLogLevels string, logger *capnslog.PackageLogger) {
+	levels, err := ParseLogLevels(userLogLevels)
+	if err != nil {
+		logger.Errorf("failed to parse the log levels %q. keeping the global log level. %v", userLogLevels, err)
+		return
+	}
+	if len(levels) == 0 {
+		return
+	}
+	repo, err := capnslog.GetRepoLogger(logRepo)
+	if err != nil {
+		logger.Errorf("failed to set the log levels. %v", err)
+		return
+	}
+	for name := range levels {
+		if _, ok := repo[name]; !ok {
+			logger.Warningf("ignoring the log level of unknown logger %q", name)
+		}
+	}
+	repo.SetLogLevel(levels)
+}
+
+// SetLogFormat sets the format of the logs, "console" or "json". The lines of all the package loggers
+// are written by zap with the name of the logger, and the name of the controller for the loggers of
+// the controllers. The logs of controller-runtime are written by the same logger with the name and
+// namespace of the reconciled resource. An unknown format is reported and the default format used.
+func SetLogFormat(format string, logger *capnslog.PackageLogger) {
+	if format == "" {
+		format = DefaultLogFormat
+	}
+	if format != LogFormatConsole && format != LogFormatJSON {
+		logger.Errorf("unknown log format %q. defaulting to %q", format, DefaultLogFormat)
+		format = DefaultLogFormat
+	}
+
+	logFormatMutex.Lock()
+	defer logFormatMutex.Unlock()
+	if format == currentLogFormat {
+		return
+	}
+	// the capnslog levels are checked by capnslog before the lines are formatted, zap writes them all
+	zapLogger := zap.New(zapcore.NewCore(newLogEncoder(format), zapcore.Lock(os.Stderr), zapcore.DebugLevel))
+	capnslog.SetFormatter(newZapFormatter(zapLogger))
+	ctrllog.SetLogger(zapr.NewLogger(zapLogger.WithOptions(zap.IncreaseLevel(runtimeLogLevel))))
+	currentLogFormat = format
+}
+
+func newLogEncoder(format string) zapcore.Encoder {
+	config := zapcore.EncoderConfig{
+		TimeKey:        "ts",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	}
+	if format == LogFormatJSON {
+		return zapcore.NewJSONEncoder(config)
+	}
+	return zapcore.NewConsoleEncoder(config)
+}
+
+// zapFormatter is a capnslog formatter writing the lines of the package loggers with zap
+type zapFormatter struct {
+	logger  *zap.Logger
+	loggers sync.Map
+}
+
+func newZapFormatter(logger *zap.Logger) *zapFormatter {
+	return &zapFormatter{logger: logger}
+}
+
+// WithLogFields attaches the given key and value pairs to the lines of the package loggers written by
+// the current goroutine until the returned function is called, for example the resource reconciled by
+// a controller. capnslog has no context, so the goroutine is what ties a line to its reconcile. The
+// lines written by the goroutines started during the reconcile do not get the fields.
+func WithLogFields(keysAndValues ...string) func() {
+	fields := make([]zap.Field, 0, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields = append(fields, zap.String(keysAndValues[i], keysAndValues[i+1]))
+	}
+	id := goroutineID()
+	goroutineLogFields.Store(id, fields)
+	atomic.AddInt64(&goroutineLogFieldsCount, 1)
+	return func() {
+		goroutineLogFields.Delete(id)
+		atomic.AddInt64(&goroutineLogFieldsCount, -1)
+	}
+}
+
+// currentLogFields returns the fields attached to the lines of the current goroutine
+func currentLogFields() []zap.Field {
+	if atomic.LoadInt64(&goroutineLogFieldsCount) == 0 {
+		return nil
+	}
+	if fields, ok := goroutineLogFields.Load(goroutineID()); ok {
+		return fields.([]zap.Field)
+	}
+	return nil
+}
+
+// goroutineID returns the ID of the current goroutine from the header of its stack, "goroutine 12 [running]:"
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = bytes.TrimPrefix(buf[:runtime.Stack(buf, false)], []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// packageLogger returns the zap logger of a package logger, named after it
+func (f *zapFormatter) packageLogger(pkg string) *zap.Logger {
+	if logger, ok := f.loggers.Load(pkg); ok {
+		return logger.(*zap.Logger)
+	}
+	logger := f.logger.Named(pkg)
+	if strings.HasSuffix(pkg, controllerLoggerSuffix) {
+		logger = logger.With(zap.String("controller", pkg))
+	}
+	f.loggers.Store(pkg, logger)
+	return logger
+}
+
+// Format writes a line of a package logger at the zap level of its capnslog level, with the fields
+// attached to the goroutine writing it
+func (f *zapFormatter) Format(pkg string, level capnslog.LogLevel, depth int, entries ...interface{}) {
+	logger := f.packageLogger(pkg)
+	if fields := currentLogFields(); fields != nil {
+		// the fields of a reconcile name its controller
+		logger = f.logger.Named(pkg).With(fields...)
+	}
+	msg := strings.TrimSuffix(fmt.Sprint(entries...), "\n")
+	if entry := logger.Check(zapLevel(level), msg); entry != nil {
+		entry.Write()
+	}
+}
+
+// Flush flushes the lines buffered by zap
+func (f *zapFormatter) Flush() {
+	_ = f.logger.Sync()
+}
+
+// zapLevel returns the zap level of a capnslog level
+func zapLevel(level capnslog.LogLevel) zapcore.Level {
+	switch {
+	case level <= capnslog.ERROR:
+		return zapcore.ErrorLevel
+	case level == capnslog.WARNING:
+		return zapcore.WarnLevel
+	case level <= capnslog.INFO:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// LoggerLevelAt returns whether the package logger of the given name logs at the given level, for
+// example whether a controller logs its debug lines. The global log level applies to the unknown
+// loggers.
+func LoggerLevelAt(name string, level capnslog.LogLevel) bool {
+	if repo, err := capnslog.GetRepoLogger(logRepo); err == nil {
+		if logger, ok := repo[name]; ok {
+			return logger.LevelAt(level)
+		}
+	}
+	return runtimeLogLevel.Enabled(zapLevel(level))
 }
@@ -17,10 +17,16 @@ limitations under the License.
 package util
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestSetGlobalLogLevel(t *testing.T) {
@@ -53,3 +59,123 @@ func TestSetGlobalLogLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLogLevels(t *testing.T) {
+	levels, err := ParseLogLevels("ceph-block-pool-controller=DEBUG, op-mon=warning,op-osd=TRACE")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]capnslog.LogLevel{
+		"ceph-block-pool-controller": capnslog.DEBUG,
+		"op-mon":                     capnslog.WARNING,
+		"op-osd":                     capnslog.DEBUG,
+	}, levels)
+
+	levels, err = ParseLogLevels("")
+	assert.NoError(t, err)
+	assert.Empty(t, levels)
+
+	_, err = ParseLogLevels("op-mon")
+	assert.Error(t, err)
+	_, err = ParseLogLevels("=DEBUG")
+	assert.Error(t, err)
+	_, err = ParseLogLevels("op-mon=LOUD")
+	assert.Error(t, err)
+	// the insecure trace level is only allowed globally
+	_, err = ParseLogLevels("op-mon=TRACE_INSECURE")
+	assert.Error(t, err)
+
+	assert.Equal(t, "a-controller=DEBUG,op-mon=ERROR", FormatLogLevels(map[string]string{"op-mon": "ERROR", "a-controller": "DEBUG"}))
+}
+
+func TestSetLogLevels(t *testing.T) {
+	logger := capnslog.NewPackageLogger("github.com/rook/rook", "pkg/util/logging_test")
+	controllerLogger := capnslog.NewPackageLogger("github.com/rook/rook", "logging-test-controller")
+
+	SetGlobalLogLevel("INFO", logger)
+	SetLogLevels("logging-test-controller=DEBUG,unknown-controller=DEBUG", logger)
+	assert.True(t, controllerLogger.LevelAt(capnslog.DEBUG))
+	assert.False(t, logger.LevelAt(capnslog.DEBUG))
+	assert.True(t, LoggerLevelAt("logging-test-controller", capnslog.DEBUG))
+	assert.False(t, LoggerLevelAt("pkg/util/logging_test", capnslog.DEBUG))
+	// the global log level applies to the unknown loggers
+	assert.False(t, LoggerLevelAt("unknown-controller", capnslog.DEBUG))
+
+	// an invalid setting keeps the levels
+	SetLogLevels("logging-test-controller=LOUD", logger)
+	assert.True(t, controllerLogger.LevelAt(capnslog.DEBUG))
+
+	// the global log level resets the levels of the loggers
+	SetGlobalLogLevel("INFO", logger)
+	assert.False(t, controllerLogger.LevelAt(capnslog.DEBUG))
+}
+
+func TestZapFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	zapLogger := zap.New(zapcore.NewCore(newLogEncoder(LogFormatJSON), zapcore.AddSync(&buf), zapcore.DebugLevel))
+	formatter := newZapFormatter(zapLogger)
+
+	formatter.Format("ceph-block-pool-controller", capnslog.WARNING, 1, "pool \"replicapool\" is degraded\n")
+	formatter.Format("op-mon", capnslog.DEBUG, 1, "checking ", 3, " mons")
+	formatter.Flush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, "WARN", line["level"])
+	assert.Equal(t, "ceph-block-pool-controller", line["logger"])
+	assert.Equal(t, "ceph-block-pool-controller", line["controller"])
+	assert.Equal(t, `pool "replicapool" is degraded`, line["msg"])
+
+	line = map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	assert.Equal(t, "DEBUG", line["level"])
+	assert.Equal(t, "op-mon", line["logger"])
+	assert.NotContains(t, line, "controller")
+	assert.Equal(t, "checking 3 mons", line["msg"])
+}
+
+func TestWithLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	zapLogger := zap.New(zapcore.NewCore(newLogEncoder(LogFormatJSON), zapcore.AddSync(&buf), zapcore.DebugLevel))
+	formatter := newZapFormatter(zapLogger)
+	readLine := func() map[string]interface{} {
+		formatter.Flush()
+		line := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		buf.Reset()
+		return line
+	}
+
+	done := WithLogFields("controller", "ceph-block-pool-controller", "namespace", "rook-ceph", "name", "replicapool")
+	formatter.Format("ceph-client", capnslog.INFO, 1, "creating pool")
+	line := readLine()
+	assert.Equal(t, "ceph-client", line["logger"])
+	assert.Equal(t, "ceph-block-pool-controller", line["controller"])
+	assert.Equal(t, "rook-ceph", line["namespace"])
+	assert.Equal(t, "replicapool", line["name"])
+
+	// the lines of the other goroutines do not get the fields
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		formatter.Format("ceph-client", capnslog.INFO, 1, "creating pool")
+	}()
+	wg.Wait()
+	assert.NotContains(t, readLine(), "name")
+
+	done()
+	formatter.Format("ceph-client", capnslog.INFO, 1, "creating pool")
+	assert.NotContains(t, readLine(), "name")
+	assert.Zero(t, goroutineLogFieldsCount)
+}
+
+func TestZapLevel(t *testing.T) {
+	assert.Equal(t, zapcore.ErrorLevel, zapLevel(capnslog.CRITICAL))
+	assert.Equal(t, zapcore.ErrorLevel, zapLevel(capnslog.ERROR))
+	assert.Equal(t, zapcore.WarnLevel, zapLevel(capnslog.WARNING))
+	assert.Equal(t, zapcore.InfoLevel, zapLevel(capnslog.NOTICE))
+	assert.Equal(t, zapcore.InfoLevel, zapLevel(capnslog.INFO))
+	assert.Equal(t, zapcore.DebugLevel, zapLevel(capnslog.DEBUG))
+	assert.Equal(t, zapcore.DebugLevel, zapLevel(capnslog.TRACE))
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "registry")
+
+const (
+	mediaTypeOCIIndex          = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest       = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList        = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest    = "application/vnd.docker.distribution.manifest.v2+json"
+	dockerContentDigestHeader  = "Docker-Content-Digest"
+	digestAlgorithmSHA256      = "sha256"
+	maxManifestSize            = 4 * 1024 * 1024
+	wwwAuthenticateHeader      = "WWW-Authenticate"
+	bearerAuthenticationScheme = "bearer"
+	basicAuthenticationScheme  = "basic"
+)
+
+// the manifests an image reference can resolve to, the indexes of the multi-arch images first
+var manifestMediaTypes = []string{mediaTypeOCIIndex, mediaTypeDockerList, mediaTypeOCIManifest, mediaTypeDockerManifest}
+
+// Credentials authenticate to a registry
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Client queries the registries with the registry API
+type Client struct {
+	httpClient  *http.Client
+	credentials map[string]Credentials
+	// the authorization headers of the repositories, keyed by registry and repository
+	authorizations map[string]string
+}
+
+// NewClient returns a client of the registries authenticating with the credentials keyed by registry
+func NewClient(httpClient *http.Client, credentials map[string]Credentials) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{httpClient: httpClient, credentials: credentials, authorizations: map[string]string{}}
+}
+
+// Resolve returns the digest of the manifest an image refers to, the manifest list of the multi-arch
+// images
+func (c *Client) Resolve(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	resp, err := c.do(ctx, http.MethodHead, ref, "/manifests/"+ref.Tag, manifestMediaTypes)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the manifest of image %q", image)
+	}
+	resp.Body.Close()
+	if digest := resp.Header.Get(dockerContentDigestHeader); digest != "" {
+		return digest, nil
+	}
+
+	// the digest header is optional, the digest is computed from the manifest instead
+	resp, err = c.do(ctx, http.MethodGet, ref, "/manifests/"+ref.Tag, manifestMediaTypes)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the manifest of image %q", image)
+	}
+	defer resp.Body.Close()
+	manifest, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read the manifest of image %q", image)
+	}
+	return digestOf(manifest), nil
+}
+
+// getManifest returns the manifest a reference refers to
+func (c *Client) getManifest(ctx context.Context, ref Reference, mediaTypes []string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref, "/manifests/"+ref.identifier(), mediaTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	manifest, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the manifest")
+	}
+	return manifest, nil
+}
+
+// getBlob returns a blob of a repository after checking its digest
+func (c *Client) getBlob(ctx context.Context, ref Reference, digest string, maxSize int64) ([]byte, error) {
+	if !strings.HasPrefix(digest, digestAlgorithmSHA256+":") {
+		return nil, errors.Errorf("unsupported digest %q", digest)
+	}
+	resp, err := c.do(ctx, http.MethodGet, ref, "/blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	blob, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read blob %q", digest)
+	}
+	if digestOf(blob) != digest {
+		return nil, errors.Errorf("digest of blob %q does not match", digest)
+	}
+	return blob, nil
+}
+
+// do sends a request to the registry API of a repository, authenticating when the registry requires it
+func (c *Client) do(ctx context.Context, method string, ref Reference, path string, accept []string) (*http.Response, error) {
+	authorization := c.authorizations[ref.Registry+"/"+ref.Repository]
+	resp, err := c.send(ctx, method, ref, path, accept, authorization)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get(wwwAuthenticateHeader)
+		resp.Body.Close()
+		authorization, err = c.authorize(ctx, ref, challenge)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.send(ctx, method, ref, path, accept, authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("registry %q returned %q", ref.Registry, resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *Client) send(ctx context.Context, method string, ref Reference, path string, accept []string, authorization string) (*http.Response, error) {
+	u := fmt.Sprintf("https://%s/v2/%s%s", ref.apiHost(), ref.Repository, path)
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request %q", u)
+	}
+	if len(accept) != 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query registry %q", ref.Registry)
+	}
+	return resp, nil
+}
+
+// authorize answers the authentication challenge of a registry, it returns the authorization header
+// of the next requests
+func (c *Client) authorize(ctx context.Context, ref Reference, challenge string) (string, error) {
+	creds, hasCreds := c.credentials[ref.Registry]
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case basicAuthenticationScheme:
+		if !hasCreds {
+			return "", errors.Errorf("registry %q requires credentials", ref.Registry)
+		}
+		authorization := "Basic " + base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Password))
+		c.authorizations[ref.Registry+"/"+ref.Repository] = authorization
+		return authorization, nil
+	case bearerAuthenticationScheme:
+	default:
+		return "", errors.Errorf("unsupported authentication %q of registry %q", challenge, ref.Registry)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", errors.Errorf("invalid authentication realm of registry %q", ref.Registry)
+	}
+	query := realm.Query()
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create the token request of registry %q", ref.Registry)
+	}
+	if hasCreds {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get a token of registry %q", ref.Registry)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("failed to get a token of registry %q. %s", ref.Registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&token); err != nil {
+		return "", errors.Wrapf(err, "failed to decode the token of registry %q", ref.Registry)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", errors.Errorf("empty token of registry %q", ref.Registry)
+	}
+	authorization := "Bearer " + token.Token
+	c.authorizations[ref.Registry+"/"+ref.Repository] = authorization
+	return authorization, nil
+}
+
+// parseChallenge parses the WWW-Authenticate header, it returns the lower-case scheme and the
+// parameters of the challenge
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	challenge = strings.TrimSpace(challenge)
+	i := strings.Index(challenge, " ")
+	if i < 0 {
+		return strings.ToLower(challenge), params
+	}
+	scheme := strings.ToLower(challenge[:i])
+	rest := challenge[i+1:]
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
+
+// ParseDockerConfig returns the credentials of a kubernetes.io/dockerconfigjson secret keyed by registry
+func ParseDockerConfig(data []byte) (map[string]Credentials, error) {
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the docker config")
+	}
+
+	credentials := map[string]Credentials{}
+	for server, auth := range config.Auths {
+		creds := Credentials{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decode the auth of registry %q", server)
+			}
+			userPass := strings.SplitN(string(decoded), ":", 2)
+			if len(userPass) != 2 {
+				return nil, errors.Errorf("invalid auth of registry %q", server)
+			}
+			creds = Credentials{Username: userPass[0], Password: userPass[1]}
+		}
+		credentials[registryOfServer(server)] = creds
+	}
+	return credentials, nil
+}
+
+// registryOfServer returns the registry of a server of the docker config, the servers can be urls
+func registryOfServer(server string) string {
+	registry := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	if i := strings.Index(registry, "/"); i >= 0 {
+		registry = registry[:i]
+	}
+	if registry == "index.docker.io" || registry == dockerHubAPIHost {
+		return DockerHub
+	}
+	return registry
+}
+
+func digestOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return digestAlgorithmSHA256 + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "test-token"
+
+// fakeRegistry serves the manifests and blobs of a repository to the clients authenticated with a token
+type fakeRegistry struct {
+	server    *httptest.Server
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	r := &fakeRegistry{manifests: map[string][]byte{}, blobs: map[string][]byte{}}
+	r.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			assert.Equal(t, "repository:ceph/ceph:pull", req.URL.Query().Get("scope"))
+			fmt.Fprintf(w, `{"token": %q}`, testToken)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer "+testToken {
+			w.Header().Set(wwwAuthenticateHeader, fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:ceph/ceph:pull"`, r.server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var content []byte
+		var ok bool
+		if strings.HasPrefix(req.URL.Path, "/v2/ceph/ceph/manifests/") {
+			content, ok = r.manifests[strings.TrimPrefix(req.URL.Path, "/v2/ceph/ceph/manifests/")]
+		} else if strings.HasPrefix(req.URL.Path, "/v2/ceph/ceph/blobs/") {
+			content, ok = r.blobs[strings.TrimPrefix(req.URL.Path, "/v2/ceph/ceph/blobs/")]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(dockerContentDigestHeader, digestOf(content))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(content)
+		}
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *fakeRegistry) image(tag string) string {
+	return strings.TrimPrefix(r.server.URL, "https://") + "/ceph/ceph:" + tag
+}
+
+// sign stores a cosign signature of a digest
+func (r *fakeRegistry) sign(t *testing.T, key *ecdsa.PrivateKey, digest string) {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"ceph/ceph"},"image":{"docker-manifest-digest":%q},"type":%q},"optional":null}`, digest, cosignSignatureType))
+	hash := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	require.NoError(t, err)
+	r.blobs[digestOf(payload)] = payload
+
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeOCIManifest,
+		"layers": []map[string]interface{}{{
+			"mediaType":   "application/vnd.dev.cosign.simplesigning.v1+json",
+			"digest":      digestOf(payload),
+			"size":        len(payload),
+			"annotations": map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+		}},
+	})
+	require.NoError(t, err)
+	r.manifests[strings.Replace(digest, ":", "-", 1)+cosignSignatureTagSuffix] = manifest
+}
+
+func TestResolve(t *testing.T) {
+	ctx := context.TODO()
+	r := newFakeRegistry(t)
+	manifest := []byte(`{"schemaVersion": 2}`)
+	r.manifests["v16.2.7"] = manifest
+	client := NewClient(r.server.Client(), nil)
+
+	digest, err := client.Resolve(ctx, r.image("v16.2.7"))
+	assert.NoError(t, err)
+	assert.Equal(t, digestOf(manifest), digest)
+
+	// an image referenced by its digest is already resolved
+	digest, err = client.Resolve(ctx, r.image("v16.2.7")+"@sha256:1234")
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:1234", digest)
+
+	_, err = client.Resolve(ctx, r.image("v17"))
+	assert.Error(t, err)
+}
+
+func TestVerifySignature(t *testing.T) {
+	ctx := context.TODO()
+	r := newFakeRegistry(t)
+	client := NewClient(r.server.Client(), nil)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signedDigest := digestOf([]byte("signed"))
+	r.sign(t, key, signedDigest)
+
+	t.Run("verified signature", func(t *testing.T) {
+		assert.NoError(t, client.VerifySignature(ctx, r.image("v16"), signedDigest, []crypto.PublicKey{&otherKey.PublicKey, &key.PublicKey}))
+	})
+	t.Run("signed by another key", func(t *testing.T) {
+		assert.Error(t, client.VerifySignature(ctx, r.image("v16"), signedDigest, []crypto.PublicKey{&otherKey.PublicKey}))
+	})
+	t.Run("no signature", func(t *testing.T) {
+		assert.Error(t, client.VerifySignature(ctx, r.image("v16"), digestOf([]byte("unsigned")), []crypto.PublicKey{&key.PublicKey}))
+	})
+	t.Run("signature of another digest", func(t *testing.T) {
+		otherDigest := digestOf([]byte("other"))
+		r.manifests[strings.Replace(otherDigest, ":", "-", 1)+cosignSignatureTagSuffix] = r.manifests[strings.Replace(signedDigest, ":", "-", 1)+cosignSignatureTagSuffix]
+		assert.Error(t, client.VerifySignature(ctx, r.image("v16"), otherDigest, []crypto.PublicKey{&key.PublicKey}))
+	})
+}
+
+func TestParsePublicKeys(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	keys, err := ParsePublicKeys(append(encoded, encoded...))
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	_, err = ParsePublicKeys([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:ceph/ceph:pull,push"`)
+	assert.Equal(t, bearerAuthenticationScheme, scheme)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:ceph/ceph:pull,push",
+	}, params)
+
+	scheme, _ = parseChallenge(`Basic realm="registry"`)
+	assert.Equal(t, basicAuthenticationScheme, scheme)
+}
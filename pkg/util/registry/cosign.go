@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// the annotation of the layers of the signature manifests holding the signature of the layer
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// the type of the payload of the image signatures
+	cosignSignatureType = "cosign container image signature"
+	// the suffix of the tag of the signature manifests, the tag is the digest of the signed image
+	cosignSignatureTagSuffix = ".sig"
+	maxPayloadSize           = 1024 * 1024
+)
+
+// the simple signing payload signed by cosign
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// VerifySignature verifies that an image digest has a cosign signature verified by one of the keys. The
+// signatures are read from the repository of the image, where cosign stores them by default.
+func (c *Client) VerifySignature(ctx context.Context, image, digest string, keys []crypto.PublicKey) error {
+	if len(keys) == 0 {
+		return errors.New("no key to verify the signatures with")
+	}
+	ref, err := ParseReference(image)
+	if err != nil {
+		return err
+	}
+	sigRef := ref
+	sigRef.Digest = ""
+	sigRef.Tag = strings.Replace(digest, ":", "-", 1) + cosignSignatureTagSuffix
+
+	content, err := c.getManifest(ctx, sigRef, []string{mediaTypeOCIManifest, mediaTypeDockerManifest})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the signatures of image %q", image)
+	}
+	var manifest struct {
+		Layers []struct {
+			Digest      string            `json:"digest"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return errors.Wrapf(err, "failed to decode the signatures of image %q", image)
+	}
+
+	for _, layer := range manifest.Layers {
+		encoded, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			logger.Debugf("skipping invalid signature of image %q. %v", image, err)
+			continue
+		}
+		payload, err := c.getBlob(ctx, sigRef, layer.Digest, maxPayloadSize)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the signed payload of image %q", image)
+		}
+		if !verifiedByKeys(keys, payload, signature) {
+			continue
+		}
+		if err := checkPayload(payload, digest); err != nil {
+			logger.Debugf("skipping signature of image %q. %v", image, err)
+			continue
+		}
+		return nil
+	}
+	return errors.Errorf("no signature of image %q with digest %q verified by the keys", image, digest)
+}
+
+// checkPayload checks that a signed payload is the signature of an image digest
+func checkPayload(payload []byte, digest string) error {
+	var p cosignPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return errors.Wrap(err, "failed to decode the signed payload")
+	}
+	if p.Critical.Type != cosignSignatureType {
+		return errors.Errorf("unexpected payload type %q", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest != digest {
+		return errors.Errorf("payload signs digest %q", p.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
+
+// verifiedByKeys returns whether a signature of a payload is verified by one of the keys
+func verifiedByKeys(keys []crypto.PublicKey, payload, signature []byte) bool {
+	hash := sha256.Sum256(payload)
+	for _, key := range keys {
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(k, hash[:], signature) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], signature) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(k, payload, signature) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParsePublicKeys returns the PEM encoded public keys, as generated by "cosign generate-key-pair"
+func ParsePublicKeys(data []byte) ([]crypto.PublicKey, error) {
+	keys := []crypto.PublicKey{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse public key")
+		}
+		switch key.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		default:
+			return nil, errors.Errorf("unsupported public key of type %T", key)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM encoded public key found")
+	}
+	return keys, nil
+}
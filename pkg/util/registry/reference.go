@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry resolves the images to their digests and verifies their cosign signatures with the
+// registry API
+package registry
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DockerHub is the registry of the images without a registry
+	DockerHub = "docker.io"
+	// the host serving the registry API of docker hub
+	dockerHubAPIHost = "registry-1.docker.io"
+	defaultTag       = "latest"
+)
+
+// Reference is a parsed image reference
+type Reference struct {
+	// Registry is the host of the registry, with its port
+	Registry string
+	// Repository is the path of the image in the registry
+	Repository string
+	// Tag is the tag of the image, empty if the image is referenced by its digest only
+	Tag string
+	// Digest is the digest of the image, empty if the image is referenced by its tag
+	Digest string
+}
+
+// ParseReference parses an image reference as the container runtimes do, the images without a registry
+// are in docker hub and the images without a tag or digest are tagged "latest"
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, errors.Errorf("invalid digest of image %q", image)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if name == "" || ref.Tag == "" && strings.HasSuffix(image, ":") {
+		return Reference{}, errors.Errorf("invalid image %q", image)
+	}
+
+	// the first component is the registry if it looks like a host
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		ref.Registry = name[:i]
+		ref.Repository = name[i+1:]
+	} else {
+		ref.Registry = DockerHub
+		ref.Repository = name
+		if !strings.Contains(name, "/") {
+			ref.Repository = "library/" + name
+		}
+	}
+	if ref.Repository == "" || strings.ToLower(ref.Repository) != ref.Repository {
+		return Reference{}, errors.Errorf("invalid repository of image %q", image)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref, nil
+}
+
+// apiHost returns the host serving the registry API
+func (r Reference) apiHost() string {
+	if r.Registry == DockerHub {
+		return dockerHubAPIHost
+	}
+	return r.Registry
+}
+
+// identifier returns the digest of the image, or its tag if not referenced by its digest
+func (r Reference) identifier() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		image    string
+		expected Reference
+	}{
+		{"ceph", Reference{Registry: DockerHub, Repository: "library/ceph", Tag: "latest"}},
+		{"ceph/ceph:v16.2.7", Reference{Registry: DockerHub, Repository: "ceph/ceph", Tag: "v16.2.7"}},
+		{"quay.io/ceph/ceph:v16.2.7", Reference{Registry: "quay.io", Repository: "ceph/ceph", Tag: "v16.2.7"}},
+		{"localhost:5000/ceph:v16", Reference{Registry: "localhost:5000", Repository: "ceph", Tag: "v16"}},
+		{"localhost/ceph", Reference{Registry: "localhost", Repository: "ceph", Tag: "latest"}},
+		{"quay.io/ceph/ceph@" + digest, Reference{Registry: "quay.io", Repository: "ceph/ceph", Digest: digest}},
+		{"quay.io/ceph/ceph:v16.2.7@" + digest, Reference{Registry: "quay.io", Repository: "ceph/ceph", Tag: "v16.2.7", Digest: digest}},
+	}
+	for _, test := range tests {
+		ref, err := ParseReference(test.image)
+		assert.NoError(t, err, test.image)
+		assert.Equal(t, test.expected, ref, test.image)
+	}
+
+	for _, image := range []string{"", "quay.io/ceph/ceph:", "quay.io/ceph/ceph@sha256", "quay.io/Ceph/ceph:v16"} {
+		_, err := ParseReference(image)
+		assert.Error(t, err, image)
+	}
+}
+
+func TestParseDockerConfig(t *testing.T) {
+	config := `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"},
+		"quay.io": {"username": "robot", "password": "secret"}}}`
+	credentials, err := ParseDockerConfig([]byte(config))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Credentials{
+		DockerHub: {Username: "user", Password: "pass"},
+		"quay.io": {Username: "robot", Password: "secret"},
+	}, credentials)
+
+	_, err = ParseDockerConfig([]byte(`{"auths": {"quay.io": {"auth": "invalid"}}}`))
+	assert.Error(t, err)
+}
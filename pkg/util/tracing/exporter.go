@@ -0,0 +1,249 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// the path of the traces of the OTLP/HTTP protocol
+	otlpTracesPath = "/v1/traces"
+	// the spans are exported when the batch is full or at the interval
+	exportBatchSize = 512
+	exportInterval  = 5 * time.Second
+	// the spans are dropped when the queue is full, such as when the collector is not reachable
+	exportQueueSize = 4096
+	exportTimeout   = 10 * time.Second
+	scopeName       = "github.com/rook/rook"
+)
+
+// Exporter exports the spans in batches to an OpenTelemetry collector with the OTLP/HTTP protocol in
+// its JSON encoding
+type Exporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+	spans       chan *Span
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// NewExporter starts an exporter of the spans to the OTLP/HTTP endpoint of a collector, such as
+// http://otel-collector.monitoring:4318. The traces path is added to the endpoint if missing.
+func NewExporter(endpoint, serviceName string) *Exporter {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, otlpTracesPath) {
+		url += otlpTracesPath
+	}
+	e := &Exporter{
+		url:         url,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: exportTimeout},
+		spans:       make(chan *Span, exportQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *Exporter) enqueue(span *Span) {
+	select {
+	case e.spans <- span:
+	default:
+		logger.Debugf("dropping span %q, the export queue is full", span.name)
+	}
+}
+
+// Shutdown exports the queued spans and stops the exporter
+func (e *Exporter) Shutdown() {
+	close(e.stop)
+	<-e.done
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := []*Span{}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			logger.Warningf("failed to export %d spans. %v", len(batch), err)
+		}
+		batch = []*Span{}
+	}
+	for {
+		select {
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.stop:
+			for {
+				select {
+				case span := <-e.spans:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// export posts the spans to the collector
+func (e *Exporter) export(spans []*Span) error {
+	body, err := json.Marshal(e.toOTLP(spans))
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the spans")
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "failed to post the spans to %q", e.url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("failed to post the spans to %q. %s: %s", e.url, resp.Status, string(message))
+	}
+	return nil
+}
+
+// the OTLP/HTTP JSON encoding of the spans
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is a value of an attribute, the int values are encoded as strings
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func (e *Exporter) toOTLP(spans []*Span) otlpTraces {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		span.mutex.Lock()
+		s := otlpSpan{
+			TraceID:           hex.EncodeToString(span.traceID[:]),
+			SpanID:            hex.EncodeToString(span.spanID[:]),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: span.statusCode, Message: span.statusMsg},
+		}
+		if span.parentID != [8]byte{} {
+			s.ParentSpanID = hex.EncodeToString(span.parentID[:])
+		}
+		for _, a := range span.attributes {
+			s.Attributes = append(s.Attributes, toOTLPAttribute(a.key, a.value))
+		}
+		span.mutex.Unlock()
+		otlpSpans = append(otlpSpans, s)
+	}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{
+		{
+			Resource:   otlpResource{Attributes: []otlpAttribute{toOTLPAttribute("service.name", e.serviceName)}},
+			ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: otlpSpans}},
+		},
+	}}
+}
+
+func toOTLPAttribute(key string, value interface{}) otlpAttribute {
+	v := otlpValue{}
+	switch value := value.(type) {
+	case string:
+		v.StringValue = &value
+	case bool:
+		v.BoolValue = &value
+	case int:
+		s := strconv.Itoa(value)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &value
+	default:
+		s := fmt.Sprintf("%v", value)
+		v.StringValue = &s
+	}
+	return otlpAttribute{Key: key, Value: v}
+}
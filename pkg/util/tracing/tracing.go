@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing records the spans of the reconciles of the operator and of the commands they
+// execute, exported to an OpenTelemetry collector with the OTLP/HTTP protocol in its JSON encoding.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "tracing")
+
+// SpanKind is the kind of a span as defined by OpenTelemetry
+type SpanKind int
+
+const (
+	// SpanKindInternal is an operation of the operator, such as a reconcile
+	SpanKindInternal SpanKind = 1
+	// SpanKindClient is a request of the operator to a remote service, such as a ceph command
+	SpanKindClient SpanKind = 3
+)
+
+const (
+	statusCodeOK    = 1
+	statusCodeError = 2
+)
+
+type spanKey struct{}
+
+var (
+	exporterMutex sync.RWMutex
+	exporter      *Exporter
+
+	// the spans of the resources being reconciled, keyed by resource
+	resourceSpansMutex sync.Mutex
+	resourceSpans      = map[string]*Span{}
+)
+
+// Span is an operation of a trace. The methods of a nil span do nothing, so that the spans can be
+// recorded whether tracing is enabled or not.
+type Span struct {
+	mutex       sync.Mutex
+	traceID     [16]byte
+	spanID      [8]byte
+	parentID    [8]byte
+	name        string
+	kind        SpanKind
+	start       time.Time
+	end         time.Time
+	attributes  []attribute
+	statusCode  int
+	statusMsg   string
+	resourceKey string
+	ended       bool
+}
+
+type attribute struct {
+	key   string
+	value interface{}
+}
+
+// SetExporter sets the exporter of the spans, shutting down the previous one. Tracing is disabled
+// if nil.
+func SetExporter(e *Exporter) {
+	exporterMutex.Lock()
+	previous := exporter
+	exporter = e
+	exporterMutex.Unlock()
+	if previous != nil && previous != e {
+		previous.Shutdown()
+	}
+}
+
+// Enabled returns whether the spans are exported
+func Enabled() bool {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	return exporter != nil
+}
+
+// Start starts a span, child of the span of the context if any, and returns a context with the span.
+// No span is started if tracing is disabled.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+	span := StartAt(FromContext(ctx), name, kind, time.Now())
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// StartResource starts the span of the reconcile of a resource like Start. Until it ends, the span is
+// the parent of the spans of the resource started with the contexts that do not have the span, such
+// as the contexts of the commands of the resource.
+func StartResource(ctx context.Context, kind, namespace, name, spanName string) (context.Context, *Span) {
+	ctx, span := Start(ctx, spanName, SpanKindInternal)
+	if span == nil {
+		return ctx, nil
+	}
+	span.resourceKey = resourceKey(kind, namespace, name)
+	resourceSpansMutex.Lock()
+	resourceSpans[span.resourceKey] = span
+	resourceSpansMutex.Unlock()
+	return ctx, span
+}
+
+// ResourceSpan returns the span of the reconcile of a resource in progress, nil if none
+func ResourceSpan(kind, namespace, name string) *Span {
+	resourceSpansMutex.Lock()
+	defer resourceSpansMutex.Unlock()
+	return resourceSpans[resourceKey(kind, namespace, name)]
+}
+
+func resourceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// StartAt starts a child span of the parent at the given time, or a span of a new trace without parent.
+// No span is started if tracing is disabled.
+func StartAt(parent *Span, name string, kind SpanKind, start time.Time) *Span {
+	if !Enabled() {
+		return nil
+	}
+	span := &Span{name: name, kind: kind, start: start}
+	if parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		randomID(span.traceID[:])
+	}
+	randomID(span.spanID[:])
+	return span
+}
+
+// FromContext returns the span of the context, nil if none
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func randomID(id []byte) {
+	if _, err := rand.Read(id); err != nil {
+		logger.Warningf("failed to generate a span id. %v", err)
+	}
+}
+
+// SetAttribute sets an attribute of the span, a string, a bool, an int, an int64 or a float64
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.attributes {
+		if s.attributes[i].key == key {
+			s.attributes[i].value = value
+			return
+		}
+	}
+	s.attributes = append(s.attributes, attribute{key: key, value: value})
+}
+
+// SetError sets the status of the span to the error if not nil, or to ok
+func (s *Span) SetError(err error) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err != nil {
+		s.statusCode = statusCodeError
+		s.statusMsg = err.Error()
+	} else {
+		s.statusCode = statusCodeOK
+		s.statusMsg = ""
+	}
+}
+
+// TraceID returns the hex id of the trace of the span, empty for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// End ends the span and queues it to be exported
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mutex.Unlock()
+
+	if s.resourceKey != "" {
+		resourceSpansMutex.Lock()
+		if resourceSpans[s.resourceKey] == s {
+			delete(resourceSpans, s.resourceKey)
+		}
+		resourceSpansMutex.Unlock()
+	}
+
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	if exporter != nil {
+		exporter.enqueue(s)
+	}
+}
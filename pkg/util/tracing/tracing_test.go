@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCollector receives the spans posted to the OTLP/HTTP traces endpoint
+type fakeCollector struct {
+	mutex sync.Mutex
+	spans []otlpSpan
+	names []string
+}
+
+func (c *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != otlpTracesPath || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	traces := otlpTraces{}
+	if err := json.NewDecoder(r.Body).Decode(&traces); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, resourceSpans := range traces.ResourceSpans {
+		c.names = append(c.names, *resourceSpans.Resource.Attributes[0].Value.StringValue)
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			c.spans = append(c.spans, scopeSpans.Spans...)
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.False(t, Enabled())
+		spanCtx, span := StartResource(ctx, "CephBlockPool", "rook-ceph", "replicapool", "reconcile CephBlockPool")
+		assert.Nil(t, span)
+		assert.Equal(t, ctx, spanCtx)
+		assert.Nil(t, ResourceSpan("CephBlockPool", "rook-ceph", "replicapool"))
+		// the methods of a nil span do nothing
+		span.SetAttribute("key", "value")
+		span.SetError(errors.New("failed"))
+		span.End()
+	})
+
+	collector := &fakeCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+	SetExporter(NewExporter(server.URL, "rook-ceph-operator"))
+
+	t.Run("reconcile with commands", func(t *testing.T) {
+		spanCtx, reconcileSpan := StartResource(ctx, "CephBlockPool", "rook-ceph", "replicapool", "reconcile CephBlockPool")
+		require.NotNil(t, reconcileSpan)
+		assert.Equal(t, reconcileSpan, FromContext(spanCtx))
+		assert.Equal(t, reconcileSpan, ResourceSpan("CephBlockPool", "rook-ceph", "replicapool"))
+		reconcileSpan.SetAttribute("rook.controller", "ceph-block-pool-controller")
+
+		command := StartAt(ResourceSpan("CephBlockPool", "rook-ceph", "replicapool"), "ceph osd pool", SpanKindClient, time.Now().Add(-time.Second))
+		command.SetAttribute("rook.command.exit_code", 2)
+		command.SetError(errors.New("exit status 2"))
+		command.End()
+
+		reconcileSpan.SetError(nil)
+		reconcileSpan.End()
+		assert.Nil(t, ResourceSpan("CephBlockPool", "rook-ceph", "replicapool"))
+	})
+
+	// the queued spans are exported when the exporter shuts down
+	SetExporter(nil)
+	assert.False(t, Enabled())
+
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	require.Equal(t, 2, len(collector.spans))
+	assert.Equal(t, []string{"rook-ceph-operator"}, collector.names)
+	command, reconcile := collector.spans[0], collector.spans[1]
+	assert.Equal(t, "reconcile CephBlockPool", reconcile.Name)
+	assert.Equal(t, SpanKindInternal, reconcile.Kind)
+	assert.Empty(t, reconcile.ParentSpanID)
+	assert.Equal(t, statusCodeOK, reconcile.Status.Code)
+	assert.Equal(t, "rook.controller", reconcile.Attributes[0].Key)
+
+	assert.Equal(t, "ceph osd pool", command.Name)
+	assert.Equal(t, SpanKindClient, command.Kind)
+	assert.Equal(t, reconcile.TraceID, command.TraceID)
+	assert.Equal(t, reconcile.SpanID, command.ParentSpanID)
+	assert.Equal(t, statusCodeError, command.Status.Code)
+	assert.Equal(t, "exit status 2", command.Status.Message)
+	assert.Equal(t, "2", *command.Attributes[0].Value.IntValue)
+	assert.Len(t, reconcile.TraceID, 32)
+	assert.Len(t, reconcile.SpanID, 16)
+}
+
+func TestNewExporter(t *testing.T) {
+	e := NewExporter("http://otel-collector.monitoring:4318/", "rook-ceph-operator")
+	defer e.Shutdown()
+	assert.Equal(t, "http://otel-collector.monitoring:4318/v1/traces", e.url)
+	e2 := NewExporter("http://otel-collector.monitoring:4318/v1/traces", "rook-ceph-operator")
+	defer e2.Shutdown()
+	assert.Equal(t, "http://otel-collector.monitoring:4318/v1/traces", e2.url)
+}
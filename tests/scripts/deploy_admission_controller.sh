@@ -116,4 +116,21 @@ echo "Deploying webhook config"
         sed -e "s|\${WEBHOOK_CONFIG_NAME}|${WEBHOOK_CONFIG_NAME}|g" | \
         sed -e "s|\${SERVICE_NAME}|${SERVICE_NAME}|g" | \
         kubectl create -f -
+
+echo "Enabling the conversion webhook"
+# serve the v2alpha1 version of the CRDs that have one, the requests are converted to and from the
+# v1 storage version by the /convert endpoint of the admission controller
+for crd in cephclusters.ceph.rook.io cephobjectstores.ceph.rook.io; do
+  kubectl annotate --overwrite crd "${crd}" cert-manager.io/inject-ca-from="${NAMESPACE}"/rook-admission-controller-cert
+  kubectl patch crd "${crd}" --type=json -p "[
+    {\"op\": \"add\", \"path\": \"/spec/conversion\", \"value\": {
+      \"strategy\": \"Webhook\",
+      \"webhook\": {
+        \"conversionReviewVersions\": [\"v1\", \"v1beta1\"],
+        \"clientConfig\": {\"service\": {\"name\": \"${SERVICE_NAME}\", \"namespace\": \"${NAMESPACE}\", \"path\": \"/convert\"}}
+      }
+    }},
+    {\"op\": \"replace\", \"path\": \"/spec/versions/1/served\", \"value\": true}
+  ]"
+done
 echo "Webhook deployed! Please start the rook operator to create the service and admission controller pods"
@@ -217,6 +217,7 @@ function deploy_cluster() {
   kubectl create -f filesystem-mirror.yaml
   kubectl create -f nfs-test.yaml
   kubectl create -f subvolumegroup.yaml
+  kubectl create -f subvolume.yaml
   deploy_manifest_with_local_build toolbox.yaml
 }
 